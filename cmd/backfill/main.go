@@ -1,15 +1,17 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"strings"
-	"time"
 
+	"github.com/trading-chitti/market-bridge/internal/backfill"
 	"github.com/trading-chitti/market-bridge/internal/broker"
 	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/timeframe"
 	"github.com/trading-chitti/market-bridge/internal/watchlist"
 )
 
@@ -19,9 +21,13 @@ var (
 	watchlistFlag  = flag.String("watchlist", "", "Watchlist name (e.g., NIFTY50, BANKNIFTY)")
 	fromDateFlag   = flag.String("from", "", "Start date (YYYY-MM-DD)")
 	toDateFlag     = flag.String("to", "", "End date (YYYY-MM-DD)")
-	timeframeFlag  = flag.String("timeframe", "day", "Timeframe (minute, 5minute, 15minute, day)")
+	timeframeFlag  = flag.String("timeframe", "day", "Timeframe (1m, 5m, 15m, 1h, 1d, or minute, 5minute, 15minute, 60minute, day)")
 	dryRunFlag     = flag.Bool("dry-run", false, "Dry run mode (don't insert data)")
 	concurrentFlag = flag.Int("concurrent", 5, "Number of concurrent requests")
+	rateFlag       = flag.Float64("rate", 3, "Max historical API requests per second")
+	retriesFlag    = flag.Int("retries", 3, "Retries per chunk on broker errors")
+	modeFlag       = flag.String("mode", "full", "Backfill mode (full, fill-gaps)")
+	syncFlag       = flag.Bool("sync-instruments", false, "Sync NSE and BSE instruments from the broker before the backfill")
 )
 
 func main() {
@@ -40,29 +46,41 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Parse dates
-	fromDate, err := time.Parse("2006-01-02", *fromDateFlag)
+	barTimeframe, err := timeframe.ParseStored(*timeframeFlag)
 	if err != nil {
-		log.Fatalf("Invalid from date: %v", err)
+		log.Fatalf("Unsupported timeframe: %v", err)
 	}
 
-	var toDate time.Time
-	if *toDateFlag == "" {
-		toDate = time.Now()
-	} else {
-		toDate, err = time.Parse("2006-01-02", *toDateFlag)
-		if err != nil {
-			log.Fatalf("Invalid to date: %v", err)
-		}
+	if *rateFlag <= 0 {
+		log.Fatal("-rate must be greater than 0")
+	}
+
+	if *modeFlag != backfill.ModeFull && *modeFlag != backfill.ModeFillGaps {
+		log.Fatalf("Unsupported mode: %s", *modeFlag)
+	}
+
+	// Parse dates in IST so day boundaries match exchange bar timestamps
+	fromDate, toDate, err := backfill.ParseDateRange(*fromDateFlag, *toDateFlag)
+	if err != nil {
+		log.Fatalf("Invalid date range: %v", err)
 	}
 
-	// Initialize database
+	// Trading holidays beyond the built-in calendar
+	if err := broker.AddIndianMarketHolidays(strings.Split(os.Getenv("MARKET_HOLIDAYS"), ",")...); err != nil {
+		log.Fatalf("Invalid MARKET_HOLIDAYS: %v", err)
+	}
+
+	// Initialize storage: PostgreSQL, or a local SQLite file without a DSN
+	dsn := os.Getenv("TRADING_CHITTI_PG_DSN")
 	dbPath := os.Getenv("DB_PATH")
 	if dbPath == "" {
-		dbPath = "./market_bridge.db"
+		dbPath = database.DefaultSQLitePath
+	}
+	if dsn == "" {
+		log.Printf("💾 TRADING_CHITTI_PG_DSN not set, using SQLite at %s", dbPath)
 	}
 
-	db, err := database.NewDatabase(dbPath)
+	db, err := database.OpenStore(dsn, dbPath)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
@@ -74,7 +92,28 @@ func main() {
 		log.Fatal("ZERODHA_API_KEY environment variable not set")
 	}
 
-	zerodha := broker.NewZerodha(apiKey, "", db)
+	accessToken := os.Getenv("ZERODHA_ACCESS_TOKEN")
+	if accessToken == "" {
+		log.Fatal("ZERODHA_ACCESS_TOKEN environment variable not set")
+	}
+
+	zerodha, err := broker.NewBroker(&broker.BrokerConfig{
+		BrokerName:  "zerodha",
+		APIKey:      apiKey,
+		AccessToken: accessToken,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize broker: %v", err)
+	}
+
+	// A new SQLite store has no instruments to resolve symbols with
+	if *syncFlag {
+		for _, exchange := range []string{"NSE", "BSE"} {
+			if err := database.SyncInstruments(db, zerodha, exchange); err != nil {
+				log.Fatalf("Failed to sync %s instruments: %v", exchange, err)
+			}
+		}
+	}
 
 	// Determine symbols to backfill
 	var symbols []string
@@ -95,22 +134,29 @@ func main() {
 	log.Printf("   Symbols: %d", len(symbols))
 	log.Printf("   From: %s", fromDate.Format("2006-01-02"))
 	log.Printf("   To: %s", toDate.Format("2006-01-02"))
-	log.Printf("   Timeframe: %s", *timeframeFlag)
+	log.Printf("   Timeframe: %s (%s)", *timeframeFlag, barTimeframe)
+	log.Printf("   Mode: %s", *modeFlag)
 	log.Printf("   Concurrent: %d", *concurrentFlag)
+	log.Printf("   Rate Limit: %.1f req/s", *rateFlag)
+	log.Printf("   Retries: %d", *retriesFlag)
 	log.Printf("   Dry Run: %v", *dryRunFlag)
 	log.Println()
 
 	// Create backfill worker
-	backfiller := &Backfiller{
-		broker:      zerodha,
-		db:          db,
-		timeframe:   *timeframeFlag,
-		dryRun:      *dryRunFlag,
-		concurrent:  *concurrentFlag,
+	backfiller, err := backfill.New(zerodha, db, backfill.Options{
+		Timeframe:  *timeframeFlag,
+		Mode:       *modeFlag,
+		DryRun:     *dryRunFlag,
+		Concurrent: *concurrentFlag,
+		Rate:       *rateFlag,
+		MaxRetries: *retriesFlag,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create backfiller: %v", err)
 	}
 
 	// Run backfill
-	stats := backfiller.Backfill(symbols, fromDate, toDate)
+	stats := backfiller.Run(context.Background(), symbols, fromDate, toDate)
 
 	// Print summary
 	log.Println()
@@ -119,121 +165,26 @@ func main() {
 	log.Printf("   Successful: %d", stats.Successful)
 	log.Printf("   Failed: %d", stats.Failed)
 	log.Printf("   Total Bars: %d", stats.TotalBars)
-	log.Printf("   Duration: %v", stats.Duration)
-}
-
-// Backfiller handles data backfilling
-type Backfiller struct {
-	broker     broker.Broker
-	db         *database.Database
-	timeframe  string
-	dryRun     bool
-	concurrent int
-}
-
-// BackfillStats contains backfill statistics
-type BackfillStats struct {
-	TotalSymbols int
-	Successful   int
-	Failed       int
-	TotalBars    int
-	Duration     time.Duration
-}
-
-// Backfill fills historical data for symbols
-func (b *Backfiller) Backfill(symbols []string, fromDate, toDate time.Time) *BackfillStats {
-	startTime := time.Now()
-	stats := &BackfillStats{
-		TotalSymbols: len(symbols),
-	}
-
-	// Create semaphore for concurrency control
-	semaphore := make(chan struct{}, b.concurrent)
-	results := make(chan BackfillResult, len(symbols))
-
-	// Process each symbol
-	for _, symbol := range symbols {
-		semaphore <- struct{}{} // Acquire semaphore
-
-		go func(sym string) {
-			defer func() { <-semaphore }() // Release semaphore
-
-			result := b.backfillSymbol(sym, fromDate, toDate)
-			results <- result
-		}(symbol)
+	if *modeFlag == backfill.ModeFillGaps {
+		log.Printf("   Gaps Found: %d", stats.GapsFound)
 	}
+	log.Printf("   Requests: %d", stats.Requests)
+	log.Printf("   Retries: %d", stats.Retries)
+	log.Printf("   Duration: %v", stats.Duration)
 
-	// Collect results
-	for i := 0; i < len(symbols); i++ {
-		result := <-results
-
+	log.Println()
+	log.Println("📋 Per-Symbol Results")
+	for _, result := range stats.Results {
 		if result.Error != nil {
-			stats.Failed++
-			log.Printf("❌ %s: %v", result.Symbol, result.Error)
+			log.Printf("   %-15s FAILED  bars=%d gaps=%d chunks=%d retries=%d (%v)",
+				result.Symbol, result.BarsInserted, result.GapsFound, result.Chunks, result.Retries, result.Error)
 		} else {
-			stats.Successful++
-			stats.TotalBars += result.BarsInserted
-			log.Printf("✅ %s: %d bars inserted", result.Symbol, result.BarsInserted)
-		}
-	}
-
-	stats.Duration = time.Since(startTime)
-	return stats
-}
-
-// BackfillResult contains result for a single symbol
-type BackfillResult struct {
-	Symbol       string
-	BarsInserted int
-	Error        error
-}
-
-// backfillSymbol backfills data for a single symbol
-func (b *Backfiller) backfillSymbol(symbol string, fromDate, toDate time.Time) BackfillResult {
-	// Get instrument token
-	token, err := b.db.GetInstrumentToken("NSE", symbol)
-	if err != nil || token == 0 {
-		token, err = b.db.GetInstrumentToken("BSE", symbol)
-		if err != nil || token == 0 {
-			return BackfillResult{
-				Symbol: symbol,
-				Error:  fmt.Errorf("instrument token not found"),
-			}
-		}
-	}
-
-	// Fetch historical data
-	// Note: The broker interface needs to support historical data fetching
-	// This is a placeholder implementation
-	log.Printf("🔄 Fetching data for %s (token: %d)...", symbol, token)
-
-	// In a real implementation, you would:
-	// 1. Fetch historical OHLCV data from broker
-	// 2. Convert to IntradayBar format
-	// 3. Insert into database
-
-	// For now, return success with 0 bars (placeholder)
-	if b.dryRun {
-		log.Printf("   [DRY RUN] Would fetch %s from %s to %s",
-			symbol, fromDate.Format("2006-01-02"), toDate.Format("2006-01-02"))
-		return BackfillResult{
-			Symbol:       symbol,
-			BarsInserted: 0,
+			log.Printf("   %-15s OK      bars=%d gaps=%d chunks=%d retries=%d",
+				result.Symbol, result.BarsInserted, result.GapsFound, result.Chunks, result.Retries)
 		}
 	}
 
-	// TODO: Implement actual data fetching and insertion
-	// bars := b.broker.GetHistoricalData(token, fromDate, toDate, b.timeframe)
-	// if !b.dryRun {
-	//     err := b.db.BulkInsertIntradayBars(bars)
-	//     if err != nil {
-	//         return BackfillResult{Symbol: symbol, Error: err}
-	//     }
-	// }
-
-	return BackfillResult{
-		Symbol:       symbol,
-		BarsInserted: 0,
-		Error:        fmt.Errorf("historical data fetching not yet implemented"),
+	if stats.Failed > 0 {
+		os.Exit(1)
 	}
 }
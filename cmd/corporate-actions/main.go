@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+var (
+	// Flags
+	fileFlag     = flag.String("file", "", "CSV file of corporate actions (symbol,ex_date,type,ratio,amount[,exchange,description])")
+	exchangeFlag = flag.String("exchange", "NSE", "Exchange for rows without an exchange column")
+	dryRunFlag   = flag.Bool("dry-run", false, "Validate the file without saving it")
+)
+
+func main() {
+	flag.Parse()
+
+	if *fileFlag == "" {
+		fmt.Println("Error: -file must be specified")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	actions, err := loadActions(*fileFlag, *exchangeFlag)
+	if err != nil {
+		log.Fatalf("Failed to load %s: %v", *fileFlag, err)
+	}
+	log.Printf("📄 Loaded %d corporate actions from %s", len(actions), *fileFlag)
+
+	if *dryRunFlag {
+		for _, action := range actions {
+			log.Printf("   %s:%s %s on %s, price factor %.4f",
+				action.Exchange, action.Symbol, action.Type, action.ExDate.Format("2006-01-02"), action.PriceFactor())
+		}
+		log.Println("🔍 Dry run, nothing saved")
+		return
+	}
+
+	dsn := os.Getenv("TRADING_CHITTI_PG_DSN")
+	if dsn == "" {
+		log.Fatal("TRADING_CHITTI_PG_DSN environment variable not set")
+	}
+
+	db, err := database.NewDatabase(dsn)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.SaveCorporateActions(actions); err != nil {
+		log.Fatalf("Failed to save corporate actions: %v", err)
+	}
+	log.Printf("✅ Saved %d corporate actions", len(actions))
+}
+
+// loadActions reads a CSV with a symbol,ex_date,type,ratio,amount header,
+// optionally followed by exchange and description columns
+func loadActions(path, defaultExchange string) ([]database.CorporateAction, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"symbol", "ex_date", "type"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing %s column", required)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var actions []database.CorporateAction
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		exchange := field(record, "exchange")
+		if exchange == "" {
+			exchange = defaultExchange
+		}
+		var amount float64
+		if value := field(record, "amount"); value != "" {
+			amount, err = strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid amount %q", line, value)
+			}
+		}
+
+		action, err := database.ParseCorporateAction(exchange, field(record, "symbol"), field(record, "ex_date"),
+			field(record, "type"), field(record, "ratio"), amount)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		action.Description = field(record, "description")
+		actions = append(actions, action)
+	}
+
+	return actions, nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/importer"
+)
+
+var (
+	// Flags
+	fileFlag      = flag.String("file", "", "Comma-separated list of CSV or Parquet files to import")
+	timeframeFlag = flag.String("timeframe", "1m", "Bar timeframe (1m, 5m, 15m, 1h, 1d)")
+	exchangeFlag  = flag.String("exchange", "NSE", "Exchange for rows without an exchange column")
+	symbolFlag    = flag.String("symbol", "", "Symbol for files without a symbol column")
+	symbolMapFlag = flag.String("symbol-map", "", "CSV file mapping vendor symbols to tradingsymbols (vendor,symbol)")
+	tzFlag        = flag.String("tz", "Asia/Kolkata", "Timezone for timestamps without an offset")
+	sourceFlag    = flag.String("source", "import", "Source tag stored with the bars")
+)
+
+func main() {
+	flag.Parse()
+
+	if *fileFlag == "" {
+		fmt.Println("Error: -file must be specified")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var err error
+	location := broker.IST()
+	if *tzFlag != "Asia/Kolkata" {
+		location, err = time.LoadLocation(*tzFlag)
+		if err != nil {
+			log.Fatalf("Invalid timezone: %v", err)
+		}
+	}
+
+	var symbolMap map[string]string
+	if *symbolMapFlag != "" {
+		symbolMap, err = loadSymbolMap(*symbolMapFlag)
+		if err != nil {
+			log.Fatalf("Failed to load symbol map: %v", err)
+		}
+	}
+
+	// Initialize storage: PostgreSQL, or a local SQLite file without a DSN
+	dsn := os.Getenv("TRADING_CHITTI_PG_DSN")
+	dbPath := os.Getenv("DB_PATH")
+	if dbPath == "" {
+		dbPath = database.DefaultSQLitePath
+	}
+	if dsn == "" {
+		log.Printf("💾 TRADING_CHITTI_PG_DSN not set, using SQLite at %s", dbPath)
+	}
+
+	db, err := database.OpenStore(dsn, dbPath)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	imp, err := importer.New(db, importer.Options{
+		Timeframe: *timeframeFlag,
+		Exchange:  *exchangeFlag,
+		Symbol:    *symbolFlag,
+		SymbolMap: symbolMap,
+		Location:  location,
+		Source:    *sourceFlag,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create importer: %v", err)
+	}
+
+	failed := false
+	for _, path := range strings.Split(*fileFlag, ",") {
+		path = strings.TrimSpace(path)
+		log.Printf("📥 Importing %s...", path)
+
+		result, err := importFile(imp, path)
+		if err != nil {
+			log.Printf("❌ %s: %v", path, err)
+			failed = true
+			continue
+		}
+
+		log.Printf("   Rows: %d, Inserted: %d, Skipped: %d, Symbols: %d",
+			result.Rows, result.Inserted, result.Skipped, len(result.Symbols))
+		for _, msg := range result.Errors {
+			log.Printf("   ⚠️  %s", msg)
+		}
+		if result.Skipped > len(result.Errors) {
+			log.Printf("   ⚠️  ... and %d more skipped rows", result.Skipped-len(result.Errors))
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func importFile(imp *importer.Importer, path string) (*importer.Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return imp.ImportFile(path, f)
+}
+
+// loadSymbolMap reads "vendor,symbol" lines into a lookup map
+func loadSymbolMap(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = 2
+	reader.Comment = '#'
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	symbolMap := make(map[string]string, len(records))
+	for _, record := range records {
+		symbolMap[strings.TrimSpace(record[0])] = strings.TrimSpace(record[1])
+	}
+
+	return symbolMap, nil
+}
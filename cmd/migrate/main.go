@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: migrate [up | version | force VERSION]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "  up             Apply pending migrations (default)")
+	fmt.Fprintln(os.Stderr, "  version        Print the applied migration version")
+	fmt.Fprintln(os.Stderr, "  force VERSION  Mark VERSION as applied after fixing a failed migration")
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	dsn := os.Getenv("TRADING_CHITTI_PG_DSN")
+	if dsn == "" {
+		log.Fatal("TRADING_CHITTI_PG_DSN environment variable not set")
+	}
+
+	command := flag.Arg(0)
+	if command == "" {
+		command = "up"
+	}
+
+	switch command {
+	case "up":
+		version, err := database.Migrate(dsn)
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		log.Printf("✅ Database at migration %d", version)
+
+	case "version":
+		version, dirty, err := database.MigrationVersion(dsn)
+		if err != nil {
+			log.Fatalf("Failed to read version: %v", err)
+		}
+		if dirty {
+			fmt.Printf("%d (dirty)\n", version)
+		} else {
+			fmt.Println(version)
+		}
+
+	case "force":
+		version, err := strconv.Atoi(flag.Arg(1))
+		if err != nil || flag.NArg() != 2 {
+			usage()
+			os.Exit(1)
+		}
+		if err := database.ForceMigrationVersion(dsn, version); err != nil {
+			log.Fatalf("Failed to force version: %v", err)
+		}
+		log.Printf("✅ Database marked at migration %d", version)
+
+	default:
+		usage()
+		os.Exit(1)
+	}
+}
@@ -1,20 +1,41 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
 
+	"github.com/trading-chitti/market-bridge/internal/alerts"
+	"github.com/trading-chitti/market-bridge/internal/algo"
+	"github.com/trading-chitti/market-bridge/internal/analyzer"
 	"github.com/trading-chitti/market-bridge/internal/api"
 	"github.com/trading-chitti/market-bridge/internal/auth"
 	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/collector"
 	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/journal"
 	"github.com/trading-chitti/market-bridge/internal/metrics"
+	"github.com/trading-chitti/market-bridge/internal/notify"
+	"github.com/trading-chitti/market-bridge/internal/options"
+	"github.com/trading-chitti/market-bridge/internal/portfolio"
+	"github.com/trading-chitti/market-bridge/internal/quality"
+	"github.com/trading-chitti/market-bridge/internal/quotes"
+	"github.com/trading-chitti/market-bridge/internal/risk"
 	"github.com/trading-chitti/market-bridge/internal/services"
+	"github.com/trading-chitti/market-bridge/internal/tracing"
+	"github.com/trading-chitti/market-bridge/internal/watchlist"
 )
 
 func main() {
@@ -23,12 +44,65 @@ func main() {
 		log.Println("No .env file found, using environment variables")
 	}
 	
+	// Trading holidays beyond the built-in calendar
+	if err := broker.AddIndianMarketHolidays(strings.Split(os.Getenv("MARKET_HOLIDAYS"), ",")...); err != nil {
+		log.Fatalf("Invalid MARKET_HOLIDAYS: %v", err)
+	}
+
+	// Export traces when an OTLP collector is configured
+	tracingConfig, err := loadTracingConfig()
+	if err != nil {
+		log.Fatalf("Invalid tracing config: %v", err)
+	}
+	if tracingConfig.Endpoint != "" {
+		shutdownTracing, err := tracing.Setup(context.Background(), tracingConfig)
+		if err != nil {
+			log.Fatalf("Failed to set up tracing: %v", err)
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTracing(ctx); err != nil {
+				log.Printf("⚠️  Failed to flush traces: %v", err)
+			}
+		}()
+		log.Printf("🔭 Exporting traces to %s (sampling %.0f%%)", tracingConfig.Endpoint, tracingConfig.SampleRatio*100)
+	}
+
+	// Apply pending schema migrations before anything queries the database
+	if os.Getenv("MIGRATE_ON_START") == "true" {
+		version, err := database.Migrate(os.Getenv("TRADING_CHITTI_PG_DSN"))
+		if err != nil {
+			log.Fatalf("Failed to migrate database: %v", err)
+		}
+		log.Printf("📦 Database at migration %d", version)
+	}
+
 	// Initialize database
 	db, err := database.NewDatabase(os.Getenv("TRADING_CHITTI_PG_DSN"))
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 	defer db.Close()
+
+	// Read 5m, 15m and 1h bars from TimescaleDB continuous aggregates when
+	// schema_timescale.sql was applied
+	if timeframes, err := db.DetectContinuousAggregates(); err != nil {
+		log.Printf("⚠️  Failed to detect continuous aggregates: %v", err)
+	} else if len(timeframes) > 0 {
+		log.Printf("📈 Reading %s bars from continuous aggregates", strings.Join(timeframes, ", "))
+	}
+
+	// Rank the movers watchlists from the collector's bars
+	moversTTL := watchlist.DefaultMoversTTL
+	if v := os.Getenv("MOVERS_CACHE_TTL"); v != "" {
+		moversTTL, err = time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid MOVERS_CACHE_TTL: %v", err)
+		}
+	}
+	watchlist.SetMoverSource(db, moversTTL)
+	watchlist.SetStore(db)
 	
 	// Load broker configuration from database
 	brokerConfig, err := db.GetActiveBrokerConfig()
@@ -42,16 +116,100 @@ func main() {
 		}
 	}
 	
-	// Initialize broker
-	brk, err := broker.NewBroker(brokerConfig)
+	// Optionally push triggered alerts, order fills, collector failures and
+	// token expiry warnings to the channels users configure
+	var notifier *notify.Notifier
+	if os.Getenv("NOTIFICATIONS_ENABLED") == "true" {
+		notifier = notify.New(db)
+		log.Println("📣 Notifications enabled")
+	}
+
+	// Retry broker reads and fail fast while the broker is down
+	resilienceConfig, err := loadResilienceConfig()
 	if err != nil {
-		log.Fatalf("Failed to initialize broker: %v", err)
+		log.Fatalf("Failed to load broker resilience config: %v", err)
+	}
+
+	// Initialize broker. In paper mode orders are simulated and the
+	// configured broker only supplies market data.
+	var brk broker.Broker
+	var brokerCircuit *broker.CircuitBreaker // Breaker of the live broker
+	var tradeJournal *journal.Journal
+	var exitPlacer broker.OrderUpdateHandler // Places exits as entries fill
+	onOrderUpdate := func(update broker.OrderUpdate) {
+		tradeJournal.RecordOrderUpdate(update)
+		notifier.OrderUpdate("", update)
+		if exitPlacer != nil {
+			exitPlacer.HandleOrderUpdate(update)
+		}
+	}
+	paperTrading := brokerConfig.BrokerName == "paper" || os.Getenv("PAPER_TRADING") == "true"
+	if paperTrading {
+		paperBroker, err := newPaperBroker(db, brokerConfig)
+		if err != nil {
+			log.Fatalf("Failed to initialize paper broker: %v", err)
+		}
+		defer paperBroker.Stop()
+		tradeJournal = journal.New(db, paperBroker.GetBrokerName(), "")
+		paperBroker.SetOrderUpdateHandler(onOrderUpdate)
+		brk = paperBroker
+		log.Println("📝 Paper trading enabled: orders are simulated")
+	} else {
+		brk, err = broker.NewBroker(brokerConfig)
+		if err != nil {
+			log.Fatalf("Failed to initialize broker: %v", err)
+		}
+		exitPlacer, _ = brk.(broker.OrderUpdateHandler)
+		resilient := broker.WithResilience(broker.WithMetrics(brk), resilienceConfig)
+		brokerCircuit = resilient.Breaker()
+		brk = resilient
+		tradeJournal = journal.New(db, brk.GetBrokerName(), "")
+	}
+
+	// Check risk limits before orders reach the broker, and journal every
+	// order placed, modified or cancelled (including risk rejections)
+	riskLimits, err := loadRiskLimits(brokerConfig)
+	if err != nil {
+		log.Fatalf("Failed to load risk limits: %v", err)
+	}
+	// With RISK_REQUIRE_MARGIN, orders the available margin doesn't cover
+	// (charges included) are rejected too
+	requireMargin := os.Getenv("RISK_REQUIRE_MARGIN") == "true"
+	riskEngine := risk.NewEngine(riskLimits)
+	riskEngine.SetRequireMargin(requireMargin)
+	riskEngine.SetInstrumentLookup(db.GetInstrument)
+	brk = tradeJournal.Wrap(riskEngine.Wrap(brk))
+	riskHandler := api.NewRiskHandler(riskEngine, brk, db, brokerConfig.ID)
+
+	scanConfig, err := loadScanConfig()
+	if err != nil {
+		log.Fatalf("Failed to load trade scan config: %v", err)
+	}
+
+	confluenceRules, err := loadConfluenceRules()
+	if err != nil {
+		log.Fatalf("Failed to load confluence rules: %v", err)
+	}
+
+	riskFreeRate, err := loadRiskFreeRate()
+	if err != nil {
+		log.Fatalf("Failed to load risk-free rate: %v", err)
+	}
+
+	streamLimits, err := loadStreamLimits()
+	if err != nil {
+		log.Fatalf("Failed to load streaming limits: %v", err)
 	}
 
 	// Initialize WebSocket hub
 	var wsHub *api.WebSocketHub
 	if brokerConfig.APIKey != "" && brokerConfig.AccessToken != "" {
 		wsHub = api.NewWebSocketHub(brokerConfig.APIKey, brokerConfig.AccessToken)
+		wsHub.SetDatabase(db)
+		if !paperTrading {
+			// Paper orders are journaled by the paper broker itself
+			wsHub.SetOrderUpdateHandler(onOrderUpdate)
+		}
 		go wsHub.Run()
 		wsHub.StartTicker()
 		log.Println("✅ WebSocket hub initialized and started")
@@ -61,6 +219,9 @@ func main() {
 
 	// Initialize token refresh service
 	tokenRefreshService := services.NewTokenRefreshService(db)
+	if notifier != nil {
+		tokenRefreshService.SetExpiryHandler(notifier.TokenExpiring)
+	}
 	tokenRefreshService.Start(1 * time.Hour) // Check every hour
 	defer tokenRefreshService.Stop()
 	log.Println("✅ Token refresh service started")
@@ -75,14 +236,18 @@ func main() {
 		}()
 	}
 
-	// Create Gin router
-	router := gin.Default()
+	// Create Gin router, logging each request with its X-Request-ID
+	requestLogger := api.NewLogger(os.Getenv("LOG_FORMAT"))
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.Use(api.RequestLogMiddleware(requestLogger))
 
 	// Add CORS middleware
 	router.Use(api.CORSMiddleware())
 
-	// Add metrics middleware
+	// Add metrics and tracing middleware
 	router.Use(api.MetricsMiddleware())
+	router.Use(api.TracingMiddleware())
 
 	// Add API key authentication (only if API_KEY is set)
 	if os.Getenv("API_KEY") != "" {
@@ -92,16 +257,255 @@ func main() {
 		log.Println("⚠️  API key authentication disabled (set API_KEY to enable)")
 	}
 
+	// Optionally limit each client's request rate per route class, in memory
+	// or shared across instances through Redis or the database
+	var rateLimiter *api.RateLimiter
+	if os.Getenv("RATE_LIMIT_ENABLED") == "true" {
+		rateLimits, err := loadRateLimits()
+		if err != nil {
+			log.Fatalf("Failed to load rate limits: %v", err)
+		}
+		var store api.RateLimitStore
+		switch os.Getenv("RATE_LIMIT_STORE") {
+		case "", "memory":
+			store = api.NewMemoryRateLimitStore()
+		case "redis":
+			options, err := redis.ParseURL(os.Getenv("REDIS_URL"))
+			if err != nil {
+				log.Fatalf("Invalid REDIS_URL: %v", err)
+			}
+			client := redis.NewClient(options)
+			if err := client.Ping(context.Background()).Err(); err != nil {
+				log.Fatalf("Failed to connect to Redis: %v", err)
+			}
+			defer client.Close()
+			store = api.NewRedisRateLimitStore(client)
+		case "postgres":
+			store = api.NewPostgresRateLimitStore(db)
+		default:
+			log.Fatalf("Invalid RATE_LIMIT_STORE: %s (use memory, redis or postgres)", os.Getenv("RATE_LIMIT_STORE"))
+		}
+		rateLimiter = api.NewRateLimiter(rateLimits, store)
+		rateLimiter.SetAPIKey(os.Getenv("API_KEY"))
+		router.Use(rateLimiter.Middleware())
+		log.Println("🚦 Rate limiting enabled")
+	}
+
 	// Initialize collector handler
 	collectorHandler := api.NewCollectorHandler(db)
 	defer collectorHandler.GetManager().StopAll()
+	if notifier != nil {
+		collectorHandler.GetManager().SetErrorHandler(notifier.CollectorFailure)
+	}
+
+	// Keep collectors that follow watchlists subscribed to their current members
+	watchlistRefresh := collector.DefaultWatchlistRefreshInterval
+	if v := os.Getenv("COLLECTOR_WATCHLIST_REFRESH_INTERVAL"); v != "" {
+		watchlistRefresh, err = time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid COLLECTOR_WATCHLIST_REFRESH_INTERVAL: %v", err)
+		}
+	}
+	collectorHandler.GetManager().StartWatchlistRefresh(watchlistRefresh)
+
+	// Watch collectors for stale data during market hours
+	healthConfig, err := loadCollectorHealthConfig()
+	if err != nil {
+		log.Fatalf("Failed to load collector health config: %v", err)
+	}
+	collectorHandler.GetManager().StartHealthWatchdog(healthConfig)
+
+	// Batch the tick writes of real collectors
+	tickWriterConfig, err := loadTickWriterConfig()
+	if err != nil {
+		log.Fatalf("Failed to load collector tick writer config: %v", err)
+	}
+	collectorHandler.GetManager().SetTickWriterConfig(tickWriterConfig)
+
+	// Shape the prices and volumes mock collectors generate
+	mockConfig, err := loadMockConfig()
+	if err != nil {
+		log.Fatalf("Failed to load mock collector config: %v", err)
+	}
+	collectorHandler.GetManager().SetMockConfig(mockConfig)
+
+	// Reject bad bars and ticks before they're stored, tag suspect ones
+	qualityConfig, err := loadDataQualityConfig()
+	if err != nil {
+		log.Fatalf("Failed to load data quality config: %v", err)
+	}
+	collectorHandler.GetManager().SetQualityGate(quality.NewMonitor(db, qualityConfig))
+
+	// Keep the latest quote of every collected symbol in memory
+	quoteMaxAge := quotes.DefaultMaxAge
+	if v := os.Getenv("QUOTE_MAX_AGE"); v != "" {
+		quoteMaxAge, err = time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid QUOTE_MAX_AGE: %v", err)
+		}
+	}
+	quoteStore := quotes.NewStore(quoteMaxAge)
+	collectorHandler.GetManager().SetQuoteStore(quoteStore)
+
+	// Re-fetch recent and partial cached candles, capping the cache size
+	historicalCachePolicy, err := loadHistoricalCacheConfig()
+	if err != nil {
+		log.Fatalf("Failed to load historical cache config: %v", err)
+	}
+
+	// Push position P&L snapshots to /ws/positions clients
+	positionInterval := api.DefaultPositionSnapshotInterval
+	if v := os.Getenv("POSITION_SNAPSHOT_INTERVAL"); v != "" {
+		positionInterval, err = time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid POSITION_SNAPSHOT_INTERVAL: %v", err)
+		}
+	}
+	if wsHub != nil {
+		wsHub.StartPositionSnapshots(brk, quoteStore, positionInterval)
+	}
+
+	// Recreate the collectors stored before the last shutdown
+	if err := collectorHandler.GetManager().RestoreCollectors(brokerConfig.APIKey, brokerConfig.AccessToken); err != nil {
+		log.Printf("⚠️  Failed to restore collectors: %v", err)
+	}
+
+	// Optionally run the after-close backfill on a schedule
+	if os.Getenv("BACKFILL_SCHEDULER_ENABLED") == "true" {
+		var watchlists []string
+		if names := os.Getenv("BACKFILL_WATCHLISTS"); names != "" {
+			for _, name := range strings.Split(names, ",") {
+				watchlists = append(watchlists, strings.TrimSpace(name))
+			}
+		}
+
+		backfillScheduler, err := services.NewBackfillScheduler(brk, db, collectorHandler.GetManager(), services.BackfillSchedulerConfig{
+			Cron:       os.Getenv("BACKFILL_CRON"),
+			Timeframe:  os.Getenv("BACKFILL_TIMEFRAME"),
+			Watchlists: watchlists,
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize backfill scheduler: %v", err)
+		}
+		backfillScheduler.Start()
+		defer backfillScheduler.Stop()
+		log.Println("✅ Backfill scheduler started")
+	}
+
+	// Initialize user-defined strategy runner
+	strategyHandler := api.NewStrategyHandler(brk, db)
+	defer strategyHandler.Stop()
+
+	// Optionally evaluate price, indicator and pattern alerts
+	var alertHandler *api.AlertHandler
+	if os.Getenv("ALERTS_ENABLED") == "true" {
+		pollInterval := alerts.DefaultPollInterval
+		if v := os.Getenv("ALERTS_POLL_INTERVAL"); v != "" {
+			pollInterval, err = time.ParseDuration(v)
+			if err != nil {
+				log.Fatalf("Invalid ALERTS_POLL_INTERVAL: %v", err)
+			}
+		}
+		alertMonitor := alerts.NewMonitor(db, pollInterval)
+		if notifier != nil {
+			alertMonitor.SetTriggerHandler(notifier.AlertTriggered)
+		}
+		alertMonitor.Start()
+		defer alertMonitor.Stop()
+		alertHandler = api.NewAlertHandler(db, alertMonitor)
+	}
+
+	// Work large orders as TWAP or iceberg slices
+	algoInterval := algo.DefaultPollInterval
+	if v := os.Getenv("ALGO_POLL_INTERVAL"); v != "" {
+		algoInterval, err = time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid ALGO_POLL_INTERVAL: %v", err)
+		}
+	}
+	algoManager := algo.NewManager(algoInterval)
+	algoManager.Start()
+	defer algoManager.Stop()
+
+	// Optionally close MIS positions before the close and exit on stops
+	var squareOffHandler *api.SquareOffHandler
+	if os.Getenv("SQUARE_OFF_ENABLED") == "true" {
+		squareOffConfig, err := loadSquareOffConfig()
+		if err != nil {
+			log.Fatalf("Failed to load square-off config: %v", err)
+		}
+		squareOffService, err := services.NewSquareOffService(brk, squareOffConfig)
+		if err != nil {
+			log.Fatalf("Failed to initialize square-off service: %v", err)
+		}
+		squareOffService.Start()
+		defer squareOffService.Stop()
+		squareOffHandler = api.NewSquareOffHandler(squareOffService)
+	}
+
+	// Value holdings and positions at live collector prices, optionally
+	// recording the value after every session
+	portfolioService := portfolio.NewService(brk, quoteStore)
+	portfolioHandler := api.NewPortfolioHandler(portfolioService, db)
+	if os.Getenv("PORTFOLIO_SNAPSHOT_ENABLED") == "true" {
+		snapshotService, err := services.NewPortfolioSnapshotService(db, portfolioService, os.Getenv("PORTFOLIO_SNAPSHOT_CRON"))
+		if err != nil {
+			log.Fatalf("Failed to initialize portfolio snapshot service: %v", err)
+		}
+		snapshotService.Start()
+		defer snapshotService.Stop()
+	}
+
+	// Optionally roll up and delete old ticks and 1m bars every night
+	var retentionHandler *api.RetentionHandler
+	if os.Getenv("RETENTION_ENABLED") == "true" {
+		retentionConfig, err := loadRetentionConfig()
+		if err != nil {
+			log.Fatalf("Failed to load retention config: %v", err)
+		}
+		retentionService, err := services.NewRetentionService(db, retentionConfig)
+		if err != nil {
+			log.Fatalf("Failed to initialize retention service: %v", err)
+		}
+		retentionService.Start()
+		defer retentionService.Stop()
+		retentionHandler = api.NewRetentionHandler(retentionService, db)
+	}
 
 	// Initialize metrics (set initial collector count to 0)
 	metrics.SetActiveCollectors(0)
 
+	// Cap the symbols labelled on per-symbol collector metrics
+	if v := os.Getenv("COLLECTOR_METRICS_MAX_SYMBOLS"); v != "" {
+		maxSymbols, err := strconv.Atoi(v)
+		if err != nil || maxSymbols < 0 {
+			log.Fatalf("Invalid COLLECTOR_METRICS_MAX_SYMBOLS: %q", v)
+		}
+		metrics.SetMaxSymbolLabels(maxSymbols)
+	}
+
 	// Check if multi-user mode is enabled
 	multiUserMode := os.Getenv("MULTI_USER_MODE") == "true"
 
+	// Hub behind /stream/ws, set once the API routes are registered
+	var streamHub *api.StreamingHub
+
+	// Per-user /ws hubs in multi-user mode
+	var wsHubManager *api.WebSocketHubManager
+
+	// Dependencies /health/ready reports besides the database. Neither makes
+	// the service unready: with an expired token it must stay reachable for
+	// the Kite login that renews it.
+	addReadinessChecks := func(apiHandler *api.API) {
+		if !paperTrading && brokerConfig.ID != 0 {
+			apiHandler.AddReadinessCheck("broker_token", false, api.BrokerTokenCheck(tokenRefreshService, brokerConfig.ID))
+		}
+		apiHandler.AddReadinessCheck("collectors", false, api.CollectorsCheck(collectorHandler.GetManager()))
+		if brokerCircuit != nil {
+			apiHandler.AddReadinessCheck("broker_circuit", false, api.BrokerCircuitCheck(brokerCircuit))
+		}
+	}
+
 	if multiUserMode {
 		log.Println("🔐 Multi-user mode enabled")
 
@@ -111,26 +515,110 @@ func main() {
 			log.Fatal("JWT_SECRET environment variable must be set in multi-user mode")
 		}
 		authService := auth.NewAuthService(jwtSecret)
+		if rateLimiter != nil {
+			rateLimiter.SetAuthService(authService)
+		}
 
 		// Initialize WebSocket hub manager for per-user hubs
-		wsHubManager := api.NewWebSocketHubManager(db)
-		defer wsHubManager.CloseAllHubs()
+		wsHubManager = api.NewWebSocketHubManager(db)
+		wsHubManager.SetNotifier(notifier)
+		wsHubManager.SetPositionSnapshots(quoteStore, positionInterval)
+
+		// Authenticates /ws and /stream connections, closing them when
+		// their session is revoked
+		streamGuard := api.NewStreamGuard(authService, os.Getenv("API_KEY"), streamLimits)
+		streamGuard.SetSessionChecker(db)
 
 		// Register authentication routes (public)
 		authHandler := api.NewAuthHandler(db, authService)
+		authHandler.SetStreamGuard(streamGuard)
+		if os.Getenv("SMTP_HOST") != "" {
+			mailer, err := loadMailer()
+			if err != nil {
+				log.Fatalf("Failed to configure SMTP: %v", err)
+			}
+			authHandler.SetMailer(mailer, os.Getenv("APP_URL"))
+			log.Println("📧 Verification and password reset emails enabled")
+		}
 		authHandler.RegisterRoutes(router.Group("/api"))
 
 		// Register broker management routes (authenticated)
 		brokerHandler := api.NewBrokerManagementHandler(db, authService)
+		brokerHandler.SetWebSocketHubManager(wsHubManager)
 		authMiddleware := api.AuthMiddleware(authService, db)
 		brokerHandler.RegisterRoutes(router.Group("/api"), authMiddleware)
 
+		// Two-factor authentication
+		api.NewTOTPHandler(db).RegisterRoutes(router.Group("/api"), authMiddleware)
+
+		// Register combined multi-account routes (authenticated, per-user)
+		api.NewAccountsHandler(db).RegisterRoutes(router.Group("/api"), authMiddleware)
+
+		// Register strategy routes (authenticated, per-user)
+		strategyHandler.RegisterRoutes(router.Group("/api"), authMiddleware)
+
+		// Register user watchlist routes (authenticated, per-user, shareable)
+		api.NewUserWatchlistHandler(db).RegisterRoutes(router.Group("/api"), authMiddleware)
+
+		// Register alert routes (authenticated, per-user)
+		if alertHandler != nil {
+			alertHandler.RegisterRoutes(router.Group("/api"), authMiddleware)
+		}
+		if notifier != nil {
+			api.NewNotificationHandler(db, notifier).RegisterRoutes(router.Group("/api"), authMiddleware)
+		}
+
+		// Register risk limit, square-off, retention and portfolio routes.
+		// They act on the operator's broker and service-wide settings, so
+		// only administrators may use them.
+		adminMiddleware := api.AdminMiddleware(db)
+		riskHandler.RegisterRoutes(router.Group(""), authMiddleware, adminMiddleware)
+		if squareOffHandler != nil {
+			squareOffHandler.RegisterRoutes(router.Group(""), authMiddleware, adminMiddleware)
+		}
+		if retentionHandler != nil {
+			retentionHandler.RegisterRoutes(router.Group(""), authMiddleware, adminMiddleware)
+		}
+		portfolioHandler.RegisterRoutes(router.Group(""), authMiddleware, adminMiddleware)
+		api.NewTokenHandler(tokenRefreshService).RegisterRoutes(router.Group(""), authMiddleware)
+
+		// Kite login redirect, storing each user's new access token
+		kiteCallbackHandler := api.NewKiteCallbackHandler(db, brk, brokerConfig)
+		kiteCallbackHandler.SetWebSocketHubManager(wsHubManager)
+		kiteCallbackHandler.RegisterRoutes(router.Group(""), authMiddleware)
+
 		// Register API handlers with authentication
 		apiHandler := api.NewAPI(brk, db)
+		apiHandler.SetRiskEngine(riskEngine)
+		apiHandler.SetAlgoManager(algoManager)
+		apiHandler.SetScanConfig(scanConfig)
+		apiHandler.SetConfluenceRules(confluenceRules)
+		apiHandler.SetRiskFreeRate(riskFreeRate)
+		apiHandler.SetLogger(requestLogger)
+		apiHandler.SetQuoteStore(quoteStore)
+		apiHandler.SetHistoricalCachePolicy(historicalCachePolicy)
+		apiHandler.SetStreamGuard(streamGuard)
+		apiHandler.SetWebSocketHubManager(wsHubManager)
+		apiHandler.SetCollectorHandler(collectorHandler)
+		apiHandler.SetAdminAuth(authMiddleware, adminMiddleware)
+		addReadinessChecks(apiHandler)
+
+		// Route /account, /market and /trade to each user's default broker,
+		// checking orders against that account's own risk limits
+		brokerResolver := api.NewBrokerResolver(db)
+		brokerResolver.SetResilienceConfig(resilienceConfig)
+		brokerResolver.SetRequireMargin(requireMargin)
+		wsHubManager.SetOrderUpdateListener(brokerResolver.HandleOrderUpdate)
+		apiHandler.SetBrokerResolver(brokerResolver, authMiddleware)
+		apiHandler.SetOrderChallenge(api.OrderTOTPMiddleware(db))
 		apiHandler.RegisterRoutes(router)
+		streamHub = apiHandler.StreamingHub()
+
+		// Register WebSocket routes (each user streams from their own broker)
+		apiHandler.RegisterWebSocketRoutes(router)
 
-		// Register collector routes (authenticated)
-		// collectorHandler.RegisterRoutes(router.Group("/api"), authMiddleware)
+		// Register collector routes (administrators only)
+		collectorHandler.RegisterRoutes(router.Group("/api"), authMiddleware, adminMiddleware)
 
 		log.Println("✅ Multi-user authentication initialized")
 	} else {
@@ -138,12 +626,24 @@ func main() {
 
 		// Initialize API handlers
 		apiHandler := api.NewAPI(brk, db)
+		apiHandler.SetRiskEngine(riskEngine)
+		apiHandler.SetAlgoManager(algoManager)
+		apiHandler.SetScanConfig(scanConfig)
+		apiHandler.SetConfluenceRules(confluenceRules)
+		apiHandler.SetRiskFreeRate(riskFreeRate)
+		apiHandler.SetLogger(requestLogger)
+		apiHandler.SetQuoteStore(quoteStore)
+		apiHandler.SetHistoricalCachePolicy(historicalCachePolicy)
+		apiHandler.SetStreamGuard(api.NewStreamGuard(nil, os.Getenv("API_KEY"), streamLimits))
+		apiHandler.SetCollectorHandler(collectorHandler)
+		addReadinessChecks(apiHandler)
 		if wsHub != nil {
 			apiHandler.SetWebSocketHub(wsHub)
 		}
 
 		// Register routes
 		apiHandler.RegisterRoutes(router)
+		streamHub = apiHandler.StreamingHub()
 
 		// Register WebSocket routes
 		if wsHub != nil {
@@ -152,6 +652,88 @@ func main() {
 
 		// Register collector routes (public for backward compatibility)
 		collectorHandler.RegisterRoutes(router.Group("/api"))
+
+		// Register strategy routes (shared in single-user mode)
+		strategyHandler.RegisterRoutes(router.Group("/api"))
+
+		// Register user watchlist routes (shared in single-user mode)
+		api.NewUserWatchlistHandler(db).RegisterRoutes(router.Group("/api"))
+
+		// Register alert routes (shared in single-user mode)
+		if alertHandler != nil {
+			alertHandler.RegisterRoutes(router.Group("/api"))
+		}
+		if notifier != nil {
+			api.NewNotificationHandler(db, notifier).RegisterRoutes(router.Group("/api"))
+		}
+
+		// Register risk limit routes
+		riskHandler.RegisterRoutes(router.Group(""))
+		if squareOffHandler != nil {
+			squareOffHandler.RegisterRoutes(router.Group(""))
+		}
+		if retentionHandler != nil {
+			retentionHandler.RegisterRoutes(router.Group(""))
+		}
+		portfolioHandler.RegisterRoutes(router.Group(""))
+		api.NewTokenHandler(tokenRefreshService).RegisterRoutes(router.Group(""))
+
+		// Kite login redirect, restarting the ticker and collectors with
+		// the new access token
+		kiteCallbackHandler := api.NewKiteCallbackHandler(db, brk, brokerConfig)
+		if wsHub != nil {
+			kiteCallbackHandler.SetWebSocketHub(wsHub)
+		}
+		kiteCallbackHandler.SetCollectorManager(collectorHandler.GetManager())
+		kiteCallbackHandler.RegisterRoutes(router.Group(""))
+	}
+
+	// Stream collector ticks and completed bars to /stream/ws clients
+	if streamHub != nil {
+		collectorHandler.GetManager().SetPublisher(streamHub)
+	}
+
+	// Optionally scan watchlist symbols for new patterns in the background
+	if os.Getenv("PATTERN_SCANNER_ENABLED") == "true" {
+		patternScanConfig, err := loadPatternScannerConfig()
+		if err != nil {
+			log.Fatalf("Failed to load pattern scanner config: %v", err)
+		}
+		patternScanner, err := services.NewPatternScannerService(db, patternScanConfig)
+		if err != nil {
+			log.Fatalf("Failed to initialize pattern scanner: %v", err)
+		}
+		patternScanner.SetDetectionHandler(func(detection database.PatternDetection) {
+			streamHub.BroadcastPattern(&detection)
+			notifier.PatternDetected(detection)
+		})
+		patternScanner.Start()
+		defer patternScanner.Stop()
+	}
+
+	// Optionally close stored signals as prices reach their stops and targets
+	if os.Getenv("SIGNAL_TRACKER_ENABLED") == "true" {
+		interval := services.DefaultSignalTrackInterval
+		if v := os.Getenv("SIGNAL_TRACKER_INTERVAL"); v != "" {
+			interval, err = time.ParseDuration(v)
+			if err != nil {
+				log.Fatalf("Invalid SIGNAL_TRACKER_INTERVAL: %v", err)
+			}
+		}
+		signalTracker := services.NewSignalTrackerService(db, database.NewHistoricalDataService(db, brk), interval)
+		signalTracker.Start()
+		defer signalTracker.Stop()
+	}
+
+	// Optionally store full quotes of collected symbols during market hours
+	if os.Getenv("QUOTE_SNAPSHOTS_ENABLED") == "true" {
+		quoteSnapshotConfig, err := loadQuoteSnapshotConfig()
+		if err != nil {
+			log.Fatalf("Failed to load quote snapshot config: %v", err)
+		}
+		quoteSnapshots := services.NewQuoteSnapshotService(brk, db, collectorHandler.GetManager(), quoteSnapshotConfig)
+		quoteSnapshots.Start()
+		defer quoteSnapshots.Stop()
 	}
 
 	// Register Prometheus metrics endpoint
@@ -170,7 +752,697 @@ func main() {
 	log.Printf("🔌 WebSocket: ws://localhost:%s/ws/market", port)
 	log.Printf("📖 API Docs: http://localhost:%s/", port)
 
-	if err := router.Run(":" + port); err != nil {
+	shutdownTimeout := 20 * time.Second
+	if v := os.Getenv("SHUTDOWN_TIMEOUT"); v != "" {
+		shutdownTimeout, err = time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid SHUTDOWN_TIMEOUT: %v", err)
+		}
+	}
+
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: router,
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	signals, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
+	select {
+	case err := <-serverErr:
 		log.Fatalf("Failed to start server: %v", err)
+	case <-signals.Done():
+	}
+	stopSignals() // A second signal kills the process right away
+
+	log.Printf("🛑 Shutting down (timeout %s)...", shutdownTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// Streams never end by themselves, so their clients are disconnected
+	// with close frames as soon as the server stops accepting connections
+	streamsClosed := make(chan struct{})
+	server.RegisterOnShutdown(func() {
+		defer close(streamsClosed)
+		if streamHub != nil {
+			streamHub.Close(ctx)
+		}
+		if wsHub != nil {
+			wsHub.Close(ctx, "server shutting down")
+		}
+		if wsHubManager != nil {
+			wsHubManager.CloseAllHubs(ctx)
+		}
+	})
+
+	// Stop accepting connections and let in-flight requests finish
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("⚠️  HTTP server did not shut down cleanly: %v", err)
+	}
+	<-streamsClosed
+	log.Println("🛑 HTTP server stopped")
+
+	// Returning runs the deferred stops in reverse order of startup:
+	// background services, then collectors (storing pending ticks and
+	// flushing forming candles), token refresh, the paper broker and
+	// finally the database
+}
+
+// newPaperBroker creates a paper broker persisted in the database. Market
+// data comes from the configured broker, or from Zerodha credentials in the
+// environment when the configured broker is itself "paper".
+func newPaperBroker(db *database.Database, config *broker.BrokerConfig) (*broker.PaperBroker, error) {
+	dataConfig := config
+	if config.BrokerName == "paper" {
+		dataConfig = &broker.BrokerConfig{
+			BrokerName:  "zerodha",
+			APIKey:      os.Getenv("ZERODHA_API_KEY"),
+			APISecret:   os.Getenv("ZERODHA_API_SECRET"),
+			AccessToken: os.Getenv("ZERODHA_ACCESS_TOKEN"),
+		}
+	}
+
+	var marketData broker.Broker
+	if dataConfig.APIKey != "" {
+		var err error
+		marketData, err = broker.NewBroker(dataConfig)
+		if err != nil {
+			log.Printf("⚠️  Paper broker has no market data broker (%v); using collected ticks", err)
+		} else {
+			marketData = broker.WithMetrics(marketData)
+		}
+	}
+
+	paper := &broker.PaperConfig{
+		AccountID:  config.AccountName,
+		MarketData: marketData,
+		Ledger:     db,
+	}
+	if v := os.Getenv("PAPER_INITIAL_CAPITAL"); v != "" {
+		capital, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PAPER_INITIAL_CAPITAL: %w", err)
+		}
+		paper.InitialCapital = capital
+	}
+	if v := os.Getenv("PAPER_SLIPPAGE_PCT"); v != "" {
+		slippage, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PAPER_SLIPPAGE_PCT: %w", err)
+		}
+		paper.SlippagePct = slippage
+	}
+
+	return broker.NewPaperBroker(&broker.BrokerConfig{
+		BrokerName:  "paper",
+		AccountName: config.AccountName,
+		Paper:       paper,
+	})
+}
+
+// loadRiskLimits returns the risk limits of the broker config. When the
+// config comes from the environment, limits come from MAX_POSITIONS,
+// MAX_RISK_PER_TRADE, MAX_DAILY_LOSS and MAX_SYMBOL_EXPOSURE.
+func loadRiskLimits(config *broker.BrokerConfig) (risk.Limits, error) {
+	limits := risk.LimitsFromConfig(config)
+	if config.ID != 0 {
+		return limits, limits.Validate()
+	}
+
+	if v := os.Getenv("MAX_POSITIONS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return limits, fmt.Errorf("invalid MAX_POSITIONS: %w", err)
+		}
+		limits.MaxPositions = n
+	}
+
+	floats := []struct {
+		name string
+		dest *float64
+	}{
+		{"MAX_RISK_PER_TRADE", &limits.MaxRiskPerTrade},
+		{"MAX_DAILY_LOSS", &limits.MaxDailyLoss},
+		{"MAX_SYMBOL_EXPOSURE", &limits.MaxSymbolExposure},
+	}
+	for _, f := range floats {
+		v := os.Getenv(f.name)
+		if v == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return limits, fmt.Errorf("invalid %s: %w", f.name, err)
+		}
+		*f.dest = value
+	}
+
+	return limits, limits.Validate()
+}
+
+// loadScanConfig reads the trade scan defaults: MIN_CONFIDENCE and DRY_RUN.
+// Scans stay dry runs unless DRY_RUN=false.
+func loadScanConfig() (api.ScanConfig, error) {
+	config := api.DefaultScanConfig()
+	config.DryRun = os.Getenv("DRY_RUN") != "false"
+
+	if v := os.Getenv("MIN_CONFIDENCE"); v != "" {
+		value, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return config, fmt.Errorf("invalid MIN_CONFIDENCE: %w", err)
+		}
+		if value <= 0 || value > 1 {
+			return config, fmt.Errorf("MIN_CONFIDENCE must be between 0 and 1")
+		}
+		config.MinConfidence = value
+	}
+
+	return config, nil
+}
+
+// loadConfluenceRules reads the multi-timeframe confluence rules: none
+// unless CONFLUENCE_ENABLED=true, then the JSON array in
+// CONFLUENCE_RULES_FILE or the default rules
+func loadConfluenceRules() ([]analyzer.ConfluenceRule, error) {
+	if os.Getenv("CONFLUENCE_ENABLED") != "true" {
+		return nil, nil
+	}
+
+	path := os.Getenv("CONFLUENCE_RULES_FILE")
+	if path == "" {
+		return analyzer.DefaultConfluenceRules(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CONFLUENCE_RULES_FILE: %w", err)
+	}
+	return analyzer.ParseConfluenceRules(data)
+}
+
+// loadRiskFreeRate reads RISK_FREE_RATE, the annual rate option chains are
+// priced at as a fraction (e.g. 0.065)
+func loadRiskFreeRate() (float64, error) {
+	v := os.Getenv("RISK_FREE_RATE")
+	if v == "" {
+		return options.DefaultRiskFreeRate, nil
+	}
+
+	rate, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid RISK_FREE_RATE: %w", err)
+	}
+	if rate < 0 || rate >= 1 {
+		return 0, fmt.Errorf("RISK_FREE_RATE must be a fraction between 0 and 1")
+	}
+	return rate, nil
+}
+
+// loadTracingConfig reads the OTLP trace export settings:
+// OTEL_EXPORTER_OTLP_ENDPOINT (tracing is off when empty), OTEL_SERVICE_NAME
+// and OTEL_TRACES_SAMPLER_ARG
+func loadTracingConfig() (tracing.Config, error) {
+	config := tracing.Config{
+		Endpoint:    os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		ServiceName: "market-bridge",
+		SampleRatio: 1,
+	}
+	if v := os.Getenv("OTEL_SERVICE_NAME"); v != "" {
+		config.ServiceName = v
+	}
+
+	if v := os.Getenv("OTEL_TRACES_SAMPLER_ARG"); v != "" {
+		value, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return config, fmt.Errorf("invalid OTEL_TRACES_SAMPLER_ARG: %w", err)
+		}
+		if value < 0 || value > 1 {
+			return config, fmt.Errorf("OTEL_TRACES_SAMPLER_ARG must be between 0 and 1")
+		}
+		config.SampleRatio = value
+	}
+
+	return config, nil
+}
+
+// loadPatternScannerConfig reads the background pattern scanner settings:
+// PATTERN_SCAN_INTERVAL, PATTERN_SCAN_WATCHLISTS, PATTERN_SCAN_TIMEFRAMES and
+// PATTERN_SCAN_MIN_CONFIDENCE
+func loadPatternScannerConfig() (services.PatternScannerConfig, error) {
+	var config services.PatternScannerConfig
+
+	if v := os.Getenv("PATTERN_SCAN_INTERVAL"); v != "" {
+		interval, err := time.ParseDuration(v)
+		if err != nil {
+			return config, fmt.Errorf("invalid PATTERN_SCAN_INTERVAL: %w", err)
+		}
+		config.Interval = interval
+	}
+	if v := os.Getenv("PATTERN_SCAN_MIN_CONFIDENCE"); v != "" {
+		confidence, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return config, fmt.Errorf("invalid PATTERN_SCAN_MIN_CONFIDENCE: %w", err)
+		}
+		config.MinConfidence = confidence
+	}
+
+	for _, name := range strings.Split(os.Getenv("PATTERN_SCAN_WATCHLISTS"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			config.Watchlists = append(config.Watchlists, name)
+		}
+	}
+	for _, tf := range strings.Split(os.Getenv("PATTERN_SCAN_TIMEFRAMES"), ",") {
+		if tf = strings.TrimSpace(tf); tf != "" {
+			config.Timeframes = append(config.Timeframes, tf)
+		}
+	}
+
+	return config, nil
+}
+
+// loadQuoteSnapshotConfig reads the quote snapshot settings:
+// QUOTE_SNAPSHOT_INTERVAL and QUOTE_SNAPSHOT_WATCHLISTS
+func loadQuoteSnapshotConfig() (services.QuoteSnapshotConfig, error) {
+	var config services.QuoteSnapshotConfig
+
+	if v := os.Getenv("QUOTE_SNAPSHOT_INTERVAL"); v != "" {
+		interval, err := time.ParseDuration(v)
+		if err != nil {
+			return config, fmt.Errorf("invalid QUOTE_SNAPSHOT_INTERVAL: %w", err)
+		}
+		config.Interval = interval
+	}
+
+	for _, name := range strings.Split(os.Getenv("QUOTE_SNAPSHOT_WATCHLISTS"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			config.Watchlists = append(config.Watchlists, name)
+		}
+	}
+
+	return config, nil
+}
+
+// loadTickWriterConfig reads the collector tick batching settings:
+// COLLECTOR_TICK_BATCH_SIZE and COLLECTOR_TICK_FLUSH_INTERVAL
+func loadTickWriterConfig() (collector.TickWriterConfig, error) {
+	config := collector.DefaultTickWriterConfig()
+
+	if v := os.Getenv("COLLECTOR_TICK_BATCH_SIZE"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil {
+			return config, fmt.Errorf("invalid COLLECTOR_TICK_BATCH_SIZE: %w", err)
+		}
+		config.BatchSize = size
+	}
+
+	if v := os.Getenv("COLLECTOR_TICK_FLUSH_INTERVAL"); v != "" {
+		interval, err := time.ParseDuration(v)
+		if err != nil {
+			return config, fmt.Errorf("invalid COLLECTOR_TICK_FLUSH_INTERVAL: %w", err)
+		}
+		config.FlushInterval = interval
+	}
+
+	return config, config.Validate()
+}
+
+// loadMockConfig reads the mock collector settings: MOCK_MARKET_HOURS_ONLY,
+// MOCK_VOLATILITY, MOCK_REGIME_LENGTH, MOCK_GAP_PROBABILITY, MOCK_MAX_GAP_PCT,
+// MOCK_MAX_DRIFT_PCT, MOCK_SEED and MOCK_SCRIPT_FILE
+func loadMockConfig() (collector.MockConfig, error) {
+	config := collector.DefaultMockConfig()
+	config.MarketHoursOnly = os.Getenv("MOCK_MARKET_HOURS_ONLY") == "true"
+
+	if v := os.Getenv("MOCK_SEED"); v != "" {
+		seed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return config, fmt.Errorf("invalid MOCK_SEED: %w", err)
+		}
+		config.Seed = seed
+	}
+
+	if path := os.Getenv("MOCK_SCRIPT_FILE"); path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return config, fmt.Errorf("failed to open MOCK_SCRIPT_FILE: %w", err)
+		}
+		defer file.Close()
+
+		config.Script, err = collector.LoadMockScript(file)
+		if err != nil {
+			return config, fmt.Errorf("invalid MOCK_SCRIPT_FILE: %w", err)
+		}
+	}
+
+	if v := os.Getenv("MOCK_REGIME_LENGTH"); v != "" {
+		value, err := time.ParseDuration(v)
+		if err != nil {
+			return config, fmt.Errorf("invalid MOCK_REGIME_LENGTH: %w", err)
+		}
+		config.RegimeLength = value
+	}
+
+	floats := []struct {
+		name string
+		dest *float64
+	}{
+		{"MOCK_VOLATILITY", &config.Volatility},
+		{"MOCK_GAP_PROBABILITY", &config.GapProbability},
+		{"MOCK_MAX_GAP_PCT", &config.MaxGapPct},
+		{"MOCK_MAX_DRIFT_PCT", &config.MaxDriftPct},
+	}
+	for _, f := range floats {
+		v := os.Getenv(f.name)
+		if v == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return config, fmt.Errorf("invalid %s: %w", f.name, err)
+		}
+		*f.dest = value
+	}
+
+	return config, config.Validate()
+}
+
+// loadCollectorHealthConfig reads the collector watchdog settings:
+// COLLECTOR_HEALTH_INTERVAL, COLLECTOR_STALE_AFTER, COLLECTOR_STALL_AFTER,
+// COLLECTOR_RESTART_COOLDOWN and COLLECTOR_AUTO_RESTART
+func loadCollectorHealthConfig() (collector.HealthConfig, error) {
+	config := collector.DefaultHealthConfig()
+	config.AutoRestart = os.Getenv("COLLECTOR_AUTO_RESTART") == "true"
+
+	durations := []struct {
+		name string
+		dest *time.Duration
+	}{
+		{"COLLECTOR_HEALTH_INTERVAL", &config.Interval},
+		{"COLLECTOR_STALE_AFTER", &config.StaleAfter},
+		{"COLLECTOR_STALL_AFTER", &config.StallAfter},
+		{"COLLECTOR_RESTART_COOLDOWN", &config.RestartCooldown},
+	}
+	for _, d := range durations {
+		v := os.Getenv(d.name)
+		if v == "" {
+			continue
+		}
+		value, err := time.ParseDuration(v)
+		if err != nil {
+			return config, fmt.Errorf("invalid %s: %w", d.name, err)
+		}
+		*d.dest = value
+	}
+
+	return config, nil
+}
+
+// loadDataQualityConfig reads the data quality thresholds:
+// DATA_QUALITY_SPIKE_ATR_MULTIPLE and DATA_QUALITY_MAX_TICK_MOVE_PCT
+func loadDataQualityConfig() (quality.Config, error) {
+	config := quality.DefaultConfig()
+
+	floats := []struct {
+		name string
+		dest *float64
+	}{
+		{"DATA_QUALITY_SPIKE_ATR_MULTIPLE", &config.SpikeATRMultiple},
+		{"DATA_QUALITY_MAX_TICK_MOVE_PCT", &config.MaxTickMovePct},
+	}
+	for _, f := range floats {
+		v := os.Getenv(f.name)
+		if v == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return config, fmt.Errorf("invalid %s: %w", f.name, err)
+		}
+		*f.dest = value
+	}
+
+	return config, nil
+}
+
+// loadStreamLimits reads the streaming connection limits:
+// STREAM_ALLOWED_ORIGINS, STREAM_MAX_CONNECTIONS_PER_USER,
+// STREAM_MAX_SUBSCRIPTIONS, STREAM_MESSAGES_PER_SECOND and
+// STREAM_MESSAGE_BURST
+func loadStreamLimits() (api.StreamLimits, error) {
+	limits := api.DefaultStreamLimits()
+
+	if v := os.Getenv("STREAM_ALLOWED_ORIGINS"); v != "" {
+		for _, origin := range strings.Split(v, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				limits.AllowedOrigins = append(limits.AllowedOrigins, origin)
+			}
+		}
 	}
+
+	ints := []struct {
+		name string
+		dest *int
+	}{
+		{"STREAM_MAX_CONNECTIONS_PER_USER", &limits.MaxConnectionsPerUser},
+		{"STREAM_MAX_SUBSCRIPTIONS", &limits.MaxSubscriptions},
+		{"STREAM_MESSAGE_BURST", &limits.MessageBurst},
+	}
+	for _, i := range ints {
+		v := os.Getenv(i.name)
+		if v == "" {
+			continue
+		}
+		value, err := strconv.Atoi(v)
+		if err != nil {
+			return limits, fmt.Errorf("invalid %s: %w", i.name, err)
+		}
+		*i.dest = value
+	}
+
+	if v := os.Getenv("STREAM_MESSAGES_PER_SECOND"); v != "" {
+		value, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return limits, fmt.Errorf("invalid STREAM_MESSAGES_PER_SECOND: %w", err)
+		}
+		limits.MessagesPerSecond = value
+	}
+
+	return limits, nil
+}
+
+// loadMailer reads the SMTP account for verification and password reset
+// emails: SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD and SMTP_FROM
+func loadMailer() (*notify.Mailer, error) {
+	config := notify.EmailConfig{
+		Host:     os.Getenv("SMTP_HOST"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+	if v := os.Getenv("SMTP_PORT"); v != "" {
+		port, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SMTP_PORT: %w", err)
+		}
+		config.Port = port
+	}
+	return notify.NewMailer(config)
+}
+
+// loadRateLimits reads the per-client rate limits: RATE_LIMIT_<CLASS>_RPS
+// and RATE_LIMIT_<CLASS>_BURST for the MARKET_DATA, TRADING and DEFAULT
+// route classes
+func loadRateLimits() (api.RateLimits, error) {
+	limits := api.DefaultRateLimits()
+
+	budgets := []struct {
+		name   string
+		budget *api.RateBudget
+	}{
+		{"MARKET_DATA", &limits.MarketData},
+		{"TRADING", &limits.Trading},
+		{"DEFAULT", &limits.Default},
+	}
+	for _, b := range budgets {
+		if v := os.Getenv("RATE_LIMIT_" + b.name + "_RPS"); v != "" {
+			value, err := strconv.ParseFloat(v, 64)
+			if err != nil || value < 0 {
+				return limits, fmt.Errorf("invalid RATE_LIMIT_%s_RPS: %s", b.name, v)
+			}
+			b.budget.Rate = value
+		}
+		if v := os.Getenv("RATE_LIMIT_" + b.name + "_BURST"); v != "" {
+			value, err := strconv.Atoi(v)
+			if err != nil || value < 1 {
+				return limits, fmt.Errorf("invalid RATE_LIMIT_%s_BURST: %s", b.name, v)
+			}
+			b.budget.Burst = value
+		}
+	}
+
+	return limits, nil
+}
+
+// loadRetentionConfig reads the data retention settings: RETENTION_CRON,
+// RETENTION_TICK_DAYS, RETENTION_MINUTE_BAR_MONTHS,
+// RETENTION_ROLLUP_TIMEFRAMES and RETENTION_DRY_RUN
+func loadRetentionConfig() (services.RetentionConfig, error) {
+	config := services.RetentionConfig{
+		Cron:   os.Getenv("RETENTION_CRON"),
+		DryRun: os.Getenv("RETENTION_DRY_RUN") == "true",
+	}
+
+	ints := []struct {
+		name string
+		dest *int
+	}{
+		{"RETENTION_TICK_DAYS", &config.TickDays},
+		{"RETENTION_MINUTE_BAR_MONTHS", &config.MinuteBarMonths},
+	}
+	for _, i := range ints {
+		v := os.Getenv(i.name)
+		if v == "" {
+			continue
+		}
+		value, err := strconv.Atoi(v)
+		if err != nil {
+			return config, fmt.Errorf("invalid %s: %w", i.name, err)
+		}
+		*i.dest = value
+	}
+
+	if v := os.Getenv("RETENTION_ROLLUP_TIMEFRAMES"); v != "" {
+		config.RollupTimeframes = []string{}
+		for _, tf := range strings.Split(v, ",") {
+			if tf = strings.TrimSpace(tf); tf != "" {
+				config.RollupTimeframes = append(config.RollupTimeframes, tf)
+			}
+		}
+	}
+
+	return config, nil
+}
+
+// loadResilienceConfig reads the broker retry and circuit breaker settings:
+// BROKER_MAX_RETRIES, BROKER_RETRY_BACKOFF, BROKER_MAX_RETRY_BACKOFF,
+// BROKER_CIRCUIT_FAILURE_THRESHOLD and BROKER_CIRCUIT_OPEN_TIMEOUT
+func loadResilienceConfig() (broker.ResilienceConfig, error) {
+	config := broker.DefaultResilienceConfig()
+
+	ints := []struct {
+		name string
+		dest *int
+	}{
+		{"BROKER_MAX_RETRIES", &config.MaxRetries},
+		{"BROKER_CIRCUIT_FAILURE_THRESHOLD", &config.FailureThreshold},
+	}
+	for _, i := range ints {
+		v := os.Getenv(i.name)
+		if v == "" {
+			continue
+		}
+		value, err := strconv.Atoi(v)
+		if err != nil || value < 0 {
+			return config, fmt.Errorf("invalid %s: %q", i.name, v)
+		}
+		*i.dest = value
+	}
+
+	durations := []struct {
+		name string
+		dest *time.Duration
+	}{
+		{"BROKER_RETRY_BACKOFF", &config.RetryBackoff},
+		{"BROKER_MAX_RETRY_BACKOFF", &config.MaxRetryBackoff},
+		{"BROKER_CIRCUIT_OPEN_TIMEOUT", &config.OpenTimeout},
+	}
+	for _, d := range durations {
+		v := os.Getenv(d.name)
+		if v == "" {
+			continue
+		}
+		value, err := time.ParseDuration(v)
+		if err != nil || value < 0 {
+			return config, fmt.Errorf("invalid %s: %q", d.name, v)
+		}
+		*d.dest = value
+	}
+
+	return config, nil
+}
+
+// loadHistoricalCacheConfig reads the historical candle cache settings:
+// HISTORICAL_CACHE_TTL, HISTORICAL_CACHE_REFETCH_DAYS,
+// HISTORICAL_CACHE_MAX_ROWS and HISTORICAL_CACHE_PRUNE_INTERVAL
+func loadHistoricalCacheConfig() (database.CachePolicy, error) {
+	policy := database.DefaultCachePolicy()
+
+	durations := []struct {
+		name string
+		dest *time.Duration
+	}{
+		{"HISTORICAL_CACHE_TTL", &policy.TTL},
+		{"HISTORICAL_CACHE_PRUNE_INTERVAL", &policy.PruneInterval},
+	}
+	for _, d := range durations {
+		v := os.Getenv(d.name)
+		if v == "" {
+			continue
+		}
+		value, err := time.ParseDuration(v)
+		if err != nil || value < 0 {
+			return policy, fmt.Errorf("invalid %s: %q", d.name, v)
+		}
+		*d.dest = value
+	}
+
+	if v := os.Getenv("HISTORICAL_CACHE_REFETCH_DAYS"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 0 {
+			return policy, fmt.Errorf("invalid HISTORICAL_CACHE_REFETCH_DAYS: %q", v)
+		}
+		policy.RefetchDays = days
+	}
+	if v := os.Getenv("HISTORICAL_CACHE_MAX_ROWS"); v != "" {
+		rows, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || rows < 0 {
+			return policy, fmt.Errorf("invalid HISTORICAL_CACHE_MAX_ROWS: %q", v)
+		}
+		policy.MaxRows = rows
+	}
+
+	return policy, nil
+}
+
+// loadSquareOffConfig reads the auto square-off settings: SQUARE_OFF_CRON,
+// SQUARE_OFF_STOP_LOSS_PCT, SQUARE_OFF_TRAILING_STOP_PCT and SQUARE_OFF_DRY_RUN
+func loadSquareOffConfig() (services.SquareOffConfig, error) {
+	config := services.SquareOffConfig{
+		Cron:   os.Getenv("SQUARE_OFF_CRON"),
+		DryRun: os.Getenv("SQUARE_OFF_DRY_RUN") == "true",
+	}
+
+	floats := []struct {
+		name string
+		dest *float64
+	}{
+		{"SQUARE_OFF_STOP_LOSS_PCT", &config.StopLossPct},
+		{"SQUARE_OFF_TRAILING_STOP_PCT", &config.TrailingStopPct},
+	}
+	for _, f := range floats {
+		v := os.Getenv(f.name)
+		if v == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return config, fmt.Errorf("invalid %s: %w", f.name, err)
+		}
+		*f.dest = value
+	}
+
+	return config, nil
 }
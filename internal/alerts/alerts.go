@@ -0,0 +1,178 @@
+// Package alerts evaluates user-defined price, indicator and pattern alerts
+// against the bars and ticks written by the collectors. Alerts move from
+// armed to triggered when their condition holds and stay triggered until
+// acknowledged; every transition is kept as history.
+package alerts
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/strategy"
+)
+
+// DefaultInterval is the bar interval used when an alert doesn't set one
+const DefaultInterval = "15minute"
+
+// PriceCondition compares the latest traded price with a level
+//
+//	{"op": "crosses_above", "value": 2500}
+type PriceCondition struct {
+	Op    string  `json:"op"`
+	Value float64 `json:"value"`
+}
+
+// PatternCondition matches a pattern completed on the latest bar, by name,
+// by signal, or both
+//
+//	{"pattern": "Bullish Engulfing", "min_confidence": 0.7}
+type PatternCondition struct {
+	Pattern       string  `json:"pattern,omitempty"`
+	Signal        string  `json:"signal,omitempty"` // bullish, bearish or neutral
+	MinConfidence float64 `json:"min_confidence,omitempty"`
+}
+
+// Indicator alerts use the strategy rule language, e.g.
+//
+//	{"left": {"indicator": "rsi", "period": 14}, "op": "<", "right": {"value": 30}}
+
+// Validate checks an alert and normalizes its fields and condition
+func Validate(alert *database.Alert) error {
+	alert.Name = strings.TrimSpace(alert.Name)
+	alert.Kind = strings.ToLower(alert.Kind)
+	alert.Exchange = strings.ToUpper(alert.Exchange)
+	alert.Symbol = strings.ToUpper(strings.TrimSpace(alert.Symbol))
+
+	if alert.Symbol == "" {
+		return fmt.Errorf("symbol is required")
+	}
+	if alert.Exchange == "" {
+		alert.Exchange = "NSE"
+	}
+	if alert.Interval == "" {
+		alert.Interval = DefaultInterval
+	}
+	interval, err := strategy.NormalizeInterval(alert.Interval)
+	if err != nil {
+		return err
+	}
+	alert.Interval = interval
+	if len(alert.Condition) == 0 {
+		return fmt.Errorf("condition is required")
+	}
+
+	var condition interface{}
+	switch alert.Kind {
+	case database.AlertKindPrice:
+		c, err := ParsePrice(alert.Condition)
+		if err != nil {
+			return err
+		}
+		condition = c
+	case database.AlertKindIndicator:
+		c, err := ParseIndicator(alert.Condition)
+		if err != nil {
+			return err
+		}
+		condition = c
+	case database.AlertKindPattern:
+		c, err := ParsePattern(alert.Condition)
+		if err != nil {
+			return err
+		}
+		condition = c
+	default:
+		return fmt.Errorf("invalid kind %q (use price, indicator or pattern)", alert.Kind)
+	}
+
+	normalized, err := json.Marshal(condition)
+	if err != nil {
+		return fmt.Errorf("invalid condition: %w", err)
+	}
+	alert.Condition = normalized
+
+	if alert.Name == "" {
+		alert.Name = fmt.Sprintf("%s %s", alert.Symbol, Describe(alert))
+	}
+	return nil
+}
+
+// ParsePrice decodes and validates a price condition
+func ParsePrice(data []byte) (*PriceCondition, error) {
+	var c PriceCondition
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("invalid price condition: %w", err)
+	}
+
+	switch c.Op {
+	case strategy.OpGreater, strategy.OpGreaterEqual, strategy.OpLess, strategy.OpLessEqual,
+		strategy.OpCrossesAbove, strategy.OpCrossesBelow:
+	default:
+		return nil, fmt.Errorf("invalid op %q", c.Op)
+	}
+	if c.Value <= 0 {
+		return nil, fmt.Errorf("price condition needs a positive value")
+	}
+	return &c, nil
+}
+
+// ParseIndicator decodes and validates an indicator condition
+func ParseIndicator(data []byte) (*strategy.Condition, error) {
+	var c strategy.Condition
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("invalid indicator condition: %w", err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ParsePattern decodes and validates a pattern condition
+func ParsePattern(data []byte) (*PatternCondition, error) {
+	var c PatternCondition
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("invalid pattern condition: %w", err)
+	}
+
+	c.Pattern = strings.TrimSpace(c.Pattern)
+	c.Signal = strings.ToLower(c.Signal)
+	if c.Pattern == "" && c.Signal == "" {
+		return nil, fmt.Errorf("pattern condition needs a pattern or a signal")
+	}
+	switch c.Signal {
+	case "", "bullish", "bearish", "neutral":
+	default:
+		return nil, fmt.Errorf("invalid signal %q (use bullish, bearish or neutral)", c.Signal)
+	}
+	if c.MinConfidence < 0 || c.MinConfidence > 1 {
+		return nil, fmt.Errorf("min_confidence must be between 0 and 1")
+	}
+	return &c, nil
+}
+
+// Describe renders an alert's condition, e.g. "price crosses_above 2500" or
+// "rsi(14) < 30 on 15minute"
+func Describe(alert *database.Alert) string {
+	switch alert.Kind {
+	case database.AlertKindPrice:
+		if c, err := ParsePrice(alert.Condition); err == nil {
+			return fmt.Sprintf("price %s %g", c.Op, c.Value)
+		}
+	case database.AlertKindIndicator:
+		if c, err := ParseIndicator(alert.Condition); err == nil {
+			return fmt.Sprintf("%s on %s", c, alert.Interval)
+		}
+	case database.AlertKindPattern:
+		if c, err := ParsePattern(alert.Condition); err == nil {
+			name := c.Pattern
+			if name == "" {
+				name = c.Signal + " pattern"
+			}
+			return fmt.Sprintf("%s on %s", name, alert.Interval)
+		}
+	}
+	return alert.Kind
+}
@@ -0,0 +1,328 @@
+package alerts
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/analyzer"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/strategy"
+	"github.com/trading-chitti/market-bridge/internal/timeframe"
+)
+
+// DefaultPollInterval is how often armed alerts are evaluated
+const DefaultPollInterval = 10 * time.Second
+
+// patternBars is the number of bars scanned for pattern alerts; chart
+// patterns need a few dozen bars of context
+const patternBars = 100
+
+// Monitor evaluates armed alerts on a fixed interval
+type Monitor struct {
+	db           *database.Database
+	pollInterval time.Duration
+	scanner      *analyzer.PatternScanner
+
+	mu        sync.Mutex
+	lastRun   *time.Time
+	errors    map[string]string
+	onTrigger func(alert database.Alert, message string)
+
+	done     chan bool
+	stopOnce sync.Once
+}
+
+// Status describes the monitor's last evaluation pass
+type Status struct {
+	PollInterval string            `json:"poll_interval"`
+	LastRunAt    *time.Time        `json:"last_run_at,omitempty"`
+	Errors       map[string]string `json:"errors,omitempty"`
+}
+
+// NewMonitor creates an alert monitor
+func NewMonitor(db *database.Database, pollInterval time.Duration) *Monitor {
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+
+	return &Monitor{
+		db:           db,
+		pollInterval: pollInterval,
+		scanner:      analyzer.NewPatternScanner(),
+		errors:       make(map[string]string),
+		done:         make(chan bool),
+	}
+}
+
+// SetTriggerHandler sets a callback invoked after an alert triggers, e.g.
+// to push notifications
+func (m *Monitor) SetTriggerHandler(fn func(alert database.Alert, message string)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onTrigger = fn
+}
+
+// Start begins evaluating alerts in the background
+func (m *Monitor) Start() {
+	go m.run()
+	log.Printf("🔔 Alert monitor started (every %s)", m.pollInterval)
+}
+
+// Stop stops the monitor
+func (m *Monitor) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.done)
+		log.Println("🛑 Alert monitor stopped")
+	})
+}
+
+// Status returns the outcome of the last evaluation pass
+func (m *Monitor) Status() Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := Status{
+		PollInterval: m.pollInterval.String(),
+		LastRunAt:    m.lastRun,
+		Errors:       make(map[string]string, len(m.errors)),
+	}
+	for k, v := range m.errors {
+		status.Errors[k] = v
+	}
+	return status
+}
+
+func (m *Monitor) run() {
+	ticker := time.NewTicker(m.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.Evaluate()
+		case <-m.done:
+			return
+		}
+	}
+}
+
+// Evaluate checks every armed alert once and triggers those whose condition
+// holds. Candles are loaded once per symbol and interval.
+func (m *Monitor) Evaluate() {
+	armed, err := m.db.GetArmedAlerts()
+	if err != nil {
+		log.Printf("❌ Failed to load alerts: %v", err)
+		return
+	}
+
+	cache := make(map[string][]broker.Candle)
+	errs := make(map[string]string)
+
+	for i := range armed {
+		alert := &armed[i]
+		if err := m.evaluate(alert, cache); err != nil {
+			errs[alert.AlertID] = err.Error()
+		}
+	}
+
+	now := time.Now()
+	m.mu.Lock()
+	m.lastRun = &now
+	m.errors = errs
+	m.mu.Unlock()
+}
+
+// evaluate checks one alert, records its progress and triggers it
+func (m *Monitor) evaluate(alert *database.Alert, cache map[string][]broker.Candle) error {
+	var (
+		holds bool
+		value float64
+		bar   *time.Time
+		desc  string
+		err   error
+	)
+
+	switch alert.Kind {
+	case database.AlertKindPrice:
+		holds, value, desc, err = m.evaluatePrice(alert)
+	case database.AlertKindIndicator:
+		holds, value, bar, desc, err = m.evaluateIndicator(alert, cache)
+	case database.AlertKindPattern:
+		holds, value, bar, desc, err = m.evaluatePattern(alert, cache)
+	default:
+		err = fmt.Errorf("unknown alert kind %q", alert.Kind)
+	}
+	if err != nil || (bar == nil && alert.Kind != database.AlertKindPrice) {
+		return err
+	}
+
+	var last *float64
+	if !math.IsNaN(value) {
+		last = &value
+	}
+
+	if !holds {
+		changed := last != nil && (alert.LastValue == nil || *alert.LastValue != *last)
+		if changed || bar != nil {
+			return m.db.UpdateAlertProgress(alert.AlertID, last, bar)
+		}
+		return nil
+	}
+
+	if bar != nil {
+		if err := m.db.UpdateAlertProgress(alert.AlertID, nil, bar); err != nil {
+			return err
+		}
+	}
+
+	message := fmt.Sprintf("%s:%s %s", alert.Exchange, alert.Symbol, desc)
+	triggered, err := m.db.SetAlertState(alert.AlertID, []string{database.AlertArmed}, database.AlertTriggered, last, message)
+	if err != nil {
+		if err == database.ErrAlertState {
+			// Acknowledged or re-armed concurrently
+			return nil
+		}
+		return err
+	}
+
+	log.Printf("🔔 Alert %q triggered: %s", alert.Name, message)
+
+	m.mu.Lock()
+	onTrigger := m.onTrigger
+	m.mu.Unlock()
+	if onTrigger != nil {
+		onTrigger(*triggered, message)
+	}
+	return nil
+}
+
+// evaluatePrice compares the latest price with the level. Crossings compare
+// against the price seen on the previous pass.
+func (m *Monitor) evaluatePrice(alert *database.Alert) (bool, float64, string, error) {
+	c, err := ParsePrice(alert.Condition)
+	if err != nil {
+		return false, math.NaN(), "", err
+	}
+
+	price, err := m.db.LatestPrice(alert.Exchange, alert.Symbol)
+	if err != nil {
+		return false, math.NaN(), "", err
+	}
+
+	desc := fmt.Sprintf("price %.2f %s %g", price, c.Op, c.Value)
+	switch c.Op {
+	case strategy.OpGreater:
+		return price > c.Value, price, desc, nil
+	case strategy.OpGreaterEqual:
+		return price >= c.Value, price, desc, nil
+	case strategy.OpLess:
+		return price < c.Value, price, desc, nil
+	case strategy.OpLessEqual:
+		return price <= c.Value, price, desc, nil
+	case strategy.OpCrossesAbove:
+		return alert.LastValue != nil && *alert.LastValue <= c.Value && price > c.Value, price, desc, nil
+	case strategy.OpCrossesBelow:
+		return alert.LastValue != nil && *alert.LastValue >= c.Value && price < c.Value, price, desc, nil
+	}
+	return false, price, desc, nil
+}
+
+// evaluateIndicator evaluates the condition on the latest completed bar.
+// Each bar is evaluated once, so a re-armed alert waits for the next bar.
+func (m *Monitor) evaluateIndicator(alert *database.Alert, cache map[string][]broker.Candle) (bool, float64, *time.Time, string, error) {
+	c, err := ParseIndicator(alert.Condition)
+	if err != nil {
+		return false, math.NaN(), nil, "", err
+	}
+
+	candles, err := m.candles(alert, c.Warmup()+1, cache)
+	if err != nil {
+		return false, math.NaN(), nil, "", err
+	}
+	bar := candles[len(candles)-1].Date
+	if alert.LastBar != nil && !bar.After(*alert.LastBar) {
+		return false, math.NaN(), nil, "", nil
+	}
+
+	holds, value := strategy.Holds(*c, candles)
+	desc := fmt.Sprintf("%s on %s (%.2f)", c, alert.Interval, value)
+	return holds, value, &bar, desc, nil
+}
+
+// evaluatePattern scans recent bars for a matching pattern completed on the
+// latest bar
+func (m *Monitor) evaluatePattern(alert *database.Alert, cache map[string][]broker.Candle) (bool, float64, *time.Time, string, error) {
+	c, err := ParsePattern(alert.Condition)
+	if err != nil {
+		return false, math.NaN(), nil, "", err
+	}
+
+	candles, err := m.candles(alert, patternBars, cache)
+	if err != nil {
+		return false, math.NaN(), nil, "", err
+	}
+	last := len(candles) - 1
+	bar := candles[last].Date
+	if alert.LastBar != nil && !bar.After(*alert.LastBar) {
+		return false, math.NaN(), nil, "", nil
+	}
+
+	for _, p := range m.scanner.ScanAllPatterns(candles) {
+		if p.EndIndex != last || p.Confidence < c.MinConfidence {
+			continue
+		}
+		if c.Pattern != "" && !strings.EqualFold(p.Type, c.Pattern) {
+			continue
+		}
+		if c.Signal != "" && p.Signal != c.Signal {
+			continue
+		}
+		desc := fmt.Sprintf("%s on %s (confidence %.0f%%)", p.Type, alert.Interval, p.Confidence*100)
+		return true, candles[last].Close, &bar, desc, nil
+	}
+
+	return false, candles[last].Close, &bar, "", nil
+}
+
+// candles returns at least bars of the most recent collector bars for the
+// alert's exchange, symbol and interval, sharing loads across alerts in one pass
+func (m *Monitor) candles(alert *database.Alert, bars int, cache map[string][]broker.Candle) ([]broker.Candle, error) {
+	tf, err := timeframe.ParseStored(alert.Interval)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported interval: %w", err)
+	}
+
+	key := alert.Exchange + ":" + alert.Symbol + "|" + tf.String()
+	if cached, ok := cache[key]; ok && len(cached) >= bars {
+		return cached[len(cached)-bars:], nil
+	}
+
+	stored, err := m.db.GetRecentIntradayBars(alert.Exchange, alert.Symbol, tf.String(), bars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bars: %w", err)
+	}
+	if len(stored) < 2 {
+		return nil, fmt.Errorf("waiting for data: %d %s bars", len(stored), tf)
+	}
+
+	candles := make([]broker.Candle, len(stored))
+	for i, b := range stored {
+		candles[i] = broker.Candle{
+			Date:   b.BarTimestamp,
+			Open:   b.Open,
+			High:   b.High,
+			Low:    b.Low,
+			Close:  b.Close,
+			Volume: b.Volume,
+		}
+	}
+	if cached, ok := cache[key]; !ok || len(candles) > len(cached) {
+		cache[key] = candles
+	}
+	return candles, nil
+}
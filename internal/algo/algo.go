@@ -0,0 +1,668 @@
+// Package algo works large orders as child slices over time (TWAP) or one
+// visible slice at a time (iceberg), chasing the price of limit slices
+// within the parent's limit, and reports their fills under the parent
+// execution
+package algo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// Execution algos
+const (
+	TWAP    = "twap"    // Equal slices at equal intervals over a duration
+	Iceberg = "iceberg" // One slice of the display quantity at a time
+)
+
+// Execution states
+const (
+	StatusRunning    = "running"
+	StatusCancelling = "cancelling" // Stopping, until the broker confirms the open slices are done
+	StatusComplete   = "complete"   // The whole quantity filled
+	StatusCancelled  = "cancelled"  // Cancelled by the user, open slices cancelled
+	StatusFailed     = "failed"     // A slice was rejected, open slices cancelled
+)
+
+// MaxSlices is the most slices a TWAP may be split into
+const MaxSlices = 100
+
+// DefaultPollInterval is how often executions are stepped by default
+const DefaultPollInterval = 2 * time.Second
+
+// Errors returned by the manager
+var (
+	ErrInvalidParams = errors.New("invalid algo order")
+	ErrNotFound      = errors.New("execution not found")
+	ErrFinished      = errors.New("execution already finished")
+)
+
+// Params choose how an order is sliced
+type Params struct {
+	Algo            string `json:"algo"`             // twap or iceberg
+	Slices          int    `json:"slices"`           // TWAP: number of slices
+	DurationSeconds int    `json:"duration_seconds"` // TWAP: time the slices are spread over
+	DisplayQuantity int    `json:"display_quantity"` // Iceberg: quantity of each slice
+	RepriceSeconds  int    `json:"reprice_seconds"`  // Limit slices open this long move to the last price, within the limit; 0 never
+	LotSize         int    `json:"lot_size"`         // Slices are whole lots; 1 if 0
+}
+
+// Request is a parent order and how to slice it. LIMIT parents are worked
+// with limit slices at the last price, never beyond the parent's price;
+// MARKET parents with market slices.
+type Request struct {
+	broker.OrderRequest
+	Params
+}
+
+// Child is a slice placed with the broker
+type Child struct {
+	OrderID        string    `json:"order_id"`
+	Quantity       int       `json:"quantity"`
+	Price          float64   `json:"price,omitempty"`
+	Status         string    `json:"status"`
+	FilledQuantity int       `json:"filled_quantity"`
+	AveragePrice   float64   `json:"average_price"`
+	Modifications  int       `json:"modifications"`
+	PlacedAt       time.Time `json:"placed_at"`
+
+	pricedAt   time.Time // When placed or last repriced
+	cancelling bool      // Cancel sent, waiting for the broker to confirm
+}
+
+// Execution is a parent order and its slices, with their consolidated fills
+type Execution struct {
+	ID              string              `json:"execution_id"`
+	UserID          string              `json:"user_id,omitempty"`
+	Order           broker.OrderRequest `json:"order"`
+	Params          Params              `json:"params"`
+	Status          string              `json:"status"`
+	FilledQuantity  int                 `json:"filled_quantity"`
+	OpenQuantity    int                 `json:"open_quantity"`    // In open slices
+	PendingQuantity int                 `json:"pending_quantity"` // Not sliced yet
+	AveragePrice    float64             `json:"average_price"`
+	Children        []Child             `json:"children"`
+	NextSliceAt     *time.Time          `json:"next_slice_at,omitempty"` // TWAP
+	Error           string              `json:"error,omitempty"`
+	StartedAt       time.Time           `json:"started_at"`
+	UpdatedAt       time.Time           `json:"updated_at"`
+	FinishedAt      *time.Time          `json:"finished_at,omitempty"`
+}
+
+// execution is a running execution and the broker it trades through
+type execution struct {
+	mu        sync.Mutex
+	state     Execution
+	broker    broker.Broker
+	placed    int    // Scheduled slices placed
+	cancelled bool   // Cancel requested
+	outcome   string // Status once stopped: cancelled or failed
+}
+
+// Manager runs executions, stepping each every poll interval: it reads
+// the slices' fills, reprices and places slices, and finishes executions.
+// Executions are kept in memory, finished ones for a day; the slices
+// themselves are journaled like any order, tagged with the execution ID.
+type Manager struct {
+	interval time.Duration
+	now      func() time.Time
+
+	mu         sync.Mutex
+	executions map[string]*execution
+
+	cancel context.CancelFunc
+	done   chan bool
+}
+
+// finishedRetention is how long finished executions are kept
+const finishedRetention = 24 * time.Hour
+
+// NewManager creates a manager stepping executions every interval
+func NewManager(interval time.Duration) *Manager {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	return &Manager{
+		interval:   interval,
+		now:        time.Now,
+		executions: make(map[string]*execution),
+		done:       make(chan bool),
+	}
+}
+
+// Start begins stepping executions
+func (m *Manager) Start() {
+	log.Printf("🔄 Starting algo executions (poll: %v)", m.interval)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+
+	go func() {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				m.Poll()
+			case <-ctx.Done():
+				m.done <- true
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops stepping executions. Their open slices stay with the broker.
+func (m *Manager) Stop() {
+	if m.cancel != nil {
+		m.cancel()
+		<-m.done
+	}
+}
+
+// Validate normalizes a request and checks its parameters
+func Validate(req *Request) error {
+	req.Exchange = strings.ToUpper(req.Exchange)
+	if req.Exchange == "" {
+		req.Exchange = "NSE"
+	}
+	req.Symbol = strings.ToUpper(req.Symbol)
+	req.TransactionType = strings.ToUpper(req.TransactionType)
+	req.Product = strings.ToUpper(req.Product)
+	req.OrderType = strings.ToUpper(req.OrderType)
+	if req.OrderType == "" {
+		req.OrderType = "MARKET"
+	}
+	req.Algo = strings.ToLower(req.Algo)
+	if req.LotSize <= 0 {
+		req.LotSize = 1
+	}
+
+	switch {
+	case req.Symbol == "":
+		return fmt.Errorf("%w: symbol is required", ErrInvalidParams)
+	case req.TransactionType != "BUY" && req.TransactionType != "SELL":
+		return fmt.Errorf("%w: transaction_type must be BUY or SELL", ErrInvalidParams)
+	case req.Product == "":
+		return fmt.Errorf("%w: product is required", ErrInvalidParams)
+	case req.OrderType != "MARKET" && req.OrderType != "LIMIT":
+		return fmt.Errorf("%w: order_type must be MARKET or LIMIT", ErrInvalidParams)
+	case req.OrderType == "LIMIT" && req.Price <= 0:
+		return fmt.Errorf("%w: LIMIT orders need a price", ErrInvalidParams)
+	case req.HasExits():
+		return fmt.Errorf("%w: algo orders can't carry a stop loss or target", ErrInvalidParams)
+	case req.Quantity <= 0 || req.Quantity%req.LotSize != 0:
+		return fmt.Errorf("%w: quantity must be a positive multiple of the lot size %d", ErrInvalidParams, req.LotSize)
+	case req.RepriceSeconds < 0:
+		return fmt.Errorf("%w: reprice_seconds cannot be negative", ErrInvalidParams)
+	}
+
+	lots := req.Quantity / req.LotSize
+	switch req.Algo {
+	case TWAP:
+		if req.Slices < 2 || req.Slices > MaxSlices || req.Slices > lots {
+			return fmt.Errorf("%w: slices must be 2 to %d and at most the %d lots ordered", ErrInvalidParams, MaxSlices, lots)
+		}
+		if req.DurationSeconds <= 0 {
+			return fmt.Errorf("%w: duration_seconds must be positive", ErrInvalidParams)
+		}
+	case Iceberg:
+		if req.DisplayQuantity <= 0 || req.DisplayQuantity >= req.Quantity || req.DisplayQuantity%req.LotSize != 0 {
+			return fmt.Errorf("%w: display_quantity must be whole lots below the quantity", ErrInvalidParams)
+		}
+	default:
+		return fmt.Errorf("%w: algo must be twap or iceberg", ErrInvalidParams)
+	}
+	return nil
+}
+
+// Submit validates a request and starts its execution through brk,
+// placing the first slice now. An execution whose first slice fails isn't
+// kept.
+func (m *Manager) Submit(brk broker.Broker, userID string, req Request) (*Execution, error) {
+	if err := Validate(&req); err != nil {
+		return nil, err
+	}
+	id, err := newExecutionID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.now()
+	e := &execution{
+		broker: brk,
+		state: Execution{
+			ID:              id,
+			UserID:          userID,
+			Order:           req.OrderRequest,
+			Params:          req.Params,
+			Status:          StatusRunning,
+			PendingQuantity: req.Quantity,
+			Children:        []Child{},
+			StartedAt:       now,
+			UpdatedAt:       now,
+		},
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	m.step(e, now)
+	if e.state.Status == StatusFailed {
+		return nil, errors.New(e.state.Error)
+	}
+
+	m.mu.Lock()
+	m.executions[id] = e
+	m.mu.Unlock()
+	log.Printf("🧩 Execution %s: %s %s %d %s:%s", id, req.Algo, req.TransactionType, req.Quantity, req.Exchange, req.Symbol)
+
+	snapshot := e.snapshot()
+	return &snapshot, nil
+}
+
+// Get returns an execution
+func (m *Manager) Get(id string) (*Execution, error) {
+	e, err := m.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	snapshot := e.snapshot()
+	return &snapshot, nil
+}
+
+// List returns a user's executions, all when userID is empty, newest first
+func (m *Manager) List(userID string) []Execution {
+	m.mu.Lock()
+	executions := make([]*execution, 0, len(m.executions))
+	for _, e := range m.executions {
+		executions = append(executions, e)
+	}
+	m.mu.Unlock()
+
+	result := make([]Execution, 0, len(executions))
+	for _, e := range executions {
+		e.mu.Lock()
+		if userID == "" || e.state.UserID == userID {
+			result = append(result, e.snapshot())
+		}
+		e.mu.Unlock()
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].StartedAt.After(result[j].StartedAt)
+	})
+	return result
+}
+
+// Cancel stops an execution, cancelling its open slices now. It is
+// cancelling until the broker confirms the slices are done, so fills made
+// meanwhile are counted. Fills so far stand.
+func (m *Manager) Cancel(id string) (*Execution, error) {
+	e, err := m.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.state.Status != StatusRunning {
+		return nil, ErrFinished
+	}
+	e.cancelled = true
+	m.step(e, m.now())
+	snapshot := e.snapshot()
+	return &snapshot, nil
+}
+
+// Poll steps every running or cancelling execution and forgets executions finished more
+// than a day ago
+func (m *Manager) Poll() {
+	now := m.now()
+
+	m.mu.Lock()
+	executions := make([]*execution, 0, len(m.executions))
+	for id, e := range m.executions {
+		e.mu.Lock()
+		finished := e.state.FinishedAt
+		e.mu.Unlock()
+		if finished != nil && now.Sub(*finished) > finishedRetention {
+			delete(m.executions, id)
+			continue
+		}
+		executions = append(executions, e)
+	}
+	m.mu.Unlock()
+
+	for _, e := range executions {
+		e.mu.Lock()
+		if e.state.Status == StatusRunning || e.state.Status == StatusCancelling {
+			m.step(e, now)
+		}
+		e.mu.Unlock()
+	}
+}
+
+func (m *Manager) lookup(id string) (*execution, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.executions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return e, nil
+}
+
+// step moves an execution on: it refreshes the slices from the broker's
+// orders, finishes the execution when filled, stops it when rejected or
+// cancelled, reprices stale limit slices and places the next slice when
+// due. A new slice, like the end of a stopped execution, waits for the
+// slices cancelled before it to be confirmed, so their last fills are
+// counted. Callers hold e.mu.
+func (m *Manager) step(e *execution, now time.Time) {
+	s := &e.state
+	defer func() { s.UpdatedAt = now }()
+
+	if hasOpen(s.Children) {
+		if err := e.refresh(); err != nil {
+			log.Printf("⚠️  Execution %s: failed to read orders: %v", s.ID, err)
+			return
+		}
+	}
+	e.total()
+
+	if s.Status == StatusCancelling {
+		e.settle(now)
+		return
+	}
+	for _, child := range s.Children {
+		if child.Status == broker.OrderStatusRejected {
+			e.stop(StatusFailed, "slice "+child.OrderID+" was rejected", now)
+			return
+		}
+	}
+	if e.cancelled {
+		e.stop(StatusCancelled, "", now)
+		return
+	}
+	if s.FilledQuantity >= s.Order.Quantity {
+		e.finish(StatusComplete, "", now)
+		return
+	}
+
+	e.reprice(now)
+
+	due, quantity := e.nextSlice(now)
+	if !due || quantity <= 0 {
+		return
+	}
+	if hasOpen(s.Children) {
+		// TWAP: the unfilled rest of the last slice goes into the next one
+		e.cancelOpen()
+		return
+	}
+	e.place(quantity, now)
+}
+
+// refresh reads the status and fills of the open slices
+func (e *execution) refresh() error {
+	orders, err := e.broker.GetOrders()
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]broker.Order, len(orders))
+	for _, o := range orders {
+		byID[o.OrderID] = o
+	}
+
+	for i := range e.state.Children {
+		child := &e.state.Children[i]
+		o, ok := byID[child.OrderID]
+		if !ok || terminal(child.Status) {
+			continue
+		}
+		child.Status = o.Status
+		child.FilledQuantity = o.FilledQuantity
+		child.AveragePrice = o.AveragePrice
+		if terminal(child.Status) {
+			child.cancelling = false
+		}
+	}
+	return nil
+}
+
+// total sums the slices' fills
+func (e *execution) total() {
+	s := &e.state
+	filled, open := 0, 0
+	var value float64
+	for _, child := range s.Children {
+		filled += child.FilledQuantity
+		value += float64(child.FilledQuantity) * child.AveragePrice
+		if !terminal(child.Status) {
+			open += child.Quantity - child.FilledQuantity
+		}
+	}
+	s.FilledQuantity = filled
+	s.OpenQuantity = open
+	s.PendingQuantity = s.Order.Quantity - filled - open
+	s.AveragePrice = 0
+	if filled > 0 {
+		s.AveragePrice = math.Round(value/float64(filled)*100) / 100
+	}
+}
+
+// nextSlice reports whether a slice is due and its quantity. TWAP slices
+// are due on schedule, the rest of the order once the schedule is over;
+// iceberg slices once the last one is done.
+func (e *execution) nextSlice(now time.Time) (bool, int) {
+	s := &e.state
+	lot := s.Params.LotSize
+	s.NextSliceAt = nil
+
+	if s.Params.Algo == Iceberg {
+		if hasOpen(s.Children) {
+			return false, 0
+		}
+		return true, min(s.Params.DisplayQuantity, s.PendingQuantity)
+	}
+
+	if e.placed >= s.Params.Slices {
+		// Slices cancelled outside the execution leave quantity to place
+		return !hasOpen(s.Children), s.PendingQuantity
+	}
+	interval := time.Duration(s.Params.DurationSeconds) * time.Second / time.Duration(s.Params.Slices)
+	dueAt := s.StartedAt.Add(time.Duration(e.placed) * interval)
+	if now.Before(dueAt) {
+		s.NextSliceAt = &dueAt
+		return false, 0
+	}
+
+	// Whole lots of what's left, spread over the slices left; the open
+	// slice about to be cancelled counts as left
+	left := s.Params.Slices - e.placed
+	lots := (s.PendingQuantity + s.OpenQuantity) / lot
+	return true, int(math.Ceil(float64(lots)/float64(left))) * lot
+}
+
+// place places a slice of quantity
+func (e *execution) place(quantity int, now time.Time) {
+	s := &e.state
+	order := s.Order
+	order.Quantity = quantity
+	order.Tag = s.ID
+
+	if order.OrderType == "LIMIT" {
+		price, err := e.slicePrice()
+		if err != nil {
+			log.Printf("⚠️  Execution %s: %v", s.ID, err)
+			return
+		}
+		order.Price = price
+	}
+
+	orderID, err := e.broker.PlaceOrder(&order)
+	if err != nil {
+		if broker.IsTransient(err) {
+			log.Printf("⚠️  Execution %s: slice not placed, retrying: %v", s.ID, err)
+			return
+		}
+		e.stop(StatusFailed, "slice not placed: "+err.Error(), now)
+		return
+	}
+
+	s.Children = append(s.Children, Child{
+		OrderID:  orderID,
+		Quantity: quantity,
+		Price:    order.Price,
+		Status:   broker.OrderStatusOpen,
+		PlacedAt: now,
+		pricedAt: now,
+	})
+	e.placed++
+	e.total()
+}
+
+// reprice moves limit slices open longer than RepriceSeconds to the last
+// price, within the parent's limit
+func (e *execution) reprice(now time.Time) {
+	s := &e.state
+	if s.Order.OrderType != "LIMIT" || s.Params.RepriceSeconds <= 0 {
+		return
+	}
+	after := time.Duration(s.Params.RepriceSeconds) * time.Second
+
+	for i := range s.Children {
+		child := &s.Children[i]
+		if terminal(child.Status) || child.cancelling || now.Sub(child.pricedAt) < after {
+			continue
+		}
+		price, err := e.slicePrice()
+		if err != nil {
+			log.Printf("⚠️  Execution %s: %v", s.ID, err)
+			return
+		}
+		child.pricedAt = now
+		if price == child.Price {
+			continue
+		}
+		if _, err := e.broker.ModifyOrder(child.OrderID, &broker.OrderModify{Price: &price}); err != nil {
+			log.Printf("⚠️  Execution %s: failed to reprice %s: %v", s.ID, child.OrderID, err)
+			continue
+		}
+		child.Price = price
+		child.Modifications++
+	}
+}
+
+// slicePrice is the last price, no worse than the parent's limit
+func (e *execution) slicePrice() (float64, error) {
+	s := &e.state
+	key := s.Order.Exchange + ":" + s.Order.Symbol
+	prices, err := e.broker.GetLTP([]string{key})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get price for %s: %w", key, err)
+	}
+	ltp := prices[key]
+	if ltp <= 0 {
+		return s.Order.Price, nil
+	}
+	if s.Order.TransactionType == "BUY" {
+		return broker.RoundTick(math.Min(ltp, s.Order.Price)), nil
+	}
+	return broker.RoundTick(math.Max(ltp, s.Order.Price)), nil
+}
+
+// cancelOpen cancels the open slices; their status is confirmed by the
+// next refresh
+func (e *execution) cancelOpen() {
+	for i := range e.state.Children {
+		child := &e.state.Children[i]
+		if terminal(child.Status) || child.cancelling {
+			continue
+		}
+		if _, err := e.broker.CancelOrder(child.OrderID); err != nil {
+			log.Printf("⚠️  Execution %s: failed to cancel %s: %v", e.state.ID, child.OrderID, err)
+			continue
+		}
+		child.cancelling = true
+	}
+}
+
+// stop cancels the open slices of the execution, which ends with outcome
+// once they are done
+func (e *execution) stop(outcome, reason string, now time.Time) {
+	e.state.Status = StatusCancelling
+	e.state.Error = reason
+	e.state.NextSliceAt = nil
+	e.outcome = outcome
+	e.settle(now)
+}
+
+// settle cancels the open slices of a stopping execution, finishing it once
+// none is open
+func (e *execution) settle(now time.Time) {
+	if hasOpen(e.state.Children) {
+		e.cancelOpen()
+		return
+	}
+	e.finish(e.outcome, e.state.Error, now)
+}
+
+// finish ends the execution
+func (e *execution) finish(status, reason string, now time.Time) {
+	s := &e.state
+	s.Status = status
+	s.Error = reason
+	s.NextSliceAt = nil
+	s.FinishedAt = &now
+	log.Printf("🧩 Execution %s %s: %d/%d filled at %.2f %s", s.ID, status, s.FilledQuantity, s.Order.Quantity, s.AveragePrice, reason)
+}
+
+// snapshot copies the execution's state. Callers hold e.mu.
+func (e *execution) snapshot() Execution {
+	snapshot := e.state
+	snapshot.Children = append([]Child(nil), e.state.Children...)
+	return snapshot
+}
+
+// hasOpen reports whether any slice is still working
+func hasOpen(children []Child) bool {
+	for _, child := range children {
+		if !terminal(child.Status) {
+			return true
+		}
+	}
+	return false
+}
+
+// terminal reports whether an order status is final
+func terminal(status string) bool {
+	switch status {
+	case broker.OrderStatusComplete, broker.OrderStatusCancelled, broker.OrderStatusRejected:
+		return true
+	}
+	return false
+}
+
+// newExecutionID returns a random execution ID, short enough to tag orders
+// with
+func newExecutionID() (string, error) {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "algo-" + hex.EncodeToString(buf), nil
+}
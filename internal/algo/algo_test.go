@@ -0,0 +1,433 @@
+package algo
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// fakeBroker keeps the orders placed through it, open until the test fills
+// them, and quotes ltp. Cancels take effect at once unless holdCancels is
+// set. Other Broker methods panic through the nil embedded interface.
+type fakeBroker struct {
+	broker.Broker
+	ltp         float64
+	placeErr    error
+	holdCancels bool
+	orders      []broker.Order
+	tags        []string
+	modified    map[string]float64
+	cancelled   []string
+}
+
+func (f *fakeBroker) PlaceOrder(order *broker.OrderRequest) (string, error) {
+	if f.placeErr != nil {
+		return "", f.placeErr
+	}
+	id := strconv.Itoa(len(f.orders) + 1)
+	f.orders = append(f.orders, broker.Order{
+		OrderID:  id,
+		Symbol:   order.Symbol,
+		Quantity: order.Quantity,
+		Price:    order.Price,
+		Status:   broker.OrderStatusOpen,
+	})
+	f.tags = append(f.tags, order.Tag)
+	return id, nil
+}
+
+func (f *fakeBroker) ModifyOrder(orderID string, order *broker.OrderModify) (string, error) {
+	if f.modified == nil {
+		f.modified = make(map[string]float64)
+	}
+	f.modified[orderID] = *order.Price
+	return orderID, nil
+}
+
+func (f *fakeBroker) CancelOrder(orderID string) (string, error) {
+	f.cancelled = append(f.cancelled, orderID)
+	if !f.holdCancels {
+		f.order(orderID).Status = broker.OrderStatusCancelled
+	}
+	return orderID, nil
+}
+
+func (f *fakeBroker) GetOrders() ([]broker.Order, error) {
+	return append([]broker.Order(nil), f.orders...), nil
+}
+
+func (f *fakeBroker) GetLTP(symbols []string) (map[string]float64, error) {
+	return map[string]float64{symbols[0]: f.ltp}, nil
+}
+
+func (f *fakeBroker) order(id string) *broker.Order {
+	for i := range f.orders {
+		if f.orders[i].OrderID == id {
+			return &f.orders[i]
+		}
+	}
+	return nil
+}
+
+// fill fills quantity of an order at price
+func (f *fakeBroker) fill(id string, quantity int, price float64) {
+	o := f.order(id)
+	value := o.AveragePrice*float64(o.FilledQuantity) + price*float64(quantity)
+	o.FilledQuantity += quantity
+	o.AveragePrice = value / float64(o.FilledQuantity)
+	if o.FilledQuantity == o.Quantity {
+		o.Status = broker.OrderStatusComplete
+	}
+}
+
+// newTestManager returns a manager whose clock the test moves
+func newTestManager(now *time.Time) *Manager {
+	m := NewManager(time.Second)
+	m.now = func() time.Time { return *now }
+	return m
+}
+
+func request(quantity int, params Params) Request {
+	return Request{
+		OrderRequest: broker.OrderRequest{Symbol: "infy", TransactionType: "buy", Product: "MIS", Quantity: quantity},
+		Params:       params,
+	}
+}
+
+func TestValidate(t *testing.T) {
+	twap := Params{Algo: "TWAP", Slices: 4, DurationSeconds: 60}
+	tests := []struct {
+		name    string
+		req     Request
+		wantErr bool
+	}{
+		{"twap", request(100, twap), false},
+		{"iceberg", request(100, Params{Algo: Iceberg, DisplayQuantity: 10}), false},
+		{"unknown algo", request(100, Params{Algo: "vwap"}), true},
+		{"one slice", request(100, Params{Algo: TWAP, Slices: 1, DurationSeconds: 60}), true},
+		{"more slices than lots", request(150, Params{Algo: TWAP, Slices: 3, DurationSeconds: 60, LotSize: 75}), true},
+		{"no duration", request(100, Params{Algo: TWAP, Slices: 4}), true},
+		{"display quantity too big", request(100, Params{Algo: Iceberg, DisplayQuantity: 100}), true},
+		{"display quantity not whole lots", request(150, Params{Algo: Iceberg, DisplayQuantity: 50, LotSize: 75}), true},
+		{"quantity not whole lots", request(100, Params{Algo: TWAP, Slices: 2, DurationSeconds: 60, LotSize: 75}), true},
+		{"stop order", func() Request { r := request(100, twap); r.OrderType = "SL"; return r }(), true},
+		{"limit without price", func() Request { r := request(100, twap); r.OrderType = "LIMIT"; return r }(), true},
+		{"exits", func() Request { r := request(100, twap); r.StopLoss = 90; return r }(), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(&tt.req)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidParams) {
+					t.Errorf("Validate = %v, want ErrInvalidParams", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Validate = %v", err)
+			}
+			if tt.req.Symbol != "INFY" || tt.req.Exchange != "NSE" || tt.req.OrderType != "MARKET" || tt.req.LotSize != 1 {
+				t.Errorf("request not normalized: %+v", tt.req)
+			}
+		})
+	}
+}
+
+func TestTWAP(t *testing.T) {
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	m := newTestManager(&now)
+	brk := &fakeBroker{}
+
+	exec, err := m.Submit(brk, "u1", request(100, Params{Algo: TWAP, Slices: 4, DurationSeconds: 60}))
+	if err != nil {
+		t.Fatalf("Submit = %v", err)
+	}
+	if len(brk.orders) != 1 || brk.orders[0].Quantity != 25 || brk.tags[0] != exec.ID {
+		t.Fatalf("first slice = %+v tags %v, want 25 tagged %s", brk.orders, brk.tags, exec.ID)
+	}
+
+	// Not due yet
+	now = now.Add(10 * time.Second)
+	m.Poll()
+	if len(brk.orders) != 1 {
+		t.Fatalf("slices placed early: %+v", brk.orders)
+	}
+
+	// Due with the first slice partly filled: it's cancelled first, then
+	// its rest goes into the next slice
+	brk.fill("1", 10, 100)
+	now = now.Add(5 * time.Second)
+	m.Poll()
+	if len(brk.cancelled) != 1 || len(brk.orders) != 1 {
+		t.Fatalf("cancelled %v, orders %+v; want the open slice cancelled and no new slice", brk.cancelled, brk.orders)
+	}
+	m.Poll()
+	if len(brk.orders) != 2 || brk.orders[1].Quantity != 30 {
+		t.Fatalf("second slice = %+v, want 30", brk.orders)
+	}
+
+	brk.fill("2", 30, 102)
+	for _, step := range []time.Duration{15 * time.Second, 15 * time.Second} {
+		now = now.Add(step)
+		m.Poll()
+		last := brk.orders[len(brk.orders)-1]
+		brk.fill(last.OrderID, last.Quantity, 104)
+	}
+	m.Poll()
+
+	got, err := m.Get(exec.ID)
+	if err != nil {
+		t.Fatalf("Get = %v", err)
+	}
+	if got.Status != StatusComplete || got.FilledQuantity != 100 || got.PendingQuantity != 0 || len(got.Children) != 4 {
+		t.Fatalf("execution = %+v, want complete with 100 filled in 4 slices", got)
+	}
+	want := (10*100 + 30*102 + 60*104) / 100.0
+	if got.AveragePrice != want {
+		t.Errorf("average price = %v, want %v", got.AveragePrice, want)
+	}
+}
+
+func TestIceberg(t *testing.T) {
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	m := newTestManager(&now)
+	brk := &fakeBroker{}
+
+	exec, err := m.Submit(brk, "u1", request(250, Params{Algo: Iceberg, DisplayQuantity: 100}))
+	if err != nil {
+		t.Fatalf("Submit = %v", err)
+	}
+
+	tests := []struct {
+		fill       int
+		wantOrders int
+		wantLast   int
+	}{
+		{0, 1, 100},  // Open slice, nothing new
+		{60, 1, 100}, // Partly filled, nothing new
+		{40, 2, 100}, // Filled, next slice
+		{100, 3, 50}, // Filled, the rest
+		{50, 3, 50},  // All filled
+	}
+	for i, tt := range tests {
+		last := brk.orders[len(brk.orders)-1]
+		if tt.fill > 0 {
+			brk.fill(last.OrderID, tt.fill, 100)
+		}
+		m.Poll()
+		if len(brk.orders) != tt.wantOrders || brk.orders[len(brk.orders)-1].Quantity != tt.wantLast {
+			t.Fatalf("step %d: orders %+v, want %d with the last of %d", i, brk.orders, tt.wantOrders, tt.wantLast)
+		}
+	}
+
+	got, _ := m.Get(exec.ID)
+	if got.Status != StatusComplete || got.FilledQuantity != 250 {
+		t.Errorf("execution = %+v, want complete", got)
+	}
+}
+
+func TestReprice(t *testing.T) {
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		side      string
+		ltp       float64
+		wantPrice float64
+		modified  bool
+	}{
+		{"buy follows the price up", "BUY", 101, 101, true},
+		{"buy capped at the limit", "BUY", 103, 102, true},
+		{"sell follows the price down", "SELL", 99, 99, true},
+		{"sell floored at the limit", "SELL", 97, 98, true},
+		{"unchanged", "BUY", 100, 100, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := now
+			m := newTestManager(&now)
+			brk := &fakeBroker{ltp: 100}
+			req := request(100, Params{Algo: Iceberg, DisplayQuantity: 50, RepriceSeconds: 10})
+			req.TransactionType = tt.side
+			req.OrderType = "LIMIT"
+			req.Price = 102
+			if tt.side == "SELL" {
+				req.Price = 98
+			}
+			if _, err := m.Submit(brk, "", req); err != nil {
+				t.Fatalf("Submit = %v", err)
+			}
+			if brk.orders[0].Price != 100 {
+				t.Fatalf("slice priced %v, want the last price 100", brk.orders[0].Price)
+			}
+
+			brk.ltp = tt.ltp
+			now = now.Add(5 * time.Second)
+			m.Poll()
+			if len(brk.modified) != 0 {
+				t.Fatalf("repriced early: %v", brk.modified)
+			}
+			now = now.Add(5 * time.Second)
+			m.Poll()
+			price, ok := brk.modified["1"]
+			if ok != tt.modified || (ok && price != tt.wantPrice) {
+				t.Errorf("modified %v, want %v at %v", brk.modified, tt.modified, tt.wantPrice)
+			}
+		})
+	}
+}
+
+func TestCancel(t *testing.T) {
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		// pending runs while the cancel of the open slice awaits the broker
+		pending     func(brk *fakeBroker)
+		wantFilled  int
+		wantAverage float64
+	}{
+		{"confirmed", func(brk *fakeBroker) {
+			brk.order("1").Status = broker.OrderStatusCancelled
+		}, 15, 100},
+		{"filled while cancelling", func(brk *fakeBroker) {
+			brk.fill("1", 25, 104)
+		}, 40, 102.5},
+		{"partly filled while cancelling", func(brk *fakeBroker) {
+			brk.fill("1", 5, 108)
+			brk.order("1").Status = broker.OrderStatusCancelled
+		}, 20, 102},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(&now)
+			brk := &fakeBroker{holdCancels: true}
+
+			exec, err := m.Submit(brk, "u1", request(100, Params{Algo: Iceberg, DisplayQuantity: 40}))
+			if err != nil {
+				t.Fatalf("Submit = %v", err)
+			}
+			brk.fill("1", 15, 100)
+
+			got, err := m.Cancel(exec.ID)
+			if err != nil {
+				t.Fatalf("Cancel = %v", err)
+			}
+			if got.Status != StatusCancelling || got.FinishedAt != nil || len(brk.cancelled) != 1 {
+				t.Fatalf("execution = %+v, cancelled %v; want cancelling", got, brk.cancelled)
+			}
+			if _, err := m.Cancel(exec.ID); !errors.Is(err, ErrFinished) {
+				t.Errorf("second Cancel = %v, want ErrFinished", err)
+			}
+
+			// Still open at the broker: keeps cancelling, without resending
+			m.Poll()
+			if got, _ := m.Get(exec.ID); got.Status != StatusCancelling || len(brk.cancelled) != 1 {
+				t.Fatalf("execution = %+v, cancelled %v; want still cancelling", got, brk.cancelled)
+			}
+
+			tt.pending(brk)
+			m.Poll()
+			got, _ = m.Get(exec.ID)
+			if got.Status != StatusCancelled || got.FilledQuantity != tt.wantFilled || got.AveragePrice != tt.wantAverage || got.FinishedAt == nil {
+				t.Errorf("execution = %+v, want cancelled with %d filled at %v", got, tt.wantFilled, tt.wantAverage)
+			}
+			if len(brk.orders) != 1 {
+				t.Errorf("orders = %+v, want no slice placed while cancelling", brk.orders)
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	m := newTestManager(&now)
+
+	if _, err := m.Submit(&fakeBroker{}, "u1", request(100, Params{Algo: Iceberg, DisplayQuantity: 40})); err != nil {
+		t.Fatalf("Submit = %v", err)
+	}
+	if _, err := m.Cancel("algo-missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Cancel unknown = %v, want ErrNotFound", err)
+	}
+	if list := m.List("u2"); len(list) != 0 {
+		t.Errorf("List(u2) = %+v, want none", list)
+	}
+	if list := m.List("u1"); len(list) != 1 {
+		t.Errorf("List(u1) = %+v, want one", list)
+	}
+}
+
+func TestFailures(t *testing.T) {
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	params := Params{Algo: Iceberg, DisplayQuantity: 40}
+
+	t.Run("rejected first slice", func(t *testing.T) {
+		m := newTestManager(&now)
+		brk := &fakeBroker{placeErr: errors.New("insufficient funds")}
+		if _, err := m.Submit(brk, "", request(100, params)); err == nil {
+			t.Fatal("Submit succeeded, want the rejection")
+		}
+		if list := m.List(""); len(list) != 0 {
+			t.Errorf("failed execution kept: %+v", list)
+		}
+	})
+
+	t.Run("transient errors retry", func(t *testing.T) {
+		m := newTestManager(&now)
+		brk := &fakeBroker{placeErr: broker.ErrBrokerUnavailable}
+		exec, err := m.Submit(brk, "", request(100, params))
+		if err != nil || exec.Status != StatusRunning {
+			t.Fatalf("Submit = %+v, %v; want running", exec, err)
+		}
+		brk.placeErr = nil
+		m.Poll()
+		if len(brk.orders) != 1 {
+			t.Errorf("orders = %+v, want the slice placed on retry", brk.orders)
+		}
+	})
+
+	t.Run("rejected slice fails the execution", func(t *testing.T) {
+		m := newTestManager(&now)
+		brk := &fakeBroker{}
+		exec, err := m.Submit(brk, "", request(100, params))
+		if err != nil {
+			t.Fatalf("Submit = %v", err)
+		}
+		brk.orders[0].Status = broker.OrderStatusRejected
+		m.Poll()
+		got, _ := m.Get(exec.ID)
+		if got.Status != StatusFailed || got.Error == "" || len(brk.orders) != 1 {
+			t.Errorf("execution = %+v, want failed without more slices", got)
+		}
+	})
+}
+
+func TestPollForgetsOldExecutions(t *testing.T) {
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	m := newTestManager(&now)
+	brk := &fakeBroker{}
+
+	exec, err := m.Submit(brk, "", request(100, Params{Algo: Iceberg, DisplayQuantity: 40}))
+	if err != nil {
+		t.Fatalf("Submit = %v", err)
+	}
+	if _, err := m.Cancel(exec.ID); err != nil {
+		t.Fatalf("Cancel = %v", err)
+	}
+	m.Poll() // Confirms the cancel
+
+	now = now.Add(finishedRetention - time.Minute)
+	m.Poll()
+	if _, err := m.Get(exec.ID); err != nil {
+		t.Fatalf("Get = %v, want the execution kept", err)
+	}
+	now = now.Add(2 * time.Minute)
+	m.Poll()
+	if _, err := m.Get(exec.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get = %v, want ErrNotFound", err)
+	}
+}
@@ -22,6 +22,11 @@ func NewAnalyzer52D() *Analyzer52D {
 	return &Analyzer52D{logger: logger}
 }
 
+// SetLogLevel adjusts analyzer logging (backtests analyze every bar)
+func (a *Analyzer52D) SetLogLevel(level logrus.Level) {
+	a.logger.SetLevel(level)
+}
+
 // Analysis represents complete 52-day analysis results
 type Analysis struct {
 	Symbol       string                 `json:"symbol"`
@@ -33,9 +38,11 @@ type Analysis struct {
 	Volume       VolumeAnalysis         `json:"volume"`
 	Support      []float64              `json:"support"`
 	Resistance   []float64              `json:"resistance"`
+	Fibonacci    *FibonacciLevels       `json:"fibonacci,omitempty"`
 	Indicators   TechnicalIndicators    `json:"indicators"`
 	RiskMetrics  RiskMetrics            `json:"risk_metrics"`
 	Signals      []Signal               `json:"signals"`
+	Confluence   []ConfluenceResult     `json:"confluence,omitempty"` // Multi-timeframe rules, when configured
 }
 
 // TrendAnalysis represents trend information
@@ -64,17 +71,24 @@ type VolumeAnalysis struct {
 
 // TechnicalIndicators represents technical indicators
 type TechnicalIndicators struct {
-	SMA20          float64 `json:"sma_20"`
-	SMA50          float64 `json:"sma_50"`
-	EMA12          float64 `json:"ema_12"`
-	EMA26          float64 `json:"ema_26"`
-	RSI            float64 `json:"rsi"`
-	MACD           float64 `json:"macd"`
-	MACDSignal     float64 `json:"macd_signal"`
-	BBUpper        float64 `json:"bb_upper"`
-	BBMiddle       float64 `json:"bb_middle"`
-	BBLower        float64 `json:"bb_lower"`
-	BBPosition     string  `json:"bb_position"` // OVERBOUGHT, OVERSOLD, NEUTRAL
+	SMA20           float64 `json:"sma_20"`
+	SMA50           float64 `json:"sma_50"`
+	EMA12           float64 `json:"ema_12"`
+	EMA26           float64 `json:"ema_26"`
+	RSI             float64 `json:"rsi"`
+	MACD            float64 `json:"macd"`
+	MACDSignal      float64 `json:"macd_signal"`
+	MACDHistogram   float64 `json:"macd_histogram"`
+	MACDCrossover   string  `json:"macd_crossover,omitempty"` // BUY or SELL when MACD crossed its signal on the last bar
+	BBUpper         float64 `json:"bb_upper"`
+	BBMiddle        float64 `json:"bb_middle"`
+	BBLower         float64 `json:"bb_lower"`
+	BBPosition      string  `json:"bb_position"` // OVERBOUGHT, OVERSOLD, NEUTRAL
+	IchimokuTenkan  float64 `json:"ichimoku_tenkan"`
+	IchimokuKijun   float64 `json:"ichimoku_kijun"`
+	IchimokuSenkouA float64 `json:"ichimoku_senkou_a"`
+	IchimokuSenkouB float64 `json:"ichimoku_senkou_b"`
+	IchimokuCloud   string  `json:"ichimoku_cloud"` // BULLISH, BEARISH, NEUTRAL
 }
 
 // RiskMetrics represents risk-adjusted metrics
@@ -128,6 +142,7 @@ func (a *Analyzer52D) Analyze(symbol string, candles []broker.Candle) (*Analysis
 	analysis.Volatility = a.analyzeVolatility(candles)
 	analysis.Volume = a.analyzeVolume(volumes)
 	analysis.Support, analysis.Resistance = a.findSupportResistance(highs, lows)
+	analysis.Fibonacci = CalculateFibonacciLevels(candles, 0)
 	analysis.Indicators = a.calculateIndicators(candles)
 	analysis.RiskMetrics = a.calculateRiskMetrics(closes)
 	analysis.Signals = a.generateSignals(analysis)
@@ -244,19 +259,7 @@ func (a *Analyzer52D) analyzeVolume(volumes []int64) VolumeAnalysis {
 
 // findSupportResistance finds support and resistance levels
 func (a *Analyzer52D) findSupportResistance(highs, lows []float64) ([]float64, []float64) {
-	// Get recent 20-day highs and lows
-	recentHighs := highs
-	recentLows := lows
-	if len(highs) > 20 {
-		recentHighs = highs[len(highs)-20:]
-		recentLows = lows[len(lows)-20:]
-	}
-	
-	// Find top 3 highs and lows
-	resistance := findTopN(recentHighs, 3)
-	support := findBottomN(recentLows, 3)
-	
-	return support, resistance
+	return FindSupportResistance(highs, lows)
 }
 
 // calculateIndicators calculates technical indicators
@@ -277,8 +280,9 @@ func (a *Analyzer52D) calculateIndicators(candles []broker.Candle) TechnicalIndi
 	
 	rsi := calculateRSI(closes, 14)
 	
-	macd := ema12 - ema26
-	macdSignal := ema(closes, 9)
+	// MACD (12, 26) with its 9-period signal line
+	macd := CalculateMACD(closes, 12, 26, 9)
+	last := len(closes) - 1
 	
 	bbMiddle := sma20
 	bbStd := stdDev(closes[len(closes)-20:])
@@ -296,23 +300,59 @@ func (a *Analyzer52D) calculateIndicators(candles []broker.Candle) TechnicalIndi
 		}
 	}
 	
+	// Ichimoku: price against the cloud under the last candle, or against
+	// the cloud projected from it when the history is too short (78 bars)
+	// for the displaced cloud to have formed
+	ichimoku := CalculateIchimoku(candles, 9, 26, 52, 26)
+	senkouA, senkouB := ichimoku.SenkouA[last], ichimoku.SenkouB[last]
+	if senkouA == 0 || senkouB == 0 {
+		senkouA, senkouB = ichimoku.SenkouAAhead[25], ichimoku.SenkouBAhead[25]
+	}
+	ichimokuCloud := "NEUTRAL"
+	if senkouA > 0 && senkouB > 0 {
+		if currentPrice > math.Max(senkouA, senkouB) {
+			ichimokuCloud = "BULLISH"
+		} else if currentPrice < math.Min(senkouA, senkouB) {
+			ichimokuCloud = "BEARISH"
+		}
+	}
+
 	return TechnicalIndicators{
 		SMA20:      sma20,
 		SMA50:      sma50,
 		EMA12:      ema12,
 		EMA26:      ema26,
 		RSI:        rsi,
-		MACD:       macd,
-		MACDSignal: macdSignal,
+		MACD:       macd.MACD[last],
+		MACDSignal: macd.Signal[last],
 		BBUpper:    bbUpper,
 		BBMiddle:   bbMiddle,
 		BBLower:    bbLower,
 		BBPosition: bbPosition,
+
+		MACDHistogram: macd.Histogram[last],
+		MACDCrossover: macd.Crossover[last],
+
+		IchimokuTenkan:  ichimoku.Tenkan[last],
+		IchimokuKijun:   ichimoku.Kijun[last],
+		IchimokuSenkouA: senkouA,
+		IchimokuSenkouB: senkouB,
+		IchimokuCloud:   ichimokuCloud,
 	}
 }
 
 // calculateRiskMetrics calculates risk-adjusted metrics
 func (a *Analyzer52D) calculateRiskMetrics(prices []float64) RiskMetrics {
+	return CalculateRiskMetrics(prices)
+}
+
+// CalculateRiskMetrics calculates the annualized Sharpe ratio (6% risk-free
+// rate), max drawdown and win rate of a daily price or value series
+func CalculateRiskMetrics(prices []float64) RiskMetrics {
+	if len(prices) < 2 {
+		return RiskMetrics{}
+	}
+
 	returns := make([]float64, len(prices)-1)
 	for i := 1; i < len(prices); i++ {
 		returns[i-1] = (prices[i] - prices[i-1]) / prices[i-1]
@@ -471,46 +511,21 @@ func sma(prices []float64, period int) float64 {
 	return sum(recent) / float64(period)
 }
 
+// ema returns the latest EMA, seeded with the SMA of the first period
 func ema(prices []float64, period int) float64 {
 	if len(prices) < period {
 		return 0
 	}
-	multiplier := 2.0 / float64(period+1)
-	ema := prices[0]
-	for i := 1; i < len(prices); i++ {
-		ema = (prices[i]-ema)*multiplier + ema
-	}
-	return ema
+	return CalculateEMA(prices, period)[len(prices)-1]
 }
 
+// calculateRSI returns the latest Wilder-smoothed RSI, or 50 without
+// enough history
 func calculateRSI(prices []float64, period int) float64 {
 	if len(prices) < period+1 {
 		return 50.0
 	}
-	
-	gains := 0.0
-	losses := 0.0
-	
-	for i := len(prices) - period; i < len(prices); i++ {
-		change := prices[i] - prices[i-1]
-		if change > 0 {
-			gains += change
-		} else {
-			losses -= change
-		}
-	}
-	
-	avgGain := gains / float64(period)
-	avgLoss := losses / float64(period)
-	
-	if avgLoss == 0 {
-		return 100
-	}
-	
-	rs := avgGain / avgLoss
-	rsi := 100 - (100 / (1 + rs))
-	
-	return rsi
+	return CalculateRSISeries(prices, period)[len(prices)-1]
 }
 
 func calculateATR(candles []broker.Candle, period int) float64 {
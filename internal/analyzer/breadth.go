@@ -0,0 +1,124 @@
+package analyzer
+
+import (
+	"sort"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// Breadth summarizes how a group of symbols moved on their latest session
+type Breadth struct {
+	Date              time.Time `json:"date"`    // Latest session among the symbols
+	Symbols           int       `json:"symbols"` // Symbols with enough data
+	Advancers         int       `json:"advancers"`
+	Decliners         int       `json:"decliners"`
+	Unchanged         int       `json:"unchanged"`
+	AdvanceDecline    float64   `json:"advance_decline_ratio"` // Advancers / decliners (advancers if none declined)
+	AboveSMA20        int       `json:"above_sma_20"`
+	AboveSMA50        int       `json:"above_sma_50"`
+	PercentAboveSMA20 float64   `json:"pct_above_sma_20"`
+	PercentAboveSMA50 float64   `json:"pct_above_sma_50"`
+	NewHighs          int       `json:"new_52w_highs"`
+	NewLows           int       `json:"new_52w_lows"`
+	AverageRSI        float64   `json:"average_rsi"`
+	Skipped           []string  `json:"skipped,omitempty"` // Symbols without enough data
+}
+
+// CalculateBreadth computes breadth from each symbol's daily candles (oldest
+// first). A symbol counts as a new 52-week high (low) when its latest high
+// (low) tops every bar of the prior 52 weeks; SMA and RSI counts only
+// include symbols with enough history for them.
+func CalculateBreadth(candles map[string][]broker.Candle) Breadth {
+	breadth := Breadth{}
+
+	symbols := make([]string, 0, len(candles))
+	for symbol := range candles {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	var sma20Count, sma50Count, rsiCount int
+	var rsiTotal float64
+	for _, symbol := range symbols {
+		bars := candles[symbol]
+		if len(bars) < 2 {
+			breadth.Skipped = append(breadth.Skipped, symbol)
+			continue
+		}
+		breadth.Symbols++
+
+		last := bars[len(bars)-1]
+		prev := bars[len(bars)-2]
+		if last.Date.After(breadth.Date) {
+			breadth.Date = last.Date
+		}
+
+		switch {
+		case last.Close > prev.Close:
+			breadth.Advancers++
+		case last.Close < prev.Close:
+			breadth.Decliners++
+		default:
+			breadth.Unchanged++
+		}
+
+		closes := make([]float64, len(bars))
+		for i, b := range bars {
+			closes[i] = b.Close
+		}
+		if len(closes) >= 20 {
+			sma20Count++
+			if last.Close > sma(closes, 20) {
+				breadth.AboveSMA20++
+			}
+		}
+		if len(closes) >= 50 {
+			sma50Count++
+			if last.Close > sma(closes, 50) {
+				breadth.AboveSMA50++
+			}
+		}
+		if len(closes) > 14 {
+			rsiCount++
+			rsiTotal += calculateRSI(closes, 14)
+		}
+
+		yearAgo := last.Date.AddDate(-1, 0, 0)
+		newHigh, newLow := true, true
+		for _, b := range bars[:len(bars)-1] {
+			if b.Date.Before(yearAgo) {
+				continue
+			}
+			if b.High >= last.High {
+				newHigh = false
+			}
+			if b.Low <= last.Low {
+				newLow = false
+			}
+		}
+		if newHigh {
+			breadth.NewHighs++
+		}
+		if newLow {
+			breadth.NewLows++
+		}
+	}
+
+	if breadth.Decliners > 0 {
+		breadth.AdvanceDecline = float64(breadth.Advancers) / float64(breadth.Decliners)
+	} else {
+		breadth.AdvanceDecline = float64(breadth.Advancers)
+	}
+	if sma20Count > 0 {
+		breadth.PercentAboveSMA20 = float64(breadth.AboveSMA20) / float64(sma20Count) * 100
+	}
+	if sma50Count > 0 {
+		breadth.PercentAboveSMA50 = float64(breadth.AboveSMA50) / float64(sma50Count) * 100
+	}
+	if rsiCount > 0 {
+		breadth.AverageRSI = rsiTotal / float64(rsiCount)
+	}
+
+	return breadth
+}
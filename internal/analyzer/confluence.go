@@ -0,0 +1,403 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// Confluence condition types
+const (
+	ConditionTrend      = "trend"      // Close above rising EMA 20 above EMA 50 (UP), or the reverse (DOWN)
+	ConditionRSI        = "rsi"        // RSI(14) below Below or above Above
+	ConditionMACD       = "macd"       // MACD(12, 26, 9) crossed its signal (BUY or SELL) in the last Within bars
+	ConditionSuperTrend = "supertrend" // SuperTrend(10, 3) is UP or DOWN
+	ConditionPattern    = "pattern"    // Pattern ended in the last Within bars
+)
+
+// ConfluenceTimeframes are the bar timeframes conditions can read
+var ConfluenceTimeframes = []string{"1m", "5m", "15m", "1h", "day"}
+
+// ConfluenceCondition is one condition of a rule, checked on the bars of
+// its timeframe
+type ConfluenceCondition struct {
+	Timeframe string  `json:"timeframe"`
+	Type      string  `json:"type"`
+	Direction string  `json:"direction,omitempty"` // trend and supertrend: UP or DOWN; macd: BUY or SELL
+	Below     float64 `json:"below,omitempty"`     // rsi
+	Above     float64 `json:"above,omitempty"`     // rsi
+	Pattern   string  `json:"pattern,omitempty"`   // Pattern type (e.g. "Bullish Engulfing"), or "bullish"/"bearish" for any
+	Within    int     `json:"within,omitempty"`    // macd and pattern: bars back (default 1, the last bar)
+	Weight    float64 `json:"weight,omitempty"`    // Share of the combined confidence (default 1)
+}
+
+// ConfluenceRule generates a signal only when all its conditions hold.
+// Its confidence is the weighted mean of the conditions' scores; the entry
+// is the last close on EntryTimeframe, with the stop StopATR ATRs away and
+// the target RewardRisk times the stop distance.
+type ConfluenceRule struct {
+	Name           string                `json:"name"`
+	Side           string                `json:"side"` // BUY or SELL
+	Conditions     []ConfluenceCondition `json:"conditions"`
+	EntryTimeframe string                `json:"entry_timeframe,omitempty"` // Default the shortest condition timeframe
+	StopATR        float64               `json:"stop_atr,omitempty"`        // Default 1.5
+	RewardRisk     float64               `json:"reward_risk,omitempty"`     // Default 2
+}
+
+// ConditionResult is how a condition evaluated
+type ConditionResult struct {
+	ConfluenceCondition
+	Met    bool    `json:"met"`
+	Score  float64 `json:"score"` // 0 to 1, how strongly it holds
+	Detail string  `json:"detail"`
+}
+
+// ConfluenceResult is how a rule evaluated on a symbol; Signal is set when
+// every condition was met
+type ConfluenceResult struct {
+	Rule       string            `json:"rule"`
+	Side       string            `json:"side"`
+	Met        bool              `json:"met"`
+	Confidence float64           `json:"confidence"`
+	Conditions []ConditionResult `json:"conditions"`
+	Signal     *Signal           `json:"signal,omitempty"`
+}
+
+// DefaultConfluenceRules pair a daily trend with a lower timeframe pullback
+// or momentum trigger
+func DefaultConfluenceRules() []ConfluenceRule {
+	return []ConfluenceRule{
+		{
+			Name: "TREND_PULLBACK_BUY",
+			Side: "BUY",
+			Conditions: []ConfluenceCondition{
+				{Timeframe: "day", Type: ConditionTrend, Direction: "UP"},
+				{Timeframe: "15m", Type: ConditionRSI, Below: 30},
+				{Timeframe: "5m", Type: ConditionPattern, Pattern: "Bullish Engulfing", Within: 3},
+			},
+		},
+		{
+			Name: "TREND_RALLY_SELL",
+			Side: "SELL",
+			Conditions: []ConfluenceCondition{
+				{Timeframe: "day", Type: ConditionTrend, Direction: "DOWN"},
+				{Timeframe: "15m", Type: ConditionRSI, Above: 70},
+				{Timeframe: "5m", Type: ConditionPattern, Pattern: "Bearish Engulfing", Within: 3},
+			},
+		},
+		{
+			Name: "MOMENTUM_BUY",
+			Side: "BUY",
+			Conditions: []ConfluenceCondition{
+				{Timeframe: "day", Type: ConditionTrend, Direction: "UP"},
+				{Timeframe: "1h", Type: ConditionSuperTrend, Direction: "UP"},
+				{Timeframe: "15m", Type: ConditionMACD, Direction: "BUY", Within: 2},
+			},
+		},
+		{
+			Name: "MOMENTUM_SELL",
+			Side: "SELL",
+			Conditions: []ConfluenceCondition{
+				{Timeframe: "day", Type: ConditionTrend, Direction: "DOWN"},
+				{Timeframe: "1h", Type: ConditionSuperTrend, Direction: "DOWN"},
+				{Timeframe: "15m", Type: ConditionMACD, Direction: "SELL", Within: 2},
+			},
+		},
+	}
+}
+
+// ParseConfluenceRules decodes a JSON array of rules and validates them
+func ParseConfluenceRules(data []byte) ([]ConfluenceRule, error) {
+	var rules []ConfluenceRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("invalid confluence rules: %w", err)
+	}
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+// Validate checks that a rule can be evaluated
+func (r ConfluenceRule) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("confluence rule needs a name")
+	}
+	if r.Side != "BUY" && r.Side != "SELL" {
+		return fmt.Errorf("rule %s: side must be BUY or SELL", r.Name)
+	}
+	if len(r.Conditions) == 0 {
+		return fmt.Errorf("rule %s: needs at least one condition", r.Name)
+	}
+	if r.EntryTimeframe != "" && timeframeRank(r.EntryTimeframe) < 0 {
+		return fmt.Errorf("rule %s: unsupported entry timeframe %s", r.Name, r.EntryTimeframe)
+	}
+	if r.StopATR < 0 || r.RewardRisk < 0 {
+		return fmt.Errorf("rule %s: stop_atr and reward_risk can't be negative", r.Name)
+	}
+
+	for i, cond := range r.Conditions {
+		if timeframeRank(cond.Timeframe) < 0 {
+			return fmt.Errorf("rule %s condition %d: unsupported timeframe %q (use %s)",
+				r.Name, i+1, cond.Timeframe, strings.Join(ConfluenceTimeframes, ", "))
+		}
+		if cond.Weight < 0 || cond.Within < 0 {
+			return fmt.Errorf("rule %s condition %d: weight and within can't be negative", r.Name, i+1)
+		}
+
+		var err error
+		switch cond.Type {
+		case ConditionTrend, ConditionSuperTrend:
+			if cond.Direction != "UP" && cond.Direction != "DOWN" {
+				err = fmt.Errorf("direction must be UP or DOWN")
+			}
+		case ConditionMACD:
+			if cond.Direction != "BUY" && cond.Direction != "SELL" {
+				err = fmt.Errorf("direction must be BUY or SELL")
+			}
+		case ConditionRSI:
+			if (cond.Below <= 0) == (cond.Above <= 0) || cond.Below > 100 || cond.Above > 100 {
+				err = fmt.Errorf("needs one of below or above, between 0 and 100")
+			}
+		case ConditionPattern:
+			if cond.Pattern == "" {
+				err = fmt.Errorf("needs a pattern")
+			}
+		default:
+			err = fmt.Errorf("unknown type %q", cond.Type)
+		}
+		if err != nil {
+			return fmt.Errorf("rule %s condition %d: %w", r.Name, i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// Timeframes returns the timeframes a rule reads, shortest first
+func (r ConfluenceRule) Timeframes() []string {
+	var timeframes []string
+	for _, tf := range ConfluenceTimeframes {
+		if tf == r.entryTimeframe() {
+			timeframes = append(timeframes, tf)
+			continue
+		}
+		for _, cond := range r.Conditions {
+			if cond.Timeframe == tf {
+				timeframes = append(timeframes, tf)
+				break
+			}
+		}
+	}
+	return timeframes
+}
+
+// entryTimeframe is EntryTimeframe or the shortest condition timeframe
+func (r ConfluenceRule) entryTimeframe() string {
+	if r.EntryTimeframe != "" {
+		return r.EntryTimeframe
+	}
+	entry := ""
+	for _, cond := range r.Conditions {
+		if entry == "" || timeframeRank(cond.Timeframe) < timeframeRank(entry) {
+			entry = cond.Timeframe
+		}
+	}
+	return entry
+}
+
+// timeframeRank is the position of tf in ConfluenceTimeframes, -1 if absent
+func timeframeRank(tf string) int {
+	for i, candidate := range ConfluenceTimeframes {
+		if tf == candidate {
+			return i
+		}
+	}
+	return -1
+}
+
+// EvaluateConfluence checks a rule against candles by timeframe, oldest
+// first. Conditions on timeframes without enough candles aren't met.
+func EvaluateConfluence(rule ConfluenceRule, candles map[string][]broker.Candle) ConfluenceResult {
+	result := ConfluenceResult{
+		Rule:       rule.Name,
+		Side:       rule.Side,
+		Met:        true,
+		Conditions: make([]ConditionResult, len(rule.Conditions)),
+	}
+
+	weighted, totalWeight := 0.0, 0.0
+	for i, cond := range rule.Conditions {
+		met, score, detail := evaluateCondition(cond, candles[cond.Timeframe])
+		result.Conditions[i] = ConditionResult{ConfluenceCondition: cond, Met: met, Score: score, Detail: detail}
+
+		weight := cond.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		weighted += score * weight
+		totalWeight += weight
+		if !met {
+			result.Met = false
+		}
+	}
+	if totalWeight > 0 {
+		result.Confidence = weighted / totalWeight
+	}
+	if !result.Met {
+		return result
+	}
+
+	entryCandles := candles[rule.entryTimeframe()]
+	if len(entryCandles) == 0 {
+		result.Met = false
+		return result
+	}
+	result.Signal = confluenceSignal(rule, entryCandles, result)
+	return result
+}
+
+// confluenceSignal places a met rule's entry at the last close, the stop
+// StopATR ATRs away and the target RewardRisk stops beyond the entry
+func confluenceSignal(rule ConfluenceRule, candles []broker.Candle, result ConfluenceResult) *Signal {
+	stopATR := rule.StopATR
+	if stopATR == 0 {
+		stopATR = 1.5
+	}
+	rewardRisk := rule.RewardRisk
+	if rewardRisk == 0 {
+		rewardRisk = 2
+	}
+
+	entry := candles[len(candles)-1].Close
+	atr := 0.0
+	if series := CalculateATR(candles, 14); len(series) > 0 {
+		atr = series[len(series)-1]
+	}
+	if atr <= 0 {
+		atr = entry * 0.01
+	}
+
+	risk := stopATR * atr
+	direction := 1.0
+	if rule.Side == "SELL" {
+		direction = -1
+	}
+
+	reasons := make([]string, len(result.Conditions))
+	for i, cond := range result.Conditions {
+		reasons[i] = cond.Timeframe + " " + cond.Detail
+	}
+
+	return &Signal{
+		Type:       rule.Side,
+		Strategy:   "MTF_" + rule.Name,
+		Confidence: result.Confidence,
+		EntryPrice: entry,
+		StopLoss:   entry - direction*risk,
+		TakeProfit: entry + direction*risk*rewardRisk,
+		Reason:     strings.Join(reasons, "; "),
+	}
+}
+
+// evaluateCondition reports whether a condition holds on candles, how
+// strongly (0 to 1) and why
+func evaluateCondition(cond ConfluenceCondition, candles []broker.Candle) (bool, float64, string) {
+	within := cond.Within
+	if within <= 0 {
+		within = 1
+	}
+	last := len(candles) - 1
+	closes := make([]float64, len(candles))
+	for i, c := range candles {
+		closes[i] = c.Close
+	}
+
+	switch cond.Type {
+	case ConditionTrend:
+		if len(candles) < 51 {
+			return false, 0, fmt.Sprintf("trend needs 51 bars, have %d", len(candles))
+		}
+		fast, slow := CalculateEMA(closes, 20), CalculateEMA(closes, 50)
+		up := closes[last] > fast[last] && fast[last] > slow[last] && fast[last] > fast[last-1]
+		down := closes[last] < fast[last] && fast[last] < slow[last] && fast[last] < fast[last-1]
+		direction := "SIDEWAYS"
+		if up {
+			direction = "UP"
+		} else if down {
+			direction = "DOWN"
+		}
+		detail := fmt.Sprintf("trend %s (EMA20 %.2f, EMA50 %.2f)", direction, fast[last], slow[last])
+		if direction != cond.Direction {
+			return false, 0, detail
+		}
+		// Wider EMA separation, stronger trend: 1% apart scores 0.8
+		return true, math.Min(1, 0.6+20*math.Abs(fast[last]-slow[last])/slow[last]), detail
+
+	case ConditionRSI:
+		if len(candles) < 15 {
+			return false, 0, fmt.Sprintf("RSI needs 15 bars, have %d", len(candles))
+		}
+		rsi := CalculateRSISeries(closes, 14)[last]
+		if cond.Below > 0 {
+			detail := fmt.Sprintf("RSI %.1f (below %.0f)", rsi, cond.Below)
+			if rsi >= cond.Below {
+				return false, 0, detail
+			}
+			return true, math.Min(1, 0.6+(cond.Below-rsi)/cond.Below), detail
+		}
+		detail := fmt.Sprintf("RSI %.1f (above %.0f)", rsi, cond.Above)
+		if rsi <= cond.Above {
+			return false, 0, detail
+		}
+		return true, math.Min(1, 0.6+(rsi-cond.Above)/(100-cond.Above)), detail
+
+	case ConditionMACD:
+		if len(candles) < 35 {
+			return false, 0, fmt.Sprintf("MACD needs 35 bars, have %d", len(candles))
+		}
+		macd := CalculateMACD(closes, 12, 26, 9)
+		for i := last; i > last-within && i >= 0; i-- {
+			if macd.Crossover[i] == cond.Direction {
+				return true, 0.75, fmt.Sprintf("MACD %s crossover %d bars ago", cond.Direction, last-i)
+			}
+		}
+		return false, 0, fmt.Sprintf("no MACD %s crossover in %d bars", cond.Direction, within)
+
+	case ConditionSuperTrend:
+		if len(candles) < 11 {
+			return false, 0, fmt.Sprintf("SuperTrend needs 11 bars, have %d", len(candles))
+		}
+		trend := CalculateSuperTrend(candles, 10, 3).Trend[last]
+		detail := "SuperTrend " + trend
+		if trend != cond.Direction {
+			return false, 0, detail
+		}
+		return true, 0.75, detail
+
+	case ConditionPattern:
+		if len(candles) < 2 {
+			return false, 0, fmt.Sprintf("patterns need 2 bars, have %d", len(candles))
+		}
+		best := -1.0
+		for _, p := range NewPatternScanner().ScanAllPatterns(candles) {
+			if p.EndIndex <= last-within {
+				continue
+			}
+			if strings.EqualFold(p.Type, cond.Pattern) || strings.EqualFold(p.Signal, cond.Pattern) {
+				best = math.Max(best, p.Confidence)
+			}
+		}
+		if best < 0 {
+			return false, 0, fmt.Sprintf("no %s in %d bars", cond.Pattern, within)
+		}
+		return true, best, fmt.Sprintf("%s (confidence %.2f)", cond.Pattern, best)
+	}
+
+	return false, 0, "unknown condition " + cond.Type
+}
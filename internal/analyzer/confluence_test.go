@@ -0,0 +1,179 @@
+package analyzer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// trendCandles returns n candles whose close moves by step each bar
+func trendCandles(n int, start, step float64, interval time.Duration) []broker.Candle {
+	candles := make([]broker.Candle, n)
+	date := time.Date(2024, 1, 1, 9, 15, 0, 0, time.UTC)
+	for i := range candles {
+		close := start + step*float64(i)
+		open := close - step/2
+		candles[i] = broker.Candle{
+			Date:   date.Add(time.Duration(i) * interval),
+			Open:   open,
+			High:   max(open, close) + 0.5,
+			Low:    min(open, close) - 0.5,
+			Close:  close,
+			Volume: 1000,
+		}
+	}
+	return candles
+}
+
+// engulfingCandles returns flat candles ending in a bullish engulfing
+func engulfingCandles() []broker.Candle {
+	candles := trendCandles(10, 100, 0, 5*time.Minute)
+	date := candles[len(candles)-1].Date
+	return append(candles,
+		broker.Candle{Date: date.Add(5 * time.Minute), Open: 101, High: 101.5, Low: 99.8, Close: 100, Volume: 1000},
+		broker.Candle{Date: date.Add(10 * time.Minute), Open: 99.5, High: 102.2, Low: 99.4, Close: 102, Volume: 3000},
+	)
+}
+
+func TestConfluenceRuleValidate(t *testing.T) {
+	valid := ConfluenceRule{
+		Name: "TEST",
+		Side: "BUY",
+		Conditions: []ConfluenceCondition{
+			{Timeframe: "day", Type: ConditionTrend, Direction: "UP"},
+		},
+	}
+	with := func(change func(r *ConfluenceRule)) ConfluenceRule {
+		r := valid
+		r.Conditions = append([]ConfluenceCondition(nil), valid.Conditions...)
+		change(&r)
+		return r
+	}
+
+	tests := []struct {
+		name    string
+		rule    ConfluenceRule
+		wantErr string
+	}{
+		{"valid", valid, ""},
+		{"no name", with(func(r *ConfluenceRule) { r.Name = "" }), "needs a name"},
+		{"bad side", with(func(r *ConfluenceRule) { r.Side = "HOLD" }), "side must be"},
+		{"no conditions", with(func(r *ConfluenceRule) { r.Conditions = nil }), "at least one condition"},
+		{"bad timeframe", with(func(r *ConfluenceRule) { r.Conditions[0].Timeframe = "15minute" }), "unsupported timeframe"},
+		{"bad entry timeframe", with(func(r *ConfluenceRule) { r.EntryTimeframe = "2m" }), "unsupported entry timeframe"},
+		{"bad trend direction", with(func(r *ConfluenceRule) { r.Conditions[0].Direction = "BUY" }), "UP or DOWN"},
+		{"macd direction", with(func(r *ConfluenceRule) {
+			r.Conditions[0] = ConfluenceCondition{Timeframe: "15m", Type: ConditionMACD, Direction: "UP"}
+		}), "BUY or SELL"},
+		{"rsi without threshold", with(func(r *ConfluenceRule) {
+			r.Conditions[0] = ConfluenceCondition{Timeframe: "15m", Type: ConditionRSI}
+		}), "one of below or above"},
+		{"rsi with both thresholds", with(func(r *ConfluenceRule) {
+			r.Conditions[0] = ConfluenceCondition{Timeframe: "15m", Type: ConditionRSI, Below: 30, Above: 70}
+		}), "one of below or above"},
+		{"pattern without name", with(func(r *ConfluenceRule) {
+			r.Conditions[0] = ConfluenceCondition{Timeframe: "5m", Type: ConditionPattern}
+		}), "needs a pattern"},
+		{"unknown type", with(func(r *ConfluenceRule) { r.Conditions[0].Type = "volume" }), "unknown type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	for _, rule := range DefaultConfluenceRules() {
+		if err := rule.Validate(); err != nil {
+			t.Errorf("default rule %s: %v", rule.Name, err)
+		}
+	}
+}
+
+func TestParseConfluenceRules(t *testing.T) {
+	rules, err := ParseConfluenceRules([]byte(`[{
+		"name": "PULLBACK", "side": "BUY", "stop_atr": 2,
+		"conditions": [
+			{"timeframe": "5m", "type": "pattern", "pattern": "bullish", "within": 2},
+			{"timeframe": "day", "type": "trend", "direction": "UP", "weight": 2}
+		]
+	}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 1 || rules[0].StopATR != 2 || rules[0].Conditions[1].Weight != 2 {
+		t.Errorf("rules = %+v", rules)
+	}
+	if got := rules[0].Timeframes(); !reflect.DeepEqual(got, []string{"5m", "day"}) {
+		t.Errorf("Timeframes() = %v, want [5m day]", got)
+	}
+
+	if _, err := ParseConfluenceRules([]byte(`[{"name": "X", "side": "BUY"}]`)); err == nil {
+		t.Error("rule without conditions parsed")
+	}
+	if _, err := ParseConfluenceRules([]byte(`{`)); err == nil {
+		t.Error("malformed JSON parsed")
+	}
+}
+
+func TestEvaluateConfluence(t *testing.T) {
+	rule := DefaultConfluenceRules()[0] // Daily uptrend, 15m RSI below 30, 5m bullish engulfing
+
+	candles := map[string][]broker.Candle{
+		"day": trendCandles(60, 100, 1, 24*time.Hour),
+		"15m": trendCandles(30, 200, -2, 15*time.Minute),
+		"5m":  engulfingCandles(),
+	}
+
+	result := EvaluateConfluence(rule, candles)
+	if !result.Met || result.Signal == nil {
+		t.Fatalf("rule not met: %+v", result)
+	}
+	for _, cond := range result.Conditions {
+		if !cond.Met || cond.Score <= 0 || cond.Score > 1 {
+			t.Errorf("condition %s on %s = %+v", cond.Type, cond.Timeframe, cond)
+		}
+	}
+
+	signal := result.Signal
+	if signal.Type != "BUY" || signal.Strategy != "MTF_TREND_PULLBACK_BUY" {
+		t.Errorf("signal = %+v", signal)
+	}
+	if signal.EntryPrice != 102 {
+		t.Errorf("entry = %v, want the last 5m close 102", signal.EntryPrice)
+	}
+	risk := signal.EntryPrice - signal.StopLoss
+	if risk <= 0 || !almostEqual(signal.TakeProfit-signal.EntryPrice, 2*risk, 1e-9) {
+		t.Errorf("stop %v and target %v aren't 1:2 around %v", signal.StopLoss, signal.TakeProfit, signal.EntryPrice)
+	}
+	if signal.Confidence != result.Confidence || signal.Confidence < 0.6 {
+		t.Errorf("confidence = %v, result %v", signal.Confidence, result.Confidence)
+	}
+
+	// A daily downtrend breaks the confluence
+	candles["day"] = trendCandles(60, 200, -1, 24*time.Hour)
+	result = EvaluateConfluence(rule, candles)
+	if result.Met || result.Signal != nil || result.Conditions[0].Met {
+		t.Errorf("rule met against the daily trend: %+v", result)
+	}
+
+	// So does missing data
+	delete(candles, "5m")
+	candles["day"] = trendCandles(60, 100, 1, 24*time.Hour)
+	result = EvaluateConfluence(rule, candles)
+	if result.Met || !strings.Contains(result.Conditions[2].Detail, "need") {
+		t.Errorf("rule met without 5m bars: %+v", result.Conditions[2])
+	}
+}
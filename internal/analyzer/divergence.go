@@ -0,0 +1,147 @@
+package analyzer
+
+import (
+	"fmt"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+const (
+	minDivergenceBars = 5  // Fewest bars between the two swings compared
+	maxDivergenceBars = 60 // Most bars between the two swings compared
+)
+
+// divergenceIndicator is an oscillator compared against price swings
+type divergenceIndicator struct {
+	name   string
+	values []float64
+	warmup int // First bar with a value
+
+	// Bonus confidence when a bullish (bearish) divergence forms in the
+	// indicator's oversold (overbought) zone
+	oversold   func(v float64) bool
+	overbought func(v float64) bool
+}
+
+// DetectDivergences compares consecutive price swing lows and highs with
+// RSI (14) and the MACD line (12, 26) at the same bars:
+//   - regular bullish: price makes a lower low, the indicator a higher low
+//   - hidden bullish: price makes a higher low, the indicator a lower low
+//   - regular bearish: price makes a higher high, the indicator a lower high
+//   - hidden bearish: price makes a lower high, the indicator a higher high
+//
+// Regular divergences warn of reversals, hidden ones of trend continuation.
+func (ps *PatternScanner) DetectDivergences(candles []broker.Candle) []Pattern {
+	patterns := []Pattern{}
+	if len(candles) < 30 {
+		return patterns
+	}
+
+	closes := make([]float64, len(candles))
+	for i, c := range candles {
+		closes[i] = c.Close
+	}
+
+	indicators := []divergenceIndicator{
+		{
+			name:       "RSI",
+			values:     CalculateRSISeries(closes, 14),
+			warmup:     14,
+			oversold:   func(v float64) bool { return v < 30 },
+			overbought: func(v float64) bool { return v > 70 },
+		},
+		{
+			name:       "MACD",
+			values:     CalculateMACD(closes, 12, 26, 9).MACD,
+			warmup:     25,
+			oversold:   func(v float64) bool { return v < 0 },
+			overbought: func(v float64) bool { return v > 0 },
+		},
+	}
+
+	troughs := findLocalTroughs(candles, 3)
+	peaks := findLocalPeaks(candles, 3)
+
+	for _, ind := range indicators {
+		for i := 1; i < len(troughs); i++ {
+			a, b := troughs[i-1], troughs[i]
+			if !divergenceSpan(a, b, ind.warmup) {
+				continue
+			}
+			ia, ib := ind.values[a.Index], ind.values[b.Index]
+
+			var kind string
+			switch {
+			case b.Low < a.Low && ib > ia:
+				kind = "Bullish"
+			case b.Low > a.Low && ib < ia:
+				kind = "Hidden Bullish"
+			default:
+				continue
+			}
+
+			confidence := divergenceConfidence(kind)
+			if ind.oversold(ia) || ind.oversold(ib) {
+				confidence += 0.1
+			}
+			patterns = append(patterns, divergencePattern(candles, kind, "bullish", ind.name, a, b, a.Low, b.Low, ia, ib, confidence))
+		}
+
+		for i := 1; i < len(peaks); i++ {
+			a, b := peaks[i-1], peaks[i]
+			if !divergenceSpan(a, b, ind.warmup) {
+				continue
+			}
+			ia, ib := ind.values[a.Index], ind.values[b.Index]
+
+			var kind string
+			switch {
+			case b.High > a.High && ib < ia:
+				kind = "Bearish"
+			case b.High < a.High && ib > ia:
+				kind = "Hidden Bearish"
+			default:
+				continue
+			}
+
+			confidence := divergenceConfidence(kind)
+			if ind.overbought(ia) || ind.overbought(ib) {
+				confidence += 0.1
+			}
+			patterns = append(patterns, divergencePattern(candles, kind, "bearish", ind.name, a, b, a.High, b.High, ia, ib, confidence))
+		}
+	}
+
+	return patterns
+}
+
+// divergenceSpan reports whether two swings are far enough apart to compare
+// and both have indicator values
+func divergenceSpan(a, b Peak, warmup int) bool {
+	span := b.Index - a.Index
+	return a.Index >= warmup && span >= minDivergenceBars && span <= maxDivergenceBars
+}
+
+// divergenceConfidence is the base confidence of a divergence: hidden
+// divergences are less reliable than regular ones
+func divergenceConfidence(kind string) float64 {
+	if kind == "Hidden Bullish" || kind == "Hidden Bearish" {
+		return 0.65
+	}
+	return 0.7
+}
+
+func divergencePattern(candles []broker.Candle, kind, signal, indicator string, a, b Peak, priceA, priceB, indA, indB, confidence float64) Pattern {
+	return Pattern{
+		Type:        fmt.Sprintf("%s %s Divergence", kind, indicator),
+		Category:    "divergence",
+		Signal:      signal,
+		Confidence:  confidence,
+		StartIndex:  a.Index,
+		EndIndex:    b.Index,
+		StartDate:   candles[a.Index].Date,
+		EndDate:     candles[b.Index].Date,
+		Description: fmt.Sprintf("%s divergence: price %.2f → %.2f while %s %.2f → %.2f", kind, priceA, priceB, indicator, indA, indB),
+		KeyLevels:   []float64{priceA, priceB},
+	}
+}
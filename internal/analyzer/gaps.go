@@ -0,0 +1,235 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// Gap classifications
+const (
+	GapCommon     = "common"
+	GapBreakaway  = "breakaway"
+	GapRunaway    = "runaway"
+	GapExhaustion = "exhaustion"
+)
+
+const (
+	minGapPercent    = 0.5  // Smallest open vs prior close move counted as a gap (%)
+	gapLookback      = 20   // Bars before the gap used to judge range and trend
+	gapTrendPercent  = 5.0  // Prior move that counts as a trend (%)
+	gapExtendPercent = 15.0 // Prior move that counts as an extended trend (%)
+	gapQuickFillBars = 5    // A gap filled within this many bars failed quickly
+)
+
+// Gap is an opening gap: the bar opened away from the prior bar's close
+type Gap struct {
+	Index       int        `json:"index"`
+	Date        time.Time  `json:"date"`
+	Direction   string     `json:"direction"` // "up" or "down"
+	Type        string     `json:"type"`      // common, breakaway, runaway, exhaustion
+	PrevClose   float64    `json:"prev_close"`
+	Open        float64    `json:"open"`
+	SizePercent float64    `json:"size_percent"` // Gap size relative to the prior close
+	VolumeRatio float64    `json:"volume_ratio"` // Gap bar volume vs the prior average
+	PriorMove   float64    `json:"prior_move"`   // % move over the lookback before the gap
+	Filled      bool       `json:"filled"`
+	FillPercent float64    `json:"fill_percent"` // How much of the gap later bars retraced (0-100)
+	FilledIndex int        `json:"filled_index,omitempty"`
+	FilledDate  *time.Time `json:"filled_date,omitempty"`
+}
+
+// FindGaps finds every opening gap, classifies it from the price action
+// before it and tracks whether later bars filled it. A gap is filled once
+// price trades back to the prior close.
+func (ps *PatternScanner) FindGaps(candles []broker.Candle) []Gap {
+	gaps := []Gap{}
+
+	for i := 1; i < len(candles); i++ {
+		prev := candles[i-1]
+		curr := candles[i]
+		if prev.Close == 0 {
+			continue
+		}
+
+		size := (curr.Open - prev.Close) / prev.Close * 100
+		if math.Abs(size) < minGapPercent {
+			continue
+		}
+
+		gap := Gap{
+			Index:       i,
+			Date:        curr.Date,
+			Direction:   "up",
+			PrevClose:   prev.Close,
+			Open:        curr.Open,
+			SizePercent: math.Abs(size),
+		}
+		if size < 0 {
+			gap.Direction = "down"
+		}
+
+		trackGapFill(candles, &gap)
+		classifyGap(candles, &gap)
+		gaps = append(gaps, gap)
+	}
+
+	return gaps
+}
+
+// trackGapFill measures how far price retraced into the gap, including the
+// gap bar itself
+func trackGapFill(candles []broker.Candle, gap *Gap) {
+	gapSize := math.Abs(gap.Open - gap.PrevClose)
+
+	for j := gap.Index; j < len(candles); j++ {
+		var retraced float64
+		if gap.Direction == "up" {
+			retraced = gap.Open - candles[j].Low
+		} else {
+			retraced = candles[j].High - gap.Open
+		}
+
+		fill := math.Min(100, math.Max(0, retraced/gapSize*100))
+		if fill > gap.FillPercent {
+			gap.FillPercent = fill
+		}
+		if fill >= 100 {
+			date := candles[j].Date
+			gap.Filled = true
+			gap.FilledIndex = j
+			gap.FilledDate = &date
+			return
+		}
+	}
+}
+
+// classifyGap labels a gap from the bars before it:
+//   - exhaustion: late in an extended trend and filled quickly
+//   - breakaway: clears the prior range without a trend behind it
+//   - runaway: continues an established trend
+//   - common: anything else, usually inside the range
+func classifyGap(candles []broker.Candle, gap *Gap) {
+	gap.Type = GapCommon
+
+	start := gap.Index - gapLookback
+	if start < 0 {
+		start = 0
+	}
+	if gap.Index-start < 5 {
+		return
+	}
+
+	first := candles[start].Close
+	last := candles[gap.Index-1].Close
+	gap.PriorMove = (last - first) / first * 100
+
+	var volume float64
+	for j := start; j < gap.Index; j++ {
+		volume += float64(candles[j].Volume)
+	}
+	if avg := volume / float64(gap.Index-start); avg > 0 {
+		gap.VolumeRatio = float64(candles[gap.Index].Volume) / avg
+	}
+
+	// Move in the gap's direction, so up and down gaps share thresholds
+	move := gap.PriorMove
+	if gap.Direction == "down" {
+		move = -move
+	}
+	quickFill := gap.Filled && gap.FilledIndex-gap.Index <= gapQuickFillBars
+
+	switch {
+	case move >= gapExtendPercent && quickFill:
+		gap.Type = GapExhaustion
+	case gap.Direction == "up" && gap.Open > findHighestBetween(candles, start, gap.Index-1) && move < gapTrendPercent,
+		gap.Direction == "down" && gap.Open < findLowestBetween(candles, start, gap.Index-1) && move < gapTrendPercent:
+		gap.Type = GapBreakaway
+	case move >= gapTrendPercent && !quickFill:
+		gap.Type = GapRunaway
+	}
+}
+
+// DetectGaps reports breakaway, runaway and exhaustion gaps as patterns.
+// Breakaway and runaway gaps signal in the gap's direction; an exhaustion
+// gap signals the trend running out.
+func (ps *PatternScanner) DetectGaps(candles []broker.Candle) []Pattern {
+	patterns := []Pattern{}
+
+	for _, gap := range ps.FindGaps(candles) {
+		if gap.Type == GapCommon {
+			continue
+		}
+
+		signal := "bullish"
+		if gap.Direction == "down" {
+			signal = "bearish"
+		}
+
+		confidence := 0.7
+		var description string
+		switch gap.Type {
+		case GapBreakaway:
+			if gap.VolumeRatio >= 1.5 {
+				confidence += 0.1
+			}
+			if !gap.Filled {
+				confidence += 0.05
+			}
+			description = fmt.Sprintf("Breakaway gap %s %.1f%% out of the prior range", gap.Direction, gap.SizePercent)
+		case GapRunaway:
+			if gap.VolumeRatio >= 1.5 {
+				confidence += 0.05
+			}
+			if !gap.Filled {
+				confidence += 0.05
+			}
+			description = fmt.Sprintf("Runaway gap %s %.1f%% continuing a %.1f%% trend", gap.Direction, gap.SizePercent, math.Abs(gap.PriorMove))
+		case GapExhaustion:
+			if signal == "bullish" {
+				signal = "bearish"
+			} else {
+				signal = "bullish"
+			}
+			if gap.VolumeRatio >= 2 {
+				confidence += 0.1
+			}
+			description = fmt.Sprintf("Exhaustion gap %s %.1f%% after a %.1f%% trend, filled within %d bars",
+				gap.Direction, gap.SizePercent, math.Abs(gap.PriorMove), gap.FilledIndex-gap.Index)
+		}
+
+		end := gap.Index
+		if gap.Type == GapExhaustion {
+			end = gap.FilledIndex // Only confirmed once filled
+		}
+
+		patterns = append(patterns, Pattern{
+			Type:        gapPatternType(gap.Type),
+			Category:    "gap",
+			Signal:      signal,
+			Confidence:  confidence,
+			StartIndex:  gap.Index - 1,
+			EndIndex:    end,
+			StartDate:   candles[gap.Index-1].Date,
+			EndDate:     candles[end].Date,
+			Description: description,
+			KeyLevels:   []float64{gap.PrevClose, gap.Open},
+		})
+	}
+
+	return patterns
+}
+
+func gapPatternType(gapType string) string {
+	switch gapType {
+	case GapBreakaway:
+		return "Breakaway Gap"
+	case GapRunaway:
+		return "Runaway Gap"
+	case GapExhaustion:
+		return "Exhaustion Gap"
+	}
+	return "Common Gap"
+}
@@ -272,3 +272,267 @@ func CalculateADX(candles []broker.Candle, period int) []float64 {
 
 	return adx
 }
+
+// CalculateSMA calculates the Simple Moving Average of each bar. Values
+// before the first full period are 0.
+func CalculateSMA(values []float64, period int) []float64 {
+	result := make([]float64, len(values))
+	if period <= 0 || len(values) < period {
+		return result
+	}
+
+	sum := 0.0
+	for i := 0; i < len(values); i++ {
+		sum += values[i]
+		if i >= period {
+			sum -= values[i-period]
+		}
+		if i >= period-1 {
+			result[i] = sum / float64(period)
+		}
+	}
+
+	return result
+}
+
+// CalculateEMA calculates the Exponential Moving Average of each bar,
+// seeded with the SMA of the first period. Values before it are 0.
+func CalculateEMA(values []float64, period int) []float64 {
+	result := make([]float64, len(values))
+	if period <= 0 || len(values) < period {
+		return result
+	}
+
+	seed := 0.0
+	for i := 0; i < period; i++ {
+		seed += values[i]
+	}
+	result[period-1] = seed / float64(period)
+
+	multiplier := 2.0 / float64(period+1)
+	for i := period; i < len(values); i++ {
+		result[i] = (values[i]-result[i-1])*multiplier + result[i-1]
+	}
+
+	return result
+}
+
+// CalculateRSISeries calculates the Relative Strength Index of each bar
+// using Wilder's smoothing. Values before the first full period are 0.
+func CalculateRSISeries(closes []float64, period int) []float64 {
+	rsi := make([]float64, len(closes))
+	if period <= 0 || len(closes) < period+1 {
+		return rsi
+	}
+
+	avgGain, avgLoss := 0.0, 0.0
+	for i := 1; i <= period; i++ {
+		change := closes[i] - closes[i-1]
+		if change > 0 {
+			avgGain += change
+		} else {
+			avgLoss -= change
+		}
+	}
+	avgGain /= float64(period)
+	avgLoss /= float64(period)
+	rsi[period] = rsiValue(avgGain, avgLoss)
+
+	for i := period + 1; i < len(closes); i++ {
+		change := closes[i] - closes[i-1]
+		gain, loss := 0.0, 0.0
+		if change > 0 {
+			gain = change
+		} else {
+			loss = -change
+		}
+		avgGain = (avgGain*float64(period-1) + gain) / float64(period)
+		avgLoss = (avgLoss*float64(period-1) + loss) / float64(period)
+		rsi[i] = rsiValue(avgGain, avgLoss)
+	}
+
+	return rsi
+}
+
+func rsiValue(avgGain, avgLoss float64) float64 {
+	if avgLoss == 0 {
+		return 100
+	}
+	return 100 - (100 / (1 + avgGain/avgLoss))
+}
+
+// MACDResult contains MACD indicator values
+type MACDResult struct {
+	MACD      []float64 // Fast EMA - slow EMA
+	Signal    []float64 // EMA of the MACD line
+	Histogram []float64 // MACD - signal
+	Crossover []string  // "BUY" where MACD crosses above the signal, "SELL" below, or ""
+}
+
+// CalculateMACD calculates the MACD line, signal line, histogram and signal
+// crossovers of each bar. The MACD line starts at bar slow-1 and the signal
+// signal-1 bars later; earlier values are 0.
+func CalculateMACD(closes []float64, fast, slow, signal int) *MACDResult {
+	result := &MACDResult{
+		MACD:      make([]float64, len(closes)),
+		Signal:    make([]float64, len(closes)),
+		Histogram: make([]float64, len(closes)),
+		Crossover: make([]string, len(closes)),
+	}
+	if len(closes) < slow {
+		return result
+	}
+
+	fastEMA := CalculateEMA(closes, fast)
+	slowEMA := CalculateEMA(closes, slow)
+	for i := slow - 1; i < len(closes); i++ {
+		result.MACD[i] = fastEMA[i] - slowEMA[i]
+	}
+
+	signalEMA := CalculateEMA(result.MACD[slow-1:], signal)
+	for i, v := range signalEMA {
+		if i < signal-1 {
+			continue
+		}
+		result.Signal[slow-1+i] = v
+		result.Histogram[slow-1+i] = result.MACD[slow-1+i] - v
+	}
+
+	// Crossovers, once the signal line has a previous value to compare
+	for i := slow + signal - 1; i < len(closes); i++ {
+		prev, curr := result.Histogram[i-1], result.Histogram[i]
+		if prev <= 0 && curr > 0 {
+			result.Crossover[i] = "BUY"
+		} else if prev >= 0 && curr < 0 {
+			result.Crossover[i] = "SELL"
+		}
+	}
+
+	return result
+}
+
+// BollingerResult contains Bollinger Bands values
+type BollingerResult struct {
+	Upper  []float64
+	Middle []float64 // SMA
+	Lower  []float64
+}
+
+// CalculateBollingerBands calculates Bollinger Bands of each bar: the SMA
+// plus and minus stdDevs standard deviations. Values before the first full
+// period are 0.
+func CalculateBollingerBands(closes []float64, period int, stdDevs float64) *BollingerResult {
+	result := &BollingerResult{
+		Upper:  make([]float64, len(closes)),
+		Middle: CalculateSMA(closes, period),
+		Lower:  make([]float64, len(closes)),
+	}
+	if period <= 0 || len(closes) < period {
+		return result
+	}
+
+	for i := period - 1; i < len(closes); i++ {
+		deviation := stdDev(closes[i-period+1 : i+1])
+		result.Upper[i] = result.Middle[i] + stdDevs*deviation
+		result.Lower[i] = result.Middle[i] - stdDevs*deviation
+	}
+
+	return result
+}
+
+// IchimokuResult contains Ichimoku Cloud values. Every series has one value
+// per candle, 0 where there isn't enough history: the Senkou spans at a bar
+// are the ones plotted there (computed displacement bars earlier) and the
+// Chikou span at a bar is the close displacement bars later.
+type IchimokuResult struct {
+	Tenkan  []float64 // Conversion line
+	Kijun   []float64 // Base line
+	SenkouA []float64 // Leading span A
+	SenkouB []float64 // Leading span B
+	Chikou  []float64 // Lagging span
+	Cloud   []string  // "BULLISH" above the cloud, "BEARISH" below, "NEUTRAL" inside, "" before it forms
+
+	// The cloud projected past the last candle, one value per future bar
+	SenkouAAhead []float64
+	SenkouBAhead []float64
+}
+
+// CalculateIchimoku calculates the Ichimoku Cloud (usually 9, 26, 52 with a
+// displacement of 26)
+func CalculateIchimoku(candles []broker.Candle, tenkanPeriod, kijunPeriod, senkouBPeriod, displacement int) *IchimokuResult {
+	n := len(candles)
+	result := &IchimokuResult{
+		Tenkan:       make([]float64, n),
+		Kijun:        make([]float64, n),
+		SenkouA:      make([]float64, n),
+		SenkouB:      make([]float64, n),
+		Chikou:       make([]float64, n),
+		Cloud:        make([]string, n),
+		SenkouAAhead: make([]float64, displacement),
+		SenkouBAhead: make([]float64, displacement),
+	}
+
+	// Spans as computed at each bar, before displacement
+	leadA := make([]float64, n)
+	leadB := make([]float64, n)
+	for i := 0; i < n; i++ {
+		if i >= tenkanPeriod-1 {
+			result.Tenkan[i] = midpoint(candles[i-tenkanPeriod+1 : i+1])
+		}
+		if i >= kijunPeriod-1 {
+			result.Kijun[i] = midpoint(candles[i-kijunPeriod+1 : i+1])
+			if i >= tenkanPeriod-1 {
+				leadA[i] = (result.Tenkan[i] + result.Kijun[i]) / 2
+			}
+		}
+		if i >= senkouBPeriod-1 {
+			leadB[i] = midpoint(candles[i-senkouBPeriod+1 : i+1])
+		}
+		if i >= displacement {
+			result.Chikou[i-displacement] = candles[i].Close
+		}
+	}
+
+	for i := 0; i < n+displacement; i++ {
+		src := i - displacement
+		if src < 0 {
+			continue
+		}
+		if i < n {
+			result.SenkouA[i] = leadA[src]
+			result.SenkouB[i] = leadB[src]
+		} else {
+			result.SenkouAAhead[i-n] = leadA[src]
+			result.SenkouBAhead[i-n] = leadB[src]
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		if result.SenkouA[i] == 0 || result.SenkouB[i] == 0 {
+			continue
+		}
+		top := math.Max(result.SenkouA[i], result.SenkouB[i])
+		bottom := math.Min(result.SenkouA[i], result.SenkouB[i])
+		switch {
+		case candles[i].Close > top:
+			result.Cloud[i] = "BULLISH"
+		case candles[i].Close < bottom:
+			result.Cloud[i] = "BEARISH"
+		default:
+			result.Cloud[i] = "NEUTRAL"
+		}
+	}
+
+	return result
+}
+
+// midpoint returns the middle of the candles' high-low range
+func midpoint(candles []broker.Candle) float64 {
+	high := candles[0].High
+	low := candles[0].Low
+	for _, c := range candles[1:] {
+		high = math.Max(high, c.High)
+		low = math.Min(low, c.Low)
+	}
+	return (high + low) / 2
+}
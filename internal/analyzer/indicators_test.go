@@ -0,0 +1,177 @@
+package analyzer
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func almostEqual(a, b, tolerance float64) bool {
+	return math.Abs(a-b) <= tolerance
+}
+
+func TestCalculateRSISeries(t *testing.T) {
+	// StockCharts' RSI(14) worked example; its published values differ from
+	// unrounded Wilder smoothing by under 0.1
+	stockCharts := []float64{
+		44.34, 44.09, 44.15, 43.61, 44.33, 44.83, 45.10, 45.42, 45.84, 46.08,
+		45.89, 46.03, 45.61, 46.28, 46.28, 46.00, 46.03, 46.41, 46.22, 45.64,
+	}
+
+	tests := []struct {
+		name      string
+		closes    []float64
+		period    int
+		want      map[int]float64 // Index -> RSI; other indices before the period must be 0
+		tolerance float64
+	}{
+		{
+			name:      "stockcharts example",
+			closes:    stockCharts,
+			period:    14,
+			want:      map[int]float64{14: 70.53, 15: 66.32, 16: 66.55, 17: 69.41, 18: 66.36, 19: 57.97},
+			tolerance: 0.1,
+		},
+		{
+			name:      "only gains",
+			closes:    []float64{1, 2, 3, 4, 5},
+			period:    3,
+			want:      map[int]float64{3: 100, 4: 100},
+			tolerance: 1e-9,
+		},
+		{
+			name:      "only losses",
+			closes:    []float64{5, 4, 3, 2, 1},
+			period:    3,
+			want:      map[int]float64{3: 0, 4: 0},
+			tolerance: 1e-9,
+		},
+		{
+			name:      "equal gains and losses",
+			closes:    []float64{10, 11, 10, 11, 10},
+			period:    4,
+			want:      map[int]float64{4: 50},
+			tolerance: 1e-9,
+		},
+		{
+			name:      "too short",
+			closes:    []float64{1, 2, 3},
+			period:    3,
+			want:      map[int]float64{},
+			tolerance: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rsi := CalculateRSISeries(tt.closes, tt.period)
+			if len(rsi) != len(tt.closes) {
+				t.Fatalf("got %d values, want %d", len(rsi), len(tt.closes))
+			}
+			for i, got := range rsi {
+				want, ok := tt.want[i]
+				if !ok {
+					if i < tt.period && got != 0 {
+						t.Errorf("rsi[%d] = %v before the first full period, want 0", i, got)
+					}
+					continue
+				}
+				if !almostEqual(got, want, tt.tolerance) {
+					t.Errorf("rsi[%d] = %.4f, want %.4f", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCalculateEMA(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		period int
+		want   []float64
+	}{
+		// Seeded with the SMA of the first period, then alpha = 2/(period+1)
+		{"period 3", []float64{1, 2, 3, 4, 5}, 3, []float64{0, 0, 2, 3, 4}},
+		{"period 1 follows values", []float64{3, 1, 4}, 1, []float64{3, 1, 4}},
+		{"constant", []float64{7, 7, 7, 7}, 2, []float64{0, 7, 7, 7}},
+		{"too short", []float64{1, 2}, 3, []float64{0, 0}},
+		{"invalid period", []float64{1, 2}, 0, []float64{0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CalculateEMA(tt.values, tt.period)
+			for i := range tt.want {
+				if !almostEqual(got[i], tt.want[i], 1e-9) {
+					t.Fatalf("EMA = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCalculateMACD(t *testing.T) {
+	// A rise, a fall and a recovery. With fast 2 and slow 3 the MACD line
+	// starts at index 2, the signal line (period 2) at index 3.
+	closes := []float64{10, 11, 12, 13, 14, 13, 12, 11, 10, 11, 12, 13}
+
+	tests := []struct {
+		name          string
+		closes        []float64
+		fast, slow    int
+		signal        int
+		wantMACD      map[int]float64
+		wantSignal    map[int]float64
+		wantCrossover []string
+	}{
+		{
+			name:   "rise fall recovery",
+			closes: closes,
+			fast:   2, slow: 3, signal: 2,
+			// EMA2 - EMA3: both trail a steady rise by a constant 0.5 and 1
+			wantMACD:      map[int]float64{0: 0, 1: 0, 2: 0.5, 3: 0.5, 4: 0.5, 5: 1.0 / 6},
+			wantSignal:    map[int]float64{2: 0, 3: 0.5, 4: 0.5, 5: 5.0 / 18},
+			wantCrossover: []string{"", "", "", "", "", "SELL", "", "", "", "BUY", "", ""},
+		},
+		{
+			name:   "constant prices",
+			closes: []float64{5, 5, 5, 5, 5, 5, 5, 5},
+			fast:   2, slow: 4, signal: 3,
+			wantMACD:      map[int]float64{3: 0, 7: 0},
+			wantSignal:    map[int]float64{5: 0, 7: 0},
+			wantCrossover: []string{"", "", "", "", "", "", "", ""},
+		},
+		{
+			name:   "shorter than slow period",
+			closes: []float64{1, 2},
+			fast:   2, slow: 3, signal: 2,
+			wantMACD:      map[int]float64{0: 0, 1: 0},
+			wantSignal:    map[int]float64{0: 0, 1: 0},
+			wantCrossover: []string{"", ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := CalculateMACD(tt.closes, tt.fast, tt.slow, tt.signal)
+
+			for i, want := range tt.wantMACD {
+				if !almostEqual(result.MACD[i], want, 1e-9) {
+					t.Errorf("MACD[%d] = %v, want %v", i, result.MACD[i], want)
+				}
+			}
+			for i, want := range tt.wantSignal {
+				if !almostEqual(result.Signal[i], want, 1e-9) {
+					t.Errorf("Signal[%d] = %v, want %v", i, result.Signal[i], want)
+				}
+				if want != 0 && !almostEqual(result.Histogram[i], result.MACD[i]-want, 1e-9) {
+					t.Errorf("Histogram[%d] = %v, want MACD - signal", i, result.Histogram[i])
+				}
+			}
+			if !reflect.DeepEqual(result.Crossover, tt.wantCrossover) {
+				t.Errorf("Crossover = %q, want %q", result.Crossover, tt.wantCrossover)
+			}
+		})
+	}
+}
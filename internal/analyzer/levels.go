@@ -0,0 +1,217 @@
+package analyzer
+
+import (
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// FibonacciRetracementRatios are the retracement levels of a swing
+var FibonacciRetracementRatios = []float64{0.236, 0.382, 0.5, 0.618, 0.786}
+
+// FibonacciExtensionRatios are the extension levels beyond a swing
+var FibonacciExtensionRatios = []float64{1.272, 1.618, 2.0, 2.618}
+
+// FibonacciLevel is a price at a Fibonacci ratio of a swing
+type FibonacciLevel struct {
+	Ratio float64 `json:"ratio"`
+	Price float64 `json:"price"`
+}
+
+// FibonacciLevels are the retracement and extension levels of the dominant
+// swing: the move between the highest high and lowest low of the window
+type FibonacciLevels struct {
+	Direction     string           `json:"direction"` // UP (low then high) or DOWN (high then low)
+	SwingHigh     float64          `json:"swing_high"`
+	SwingLow      float64          `json:"swing_low"`
+	SwingHighDate time.Time        `json:"swing_high_date"`
+	SwingLowDate  time.Time        `json:"swing_low_date"`
+	Retracements  []FibonacciLevel `json:"retracements"` // Back toward the swing's start
+	Extensions    []FibonacciLevel `json:"extensions"`   // Beyond the swing's end
+}
+
+// CalculateFibonacciLevels finds the dominant swing over the last lookback
+// candles (all of them if lookback <= 0) and computes its Fibonacci levels.
+// Returns nil if the window has no range.
+func CalculateFibonacciLevels(candles []broker.Candle, lookback int) *FibonacciLevels {
+	if lookback > 0 && len(candles) > lookback {
+		candles = candles[len(candles)-lookback:]
+	}
+	if len(candles) < 2 {
+		return nil
+	}
+
+	highIndex, lowIndex := 0, 0
+	for i, c := range candles {
+		if c.High > candles[highIndex].High {
+			highIndex = i
+		}
+		if c.Low < candles[lowIndex].Low {
+			lowIndex = i
+		}
+	}
+
+	high := candles[highIndex].High
+	low := candles[lowIndex].Low
+	swing := high - low
+	if swing <= 0 {
+		return nil
+	}
+
+	levels := &FibonacciLevels{
+		Direction:     "UP",
+		SwingHigh:     high,
+		SwingLow:      low,
+		SwingHighDate: candles[highIndex].Date,
+		SwingLowDate:  candles[lowIndex].Date,
+		Retracements:  make([]FibonacciLevel, len(FibonacciRetracementRatios)),
+		Extensions:    make([]FibonacciLevel, len(FibonacciExtensionRatios)),
+	}
+	if highIndex < lowIndex {
+		levels.Direction = "DOWN"
+	}
+
+	for i, ratio := range FibonacciRetracementRatios {
+		price := high - ratio*swing // Pullback from the high
+		if levels.Direction == "DOWN" {
+			price = low + ratio*swing // Bounce from the low
+		}
+		levels.Retracements[i] = FibonacciLevel{Ratio: ratio, Price: price}
+	}
+	for i, ratio := range FibonacciExtensionRatios {
+		price := low + ratio*swing
+		if levels.Direction == "DOWN" {
+			price = high - ratio*swing
+		}
+		levels.Extensions[i] = FibonacciLevel{Ratio: ratio, Price: price}
+	}
+
+	return levels
+}
+
+// FindSupportResistance returns the three lowest lows (support) and three
+// highest highs (resistance) of the last 20 bars
+func FindSupportResistance(highs, lows []float64) ([]float64, []float64) {
+	// Get recent 20-day highs and lows
+	recentHighs := highs
+	recentLows := lows
+	if len(highs) > 20 {
+		recentHighs = highs[len(highs)-20:]
+		recentLows = lows[len(lows)-20:]
+	}
+
+	// Find top 3 highs and lows
+	resistance := findTopN(recentHighs, 3)
+	support := findBottomN(recentLows, 3)
+
+	return support, resistance
+}
+
+// PivotLevels are one method's pivot and support/resistance levels
+type PivotLevels struct {
+	Pivot float64 `json:"pivot"`
+	R1    float64 `json:"r1"`
+	R2    float64 `json:"r2"`
+	R3    float64 `json:"r3,omitempty"`
+	R4    float64 `json:"r4,omitempty"`
+	S1    float64 `json:"s1"`
+	S2    float64 `json:"s2"`
+	S3    float64 `json:"s3,omitempty"`
+	S4    float64 `json:"s4,omitempty"`
+}
+
+// PivotPoints are the classic, Camarilla and Woodie pivots of a session,
+// computed from the prior session's high, low and close
+type PivotPoints struct {
+	SessionDate time.Time   `json:"session_date"` // Start of the prior session used
+	High        float64     `json:"high"`
+	Low         float64     `json:"low"`
+	Close       float64     `json:"close"`
+	Classic     PivotLevels `json:"classic"`
+	Camarilla   PivotLevels `json:"camarilla"`
+	Woodie      PivotLevels `json:"woodie"`
+}
+
+// CalculatePivotPoints computes pivot points from a prior session's candle
+func CalculatePivotPoints(session broker.Candle) PivotPoints {
+	high, low, close := session.High, session.Low, session.Close
+	rng := high - low
+
+	pivot := (high + low + close) / 3
+	classic := PivotLevels{
+		Pivot: pivot,
+		R1:    2*pivot - low,
+		R2:    pivot + rng,
+		R3:    high + 2*(pivot-low),
+		S1:    2*pivot - high,
+		S2:    pivot - rng,
+		S3:    low - 2*(high-pivot),
+	}
+
+	camarilla := PivotLevels{
+		Pivot: pivot,
+		R1:    close + rng*1.1/12,
+		R2:    close + rng*1.1/6,
+		R3:    close + rng*1.1/4,
+		R4:    close + rng*1.1/2,
+		S1:    close - rng*1.1/12,
+		S2:    close - rng*1.1/6,
+		S3:    close - rng*1.1/4,
+		S4:    close - rng*1.1/2,
+	}
+
+	woodiePivot := (high + low + 2*close) / 4
+	woodie := PivotLevels{
+		Pivot: woodiePivot,
+		R1:    2*woodiePivot - low,
+		R2:    woodiePivot + rng,
+		S1:    2*woodiePivot - high,
+		S2:    woodiePivot - rng,
+	}
+
+	return PivotPoints{
+		SessionDate: session.Date,
+		High:        high,
+		Low:         low,
+		Close:       close,
+		Classic:     classic,
+		Camarilla:   camarilla,
+		Woodie:      woodie,
+	}
+}
+
+// AggregateWeekly combines daily candles (oldest first) into weekly candles
+// starting on Monday (IST)
+func AggregateWeekly(daily []broker.Candle) []broker.Candle {
+	ist := broker.IST()
+
+	weekly := []broker.Candle{}
+	var weekStart time.Time
+	for _, c := range daily {
+		day := c.Date.In(ist)
+		start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, ist).
+			AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+
+		if len(weekly) == 0 || !start.Equal(weekStart) {
+			weekStart = start
+			weekly = append(weekly, broker.Candle{
+				Date: start,
+				Open: c.Open,
+				High: c.High,
+				Low:  c.Low,
+			})
+		}
+
+		w := &weekly[len(weekly)-1]
+		if c.High > w.High {
+			w.High = c.High
+		}
+		if c.Low < w.Low {
+			w.Low = c.Low
+		}
+		w.Close = c.Close
+		w.Volume += c.Volume
+	}
+
+	return weekly
+}
@@ -0,0 +1,126 @@
+package analyzer
+
+import (
+	"errors"
+	"math"
+)
+
+// Option types, as in the instrument master
+const (
+	OptionCall = "CE"
+	OptionPut  = "PE"
+)
+
+// ErrNoImpliedVolatility is returned when an option's price is outside the
+// range any volatility can produce, e.g. below intrinsic value
+var ErrNoImpliedVolatility = errors.New("price has no implied volatility")
+
+// Implied volatility is searched for between these bounds
+const (
+	minVolatility = 1e-4
+	maxVolatility = 5.0
+)
+
+// Greeks are an option's price sensitivities. Theta is per calendar day and
+// vega per volatility point (1%), the way option chains quote them.
+type Greeks struct {
+	Delta float64 `json:"delta"`
+	Gamma float64 `json:"gamma"`
+	Theta float64 `json:"theta"`
+	Vega  float64 `json:"vega"`
+}
+
+// normCDF is the standard normal cumulative distribution
+func normCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+// normPDF is the standard normal density
+func normPDF(x float64) float64 {
+	return math.Exp(-x*x/2) / math.Sqrt(2*math.Pi)
+}
+
+// d1d2 returns the Black-Scholes d1 and d2 terms
+func d1d2(spot, strike, years, rate, vol float64) (float64, float64) {
+	sqrtT := math.Sqrt(years)
+	d1 := (math.Log(spot/strike) + (rate+vol*vol/2)*years) / (vol * sqrtT)
+	return d1, d1 - vol*sqrtT
+}
+
+// BlackScholesPrice prices a European option. years is the time to expiry,
+// rate the continuously compounded risk-free rate and vol the annualized
+// volatility, both as fractions.
+func BlackScholesPrice(optionType string, spot, strike, years, rate, vol float64) float64 {
+	discount := math.Exp(-rate * years)
+	if years <= 0 || vol <= 0 {
+		if optionType == OptionPut {
+			return math.Max(strike*discount-spot, 0)
+		}
+		return math.Max(spot-strike*discount, 0)
+	}
+
+	d1, d2 := d1d2(spot, strike, years, rate, vol)
+	if optionType == OptionPut {
+		return strike*discount*normCDF(-d2) - spot*normCDF(-d1)
+	}
+	return spot*normCDF(d1) - strike*discount*normCDF(d2)
+}
+
+// BlackScholesGreeks returns a European option's Greeks, with the arguments
+// of BlackScholesPrice. An expired option, or one without volatility, only
+// has delta: 1 (-1 for puts) in the money, 0 otherwise.
+func BlackScholesGreeks(optionType string, spot, strike, years, rate, vol float64) Greeks {
+	if years <= 0 || vol <= 0 || spot <= 0 || strike <= 0 {
+		var greeks Greeks
+		switch {
+		case optionType == OptionPut && spot < strike:
+			greeks.Delta = -1
+		case optionType != OptionPut && spot > strike:
+			greeks.Delta = 1
+		}
+		return greeks
+	}
+
+	sqrtT := math.Sqrt(years)
+	discount := math.Exp(-rate * years)
+	d1, d2 := d1d2(spot, strike, years, rate, vol)
+	greeks := Greeks{
+		Gamma: normPDF(d1) / (spot * vol * sqrtT),
+		Vega:  spot * normPDF(d1) * sqrtT / 100,
+	}
+
+	decay := -spot * normPDF(d1) * vol / (2 * sqrtT)
+	if optionType == OptionPut {
+		greeks.Delta = normCDF(d1) - 1
+		greeks.Theta = (decay + rate*strike*discount*normCDF(-d2)) / 365
+	} else {
+		greeks.Delta = normCDF(d1)
+		greeks.Theta = (decay - rate*strike*discount*normCDF(d2)) / 365
+	}
+	return greeks
+}
+
+// ImpliedVolatility returns the volatility at which the Black-Scholes price
+// of an option matches price, found by bisection since the price rises
+// monotonically with volatility
+func ImpliedVolatility(optionType string, price, spot, strike, years, rate float64) (float64, error) {
+	if price <= 0 || spot <= 0 || strike <= 0 || years <= 0 {
+		return 0, ErrNoImpliedVolatility
+	}
+
+	low, high := minVolatility, maxVolatility
+	if price < BlackScholesPrice(optionType, spot, strike, years, rate, low) ||
+		price > BlackScholesPrice(optionType, spot, strike, years, rate, high) {
+		return 0, ErrNoImpliedVolatility
+	}
+
+	for i := 0; i < 100 && high-low > 1e-6; i++ {
+		mid := (low + high) / 2
+		if BlackScholesPrice(optionType, spot, strike, years, rate, mid) < price {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return (low + high) / 2, nil
+}
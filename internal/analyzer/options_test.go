@@ -0,0 +1,126 @@
+package analyzer
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBlackScholesPrice(t *testing.T) {
+	// Hull's worked example: S=42, K=40, r=10%, sigma=20%, T=0.5
+	tests := []struct {
+		name       string
+		optionType string
+		spot       float64
+		years      float64
+		vol        float64
+		want       float64
+	}{
+		{"call", OptionCall, 42, 0.5, 0.2, 4.76},
+		{"put", OptionPut, 42, 0.5, 0.2, 0.81},
+		{"expired call", OptionCall, 42, 0, 0.2, 2},
+		{"expired put out of the money", OptionPut, 42, 0, 0.2, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BlackScholesPrice(tt.optionType, tt.spot, 40, tt.years, 0.1, tt.vol)
+			if !almostEqual(got, tt.want, 0.01) {
+				t.Errorf("price = %.4f, want %.2f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImpliedVolatility(t *testing.T) {
+	tests := []struct {
+		name       string
+		optionType string
+		strike     float64
+		vol        float64
+		price      float64 // Set to price directly instead of from vol
+		err        error
+	}{
+		{name: "atm call", optionType: OptionCall, strike: 22000, vol: 0.14},
+		{name: "otm put", optionType: OptionPut, strike: 21000, vol: 0.18},
+		{name: "deep itm call", optionType: OptionCall, strike: 18000, vol: 0.25},
+		{name: "below intrinsic", optionType: OptionCall, strike: 21000, price: 500, err: ErrNoImpliedVolatility},
+		{name: "no price", optionType: OptionPut, strike: 22000, price: -1, err: ErrNoImpliedVolatility},
+	}
+
+	const spot, years, rate = 22000.0, 30.0 / 365, 0.065
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price := tt.price
+			if price == 0 {
+				price = BlackScholesPrice(tt.optionType, spot, tt.strike, years, rate, tt.vol)
+			}
+
+			got, err := ImpliedVolatility(tt.optionType, price, spot, tt.strike, years, rate)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && !almostEqual(got, tt.vol, 1e-4) {
+				t.Errorf("IV = %.6f, want %.4f", got, tt.vol)
+			}
+		})
+	}
+}
+
+func TestBlackScholesGreeks(t *testing.T) {
+	tests := []struct {
+		name       string
+		optionType string
+		spot       float64
+		years      float64
+		vol        float64
+		want       Greeks
+	}{
+		// Hull's worked example: S=42, K=40, r=10%, sigma=20%, T=0.5
+		{"call", OptionCall, 42, 0.5, 0.2, Greeks{Delta: 0.7791, Gamma: 0.0499, Theta: -0.01249, Vega: 0.0881}},
+		{"put", OptionPut, 42, 0.5, 0.2, Greeks{Delta: -0.2209, Gamma: 0.0499, Theta: -0.00206, Vega: 0.0881}},
+		{"expired call in the money", OptionCall, 42, 0, 0.2, Greeks{Delta: 1}},
+		{"expired put out of the money", OptionPut, 42, 0, 0.2, Greeks{}},
+		{"expired put in the money", OptionPut, 38, 0, 0.2, Greeks{Delta: -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BlackScholesGreeks(tt.optionType, tt.spot, 40, tt.years, 0.1, tt.vol)
+			if !almostEqual(got.Delta, tt.want.Delta, 1e-4) || !almostEqual(got.Gamma, tt.want.Gamma, 1e-4) ||
+				!almostEqual(got.Theta, tt.want.Theta, 1e-4) || !almostEqual(got.Vega, tt.want.Vega, 1e-4) {
+				t.Errorf("greeks = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlackScholesGreeksMatchPrices(t *testing.T) {
+	const spot, strike, years, rate, vol = 22000.0, 22200.0, 20.0 / 365, 0.065, 0.16
+	for _, optionType := range []string{OptionCall, OptionPut} {
+		t.Run(optionType, func(t *testing.T) {
+			price := func(spot, years, vol float64) float64 {
+				return BlackScholesPrice(optionType, spot, strike, years, rate, vol)
+			}
+			greeks := BlackScholesGreeks(optionType, spot, strike, years, rate, vol)
+
+			const h = 1.0
+			delta := (price(spot+h, years, vol) - price(spot-h, years, vol)) / (2 * h)
+			gamma := (price(spot+h, years, vol) - 2*price(spot, years, vol) + price(spot-h, years, vol)) / (h * h)
+			theta := price(spot, years-1.0/365, vol) - price(spot, years, vol)
+			vega := price(spot, years, vol+0.01) - price(spot, years, vol)
+
+			if !almostEqual(greeks.Delta, delta, 1e-4) {
+				t.Errorf("delta = %.5f, finite difference %.5f", greeks.Delta, delta)
+			}
+			if !almostEqual(greeks.Gamma, gamma, 1e-5) {
+				t.Errorf("gamma = %.6f, finite difference %.6f", greeks.Gamma, gamma)
+			}
+			if !almostEqual(greeks.Theta, theta, 0.2) {
+				t.Errorf("theta = %.3f, one day's decay %.3f", greeks.Theta, theta)
+			}
+			if !almostEqual(greeks.Vega, vega, 0.2) {
+				t.Errorf("vega = %.3f, one point's change %.3f", greeks.Vega, vega)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package analyzer
 
 import (
+	"fmt"
 	"math"
 	"time"
 
@@ -53,6 +54,15 @@ func (ps *PatternScanner) ScanAllPatterns(candles []broker.Candle) []Pattern {
 	patterns = append(patterns, ps.DetectTriangle(candles)...)
 	patterns = append(patterns, ps.DetectFlag(candles)...)
 	patterns = append(patterns, ps.DetectWedge(candles)...)
+	patterns = append(patterns, ps.DetectCupAndHandle(candles)...)
+	patterns = append(patterns, ps.DetectRoundingBottom(candles)...)
+	patterns = append(patterns, ps.DetectTripleTopBottom(candles)...)
+
+	// Gap patterns
+	patterns = append(patterns, ps.DetectGaps(candles)...)
+
+	// Indicator divergences
+	patterns = append(patterns, ps.DetectDivergences(candles)...)
 
 	// Filter by minimum confidence
 	filtered := []Pattern{}
@@ -717,6 +727,263 @@ func (ps *PatternScanner) DetectWedge(candles []broker.Candle) []Pattern {
 	return patterns
 }
 
+// DetectCupAndHandle detects Cup and Handle patterns (bullish continuation):
+// a rounded decline and recovery between two roughly equal rims, followed
+// by a shallow pullback (the handle) that holds in the upper half of the cup
+func (ps *PatternScanner) DetectCupAndHandle(candles []broker.Candle) []Pattern {
+	patterns := []Pattern{}
+	minCupLength := 15
+	maxCupLength := 80
+
+	if len(candles) < minCupLength+3 {
+		return patterns
+	}
+
+	peaks := findLocalPeaks(candles, 5)
+	for i := 1; i < len(peaks); i++ {
+		left := peaks[i-1]
+		right := peaks[i]
+		cupLength := right.Index - left.Index
+		if cupLength < minCupLength || cupLength > maxCupLength {
+			continue
+		}
+
+		// Rims should be roughly equal (within 3%)
+		rimDiff := math.Abs(left.High-right.High) / left.High
+		if rimDiff > 0.03 {
+			continue
+		}
+		rim := math.Max(left.High, right.High)
+
+		bottomIndex := left.Index
+		for j := left.Index; j <= right.Index; j++ {
+			if candles[j].Low < candles[bottomIndex].Low {
+				bottomIndex = j
+			}
+		}
+		bottom := candles[bottomIndex].Low
+		depth := (rim - bottom) / rim
+		if depth < 0.05 || depth > 0.5 {
+			continue
+		}
+
+		// A cup is U-shaped: the low sits in the middle of the cup and price
+		// spends a good part of the cup in its lower half
+		position := float64(bottomIndex-left.Index) / float64(cupLength)
+		if position < 0.25 || position > 0.75 {
+			continue
+		}
+		midpoint := bottom + (rim-bottom)/2
+		lowerHalf := 0
+		for j := left.Index; j <= right.Index; j++ {
+			if candles[j].Close < midpoint {
+				lowerHalf++
+			}
+		}
+		roundness := float64(lowerHalf) / float64(cupLength+1)
+		if roundness < 0.3 {
+			continue
+		}
+
+		// Handle: the lowest point of the pullback after the right rim, at
+		// least two bars on and within a third of the cup's length
+		handleEnd := right.Index + cupLength/3
+		if handleEnd < right.Index+5 {
+			handleEnd = right.Index + 5
+		}
+		if handleEnd > len(candles)-1 {
+			handleEnd = len(candles) - 1
+		}
+		handleIndex := -1
+		for j := right.Index + 1; j <= handleEnd; j++ {
+			if candles[j].High > rim {
+				break // Broke out before forming a handle
+			}
+			if handleIndex < 0 || candles[j].Low < candles[handleIndex].Low {
+				handleIndex = j
+			}
+		}
+		if handleIndex < right.Index+2 {
+			continue
+		}
+		handleLow := candles[handleIndex].Low
+		retrace := (right.High - handleLow) / (rim - bottom)
+		if retrace < 0.1 || retrace > 0.5 {
+			continue
+		}
+
+		confidence := 0.7
+		if rimDiff < 0.01 {
+			confidence += 0.05
+		}
+		if position >= 0.35 && position <= 0.65 {
+			confidence += 0.05
+		}
+		if retrace <= 0.33 {
+			confidence += 0.05
+		}
+
+		patterns = append(patterns, Pattern{
+			Type:        "Cup and Handle",
+			Category:    "chart",
+			Signal:      "bullish",
+			Confidence:  confidence,
+			StartIndex:  left.Index,
+			EndIndex:    handleIndex,
+			StartDate:   candles[left.Index].Date,
+			EndDate:     candles[handleIndex].Date,
+			Description: fmt.Sprintf("Bullish continuation: %.1f%% deep cup with handle, breakout above %.2f", depth*100, rim),
+			KeyLevels:   []float64{rim, bottom, handleLow},
+		})
+	}
+
+	return patterns
+}
+
+// DetectRoundingBottom detects Rounding Bottom (saucer) patterns (bullish
+// reversal): closes that trace a gradual U, fitted with a parabola
+func (ps *PatternScanner) DetectRoundingBottom(candles []broker.Candle) []Pattern {
+	patterns := []Pattern{}
+	length := 30
+
+	if len(candles) < length {
+		return patterns
+	}
+
+	closes := make([]float64, len(candles))
+	for i, c := range candles {
+		closes[i] = c.Close
+	}
+
+	for i := length; i <= len(candles); i++ {
+		start := i - length
+		window := closes[start:i]
+
+		a, b, _, rSquared := fitParabola(window)
+		if a <= 0 || rSquared < 0.8 {
+			continue
+		}
+
+		// The low of the curve should be in the middle of the window
+		vertex := -b / (2 * a)
+		if vertex < float64(length)*0.3 || vertex > float64(length)*0.7 {
+			continue
+		}
+
+		rim := findHighestBetween(candles, start, start+length/5)
+		bottom := findLowestBetween(candles, start, i-1)
+		depth := (rim - bottom) / rim
+		if depth < 0.05 {
+			continue
+		}
+
+		confidence := math.Min(0.9, 0.65+(rSquared-0.8)*1.25)
+		patterns = append(patterns, Pattern{
+			Type:        "Rounding Bottom",
+			Category:    "chart",
+			Signal:      "bullish",
+			Confidence:  confidence,
+			StartIndex:  start,
+			EndIndex:    i - 1,
+			StartDate:   candles[start].Date,
+			EndDate:     candles[i-1].Date,
+			Description: fmt.Sprintf("Bullish reversal: %.1f%% deep saucer, breakout above %.2f", depth*100, rim),
+			KeyLevels:   []float64{rim, bottom},
+		})
+
+		// Skip ahead so one saucer isn't reported for every overlapping window
+		i += length / 2
+	}
+
+	return patterns
+}
+
+// DetectTripleTopBottom detects Triple Top/Bottom patterns: three roughly
+// equal peaks (bearish) or troughs (bullish) with the neckline between them
+func (ps *PatternScanner) DetectTripleTopBottom(candles []broker.Candle) []Pattern {
+	patterns := []Pattern{}
+	minPatternLength := 15
+	maxPatternLength := 80
+
+	if len(candles) < minPatternLength {
+		return patterns
+	}
+
+	// Triple Top
+	peaks := findLocalPeaks(candles, 3)
+	for i := 2; i < len(peaks); i++ {
+		first, second, third := peaks[i-2], peaks[i-1], peaks[i]
+		span := third.Index - first.Index
+		if span < minPatternLength || span > maxPatternLength {
+			continue
+		}
+
+		level := (first.High + second.High + third.High) / 3
+		spread := (math.Max(first.High, math.Max(second.High, third.High)) -
+			math.Min(first.High, math.Min(second.High, third.High))) / level
+		if spread > 0.025 {
+			continue
+		}
+
+		neckline := findLowestBetween(candles, first.Index, third.Index)
+		depth := (level - neckline) / level
+		if depth < 0.02 {
+			continue
+		}
+
+		patterns = append(patterns, Pattern{
+			Type:        "Triple Top",
+			Category:    "chart",
+			Signal:      "bearish",
+			Confidence:  0.85 - spread*8,
+			StartIndex:  first.Index,
+			EndIndex:    third.Index,
+			StartDate:   candles[first.Index].Date,
+			EndDate:     candles[third.Index].Date,
+			Description: fmt.Sprintf("Bearish reversal with three peaks near %.2f, breakdown below %.2f", level, neckline),
+			KeyLevels:   []float64{level, neckline},
+		})
+	}
+
+	// Triple Bottom
+	troughs := findLocalTroughs(candles, 3)
+	for i := 2; i < len(troughs); i++ {
+		first, second, third := troughs[i-2], troughs[i-1], troughs[i]
+		span := third.Index - first.Index
+		if span < minPatternLength || span > maxPatternLength {
+			continue
+		}
+
+		level := (first.Low + second.Low + third.Low) / 3
+		spread := (math.Max(first.Low, math.Max(second.Low, third.Low)) -
+			math.Min(first.Low, math.Min(second.Low, third.Low))) / level
+		if spread > 0.025 {
+			continue
+		}
+
+		neckline := findHighestBetween(candles, first.Index, third.Index)
+		depth := (neckline - level) / level
+		if depth < 0.02 {
+			continue
+		}
+
+		patterns = append(patterns, Pattern{
+			Type:        "Triple Bottom",
+			Category:    "chart",
+			Signal:      "bullish",
+			Confidence:  0.85 - spread*8,
+			StartIndex:  first.Index,
+			EndIndex:    third.Index,
+			StartDate:   candles[first.Index].Date,
+			EndDate:     candles[third.Index].Date,
+			Description: fmt.Sprintf("Bullish reversal with three troughs near %.2f, breakout above %.2f", level, neckline),
+			KeyLevels:   []float64{level, neckline},
+		})
+	}
+
+	return patterns
+}
+
 // ============================================================================
 // HELPER FUNCTIONS
 // ============================================================================
@@ -855,3 +1122,47 @@ func findLowerTrendline(candles []broker.Candle) (slope, intercept float64) {
 
 	return slope, intercept
 }
+
+// fitParabola fits y = a*x² + b*x + c to the values (x = index) by least
+// squares and returns the coefficients and R²
+func fitParabola(ys []float64) (a, b, c, rSquared float64) {
+	var n, sx, sx2, sx3, sx4, sy, sxy, sx2y float64
+	for i, y := range ys {
+		x := float64(i)
+		n++
+		sx += x
+		sx2 += x * x
+		sx3 += x * x * x
+		sx4 += x * x * x * x
+		sy += y
+		sxy += x * y
+		sx2y += x * x * y
+	}
+
+	// Solve the normal equations with Cramer's rule
+	det := func(m [3][3]float64) float64 {
+		return m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+			m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+			m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+	}
+	d := det([3][3]float64{{sx4, sx3, sx2}, {sx3, sx2, sx}, {sx2, sx, n}})
+	if d == 0 {
+		return 0, 0, 0, 0
+	}
+	a = det([3][3]float64{{sx2y, sx3, sx2}, {sxy, sx2, sx}, {sy, sx, n}}) / d
+	b = det([3][3]float64{{sx4, sx2y, sx2}, {sx3, sxy, sx}, {sx2, sy, n}}) / d
+	c = det([3][3]float64{{sx4, sx3, sx2y}, {sx3, sx2, sxy}, {sx2, sx, sy}}) / d
+
+	mean := sy / n
+	var ssTotal, ssResidual float64
+	for i, y := range ys {
+		x := float64(i)
+		predicted := a*x*x + b*x + c
+		ssTotal += (y - mean) * (y - mean)
+		ssResidual += (y - predicted) * (y - predicted)
+	}
+	if ssTotal == 0 {
+		return a, b, c, 0
+	}
+	return a, b, c, 1 - ssResidual/ssTotal
+}
@@ -0,0 +1,101 @@
+package analyzer
+
+import (
+	"sort"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// RelativeStrength compares a symbol's returns with a benchmark's
+type RelativeStrength struct {
+	Symbol string `json:"symbol"`
+
+	// Per lookback (bars): the symbol's return and its RS, 100 * (1 + return)
+	// / (1 + benchmark return), so 100 means in line with the benchmark
+	Returns map[int]float64 `json:"returns"`
+	RS      map[int]float64 `json:"rs"`
+
+	Score      float64 `json:"score"`      // Average RS over the lookbacks
+	Rank       int     `json:"rank"`       // 1 = strongest
+	Percentile float64 `json:"percentile"` // Share of the group with a lower score (0-100)
+}
+
+// CalculateRelativeStrength scores each symbol's daily candles (oldest
+// first) against the benchmark's over each lookback and ranks them,
+// strongest first. Symbols without enough history for every lookback are
+// returned as skipped.
+func CalculateRelativeStrength(candles map[string][]broker.Candle, benchmark []broker.Candle, lookbacks []int) ([]RelativeStrength, []string) {
+	results := []RelativeStrength{}
+	skipped := []string{}
+
+	benchReturns := make(map[int]float64, len(lookbacks))
+	for _, lookback := range lookbacks {
+		r, ok := periodReturn(benchmark, lookback)
+		if !ok {
+			// Nothing can be compared without the benchmark
+			for symbol := range candles {
+				skipped = append(skipped, symbol)
+			}
+			sort.Strings(skipped)
+			return results, skipped
+		}
+		benchReturns[lookback] = r
+	}
+
+	for symbol, bars := range candles {
+		rs := RelativeStrength{
+			Symbol:  symbol,
+			Returns: make(map[int]float64, len(lookbacks)),
+			RS:      make(map[int]float64, len(lookbacks)),
+		}
+
+		ok := true
+		for _, lookback := range lookbacks {
+			r, enough := periodReturn(bars, lookback)
+			if !enough {
+				ok = false
+				break
+			}
+			rs.Returns[lookback] = r * 100
+			rs.RS[lookback] = 100 * (1 + r) / (1 + benchReturns[lookback])
+			rs.Score += rs.RS[lookback]
+		}
+		if !ok {
+			skipped = append(skipped, symbol)
+			continue
+		}
+
+		rs.Score /= float64(len(lookbacks))
+		results = append(results, rs)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Symbol < results[j].Symbol
+	})
+	for i := range results {
+		results[i].Rank = i + 1
+		if len(results) > 1 {
+			results[i].Percentile = float64(len(results)-1-i) / float64(len(results)-1) * 100
+		} else {
+			results[i].Percentile = 100
+		}
+	}
+	sort.Strings(skipped)
+
+	return results, skipped
+}
+
+// periodReturn returns the fractional return over the last lookback bars
+func periodReturn(candles []broker.Candle, lookback int) (float64, bool) {
+	if lookback <= 0 || len(candles) <= lookback {
+		return 0, false
+	}
+	start := candles[len(candles)-1-lookback].Close
+	if start == 0 {
+		return 0, false
+	}
+	return candles[len(candles)-1].Close/start - 1, true
+}
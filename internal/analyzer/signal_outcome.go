@@ -0,0 +1,90 @@
+package analyzer
+
+import (
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// Signal outcomes
+const (
+	OutcomeOpen    = "OPEN"    // Neither level hit yet
+	OutcomeTarget  = "TARGET"  // Take profit hit first
+	OutcomeStop    = "STOP"    // Stop loss hit first
+	OutcomeExpired = "EXPIRED" // Neither hit before the signal expired; closed at the last price
+)
+
+// SignalOutcome is how a signal played out
+type SignalOutcome struct {
+	Outcome   string    `json:"outcome"`
+	ExitPrice float64   `json:"exit_price,omitempty"`
+	ExitAt    time.Time `json:"exit_at,omitempty"`
+	RMultiple float64   `json:"r_multiple"` // Profit in units of the entry-to-stop risk
+}
+
+// RebaseSignal moves a signal's entry to price, keeping its stop loss and
+// take profit at the same relative distance. The 52-day signals are
+// relative to a reference price (SMA 20), not the market.
+func RebaseSignal(s Signal, price float64) Signal {
+	if s.EntryPrice <= 0 || price <= 0 {
+		return s
+	}
+	ratio := price / s.EntryPrice
+	s.EntryPrice = price
+	s.StopLoss *= ratio
+	s.TakeProfit *= ratio
+	return s
+}
+
+// EvaluateSignal walks the candles since a signal, oldest first, for the
+// first one reaching its stop loss or take profit. A candle reaching both
+// counts as the stop, and one opening beyond a level exits at its open.
+// Without either, the signal is OutcomeExpired at the last close if expired,
+// else OutcomeOpen.
+func EvaluateSignal(s Signal, candles []broker.Candle, expired bool) SignalOutcome {
+	direction := 1.0
+	if s.Type == "SELL" {
+		direction = -1
+	}
+	risk := direction * (s.EntryPrice - s.StopLoss)
+
+	closeAt := func(outcome string, price float64, at time.Time) SignalOutcome {
+		result := SignalOutcome{Outcome: outcome, ExitPrice: price, ExitAt: at}
+		if risk > 0 {
+			result.RMultiple = direction * (price - s.EntryPrice) / risk
+		}
+		return result
+	}
+
+	for _, c := range candles {
+		// Price moving against the signal: the low for a BUY, the high for a SELL
+		adverse, favourable := c.Low, c.High
+		if direction < 0 {
+			adverse, favourable = c.High, c.Low
+		}
+
+		if s.StopLoss > 0 && direction*(adverse-s.StopLoss) <= 0 {
+			exit := s.StopLoss
+			if direction*(c.Open-s.StopLoss) < 0 {
+				exit = c.Open
+			}
+			return closeAt(OutcomeStop, exit, c.Date)
+		}
+		if s.TakeProfit > 0 && direction*(favourable-s.TakeProfit) >= 0 {
+			exit := s.TakeProfit
+			if direction*(c.Open-s.TakeProfit) > 0 {
+				exit = c.Open
+			}
+			return closeAt(OutcomeTarget, exit, c.Date)
+		}
+	}
+
+	if !expired {
+		return SignalOutcome{Outcome: OutcomeOpen}
+	}
+	if len(candles) == 0 {
+		return SignalOutcome{Outcome: OutcomeExpired, ExitPrice: s.EntryPrice}
+	}
+	last := candles[len(candles)-1]
+	return closeAt(OutcomeExpired, last.Close, last.Date)
+}
@@ -0,0 +1,102 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+func TestRebaseSignal(t *testing.T) {
+	signal := Signal{Type: "BUY", EntryPrice: 100, StopLoss: 97, TakeProfit: 105}
+	got := RebaseSignal(signal, 200)
+	if got.EntryPrice != 200 || got.StopLoss != 194 || got.TakeProfit != 210 {
+		t.Errorf("RebaseSignal() = %+v, want entry 200, stop 194, target 210", got)
+	}
+	if got := RebaseSignal(Signal{EntryPrice: 0, StopLoss: 5}, 200); got.StopLoss != 5 {
+		t.Errorf("signal without an entry was rebased: %+v", got)
+	}
+}
+
+func TestEvaluateSignal(t *testing.T) {
+	start := time.Date(2024, 1, 30, 10, 0, 0, 0, time.UTC)
+	bar := func(minute int, open, high, low, close float64) broker.Candle {
+		return broker.Candle{Date: start.Add(time.Duration(minute) * time.Minute), Open: open, High: high, Low: low, Close: close}
+	}
+	buy := Signal{Type: "BUY", EntryPrice: 100, StopLoss: 98, TakeProfit: 104}
+	sell := Signal{Type: "SELL", EntryPrice: 100, StopLoss: 102, TakeProfit: 96}
+
+	tests := []struct {
+		name    string
+		signal  Signal
+		candles []broker.Candle
+		expired bool
+		want    SignalOutcome
+	}{
+		{
+			name:    "buy hits target",
+			signal:  buy,
+			candles: []broker.Candle{bar(0, 100, 101, 99, 100.5), bar(1, 101, 104.5, 100.8, 104)},
+			want:    SignalOutcome{Outcome: OutcomeTarget, ExitPrice: 104, ExitAt: start.Add(time.Minute), RMultiple: 2},
+		},
+		{
+			name:    "buy hits stop",
+			signal:  buy,
+			candles: []broker.Candle{bar(0, 100, 101, 97.5, 98)},
+			want:    SignalOutcome{Outcome: OutcomeStop, ExitPrice: 98, ExitAt: start, RMultiple: -1},
+		},
+		{
+			name:    "both in one bar counts as the stop",
+			signal:  buy,
+			candles: []broker.Candle{bar(0, 100, 105, 97, 101)},
+			want:    SignalOutcome{Outcome: OutcomeStop, ExitPrice: 98, ExitAt: start, RMultiple: -1},
+		},
+		{
+			name:    "gap below the stop exits at the open",
+			signal:  buy,
+			candles: []broker.Candle{bar(0, 100, 100.5, 99.5, 100), bar(1, 97, 97.5, 96, 97)},
+			want:    SignalOutcome{Outcome: OutcomeStop, ExitPrice: 97, ExitAt: start.Add(time.Minute), RMultiple: -1.5},
+		},
+		{
+			name:    "sell hits target",
+			signal:  sell,
+			candles: []broker.Candle{bar(0, 99, 99.5, 95.5, 96)},
+			want:    SignalOutcome{Outcome: OutcomeTarget, ExitPrice: 96, ExitAt: start, RMultiple: 2},
+		},
+		{
+			name:    "sell gaps above the stop",
+			signal:  sell,
+			candles: []broker.Candle{bar(0, 103, 104, 102.5, 103.5)},
+			want:    SignalOutcome{Outcome: OutcomeStop, ExitPrice: 103, ExitAt: start, RMultiple: -1.5},
+		},
+		{
+			name:    "still open",
+			signal:  buy,
+			candles: []broker.Candle{bar(0, 100, 101, 99, 101)},
+			want:    SignalOutcome{Outcome: OutcomeOpen},
+		},
+		{
+			name:    "expired at the last close",
+			signal:  buy,
+			candles: []broker.Candle{bar(0, 100, 101, 99, 101), bar(1, 101, 102, 100.5, 101)},
+			expired: true,
+			want:    SignalOutcome{Outcome: OutcomeExpired, ExitPrice: 101, ExitAt: start.Add(time.Minute), RMultiple: 0.5},
+		},
+		{
+			name:    "expired without prices",
+			signal:  buy,
+			expired: true,
+			want:    SignalOutcome{Outcome: OutcomeExpired, ExitPrice: 100},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EvaluateSignal(tt.signal, tt.candles, tt.expired)
+			if got.Outcome != tt.want.Outcome || got.ExitPrice != tt.want.ExitPrice ||
+				!got.ExitAt.Equal(tt.want.ExitAt) || !almostEqual(got.RMultiple, tt.want.RMultiple, 1e-9) {
+				t.Errorf("EvaluateSignal() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
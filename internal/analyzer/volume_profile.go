@@ -0,0 +1,224 @@
+package analyzer
+
+import (
+	"math"
+	"time"
+)
+
+// DefaultProfileBins is how many price bins a volume profile without a bin
+// size spreads its range over
+const DefaultProfileBins = 50
+
+// MaxProfileBins caps the bins of a volume profile; smaller bin sizes are
+// widened to fit
+const MaxProfileBins = 1000
+
+// DefaultValueAreaPct is the share of volume in a profile's value area
+const DefaultValueAreaPct = 0.7
+
+// VolumeSample is volume traded at Time across Low to High. A tick's range
+// is its price; a bar's Price is its typical price.
+type VolumeSample struct {
+	Time   time.Time
+	Price  float64
+	Low    float64
+	High   float64
+	Volume int64
+}
+
+// VWAPPoint is the anchored VWAP after a sample
+type VWAPPoint struct {
+	Time   time.Time `json:"time"`
+	VWAP   float64   `json:"vwap"`
+	Volume int64     `json:"volume"` // Cumulative since the anchor
+}
+
+// CalculateAnchoredVWAP computes the VWAP of the samples from anchor on,
+// one point per sample. Samples before the anchor are skipped.
+func CalculateAnchoredVWAP(samples []VolumeSample, anchor time.Time) []VWAPPoint {
+	points := []VWAPPoint{}
+	cumulativePV := 0.0
+	var cumulativeVolume int64
+
+	for _, s := range samples {
+		if s.Time.Before(anchor) {
+			continue
+		}
+		cumulativePV += s.Price * float64(s.Volume)
+		cumulativeVolume += s.Volume
+
+		point := VWAPPoint{Time: s.Time, Volume: cumulativeVolume}
+		if cumulativeVolume > 0 {
+			point.VWAP = cumulativePV / float64(cumulativeVolume)
+		}
+		points = append(points, point)
+	}
+
+	return points
+}
+
+// CalculateTWAP computes the time-weighted average price of the samples, in
+// time order. Each price holds until the next sample, the last one until
+// end, but never longer than maxHold, so overnight and lunch gaps don't
+// count. Returns 0 without samples.
+func CalculateTWAP(samples []VolumeSample, end time.Time, maxHold time.Duration) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+
+	weighted, total := 0.0, 0.0
+	for i, s := range samples {
+		next := end
+		if i+1 < len(samples) {
+			next = samples[i+1].Time
+		}
+		hold := next.Sub(s.Time)
+		if maxHold > 0 && hold > maxHold {
+			hold = maxHold
+		}
+		if hold < 0 {
+			hold = 0
+		}
+		weighted += s.Price * hold.Seconds()
+		total += hold.Seconds()
+	}
+
+	// All samples at the same instant
+	if total == 0 {
+		sum := 0.0
+		for _, s := range samples {
+			sum += s.Price
+		}
+		return sum / float64(len(samples))
+	}
+
+	return weighted / total
+}
+
+// ProfileBin is the volume traded between Low and High
+type ProfileBin struct {
+	Low    float64 `json:"low"`
+	High   float64 `json:"high"`
+	Volume float64 `json:"volume"`
+}
+
+// VolumeProfile is a histogram of volume by price. The point of control
+// (POC) is the middle of the busiest bin, and the value area the bins
+// around it holding ValueAreaPct of the volume.
+type VolumeProfile struct {
+	BinSize         float64      `json:"bin_size"`
+	Bins            []ProfileBin `json:"bins"` // Lowest price first
+	TotalVolume     float64      `json:"total_volume"`
+	POC             float64      `json:"poc"`
+	ValueAreaLow    float64      `json:"value_area_low"`
+	ValueAreaHigh   float64      `json:"value_area_high"`
+	ValueAreaPct    float64      `json:"value_area_pct"`
+	ValueAreaVolume float64      `json:"value_area_volume"`
+}
+
+// CalculateVolumeProfile bins the samples' volume by price. A sample
+// spanning several bins, like a bar, splits its volume in proportion to its
+// range in each. binSize <= 0 spreads the range over DefaultProfileBins, and
+// valueAreaPct outside (0, 1] uses DefaultValueAreaPct. Returns nil without
+// volume.
+func CalculateVolumeProfile(samples []VolumeSample, binSize, valueAreaPct float64) *VolumeProfile {
+	if valueAreaPct <= 0 || valueAreaPct > 1 {
+		valueAreaPct = DefaultValueAreaPct
+	}
+
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, s := range samples {
+		if s.Volume <= 0 {
+			continue
+		}
+		low = math.Min(low, s.Low)
+		high = math.Max(high, s.High)
+	}
+	if math.IsInf(low, 1) {
+		return nil
+	}
+
+	if binSize <= 0 {
+		binSize = (high - low) / DefaultProfileBins
+		if binSize == 0 {
+			binSize = 1
+		}
+	}
+	if (high-low)/binSize > MaxProfileBins {
+		binSize = (high - low) / MaxProfileBins
+	}
+	// Bins start on a multiple of the bin size, so profiles of different
+	// windows line up
+	low = math.Floor(low/binSize) * binSize
+	count := int((high-low)/binSize) + 1
+
+	bins := make([]ProfileBin, count)
+	for i := range bins {
+		bins[i].Low = low + float64(i)*binSize
+		bins[i].High = bins[i].Low + binSize
+	}
+	binOf := func(price float64) int {
+		i := int((price - low) / binSize)
+		return max(0, min(i, count-1))
+	}
+
+	total := 0.0
+	for _, s := range samples {
+		if s.Volume <= 0 {
+			continue
+		}
+		volume := float64(s.Volume)
+		total += volume
+
+		first, last := binOf(s.Low), binOf(s.High)
+		if first == last || s.High <= s.Low {
+			bins[first].Volume += volume
+			continue
+		}
+		span := s.High - s.Low
+		for i := first; i <= last; i++ {
+			overlap := math.Min(bins[i].High, s.High) - math.Max(bins[i].Low, s.Low)
+			if overlap > 0 {
+				bins[i].Volume += volume * overlap / span
+			}
+		}
+	}
+
+	poc := 0
+	for i, bin := range bins {
+		if bin.Volume > bins[poc].Volume {
+			poc = i
+		}
+	}
+
+	// Grow the value area from the POC toward the busier neighbour
+	lowIndex, highIndex := poc, poc
+	areaVolume := bins[poc].Volume
+	for areaVolume < valueAreaPct*total && (lowIndex > 0 || highIndex < count-1) {
+		above, below := -1.0, -1.0
+		if highIndex < count-1 {
+			above = bins[highIndex+1].Volume
+		}
+		if lowIndex > 0 {
+			below = bins[lowIndex-1].Volume
+		}
+		if above >= below {
+			highIndex++
+			areaVolume += above
+		} else {
+			lowIndex--
+			areaVolume += below
+		}
+	}
+
+	return &VolumeProfile{
+		BinSize:         binSize,
+		Bins:            bins,
+		TotalVolume:     total,
+		POC:             (bins[poc].Low + bins[poc].High) / 2,
+		ValueAreaLow:    bins[lowIndex].Low,
+		ValueAreaHigh:   bins[highIndex].High,
+		ValueAreaPct:    valueAreaPct,
+		ValueAreaVolume: areaVolume,
+	}
+}
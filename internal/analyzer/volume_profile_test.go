@@ -0,0 +1,191 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+)
+
+var profileStart = time.Date(2024, 1, 30, 9, 15, 0, 0, time.UTC)
+
+// tick returns a sample of volume traded at price, minute minutes in
+func tick(minute int, price float64, volume int64) VolumeSample {
+	return VolumeSample{
+		Time:   profileStart.Add(time.Duration(minute) * time.Minute),
+		Price:  price,
+		Low:    price,
+		High:   price,
+		Volume: volume,
+	}
+}
+
+func TestCalculateAnchoredVWAP(t *testing.T) {
+	samples := []VolumeSample{
+		tick(0, 90, 1000), // Before the anchor
+		tick(1, 100, 100),
+		tick(2, 110, 300),
+		tick(3, 120, 0),
+		tick(4, 90, 200),
+	}
+
+	points := CalculateAnchoredVWAP(samples, profileStart.Add(time.Minute))
+	want := []VWAPPoint{
+		{Time: samples[1].Time, VWAP: 100, Volume: 100},
+		{Time: samples[2].Time, VWAP: 107.5, Volume: 400},
+		{Time: samples[3].Time, VWAP: 107.5, Volume: 400},
+		{Time: samples[4].Time, VWAP: 101.66666666666667, Volume: 600},
+	}
+	if len(points) != len(want) {
+		t.Fatalf("points = %+v, want %+v", points, want)
+	}
+	for i := range points {
+		if !points[i].Time.Equal(want[i].Time) || !almostEqual(points[i].VWAP, want[i].VWAP, 1e-9) || points[i].Volume != want[i].Volume {
+			t.Errorf("point %d = %+v, want %+v", i, points[i], want[i])
+		}
+	}
+
+	if points := CalculateAnchoredVWAP(samples, profileStart.Add(time.Hour)); len(points) != 0 {
+		t.Errorf("points after the last sample = %+v, want none", points)
+	}
+}
+
+func TestCalculateTWAP(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []VolumeSample
+		end     time.Time
+		maxHold time.Duration
+		want    float64
+	}{
+		{
+			name:    "none",
+			samples: nil,
+			end:     profileStart,
+			want:    0,
+		},
+		{
+			name:    "prices held until the next sample",
+			samples: []VolumeSample{tick(0, 100, 1), tick(3, 110, 1)},
+			end:     profileStart.Add(4 * time.Minute),
+			want:    102.5, // 100 for 3 minutes, 110 for 1
+		},
+		{
+			name:    "gap capped at the max hold",
+			samples: []VolumeSample{tick(0, 100, 1), tick(60, 110, 1)},
+			end:     profileStart.Add(61 * time.Minute),
+			maxHold: time.Minute,
+			want:    105,
+		},
+		{
+			name:    "same instant",
+			samples: []VolumeSample{tick(0, 100, 1), tick(0, 110, 1)},
+			end:     profileStart,
+			want:    105,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CalculateTWAP(tt.samples, tt.end, tt.maxHold); !almostEqual(got, tt.want, 1e-9) {
+				t.Errorf("CalculateTWAP() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateVolumeProfile(t *testing.T) {
+	tests := []struct {
+		name          string
+		samples       []VolumeSample
+		binSize       float64
+		valueArea     float64
+		wantBins      []float64 // Volume per bin
+		wantLow       float64   // Of the first bin
+		wantPOC       float64
+		wantAreaLow   float64
+		wantAreaHigh  float64
+		wantAreaTotal float64
+	}{
+		{
+			name: "ticks",
+			samples: []VolumeSample{
+				tick(0, 100.2, 100),
+				tick(1, 101.5, 500),
+				tick(2, 102.7, 300),
+				tick(3, 103.1, 50),
+				tick(4, 104.9, 50),
+			},
+			binSize:       1,
+			valueArea:     0.7,
+			wantBins:      []float64{100, 500, 300, 50, 50},
+			wantLow:       100,
+			wantPOC:       101.5,
+			wantAreaLow:   101,
+			wantAreaHigh:  103,
+			wantAreaTotal: 800,
+		},
+		{
+			name: "bar volume split across its range",
+			samples: []VolumeSample{
+				{Time: profileStart, Price: 101, Low: 100, High: 102, Volume: 400},
+				{Time: profileStart.Add(time.Minute), Price: 101.5, Low: 101.5, High: 101.5, Volume: 100},
+			},
+			binSize:       0.5,
+			valueArea:     0.5,
+			wantBins:      []float64{100, 100, 100, 200, 0},
+			wantLow:       100,
+			wantPOC:       101.75,
+			wantAreaLow:   101,
+			wantAreaHigh:  102,
+			wantAreaTotal: 300,
+		},
+		{
+			name:          "one price",
+			samples:       []VolumeSample{tick(0, 250, 10), tick(1, 250, 20)},
+			wantBins:      []float64{30},
+			wantLow:       250,
+			wantPOC:       250.5,
+			wantAreaLow:   250,
+			wantAreaHigh:  251,
+			wantAreaTotal: 30,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile := CalculateVolumeProfile(tt.samples, tt.binSize, tt.valueArea)
+			if profile == nil {
+				t.Fatal("profile is nil")
+			}
+			if len(profile.Bins) != len(tt.wantBins) {
+				t.Fatalf("bins = %+v, want volumes %v", profile.Bins, tt.wantBins)
+			}
+			for i, bin := range profile.Bins {
+				if !almostEqual(bin.Volume, tt.wantBins[i], 1e-9) {
+					t.Errorf("bin %d volume = %v, want %v", i, bin.Volume, tt.wantBins[i])
+				}
+			}
+			if profile.Bins[0].Low != tt.wantLow {
+				t.Errorf("first bin starts at %v, want %v", profile.Bins[0].Low, tt.wantLow)
+			}
+			if profile.POC != tt.wantPOC {
+				t.Errorf("POC = %v, want %v", profile.POC, tt.wantPOC)
+			}
+			if profile.ValueAreaLow != tt.wantAreaLow || profile.ValueAreaHigh != tt.wantAreaHigh {
+				t.Errorf("value area = %v-%v, want %v-%v", profile.ValueAreaLow, profile.ValueAreaHigh, tt.wantAreaLow, tt.wantAreaHigh)
+			}
+			if !almostEqual(profile.ValueAreaVolume, tt.wantAreaTotal, 1e-9) {
+				t.Errorf("value area volume = %v, want %v", profile.ValueAreaVolume, tt.wantAreaTotal)
+			}
+		})
+	}
+
+	if profile := CalculateVolumeProfile([]VolumeSample{tick(0, 100, 0)}, 1, 0.7); profile != nil {
+		t.Errorf("profile without volume = %+v, want nil", profile)
+	}
+
+	// A bin size too small for the range is widened to MaxProfileBins
+	wide := CalculateVolumeProfile([]VolumeSample{tick(0, 100, 1), tick(1, 200, 1)}, 0.001, 0.7)
+	if len(wide.Bins) > MaxProfileBins+2 {
+		t.Errorf("profile has %d bins, want at most about %d", len(wide.Bins), MaxProfileBins)
+	}
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/portfolio"
+)
+
+// AccountsHandler combines the positions, holdings and margins of all of a
+// user's active broker accounts
+type AccountsHandler struct {
+	db *database.Database
+}
+
+// NewAccountsHandler creates a new multi-account handler
+func NewAccountsHandler(db *database.Database) *AccountsHandler {
+	return &AccountsHandler{db: db}
+}
+
+// RegisterRoutes registers multi-account routes
+func (h *AccountsHandler) RegisterRoutes(r *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	accounts := r.Group("/portfolio/accounts")
+	accounts.Use(authMiddleware)
+	{
+		accounts.GET("", h.GetSummary)
+		accounts.GET("/positions", h.GetPositions)
+		accounts.GET("/holdings", h.GetHoldings)
+		accounts.GET("/margins", h.GetMargins)
+	}
+}
+
+// fetch fetches the user's active accounts. Accounts that fail carry their
+// error and are left out of the totals. On failure it writes the error
+// response and returns false.
+func (h *AccountsHandler) fetch(c *gin.Context, fetch portfolio.AccountFetch) ([]portfolio.AccountData, bool) {
+	userID, exists := RequireUserID(c)
+	if !exists {
+		return nil, false
+	}
+
+	configs, err := h.db.GetUserBrokerConfigs(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch broker accounts: " + err.Error(),
+		})
+		return nil, false
+	}
+
+	return portfolio.FetchAccounts(configs, fetch), true
+}
+
+// GetSummary totals holdings, positions and margins per account and across
+// all of them
+// GET /api/portfolio/accounts
+func (h *AccountsHandler) GetSummary(c *gin.Context) {
+	data, ok := h.fetch(c, portfolio.AccountFetch{Positions: true, Holdings: true, Margins: true})
+	if !ok {
+		return
+	}
+
+	accounts, totals := portfolio.SummarizeAccounts(data)
+	c.JSON(http.StatusOK, gin.H{
+		"accounts": accounts,
+		"totals":   totals,
+	})
+}
+
+// GetPositions merges the accounts' net positions by instrument and
+// product, with each account's share
+// GET /api/portfolio/accounts/positions
+func (h *AccountsHandler) GetPositions(c *gin.Context) {
+	data, ok := h.fetch(c, portfolio.AccountFetch{Positions: true})
+	if !ok {
+		return
+	}
+
+	positions := portfolio.MergePositions(data)
+	var totalPNL float64
+	for _, position := range positions {
+		totalPNL += position.PNL
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"positions": positions,
+		"total_pnl": totalPNL,
+		"accounts":  portfolio.Accounts(data),
+	})
+}
+
+// GetHoldings merges the accounts' holdings by instrument, with each
+// account's share
+// GET /api/portfolio/accounts/holdings
+func (h *AccountsHandler) GetHoldings(c *gin.Context) {
+	data, ok := h.fetch(c, portfolio.AccountFetch{Holdings: true})
+	if !ok {
+		return
+	}
+
+	holdings := portfolio.MergeHoldings(data)
+	var totalValue, totalPNL float64
+	for _, holding := range holdings {
+		totalValue += holding.Value
+		totalPNL += holding.PNL
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"holdings":    holdings,
+		"total_value": totalValue,
+		"total_pnl":   totalPNL,
+		"accounts":    portfolio.Accounts(data),
+	})
+}
+
+// GetMargins returns each account's margins and their sum
+// GET /api/portfolio/accounts/margins
+func (h *AccountsHandler) GetMargins(c *gin.Context) {
+	data, ok := h.fetch(c, portfolio.AccountFetch{Margins: true})
+	if !ok {
+		return
+	}
+
+	accounts, totals := portfolio.MergeMargins(data)
+	c.JSON(http.StatusOK, gin.H{
+		"accounts": accounts,
+		"totals":   totals,
+	})
+}
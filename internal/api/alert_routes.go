@@ -0,0 +1,308 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/alerts"
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// AlertHandler manages price, indicator and pattern alerts. In single-user
+// mode alerts are shared; in multi-user mode each user sees only their own.
+type AlertHandler struct {
+	db      *database.Database
+	monitor *alerts.Monitor
+}
+
+// NewAlertHandler creates a new alert handler
+func NewAlertHandler(db *database.Database, monitor *alerts.Monitor) *AlertHandler {
+	return &AlertHandler{
+		db:      db,
+		monitor: monitor,
+	}
+}
+
+// RegisterRoutes registers alert routes. Pass the auth middleware in
+// multi-user mode.
+func (h *AlertHandler) RegisterRoutes(r *gin.RouterGroup, middleware ...gin.HandlerFunc) {
+	group := r.Group("/alerts")
+	group.Use(middleware...)
+	{
+		group.GET("", h.ListAlerts)
+		group.POST("", h.CreateAlert)
+		group.GET("/history", h.GetHistory)
+		group.GET("/status", h.GetStatus)
+		group.GET("/:id", h.GetAlert)
+		group.PUT("/:id", h.UpdateAlert)
+		group.DELETE("/:id", h.DeleteAlert)
+		group.POST("/:id/acknowledge", h.AcknowledgeAlert)
+		group.POST("/:id/arm", h.ArmAlert)
+		group.GET("/:id/history", h.GetHistory)
+	}
+}
+
+// AlertRequest creates or replaces an alert
+type AlertRequest struct {
+	Name      string          `json:"name"`
+	Kind      string          `json:"kind" binding:"required"` // price, indicator, pattern
+	Exchange  string          `json:"exchange"`
+	Symbol    string          `json:"symbol" binding:"required"`
+	Interval  string          `json:"interval"` // Bar interval for indicator and pattern alerts
+	Condition json.RawMessage `json:"condition" binding:"required"`
+}
+
+// bindAlertRequest validates the request body into an alert
+func bindAlertRequest(c *gin.Context) (*database.Alert, bool) {
+	var req AlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: " + err.Error(),
+		})
+		return nil, false
+	}
+
+	alert := &database.Alert{
+		Name:      req.Name,
+		Kind:      req.Kind,
+		Exchange:  req.Exchange,
+		Symbol:    req.Symbol,
+		Interval:  req.Interval,
+		Condition: req.Condition,
+	}
+	if err := alerts.Validate(alert); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return nil, false
+	}
+
+	return alert, true
+}
+
+// ListAlerts lists the user's alerts, optionally filtered by state
+// GET /alerts?state=triggered
+func (h *AlertHandler) ListAlerts(c *gin.Context) {
+	state := c.Query("state")
+	switch state {
+	case "", database.AlertArmed, database.AlertTriggered, database.AlertAcknowledged:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "state must be armed, triggered or acknowledged",
+		})
+		return
+	}
+
+	records, err := h.db.GetAlerts(ownerID(c), state)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch alerts: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"alerts": records,
+		"count":  len(records),
+	})
+}
+
+// CreateAlert validates and stores an armed alert
+// POST /alerts
+func (h *AlertHandler) CreateAlert(c *gin.Context) {
+	alert, ok := bindAlertRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.db.CreateAlert(ownerID(c), alert); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to create alert: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, alert)
+}
+
+// GetAlert returns an alert
+// GET /alerts/:id
+func (h *AlertHandler) GetAlert(c *gin.Context) {
+	alert, ok := h.loadAlert(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, alert)
+}
+
+// UpdateAlert replaces an alert's condition and re-arms it
+// PUT /alerts/:id
+func (h *AlertHandler) UpdateAlert(c *gin.Context) {
+	alert, ok := bindAlertRequest(c)
+	if !ok {
+		return
+	}
+	alert.AlertID = c.Param("id")
+
+	err := h.db.UpdateAlert(ownerID(c), alert)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "alert not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to update alert: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, alert)
+}
+
+// DeleteAlert deletes an alert and its history
+// DELETE /alerts/:id
+func (h *AlertHandler) DeleteAlert(c *gin.Context) {
+	err := h.db.DeleteAlert(ownerID(c), c.Param("id"))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "alert not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to delete alert: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "alert deleted",
+	})
+}
+
+// AcknowledgeAlert acknowledges a triggered alert
+// POST /alerts/:id/acknowledge
+func (h *AlertHandler) AcknowledgeAlert(c *gin.Context) {
+	h.transition(c, []string{database.AlertTriggered}, database.AlertAcknowledged, "acknowledged")
+}
+
+// ArmAlert re-arms a triggered or acknowledged alert
+// POST /alerts/:id/arm
+func (h *AlertHandler) ArmAlert(c *gin.Context) {
+	h.transition(c, []string{database.AlertTriggered, database.AlertAcknowledged}, database.AlertArmed, "re-armed")
+}
+
+// transition moves the alert in the :id param between states
+func (h *AlertHandler) transition(c *gin.Context, from []string, state, message string) {
+	alert, ok := h.loadAlert(c)
+	if !ok {
+		return
+	}
+
+	updated, err := h.db.SetAlertState(alert.AlertID, from, state, nil, message)
+	if errors.Is(err, database.ErrAlertState) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "alert is " + alert.State + ", cannot move to " + state,
+		})
+		return
+	}
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "alert not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to update alert: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// GetHistory returns recent alert transitions, for one alert or all of the
+// user's alerts
+// GET /alerts/history?limit=100
+// GET /alerts/:id/history?limit=100
+func (h *AlertHandler) GetHistory(c *gin.Context) {
+	alertID := ""
+	if c.Param("id") != "" {
+		alert, ok := h.loadAlert(c)
+		if !ok {
+			return
+		}
+		alertID = alert.AlertID
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	events, err := h.db.GetAlertEvents(ownerID(c), alertID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch alert history: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events": events,
+		"count":  len(events),
+	})
+}
+
+// GetStatus returns the outcome of the monitor's last evaluation pass,
+// limited to errors for the user's own alerts
+// GET /alerts/status
+func (h *AlertHandler) GetStatus(c *gin.Context) {
+	records, err := h.db.GetAlerts(ownerID(c), database.AlertArmed)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch alerts: " + err.Error(),
+		})
+		return
+	}
+
+	status := h.monitor.Status()
+	errs := make(map[string]string)
+	for _, alert := range records {
+		if msg, ok := status.Errors[alert.AlertID]; ok {
+			errs[alert.AlertID] = msg
+		}
+	}
+	status.Errors = errs
+
+	c.JSON(http.StatusOK, status)
+}
+
+// loadAlert fetches the alert in the :id param, writing the error response
+// if it can't
+func (h *AlertHandler) loadAlert(c *gin.Context) (*database.Alert, bool) {
+	alert, err := h.db.GetAlert(ownerID(c), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch alert: " + err.Error(),
+		})
+		return nil, false
+	}
+	if alert == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "alert not found",
+		})
+		return nil, false
+	}
+
+	return alert, true
+}
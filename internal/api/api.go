@@ -1,14 +1,19 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	"github.com/trading-chitti/market-bridge/internal/algo"
 	"github.com/trading-chitti/market-bridge/internal/analyzer"
 	"github.com/trading-chitti/market-bridge/internal/broker"
 	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/options"
+	"github.com/trading-chitti/market-bridge/internal/quotes"
+	"github.com/trading-chitti/market-bridge/internal/risk"
 )
 
 // API handles HTTP requests
@@ -18,35 +23,105 @@ type API struct {
 	analyzer          *analyzer.Analyzer52D
 	historicalService *database.HistoricalDataService
 	wsHub             *WebSocketHub
+	wsHubs            *WebSocketHubManager
+	streamGuard       *StreamGuard
+	streamHub         *StreamingHub
+	riskEngine        *risk.Engine
+	brokers           *BrokerResolver
+	userAuth          []gin.HandlerFunc
+	adminAuth         []gin.HandlerFunc
+	collectors        *CollectorHandler
+	orderChallenge    []gin.HandlerFunc
+	quotes            *quotes.Store
+	algos             *algo.Manager
+	scanConfig        ScanConfig
+	confluenceRules   []analyzer.ConfluenceRule
+	riskFreeRate      float64
+	readiness         []readinessCheck
 	logger            *logrus.Logger
 }
 
 // NewAPI creates a new API handler
 func NewAPI(b broker.Broker, db *database.Database) *API {
-	logger := logrus.New()
-	logger.SetFormatter(&logrus.TextFormatter{
-		FullTimestamp: true,
-	})
-
 	return &API{
 		broker:            b,
 		db:                db,
 		analyzer:          analyzer.NewAnalyzer52D(),
 		historicalService: database.NewHistoricalDataService(db, b),
-		logger:            logger,
+		scanConfig:        DefaultScanConfig(),
+		riskFreeRate:      options.DefaultRiskFreeRate,
+		logger:            NewLogger("text"),
 	}
 }
 
+// SetLogger replaces the handler logger, e.g. with the JSON one shared with
+// the request log
+func (a *API) SetLogger(logger *logrus.Logger) {
+	a.logger = logger
+}
+
+// SetRiskFreeRate sets the annual rate, as a fraction, option chains are
+// priced at
+func (a *API) SetRiskFreeRate(rate float64) {
+	a.riskFreeRate = rate
+}
+
+// SetHistoricalCachePolicy sets when cached historical candles are
+// re-fetched and how many are kept
+func (a *API) SetHistoricalCachePolicy(policy database.CachePolicy) {
+	a.historicalService.SetCachePolicy(policy)
+}
+
 // SetWebSocketHub sets the WebSocket hub for the API
 func (a *API) SetWebSocketHub(hub *WebSocketHub) {
 	a.wsHub = hub
 }
 
+// SetWebSocketHubManager serves /ws from each user's own hub (multi-user
+// mode) instead of the shared one
+func (a *API) SetWebSocketHubManager(manager *WebSocketHubManager) {
+	a.wsHubs = manager
+}
+
+// SetStreamGuard authenticates and limits the /ws and /stream connections.
+// Call before RegisterRoutes.
+func (a *API) SetStreamGuard(guard *StreamGuard) {
+	a.streamGuard = guard
+}
+
+// SetQuoteStore answers LTP and latest-bar requests from the collectors'
+// quotes when they are fresh. Call before RegisterRoutes.
+func (a *API) SetQuoteStore(store *quotes.Store) {
+	a.quotes = store
+}
+
+// SetCollectorHandler serves /collectors from the server's collector
+// manager. Call before RegisterRoutes.
+func (a *API) SetCollectorHandler(handler *CollectorHandler) {
+	a.collectors = handler
+}
+
+// SetAdminAuth runs middleware before the routes that act on service-wide
+// data (collectors, backfills, imports), e.g. AuthMiddleware followed by
+// AdminMiddleware in multi-user mode. Call before RegisterRoutes.
+func (a *API) SetAdminAuth(middleware ...gin.HandlerFunc) {
+	a.adminAuth = middleware
+}
+
+// StreamingHub returns the hub behind /stream/ws, or nil before
+// RegisterRoutes
+func (a *API) StreamingHub() *StreamingHub {
+	return a.streamHub
+}
+
 // RegisterRoutes registers all API routes
 func (a *API) RegisterRoutes(r *gin.Engine) {
 	// Health & Info
 	r.GET("/", a.Root)
 	r.GET("/health", a.Health)
+	r.GET("/health/live", a.Liveness)
+	r.GET("/health/ready", a.Readiness)
+	a.RegisterDocsRoutes(r)
 	
 	// Authentication
 	auth := r.Group("/auth")
@@ -57,6 +132,7 @@ func (a *API) RegisterRoutes(r *gin.Engine) {
 	
 	// Account
 	account := r.Group("/account")
+	account.Use(a.userAuth...)
 	{
 		account.GET("/profile", a.GetProfile)
 		account.GET("/margins", a.GetMargins)
@@ -67,6 +143,7 @@ func (a *API) RegisterRoutes(r *gin.Engine) {
 	
 	// Market Data
 	market := r.Group("/market")
+	market.Use(a.userAuth...)
 	{
 		market.POST("/quote", a.GetQuote)
 		market.POST("/ltp", a.GetLTP)
@@ -80,6 +157,21 @@ func (a *API) RegisterRoutes(r *gin.Engine) {
 		instruments.GET("/search", a.SearchInstruments)
 		instruments.GET("/:token", a.GetInstrumentByToken)
 		instruments.POST("/sync", a.SyncInstruments)
+		instruments.GET("/isin/:isin", a.GetInstrumentsByISIN)
+		instruments.GET("/symbol-changes/:symbol", a.GetSymbolChanges)
+	}
+	instrumentWrites := r.Group("/instruments", a.adminAuth...)
+	{
+		instrumentWrites.POST("/isin", a.AssignISINs)
+		instrumentWrites.POST("/symbol-changes", a.SaveSymbolChanges)
+	}
+
+	// Options
+	opts := r.Group("/options")
+	opts.Use(a.userAuth...)
+	{
+		opts.GET("/expiries/:underlying", a.GetOptionExpiries)
+		opts.GET("/chain/:underlying", a.GetOptionChain)
 	}
 
 	// Historical Data
@@ -87,7 +179,15 @@ func (a *API) RegisterRoutes(r *gin.Engine) {
 	{
 		historical.POST("/", a.GetHistoricalData)
 		historical.GET("/52day", a.Get52DayHistorical)
+		historical.GET("/continuous/:underlying", a.GetContinuousHistorical)
 		historical.POST("/warm-cache", a.WarmCache)
+		historical.GET("/cache", a.GetHistoricalCacheStats)
+	}
+	historicalCache := r.Group("/historical/cache", a.adminAuth...)
+	{
+		historicalCache.DELETE("", a.ClearHistoricalCache)
+		historicalCache.DELETE("/:symbol", a.InvalidateHistoricalCache)
+		historicalCache.POST("/prune", a.PruneHistoricalCache)
 	}
 
 	// Pattern Recognition
@@ -96,11 +196,53 @@ func (a *API) RegisterRoutes(r *gin.Engine) {
 
 	// Intraday Data
 	intradayHandler := NewIntradayHandler(a.db)
-	intradayHandler.RegisterRoutes(r.Group(""))
+	intradayHandler.SetQuoteStore(a.quotes)
+	intradayHandler.RegisterRoutes(r.Group(""), a.adminAuth...)
+
+	// Corporate Actions
+	corporateActionHandler := NewCorporateActionHandler(a.db)
+	corporateActionHandler.RegisterRoutes(r.Group(""), a.adminAuth...)
+
+	// Index Spot Data
+	indexHandler := NewIndexHandler(a.broker, a.db)
+	indexHandler.RegisterRoutes(r.Group(""))
+
+	// TradingView UDF Datafeed
+	udfHandler := NewUDFHandler(a.broker, a.db)
+	udfHandler.RegisterRoutes(r.Group(""))
+
+	// Indicator Series
+	indicatorHandler := NewIndicatorHandler(a.db)
+	indicatorHandler.RegisterRoutes(r.Group(""))
+
+	// Price Levels
+	levelsHandler := NewLevelsHandler(a.broker, a.db)
+	levelsHandler.RegisterRoutes(r.Group(""))
+
+	// Market Breadth
+	breadthHandler := NewBreadthHandler(a.db)
+	breadthHandler.RegisterRoutes(r.Group(""))
+
+	// Data Quality
+	qualityHandler := NewQualityHandler(a.db)
+	qualityHandler.RegisterRoutes(r.Group(""))
+
+	// Screeners
+	screenerHandler := NewScreenerHandler(a.db)
+	screenerHandler.RegisterRoutes(r.Group(""))
+
+	// Backtesting
+	backtestHandler := NewBacktestHandler(a.broker, a.db)
+	backtestHandler.RegisterRoutes(r.Group(""))
+
+	// Backfill Jobs & Runs
+	backfillHandler := NewBackfillHandler(a.broker, a.db)
+	backfillHandler.RegisterRoutes(r.Group(""), a.adminAuth...)
 
 	// Data Collectors
-	collectorHandler := NewCollectorHandler(a.db)
-	collectorHandler.RegisterRoutes(r.Group(""))
+	if a.collectors != nil {
+		a.collectors.RegisterRoutes(r.Group(""), a.adminAuth...)
+	}
 
 	// Watchlists
 	watchlistHandler := NewWatchlistHandler()
@@ -108,18 +250,39 @@ func (a *API) RegisterRoutes(r *gin.Engine) {
 
 	// WebSocket Streaming for market data
 	streamHandler := NewStreamingHandler(a.db)
+	streamHandler.SetGuard(a.streamGuard)
 	streamHandler.RegisterRoutes(r.Group(""))
+	a.streamHub = streamHandler.GetHub()
 
 	// Analysis & Trading
 	trade := r.Group("/trade")
+	trade.Use(a.userAuth...)
 	{
 		trade.POST("/analyze", a.AnalyzeSymbols)
-		trade.POST("/scan", a.ScanAndTrade)
-		trade.POST("/order", a.PlaceOrder)
-		trade.PUT("/order/:orderID", a.ModifyOrder)
+		trade.POST("/scan", append(a.orderChallenge, a.ScanAndTrade)...)
+		trade.GET("/analysis/latest", a.GetLatestAnalyses)
+		trade.GET("/analysis/:symbol", a.GetAnalysisHistory)
+		trade.POST("/order", append(a.orderChallenge, a.PlaceOrder)...)
+		trade.POST("/margin-check", a.CheckMargin)
+		trade.POST("/basket", append(a.orderChallenge, a.PlaceBasket)...)
+		trade.POST("/algo", append(a.orderChallenge, a.StartAlgo)...)
+		trade.GET("/algo", a.ListAlgos)
+		trade.GET("/algo/:id", a.GetAlgo)
+		trade.DELETE("/algo/:id", a.CancelAlgo)
+		trade.GET("/gtt", a.ListGTTs)
+		trade.POST("/gtt", append(a.orderChallenge, a.PlaceGTT)...)
+		trade.GET("/gtt/:id", a.GetGTT)
+		trade.PUT("/gtt/:id", append(a.orderChallenge, a.ModifyGTT)...)
+		trade.DELETE("/gtt/:id", a.DeleteGTT)
+		trade.PUT("/order/:orderID", append(a.orderChallenge, a.ModifyOrder)...)
 		trade.DELETE("/order/:orderID", a.CancelOrder)
 		trade.POST("/positions/close-all", a.CloseAllPositions)
+		trade.GET("/journal", a.GetTradeJournal)
 	}
+
+	// Signal Tracking
+	signalHandler := NewSignalHandler(a.db)
+	signalHandler.RegisterRoutes(r.Group(""), a.userAuth...)
 	
 	// Broker Management
 	brokers := r.Group("/brokers")
@@ -181,9 +344,14 @@ func (a *API) GenerateSession(c *gin.Context) {
 
 // GetProfile returns user profile
 func (a *API) GetProfile(c *gin.Context) {
-	profile, err := a.broker.GetProfile()
+	brk, ok := a.brokerFor(c)
+	if !ok {
+		return
+	}
+
+	profile, err := brk.GetProfile()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(brokerErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	
@@ -192,9 +360,14 @@ func (a *API) GetProfile(c *gin.Context) {
 
 // GetMargins returns account margins
 func (a *API) GetMargins(c *gin.Context) {
-	margins, err := a.broker.GetMargins()
+	brk, ok := a.brokerFor(c)
+	if !ok {
+		return
+	}
+
+	margins, err := brk.GetMargins()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(brokerErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	
@@ -203,9 +376,14 @@ func (a *API) GetMargins(c *gin.Context) {
 
 // GetPositions returns current positions
 func (a *API) GetPositions(c *gin.Context) {
-	positions, err := a.broker.GetPositions()
+	brk, ok := a.brokerFor(c)
+	if !ok {
+		return
+	}
+
+	positions, err := brk.GetPositions()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(brokerErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	
@@ -214,9 +392,14 @@ func (a *API) GetPositions(c *gin.Context) {
 
 // GetHoldings returns holdings
 func (a *API) GetHoldings(c *gin.Context) {
-	holdings, err := a.broker.GetHoldings()
+	brk, ok := a.brokerFor(c)
+	if !ok {
+		return
+	}
+
+	holdings, err := brk.GetHoldings()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(brokerErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	
@@ -225,9 +408,14 @@ func (a *API) GetHoldings(c *gin.Context) {
 
 // GetOrders returns orders
 func (a *API) GetOrders(c *gin.Context) {
-	orders, err := a.broker.GetOrders()
+	brk, ok := a.brokerFor(c)
+	if !ok {
+		return
+	}
+
+	orders, err := brk.GetOrders()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(brokerErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	
@@ -236,6 +424,11 @@ func (a *API) GetOrders(c *gin.Context) {
 
 // GetQuote returns real-time quotes
 func (a *API) GetQuote(c *gin.Context) {
+	brk, ok := a.brokerFor(c)
+	if !ok {
+		return
+	}
+
 	var req struct {
 		Symbols []string `json:"symbols" binding:"required"`
 	}
@@ -245,17 +438,23 @@ func (a *API) GetQuote(c *gin.Context) {
 		return
 	}
 	
-	quotes, err := a.broker.GetQuote(req.Symbols)
+	quotes, err := brk.GetQuote(req.Symbols)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(brokerErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	
 	c.JSON(http.StatusOK, quotes)
 }
 
-// GetLTP returns last traded price
+// GetLTP returns last traded price. Symbols with a fresh collector quote
+// are answered from memory, the rest by the broker.
 func (a *API) GetLTP(c *gin.Context) {
+	brk, ok := a.brokerFor(c)
+	if !ok {
+		return
+	}
+
 	var req struct {
 		Symbols []string `json:"symbols" binding:"required"`
 	}
@@ -264,31 +463,57 @@ func (a *API) GetLTP(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	
-	ltp, err := a.broker.GetLTP(req.Symbols)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+	if a.quotes == nil {
+		ltp, err := brk.GetLTP(req.Symbols)
+		if err != nil {
+			c.JSON(brokerErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, ltp)
 		return
 	}
+
+	ltp, missing := a.quotes.LTP(req.Symbols)
+	if len(missing) > 0 {
+		brokerLTP, err := brk.GetLTP(missing)
+		if err != nil {
+			c.JSON(brokerErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		for symbol, price := range brokerLTP {
+			ltp[symbol] = price
+		}
+	}
 	
 	c.JSON(http.StatusOK, ltp)
 }
 
 // GetMarketStatus returns market status
 func (a *API) GetMarketStatus(c *gin.Context) {
+	brk, ok := a.brokerFor(c)
+	if !ok {
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"status":  a.broker.GetMarketStatus(),
-		"is_open": a.broker.IsMarketOpen(),
+		"status":  brk.GetMarketStatus(),
+		"is_open": brk.IsMarketOpen(),
 	})
 }
 
 // GetInstruments returns tradable instruments
 func (a *API) GetInstruments(c *gin.Context) {
+	brk, ok := a.brokerFor(c)
+	if !ok {
+		return
+	}
+
 	exchange := c.Param("exchange")
 	
-	instruments, err := a.broker.GetInstruments(exchange)
+	instruments, err := brk.GetInstruments(exchange)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(brokerErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	
@@ -299,49 +524,13 @@ func (a *API) GetInstruments(c *gin.Context) {
 	})
 }
 
-// AnalyzeSymbols analyzes symbols and generates signals
-func (a *API) AnalyzeSymbols(c *gin.Context) {
-	var req struct {
-		Symbols []string `json:"symbols" binding:"required"`
-	}
-	
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+// PlaceOrder places a new order
+func (a *API) PlaceOrder(c *gin.Context) {
+	brk, ok := a.brokerFor(c)
+	if !ok {
 		return
 	}
-	
-	// TODO: Fetch historical data and analyze
-	// This is a placeholder - need to implement instrument token lookup and historical data fetching
-	
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Analysis endpoint - implementation in progress",
-		"symbols": req.Symbols,
-	})
-}
 
-// ScanAndTrade scans symbols and executes trades
-func (a *API) ScanAndTrade(c *gin.Context) {
-	var req struct {
-		Symbols []string `json:"symbols" binding:"required"`
-		DryRun  bool     `json:"dry_run"`
-	}
-	
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	
-	// TODO: Implement trading logic
-	
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Trade scan initiated",
-		"symbols": req.Symbols,
-		"dry_run": req.DryRun,
-	})
-}
-
-// PlaceOrder places a new order
-func (a *API) PlaceOrder(c *gin.Context) {
 	var order broker.OrderRequest
 	
 	if err := c.ShouldBindJSON(&order); err != nil {
@@ -349,20 +538,65 @@ func (a *API) PlaceOrder(c *gin.Context) {
 		return
 	}
 	
-	orderID, err := a.broker.PlaceOrder(&order)
+	// Exits are placed from the account's order updates, which only flow
+	// while the user's hub is connected to the broker
+	if order.HasExits() && a.wsHubs != nil {
+		if userID, ok := GetUserID(c); ok {
+			if _, err := a.wsHubs.GetOrCreateHub(userID); err != nil {
+				RequestLog(c).Warnf("No order updates for user %s, exits won't be placed: %v", userID, err)
+			}
+		}
+	}
+
+	orderID, err := brk.PlaceOrder(&order)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		response := gin.H{"error": err.Error()}
+		if orderID != "" {
+			// The entry went through but its exit legs didn't
+			response["order_id"] = orderID
+		}
+		c.JSON(orderErrorStatus(err), response)
 		return
 	}
 	
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"order_id": orderID,
 		"status":   "placed",
-	})
+	}
+	if order.HasExits() {
+		response["stop_loss"] = order.StopLoss
+		response["target"] = order.Target
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// orderErrorStatus maps order placement errors to HTTP status codes
+func orderErrorStatus(err error) int {
+	if errors.Is(err, risk.ErrLimitExceeded) {
+		return http.StatusUnprocessableEntity
+	}
+	if errors.Is(err, broker.ErrExitsNotSupported) || errors.Is(err, broker.ErrInvalidPrice) {
+		return http.StatusBadRequest
+	}
+	return brokerErrorStatus(err)
+}
+
+// brokerErrorStatus maps broker call errors to HTTP status codes: 503 while
+// the broker is unreachable or its circuit is open, 500 otherwise
+func brokerErrorStatus(err error) int {
+	if errors.Is(err, broker.ErrCircuitOpen) || broker.IsTransient(err) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
 }
 
 // ModifyOrder modifies an existing order
 func (a *API) ModifyOrder(c *gin.Context) {
+	brk, ok := a.brokerFor(c)
+	if !ok {
+		return
+	}
+
 	orderID := c.Param("orderID")
 	
 	var modify broker.OrderModify
@@ -371,9 +605,9 @@ func (a *API) ModifyOrder(c *gin.Context) {
 		return
 	}
 	
-	newOrderID, err := a.broker.ModifyOrder(orderID, &modify)
+	newOrderID, err := brk.ModifyOrder(orderID, &modify)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	
@@ -385,11 +619,16 @@ func (a *API) ModifyOrder(c *gin.Context) {
 
 // CancelOrder cancels an order
 func (a *API) CancelOrder(c *gin.Context) {
+	brk, ok := a.brokerFor(c)
+	if !ok {
+		return
+	}
+
 	orderID := c.Param("orderID")
 	
-	cancelledID, err := a.broker.CancelOrder(orderID)
+	cancelledID, err := brk.CancelOrder(orderID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(brokerErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	
@@ -401,9 +640,14 @@ func (a *API) CancelOrder(c *gin.Context) {
 
 // CloseAllPositions closes all open positions
 func (a *API) CloseAllPositions(c *gin.Context) {
-	positions, err := a.broker.GetPositions()
+	brk, ok := a.brokerFor(c)
+	if !ok {
+		return
+	}
+
+	positions, err := brk.GetPositions()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(brokerErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	
@@ -427,7 +671,7 @@ func (a *API) CloseAllPositions(c *gin.Context) {
 			Quantity:        abs(pos.Quantity),
 		}
 		
-		if _, err := a.broker.PlaceOrder(order); err == nil {
+		if _, err := brk.PlaceOrder(order); err == nil {
 			closedCount++
 		}
 	}
@@ -13,8 +13,10 @@ import (
 // Checks X-API-Key header against configured key
 func APIKeyMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Skip authentication for health check and metrics endpoints
-		if c.Request.URL.Path == "/health" || c.Request.URL.Path == "/metrics" {
+		// Skip authentication for health check, metrics and docs endpoints;
+		// StreamGuard checks the key of streaming connections
+		if isHealthPath(c.Request.URL.Path) || c.Request.URL.Path == "/metrics" || isStreamPath(c.Request.URL.Path) ||
+			isCallbackPath(c.Request.URL.Path) || isDocsPath(c.Request.URL.Path) {
 			c.Next()
 			return
 		}
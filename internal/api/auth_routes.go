@@ -1,17 +1,27 @@
 package api
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/trading-chitti/market-bridge/internal/auth"
 	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/notify"
 )
 
 // AuthHandler handles authentication-related requests
 type AuthHandler struct {
 	db          *database.Database
 	authService *auth.AuthService
+	mailer      *notify.Mailer // nil when email isn't configured
+	appURL      string         // Base of the links in account emails
+	streams     *StreamGuard   // Closes the streams of revoked sessions
 }
 
 // NewAuthHandler creates a new authentication handler
@@ -22,6 +32,20 @@ func NewAuthHandler(db *database.Database, authService *auth.AuthService) *AuthH
 	}
 }
 
+// SetMailer sends verification and password reset emails through mailer.
+// Their links point to appURL + /verify-email or /reset-password with a
+// token parameter; without appURL the emails carry the bare token.
+func (h *AuthHandler) SetMailer(mailer *notify.Mailer, appURL string) {
+	h.mailer = mailer
+	h.appURL = strings.TrimRight(appURL, "/")
+}
+
+// SetStreamGuard closes the streaming connections of sessions revoked by
+// logout, session revocation or password reset
+func (h *AuthHandler) SetStreamGuard(guard *StreamGuard) {
+	h.streams = guard
+}
+
 // RegisterRequest represents user registration request
 type RegisterRequest struct {
 	Email    string `json:"email" binding:"required,email"`
@@ -33,6 +57,7 @@ type RegisterRequest struct {
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
+	TOTPCode string `json:"totp_code"` // Needed when two-factor authentication is enabled
 }
 
 // RefreshRequest represents token refresh request
@@ -40,6 +65,22 @@ type RefreshRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
+// TokenRequest carries a one-time token from an account email
+type TokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ForgotPasswordRequest asks for a password reset email
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest sets a new password with a reset token
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
 // RegisterRoutes registers authentication routes
 func (h *AuthHandler) RegisterRoutes(r *gin.RouterGroup) {
 	auth := r.Group("/auth")
@@ -49,6 +90,13 @@ func (h *AuthHandler) RegisterRoutes(r *gin.RouterGroup) {
 		auth.POST("/logout", h.Logout)
 		auth.POST("/refresh", h.RefreshToken)
 		auth.GET("/me", AuthMiddleware(h.authService, h.db), h.GetCurrentUser)
+		auth.POST("/verify-email/send", AuthMiddleware(h.authService, h.db), h.SendVerificationEmail)
+		auth.POST("/verify-email", h.VerifyEmail)
+		auth.POST("/forgot-password", h.ForgotPassword)
+		auth.POST("/reset-password", h.ResetPassword)
+		auth.GET("/sessions", AuthMiddleware(h.authService, h.db), h.ListSessions)
+		auth.DELETE("/sessions/:session_id", AuthMiddleware(h.authService, h.db), h.RevokeSession)
+		auth.GET("/audit-log", AuthMiddleware(h.authService, h.db), h.GetAuditLog)
 	}
 }
 
@@ -119,6 +167,15 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	// Audit log
 	h.db.CreateAuditLog(user.UserID, "user.register", "user", user.UserID, c.ClientIP(), c.GetHeader("User-Agent"), nil)
 
+	// Ask the new user to confirm their address
+	if h.mailer != nil {
+		go func() {
+			if err := h.sendVerificationEmail(user); err != nil {
+				log.Printf("⚠️  Failed to send verification email to user %s: %v", user.UserID, err)
+			}
+		}()
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"user": gin.H{
 			"user_id":   user.UserID,
@@ -164,6 +221,24 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	// Second factor
+	if user.TOTPEnabled {
+		totp, err := h.db.GetUserTOTP(user.UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to get two-factor status",
+			})
+			return
+		}
+		if err := verifyTOTP(h.db, user.UserID, totp, req.TOTPCode); err != nil {
+			if errors.Is(err, auth.ErrInvalidTOTPCode) || errors.Is(err, auth.ErrTOTPLocked) {
+				h.db.CreateAuditLog(user.UserID, "user.login_2fa_failed", "user", user.UserID, c.ClientIP(), c.GetHeader("User-Agent"), nil)
+			}
+			respondTOTPError(c, err)
+			return
+		}
+	}
+
 	// Update last login
 	h.db.UpdateLastLogin(user.UserID)
 
@@ -220,6 +295,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	sessionIDStr, ok := sessionID.(string)
 	if ok {
 		h.db.RevokeSession(sessionIDStr)
+		h.streams.CloseSession(sessionIDStr)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -322,3 +398,213 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 		},
 	})
 }
+
+// SendVerificationEmail emails the user a link to confirm their address
+// POST /api/auth/verify-email/send
+func (h *AuthHandler) SendVerificationEmail(c *gin.Context) {
+	userID, exists := RequireUserID(c)
+	if !exists {
+		return
+	}
+
+	if h.mailer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "email delivery is not configured",
+		})
+		return
+	}
+
+	user, err := h.db.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch user: " + err.Error(),
+		})
+		return
+	}
+	if user.EmailVerified {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "email already verified",
+		})
+		return
+	}
+
+	if err := h.sendVerificationEmail(user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to send verification email: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "verification email sent",
+	})
+}
+
+// VerifyEmail confirms a user's address with the token from their
+// verification email
+// POST /api/auth/verify-email
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req TokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	userID, err := h.db.VerifyUserEmail(h.authService.HashOneTimeToken(req.Token))
+	if errors.Is(err, auth.ErrInvalidToken) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to verify email: " + err.Error(),
+		})
+		return
+	}
+
+	h.db.CreateAuditLog(userID, "user.verify_email", "user", userID, c.ClientIP(), c.GetHeader("User-Agent"), nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "email verified",
+	})
+}
+
+// ForgotPassword emails a password reset link to an account's address. It
+// answers the same whether or not the account exists.
+// POST /api/auth/forgot-password
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	if h.mailer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "email delivery is not configured",
+		})
+		return
+	}
+
+	// Send in the background so the response time doesn't reveal accounts
+	ip, userAgent := c.ClientIP(), c.GetHeader("User-Agent")
+	go func() {
+		user, err := h.db.GetUserByEmail(req.Email)
+		if err != nil || !user.IsActive {
+			return
+		}
+		if err := h.sendPasswordResetEmail(user); err != nil {
+			log.Printf("⚠️  Failed to send password reset email to user %s: %v", user.UserID, err)
+			return
+		}
+		h.db.CreateAuditLog(user.UserID, "user.forgot_password", "user", user.UserID, ip, userAgent, nil)
+	}()
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "if an account exists for this email, a password reset link has been sent",
+	})
+}
+
+// ResetPassword sets a new password with the token from a password reset
+// email and logs the user out everywhere
+// POST /api/auth/reset-password
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	passwordHash, err := h.authService.HashPassword(req.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to process password",
+		})
+		return
+	}
+
+	userID, err := h.db.ResetUserPassword(h.authService.HashOneTimeToken(req.Token), passwordHash)
+	if errors.Is(err, auth.ErrInvalidToken) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to reset password: " + err.Error(),
+		})
+		return
+	}
+
+	h.streams.CloseUserSessions(userID)
+	h.db.CreateAuditLog(userID, "user.reset_password", "user", userID, c.ClientIP(), c.GetHeader("User-Agent"), nil)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "password reset, log in with the new password",
+	})
+}
+
+// sendVerificationEmail issues a verification token and emails it
+func (h *AuthHandler) sendVerificationEmail(user *auth.User) error {
+	token, err := h.issueToken(user, auth.TokenPurposeVerifyEmail, auth.VerifyEmailTokenExpiry)
+	if err != nil {
+		return err
+	}
+	return h.mailer.Send(user.Email, notify.Notification{
+		Title:   "Verify your Market Bridge email",
+		Message: h.tokenMessage(user, "Confirm your email address", "/verify-email", token, auth.VerifyEmailTokenExpiry),
+	})
+}
+
+// sendPasswordResetEmail issues a password reset token and emails it
+func (h *AuthHandler) sendPasswordResetEmail(user *auth.User) error {
+	token, err := h.issueToken(user, auth.TokenPurposeResetPassword, auth.ResetPasswordTokenExpiry)
+	if err != nil {
+		return err
+	}
+	return h.mailer.Send(user.Email, notify.Notification{
+		Title:   "Reset your Market Bridge password",
+		Message: h.tokenMessage(user, "Choose a new password", "/reset-password", token, auth.ResetPasswordTokenExpiry),
+	})
+}
+
+// issueToken stores a new one-time token for the user and returns it
+func (h *AuthHandler) issueToken(user *auth.User, purpose string, expiry time.Duration) (string, error) {
+	token, hash, err := h.authService.GenerateOneTimeToken()
+	if err != nil {
+		return "", err
+	}
+	if err := h.db.CreateUserToken(user.UserID, purpose, hash, time.Now().Add(expiry)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// tokenMessage is the body of an account email: a link to path on the app
+// carrying the token, or the bare token without an app URL
+func (h *AuthHandler) tokenMessage(user *auth.User, action, path, token string, expiry time.Duration) string {
+	var msg strings.Builder
+	if user.FullName != "" {
+		fmt.Fprintf(&msg, "Hi %s,\n\n", user.FullName)
+	}
+	if h.appURL != "" {
+		fmt.Fprintf(&msg, "%s by opening this link:\n\n%s%s?token=%s\n\n", action, h.appURL, path, url.QueryEscape(token))
+	} else {
+		fmt.Fprintf(&msg, "%s with this token:\n\n%s\n\n", action, token)
+	}
+	expires := fmt.Sprintf("%.0f hours", expiry.Hours())
+	if expiry == time.Hour {
+		expires = "1 hour"
+	}
+	fmt.Fprintf(&msg, "It expires in %s. If you didn't ask for this, you can ignore this email.", expires)
+	return msg.String()
+}
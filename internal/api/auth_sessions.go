@@ -0,0 +1,152 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// ListSessions returns the user's active sessions (devices), flagging the
+// one making the request
+// GET /api/auth/sessions
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	userID, exists := RequireUserID(c)
+	if !exists {
+		return
+	}
+	currentID := c.GetString("session_id")
+
+	sessions, err := h.db.GetUserSessions(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch sessions: " + err.Error(),
+		})
+		return
+	}
+
+	result := make([]gin.H, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, gin.H{
+			"session_id":   session.SessionID,
+			"ip_address":   session.IPAddress,
+			"user_agent":   session.UserAgent,
+			"created_at":   session.CreatedAt,
+			"last_used_at": session.LastUsedAt,
+			"expires_at":   session.ExpiresAt,
+			"current":      session.SessionID == currentID,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":    len(result),
+		"sessions": result,
+	})
+}
+
+// RevokeSession signs out one of the user's sessions. Its tokens stop
+// working immediately.
+// DELETE /api/auth/sessions/:session_id
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	userID, exists := RequireUserID(c)
+	if !exists {
+		return
+	}
+
+	sessionID := c.Param("session_id")
+	if _, err := uuid.Parse(sessionID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid session_id",
+		})
+		return
+	}
+
+	err := h.db.RevokeUserSession(userID, sessionID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "session not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to revoke session: " + err.Error(),
+		})
+		return
+	}
+	h.streams.CloseSession(sessionID)
+
+	// Audit log
+	h.db.CreateAuditLog(userID, "session.revoke", "session", sessionID, c.ClientIP(), c.GetHeader("User-Agent"),
+		map[string]interface{}{"current": sessionID == c.GetString("session_id")})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "session revoked",
+		"session_id": sessionID,
+	})
+}
+
+// GetAuditLog returns the user's security audit trail, newest first. Dates
+// are UTC days, both inclusive.
+// GET /api/auth/audit-log?action=user.login&resource_type=broker_config&from=2024-01-01&to=2024-01-31&limit=100&offset=0
+func (h *AuthHandler) GetAuditLog(c *gin.Context) {
+	userID, exists := RequireUserID(c)
+	if !exists {
+		return
+	}
+
+	filter := database.AuditLogFilter{
+		UserID:       userID,
+		Action:       c.Query("action"),
+		ResourceType: c.Query("resource_type"),
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	filter.Limit = limit
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset"})
+		return
+	}
+	filter.Offset = offset
+
+	if from := c.Query("from"); from != "" {
+		filter.From, err = time.Parse("2006-01-02", from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from date (use YYYY-MM-DD)"})
+			return
+		}
+	}
+	if to := c.Query("to"); to != "" {
+		toDate, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to date (use YYYY-MM-DD)"})
+			return
+		}
+		filter.To = toDate.AddDate(0, 0, 1)
+	}
+
+	entries, total, err := h.db.GetAuditLog(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch audit log: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":   len(entries),
+		"total":   total,
+		"limit":   filter.Limit,
+		"offset":  filter.Offset,
+		"entries": entries,
+	})
+}
@@ -0,0 +1,246 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/backfill"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/watchlist"
+)
+
+// BackfillHandler handles backfill job and run requests
+type BackfillHandler struct {
+	db   *database.Database
+	jobs *backfill.JobManager
+}
+
+// NewBackfillHandler creates a new backfill handler
+func NewBackfillHandler(brk broker.Broker, db *database.Database) *BackfillHandler {
+	return &BackfillHandler{
+		db:   db,
+		jobs: backfill.NewJobManager(brk, db, 3),
+	}
+}
+
+// RegisterRoutes registers backfill routes behind the given middleware
+func (h *BackfillHandler) RegisterRoutes(r *gin.RouterGroup, middleware ...gin.HandlerFunc) {
+	backfills := r.Group("/backfill")
+	backfills.Use(middleware...)
+	{
+		backfills.POST("/jobs", h.CreateJob)
+		backfills.GET("/jobs", h.ListJobs)
+		backfills.GET("/jobs/:id", h.GetJob)
+		backfills.DELETE("/jobs/:id", h.CancelJob)
+		backfills.GET("/runs", h.GetRuns)
+		backfills.GET("/runs/:id", h.GetRun)
+	}
+}
+
+// CreateBackfillJobRequest represents a backfill job request
+type CreateBackfillJobRequest struct {
+	Symbols    []string `json:"symbols"`
+	Watchlist  string   `json:"watchlist"`
+	From       string   `json:"from" binding:"required"` // YYYY-MM-DD (IST)
+	To         string   `json:"to"`                      // YYYY-MM-DD (IST), defaults to now
+	Timeframe  string   `json:"timeframe"`               // 1m, 5m, 15m, 1h or 1d, or minute, 5minute, 15minute, 60minute, day
+	Mode       string   `json:"mode"`                    // full or fill-gaps
+	DryRun     bool     `json:"dry_run"`
+	Concurrent int      `json:"concurrent"`
+}
+
+// CreateJob starts a background backfill job
+// POST /backfill/jobs
+func (h *BackfillHandler) CreateJob(c *gin.Context) {
+	var req CreateBackfillJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	symbols := []string{}
+	for _, symbol := range req.Symbols {
+		if symbol = strings.ToUpper(strings.TrimSpace(symbol)); symbol != "" {
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	if req.Watchlist != "" {
+		wl := watchlist.GetWatchlist(req.Watchlist)
+		if wl == nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "watchlist not found: " + req.Watchlist,
+			})
+			return
+		}
+		symbols = append(symbols, wl.Symbols...)
+	}
+
+	if len(symbols) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "either symbols or watchlist must be specified",
+		})
+		return
+	}
+
+	fromDate, toDate, err := backfill.ParseDateRange(req.From, req.To)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	job, err := h.jobs.Submit(dedupeSymbols(symbols), fromDate, toDate, backfill.Options{
+		Timeframe:  req.Timeframe,
+		Mode:       req.Mode,
+		DryRun:     req.DryRun,
+		Concurrent: req.Concurrent,
+		MaxRetries: 3,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "failed to start backfill job: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// ListJobs lists backfill jobs started since the server came up
+// GET /backfill/jobs
+func (h *BackfillHandler) ListJobs(c *gin.Context) {
+	jobs := h.jobs.List()
+
+	c.JSON(http.StatusOK, gin.H{
+		"jobs":  jobs,
+		"count": len(jobs),
+	})
+}
+
+// GetJob returns progress for a backfill job
+// GET /backfill/jobs/:id
+func (h *BackfillHandler) GetJob(c *gin.Context) {
+	jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid job id",
+		})
+		return
+	}
+
+	job, err := h.jobs.Get(jobID)
+	if errors.Is(err, backfill.ErrJobNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch backfill job: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// CancelJob cancels a running backfill job
+// DELETE /backfill/jobs/:id
+func (h *BackfillHandler) CancelJob(c *gin.Context) {
+	jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid job id",
+		})
+		return
+	}
+
+	switch err := h.jobs.Cancel(jobID); {
+	case errors.Is(err, backfill.ErrJobNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+	case errors.Is(err, backfill.ErrJobFinished):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusOK, gin.H{
+			"message": "backfill job cancelling",
+			"job_id":  jobID,
+		})
+	}
+}
+
+// GetRuns lists recent backfill run reports
+// GET /backfill/runs?limit=20
+func (h *BackfillHandler) GetRuns(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 500 {
+		limit = 20
+	}
+
+	runs, err := h.db.GetBackfillRuns(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch backfill runs: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"runs":  runs,
+		"count": len(runs),
+	})
+}
+
+// GetRun returns a single backfill run report with per-symbol results
+// GET /backfill/runs/:id
+func (h *BackfillHandler) GetRun(c *gin.Context) {
+	runID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid run id",
+		})
+		return
+	}
+
+	run, err := h.db.GetBackfillRun(runID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch backfill run: " + err.Error(),
+		})
+		return
+	}
+
+	if run == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "backfill run not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// dedupeSymbols removes duplicate symbols, keeping the first occurrence
+func dedupeSymbols(symbols []string) []string {
+	seen := make(map[string]bool, len(symbols))
+	unique := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		if !seen[symbol] {
+			seen[symbol] = true
+			unique = append(unique, symbol)
+		}
+	}
+	return unique
+}
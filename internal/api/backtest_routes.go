@@ -0,0 +1,164 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/backtest"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// BacktestHandler runs strategies over cached historical bars
+type BacktestHandler struct {
+	historical *database.HistoricalDataService
+}
+
+// NewBacktestHandler creates a new backtest handler
+func NewBacktestHandler(brk broker.Broker, db *database.Database) *BacktestHandler {
+	return &BacktestHandler{
+		historical: database.NewHistoricalDataService(db, brk),
+	}
+}
+
+// RegisterRoutes registers backtest routes
+func (h *BacktestHandler) RegisterRoutes(r *gin.RouterGroup) {
+	bt := r.Group("/backtest")
+	{
+		bt.POST("", h.RunBacktest)
+		bt.GET("/strategies", h.ListStrategies)
+	}
+}
+
+// BacktestRequest represents a backtest request
+type BacktestRequest struct {
+	Exchange        string                  `json:"exchange"`
+	Symbol          string                  `json:"symbol" binding:"required"`
+	Interval        string                  `json:"interval"`                     // day, 60minute, 15minute, 5minute, minute
+	FromDate        string                  `json:"from_date" binding:"required"` // YYYY-MM-DD
+	ToDate          string                  `json:"to_date"`                      // YYYY-MM-DD, defaults to today
+	Strategy        backtest.StrategyConfig `json:"strategy" binding:"required"`
+	InitialCapital  float64                 `json:"initial_capital"`
+	PositionSizePct float64                 `json:"position_size_pct"`
+	FixedQuantity   int64                   `json:"fixed_quantity"`
+	FixedAmount     float64                 `json:"fixed_amount"`
+	RiskPct         float64                 `json:"risk_pct"`
+	SlippagePct     float64                 `json:"slippage_pct"`
+	StopLossPct     float64                 `json:"stop_loss_pct"`
+	TakeProfitPct   float64                 `json:"take_profit_pct"`
+	AllowShort      bool                    `json:"allow_short"`
+	Brokerage       *backtest.Brokerage     `json:"brokerage"`
+	IncludeEquity   *bool                   `json:"include_equity_curve"`
+}
+
+// RunBacktest runs a strategy over cached historical data
+// POST /backtest
+func (h *BacktestHandler) RunBacktest(c *gin.Context) {
+	var req BacktestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	if req.Exchange == "" {
+		req.Exchange = "NSE"
+	}
+	if req.Interval == "" {
+		req.Interval = "day"
+	}
+	req.Symbol = strings.ToUpper(req.Symbol)
+
+	fromDate, err := time.Parse("2006-01-02", req.FromDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid from_date format (use YYYY-MM-DD)",
+		})
+		return
+	}
+
+	toDate := time.Now()
+	if req.ToDate != "" {
+		toDate, err = time.Parse("2006-01-02", req.ToDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid to_date format (use YYYY-MM-DD)",
+			})
+			return
+		}
+	}
+
+	strategy, err := backtest.NewStrategy(req.Strategy)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	cached, err := h.historical.GetHistoricalData(req.Exchange, req.Symbol, req.Interval, fromDate, toDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch historical data: " + err.Error(),
+		})
+		return
+	}
+	if len(cached) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "no historical data for " + req.Exchange + ":" + req.Symbol,
+		})
+		return
+	}
+
+	candles := make([]broker.Candle, len(cached))
+	for i, hc := range cached {
+		candles[i] = broker.Candle{
+			Date:   hc.CandleTimestamp,
+			Open:   hc.Open,
+			High:   hc.High,
+			Low:    hc.Low,
+			Close:  hc.Close,
+			Volume: hc.Volume,
+		}
+	}
+
+	result, err := backtest.Run(strategy, candles, backtest.Config{
+		Symbol:          req.Symbol,
+		InitialCapital:  req.InitialCapital,
+		PositionSizePct: req.PositionSizePct,
+		FixedQuantity:   req.FixedQuantity,
+		FixedAmount:     req.FixedAmount,
+		RiskPct:         req.RiskPct,
+		SlippagePct:     req.SlippagePct,
+		StopLossPct:     req.StopLossPct,
+		TakeProfitPct:   req.TakeProfitPct,
+		AllowShort:      req.AllowShort,
+		Brokerage:       req.Brokerage,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if req.IncludeEquity != nil && !*req.IncludeEquity {
+		result.EquityCurve = nil
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ListStrategies lists built-in backtest strategies and their parameters
+// GET /backtest/strategies
+func (h *BacktestHandler) ListStrategies(c *gin.Context) {
+	strategies := backtest.ListStrategies()
+
+	c.JSON(http.StatusOK, gin.H{
+		"strategies": strategies,
+		"count":      len(strategies),
+	})
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/analyzer"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/watchlist"
+)
+
+// BreadthHandler serves market breadth of watchlists
+type BreadthHandler struct {
+	db *database.Database
+}
+
+// NewBreadthHandler creates a new breadth handler
+func NewBreadthHandler(db *database.Database) *BreadthHandler {
+	return &BreadthHandler{db: db}
+}
+
+// RegisterRoutes registers breadth routes
+func (h *BreadthHandler) RegisterRoutes(r *gin.RouterGroup) {
+	breadth := r.Group("/breadth")
+	{
+		breadth.GET("/:watchlist", h.GetBreadth)
+		breadth.GET("/:watchlist/history", h.GetBreadthHistory)
+	}
+}
+
+// GetBreadth computes a watchlist's breadth for the latest session from
+// cached daily candles and stores it for GET /breadth/:watchlist/history
+// GET /breadth/:watchlist
+func (h *BreadthHandler) GetBreadth(c *gin.Context) {
+	name := strings.ToUpper(c.Param("watchlist"))
+	wl := watchlist.GetWatchlist(name)
+	if wl == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "watchlist not found: " + name,
+		})
+		return
+	}
+
+	// A year of history for 52-week highs and lows, plus holidays
+	candles, err := cachedDailyCandles(h.db, watchlistExchange(wl), wl.Symbols, 380)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to load cached candles: " + err.Error(),
+		})
+		return
+	}
+
+	breadth := analyzer.CalculateBreadth(candles)
+	if breadth.Symbols == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "no cached daily candles for " + name + ", warm the cache with POST /historical/warm-cache",
+		})
+		return
+	}
+
+	if err := h.db.SaveBreadth(name, breadth); err != nil {
+		log.Printf("⚠️  Failed to store breadth for %s: %v", name, err)
+	}
+
+	response := gin.H{
+		"watchlist": name,
+		"breadth":   breadth,
+	}
+
+	// The moves of the index the watchlist tracks and of INDIA VIX
+	moves := map[string]string{"vix": "INDIA VIX"}
+	for _, index := range database.Indices {
+		if index.Watchlist == name {
+			moves["index"] = index.Symbol
+		}
+	}
+	for field, symbol := range moves {
+		index, _ := database.LookupIndex(symbol)
+		move, err := indexMove(h.db, index)
+		if err != nil {
+			log.Printf("⚠️  Failed to load %s candles for breadth: %v", symbol, err)
+		} else if move != nil {
+			response[field] = move
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// indexMove returns an index's latest cached close and its change on the
+// session before, nil without two cached sessions
+func indexMove(db *database.Database, index database.Index) (gin.H, error) {
+	candles, err := cachedDailyCandles(db, index.Exchange, []string{index.Symbol}, 10)
+	if err != nil {
+		return nil, err
+	}
+	series := candles[index.Symbol]
+	if len(series) < 2 {
+		return nil, nil
+	}
+
+	last, prev := series[len(series)-1], series[len(series)-2]
+	change := last.Close - prev.Close
+	return gin.H{
+		"symbol":     index.Symbol,
+		"date":       last.Date,
+		"close":      last.Close,
+		"change":     math.Round(change*100) / 100,
+		"change_pct": math.Round(change/prev.Close*10000) / 100,
+	}, nil
+}
+
+// GetBreadthHistory returns a watchlist's stored daily breadth, oldest first
+// GET /breadth/:watchlist/history?days=90
+func (h *BreadthHandler) GetBreadthHistory(c *gin.Context) {
+	name := strings.ToUpper(c.Param("watchlist"))
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "90"))
+	if err != nil || days <= 0 || days > 3650 {
+		days = 90
+	}
+
+	records, err := h.db.GetBreadthHistory(name, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch breadth history: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"watchlist": name,
+		"count":     len(records),
+		"history":   records,
+	})
+}
+
+// watchlistExchange returns the exchange of a watchlist's symbols
+func watchlistExchange(wl *watchlist.Watchlist) string {
+	if wl.Exchange == "" {
+		return "NSE"
+	}
+	return wl.Exchange
+}
+
+// cachedDailyCandles loads the symbols' cached daily candles for the last
+// days, oldest first. Indices need no instrument token; other symbols
+// without one or without cached candles map to no candles. Only database
+// failures are returned as errors.
+func cachedDailyCandles(db *database.Database, exchange string, symbols []string, days int) (map[string][]broker.Candle, error) {
+	toDate := time.Now()
+	fromDate := toDate.AddDate(0, 0, -days)
+
+	result := make(map[string][]broker.Candle, len(symbols))
+	for _, symbol := range symbols {
+		result[symbol] = nil
+
+		index, ok := database.ResolveIndex(exchange, symbol)
+		token := index.InstrumentToken
+		if !ok {
+			var err error
+			if token, err = db.GetInstrumentToken(exchange, symbol); err != nil || token == 0 {
+				continue
+			}
+		}
+
+		cached, err := db.GetHistoricalFromCache(token, "day", fromDate, toDate)
+		if err != nil {
+			return nil, err
+		}
+
+		candles := make([]broker.Candle, len(cached))
+		for i, cc := range cached {
+			candles[i] = broker.Candle{
+				Date:   cc.CandleTimestamp,
+				Open:   cc.Open,
+				High:   cc.High,
+				Low:    cc.Low,
+				Close:  cc.Close,
+				Volume: cc.Volume,
+			}
+		}
+		result[symbol] = candles
+	}
+
+	return result, nil
+}
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/journal"
+	"github.com/trading-chitti/market-bridge/internal/risk"
+	"github.com/trading-chitti/market-bridge/internal/tracing"
+)
+
+// ErrNoDefaultBroker is returned when a user has no active default broker
+// account to trade through
+var ErrNoDefaultBroker = errors.New("no active default broker account")
+
+// BrokerResolver gives each user a broker for their default account in
+// multi-user mode. Brokers are cached per user and rebuilt when the user
+// switches default account or the account's access token changes.
+type BrokerResolver struct {
+	db            *database.Database
+	resilience    broker.ResilienceConfig
+	requireMargin bool // Orders must be covered by the account's margin
+
+	mu      sync.Mutex
+	brokers map[string]*userBroker // userID -> broker
+}
+
+// userBroker is a user's cached broker and the account it was built from
+type userBroker struct {
+	configID    int
+	accessToken string
+	broker      broker.Broker
+	riskEngine  *risk.Engine              // Limits of the account, checked on its orders
+	updates     broker.OrderUpdateHandler // The unwrapped broker, if it acts on order updates
+}
+
+// NewBrokerResolver creates a per-user broker resolver
+func NewBrokerResolver(db *database.Database) *BrokerResolver {
+	return &BrokerResolver{
+		db:         db,
+		resilience: broker.DefaultResilienceConfig(),
+		brokers:    make(map[string]*userBroker),
+	}
+}
+
+// SetResilienceConfig sets how the brokers built from now on retry calls
+// and open their circuit breakers
+func (r *BrokerResolver) SetResilienceConfig(config broker.ResilienceConfig) {
+	r.resilience = config
+}
+
+// SetRequireMargin sets whether the brokers built from now on reject orders
+// the account's available margin doesn't cover
+func (r *BrokerResolver) SetRequireMargin(require bool) {
+	r.requireMargin = require
+}
+
+// Broker returns the broker for a user's default account, checking its
+// orders against the account's own risk limits and journaling them under
+// the user. Returns ErrNoDefaultBroker if the user has no active default
+// account.
+func (r *BrokerResolver) Broker(userID string) (broker.Broker, error) {
+	configs, err := r.db.GetUserBrokerConfigs(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get broker configs: %w", err)
+	}
+
+	var config *broker.BrokerConfig
+	for _, cfg := range configs {
+		if cfg.IsDefault && cfg.IsActive {
+			config = cfg
+			break
+		}
+	}
+	if config == nil {
+		return nil, ErrNoDefaultBroker
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cached, ok := r.brokers[userID]; ok &&
+		cached.configID == config.ConfigID && cached.accessToken == config.AccessToken {
+		// Limits may have been updated since the broker was built
+		cached.riskEngine.SetLimits(risk.LimitsFromConfig(config))
+		return cached.broker, nil
+	}
+
+	brk, err := broker.NewBroker(config)
+	if err != nil {
+		return nil, err
+	}
+	updates, _ := brk.(broker.OrderUpdateHandler)
+	engine := risk.NewEngine(risk.LimitsFromConfig(config))
+	engine.SetRequireMargin(r.requireMargin)
+	engine.SetInstrumentLookup(r.db.GetInstrument)
+	brk = journal.New(r.db, config.BrokerName, userID).Wrap(engine.Wrap(broker.WithResilience(broker.WithMetrics(brk), r.resilience)))
+
+	r.brokers[userID] = &userBroker{
+		configID:    config.ConfigID,
+		accessToken: config.AccessToken,
+		broker:      brk,
+		riskEngine:  engine,
+		updates:     updates,
+	}
+	log.Printf("🔌 Created %s broker for user %s (config %d)", config.BrokerName, userID, config.ConfigID)
+
+	return brk, nil
+}
+
+// RiskEngine returns the risk engine checking the orders of a user's
+// default account, with the account's own limits. Returns
+// ErrNoDefaultBroker if the user has no active default account.
+func (r *BrokerResolver) RiskEngine(userID string) (*risk.Engine, error) {
+	if _, err := r.Broker(userID); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cached, ok := r.brokers[userID]
+	if !ok {
+		return nil, ErrNoDefaultBroker
+	}
+	return cached.riskEngine, nil
+}
+
+// HandleOrderUpdate passes an order update of a user's default account to
+// their cached broker, which places the exits of entries as they fill
+func (r *BrokerResolver) HandleOrderUpdate(userID string, update broker.OrderUpdate) {
+	r.mu.Lock()
+	cached, ok := r.brokers[userID]
+	r.mu.Unlock()
+
+	if ok && cached.updates != nil {
+		cached.updates.HandleOrderUpdate(update)
+	}
+}
+
+// SetBrokerResolver makes /account, /market and /trade requests use the
+// authenticated user's default broker account instead of the global broker
+// (multi-user mode). authMiddleware identifies the user on those routes.
+// Call before RegisterRoutes.
+func (a *API) SetBrokerResolver(resolver *BrokerResolver, authMiddleware gin.HandlerFunc) {
+	a.brokers = resolver
+	a.userAuth = []gin.HandlerFunc{authMiddleware}
+}
+
+// brokerFor returns the broker a request goes to: the user's default
+// account with a resolver, the global broker otherwise, tracing its calls
+// within the request. When there is none it answers the request and
+// returns false.
+func (a *API) brokerFor(c *gin.Context) (broker.Broker, bool) {
+	if a.brokers == nil {
+		return tracing.Broker(c.Request.Context(), a.broker), true
+	}
+
+	userID, ok := RequireUserID(c)
+	if !ok {
+		return nil, false
+	}
+
+	brk, err := a.brokers.Broker(userID)
+	if errors.Is(err, ErrNoDefaultBroker) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create broker: " + err.Error()})
+		return nil, false
+	}
+	return tracing.Broker(c.Request.Context(), brk), true
+}
+
+// riskEngineFor returns the risk engine of the broker brokerFor returns:
+// the user's account's with a resolver, the global one otherwise, which
+// may be nil. When it can't get the user's it answers the request and
+// returns false.
+func (a *API) riskEngineFor(c *gin.Context) (*risk.Engine, bool) {
+	if a.brokers == nil {
+		return a.riskEngine, true
+	}
+
+	userID, ok := RequireUserID(c)
+	if !ok {
+		return nil, false
+	}
+
+	engine, err := a.brokers.RiskEngine(userID)
+	if errors.Is(err, ErrNoDefaultBroker) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create broker: " + err.Error()})
+		return nil, false
+	}
+	return engine, true
+}
+
+// SetOrderChallenge runs challenge before the routes that place or change
+// orders: /trade/order, /trade/scan, /trade/basket, /trade/algo and
+// /trade/gtt, e.g. OrderTOTPMiddleware. Call after SetBrokerResolver and
+// before RegisterRoutes.
+func (a *API) SetOrderChallenge(challenge gin.HandlerFunc) {
+	a.orderChallenge = []gin.HandlerFunc{challenge}
+}
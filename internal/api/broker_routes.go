@@ -1,17 +1,21 @@
 package api
 
 import (
+	"database/sql"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/trading-chitti/market-bridge/internal/auth"
 	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/risk"
 )
 
 // BrokerManagementHandler handles per-user broker account management
 type BrokerManagementHandler struct {
 	db          *database.Database
 	authService *auth.AuthService
+	hubs        *WebSocketHubManager
 }
 
 // NewBrokerManagementHandler creates a new broker management handler
@@ -22,6 +26,12 @@ func NewBrokerManagementHandler(db *database.Database, authService *auth.AuthSer
 	}
 }
 
+// SetWebSocketHubManager closes a user's /ws hub when their default broker
+// account changes, so the next connection streams from the new one
+func (h *BrokerManagementHandler) SetWebSocketHubManager(manager *WebSocketHubManager) {
+	h.hubs = manager
+}
+
 // AddBrokerAccountRequest represents adding a new broker account
 type AddBrokerAccountRequest struct {
 	BrokerName  string `json:"broker_name" binding:"required"`
@@ -37,6 +47,9 @@ type UpdateBrokerAccountRequest struct {
 	AccountName string `json:"account_name"`
 	IsDefault   *bool  `json:"is_default"`
 	IsActive    *bool  `json:"is_active"`
+
+	// Replaces the risk limits checked on the account's orders
+	RiskLimits *risk.Limits `json:"risk_limits"`
 }
 
 // RegisterRoutes registers broker management routes
@@ -107,6 +120,8 @@ func (h *BrokerManagementHandler) AddBrokerAccount(c *gin.Context) {
 		"zerodha":     true,
 		"angelone":    true,
 		"upstox":      true,
+		"fyers":       true,
+		"paper":       true,
 		"icicidirect": true,
 	}
 	if !validBrokers[req.BrokerName] {
@@ -137,7 +152,7 @@ func (h *BrokerManagementHandler) AddBrokerAccount(c *gin.Context) {
 		userID,
 		"broker.add",
 		"broker_config",
-		string(rune(config.ConfigID)),
+		strconv.Itoa(config.ConfigID),
 		c.ClientIP(),
 		c.GetHeader("User-Agent"),
 		map[string]interface{}{
@@ -163,54 +178,39 @@ func (h *BrokerManagementHandler) GetBrokerAccount(c *gin.Context) {
 		return
 	}
 
-	configID := c.Param("config_id")
+	configID, ok := parseConfigID(c)
+	if !ok {
+		return
+	}
 
-	// Get user's broker configs to verify ownership
-	configs, err := h.db.GetUserBrokerConfigs(userID)
+	config, err := h.db.GetUserBrokerConfig(userID, configID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to fetch broker account",
 		})
 		return
 	}
-
-	// Find the specific config
-	var foundConfig *database.BrokerConfig
-	for _, cfg := range configs {
-		if string(rune(cfg.ConfigID)) == configID {
-			foundConfig = cfg
-			break
-		}
-	}
-
-	if foundConfig == nil {
+	if config == nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "broker account not found",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"config_id":        foundConfig.ConfigID,
-		"broker_name":      foundConfig.BrokerName,
-		"account_name":     foundConfig.AccountName,
-		"is_default":       foundConfig.IsDefault,
-		"is_active":        foundConfig.IsActive,
-		"has_access_token": foundConfig.AccessToken != "",
-		"token_expires_at": foundConfig.TokenExpiresAt,
-		"created_at":       foundConfig.CreatedAt,
-		"updated_at":       foundConfig.UpdatedAt,
-	})
+	c.JSON(http.StatusOK, brokerAccountJSON(config))
 }
 
 // UpdateBrokerAccount updates a broker account
 func (h *BrokerManagementHandler) UpdateBrokerAccount(c *gin.Context) {
-	_, exists := RequireUserID(c)
+	userID, exists := RequireUserID(c)
 	if !exists {
 		return
 	}
 
-	configID := c.Param("config_id")
+	configID, ok := parseConfigID(c)
+	if !ok {
+		return
+	}
 
 	var req UpdateBrokerAccountRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -220,12 +220,72 @@ func (h *BrokerManagementHandler) UpdateBrokerAccount(c *gin.Context) {
 		return
 	}
 
-	// TODO: Implement update logic in database package
-	// For now, return success
-	c.JSON(http.StatusOK, gin.H{
-		"message": "broker account updated",
-		"config_id": configID,
-	})
+	previous, err := h.db.GetUserBrokerConfig(userID, configID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch broker account: " + err.Error(),
+		})
+		return
+	}
+	if previous == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "broker account not found",
+		})
+		return
+	}
+
+	update := database.BrokerConfigUpdate{
+		AccountName: req.AccountName,
+		AccessToken: req.AccessToken,
+		IsActive:    req.IsActive,
+		IsDefault:   req.IsDefault,
+	}
+	if limits := req.RiskLimits; limits != nil {
+		if err := limits.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		update.MaxPositions = &limits.MaxPositions
+		update.MaxRiskPerTrade = &limits.MaxRiskPerTrade
+		update.MaxDailyLoss = &limits.MaxDailyLoss
+		update.MaxSymbolExposure = &limits.MaxSymbolExposure
+	}
+
+	config, err := h.db.UpdateUserBrokerConfig(userID, configID, update)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "broker account not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to update broker account: " + err.Error(),
+		})
+		return
+	}
+
+	if previous.IsDefault || config.IsDefault {
+		h.closeHub(userID)
+	}
+
+	// Audit log
+	h.db.CreateAuditLog(
+		userID,
+		"broker.update",
+		"broker_config",
+		strconv.Itoa(configID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+		map[string]interface{}{
+			"account_name":     req.AccountName,
+			"access_token_set": req.AccessToken != "",
+			"is_default":       req.IsDefault,
+			"is_active":        req.IsActive,
+		},
+	)
+
+	c.JSON(http.StatusOK, brokerAccountJSON(config))
 }
 
 // DeleteBrokerAccount deletes a broker account
@@ -235,41 +295,136 @@ func (h *BrokerManagementHandler) DeleteBrokerAccount(c *gin.Context) {
 		return
 	}
 
-	configID := c.Param("config_id")
+	configID, ok := parseConfigID(c)
+	if !ok {
+		return
+	}
+
+	config, err := h.db.GetUserBrokerConfig(userID, configID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch broker account: " + err.Error(),
+		})
+		return
+	}
+	if config == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "broker account not found",
+		})
+		return
+	}
+
+	err = h.db.DeleteUserBrokerConfig(userID, configID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "broker account not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to delete broker account: " + err.Error(),
+		})
+		return
+	}
 
-	// TODO: Implement delete logic in database package
-	// For now, return success
+	if config.IsDefault {
+		h.closeHub(userID)
+	}
 
 	// Audit log
 	h.db.CreateAuditLog(
 		userID,
 		"broker.delete",
 		"broker_config",
-		configID,
+		strconv.Itoa(configID),
 		c.ClientIP(),
 		c.GetHeader("User-Agent"),
-		nil,
+		map[string]interface{}{
+			"broker_name":  config.BrokerName,
+			"account_name": config.AccountName,
+		},
 	)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "broker account deleted",
+		"message":   "broker account deleted",
+		"config_id": configID,
 	})
 }
 
 // SetDefaultBrokerAccount sets a broker account as the default
 func (h *BrokerManagementHandler) SetDefaultBrokerAccount(c *gin.Context) {
-	_, exists := RequireUserID(c)
+	userID, exists := RequireUserID(c)
 	if !exists {
 		return
 	}
 
-	configID := c.Param("config_id")
+	configID, ok := parseConfigID(c)
+	if !ok {
+		return
+	}
+
+	config, err := h.db.SetUserDefaultBrokerConfig(userID, configID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "broker account not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to set default broker account: " + err.Error(),
+		})
+		return
+	}
+
+	h.closeHub(userID)
 
-	// TODO: Implement set default logic in database package
-	// For now, return success
+	// Audit log
+	h.db.CreateAuditLog(
+		userID,
+		"broker.set_default",
+		"broker_config",
+		strconv.Itoa(configID),
+		c.ClientIP(),
+		c.GetHeader("User-Agent"),
+		nil,
+	)
 
-	c.JSON(http.StatusOK, gin.H{
-		"message":   "default broker account updated",
-		"config_id": configID,
-	})
+	c.JSON(http.StatusOK, brokerAccountJSON(config))
+}
+
+// closeHub drops the user's /ws hub after their default account changed
+func (h *BrokerManagementHandler) closeHub(userID string) {
+	if h.hubs != nil {
+		h.hubs.CloseHub(userID)
+	}
+}
+
+// parseConfigID reads the :config_id path parameter, answering 400 if it
+// isn't a number
+func parseConfigID(c *gin.Context) (int, bool) {
+	configID, err := strconv.Atoi(c.Param("config_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid config_id",
+		})
+		return 0, false
+	}
+	return configID, true
+}
+
+// brokerAccountJSON describes a broker account without its credentials
+func brokerAccountJSON(config *database.BrokerConfig) gin.H {
+	return gin.H{
+		"config_id":        config.ConfigID,
+		"broker_name":      config.BrokerName,
+		"account_name":     config.AccountName,
+		"is_default":       config.IsDefault,
+		"is_active":        config.IsActive,
+		"has_access_token": config.AccessToken != "",
+		"token_expires_at": config.TokenExpiresAt,
+		"created_at":       config.CreatedAt,
+		"updated_at":       config.UpdatedAt,
+	}
 }
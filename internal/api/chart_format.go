@@ -0,0 +1,103 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// Response formats of bar and candle endpoints
+const (
+	formatJSON        = "json"         // An object per bar
+	formatOHLCVArrays = "ohlcv-arrays" // Columnar arrays in place of the bar objects
+	formatTV          = "tv"           // Only the arrays, with a TradingView UDF status
+)
+
+// ohlcvArrays holds bars as parallel arrays, the shape uPlot and the
+// TradingView datafeeds take. Timestamps are Unix seconds. It is a fraction
+// of the size of the same bars as objects.
+type ohlcvArrays struct {
+	T []int64   `json:"t"`
+	O []float64 `json:"o"`
+	H []float64 `json:"h"`
+	L []float64 `json:"l"`
+	C []float64 `json:"c"`
+	V []int64   `json:"v"`
+}
+
+// newOHLCVArrays allocates arrays for n bars
+func newOHLCVArrays(n int) ohlcvArrays {
+	return ohlcvArrays{
+		T: make([]int64, 0, n),
+		O: make([]float64, 0, n),
+		H: make([]float64, 0, n),
+		L: make([]float64, 0, n),
+		C: make([]float64, 0, n),
+		V: make([]int64, 0, n),
+	}
+}
+
+// barArrays converts intraday bars to arrays
+func barArrays(bars []database.IntradayBar) ohlcvArrays {
+	a := newOHLCVArrays(len(bars))
+	for _, bar := range bars {
+		a.T = append(a.T, bar.BarTimestamp.Unix())
+		a.O = append(a.O, bar.Open)
+		a.H = append(a.H, bar.High)
+		a.L = append(a.L, bar.Low)
+		a.C = append(a.C, bar.Close)
+		a.V = append(a.V, bar.Volume)
+	}
+	return a
+}
+
+// candleArrays converts historical candles to arrays
+func candleArrays(candles []database.HistoricalCandle) ohlcvArrays {
+	a := newOHLCVArrays(len(candles))
+	for _, candle := range candles {
+		a.T = append(a.T, candle.CandleTimestamp.Unix())
+		a.O = append(a.O, candle.Open)
+		a.H = append(a.H, candle.High)
+		a.L = append(a.L, candle.Low)
+		a.C = append(a.C, candle.Close)
+		a.V = append(a.V, candle.Volume)
+	}
+	return a
+}
+
+// udf returns the arrays as a UDF history response: status ok with the
+// arrays, or no_data without bars
+func (a ohlcvArrays) udf() gin.H {
+	if len(a.T) == 0 {
+		return gin.H{"s": "no_data"}
+	}
+	return gin.H{"s": "ok", "t": a.T, "o": a.O, "h": a.H, "l": a.L, "c": a.C, "v": a.V}
+}
+
+// chartFormat reads the format query parameter, json if absent. It
+// responds with an error for other formats.
+func chartFormat(c *gin.Context) (string, bool) {
+	switch format := c.DefaultQuery("format", formatJSON); format {
+	case formatJSON, formatOHLCVArrays, formatTV:
+		return format, true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid format, must be one of: json, ohlcv-arrays, tv",
+		})
+		return "", false
+	}
+}
+
+// writeOHLCV responds with body in format. For ohlcv-arrays the rows under
+// key are replaced by arrays; tv responds with only the arrays.
+func writeOHLCV(c *gin.Context, format string, body gin.H, key string, arrays func() ohlcvArrays) {
+	switch format {
+	case formatOHLCVArrays:
+		body[key] = arrays()
+	case formatTV:
+		c.JSON(http.StatusOK, arrays().udf())
+		return
+	}
+	c.JSON(http.StatusOK, body)
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+func TestWriteOHLCV(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	start := time.Date(2024, 1, 30, 3, 45, 0, 0, time.UTC)
+	bars := []database.IntradayBar{
+		{Symbol: "INFY", BarTimestamp: start, Open: 100, High: 102, Low: 99.5, Close: 101, Volume: 1200},
+		{Symbol: "INFY", BarTimestamp: start.Add(time.Minute), Open: 101, High: 101.5, Low: 100.25, Close: 100.5, Volume: 800},
+	}
+	arrays := map[string]interface{}{
+		"t": []interface{}{float64(start.Unix()), float64(start.Unix() + 60)},
+		"o": []interface{}{100.0, 101.0},
+		"h": []interface{}{102.0, 101.5},
+		"l": []interface{}{99.5, 100.25},
+		"c": []interface{}{101.0, 100.5},
+		"v": []interface{}{1200.0, 800.0},
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		bars       []database.IntradayBar
+		wantStatus int
+		check      func(t *testing.T, body map[string]interface{})
+	}{
+		{
+			name:       "objects by default",
+			bars:       bars,
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body map[string]interface{}) {
+				rows, _ := body["bars"].([]interface{})
+				if len(rows) != 2 || body["symbol"] != "INFY" {
+					t.Errorf("body = %v, want 2 bar objects with metadata", body)
+				}
+			},
+		},
+		{
+			name:       "arrays in the envelope",
+			query:      "?format=ohlcv-arrays",
+			bars:       bars,
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body map[string]interface{}) {
+				if !reflect.DeepEqual(body["bars"], arrays) || body["symbol"] != "INFY" {
+					t.Errorf("body = %v, want arrays %v with metadata", body, arrays)
+				}
+			},
+		},
+		{
+			name:       "tv arrays alone",
+			query:      "?format=tv",
+			bars:       bars,
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body map[string]interface{}) {
+				want := map[string]interface{}{"s": "ok"}
+				for k, v := range arrays {
+					want[k] = v
+				}
+				if !reflect.DeepEqual(body, want) {
+					t.Errorf("body = %v, want %v", body, want)
+				}
+			},
+		},
+		{
+			name:       "tv without bars",
+			query:      "?format=tv",
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body map[string]interface{}) {
+				if !reflect.DeepEqual(body, map[string]interface{}{"s": "no_data"}) {
+					t.Errorf("body = %v, want no_data", body)
+				}
+			},
+		},
+		{
+			name:       "unknown format",
+			query:      "?format=csv",
+			bars:       bars,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/intraday/bars/INFY"+tt.query, nil)
+
+			if format, ok := chartFormat(c); ok {
+				writeOHLCV(c, format, gin.H{"symbol": "INFY", "bars": tt.bars}, "bars", func() ohlcvArrays { return barArrays(tt.bars) })
+			}
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.check == nil {
+				return
+			}
+			var body map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("body is not JSON: %v", err)
+			}
+			tt.check(t, body)
+		})
+	}
+}
+
+func TestCandleArrays(t *testing.T) {
+	day := time.Date(2024, 1, 29, 0, 0, 0, 0, time.FixedZone("IST", 5*3600+1800))
+	candles := []database.HistoricalCandle{
+		{CandleTimestamp: day, Open: 21500, High: 21750, Low: 21450, Close: 21700, Volume: 0},
+		{CandleTimestamp: day.AddDate(0, 0, 1), Open: 21700, High: 21800, Low: 21500, Close: 21550, Volume: 0},
+	}
+
+	got := candleArrays(candles)
+	want := ohlcvArrays{
+		T: []int64{day.Unix(), day.AddDate(0, 0, 1).Unix()},
+		O: []float64{21500, 21700},
+		H: []float64{21750, 21800},
+		L: []float64{21450, 21500},
+		C: []float64{21700, 21550},
+		V: []int64{0, 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("candleArrays() = %+v, want %+v", got, want)
+	}
+
+	// Empty arrays, not null, so charts can take them as they are
+	empty, _ := json.Marshal(candleArrays(nil))
+	if string(empty) != `{"t":[],"o":[],"h":[],"l":[],"c":[],"v":[]}` {
+		t.Errorf("no candles = %s, want empty arrays", empty)
+	}
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/trading-chitti/market-bridge/internal/collector"
@@ -20,20 +21,34 @@ func NewCollectorHandler(db *database.Database) *CollectorHandler {
 	}
 }
 
-// RegisterRoutes registers collector routes
-func (h *CollectorHandler) RegisterRoutes(r *gin.RouterGroup) {
+// RegisterRoutes registers collector and replay routes. Pass the auth
+// middleware in multi-user mode.
+func (h *CollectorHandler) RegisterRoutes(r *gin.RouterGroup, middleware ...gin.HandlerFunc) {
 	collectors := r.Group("/collectors")
+	collectors.Use(middleware...)
 	{
 		collectors.POST("", h.CreateCollector)
 		collectors.GET("", h.ListCollectors)
 		collectors.GET("/:name", h.GetCollectorStatus)
+		collectors.GET("/:name/health", h.GetCollectorHealth)
 		collectors.POST("/:name/start", h.StartCollector)
 		collectors.POST("/:name/stop", h.StopCollector)
 		collectors.POST("/:name/subscribe", h.SubscribeSymbols)
 		collectors.POST("/:name/unsubscribe", h.UnsubscribeSymbols)
+		collectors.POST("/:name/watchlists", h.SubscribeWatchlist)
+		collectors.DELETE("/:name/watchlists/:watchlist", h.UnsubscribeWatchlist)
 		collectors.DELETE("/:name", h.DeleteCollector)
 		collectors.GET("/metrics", h.GetMetrics)
 	}
+
+	replays := r.Group("/replays")
+	replays.Use(middleware...)
+	{
+		replays.POST("", h.StartReplay)
+		replays.GET("", h.ListReplays)
+		replays.GET("/:id", h.GetReplay)
+		replays.DELETE("/:id", h.DeleteReplay)
+	}
 }
 
 // CreateCollectorRequest represents collector creation request
@@ -42,7 +57,10 @@ type CreateCollectorRequest struct {
 	Type        string   `json:"type" binding:"required"` // "real" or "mock"
 	APIKey      string   `json:"api_key"`                 // Required for real collectors
 	AccessToken string   `json:"access_token"`            // Required for real collectors
-	Symbols     []string `json:"symbols"`                 // Required for mock collectors
+	Symbols     []string `json:"symbols"`                 // Required for mock collectors without watchlists
+	Watchlists  []string `json:"watchlists"`              // Watchlists to follow, e.g. TOP_GAINERS
+	Mode        string   `json:"mode"`                    // ltp, quote or full (default) for real collectors
+	AutoStart   bool     `json:"auto_start"`              // Start now; running collectors start again on boot
 }
 
 // SubscribeRequest represents symbol subscription request
@@ -50,6 +68,11 @@ type SubscribeRequest struct {
 	Symbols []string `json:"symbols" binding:"required"`
 }
 
+// SubscribeWatchlistRequest subscribes a collector to a watchlist
+type SubscribeWatchlistRequest struct {
+	Watchlist string `json:"watchlist" binding:"required"` // Built-in name, or user watchlist ID or name
+}
+
 // CreateCollector creates a new data collector
 // POST /collectors
 func (h *CollectorHandler) CreateCollector(c *gin.Context) {
@@ -70,11 +93,20 @@ func (h *CollectorHandler) CreateCollector(c *gin.Context) {
 			})
 			return
 		}
+		if req.Mode != "" && req.Mode != collector.ModeLTP && req.Mode != collector.ModeQuote && req.Mode != collector.ModeFull {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "mode must be 'ltp', 'quote' or 'full'",
+			})
+			return
+		}
 		err = h.manager.CreateRealCollector(req.Name, req.APIKey, req.AccessToken)
+		if err == nil && req.Mode != "" && req.Mode != collector.ModeFull {
+			err = h.manager.SetCollectorMode(req.Name, req.Mode)
+		}
 	case "mock":
-		if len(req.Symbols) == 0 {
+		if len(req.Symbols) == 0 && len(req.Watchlists) == 0 {
 			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "symbols are required for mock collectors",
+				"error": "symbols or watchlists are required for mock collectors",
 			})
 			return
 		}
@@ -93,11 +125,37 @@ func (h *CollectorHandler) CreateCollector(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
+	followed := make(map[string][]string, len(req.Watchlists))
+	for _, name := range req.Watchlists {
+		symbols, err := h.manager.SubscribeWatchlist(req.Name, strings.TrimSpace(name))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "collector created but failed to follow watchlist " + name + ": " + err.Error(),
+			})
+			return
+		}
+		followed[name] = symbols
+	}
+
+	if req.AutoStart {
+		if err := h.manager.StartCollector(req.Name); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "collector created but failed to start: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	response := gin.H{
 		"message": "collector created successfully",
 		"name":    req.Name,
 		"type":    req.Type,
-	})
+		"running": req.AutoStart,
+	}
+	if len(followed) > 0 {
+		response["watchlists"] = followed
+	}
+	c.JSON(http.StatusCreated, response)
 }
 
 // ListCollectors lists all collectors
@@ -123,10 +181,28 @@ func (h *CollectorHandler) GetCollectorStatus(c *gin.Context) {
 		})
 		return
 	}
+	metrics["watchlists"] = h.manager.GetFollowedWatchlists(name)
 
 	c.JSON(http.StatusOK, metrics)
 }
 
+// GetCollectorHealth checks whether a collector's data is flowing:
+// HEALTHY, DEGRADED (some symbols stale), STALLED, STOPPED or IDLE
+// GET /collectors/:name/health
+func (h *CollectorHandler) GetCollectorHealth(c *gin.Context) {
+	name := c.Param("name")
+
+	health, err := h.manager.CheckHealth(name)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, health)
+}
+
 // StartCollector starts a data collector
 // POST /collectors/:name/start
 func (h *CollectorHandler) StartCollector(c *gin.Context) {
@@ -218,6 +294,60 @@ func (h *CollectorHandler) UnsubscribeSymbols(c *gin.Context) {
 	})
 }
 
+// SubscribeWatchlist subscribes a collector to a watchlist's symbols and
+// keeps them in sync as the watchlist changes (movers, edited user lists)
+// POST /collectors/:name/watchlists
+// Body: {"watchlist": "TOP_GAINERS"}
+func (h *CollectorHandler) SubscribeWatchlist(c *gin.Context) {
+	name := c.Param("name")
+
+	var req SubscribeWatchlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: " + err.Error(),
+		})
+		return
+	}
+	watchlistName := strings.TrimSpace(req.Watchlist)
+
+	symbols, err := h.manager.SubscribeWatchlist(name, watchlistName)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "failed to subscribe: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "watchlist subscribed successfully",
+		"collector":     name,
+		"watchlist":     watchlistName,
+		"symbols":       symbols,
+		"symbols_count": len(symbols),
+	})
+}
+
+// UnsubscribeWatchlist stops following a watchlist, unsubscribing symbols
+// no other followed watchlist or direct subscription needs
+// DELETE /collectors/:name/watchlists/:watchlist
+func (h *CollectorHandler) UnsubscribeWatchlist(c *gin.Context) {
+	name := c.Param("name")
+	watchlistName := c.Param("watchlist")
+
+	if err := h.manager.UnsubscribeWatchlist(name, watchlistName); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "failed to unsubscribe: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "watchlist unsubscribed successfully",
+		"collector": name,
+		"watchlist": watchlistName,
+	})
+}
+
 // GetMetrics returns metrics for all collectors
 // GET /collectors/metrics
 func (h *CollectorHandler) GetMetrics(c *gin.Context) {
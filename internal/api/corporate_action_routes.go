@@ -0,0 +1,174 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// CorporateActionHandler handles corporate action requests
+type CorporateActionHandler struct {
+	db *database.Database
+}
+
+// NewCorporateActionHandler creates a new corporate action handler
+func NewCorporateActionHandler(db *database.Database) *CorporateActionHandler {
+	return &CorporateActionHandler{db: db}
+}
+
+// RegisterRoutes registers corporate action routes. writeMiddleware runs
+// before the routes that add and delete actions, which change everyone's
+// adjusted prices.
+func (h *CorporateActionHandler) RegisterRoutes(r *gin.RouterGroup, writeMiddleware ...gin.HandlerFunc) {
+	actions := r.Group("/corporate-actions")
+	{
+		actions.GET("/:symbol", h.GetCorporateActions)
+	}
+
+	writes := r.Group("/corporate-actions", writeMiddleware...)
+	{
+		writes.POST("", h.SaveCorporateActions)
+		writes.DELETE("/:symbol/:id", h.DeleteCorporateAction)
+	}
+}
+
+// CorporateActionRequest is one action to store
+type CorporateActionRequest struct {
+	Exchange    string  `json:"exchange"` // Defaults to NSE
+	Symbol      string  `json:"symbol" binding:"required"`
+	ExDate      string  `json:"ex_date" binding:"required"` // YYYY-MM-DD
+	Type        string  `json:"type" binding:"required"`    // split, bonus or dividend
+	Ratio       string  `json:"ratio"`                      // Splits and bonuses, e.g. 1:5
+	Amount      float64 `json:"amount"`                     // Dividends, per share
+	Description string  `json:"description"`
+}
+
+// SaveCorporateActions stores a list of actions, replacing any stored for
+// the same symbol, ex-date and type
+// POST /corporate-actions
+func (h *CorporateActionHandler) SaveCorporateActions(c *gin.Context) {
+	var req []CorporateActionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: " + err.Error(),
+		})
+		return
+	}
+	if len(req) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "at least one corporate action is required",
+		})
+		return
+	}
+
+	actions := make([]database.CorporateAction, len(req))
+	for i, r := range req {
+		if r.Exchange == "" {
+			r.Exchange = "NSE"
+		}
+		action, err := database.ParseCorporateAction(r.Exchange, r.Symbol, r.ExDate, r.Type, r.Ratio, r.Amount)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "action " + strconv.Itoa(i) + ": " + err.Error(),
+			})
+			return
+		}
+		action.Description = r.Description
+		actions[i] = action
+	}
+
+	if err := h.db.SaveCorporateActions(actions); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to save corporate actions: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "corporate actions saved",
+		"count":   len(actions),
+	})
+}
+
+// GetCorporateActions lists a symbol's actions with each one's price factor
+// GET /corporate-actions/:symbol?exchange=NSE
+func (h *CorporateActionHandler) GetCorporateActions(c *gin.Context) {
+	symbol := strings.ToUpper(c.Param("symbol"))
+	exchange := strings.ToUpper(c.DefaultQuery("exchange", "NSE"))
+
+	actions, err := h.db.GetCorporateActions(exchange, symbol)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch corporate actions: " + err.Error(),
+		})
+		return
+	}
+
+	type actionWithFactor struct {
+		database.CorporateAction
+		PriceFactor float64 `json:"price_factor"`
+	}
+	results := make([]actionWithFactor, len(actions))
+	for i, action := range actions {
+		results[i] = actionWithFactor{CorporateAction: action, PriceFactor: action.PriceFactor()}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"exchange": exchange,
+		"symbol":   symbol,
+		"count":    len(results),
+		"actions":  results,
+	})
+}
+
+// DeleteCorporateAction removes one of a symbol's actions entered by mistake
+// DELETE /corporate-actions/:symbol/:id
+func (h *CorporateActionHandler) DeleteCorporateAction(c *gin.Context) {
+	actionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid action id",
+		})
+		return
+	}
+
+	deleted, err := h.db.DeleteCorporateAction(strings.ToUpper(c.Param("symbol")), actionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to delete corporate action: " + err.Error(),
+		})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "corporate action not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "corporate action deleted",
+		"action_id": actionID,
+	})
+}
+
+// corporateActionsFor returns the actions to adjust a symbol's prices by
+// when the request asks for adjusted=true, nil otherwise. It responds with
+// an error and returns false when they can't be loaded.
+func corporateActionsFor(c *gin.Context, db *database.Database, exchange, symbol string) ([]database.CorporateAction, bool) {
+	if c.Query("adjusted") != "true" {
+		return nil, true
+	}
+
+	actions, err := db.GetCorporateActions(exchange, symbol)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch corporate actions: " + err.Error(),
+		})
+		return nil, false
+	}
+	return actions, true
+}
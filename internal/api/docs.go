@@ -0,0 +1,75 @@
+package api
+
+import (
+	_ "embed"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openAPISpec documents every HTTP endpoint; keep it in step with the routes
+//
+//go:embed docs/openapi.yaml
+var openAPISpec []byte
+
+// websocketDoc describes the streaming message formats
+//
+//go:embed docs/websocket.md
+var websocketDoc []byte
+
+// swaggerUIPage renders /docs/openapi.yaml with Swagger UI from a CDN
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Market Bridge API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({
+        url: "/docs/openapi.yaml",
+        dom_id: "#swagger-ui",
+        persistAuthorization: true,
+      });
+    };
+  </script>
+</body>
+</html>
+`
+
+// RegisterDocsRoutes serves the API documentation. The pages are public so
+// the key can be entered in Swagger UI.
+func (a *API) RegisterDocsRoutes(r *gin.Engine) {
+	r.GET("/docs", a.SwaggerUI)
+	r.GET("/docs/openapi.yaml", a.OpenAPISpec)
+	r.GET("/docs/websocket", a.WebSocketDoc)
+}
+
+// SwaggerUI serves the interactive API documentation
+// GET /docs
+func (a *API) SwaggerUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+}
+
+// OpenAPISpec serves the OpenAPI specification
+// GET /docs/openapi.yaml
+func (a *API) OpenAPISpec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/yaml; charset=utf-8", openAPISpec)
+}
+
+// WebSocketDoc serves the streaming message formats as Markdown
+// GET /docs/websocket
+func (a *API) WebSocketDoc(c *gin.Context) {
+	c.Data(http.StatusOK, "text/markdown; charset=utf-8", websocketDoc)
+}
+
+// isDocsPath reports whether a path is API documentation, which is served
+// without authentication
+func isDocsPath(path string) bool {
+	return path == "/docs" || strings.HasPrefix(path, "/docs/")
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// documentedRouter mounts every route the server registers, with nil
+// dependencies since only the route table is needed. /metrics is mounted in
+// main.
+func documentedRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	noop := func(c *gin.Context) {}
+
+	a := NewAPI(nil, nil)
+	a.SetCollectorHandler(NewCollectorHandler(nil))
+	a.RegisterRoutes(r)
+	a.RegisterWebSocketRoutes(r)
+	r.GET("/metrics", noop)
+
+	api := r.Group("/api")
+	NewAuthHandler(nil, nil).RegisterRoutes(api)
+	NewBrokerManagementHandler(nil, nil).RegisterRoutes(api, noop)
+	NewTOTPHandler(nil).RegisterRoutes(api, noop)
+	NewAccountsHandler(nil).RegisterRoutes(api, noop)
+	NewStrategyHandler(nil, nil).RegisterRoutes(api)
+	NewUserWatchlistHandler(nil).RegisterRoutes(api)
+	NewAlertHandler(nil, nil).RegisterRoutes(api)
+	NewNotificationHandler(nil, nil).RegisterRoutes(api)
+
+	root := r.Group("")
+	NewRiskHandler(nil, nil, nil, 0).RegisterRoutes(root)
+	NewSquareOffHandler(nil).RegisterRoutes(root)
+	NewRetentionHandler(nil, nil).RegisterRoutes(root)
+	NewPortfolioHandler(nil, nil).RegisterRoutes(root)
+	NewTokenHandler(nil).RegisterRoutes(root)
+	NewKiteCallbackHandler(nil, nil, nil).RegisterRoutes(root)
+	return r
+}
+
+type openAPIDoc struct {
+	Paths map[string]map[string]interface{} `yaml:"paths"`
+}
+
+func loadSpec(t *testing.T) (openAPIDoc, map[string]interface{}) {
+	t.Helper()
+	var spec openAPIDoc
+	if err := yaml.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatalf("openapi.yaml doesn't parse: %v", err)
+	}
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(openAPISpec, &raw); err != nil {
+		t.Fatal(err)
+	}
+	return spec, raw
+}
+
+var ginParam = regexp.MustCompile(`:([A-Za-z_]+)`)
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	spec, _ := loadSpec(t)
+
+	registered := map[string]bool{}
+	for _, route := range documentedRouter().Routes() {
+		path := ginParam.ReplaceAllString(route.Path, "{$1}")
+		method := strings.ToLower(route.Method)
+		registered[method+" "+path] = true
+
+		if _, ok := spec.Paths[path][method]; !ok {
+			t.Errorf("%s %s is not documented", route.Method, path)
+		}
+	}
+
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			if method == "parameters" {
+				continue
+			}
+			if !registered[method+" "+path] {
+				t.Errorf("%s %s is documented but not registered", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestOpenAPIRefsResolve(t *testing.T) {
+	_, raw := loadSpec(t)
+
+	var refs []string
+	var walk func(node interface{})
+	walk = func(node interface{}) {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			for key, value := range n {
+				if ref, ok := value.(string); ok && key == "$ref" {
+					refs = append(refs, ref)
+				}
+				walk(value)
+			}
+		case []interface{}:
+			for _, value := range n {
+				walk(value)
+			}
+		}
+	}
+	walk(raw)
+	if len(refs) == 0 {
+		t.Fatal("no $refs found")
+	}
+
+	for _, ref := range refs {
+		var node interface{} = raw
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			m, ok := node.(map[string]interface{})
+			if !ok {
+				node = nil
+				break
+			}
+			node = m[part]
+		}
+		if node == nil {
+			t.Errorf("%s doesn't resolve", ref)
+		}
+	}
+}
+
+func TestDocsRoutes(t *testing.T) {
+	tests := []struct {
+		path        string
+		contentType string
+		contains    string
+	}{
+		{"/docs", "text/html", "/docs/openapi.yaml"},
+		{"/docs/openapi.yaml", "application/yaml", "openapi: 3.0.3"},
+		{"/docs/websocket", "text/markdown", "# Streaming API"},
+	}
+
+	router := gin.New()
+	router.Use(APIKeyMiddleware())
+	(&API{}).RegisterDocsRoutes(router)
+	t.Setenv("API_KEY", "secret")
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("code = %d, want 200 without an API key", w.Code)
+			}
+			if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, tt.contentType) {
+				t.Errorf("Content-Type = %q, want %s", got, tt.contentType)
+			}
+			if !strings.Contains(w.Body.String(), tt.contains) {
+				t.Errorf("body doesn't contain %q", tt.contains)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/collector"
+	"github.com/trading-chitti/market-bridge/internal/services"
+)
+
+// Dependency statuses reported by /health/ready
+const (
+	DependencyOK       = "ok"
+	DependencyDegraded = "degraded"
+	DependencyDown     = "down"
+)
+
+// readinessTimeout bounds the whole /health/ready run; checks still running
+// then are reported down
+const readinessTimeout = 3 * time.Second
+
+// DependencyStatus is the result of one readiness check
+type DependencyStatus struct {
+	Status   string                 `json:"status"`
+	Critical bool                   `json:"critical"`
+	Message  string                 `json:"message,omitempty"`
+	Details  map[string]interface{} `json:"details,omitempty"`
+}
+
+// ReadinessCheck reports the state of one dependency
+type ReadinessCheck func(ctx context.Context) DependencyStatus
+
+type readinessCheck struct {
+	name     string
+	critical bool
+	check    ReadinessCheck
+}
+
+// AddReadinessCheck adds a dependency to /health/ready. The service is only
+// reported not ready when a critical dependency is down; the others are
+// listed for information. The database is always checked. Call before
+// RegisterRoutes.
+func (a *API) AddReadinessCheck(name string, critical bool, check ReadinessCheck) {
+	a.readiness = append(a.readiness, readinessCheck{name: name, critical: critical, check: check})
+}
+
+// Liveness reports that the process is serving requests. It checks no
+// dependencies, so an outage elsewhere doesn't get the pod restarted.
+// GET /health/live
+func (a *API) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "alive",
+		"timestamp": time.Now(),
+	})
+}
+
+// Readiness checks every dependency concurrently and answers 503 when a
+// critical one is down
+// GET /health/ready
+func (a *API) Readiness(c *gin.Context) {
+	checks := a.readiness
+	if a.db != nil {
+		checks = append([]readinessCheck{{name: "database", critical: true, check: DatabaseCheck(a.db.Ping)}}, checks...)
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	results := runReadinessChecks(ctx, checks)
+	ready := true
+	for _, result := range results {
+		if result.Critical && result.Status == DependencyDown {
+			ready = false
+		}
+	}
+
+	status, code := "ready", http.StatusOK
+	if !ready {
+		status, code = "not_ready", http.StatusServiceUnavailable
+	}
+	c.JSON(code, gin.H{
+		"status":       status,
+		"dependencies": results,
+		"timestamp":    time.Now(),
+	})
+}
+
+// runReadinessChecks runs the checks concurrently until ctx is done
+func runReadinessChecks(ctx context.Context, checks []readinessCheck) map[string]DependencyStatus {
+	results := make(map[string]DependencyStatus, len(checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, rc := range checks {
+		wg.Add(1)
+		go func(rc readinessCheck) {
+			defer wg.Done()
+
+			done := make(chan DependencyStatus, 1)
+			go func() { done <- rc.check(ctx) }()
+
+			var result DependencyStatus
+			select {
+			case result = <-done:
+			case <-ctx.Done():
+				result = DependencyStatus{Status: DependencyDown, Message: "check timed out"}
+			}
+			result.Critical = rc.critical
+
+			mu.Lock()
+			results[rc.name] = result
+			mu.Unlock()
+		}(rc)
+	}
+	wg.Wait()
+	return results
+}
+
+// DatabaseCheck reports the database down when ping fails
+func DatabaseCheck(ping func(ctx context.Context) error) ReadinessCheck {
+	return func(ctx context.Context) DependencyStatus {
+		if err := ping(ctx); err != nil {
+			return DependencyStatus{Status: DependencyDown, Message: err.Error()}
+		}
+		return DependencyStatus{Status: DependencyOK}
+	}
+}
+
+// BrokerTokenCheck reports whether a broker's access token is usable: an
+// expiring token is degraded, an expired, failed or missing one is down
+func BrokerTokenCheck(service *services.TokenRefreshService, configID int) ReadinessCheck {
+	return func(ctx context.Context) DependencyStatus {
+		status, err := service.Status(configID)
+		if err != nil {
+			return DependencyStatus{Status: DependencyDown, Message: fmt.Sprintf("failed to read token status: %v", err)}
+		}
+		if status == nil {
+			return DependencyStatus{Status: DependencyDown, Message: fmt.Sprintf("broker config %d not found", configID)}
+		}
+		return tokenDependency(status)
+	}
+}
+
+func tokenDependency(status *services.TokenStatus) DependencyStatus {
+	details := map[string]interface{}{
+		"broker":     status.BrokerName,
+		"config_id":  status.ConfigID,
+		"token":      status.Status,
+		"expires_at": status.ExpiresAt,
+	}
+
+	switch status.Status {
+	case "valid":
+		return DependencyStatus{Status: DependencyOK, Details: details}
+	case "expiring":
+		return DependencyStatus{Status: DependencyDegraded, Message: "access token expires soon", Details: details}
+	default:
+		message := "access token " + strings.ReplaceAll(status.Status, "_", " ")
+		if status.LastError != "" {
+			message += ": " + status.LastError
+		}
+		return DependencyStatus{Status: DependencyDown, Message: message, Details: details}
+	}
+}
+
+// BrokerCircuitCheck reports a broker's circuit breaker: down while open,
+// degraded while a probe decides whether to close it
+func BrokerCircuitCheck(breaker *broker.CircuitBreaker) ReadinessCheck {
+	return func(ctx context.Context) DependencyStatus {
+		return circuitDependency(breaker.Status())
+	}
+}
+
+func circuitDependency(status broker.CircuitStatus) DependencyStatus {
+	details := map[string]interface{}{
+		"broker":               status.Broker,
+		"state":                status.State,
+		"consecutive_failures": status.Failures,
+	}
+	if status.RetryAt != nil {
+		details["retry_at"] = status.RetryAt
+	}
+
+	switch status.State {
+	case broker.CircuitClosed:
+		return DependencyStatus{Status: DependencyOK, Details: details}
+	case broker.CircuitHalfOpen:
+		return DependencyStatus{Status: DependencyDegraded, Message: "probing the broker after failures: " + status.LastError, Details: details}
+	default:
+		return DependencyStatus{Status: DependencyDown, Message: "broker calls suspended after failures: " + status.LastError, Details: details}
+	}
+}
+
+// CollectorsCheck reports the collectors' data flow: down when one is
+// stalled or has lost its ticker connection, degraded when symbols have
+// stopped ticking
+func CollectorsCheck(manager *collector.UnifiedCollectorManager) ReadinessCheck {
+	return func(ctx context.Context) DependencyStatus {
+		return collectorsDependency(manager.HealthReport())
+	}
+}
+
+func collectorsDependency(report []*collector.CollectorHealth) DependencyStatus {
+	result := DependencyStatus{Status: DependencyOK, Details: map[string]interface{}{}}
+	var problems []string
+
+	for _, health := range report {
+		status := DependencyOK
+		switch {
+		case health.Status == collector.HealthStalled:
+			status = DependencyDown
+		case health.Running && !health.Connected:
+			status = DependencyDown
+			health.Reason = "ticker disconnected"
+		case health.Status == collector.HealthDegraded:
+			status = DependencyDegraded
+		}
+
+		result.Details[health.Name] = gin.H{
+			"status":    health.Status,
+			"connected": health.Connected,
+		}
+		if status == DependencyOK {
+			continue
+		}
+		problems = append(problems, fmt.Sprintf("%s: %s", health.Name, health.Reason))
+		if status == DependencyDown || result.Status == DependencyOK {
+			result.Status = status
+		}
+	}
+
+	result.Message = strings.Join(problems, "; ")
+	return result
+}
+
+// isHealthPath reports whether path is a health probe, which is served
+// without authentication or rate limiting
+func isHealthPath(path string) bool {
+	return path == "/health" || strings.HasPrefix(path, "/health/")
+}
@@ -0,0 +1,229 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/collector"
+	"github.com/trading-chitti/market-bridge/internal/services"
+)
+
+func staticCheck(status string) ReadinessCheck {
+	return func(ctx context.Context) DependencyStatus {
+		return DependencyStatus{Status: status}
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type check struct {
+		name     string
+		critical bool
+		check    ReadinessCheck
+	}
+	tests := []struct {
+		name   string
+		checks []check
+		code   int
+		status string
+		want   map[string]string
+	}{
+		{
+			name:   "no dependencies",
+			code:   http.StatusOK,
+			status: "ready",
+			want:   map[string]string{},
+		},
+		{
+			name: "all ok",
+			checks: []check{
+				{"database", true, staticCheck(DependencyOK)},
+				{"collectors", false, staticCheck(DependencyOK)},
+			},
+			code:   http.StatusOK,
+			status: "ready",
+			want:   map[string]string{"database": DependencyOK, "collectors": DependencyOK},
+		},
+		{
+			name: "non-critical down",
+			checks: []check{
+				{"database", true, staticCheck(DependencyOK)},
+				{"broker_token", false, staticCheck(DependencyDown)},
+			},
+			code:   http.StatusOK,
+			status: "ready",
+			want:   map[string]string{"database": DependencyOK, "broker_token": DependencyDown},
+		},
+		{
+			name: "critical degraded",
+			checks: []check{
+				{"database", true, staticCheck(DependencyDegraded)},
+			},
+			code:   http.StatusOK,
+			status: "ready",
+			want:   map[string]string{"database": DependencyDegraded},
+		},
+		{
+			name: "critical down",
+			checks: []check{
+				{"database", true, staticCheck(DependencyDown)},
+				{"collectors", false, staticCheck(DependencyOK)},
+			},
+			code:   http.StatusServiceUnavailable,
+			status: "not_ready",
+			want:   map[string]string{"database": DependencyDown, "collectors": DependencyOK},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &API{}
+			for _, c := range tt.checks {
+				a.AddReadinessCheck(c.name, c.critical, c.check)
+			}
+			router := gin.New()
+			router.GET("/health/ready", a.Readiness)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+			if w.Code != tt.code {
+				t.Fatalf("code = %d, want %d", w.Code, tt.code)
+			}
+
+			var body struct {
+				Status       string                      `json:"status"`
+				Dependencies map[string]DependencyStatus `json:"dependencies"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Status != tt.status {
+				t.Errorf("status = %q, want %q", body.Status, tt.status)
+			}
+			if len(body.Dependencies) != len(tt.want) {
+				t.Fatalf("got %d dependencies, want %d", len(body.Dependencies), len(tt.want))
+			}
+			for name, want := range tt.want {
+				if got := body.Dependencies[name].Status; got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRunReadinessChecksTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	hung := func(ctx context.Context) DependencyStatus {
+		time.Sleep(time.Second)
+		return DependencyStatus{Status: DependencyOK}
+	}
+	results := runReadinessChecks(ctx, []readinessCheck{{name: "hung", critical: true, check: hung}})
+	if got := results["hung"]; got.Status != DependencyDown || !got.Critical {
+		t.Errorf("hung check = %+v, want critical and down", got)
+	}
+}
+
+func TestTokenDependency(t *testing.T) {
+	tests := []struct {
+		token string
+		want  string
+	}{
+		{"valid", DependencyOK},
+		{"expiring", DependencyDegraded},
+		{"expired", DependencyDown},
+		{"refresh_failed", DependencyDown},
+		{"missing", DependencyDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.token, func(t *testing.T) {
+			got := tokenDependency(&services.TokenStatus{ConfigID: 1, BrokerName: "zerodha", Status: tt.token})
+			if got.Status != tt.want {
+				t.Errorf("status = %q, want %q", got.Status, tt.want)
+			}
+		})
+	}
+}
+
+func TestCircuitDependency(t *testing.T) {
+	tests := []struct {
+		state string
+		want  string
+	}{
+		{broker.CircuitClosed, DependencyOK},
+		{broker.CircuitHalfOpen, DependencyDegraded},
+		{broker.CircuitOpen, DependencyDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.state, func(t *testing.T) {
+			got := circuitDependency(broker.CircuitStatus{Broker: "zerodha", State: tt.state})
+			if got.Status != tt.want {
+				t.Errorf("status = %q, want %q", got.Status, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectorsDependency(t *testing.T) {
+	healthy := &collector.CollectorHealth{Name: "a", Status: collector.HealthHealthy, Running: true, Connected: true}
+	idle := &collector.CollectorHealth{Name: "b", Status: collector.HealthIdle, Running: true, Connected: true}
+	stopped := &collector.CollectorHealth{Name: "c", Status: collector.HealthStopped}
+	degraded := &collector.CollectorHealth{Name: "d", Status: collector.HealthDegraded, Running: true, Connected: true}
+	stalled := &collector.CollectorHealth{Name: "e", Status: collector.HealthStalled, Running: true, Connected: true}
+	disconnected := &collector.CollectorHealth{Name: "f", Status: collector.HealthHealthy, Running: true}
+
+	tests := []struct {
+		name   string
+		report []*collector.CollectorHealth
+		want   string
+	}{
+		{"no collectors", nil, DependencyOK},
+		{"healthy, idle and stopped", []*collector.CollectorHealth{healthy, idle, stopped}, DependencyOK},
+		{"degraded", []*collector.CollectorHealth{healthy, degraded}, DependencyDegraded},
+		{"stalled", []*collector.CollectorHealth{healthy, stalled}, DependencyDown},
+		{"disconnected", []*collector.CollectorHealth{disconnected}, DependencyDown},
+		{"down outranks degraded", []*collector.CollectorHealth{stalled, degraded}, DependencyDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := collectorsDependency(tt.report)
+			if got.Status != tt.want {
+				t.Errorf("status = %q, want %q (%s)", got.Status, tt.want, got.Message)
+			}
+			if len(got.Details) != len(tt.report) {
+				t.Errorf("got %d collector details, want %d", len(got.Details), len(tt.report))
+			}
+		})
+	}
+}
+
+func TestIsHealthPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/health", true},
+		{"/health/live", true},
+		{"/health/ready", true},
+		{"/healthz", false},
+		{"/collectors/a/health", false},
+	}
+
+	for _, tt := range tests {
+		if got := isHealthPath(tt.path); got != tt.want {
+			t.Errorf("isHealthPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
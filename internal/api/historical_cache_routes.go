@@ -0,0 +1,159 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/timeframe"
+)
+
+// GetHistoricalCacheStats reports the size of the historical candle cache
+// and the policy it is kept under. Hit and miss counts are in /metrics.
+// GET /historical/cache
+func (a *API) GetHistoricalCacheStats(c *gin.Context) {
+	stats, err := a.db.GetHistoricalCacheStats()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to read cache stats: " + err.Error(),
+		})
+		return
+	}
+
+	policy := a.historicalService.CachePolicy()
+	c.JSON(http.StatusOK, gin.H{
+		"stats": stats,
+		"policy": gin.H{
+			"ttl_seconds":  policy.TTL.Seconds(),
+			"refetch_days": policy.RefetchDays,
+			"max_rows":     policy.MaxRows,
+		},
+	})
+}
+
+// InvalidateHistoricalCache deletes a symbol's cached candles, of one
+// interval or all and optionally only from_date to to_date (IST days), so
+// the next read fetches them from the broker
+// DELETE /historical/cache/:symbol?exchange=NSE&interval=day&from_date=2024-01-01&to_date=2024-01-31
+func (a *API) InvalidateHistoricalCache(c *gin.Context) {
+	exchange := strings.ToUpper(c.DefaultQuery("exchange", "NSE"))
+	symbol := c.Param("symbol")
+
+	ist := broker.IST()
+	var fromDate, toDate time.Time
+	if value := c.Query("from_date"); value != "" {
+		var err error
+		if fromDate, err = time.ParseInLocation("2006-01-02", value, ist); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid from_date format (use YYYY-MM-DD)",
+			})
+			return
+		}
+	}
+	if value := c.Query("to_date"); value != "" {
+		day, err := time.ParseInLocation("2006-01-02", value, ist)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid to_date format (use YYYY-MM-DD)",
+			})
+			return
+		}
+		toDate = day.AddDate(0, 0, 1).Add(-time.Nanosecond) // Through the end of the day
+	}
+
+	interval, ok := cacheInterval(c)
+	if !ok {
+		return
+	}
+
+	deleted, err := a.historicalService.InvalidateCache(exchange, symbol, interval, fromDate, toDate)
+	if errors.Is(err, database.ErrInstrumentNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "instrument not found: " + exchange + ":" + symbol,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to invalidate cache: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"exchange": exchange,
+		"symbol":   symbol,
+		"interval": interval,
+		"deleted":  deleted,
+	})
+}
+
+// ClearHistoricalCache deletes every cached candle, or those of one
+// interval
+// DELETE /historical/cache?interval=day
+func (a *API) ClearHistoricalCache(c *gin.Context) {
+	interval, ok := cacheInterval(c)
+	if !ok {
+		return
+	}
+
+	deleted, err := a.db.ClearHistoricalCache(interval)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to clear cache: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"interval": interval,
+		"deleted":  deleted,
+	})
+}
+
+// PruneHistoricalCache prunes the least recently read series over the
+// cache's row cap now rather than after the next broker fetch
+// POST /historical/cache/prune
+func (a *API) PruneHistoricalCache(c *gin.Context) {
+	if a.historicalService.CachePolicy().MaxRows <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "the historical cache has no row cap, set HISTORICAL_CACHE_MAX_ROWS",
+		})
+		return
+	}
+
+	pruned, err := a.historicalService.PruneCache()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to prune cache: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pruned":   pruned,
+		"max_rows": a.historicalService.CachePolicy().MaxRows,
+	})
+}
+
+// cacheInterval reads the optional interval query parameter as the Kite
+// interval candles are cached under, empty for every interval. It responds
+// with an error for unknown intervals.
+func cacheInterval(c *gin.Context) (string, bool) {
+	value := c.Query("interval")
+	if value == "" {
+		return "", true
+	}
+	interval, err := timeframe.KiteInterval(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return "", false
+	}
+	return interval, true
+}
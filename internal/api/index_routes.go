@@ -0,0 +1,122 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// IndexHandler serves the spot data of indices: collector bars stored on
+// the INDEX exchange and the broker's historical candles
+type IndexHandler struct {
+	intraday   *IntradayHandler
+	historical *database.HistoricalDataService
+}
+
+// NewIndexHandler creates a new index handler
+func NewIndexHandler(brk broker.Broker, db *database.Database) *IndexHandler {
+	return &IndexHandler{
+		intraday:   NewIntradayHandler(db),
+		historical: database.NewHistoricalDataService(db, brk),
+	}
+}
+
+// RegisterRoutes registers index routes
+func (h *IndexHandler) RegisterRoutes(r *gin.RouterGroup) {
+	indices := r.Group("/indices")
+	{
+		indices.GET("", h.ListIndices)
+		indices.GET("/:index/intraday", h.GetIndexIntraday)
+		indices.GET("/:index/historical", h.GetIndexHistorical)
+	}
+}
+
+// ListIndices lists the supported indices and their aliases
+// GET /indices
+func (h *IndexHandler) ListIndices(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"exchange": database.IndexExchange,
+		"count":    len(database.Indices),
+		"indices":  database.Indices,
+	})
+}
+
+// lookupIndex resolves the :index parameter, responding 404 when it names
+// no supported index
+func lookupIndex(c *gin.Context) (database.Index, bool) {
+	index, ok := database.LookupIndex(c.Param("index"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "unknown index: " + c.Param("index") + " (see GET /indices)",
+		})
+	}
+	return index, ok
+}
+
+// GetIndexIntraday returns an index's collector bars; subscribe a collector
+// to it (e.g. NIFTY) first
+// GET /indices/:index/intraday?timeframe=1m&from=2024-01-30T09:15:00Z&to=2024-01-30T15:30:00Z&limit=1000
+func (h *IndexHandler) GetIndexIntraday(c *gin.Context) {
+	index, ok := lookupIndex(c)
+	if !ok {
+		return
+	}
+	h.intraday.writeBars(c, database.IndexExchange, index.Symbol)
+}
+
+// GetIndexHistorical returns an index's historical candles, cached like any
+// other symbol's
+// GET /indices/:index/historical?interval=day&from_date=2024-01-01&to_date=2024-06-30&format=json
+func (h *IndexHandler) GetIndexHistorical(c *gin.Context) {
+	index, ok := lookupIndex(c)
+	if !ok {
+		return
+	}
+	format, ok := chartFormat(c)
+	if !ok {
+		return
+	}
+	interval := c.DefaultQuery("interval", "day")
+
+	toDate := time.Now()
+	var err error
+	if value := c.Query("to_date"); value != "" {
+		toDate, err = time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid to_date format (use YYYY-MM-DD)",
+			})
+			return
+		}
+	}
+
+	fromDate := toDate.AddDate(-1, 0, 0)
+	if value := c.Query("from_date"); value != "" {
+		fromDate, err = time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid from_date format (use YYYY-MM-DD)",
+			})
+			return
+		}
+	}
+
+	candles, err := h.historical.GetHistoricalData(index.Exchange, index.Symbol, interval, fromDate, toDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch historical data: " + err.Error(),
+		})
+		return
+	}
+
+	writeOHLCV(c, format, gin.H{
+		"exchange": index.Exchange,
+		"symbol":   index.Symbol,
+		"interval": interval,
+		"count":    len(candles),
+		"candles":  candles,
+	}, "candles", func() ohlcvArrays { return candleArrays(candles) })
+}
@@ -0,0 +1,266 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/analyzer"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// defaultIndicators are computed when the request doesn't name any
+var defaultIndicators = []string{"rsi", "macd", "bbands", "supertrend"}
+
+// IndicatorHandler serves full indicator series for charting
+type IndicatorHandler struct {
+	db *database.Database
+}
+
+// NewIndicatorHandler creates a new indicator handler
+func NewIndicatorHandler(db *database.Database) *IndicatorHandler {
+	return &IndicatorHandler{db: db}
+}
+
+// RegisterRoutes registers indicator routes
+func (h *IndicatorHandler) RegisterRoutes(r *gin.RouterGroup) {
+	indicators := r.Group("/indicators")
+	{
+		indicators.GET("/:symbol", h.GetIndicators)
+	}
+}
+
+// indicatorSeries computes one indicator's series, keyed by series name.
+// Every series has one value per candle; bars before the indicator has
+// enough history are null.
+type indicatorSeries func(candles []broker.Candle, closes []float64) map[string]interface{}
+
+var indicatorCalculators = map[string]indicatorSeries{
+	"rsi": func(candles []broker.Candle, closes []float64) map[string]interface{} {
+		return map[string]interface{}{
+			"rsi": seriesFrom(analyzer.CalculateRSISeries(closes, 14), 14, len(closes)),
+		}
+	},
+	"macd": func(candles []broker.Candle, closes []float64) map[string]interface{} {
+		macd := analyzer.CalculateMACD(closes, 12, 26, 9)
+		return map[string]interface{}{
+			"macd":           seriesFrom(macd.MACD, 25, len(closes)),
+			"macd_signal":    seriesFrom(macd.Signal, 33, len(closes)),
+			"macd_histogram": seriesFrom(macd.Histogram, 33, len(closes)),
+			"macd_crossover": padStrings(macd.Crossover, len(candles)),
+		}
+	},
+	"bbands": func(candles []broker.Candle, closes []float64) map[string]interface{} {
+		bb := analyzer.CalculateBollingerBands(closes, 20, 2)
+		return map[string]interface{}{
+			"bb_upper":  seriesFrom(bb.Upper, 19, len(closes)),
+			"bb_middle": seriesFrom(bb.Middle, 19, len(closes)),
+			"bb_lower":  seriesFrom(bb.Lower, 19, len(closes)),
+		}
+	},
+	"supertrend": func(candles []broker.Candle, closes []float64) map[string]interface{} {
+		st := analyzer.CalculateSuperTrend(candles, 10, 3)
+		return map[string]interface{}{
+			"supertrend":           seriesFrom(st.SuperTrend, 9, len(closes)),
+			"supertrend_direction": padStrings(st.Trend, len(candles)),
+			"supertrend_signal":    padStrings(st.Signals, len(candles)),
+		}
+	},
+	"ichimoku": func(candles []broker.Candle, closes []float64) map[string]interface{} {
+		ichimoku := analyzer.CalculateIchimoku(candles, 9, 26, 52, 26)
+
+		// The Chikou span only exists up to 26 bars before the last candle
+		chikou := seriesFrom(ichimoku.Chikou, 0, len(closes))
+		for i := len(chikou) - 26; i < len(chikou); i++ {
+			if i >= 0 {
+				chikou[i] = nil
+			}
+		}
+
+		return map[string]interface{}{
+			"ichimoku_tenkan":   seriesFrom(ichimoku.Tenkan, 8, len(closes)),
+			"ichimoku_kijun":    seriesFrom(ichimoku.Kijun, 25, len(closes)),
+			"ichimoku_senkou_a": seriesFrom(ichimoku.SenkouA, 51, len(closes)),
+			"ichimoku_senkou_b": seriesFrom(ichimoku.SenkouB, 77, len(closes)),
+			"ichimoku_chikou":   chikou,
+			"ichimoku_cloud":    padStrings(ichimoku.Cloud, len(candles)),
+			// Cloud for the 26 bars after the last candle
+			"ichimoku_senkou_a_ahead": seriesFrom(ichimoku.SenkouAAhead, 51-len(closes), 26),
+			"ichimoku_senkou_b_ahead": seriesFrom(ichimoku.SenkouBAhead, 77-len(closes), 26),
+		}
+	},
+	"sma": func(candles []broker.Candle, closes []float64) map[string]interface{} {
+		return map[string]interface{}{
+			"sma_20": seriesFrom(analyzer.CalculateSMA(closes, 20), 19, len(closes)),
+			"sma_50": seriesFrom(analyzer.CalculateSMA(closes, 50), 49, len(closes)),
+		}
+	},
+	"ema": func(candles []broker.Candle, closes []float64) map[string]interface{} {
+		return map[string]interface{}{
+			"ema_12": seriesFrom(analyzer.CalculateEMA(closes, 12), 11, len(closes)),
+			"ema_26": seriesFrom(analyzer.CalculateEMA(closes, 26), 25, len(closes)),
+		}
+	},
+	"atr": func(candles []broker.Candle, closes []float64) map[string]interface{} {
+		return map[string]interface{}{
+			"atr": seriesFrom(analyzer.CalculateATR(candles, 14), 13, len(closes)),
+		}
+	},
+	"adx": func(candles []broker.Candle, closes []float64) map[string]interface{} {
+		adx := []float64{}
+		if len(candles) >= 28 {
+			adx = analyzer.CalculateADX(candles, 14)
+		}
+		return map[string]interface{}{
+			"adx": seriesFrom(adx, 27, len(closes)),
+		}
+	},
+	"stochrsi": func(candles []broker.Candle, closes []float64) map[string]interface{} {
+		// Stochastic RSI over the RSI values, once RSI has warmed up
+		stoch := make([]float64, len(closes))
+		if rsi := analyzer.CalculateRSISeries(closes, 14); len(rsi) > 14 {
+			copy(stoch[14:], analyzer.CalculateStochasticRSI(rsi[14:], 14))
+		}
+		return map[string]interface{}{
+			"stoch_rsi": seriesFrom(stoch, 27, len(closes)),
+		}
+	},
+	"vwap": func(candles []broker.Candle, closes []float64) map[string]interface{} {
+		return map[string]interface{}{
+			"vwap": seriesFrom(sessionVWAP(candles), 0, len(closes)),
+		}
+	},
+}
+
+// GetIndicators returns full indicator series for a symbol's recent bars,
+// aligned with the bar timestamps, so charts can overlay them without
+// recomputing. Indicators: rsi (14), macd (12, 26, 9), bbands (20, 2),
+// supertrend (10, 3), ichimoku (9, 26, 52), sma (20, 50), ema (12, 26),
+// atr (14), adx (14), stochrsi (14, 14) and vwap (reset each session).
+// GET /indicators/:symbol?exchange=NSE&indicators=rsi,macd,bbands,supertrend&timeframe=15m&limit=500
+func (h *IndicatorHandler) GetIndicators(c *gin.Context) {
+	exchange, symbol := storedSymbol(c, strings.ToUpper(c.Param("symbol")))
+	timeframe, ok := storedTimeframe(c, "15m")
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "500"))
+	if err != nil || limit <= 0 || limit > 5000 {
+		limit = 500
+	}
+
+	names := defaultIndicators
+	if v := c.Query("indicators"); v != "" {
+		names = nil
+		for _, name := range strings.Split(v, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if _, ok := indicatorCalculators[name]; !ok {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error":     "unknown indicator: " + name,
+					"supported": supportedIndicators(),
+				})
+				return
+			}
+			names = append(names, name)
+		}
+	}
+
+	bars, err := h.db.GetRecentIntradayBars(exchange, symbol, timeframe, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch bars: " + err.Error(),
+		})
+		return
+	}
+
+	candles := make([]broker.Candle, len(bars))
+	closes := make([]float64, len(bars))
+	timestamps := make([]time.Time, len(bars))
+	for i, b := range bars {
+		candles[i] = broker.Candle{
+			Date:   b.BarTimestamp,
+			Open:   b.Open,
+			High:   b.High,
+			Low:    b.Low,
+			Close:  b.Close,
+			Volume: b.Volume,
+		}
+		closes[i] = b.Close
+		timestamps[i] = b.BarTimestamp
+	}
+
+	series := make(map[string]interface{})
+	for _, name := range names {
+		for key, values := range indicatorCalculators[name](candles, closes) {
+			series[key] = values
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"exchange":   exchange,
+		"symbol":     symbol,
+		"timeframe":  timeframe,
+		"indicators": names,
+		"bars_count": len(bars),
+		"timestamps": timestamps,
+		"series":     series,
+	})
+}
+
+// seriesFrom converts an indicator's values to a series of n entries, null
+// before the warmup index and wherever the indicator returned no value
+func seriesFrom(values []float64, warmup, n int) []*float64 {
+	series := make([]*float64, n)
+	if warmup < 0 {
+		warmup = 0
+	}
+	for i := warmup; i < len(values) && i < n; i++ {
+		v := values[i]
+		series[i] = &v
+	}
+	return series
+}
+
+// padStrings returns the values padded to n, for indicators that return
+// nothing on short histories
+func padStrings(values []string, n int) []string {
+	if len(values) >= n {
+		return values
+	}
+	padded := make([]string, n)
+	copy(padded, values)
+	return padded
+}
+
+// sessionVWAP calculates VWAP restarting at each trading day (IST)
+func sessionVWAP(candles []broker.Candle) []float64 {
+	ist := broker.IST()
+
+	vwap := make([]float64, 0, len(candles))
+	start := 0
+	for i := 1; i <= len(candles); i++ {
+		if i < len(candles) && candles[i].Date.In(ist).Format("2006-01-02") == candles[start].Date.In(ist).Format("2006-01-02") {
+			continue
+		}
+		vwap = append(vwap, analyzer.CalculateVWAP(candles[start:i])...)
+		start = i
+	}
+	return vwap
+}
+
+func supportedIndicators() []string {
+	names := make([]string, 0, len(indicatorCalculators))
+	for name := range indicatorCalculators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
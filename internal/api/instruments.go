@@ -1,30 +1,80 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/database"
 )
 
-// SearchInstruments searches for instruments by symbol or name
+// SearchInstruments searches for instruments by symbol or name, filtered by
+// exchange, segment, type, expiry and strike, a page at a time
+// GET /instruments/search?q=NIFTY&exchange=NFO&type=CE&expiry_from=2024-03-01&expiry_to=2024-03-31&strike_min=21000&strike_max=23000&limit=20&cursor=...
 func (a *API) SearchInstruments(c *gin.Context) {
-	pattern := c.Query("q")
-	if pattern == "" {
+	filter := database.InstrumentFilter{
+		Query:          strings.TrimSpace(c.Query("q")),
+		Exchange:       c.Query("exchange"),
+		Segment:        c.Query("segment"),
+		InstrumentType: c.Query("type"),
+		Cursor:         c.Query("cursor"),
+	}
+	if filter.Query == "" && filter.Exchange == "" && filter.Segment == "" && filter.InstrumentType == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "query parameter 'q' is required",
+			"error": "query parameter 'q' or one of exchange, segment or type is required",
 		})
 		return
 	}
 
 	limitStr := c.DefaultQuery("limit", "20")
 	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 || limit > 100 {
+	if err != nil || limit <= 0 || limit > 500 {
 		limit = 20
 	}
+	filter.Limit = limit
+
+	for _, param := range []struct {
+		name string
+		dest **time.Time
+	}{{"expiry_from", &filter.ExpiryFrom}, {"expiry_to", &filter.ExpiryTo}} {
+		if value := c.Query(param.name); value != "" {
+			date, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "invalid " + param.name + " format (use YYYY-MM-DD)",
+				})
+				return
+			}
+			*param.dest = &date
+		}
+	}
+
+	for _, param := range []struct {
+		name string
+		dest **float64
+	}{{"strike_min", &filter.StrikeMin}, {"strike_max", &filter.StrikeMax}} {
+		if value := c.Query(param.name); value != "" {
+			strike, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": param.name + " must be a number",
+				})
+				return
+			}
+			*param.dest = &strike
+		}
+	}
 
-	instruments, err := a.db.SearchInstruments(pattern, limit)
+	page, err := a.db.SearchInstruments(filter)
+	if errors.Is(err, database.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to search instruments",
@@ -33,9 +83,10 @@ func (a *API) SearchInstruments(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"query":       pattern,
-		"count":       len(instruments),
-		"instruments": instruments,
+		"query":       filter.Query,
+		"count":       len(page.Instruments),
+		"instruments": page.Instruments,
+		"next_cursor": page.NextCursor,
 	})
 }
 
@@ -103,7 +154,8 @@ func (a *API) SyncInstruments(c *gin.Context) {
 	}
 }
 
-// GetHistoricalData returns historical candle data with caching
+// GetHistoricalData returns historical candle data with caching, split and
+// bonus adjusted with adjusted=true
 func (a *API) GetHistoricalData(c *gin.Context) {
 	type HistoricalRequest struct {
 		Exchange  string `json:"exchange" binding:"required"`
@@ -138,6 +190,11 @@ func (a *API) GetHistoricalData(c *gin.Context) {
 		return
 	}
 
+	format, ok := chartFormat(c)
+	if !ok {
+		return
+	}
+
 	// Fetch historical data (with caching)
 	if a.historicalService == nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{
@@ -146,6 +203,11 @@ func (a *API) GetHistoricalData(c *gin.Context) {
 		return
 	}
 
+	actions, ok := corporateActionsFor(c, a.db, req.Exchange, req.Symbol)
+	if !ok {
+		return
+	}
+
 	candles, err := a.historicalService.GetHistoricalData(
 		req.Exchange,
 		req.Symbol,
@@ -159,17 +221,22 @@ func (a *API) GetHistoricalData(c *gin.Context) {
 		})
 		return
 	}
+	if actions != nil {
+		candles = database.AdjustCandles(candles, actions)
+	}
 
-	c.JSON(http.StatusOK, gin.H{
+	writeOHLCV(c, format, gin.H{
 		"exchange": req.Exchange,
 		"symbol":   req.Symbol,
 		"interval": req.Interval,
+		"adjusted": actions != nil,
 		"count":    len(candles),
 		"candles":  candles,
-	})
+	}, "candles", func() ohlcvArrays { return candleArrays(candles) })
 }
 
-// Get52DayHistorical returns 52 trading days of historical data
+// Get52DayHistorical returns 52 trading days of historical data, split and
+// bonus adjusted with adjusted=true
 func (a *API) Get52DayHistorical(c *gin.Context) {
 	exchange := c.Query("exchange")
 	symbol := c.Query("symbol")
@@ -180,6 +247,10 @@ func (a *API) Get52DayHistorical(c *gin.Context) {
 		})
 		return
 	}
+	format, ok := chartFormat(c)
+	if !ok {
+		return
+	}
 
 	if a.historicalService == nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{
@@ -188,6 +259,11 @@ func (a *API) Get52DayHistorical(c *gin.Context) {
 		return
 	}
 
+	actions, ok := corporateActionsFor(c, a.db, exchange, symbol)
+	if !ok {
+		return
+	}
+
 	candles, err := a.historicalService.Get52DayHistoricalData(exchange, symbol)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -195,13 +271,95 @@ func (a *API) Get52DayHistorical(c *gin.Context) {
 		})
 		return
 	}
+	if actions != nil {
+		candles = database.AdjustCandles(candles, actions)
+	}
 
-	c.JSON(http.StatusOK, gin.H{
+	writeOHLCV(c, format, gin.H{
 		"exchange": exchange,
 		"symbol":   symbol,
+		"adjusted": actions != nil,
 		"days":     len(candles),
 		"candles":  candles,
-	})
+	}, "candles", func() ohlcvArrays { return candleArrays(candles) })
+}
+
+// GetContinuousHistorical returns an underlying's continuous front month
+// futures candles, stitched across expiries with the rolls made
+// GET /historical/continuous/:underlying?exchange=NFO&interval=day&from_date=2024-01-01&to_date=2024-06-30&adjust=difference&roll_days=0&format=json
+func (a *API) GetContinuousHistorical(c *gin.Context) {
+	underlying := strings.ToUpper(c.Param("underlying"))
+	exchange := strings.ToUpper(c.DefaultQuery("exchange", "NFO"))
+	interval := c.DefaultQuery("interval", "day")
+
+	fromDate, err := time.Parse("2006-01-02", c.Query("from_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid from_date format (use YYYY-MM-DD)",
+		})
+		return
+	}
+
+	toDate := time.Now()
+	if value := c.Query("to_date"); value != "" {
+		toDate, err = time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid to_date format (use YYYY-MM-DD)",
+			})
+			return
+		}
+	}
+
+	opts := database.ContinuousOptions{Adjust: c.Query("adjust")}
+	if opts.RollDays, err = strconv.Atoi(c.DefaultQuery("roll_days", "0")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "roll_days must be a number",
+		})
+		return
+	}
+	if err := database.ValidateContinuousOptions(&opts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	format, ok := chartFormat(c)
+	if !ok {
+		return
+	}
+
+	if a.historicalService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "historical data service not available",
+		})
+		return
+	}
+
+	candles, rolls, err := a.historicalService.GetContinuousData(exchange, underlying, interval, fromDate, toDate, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to build continuous series: " + err.Error(),
+		})
+		return
+	}
+	if len(candles) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "no " + underlying + " futures data; sync contracts with POST /instruments/sync?exchange=" + exchange,
+		})
+		return
+	}
+
+	writeOHLCV(c, format, gin.H{
+		"exchange":  exchange,
+		"symbol":    underlying + database.ContinuousSuffix,
+		"interval":  interval,
+		"adjust":    opts.Adjust,
+		"roll_days": opts.RollDays,
+		"count":     len(candles),
+		"candles":   candles,
+		"rolls":     rolls,
+	}, "candles", func() ohlcvArrays { return candleArrays(candles) })
 }
 
 // WarmCache pre-fetches and caches historical data
@@ -229,10 +387,11 @@ func (a *API) WarmCache(c *gin.Context) {
 	}
 
 	// Run cache warming in background
+	logger := RequestLog(c)
 	go func() {
 		err := a.historicalService.WarmCache(req.Exchange, req.Symbols, req.Interval, req.Days)
 		if err != nil {
-			a.logger.Error("Cache warming failed: ", err)
+			logger.Error("Cache warming failed: ", err)
 		}
 	}()
 
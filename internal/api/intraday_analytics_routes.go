@@ -0,0 +1,261 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/analyzer"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// Most 1m bars and ticks read for one VWAP, TWAP or volume profile; longer
+// windows are cut short and marked truncated
+const (
+	maxAnalyticsBars  = 50000
+	maxAnalyticsTicks = 200000
+)
+
+// GetAnchoredVWAP computes the VWAP from anchor to to, with its value after
+// every 1m bar or tick
+// GET /intraday/anchored-vwap/:symbol?anchor=2024-01-30T03:45:00Z&to=2024-01-30T10:00:00Z&source=bars
+func (h *IntradayHandler) GetAnchoredVWAP(c *gin.Context) {
+	exchange, symbol := storedSymbol(c, c.Param("symbol"))
+	if c.Query("anchor") == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "anchor is required (RFC3339)",
+		})
+		return
+	}
+	anchor, to, ok := analyticsWindow(c, "anchor")
+	if !ok {
+		return
+	}
+
+	samples, source, truncated, ok := h.volumeSamples(c, exchange, symbol, anchor, to)
+	if !ok {
+		return
+	}
+
+	points := analyzer.CalculateAnchoredVWAP(samples, anchor)
+	response := gin.H{
+		"exchange":  exchange,
+		"symbol":    symbol,
+		"anchor":    anchor,
+		"to":        to,
+		"source":    source,
+		"truncated": truncated,
+		"vwap":      nil,
+		"points":    points,
+	}
+	if len(points) > 0 {
+		response["vwap"] = points[len(points)-1].VWAP
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTWAP computes the time-weighted average price over a window, by
+// default the current session
+// GET /intraday/twap/:symbol?from=2024-01-30T03:45:00Z&to=2024-01-30T10:00:00Z&source=bars
+func (h *IntradayHandler) GetTWAP(c *gin.Context) {
+	exchange, symbol := storedSymbol(c, c.Param("symbol"))
+	from, to, ok := analyticsWindow(c, "from")
+	if !ok {
+		return
+	}
+
+	samples, source, truncated, ok := h.volumeSamples(c, exchange, symbol, from, to)
+	if !ok {
+		return
+	}
+
+	// A 1m bar's price holds for its minute; a tick's until the next one,
+	// across quiet spells but not the overnight gap
+	maxHold := time.Minute
+	if source == "ticks" {
+		maxHold = 30 * time.Minute
+	}
+
+	response := gin.H{
+		"exchange":  exchange,
+		"symbol":    symbol,
+		"from":      from,
+		"to":        to,
+		"source":    source,
+		"truncated": truncated,
+		"samples":   len(samples),
+		"twap":      nil,
+	}
+	if len(samples) > 0 {
+		response["twap"] = analyzer.CalculateTWAP(samples, to, maxHold)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetVolumeProfile computes the volume traded at each price over a window,
+// by default the current session, with its point of control and value area
+// GET /intraday/volume-profile/:symbol?from=2024-01-30T03:45:00Z&to=2024-01-30T10:00:00Z&source=bars&bin_size=0.5&value_area=0.7
+func (h *IntradayHandler) GetVolumeProfile(c *gin.Context) {
+	exchange, symbol := storedSymbol(c, c.Param("symbol"))
+	from, to, ok := analyticsWindow(c, "from")
+	if !ok {
+		return
+	}
+
+	binSize := 0.0
+	if v := c.Query("bin_size"); v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "bin_size must be a positive price",
+			})
+			return
+		}
+		binSize = parsed
+	}
+	valueArea := analyzer.DefaultValueAreaPct
+	if v := c.Query("value_area"); v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil || parsed <= 0 || parsed > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "value_area must be a fraction of volume in (0, 1]",
+			})
+			return
+		}
+		valueArea = parsed
+	}
+
+	samples, source, truncated, ok := h.volumeSamples(c, exchange, symbol, from, to)
+	if !ok {
+		return
+	}
+
+	profile := analyzer.CalculateVolumeProfile(samples, binSize, valueArea)
+	if profile == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "no volume traded in the window",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"exchange":  exchange,
+		"symbol":    symbol,
+		"from":      from,
+		"to":        to,
+		"source":    source,
+		"truncated": truncated,
+		"profile":   profile,
+	})
+}
+
+// analyticsWindow parses the window starting at the fromParam query
+// parameter, by default today's 09:15 IST open, and ending at to, by
+// default now. It responds with an error if either is malformed.
+func analyticsWindow(c *gin.Context, fromParam string) (time.Time, time.Time, bool) {
+	ist := broker.IST()
+	now := time.Now().In(ist)
+
+	from := time.Date(now.Year(), now.Month(), now.Day(), 9, 15, 0, 0, ist)
+	if v := c.Query(fromParam); v != "" {
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid '" + fromParam + "' time format, use RFC3339",
+			})
+			return time.Time{}, time.Time{}, false
+		}
+		from = parsed
+	}
+
+	to := now
+	if v := c.Query("to"); v != "" {
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid 'to' time format, use RFC3339",
+			})
+			return time.Time{}, time.Time{}, false
+		}
+		to = parsed
+	}
+
+	if !to.After(from) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "'to' must be after '" + fromParam + "'",
+		})
+		return time.Time{}, time.Time{}, false
+	}
+
+	return from, to, true
+}
+
+// volumeSamples reads the symbol's 1m bars or, with source=ticks, its ticks
+// in the window. It also returns the source and whether the read hit its
+// cap, and responds with an error when it fails.
+func (h *IntradayHandler) volumeSamples(c *gin.Context, exchange, symbol string, from, to time.Time) ([]analyzer.VolumeSample, string, bool, bool) {
+	source := c.DefaultQuery("source", "bars")
+
+	switch source {
+	case "bars":
+		bars, err := h.db.GetIntradayBars(exchange, symbol, "1m", from, to, maxAnalyticsBars)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to fetch intraday bars: " + err.Error(),
+			})
+			return nil, "", false, false
+		}
+		return barVolumeSamples(bars), source, len(bars) == maxAnalyticsBars, true
+
+	case "ticks":
+		ticks, err := h.db.GetTickData(exchange, symbol, from, to, maxAnalyticsTicks)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to fetch tick data: " + err.Error(),
+			})
+			return nil, "", false, false
+		}
+		return tickVolumeSamples(ticks), source, len(ticks) == maxAnalyticsTicks, true
+
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "source must be bars or ticks",
+		})
+		return nil, "", false, false
+	}
+}
+
+// barVolumeSamples converts bars to samples at their typical price, traded
+// across their range
+func barVolumeSamples(bars []database.IntradayBar) []analyzer.VolumeSample {
+	samples := make([]analyzer.VolumeSample, len(bars))
+	for i, bar := range bars {
+		samples[i] = analyzer.VolumeSample{
+			Time:   bar.BarTimestamp,
+			Price:  (bar.High + bar.Low + bar.Close) / 3,
+			Low:    bar.Low,
+			High:   bar.High,
+			Volume: bar.Volume,
+		}
+	}
+	return samples
+}
+
+// tickVolumeSamples converts ticks to samples of their last trade
+func tickVolumeSamples(ticks []database.TickData) []analyzer.VolumeSample {
+	samples := make([]analyzer.VolumeSample, len(ticks))
+	for i, tick := range ticks {
+		samples[i] = analyzer.VolumeSample{
+			Time:   tick.TickTimestamp,
+			Price:  tick.Price,
+			Low:    tick.Price,
+			High:   tick.Price,
+			Volume: tick.Quantity,
+		}
+	}
+	return samples
+}
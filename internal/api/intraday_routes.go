@@ -1,17 +1,31 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/analyzer"
+	"github.com/trading-chitti/market-bridge/internal/broker"
 	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/importer"
+	"github.com/trading-chitti/market-bridge/internal/quotes"
+	"github.com/trading-chitti/market-bridge/internal/timeframe"
 )
 
+// maxImportBytes caps /intraday/import uploads
+const maxImportBytes = 256 << 20
+
 // IntradayHandler handles intraday data requests
 type IntradayHandler struct {
-	db *database.Database
+	db     *database.Database
+	quotes *quotes.Store
 }
 
 // NewIntradayHandler creates a new intraday handler
@@ -19,8 +33,15 @@ func NewIntradayHandler(db *database.Database) *IntradayHandler {
 	return &IntradayHandler{db: db}
 }
 
-// RegisterRoutes registers intraday data routes
-func (h *IntradayHandler) RegisterRoutes(r *gin.RouterGroup) {
+// SetQuoteStore serves the latest 1m bar from the collectors' ticks when
+// they are fresh. A nil store always reads the database.
+func (h *IntradayHandler) SetQuoteStore(store *quotes.Store) {
+	h.quotes = store
+}
+
+// RegisterRoutes registers intraday data routes. importMiddleware runs
+// before /intraday/import, which writes bars for everyone.
+func (h *IntradayHandler) RegisterRoutes(r *gin.RouterGroup, importMiddleware ...gin.HandlerFunc) {
 	intraday := r.Group("/intraday")
 	{
 		intraday.GET("/bars/:symbol", h.GetIntradayBars)
@@ -28,18 +49,33 @@ func (h *IntradayHandler) RegisterRoutes(r *gin.RouterGroup) {
 		intraday.GET("/today/:symbol", h.GetTodayBars)
 		intraday.GET("/stats/:symbol", h.GetIntradayStats)
 		intraday.GET("/vwap/:symbol", h.GetTodayVWAP)
+		intraday.GET("/anchored-vwap/:symbol", h.GetAnchoredVWAP)
+		intraday.GET("/twap/:symbol", h.GetTWAP)
+		intraday.GET("/volume-profile/:symbol", h.GetVolumeProfile)
 		intraday.GET("/ticks/:symbol", h.GetTickData)
 		intraday.GET("/orderbook/:symbol", h.GetLatestOrderBook)
+		intraday.GET("/snapshots/:symbol", h.GetQuoteSnapshots)
 		intraday.GET("/gaps/:symbol", h.GetDataGaps)
 		intraday.GET("/completeness/:symbol", h.GetDataCompleteness)
+		intraday.POST("/import", append(importMiddleware, h.ImportBars)...)
 	}
 }
 
-// GetIntradayBars retrieves intraday bars for a symbol
-// GET /intraday/bars/:symbol?timeframe=1m&from=2024-01-30T09:15:00Z&to=2024-01-30T15:30:00Z&limit=1000
+// GetIntradayBars retrieves intraday bars for a symbol. Timeframes other
+// than 1m, 5m, 15m, 1h and 1d (or day) (e.g. 3m, 2h) are resampled from 1m bars;
+// adjusted=true adjusts them for the symbol's splits and bonuses on exchange.
+// Pages and streams like GetTickData; format=ohlcv-arrays or tv returns
+// columnar arrays for charting libraries.
+// GET /intraday/bars/:symbol?timeframe=1m&from=2024-01-30T09:15:00Z&to=2024-01-30T15:30:00Z&limit=1000&adjusted=false&exchange=NSE&cursor=...&stream=false&format=json
 func (h *IntradayHandler) GetIntradayBars(c *gin.Context) {
-	symbol := c.Param("symbol")
-	timeframe := c.DefaultQuery("timeframe", "1m")
+	exchange, symbol := storedSymbol(c, c.Param("symbol"))
+	h.writeBars(c, exchange, symbol)
+}
+
+// writeBars responds with a symbol's bars for the timeframe, range and
+// limit in the query
+func (h *IntradayHandler) writeBars(c *gin.Context, exchange, symbol string) {
+	tf := c.DefaultQuery("timeframe", "1m")
 	limitStr := c.DefaultQuery("limit", "1000")
 
 	limit, err := strconv.Atoi(limitStr)
@@ -78,43 +114,168 @@ func (h *IntradayHandler) GetIntradayBars(c *gin.Context) {
 		toTime = time.Now()
 	}
 
-	// Validate timeframe
-	validTimeframes := map[string]bool{
-		"1m": true, "5m": true, "15m": true, "1h": true, "day": true,
+	// Validate timeframe; other minute and hour timeframes are resampled
+	// from 1m bars
+	resampled := false
+	if stored, err := timeframe.ParseStored(tf); err == nil {
+		tf = stored.String()
+	} else if _, err := database.ParseResampleTimeframe(tf); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid timeframe, must be one of: " + timeframe.StoredNames() + ", or minutes/hours to resample 1m bars into (e.g. 3m, 10m, 2h)",
+		})
+		return
+	} else {
+		resampled = true
+	}
+
+	cursor, ok := queryCursor(c)
+	if !ok {
+		return
 	}
-	if !validTimeframes[timeframe] {
+	format, ok := chartFormat(c)
+	if !ok {
+		return
+	}
+	stream := c.Query("stream") == "true"
+	if stream && format != formatJSON {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "invalid timeframe, must be one of: 1m, 5m, 15m, 1h, day",
+			"error": "streams are newline-delimited bar objects; omit format",
 		})
 		return
 	}
+	actions, ok := corporateActionsFor(c, h.db, exchange, symbol)
+	if !ok {
+		return
+	}
 
-	// Fetch data
-	bars, err := h.db.GetIntradayBars(symbol, timeframe, fromTime, toTime, limit)
+	// Fetch data, continuing after the cursor when there is one
+	fetch := func(after *database.Cursor, limit int) ([]database.IntradayBar, error) {
+		var bars []database.IntradayBar
+		var err error
+		switch {
+		case resampled && after != nil:
+			bars, err = h.db.GetResampledBarsAfter(exchange, symbol, tf, *after, toTime, limit)
+		case resampled:
+			bars, err = h.db.GetResampledBars(exchange, symbol, tf, fromTime, toTime, limit)
+		case after != nil:
+			bars, err = h.db.GetIntradayBarsAfter(exchange, symbol, tf, *after, toTime, limit)
+		default:
+			bars, err = h.db.GetIntradayBars(exchange, symbol, tf, fromTime, toTime, limit)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if actions != nil {
+			bars = database.AdjustBars(bars, actions)
+		}
+		return bars, nil
+	}
+
+	if stream {
+		streamPages(c, cursor, func(after *database.Cursor, limit int) ([]interface{}, *database.Cursor, error) {
+			bars, err := fetch(after, limit)
+			if err != nil || len(bars) == 0 {
+				return nil, nil, err
+			}
+			rows := make([]interface{}, len(bars))
+			for i := range bars {
+				rows[i] = bars[i]
+			}
+			last := database.BarCursor(bars[len(bars)-1])
+			return rows, &last, nil
+		})
+		return
+	}
+
+	bars, err := fetch(cursor, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to fetch intraday bars: " + err.Error(),
 		})
 		return
 	}
+	var last *database.Cursor
+	if len(bars) > 0 {
+		at := database.BarCursor(bars[len(bars)-1])
+		last = &at
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"symbol":     symbol,
-		"timeframe":  timeframe,
-		"from":       fromTime,
-		"to":         toTime,
-		"bars_count": len(bars),
-		"bars":       bars,
-	})
+	writeOHLCV(c, format, gin.H{
+		"exchange":    exchange,
+		"symbol":      symbol,
+		"timeframe":   tf,
+		"resampled":   resampled,
+		"adjusted":    actions != nil,
+		"from":        fromTime,
+		"to":          toTime,
+		"bars_count":  len(bars),
+		"bars":        bars,
+		"next_cursor": nextCursor(len(bars), limit, last),
+	}, "bars", func() ohlcvArrays { return barArrays(bars) })
+}
+
+// storedTimeframe reads the timeframe query parameter, def if absent, as the
+// name its bars are stored under (day is 1d, 5minute is 5m). It responds
+// with an error if md.intraday_bars doesn't keep that timeframe.
+func storedTimeframe(c *gin.Context, def string) (string, bool) {
+	tf, err := timeframe.ParseStored(c.DefaultQuery("timeframe", def))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return "", false
+	}
+	return tf.String(), true
+}
+
+// storedSymbol reads the exchange query parameter, NSE if absent, and
+// returns the exchange and symbol the symbol's bars and ticks are stored
+// under (see storedKey)
+func storedSymbol(c *gin.Context, symbol string) (string, string) {
+	return storedKey(c.DefaultQuery("exchange", "NSE"), symbol)
+}
+
+// storedKey returns the exchange and symbol a symbol's bars and ticks are
+// stored under. Indices are stored on database.IndexExchange, so NSE:NIFTY
+// 50 and INDEX:NIFTY both find the NIFTY 50 bars.
+func storedKey(exchange, symbol string) (string, string) {
+	exchange = strings.ToUpper(exchange)
+	if index, ok := database.ResolveIndex(exchange, symbol); ok {
+		return database.IndexExchange, index.Symbol
+	}
+	return exchange, symbol
+}
+
+// istDate formats the IST trading day of t
+func istDate(t time.Time) string {
+	day, _ := database.ISTDay(t)
+	return day.Format("2006-01-02")
 }
 
-// GetLatestBar retrieves the most recent bar for a symbol
-// GET /intraday/latest/:symbol?timeframe=1m
+// GetLatestBar retrieves the most recent bar for a symbol. The 1m bar of a
+// symbol being collected comes from memory, including the current minute.
+// GET /intraday/latest/:symbol?timeframe=1m&exchange=NSE
 func (h *IntradayHandler) GetLatestBar(c *gin.Context) {
-	symbol := c.Param("symbol")
-	timeframe := c.DefaultQuery("timeframe", "1m")
+	exchange, symbol := storedSymbol(c, c.Param("symbol"))
+	timeframe, ok := storedTimeframe(c, "1m")
+	if !ok {
+		return
+	}
 
-	bar, err := h.db.GetLatestIntradayBar(symbol, timeframe)
+	if h.quotes != nil && timeframe == "1m" {
+		if bar, ok := h.quotes.LatestBar(exchange, symbol); ok {
+			c.JSON(http.StatusOK, gin.H{
+				"exchange":  exchange,
+				"symbol":    symbol,
+				"timeframe": timeframe,
+				"bar":       bar,
+				"source":    "memory",
+			})
+			return
+		}
+	}
+
+	bar, err := h.db.GetLatestIntradayBar(exchange, symbol, timeframe)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to fetch latest bar: " + err.Error(),
@@ -130,19 +291,28 @@ func (h *IntradayHandler) GetLatestBar(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
+		"exchange":  exchange,
 		"symbol":    symbol,
 		"timeframe": timeframe,
 		"bar":       bar,
+		"source":    "database",
 	})
 }
 
-// GetTodayBars retrieves all bars for current trading day
-// GET /intraday/today/:symbol?timeframe=1m
+// GetTodayBars retrieves all bars for the current IST trading day
+// GET /intraday/today/:symbol?timeframe=1m&format=json
 func (h *IntradayHandler) GetTodayBars(c *gin.Context) {
-	symbol := c.Param("symbol")
-	timeframe := c.DefaultQuery("timeframe", "1m")
+	exchange, symbol := storedSymbol(c, c.Param("symbol"))
+	timeframe, ok := storedTimeframe(c, "1m")
+	if !ok {
+		return
+	}
+	format, ok := chartFormat(c)
+	if !ok {
+		return
+	}
 
-	bars, err := h.db.GetTodayBars(symbol, timeframe)
+	bars, err := h.db.GetTodayBars(exchange, symbol, timeframe)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to fetch today's bars: " + err.Error(),
@@ -150,44 +320,75 @@ func (h *IntradayHandler) GetTodayBars(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	writeOHLCV(c, format, gin.H{
+		"exchange":   exchange,
 		"symbol":     symbol,
 		"timeframe":  timeframe,
-		"date":       time.Now().Format("2006-01-02"),
+		"date":       istDate(time.Now()),
 		"bars_count": len(bars),
 		"bars":       bars,
-	})
+	}, "bars", func() ohlcvArrays { return barArrays(bars) })
 }
 
-// GetIntradayStats retrieves intraday statistics for current day
+// GetIntradayStats retrieves intraday statistics for the current IST
+// trading day, null before its first bar. The 1m stats of a symbol being
+// collected since the open come from memory.
 // GET /intraday/stats/:symbol?timeframe=1m
 func (h *IntradayHandler) GetIntradayStats(c *gin.Context) {
-	symbol := c.Param("symbol")
-	timeframe := c.DefaultQuery("timeframe", "1m")
-
-	stats, err := h.db.GetIntradayStats(symbol, timeframe)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "failed to fetch intraday stats: " + err.Error(),
-		})
+	exchange, symbol := storedSymbol(c, c.Param("symbol"))
+	timeframe, ok := storedTimeframe(c, "1m")
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	var stats *database.IntradayStats
+	source := "database"
+	if h.quotes != nil && timeframe == "1m" {
+		if stats, ok = h.quotes.DayStats(exchange, symbol); ok {
+			source = "memory"
+		}
+	}
+	if stats == nil {
+		var err error
+		stats, err = h.db.GetIntradayStats(exchange, symbol, timeframe)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to fetch intraday stats: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	response := gin.H{
+		"exchange":  exchange,
 		"symbol":    symbol,
 		"timeframe": timeframe,
-		"date":      time.Now().Format("2006-01-02"),
+		"date":      istDate(time.Now()),
 		"stats":     stats,
-	})
+		"source":    source,
+	}
+
+	// Pivot points from the prior session, for day traders
+	session, err := h.db.GetPriorSessionOHLC(exchange, symbol, timeframe)
+	if err != nil {
+		log.Printf("⚠️  Failed to get prior session for %s: %v", symbol, err)
+	} else if session != nil {
+		response["pivots"] = analyzer.CalculatePivotPoints(*session)
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 // GetTodayVWAP calculates VWAP for current trading day
 // GET /intraday/vwap/:symbol?timeframe=1m
 func (h *IntradayHandler) GetTodayVWAP(c *gin.Context) {
-	symbol := c.Param("symbol")
-	timeframe := c.DefaultQuery("timeframe", "1m")
+	exchange, symbol := storedSymbol(c, c.Param("symbol"))
+	timeframe, ok := storedTimeframe(c, "1m")
+	if !ok {
+		return
+	}
 
-	vwap, err := h.db.CalculateTodayVWAP(symbol, timeframe)
+	vwap, err := h.db.CalculateTodayVWAP(exchange, symbol, timeframe)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to calculate VWAP: " + err.Error(),
@@ -196,17 +397,20 @@ func (h *IntradayHandler) GetTodayVWAP(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
+		"exchange":  exchange,
 		"symbol":    symbol,
 		"timeframe": timeframe,
-		"date":      time.Now().Format("2006-01-02"),
+		"date":      istDate(time.Now()),
 		"vwap":      vwap,
 	})
 }
 
-// GetTickData retrieves tick-level data
-// GET /intraday/ticks/:symbol?from=2024-01-30T09:15:00Z&to=2024-01-30T09:20:00Z&limit=1000
+// GetTickData retrieves tick-level data. A full page carries a next_cursor
+// to pass as cursor for the next; stream=true writes every tick in the range
+// as newline-delimited JSON instead.
+// GET /intraday/ticks/:symbol?from=2024-01-30T09:15:00Z&to=2024-01-30T09:20:00Z&limit=1000&cursor=...&stream=false
 func (h *IntradayHandler) GetTickData(c *gin.Context) {
-	symbol := c.Param("symbol")
+	exchange, symbol := storedSymbol(c, c.Param("symbol"))
 	limitStr := c.DefaultQuery("limit", "1000")
 
 	limit, err := strconv.Atoi(limitStr)
@@ -244,30 +448,124 @@ func (h *IntradayHandler) GetTickData(c *gin.Context) {
 		toTime = time.Now()
 	}
 
-	// Fetch data
-	ticks, err := h.db.GetTickData(symbol, fromTime, toTime, limit)
+	cursor, ok := queryCursor(c)
+	if !ok {
+		return
+	}
+
+	// Fetch data, continuing after the cursor when there is one
+	fetch := func(after *database.Cursor, limit int) ([]database.TickData, error) {
+		if after != nil {
+			return h.db.GetTickDataAfter(exchange, symbol, *after, toTime, limit)
+		}
+		return h.db.GetTickData(exchange, symbol, fromTime, toTime, limit)
+	}
+
+	if c.Query("stream") == "true" {
+		streamPages(c, cursor, func(after *database.Cursor, limit int) ([]interface{}, *database.Cursor, error) {
+			ticks, err := fetch(after, limit)
+			if err != nil || len(ticks) == 0 {
+				return nil, nil, err
+			}
+			rows := make([]interface{}, len(ticks))
+			for i := range ticks {
+				rows[i] = ticks[i]
+			}
+			last := database.TickCursor(ticks[len(ticks)-1])
+			return rows, &last, nil
+		})
+		return
+	}
+
+	ticks, err := fetch(cursor, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to fetch tick data: " + err.Error(),
 		})
 		return
 	}
+	var last *database.Cursor
+	if len(ticks) > 0 {
+		at := database.TickCursor(ticks[len(ticks)-1])
+		last = &at
+	}
 
 	c.JSON(http.StatusOK, gin.H{
+		"exchange":    exchange,
 		"symbol":      symbol,
 		"from":        fromTime,
 		"to":          toTime,
 		"ticks_count": len(ticks),
 		"ticks":       ticks,
+		"next_cursor": nextCursor(len(ticks), limit, last),
+	})
+}
+
+// GetQuoteSnapshots retrieves a symbol's stored quote snapshots with the
+// order book imbalance of each, -1 (only sellers) to 1 (only buyers)
+// GET /intraday/snapshots/:symbol?exchange=NSE&from=2024-01-30T03:45:00Z&to=2024-01-30T10:00:00Z&limit=500
+func (h *IntradayHandler) GetQuoteSnapshots(c *gin.Context) {
+	exchange, symbol := storedSymbol(c, c.Param("symbol"))
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "500"))
+	if err != nil || limit <= 0 || limit > 10000 {
+		limit = 500
+	}
+
+	fromTime := time.Now().Add(-24 * time.Hour)
+	if v := c.Query("from"); v != "" {
+		fromTime, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid 'from' time format, use RFC3339",
+			})
+			return
+		}
+	}
+	toTime := time.Now()
+	if v := c.Query("to"); v != "" {
+		toTime, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid 'to' time format, use RFC3339",
+			})
+			return
+		}
+	}
+
+	snapshots, err := h.db.GetQuoteSnapshots(exchange, symbol, fromTime, toTime, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch quote snapshots: " + err.Error(),
+		})
+		return
+	}
+
+	type snapshotWithImbalance struct {
+		database.QuoteSnapshot
+		Imbalance float64 `json:"imbalance"`
+	}
+	results := make([]snapshotWithImbalance, len(snapshots))
+	for i, snapshot := range snapshots {
+		results[i] = snapshotWithImbalance{QuoteSnapshot: snapshot, Imbalance: snapshot.Imbalance()}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"exchange":  strings.ToUpper(exchange),
+		"symbol":    strings.ToUpper(symbol),
+		"from":      fromTime,
+		"to":        toTime,
+		"count":     len(results),
+		"snapshots": results,
 	})
 }
 
 // GetLatestOrderBook retrieves the most recent order book snapshot
 // GET /intraday/orderbook/:symbol
 func (h *IntradayHandler) GetLatestOrderBook(c *gin.Context) {
-	symbol := c.Param("symbol")
+	exchange, symbol := storedSymbol(c, c.Param("symbol"))
 
-	orderBook, err := h.db.GetLatestOrderBook(symbol)
+	orderBook, err := h.db.GetLatestOrderBook(exchange, symbol)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to fetch order book: " + err.Error(),
@@ -283,16 +581,20 @@ func (h *IntradayHandler) GetLatestOrderBook(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
+		"exchange":   exchange,
 		"symbol":     symbol,
 		"order_book": orderBook,
 	})
 }
 
-// GetDataGaps identifies missing data gaps
+// GetDataGaps identifies bars missing in market hours (09:15-15:30 IST, trading days)
 // GET /intraday/gaps/:symbol?timeframe=1m&from=2024-01-30T09:15:00Z&to=2024-01-30T15:30:00Z
 func (h *IntradayHandler) GetDataGaps(c *gin.Context) {
-	symbol := c.Param("symbol")
-	timeframe := c.DefaultQuery("timeframe", "1m")
+	exchange, symbol := storedSymbol(c, c.Param("symbol"))
+	timeframe, ok := storedTimeframe(c, "1m")
+	if !ok {
+		return
+	}
 
 	fromStr := c.Query("from")
 	toStr := c.Query("to")
@@ -323,7 +625,7 @@ func (h *IntradayHandler) GetDataGaps(c *gin.Context) {
 		return
 	}
 
-	gaps, err := h.db.GetDataGaps(symbol, timeframe, fromTime, toTime)
+	gaps, err := h.db.GetDataGaps(exchange, symbol, timeframe, fromTime, toTime)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to identify gaps: " + err.Error(),
@@ -332,6 +634,7 @@ func (h *IntradayHandler) GetDataGaps(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
+		"exchange":   exchange,
 		"symbol":     symbol,
 		"timeframe":  timeframe,
 		"from":       fromTime,
@@ -341,11 +644,15 @@ func (h *IntradayHandler) GetDataGaps(c *gin.Context) {
 	})
 }
 
-// GetDataCompleteness calculates data completeness percentage
+// GetDataCompleteness calculates the percentage of bars expected in market
+// hours that are present, overall and per trading day
 // GET /intraday/completeness/:symbol?timeframe=1m&from=2024-01-30T09:15:00Z&to=2024-01-30T15:30:00Z
 func (h *IntradayHandler) GetDataCompleteness(c *gin.Context) {
-	symbol := c.Param("symbol")
-	timeframe := c.DefaultQuery("timeframe", "1m")
+	exchange, symbol := storedSymbol(c, c.Param("symbol"))
+	timeframe, ok := storedTimeframe(c, "1m")
+	if !ok {
+		return
+	}
 
 	fromStr := c.Query("from")
 	toStr := c.Query("to")
@@ -376,15 +683,23 @@ func (h *IntradayHandler) GetDataCompleteness(c *gin.Context) {
 		return
 	}
 
-	completeness, err := h.db.GetDataCompleteness(symbol, timeframe, fromTime, toTime)
+	days, err := h.db.GetDataCompletenessByDay(exchange, symbol, timeframe, fromTime, toTime)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to calculate completeness: " + err.Error(),
 		})
 		return
 	}
+	completeness := database.TotalCompleteness(days)
+
+	expected, present := 0, 0
+	for _, day := range days {
+		expected += day.ExpectedBars
+		present += day.PresentBars
+	}
 
 	c.JSON(http.StatusOK, gin.H{
+		"exchange":        exchange,
 		"symbol":          symbol,
 		"timeframe":       timeframe,
 		"from":            fromTime,
@@ -392,6 +707,90 @@ func (h *IntradayHandler) GetDataCompleteness(c *gin.Context) {
 		"completeness":    completeness,
 		"completeness_pct": completeness,
 		"quality":         getQualityRating(completeness),
+		"expected_bars":   expected,
+		"present_bars":    present,
+		"days":            days,
+	})
+}
+
+// ImportBars imports vendor CSV or Parquet files into md.intraday_bars
+// POST /intraday/import (multipart: file, timeframe, exchange, symbol, tz, symbol_map)
+func (h *IntradayHandler) ImportBars(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+
+	fileHeader, err := c.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": fmt.Sprintf("upload exceeds %d MB", maxImportBytes>>20),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "file is required: " + err.Error(),
+		})
+		return
+	}
+
+	location := broker.IST()
+	if tz := c.PostForm("tz"); tz != "" && tz != "Asia/Kolkata" {
+		location, err = time.LoadLocation(tz)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid timezone: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	// symbol_map is a JSON object of vendor symbol -> tradingsymbol
+	var symbolMap map[string]string
+	if raw := c.PostForm("symbol_map"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &symbolMap); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid symbol_map: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	imp, err := importer.New(h.db, importer.Options{
+		Timeframe: c.DefaultPostForm("timeframe", "1m"),
+		Exchange:  c.PostForm("exchange"),
+		Symbol:    c.PostForm("symbol"),
+		SymbolMap: symbolMap,
+		Location:  location,
+		Source:    c.DefaultPostForm("source", "import"),
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "failed to open upload: " + err.Error(),
+		})
+		return
+	}
+	defer file.Close()
+
+	result, err := imp.ImportFile(fileHeader.Filename, file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "import failed: " + err.Error(),
+			"result": result,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"file":   fileHeader.Filename,
+		"result": result,
 	})
 }
 
@@ -0,0 +1,338 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/collector"
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// kiteLoginTTL is how long a login started from /auth/kite/login-url can
+// take to come back to /auth/callback
+const kiteLoginTTL = 10 * time.Minute
+
+// kiteLogin is a login started from /auth/kite/login-url, waiting for
+// Kite's redirect
+type kiteLogin struct {
+	userID   string // Empty in single-user mode
+	configID int    // The user's broker config in multi-user mode
+	expires  time.Time
+}
+
+// KiteCallbackHandler completes the Kite Connect login: Kite redirects the
+// browser to /auth/callback with a request token, which is exchanged for
+// the day's access token. The token is stored on the broker config and the
+// ticker, collectors or hubs using it are restarted with it.
+type KiteCallbackHandler struct {
+	db *database.Database
+
+	// Single-user mode: the broker, its config and what streams from it
+	broker     broker.Broker
+	config     *broker.BrokerConfig
+	hub        *WebSocketHub
+	collectors *collector.UnifiedCollectorManager
+
+	// Multi-user mode: per-user hubs, restarted for default configs
+	hubs *WebSocketHubManager
+
+	mu     sync.Mutex
+	logins map[string]kiteLogin // state -> login
+}
+
+// NewKiteCallbackHandler creates a Kite login callback handler. brk and
+// config are the single-user broker and its config; in multi-user mode each
+// user's config is used instead.
+func NewKiteCallbackHandler(db *database.Database, brk broker.Broker, config *broker.BrokerConfig) *KiteCallbackHandler {
+	return &KiteCallbackHandler{
+		db:     db,
+		broker: brk,
+		config: config,
+		logins: make(map[string]kiteLogin),
+	}
+}
+
+// SetWebSocketHub restarts the /ws ticker with renewed tokens (single-user)
+func (h *KiteCallbackHandler) SetWebSocketHub(hub *WebSocketHub) {
+	h.hub = hub
+}
+
+// SetCollectorManager restarts the real collectors with renewed tokens
+// (single-user)
+func (h *KiteCallbackHandler) SetCollectorManager(manager *collector.UnifiedCollectorManager) {
+	h.collectors = manager
+}
+
+// SetWebSocketHubManager recreates a user's hub when their default broker
+// config gets a new token (multi-user)
+func (h *KiteCallbackHandler) SetWebSocketHubManager(manager *WebSocketHubManager) {
+	h.hubs = manager
+}
+
+// RegisterRoutes registers the login routes. The callback is public since
+// Kite redirects the browser to it; the state issued with the login URL
+// ties it to the caller. Pass the auth middleware in multi-user mode.
+func (h *KiteCallbackHandler) RegisterRoutes(r *gin.RouterGroup, middleware ...gin.HandlerFunc) {
+	r.GET("/auth/callback", h.Callback)
+
+	kite := r.Group("/auth/kite")
+	kite.Use(middleware...)
+	{
+		kite.GET("/login-url", h.GetLoginURL)
+	}
+}
+
+// isCallbackPath reports whether a path is the public Kite login callback
+func isCallbackPath(path string) bool {
+	return path == "/auth/callback"
+}
+
+// GetLoginURL returns the Kite login URL for the broker config (config_id,
+// required in multi-user mode). Kite redirects back to /auth/callback,
+// which must be the app's registered redirect URL.
+// GET /auth/kite/login-url
+func (h *KiteCallbackHandler) GetLoginURL(c *gin.Context) {
+	login := kiteLogin{expires: time.Now().Add(kiteLoginTTL)}
+	brk := h.broker
+
+	if userID, ok := GetUserID(c); ok {
+		configID, err := strconv.Atoi(c.Query("config_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "config_id is required",
+			})
+			return
+		}
+		config, err := h.userConfig(userID, configID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to fetch broker account: " + err.Error(),
+			})
+			return
+		}
+		if config == nil || config.BrokerName != "zerodha" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "zerodha broker account not found",
+			})
+			return
+		}
+		if brk, err = broker.NewBroker(config); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to create broker: " + err.Error(),
+			})
+			return
+		}
+		login.userID, login.configID = userID, configID
+	}
+
+	loginURL := brk.GetLoginURL()
+	if loginURL == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "broker has no login URL",
+		})
+		return
+	}
+
+	state, err := h.startLogin(login)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to start login: " + err.Error(),
+		})
+		return
+	}
+
+	// Kite passes redirect_params back to the redirect URL
+	loginURL += "&redirect_params=" + url.QueryEscape("state="+state)
+
+	c.JSON(http.StatusOK, gin.H{
+		"login_url":  loginURL,
+		"expires_at": login.expires,
+	})
+}
+
+// Callback exchanges Kite's request token for an access token, stores it
+// and restarts what streams with the old one, then shows the outcome
+// GET /auth/callback
+func (h *KiteCallbackHandler) Callback(c *gin.Context) {
+	login, ok := h.finishLogin(c.Query("state"))
+	if !ok {
+		renderCallback(c, http.StatusBadRequest, "Login link expired",
+			"Start the login again from /auth/kite/login-url.")
+		return
+	}
+
+	requestToken := c.Query("request_token")
+	if c.Query("status") != "success" || requestToken == "" {
+		renderCallback(c, http.StatusBadRequest, "Login failed",
+			"Kite did not return a request token.")
+		return
+	}
+
+	var err error
+	var restarted []string
+	if login.userID == "" {
+		restarted, err = h.completeLogin(requestToken)
+	} else {
+		restarted, err = h.completeUserLogin(login, requestToken)
+	}
+	if err != nil {
+		log.Printf("❌ Kite login failed: %v", err)
+		renderCallback(c, http.StatusBadGateway, "Login failed", err.Error())
+		return
+	}
+
+	message := "The access token is stored. You can close this window."
+	if len(restarted) > 0 {
+		message = "The access token is stored and these were restarted with it: " +
+			strings.Join(restarted, ", ") + ". You can close this window."
+	}
+	renderCallback(c, http.StatusOK, "Logged in to Kite", message)
+}
+
+// completeLogin renews the single-user broker's token
+func (h *KiteCallbackHandler) completeLogin(requestToken string) ([]string, error) {
+	session, err := h.broker.GenerateSession(requestToken)
+	if err != nil {
+		return nil, err
+	}
+	h.broker.SetAccessToken(session.AccessToken)
+	h.config.AccessToken = session.AccessToken
+
+	// Brokers configured from the environment have no row to update
+	if h.config.ID > 0 {
+		if err := h.db.UpdateBrokerTokens(h.config.ID, session.AccessToken, session.RefreshToken, session.ExpiresAt); err != nil {
+			return nil, err
+		}
+	} else {
+		log.Println("⚠️  Broker configured from the environment, the new access token is not stored")
+	}
+	log.Printf("🔑 Kite login completed for %s", session.UserID)
+
+	var restarted []string
+	if h.hub != nil {
+		h.hub.SetAccessToken(h.config.APIKey, session.AccessToken)
+		restarted = append(restarted, "/ws ticker")
+	}
+	if h.collectors != nil {
+		for _, name := range h.collectors.UpdateAccessToken(h.config.APIKey, session.AccessToken) {
+			restarted = append(restarted, "collector "+name)
+		}
+	}
+	return restarted, nil
+}
+
+// completeUserLogin renews a user's broker config token and restarts their
+// hub if it streams from that config
+func (h *KiteCallbackHandler) completeUserLogin(login kiteLogin, requestToken string) ([]string, error) {
+	config, err := h.userConfig(login.userID, login.configID)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, fmt.Errorf("broker account %d not found", login.configID)
+	}
+
+	brk, err := broker.NewBroker(config)
+	if err != nil {
+		return nil, err
+	}
+	session, err := brk.GenerateSession(requestToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.db.UpdateUserBrokerTokens(login.userID, login.configID, session.AccessToken, session.RefreshToken, session.ExpiresAt); err != nil {
+		return nil, err
+	}
+	config.AccessToken = session.AccessToken
+	config.RefreshToken = session.RefreshToken
+	config.TokenExpiresAt = &session.ExpiresAt
+	log.Printf("🔑 Kite login completed for user %s (config %d)", login.userID, login.configID)
+
+	var restarted []string
+	if h.hubs != nil && config.IsDefault && config.IsActive {
+		if err := h.hubs.UpdateUserBrokerConfig(login.userID, config); err != nil {
+			return nil, err
+		}
+		restarted = append(restarted, "/ws stream")
+	}
+	return restarted, nil
+}
+
+// userConfig returns a user's broker config, nil if they have none with
+// the ID
+func (h *KiteCallbackHandler) userConfig(userID string, configID int) (*broker.BrokerConfig, error) {
+	configs, err := h.db.GetUserBrokerConfigs(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, config := range configs {
+		if config.ConfigID == configID {
+			return config, nil
+		}
+	}
+	return nil, nil
+}
+
+// startLogin records a pending login under a new random state
+func (h *KiteCallbackHandler) startLogin(login kiteLogin) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	state := hex.EncodeToString(buf)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now()
+	for s, pending := range h.logins {
+		if now.After(pending.expires) {
+			delete(h.logins, s)
+		}
+	}
+	h.logins[state] = login
+	return state, nil
+}
+
+// finishLogin takes the pending login of a state; each state works once
+func (h *KiteCallbackHandler) finishLogin(state string) (kiteLogin, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	login, ok := h.logins[state]
+	if !ok {
+		return kiteLogin{}, false
+	}
+	delete(h.logins, state)
+	return login, time.Now().Before(login.expires)
+}
+
+var callbackPage = template.Must(template.New("callback").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}} - Market Bridge</title></head>
+<body style="font-family: sans-serif; max-width: 40em; margin: 4em auto;">
+<h1>{{.Title}}</h1>
+<p>{{.Message}}</p>
+</body>
+</html>
+`))
+
+// renderCallback shows the outcome of a login to the browser
+func renderCallback(c *gin.Context, status int, title, message string) {
+	c.Status(status)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := callbackPage.Execute(c.Writer, gin.H{"Title": title, "Message": message}); err != nil {
+		log.Printf("⚠️  Failed to render login callback: %v", err)
+	}
+}
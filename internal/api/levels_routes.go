@@ -0,0 +1,174 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/analyzer"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// LevelsHandler serves price levels (support/resistance, Fibonacci) derived
+// from daily history
+type LevelsHandler struct {
+	historical *database.HistoricalDataService
+}
+
+// NewLevelsHandler creates a new levels handler
+func NewLevelsHandler(brk broker.Broker, db *database.Database) *LevelsHandler {
+	return &LevelsHandler{
+		historical: database.NewHistoricalDataService(db, brk),
+	}
+}
+
+// RegisterRoutes registers price level routes
+func (h *LevelsHandler) RegisterRoutes(r *gin.RouterGroup) {
+	levels := r.Group("/levels")
+	{
+		levels.GET("/pivots/:symbol", h.GetPivots)
+		levels.GET("/:symbol", h.GetLevels)
+	}
+}
+
+// GetLevels returns a symbol's support and resistance and the Fibonacci
+// retracement and extension levels of the dominant swing over the window
+// GET /levels/:symbol?exchange=NSE&days=90
+func (h *LevelsHandler) GetLevels(c *gin.Context) {
+	symbol := strings.ToUpper(c.Param("symbol"))
+	exchange := strings.ToUpper(c.DefaultQuery("exchange", "NSE"))
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "90"))
+	if err != nil || days <= 0 || days > 2000 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "days must be between 1 and 2000",
+		})
+		return
+	}
+
+	candles, err := h.dailyCandles(exchange, symbol, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch historical data: " + err.Error(),
+		})
+		return
+	}
+	if len(candles) < 2 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "not enough historical data for " + symbol,
+		})
+		return
+	}
+
+	highs := make([]float64, len(candles))
+	lows := make([]float64, len(candles))
+	for i, candle := range candles {
+		highs[i] = candle.High
+		lows[i] = candle.Low
+	}
+	support, resistance := analyzer.FindSupportResistance(highs, lows)
+
+	last := candles[len(candles)-1]
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":     symbol,
+		"exchange":   exchange,
+		"last_close": last.Close,
+		"as_of":      last.Date,
+		"support":    support,
+		"resistance": resistance,
+		"fibonacci":  analyzer.CalculateFibonacciLevels(candles, 0),
+	})
+}
+
+// GetPivots returns classic, Camarilla and Woodie pivot points for the
+// current session from the prior completed day and week
+// GET /levels/pivots/:symbol?exchange=NSE&period=daily
+func (h *LevelsHandler) GetPivots(c *gin.Context) {
+	symbol := strings.ToUpper(c.Param("symbol"))
+	exchange := strings.ToUpper(c.DefaultQuery("exchange", "NSE"))
+	period := strings.ToLower(c.Query("period")) // daily, weekly or empty for both
+
+	if period != "" && period != "daily" && period != "weekly" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "period must be daily or weekly",
+		})
+		return
+	}
+
+	// Three weeks covers the prior week plus holidays
+	candles, err := h.dailyCandles(exchange, symbol, 21)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch historical data: " + err.Error(),
+		})
+		return
+	}
+
+	ist := broker.IST()
+	now := time.Now().In(ist)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, ist)
+	thisWeek := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+
+	response := gin.H{
+		"symbol":   symbol,
+		"exchange": exchange,
+	}
+
+	if period == "" || period == "daily" {
+		if session, ok := lastSessionBefore(candles, today); ok {
+			response["daily"] = analyzer.CalculatePivotPoints(session)
+		}
+	}
+	if period == "" || period == "weekly" {
+		if session, ok := lastSessionBefore(analyzer.AggregateWeekly(candles), thisWeek); ok {
+			response["weekly"] = analyzer.CalculatePivotPoints(session)
+		}
+	}
+
+	if response["daily"] == nil && response["weekly"] == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "no prior session data for " + symbol,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// lastSessionBefore returns the last candle (oldest first) that started
+// before the given time, skipping the session in progress
+func lastSessionBefore(candles []broker.Candle, before time.Time) (broker.Candle, bool) {
+	for i := len(candles) - 1; i >= 0; i-- {
+		if candles[i].Date.Before(before) {
+			return candles[i], true
+		}
+	}
+	return broker.Candle{}, false
+}
+
+// dailyCandles returns a symbol's daily candles for the last days, oldest
+// first
+func (h *LevelsHandler) dailyCandles(exchange, symbol string, days int) ([]broker.Candle, error) {
+	toDate := time.Now()
+	fromDate := toDate.AddDate(0, 0, -days)
+
+	history, err := h.historical.GetHistoricalData(exchange, symbol, "day", fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+
+	candles := make([]broker.Candle, len(history))
+	for i, hc := range history {
+		candles[i] = broker.Candle{
+			Date:   hc.CandleTimestamp,
+			Open:   hc.Open,
+			High:   hc.High,
+			Low:    hc.Low,
+			Close:  hc.Close,
+			Volume: hc.Volume,
+		}
+	}
+	return candles, nil
+}
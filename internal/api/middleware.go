@@ -8,9 +8,19 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/trading-chitti/market-bridge/internal/auth"
 	"github.com/trading-chitti/market-bridge/internal/metrics"
+	"github.com/trading-chitti/market-bridge/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
-// AuthMiddleware validates JWT tokens and adds user context
+// sessionChecker looks up whether a session is still active, so logging out
+// or revoking a session takes effect before its token expires
+type sessionChecker interface {
+	IsSessionActive(sessionID string) (bool, error)
+}
+
+// AuthMiddleware validates JWT tokens and adds user context. When db can
+// check sessions, tokens of revoked or expired sessions are rejected.
 func AuthMiddleware(authService *auth.AuthService, db interface{}) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Extract token from Authorization header
@@ -45,6 +55,25 @@ func AuthMiddleware(authService *auth.AuthService, db interface{}) gin.HandlerFu
 			return
 		}
 
+		// Reject tokens of sessions revoked since they were issued
+		if checker, ok := db.(sessionChecker); ok {
+			active, err := checker.IsSessionActive(claims.SessionID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": "failed to check session",
+				})
+				c.Abort()
+				return
+			}
+			if !active {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error": auth.ErrSessionRevoked.Error(),
+				})
+				c.Abort()
+				return
+			}
+		}
+
 		// Store user info in context
 		c.Set("user_id", claims.UserID)
 		c.Set("email", claims.Email)
@@ -54,6 +83,42 @@ func AuthMiddleware(authService *auth.AuthService, db interface{}) gin.HandlerFu
 	}
 }
 
+// adminChecker looks up whether a user is an administrator
+type adminChecker interface {
+	IsUserAdmin(userID string) (bool, error)
+}
+
+// AdminMiddleware only lets administrators through. It goes after
+// AuthMiddleware, on routes that change service-wide settings or act on the
+// operator's broker rather than the caller's own.
+func AdminMiddleware(db adminChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := RequireUserID(c)
+		if !ok {
+			c.Abort()
+			return
+		}
+
+		admin, err := db.IsUserAdmin(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to check administrator",
+			})
+			c.Abort()
+			return
+		}
+		if !admin {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "administrator access required",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
 // OptionalAuthMiddleware validates JWT tokens but doesn't require them
 func OptionalAuthMiddleware(authService *auth.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -107,7 +172,8 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Request-ID")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", RequestIDHeader)
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
 
 		if c.Request.Method == "OPTIONS" {
@@ -119,16 +185,6 @@ func CORSMiddleware() gin.HandlerFunc {
 	}
 }
 
-// RateLimitMiddleware implements simple rate limiting (in-memory)
-// For production, use Redis-based rate limiting
-func RateLimitMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// TODO: Implement rate limiting with Redis or similar
-		// For now, just pass through
-		c.Next()
-	}
-}
-
 // AuditLogMiddleware logs all authenticated requests
 func AuditLogMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -164,3 +220,40 @@ func MetricsMiddleware() gin.HandlerFunc {
 		metrics.RecordHTTPRequest(method, endpoint, http.StatusText(status), duration)
 	}
 }
+
+// TracingMiddleware traces each request, continuing the caller's trace from
+// its traceparent header. Probes, metrics scrapes and streaming connections,
+// which stay open for hours, aren't traced.
+func TracingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if isHealthPath(path) || path == "/metrics" || isStreamPath(path) {
+			c.Next()
+			return
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		ctx, span := tracing.StartRequest(c.Request.Context(), c.Request.Header, c.Request.Method+" "+route,
+			attribute.String("http.request.method", c.Request.Method),
+			attribute.String("http.route", route),
+			attribute.String("url.path", path),
+			attribute.String("http.request.id", RequestID(c)),
+		)
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if userID, ok := GetUserID(c); ok {
+			span.SetAttributes(attribute.String("enduser.id", userID))
+		}
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
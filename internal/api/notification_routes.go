@@ -0,0 +1,264 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/notify"
+)
+
+// NotificationHandler manages the channels events are pushed to. In
+// single-user mode channels are shared and also receive system events; in
+// multi-user mode each user sees only their own.
+type NotificationHandler struct {
+	db       *database.Database
+	notifier *notify.Notifier
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(db *database.Database, notifier *notify.Notifier) *NotificationHandler {
+	return &NotificationHandler{
+		db:       db,
+		notifier: notifier,
+	}
+}
+
+// RegisterRoutes registers notification routes. Pass the auth middleware in
+// multi-user mode.
+func (h *NotificationHandler) RegisterRoutes(r *gin.RouterGroup, middleware ...gin.HandlerFunc) {
+	group := r.Group("/notifications")
+	group.Use(middleware...)
+	{
+		group.GET("/events", h.ListEvents)
+		group.GET("/channels", h.ListChannels)
+		group.POST("/channels", h.CreateChannel)
+		group.GET("/channels/:id", h.GetChannel)
+		group.PUT("/channels/:id", h.UpdateChannel)
+		group.DELETE("/channels/:id", h.DeleteChannel)
+		group.POST("/channels/:id/test", h.TestChannel)
+	}
+}
+
+// NotificationChannelRequest creates or replaces a channel
+type NotificationChannelRequest struct {
+	Name    string          `json:"name"`
+	Kind    string          `json:"kind" binding:"required"` // telegram, slack, email, webhook
+	Config  json.RawMessage `json:"config" binding:"required"`
+	Events  []string        `json:"events"` // Empty subscribes to all events
+	Enabled *bool           `json:"enabled"`
+}
+
+// bindChannelRequest reads the request body into a channel record
+func bindChannelRequest(c *gin.Context) (*database.NotificationChannel, bool) {
+	var req NotificationChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: " + err.Error(),
+		})
+		return nil, false
+	}
+
+	channel := &database.NotificationChannel{
+		Name:    req.Name,
+		Kind:    req.Kind,
+		Config:  req.Config,
+		Events:  req.Events,
+		Enabled: true,
+	}
+	if req.Enabled != nil {
+		channel.Enabled = *req.Enabled
+	}
+
+	return channel, true
+}
+
+// redactChannel masks the credentials in a channel's config
+func redactChannel(channel database.NotificationChannel) database.NotificationChannel {
+	channel.Config = notify.Redact(channel.Kind, channel.Config)
+	return channel
+}
+
+// ListEvents lists the events channels can subscribe to
+// GET /notifications/events
+func (h *NotificationHandler) ListEvents(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"events": notify.Events,
+	})
+}
+
+// ListChannels lists the user's channels
+// GET /notifications/channels
+func (h *NotificationHandler) ListChannels(c *gin.Context) {
+	records, err := h.db.GetNotificationChannels(ownerID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch channels: " + err.Error(),
+		})
+		return
+	}
+
+	channels := make([]database.NotificationChannel, len(records))
+	for i, record := range records {
+		channels[i] = redactChannel(record)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"channels": channels,
+		"count":    len(channels),
+	})
+}
+
+// CreateChannel validates and stores a channel
+// POST /notifications/channels
+func (h *NotificationHandler) CreateChannel(c *gin.Context) {
+	channel, ok := bindChannelRequest(c)
+	if !ok {
+		return
+	}
+	if err := notify.Validate(channel); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := h.db.CreateNotificationChannel(ownerID(c), channel); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to create channel: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, redactChannel(*channel))
+}
+
+// GetChannel returns a channel
+// GET /notifications/channels/:id
+func (h *NotificationHandler) GetChannel(c *gin.Context) {
+	channel, ok := h.loadChannel(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, redactChannel(*channel))
+}
+
+// UpdateChannel replaces a channel's settings. Credentials left empty or
+// masked keep their stored value.
+// PUT /notifications/channels/:id
+func (h *NotificationHandler) UpdateChannel(c *gin.Context) {
+	stored, ok := h.loadChannel(c)
+	if !ok {
+		return
+	}
+
+	channel, ok := bindChannelRequest(c)
+	if !ok {
+		return
+	}
+	channel.ChannelID = stored.ChannelID
+
+	if channel.Kind == stored.Kind {
+		config, err := notify.KeepSecrets(channel.Kind, stored.Config, channel.Config)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		channel.Config = config
+	}
+	if err := notify.Validate(channel); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	err := h.db.UpdateNotificationChannel(ownerID(c), channel)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "channel not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to update channel: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, redactChannel(*channel))
+}
+
+// DeleteChannel deletes a channel
+// DELETE /notifications/channels/:id
+func (h *NotificationHandler) DeleteChannel(c *gin.Context) {
+	err := h.db.DeleteNotificationChannel(ownerID(c), c.Param("id"))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "channel not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to delete channel: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "channel deleted",
+	})
+}
+
+// TestChannel sends a test notification through a channel and reports the
+// delivery error, if any
+// POST /notifications/channels/:id/test
+func (h *NotificationHandler) TestChannel(c *gin.Context) {
+	channel, ok := h.loadChannel(c)
+	if !ok {
+		return
+	}
+
+	err := h.notifier.Send(*channel, notify.Notification{
+		Event:   "test",
+		Title:   "🧪 Test notification",
+		Message: "Notifications from market-bridge reach this channel.",
+	})
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error": "delivery failed: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "test notification sent",
+	})
+}
+
+// loadChannel fetches the channel in the :id param, writing the error
+// response if it can't
+func (h *NotificationHandler) loadChannel(c *gin.Context) (*database.NotificationChannel, bool) {
+	channel, err := h.db.GetNotificationChannel(ownerID(c), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch channel: " + err.Error(),
+		})
+		return nil, false
+	}
+	if channel == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "channel not found",
+		})
+		return nil, false
+	}
+
+	return channel, true
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/options"
+)
+
+// GetOptionExpiries lists an underlying's upcoming option expiries
+// GET /options/expiries/:underlying
+func (a *API) GetOptionExpiries(c *gin.Context) {
+	underlying := strings.ToUpper(c.Param("underlying"))
+	ist := broker.IST()
+
+	expiries, err := a.db.GetOptionExpiries(underlying, time.Now().In(ist))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch expiries: " + err.Error()})
+		return
+	}
+
+	dates := make([]string, len(expiries))
+	for i, expiry := range expiries {
+		dates[i] = expiry.Format("2006-01-02")
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"underlying": underlying,
+		"expiries":   dates,
+	})
+}
+
+// GetOptionChain returns an underlying's calls and puts of one expiry by
+// strike, with LTP, OI, implied volatility and Greeks. The expiry defaults
+// to the nearest; strikes limits the chain to that many strikes either side
+// of the money.
+// GET /options/chain/:underlying?expiry=2024-03-28&strikes=10
+func (a *API) GetOptionChain(c *gin.Context) {
+	underlying := strings.ToUpper(c.Param("underlying"))
+	ist := broker.IST()
+	now := time.Now().In(ist)
+
+	strikes, err := strconv.Atoi(c.DefaultQuery("strikes", "0"))
+	if err != nil || strikes < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "strikes must be a non-negative number"})
+		return
+	}
+
+	var expiry time.Time
+	if value := c.Query("expiry"); value != "" {
+		expiry, err = time.ParseInLocation("2006-01-02", value, ist)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid expiry (use YYYY-MM-DD)"})
+			return
+		}
+	} else {
+		expiries, err := a.db.GetOptionExpiries(underlying, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch expiries: " + err.Error()})
+			return
+		}
+		if len(expiries) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "no option contracts for " + underlying + "; sync them with POST /instruments/sync?exchange=NFO",
+			})
+			return
+		}
+		expiry = expiries[0]
+	}
+
+	contracts, err := a.db.GetOptionContracts(underlying, expiry)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch contracts: " + err.Error()})
+		return
+	}
+	if len(contracts) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "no " + underlying + " options expire on " + expiry.Format("2006-01-02"),
+		})
+		return
+	}
+
+	brk, ok := a.brokerFor(c)
+	if !ok {
+		return
+	}
+
+	spot, err := a.spotPrice(brk, options.SpotSymbol(underlying))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch spot price: " + err.Error()})
+		return
+	}
+
+	contracts = options.NearStrikes(contracts, spot, strikes)
+	quotes, err := options.FetchQuotes(brk, contracts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch quotes: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, options.BuildChain(underlying, expiry, spot, contracts, quotes, now, a.riskFreeRate))
+}
+
+// spotPrice returns a symbol's last price from the collectors' quotes when
+// fresh, from the broker otherwise
+func (a *API) spotPrice(brk broker.Broker, symbol string) (float64, error) {
+	if a.quotes != nil {
+		if ltp, missing := a.quotes.LTP([]string{symbol}); len(missing) == 0 {
+			return ltp[symbol], nil
+		}
+	}
+
+	ltp, err := brk.GetLTP([]string{symbol})
+	if err != nil {
+		return 0, err
+	}
+	return ltp[symbol], nil
+}
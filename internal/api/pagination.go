@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// streamPageSize is the number of rows a streaming response reads per query
+const streamPageSize = 5000
+
+// pageFunc reads up to limit rows after the cursor, from the start of the
+// range when it is nil, and returns them with the cursor of the last one
+type pageFunc func(after *database.Cursor, limit int) ([]interface{}, *database.Cursor, error)
+
+// queryCursor reads the cursor query parameter, nil if absent. It responds
+// with an error if the cursor is invalid.
+func queryCursor(c *gin.Context) (*database.Cursor, bool) {
+	s := c.Query("cursor")
+	if s == "" {
+		return nil, true
+	}
+	cursor, err := database.ParseCursor(s)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid 'cursor', use the next_cursor of the previous page",
+		})
+		return nil, false
+	}
+	return &cursor, true
+}
+
+// nextCursor returns the next_cursor of a page: the last row's cursor if
+// the page is full and more rows may follow, or nil
+func nextCursor(rows, limit int, last *database.Cursor) interface{} {
+	if rows < limit || last == nil {
+		return nil
+	}
+	return last.String()
+}
+
+// streamPages writes every row after the cursor as newline-delimited JSON,
+// reading a page at a time so a whole session of ticks never sits in
+// memory. A failure after the first row can no longer change the status,
+// so it ends the stream with an {"error": ...} line instead.
+func streamPages(c *gin.Context, after *database.Cursor, page pageFunc) {
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Cache-Control", "no-cache")
+	c.Status(http.StatusOK)
+
+	enc := json.NewEncoder(c.Writer)
+	for {
+		rows, last, err := page(after, streamPageSize)
+		if err != nil {
+			enc.Encode(gin.H{"error": err.Error()})
+			return
+		}
+		for _, row := range rows {
+			if err := enc.Encode(row); err != nil {
+				return // Client went away
+			}
+		}
+		c.Writer.Flush()
+
+		if len(rows) < streamPageSize || last == nil || c.Request.Context().Err() != nil {
+			return
+		}
+		after = last
+	}
+}
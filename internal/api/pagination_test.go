@@ -0,0 +1,160 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// numberedRows is a pageFunc over rows 0 to n-1, failing from row failAt
+// when it is positive
+func numberedRows(n, failAt int, calls *int) pageFunc {
+	return func(after *database.Cursor, limit int) ([]interface{}, *database.Cursor, error) {
+		*calls++
+		start := 0
+		if after != nil {
+			start = int(after.ID) + 1
+		}
+		if failAt > 0 && start >= failAt {
+			return nil, nil, errors.New("connection reset")
+		}
+		var rows []interface{}
+		for i := start; i < n && len(rows) < limit; i++ {
+			rows = append(rows, gin.H{"id": i})
+		}
+		if len(rows) == 0 {
+			return nil, nil, nil
+		}
+		last := &database.Cursor{Time: time.Unix(0, 0), ID: int64(start + len(rows) - 1)}
+		return rows, last, nil
+	}
+}
+
+func TestStreamPages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		rows      int
+		failAt    int
+		wantLines int
+		wantCalls int
+		wantError bool
+	}{
+		{name: "empty", rows: 0, wantLines: 0, wantCalls: 1},
+		{name: "one page", rows: 3, wantLines: 3, wantCalls: 1},
+		{name: "exact page", rows: streamPageSize, wantLines: streamPageSize, wantCalls: 2},
+		{name: "several pages", rows: 2*streamPageSize + 3, wantLines: 2*streamPageSize + 3, wantCalls: 3},
+		{name: "failure after a page", rows: 2 * streamPageSize, failAt: streamPageSize, wantLines: streamPageSize + 1, wantCalls: 2, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/intraday/ticks/INFY?stream=true", nil)
+
+			calls := 0
+			streamPages(c, nil, numberedRows(tt.rows, tt.failAt, &calls))
+
+			if got := w.Header().Get("Content-Type"); got != "application/x-ndjson" {
+				t.Errorf("Content-Type = %q, want application/x-ndjson", got)
+			}
+			var lines []map[string]interface{}
+			scanner := bufio.NewScanner(w.Body)
+			for scanner.Scan() {
+				var line map[string]interface{}
+				if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+					t.Fatalf("line %d is not JSON: %v", len(lines), err)
+				}
+				lines = append(lines, line)
+			}
+			if len(lines) != tt.wantLines {
+				t.Fatalf("got %d lines, want %d", len(lines), tt.wantLines)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("read %d pages, want %d", calls, tt.wantCalls)
+			}
+
+			rows := len(lines)
+			if tt.wantError {
+				if _, ok := lines[len(lines)-1]["error"]; !ok {
+					t.Errorf("last line = %v, want an error", lines[len(lines)-1])
+				}
+				rows--
+			}
+			// Rows arrive in order, none repeated
+			for i := 0; i < rows; i++ {
+				if id := lines[i]["id"]; id != float64(i) {
+					t.Fatalf("line %d has id %v", i, id)
+				}
+			}
+		})
+	}
+}
+
+func TestNextCursor(t *testing.T) {
+	last := &database.Cursor{Time: time.Date(2024, 1, 30, 3, 45, 0, 0, time.UTC), ID: 42}
+
+	tests := []struct {
+		name  string
+		rows  int
+		limit int
+		last  *database.Cursor
+		want  interface{}
+	}{
+		{name: "full page", rows: 100, limit: 100, last: last, want: last.String()},
+		{name: "short page", rows: 99, limit: 100, last: last, want: nil},
+		{name: "empty", rows: 0, limit: 100, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextCursor(tt.rows, tt.limit, tt.last); got != tt.want {
+				t.Errorf("nextCursor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryCursor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	valid := database.Cursor{Time: time.Date(2024, 1, 30, 3, 45, 0, 0, time.UTC), ID: 7}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantOK     bool
+		wantCursor *database.Cursor
+	}{
+		{name: "absent", query: "", wantOK: true},
+		{name: "valid", query: "?cursor=" + valid.String(), wantOK: true, wantCursor: &valid},
+		{name: "invalid", query: "?cursor=garbage", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/intraday/bars/INFY"+tt.query, nil)
+
+			got, ok := queryCursor(c)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok && w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want 400", w.Code)
+			}
+			if (got == nil) != (tt.wantCursor == nil) || (got != nil && (got.ID != tt.wantCursor.ID || !got.Time.Equal(tt.wantCursor.Time))) {
+				t.Errorf("cursor = %+v, want %+v", got, tt.wantCursor)
+			}
+		})
+	}
+}
@@ -1,13 +1,21 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/trading-chitti/market-bridge/internal/analyzer"
 	"github.com/trading-chitti/market-bridge/internal/broker"
 	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // PatternHandler handles pattern detection requests
@@ -55,6 +63,7 @@ func (h *PatternHandler) RegisterRoutes(r *gin.RouterGroup) {
 		patterns.POST("/scan-multiple", h.ScanMultipleSymbols)
 		patterns.GET("/types", h.ListPatternTypes)
 		patterns.GET("/recent", h.GetRecentPatterns)
+		patterns.GET("/gaps/:symbol", h.GetGaps)
 	}
 }
 
@@ -79,62 +88,18 @@ func (h *PatternHandler) ScanPatterns(c *gin.Context) {
 		req.MinConfidence = 0.65
 	}
 
-	// Fetch historical data
-	toDate := time.Now()
-	fromDate := toDate.AddDate(0, 0, -req.Days)
-
-	// Get instrument token
-	instrumentToken, err := h.db.GetInstrumentToken(req.Exchange, req.Symbol)
-	if err != nil || instrumentToken == 0 {
+	candles, err := h.fetchCandles(req.Exchange, req.Symbol, req.Interval, req.Days)
+	if errors.Is(err, errInstrumentNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "instrument not found, please sync instruments first",
 		})
 		return
 	}
-
-	// Check cache first
-	cachedCandles, err := h.db.GetHistoricalFromCache(instrumentToken, req.Interval, fromDate, toDate)
-	var candles []broker.Candle
-
-	if err == nil && len(cachedCandles) > 0 {
-		// Convert database candles to broker candles
-		candles = make([]broker.Candle, len(cachedCandles))
-		for i, cc := range cachedCandles {
-			candles[i] = broker.Candle{
-				Date:   cc.CandleTimestamp,
-				Open:   cc.Open,
-				High:   cc.High,
-				Low:    cc.Low,
-				Close:  cc.Close,
-				Volume: cc.Volume,
-			}
-		}
-	} else {
-		// Fetch from broker
-		symbol := req.Exchange + ":" + req.Symbol
-		candles, err = h.broker.GetHistoricalData(symbol, fromDate, toDate, req.Interval)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "failed to fetch historical data: " + err.Error(),
-			})
-			return
-		}
-
-		// Cache the data
-		dbCandles := make([]database.HistoricalCandle, len(candles))
-		for i, candle := range candles {
-			dbCandles[i] = database.HistoricalCandle{
-				InstrumentToken: instrumentToken,
-				Interval:        req.Interval,
-				CandleTimestamp: candle.Date,
-				Open:            candle.Open,
-				High:            candle.High,
-				Low:             candle.Low,
-				Close:           candle.Close,
-				Volume:          candle.Volume,
-			}
-		}
-		h.db.CacheHistoricalCandles(dbCandles)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch historical data: " + err.Error(),
+		})
+		return
 	}
 
 	if len(candles) == 0 {
@@ -150,6 +115,7 @@ func (h *PatternHandler) ScanPatterns(c *gin.Context) {
 
 	// Scan for patterns
 	allPatterns := h.scanner.ScanAllPatterns(candles)
+	newPatterns := h.storePatterns(h.db, req.Exchange, req.Symbol, req.Interval, allPatterns)
 
 	// Filter by category if specified
 	filtered := allPatterns
@@ -168,6 +134,7 @@ func (h *PatternHandler) ScanPatterns(c *gin.Context) {
 		"interval":       req.Interval,
 		"candles_count":  len(candles),
 		"patterns_found": len(filtered),
+		"new_patterns":   newPatterns,
 		"patterns":       filtered,
 		"scanned_at":     time.Now(),
 	})
@@ -201,79 +168,91 @@ func (h *PatternHandler) ScanMultipleSymbols(c *gin.Context) {
 	h.scanner.MinConfidence = req.MinConfidence
 
 	for _, symbol := range req.Symbols {
-		// Get instrument token
-		instrumentToken, err := h.db.GetInstrumentToken(req.Exchange, symbol)
-		if err != nil || instrumentToken == 0 {
-			results = append(results, gin.H{
-				"symbol": symbol,
-				"error":  "instrument not found",
-			})
-			continue
+		results = append(results, h.scanSymbol(c.Request.Context(), req, symbol, fromDate, toDate))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"scanned_symbols": len(req.Symbols),
+		"results":         results,
+		"scanned_at":      time.Now(),
+	})
+}
+
+// scanSymbol scans one symbol of a ScanMultipleSymbols request, traced as a
+// span of its own
+func (h *PatternHandler) scanSymbol(ctx context.Context, req ScanMultipleRequest, symbol string, fromDate, toDate time.Time) gin.H {
+	ctx, span := tracing.Start(ctx, "patterns.scan_symbol", attribute.String("symbol", symbol))
+	defer span.End()
+	db := h.db.WithContext(ctx)
+
+	// Get instrument token
+	instrumentToken, err := db.GetInstrumentToken(req.Exchange, symbol)
+	if err != nil || instrumentToken == 0 {
+		return gin.H{
+			"symbol": symbol,
+			"error":  "instrument not found",
 		}
+	}
+
+	// Check cache first
+	cachedCandles, err := db.GetHistoricalFromCache(instrumentToken, req.Interval, fromDate, toDate)
+	var candles []broker.Candle
 
-		// Check cache first
-		cachedCandles, err := h.db.GetHistoricalFromCache(instrumentToken, req.Interval, fromDate, toDate)
-		var candles []broker.Candle
-
-		if err == nil && len(cachedCandles) > 0 {
-			candles = make([]broker.Candle, len(cachedCandles))
-			for i, cc := range cachedCandles {
-				candles[i] = broker.Candle{
-					Date:   cc.CandleTimestamp,
-					Open:   cc.Open,
-					High:   cc.High,
-					Low:    cc.Low,
-					Close:  cc.Close,
-					Volume: cc.Volume,
-				}
+	if err == nil && len(cachedCandles) > 0 {
+		candles = make([]broker.Candle, len(cachedCandles))
+		for i, cc := range cachedCandles {
+			candles[i] = broker.Candle{
+				Date:   cc.CandleTimestamp,
+				Open:   cc.Open,
+				High:   cc.High,
+				Low:    cc.Low,
+				Close:  cc.Close,
+				Volume: cc.Volume,
 			}
-		} else {
-			// Fetch from broker
-			fullSymbol := req.Exchange + ":" + symbol
-			candles, err = h.broker.GetHistoricalData(fullSymbol, fromDate, toDate, req.Interval)
-			if err != nil {
-				results = append(results, gin.H{
-					"symbol": symbol,
-					"error":  "failed to fetch data",
-				})
-				continue
+		}
+	} else {
+		// Fetch from broker
+		fullSymbol := req.Exchange + ":" + symbol
+		candles, err = tracing.Broker(ctx, h.broker).GetHistoricalData(fullSymbol, fromDate, toDate, req.Interval)
+		if err != nil {
+			return gin.H{
+				"symbol": symbol,
+				"error":  "failed to fetch data",
 			}
 		}
+	}
 
-		if len(candles) == 0 {
-			results = append(results, gin.H{
-				"symbol":   symbol,
-				"patterns": []analyzer.Pattern{},
-			})
-			continue
+	if len(candles) == 0 {
+		return gin.H{
+			"symbol":   symbol,
+			"patterns": []analyzer.Pattern{},
 		}
+	}
 
-		// Scan for patterns
-		allPatterns := h.scanner.ScanAllPatterns(candles)
-
-		// Filter by category
-		filtered := allPatterns
-		if req.CategoryFilter != "" {
-			filtered = []analyzer.Pattern{}
-			for _, p := range allPatterns {
-				if p.Category == req.CategoryFilter {
-					filtered = append(filtered, p)
-				}
+	// Scan for patterns
+	_, scan := tracing.Start(ctx, "patterns.scan", attribute.Int("patterns.candles", len(candles)))
+	allPatterns := h.scanner.ScanAllPatterns(candles)
+	scan.End()
+	newPatterns := h.storePatterns(db, req.Exchange, symbol, req.Interval, allPatterns)
+
+	// Filter by category
+	filtered := allPatterns
+	if req.CategoryFilter != "" {
+		filtered = []analyzer.Pattern{}
+		for _, p := range allPatterns {
+			if p.Category == req.CategoryFilter {
+				filtered = append(filtered, p)
 			}
 		}
-
-		results = append(results, gin.H{
-			"symbol":         symbol,
-			"patterns_found": len(filtered),
-			"patterns":       filtered,
-		})
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"scanned_symbols": len(req.Symbols),
-		"results":         results,
-		"scanned_at":      time.Now(),
-	})
+	span.SetAttributes(attribute.Int("patterns.found", len(filtered)))
+	return gin.H{
+		"symbol":         symbol,
+		"patterns_found": len(filtered),
+		"new_patterns":   newPatterns,
+		"patterns":       filtered,
+	}
 }
 
 // ListPatternTypes lists all supported pattern types
@@ -302,20 +281,237 @@ func (h *PatternHandler) ListPatternTypes(c *gin.Context) {
 			{"type": "Bearish Flag", "signal": "bearish", "description": "Bearish continuation after decline"},
 			{"type": "Rising Wedge", "signal": "bearish", "description": "Bearish reversal"},
 			{"type": "Falling Wedge", "signal": "bullish", "description": "Bullish reversal"},
+			{"type": "Cup and Handle", "signal": "bullish", "description": "Bullish continuation with rounded base and pullback"},
+			{"type": "Rounding Bottom", "signal": "bullish", "description": "Bullish reversal with gradual U-shaped base"},
+			{"type": "Triple Top", "signal": "bearish", "description": "Bearish reversal with three peaks"},
+			{"type": "Triple Bottom", "signal": "bullish", "description": "Bullish reversal with three troughs"},
+		},
+		"gap_patterns": []gin.H{
+			{"type": "Breakaway Gap", "signal": "bullish/bearish", "description": "Gap out of a range, starting a move in its direction"},
+			{"type": "Runaway Gap", "signal": "bullish/bearish", "description": "Gap continuing an established trend"},
+			{"type": "Exhaustion Gap", "signal": "bearish/bullish", "description": "Late gap in an extended trend that is quickly filled"},
+		},
+		"divergence_patterns": []gin.H{
+			{"type": "Bullish RSI Divergence", "signal": "bullish", "description": "Price lower low, RSI higher low"},
+			{"type": "Hidden Bullish RSI Divergence", "signal": "bullish", "description": "Price higher low, RSI lower low"},
+			{"type": "Bearish RSI Divergence", "signal": "bearish", "description": "Price higher high, RSI lower high"},
+			{"type": "Hidden Bearish RSI Divergence", "signal": "bearish", "description": "Price lower high, RSI higher high"},
+			{"type": "Bullish MACD Divergence", "signal": "bullish", "description": "Price lower low, MACD higher low"},
+			{"type": "Hidden Bullish MACD Divergence", "signal": "bullish", "description": "Price higher low, MACD lower low"},
+			{"type": "Bearish MACD Divergence", "signal": "bearish", "description": "Price higher high, MACD lower high"},
+			{"type": "Hidden Bearish MACD Divergence", "signal": "bearish", "description": "Price lower high, MACD higher high"},
 		},
-		"total_patterns": 20,
+		"total_patterns": 35,
 	}
 
 	c.JSON(http.StatusOK, patternTypes)
 }
 
-// GetRecentPatterns gets recent patterns from database (if pattern alerts are stored)
+// errInstrumentNotFound is returned by fetchCandles for symbols missing
+// from the instruments table
+var errInstrumentNotFound = errors.New("instrument not found")
+
+// fetchCandles returns a symbol's candles for the last days, from the
+// historical cache when available and otherwise from the broker (caching
+// the result)
+func (h *PatternHandler) fetchCandles(exchange, symbol, interval string, days int) ([]broker.Candle, error) {
+	toDate := time.Now()
+	fromDate := toDate.AddDate(0, 0, -days)
+
+	instrumentToken, err := h.db.GetInstrumentToken(exchange, symbol)
+	if err != nil || instrumentToken == 0 {
+		return nil, errInstrumentNotFound
+	}
+
+	// Check cache first
+	cachedCandles, err := h.db.GetHistoricalFromCache(instrumentToken, interval, fromDate, toDate)
+	if err == nil && len(cachedCandles) > 0 {
+		candles := make([]broker.Candle, len(cachedCandles))
+		for i, cc := range cachedCandles {
+			candles[i] = broker.Candle{
+				Date:   cc.CandleTimestamp,
+				Open:   cc.Open,
+				High:   cc.High,
+				Low:    cc.Low,
+				Close:  cc.Close,
+				Volume: cc.Volume,
+			}
+		}
+		return candles, nil
+	}
+
+	// Fetch from broker
+	candles, err := h.broker.GetHistoricalData(exchange+":"+symbol, fromDate, toDate, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache the data
+	dbCandles := make([]database.HistoricalCandle, len(candles))
+	for i, candle := range candles {
+		dbCandles[i] = database.HistoricalCandle{
+			InstrumentToken: instrumentToken,
+			Interval:        interval,
+			CandleTimestamp: candle.Date,
+			Open:            candle.Open,
+			High:            candle.High,
+			Low:             candle.Low,
+			Close:           candle.Close,
+			Volume:          candle.Volume,
+		}
+	}
+	h.db.CacheHistoricalCandles(dbCandles)
+
+	return candles, nil
+}
+
+// GetGaps returns a symbol's opening gaps, classified as common, breakaway,
+// runaway or exhaustion, with whether each has been filled
+// GET /patterns/gaps/:symbol?exchange=NSE&interval=day&days=90&type=breakaway&unfilled=true
+func (h *PatternHandler) GetGaps(c *gin.Context) {
+	symbol := strings.ToUpper(c.Param("symbol"))
+	exchange := strings.ToUpper(c.DefaultQuery("exchange", "NSE"))
+	interval := c.DefaultQuery("interval", "day")
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "90"))
+	if err != nil || days <= 0 || days > 2000 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "days must be between 1 and 2000",
+		})
+		return
+	}
+
+	candles, err := h.fetchCandles(exchange, symbol, interval, days)
+	if errors.Is(err, errInstrumentNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "instrument not found, please sync instruments first",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch historical data: " + err.Error(),
+		})
+		return
+	}
+
+	gapType := strings.ToLower(c.Query("type"))
+	unfilled := c.Query("unfilled") == "true"
+
+	gaps := []analyzer.Gap{}
+	for _, gap := range h.scanner.FindGaps(candles) {
+		if gapType != "" && gap.Type != gapType {
+			continue
+		}
+		if unfilled && gap.Filled {
+			continue
+		}
+		gaps = append(gaps, gap)
+	}
+
+	unfilledCount := 0
+	for _, gap := range gaps {
+		if !gap.Filled {
+			unfilledCount++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":        symbol,
+		"exchange":      exchange,
+		"interval":      interval,
+		"candles_count": len(candles),
+		"count":         len(gaps),
+		"unfilled":      unfilledCount,
+		"gaps":          gaps,
+	})
+}
+
+// storePatterns records scanned patterns and returns how many weren't
+// stored before. Storage failures are logged so the scan still succeeds.
+func (h *PatternHandler) storePatterns(db *database.Database, exchange, symbol, interval string, patterns []analyzer.Pattern) int {
+	added, err := db.SavePatterns(exchange, symbol, interval, patterns)
+	if err != nil {
+		log.Printf("⚠️  Failed to store patterns for %s:%s: %v", exchange, symbol, err)
+		return 0
+	}
+	return len(added)
+}
+
+// GetRecentPatterns returns stored patterns completed within the lookback
+// window (default 7d; Go durations such as 12h or a number of days such as
+// 3d), most recent first
+// GET /patterns/recent?signal=bullish&category=chart&min_confidence=0.75&lookback=3d&symbol=RELIANCE&interval=day&type=Hammer&limit=100
 func (h *PatternHandler) GetRecentPatterns(c *gin.Context) {
-	// TODO: Implement database storage for pattern alerts
-	// For now, return empty array
+	lookback, err := parseLookback(c.DefaultQuery("lookback", "7d"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	filter := database.PatternFilter{
+		Exchange:  c.Query("exchange"),
+		Symbol:    c.Query("symbol"),
+		Timeframe: c.Query("interval"),
+		Type:      c.Query("type"),
+		Signal:    strings.ToLower(c.Query("signal")),
+		Category:  strings.ToLower(c.Query("category")),
+		Since:     time.Now().Add(-lookback),
+	}
+
+	if v := c.Query("min_confidence"); v != "" {
+		filter.MinConfidence, err = strconv.ParseFloat(v, 64)
+		if err != nil || filter.MinConfidence < 0 || filter.MinConfidence > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "min_confidence must be between 0 and 1",
+			})
+			return
+		}
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	filter.Limit = limit
+
+	patterns, err := h.db.GetRecentPatterns(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch patterns: " + err.Error(),
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"patterns": []analyzer.Pattern{},
-		"message":  "Pattern alert storage coming soon",
+		"count":    len(patterns),
+		"patterns": patterns,
+		"since":    filter.Since,
 	})
 }
+
+// parseLookback parses a lookback window as a Go duration or a number of
+// days ("3d")
+func parseLookback(v string) (time.Duration, error) {
+	var lookback time.Duration
+	if days, ok := strings.CutSuffix(v, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid lookback %q (use e.g. 12h or 3d)", v)
+		}
+		lookback = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, fmt.Errorf("invalid lookback %q (use e.g. 12h or 3d)", v)
+		}
+		lookback = d
+	}
+
+	if lookback <= 0 {
+		return 0, fmt.Errorf("lookback must be positive")
+	}
+	return lookback, nil
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/portfolio"
+)
+
+// PortfolioHandler exposes the live portfolio valuation and its history
+type PortfolioHandler struct {
+	service *portfolio.Service
+	db      *database.Database
+}
+
+// NewPortfolioHandler creates a new portfolio handler
+func NewPortfolioHandler(service *portfolio.Service, db *database.Database) *PortfolioHandler {
+	return &PortfolioHandler{service: service, db: db}
+}
+
+// RegisterRoutes registers portfolio routes. Pass the auth middleware in
+// multi-user mode.
+func (h *PortfolioHandler) RegisterRoutes(r *gin.RouterGroup, middleware ...gin.HandlerFunc) {
+	portfolio := r.Group("/portfolio")
+	portfolio.Use(middleware...)
+	{
+		portfolio.GET("/live", h.GetLive)
+		portfolio.GET("/performance", h.GetPerformance)
+		portfolio.POST("/snapshot", h.RecordSnapshot)
+	}
+}
+
+// GetLive values the holdings and positions at live prices, with total and
+// day P&L and exposure by sector. /ws/portfolio streams the same snapshot.
+// GET /portfolio/live
+func (h *PortfolioHandler) GetLive(c *gin.Context) {
+	snapshot, err := h.service.Snapshot()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to value portfolio: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// GetPerformance analyzes the recorded daily portfolio values since from
+// (YYYY-MM-DD, default all): period returns, CAGR, max drawdown and Sharpe,
+// against the benchmark's cached daily candles (default NIFTY 50)
+// GET /portfolio/performance
+func (h *PortfolioHandler) GetPerformance(c *gin.Context) {
+	var from time.Time
+	if v := c.Query("from"); v != "" {
+		var err error
+		from, err = time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid from format (use YYYY-MM-DD)",
+			})
+			return
+		}
+	}
+	benchmark := strings.ToUpper(c.DefaultQuery("benchmark", DefaultBenchmark))
+
+	days, err := h.db.GetPortfolioHistory(h.service.Account(), from)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get portfolio history: " + err.Error(),
+		})
+		return
+	}
+	if len(days) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "no portfolio history recorded",
+		})
+		return
+	}
+
+	lookback := int(time.Since(days[0].Date).Hours()/24) + 7
+	candles, err := cachedDailyCandles(h.db, "NSE", []string{benchmark}, lookback)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to load benchmark candles: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, portfolio.AnalyzePerformance(days, benchmark, candles[benchmark]))
+}
+
+// RecordSnapshot records today's portfolio value in the history now,
+// replacing an earlier snapshot of the same session
+// POST /portfolio/snapshot
+func (h *PortfolioHandler) RecordSnapshot(c *gin.Context) {
+	day, err := h.service.RecordDay(h.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to record portfolio snapshot: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, day)
+}
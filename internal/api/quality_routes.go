@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/quality"
+)
+
+// QualityHandler serves data quality reports of stored bars
+type QualityHandler struct {
+	db *database.Database
+}
+
+// NewQualityHandler creates a new quality handler
+func NewQualityHandler(db *database.Database) *QualityHandler {
+	return &QualityHandler{db: db}
+}
+
+// RegisterRoutes registers quality routes
+func (h *QualityHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/quality/:symbol", h.GetQualityReport)
+}
+
+// GetQualityReport scans a symbol's stored bars for anomalies and lists the
+// issues collectors recorded for it in the same range
+// GET /quality/:symbol?exchange=NSE&timeframe=1m&from=2024-01-01T09:15:00Z&to=2024-01-01T15:30:00Z
+func (h *QualityHandler) GetQualityReport(c *gin.Context) {
+	exchange, symbol := storedSymbol(c, strings.ToUpper(c.Param("symbol")))
+	timeframe, ok := storedTimeframe(c, "1m")
+	if !ok {
+		return
+	}
+
+	var err error
+	fromTime := time.Now().Add(-24 * time.Hour)
+	toTime := time.Now()
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		fromTime, err = time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid 'from' time format, use RFC3339",
+			})
+			return
+		}
+	}
+	if toStr := c.Query("to"); toStr != "" {
+		toTime, err = time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid 'to' time format, use RFC3339",
+			})
+			return
+		}
+	}
+
+	bars, err := h.db.GetIntradayBars(exchange, symbol, timeframe, fromTime, toTime, 10000)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch bars: " + err.Error(),
+		})
+		return
+	}
+
+	recorded, err := h.db.GetQualityIssues(symbol, fromTime, toTime, 500)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch recorded issues: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"exchange":        exchange,
+		"symbol":          symbol,
+		"timeframe":       timeframe,
+		"from":            fromTime,
+		"to":              toTime,
+		"report":          quality.ScanBars(bars, quality.DefaultConfig()),
+		"recorded_issues": recorded,
+	})
+}
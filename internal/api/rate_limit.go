@@ -0,0 +1,290 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/auth"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/metrics"
+	"github.com/trading-chitti/market-bridge/internal/ratelimit"
+)
+
+// Route classes, each with its own rate limit budget
+const (
+	RouteClassMarketData = "market_data" // Quotes, history, indicators and scans of market data
+	RouteClassTrading    = "trading"     // Placing, changing and cancelling orders
+	RouteClassDefault    = "default"     // Everything else
+)
+
+// rateLimitIdle is how long an unused bucket is kept. Buckets refill
+// completely well within it.
+const rateLimitIdle = time.Hour
+
+// RateBudget is a token bucket: Rate requests per second on average, with
+// bursts of up to Burst. A zero Rate doesn't limit.
+type RateBudget struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+// RateLimits are the budgets of each route class, applied per client
+type RateLimits struct {
+	MarketData RateBudget `json:"market_data"`
+	Trading    RateBudget `json:"trading"`
+	Default    RateBudget `json:"default"`
+}
+
+// DefaultRateLimits returns the default rate limits
+func DefaultRateLimits() RateLimits {
+	return RateLimits{
+		MarketData: RateBudget{Rate: 20, Burst: 40},
+		Trading:    RateBudget{Rate: 5, Burst: 10},
+		Default:    RateBudget{Rate: 10, Burst: 30},
+	}
+}
+
+// budget returns the budget of a route class
+func (l RateLimits) budget(class string) RateBudget {
+	switch class {
+	case RouteClassMarketData:
+		return l.MarketData
+	case RouteClassTrading:
+		return l.Trading
+	default:
+		return l.Default
+	}
+}
+
+// RateLimitStore holds the token buckets
+type RateLimitStore interface {
+	// Take takes a token from the bucket of key if one is available and
+	// returns the tokens left
+	Take(key string, budget RateBudget) (allowed bool, tokens float64, err error)
+}
+
+// RateLimiter limits the request rate of each client (user, API key or IP)
+// per route class. Streaming endpoints are limited by StreamGuard instead.
+type RateLimiter struct {
+	limits      RateLimits
+	store       RateLimitStore
+	authService *auth.AuthService
+	apiKey      string
+}
+
+// NewRateLimiter creates a rate limiter keeping its buckets in store
+func NewRateLimiter(limits RateLimits, store RateLimitStore) *RateLimiter {
+	for _, class := range []string{RouteClassMarketData, RouteClassTrading, RouteClassDefault} {
+		budget := limits.budget(class)
+		metrics.SetRateLimitBudget(class, budget.Rate, budget.Burst)
+	}
+	return &RateLimiter{
+		limits: limits,
+		store:  store,
+	}
+}
+
+// SetAuthService counts requests with a JWT per user (multi-user mode).
+// Call before serving requests.
+func (l *RateLimiter) SetAuthService(authService *auth.AuthService) {
+	l.authService = authService
+}
+
+// SetAPIKey counts requests carrying the configured API_KEY against the
+// key rather than the client IP. Call before serving requests.
+func (l *RateLimiter) SetAPIKey(key string) {
+	l.apiKey = key
+}
+
+// Middleware rejects requests over their client's budget with 429 and a
+// Retry-After header. Requests are let through when the store fails.
+func (l *RateLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if isHealthPath(path) || path == "/metrics" || isStreamPath(path) || isCallbackPath(path) ||
+			c.Request.Method == http.MethodOptions {
+			c.Next()
+			return
+		}
+
+		class := routeClass(c.Request.Method, path)
+		budget := l.limits.budget(class)
+		if budget.Rate <= 0 {
+			c.Next()
+			return
+		}
+		if budget.Burst < 1 {
+			budget.Burst = 1
+		}
+
+		allowed, tokens, err := l.store.Take(class+":"+l.client(c), budget)
+		if err != nil {
+			metrics.RecordRateLimit(class, "error")
+			log.Printf("⚠️  Rate limit check failed, allowing request: %v", err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(budget.Burst))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(int(math.Max(0, math.Floor(tokens)))))
+
+		if !allowed {
+			metrics.RecordRateLimit(class, "limited")
+			retryAfter := int(math.Ceil((1 - tokens) / budget.Rate))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":       "rate limit exceeded",
+				"route_class": class,
+				"retry_after": retryAfter,
+			})
+			return
+		}
+
+		metrics.RecordRateLimit(class, "allowed")
+		c.Next()
+	}
+}
+
+// client identifies who a request counts against: the user of a valid JWT
+// in multi-user mode, else the configured API key, else the client IP.
+// Other keys count against the IP, so a client can't get a fresh budget per
+// request by making keys up. Keys are hashed so they don't end up in the
+// store.
+func (l *RateLimiter) client(c *gin.Context) string {
+	if l.authService != nil {
+		if header := c.GetHeader("Authorization"); strings.HasPrefix(header, "Bearer ") {
+			if claims, err := l.authService.ValidateToken(strings.TrimPrefix(header, "Bearer ")); err == nil {
+				return "user:" + claims.UserID
+			}
+		}
+	}
+	if key := c.GetHeader("X-API-Key"); l.apiKey != "" && compareKeys(l.apiKey, key) {
+		sum := sha256.Sum256([]byte(key))
+		return "key:" + hex.EncodeToString(sum[:8])
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// marketDataPrefixes are the route groups serving market data
+var marketDataPrefixes = []string{
+	"/market", "/historical", "/instruments", "/indicators", "/intraday",
+	"/levels", "/patterns", "/screener", "/breadth", "/indices",
+}
+
+// tradingPrefixes are the route groups placing or changing orders, limited
+// when called with anything but GET
+var tradingPrefixes = []string{
+	"/trade/order", "/trade/basket", "/trade/algo", "/trade/gtt", "/trade/positions", "/trade/scan", "/square-off",
+}
+
+// routeClass returns the route class of a request
+func routeClass(method, path string) string {
+	if method != http.MethodGet {
+		for _, prefix := range tradingPrefixes {
+			if hasPathPrefix(path, prefix) {
+				return RouteClassTrading
+			}
+		}
+	}
+	for _, prefix := range marketDataPrefixes {
+		if hasPathPrefix(path, prefix) {
+			return RouteClassMarketData
+		}
+	}
+	return RouteClassDefault
+}
+
+// hasPathPrefix reports whether path is prefix or below it
+func hasPathPrefix(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// MemoryRateLimitStore keeps token buckets in memory, for a single instance
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*ratelimit.Bucket
+	lastSweep time.Time
+}
+
+// NewMemoryRateLimitStore creates an in-memory rate limit store
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets:   make(map[string]*ratelimit.Bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Take takes a token from the bucket of key
+func (s *MemoryRateLimitStore) Take(key string, budget RateBudget) (bool, float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) > rateLimitIdle {
+		for k, b := range s.buckets {
+			if now.Sub(b.LastUsed()) > rateLimitIdle {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &ratelimit.Bucket{}
+		s.buckets[key] = b
+	}
+	b.Rate, b.Burst = budget.Rate, budget.Burst
+
+	allowed, tokens := b.Take(now)
+	return allowed, tokens, nil
+}
+
+// PostgresRateLimitStore keeps token buckets in the database, shared by all
+// instances behind a load balancer
+type PostgresRateLimitStore struct {
+	db *database.Database
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// NewPostgresRateLimitStore creates a database-backed rate limit store.
+// Apply migrations/0005_rate_limits.up.sql before use.
+func NewPostgresRateLimitStore(db *database.Database) *PostgresRateLimitStore {
+	return &PostgresRateLimitStore{
+		db:        db,
+		lastSweep: time.Now(),
+	}
+}
+
+// Take takes a token from the shared bucket of key
+func (s *PostgresRateLimitStore) Take(key string, budget RateBudget) (bool, float64, error) {
+	s.mu.Lock()
+	sweep := time.Since(s.lastSweep) > rateLimitIdle
+	if sweep {
+		s.lastSweep = time.Now()
+	}
+	s.mu.Unlock()
+
+	if sweep {
+		go func() {
+			if _, err := s.db.DeleteIdleRateLimitBuckets(rateLimitIdle); err != nil {
+				log.Printf("⚠️  %v", err)
+			}
+		}()
+	}
+
+	return s.db.TakeRateLimitToken(key, budget.Rate, budget.Burst)
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisRateLimitTimeout bounds each bucket update, so a slow Redis delays
+// requests by at most this long before they are let through
+const redisRateLimitTimeout = 500 * time.Millisecond
+
+// redisTakeScript refills and takes from the bucket in KEYS[1] atomically,
+// using the server's clock so every instance agrees on the elapsed time.
+// ARGV: rate per second, burst, idle expiry in seconds. Returns
+// {allowed, tokens left}; tokens are a string since Lua numbers returned to
+// Redis are truncated to integers.
+var redisTakeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) + tonumber(time[2]) / 1000000
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(state[1])
+local updated = tonumber(state[2])
+if tokens == nil or updated == nil then
+	tokens = burst
+	updated = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - updated) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(now))
+redis.call('EXPIRE', KEYS[1], ARGV[3])
+return {allowed, tostring(tokens)}
+`)
+
+// RedisRateLimitStore keeps token buckets in Redis, shared by all instances
+// behind a load balancer. Idle buckets expire on their own.
+type RedisRateLimitStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisRateLimitStore creates a Redis-backed rate limit store. Bucket
+// keys are prefixed with "ratelimit:".
+func NewRedisRateLimitStore(client redis.UniversalClient) *RedisRateLimitStore {
+	return &RedisRateLimitStore{
+		client: client,
+		prefix: "ratelimit:",
+	}
+}
+
+// Take takes a token from the shared bucket of key
+func (s *RedisRateLimitStore) Take(key string, budget RateBudget) (bool, float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisRateLimitTimeout)
+	defer cancel()
+
+	result, err := redisTakeScript.Run(ctx, s.client, []string{s.prefix + key},
+		budget.Rate, budget.Burst, int(rateLimitIdle.Seconds())).Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+	if len(result) != 2 {
+		return false, 0, fmt.Errorf("unexpected rate limit script result: %v", result)
+	}
+
+	allowed, _ := result[0].(int64)
+	tokensText, _ := result[1].(string)
+	tokens, err := strconv.ParseFloat(tokensText, 64)
+	if err != nil {
+		return false, 0, fmt.Errorf("invalid rate limit token count %q: %w", tokensText, err)
+	}
+	return allowed == 1, tokens, nil
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRateLimitStores(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	stores := map[string]RateLimitStore{
+		"memory": NewMemoryRateLimitStore(),
+		"redis":  NewRedisRateLimitStore(client),
+	}
+
+	tests := []struct {
+		name   string
+		budget RateBudget
+		takes  int
+		want   []bool
+		tokens []float64
+	}{
+		{
+			name:   "burst of three",
+			budget: RateBudget{Rate: 0.001, Burst: 3},
+			takes:  4,
+			want:   []bool{true, true, true, false},
+			tokens: []float64{2, 1, 0, 0},
+		},
+		{
+			name:   "burst of one",
+			budget: RateBudget{Rate: 0.001, Burst: 1},
+			takes:  2,
+			want:   []bool{true, false},
+			tokens: []float64{0, 0},
+		},
+	}
+
+	for storeName, store := range stores {
+		for _, tt := range tests {
+			t.Run(storeName+"/"+tt.name, func(t *testing.T) {
+				key := "test:" + storeName + ":" + tt.name
+				for i := 0; i < tt.takes; i++ {
+					allowed, tokens, err := store.Take(key, tt.budget)
+					if err != nil {
+						t.Fatalf("Take %d: %v", i, err)
+					}
+					if allowed != tt.want[i] {
+						t.Errorf("take %d allowed = %v, want %v", i, allowed, tt.want[i])
+					}
+					// Tokens refill slightly between takes
+					if tokens < tt.tokens[i] || tokens > tt.tokens[i]+0.01 {
+						t.Errorf("take %d tokens = %v, want about %v", i, tokens, tt.tokens[i])
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestRedisRateLimitStoreExpiresIdleBuckets(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	store := NewRedisRateLimitStore(client)
+	if _, _, err := store.Take("default:ip:1.2.3.4", RateBudget{Rate: 1, Burst: 1}); err != nil {
+		t.Fatalf("Take: %v", err)
+	}
+
+	key := "ratelimit:default:ip:1.2.3.4"
+	if ttl := server.TTL(key); ttl != rateLimitIdle {
+		t.Errorf("TTL of %s = %v, want %v", key, ttl, rateLimitIdle)
+	}
+	server.FastForward(rateLimitIdle)
+	if server.Exists(key) {
+		t.Errorf("%s still exists after %v idle", key, rateLimitIdle)
+	}
+}
+
+func TestRouteClass(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{http.MethodGet, "/market/ltp", RouteClassMarketData},
+		{http.MethodGet, "/intraday/bars/RELIANCE", RouteClassMarketData},
+		{http.MethodPost, "/trade/order", RouteClassTrading},
+		{http.MethodGet, "/trade/orders", RouteClassDefault},
+		{http.MethodGet, "/trade/order", RouteClassDefault},
+		{http.MethodDelete, "/trade/order/123", RouteClassTrading},
+		{http.MethodPost, "/square-off", RouteClassTrading},
+		{http.MethodGet, "/marketplace", RouteClassDefault},
+		{http.MethodGet, "/account/positions", RouteClassDefault},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			if got := routeClass(tt.method, tt.path); got != tt.want {
+				t.Errorf("routeClass(%s, %s) = %s, want %s", tt.method, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimiterClient(t *testing.T) {
+	tests := []struct {
+		name   string
+		apiKey string
+		header string
+		want   string
+	}{
+		{"configured key", "secret", "secret", "key:"},
+		{"made-up key", "secret", "guess", "ip:"},
+		{"no key configured", "", "guess", "ip:"},
+		{"no key sent", "secret", "", "ip:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewRateLimiter(RateLimits{}, NewMemoryRateLimitStore())
+			l.SetAPIKey(tt.apiKey)
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/auth/login", nil)
+			if tt.header != "" {
+				c.Request.Header.Set("X-API-Key", tt.header)
+			}
+			if got := l.client(c); !strings.HasPrefix(got, tt.want) {
+				t.Errorf("client = %s, want %s...", got, tt.want)
+			}
+		})
+	}
+}
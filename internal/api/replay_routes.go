@@ -0,0 +1,115 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/collector"
+)
+
+// StartReplayRequest selects a stored session to replay
+type StartReplayRequest struct {
+	Exchange string   `json:"exchange"` // Default NSE
+	Symbols  []string `json:"symbols" binding:"required"`
+	Date     string   `json:"date" binding:"required"` // IST session, YYYY-MM-DD
+	Start    string   `json:"start"`                   // IST, HH:MM (default 09:15)
+	End      string   `json:"end"`                     // IST, HH:MM (default 15:30)
+	Speed    float64  `json:"speed"`                   // 1 to 100 (default 1)
+	Data     string   `json:"data"`                    // ticks (default) or bars
+}
+
+// StartReplay replays a stored session's ticks or 1m bars to /stream
+// clients at 1x-100x the recorded pace
+// POST /replays
+func (h *CollectorHandler) StartReplay(c *gin.Context) {
+	var req StartReplayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: " + err.Error(),
+		})
+		return
+	}
+	if req.Start == "" {
+		req.Start = "09:15"
+	}
+	if req.End == "" {
+		req.End = "15:30"
+	}
+	if req.Speed == 0 {
+		req.Speed = 1
+	}
+
+	ist := broker.IST()
+	from, err := time.ParseInLocation("2006-01-02 15:04", req.Date+" "+req.Start, ist)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date or start (use YYYY-MM-DD and HH:MM)"})
+		return
+	}
+	to, err := time.ParseInLocation("2006-01-02 15:04", req.Date+" "+req.End, ist)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid end (use HH:MM)"})
+		return
+	}
+
+	status, err := h.manager.StartReplay(collector.ReplayConfig{
+		Exchange: req.Exchange,
+		Symbols:  req.Symbols,
+		From:     from,
+		To:       to,
+		Speed:    req.Speed,
+		Data:     req.Data,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "failed to start replay: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, status)
+}
+
+// ListReplays lists replays, oldest first
+// GET /replays
+func (h *CollectorHandler) ListReplays(c *gin.Context) {
+	replays := h.manager.ListReplays()
+
+	c.JSON(http.StatusOK, gin.H{
+		"replays": replays,
+		"total":   len(replays),
+	})
+}
+
+// GetReplay returns a replay's progress
+// GET /replays/:id
+func (h *CollectorHandler) GetReplay(c *gin.Context) {
+	status, err := h.manager.GetReplay(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// DeleteReplay stops a replay and removes it
+// DELETE /replays/:id
+func (h *CollectorHandler) DeleteReplay(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.manager.DeleteReplay(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "replay deleted successfully",
+		"id":      id,
+	})
+}
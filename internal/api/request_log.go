@@ -0,0 +1,109 @@
+package api
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the request ID, taken from the caller when it
+// sends a usable one and returned on every response
+const RequestIDHeader = "X-Request-ID"
+
+const (
+	requestIDKey  = "request_id"
+	requestLogKey = "request_log"
+)
+
+// validRequestID accepts IDs from callers that are short and safe to log
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+// NewLogger creates the logger for request and handler logs: JSON for log
+// shippers when format is "json", text otherwise
+func NewLogger(format string) *logrus.Logger {
+	logger := logrus.New()
+	if format == "json" {
+		logger.SetFormatter(&logrus.JSONFormatter{})
+	} else {
+		logger.SetFormatter(&logrus.TextFormatter{
+			FullTimestamp: true,
+		})
+	}
+	return logger
+}
+
+// RequestLogMiddleware assigns each request an ID, returned in the
+// X-Request-ID header, and logs the request once it completes with its
+// route, status, latency, user and trace. Handlers log through RequestLog
+// to carry the same ID. Health probes and metrics scrapes are logged at
+// debug level.
+func RequestLogMiddleware(logger *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+		c.Set(requestIDKey, requestID)
+		c.Set(requestLogKey, logger.WithField("request_id", requestID))
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+
+		entry := RequestLog(c).WithFields(logrus.Fields{
+			"method":     c.Request.Method,
+			"path":       c.Request.URL.Path,
+			"status":     c.Writer.Status(),
+			"latency_ms": time.Since(start).Milliseconds(),
+			"client_ip":  c.ClientIP(),
+			"bytes":      c.Writer.Size(),
+		})
+		if len(c.Errors) > 0 {
+			entry = entry.WithField("errors", c.Errors.String())
+		}
+
+		status := c.Writer.Status()
+		path := c.Request.URL.Path
+		switch {
+		case isHealthPath(path) || path == "/metrics":
+			entry.Debug("request")
+		case status >= 500:
+			entry.Error("request")
+		case status >= 400:
+			entry.Warn("request")
+		default:
+			entry.Info("request")
+		}
+	}
+}
+
+// RequestID returns the ID RequestLogMiddleware gave the request
+func RequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// RequestLog returns a log entry tagged with the request's ID, route, user
+// and trace, so handler logs can be matched to the request log line
+func RequestLog(c *gin.Context) *logrus.Entry {
+	entry, ok := c.Value(requestLogKey).(*logrus.Entry)
+	if !ok {
+		entry = logrus.NewEntry(logrus.StandardLogger())
+	}
+
+	fields := logrus.Fields{}
+	if route := c.FullPath(); route != "" {
+		fields["route"] = route
+	}
+	if userID, ok := GetUserID(c); ok {
+		fields["user_id"] = userID
+	}
+	if span := trace.SpanContextFromContext(c.Request.Context()); span.IsValid() {
+		fields["trace_id"] = span.TraceID().String()
+	}
+	return entry.WithFields(fields)
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestRequestLogMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		path      string
+		requestID string
+		status    int
+		keepID    bool
+		level     logrus.Level
+	}{
+		{
+			name:   "generated id",
+			path:   "/orders/7",
+			status: http.StatusOK,
+			level:  logrus.InfoLevel,
+		},
+		{
+			name:      "caller id kept",
+			path:      "/orders/7",
+			requestID: "client-req.42",
+			status:    http.StatusOK,
+			keepID:    true,
+			level:     logrus.InfoLevel,
+		},
+		{
+			name:      "unsafe caller id replaced",
+			path:      "/orders/7",
+			requestID: "bad id\nforged=1",
+			status:    http.StatusOK,
+			level:     logrus.InfoLevel,
+		},
+		{
+			name:   "client error",
+			path:   "/orders/7",
+			status: http.StatusUnprocessableEntity,
+			level:  logrus.WarnLevel,
+		},
+		{
+			name:   "server error",
+			path:   "/orders/7",
+			status: http.StatusBadGateway,
+			level:  logrus.ErrorLevel,
+		},
+		{
+			name:   "health probe",
+			path:   "/health/live",
+			status: http.StatusOK,
+			level:  logrus.DebugLevel,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, hook := test.NewNullLogger()
+			logger.SetLevel(logrus.DebugLevel)
+
+			var handlerID string
+			respond := func(c *gin.Context) {
+				c.Set("user_id", "42")
+				RequestLog(c).Info("handled")
+				handlerID = RequestID(c)
+				c.Status(tt.status)
+			}
+			router := gin.New()
+			router.Use(RequestLogMiddleware(logger))
+			router.GET("/orders/:id", respond)
+			router.GET("/health/live", respond)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.requestID != "" {
+				req.Header.Set(RequestIDHeader, tt.requestID)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			requestID := w.Header().Get(RequestIDHeader)
+			if tt.keepID {
+				if requestID != tt.requestID {
+					t.Errorf("%s = %q, want the caller's %q", RequestIDHeader, requestID, tt.requestID)
+				}
+			} else if _, err := uuid.Parse(requestID); err != nil {
+				t.Errorf("%s = %q, want a generated UUID", RequestIDHeader, requestID)
+			}
+			if handlerID != requestID {
+				t.Errorf("handler saw request ID %q, response has %q", handlerID, requestID)
+			}
+
+			entries := hook.AllEntries()
+			if len(entries) != 2 {
+				t.Fatalf("got %d log entries, want the handler's and the request's", len(entries))
+			}
+			for _, entry := range entries {
+				if entry.Data["request_id"] != requestID {
+					t.Errorf("%q logged request_id %v, want %q", entry.Message, entry.Data["request_id"], requestID)
+				}
+				if entry.Data["user_id"] != "42" {
+					t.Errorf("%q logged user_id %v, want 42", entry.Message, entry.Data["user_id"])
+				}
+			}
+
+			entry := entries[1]
+			if entry.Level != tt.level {
+				t.Errorf("request logged at %s, want %s", entry.Level, tt.level)
+			}
+			if entry.Data["status"] != tt.status {
+				t.Errorf("status = %v, want %d", entry.Data["status"], tt.status)
+			}
+			if route := entry.Data["route"]; tt.path == "/orders/7" && route != "/orders/:id" {
+				t.Errorf("route = %v, want /orders/:id", route)
+			}
+		})
+	}
+}
+
+func TestRequestLogWithoutMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	if entry := RequestLog(c); entry == nil || entry.Data["request_id"] != nil {
+		t.Errorf("RequestLog without the middleware = %v, want an untagged entry", entry)
+	}
+}
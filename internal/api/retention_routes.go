@@ -0,0 +1,101 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/services"
+)
+
+// RetentionHandler exposes the data retention service
+type RetentionHandler struct {
+	service *services.RetentionService
+	db      *database.Database
+}
+
+// NewRetentionHandler creates a new retention handler
+func NewRetentionHandler(service *services.RetentionService, db *database.Database) *RetentionHandler {
+	return &RetentionHandler{service: service, db: db}
+}
+
+// RegisterRoutes registers retention routes. Pass the auth middleware in
+// multi-user mode.
+func (h *RetentionHandler) RegisterRoutes(r *gin.RouterGroup, middleware ...gin.HandlerFunc) {
+	retention := r.Group("/retention")
+	retention.Use(middleware...)
+	{
+		retention.GET("", h.GetStatus)
+		retention.GET("/runs", h.ListRuns)
+		retention.POST("/run", h.Run)
+	}
+}
+
+// GetStatus returns the retention policy and the last run
+// GET /retention
+func (h *RetentionHandler) GetStatus(c *gin.Context) {
+	runs, err := h.db.GetRetentionRuns(1)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch retention runs: " + err.Error(),
+		})
+		return
+	}
+
+	config := h.service.Config()
+	response := gin.H{
+		"cron":              config.Cron,
+		"tick_days":         config.TickDays,
+		"minute_bar_months": config.MinuteBarMonths,
+		"rollup_timeframes": config.RollupTimeframes,
+		"dry_run":           config.DryRun,
+		"last_run":          nil,
+	}
+	if len(runs) > 0 {
+		response["last_run"] = runs[0]
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ListRuns returns recent retention runs, newest first
+// GET /retention/runs?limit=20
+func (h *RetentionHandler) ListRuns(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	runs, err := h.db.GetRetentionRuns(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch retention runs: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"runs":  runs,
+		"count": len(runs),
+	})
+}
+
+// Run applies the retention policy now and returns the run report. Defaults
+// to the configured dry-run mode.
+// POST /retention/run?dry_run=true
+func (h *RetentionHandler) Run(c *gin.Context) {
+	dryRun := h.service.Config().DryRun
+	if v := c.Query("dry_run"); v != "" {
+		dryRun = v == "true"
+	}
+
+	run := h.service.RunOnce(c.Request.Context(), services.RetentionManual, dryRun)
+	if run == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to record retention run",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/risk"
+)
+
+// RiskHandler exposes the pre-trade risk limits of the active broker
+type RiskHandler struct {
+	engine   *risk.Engine
+	broker   broker.Broker
+	db       *database.Database
+	configID int // brokers.config id the limits are stored in, 0 if from env
+}
+
+// NewRiskHandler creates a new risk handler
+func NewRiskHandler(engine *risk.Engine, brk broker.Broker, db *database.Database, configID int) *RiskHandler {
+	return &RiskHandler{
+		engine:   engine,
+		broker:   brk,
+		db:       db,
+		configID: configID,
+	}
+}
+
+// RegisterRoutes registers risk routes. Pass the auth middleware in
+// multi-user mode.
+func (h *RiskHandler) RegisterRoutes(r *gin.RouterGroup, middleware ...gin.HandlerFunc) {
+	riskGroup := r.Group("/risk")
+	riskGroup.Use(middleware...)
+	{
+		riskGroup.GET("/limits", h.GetLimits)
+		riskGroup.PUT("/limits", h.UpdateLimits)
+	}
+}
+
+// GetLimits returns the risk limits and the account usage they're checked against
+// GET /risk/limits
+func (h *RiskHandler) GetLimits(c *gin.Context) {
+	response := gin.H{
+		"limits":                h.engine.Limits(),
+		"default_stop_loss_pct": risk.DefaultStopLossPct,
+	}
+
+	usage, err := h.engine.Usage(h.broker)
+	if err != nil {
+		response["usage_error"] = err.Error()
+	} else {
+		response["usage"] = usage
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateLimits replaces the risk limits, saving them to the broker config
+// PUT /risk/limits
+func (h *RiskHandler) UpdateLimits(c *gin.Context) {
+	var limits risk.Limits
+	if err := c.ShouldBindJSON(&limits); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := limits.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	persisted := false
+	if h.configID != 0 {
+		err := h.db.UpdateBrokerRiskLimits(h.configID, limits.MaxPositions, limits.MaxRiskPerTrade, limits.MaxDailyLoss, limits.MaxSymbolExposure)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to save risk limits: " + err.Error(),
+			})
+			return
+		}
+		persisted = true
+	}
+
+	h.engine.SetLimits(limits)
+
+	c.JSON(http.StatusOK, gin.H{
+		"limits":    limits,
+		"persisted": persisted,
+	})
+}
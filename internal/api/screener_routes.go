@@ -0,0 +1,202 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/analyzer"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/strategy"
+	"github.com/trading-chitti/market-bridge/internal/watchlist"
+)
+
+// DefaultBenchmark is the index relative strength is measured against
+const DefaultBenchmark = "NIFTY 50"
+
+// ScreenerHandler screens watchlist symbols over cached daily candles
+type ScreenerHandler struct {
+	db *database.Database
+}
+
+// NewScreenerHandler creates a new screener handler
+func NewScreenerHandler(db *database.Database) *ScreenerHandler {
+	return &ScreenerHandler{db: db}
+}
+
+// RegisterRoutes registers screener routes
+func (h *ScreenerHandler) RegisterRoutes(r *gin.RouterGroup) {
+	screener := r.Group("/screener")
+	{
+		screener.POST("", h.Screen)
+		screener.GET("/relative-strength", h.GetRelativeStrength)
+	}
+}
+
+// ScreenRequest filters a watchlist, or an explicit list of symbols, with a
+// screener expression
+type ScreenRequest struct {
+	Filter    string   `json:"filter" binding:"required"`
+	Watchlist string   `json:"watchlist"` // Defaults to NIFTY50
+	Symbols   []string `json:"symbols"`   // Screens these instead of the watchlist
+	Exchange  string   `json:"exchange"`  // Exchange of symbols, defaults to NSE
+}
+
+// Screen evaluates a filter expression on each symbol's latest cached daily
+// candle and returns the symbols that pass with the metric values the
+// filter referenced
+// POST /screener {"watchlist": "NIFTY50", "filter": "RSI < 30 AND price > SMA50 AND volume > 2x avg"}
+func (h *ScreenerHandler) Screen(c *gin.Context) {
+	var req ScreenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	screen, err := strategy.ParseScreen(req.Filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid filter: " + err.Error(),
+		})
+		return
+	}
+
+	var name, exchange string
+	var symbols []string
+	if len(req.Symbols) > 0 {
+		exchange = strings.ToUpper(req.Exchange)
+		if exchange == "" {
+			exchange = "NSE"
+		}
+		for _, symbol := range req.Symbols {
+			symbols = append(symbols, strings.ToUpper(strings.TrimSpace(symbol)))
+		}
+	} else {
+		name = strings.ToUpper(req.Watchlist)
+		if name == "" {
+			name = "NIFTY50"
+		}
+		wl := watchlist.GetWatchlist(name)
+		if wl == nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "watchlist not found: " + name,
+			})
+			return
+		}
+		exchange = watchlistExchange(wl)
+		symbols = wl.Symbols
+	}
+
+	// Calendar days covering the warmup, plus weekends and holidays
+	bars := screen.Warmup()
+	candles, err := cachedDailyCandles(h.db, exchange, symbols, bars*3/2+15)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to load cached candles: " + err.Error(),
+		})
+		return
+	}
+
+	scanner := analyzer.NewPatternScanner()
+	matches := []*strategy.ScreenMatch{}
+	var skipped []string
+	for _, symbol := range symbols {
+		if len(candles[symbol]) < bars {
+			skipped = append(skipped, symbol)
+			continue
+		}
+		if match, ok := screen.Evaluate(symbol, candles[symbol], scanner); ok {
+			matches = append(matches, match)
+		}
+	}
+
+	response := gin.H{
+		"filter":  screen.String(),
+		"scanned": len(symbols) - len(skipped),
+		"count":   len(matches),
+		"matches": matches,
+	}
+	if name != "" {
+		response["watchlist"] = name
+	}
+	if len(skipped) > 0 {
+		response["skipped"] = skipped
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetRelativeStrength ranks a watchlist's symbols by their return against a
+// benchmark over one or more lookbacks (trading days, comma-separated; the
+// score averages them)
+// GET /screener/relative-strength?watchlist=NIFTY50&period=55&benchmark=NIFTY%2050
+func (h *ScreenerHandler) GetRelativeStrength(c *gin.Context) {
+	name := strings.ToUpper(c.DefaultQuery("watchlist", "NIFTY50"))
+	wl := watchlist.GetWatchlist(name)
+	if wl == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "watchlist not found: " + name,
+		})
+		return
+	}
+	exchange := watchlistExchange(wl)
+	benchmark := strings.ToUpper(c.DefaultQuery("benchmark", DefaultBenchmark))
+	benchExchange := exchange
+	if index, ok := database.LookupIndex(benchmark); ok {
+		benchmark, benchExchange = index.Symbol, index.Exchange
+	}
+
+	var lookbacks []int
+	for _, v := range strings.Split(c.DefaultQuery("period", "55"), ",") {
+		lookback, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || lookback <= 0 || lookback > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "period must be trading days between 1 and 500, e.g. 55 or 21,55,123",
+			})
+			return
+		}
+		lookbacks = append(lookbacks, lookback)
+	}
+	sort.Ints(lookbacks)
+
+	// Calendar days covering the longest lookback, plus weekends and holidays
+	days := lookbacks[len(lookbacks)-1]*3/2 + 15
+
+	candles, err := cachedDailyCandles(h.db, exchange, wl.Symbols, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to load cached candles: " + err.Error(),
+		})
+		return
+	}
+	benchCandles, err := cachedDailyCandles(h.db, benchExchange, []string{benchmark}, days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to load cached candles: " + err.Error(),
+		})
+		return
+	}
+	if len(benchCandles[benchmark]) <= lookbacks[len(lookbacks)-1] {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "not enough cached daily candles for benchmark " + benchmark + ", warm the cache with POST /historical/warm-cache",
+		})
+		return
+	}
+
+	results, skipped := analyzer.CalculateRelativeStrength(candles, benchCandles[benchmark], lookbacks)
+
+	response := gin.H{
+		"watchlist": name,
+		"benchmark": benchmark,
+		"periods":   lookbacks,
+		"count":     len(results),
+		"results":   results,
+	}
+	if len(skipped) > 0 {
+		response["skipped"] = skipped
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/analyzer"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// SignalHandler handles stored signal requests
+type SignalHandler struct {
+	db *database.Database
+}
+
+// NewSignalHandler creates a new signal handler
+func NewSignalHandler(db *database.Database) *SignalHandler {
+	return &SignalHandler{db: db}
+}
+
+// RegisterRoutes registers signal routes behind middleware
+func (h *SignalHandler) RegisterRoutes(r *gin.RouterGroup, middleware ...gin.HandlerFunc) {
+	signals := r.Group("/signals", middleware...)
+	{
+		signals.GET("", h.GetSignals)
+		signals.GET("/performance", h.GetSignalPerformance)
+	}
+}
+
+// GetSignals returns stored signals, newest first, with their outcomes.
+// Dates are IST days, both inclusive.
+// GET /signals?symbol=RELIANCE&strategy=RSI_OVERSOLD&outcome=OPEN&from=2024-01-01&to=2024-01-31&limit=100
+func (h *SignalHandler) GetSignals(c *gin.Context) {
+	filter, ok := signalFilter(c)
+	if !ok {
+		return
+	}
+	filter.Symbol = c.Query("symbol")
+	filter.Outcome = c.Query("outcome")
+	switch strings.ToUpper(filter.Outcome) {
+	case "", analyzer.OutcomeOpen, analyzer.OutcomeTarget, analyzer.OutcomeStop, analyzer.OutcomeExpired:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "outcome must be OPEN, TARGET, STOP or EXPIRED"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	filter.Limit = limit
+
+	signals, err := h.db.GetSignals(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch signals: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":   len(signals),
+		"signals": signals,
+	})
+}
+
+// GetSignalPerformance reports the hit rate, average R multiple and
+// expectancy of each strategy's signals generated in the window, and of all
+// of them as strategy ALL
+// GET /signals/performance?strategy=RSI_OVERSOLD&from=2024-01-01&to=2024-03-31
+func (h *SignalHandler) GetSignalPerformance(c *gin.Context) {
+	filter, ok := signalFilter(c)
+	if !ok {
+		return
+	}
+
+	signals, err := h.db.GetSignals(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch signals: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":        c.Query("from"),
+		"to":          c.Query("to"),
+		"performance": database.SummarizeSignals(signals),
+	})
+}
+
+// signalFilter reads the strategy, from and to query parameters, writing
+// the error response if they're invalid
+func signalFilter(c *gin.Context) (database.SignalFilter, bool) {
+	filter := database.SignalFilter{
+		Strategy: strings.ToUpper(c.Query("strategy")),
+	}
+
+	ist := broker.IST()
+	if from := c.Query("from"); from != "" {
+		fromDate, err := time.ParseInLocation("2006-01-02", from, ist)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from date (use YYYY-MM-DD)"})
+			return filter, false
+		}
+		filter.From = fromDate
+	}
+	if to := c.Query("to"); to != "" {
+		toDate, err := time.ParseInLocation("2006-01-02", to, ist)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to date (use YYYY-MM-DD)"})
+			return filter, false
+		}
+		filter.To = toDate.AddDate(0, 0, 1)
+	}
+
+	return filter, true
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/services"
+)
+
+// SquareOffHandler exposes the auto square-off service
+type SquareOffHandler struct {
+	service *services.SquareOffService
+}
+
+// NewSquareOffHandler creates a new square-off handler
+func NewSquareOffHandler(service *services.SquareOffService) *SquareOffHandler {
+	return &SquareOffHandler{service: service}
+}
+
+// RegisterRoutes registers square-off routes. Pass the auth middleware in
+// multi-user mode.
+func (h *SquareOffHandler) RegisterRoutes(r *gin.RouterGroup, middleware ...gin.HandlerFunc) {
+	squareOff := r.Group("/square-off")
+	squareOff.Use(middleware...)
+	{
+		squareOff.GET("", h.GetStatus)
+		squareOff.POST("/run", h.Run)
+		squareOff.GET("/stops", h.ListStops)
+		squareOff.PUT("/stops", h.SetStop)
+		squareOff.DELETE("/stops/:exchange/:symbol", h.RemoveStop)
+	}
+}
+
+// GetStatus returns the square-off configuration, watched stops and the
+// last pass that acted on positions
+// GET /square-off
+func (h *SquareOffHandler) GetStatus(c *gin.Context) {
+	config := h.service.Config()
+	c.JSON(http.StatusOK, gin.H{
+		"cron":              config.Cron,
+		"stop_loss_pct":     config.StopLossPct,
+		"trailing_stop_pct": config.TrailingStopPct,
+		"dry_run":           config.DryRun,
+		"stops":             h.service.Stops(),
+		"last_report":       h.service.LastReport(),
+	})
+}
+
+// Run closes all MIS positions now. Defaults to the configured dry-run mode.
+// POST /square-off/run?dry_run=true
+func (h *SquareOffHandler) Run(c *gin.Context) {
+	dryRun := h.service.Config().DryRun
+	if v := c.Query("dry_run"); v != "" {
+		dryRun = v == "true"
+	}
+
+	report := h.service.SquareOffIntraday(services.SquareOffManual, dryRun)
+	c.JSON(http.StatusOK, report)
+}
+
+// ListStops returns the watched position stops
+// GET /square-off/stops
+func (h *SquareOffHandler) ListStops(c *gin.Context) {
+	stops := h.service.Stops()
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(stops),
+		"stops": stops,
+	})
+}
+
+// SetStop sets the stop loss and/or trailing stop of a position
+// PUT /square-off/stops
+func (h *SquareOffHandler) SetStop(c *gin.Context) {
+	var stop services.PositionStop
+	if err := c.ShouldBindJSON(&stop); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: " + err.Error(),
+		})
+		return
+	}
+	stop.Exchange = strings.ToUpper(stop.Exchange)
+	stop.Symbol = strings.ToUpper(stop.Symbol)
+	stop.Product = strings.ToUpper(stop.Product)
+
+	if err := h.service.SetStop(stop); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"stops": h.service.Stops()})
+}
+
+// RemoveStop stops watching a position
+// DELETE /square-off/stops/:exchange/:symbol?product=MIS
+func (h *SquareOffHandler) RemoveStop(c *gin.Context) {
+	exchange := strings.ToUpper(c.Param("exchange"))
+	symbol := strings.ToUpper(c.Param("symbol"))
+	product := strings.ToUpper(c.DefaultQuery("product", "MIS"))
+
+	if !h.service.RemoveStop(exchange, symbol, product) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no stop set for " + exchange + ":" + symbol + " " + product})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "stop removed"})
+}
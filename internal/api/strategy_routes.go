@@ -0,0 +1,549 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/backtest"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/strategy"
+	"github.com/trading-chitti/market-bridge/internal/watchlist"
+)
+
+// StrategyHandler manages user-defined strategies and runs them in backtest,
+// scan and live-signal modes. In single-user mode strategies are shared; in
+// multi-user mode each user sees only their own.
+type StrategyHandler struct {
+	db     *database.Database
+	runner *strategy.Runner
+}
+
+// NewStrategyHandler creates a new strategy handler
+func NewStrategyHandler(brk broker.Broker, db *database.Database) *StrategyHandler {
+	return &StrategyHandler{
+		db:     db,
+		runner: strategy.NewRunner(brk, db),
+	}
+}
+
+// Stop stops all live strategies
+func (h *StrategyHandler) Stop() {
+	h.runner.Stop()
+}
+
+// RegisterRoutes registers strategy routes. Pass the auth middleware in
+// multi-user mode.
+func (h *StrategyHandler) RegisterRoutes(r *gin.RouterGroup, middleware ...gin.HandlerFunc) {
+	strategies := r.Group("/strategies")
+	strategies.Use(middleware...)
+	{
+		strategies.GET("", h.ListStrategies)
+		strategies.POST("", h.CreateStrategy)
+		strategies.GET("/live", h.ListLive)
+		strategies.GET("/:id", h.GetStrategy)
+		strategies.PUT("/:id", h.UpdateStrategy)
+		strategies.DELETE("/:id", h.DeleteStrategy)
+		strategies.POST("/:id/backtest", h.Backtest)
+		strategies.POST("/:id/scan", h.Scan)
+		strategies.POST("/:id/live", h.StartLive)
+		strategies.GET("/:id/live", h.GetLive)
+		strategies.DELETE("/:id/live", h.StopLive)
+		strategies.GET("/:id/signals", h.GetSignals)
+	}
+}
+
+// ownerID returns the authenticated user, or "" in single-user mode
+func ownerID(c *gin.Context) string {
+	userID, _ := GetUserID(c)
+	return userID
+}
+
+// loadStrategy fetches and compiles the strategy in the :id param, writing
+// the error response if it can't
+func (h *StrategyHandler) loadStrategy(c *gin.Context) (*database.StrategyRecord, *strategy.Strategy, bool) {
+	record, err := h.db.GetStrategy(ownerID(c), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch strategy: " + err.Error(),
+		})
+		return nil, nil, false
+	}
+	if record == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "strategy not found",
+		})
+		return nil, nil, false
+	}
+
+	def, err := strategy.Parse(record.Definition)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "stored definition is invalid: " + err.Error(),
+		})
+		return nil, nil, false
+	}
+
+	return record, strategy.New(def), true
+}
+
+// ============================================================================
+// CRUD
+// ============================================================================
+
+// StrategyRequest creates or replaces a strategy
+type StrategyRequest struct {
+	Definition json.RawMessage `json:"definition" binding:"required"`
+	IsActive   *bool           `json:"is_active"`
+}
+
+// bindStrategyRequest validates the request body into a record
+func bindStrategyRequest(c *gin.Context) (*database.StrategyRecord, bool) {
+	var req StrategyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: " + err.Error(),
+		})
+		return nil, false
+	}
+
+	def, err := strategy.Parse(req.Definition)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return nil, false
+	}
+
+	// Store the normalized definition so defaults are explicit
+	normalized, err := json.Marshal(def)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to encode definition: " + err.Error(),
+		})
+		return nil, false
+	}
+
+	record := &database.StrategyRecord{
+		Name:        def.Name,
+		Description: def.Description,
+		Definition:  normalized,
+		IsActive:    true,
+	}
+	if req.IsActive != nil {
+		record.IsActive = *req.IsActive
+	}
+
+	return record, true
+}
+
+// ListStrategies lists the user's strategies
+// GET /strategies
+func (h *StrategyHandler) ListStrategies(c *gin.Context) {
+	records, err := h.db.GetStrategies(ownerID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch strategies: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"strategies": records,
+		"count":      len(records),
+	})
+}
+
+// CreateStrategy validates and stores a strategy definition
+// POST /strategies
+func (h *StrategyHandler) CreateStrategy(c *gin.Context) {
+	record, ok := bindStrategyRequest(c)
+	if !ok {
+		return
+	}
+
+	err := h.db.CreateStrategy(ownerID(c), record)
+	if errors.Is(err, database.ErrStrategyExists) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to create strategy: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, record)
+}
+
+// GetStrategy returns a strategy
+// GET /strategies/:id
+func (h *StrategyHandler) GetStrategy(c *gin.Context) {
+	record, _, ok := h.loadStrategy(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, record)
+}
+
+// UpdateStrategy replaces a strategy's definition. A strategy running live
+// keeps its old rules until restarted.
+// PUT /strategies/:id
+func (h *StrategyHandler) UpdateStrategy(c *gin.Context) {
+	record, ok := bindStrategyRequest(c)
+	if !ok {
+		return
+	}
+	record.StrategyID = c.Param("id")
+
+	err := h.db.UpdateStrategy(ownerID(c), record)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "strategy not found",
+		})
+		return
+	}
+	if errors.Is(err, database.ErrStrategyExists) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to update strategy: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, record)
+}
+
+// DeleteStrategy stops and deletes a strategy along with its signals
+// DELETE /strategies/:id
+func (h *StrategyHandler) DeleteStrategy(c *gin.Context) {
+	strategyID := c.Param("id")
+
+	err := h.db.DeleteStrategy(ownerID(c), strategyID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "strategy not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to delete strategy: " + err.Error(),
+		})
+		return
+	}
+
+	h.runner.StopLive(strategyID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "strategy deleted",
+	})
+}
+
+// ============================================================================
+// RUN MODES
+// ============================================================================
+
+// StrategyBacktestRequest backtests a stored strategy on one symbol
+type StrategyBacktestRequest struct {
+	Exchange       string              `json:"exchange"`
+	Symbol         string              `json:"symbol" binding:"required"`
+	FromDate       string              `json:"from_date" binding:"required"` // YYYY-MM-DD
+	ToDate         string              `json:"to_date"`                      // YYYY-MM-DD, defaults to today
+	InitialCapital float64             `json:"initial_capital"`
+	SlippagePct    float64             `json:"slippage_pct"`
+	Brokerage      *backtest.Brokerage `json:"brokerage"`
+	IncludeEquity  *bool               `json:"include_equity_curve"`
+}
+
+// Backtest runs a stored strategy over cached historical data
+// POST /strategies/:id/backtest
+func (h *StrategyHandler) Backtest(c *gin.Context) {
+	var req StrategyBacktestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	_, strat, ok := h.loadStrategy(c)
+	if !ok {
+		return
+	}
+
+	if req.Exchange == "" {
+		req.Exchange = "NSE"
+	}
+	req.Symbol = strings.ToUpper(req.Symbol)
+
+	fromDate, err := time.Parse("2006-01-02", req.FromDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid from_date format (use YYYY-MM-DD)",
+		})
+		return
+	}
+
+	toDate := time.Now()
+	if req.ToDate != "" {
+		toDate, err = time.Parse("2006-01-02", req.ToDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid to_date format (use YYYY-MM-DD)",
+			})
+			return
+		}
+	}
+
+	result, err := h.runner.Backtest(strat, req.Exchange, req.Symbol, fromDate, toDate, backtest.Config{
+		InitialCapital: req.InitialCapital,
+		SlippagePct:    req.SlippagePct,
+		Brokerage:      req.Brokerage,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if req.IncludeEquity != nil && !*req.IncludeEquity {
+		result.EquityCurve = nil
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// StrategySymbolsRequest selects the symbols to scan or run live
+type StrategySymbolsRequest struct {
+	Exchange    string   `json:"exchange"`
+	Symbols     []string `json:"symbols"`
+	Watchlist   string   `json:"watchlist"`
+	PollSeconds int      `json:"poll_seconds"` // Live mode only, default 60
+}
+
+// bindSymbols resolves symbols and watchlist into a deduplicated list
+func bindSymbols(c *gin.Context) (*StrategySymbolsRequest, []string, bool) {
+	var req StrategySymbolsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: " + err.Error(),
+		})
+		return nil, nil, false
+	}
+
+	if req.Exchange == "" {
+		req.Exchange = "NSE"
+	}
+
+	symbols := make([]string, 0, len(req.Symbols))
+	for _, symbol := range req.Symbols {
+		symbols = append(symbols, strings.ToUpper(strings.TrimSpace(symbol)))
+	}
+
+	if req.Watchlist != "" {
+		wl := watchlist.GetWatchlist(req.Watchlist)
+		if wl == nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "watchlist not found: " + req.Watchlist,
+			})
+			return nil, nil, false
+		}
+		symbols = append(symbols, wl.Symbols...)
+	}
+
+	if len(symbols) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "either symbols or watchlist must be specified",
+		})
+		return nil, nil, false
+	}
+
+	return &req, dedupeSymbols(symbols), true
+}
+
+// Scan evaluates the strategy on the latest bar of each symbol
+// POST /strategies/:id/scan
+func (h *StrategyHandler) Scan(c *gin.Context) {
+	req, symbols, ok := bindSymbols(c)
+	if !ok {
+		return
+	}
+
+	record, strat, ok := h.loadStrategy(c)
+	if !ok {
+		return
+	}
+
+	result := h.runner.Scan(record.StrategyID, strat, req.Exchange, symbols)
+
+	c.JSON(http.StatusOK, result)
+}
+
+// StartLive starts evaluating the strategy on the collector's bars
+// POST /strategies/:id/live
+func (h *StrategyHandler) StartLive(c *gin.Context) {
+	req, symbols, ok := bindSymbols(c)
+	if !ok {
+		return
+	}
+
+	record, strat, ok := h.loadStrategy(c)
+	if !ok {
+		return
+	}
+
+	if !record.IsActive {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "strategy is inactive",
+		})
+		return
+	}
+
+	if req.PollSeconds == 0 {
+		req.PollSeconds = 60
+	}
+	if req.PollSeconds < 10 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "poll_seconds must be at least 10",
+		})
+		return
+	}
+
+	status, err := h.runner.StartLive(record.StrategyID, ownerID(c), strat, req.Exchange, symbols,
+		time.Duration(req.PollSeconds)*time.Second)
+	if errors.Is(err, strategy.ErrAlreadyRunning) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to start strategy: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, status)
+}
+
+// GetLive returns the live status of a strategy
+// GET /strategies/:id/live
+func (h *StrategyHandler) GetLive(c *gin.Context) {
+	record, _, ok := h.loadStrategy(c)
+	if !ok {
+		return
+	}
+
+	status, running := h.runner.GetLiveStatus(record.StrategyID)
+	if !running {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": strategy.ErrNotRunning.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// StopLive stops a live strategy
+// DELETE /strategies/:id/live
+func (h *StrategyHandler) StopLive(c *gin.Context) {
+	record, err := h.db.GetStrategy(ownerID(c), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch strategy: " + err.Error(),
+		})
+		return
+	}
+	if record == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "strategy not found",
+		})
+		return
+	}
+
+	if err := h.runner.StopLive(record.StrategyID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "strategy stopped",
+	})
+}
+
+// ListLive lists the user's live strategies
+// GET /strategies/live
+func (h *StrategyHandler) ListLive(c *gin.Context) {
+	statuses := h.runner.ListLive(ownerID(c))
+
+	c.JSON(http.StatusOK, gin.H{
+		"strategies": statuses,
+		"count":      len(statuses),
+	})
+}
+
+// GetSignals returns a strategy's recent signals
+// GET /strategies/:id/signals?mode=live&limit=100
+func (h *StrategyHandler) GetSignals(c *gin.Context) {
+	record, err := h.db.GetStrategy(ownerID(c), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch strategy: " + err.Error(),
+		})
+		return
+	}
+	if record == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "strategy not found",
+		})
+		return
+	}
+
+	mode := c.Query("mode")
+	if mode != "" && mode != strategy.ModeScan && mode != strategy.ModeLive {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "mode must be scan or live",
+		})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	signals, err := h.db.GetStrategySignals(record.StrategyID, mode, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch signals: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"strategy_id": record.StrategyID,
+		"signals":     signals,
+		"count":       len(signals),
+	})
+}
@@ -0,0 +1,264 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/auth"
+	"github.com/trading-chitti/market-bridge/internal/metrics"
+	"github.com/trading-chitti/market-bridge/internal/ratelimit"
+)
+
+// StreamLimits bounds the streaming connections (/ws, /stream/ws and
+// /stream/sse)
+type StreamLimits struct {
+	AllowedOrigins        []string // Browser origins allowed to connect, empty for any
+	MaxConnectionsPerUser int      // 0 for no limit
+	MaxSubscriptions      int      // Symbols or tokens per connection, 0 for no limit
+	MessagesPerSecond     float64  // Client messages per connection, 0 for no limit
+	MessageBurst          int
+}
+
+// DefaultStreamLimits returns the default streaming limits
+func DefaultStreamLimits() StreamLimits {
+	return StreamLimits{
+		MaxConnectionsPerUser: 5,
+		MaxSubscriptions:      200,
+		MessagesPerSecond:     5,
+		MessageBurst:          20,
+	}
+}
+
+// StreamGuard authenticates streaming connections and enforces the
+// streaming limits. Connections are counted per user in multi-user mode and
+// per client IP otherwise.
+type StreamGuard struct {
+	authService *auth.AuthService // Requires a JWT when set (multi-user mode)
+	sessions    sessionChecker    // Rejects tokens of revoked sessions when set
+	apiKey      string            // Requires the API key when set
+	limits      StreamLimits
+
+	mu          sync.Mutex
+	connections map[string]int
+	open        map[*streamConn]bool // Authenticated connections, closed on revocation
+}
+
+// streamConn is an open connection of a user's session
+type streamConn struct {
+	userID    string
+	sessionID string
+	close     func() // Set through OnRevoke
+	closeOnce sync.Once
+}
+
+// streamConnKey is the context key of the connection Admit let in
+const streamConnKey = "stream_conn"
+
+// NewStreamGuard creates a stream guard. authService is nil in single-user
+// mode and apiKey empty when API key authentication is disabled.
+func NewStreamGuard(authService *auth.AuthService, apiKey string, limits StreamLimits) *StreamGuard {
+	return &StreamGuard{
+		authService: authService,
+		apiKey:      apiKey,
+		limits:      limits,
+		connections: make(map[string]int),
+		open:        make(map[*streamConn]bool),
+	}
+}
+
+// SetSessionChecker rejects connections whose session was revoked or
+// expired, like AuthMiddleware does for requests. Call before serving.
+func (g *StreamGuard) SetSessionChecker(sessions sessionChecker) {
+	g.sessions = sessions
+}
+
+// Limits returns the guard's limits
+func (g *StreamGuard) Limits() StreamLimits {
+	return g.limits
+}
+
+// isStreamPath reports whether a path is a streaming endpoint, which
+// StreamGuard authenticates itself since browsers can't set headers on
+// WebSocket or EventSource requests
+func isStreamPath(path string) bool {
+	return path == "/ws" || strings.HasPrefix(path, "/ws/") ||
+		path == "/stream/ws" || path == "/stream/sse"
+}
+
+// Admit authenticates a connection request to endpoint and reserves a
+// connection slot for its user. On failure it writes the error response and
+// returns false; otherwise release must be called once the connection
+// closes. In multi-user mode the user ID is set on the context. A nil guard
+// admits everything.
+func (g *StreamGuard) Admit(c *gin.Context, endpoint string) (release func(), ok bool) {
+	if g == nil {
+		return func() {}, true
+	}
+
+	reject := func(status int, reason, message string) (func(), bool) {
+		metrics.RecordStreamRejection(endpoint, reason)
+		c.JSON(status, gin.H{"error": message})
+		return nil, false
+	}
+
+	if !g.originAllowed(c.GetHeader("Origin")) {
+		return reject(http.StatusForbidden, "origin", "origin not allowed")
+	}
+
+	// Headers first, query parameters for browsers
+	bearer := ""
+	if header := c.GetHeader("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		bearer = strings.TrimPrefix(header, "Bearer ")
+	}
+
+	if g.apiKey != "" {
+		key := c.GetHeader("X-API-Key")
+		if key == "" {
+			key = c.Query("api_key")
+		}
+		if key == "" && g.authService == nil {
+			key = bearer
+		}
+		if key == "" || !compareKeys(g.apiKey, key) {
+			return reject(http.StatusUnauthorized, "unauthorized", "missing or invalid API key")
+		}
+	}
+
+	user := "ip:" + c.ClientIP()
+	if g.authService != nil {
+		token := bearer
+		if token == "" {
+			token = c.Query("token")
+		}
+		if token == "" {
+			return reject(http.StatusUnauthorized, "unauthorized", "missing token")
+		}
+		claims, err := g.authService.ValidateToken(token)
+		if err != nil {
+			return reject(http.StatusUnauthorized, "unauthorized", "invalid or expired token")
+		}
+		if g.sessions != nil {
+			active, err := g.sessions.IsSessionActive(claims.SessionID)
+			if err != nil {
+				return reject(http.StatusInternalServerError, "session_check", "failed to check session")
+			}
+			if !active {
+				return reject(http.StatusUnauthorized, "unauthorized", auth.ErrSessionRevoked.Error())
+			}
+		}
+		c.Set("user_id", claims.UserID)
+		c.Set("email", claims.Email)
+		c.Set("session_id", claims.SessionID)
+		user = claims.UserID
+	}
+
+	g.mu.Lock()
+	if max := g.limits.MaxConnectionsPerUser; max > 0 && g.connections[user] >= max {
+		g.mu.Unlock()
+		return reject(http.StatusTooManyRequests, "connection_limit", "too many streaming connections")
+	}
+	g.connections[user]++
+	var conn *streamConn
+	if sessionID := c.GetString("session_id"); sessionID != "" {
+		conn = &streamConn{userID: user, sessionID: sessionID}
+		g.open[conn] = true
+		c.Set(streamConnKey, conn)
+	}
+	g.mu.Unlock()
+	metrics.IncrementWebSocketConnections()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			g.mu.Lock()
+			if g.connections[user]--; g.connections[user] <= 0 {
+				delete(g.connections, user)
+			}
+			delete(g.open, conn)
+			g.mu.Unlock()
+			metrics.DecrementWebSocketConnections()
+		})
+	}, true
+}
+
+// OnRevoke sets how to close the connection Admit let in for c when its
+// session is revoked. Connections without a session are never closed.
+func (g *StreamGuard) OnRevoke(c *gin.Context, closeFn func()) {
+	if g == nil {
+		return
+	}
+	conn, ok := c.Value(streamConnKey).(*streamConn)
+	if !ok {
+		return
+	}
+	g.mu.Lock()
+	conn.close = closeFn
+	g.mu.Unlock()
+}
+
+// CloseSession closes the open connections of a revoked session
+func (g *StreamGuard) CloseSession(sessionID string) {
+	g.closeWhere(func(conn *streamConn) bool { return conn.sessionID == sessionID })
+}
+
+// CloseUserSessions closes the open connections of all of a user's
+// sessions, e.g. after a password reset
+func (g *StreamGuard) CloseUserSessions(userID string) {
+	g.closeWhere(func(conn *streamConn) bool { return conn.userID == userID })
+}
+
+func (g *StreamGuard) closeWhere(match func(*streamConn) bool) {
+	if g == nil {
+		return
+	}
+
+	var closing []*streamConn
+	g.mu.Lock()
+	for conn := range g.open {
+		if match(conn) && conn.close != nil {
+			closing = append(closing, conn)
+		}
+	}
+	g.mu.Unlock()
+
+	for _, conn := range closing {
+		conn.closeOnce.Do(conn.close)
+	}
+	if len(closing) > 0 {
+		log.Printf("🔒 Closed %d streaming connections of revoked sessions", len(closing))
+	}
+}
+
+// originAllowed reports whether a browser origin may connect. Requests
+// without an Origin header don't come from browsers.
+func (g *StreamGuard) originAllowed(origin string) bool {
+	if origin == "" || len(g.limits.AllowedOrigins) == 0 {
+		return true
+	}
+	for _, allowed := range g.limits.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxSubscriptions returns the per-connection subscription cap, 0 for none
+func (g *StreamGuard) maxSubscriptions() int {
+	if g == nil {
+		return 0
+	}
+	return g.limits.MaxSubscriptions
+}
+
+// newMessageLimiter returns a limiter for a connection's client messages,
+// nil when messages aren't limited
+func (g *StreamGuard) newMessageLimiter() *ratelimit.Limiter {
+	if g == nil || g.limits.MessagesPerSecond <= 0 {
+		return nil
+	}
+	return ratelimit.NewLimiter(g.limits.MessagesPerSecond, g.limits.MessageBurst)
+}
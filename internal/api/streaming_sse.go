@@ -0,0 +1,164 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/metrics"
+)
+
+// sseHeartbeatInterval keeps idle SSE connections open through proxies
+const sseHeartbeatInterval = 15 * time.Second
+
+// HandleSSE streams the symbols' tick, bar, stats and pattern messages as
+// Server-Sent Events, for clients that can't use WebSockets. Each broadcast
+// message carries its ID, so a reconnecting EventSource resumes after the
+// last one it received (Last-Event-ID header, or last_event_id for clients
+// that can't set headers) from the hub's recent messages.
+// GET /stream/sse?symbols=RELIANCE,TCS
+func (h *StreamingHandler) HandleSSE(c *gin.Context) {
+	subscriptions := make(map[string]bool)
+	for _, symbol := range strings.Split(c.Query("symbols"), ",") {
+		if symbol = strings.TrimSpace(symbol); symbol != "" {
+			subscriptions[symbol] = true
+		}
+	}
+	if len(subscriptions) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "symbols is required, e.g. ?symbols=RELIANCE,TCS",
+		})
+		return
+	}
+	if max := h.guard.maxSubscriptions(); max > 0 && len(subscriptions) > max {
+		metrics.RecordStreamRejection("stream_sse", "subscription_limit")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("at most %d symbols per connection", max),
+		})
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	var resumeAfter uint64
+	if lastEventID != "" {
+		id, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid Last-Event-ID: " + lastEventID,
+			})
+			return
+		}
+		resumeAfter = id
+	}
+
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "streaming not supported",
+		})
+		return
+	}
+
+	release, ok := h.guard.Admit(c, "stream_sse")
+	if !ok {
+		return
+	}
+	defer release()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable nginx buffering
+	c.Status(http.StatusOK)
+
+	// Room for a full replay on top of the live buffer
+	client := &StreamingClient{
+		hub:           h.hub,
+		send:          make(chan *StreamMessage, streamHistorySize+256),
+		subscriptions: subscriptions,
+		resumeAfter:   resumeAfter,
+	}
+
+	symbols := make([]string, 0, len(subscriptions))
+	for symbol := range subscriptions {
+		symbols = append(symbols, symbol)
+	}
+	client.send <- &StreamMessage{
+		Type: "connected",
+		Data: map[string]interface{}{
+			"message": "Connected to Market Bridge streaming",
+			"server":  "market-bridge",
+			"version": "1.0.0",
+			"symbols": symbols,
+		},
+		Timestamp: time.Now(),
+	}
+
+	h.hub.register <- client
+	defer func() {
+		h.hub.unregister <- client
+	}()
+
+	// Reconnect after 3s when the connection drops
+	fmt.Fprint(c.Writer, "retry: 3000\n\n")
+	flusher.Flush()
+
+	revoked := make(chan struct{})
+	h.guard.OnRevoke(c, func() { close(revoked) })
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case message, ok := <-client.send:
+			if !ok {
+				return // Dropped by the hub for falling behind
+			}
+			if err := writeSSE(c.Writer, message); err != nil {
+				log.Printf("SSE write error: %v", err)
+				return
+			}
+			flusher.Flush()
+			metrics.RecordWebSocketMessage(message.Type)
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+
+		case <-c.Request.Context().Done():
+			return
+
+		case <-revoked:
+			return
+		}
+	}
+}
+
+// writeSSE writes a message as an event named after its type. Only
+// broadcast messages have an ID, so control messages don't move the
+// client's resume point.
+func writeSSE(w gin.ResponseWriter, message *StreamMessage) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	if message.ID > 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", message.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", message.Type, data)
+	return err
+}
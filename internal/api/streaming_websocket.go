@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
@@ -10,9 +11,15 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/metrics"
+	"github.com/trading-chitti/market-bridge/internal/ratelimit"
 )
 
-// StreamingHub manages WebSocket connections for live data streaming
+// streamHistorySize is how many broadcast messages are kept for clients
+// resuming a stream
+const streamHistorySize = 1000
+
+// StreamingHub manages WebSocket and SSE connections for live data streaming
 type StreamingHub struct {
 	clients    map[*StreamingClient]bool
 	broadcast  chan *StreamMessage
@@ -20,19 +27,39 @@ type StreamingHub struct {
 	unregister chan *StreamingClient
 	mu         sync.RWMutex
 	db         *database.Database
+	closed     bool           // Set by Close; later clients are turned away
+	closeFrame []byte         // Sent to WebSocket clients by Close
+	writers    sync.WaitGroup // WebSocket clients' write pumps, waited for by Close
+
+	// Owned by Run: the last message ID and the most recent messages
+	lastID  uint64
+	history []*StreamMessage
 }
 
-// StreamingClient represents a connected WebSocket client
+// StreamingClient represents a connected WebSocket or SSE client
 type StreamingClient struct {
 	hub           *StreamingHub
-	conn          *websocket.Conn
+	conn          *websocket.Conn // nil for SSE clients
 	send          chan *StreamMessage
 	subscriptions map[string]bool // symbol -> subscribed
 	mu            sync.RWMutex
+
+	// Replay the buffered messages after this ID on registration (SSE
+	// Last-Event-ID); 0 to start with live messages
+	resumeAfter uint64
+
+	// Set from the handler's StreamGuard
+	release          func() // Frees the connection slot, nil if none
+	maxSubscriptions int
+	limiter          *ratelimit.Limiter
+
+	// Close frame written once send is closed; empty for no status
+	closeFrame []byte
 }
 
 // StreamMessage represents a message to stream to clients
 type StreamMessage struct {
+	ID        uint64                 `json:"id,omitempty"` // Set on broadcast messages, increasing
 	Type      string                 `json:"type"`
 	Symbol    string                 `json:"symbol,omitempty"`
 	Data      interface{}            `json:"data"`
@@ -64,7 +91,16 @@ func (h *StreamingHub) Run() {
 	for {
 		select {
 		case client := <-h.register:
+			if client.resumeAfter > 0 {
+				h.replay(client)
+			}
 			h.mu.Lock()
+			if h.closed {
+				client.closeFrame = h.closeFrame
+				close(client.send)
+				h.mu.Unlock()
+				continue
+			}
 			h.clients[client] = true
 			h.mu.Unlock()
 			log.Printf("📱 Client connected (total: %d)", len(h.clients))
@@ -79,7 +115,14 @@ func (h *StreamingHub) Run() {
 			log.Printf("📱 Client disconnected (total: %d)", len(h.clients))
 
 		case message := <-h.broadcast:
-			h.mu.RLock()
+			h.lastID++
+			message.ID = h.lastID
+			h.history = append(h.history, message)
+			if len(h.history) > streamHistorySize {
+				h.history = h.history[len(h.history)-streamHistorySize:]
+			}
+
+			h.mu.Lock()
 			for client := range h.clients {
 				// Check if client is subscribed to this symbol
 				if message.Symbol != "" {
@@ -96,15 +139,90 @@ func (h *StreamingHub) Run() {
 				case client.send <- message:
 				default:
 					// Client buffer full, disconnect
+					metrics.RecordSlowStreamClient(client.endpoint())
+					log.Printf("🐢 Disconnecting slow %s client", client.endpoint())
 					close(client.send)
 					delete(h.clients, client)
 				}
 			}
-			h.mu.RUnlock()
+			h.mu.Unlock()
+		}
+	}
+}
+
+// Close disconnects all clients: WebSocket clients get a going-away close
+// frame and SSE streams end. It waits until the frames are written or ctx
+// is done. The hub keeps running so late unregistrations don't block.
+func (h *StreamingHub) Close(ctx context.Context) {
+	h.mu.Lock()
+	h.closed = true
+	h.closeFrame = websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	for client := range h.clients {
+		client.closeFrame = h.closeFrame
+		close(client.send)
+		delete(h.clients, client)
+	}
+	h.mu.Unlock()
+
+	waitGroup(ctx, &h.writers)
+}
+
+// admit counts a new WebSocket client's write pump, unless the hub is closed
+func (h *StreamingHub) admit() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return false
+	}
+	h.writers.Add(1)
+	return true
+}
+
+// replay queues the buffered messages after client.resumeAfter for the
+// client's symbols, preceded by a "resumed" message saying whether none
+// were lost (older than the buffer, or sent before a server restart)
+func (h *StreamingHub) replay(client *StreamingClient) {
+	complete := client.resumeAfter <= h.lastID
+	if complete && len(h.history) > 0 {
+		complete = client.resumeAfter >= h.history[0].ID-1
+	} else if complete {
+		complete = client.resumeAfter == h.lastID
+	}
+
+	client.send <- &StreamMessage{
+		Type: "resumed",
+		Data: map[string]interface{}{
+			"last_event_id": client.resumeAfter,
+			"complete":      complete,
+		},
+		Timestamp: time.Now(),
+	}
+
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+	for _, message := range h.history {
+		if message.ID <= client.resumeAfter || !client.subscriptions[message.Symbol] {
+			continue
+		}
+		select {
+		case client.send <- message:
+		default:
+			metrics.RecordDroppedStreamMessage(client.endpoint(), message.Type)
+			return // Buffer full; the client sees the gap in the IDs
 		}
 	}
 }
 
+// enqueue hands a message to the hub, dropping it when the hub is behind;
+// for candle updates a later one supersedes it
+func (h *StreamingHub) enqueue(message *StreamMessage) {
+	select {
+	case h.broadcast <- message:
+	default:
+		metrics.RecordDroppedStreamMessage("stream", message.Type)
+	}
+}
+
 // BroadcastTick broadcasts a tick update to all subscribed clients
 func (h *StreamingHub) BroadcastTick(symbol string, tick *database.TickData) {
 	message := &StreamMessage{
@@ -114,11 +232,7 @@ func (h *StreamingHub) BroadcastTick(symbol string, tick *database.TickData) {
 		Timestamp: time.Now(),
 	}
 
-	select {
-	case h.broadcast <- message:
-	default:
-		// Channel full, skip this tick
-	}
+	h.enqueue(message)
 }
 
 // BroadcastBar broadcasts a new candle to all subscribed clients
@@ -130,11 +244,20 @@ func (h *StreamingHub) BroadcastBar(symbol string, bar *database.IntradayBar) {
 		Timestamp: time.Now(),
 	}
 
-	select {
-	case h.broadcast <- message:
-	default:
-		// Channel full, skip
+	h.enqueue(message)
+}
+
+// BroadcastCandleUpdate broadcasts the forming (incomplete) candle of a
+// symbol to subscribed clients, as a "candle_update" message
+func (h *StreamingHub) BroadcastCandleUpdate(symbol string, bar *database.IntradayBar) {
+	message := &StreamMessage{
+		Type:      "candle_update",
+		Symbol:    symbol,
+		Data:      bar,
+		Timestamp: time.Now(),
 	}
+
+	h.enqueue(message)
 }
 
 // BroadcastStats broadcasts intraday stats update
@@ -146,10 +269,20 @@ func (h *StreamingHub) BroadcastStats(symbol string, stats map[string]interface{
 		Timestamp: time.Now(),
 	}
 
-	select {
-	case h.broadcast <- message:
-	default:
+	h.enqueue(message)
+}
+
+// BroadcastPattern broadcasts a newly detected pattern to clients
+// subscribed to its symbol
+func (h *StreamingHub) BroadcastPattern(detection *database.PatternDetection) {
+	message := &StreamMessage{
+		Type:      "pattern",
+		Symbol:    detection.Symbol,
+		Data:      detection,
+		Timestamp: time.Now(),
 	}
+
+	h.enqueue(message)
 }
 
 // GetClientCount returns the number of connected clients
@@ -163,10 +296,34 @@ func (h *StreamingHub) GetClientCount() int {
 // CLIENT METHODS
 // ============================================================================
 
+// endpoint names the client's endpoint in metrics
+func (c *StreamingClient) endpoint() string {
+	if c.conn == nil {
+		return "stream_sse"
+	}
+	return "stream_ws"
+}
+
+// sendError tells the client a message was rejected, unless its buffer is
+// full
+func (c *StreamingClient) sendError(message string) {
+	select {
+	case c.send <- &StreamMessage{
+		Type:      "error",
+		Data:      map[string]interface{}{"error": message},
+		Timestamp: time.Now(),
+	}:
+	default:
+	}
+}
+
 func (c *StreamingClient) readPump() {
 	defer func() {
 		c.hub.unregister <- c
 		c.conn.Close()
+		if c.release != nil {
+			c.release()
+		}
 	}()
 
 	c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
@@ -194,6 +351,7 @@ func (c *StreamingClient) writePump() {
 	defer func() {
 		ticker.Stop()
 		c.conn.Close()
+		c.hub.writers.Done()
 	}()
 
 	for {
@@ -201,7 +359,7 @@ func (c *StreamingClient) writePump() {
 		case message, ok := <-c.send:
 			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
 			if !ok {
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				c.conn.WriteMessage(websocket.CloseMessage, c.closeFrame)
 				return
 			}
 
@@ -213,6 +371,7 @@ func (c *StreamingClient) writePump() {
 			// Write message
 			data, _ := json.Marshal(message)
 			w.Write(data)
+			metrics.RecordWebSocketMessage(message.Type)
 
 			// Add queued messages to current websocket message
 			n := len(c.send)
@@ -221,6 +380,7 @@ func (c *StreamingClient) writePump() {
 				data, _ := json.Marshal(msg)
 				w.Write([]byte("\n"))
 				w.Write(data)
+				metrics.RecordWebSocketMessage(msg.Type)
 			}
 
 			if err := w.Close(); err != nil {
@@ -248,6 +408,12 @@ func (c *StreamingClient) handleMessage(message []byte) {
 		return
 	}
 
+	if !c.limiter.Allow() {
+		metrics.RecordStreamRejection(c.endpoint(), "rate_limited")
+		c.sendError("too many messages, slow down")
+		return
+	}
+
 	switch msgType {
 	case "subscribe":
 		symbols, ok := msg["symbols"].([]interface{})
@@ -255,22 +421,39 @@ func (c *StreamingClient) handleMessage(message []byte) {
 			return
 		}
 
+		// Symbols past the subscription cap are rejected
+		subscribed := make([]string, 0, len(symbols))
+		var rejected []string
 		c.mu.Lock()
 		for _, sym := range symbols {
-			if symbol, ok := sym.(string); ok {
-				c.subscriptions[symbol] = true
-				log.Printf("📊 Client subscribed to %s", symbol)
+			symbol, ok := sym.(string)
+			if !ok {
+				continue
+			}
+			if !c.subscriptions[symbol] && c.maxSubscriptions > 0 && len(c.subscriptions) >= c.maxSubscriptions {
+				rejected = append(rejected, symbol)
+				continue
 			}
+			c.subscriptions[symbol] = true
+			subscribed = append(subscribed, symbol)
+			log.Printf("📊 Client subscribed to %s", symbol)
 		}
 		c.mu.Unlock()
 
+		data := map[string]interface{}{
+			"symbols": subscribed,
+			"count":   len(subscribed),
+		}
+		if len(rejected) > 0 {
+			metrics.RecordStreamRejection(c.endpoint(), "subscription_limit")
+			data["rejected"] = rejected
+			data["max_subscriptions"] = c.maxSubscriptions
+		}
+
 		// Send confirmation
 		c.send <- &StreamMessage{
-			Type: "subscribed",
-			Data: map[string]interface{}{
-				"symbols": symbols,
-				"count":   len(symbols),
-			},
+			Type:      "subscribed",
+			Data:      data,
 			Timestamp: time.Now(),
 		}
 
@@ -307,9 +490,11 @@ func (c *StreamingClient) handleMessage(message []byte) {
 		}
 		c.mu.RUnlock()
 
-		// Send latest bars for each symbol
+		// Send latest bars for each symbol; subscriptions name NSE symbols
+		// and indices
 		for _, symbol := range symbols {
-			bar, err := c.hub.db.GetLatestIntradayBar(symbol, "1m")
+			exchange, stored := storedKey("NSE", symbol)
+			bar, err := c.hub.db.GetLatestIntradayBar(exchange, stored, "1m")
 			if err == nil && bar != nil {
 				c.send <- &StreamMessage{
 					Type:      "bar",
@@ -328,7 +513,8 @@ func (c *StreamingClient) handleMessage(message []byte) {
 
 // StreamingHandler handles WebSocket streaming requests
 type StreamingHandler struct {
-	hub *StreamingHub
+	hub   *StreamingHub
+	guard *StreamGuard
 }
 
 // NewStreamingHandler creates a new streaming handler
@@ -341,33 +527,53 @@ func NewStreamingHandler(db *database.Database) *StreamingHandler {
 	}
 }
 
+// SetGuard authenticates and limits the handler's connections
+func (h *StreamingHandler) SetGuard(guard *StreamGuard) {
+	h.guard = guard
+}
+
 // RegisterRoutes registers streaming routes
 func (h *StreamingHandler) RegisterRoutes(r *gin.RouterGroup) {
 	stream := r.Group("/stream")
 	{
 		stream.GET("/ws", h.HandleWebSocket)
+		stream.GET("/sse", h.HandleSSE)
 		stream.GET("/stats", h.GetStats)
 	}
 }
 
 // HandleWebSocket handles WebSocket connections
 func (h *StreamingHandler) HandleWebSocket(c *gin.Context) {
+	release, ok := h.guard.Admit(c, "stream_ws")
+	if !ok {
+		return
+	}
+
 	conn, err := streamingUpgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Printf("WebSocket upgrade error: %v", err)
+		release()
+		return
+	}
+	if !h.hub.admit() {
+		conn.WriteMessage(websocket.CloseMessage, h.hub.closeFrame)
+		conn.Close()
+		release()
 		return
 	}
 
 	client := &StreamingClient{
-		hub:           h.hub,
-		conn:          conn,
-		send:          make(chan *StreamMessage, 256),
-		subscriptions: make(map[string]bool),
+		hub:              h.hub,
+		conn:             conn,
+		send:             make(chan *StreamMessage, 256),
+		subscriptions:    make(map[string]bool),
+		release:          release,
+		maxSubscriptions: h.guard.maxSubscriptions(),
+		limiter:          h.guard.newMessageLimiter(),
 	}
 
-	client.hub.register <- client
-
-	// Send welcome message
+	// Send welcome message, queued before registering since the hub may
+	// close send as soon as it has the client
 	client.send <- &StreamMessage{
 		Type: "connected",
 		Data: map[string]interface{}{
@@ -378,6 +584,9 @@ func (h *StreamingHandler) HandleWebSocket(c *gin.Context) {
 		Timestamp: time.Now(),
 	}
 
+	client.hub.register <- client
+	h.guard.OnRevoke(c, func() { conn.Close() })
+
 	// Start read and write pumps
 	go client.writePump()
 	go client.readPump()
@@ -0,0 +1,240 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// ISINAssignment maps a symbol to its ISIN
+type ISINAssignment struct {
+	Exchange string `json:"exchange"` // Defaults to NSE
+	Symbol   string `json:"symbol" binding:"required"`
+	ISIN     string `json:"isin" binding:"required"`
+}
+
+// AssignISINsRequest is a batch of ISINs, e.g. from an exchange's
+// securities list. A symbol taking over another's ISIN is recorded as a
+// rename effective on EffectiveDate.
+type AssignISINsRequest struct {
+	EffectiveDate string           `json:"effective_date"` // YYYY-MM-DD, defaults to today
+	Instruments   []ISINAssignment `json:"instruments" binding:"required"`
+}
+
+// AssignISINs sets the ISINs of synced instruments and records the renames
+// they reveal
+// POST /instruments/isin
+func (a *API) AssignISINs(c *gin.Context) {
+	var req AssignISINsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	ist := broker.IST()
+	effective := time.Now().In(ist)
+	if req.EffectiveDate != "" {
+		var err error
+		effective, err = time.ParseInLocation("2006-01-02", req.EffectiveDate, ist)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid effective_date format (use YYYY-MM-DD)",
+			})
+			return
+		}
+	}
+
+	for i, assignment := range req.Instruments {
+		req.Instruments[i].ISIN = strings.ToUpper(strings.TrimSpace(assignment.ISIN))
+		if err := database.ValidateISIN(req.Instruments[i].ISIN); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "instrument " + strconv.Itoa(i) + ": " + err.Error(),
+			})
+			return
+		}
+	}
+
+	assigned := 0
+	notFound := []string{}
+	changes := []database.SymbolChange{}
+	for _, assignment := range req.Instruments {
+		exchange := assignment.Exchange
+		if exchange == "" {
+			exchange = "NSE"
+		}
+
+		change, found, err := a.db.AssignISIN(exchange, assignment.Symbol, assignment.ISIN, effective)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to assign ISIN of " + assignment.Symbol + ": " + err.Error(),
+			})
+			return
+		}
+		if !found {
+			notFound = append(notFound, strings.ToUpper(exchange)+":"+strings.ToUpper(assignment.Symbol))
+			continue
+		}
+		assigned++
+		if change != nil {
+			changes = append(changes, *change)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"assigned":       assigned,
+		"not_found":      notFound,
+		"symbol_changes": changes,
+	})
+}
+
+// SymbolChangeRequest is a rename to record
+type SymbolChangeRequest struct {
+	Exchange      string `json:"exchange"` // Defaults to NSE
+	OldSymbol     string `json:"old_symbol" binding:"required"`
+	NewSymbol     string `json:"new_symbol" binding:"required"`
+	ISIN          string `json:"isin"`
+	EffectiveDate string `json:"effective_date" binding:"required"` // YYYY-MM-DD, first session as new_symbol
+}
+
+// SaveSymbolChanges records renames, e.g. ones made before ISINs were
+// assigned
+// POST /instruments/symbol-changes
+func (a *API) SaveSymbolChanges(c *gin.Context) {
+	var req []SymbolChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	ist := broker.IST()
+	changes := make([]database.SymbolChange, len(req))
+	for i, r := range req {
+		effective, err := time.ParseInLocation("2006-01-02", r.EffectiveDate, ist)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "change " + strconv.Itoa(i) + ": invalid effective_date format (use YYYY-MM-DD)",
+			})
+			return
+		}
+		if strings.EqualFold(r.OldSymbol, r.NewSymbol) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "change " + strconv.Itoa(i) + ": old_symbol and new_symbol are the same",
+			})
+			return
+		}
+		isin := strings.ToUpper(strings.TrimSpace(r.ISIN))
+		if isin != "" {
+			if err := database.ValidateISIN(isin); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": "change " + strconv.Itoa(i) + ": " + err.Error(),
+				})
+				return
+			}
+		}
+		if r.Exchange == "" {
+			r.Exchange = "NSE"
+		}
+		changes[i] = database.SymbolChange{
+			Exchange:      r.Exchange,
+			OldSymbol:     r.OldSymbol,
+			NewSymbol:     r.NewSymbol,
+			ISIN:          isin,
+			EffectiveDate: effective,
+		}
+	}
+
+	if err := a.db.SaveSymbolChanges(changes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to save symbol changes: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "symbol changes saved",
+		"count":   len(changes),
+	})
+}
+
+// GetSymbolChanges returns the renames that led to a symbol, oldest first
+// GET /instruments/symbol-changes/:symbol?exchange=NSE
+func (a *API) GetSymbolChanges(c *gin.Context) {
+	symbol := strings.ToUpper(c.Param("symbol"))
+	exchange := strings.ToUpper(c.DefaultQuery("exchange", "NSE"))
+
+	changes, err := a.db.GetSymbolHistory(exchange, symbol)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch symbol changes: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"exchange":       exchange,
+		"symbol":         symbol,
+		"count":          len(changes),
+		"symbol_changes": changes,
+	})
+}
+
+// GetInstrumentsByISIN returns the instruments listed under an ISIN and
+// the renames of each exchange's current symbol
+// GET /instruments/isin/:isin
+func (a *API) GetInstrumentsByISIN(c *gin.Context) {
+	isin := strings.ToUpper(c.Param("isin"))
+	if err := database.ValidateISIN(isin); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	instruments, err := a.db.GetInstrumentsByISIN(isin)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch instruments: " + err.Error(),
+		})
+		return
+	}
+	if len(instruments) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "no instruments with ISIN " + isin + "; assign ISINs with POST /instruments/isin",
+		})
+		return
+	}
+
+	// Instruments come latest first within an exchange
+	changes := []database.SymbolChange{}
+	seen := map[string]bool{}
+	for _, inst := range instruments {
+		if seen[inst.Exchange] {
+			continue
+		}
+		seen[inst.Exchange] = true
+
+		history, err := a.db.GetSymbolHistory(inst.Exchange, inst.Tradingsymbol)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to fetch symbol changes: " + err.Error(),
+			})
+			return
+		}
+		changes = append(changes, history...)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"isin":           isin,
+		"instruments":    instruments,
+		"symbol_changes": changes,
+	})
+}
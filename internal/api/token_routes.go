@@ -0,0 +1,121 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/services"
+)
+
+// TokenHandler exposes broker token status and manual refresh
+type TokenHandler struct {
+	service *services.TokenRefreshService
+}
+
+// NewTokenHandler creates a new token handler
+func NewTokenHandler(service *services.TokenRefreshService) *TokenHandler {
+	return &TokenHandler{service: service}
+}
+
+// RegisterRoutes registers token routes. Pass the auth middleware in
+// multi-user mode, where users only see their own brokers.
+func (h *TokenHandler) RegisterRoutes(r *gin.RouterGroup, middleware ...gin.HandlerFunc) {
+	brokers := r.Group("/brokers")
+	brokers.Use(middleware...)
+	{
+		brokers.GET("/:id/token-status", h.GetStatus)
+		brokers.POST("/:id/refresh", h.Refresh)
+	}
+}
+
+// configID parses the broker config ID. On failure it writes the error
+// response and returns false.
+func (h *TokenHandler) configID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid broker id",
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// ownsBroker reports whether the caller may see a broker's token. Without a
+// user (single-user mode) every broker is visible.
+func ownsBroker(c *gin.Context, status *services.TokenStatus) bool {
+	if status == nil {
+		return false
+	}
+	userID, ok := GetUserID(c)
+	return !ok || status.UserID == userID
+}
+
+// GetStatus returns a broker's token state, expiry and how it is renewed,
+// with the login URL when only the user can renew it
+// GET /brokers/:id/token-status
+func (h *TokenHandler) GetStatus(c *gin.Context) {
+	id, ok := h.configID(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.service.Status(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get token status: " + err.Error(),
+		})
+		return
+	}
+	if !ownsBroker(c, status) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "broker not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// Refresh renews a broker's token now from its refresh token. Brokers that
+// need the user's login answer 409 with the login URL.
+// POST /brokers/:id/refresh
+func (h *TokenHandler) Refresh(c *gin.Context) {
+	id, ok := h.configID(c)
+	if !ok {
+		return
+	}
+
+	// Check ownership before touching the broker
+	status, err := h.service.Status(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to get token status: " + err.Error(),
+		})
+		return
+	}
+	if !ownsBroker(c, status) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "broker not found",
+		})
+		return
+	}
+
+	status, err = h.service.Refresh(id)
+	switch {
+	case errors.Is(err, services.ErrLoginRequired):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":        "broker has no refresh token, log in again to renew it",
+			"token_status": status,
+		})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":        err.Error(),
+			"token_status": status,
+		})
+	default:
+		c.JSON(http.StatusOK, status)
+	}
+}
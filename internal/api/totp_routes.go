@@ -0,0 +1,321 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/auth"
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// TOTPCodeHeader carries a TOTP code with requests that need one, such as
+// orders of users who require two-factor authentication for trading
+const TOTPCodeHeader = "X-TOTP-Code"
+
+// TOTPHandler handles two-factor authentication enrollment and settings
+type TOTPHandler struct {
+	db *database.Database
+}
+
+// NewTOTPHandler creates a new two-factor authentication handler
+func NewTOTPHandler(db *database.Database) *TOTPHandler {
+	return &TOTPHandler{db: db}
+}
+
+// TOTPCodeRequest carries a code from the user's authenticator app
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TOTPOrdersRequest changes whether orders need a TOTP code
+type TOTPOrdersRequest struct {
+	Require *bool  `json:"require" binding:"required"`
+	Code    string `json:"code" binding:"required"`
+}
+
+// RegisterRoutes registers two-factor authentication routes
+func (h *TOTPHandler) RegisterRoutes(r *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	twoFactor := r.Group("/auth/2fa")
+	twoFactor.Use(authMiddleware)
+	{
+		twoFactor.GET("", h.GetStatus)
+		twoFactor.POST("/enroll", h.Enroll)
+		twoFactor.POST("/enable", h.Enable)
+		twoFactor.POST("/disable", h.Disable)
+		twoFactor.PUT("/orders", h.SetOrders)
+	}
+}
+
+// GetStatus returns the user's two-factor authentication settings
+// GET /api/auth/2fa
+func (h *TOTPHandler) GetStatus(c *gin.Context) {
+	userID, exists := RequireUserID(c)
+	if !exists {
+		return
+	}
+
+	totp, err := h.db.GetUserTOTP(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get two-factor status: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":             totp.Enabled,
+		"enrollment_pending":  !totp.Enabled && totp.Secret != "",
+		"required_for_orders": totp.Enabled && totp.ForOrders,
+	})
+}
+
+// Enroll issues a new TOTP secret and its provisioning URI, to show as a QR
+// code. It only takes effect once confirmed with Enable.
+// POST /api/auth/2fa/enroll
+func (h *TOTPHandler) Enroll(c *gin.Context) {
+	userID, exists := RequireUserID(c)
+	if !exists {
+		return
+	}
+
+	user, err := h.db.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user: " + err.Error()})
+		return
+	}
+	if user.TOTPEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "two-factor authentication is already enabled"})
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.db.SetUserTOTPSecret(userID, secret); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to enroll: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":           secret,
+		"provisioning_uri": auth.TOTPProvisioningURI(secret, user.Email),
+		"message":          "scan the provisioning URI with an authenticator app, then confirm a code at /api/auth/2fa/enable",
+	})
+}
+
+// Enable turns on two-factor authentication after a code confirms the
+// enrolled secret
+// POST /api/auth/2fa/enable
+func (h *TOTPHandler) Enable(c *gin.Context) {
+	userID, exists := RequireUserID(c)
+	if !exists {
+		return
+	}
+
+	var req TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request: " + err.Error()})
+		return
+	}
+
+	totp, err := h.db.GetUserTOTP(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get two-factor status: " + err.Error()})
+		return
+	}
+	if totp.Enabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "two-factor authentication is already enabled"})
+		return
+	}
+	if totp.Secret == "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "enroll at /api/auth/2fa/enroll first"})
+		return
+	}
+
+	if !h.checkCode(c, userID, totp, req.Code) {
+		return
+	}
+	if err := h.db.SetUserTOTPEnabled(userID, true); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to enable two-factor authentication: " + err.Error()})
+		return
+	}
+
+	h.db.CreateAuditLog(userID, "user.2fa_enable", "user", userID, c.ClientIP(), c.GetHeader("User-Agent"), nil)
+
+	c.JSON(http.StatusOK, gin.H{"enabled": true})
+}
+
+// Disable turns off two-factor authentication. Needs a current code.
+// POST /api/auth/2fa/disable
+func (h *TOTPHandler) Disable(c *gin.Context) {
+	userID, exists := RequireUserID(c)
+	if !exists {
+		return
+	}
+
+	var req TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request: " + err.Error()})
+		return
+	}
+
+	totp, ok := h.enabledTOTP(c, userID)
+	if !ok {
+		return
+	}
+	if !h.checkCode(c, userID, totp, req.Code) {
+		return
+	}
+	if err := h.db.SetUserTOTPEnabled(userID, false); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to disable two-factor authentication: " + err.Error()})
+		return
+	}
+
+	h.db.CreateAuditLog(userID, "user.2fa_disable", "user", userID, c.ClientIP(), c.GetHeader("User-Agent"), nil)
+
+	c.JSON(http.StatusOK, gin.H{"enabled": false})
+}
+
+// SetOrders sets whether placing an order needs a TOTP code in the
+// X-TOTP-Code header. Needs a current code.
+// PUT /api/auth/2fa/orders
+func (h *TOTPHandler) SetOrders(c *gin.Context) {
+	userID, exists := RequireUserID(c)
+	if !exists {
+		return
+	}
+
+	var req TOTPOrdersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request: " + err.Error()})
+		return
+	}
+
+	totp, ok := h.enabledTOTP(c, userID)
+	if !ok {
+		return
+	}
+	if !h.checkCode(c, userID, totp, req.Code) {
+		return
+	}
+	if err := h.db.SetUserTOTPForOrders(userID, *req.Require); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.db.CreateAuditLog(userID, "user.2fa_orders", "user", userID, c.ClientIP(), c.GetHeader("User-Agent"),
+		map[string]interface{}{"require": *req.Require})
+
+	c.JSON(http.StatusOK, gin.H{"required_for_orders": *req.Require})
+}
+
+// enabledTOTP returns the user's two-factor state, answering 409 if two-factor
+// authentication isn't enabled
+func (h *TOTPHandler) enabledTOTP(c *gin.Context, userID string) (*database.UserTOTP, bool) {
+	totp, err := h.db.GetUserTOTP(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get two-factor status: " + err.Error()})
+		return nil, false
+	}
+	if !totp.Enabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "two-factor authentication is not enabled"})
+		return nil, false
+	}
+	return totp, true
+}
+
+// checkCode verifies a code, answering the request when it is rejected
+func (h *TOTPHandler) checkCode(c *gin.Context, userID string, totp *database.UserTOTP, code string) bool {
+	if err := verifyTOTP(h.db, userID, totp, code); err != nil {
+		respondTOTPError(c, err)
+		return false
+	}
+	return true
+}
+
+// verifyTOTP checks a code against the user's secret and uses it up.
+// Returns auth.ErrTOTPRequired without a code, auth.ErrInvalidTOTPCode for
+// a wrong or already used one and auth.ErrTOTPLocked while too many invalid
+// codes in a row lock the user out.
+func verifyTOTP(db *database.Database, userID string, totp *database.UserTOTP, code string) error {
+	if time.Now().Before(totp.LockedUntil) {
+		return auth.ErrTOTPLocked
+	}
+	if code == "" {
+		return auth.ErrTOTPRequired
+	}
+
+	step, ok := auth.ValidateTOTP(totp.Secret, code, time.Now(), totp.LastStep)
+	if ok {
+		used, err := db.UseUserTOTPStep(userID, step)
+		if err != nil {
+			return err
+		}
+		ok = used
+	}
+	if ok {
+		return nil
+	}
+
+	lockedUntil, err := db.RecordTOTPFailure(userID, auth.TOTPMaxFailures, auth.TOTPLockout)
+	if err != nil {
+		return err
+	}
+	if !lockedUntil.IsZero() {
+		return auth.ErrTOTPLocked
+	}
+	return auth.ErrInvalidTOTPCode
+}
+
+// respondTOTPError answers a request whose TOTP code was rejected. Missing
+// and wrong codes get 401 with totp_required so clients know to prompt;
+// locked out users get 429.
+func respondTOTPError(c *gin.Context, err error) {
+	if errors.Is(err, auth.ErrTOTPLocked) {
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+			"error":         err.Error(),
+			"totp_required": true,
+		})
+		return
+	}
+	if errors.Is(err, auth.ErrTOTPRequired) || errors.Is(err, auth.ErrInvalidTOTPCode) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error":         err.Error(),
+			"totp_required": true,
+		})
+		return
+	}
+	c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to verify two-factor code: " + err.Error()})
+}
+
+// OrderTOTPMiddleware challenges orders of users who require two-factor
+// authentication for trading: without a valid code in the X-TOTP-Code
+// header the request is rejected before the order is placed. Must run after
+// AuthMiddleware.
+func OrderTOTPMiddleware(db *database.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := GetUserID(c)
+		if !exists {
+			c.Next()
+			return
+		}
+
+		totp, err := db.GetUserTOTP(userID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to get two-factor status: " + err.Error()})
+			return
+		}
+		if !totp.Enabled || !totp.ForOrders {
+			c.Next()
+			return
+		}
+
+		if err := verifyTOTP(db, userID, totp, c.GetHeader(TOTPCodeHeader)); err != nil {
+			respondTOTPError(c, err)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/algo"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// SetAlgoManager enables the TWAP and iceberg executions of /trade/algo
+func (a *API) SetAlgoManager(manager *algo.Manager) {
+	a.algos = manager
+}
+
+// StartAlgo works a large order as child slices: TWAP spreads equal slices
+// over a duration, iceberg shows one slice at a time. Limit slices go at
+// the last price, no worse than the order's price, and chase it every
+// reprice_seconds. Each slice is placed, risk checked and journaled like
+// any order, tagged with the execution ID.
+// POST /trade/algo
+func (a *API) StartAlgo(c *gin.Context) {
+	if !a.algosEnabled(c) {
+		return
+	}
+	brk, ok := a.algoBrokerFor(c)
+	if !ok {
+		return
+	}
+
+	var req algo.Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.LotSize == 0 && a.db != nil {
+		// F&O slices must be whole lots
+		exchange := strings.ToUpper(req.Exchange)
+		if exchange == "" {
+			exchange = "NSE"
+		}
+		if instrument, err := a.db.GetInstrument(exchange, strings.ToUpper(req.Symbol)); err == nil && instrument.LotSize > 1 {
+			req.LotSize = instrument.LotSize
+		}
+	}
+
+	userID, _ := GetUserID(c)
+	execution, err := a.algos.Submit(brk, userID, req)
+	if errors.Is(err, algo.ErrInvalidParams) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(orderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, execution)
+}
+
+// ListAlgos returns the user's executions, newest first, finished ones for
+// a day
+// GET /trade/algo
+func (a *API) ListAlgos(c *gin.Context) {
+	if !a.algosEnabled(c) {
+		return
+	}
+	userID, _ := GetUserID(c)
+	executions := a.algos.List(userID)
+	c.JSON(http.StatusOK, gin.H{
+		"count":      len(executions),
+		"executions": executions,
+	})
+}
+
+// GetAlgo returns an execution with its slices and consolidated fills
+// GET /trade/algo/:id
+func (a *API) GetAlgo(c *gin.Context) {
+	if !a.algosEnabled(c) {
+		return
+	}
+	execution, err := a.algos.Get(c.Param("id"))
+	if err != nil || !a.ownsExecution(c, execution) {
+		c.JSON(http.StatusNotFound, gin.H{"error": algo.ErrNotFound.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, execution)
+}
+
+// CancelAlgo stops an execution and cancels its open slices; it's
+// cancelling until the broker confirms them. Fills so far stand.
+// DELETE /trade/algo/:id
+func (a *API) CancelAlgo(c *gin.Context) {
+	if !a.algosEnabled(c) {
+		return
+	}
+	execution, err := a.algos.Get(c.Param("id"))
+	if err != nil || !a.ownsExecution(c, execution) {
+		c.JSON(http.StatusNotFound, gin.H{"error": algo.ErrNotFound.Error()})
+		return
+	}
+
+	execution, err = a.algos.Cancel(execution.ID)
+	if errors.Is(err, algo.ErrFinished) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, execution)
+}
+
+// algosEnabled responds 503 when no algo manager is set
+func (a *API) algosEnabled(c *gin.Context) bool {
+	if a.algos == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "algo executions are not enabled"})
+		return false
+	}
+	return true
+}
+
+// ownsExecution reports whether the execution belongs to the requesting
+// user; in single-user mode every execution does
+func (a *API) ownsExecution(c *gin.Context, execution *algo.Execution) bool {
+	userID, _ := GetUserID(c)
+	return userID == "" || execution.UserID == userID
+}
+
+// algoBrokerFor returns the user's broker for an execution. Executions
+// outlive the request, so the request's tracing is peeled off.
+func (a *API) algoBrokerFor(c *gin.Context) (broker.Broker, bool) {
+	brk, ok := a.brokerFor(c)
+	if !ok {
+		return nil, false
+	}
+	if traced, ok := brk.(interface{ Unwrap() broker.Broker }); ok {
+		return traced.Unwrap(), true
+	}
+	return brk, true
+}
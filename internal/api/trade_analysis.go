@@ -0,0 +1,247 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/analyzer"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// analyzeSymbol runs the 52-day analysis on a symbol's daily history and
+// stores the result and its signals. A failed save is logged, not
+// returned, so callers still get the analysis.
+func (a *API) analyzeSymbol(exchange, symbol string) (*analyzer.Analysis, error) {
+	history, err := a.historicalService.Get52DayHistoricalData(exchange, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch history: %w", err)
+	}
+
+	candles := make([]broker.Candle, len(history))
+	for i, hc := range history {
+		candles[i] = broker.Candle{
+			Date:   hc.CandleTimestamp,
+			Open:   hc.Open,
+			High:   hc.High,
+			Low:    hc.Low,
+			Close:  hc.Close,
+			Volume: hc.Volume,
+		}
+	}
+
+	analysis, err := a.analyzer.Analyze(symbol, candles)
+	if err != nil {
+		return nil, fmt.Errorf("analysis failed: %w", err)
+	}
+	a.evaluateConfluence(analysis, exchange, symbol, candles)
+
+	analysisID, err := a.db.SaveAnalysis(analysis)
+	if err != nil {
+		a.logger.Warnf("⚠️  Failed to store analysis for %s: %v", symbol, err)
+	}
+
+	// Track the signals from the last close for GET /signals/performance
+	lastClose := candles[len(candles)-1].Close
+	tracked := make([]analyzer.Signal, len(analysis.Signals))
+	for i, signal := range analysis.Signals {
+		tracked[i] = analyzer.RebaseSignal(signal, lastClose)
+	}
+	now := time.Now()
+	if err := a.db.SaveSignals(analysisID, exchange, symbol, tracked, now, now.Add(database.DefaultSignalExpiry)); err != nil {
+		a.logger.Warnf("⚠️  Failed to store signals for %s: %v", symbol, err)
+	}
+	return analysis, nil
+}
+
+// confluenceBars is how many recent bars of each intraday timeframe the
+// confluence rules read
+const confluenceBars = 200
+
+// evaluateConfluence checks the configured confluence rules against the
+// daily candles and the collector's intraday bars of a symbol, adding a
+// signal for each rule met
+func (a *API) evaluateConfluence(analysis *analyzer.Analysis, exchange, symbol string, daily []broker.Candle) {
+	if len(a.confluenceRules) == 0 {
+		return
+	}
+
+	candles := map[string][]broker.Candle{"day": daily}
+	for _, rule := range a.confluenceRules {
+		for _, tf := range rule.Timeframes() {
+			if _, ok := candles[tf]; ok {
+				continue
+			}
+			bars, err := a.db.GetRecentIntradayBars(exchange, symbol, tf, confluenceBars)
+			if err != nil {
+				a.logger.Warnf("⚠️  Failed to fetch %s bars of %s for confluence: %v", tf, symbol, err)
+			}
+			candles[tf] = intradayCandles(bars)
+		}
+	}
+
+	for _, rule := range a.confluenceRules {
+		result := analyzer.EvaluateConfluence(rule, candles)
+		analysis.Confluence = append(analysis.Confluence, result)
+		if result.Signal != nil {
+			analysis.Signals = append(analysis.Signals, *result.Signal)
+		}
+	}
+}
+
+// intradayCandles converts stored bars to candles
+func intradayCandles(bars []database.IntradayBar) []broker.Candle {
+	candles := make([]broker.Candle, len(bars))
+	for i, b := range bars {
+		candles[i] = broker.Candle{
+			Date:   b.BarTimestamp,
+			Open:   b.Open,
+			High:   b.High,
+			Low:    b.Low,
+			Close:  b.Close,
+			Volume: b.Volume,
+		}
+	}
+	return candles
+}
+
+// AnalyzeSymbols runs the 52-day analysis on each symbol and stores the
+// results for GET /trade/analysis
+// POST /trade/analyze
+func (a *API) AnalyzeSymbols(c *gin.Context) {
+	var req struct {
+		Symbols  []string `json:"symbols" binding:"required,min=1"`
+		Exchange string   `json:"exchange"` // Default NSE
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	exchange := strings.ToUpper(req.Exchange)
+	if exchange == "" {
+		exchange = "NSE"
+	}
+
+	results := []*analyzer.Analysis{}
+	errs := make(map[string]string)
+	totalSignals := 0
+	for _, raw := range req.Symbols {
+		symbol := strings.ToUpper(strings.TrimSpace(raw))
+		if symbol == "" {
+			continue
+		}
+
+		analysis, err := a.analyzeSymbol(exchange, symbol)
+		if err != nil {
+			errs[symbol] = err.Error()
+			continue
+		}
+		results = append(results, analysis)
+		totalSignals += len(analysis.Signals)
+	}
+
+	response := gin.H{
+		"analyzed":      len(results),
+		"total_signals": totalSignals,
+		"results":       results,
+	}
+	if len(errs) > 0 {
+		response["errors"] = errs
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetAnalysisHistory returns a symbol's stored analyses, oldest first, so
+// clients can chart how its trend, indicators and signals evolved. Dates
+// are IST days, both inclusive; full=true includes the complete analyzer
+// output of each run.
+// GET /trade/analysis/:symbol?from=2024-01-01&to=2024-01-31&limit=100&full=true
+func (a *API) GetAnalysisHistory(c *gin.Context) {
+	filter, ok := analysisFilter(c, 100, 1000)
+	if !ok {
+		return
+	}
+
+	symbol := strings.ToUpper(c.Param("symbol"))
+	records, err := a.db.GetAnalysisHistory(symbol, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch analysis history: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"symbol":   symbol,
+		"count":    len(records),
+		"analyses": records,
+	})
+}
+
+// GetLatestAnalyses returns the most recent stored analysis of each symbol,
+// newest first, optionally limited to some symbols and a date range
+// GET /trade/analysis/latest?symbols=RELIANCE,TCS&from=2024-01-01&to=2024-01-31&full=true
+func (a *API) GetLatestAnalyses(c *gin.Context) {
+	filter, ok := analysisFilter(c, 500, 5000)
+	if !ok {
+		return
+	}
+
+	for _, symbol := range strings.Split(c.Query("symbols"), ",") {
+		if symbol = strings.ToUpper(strings.TrimSpace(symbol)); symbol != "" {
+			filter.Symbols = append(filter.Symbols, symbol)
+		}
+	}
+
+	records, err := a.db.GetLatestAnalyses(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch latest analyses: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":    len(records),
+		"analyses": records,
+	})
+}
+
+// analysisFilter reads the from, to, limit and full query parameters,
+// writing the error response if they're invalid
+func analysisFilter(c *gin.Context, defaultLimit, maxLimit int) (database.AnalysisFilter, bool) {
+	filter := database.AnalysisFilter{
+		Full: c.Query("full") == "true",
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
+	if err != nil || limit <= 0 || limit > maxLimit {
+		limit = defaultLimit
+	}
+	filter.Limit = limit
+
+	ist := broker.IST()
+	if from := c.Query("from"); from != "" {
+		filter.From, err = time.ParseInLocation("2006-01-02", from, ist)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from date (use YYYY-MM-DD)"})
+			return filter, false
+		}
+	}
+	if to := c.Query("to"); to != "" {
+		toDate, err := time.ParseInLocation("2006-01-02", to, ist)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to date (use YYYY-MM-DD)"})
+			return filter, false
+		}
+		filter.To = toDate.AddDate(0, 0, 1)
+	}
+
+	return filter, true
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/basket"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// basketRequest is the body of POST /trade/basket
+type basketRequest struct {
+	Legs      []basket.Leg `json:"legs"`
+	OnFailure string       `json:"on_failure"` // rollback (default), stop or continue
+	DryRun    bool         `json:"dry_run"`    // Check the margin and plan without placing
+}
+
+// PlaceBasket places several orders together, e.g. the legs of a hedged
+// option spread. The margin of the whole basket, hedges included, must be
+// covered by the available margin; legs are then placed in dependency
+// order, buys first, and by default the placed legs are cancelled if a
+// later one fails. Responds 200 when every leg was placed, 207 with the
+// status of each leg otherwise.
+// POST /trade/basket
+func (a *API) PlaceBasket(c *gin.Context) {
+	brk, ok := a.brokerFor(c)
+	if !ok {
+		return
+	}
+
+	var req basketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	onFailure, err := basket.ParseOnFailure(req.OnFailure)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := basket.Normalize(req.Legs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	plan, err := basket.Plan(req.Legs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	orders := make([]broker.OrderRequest, len(req.Legs))
+	for i, leg := range req.Legs {
+		orders[i] = leg.OrderRequest
+	}
+	check, err := a.marginEngine().CheckMargin(brk, orders)
+	if err != nil {
+		c.JSON(marginErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if !check.Sufficient {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":  fmt.Sprintf("basket needs ₹%.2f of margin and charges, ₹%.2f is available", check.Required, check.Available),
+			"margin": check,
+		})
+		return
+	}
+
+	if req.DryRun {
+		ids := make([]string, len(plan))
+		for i, leg := range plan {
+			ids[i] = req.Legs[leg].ID
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status": "dry_run",
+			"plan":   ids,
+			"margin": check,
+		})
+		return
+	}
+
+	id, err := newBasketID()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	result, err := basket.Execute(brk, id, req.Legs, onFailure)
+	if errors.Is(err, basket.ErrInvalidBasket) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := http.StatusOK
+	if result.Status != basket.StatusComplete {
+		RequestLog(c).Warnf("Basket %s %s", result.ID, result.Status)
+		status = http.StatusMultiStatus
+	}
+
+	c.JSON(status, gin.H{
+		"basket_id": result.ID,
+		"status":    result.Status,
+		"legs":      result.Legs,
+		"margin":    check,
+	})
+}
+
+// newBasketID returns a random basket ID, short enough to tag orders with
+func newBasketID() (string, error) {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "basket-" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// ListGTTs returns the account's GTTs, active and recently finished
+// GET /trade/gtt
+func (a *API) ListGTTs(c *gin.Context) {
+	gtts, ok := a.gttManagerFor(c)
+	if !ok {
+		return
+	}
+
+	list, err := gtts.GetGTTs()
+	if err != nil {
+		c.JSON(brokerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(list),
+		"gtts":  list,
+	})
+}
+
+// PlaceGTT places a good-till-triggered order: the broker holds the trigger
+// and places the leg's limit order when the last price crosses it, for up
+// to a year, whether or not this server is running. Two legs make an OCO,
+// the lower trigger first; when one triggers the other is cancelled.
+// POST /trade/gtt
+func (a *API) PlaceGTT(c *gin.Context) {
+	gtts, ok := a.gttManagerFor(c)
+	if !ok {
+		return
+	}
+
+	var req broker.GTTRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	triggerID, err := gtts.PlaceGTT(&req)
+	if err != nil {
+		c.JSON(gttErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"trigger_id": triggerID,
+		"status":     "placed",
+	})
+}
+
+// GetGTT returns a GTT
+// GET /trade/gtt/:id
+func (a *API) GetGTT(c *gin.Context) {
+	gtts, ok := a.gttManagerFor(c)
+	if !ok {
+		return
+	}
+	triggerID, ok := gttID(c)
+	if !ok {
+		return
+	}
+
+	gtt, err := gtts.GetGTT(triggerID)
+	if err != nil {
+		c.JSON(brokerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gtt)
+}
+
+// ModifyGTT replaces the triggers and orders of an active GTT
+// PUT /trade/gtt/:id
+func (a *API) ModifyGTT(c *gin.Context) {
+	gtts, ok := a.gttManagerFor(c)
+	if !ok {
+		return
+	}
+	triggerID, ok := gttID(c)
+	if !ok {
+		return
+	}
+
+	var req broker.GTTRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := gtts.ModifyGTT(triggerID, &req); err != nil {
+		c.JSON(gttErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"trigger_id": triggerID,
+		"status":     "modified",
+	})
+}
+
+// DeleteGTT deletes a GTT
+// DELETE /trade/gtt/:id
+func (a *API) DeleteGTT(c *gin.Context) {
+	gtts, ok := a.gttManagerFor(c)
+	if !ok {
+		return
+	}
+	triggerID, ok := gttID(c)
+	if !ok {
+		return
+	}
+
+	if err := gtts.DeleteGTT(triggerID); err != nil {
+		c.JSON(brokerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"trigger_id": triggerID,
+		"status":     "deleted",
+	})
+}
+
+// gttManagerFor returns the user's broker's GTTs, responding 501 when the
+// broker has none
+func (a *API) gttManagerFor(c *gin.Context) (broker.GTTManager, bool) {
+	brk, ok := a.brokerFor(c)
+	if !ok {
+		return nil, false
+	}
+	gtts, err := broker.GTTs(brk)
+	if err != nil {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return gtts, true
+}
+
+// gttID parses the trigger ID in the path, responding 400 when it isn't
+// one
+func gttID(c *gin.Context) (int, bool) {
+	triggerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || triggerID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid GTT trigger id " + strconv.Quote(c.Param("id"))})
+		return 0, false
+	}
+	return triggerID, true
+}
+
+// gttErrorStatus maps GTT placement errors to HTTP status codes: 400 when
+// the broker can't price the triggers, e.g. for an unknown symbol
+func gttErrorStatus(err error) int {
+	if errors.Is(err, broker.ErrInvalidSymbol) {
+		return http.StatusBadRequest
+	}
+	return brokerErrorStatus(err)
+}
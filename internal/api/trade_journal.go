@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/journal"
+)
+
+// GetTradeJournal returns the order audit trail with realized P&L per
+// symbol and strategy tag. Dates are IST trading days, both inclusive.
+// GET /trade/journal?symbol=RELIANCE&strategy=orb&event=UPDATE&from=2024-01-01&to=2024-01-31&limit=500
+func (a *API) GetTradeJournal(c *gin.Context) {
+	filter := database.ExecutionFilter{
+		Symbol:   strings.ToUpper(c.Query("symbol")),
+		Strategy: c.Query("strategy"),
+		Event:    strings.ToUpper(c.Query("event")),
+	}
+	filter.UserID, _ = GetUserID(c)
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "500"))
+	if err != nil || limit <= 0 || limit > 5000 {
+		limit = 500
+	}
+	filter.Limit = limit
+
+	ist := broker.IST()
+	if from := c.Query("from"); from != "" {
+		filter.From, err = time.ParseInLocation("2006-01-02", from, ist)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from date (use YYYY-MM-DD)"})
+			return
+		}
+	}
+	if to := c.Query("to"); to != "" {
+		toDate, err := time.ParseInLocation("2006-01-02", to, ist)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to date (use YYYY-MM-DD)"})
+			return
+		}
+		filter.To = toDate.AddDate(0, 0, 1)
+	}
+
+	executions, err := a.db.GetExecutions(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch trade journal: " + err.Error(),
+		})
+		return
+	}
+
+	summary := journal.Summarize(executions)
+	var realized float64
+	for _, s := range summary {
+		realized += s.RealizedPnL
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":        len(executions),
+		"executions":   executions,
+		"summary":      summary,
+		"realized_pnl": realized,
+	})
+}
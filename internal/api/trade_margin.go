@@ -0,0 +1,94 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/charges"
+	"github.com/trading-chitti/market-bridge/internal/risk"
+)
+
+// CheckMargin estimates the margin a proposed order would block, from the
+// broker's margin calculator or approximately, with its brokerage, STT,
+// exchange charges, SEBI fees, stamp duty and GST, and whether the
+// account's available margin covers both. Nothing is placed.
+// POST /trade/margin-check
+func (a *API) CheckMargin(c *gin.Context) {
+	brk, ok := a.brokerFor(c)
+	if !ok {
+		return
+	}
+
+	var order broker.OrderRequest
+	if err := c.ShouldBindJSON(&order); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateMarginOrder(&order); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	engine := a.marginEngine()
+	check, err := engine.CheckMargin(brk, []broker.OrderRequest{order})
+	if err != nil {
+		c.JSON(marginErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"order":           check.Orders[0],
+		"source":          check.Source,
+		"required_margin": check.RequiredMargin,
+		"charges":         check.Charges,
+		"required":        check.Required,
+		"available":       check.Available,
+		"sufficient":      check.Sufficient,
+		"shortfall":       check.Shortfall,
+		"enforced":        engine.RequireMargin(),
+	})
+}
+
+// marginEngine returns the risk engine margins are checked with
+func (a *API) marginEngine() *risk.Engine {
+	if a.riskEngine != nil {
+		return a.riskEngine
+	}
+	engine := risk.NewEngine(risk.Limits{})
+	engine.SetInstrumentLookup(a.db.GetInstrument)
+	return engine
+}
+
+// validateMarginOrder checks that an order names what its margin and
+// charges depend on
+func validateMarginOrder(order *broker.OrderRequest) error {
+	if order.Symbol == "" {
+		return errors.New("symbol is required")
+	}
+	if order.Quantity <= 0 {
+		return errors.New("quantity must be positive")
+	}
+	switch strings.ToUpper(order.TransactionType) {
+	case "BUY", "SELL":
+	default:
+		return errors.New("transaction_type must be BUY or SELL")
+	}
+	if order.Product == "" {
+		return errors.New("product is required (CNC, MIS or NRML)")
+	}
+	if order.OrderType == "" {
+		order.OrderType = "MARKET"
+	}
+	return nil
+}
+
+// marginErrorStatus maps margin check errors to HTTP status codes
+func marginErrorStatus(err error) int {
+	if errors.Is(err, charges.ErrUnsupportedSegment) || errors.Is(err, risk.ErrNoMarginEstimate) {
+		return http.StatusUnprocessableEntity
+	}
+	return brokerErrorStatus(err)
+}
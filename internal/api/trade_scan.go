@@ -0,0 +1,320 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/analyzer"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/risk"
+)
+
+// DefaultRiskPerTrade is the % of capital risked per scanned trade when no
+// MaxRiskPerTrade limit is set
+const DefaultRiskPerTrade = 1.0
+
+// scanOrderTag tags orders placed by the scanner
+const scanOrderTag = "scan"
+
+// ScanConfig holds the defaults for POST /trade/scan
+type ScanConfig struct {
+	MinConfidence float64 // Signals below this are ignored
+	DryRun        bool    // Return the would-be orders instead of placing them
+}
+
+// DefaultScanConfig returns the scan defaults: confidence 0.75, dry run on
+func DefaultScanConfig() ScanConfig {
+	return ScanConfig{
+		MinConfidence: 0.75,
+		DryRun:        true,
+	}
+}
+
+// SetScanConfig sets the defaults for trade scans
+func (a *API) SetScanConfig(config ScanConfig) {
+	a.scanConfig = config
+}
+
+// SetConfluenceRules sets the multi-timeframe rules every analysis
+// evaluates, adding a signal for each rule met. Without rules only the
+// daily analysis runs.
+func (a *API) SetConfluenceRules(rules []analyzer.ConfluenceRule) {
+	a.confluenceRules = rules
+}
+
+// SetRiskEngine sets the risk engine whose MaxRiskPerTrade sizes scanned
+// trades. Dry runs also report the orders it would reject. In multi-user
+// mode each user's account's engine is used instead.
+func (a *API) SetRiskEngine(engine *risk.Engine) {
+	a.riskEngine = engine
+}
+
+// ScanRequest selects the symbols to scan and how to trade them
+type ScanRequest struct {
+	Symbols       []string `json:"symbols" binding:"required,min=1"`
+	Exchange      string   `json:"exchange"`       // Default NSE
+	Product       string   `json:"product"`        // Default CNC; SELL signals need MIS
+	MinConfidence float64  `json:"min_confidence"` // Default MIN_CONFIDENCE
+	RiskPerTrade  float64  `json:"risk_per_trade"` // % of capital; at most MAX_RISK_PER_TRADE
+	DryRun        *bool    `json:"dry_run"`        // Default DRY_RUN
+}
+
+// Scan result statuses
+const (
+	ScanNoSignal = "no_signal" // No signal at or above the confidence threshold
+	ScanSkipped  = "skipped"   // Signal found but not tradable (see reason)
+	ScanDryRun   = "dry_run"   // Order would be placed
+	ScanPlaced   = "placed"    // Order placed
+	ScanFailed   = "failed"    // Analysis or placement failed (see reason)
+)
+
+// ScanResult is the outcome of scanning one symbol
+type ScanResult struct {
+	Symbol  string               `json:"symbol"`
+	Status  string               `json:"status"`
+	Signal  *analyzer.Signal     `json:"signal,omitempty"`
+	Order   *broker.OrderRequest `json:"order,omitempty"`
+	OrderID string               `json:"order_id,omitempty"`
+	Price   float64              `json:"price,omitempty"` // Price the order was sized at
+	Risk    float64              `json:"risk,omitempty"`  // Loss if the stop is hit
+	Reason  string               `json:"reason,omitempty"`
+}
+
+// ScanAndTrade analyzes the symbols, keeps the strongest signal per symbol
+// at or above the confidence threshold, sizes each trade so that hitting
+// the stop loses at most the per-trade risk (and the order fits in the
+// available margin), then places the orders or, in dry run, returns them.
+// POST /trade/scan
+func (a *API) ScanAndTrade(c *gin.Context) {
+	var req ScanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	exchange := strings.ToUpper(req.Exchange)
+	if exchange == "" {
+		exchange = "NSE"
+	}
+	product := strings.ToUpper(req.Product)
+	if product == "" {
+		product = "CNC"
+	}
+	minConfidence := req.MinConfidence
+	if minConfidence <= 0 {
+		minConfidence = a.scanConfig.MinConfidence
+	}
+	if minConfidence > 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "min_confidence must be between 0 and 1"})
+		return
+	}
+	dryRun := a.scanConfig.DryRun
+	if req.DryRun != nil {
+		dryRun = *req.DryRun
+	}
+
+	brk, ok := a.brokerFor(c)
+	if !ok {
+		return
+	}
+	engine, ok := a.riskEngineFor(c)
+	if !ok {
+		return
+	}
+
+	riskPct, err := scanRiskPerTrade(engine, req.RiskPerTrade)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	margins, err := brk.GetMargins()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get margins: " + err.Error()})
+		return
+	}
+	positions, err := brk.GetPositions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get positions: " + err.Error()})
+		return
+	}
+	held := make(map[string]bool)
+	for _, p := range positions.Net {
+		if p.Quantity != 0 {
+			held[p.Exchange+":"+p.Symbol] = true
+		}
+	}
+
+	capital := margins.Equity.Net
+	available := margins.Equity.Available
+	riskBudget := capital * riskPct / 100
+
+	results := make([]ScanResult, 0, len(req.Symbols))
+	orders := 0
+	for _, raw := range req.Symbols {
+		symbol := strings.ToUpper(strings.TrimSpace(raw))
+		if symbol == "" {
+			continue
+		}
+
+		result := a.scanSymbol(brk, exchange, symbol, product, minConfidence, riskBudget, available)
+		if result.Order != nil && held[exchange+":"+symbol] {
+			result.Status = ScanSkipped
+			result.Reason = "position already open"
+		}
+
+		if result.Status == ScanDryRun {
+			if dryRun {
+				if engine != nil {
+					if err := engine.Check(brk, result.Order); err != nil {
+						result.Status = ScanSkipped
+						result.Reason = err.Error()
+					}
+				}
+			} else {
+				orderID, err := brk.PlaceOrder(result.Order)
+				result.OrderID = orderID
+				if err != nil && orderID == "" {
+					result.Status = ScanFailed
+					result.Reason = err.Error()
+				} else {
+					result.Status = ScanPlaced
+					if err != nil {
+						// The entry went through but its exit legs didn't
+						result.Reason = err.Error()
+					}
+				}
+			}
+		}
+
+		if result.Status == ScanDryRun || result.Status == ScanPlaced {
+			orders++
+			available -= float64(result.Order.Quantity) * result.Price
+			RequestLog(c).Infof("📈 Scan %s: %s %d %s:%s (confidence %.2f)", result.Status,
+				result.Order.TransactionType, result.Order.Quantity, exchange, symbol, result.Signal.Confidence)
+		}
+		results = append(results, result)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dry_run":        dryRun,
+		"exchange":       exchange,
+		"product":        product,
+		"min_confidence": minConfidence,
+		"risk_per_trade": riskPct,
+		"capital":        capital,
+		"available":      margins.Equity.Available,
+		"orders":         orders,
+		"results":        results,
+	})
+}
+
+// scanRiskPerTrade returns the % of capital to risk per trade: the
+// requested value, which can't exceed the engine's MaxRiskPerTrade, or the
+// limit itself
+func scanRiskPerTrade(engine *risk.Engine, requested float64) (float64, error) {
+	limit := 0.0
+	if engine != nil {
+		limit = engine.Limits().MaxRiskPerTrade
+	}
+
+	if requested < 0 || requested > 100 {
+		return 0, fmt.Errorf("risk_per_trade must be between 0 and 100")
+	}
+	if requested > 0 {
+		if limit > 0 && requested > limit {
+			return 0, fmt.Errorf("risk_per_trade %.2f%% exceeds the limit of %.2f%%", requested, limit)
+		}
+		return requested, nil
+	}
+	if limit > 0 {
+		return limit, nil
+	}
+	return DefaultRiskPerTrade, nil
+}
+
+// scanSymbol analyzes one symbol and builds the order for its strongest
+// signal. A tradable result has status ScanDryRun; the caller places it.
+func (a *API) scanSymbol(brk broker.Broker, exchange, symbol, product string, minConfidence, riskBudget, available float64) ScanResult {
+	result := ScanResult{Symbol: symbol}
+	fail := func(status, reason string) ScanResult {
+		result.Status = status
+		result.Reason = reason
+		return result
+	}
+
+	analysis, err := a.analyzeSymbol(exchange, symbol)
+	if err != nil {
+		return fail(ScanFailed, err.Error())
+	}
+
+	signals := make([]analyzer.Signal, 0, len(analysis.Signals))
+	for _, s := range analysis.Signals {
+		if s.Confidence >= minConfidence && s.EntryPrice > 0 && s.StopLoss > 0 {
+			signals = append(signals, s)
+		}
+	}
+	if len(signals) == 0 {
+		return fail(ScanNoSignal, "")
+	}
+	sort.SliceStable(signals, func(i, j int) bool {
+		return signals[i].Confidence > signals[j].Confidence
+	})
+
+	signal := signals[0]
+	result.Signal = &signal
+	if signal.Type == "SELL" && product != "MIS" {
+		return fail(ScanSkipped, "SELL signals need product MIS")
+	}
+
+	key := exchange + ":" + symbol
+	ltp, err := brk.GetLTP([]string{key})
+	if err != nil {
+		return fail(ScanFailed, "failed to get price: "+err.Error())
+	}
+	price := ltp[key]
+	if price <= 0 {
+		return fail(ScanFailed, "no price available for "+key)
+	}
+
+	// Signal levels are relative to the analyzer's reference price; keep
+	// their distance and apply it to the current price
+	stopLoss := broker.RoundTick(price * signal.StopLoss / signal.EntryPrice)
+	target := 0.0
+	if signal.TakeProfit > 0 {
+		target = broker.RoundTick(price * signal.TakeProfit / signal.EntryPrice)
+	}
+	perShare := math.Abs(price - stopLoss)
+	if perShare == 0 {
+		return fail(ScanSkipped, "stop loss is at the current price")
+	}
+
+	quantity := int(riskBudget / perShare)
+	if affordable := int(available / price); affordable < quantity {
+		quantity = affordable
+	}
+	if quantity <= 0 {
+		return fail(ScanSkipped, fmt.Sprintf("insufficient capital for one share at %.2f", price))
+	}
+
+	result.Status = ScanDryRun
+	result.Price = price
+	result.Risk = math.Round(float64(quantity)*perShare*100) / 100
+	result.Order = &broker.OrderRequest{
+		Symbol:          symbol,
+		Exchange:        exchange,
+		TransactionType: signal.Type,
+		OrderType:       "MARKET",
+		Product:         product,
+		Quantity:        quantity,
+		Validity:        "DAY",
+		Tag:             scanOrderTag,
+		StopLoss:        stopLoss,
+		Target:          target,
+	}
+	return result
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/trading-chitti/market-bridge/internal/risk"
+)
+
+func TestScanRiskPerTrade(t *testing.T) {
+	tests := []struct {
+		name      string
+		engine    *risk.Engine
+		requested float64
+		want      float64
+		wantErr   bool
+	}{
+		{"no engine", nil, 0, DefaultRiskPerTrade, false},
+		{"no engine requested", nil, 3, 3, false},
+		{"no limit", risk.NewEngine(risk.Limits{MaxPositions: 5}), 0, DefaultRiskPerTrade, false},
+		{"account limit", risk.NewEngine(risk.Limits{MaxRiskPerTrade: 0.5}), 0, 0.5, false},
+		{"within account limit", risk.NewEngine(risk.Limits{MaxRiskPerTrade: 2}), 1.5, 1.5, false},
+		{"over account limit", risk.NewEngine(risk.Limits{MaxRiskPerTrade: 0.5}), 1, 0, true},
+		{"out of range", nil, 101, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := scanRiskPerTrade(tt.engine, tt.requested)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("scanRiskPerTrade error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("scanRiskPerTrade = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
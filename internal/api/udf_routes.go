@@ -0,0 +1,408 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/timeframe"
+)
+
+// udfResolutions are the chart resolutions /tv/udf serves: stored and
+// resampled intraday bars in minutes, and daily candles
+var udfResolutions = []string{"1", "3", "5", "10", "15", "30", "60", "120", "240", "1D"}
+
+// udfExchanges are the exchanges offered in the symbol search
+var udfExchanges = []string{"NSE", "BSE", "NFO", "BFO", "MCX", "CDS"}
+
+// udfInstrumentTypes maps the UDF symbol types found in the instruments
+// table to the instrument type searched for; options are CE or PE, so any
+// type is searched and they are picked out after
+var udfInstrumentTypes = map[string]string{
+	"":        "",
+	"stock":   "EQ",
+	"futures": "FUT",
+	"option":  "",
+}
+
+// udfSessions are the IST trading hours of exchanges other than the
+// 09:15-15:30 equity and F&O session
+var udfSessions = map[string]string{
+	"MCX": "0900-2330",
+	"CDS": "0900-1700",
+}
+
+// UDFHandler implements the TradingView UDF datafeed protocol, so the
+// TradingView charting library's UDFCompatibleDatafeed can chart stored
+// intraday bars and historical candles directly
+type UDFHandler struct {
+	db         *database.Database
+	historical *database.HistoricalDataService
+}
+
+// NewUDFHandler creates a new UDF handler
+func NewUDFHandler(brk broker.Broker, db *database.Database) *UDFHandler {
+	return &UDFHandler{
+		db:         db,
+		historical: database.NewHistoricalDataService(db, brk),
+	}
+}
+
+// RegisterRoutes registers the UDF routes; point the datafeed at /tv/udf
+func (h *UDFHandler) RegisterRoutes(r *gin.RouterGroup) {
+	udf := r.Group("/tv/udf")
+	{
+		udf.GET("/config", h.GetConfig)
+		udf.GET("/time", h.GetTime)
+		udf.GET("/symbols", h.GetSymbol)
+		udf.GET("/search", h.SearchSymbols)
+		udf.GET("/history", h.GetHistory)
+	}
+}
+
+// GetConfig describes the datafeed's capabilities
+// GET /tv/udf/config
+func (h *UDFHandler) GetConfig(c *gin.Context) {
+	exchanges := []gin.H{{"value": "", "name": "All Exchanges", "desc": ""}}
+	for _, exchange := range udfExchanges {
+		exchanges = append(exchanges, gin.H{"value": exchange, "name": exchange, "desc": exchange})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"supported_resolutions":    udfResolutions,
+		"supports_search":          true,
+		"supports_group_request":   false,
+		"supports_marks":           false,
+		"supports_timescale_marks": false,
+		"supports_time":            true,
+		"exchanges":                exchanges,
+		"symbols_types": []gin.H{
+			{"name": "All types", "value": ""},
+			{"name": "Stock", "value": "stock"},
+			{"name": "Index", "value": "index"},
+			{"name": "Futures", "value": "futures"},
+			{"name": "Option", "value": "option"},
+		},
+	})
+}
+
+// GetTime returns the server time in Unix seconds, as plain text
+// GET /tv/udf/time
+func (h *UDFHandler) GetTime(c *gin.Context) {
+	c.String(http.StatusOK, strconv.FormatInt(time.Now().Unix(), 10))
+}
+
+// GetSymbol resolves a symbol, EXCHANGE:SYMBOL or an NSE symbol, to its
+// UDF symbol info
+// GET /tv/udf/symbols?symbol=NSE:RELIANCE
+func (h *UDFHandler) GetSymbol(c *gin.Context) {
+	exchange, symbol := splitTicker(c.Query("symbol"))
+
+	if index, ok := database.ResolveIndex(exchange, symbol); ok {
+		c.JSON(http.StatusOK, udfIndexInfo(index))
+		return
+	}
+
+	inst, err := h.db.GetInstrument(exchange, symbol)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"s":      "error",
+			"errmsg": "failed to fetch instrument",
+		})
+		return
+	}
+	if inst == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"s":      "error",
+			"errmsg": "unknown_symbol",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, udfSymbolInfo(*inst))
+}
+
+// SearchSymbols searches the indices and the instruments table. Options
+// are told apart after the search, so fewer than limit may be returned.
+// GET /tv/udf/search?query=REL&type=stock&exchange=NSE&limit=30
+func (h *UDFHandler) SearchSymbols(c *gin.Context) {
+	query := strings.ToUpper(strings.TrimSpace(c.Query("query")))
+	exchange := strings.ToUpper(c.Query("exchange"))
+	symbolType := c.Query("type")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "30"))
+	if err != nil || limit <= 0 || limit > 100 {
+		limit = 30
+	}
+
+	results := []gin.H{}
+	if symbolType == "" || symbolType == "index" {
+		for _, index := range database.Indices {
+			if exchange != "" && exchange != index.Exchange {
+				continue
+			}
+			if strings.Contains(index.Symbol, query) || containsAlias(index.Aliases, query) {
+				results = append(results, udfSearchResult(index.Exchange, index.Symbol, index.Symbol, "index"))
+			}
+		}
+	}
+
+	instrumentType, ok := udfInstrumentTypes[symbolType]
+	if ok && len(results) < limit {
+		page, err := h.db.SearchInstruments(database.InstrumentFilter{
+			Query:          query,
+			Exchange:       exchange,
+			InstrumentType: instrumentType,
+			Limit:          limit - len(results),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"s":      "error",
+				"errmsg": "failed to search instruments",
+			})
+			return
+		}
+		for _, inst := range page.Instruments {
+			kind := udfType(inst.InstrumentType)
+			if symbolType == "" || kind == symbolType {
+				results = append(results, udfSearchResult(inst.Exchange, inst.Tradingsymbol, inst.Name, kind))
+			}
+		}
+	}
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// GetHistory returns bars between from and to (Unix seconds) as UDF
+// arrays. Minute resolutions read stored 1m, 5m, 15m and 1h bars and
+// resample the others from 1m bars; 1D reads the cached daily candles.
+// With countback only the last that many bars are returned.
+// GET /tv/udf/history?symbol=NSE:RELIANCE&resolution=5&from=1706586300&to=1706608800&countback=300
+func (h *UDFHandler) GetHistory(c *gin.Context) {
+	exchange, symbol := splitTicker(c.Query("symbol"))
+
+	from, errFrom := strconv.ParseInt(c.Query("from"), 10, 64)
+	to, errTo := strconv.ParseInt(c.Query("to"), 10, 64)
+	if symbol == "" || errFrom != nil || errTo != nil || from > to {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"s":      "error",
+			"errmsg": "symbol, from and to (Unix seconds, from <= to) are required",
+		})
+		return
+	}
+	fromTime, toTime := time.Unix(from, 0), time.Unix(to, 0)
+
+	countback, err := strconv.Atoi(c.DefaultQuery("countback", "0"))
+	if err != nil || countback < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"s":      "error",
+			"errmsg": "countback must be a positive number",
+		})
+		return
+	}
+
+	tf, daily, err := udfTimeframe(c.Query("resolution"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"s":      "error",
+			"errmsg": err.Error(),
+		})
+		return
+	}
+
+	var arrays ohlcvArrays
+	if daily {
+		arrays, err = h.dailyHistory(exchange, symbol, fromTime, toTime)
+	} else {
+		arrays, err = h.intradayHistory(exchange, symbol, tf, fromTime, toTime)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"s":      "error",
+			"errmsg": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, arrays.last(countback).udf())
+}
+
+// intradayHistory reads stored bars of tf, resampling 1m bars if tf isn't
+// stored
+func (h *UDFHandler) intradayHistory(exchange, symbol, tf string, from, to time.Time) (ohlcvArrays, error) {
+	exchange, symbol = storedKey(exchange, symbol)
+
+	var bars []database.IntradayBar
+	var err error
+	if stored, parseErr := timeframe.ParseStored(tf); parseErr == nil {
+		bars, err = h.db.GetIntradayBars(exchange, symbol, stored.String(), from, to, 10000)
+	} else {
+		bars, err = h.db.GetResampledBars(exchange, symbol, tf, from, to, 10000)
+	}
+	if err != nil {
+		return ohlcvArrays{}, fmt.Errorf("failed to fetch bars: %w", err)
+	}
+	return barArrays(bars), nil
+}
+
+// dailyHistory reads daily candles through the historical cache, or the
+// stored 1d bars when the broker has none (e.g. unsynced instruments)
+func (h *UDFHandler) dailyHistory(exchange, symbol string, from, to time.Time) (ohlcvArrays, error) {
+	if index, ok := database.ResolveIndex(exchange, symbol); ok {
+		exchange, symbol = index.Exchange, index.Symbol
+	}
+
+	candles, err := h.historical.GetHistoricalData(exchange, symbol, timeframe.Day.Kite(), from, to)
+	if err == nil && len(candles) > 0 {
+		return candleArrays(candles), nil
+	}
+
+	stored, storedErr := h.intradayHistory(exchange, symbol, timeframe.Day.String(), from, to)
+	if err != nil && (storedErr != nil || len(stored.T) == 0) {
+		return ohlcvArrays{}, fmt.Errorf("failed to fetch daily candles: %w", err)
+	}
+	return stored, storedErr
+}
+
+// last returns the last n bars, all of them when n is 0
+func (a ohlcvArrays) last(n int) ohlcvArrays {
+	if n <= 0 || n >= len(a.T) {
+		return a
+	}
+	i := len(a.T) - n
+	return ohlcvArrays{T: a.T[i:], O: a.O[i:], H: a.H[i:], L: a.L[i:], C: a.C[i:], V: a.V[i:]}
+}
+
+// udfTimeframe converts a UDF resolution, minutes (5) or days (D, 1D), to
+// a timeframe name and whether it is daily
+func udfTimeframe(resolution string) (string, bool, error) {
+	switch strings.ToUpper(resolution) {
+	case "D", "1D":
+		return timeframe.Day.String(), true, nil
+	}
+
+	minutes, err := strconv.Atoi(resolution)
+	if err != nil || minutes <= 0 {
+		return "", false, fmt.Errorf("unsupported resolution %q, use minutes (e.g. 5) or 1D", resolution)
+	}
+	tf := strconv.Itoa(minutes) + "m"
+	if stored, err := timeframe.ParseStored(tf); err == nil {
+		return stored.String(), false, nil
+	}
+	if _, err := database.ParseResampleTimeframe(tf); err != nil {
+		return "", false, fmt.Errorf("unsupported resolution %q: %v", resolution, err)
+	}
+	return tf, false, nil
+}
+
+// splitTicker splits EXCHANGE:SYMBOL, taking a bare symbol to be on NSE
+func splitTicker(ticker string) (string, string) {
+	ticker = strings.ToUpper(strings.TrimSpace(ticker))
+	if exchange, symbol, ok := strings.Cut(ticker, ":"); ok {
+		return exchange, symbol
+	}
+	return "NSE", ticker
+}
+
+// udfType maps a Kite instrument type to a UDF symbol type
+func udfType(instrumentType string) string {
+	switch instrumentType {
+	case "FUT":
+		return "futures"
+	case "CE", "PE":
+		return "option"
+	default:
+		return "stock"
+	}
+}
+
+// udfPriceScale returns the pricescale and minmov of a tick size, e.g. 100
+// and 5 for 0.05. Instruments without one tick in paise.
+func udfPriceScale(tickSize float64) (int, int) {
+	if tickSize <= 0 {
+		tickSize = 0.05
+	}
+	scale := 1
+	for decimals := 0; decimals < 6 && math.Abs(tickSize*float64(scale)-math.Round(tickSize*float64(scale))) > 1e-9; decimals++ {
+		scale *= 10
+	}
+	return scale, int(math.Round(tickSize * float64(scale)))
+}
+
+// udfSymbolInfo returns the UDF symbol info of an instrument
+func udfSymbolInfo(inst database.Instrument) gin.H {
+	pricescale, minmov := udfPriceScale(inst.TickSize)
+	session, ok := udfSessions[inst.Exchange]
+	if !ok {
+		session = "0915-1530"
+	}
+	description := inst.Name
+	if description == "" {
+		description = inst.Tradingsymbol
+	}
+
+	return gin.H{
+		"name":                  inst.Tradingsymbol,
+		"ticker":                inst.Exchange + ":" + inst.Tradingsymbol,
+		"description":           description,
+		"type":                  udfType(inst.InstrumentType),
+		"exchange":              inst.Exchange,
+		"listed_exchange":       inst.Exchange,
+		"session":               session,
+		"timezone":              "Asia/Kolkata",
+		"minmov":                minmov,
+		"pricescale":            pricescale,
+		"has_intraday":          true,
+		"has_daily":             true,
+		"intraday_multipliers":  []string{"1", "5", "15", "60"},
+		"supported_resolutions": udfResolutions,
+		"volume_precision":      0,
+		"data_status":           "streaming",
+	}
+}
+
+// udfIndexInfo returns the UDF symbol info of an index, which has no volume
+func udfIndexInfo(index database.Index) gin.H {
+	info := udfSymbolInfo(database.Instrument{
+		Tradingsymbol: index.Symbol,
+		Name:          index.Symbol,
+		Exchange:      index.Exchange,
+	})
+	info["type"] = "index"
+	info["visible_plots_set"] = "ohlc"
+	return info
+}
+
+// udfSearchResult returns a symbol search result
+func udfSearchResult(exchange, symbol, description, kind string) gin.H {
+	if description == "" {
+		description = symbol
+	}
+	return gin.H{
+		"symbol":      symbol,
+		"full_name":   exchange + ":" + symbol,
+		"description": description,
+		"exchange":    exchange,
+		"ticker":      exchange + ":" + symbol,
+		"type":        kind,
+	}
+}
+
+// containsAlias reports whether an alias contains query
+func containsAlias(aliases []string, query string) bool {
+	for _, alias := range aliases {
+		if strings.Contains(alias, query) {
+			return true
+		}
+	}
+	return false
+}
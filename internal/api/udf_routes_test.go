@@ -0,0 +1,192 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUDFTimeframe(t *testing.T) {
+	tests := []struct {
+		resolution string
+		want       string
+		wantDaily  bool
+		wantErr    bool
+	}{
+		{resolution: "1", want: "1m"},
+		{resolution: "5", want: "5m"},
+		{resolution: "60", want: "1h"},
+		{resolution: "3", want: "3m"},
+		{resolution: "240", want: "240m"},
+		{resolution: "D", want: "1d", wantDaily: true},
+		{resolution: "1D", want: "1d", wantDaily: true},
+		{resolution: "400", wantErr: true}, // Longer than a session
+		{resolution: "W", wantErr: true},
+		{resolution: "0", wantErr: true},
+		{resolution: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.resolution, func(t *testing.T) {
+			got, daily, err := udfTimeframe(tt.resolution)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("udfTimeframe(%q) error = %v, wantErr %v", tt.resolution, err, tt.wantErr)
+			}
+			if got != tt.want || daily != tt.wantDaily {
+				t.Errorf("udfTimeframe(%q) = %q, %v, want %q, %v", tt.resolution, got, daily, tt.want, tt.wantDaily)
+			}
+		})
+	}
+}
+
+func TestSplitTicker(t *testing.T) {
+	tests := []struct {
+		ticker, wantExchange, wantSymbol string
+	}{
+		{"NSE:RELIANCE", "NSE", "RELIANCE"},
+		{"bse:tcs", "BSE", "TCS"},
+		{"INFY", "NSE", "INFY"},
+		{"NSE:NIFTY 50", "NSE", "NIFTY 50"},
+	}
+	for _, tt := range tests {
+		if exchange, symbol := splitTicker(tt.ticker); exchange != tt.wantExchange || symbol != tt.wantSymbol {
+			t.Errorf("splitTicker(%q) = %q, %q, want %q, %q", tt.ticker, exchange, symbol, tt.wantExchange, tt.wantSymbol)
+		}
+	}
+}
+
+func TestUDFPriceScale(t *testing.T) {
+	tests := []struct {
+		tickSize       float64
+		wantPriceScale int
+		wantMinMov     int
+	}{
+		{tickSize: 0.05, wantPriceScale: 100, wantMinMov: 5},
+		{tickSize: 0.01, wantPriceScale: 100, wantMinMov: 1},
+		{tickSize: 0.0025, wantPriceScale: 10000, wantMinMov: 25}, // Currency
+		{tickSize: 1, wantPriceScale: 1, wantMinMov: 1},
+		{tickSize: 0, wantPriceScale: 100, wantMinMov: 5},
+	}
+	for _, tt := range tests {
+		scale, minmov := udfPriceScale(tt.tickSize)
+		if scale != tt.wantPriceScale || minmov != tt.wantMinMov {
+			t.Errorf("udfPriceScale(%v) = %d, %d, want %d, %d", tt.tickSize, scale, minmov, tt.wantPriceScale, tt.wantMinMov)
+		}
+	}
+}
+
+func TestOHLCVArraysLast(t *testing.T) {
+	a := ohlcvArrays{
+		T: []int64{1, 2, 3},
+		O: []float64{10, 20, 30},
+		H: []float64{11, 21, 31},
+		L: []float64{9, 19, 29},
+		C: []float64{10.5, 20.5, 30.5},
+		V: []int64{100, 200, 300},
+	}
+
+	tests := []struct {
+		n     int
+		wantT []int64
+	}{
+		{n: 0, wantT: []int64{1, 2, 3}},
+		{n: 2, wantT: []int64{2, 3}},
+		{n: 5, wantT: []int64{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		got := a.last(tt.n)
+		if !reflect.DeepEqual(got.T, tt.wantT) || len(got.O) != len(tt.wantT) || len(got.V) != len(tt.wantT) {
+			t.Errorf("last(%d) = %+v, want times %v", tt.n, got, tt.wantT)
+		}
+	}
+}
+
+// TestUDFHandler covers the requests answered without the database
+func TestUDFHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	NewUDFHandler(nil, nil).RegisterRoutes(r.Group(""))
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		check      func(t *testing.T, body map[string]interface{})
+	}{
+		{
+			name:       "config",
+			path:       "/tv/udf/config",
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body map[string]interface{}) {
+				if body["supports_search"] != true || body["supports_time"] != true {
+					t.Errorf("config = %v, want search and time supported", body)
+				}
+				if resolutions, _ := body["supported_resolutions"].([]interface{}); len(resolutions) != len(udfResolutions) {
+					t.Errorf("supported_resolutions = %v, want %v", body["supported_resolutions"], udfResolutions)
+				}
+			},
+		},
+		{
+			name:       "index symbol",
+			path:       "/tv/udf/symbols?symbol=NSE:NIFTY%2050",
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body map[string]interface{}) {
+				if body["name"] != "NIFTY 50" || body["type"] != "index" || body["ticker"] != "NSE:NIFTY 50" {
+					t.Errorf("symbol info = %v, want the NIFTY 50 index", body)
+				}
+			},
+		},
+		{
+			name:       "index alias",
+			path:       "/tv/udf/symbols?symbol=INDEX:NIFTY",
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body map[string]interface{}) {
+				if body["name"] != "NIFTY 50" || body["type"] != "index" || body["timezone"] != "Asia/Kolkata" || body["session"] != "0915-1530" {
+					t.Errorf("symbol info = %v, want the NIFTY 50 index", body)
+				}
+			},
+		},
+		{
+			name:       "history without a range",
+			path:       "/tv/udf/history?symbol=NSE:RELIANCE&resolution=5",
+			wantStatus: http.StatusBadRequest,
+			check: func(t *testing.T, body map[string]interface{}) {
+				if body["s"] != "error" {
+					t.Errorf("body = %v, want UDF error", body)
+				}
+			},
+		},
+		{
+			name:       "history with an unknown resolution",
+			path:       "/tv/udf/history?symbol=NSE:RELIANCE&resolution=W&from=1706586300&to=1706608800",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "history with a reversed range",
+			path:       "/tv/udf/history?symbol=NSE:RELIANCE&resolution=5&from=1706608800&to=1706586300",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.check == nil {
+				return
+			}
+			var body map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("body is not JSON: %v", err)
+			}
+			tt.check(t, body)
+		})
+	}
+}
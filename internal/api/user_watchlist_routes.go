@@ -0,0 +1,443 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trading-chitti/market-bridge/internal/auth"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/watchlist"
+)
+
+// maxWatchlistSymbols caps the size of a user watchlist
+const maxWatchlistSymbols = 500
+
+// UserWatchlistHandler manages user-defined watchlists. The built-in index,
+// sector and movers lists are read-only and can seed new ones. In
+// single-user mode watchlists are shared; in multi-user mode each user sees
+// their own plus those shared with them.
+type UserWatchlistHandler struct {
+	db *database.Database
+}
+
+// NewUserWatchlistHandler creates a new user watchlist handler
+func NewUserWatchlistHandler(db *database.Database) *UserWatchlistHandler {
+	return &UserWatchlistHandler{db: db}
+}
+
+// RegisterRoutes registers user watchlist routes. Pass the auth middleware
+// in multi-user mode.
+func (h *UserWatchlistHandler) RegisterRoutes(r *gin.RouterGroup, middleware ...gin.HandlerFunc) {
+	group := r.Group("/watchlists")
+	group.Use(middleware...)
+	{
+		group.GET("", h.ListWatchlists)
+		group.POST("", h.CreateWatchlist)
+		group.GET("/:id", h.GetWatchlist)
+		group.PUT("/:id", h.UpdateWatchlist)
+		group.DELETE("/:id", h.DeleteWatchlist)
+		group.POST("/:id/symbols", h.AddSymbols)
+		group.DELETE("/:id/symbols/:symbol", h.RemoveSymbol)
+		group.POST("/:id/share", h.ShareWatchlist)
+		group.DELETE("/:id/share/:email", h.UnshareWatchlist)
+	}
+}
+
+// WatchlistRequest creates or replaces a user watchlist. From copies the
+// symbols (and exchange) of a built-in watchlist before Symbols are added.
+type WatchlistRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description"`
+	Exchange    string   `json:"exchange"`
+	Symbols     []string `json:"symbols"`
+	From        string   `json:"from"` // Built-in watchlist to seed from, e.g. NIFTY50
+}
+
+// WatchlistSymbolsRequest adds symbols to a watchlist
+type WatchlistSymbolsRequest struct {
+	Symbols []string `json:"symbols" binding:"required"`
+}
+
+// ShareWatchlistRequest shares a watchlist with another user
+type ShareWatchlistRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// normalizeSymbols upper-cases and trims symbols, dropping blanks and
+// duplicates while keeping their order
+func normalizeSymbols(symbols []string) []string {
+	seen := make(map[string]bool, len(symbols))
+	result := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if symbol == "" || seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+		result = append(result, symbol)
+	}
+	return result
+}
+
+// bindWatchlistRequest validates the request body into a record
+func bindWatchlistRequest(c *gin.Context) (*database.WatchlistRecord, bool) {
+	var req WatchlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: " + err.Error(),
+		})
+		return nil, false
+	}
+
+	record := &database.WatchlistRecord{
+		Name:        strings.ToUpper(strings.TrimSpace(req.Name)),
+		Description: strings.TrimSpace(req.Description),
+		Exchange:    strings.ToUpper(req.Exchange),
+	}
+	if record.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "name is required",
+		})
+		return nil, false
+	}
+	if watchlist.GetWatchlist(record.Name) != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": record.Name + " is a built-in watchlist name",
+		})
+		return nil, false
+	}
+
+	symbols := req.Symbols
+	if req.From != "" {
+		seed := watchlist.GetWatchlist(strings.ToUpper(req.From))
+		if seed == nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "watchlist not found: " + req.From,
+			})
+			return nil, false
+		}
+		symbols = append(append([]string{}, seed.Symbols...), req.Symbols...)
+		if record.Exchange == "" {
+			record.Exchange = seed.Exchange
+		}
+		if record.Description == "" {
+			record.Description = "Based on " + seed.Name
+		}
+	}
+	if record.Exchange == "" {
+		record.Exchange = "NSE"
+	}
+
+	record.Symbols = normalizeSymbols(symbols)
+	if len(record.Symbols) > maxWatchlistSymbols {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "a watchlist can hold at most 500 symbols",
+		})
+		return nil, false
+	}
+
+	return record, true
+}
+
+// ListWatchlists lists the built-in watchlists, the user's own and those
+// shared with them
+// GET /watchlists
+func (h *UserWatchlistHandler) ListWatchlists(c *gin.Context) {
+	userID := ownerID(c)
+
+	records, err := h.db.GetWatchlistRecords(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch watchlists: " + err.Error(),
+		})
+		return
+	}
+
+	shared := []database.WatchlistRecord{}
+	if userID != "" {
+		shared, err = h.db.GetSharedWatchlistRecords(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to fetch shared watchlists: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"builtin":    watchlist.GetAllWatchlists(),
+		"watchlists": records,
+		"shared":     shared,
+		"count":      len(records),
+	})
+}
+
+// CreateWatchlist stores a new watchlist
+// POST /watchlists
+// Body: {"name": "MY_BANKS", "from": "BANKNIFTY", "symbols": ["BAJFINANCE"]}
+func (h *UserWatchlistHandler) CreateWatchlist(c *gin.Context) {
+	record, ok := bindWatchlistRequest(c)
+	if !ok {
+		return
+	}
+
+	err := h.db.CreateWatchlist(ownerID(c), record)
+	if errors.Is(err, database.ErrWatchlistExists) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to create watchlist: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, record)
+}
+
+// GetWatchlist returns a user watchlist by ID, or a built-in one by name
+// GET /watchlists/:id
+func (h *UserWatchlistHandler) GetWatchlist(c *gin.Context) {
+	if builtin := watchlist.GetWatchlist(strings.ToUpper(c.Param("id"))); builtin != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"watchlist": builtin,
+			"read_only": true,
+		})
+		return
+	}
+
+	record, err := h.db.GetWatchlistRecord(ownerID(c), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch watchlist: " + err.Error(),
+		})
+		return
+	}
+	if record == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "watchlist not found",
+		})
+		return
+	}
+
+	userID := ownerID(c)
+	c.JSON(http.StatusOK, gin.H{
+		"watchlist": record,
+		"read_only": userID != "" && (record.UserID == nil || *record.UserID != userID),
+	})
+}
+
+// UpdateWatchlist replaces a watchlist's name, description, exchange and
+// symbols
+// PUT /watchlists/:id
+func (h *UserWatchlistHandler) UpdateWatchlist(c *gin.Context) {
+	record, ok := bindWatchlistRequest(c)
+	if !ok {
+		return
+	}
+	record.WatchlistID = c.Param("id")
+
+	err := h.db.UpdateWatchlist(ownerID(c), record)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "watchlist not found",
+		})
+		return
+	}
+	if errors.Is(err, database.ErrWatchlistExists) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to update watchlist: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, record)
+}
+
+// DeleteWatchlist deletes a watchlist
+// DELETE /watchlists/:id
+func (h *UserWatchlistHandler) DeleteWatchlist(c *gin.Context) {
+	err := h.db.DeleteWatchlist(ownerID(c), c.Param("id"))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "watchlist not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to delete watchlist: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "watchlist deleted",
+	})
+}
+
+// AddSymbols appends symbols to a watchlist, skipping ones already in it
+// POST /watchlists/:id/symbols
+// Body: {"symbols": ["INFY", "TCS"]}
+func (h *UserWatchlistHandler) AddSymbols(c *gin.Context) {
+	var req WatchlistSymbolsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: " + err.Error(),
+		})
+		return
+	}
+	symbols := normalizeSymbols(req.Symbols)
+	if len(symbols) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "at least one symbol required",
+		})
+		return
+	}
+
+	record, err := h.db.AddWatchlistSymbols(ownerID(c), c.Param("id"), symbols)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "watchlist not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to add symbols: " + err.Error(),
+		})
+		return
+	}
+
+	if len(record.Symbols) > maxWatchlistSymbols {
+		// Roll back the symbols that didn't fit
+		record, err = h.db.RemoveWatchlistSymbols(ownerID(c), record.WatchlistID, record.Symbols[maxWatchlistSymbols:])
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to add symbols: " + err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":     "a watchlist can hold at most 500 symbols",
+			"watchlist": record,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, record)
+}
+
+// RemoveSymbol removes a symbol from a watchlist
+// DELETE /watchlists/:id/symbols/:symbol
+func (h *UserWatchlistHandler) RemoveSymbol(c *gin.Context) {
+	symbol := strings.ToUpper(c.Param("symbol"))
+
+	record, err := h.db.RemoveWatchlistSymbols(ownerID(c), c.Param("id"), []string{symbol})
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "watchlist not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to remove symbol: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, record)
+}
+
+// ShareWatchlist gives another user read-only access to a watchlist
+// (multi-user mode)
+// POST /watchlists/:id/share
+// Body: {"email": "friend@example.com"}
+func (h *UserWatchlistHandler) ShareWatchlist(c *gin.Context) {
+	var req ShareWatchlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: " + err.Error(),
+		})
+		return
+	}
+	h.changeShare(c, req.Email, true)
+}
+
+// UnshareWatchlist revokes a user's access to a watchlist
+// DELETE /watchlists/:id/share/:email
+func (h *UserWatchlistHandler) UnshareWatchlist(c *gin.Context) {
+	h.changeShare(c, c.Param("email"), false)
+}
+
+// changeShare shares a watchlist with, or unshares it from, the user with
+// the given email
+func (h *UserWatchlistHandler) changeShare(c *gin.Context, email string, share bool) {
+	userID := ownerID(c)
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "sharing is only available in multi-user mode",
+		})
+		return
+	}
+
+	user, err := h.db.GetUserByEmail(strings.TrimSpace(email))
+	if errors.Is(err, auth.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "user not found: " + email,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch user: " + err.Error(),
+		})
+		return
+	}
+	if user.UserID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "cannot share a watchlist with yourself",
+		})
+		return
+	}
+
+	if share {
+		err = h.db.ShareWatchlist(userID, c.Param("id"), user.UserID)
+	} else {
+		err = h.db.UnshareWatchlist(userID, c.Param("id"), user.UserID)
+	}
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "watchlist not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to update sharing: " + err.Error(),
+		})
+		return
+	}
+
+	record, err := h.db.GetWatchlistRecord(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to fetch watchlist: " + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, record)
+}
@@ -2,6 +2,8 @@ package api
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/trading-chitti/market-bridge/internal/watchlist"
@@ -23,6 +25,7 @@ func (h *WatchlistHandler) RegisterRoutes(r *gin.RouterGroup) {
 		wl.GET("/names", h.ListWatchlistNames)
 		wl.GET("/categories", h.ListCategories)
 		wl.GET("/category/:category", h.GetWatchlistsByCategory)
+		wl.GET("/movers", h.GetMovers)
 		wl.GET("/:name", h.GetWatchlist)
 		wl.POST("/merge", h.MergeWatchlists)
 	}
@@ -93,6 +96,45 @@ func (h *WatchlistHandler) GetWatchlistsByCategory(c *gin.Context) {
 	})
 }
 
+// GetMovers ranks an exchange's symbols by their move over the latest
+// session from the collector's bars
+// GET /watchlists/movers?type=gainers&exchange=NSE&limit=20
+func (h *WatchlistHandler) GetMovers(c *gin.Context) {
+	kind := strings.ToLower(c.DefaultQuery("type", watchlist.MoversGainers))
+	exchange := strings.ToUpper(c.DefaultQuery("exchange", "NSE"))
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(watchlist.DefaultMoversLimit)))
+	if err != nil || limit <= 0 || limit > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "limit must be between 1 and 500",
+		})
+		return
+	}
+
+	if kind != watchlist.MoversGainers && kind != watchlist.MoversLosers && kind != watchlist.MoversActive {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "type must be gainers, losers or active",
+		})
+		return
+	}
+
+	movers, asOf, err := watchlist.GetMovers(kind, exchange, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to compute movers: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"type":     kind,
+		"exchange": exchange,
+		"as_of":    asOf,
+		"count":    len(movers),
+		"movers":   movers,
+	})
+}
+
 // MergeWatchlistsRequest represents a merge request
 type MergeWatchlistsRequest struct {
 	Names []string `json:"names" binding:"required"`
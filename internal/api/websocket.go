@@ -1,14 +1,21 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/metrics"
+	"github.com/trading-chitti/market-bridge/internal/ratelimit"
 	kiteconnect "github.com/zerodha/gokiteconnect/v4"
 	"github.com/zerodha/gokiteconnect/v4/models"
 	kiteticker "github.com/zerodha/gokiteconnect/v4/ticker"
@@ -27,32 +34,86 @@ type WebSocketClient struct {
 	hub          *WebSocketHub
 	subscriptions map[string]bool
 	mu           sync.RWMutex
+
+	// Messages the client receives: wsChannelMarket, wsChannelOrders,
+	// wsChannelPositions or wsChannelPortfolio, or "" for all of them
+	channel string
+
+	// Set from the API's StreamGuard
+	release          func() // Frees the connection slot
+	maxSubscriptions int
+	limiter          *ratelimit.Limiter
+
+	// Close frame written once send is closed; empty for no status
+	closeFrame []byte
+}
+
+// /ws channels, selected by the endpoint a client connects to
+const (
+	wsChannelMarket    = "market"    // Ticks
+	wsChannelOrders    = "orders"    // Order updates
+	wsChannelPositions = "positions" // Position P&L snapshots
+	wsChannelPortfolio = "portfolio" // Portfolio valuation snapshots
+)
+
+// hubMessage is a message for the clients of a channel, or all clients if
+// channel is empty
+type hubMessage struct {
+	channel string
+	data    []byte
 }
 
 // WebSocketHub manages WebSocket connections
 type WebSocketHub struct {
 	clients    map[*WebSocketClient]bool
-	broadcast  chan []byte
+	broadcast  chan hubMessage
 	register   chan *WebSocketClient
 	unregister chan *WebSocketClient
 	mu         sync.RWMutex
+	closed     bool           // Set by Close; later clients are turned away
+	closeFrame []byte         // Sent to clients by Close
+	writers    sync.WaitGroup // Clients' write pumps, waited for by Close
 	
-	// Zerodha ticker for real-time market data
-	ticker *kiteticker.Ticker
+	// Zerodha ticker for real-time market data, replaced when the access
+	// token is renewed, and the instruments subscribed on it
+	tickerMu   sync.Mutex
+	ticker     *kiteticker.Ticker
+	subscribed map[uint32]bool
+
+	// Optional callback for order updates, e.g. the trade journal
+	onOrderUpdateHandler func(broker.OrderUpdate)
+
+	// Resolves "NSE:RELIANCE" style symbols to instrument tokens and back
+	db         *database.Database
+	symbolMu   sync.RWMutex
+	tokenNames map[uint32]string // token -> "EXCHANGE:SYMBOL", "" if unknown
+
+	// Position snapshots, see StartPositionSnapshots
+	positionRefresh chan struct{}
+	positionStop    chan struct{}
+	positionOnce    sync.Once
 }
 
 // NewWebSocketHub creates a new WebSocket hub
 func NewWebSocketHub(apiKey, accessToken string) *WebSocketHub {
 	hub := &WebSocketHub{
 		clients:    make(map[*WebSocketClient]bool),
-		broadcast:  make(chan []byte, 256),
+		broadcast:  make(chan hubMessage, 256),
 		register:   make(chan *WebSocketClient),
 		unregister: make(chan *WebSocketClient),
+		tokenNames: make(map[uint32]string),
+		subscribed: make(map[uint32]bool),
 	}
 	
 	// Initialize Zerodha WebSocket ticker
+	hub.ticker = hub.newTicker(apiKey, accessToken)
+
+	return hub
+}
+
+// newTicker creates a Kite ticker feeding the hub
+func (h *WebSocketHub) newTicker(apiKey, accessToken string) *kiteticker.Ticker {
 	ticker := kiteticker.New(apiKey, accessToken)
-	hub.ticker = ticker
 
 	// Enable auto-reconnect with retry logic
 	ticker.SetAutoReconnect(true)
@@ -60,15 +121,86 @@ func NewWebSocketHub(apiKey, accessToken string) *WebSocketHub {
 	ticker.SetReconnectMaxDelay(60 * time.Second)
 
 	// Set up ticker callbacks
-	ticker.OnConnect(hub.onTickerConnect)
-	ticker.OnTick(hub.onTick)
-	ticker.OnError(hub.onTickerError)
-	ticker.OnClose(hub.onTickerClose)
-	ticker.OnReconnect(hub.onTickerReconnect)
-	ticker.OnNoReconnect(hub.onTickerNoReconnect)
-	ticker.OnOrderUpdate(hub.onOrderUpdate)
+	ticker.OnConnect(func() { h.onTickerConnect(ticker) })
+	ticker.OnTick(h.onTick)
+	ticker.OnError(h.onTickerError)
+	ticker.OnClose(h.onTickerClose)
+	ticker.OnReconnect(h.onTickerReconnect)
+	ticker.OnNoReconnect(h.onTickerNoReconnect)
+	ticker.OnOrderUpdate(h.onOrderUpdate)
 
-	return hub
+	return ticker
+}
+
+// SetOrderUpdateHandler registers a callback for order updates pushed by
+// the broker. Call it before StartTicker.
+func (h *WebSocketHub) SetOrderUpdateHandler(handler func(broker.OrderUpdate)) {
+	h.onOrderUpdateHandler = handler
+}
+
+// SetDatabase lets clients subscribe by symbol and names the symbol of
+// every tick, using the instruments table. Call it before Run.
+func (h *WebSocketHub) SetDatabase(db *database.Database) {
+	h.db = db
+}
+
+// ResolveSymbols looks up the instrument tokens of "EXCHANGE:SYMBOL" names
+// (NSE when the exchange is omitted), returning the tokens by normalized
+// name and the names not found
+func (h *WebSocketHub) ResolveSymbols(names []string) (map[string]uint32, []string) {
+	resolved := make(map[string]uint32)
+	var unknown []string
+
+	for _, name := range names {
+		exchange, symbol := "NSE", strings.ToUpper(strings.TrimSpace(name))
+		if i := strings.Index(symbol, ":"); i >= 0 {
+			exchange, symbol = symbol[:i], symbol[i+1:]
+		}
+		normalized := exchange + ":" + symbol
+
+		var token uint32
+		if h.db != nil {
+			var err error
+			token, err = h.db.GetInstrumentToken(exchange, symbol)
+			if err != nil {
+				log.Printf("⚠️  Failed to resolve %s: %v", normalized, err)
+			}
+		}
+		if token == 0 {
+			unknown = append(unknown, name)
+			continue
+		}
+
+		resolved[normalized] = token
+		h.symbolMu.Lock()
+		h.tokenNames[token] = normalized
+		h.symbolMu.Unlock()
+	}
+
+	return resolved, unknown
+}
+
+// symbolName returns the "EXCHANGE:SYMBOL" name of a token, looking up
+// tokens subscribed by number once
+func (h *WebSocketHub) symbolName(token uint32) string {
+	h.symbolMu.RLock()
+	name, known := h.tokenNames[token]
+	h.symbolMu.RUnlock()
+	if known || h.db == nil {
+		return name
+	}
+
+	inst, err := h.db.GetInstrumentByToken(token)
+	if err != nil {
+		log.Printf("⚠️  Failed to look up instrument %d: %v", token, err)
+	} else if inst != nil {
+		name = inst.Exchange + ":" + inst.Tradingsymbol
+	}
+
+	h.symbolMu.Lock()
+	h.tokenNames[token] = name
+	h.symbolMu.Unlock()
+	return name
 }
 
 // Run starts the WebSocket hub
@@ -77,8 +209,17 @@ func (h *WebSocketHub) Run() {
 		select {
 		case client := <-h.register:
 			h.mu.Lock()
+			if h.closed {
+				client.closeFrame = h.closeFrame
+				close(client.send)
+				h.mu.Unlock()
+				continue
+			}
 			h.clients[client] = true
 			h.mu.Unlock()
+			if client.channel == "" || client.channel == wsChannelPositions || client.channel == wsChannelPortfolio {
+				h.refreshPositions()
+			}
 			log.Printf("📡 WebSocket client connected (total: %d)", len(h.clients))
 			
 		case client := <-h.unregister:
@@ -91,27 +232,126 @@ func (h *WebSocketHub) Run() {
 			log.Printf("📡 WebSocket client disconnected (total: %d)", len(h.clients))
 			
 		case message := <-h.broadcast:
-			h.mu.RLock()
+			h.mu.Lock()
 			for client := range h.clients {
+				if message.channel != "" && client.channel != "" && client.channel != message.channel {
+					continue
+				}
 				select {
-				case client.send <- message:
+				case client.send <- message.data:
 				default:
+					// Client buffer full, disconnect
+					metrics.RecordSlowStreamClient("ws")
+					log.Printf("🐢 Disconnecting slow WebSocket client")
 					close(client.send)
 					delete(h.clients, client)
 				}
 			}
-			h.mu.RUnlock()
+			h.mu.Unlock()
 		}
 	}
 }
 
+// Close stops the hub's ticker and position snapshots and disconnects its
+// clients with a going-away close frame giving reason, so they reconnect.
+// It waits until the frames are written or ctx is done. The hub keeps
+// running so late unregistrations don't block.
+func (h *WebSocketHub) Close(ctx context.Context, reason string) {
+	h.tickerMu.Lock()
+	if h.ticker != nil {
+		h.ticker.Stop()
+		h.ticker = nil
+	}
+	h.tickerMu.Unlock()
+	h.StopPositionSnapshots()
+
+	h.mu.Lock()
+	h.closed = true
+	h.closeFrame = websocket.FormatCloseMessage(websocket.CloseGoingAway, reason)
+	for client := range h.clients {
+		client.closeFrame = h.closeFrame
+		close(client.send)
+		delete(h.clients, client)
+	}
+	h.mu.Unlock()
+
+	waitGroup(ctx, &h.writers)
+}
+
+// admit counts a new client's write pump, unless the hub is closed
+func (h *WebSocketHub) admit() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return false
+	}
+	h.writers.Add(1)
+	return true
+}
+
+// waitGroup waits for wg or until ctx is done
+func waitGroup(ctx context.Context, wg *sync.WaitGroup) {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+// publish queues a message for the clients of a channel ("" for all)
+func (h *WebSocketHub) publish(channel string, data interface{}) {
+	if msg, err := json.Marshal(data); err == nil {
+		h.broadcast <- hubMessage{channel: channel, data: msg}
+	}
+}
+
 // StartTicker starts the Zerodha WebSocket ticker
 func (h *WebSocketHub) StartTicker() {
-	go h.ticker.Serve()
+	h.tickerMu.Lock()
+	ticker := h.ticker
+	h.tickerMu.Unlock()
+	if ticker == nil {
+		return // Closed
+	}
+	go ticker.Serve()
+}
+
+// SetAccessToken replaces the ticker with one using a renewed access
+// token. Clients stay connected and the subscribed instruments are
+// subscribed again once the new ticker connects.
+func (h *WebSocketHub) SetAccessToken(apiKey, accessToken string) {
+	h.mu.RLock()
+	closed := h.closed
+	h.mu.RUnlock()
+	if closed {
+		return
+	}
+
+	h.tickerMu.Lock()
+	old := h.ticker
+	h.ticker = h.newTicker(apiKey, accessToken)
+	ticker := h.ticker
+	h.tickerMu.Unlock()
+
+	if old != nil {
+		old.Stop()
+	}
+	go ticker.Serve()
+	log.Println("🔑 WebSocket ticker restarted with the renewed access token")
 }
 
 // Subscribe subscribes to instrument tokens
 func (h *WebSocketHub) Subscribe(tokens []uint32) {
+	h.tickerMu.Lock()
+	defer h.tickerMu.Unlock()
+
+	for _, token := range tokens {
+		h.subscribed[token] = true
+	}
 	if h.ticker != nil {
 		h.ticker.Subscribe(tokens)
 		h.ticker.SetMode(kiteticker.ModeFull, tokens)
@@ -119,13 +359,27 @@ func (h *WebSocketHub) Subscribe(tokens []uint32) {
 }
 
 // Ticker callbacks
-func (h *WebSocketHub) onTickerConnect() {
+func (h *WebSocketHub) onTickerConnect(ticker *kiteticker.Ticker) {
 	log.Println("✅ Zerodha WebSocket ticker connected")
+
+	// A ticker replaced by SetAccessToken starts without subscriptions
+	h.tickerMu.Lock()
+	defer h.tickerMu.Unlock()
+	if ticker != h.ticker || len(h.subscribed) == 0 {
+		return
+	}
+	tokens := make([]uint32, 0, len(h.subscribed))
+	for token := range h.subscribed {
+		tokens = append(tokens, token)
+	}
+	ticker.Subscribe(tokens)
+	ticker.SetMode(kiteticker.ModeFull, tokens)
 }
 
 func (h *WebSocketHub) onTick(tick models.Tick) {
 	data := map[string]interface{}{
 		"type":          "tick",
+		"symbol":        h.symbolName(tick.InstrumentToken),
 		"instrument_token": tick.InstrumentToken,
 		"last_price":    tick.LastPrice,
 		"last_quantity": tick.LastTradedQuantity,
@@ -139,9 +393,7 @@ func (h *WebSocketHub) onTick(tick models.Tick) {
 		},
 	}
 
-	if msg, err := json.Marshal(data); err == nil {
-		h.broadcast <- msg
-	}
+	h.publish(wsChannelMarket, data)
 }
 
 func (h *WebSocketHub) onTickerError(err error) {
@@ -163,9 +415,7 @@ func (h *WebSocketHub) onTickerReconnect(attempt int, delay time.Duration) {
 		"delay":   delay.String(),
 	}
 
-	if msg, err := json.Marshal(data); err == nil {
-		h.broadcast <- msg
-	}
+	h.publish("", data)
 }
 
 func (h *WebSocketHub) onTickerNoReconnect(attempt int) {
@@ -178,19 +428,41 @@ func (h *WebSocketHub) onTickerNoReconnect(attempt int) {
 		"message": "Max reconnection attempts reached",
 	}
 
-	if msg, err := json.Marshal(data); err == nil {
-		h.broadcast <- msg
-	}
+	h.publish("", data)
 }
 
 func (h *WebSocketHub) onOrderUpdate(order kiteconnect.Order) {
-	log.Printf("📋 Order Update: %s | Status: %s | Filled: %d/%d",
+	log.Printf("📋 Order Update: %s | Status: %s | Filled: %g/%g",
 		order.OrderID,
 		order.Status,
 		order.FilledQuantity,
 		order.Quantity)
 
-	// Broadcast order update to all clients
+	if h.onOrderUpdateHandler != nil {
+		h.onOrderUpdateHandler(broker.OrderUpdate{
+			Order: broker.Order{
+				OrderID:         order.OrderID,
+				Symbol:          order.TradingSymbol,
+				Exchange:        order.Exchange,
+				TransactionType: order.TransactionType,
+				OrderType:       order.OrderType,
+				Product:         order.Product,
+				Quantity:        int(order.Quantity),
+				Price:           order.Price,
+				TriggerPrice:    order.TriggerPrice,
+				Status:          order.Status,
+				FilledQuantity:  int(order.FilledQuantity),
+				PendingQuantity: int(order.PendingQuantity),
+				AveragePrice:    order.AveragePrice,
+				PlacedAt:        order.OrderTimestamp.Time,
+				UpdatedAt:       order.ExchangeUpdateTimestamp.Time,
+			},
+			Tag:           order.Tag,
+			StatusMessage: order.StatusMessage,
+		})
+	}
+
+	// Broadcast order update to order and all-message clients
 	data := map[string]interface{}{
 		"type":            "order_update",
 		"order_id":        order.OrderID,
@@ -207,29 +479,74 @@ func (h *WebSocketHub) onOrderUpdate(order kiteconnect.Order) {
 		"timestamp":       order.OrderTimestamp.Time,
 	}
 
-	if msg, err := json.Marshal(data); err == nil {
-		h.broadcast <- msg
-	}
+	h.publish(wsChannelOrders, data)
 }
 
-// HandleWebSocket handles WebSocket connections
+// HandleWebSocket handles WebSocket connections receiving all messages. In
+// multi-user mode each user gets the hub of their default broker account.
 func (a *API) HandleWebSocket(c *gin.Context) {
+	a.handleWebSocket(c, "")
+}
+
+// webSocketChannel handles WebSocket connections receiving one channel's
+// messages
+func (a *API) webSocketChannel(channel string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		a.handleWebSocket(c, channel)
+	}
+}
+
+func (a *API) handleWebSocket(c *gin.Context, channel string) {
+	release, ok := a.streamGuard.Admit(c, "ws")
+	if !ok {
+		return
+	}
+
+	hub := a.wsHub
+	if a.wsHubs != nil {
+		userID, ok := GetUserID(c)
+		if !ok {
+			release()
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+			return
+		}
+		var err error
+		if hub, err = a.wsHubs.GetOrCreateHub(userID); err != nil {
+			release()
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "failed to start market data: " + err.Error()})
+			return
+		}
+	}
+
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Printf("WebSocket upgrade error: %v", err)
+		release()
+		return
+	}
+	if hub != nil && !hub.admit() {
+		conn.WriteMessage(websocket.CloseMessage, hub.closeFrame)
+		conn.Close()
+		release()
 		return
 	}
 	
 	client := &WebSocketClient{
-		conn:          conn,
-		send:          make(chan []byte, 256),
-		subscriptions: make(map[string]bool),
+		conn:             conn,
+		send:             make(chan []byte, 256),
+		hub:              hub,
+		subscriptions:    make(map[string]bool),
+		channel:          channel,
+		release:          release,
+		maxSubscriptions: a.streamGuard.maxSubscriptions(),
+		limiter:          a.streamGuard.newMessageLimiter(),
 	}
 	
 	// Register client
-	if a.wsHub != nil {
-		a.wsHub.register <- client
+	if hub != nil {
+		hub.register <- client
 	}
+	a.streamGuard.OnRevoke(c, func() { conn.Close() })
 	
 	// Start goroutines for reading and writing
 	go client.readPump()
@@ -243,6 +560,7 @@ func (c *WebSocketClient) readPump() {
 			c.hub.unregister <- c
 		}
 		c.conn.Close()
+		c.release()
 	}()
 	
 	c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
@@ -260,6 +578,12 @@ func (c *WebSocketClient) readPump() {
 			break
 		}
 		
+		if !c.limiter.Allow() {
+			metrics.RecordStreamRejection("ws", "rate_limited")
+			c.sendJSON(map[string]interface{}{"type": "error", "error": "too many messages, slow down"})
+			continue
+		}
+
 		// Handle subscription messages
 		var msg map[string]interface{}
 		if err := json.Unmarshal(message, &msg); err == nil {
@@ -269,14 +593,30 @@ func (c *WebSocketClient) readPump() {
 					if tokens, ok := msg["tokens"].([]interface{}); ok {
 						// Convert to uint32 slice and subscribe
 						var tokenList []uint32
+						var rejected []uint32
 						for _, t := range tokens {
 							if token, ok := t.(float64); ok {
+								if !c.reserve(strconv.FormatUint(uint64(token), 10)) {
+									rejected = append(rejected, uint32(token))
+									continue
+								}
 								tokenList = append(tokenList, uint32(token))
 							}
 						}
-						if c.hub != nil {
+						if c.hub != nil && len(tokenList) > 0 {
 							c.hub.Subscribe(tokenList)
 						}
+						if len(rejected) > 0 {
+							metrics.RecordStreamRejection("ws", "subscription_limit")
+							c.sendJSON(map[string]interface{}{
+								"type":     "error",
+								"error":    "subscription limit reached",
+								"rejected": rejected,
+							})
+						}
+					}
+					if symbols, ok := msg["symbols"].([]interface{}); ok && c.hub != nil {
+						c.subscribeSymbols(symbols)
 					}
 				case "unsubscribe":
 					// Handle unsubscribe
@@ -286,12 +626,80 @@ func (c *WebSocketClient) readPump() {
 	}
 }
 
+// subscribeSymbols subscribes to "NSE:RELIANCE" style symbols and tells the
+// client which tokens they resolved to and which weren't found
+func (c *WebSocketClient) subscribeSymbols(symbols []interface{}) {
+	names := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		if name, ok := s.(string); ok && name != "" {
+			names = append(names, name)
+		}
+	}
+
+	resolved, unknown := c.hub.ResolveSymbols(names)
+	tokens := make([]uint32, 0, len(resolved))
+	var rejected []string
+	for name, token := range resolved {
+		if !c.reserve(name) {
+			rejected = append(rejected, name)
+			delete(resolved, name)
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+	if len(tokens) > 0 {
+		c.hub.Subscribe(tokens)
+	}
+
+	data := map[string]interface{}{
+		"type":    "subscribed",
+		"symbols": resolved,
+	}
+	if len(unknown) > 0 {
+		data["unknown"] = unknown
+	}
+	if len(rejected) > 0 {
+		metrics.RecordStreamRejection("ws", "subscription_limit")
+		data["rejected"] = rejected
+		data["max_subscriptions"] = c.maxSubscriptions
+	}
+	c.sendJSON(data)
+}
+
+// reserve records a subscription, a symbol or token, unless the client is
+// at its subscription cap
+func (c *WebSocketClient) reserve(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.subscriptions[key] && c.maxSubscriptions > 0 && len(c.subscriptions) >= c.maxSubscriptions {
+		return false
+	}
+	c.subscriptions[key] = true
+	return true
+}
+
+// sendJSON sends a message to the client, unless its buffer is full
+func (c *WebSocketClient) sendJSON(data map[string]interface{}) {
+	if msg, err := json.Marshal(data); err == nil {
+		select {
+		case c.send <- msg:
+		default:
+			messageType, _ := data["type"].(string)
+			metrics.RecordDroppedStreamMessage("ws", messageType)
+		}
+	}
+}
+
 // writePump writes messages to WebSocket client
 func (c *WebSocketClient) writePump() {
 	ticker := time.NewTicker(54 * time.Second)
 	defer func() {
 		ticker.Stop()
 		c.conn.Close()
+		if c.hub != nil {
+			c.hub.writers.Done()
+		}
 	}()
 	
 	for {
@@ -299,7 +707,7 @@ func (c *WebSocketClient) writePump() {
 		case message, ok := <-c.send:
 			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
 			if !ok {
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				c.conn.WriteMessage(websocket.CloseMessage, c.closeFrame)
 				return
 			}
 			
@@ -335,11 +743,14 @@ func (a *API) RegisterWebSocketRoutes(r *gin.Engine) {
 	r.GET("/ws", a.HandleWebSocket)
 	
 	// WebSocket for market data streaming
-	r.GET("/ws/market", a.HandleWebSocket)
+	r.GET("/ws/market", a.webSocketChannel(wsChannelMarket))
 	
-	// WebSocket for order updates
-	r.GET("/ws/orders", a.HandleWebSocket)
+	// WebSocket for the user's order updates
+	r.GET("/ws/orders", a.webSocketChannel(wsChannelOrders))
 	
-	// WebSocket for position updates
-	r.GET("/ws/positions", a.HandleWebSocket)
+	// WebSocket for the user's position P&L snapshots
+	r.GET("/ws/positions", a.webSocketChannel(wsChannelPositions))
+
+	// WebSocket for the user's portfolio valuation
+	r.GET("/ws/portfolio", a.webSocketChannel(wsChannelPortfolio))
 }
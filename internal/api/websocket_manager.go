@@ -1,19 +1,30 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/trading-chitti/market-bridge/internal/broker"
 	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/journal"
+	"github.com/trading-chitti/market-bridge/internal/notify"
+	"github.com/trading-chitti/market-bridge/internal/quotes"
 )
 
 // WebSocketHubManager manages per-user WebSocket hubs
 type WebSocketHubManager struct {
-	db    *database.Database
-	hubs  map[string]*WebSocketHub // userID -> hub
-	mu    sync.RWMutex
+	db       *database.Database
+	notifier *notify.Notifier
+	listener func(userID string, update broker.OrderUpdate)
+	hubs     map[string]*WebSocketHub // userID -> hub
+	mu       sync.RWMutex
+
+	// Position snapshots for /ws/positions
+	quotes           *quotes.Store
+	positionInterval time.Duration
 }
 
 // NewWebSocketHubManager creates a new hub manager
@@ -24,6 +35,59 @@ func NewWebSocketHubManager(db *database.Database) *WebSocketHubManager {
 	}
 }
 
+// SetNotifier sets the notifier told about each user's order fills. Hubs
+// created afterwards use it.
+func (m *WebSocketHubManager) SetNotifier(notifier *notify.Notifier) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.notifier = notifier
+}
+
+// SetPositionSnapshots sets how often hubs created afterwards push position
+// snapshots, marked to store's quotes (see StartPositionSnapshots)
+func (m *WebSocketHubManager) SetPositionSnapshots(store *quotes.Store, interval time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.quotes = store
+	m.positionInterval = interval
+}
+
+// startPositionSnapshots starts a user's position snapshots from the
+// broker account config; callers must hold m.mu
+func (m *WebSocketHubManager) startPositionSnapshots(hub *WebSocketHub, config *broker.BrokerConfig, userID string) {
+	brk, err := broker.NewBroker(config)
+	if err != nil {
+		log.Printf("⚠️  No position snapshots for user %s: %v", userID, err)
+		return
+	}
+	hub.StartPositionSnapshots(brk, m.quotes, m.positionInterval)
+}
+
+// SetOrderUpdateListener passes every user's order updates to fn, e.g. so
+// their broker can place exits as entries fill. Hubs created afterwards
+// use it.
+func (m *WebSocketHubManager) SetOrderUpdateListener(fn func(userID string, update broker.OrderUpdate)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listener = fn
+}
+
+// orderUpdateHandler journals a user's order updates, notifies them of
+// fills and rejections and passes them on to the listener; callers must
+// hold m.mu
+func (m *WebSocketHubManager) orderUpdateHandler(brokerName, userID string) func(broker.OrderUpdate) {
+	record := journal.New(m.db, brokerName, userID).RecordOrderUpdate
+	notifier := m.notifier
+	listener := m.listener
+	return func(update broker.OrderUpdate) {
+		record(update)
+		notifier.OrderUpdate(userID, update)
+		if listener != nil {
+			listener(userID, update)
+		}
+	}
+}
+
 // GetOrCreateHub gets an existing hub or creates a new one for the user
 func (m *WebSocketHubManager) GetOrCreateHub(userID string) (*WebSocketHub, error) {
 	m.mu.RLock()
@@ -78,6 +142,9 @@ func (m *WebSocketHubManager) GetOrCreateHub(userID string) (*WebSocketHub, erro
 
 	// Create new hub for this user
 	hub = NewWebSocketHub(defaultConfig.APIKey, defaultConfig.AccessToken)
+	hub.SetOrderUpdateHandler(m.orderUpdateHandler(defaultConfig.BrokerName, userID))
+	hub.SetDatabase(m.db)
+	m.startPositionSnapshots(hub, defaultConfig, userID)
 	go hub.Run()
 	hub.StartTicker()
 
@@ -95,29 +162,37 @@ func (m *WebSocketHubManager) GetHub(userID string) *WebSocketHub {
 	return m.hubs[userID]
 }
 
-// CloseHub closes and removes a user's hub
+// CloseHub closes and removes a user's hub. Its clients are disconnected
+// and reconnect to a new hub.
 func (m *WebSocketHubManager) CloseHub(userID string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if _, exists := m.hubs[userID]; exists {
-		// hub.StopTicker() // TODO: Implement ticker cleanup
+	if hub, exists := m.hubs[userID]; exists {
+		go hub.Close(context.Background(), "broker account changed")
 		delete(m.hubs, userID)
 		log.Printf("🔌 Closed WebSocket hub for user %s", userID)
 	}
 }
 
-// CloseAllHubs closes all active hubs (for shutdown)
-func (m *WebSocketHubManager) CloseAllHubs() {
+// CloseAllHubs closes all active hubs (for shutdown), waiting until their
+// clients are disconnected or ctx is done
+func (m *WebSocketHubManager) CloseAllHubs(ctx context.Context) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	for userID := range m.hubs {
-		// hub.StopTicker() // TODO: Implement ticker cleanup
-		log.Printf("🔌 Closed WebSocket hub for user %s", userID)
-	}
-
+	hubs := m.hubs
 	m.hubs = make(map[string]*WebSocketHub)
+	m.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for userID, hub := range hubs {
+		wg.Add(1)
+		go func(userID string, hub *WebSocketHub) {
+			defer wg.Done()
+			hub.Close(ctx, "server shutting down")
+			log.Printf("🔌 Closed WebSocket hub for user %s", userID)
+		}(userID, hub)
+	}
+	wg.Wait()
 }
 
 // GetActiveUserCount returns the number of users with active hubs
@@ -145,14 +220,17 @@ func (m *WebSocketHubManager) UpdateUserBrokerConfig(userID string, newConfig *b
 	defer m.mu.Unlock()
 
 	// Close existing hub if any
-	if _, exists := m.hubs[userID]; exists {
-		// hub.StopTicker() // TODO: Implement ticker cleanup
+	if hub, exists := m.hubs[userID]; exists {
+		go hub.Close(context.Background(), "broker account updated")
 		delete(m.hubs, userID)
 	}
 
 	// Create new hub with updated config
 	if newConfig.IsActive && newConfig.AccessToken != "" {
 		hub := NewWebSocketHub(newConfig.APIKey, newConfig.AccessToken)
+		hub.SetOrderUpdateHandler(m.orderUpdateHandler(newConfig.BrokerName, userID))
+		hub.SetDatabase(m.db)
+		m.startPositionSnapshots(hub, newConfig, userID)
 		go hub.Run()
 		hub.StartTicker()
 		m.hubs[userID] = hub
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"log"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/portfolio"
+	"github.com/trading-chitti/market-bridge/internal/quotes"
+)
+
+// DefaultPositionSnapshotInterval is how often /ws/positions and
+// /ws/portfolio clients get a snapshot
+const DefaultPositionSnapshotInterval = 5 * time.Second
+
+// StartPositionSnapshots pushes the hub's positions, from brk, to its
+// /ws/positions clients, and the portfolio valuation to its /ws/portfolio
+// clients, every interval and whenever one connects. P&L is marked to the
+// latest collector quote when store has a fresh one. The broker is only
+// asked while such clients are connected.
+func (h *WebSocketHub) StartPositionSnapshots(brk broker.Broker, store *quotes.Store, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultPositionSnapshotInterval
+	}
+
+	h.positionRefresh = make(chan struct{}, 1)
+	h.positionStop = make(chan struct{})
+	go h.pollPositions(brk, store, portfolio.NewService(brk, store), interval, h.positionRefresh, h.positionStop)
+}
+
+// StopPositionSnapshots stops the snapshots started by StartPositionSnapshots
+func (h *WebSocketHub) StopPositionSnapshots() {
+	if h.positionStop == nil {
+		return
+	}
+	h.positionOnce.Do(func() { close(h.positionStop) })
+}
+
+// refreshPositions asks for a snapshot now, if snapshots are running
+func (h *WebSocketHub) refreshPositions() {
+	if h.positionRefresh == nil {
+		return
+	}
+	select {
+	case h.positionRefresh <- struct{}{}:
+	default: // One is already pending
+	}
+}
+
+func (h *WebSocketHub) pollPositions(brk broker.Broker, store *quotes.Store, valuer *portfolio.Service, interval time.Duration, refresh, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-refresh:
+		case <-stop:
+			return
+		}
+
+		if h.hasClients(wsChannelPortfolio) {
+			h.publishPortfolio(valuer)
+		}
+
+		if !h.hasClients(wsChannelPositions) {
+			continue
+		}
+
+		positions, err := brk.GetPositions()
+		if err != nil {
+			log.Printf("⚠️  Failed to get positions for snapshot: %v", err)
+			h.publish(wsChannelPositions, map[string]interface{}{
+				"type":      "positions_error",
+				"error":     "failed to get positions: " + err.Error(),
+				"timestamp": time.Now(),
+			})
+			continue
+		}
+
+		h.publish(wsChannelPositions, positionSnapshot(positions, store))
+	}
+}
+
+// publishPortfolio pushes a portfolio valuation to /ws/portfolio clients
+func (h *WebSocketHub) publishPortfolio(valuer *portfolio.Service) {
+	snapshot, err := valuer.Snapshot()
+	if err != nil {
+		log.Printf("⚠️  Failed to value portfolio: %v", err)
+		h.publish(wsChannelPortfolio, map[string]interface{}{
+			"type":      "portfolio_error",
+			"error":     "failed to value portfolio: " + err.Error(),
+			"timestamp": time.Now(),
+		})
+		return
+	}
+
+	h.publish(wsChannelPortfolio, struct {
+		Type string `json:"type"`
+		*portfolio.Snapshot
+	}{"portfolio", snapshot})
+}
+
+// hasClients reports whether any client receives the channel's messages
+func (h *WebSocketHub) hasClients(channel string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.clients {
+		if client.channel == "" || client.channel == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// positionSnapshot builds the "positions" message from the broker's net
+// positions. Open positions with a fresh quote are marked to it: the P&L
+// moves by the price change since the broker's last price.
+func positionSnapshot(positions *broker.Positions, store *quotes.Store) map[string]interface{} {
+	rows := make([]map[string]interface{}, 0, len(positions.Net))
+	var totalPNL, unrealizedPNL float64
+
+	for _, pos := range positions.Net {
+		lastPrice, pnl := pos.LastPrice, pos.PNL
+		if store != nil && pos.Quantity != 0 {
+			if quote, ok := store.Get(pos.Exchange, pos.Symbol); ok {
+				pnl += (quote.LastPrice - pos.LastPrice) * float64(pos.Quantity)
+				lastPrice = quote.LastPrice
+			}
+		}
+
+		totalPNL += pnl
+		if pos.Quantity != 0 {
+			unrealizedPNL += (lastPrice - pos.AveragePrice) * float64(pos.Quantity)
+		}
+
+		rows = append(rows, map[string]interface{}{
+			"symbol":        pos.Symbol,
+			"exchange":      pos.Exchange,
+			"product":       pos.Product,
+			"quantity":      pos.Quantity,
+			"average_price": pos.AveragePrice,
+			"last_price":    lastPrice,
+			"pnl":           pnl,
+			"overnight":     pos.Overnight,
+		})
+	}
+
+	return map[string]interface{}{
+		"type":           "positions",
+		"positions":      rows,
+		"total_pnl":      totalPNL,
+		"unrealized_pnl": unrealizedPNL,
+		"timestamp":      time.Now(),
+	}
+}
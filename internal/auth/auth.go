@@ -19,6 +19,18 @@ var (
 	ErrUserExists         = errors.New("user already exists")
 	ErrInvalidToken       = errors.New("invalid or expired token")
 	ErrSessionRevoked     = errors.New("session has been revoked")
+	ErrTOTPRequired       = errors.New("two-factor code required")
+	ErrInvalidTOTPCode    = errors.New("invalid two-factor code")
+	ErrTOTPLocked         = errors.New("too many invalid two-factor codes, try again later")
+)
+
+// One-time token purposes and how long tokens of each stay valid
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposeResetPassword = "reset_password"
+
+	VerifyEmailTokenExpiry   = 48 * time.Hour
+	ResetPasswordTokenExpiry = time.Hour
 )
 
 // JWTClaims represents the JWT token claims
@@ -40,6 +52,7 @@ type User struct {
 	LastLoginAt   *time.Time `json:"last_login_at,omitempty" db:"last_login_at"`
 	IsActive      bool      `json:"is_active" db:"is_active"`
 	EmailVerified bool      `json:"email_verified" db:"email_verified"`
+	TOTPEnabled   bool      `json:"totp_enabled" db:"totp_enabled"`
 }
 
 // Session represents an active user session
@@ -181,3 +194,18 @@ func (s *AuthService) hashToken(token string) string {
 func (s *AuthService) HashRefreshToken(refreshToken string) string {
 	return s.hashToken(refreshToken)
 }
+
+// GenerateOneTimeToken creates a random token for an email link (address
+// verification, password reset) and the hash to store in its place
+func (s *AuthService) GenerateOneTimeToken() (token, hash string, err error) {
+	token, err = s.generateRandomToken()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return token, s.hashToken(token), nil
+}
+
+// HashOneTimeToken hashes a one-time token to look it up
+func (s *AuthService) HashOneTimeToken(token string) string {
+	return s.hashToken(token)
+}
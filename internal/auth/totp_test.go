@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the RFC 6238 SHA1 test key "12345678901234567890" in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTPVectors(t *testing.T) {
+	// RFC 6238 appendix B SHA1 vectors, truncated to 6 digits
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			step, ok := ValidateTOTP(rfcSecret, tt.code, time.Unix(tt.unix, 0), 0)
+			if !ok {
+				t.Fatalf("code %s rejected at %d", tt.code, tt.unix)
+			}
+			if want := tt.unix / totpPeriod; step != want {
+				t.Errorf("step = %d, want %d", step, want)
+			}
+		})
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := now.Unix() / totpPeriod
+	key, _ := totpEncoding.DecodeString(rfcSecret)
+	codeAt := func(offset int64) string { return totpCode(key, current+offset) }
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		lastStep int64
+		wantOK   bool
+		wantStep int64
+	}{
+		{"current code", rfcSecret, codeAt(0), 0, true, current},
+		{"previous step for clock drift", rfcSecret, codeAt(-1), 0, true, current - 1},
+		{"next step for clock drift", rfcSecret, codeAt(1), 0, true, current + 1},
+		{"two steps old", rfcSecret, codeAt(-2), 0, false, 0},
+		{"two steps ahead", rfcSecret, codeAt(2), 0, false, 0},
+		{"already used", rfcSecret, codeAt(0), current, false, 0},
+		{"later step already used", rfcSecret, codeAt(-1), current, false, 0},
+		{"surrounding spaces", rfcSecret, " " + codeAt(0) + " ", 0, true, current},
+		{"lowercase secret", strings.ToLower(rfcSecret), codeAt(0), 0, true, current},
+		{"wrong code", rfcSecret, "000000", 0, false, 0},
+		{"too short", rfcSecret, "12345", 0, false, 0},
+		{"too long", rfcSecret, "1234567", 0, false, 0},
+		{"invalid secret", "not base32!", codeAt(0), 0, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(tt.secret, tt.code, now, tt.lastStep)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("ValidateTOTP = (%d, %v), want (%d, %v)", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret: %v", err)
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("secret %q isn't base32: %v", secret, err)
+	}
+	if len(key) != 20 {
+		t.Errorf("key is %d bytes, want 20", len(key))
+	}
+
+	other, _ := GenerateTOTPSecret()
+	if other == secret {
+		t.Error("two generated secrets are equal")
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := TOTPProvisioningURI(rfcSecret, "trader one@example.com")
+
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		t.Fatalf("invalid URI %q: %v", uri, err)
+	}
+	if parsed.Scheme != "otpauth" || parsed.Host != "totp" {
+		t.Errorf("URI %q isn't otpauth://totp", uri)
+	}
+	if strings.Contains(uri, "+") {
+		t.Errorf("URI %q encodes spaces as +", uri)
+	}
+
+	query := parsed.Query()
+	for key, want := range map[string]string{
+		"secret": rfcSecret, "issuer": totpIssuer, "digits": "6", "period": "30", "algorithm": "SHA1",
+	} {
+		if got := query.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
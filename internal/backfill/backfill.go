@@ -0,0 +1,413 @@
+package backfill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/ratelimit"
+	"github.com/trading-chitti/market-bridge/internal/timeframe"
+	"github.com/trading-chitti/market-bridge/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Backfill modes
+const (
+	ModeFull     = "full"
+	ModeFillGaps = "fill-gaps"
+)
+
+// ErrCancelled is reported for symbols skipped or interrupted by cancellation
+var ErrCancelled = errors.New("backfill cancelled")
+
+// maxChunkDays is the largest date range Kite serves per historical request
+var maxChunkDays = map[timeframe.Timeframe]int{
+	timeframe.Minute1:  60,
+	timeframe.Minute5:  100,
+	timeframe.Minute15: 200,
+	timeframe.Hour1:    400,
+	timeframe.Day:      2000,
+}
+
+// Options configures a Backfiller
+type Options struct {
+	Timeframe  string  // Kite interval (minute ... day) or bar timeframe (1m ... 1d); New normalizes to the Kite interval
+	Mode       string  // ModeFull or ModeFillGaps
+	DryRun     bool    // Fetch but don't insert
+	Concurrent int     // Symbols processed in parallel
+	Rate       float64 // Max historical API requests per second
+	MaxRetries int     // Retries per chunk on broker errors
+	Source     string  // Value stored in md.intraday_bars.source
+}
+
+// Stats contains backfill statistics
+type Stats struct {
+	TotalSymbols int
+	Successful   int
+	Failed       int
+	TotalBars    int
+	GapsFound    int
+	Requests     int
+	Retries      int
+	Duration     time.Duration
+	Results      []Result
+}
+
+// Result contains result for a single symbol
+type Result struct {
+	Symbol       string
+	BarsInserted int
+	GapsFound    int
+	Chunks       int
+	Retries      int
+	Error        error
+}
+
+// Backfiller fetches historical candles from a broker into md.intraday_bars
+type Backfiller struct {
+	broker       broker.Broker
+	db           database.Store
+	opts         Options
+	barTimeframe timeframe.Timeframe
+	location     *time.Location
+
+	// limiter is shared across runs when set (job manager); otherwise each
+	// Run creates its own
+	limiter *ratelimit.Limiter
+
+	// OnResult is called as each symbol finishes (optional)
+	OnResult func(Result)
+}
+
+// New creates a backfiller, applying defaults for unset options
+func New(brk broker.Broker, db database.Store, opts Options) (*Backfiller, error) {
+	if opts.Timeframe == "" {
+		opts.Timeframe = timeframe.Day.Kite()
+	}
+	barTimeframe, err := timeframe.ParseStored(opts.Timeframe)
+	if err != nil {
+		return nil, err
+	}
+	opts.Timeframe = barTimeframe.Kite()
+
+	if opts.Mode == "" {
+		opts.Mode = ModeFull
+	}
+	if opts.Mode != ModeFull && opts.Mode != ModeFillGaps {
+		return nil, fmt.Errorf("unsupported mode: %s", opts.Mode)
+	}
+
+	if opts.Concurrent <= 0 {
+		opts.Concurrent = 5
+	}
+	if opts.Rate <= 0 {
+		opts.Rate = 3
+	}
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	}
+	if opts.Source == "" {
+		opts.Source = "backfill"
+	}
+
+	ist := broker.IST()
+
+	return &Backfiller{
+		broker:       brk,
+		db:           db,
+		opts:         opts,
+		barTimeframe: barTimeframe,
+		location:     ist,
+	}, nil
+}
+
+// Location returns the IST location used for date boundaries
+func (b *Backfiller) Location() *time.Location {
+	return b.location
+}
+
+// ParseDateRange parses YYYY-MM-DD dates in IST. An empty to means now;
+// otherwise the whole end day is included.
+func ParseDateRange(from, to string) (time.Time, time.Time, error) {
+	ist := broker.IST()
+
+	fromDate, err := time.ParseInLocation("2006-01-02", from, ist)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid from date: %w", err)
+	}
+
+	var toDate time.Time
+	if to == "" {
+		toDate = time.Now().In(ist)
+	} else {
+		toDate, err = time.ParseInLocation("2006-01-02", to, ist)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to date: %w", err)
+		}
+		// Include the whole end day for intraday timeframes
+		toDate = toDate.Add(24*time.Hour - time.Second)
+	}
+
+	if !toDate.After(fromDate) {
+		return time.Time{}, time.Time{}, fmt.Errorf("to date must be after from date")
+	}
+
+	return fromDate, toDate, nil
+}
+
+// RunResult converts a symbol result to its stored run report form
+func (r Result) RunResult() database.BackfillSymbolResult {
+	rr := database.BackfillSymbolResult{
+		Symbol:       r.Symbol,
+		BarsInserted: r.BarsInserted,
+		GapsFound:    r.GapsFound,
+	}
+	if r.Error != nil {
+		rr.Error = r.Error.Error()
+	}
+	return rr
+}
+
+// Run backfills all symbols between fromDate and toDate. Cancelling ctx stops
+// dispatching new symbols and interrupts in-flight ones between chunks.
+func (b *Backfiller) Run(ctx context.Context, symbols []string, fromDate, toDate time.Time) *Stats {
+	startTime := time.Now()
+	stats := &Stats{
+		TotalSymbols: len(symbols),
+	}
+
+	ctx, span := tracing.Start(ctx, "backfill.run",
+		attribute.Int("backfill.symbols", len(symbols)),
+		attribute.String("backfill.timeframe", b.opts.Timeframe),
+		attribute.String("backfill.mode", b.opts.Mode),
+		attribute.String("backfill.from", fromDate.Format("2006-01-02")),
+		attribute.String("backfill.to", toDate.Format("2006-01-02")),
+	)
+	defer func() {
+		span.SetAttributes(
+			attribute.Int("backfill.failed", stats.Failed),
+			attribute.Int("backfill.bars_inserted", stats.TotalBars),
+		)
+		span.End()
+	}()
+
+	limiter := b.limiter
+	if limiter == nil {
+		limiter = ratelimit.NewLimiter(b.opts.Rate, int(b.opts.Rate))
+	}
+
+	// Create semaphore for concurrency control
+	semaphore := make(chan struct{}, b.opts.Concurrent)
+	results := make(chan Result, len(symbols))
+
+	var wg sync.WaitGroup
+
+	// Process each symbol
+	for _, symbol := range symbols {
+		select {
+		case semaphore <- struct{}{}: // Acquire semaphore
+		case <-ctx.Done():
+			results <- Result{Symbol: symbol, Error: ErrCancelled}
+			continue
+		}
+
+		wg.Add(1)
+		go func(sym string) {
+			defer wg.Done()
+			defer func() { <-semaphore }() // Release semaphore
+
+			results <- b.backfillSymbol(ctx, limiter, sym, fromDate, toDate)
+		}(symbol)
+	}
+
+	// Collect results
+	for i := 0; i < len(symbols); i++ {
+		result := <-results
+
+		stats.Requests += result.Chunks + result.Retries
+		stats.Retries += result.Retries
+		stats.TotalBars += result.BarsInserted
+		stats.GapsFound += result.GapsFound
+
+		if result.Error != nil {
+			stats.Failed++
+			log.Printf("❌ %s: %v", result.Symbol, result.Error)
+		} else {
+			stats.Successful++
+			log.Printf("✅ %s: %d bars inserted", result.Symbol, result.BarsInserted)
+		}
+
+		stats.Results = append(stats.Results, result)
+
+		if b.OnResult != nil {
+			b.OnResult(result)
+		}
+	}
+
+	wg.Wait()
+
+	sort.Slice(stats.Results, func(i, j int) bool {
+		return stats.Results[i].Symbol < stats.Results[j].Symbol
+	})
+
+	stats.Duration = time.Since(startTime)
+	return stats
+}
+
+// backfillSymbol backfills data for a single symbol
+func (b *Backfiller) backfillSymbol(ctx context.Context, limiter *ratelimit.Limiter, symbol string, fromDate, toDate time.Time) (result Result) {
+	result = Result{Symbol: symbol}
+
+	ctx, span := tracing.Start(ctx, "backfill.symbol", attribute.String("symbol", symbol))
+	defer func() {
+		span.SetAttributes(
+			attribute.Int("backfill.chunks", result.Chunks),
+			attribute.Int("backfill.retries", result.Retries),
+			attribute.Int("backfill.bars_inserted", result.BarsInserted),
+		)
+		tracing.End(span, result.Error)
+	}()
+
+	// Get instrument token
+	exchange := "NSE"
+	token, err := b.db.GetInstrumentToken(exchange, symbol)
+	if err != nil || token == 0 {
+		exchange = "BSE"
+		token, err = b.db.GetInstrumentToken(exchange, symbol)
+		if err != nil || token == 0 {
+			result.Error = fmt.Errorf("instrument token not found")
+			return result
+		}
+	}
+
+	chunks := splitDateRange(fromDate, toDate, maxChunkDays[b.barTimeframe])
+
+	// In fill-gaps mode only missing windows are fetched, and only bars at
+	// missing timestamps are inserted
+	var missing map[int64]bool
+	if b.opts.Mode == ModeFillGaps {
+		gaps, err := b.findGaps(exchange, symbol, fromDate, toDate)
+		if err != nil {
+			result.Error = fmt.Errorf("failed to detect gaps: %w", err)
+			return result
+		}
+
+		result.GapsFound = len(gaps)
+		if len(gaps) == 0 {
+			log.Printf("✅ %s: no gaps", symbol)
+			return result
+		}
+
+		missing = make(map[int64]bool, len(gaps))
+		for _, gap := range gaps {
+			missing[gap.Unix()] = true
+		}
+		chunks = gapWindows(gaps, b.barTimeframe.Duration(), maxChunkDays[b.barTimeframe])
+
+		log.Printf("🔍 %s: %d missing bars in %d fetch windows", symbol, len(gaps), len(chunks))
+	}
+
+	log.Printf("🔄 Fetching data for %s (token: %d)...", symbol, token)
+
+	for _, chunk := range chunks {
+		if ctx.Err() != nil {
+			result.Error = ErrCancelled
+			return result
+		}
+
+		candles, retries, err := b.fetchChunk(ctx, limiter, token, chunk[0], chunk[1])
+		result.Chunks++
+		result.Retries += retries
+		if err != nil {
+			result.Error = fmt.Errorf("failed to fetch %s to %s: %w",
+				chunk[0].Format("2006-01-02"), chunk[1].Format("2006-01-02"), err)
+			return result
+		}
+
+		if missing != nil {
+			filtered := candles[:0]
+			for _, candle := range candles {
+				if missing[candle.Date.Unix()] {
+					filtered = append(filtered, candle)
+				}
+			}
+			candles = filtered
+		}
+
+		if len(candles) == 0 {
+			continue
+		}
+
+		bars := database.ConvertBrokerCandlesToIntradayBars(
+			candles, exchange, symbol, int64(token), b.barTimeframe.String(), b.opts.Source)
+
+		if b.opts.DryRun {
+			log.Printf("   [DRY RUN] %s: would insert %d bars (%s to %s)",
+				symbol, len(bars), chunk[0].Format("2006-01-02"), chunk[1].Format("2006-01-02"))
+		} else if err := b.storeBars(ctx, bars); err != nil {
+			result.Error = fmt.Errorf("failed to insert bars: %w", err)
+			return result
+		}
+
+		result.BarsInserted += len(bars)
+	}
+
+	return result
+}
+
+// storeBars inserts a chunk's bars
+func (b *Backfiller) storeBars(ctx context.Context, bars []database.IntradayBar) error {
+	_, span := tracing.Start(ctx, "backfill.store", attribute.Int("backfill.bars", len(bars)))
+	err := b.db.BulkInsertIntradayBars(bars)
+	tracing.End(span, err)
+	return err
+}
+
+// fetchChunk fetches one date range from the broker, retrying with exponential backoff
+func (b *Backfiller) fetchChunk(ctx context.Context, limiter *ratelimit.Limiter, token uint32, from, to time.Time) (candles []broker.Candle, retries int, err error) {
+	instrument := strconv.FormatUint(uint64(token), 10)
+	backoff := time.Second
+
+	ctx, span := tracing.Start(ctx, "backfill.fetch_chunk",
+		attribute.String("broker.instrument", instrument),
+		attribute.String("backfill.from", from.Format("2006-01-02")),
+		attribute.String("backfill.to", to.Format("2006-01-02")),
+	)
+	defer func() {
+		span.SetAttributes(attribute.Int("backfill.retries", retries))
+		tracing.End(span, err)
+	}()
+	brk := tracing.Broker(ctx, b.broker)
+
+	var lastErr error
+	for attempt := 0; attempt <= b.opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			log.Printf("⚠️  Retry %d/%d for token %d after error: %v", attempt, b.opts.MaxRetries, token, lastErr)
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return nil, attempt - 1, ErrCancelled
+			}
+			backoff *= 2
+		}
+
+		if err := limiter.Wait(ctx); err != nil {
+			return nil, attempt, ErrCancelled
+		}
+
+		candles, err := brk.GetHistoricalData(instrument, from, to, b.opts.Timeframe)
+		if err == nil {
+			return candles, attempt, nil
+		}
+		lastErr = err
+	}
+
+	return nil, b.opts.MaxRetries, lastErr
+}
@@ -0,0 +1,76 @@
+package backfill
+
+import (
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/timeframe"
+)
+
+// findGaps returns missing bar timestamps for a symbol on an exchange. The
+// database only expects bars in trading sessions (9:15-15:30 IST on trading
+// days, see broker.IsIndianTradingDay), so weekends and holidays are never
+// gaps.
+func (b *Backfiller) findGaps(exchange, symbol string, fromDate, toDate time.Time) ([]time.Time, error) {
+	// Intraday series start at the 9:15 open so 1h bars line up with the exchange
+	seriesStart := fromDate
+	if b.barTimeframe != timeframe.Day {
+		seriesStart = fromDate.Add(9*time.Hour + 15*time.Minute)
+	}
+
+	rows, err := b.db.GetDataGaps(exchange, symbol, b.barTimeframe.String(), seriesStart, toDate)
+	if err != nil {
+		return nil, err
+	}
+
+	gaps := make([]time.Time, 0, len(rows))
+	for _, row := range rows {
+		if ts, ok := row["missing_timestamp"].(time.Time); ok {
+			gaps = append(gaps, ts)
+		}
+	}
+
+	return gaps, nil
+}
+
+// gapWindows groups sorted missing timestamps into fetch ranges of at most
+// maxDays, so each broker request covers as many gaps as it can
+func gapWindows(gaps []time.Time, step time.Duration, maxDays int) [][2]time.Time {
+	if len(gaps) == 0 {
+		return nil
+	}
+
+	maxSpan := time.Duration(maxDays) * 24 * time.Hour
+
+	var windows [][2]time.Time
+	start, end := gaps[0], gaps[0]
+	for _, gap := range gaps[1:] {
+		if gap.Sub(start)+step <= maxSpan {
+			end = gap
+			continue
+		}
+		windows = append(windows, [2]time.Time{start, end.Add(step - time.Second)})
+		start, end = gap, gap
+	}
+	windows = append(windows, [2]time.Time{start, end.Add(step - time.Second)})
+
+	return windows
+}
+
+// splitDateRange splits [from, to] into consecutive ranges of at most maxDays days
+func splitDateRange(from, to time.Time, maxDays int) [][2]time.Time {
+	if maxDays <= 0 {
+		maxDays = 60
+	}
+
+	var chunks [][2]time.Time
+	for start := from; start.Before(to); {
+		end := start.AddDate(0, 0, maxDays)
+		if end.After(to) {
+			end = to
+		}
+		chunks = append(chunks, [2]time.Time{start, end})
+		start = end.Add(time.Second)
+	}
+
+	return chunks
+}
@@ -0,0 +1,300 @@
+package backfill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/ratelimit"
+)
+
+// ErrJobNotFound is returned for unknown job IDs
+var ErrJobNotFound = errors.New("backfill job not found")
+
+// ErrJobFinished is returned when cancelling a job that is no longer running
+var ErrJobFinished = errors.New("backfill job already finished")
+
+// JobStatus is a point-in-time view of a backfill job
+type JobStatus struct {
+	ID               int64             `json:"job_id"`
+	Status           string            `json:"status"`
+	Timeframe        string            `json:"timeframe"`
+	Mode             string            `json:"mode"`
+	DryRun           bool              `json:"dry_run"`
+	FromDate         time.Time         `json:"from_date"`
+	ToDate           time.Time         `json:"to_date"`
+	TotalSymbols     int               `json:"total_symbols"`
+	SymbolsCompleted int               `json:"symbols_completed"`
+	Successful       int               `json:"successful"`
+	Failed           int               `json:"failed"`
+	BarsInserted     int               `json:"bars_inserted"`
+	Progress         float64           `json:"progress"` // Percent of symbols completed
+	Errors           map[string]string `json:"errors,omitempty"`
+	StartedAt        time.Time         `json:"started_at"`
+	FinishedAt       *time.Time        `json:"finished_at,omitempty"`
+}
+
+// job tracks a running or finished backfill
+type job struct {
+	status JobStatus
+	run    *database.BackfillRun
+	cancel context.CancelFunc
+	mu     sync.RWMutex
+}
+
+func (j *job) snapshot() JobStatus {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	status := j.status
+	status.Errors = make(map[string]string, len(j.status.Errors))
+	for symbol, msg := range j.status.Errors {
+		status.Errors[symbol] = msg
+	}
+	return status
+}
+
+// JobManager runs backfill jobs in the background. All jobs share one rate
+// limiter so concurrent jobs stay within the broker's historical API limit.
+type JobManager struct {
+	broker  broker.Broker
+	db      *database.Database
+	limiter *ratelimit.Limiter
+
+	jobs map[int64]*job
+	mu   sync.RWMutex
+}
+
+// NewJobManager creates a job manager limited to rate historical requests/sec
+func NewJobManager(brk broker.Broker, db *database.Database, rate float64) *JobManager {
+	if rate <= 0 {
+		rate = 3
+	}
+
+	return &JobManager{
+		broker:  brk,
+		db:      db,
+		limiter: ratelimit.NewLimiter(rate, int(rate)),
+		jobs:    make(map[int64]*job),
+	}
+}
+
+// Submit validates the request, records a run and starts the backfill in the
+// background. The returned job ID is the md.backfill_runs run ID.
+func (m *JobManager) Submit(symbols []string, fromDate, toDate time.Time, opts Options) (JobStatus, error) {
+	if len(symbols) == 0 {
+		return JobStatus{}, fmt.Errorf("no symbols to backfill")
+	}
+
+	backfiller, err := New(m.broker, m.db, opts)
+	if err != nil {
+		return JobStatus{}, err
+	}
+	backfiller.limiter = m.limiter
+	opts = backfiller.opts
+
+	run := &database.BackfillRun{
+		Trigger:      "api",
+		Timeframe:    opts.Timeframe,
+		FromDate:     fromDate,
+		ToDate:       toDate,
+		TotalSymbols: len(symbols),
+	}
+	if err := m.db.CreateBackfillRun(run); err != nil {
+		return JobStatus{}, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &job{
+		run:    run,
+		cancel: cancel,
+		status: JobStatus{
+			ID:           run.RunID,
+			Status:       database.BackfillRunning,
+			Timeframe:    opts.Timeframe,
+			Mode:         opts.Mode,
+			DryRun:       opts.DryRun,
+			FromDate:     fromDate,
+			ToDate:       toDate,
+			TotalSymbols: len(symbols),
+			Errors:       make(map[string]string),
+			StartedAt:    run.StartedAt,
+		},
+	}
+
+	backfiller.OnResult = func(result Result) {
+		j.mu.Lock()
+		defer j.mu.Unlock()
+
+		j.status.SymbolsCompleted++
+		j.status.BarsInserted += result.BarsInserted
+		if result.Error != nil {
+			j.status.Failed++
+			j.status.Errors[result.Symbol] = result.Error.Error()
+		} else {
+			j.status.Successful++
+		}
+		j.status.Progress = float64(j.status.SymbolsCompleted) / float64(j.status.TotalSymbols) * 100
+	}
+
+	m.mu.Lock()
+	m.jobs[run.RunID] = j
+	m.mu.Unlock()
+
+	log.Printf("🚀 Backfill job #%d started: %d symbols, %s %s to %s",
+		run.RunID, len(symbols), opts.Timeframe, fromDate.Format("2006-01-02"), toDate.Format("2006-01-02"))
+
+	go m.execute(ctx, j, backfiller, symbols, fromDate, toDate)
+
+	return j.snapshot(), nil
+}
+
+func (m *JobManager) execute(ctx context.Context, j *job, backfiller *Backfiller, symbols []string, fromDate, toDate time.Time) {
+	defer j.cancel()
+
+	stats := backfiller.Run(ctx, symbols, fromDate, toDate)
+
+	run := j.run
+	run.Successful = stats.Successful
+	run.Failed = stats.Failed
+	run.TotalBars = stats.TotalBars
+	for _, result := range stats.Results {
+		run.Results = append(run.Results, result.RunResult())
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		run.Status = database.BackfillCancelled
+	case stats.Failed > 0 && stats.Successful == 0:
+		run.Status = database.BackfillFailed
+	default:
+		run.Status = database.BackfillCompleted
+	}
+
+	if err := m.db.FinishBackfillRun(run); err != nil {
+		log.Printf("❌ Failed to save backfill job #%d: %v", run.RunID, err)
+	}
+
+	finishedAt := time.Now()
+	if run.FinishedAt != nil {
+		finishedAt = *run.FinishedAt
+	}
+
+	j.mu.Lock()
+	j.status.Status = run.Status
+	j.status.FinishedAt = &finishedAt
+	j.mu.Unlock()
+
+	log.Printf("📊 Backfill job #%d %s: %d/%d symbols, %d bars in %v",
+		run.RunID, run.Status, stats.Successful, stats.TotalSymbols, stats.TotalBars, stats.Duration)
+}
+
+// Get returns the status of a job. Jobs from before a restart are served
+// from their stored run report.
+func (m *JobManager) Get(id int64) (JobStatus, error) {
+	m.mu.RLock()
+	j, ok := m.jobs[id]
+	m.mu.RUnlock()
+
+	if ok {
+		return j.snapshot(), nil
+	}
+
+	run, err := m.db.GetBackfillRun(id)
+	if err != nil {
+		return JobStatus{}, err
+	}
+	if run == nil {
+		return JobStatus{}, ErrJobNotFound
+	}
+
+	return statusFromRun(run), nil
+}
+
+// List returns in-memory jobs, newest first
+func (m *JobManager) List() []JobStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	jobs := make([]JobStatus, 0, len(m.jobs))
+	for _, j := range m.jobs {
+		jobs = append(jobs, j.snapshot())
+	}
+
+	sort.Slice(jobs, func(i, k int) bool {
+		return jobs[i].ID > jobs[k].ID
+	})
+
+	return jobs
+}
+
+// Cancel stops a running job. Symbols already in flight stop at their next
+// chunk; completed bars are kept.
+func (m *JobManager) Cancel(id int64) error {
+	m.mu.RLock()
+	j, ok := m.jobs[id]
+	m.mu.RUnlock()
+
+	if !ok {
+		return ErrJobNotFound
+	}
+
+	j.mu.RLock()
+	running := j.status.Status == database.BackfillRunning
+	j.mu.RUnlock()
+
+	if !running {
+		return ErrJobFinished
+	}
+
+	j.cancel()
+	log.Printf("🛑 Backfill job #%d cancellation requested", id)
+	return nil
+}
+
+// Stop cancels all running jobs
+func (m *JobManager) Stop() {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, j := range m.jobs {
+		j.cancel()
+	}
+}
+
+// statusFromRun builds a job status from a stored run report
+func statusFromRun(run *database.BackfillRun) JobStatus {
+	status := JobStatus{
+		ID:               run.RunID,
+		Status:           run.Status,
+		Timeframe:        run.Timeframe,
+		FromDate:         run.FromDate,
+		ToDate:           run.ToDate,
+		TotalSymbols:     run.TotalSymbols,
+		SymbolsCompleted: len(run.Results),
+		Successful:       run.Successful,
+		Failed:           run.Failed,
+		BarsInserted:     run.TotalBars,
+		Errors:           make(map[string]string),
+		StartedAt:        run.StartedAt,
+		FinishedAt:       run.FinishedAt,
+	}
+
+	if run.TotalSymbols > 0 {
+		status.Progress = float64(status.SymbolsCompleted) / float64(run.TotalSymbols) * 100
+	}
+
+	for _, result := range run.Results {
+		if result.Error != "" {
+			status.Errors[result.Symbol] = result.Error
+		}
+	}
+
+	return status
+}
@@ -0,0 +1,431 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// Brokerage models per-order charges. The defaults approximate a discount
+// broker's equity intraday charges.
+type Brokerage struct {
+	Pct          float64 `json:"pct"`           // Percent of order value
+	MaxPerOrder  float64 `json:"max_per_order"` // Cap on the percentage charge (0 = no cap)
+	FlatPerOrder float64 `json:"flat_per_order"`
+	TaxesPct     float64 `json:"taxes_pct"` // STT, exchange, GST and stamp duty, as percent of order value
+}
+
+// DefaultBrokerage is 0.03% capped at ₹20 per order plus ~0.04% statutory charges
+var DefaultBrokerage = Brokerage{
+	Pct:         0.03,
+	MaxPerOrder: 20,
+	TaxesPct:    0.04,
+}
+
+// charges returns the cost of one executed order
+func (b Brokerage) charges(value float64) float64 {
+	fee := value * b.Pct / 100
+	if b.MaxPerOrder > 0 && fee > b.MaxPerOrder {
+		fee = b.MaxPerOrder
+	}
+	return fee + b.FlatPerOrder + value*b.TaxesPct/100
+}
+
+// Config controls a backtest run
+type Config struct {
+	Symbol          string     `json:"symbol"`
+	InitialCapital  float64    `json:"initial_capital"`
+	PositionSizePct float64    `json:"position_size_pct"` // Percent of equity per trade (default 100)
+	FixedQuantity   int64      `json:"fixed_quantity"`    // Shares per trade, overrides PositionSizePct
+	FixedAmount     float64    `json:"fixed_amount"`      // Rupees per trade, overrides PositionSizePct
+	RiskPct         float64    `json:"risk_pct"`          // Percent of equity risked to the stop, overrides PositionSizePct
+	SlippagePct     float64    `json:"slippage_pct"`      // Adverse slippage per fill
+	StopLossPct     float64    `json:"stop_loss_pct"`     // Default stop (0 = none)
+	TakeProfitPct   float64    `json:"take_profit_pct"`   // Default target (0 = none)
+	AllowShort      bool       `json:"allow_short"`
+	Brokerage       *Brokerage `json:"brokerage,omitempty"`
+}
+
+// Trade is one completed round trip
+type Trade struct {
+	Side        string    `json:"side"` // LONG or SHORT
+	EntryTime   time.Time `json:"entry_time"`
+	EntryPrice  float64   `json:"entry_price"`
+	ExitTime    time.Time `json:"exit_time"`
+	ExitPrice   float64   `json:"exit_price"`
+	Quantity    int64     `json:"quantity"`
+	GrossPnL    float64   `json:"gross_pnl"`
+	Charges     float64   `json:"charges"`
+	NetPnL      float64   `json:"net_pnl"`
+	ReturnPct   float64   `json:"return_pct"`
+	BarsHeld    int       `json:"bars_held"`
+	EntryReason string    `json:"entry_reason"`
+	ExitReason  string    `json:"exit_reason"`
+}
+
+// EquityPoint is the marked-to-market equity at a bar's close
+type EquityPoint struct {
+	Time        time.Time `json:"time"`
+	Equity      float64   `json:"equity"`
+	DrawdownPct float64   `json:"drawdown_pct"`
+}
+
+// Metrics summarizes a run
+type Metrics struct {
+	InitialCapital float64 `json:"initial_capital"`
+	FinalEquity    float64 `json:"final_equity"`
+	NetProfit      float64 `json:"net_profit"`
+	TotalReturnPct float64 `json:"total_return_pct"`
+	MaxDrawdownPct float64 `json:"max_drawdown_pct"`
+	TotalTrades    int     `json:"total_trades"`
+	Wins           int     `json:"wins"`
+	Losses         int     `json:"losses"`
+	WinRate        float64 `json:"win_rate"`
+	AvgWin         float64 `json:"avg_win"`
+	AvgLoss        float64 `json:"avg_loss"`
+	ProfitFactor   float64 `json:"profit_factor"`
+	TotalCharges   float64 `json:"total_charges"`
+	ExposurePct    float64 `json:"exposure_pct"` // Percent of bars with an open position
+}
+
+// Result is the full output of a backtest
+type Result struct {
+	Symbol      string        `json:"symbol"`
+	Strategy    string        `json:"strategy"`
+	From        time.Time     `json:"from"`
+	To          time.Time     `json:"to"`
+	Bars        int           `json:"bars"`
+	Metrics     Metrics       `json:"metrics"`
+	EquityCurve []EquityPoint `json:"equity_curve"`
+	Trades      []Trade       `json:"trades"`
+}
+
+// position is the currently open trade
+type position struct {
+	side        string
+	quantity    int64
+	entryPrice  float64
+	entryTime   time.Time
+	entryBar    int
+	entryCharge float64
+	stopLoss    float64
+	takeProfit  float64
+	reason      string
+}
+
+// Run simulates a strategy over candles. Signals are taken at a bar's close
+// and filled at the next bar's open; stops and targets are checked against
+// each bar's range, with the stop assumed to hit first when both are touched.
+func Run(strategy Strategy, candles []broker.Candle, config Config) (*Result, error) {
+	if config.InitialCapital <= 0 {
+		config.InitialCapital = 100000
+	}
+	if config.PositionSizePct <= 0 || config.PositionSizePct > 100 {
+		config.PositionSizePct = 100
+	}
+	if config.SlippagePct < 0 {
+		return nil, fmt.Errorf("slippage_pct cannot be negative")
+	}
+
+	brokerage := DefaultBrokerage
+	if config.Brokerage != nil {
+		brokerage = *config.Brokerage
+	}
+
+	indexed, isIndexed := strategy.(IndexedStrategy)
+	if isIndexed {
+		indexed.Prepare(candles)
+	}
+
+	warmup := strategy.Warmup()
+	if len(candles) <= warmup+1 {
+		return nil, fmt.Errorf("insufficient data: %s needs more than %d candles, got %d",
+			strategy.Name(), warmup+1, len(candles))
+	}
+
+	result := &Result{
+		Symbol:   config.Symbol,
+		Strategy: strategy.Name(),
+		From:     candles[0].Date,
+		To:       candles[len(candles)-1].Date,
+		Bars:     len(candles),
+		Trades:   []Trade{},
+	}
+
+	cash := config.InitialCapital
+	peak := cash
+	barsInMarket := 0
+	var pos *position
+	var pending *Decision
+
+	closePosition := func(bar int, rawPrice float64, reason string) {
+		exitPrice := slip(rawPrice, config.SlippagePct, pos.side == "SHORT")
+		value := exitPrice * float64(pos.quantity)
+		exitCharge := brokerage.charges(value)
+
+		gross := (exitPrice - pos.entryPrice) * float64(pos.quantity)
+		if pos.side == "SHORT" {
+			gross = -gross
+		}
+		charges := pos.entryCharge + exitCharge
+		net := gross - charges
+
+		if pos.side == "LONG" {
+			cash += value - exitCharge
+		} else {
+			cash -= value + exitCharge
+		}
+
+		result.Trades = append(result.Trades, Trade{
+			Side:        pos.side,
+			EntryTime:   pos.entryTime,
+			EntryPrice:  round2(pos.entryPrice),
+			ExitTime:    candles[bar].Date,
+			ExitPrice:   round2(exitPrice),
+			Quantity:    pos.quantity,
+			GrossPnL:    round2(gross),
+			Charges:     round2(charges),
+			NetPnL:      round2(net),
+			ReturnPct:   round2(net / (pos.entryPrice * float64(pos.quantity)) * 100),
+			BarsHeld:    bar - pos.entryBar,
+			EntryReason: pos.reason,
+			ExitReason:  reason,
+		})
+		pos = nil
+	}
+
+	openPosition := func(bar int, decision *Decision) {
+		side := "LONG"
+		if decision.Action == ActionSell {
+			side = "SHORT"
+		}
+
+		entryPrice := slip(candles[bar].Open, config.SlippagePct, side == "LONG")
+
+		stopPct, targetPct := config.StopLossPct, config.TakeProfitPct
+		if decision.StopLossPct > 0 {
+			stopPct = decision.StopLossPct
+		}
+		if decision.TakeProfitPct > 0 {
+			targetPct = decision.TakeProfitPct
+		}
+
+		quantity := positionSize(config, brokerage, cash, entryPrice, stopPct)
+		if quantity <= 0 {
+			return
+		}
+
+		value := entryPrice * float64(quantity)
+		charge := brokerage.charges(value)
+
+		if side == "LONG" {
+			cash -= value + charge
+		} else {
+			cash += value - charge
+		}
+
+		pos = &position{
+			side:        side,
+			quantity:    quantity,
+			entryPrice:  entryPrice,
+			entryTime:   candles[bar].Date,
+			entryBar:    bar,
+			entryCharge: charge,
+			reason:      decision.Reason,
+		}
+
+		if side == "LONG" {
+			if stopPct > 0 {
+				pos.stopLoss = entryPrice * (1 - stopPct/100)
+			}
+			if targetPct > 0 {
+				pos.takeProfit = entryPrice * (1 + targetPct/100)
+			}
+		} else {
+			if stopPct > 0 {
+				pos.stopLoss = entryPrice * (1 + stopPct/100)
+			}
+			if targetPct > 0 {
+				pos.takeProfit = entryPrice * (1 - targetPct/100)
+			}
+		}
+	}
+
+	for i := warmup; i < len(candles); i++ {
+		bar := candles[i]
+
+		// Execute the previous bar's decision at this bar's open
+		if pending != nil {
+			wantLong := pending.Action == ActionBuy
+			wantShort := pending.Action == ActionSell && config.AllowShort
+
+			if pos != nil {
+				reverse := (pos.side == "LONG" && pending.Action == ActionSell) ||
+					(pos.side == "SHORT" && pending.Action == ActionBuy)
+				if reverse || pending.Action == ActionExit {
+					closePosition(i, bar.Open, "signal: "+pending.Reason)
+				}
+			}
+			if pos == nil && (wantLong || wantShort) {
+				openPosition(i, pending)
+			}
+			pending = nil
+		}
+
+		// Intrabar stop loss and take profit
+		if pos != nil {
+			if pos.side == "LONG" {
+				switch {
+				case pos.stopLoss > 0 && bar.Low <= pos.stopLoss:
+					closePosition(i, math.Min(bar.Open, pos.stopLoss), "stop loss")
+				case pos.takeProfit > 0 && bar.High >= pos.takeProfit:
+					closePosition(i, math.Max(bar.Open, pos.takeProfit), "take profit")
+				}
+			} else {
+				switch {
+				case pos.stopLoss > 0 && bar.High >= pos.stopLoss:
+					closePosition(i, math.Max(bar.Open, pos.stopLoss), "stop loss")
+				case pos.takeProfit > 0 && bar.Low <= pos.takeProfit:
+					closePosition(i, math.Min(bar.Open, pos.takeProfit), "take profit")
+				}
+			}
+		}
+
+		if pos != nil {
+			barsInMarket++
+		}
+
+		// Mark to market at the close
+		equity := cash
+		if pos != nil {
+			if pos.side == "LONG" {
+				equity += bar.Close * float64(pos.quantity)
+			} else {
+				equity -= bar.Close * float64(pos.quantity)
+			}
+		}
+		if equity > peak {
+			peak = equity
+		}
+		drawdown := 0.0
+		if peak > 0 {
+			drawdown = (peak - equity) / peak * 100
+		}
+		result.EquityCurve = append(result.EquityCurve, EquityPoint{
+			Time:        bar.Date,
+			Equity:      round2(equity),
+			DrawdownPct: round2(drawdown),
+		})
+
+		// Decide on this bar's close, to be filled at the next open
+		if i < len(candles)-1 {
+			var decision Decision
+			if isIndexed {
+				decision = indexed.EvaluateAt(i)
+			} else {
+				decision = strategy.Evaluate(candles[:i+1])
+			}
+			if decision.Action != ActionHold && decision.Action != "" {
+				pending = &decision
+			}
+		}
+	}
+
+	// Close anything still open at the last close
+	if pos != nil {
+		last := len(candles) - 1
+		closePosition(last, candles[last].Close, "end of data")
+		result.EquityCurve[len(result.EquityCurve)-1].Equity = round2(cash)
+	}
+
+	result.Metrics = computeMetrics(config.InitialCapital, cash, result.Trades, result.EquityCurve)
+	if traded := len(candles) - warmup; traded > 0 {
+		result.Metrics.ExposurePct = round2(float64(barsInMarket) / float64(traded) * 100)
+	}
+
+	return result, nil
+}
+
+// positionSize returns the quantity to open, never spending more than the
+// available cash (including charges)
+func positionSize(config Config, brokerage Brokerage, cash, price, stopPct float64) int64 {
+	costPerShare := price * (1 + (brokerage.Pct+brokerage.TaxesPct)/100)
+	maxQuantity := int64(cash / costPerShare)
+
+	var quantity int64
+	switch {
+	case config.FixedQuantity > 0:
+		quantity = config.FixedQuantity
+	case config.FixedAmount > 0:
+		quantity = int64(config.FixedAmount / costPerShare)
+	case config.RiskPct > 0 && stopPct > 0:
+		riskPerShare := price * stopPct / 100
+		quantity = int64(cash * config.RiskPct / 100 / riskPerShare)
+	default:
+		quantity = int64(cash * config.PositionSizePct / 100 / costPerShare)
+	}
+
+	if quantity > maxQuantity {
+		quantity = maxQuantity
+	}
+	return quantity
+}
+
+// computeMetrics derives summary statistics from trades and the equity curve
+func computeMetrics(initial, final float64, trades []Trade, curve []EquityPoint) Metrics {
+	m := Metrics{
+		InitialCapital: initial,
+		FinalEquity:    round2(final),
+		NetProfit:      round2(final - initial),
+		TotalReturnPct: round2((final - initial) / initial * 100),
+		TotalTrades:    len(trades),
+	}
+
+	for _, point := range curve {
+		if point.DrawdownPct > m.MaxDrawdownPct {
+			m.MaxDrawdownPct = point.DrawdownPct
+		}
+	}
+
+	grossWin, grossLoss := 0.0, 0.0
+	for _, t := range trades {
+		m.TotalCharges += t.Charges
+		if t.NetPnL > 0 {
+			m.Wins++
+			grossWin += t.NetPnL
+		} else {
+			m.Losses++
+			grossLoss -= t.NetPnL
+		}
+	}
+	m.TotalCharges = round2(m.TotalCharges)
+
+	if m.TotalTrades > 0 {
+		m.WinRate = round2(float64(m.Wins) / float64(m.TotalTrades) * 100)
+	}
+	if m.Wins > 0 {
+		m.AvgWin = round2(grossWin / float64(m.Wins))
+	}
+	if m.Losses > 0 {
+		m.AvgLoss = round2(-grossLoss / float64(m.Losses))
+	}
+	if grossLoss > 0 {
+		m.ProfitFactor = round2(grossWin / grossLoss)
+	}
+
+	return m
+}
+
+// slip moves a fill price against the trader: up when buying, down when selling
+func slip(price, pct float64, buying bool) float64 {
+	if buying {
+		return price * (1 + pct/100)
+	}
+	return price * (1 - pct/100)
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
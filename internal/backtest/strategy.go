@@ -0,0 +1,280 @@
+package backtest
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	"github.com/trading-chitti/market-bridge/internal/analyzer"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// Action is what a strategy wants to do at the close of a bar
+type Action string
+
+// Strategy actions
+const (
+	ActionHold Action = "HOLD"
+	ActionBuy  Action = "BUY"
+	ActionSell Action = "SELL"
+	ActionExit Action = "EXIT"
+)
+
+// Decision is a strategy's output for one bar. StopLossPct and TakeProfitPct
+// override the run defaults for the position opened by this decision.
+type Decision struct {
+	Action        Action
+	StopLossPct   float64
+	TakeProfitPct float64
+	Reason        string
+}
+
+// Strategy evaluates candles up to and including the current bar. The engine
+// never passes future bars, so strategies can't look ahead.
+type Strategy interface {
+	Name() string
+	Warmup() int
+	Evaluate(candles []broker.Candle) Decision
+}
+
+// IndexedStrategy is implemented by strategies that compute indicator series
+// once per run. The engine calls Prepare with all candles and then EvaluateAt
+// for each bar index; implementations must only use data up to that index.
+type IndexedStrategy interface {
+	Strategy
+	Prepare(candles []broker.Candle)
+	EvaluateAt(i int) Decision
+}
+
+// StrategyConfig selects a built-in strategy and its parameters
+type StrategyConfig struct {
+	Name   string             `json:"name"`
+	Params map[string]float64 `json:"params"`
+}
+
+// StrategyInfo describes a built-in strategy
+type StrategyInfo struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Defaults    map[string]float64 `json:"defaults"`
+}
+
+// builtins lists the strategies NewStrategy can build
+var builtins = map[string]StrategyInfo{
+	"analyzer": {
+		Name:        "analyzer",
+		Description: "52-day analyzer signals (RSI, trend+volume, Bollinger); uses the signal's stop and target",
+		Defaults:    map[string]float64{"lookback": 52, "min_confidence": 0.65},
+	},
+	"sma_crossover": {
+		Name:        "sma_crossover",
+		Description: "Buy when the fast SMA crosses above the slow SMA, sell on the reverse cross",
+		Defaults:    map[string]float64{"fast": 20, "slow": 50},
+	},
+	"rsi": {
+		Name:        "rsi",
+		Description: "Buy when RSI crosses up through oversold, sell when it crosses down through overbought",
+		Defaults:    map[string]float64{"period": 14, "oversold": 30, "overbought": 70},
+	},
+}
+
+// ListStrategies returns the built-in strategies sorted by name
+func ListStrategies() []StrategyInfo {
+	infos := make([]StrategyInfo, 0, len(builtins))
+	for _, info := range builtins {
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// NewStrategy builds a built-in strategy, filling unset params from defaults
+func NewStrategy(config StrategyConfig) (Strategy, error) {
+	info, ok := builtins[config.Name]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy: %s", config.Name)
+	}
+
+	params := make(map[string]float64, len(info.Defaults))
+	for k, v := range info.Defaults {
+		params[k] = v
+	}
+	for k, v := range config.Params {
+		if _, known := info.Defaults[k]; !known {
+			return nil, fmt.Errorf("unknown parameter %q for strategy %s", k, config.Name)
+		}
+		params[k] = v
+	}
+
+	switch config.Name {
+	case "analyzer":
+		a := analyzer.NewAnalyzer52D()
+		a.SetLogLevel(logrus.WarnLevel)
+		lookback := int(params["lookback"])
+		if lookback < 30 {
+			return nil, fmt.Errorf("lookback must be at least 30")
+		}
+		return &analyzerStrategy{
+			analyzer:      a,
+			lookback:      lookback,
+			minConfidence: params["min_confidence"],
+		}, nil
+
+	case "sma_crossover":
+		fast, slow := int(params["fast"]), int(params["slow"])
+		if fast <= 0 || slow <= fast {
+			return nil, fmt.Errorf("sma_crossover needs 0 < fast < slow")
+		}
+		return &smaCrossover{fast: fast, slow: slow}, nil
+
+	case "rsi":
+		period := int(params["period"])
+		if period <= 1 || params["oversold"] >= params["overbought"] {
+			return nil, fmt.Errorf("rsi needs period > 1 and oversold < overbought")
+		}
+		return &rsiStrategy{
+			period:     period,
+			oversold:   params["oversold"],
+			overbought: params["overbought"],
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown strategy: %s", config.Name)
+}
+
+// ============================================================================
+// ANALYZER SIGNALS
+// ============================================================================
+
+type analyzerStrategy struct {
+	analyzer      *analyzer.Analyzer52D
+	lookback      int
+	minConfidence float64
+}
+
+func (s *analyzerStrategy) Name() string { return "analyzer" }
+func (s *analyzerStrategy) Warmup() int  { return s.lookback }
+
+func (s *analyzerStrategy) Evaluate(candles []broker.Candle) Decision {
+	window := candles[len(candles)-s.lookback:]
+
+	analysis, err := s.analyzer.Analyze("", window)
+	if err != nil {
+		return Decision{Action: ActionHold}
+	}
+
+	// Act on the most confident signal
+	var best *analyzer.Signal
+	for i := range analysis.Signals {
+		sig := &analysis.Signals[i]
+		if sig.Confidence < s.minConfidence {
+			continue
+		}
+		if best == nil || sig.Confidence > best.Confidence {
+			best = sig
+		}
+	}
+	if best == nil || best.EntryPrice <= 0 {
+		return Decision{Action: ActionHold}
+	}
+
+	decision := Decision{
+		Action: Action(best.Type),
+		Reason: fmt.Sprintf("%s (%.0f%%): %s", best.Strategy, best.Confidence*100, best.Reason),
+	}
+
+	// Signal levels are relative to the analyzer's reference price
+	switch decision.Action {
+	case ActionBuy:
+		decision.StopLossPct = (best.EntryPrice - best.StopLoss) / best.EntryPrice * 100
+		decision.TakeProfitPct = (best.TakeProfit - best.EntryPrice) / best.EntryPrice * 100
+	case ActionSell:
+		decision.StopLossPct = (best.StopLoss - best.EntryPrice) / best.EntryPrice * 100
+		decision.TakeProfitPct = (best.EntryPrice - best.TakeProfit) / best.EntryPrice * 100
+	}
+
+	return decision
+}
+
+// ============================================================================
+// SMA CROSSOVER
+// ============================================================================
+
+type smaCrossover struct {
+	fast, slow int
+}
+
+func (s *smaCrossover) Name() string { return "sma_crossover" }
+func (s *smaCrossover) Warmup() int  { return s.slow + 1 }
+
+func (s *smaCrossover) Evaluate(candles []broker.Candle) Decision {
+	n := len(candles)
+	fastNow, slowNow := smaAt(candles, n, s.fast), smaAt(candles, n, s.slow)
+	fastPrev, slowPrev := smaAt(candles, n-1, s.fast), smaAt(candles, n-1, s.slow)
+
+	switch {
+	case fastPrev <= slowPrev && fastNow > slowNow:
+		return Decision{Action: ActionBuy, Reason: fmt.Sprintf("SMA%d crossed above SMA%d", s.fast, s.slow)}
+	case fastPrev >= slowPrev && fastNow < slowNow:
+		return Decision{Action: ActionSell, Reason: fmt.Sprintf("SMA%d crossed below SMA%d", s.fast, s.slow)}
+	}
+
+	return Decision{Action: ActionHold}
+}
+
+// smaAt returns the SMA of closes over the period ending before index end
+func smaAt(candles []broker.Candle, end, period int) float64 {
+	total := 0.0
+	for _, c := range candles[end-period : end] {
+		total += c.Close
+	}
+	return total / float64(period)
+}
+
+// ============================================================================
+// RSI
+// ============================================================================
+
+type rsiStrategy struct {
+	period               int
+	oversold, overbought float64
+}
+
+func (s *rsiStrategy) Name() string { return "rsi" }
+func (s *rsiStrategy) Warmup() int  { return s.period + 2 }
+
+func (s *rsiStrategy) Evaluate(candles []broker.Candle) Decision {
+	n := len(candles)
+	now := rsiAt(candles, n, s.period)
+	prev := rsiAt(candles, n-1, s.period)
+
+	switch {
+	case prev < s.oversold && now >= s.oversold:
+		return Decision{Action: ActionBuy, Reason: fmt.Sprintf("RSI crossed up through %.0f (%.1f)", s.oversold, now)}
+	case prev > s.overbought && now <= s.overbought:
+		return Decision{Action: ActionSell, Reason: fmt.Sprintf("RSI crossed down through %.0f (%.1f)", s.overbought, now)}
+	}
+
+	return Decision{Action: ActionHold}
+}
+
+// rsiAt returns the simple-average RSI over the period ending before index end
+func rsiAt(candles []broker.Candle, end, period int) float64 {
+	gains, losses := 0.0, 0.0
+	for i := end - period; i < end; i++ {
+		change := candles[i].Close - candles[i-1].Close
+		if change > 0 {
+			gains += change
+		} else {
+			losses -= change
+		}
+	}
+
+	if losses == 0 {
+		return 100
+	}
+	rs := gains / losses
+	return 100 - 100/(1+rs)
+}
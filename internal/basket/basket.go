@@ -0,0 +1,298 @@
+// Package basket places several orders as one basket, such as the legs of
+// a hedged option spread, in dependency order, cancelling the legs already
+// placed when a later one fails
+package basket
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// MaxLegs is the most legs a basket may have
+const MaxLegs = 20
+
+// What happens to the rest of a basket when a leg fails
+const (
+	OnFailureRollback = "rollback" // Stop and cancel the legs already placed
+	OnFailureStop     = "stop"     // Stop, leaving the legs already placed
+	OnFailureContinue = "continue" // Place the legs that don't depend on the failed one
+)
+
+// Leg states
+const (
+	LegPending      = "pending"       // Not placed yet
+	LegPlaced       = "placed"        // Accepted by the broker
+	LegFailed       = "failed"        // Rejected by the risk checks or the broker
+	LegSkipped      = "skipped"       // Not placed, after a failure
+	LegCancelled    = "cancelled"     // Placed, then cancelled by the rollback
+	LegCancelFailed = "cancel_failed" // Placed, and could not be cancelled, e.g. filled
+)
+
+// Basket states
+const (
+	StatusComplete   = "complete"    // Every leg placed
+	StatusPartial    = "partial"     // Some legs placed, some not
+	StatusFailed     = "failed"      // No leg placed
+	StatusRolledBack = "rolled_back" // A leg failed and the placed legs were cancelled
+)
+
+// ErrInvalidBasket is returned for baskets that can't be placed as given
+var ErrInvalidBasket = errors.New("invalid basket")
+
+// Leg is an order of a basket
+type Leg struct {
+	broker.OrderRequest
+	ID        string   `json:"id"`         // Name other legs depend on, leg1, leg2... if empty
+	DependsOn []string `json:"depends_on"` // Legs placed before this one
+}
+
+// LegResult is what became of a leg
+type LegResult struct {
+	ID      string              `json:"id"`
+	Order   broker.OrderRequest `json:"order"`
+	OrderID string              `json:"order_id,omitempty"`
+	Status  string              `json:"status"`
+	Error   string              `json:"error,omitempty"`
+}
+
+// Result is what became of a basket, its legs in the order they were
+// placed
+type Result struct {
+	ID     string      `json:"basket_id"`
+	Status string      `json:"status"`
+	Legs   []LegResult `json:"legs"`
+}
+
+// ParseOnFailure checks what is to happen when a leg fails, rollback if
+// empty
+func ParseOnFailure(onFailure string) (string, error) {
+	switch onFailure = strings.ToLower(onFailure); onFailure {
+	case "":
+		return OnFailureRollback, nil
+	case OnFailureRollback, OnFailureStop, OnFailureContinue:
+		return onFailure, nil
+	}
+	return "", fmt.Errorf("%w: on_failure must be rollback, stop or continue", ErrInvalidBasket)
+}
+
+// Normalize names unnamed legs, upper-cases their fields and defaults the
+// exchange to NSE, checking that every leg is a complete order and that
+// dependencies name other legs
+func Normalize(legs []Leg) error {
+	if len(legs) == 0 {
+		return fmt.Errorf("%w: no legs", ErrInvalidBasket)
+	}
+	if len(legs) > MaxLegs {
+		return fmt.Errorf("%w: %d legs, at most %d", ErrInvalidBasket, len(legs), MaxLegs)
+	}
+
+	ids := make(map[string]bool, len(legs))
+	for i := range legs {
+		leg := &legs[i]
+		if leg.ID == "" {
+			leg.ID = "leg" + strconv.Itoa(i+1)
+		}
+		if ids[leg.ID] {
+			return fmt.Errorf("%w: duplicate leg id %q", ErrInvalidBasket, leg.ID)
+		}
+		ids[leg.ID] = true
+
+		leg.Exchange = strings.ToUpper(leg.Exchange)
+		if leg.Exchange == "" {
+			leg.Exchange = "NSE"
+		}
+		leg.Symbol = strings.ToUpper(leg.Symbol)
+		leg.TransactionType = strings.ToUpper(leg.TransactionType)
+		leg.OrderType = strings.ToUpper(leg.OrderType)
+		if leg.OrderType == "" {
+			leg.OrderType = "MARKET"
+		}
+		leg.Product = strings.ToUpper(leg.Product)
+
+		switch {
+		case leg.Symbol == "":
+			return fmt.Errorf("%w: %s has no symbol", ErrInvalidBasket, leg.ID)
+		case leg.Quantity <= 0:
+			return fmt.Errorf("%w: %s quantity must be positive", ErrInvalidBasket, leg.ID)
+		case leg.TransactionType != "BUY" && leg.TransactionType != "SELL":
+			return fmt.Errorf("%w: %s transaction_type must be BUY or SELL", ErrInvalidBasket, leg.ID)
+		case leg.Product == "":
+			return fmt.Errorf("%w: %s has no product", ErrInvalidBasket, leg.ID)
+		case leg.HasExits():
+			return fmt.Errorf("%w: %s: basket legs can't carry a stop loss or target", ErrInvalidBasket, leg.ID)
+		}
+	}
+
+	for _, leg := range legs {
+		for _, dep := range leg.DependsOn {
+			if !ids[dep] {
+				return fmt.Errorf("%w: %s depends on unknown leg %q", ErrInvalidBasket, leg.ID, dep)
+			}
+			if dep == leg.ID {
+				return fmt.Errorf("%w: %s depends on itself", ErrInvalidBasket, leg.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// Plan returns the order legs are placed in: every leg after the legs it
+// depends on and, of the legs free to go next, buys before sells, so that
+// the long legs of a spread are in place to hedge the short ones, then in
+// the order given. It fails on dependency cycles.
+func Plan(legs []Leg) ([]int, error) {
+	index := make(map[string]int, len(legs))
+	for i, leg := range legs {
+		index[leg.ID] = i
+	}
+
+	waiting := make([]int, len(legs)) // Unplaced legs each depends on
+	dependents := make([][]int, len(legs))
+	for i, leg := range legs {
+		for _, dep := range leg.DependsOn {
+			waiting[i]++
+			dependents[index[dep]] = append(dependents[index[dep]], i)
+		}
+	}
+
+	var ready, order []int
+	for i := range legs {
+		if waiting[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	for len(ready) > 0 {
+		sort.Slice(ready, func(a, b int) bool {
+			buyA, buyB := legs[ready[a]].TransactionType == "BUY", legs[ready[b]].TransactionType == "BUY"
+			if buyA != buyB {
+				return buyA
+			}
+			return ready[a] < ready[b]
+		})
+		next := ready[0]
+		ready = ready[1:]
+		order = append(order, next)
+		for _, dependent := range dependents[next] {
+			if waiting[dependent]--; waiting[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	if len(order) < len(legs) {
+		return nil, fmt.Errorf("%w: legs depend on each other in a cycle", ErrInvalidBasket)
+	}
+	return order, nil
+}
+
+// Execute places the legs, normalized, through brk in plan order, tagging
+// legs without a tag with the basket's ID. When a leg fails, the legs
+// depending on it are skipped and onFailure decides what happens to the
+// others, rollback if empty. A leg counts as placed once the broker accepts
+// it; fills are not awaited.
+func Execute(brk broker.Broker, id string, legs []Leg, onFailure string) (*Result, error) {
+	onFailure, err := ParseOnFailure(onFailure)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := Plan(legs)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]LegResult, len(legs))
+	for i, leg := range legs {
+		results[i] = LegResult{ID: leg.ID, Order: leg.OrderRequest, Status: LegPending}
+	}
+
+	failed := false
+	for _, i := range plan {
+		if failed && onFailure != OnFailureContinue {
+			results[i].Status = LegSkipped
+			continue
+		}
+		if dep, ok := unplacedDependency(legs[i], legs, results); ok {
+			results[i].Status = LegSkipped
+			results[i].Error = "depends on " + dep + ", which wasn't placed"
+			continue
+		}
+
+		order := legs[i].OrderRequest
+		if order.Tag == "" {
+			order.Tag = id
+		}
+		orderID, err := brk.PlaceOrder(&order)
+		if err != nil {
+			results[i].Status = LegFailed
+			results[i].Error = err.Error()
+			failed = true
+			continue
+		}
+		results[i].OrderID = orderID
+		results[i].Status = LegPlaced
+	}
+
+	if failed && onFailure == OnFailureRollback {
+		rollback(brk, plan, results)
+	}
+
+	result := &Result{ID: id, Legs: make([]LegResult, 0, len(legs))}
+	for _, i := range plan {
+		result.Legs = append(result.Legs, results[i])
+	}
+	result.Status = status(result.Legs)
+	return result, nil
+}
+
+// unplacedDependency returns a leg the given one depends on that wasn't
+// placed
+func unplacedDependency(leg Leg, legs []Leg, results []LegResult) (string, bool) {
+	for _, dep := range leg.DependsOn {
+		for i := range legs {
+			if legs[i].ID == dep && results[i].Status != LegPlaced {
+				return dep, true
+			}
+		}
+	}
+	return "", false
+}
+
+// rollback cancels the placed legs, last placed first
+func rollback(brk broker.Broker, plan []int, results []LegResult) {
+	for j := len(plan) - 1; j >= 0; j-- {
+		result := &results[plan[j]]
+		if result.Status != LegPlaced {
+			continue
+		}
+		if _, err := brk.CancelOrder(result.OrderID); err != nil {
+			result.Status = LegCancelFailed
+			result.Error = "rollback: " + err.Error()
+			continue
+		}
+		result.Status = LegCancelled
+	}
+}
+
+// status sums up the legs of a basket
+func status(legs []LegResult) string {
+	counts := make(map[string]int)
+	for _, leg := range legs {
+		counts[leg.Status]++
+	}
+	placed := counts[LegPlaced]
+	switch {
+	case counts[LegCancelFailed] > 0 || (placed > 0 && placed < len(legs)):
+		return StatusPartial
+	case counts[LegCancelled] > 0:
+		return StatusRolledBack
+	case placed == len(legs):
+		return StatusComplete
+	}
+	return StatusFailed
+}
@@ -0,0 +1,238 @@
+package basket
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// fakeBroker places orders except for the symbols in reject, and cancels
+// them except for the order IDs in filled. Other Broker methods panic
+// through the nil embedded interface.
+type fakeBroker struct {
+	broker.Broker
+	reject    map[string]bool
+	filled    map[string]bool
+	placed    []broker.OrderRequest
+	cancelled []string
+}
+
+func (f *fakeBroker) PlaceOrder(order *broker.OrderRequest) (string, error) {
+	if f.reject[order.Symbol] {
+		return "", errors.New("order rejected")
+	}
+	f.placed = append(f.placed, *order)
+	return strconv.Itoa(len(f.placed)), nil
+}
+
+func (f *fakeBroker) CancelOrder(orderID string) (string, error) {
+	if f.filled[orderID] {
+		return "", errors.New("order is already complete")
+	}
+	f.cancelled = append(f.cancelled, orderID)
+	return orderID, nil
+}
+
+func leg(id, symbol, side string, dependsOn ...string) Leg {
+	return Leg{
+		OrderRequest: broker.OrderRequest{Symbol: symbol, Exchange: "NFO", TransactionType: side, Product: "NRML", Quantity: 75},
+		ID:           id,
+		DependsOn:    dependsOn,
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		legs    []Leg
+		wantErr bool
+	}{
+		{"valid", []Leg{leg("a", "X", "buy"), leg("", "Y", "SELL", "a")}, false},
+		{"no legs", nil, true},
+		{"too many legs", make([]Leg, MaxLegs+1), true},
+		{"duplicate ids", []Leg{leg("a", "X", "BUY"), leg("a", "Y", "SELL")}, true},
+		{"unknown dependency", []Leg{leg("a", "X", "BUY", "b")}, true},
+		{"self dependency", []Leg{leg("a", "X", "BUY", "a")}, true},
+		{"no quantity", []Leg{{OrderRequest: broker.OrderRequest{Symbol: "X", TransactionType: "BUY", Product: "MIS"}}}, true},
+		{"bad side", []Leg{leg("a", "X", "HOLD")}, true},
+		{"exits", []Leg{{OrderRequest: broker.OrderRequest{Symbol: "X", TransactionType: "BUY", Product: "MIS", Quantity: 1, StopLoss: 90}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Normalize(tt.legs)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidBasket) {
+					t.Errorf("Normalize = %v, want ErrInvalidBasket", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Normalize = %v", err)
+			}
+			if tt.legs[1].ID != "leg2" || tt.legs[0].TransactionType != "BUY" || tt.legs[0].OrderType != "MARKET" {
+				t.Errorf("legs not normalized: %+v", tt.legs)
+			}
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	tests := []struct {
+		name    string
+		legs    []Leg
+		want    []int
+		wantErr bool
+	}{
+		{
+			name: "buys first",
+			legs: []Leg{leg("a", "A", "SELL"), leg("b", "B", "BUY"), leg("c", "C", "SELL"), leg("d", "D", "BUY")},
+			want: []int{1, 3, 0, 2},
+		},
+		{
+			name: "dependencies before buys",
+			legs: []Leg{leg("a", "A", "SELL"), leg("b", "B", "BUY", "a")},
+			want: []int{0, 1},
+		},
+		{
+			name: "chain",
+			legs: []Leg{leg("a", "A", "BUY", "c"), leg("b", "B", "BUY", "a"), leg("c", "C", "SELL")},
+			want: []int{2, 0, 1},
+		},
+		{
+			name:    "cycle",
+			legs:    []Leg{leg("a", "A", "BUY", "b"), leg("b", "B", "BUY", "a")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Plan(tt.legs)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidBasket) {
+					t.Errorf("Plan = %v, want ErrInvalidBasket", err)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Plan = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecute(t *testing.T) {
+	// A spread: the hedge goes first, the short leg after it, and an
+	// independent leg last
+	spread := func() []Leg {
+		return []Leg{leg("short", "SHORT", "SELL", "hedge"), leg("hedge", "HEDGE", "BUY"), leg("other", "OTHER", "SELL")}
+	}
+
+	tests := []struct {
+		name          string
+		onFailure     string
+		reject        []string
+		filled        []string
+		wantStatus    string
+		wantLegs      map[string]string
+		wantCancelled []string
+	}{
+		{
+			name:       "complete",
+			wantStatus: StatusComplete,
+			wantLegs:   map[string]string{"hedge": LegPlaced, "short": LegPlaced, "other": LegPlaced},
+		},
+		{
+			name:          "rollback",
+			reject:        []string{"OTHER"},
+			wantStatus:    StatusRolledBack,
+			wantLegs:      map[string]string{"hedge": LegCancelled, "short": LegCancelled, "other": LegFailed},
+			wantCancelled: []string{"2", "1"},
+		},
+		{
+			name:          "rollback of a filled leg",
+			reject:        []string{"OTHER"},
+			filled:        []string{"1"},
+			wantStatus:    StatusPartial,
+			wantLegs:      map[string]string{"hedge": LegCancelFailed, "short": LegCancelled, "other": LegFailed},
+			wantCancelled: []string{"2"},
+		},
+		{
+			name:       "first leg fails",
+			reject:     []string{"HEDGE"},
+			wantStatus: StatusFailed,
+			wantLegs:   map[string]string{"hedge": LegFailed, "short": LegSkipped, "other": LegSkipped},
+		},
+		{
+			name:       "stop",
+			onFailure:  OnFailureStop,
+			reject:     []string{"SHORT"},
+			wantStatus: StatusPartial,
+			wantLegs:   map[string]string{"hedge": LegPlaced, "short": LegFailed, "other": LegSkipped},
+		},
+		{
+			name:       "continue skips dependents only",
+			onFailure:  OnFailureContinue,
+			reject:     []string{"HEDGE"},
+			wantStatus: StatusPartial,
+			wantLegs:   map[string]string{"hedge": LegFailed, "short": LegSkipped, "other": LegPlaced},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			brk := &fakeBroker{reject: make(map[string]bool), filled: make(map[string]bool)}
+			for _, symbol := range tt.reject {
+				brk.reject[symbol] = true
+			}
+			for _, id := range tt.filled {
+				brk.filled[id] = true
+			}
+
+			result, err := Execute(brk, "basket-1", spread(), tt.onFailure)
+			if err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", result.Status, tt.wantStatus)
+			}
+			for _, leg := range result.Legs {
+				if leg.Status != tt.wantLegs[leg.ID] {
+					t.Errorf("%s status = %q, want %q", leg.ID, leg.Status, tt.wantLegs[leg.ID])
+				}
+			}
+			if !reflect.DeepEqual(brk.cancelled, tt.wantCancelled) {
+				t.Errorf("cancelled %v, want %v", brk.cancelled, tt.wantCancelled)
+			}
+			for _, order := range brk.placed {
+				if order.Tag != "basket-1" {
+					t.Errorf("%s tagged %q, want the basket ID", order.Symbol, order.Tag)
+				}
+			}
+		})
+	}
+}
+
+func TestParseOnFailure(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"", OnFailureRollback, false},
+		{"STOP", OnFailureStop, false},
+		{"continue", OnFailureContinue, false},
+		{"ignore", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseOnFailure(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseOnFailure(%q) = %q, %v, want %q", tt.value, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,1053 @@
+package broker
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/trading-chitti/market-bridge/internal/timeframe"
+)
+
+const (
+	angelOneBaseURL        = "https://apiconnect.angelone.in"
+	angelOneLoginURL       = "https://smartapi.angelone.in/publisher-login"
+	angelOneScripMasterURL = "https://margincalculator.angelbroking.com/OpenAPI_File/files/OpenAPIScripMaster.json"
+)
+
+// AngelOneBroker implements the Broker interface for Angel One SmartAPI
+type AngelOneBroker struct {
+	config *BrokerConfig
+	client *http.Client
+	logger *logrus.Logger
+
+	mu          sync.RWMutex
+	accessToken string
+	feedToken   string
+
+	// Scrip master cache: "NSE:RELIANCE-EQ" -> symbol token
+	scripMu     sync.RWMutex
+	scripTokens map[string]string
+	scripNames  map[string]string // token -> trading symbol
+}
+
+// NewAngelOneBroker creates a new Angel One broker instance
+func NewAngelOneBroker(config *BrokerConfig) (*AngelOneBroker, error) {
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("%w: angelone api key is required", ErrInvalidCredentials)
+	}
+
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.TextFormatter{
+		FullTimestamp: true,
+	})
+
+	broker := &AngelOneBroker{
+		config:      config,
+		client:      &http.Client{Timeout: 30 * time.Second},
+		logger:      logger,
+		accessToken: config.AccessToken,
+	}
+
+	broker.logger.Info("✅ Angel One broker initialized")
+
+	return broker, nil
+}
+
+// ============================================================================
+// HTTP HELPERS
+// ============================================================================
+
+// angelResponse is the common SmartAPI response envelope
+type angelResponse struct {
+	Status    bool            `json:"status"`
+	Message   string          `json:"message"`
+	ErrorCode string          `json:"errorcode"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// angelNumber decodes SmartAPI numeric fields, which are sent either as
+// JSON numbers or as quoted strings depending on the endpoint
+type angelNumber float64
+
+func (n *angelNumber) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "null" {
+		*n = 0
+		return nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*n = angelNumber(f)
+	return nil
+}
+
+// request performs a SmartAPI call and decodes the data field into out
+func (a *AngelOneBroker) request(method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, angelOneBaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-UserType", "USER")
+	req.Header.Set("X-SourceID", "WEB")
+	req.Header.Set("X-ClientLocalIP", "127.0.0.1")
+	req.Header.Set("X-ClientPublicIP", "127.0.0.1")
+	req.Header.Set("X-MACAddress", "00:00:00:00:00:00")
+	req.Header.Set("X-PrivateKey", a.config.APIKey)
+
+	a.mu.RLock()
+	token := a.accessToken
+	a.mu.RUnlock()
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("angelone request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read angelone response: %w", err)
+	}
+
+	if unavailableStatus(resp.StatusCode) {
+		return fmt.Errorf("%w: angelone returned HTTP %d: %s", ErrBrokerUnavailable, resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return ErrSessionExpired
+	}
+
+	var envelope angelResponse
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return fmt.Errorf("angelone returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+
+	if !envelope.Status {
+		switch envelope.ErrorCode {
+		case "AG8001", "AG8002", "AG8003":
+			return ErrSessionExpired
+		case "AB1007":
+			return ErrInsufficientFunds
+		}
+		return fmt.Errorf("angelone error %s: %s", envelope.ErrorCode, envelope.Message)
+	}
+
+	if out == nil || len(envelope.Data) == 0 || string(envelope.Data) == "null" {
+		return nil
+	}
+
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		return fmt.Errorf("failed to decode angelone response: %w", err)
+	}
+
+	return nil
+}
+
+// ============================================================================
+// AUTHENTICATION
+// ============================================================================
+
+type angelTokens struct {
+	JWTToken     string `json:"jwtToken"`
+	RefreshToken string `json:"refreshToken"`
+	FeedToken    string `json:"feedToken"`
+}
+
+// GetLoginURL returns the SmartAPI publisher login URL
+func (a *AngelOneBroker) GetLoginURL() string {
+	return angelOneLoginURL + "?api_key=" + url.QueryEscape(a.config.APIKey)
+}
+
+// GenerateSession exchanges the refresh token returned by the publisher
+// login redirect for a JWT access token
+func (a *AngelOneBroker) GenerateSession(requestToken string) (*Session, error) {
+	var tokens angelTokens
+	err := a.request(http.MethodPost, "/rest/auth/angelbroking/jwt/v1/generateTokens",
+		map[string]string{"refreshToken": requestToken}, &tokens)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate session: %w", err)
+	}
+
+	return a.applySession(&tokens, requestToken)
+}
+
+// RefreshSession renews the JWT from the config's refresh token, which
+// outlives the JWT
+func (a *AngelOneBroker) RefreshSession() (*Session, error) {
+	if a.config.RefreshToken == "" {
+		return nil, fmt.Errorf("%w: no refresh token", ErrInvalidCredentials)
+	}
+
+	var tokens angelTokens
+	err := a.request(http.MethodPost, "/rest/auth/angelbroking/jwt/v1/generateTokens",
+		map[string]string{"refreshToken": a.config.RefreshToken}, &tokens)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh session: %w", err)
+	}
+
+	return a.applySession(&tokens, a.config.RefreshToken)
+}
+
+// LoginWithPassword logs in with client code, PIN and TOTP (headless login)
+func (a *AngelOneBroker) LoginWithPassword(clientCode, pin, totp string) (*Session, error) {
+	var tokens angelTokens
+	err := a.request(http.MethodPost, "/rest/auth/angelbroking/user/v1/loginByPassword",
+		map[string]string{
+			"clientcode": clientCode,
+			"password":   pin,
+			"totp":       totp,
+		}, &tokens)
+	if err != nil {
+		return nil, fmt.Errorf("failed to login: %w", err)
+	}
+
+	return a.applySession(&tokens, "")
+}
+
+func (a *AngelOneBroker) applySession(tokens *angelTokens, fallbackRefresh string) (*Session, error) {
+	if tokens.JWTToken == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	accessToken := strings.TrimPrefix(tokens.JWTToken, "Bearer ")
+	a.SetAccessToken(accessToken)
+
+	a.mu.Lock()
+	a.feedToken = tokens.FeedToken
+	a.mu.Unlock()
+
+	if tokens.RefreshToken != "" {
+		a.config.RefreshToken = tokens.RefreshToken
+	} else if fallbackRefresh != "" {
+		a.config.RefreshToken = fallbackRefresh
+	}
+
+	profile, err := a.GetProfile()
+	userID := ""
+	if err == nil {
+		userID = profile.UserID
+	}
+
+	a.logger.Infof("✅ Session generated for user: %s", userID)
+
+	return &Session{
+		UserID:       userID,
+		AccessToken:  accessToken,
+		RefreshToken: a.config.RefreshToken,
+		ExpiresAt:    time.Now().Add(24 * time.Hour), // SmartAPI JWT expires daily
+	}, nil
+}
+
+// SetAccessToken sets the JWT access token
+func (a *AngelOneBroker) SetAccessToken(token string) {
+	a.mu.Lock()
+	a.accessToken = token
+	a.mu.Unlock()
+	a.config.AccessToken = token
+}
+
+// GetFeedToken returns the feed token used by the SmartAPI WebSocket
+func (a *AngelOneBroker) GetFeedToken() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.feedToken
+}
+
+// ============================================================================
+// ACCOUNT INFO
+// ============================================================================
+
+// GetProfile returns user profile
+func (a *AngelOneBroker) GetProfile() (*Profile, error) {
+	var data struct {
+		ClientCode string   `json:"clientcode"`
+		Name       string   `json:"name"`
+		Email      string   `json:"email"`
+		MobileNo   string   `json:"mobileno"`
+		Exchanges  []string `json:"exchanges"`
+		Products   []string `json:"products"`
+	}
+	if err := a.request(http.MethodGet, "/rest/secure/angelbroking/user/v1/getProfile", nil, &data); err != nil {
+		return nil, err
+	}
+
+	return &Profile{
+		UserID:    data.ClientCode,
+		UserName:  data.Name,
+		Email:     data.Email,
+		Phone:     data.MobileNo,
+		Broker:    "angelone",
+		Products:  data.Products,
+		Exchanges: data.Exchanges,
+	}, nil
+}
+
+// GetMargins returns account margins (RMS limits)
+func (a *AngelOneBroker) GetMargins() (*Margins, error) {
+	var data struct {
+		Net            angelNumber `json:"net"`
+		AvailableCash  angelNumber `json:"availablecash"`
+		UtilisedDebits angelNumber `json:"utiliseddebits"`
+	}
+	if err := a.request(http.MethodGet, "/rest/secure/angelbroking/user/v1/getRMS", nil, &data); err != nil {
+		return nil, err
+	}
+
+	result := &Margins{}
+	result.Equity.Available = float64(data.AvailableCash)
+	result.Equity.Used = float64(data.UtilisedDebits)
+	result.Equity.Net = float64(data.Net)
+
+	a.logger.Infof("💰 Equity Available: ₹%.2f", result.Equity.Available)
+
+	return result, nil
+}
+
+// GetPositions returns current positions
+func (a *AngelOneBroker) GetPositions() (*Positions, error) {
+	var data []struct {
+		TradingSymbol string      `json:"tradingsymbol"`
+		Exchange      string      `json:"exchange"`
+		ProductType   string      `json:"producttype"`
+		NetQty        angelNumber `json:"netqty"`
+		AvgNetPrice   angelNumber `json:"avgnetprice"`
+		LTP           angelNumber `json:"ltp"`
+		PNL           angelNumber `json:"pnl"`
+		CFBuyQty      angelNumber `json:"cfbuyqty"`
+		CFSellQty     angelNumber `json:"cfsellqty"`
+	}
+	if err := a.request(http.MethodGet, "/rest/secure/angelbroking/order/v1/getPosition", nil, &data); err != nil {
+		return nil, err
+	}
+
+	result := &Positions{
+		Net: make([]Position, 0, len(data)),
+		Day: make([]Position, 0, len(data)),
+	}
+
+	for _, p := range data {
+		overnight := p.CFBuyQty > 0 || p.CFSellQty > 0
+		position := Position{
+			Symbol:       p.TradingSymbol,
+			Exchange:     p.Exchange,
+			Product:      angelToProduct(p.ProductType),
+			Quantity:     int(p.NetQty),
+			AveragePrice: float64(p.AvgNetPrice),
+			LastPrice:    float64(p.LTP),
+			PNL:          float64(p.PNL),
+			Overnight:    overnight,
+		}
+		result.Net = append(result.Net, position)
+		if !overnight {
+			result.Day = append(result.Day, position)
+		}
+	}
+
+	a.logger.Infof("📊 Positions: %d net, %d day", len(result.Net), len(result.Day))
+
+	return result, nil
+}
+
+// GetHoldings returns holdings
+func (a *AngelOneBroker) GetHoldings() ([]Holding, error) {
+	var data []struct {
+		TradingSymbol string      `json:"tradingsymbol"`
+		Exchange      string      `json:"exchange"`
+		Quantity      angelNumber `json:"quantity"`
+		AveragePrice  angelNumber `json:"averageprice"`
+		LTP           angelNumber `json:"ltp"`
+		ProfitAndLoss angelNumber `json:"profitandloss"`
+		PNLPercentage angelNumber `json:"pnlpercentage"`
+	}
+	if err := a.request(http.MethodGet, "/rest/secure/angelbroking/portfolio/v1/getHolding", nil, &data); err != nil {
+		return nil, err
+	}
+
+	result := make([]Holding, 0, len(data))
+	for _, h := range data {
+		result = append(result, Holding{
+			Symbol:       h.TradingSymbol,
+			Exchange:     h.Exchange,
+			Quantity:     int(h.Quantity),
+			AveragePrice: float64(h.AveragePrice),
+			LastPrice:    float64(h.LTP),
+			PNL:          float64(h.ProfitAndLoss),
+			PNLPercent:   float64(h.PNLPercentage),
+		})
+	}
+
+	a.logger.Infof("💼 Holdings: %d stocks", len(result))
+
+	return result, nil
+}
+
+// angelOrder is an order book entry
+type angelOrder struct {
+	OrderID         string      `json:"orderid"`
+	Variety         string      `json:"variety"`
+	TradingSymbol   string      `json:"tradingsymbol"`
+	SymbolToken     string      `json:"symboltoken"`
+	Exchange        string      `json:"exchange"`
+	TransactionType string      `json:"transactiontype"`
+	OrderType       string      `json:"ordertype"`
+	ProductType     string      `json:"producttype"`
+	Duration        string      `json:"duration"`
+	Quantity        angelNumber `json:"quantity"`
+	Price           angelNumber `json:"price"`
+	TriggerPrice    angelNumber `json:"triggerprice"`
+	Status          string      `json:"status"`
+	FilledShares    angelNumber `json:"filledshares"`
+	UnfilledShares  angelNumber `json:"unfilledshares"`
+	AveragePrice    angelNumber `json:"averageprice"`
+	UpdateTime      string      `json:"updatetime"`
+	ExchOrderTime   string      `json:"exchorderupdatetime"`
+}
+
+func (a *AngelOneBroker) getOrderBook() ([]angelOrder, error) {
+	var data []angelOrder
+	if err := a.request(http.MethodGet, "/rest/secure/angelbroking/order/v1/getOrderBook", nil, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetOrders returns orders for the day
+func (a *AngelOneBroker) GetOrders() ([]Order, error) {
+	orders, err := a.getOrderBook()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Order, 0, len(orders))
+	for _, o := range orders {
+		updatedAt := parseAngelTime(o.UpdateTime)
+		placedAt := parseAngelTime(o.ExchOrderTime)
+		if placedAt.IsZero() {
+			placedAt = updatedAt
+		}
+
+		result = append(result, Order{
+			OrderID:         o.OrderID,
+			Symbol:          o.TradingSymbol,
+			Exchange:        o.Exchange,
+			TransactionType: o.TransactionType,
+			OrderType:       angelToOrderType(o.OrderType),
+			Product:         angelToProduct(o.ProductType),
+			Quantity:        int(o.Quantity),
+			Price:           float64(o.Price),
+			TriggerPrice:    float64(o.TriggerPrice),
+			Status:          strings.ToUpper(o.Status),
+			FilledQuantity:  int(o.FilledShares),
+			PendingQuantity: int(o.UnfilledShares),
+			AveragePrice:    float64(o.AveragePrice),
+			PlacedAt:        placedAt,
+			UpdatedAt:       updatedAt,
+		})
+	}
+
+	a.logger.Infof("📝 Orders today: %d", len(result))
+
+	return result, nil
+}
+
+// ============================================================================
+// MARKET DATA
+// ============================================================================
+
+// GetQuote returns real-time quotes for "EXCHANGE:SYMBOL" instruments
+func (a *AngelOneBroker) GetQuote(symbols []string) (map[string]Quote, error) {
+	fetched, keys, err := a.marketData("FULL", symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]Quote)
+	for _, q := range fetched {
+		symbol, ok := keys[q.Exchange+":"+q.SymbolToken]
+		if !ok {
+			continue
+		}
+
+		changePercent := float64(q.PercentChange)
+		if changePercent == 0 && q.Close != 0 {
+			changePercent = float64((q.LTP - q.Close) / q.Close * 100)
+		}
+
+		result[symbol] = Quote{
+			Symbol:        symbol,
+			LastPrice:     float64(q.LTP),
+			Open:          float64(q.Open),
+			High:          float64(q.High),
+			Low:           float64(q.Low),
+			Close:         float64(q.Close),
+			Change:        float64(q.NetChange),
+			ChangePercent: changePercent,
+			Volume:        int64(q.TradeVolume),
+			BuyQuantity:   int64(q.TotBuyQuan),
+			SellQuantity:  int64(q.TotSellQuan),
+			OI:            int64(q.OpnInterest),
+			Timestamp:     parseAngelTime(q.ExchFeedTime),
+		}
+	}
+
+	return result, nil
+}
+
+// GetLTP returns last traded prices for "EXCHANGE:SYMBOL" instruments
+func (a *AngelOneBroker) GetLTP(symbols []string) (map[string]float64, error) {
+	fetched, keys, err := a.marketData("LTP", symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]float64)
+	for _, q := range fetched {
+		if symbol, ok := keys[q.Exchange+":"+q.SymbolToken]; ok {
+			result[symbol] = float64(q.LTP)
+		}
+	}
+
+	return result, nil
+}
+
+type angelQuote struct {
+	Exchange      string      `json:"exchange"`
+	SymbolToken   string      `json:"symbolToken"`
+	LTP           angelNumber `json:"ltp"`
+	Open          angelNumber `json:"open"`
+	High          angelNumber `json:"high"`
+	Low           angelNumber `json:"low"`
+	Close         angelNumber `json:"close"`
+	NetChange     angelNumber `json:"netChange"`
+	PercentChange angelNumber `json:"percentChange"`
+	TradeVolume   angelNumber `json:"tradeVolume"`
+	TotBuyQuan    angelNumber `json:"totBuyQuan"`
+	TotSellQuan   angelNumber `json:"totSellQuan"`
+	OpnInterest   angelNumber `json:"opnInterest"`
+	ExchFeedTime  string      `json:"exchFeedTime"`
+}
+
+// marketData calls the quote endpoint and returns the fetched quotes plus a
+// map of "EXCHANGE:TOKEN" back to the caller's symbol
+func (a *AngelOneBroker) marketData(mode string, symbols []string) ([]angelQuote, map[string]string, error) {
+	exchangeTokens := make(map[string][]string)
+	keys := make(map[string]string)
+
+	for _, symbol := range symbols {
+		exchange, token, err := a.resolveInstrument(symbol)
+		if err != nil {
+			return nil, nil, err
+		}
+		exchangeTokens[exchange] = append(exchangeTokens[exchange], token)
+		keys[exchange+":"+token] = symbol
+	}
+
+	var data struct {
+		Fetched []angelQuote `json:"fetched"`
+	}
+	err := a.request(http.MethodPost, "/rest/secure/angelbroking/market/v1/quote", map[string]interface{}{
+		"mode":           mode,
+		"exchangeTokens": exchangeTokens,
+	}, &data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return data.Fetched, keys, nil
+}
+
+// angelIntervals maps timeframes to SmartAPI intervals
+var angelIntervals = map[timeframe.Timeframe]string{
+	timeframe.Minute1:  "ONE_MINUTE",
+	timeframe.Minute3:  "THREE_MINUTE",
+	timeframe.Minute5:  "FIVE_MINUTE",
+	timeframe.Minute10: "TEN_MINUTE",
+	timeframe.Minute15: "FIFTEEN_MINUTE",
+	timeframe.Minute30: "THIRTY_MINUTE",
+	timeframe.Hour1:    "ONE_HOUR",
+	timeframe.Day:      "ONE_DAY",
+}
+
+// GetHistoricalData returns historical OHLCV data.
+// instrument is "EXCHANGE:SYMBOL" or "EXCHANGE:TOKEN" (a bare value defaults to NSE);
+// interval is a Kite name (5minute) or bar timeframe (5m)
+func (a *AngelOneBroker) GetHistoricalData(instrument string, from, to time.Time, interval string) ([]Candle, error) {
+	tf, err := timeframe.Parse(interval)
+	if err != nil {
+		return nil, err
+	}
+	angelInterval := angelIntervals[tf]
+
+	exchange, token, err := a.resolveInstrument(instrument)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := IST()
+
+	var rows [][]interface{}
+	err = a.request(http.MethodPost, "/rest/secure/angelbroking/historical/v1/getCandleData", map[string]string{
+		"exchange":    exchange,
+		"symboltoken": token,
+		"interval":    angelInterval,
+		"fromdate":    from.In(loc).Format("2006-01-02 15:04"),
+		"todate":      to.In(loc).Format("2006-01-02 15:04"),
+	}, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	candles := make([]Candle, 0, len(rows))
+	for _, row := range rows {
+		if len(row) < 6 {
+			continue
+		}
+		ts, _ := row[0].(string)
+		date, err := time.Parse(time.RFC3339, ts)
+		if err != nil {
+			continue
+		}
+		candles = append(candles, Candle{
+			Date:   date,
+			Open:   toFloat(row[1]),
+			High:   toFloat(row[2]),
+			Low:    toFloat(row[3]),
+			Close:  toFloat(row[4]),
+			Volume: int64(toFloat(row[5])),
+		})
+	}
+
+	return candles, nil
+}
+
+// angelScrip is an entry in the SmartAPI scrip master
+type angelScrip struct {
+	Token          string `json:"token"`
+	Symbol         string `json:"symbol"`
+	Name           string `json:"name"`
+	Expiry         string `json:"expiry"`
+	Strike         string `json:"strike"`
+	LotSize        string `json:"lotsize"`
+	InstrumentType string `json:"instrumenttype"`
+	ExchSeg        string `json:"exch_seg"`
+	TickSize       string `json:"tick_size"`
+}
+
+func (a *AngelOneBroker) downloadScripMaster() ([]angelScrip, error) {
+	resp, err := a.client.Get(angelOneScripMasterURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download scrip master: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("scrip master download returned HTTP %d", resp.StatusCode)
+	}
+
+	var scrips []angelScrip
+	if err := json.NewDecoder(resp.Body).Decode(&scrips); err != nil {
+		return nil, fmt.Errorf("failed to decode scrip master: %w", err)
+	}
+
+	// Refresh symbol/token cache while we have the full list
+	tokens := make(map[string]string, len(scrips))
+	names := make(map[string]string, len(scrips))
+	for _, s := range scrips {
+		tokens[s.ExchSeg+":"+s.Symbol] = s.Token
+		names[s.ExchSeg+":"+s.Token] = s.Symbol
+	}
+	a.scripMu.Lock()
+	a.scripTokens = tokens
+	a.scripNames = names
+	a.scripMu.Unlock()
+
+	return scrips, nil
+}
+
+// GetInstruments returns all tradable instruments from the scrip master
+func (a *AngelOneBroker) GetInstruments(exchange string) ([]Instrument, error) {
+	scrips, err := a.downloadScripMaster()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Instrument, 0)
+	for _, s := range scrips {
+		if exchange != "" && s.ExchSeg != exchange {
+			continue
+		}
+
+		token, err := strconv.ParseInt(s.Token, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		var expiry *time.Time
+		if s.Expiry != "" {
+			if t, err := time.Parse("02Jan2006", s.Expiry); err == nil {
+				expiry = &t
+			}
+		}
+
+		// Strike and tick size are published in paise
+		strike, _ := strconv.ParseFloat(s.Strike, 64)
+		if strike < 0 {
+			strike = 0
+		}
+		tickSize, _ := strconv.ParseFloat(s.TickSize, 64)
+		lotSize, _ := strconv.Atoi(s.LotSize)
+
+		instrumentType := s.InstrumentType
+		if instrumentType == "" {
+			instrumentType = "EQ"
+		}
+
+		result = append(result, Instrument{
+			InstrumentToken: token,
+			ExchangeToken:   token,
+			TradingSymbol:   s.Symbol,
+			Name:            s.Name,
+			Exchange:        s.ExchSeg,
+			InstrumentType:  instrumentType,
+			Segment:         s.ExchSeg,
+			Expiry:          expiry,
+			Strike:          strike / 100,
+			TickSize:        tickSize / 100,
+			LotSize:         lotSize,
+		})
+	}
+
+	a.logger.Infof("🏢 Loaded %d instruments from %s", len(result), exchange)
+
+	return result, nil
+}
+
+// resolveInstrument turns "EXCHANGE:SYMBOL" or "EXCHANGE:TOKEN" into an
+// exchange and SmartAPI symbol token, loading the scrip master on first use
+func (a *AngelOneBroker) resolveInstrument(instrument string) (string, string, error) {
+	exchange, symbol := "NSE", instrument
+	if parts := strings.SplitN(instrument, ":", 2); len(parts) == 2 {
+		exchange, symbol = strings.ToUpper(parts[0]), parts[1]
+	}
+
+	if _, err := strconv.ParseInt(symbol, 10, 64); err == nil {
+		return exchange, symbol, nil
+	}
+
+	a.scripMu.RLock()
+	loaded := a.scripTokens != nil
+	a.scripMu.RUnlock()
+	if !loaded {
+		if _, err := a.downloadScripMaster(); err != nil {
+			return "", "", err
+		}
+	}
+
+	a.scripMu.RLock()
+	defer a.scripMu.RUnlock()
+
+	// NSE cash symbols carry a series suffix (RELIANCE-EQ)
+	for _, candidate := range []string{symbol, symbol + "-EQ"} {
+		if token, ok := a.scripTokens[exchange+":"+candidate]; ok {
+			return exchange, token, nil
+		}
+	}
+
+	return "", "", fmt.Errorf("%w: %s", ErrInvalidSymbol, instrument)
+}
+
+// tradingSymbol returns the SmartAPI trading symbol for an order request
+func (a *AngelOneBroker) tradingSymbol(exchange, symbol, token string) string {
+	a.scripMu.RLock()
+	defer a.scripMu.RUnlock()
+	if name, ok := a.scripNames[exchange+":"+token]; ok {
+		return name
+	}
+	return symbol
+}
+
+// ============================================================================
+// TRADING
+// ============================================================================
+
+// PlaceOrder places a new order
+func (a *AngelOneBroker) PlaceOrder(order *OrderRequest) (string, error) {
+	if order.Quantity <= 0 {
+		return "", ErrInvalidQuantity
+	}
+	if order.HasExits() {
+		return "", ErrExitsNotSupported
+	}
+
+	orderType, variety, err := orderTypeToAngel(order.OrderType)
+	if err != nil {
+		return "", err
+	}
+
+	exchange, token, err := a.resolveInstrument(order.Exchange + ":" + order.Symbol)
+	if err != nil {
+		return "", err
+	}
+
+	duration := order.Validity
+	if duration == "" {
+		duration = "DAY"
+	}
+
+	params := map[string]string{
+		"variety":         variety,
+		"tradingsymbol":   a.tradingSymbol(exchange, order.Symbol, token),
+		"symboltoken":     token,
+		"transactiontype": order.TransactionType,
+		"exchange":        exchange,
+		"ordertype":       orderType,
+		"producttype":     productToAngel(order.Product),
+		"duration":        duration,
+		"price":           strconv.FormatFloat(order.Price, 'f', 2, 64),
+		"triggerprice":    strconv.FormatFloat(order.TriggerPrice, 'f', 2, 64),
+		"quantity":        strconv.Itoa(order.Quantity),
+	}
+	if order.Tag != "" {
+		params["ordertag"] = order.Tag
+	}
+
+	var data struct {
+		OrderID string `json:"orderid"`
+	}
+	if err := a.request(http.MethodPost, "/rest/secure/angelbroking/order/v1/placeOrder", params, &data); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrOrderRejected, err)
+	}
+
+	a.logger.Infof("📤 Order placed: %s - %s %d %s @ %s",
+		data.OrderID, order.TransactionType, order.Quantity, order.Symbol, order.OrderType)
+
+	return data.OrderID, nil
+}
+
+// ModifyOrder modifies an existing order. SmartAPI requires the full order
+// definition, so unchanged fields are taken from the order book.
+func (a *AngelOneBroker) ModifyOrder(orderID string, modify *OrderModify) (string, error) {
+	orders, err := a.getOrderBook()
+	if err != nil {
+		return "", err
+	}
+
+	var existing *angelOrder
+	for i := range orders {
+		if orders[i].OrderID == orderID {
+			existing = &orders[i]
+			break
+		}
+	}
+	if existing == nil {
+		return "", fmt.Errorf("order not found: %s", orderID)
+	}
+
+	orderType, variety := existing.OrderType, existing.Variety
+	quantity := int(existing.Quantity)
+	price := float64(existing.Price)
+	triggerPrice := float64(existing.TriggerPrice)
+
+	if modify.OrderType != nil {
+		orderType, variety, err = orderTypeToAngel(*modify.OrderType)
+		if err != nil {
+			return "", err
+		}
+	}
+	if modify.Quantity != nil {
+		quantity = *modify.Quantity
+	}
+	if modify.Price != nil {
+		price = *modify.Price
+	}
+	if modify.TriggerPrice != nil {
+		triggerPrice = *modify.TriggerPrice
+	}
+
+	params := map[string]string{
+		"variety":       variety,
+		"orderid":       orderID,
+		"ordertype":     orderType,
+		"producttype":   existing.ProductType,
+		"duration":      existing.Duration,
+		"price":         strconv.FormatFloat(price, 'f', 2, 64),
+		"triggerprice":  strconv.FormatFloat(triggerPrice, 'f', 2, 64),
+		"quantity":      strconv.Itoa(quantity),
+		"tradingsymbol": existing.TradingSymbol,
+		"symboltoken":   existing.SymbolToken,
+		"exchange":      existing.Exchange,
+	}
+
+	var data struct {
+		OrderID string `json:"orderid"`
+	}
+	if err := a.request(http.MethodPost, "/rest/secure/angelbroking/order/v1/modifyOrder", params, &data); err != nil {
+		return "", err
+	}
+	if data.OrderID == "" {
+		data.OrderID = orderID
+	}
+
+	a.logger.Infof("✏️  Order modified: %s", data.OrderID)
+
+	return data.OrderID, nil
+}
+
+// CancelOrder cancels an order
+func (a *AngelOneBroker) CancelOrder(orderID string) (string, error) {
+	variety := "NORMAL"
+	if orders, err := a.getOrderBook(); err == nil {
+		for _, o := range orders {
+			if o.OrderID == orderID && o.Variety != "" {
+				variety = o.Variety
+				break
+			}
+		}
+	}
+
+	var data struct {
+		OrderID string `json:"orderid"`
+	}
+	err := a.request(http.MethodPost, "/rest/secure/angelbroking/order/v1/cancelOrder", map[string]string{
+		"variety": variety,
+		"orderid": orderID,
+	}, &data)
+	if err != nil {
+		return "", err
+	}
+	if data.OrderID == "" {
+		data.OrderID = orderID
+	}
+
+	a.logger.Infof("❌ Order cancelled: %s", data.OrderID)
+
+	return data.OrderID, nil
+}
+
+// ============================================================================
+// UTILITY
+// ============================================================================
+
+// IsMarketOpen checks if market is open
+func (a *AngelOneBroker) IsMarketOpen() bool {
+	return isIndianMarketOpen()
+}
+
+// GetMarketStatus returns current market status
+func (a *AngelOneBroker) GetMarketStatus() string {
+	return indianMarketStatus()
+}
+
+// GetBrokerName returns the broker name
+func (a *AngelOneBroker) GetBrokerName() string {
+	return "angelone"
+}
+
+// orderTypeToAngel maps MARKET/LIMIT/SL/SL-M to SmartAPI order type and variety
+func orderTypeToAngel(orderType string) (string, string, error) {
+	switch orderType {
+	case "MARKET":
+		return "MARKET", "NORMAL", nil
+	case "LIMIT":
+		return "LIMIT", "NORMAL", nil
+	case "SL":
+		return "STOPLOSS_LIMIT", "STOPLOSS", nil
+	case "SL-M":
+		return "STOPLOSS_MARKET", "STOPLOSS", nil
+	default:
+		return "", "", ErrInvalidOrderType
+	}
+}
+
+func angelToOrderType(orderType string) string {
+	switch orderType {
+	case "STOPLOSS_LIMIT":
+		return "SL"
+	case "STOPLOSS_MARKET":
+		return "SL-M"
+	default:
+		return orderType
+	}
+}
+
+// productToAngel maps MIS/CNC/NRML to SmartAPI product types
+func productToAngel(product string) string {
+	switch product {
+	case "MIS":
+		return "INTRADAY"
+	case "CNC":
+		return "DELIVERY"
+	case "NRML":
+		return "CARRYFORWARD"
+	default:
+		return product
+	}
+}
+
+func angelToProduct(product string) string {
+	switch product {
+	case "INTRADAY":
+		return "MIS"
+	case "DELIVERY":
+		return "CNC"
+	case "CARRYFORWARD":
+		return "NRML"
+	default:
+		return product
+	}
+}
+
+// parseAngelTime parses SmartAPI timestamps such as "03-Jan-2024 10:15:00" (IST)
+func parseAngelTime(value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	loc := IST()
+	for _, layout := range []string{"02-Jan-2006 15:04:05", "2006-01-02 15:04:05", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// toFloat converts a decoded JSON value to float64
+func toFloat(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case string:
+		f, _ := strconv.ParseFloat(n, 64)
+		return f
+	case json.Number:
+		f, _ := n.Float64()
+		return f
+	default:
+		return 0
+	}
+}
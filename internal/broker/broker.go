@@ -1,6 +1,9 @@
 package broker
 
 import (
+	"fmt"
+	"math"
+	"strings"
 	"time"
 )
 
@@ -38,9 +41,16 @@ type Broker interface {
 
 // Session represents authentication session
 type Session struct {
-	UserID      string
-	AccessToken string
-	ExpiresAt   time.Time
+	UserID       string
+	AccessToken  string
+	RefreshToken string // Set by brokers that issue one
+	ExpiresAt    time.Time
+}
+
+// TokenRefresher is implemented by brokers that can renew the access token
+// from the config's refresh token, without the user logging in again
+type TokenRefresher interface {
+	RefreshSession() (*Session, error)
 }
 
 // Profile represents user profile
@@ -116,18 +126,209 @@ type Order struct {
 	UpdatedAt        time.Time
 }
 
+// OrderUpdate is an order status change pushed by the broker, e.g. Kite's
+// order postbacks over the ticker WebSocket
+type OrderUpdate struct {
+	Order
+	Tag           string
+	StatusMessage string
+}
+
 // OrderRequest represents a new order request
 type OrderRequest struct {
-	Symbol          string
-	Exchange        string
-	TransactionType string
-	OrderType       string
-	Product         string
-	Quantity        int
-	Price           float64
-	TriggerPrice    float64
-	Validity        string // DAY, IOC
-	Tag             string
+	Symbol          string  `json:"symbol"`
+	Exchange        string  `json:"exchange"`
+	TransactionType string  `json:"transaction_type"`
+	OrderType       string  `json:"order_type"`
+	Product         string  `json:"product"`
+	Quantity        int     `json:"quantity"`
+	Price           float64 `json:"price"`
+	TriggerPrice    float64 `json:"trigger_price"`
+	Validity        string  `json:"validity"` // DAY, IOC
+	Tag             string  `json:"tag"`
+
+	// Exit legs attached to the entry, as absolute prices (0 for none).
+	// Brokers that can't attach them reject the order with ErrExitsNotSupported.
+	StopLoss float64 `json:"stop_loss"`
+	Target   float64 `json:"target"`
+}
+
+// HasExits reports whether the order carries a stop-loss or target leg
+func (o *OrderRequest) HasExits() bool {
+	return o.StopLoss > 0 || o.Target > 0
+}
+
+// ValidateExits checks that the stop loss is on the losing side and the
+// target on the winning side of the entry price
+func (o *OrderRequest) ValidateExits(entry float64) error {
+	if o.StopLoss < 0 || o.Target < 0 {
+		return fmt.Errorf("%w: stop loss and target cannot be negative", ErrInvalidPrice)
+	}
+
+	buy := strings.ToUpper(o.TransactionType) == "BUY"
+	below, above := "below", "above"
+	if !buy {
+		below, above = above, below
+	}
+	if o.StopLoss > 0 && ((buy && o.StopLoss >= entry) || (!buy && o.StopLoss <= entry)) {
+		return fmt.Errorf("%w: stop loss %.2f must be %s the entry price %.2f", ErrInvalidPrice, o.StopLoss, below, entry)
+	}
+	if o.Target > 0 && ((buy && o.Target <= entry) || (!buy && o.Target >= entry)) {
+		return fmt.Errorf("%w: target %.2f must be %s the entry price %.2f", ErrInvalidPrice, o.Target, above, entry)
+	}
+	return nil
+}
+
+// RoundTick rounds a price to the NSE tick size of ₹0.05
+func RoundTick(price float64) float64 {
+	return math.Round(price*20) / 20
+}
+
+// OrderUpdateHandler is implemented by brokers that act on the updates of
+// their own orders, e.g. to place exit legs once an entry fills. Feed it
+// the broker's order postbacks.
+type OrderUpdateHandler interface {
+	HandleOrderUpdate(update OrderUpdate)
+}
+
+// MarginCalculator is implemented by brokers that compute the margin orders
+// need from the exchange's SPAN and exposure files before they're placed
+type MarginCalculator interface {
+	GetOrderMargins(orders []OrderRequest) ([]OrderMargin, error)
+	GetBasketMargin(orders []OrderRequest) (*BasketMargin, error)
+}
+
+// OrderMargin is the margin blocked by an order, in the order of the
+// requests it was computed for
+type OrderMargin struct {
+	Exchange        string  `json:"exchange"`
+	Symbol          string  `json:"symbol"`
+	TransactionType string  `json:"transaction_type"`
+	SPAN            float64 `json:"span"`
+	Exposure        float64 `json:"exposure"`
+	OptionPremium   float64 `json:"option_premium"`
+	VAR             float64 `json:"var"` // VaR + ELM of equity orders
+	Total           float64 `json:"total"`
+}
+
+// BasketMargin is the margin blocked by orders placed together. Final is
+// less than Initial, the sum of the orders' own margins, when they hedge
+// each other or the open positions.
+type BasketMargin struct {
+	Initial OrderMargin   `json:"initial"`
+	Final   OrderMargin   `json:"final"`
+	Orders  []OrderMargin `json:"orders"`
+}
+
+// GTTManager is implemented by brokers with good-till-triggered orders: the
+// broker holds the trigger, places its order when the last price crosses it
+// and keeps it until then, for up to a year
+type GTTManager interface {
+	PlaceGTT(gtt *GTTRequest) (int, error)
+	ModifyGTT(triggerID int, gtt *GTTRequest) error
+	DeleteGTT(triggerID int) error
+	GetGTTs() ([]GTT, error)
+	GetGTT(triggerID int) (*GTT, error)
+}
+
+// GTTs returns b's GTTs when the broker beneath its decorators has them.
+// Decorators forward the GTT calls, each through GTTs of the broker it
+// wraps, so calls go through their circuit breaker, metrics and spans.
+func GTTs(b Broker) (GTTManager, error) {
+	if _, ok := Unwrap(b).(GTTManager); !ok {
+		return nil, ErrGTTNotSupported
+	}
+	gtts, ok := b.(GTTManager)
+	if !ok {
+		return nil, ErrGTTNotSupported
+	}
+	return gtts, nil
+}
+
+// GTT types
+const (
+	GTTSingle = "single"  // One trigger, above or below the last price
+	GTTOCO    = "two-leg" // A lower and an upper trigger; one cancels the other
+)
+
+// GTTLeg is a trigger and the limit order placed when the last price
+// crosses it
+type GTTLeg struct {
+	TriggerPrice float64 `json:"trigger_price"`
+	LimitPrice   float64 `json:"limit_price"`
+	Quantity     int     `json:"quantity"`
+}
+
+// GTTRequest is a GTT to place or modify: one leg for a single trigger,
+// the lower then the upper trigger for OCO. LastPrice is the price the
+// triggers are set against, the current one if 0.
+type GTTRequest struct {
+	Symbol          string   `json:"symbol"`
+	Exchange        string   `json:"exchange"`
+	TransactionType string   `json:"transaction_type"`
+	LastPrice       float64  `json:"last_price"`
+	Legs            []GTTLeg `json:"legs"`
+}
+
+// Validate upper-cases the request, defaulting the exchange to NSE, and
+// checks its legs: one, or two with the lower trigger first, on either side
+// of the last price when it's set
+func (g *GTTRequest) Validate() error {
+	g.Symbol = strings.ToUpper(g.Symbol)
+	g.Exchange = strings.ToUpper(g.Exchange)
+	if g.Exchange == "" {
+		g.Exchange = "NSE"
+	}
+	g.TransactionType = strings.ToUpper(g.TransactionType)
+
+	switch {
+	case g.Symbol == "":
+		return fmt.Errorf("%w: symbol is required", ErrInvalidSymbol)
+	case g.TransactionType != "BUY" && g.TransactionType != "SELL":
+		return fmt.Errorf("%w: transaction_type must be BUY or SELL", ErrInvalidOrderType)
+	case len(g.Legs) != 1 && len(g.Legs) != 2:
+		return fmt.Errorf("%w: a GTT has one leg, or two for OCO", ErrInvalidOrderType)
+	case g.LastPrice < 0:
+		return fmt.Errorf("%w: last price cannot be negative", ErrInvalidPrice)
+	}
+	for i, leg := range g.Legs {
+		if leg.TriggerPrice <= 0 || leg.LimitPrice <= 0 {
+			return fmt.Errorf("%w: leg %d needs a trigger and limit price", ErrInvalidPrice, i+1)
+		}
+		if leg.Quantity <= 0 {
+			return fmt.Errorf("%w: leg %d quantity must be positive", ErrInvalidQuantity, i+1)
+		}
+	}
+
+	if len(g.Legs) == 2 {
+		lower, upper := g.Legs[0].TriggerPrice, g.Legs[1].TriggerPrice
+		if lower >= upper {
+			return fmt.Errorf("%w: the lower trigger %.2f must come first, below the upper %.2f", ErrInvalidPrice, lower, upper)
+		}
+		if g.LastPrice > 0 && (lower >= g.LastPrice || upper <= g.LastPrice) {
+			return fmt.Errorf("%w: triggers %.2f and %.2f must be either side of the last price %.2f", ErrInvalidPrice, lower, upper, g.LastPrice)
+		}
+	} else if g.LastPrice > 0 && g.Legs[0].TriggerPrice == g.LastPrice {
+		return fmt.Errorf("%w: trigger %.2f must differ from the last price", ErrInvalidPrice, g.LastPrice)
+	}
+	return nil
+}
+
+// GTT is a GTT held by the broker
+type GTT struct {
+	ID              int       `json:"id"`
+	Type            string    `json:"type"`   // single or two-leg
+	Status          string    `json:"status"` // active, triggered, disabled, expired, cancelled, rejected or deleted
+	Symbol          string    `json:"symbol"`
+	Exchange        string    `json:"exchange"`
+	TransactionType string    `json:"transaction_type"`
+	Product         string    `json:"product"`
+	LastPrice       float64   `json:"last_price"`
+	Legs            []GTTLeg  `json:"legs"`
+	RejectionReason string    `json:"rejection_reason,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	ExpiresAt       time.Time `json:"expires_at"`
 }
 
 // OrderModify represents order modification
@@ -151,6 +352,7 @@ type Quote struct {
 	Volume       int64
 	BuyQuantity  int64
 	SellQuantity int64
+	OI           int64 // Open interest, for futures and options
 	Timestamp    time.Time
 }
 
@@ -183,25 +385,49 @@ type Instrument struct {
 type BrokerConfig struct {
 	ConfigID         int        `db:"config_id"`
 	UserID           string     `db:"user_id"`           // User who owns this broker account
-	BrokerName       string     `db:"broker_name"`       // zerodha, angelone, upstox, icicidirect
+	BrokerName       string     `db:"broker_name"`       // zerodha, angelone, upstox, fyers, icicidirect, paper
 	APIKey           string     `db:"api_key"`
 	APISecret        string     `db:"api_secret"`
 	AccessToken      string     `db:"access_token"`
 	RefreshToken     string     `db:"refresh_token"`
 	TokenExpiresAt   *time.Time `db:"token_expires_at"`
 	LastTokenRefresh *time.Time `db:"last_token_refresh"`
+	TokenStatus      string     `db:"token_status"`      // active, expired or refresh_failed
+	TokenError       string     `db:"token_error"`       // Why the last refresh failed
 	IsActive         bool       `db:"is_active"`
 	AccountName      string     `db:"account_name"`      // User-friendly name for this account
 	IsDefault        bool       `db:"is_default"`        // Default broker account for user
 	CreatedAt        time.Time  `db:"created_at"`
 	UpdatedAt        time.Time  `db:"updated_at"`
 
+	// OAuth redirect URI registered with the broker app (Upstox, Fyers)
+	RedirectURL string
+
+	// Paper trading settings when BrokerName is "paper"
+	Paper *PaperConfig
+
 	// Legacy fields for backward compatibility
-	ID              int
-	DisplayName     string
-	Enabled         bool
-	MaxPositions    int
-	MaxRiskPerTrade float64
+	ID          int
+	DisplayName string
+	Enabled     bool
+
+	// Risk limits enforced on new orders (0 disables a limit)
+	MaxPositions      int
+	MaxRiskPerTrade   float64 // % of capital at risk per trade
+	MaxDailyLoss      float64 // Loss for the day after which new orders are rejected
+	MaxSymbolExposure float64 // % of capital in a single symbol
+}
+
+// Unwrap returns the broker beneath any decorators, such as the trade
+// journal, that implement Unwrap() Broker
+func Unwrap(b Broker) Broker {
+	for {
+		wrapper, ok := b.(interface{ Unwrap() Broker })
+		if !ok {
+			return b
+		}
+		b = wrapper.Unwrap()
+	}
 }
 
 // Factory creates broker instances based on config
@@ -210,11 +436,13 @@ func NewBroker(config *BrokerConfig) (Broker, error) {
 	case "zerodha":
 		return NewZerodhaBroker(config)
 	case "angelone":
-		// return NewAngelOneBroker(config)
-		return nil, ErrBrokerNotSupported
+		return NewAngelOneBroker(config)
 	case "upstox":
-		// return NewUpstoxBroker(config)
-		return nil, ErrBrokerNotSupported
+		return NewUpstoxBroker(config)
+	case "fyers":
+		return NewFyersBroker(config)
+	case "paper":
+		return NewPaperBroker(config)
 	default:
 		return nil, ErrBrokerNotSupported
 	}
@@ -1,6 +1,9 @@
 package broker
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+)
 
 var (
 	ErrBrokerNotSupported   = errors.New("broker not supported")
@@ -14,4 +17,14 @@ var (
 	ErrInvalidQuantity      = errors.New("invalid quantity")
 	ErrInvalidPrice         = errors.New("invalid price")
 	ErrMaxPositionsReached  = errors.New("maximum positions reached")
+	ErrExitsNotSupported    = errors.New("stop-loss and target legs not supported by this broker")
+	ErrGTTNotSupported      = errors.New("GTT orders not supported by this broker")
+	ErrBrokerUnavailable    = errors.New("broker unavailable") // Server errors and rate limiting, worth retrying
+	ErrCircuitOpen          = errors.New("broker circuit open, calls suspended after repeated failures")
 )
+
+// unavailableStatus reports whether an HTTP status is the broker failing or
+// rate limiting rather than rejecting the request
+func unavailableStatus(status int) bool {
+	return status >= http.StatusInternalServerError || status == http.StatusTooManyRequests
+}
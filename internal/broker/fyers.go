@@ -0,0 +1,947 @@
+package broker
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/trading-chitti/market-bridge/internal/timeframe"
+)
+
+const (
+	fyersBaseURL      = "https://api-t1.fyers.in/api/v3"
+	fyersDataURL      = "https://api-t1.fyers.in/data"
+	fyersSymbolMaster = "https://public.fyers.in/sym_details/%s.csv"
+)
+
+// fyersSegments are the symbol master files loaded for each exchange
+var fyersSegments = map[string][]string{
+	"NSE": {"NSE_CM", "NSE_FO"},
+	"BSE": {"BSE_CM", "BSE_FO"},
+	"MCX": {"MCX_COM"},
+}
+
+// FyersBroker implements the Broker interface for Fyers API v3
+type FyersBroker struct {
+	config *BrokerConfig
+	client *http.Client
+	logger *logrus.Logger
+
+	mu          sync.RWMutex
+	accessToken string
+}
+
+// NewFyersBroker creates a new Fyers broker instance.
+// APIKey is the Fyers app ID (e.g. "XB12345-100")
+func NewFyersBroker(config *BrokerConfig) (*FyersBroker, error) {
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("%w: fyers app id is required", ErrInvalidCredentials)
+	}
+
+	if config.RedirectURL == "" {
+		config.RedirectURL = os.Getenv("FYERS_REDIRECT_URI")
+	}
+
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.TextFormatter{
+		FullTimestamp: true,
+	})
+
+	broker := &FyersBroker{
+		config:      config,
+		client:      &http.Client{Timeout: 30 * time.Second},
+		logger:      logger,
+		accessToken: config.AccessToken,
+	}
+
+	broker.logger.Info("✅ Fyers broker initialized")
+
+	return broker, nil
+}
+
+// ============================================================================
+// HTTP HELPERS
+// ============================================================================
+
+// fyersStatus is embedded in every Fyers response
+type fyersStatus struct {
+	S       string `json:"s"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// request performs a Fyers API call and decodes the whole response into out.
+// Fyers returns payload fields next to the status, not under a data key.
+func (f *FyersBroker) request(method, endpoint string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	f.mu.RLock()
+	token := f.accessToken
+	f.mu.RUnlock()
+	if token != "" {
+		req.Header.Set("Authorization", f.config.APIKey+":"+token)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fyers request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read fyers response: %w", err)
+	}
+
+	if unavailableStatus(resp.StatusCode) {
+		return fmt.Errorf("%w: fyers returned HTTP %d: %s", ErrBrokerUnavailable, resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+
+	var status fyersStatus
+	if err := json.Unmarshal(raw, &status); err != nil {
+		return fmt.Errorf("fyers returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+
+	if status.S != "ok" {
+		switch status.Code {
+		case -8, -15, -16, -17:
+			return ErrSessionExpired
+		case -99:
+			return ErrInsufficientFunds
+		}
+		return fmt.Errorf("fyers error %d: %s", status.Code, status.Message)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("failed to decode fyers response: %w", err)
+	}
+
+	return nil
+}
+
+// ============================================================================
+// AUTHENTICATION
+// ============================================================================
+
+// GetLoginURL returns the Fyers auth code URL
+func (f *FyersBroker) GetLoginURL() string {
+	params := url.Values{}
+	params.Set("client_id", f.config.APIKey)
+	params.Set("redirect_uri", f.config.RedirectURL)
+	params.Set("response_type", "code")
+	params.Set("state", "market-bridge")
+	return fyersBaseURL + "/generate-authcode?" + params.Encode()
+}
+
+// GenerateSession exchanges the auth code for an access token
+func (f *FyersBroker) GenerateSession(requestToken string) (*Session, error) {
+	hash := sha256.Sum256([]byte(f.config.APIKey + ":" + f.config.APISecret))
+
+	var data struct {
+		fyersStatus
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	err := f.request(http.MethodPost, fyersBaseURL+"/validate-authcode", map[string]string{
+		"grant_type": "authorization_code",
+		"appIdHash":  hex.EncodeToString(hash[:]),
+		"code":       requestToken,
+	}, &data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate session: %w", err)
+	}
+
+	if data.AccessToken == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	f.SetAccessToken(data.AccessToken)
+	if data.RefreshToken != "" {
+		f.config.RefreshToken = data.RefreshToken
+	}
+
+	userID := ""
+	if profile, err := f.GetProfile(); err == nil {
+		userID = profile.UserID
+	}
+
+	f.logger.Infof("✅ Session generated for user: %s", userID)
+
+	return &Session{
+		UserID:       userID,
+		AccessToken:  data.AccessToken,
+		RefreshToken: data.RefreshToken,
+		ExpiresAt:    time.Now().Add(24 * time.Hour), // Fyers tokens expire daily
+	}, nil
+}
+
+// SetAccessToken sets the access token
+func (f *FyersBroker) SetAccessToken(token string) {
+	f.mu.Lock()
+	f.accessToken = token
+	f.mu.Unlock()
+	f.config.AccessToken = token
+}
+
+// ============================================================================
+// ACCOUNT INFO
+// ============================================================================
+
+// GetProfile returns user profile
+func (f *FyersBroker) GetProfile() (*Profile, error) {
+	var data struct {
+		fyersStatus
+		Data struct {
+			FyID         string `json:"fy_id"`
+			Name         string `json:"name"`
+			EmailID      string `json:"email_id"`
+			MobileNumber string `json:"mobile_number"`
+		} `json:"data"`
+	}
+	if err := f.request(http.MethodGet, fyersBaseURL+"/profile", nil, &data); err != nil {
+		return nil, err
+	}
+
+	return &Profile{
+		UserID:    data.Data.FyID,
+		UserName:  data.Data.Name,
+		Email:     data.Data.EmailID,
+		Phone:     data.Data.MobileNumber,
+		Broker:    "fyers",
+		Products:  []string{"CNC", "INTRADAY", "MARGIN"},
+		Exchanges: []string{"NSE", "BSE", "MCX"},
+	}, nil
+}
+
+// GetMargins returns account margins
+func (f *FyersBroker) GetMargins() (*Margins, error) {
+	var data struct {
+		fyersStatus
+		FundLimit []struct {
+			Title           string  `json:"title"`
+			EquityAmount    float64 `json:"equityAmount"`
+			CommodityAmount float64 `json:"commodityAmount"`
+		} `json:"fund_limit"`
+	}
+	if err := f.request(http.MethodGet, fyersBaseURL+"/funds", nil, &data); err != nil {
+		return nil, err
+	}
+
+	result := &Margins{}
+	for _, limit := range data.FundLimit {
+		switch limit.Title {
+		case "Available Balance":
+			result.Equity.Available = limit.EquityAmount
+			result.Commodity.Available = limit.CommodityAmount
+		case "Utilized Amount":
+			result.Equity.Used = limit.EquityAmount
+			result.Commodity.Used = limit.CommodityAmount
+		case "Total Balance":
+			result.Equity.Net = limit.EquityAmount
+			result.Commodity.Net = limit.CommodityAmount
+		}
+	}
+
+	f.logger.Infof("💰 Equity Available: ₹%.2f", result.Equity.Available)
+
+	return result, nil
+}
+
+// GetPositions returns current positions
+func (f *FyersBroker) GetPositions() (*Positions, error) {
+	var data struct {
+		fyersStatus
+		NetPositions []struct {
+			Symbol      string  `json:"symbol"`
+			NetQty      int     `json:"netQty"`
+			NetAvg      float64 `json:"netAvg"`
+			LTP         float64 `json:"ltp"`
+			PL          float64 `json:"pl"`
+			ProductType string  `json:"productType"`
+			CFBuyQty    int     `json:"cfBuyQty"`
+			CFSellQty   int     `json:"cfSellQty"`
+		} `json:"netPositions"`
+	}
+	if err := f.request(http.MethodGet, fyersBaseURL+"/positions", nil, &data); err != nil {
+		return nil, err
+	}
+
+	result := &Positions{
+		Net: make([]Position, 0, len(data.NetPositions)),
+		Day: make([]Position, 0, len(data.NetPositions)),
+	}
+
+	for _, p := range data.NetPositions {
+		exchange, symbol := splitFyersSymbol(p.Symbol)
+		overnight := p.CFBuyQty > 0 || p.CFSellQty > 0
+		position := Position{
+			Symbol:       symbol,
+			Exchange:     exchange,
+			Product:      fyersToProduct(p.ProductType),
+			Quantity:     p.NetQty,
+			AveragePrice: p.NetAvg,
+			LastPrice:    p.LTP,
+			PNL:          p.PL,
+			Overnight:    overnight,
+		}
+		result.Net = append(result.Net, position)
+		if !overnight {
+			result.Day = append(result.Day, position)
+		}
+	}
+
+	f.logger.Infof("📊 Positions: %d net, %d day", len(result.Net), len(result.Day))
+
+	return result, nil
+}
+
+// GetHoldings returns holdings
+func (f *FyersBroker) GetHoldings() ([]Holding, error) {
+	var data struct {
+		fyersStatus
+		Holdings []struct {
+			Symbol    string  `json:"symbol"`
+			Quantity  int     `json:"quantity"`
+			CostPrice float64 `json:"costPrice"`
+			LTP       float64 `json:"ltp"`
+			PL        float64 `json:"pl"`
+		} `json:"holdings"`
+	}
+	if err := f.request(http.MethodGet, fyersBaseURL+"/holdings", nil, &data); err != nil {
+		return nil, err
+	}
+
+	result := make([]Holding, 0, len(data.Holdings))
+	for _, h := range data.Holdings {
+		exchange, symbol := splitFyersSymbol(h.Symbol)
+		pnlPercent := 0.0
+		if h.CostPrice != 0 {
+			pnlPercent = (h.LTP - h.CostPrice) / h.CostPrice * 100
+		}
+		result = append(result, Holding{
+			Symbol:       symbol,
+			Exchange:     exchange,
+			Quantity:     h.Quantity,
+			AveragePrice: h.CostPrice,
+			LastPrice:    h.LTP,
+			PNL:          h.PL,
+			PNLPercent:   pnlPercent,
+		})
+	}
+
+	f.logger.Infof("💼 Holdings: %d stocks", len(result))
+
+	return result, nil
+}
+
+// GetOrders returns orders for the day
+func (f *FyersBroker) GetOrders() ([]Order, error) {
+	var data struct {
+		fyersStatus
+		OrderBook []struct {
+			ID                string  `json:"id"`
+			Symbol            string  `json:"symbol"`
+			Qty               int     `json:"qty"`
+			Type              int     `json:"type"`
+			Side              int     `json:"side"`
+			ProductType       string  `json:"productType"`
+			LimitPrice        float64 `json:"limitPrice"`
+			StopPrice         float64 `json:"stopPrice"`
+			Status            int     `json:"status"`
+			FilledQty         int     `json:"filledQty"`
+			RemainingQuantity int     `json:"remainingQuantity"`
+			TradedPrice       float64 `json:"tradedPrice"`
+			OrderDateTime     string  `json:"orderDateTime"`
+		} `json:"orderBook"`
+	}
+	if err := f.request(http.MethodGet, fyersBaseURL+"/orders", nil, &data); err != nil {
+		return nil, err
+	}
+
+	loc := IST()
+
+	result := make([]Order, 0, len(data.OrderBook))
+	for _, o := range data.OrderBook {
+		exchange, symbol := splitFyersSymbol(o.Symbol)
+		placedAt, _ := time.ParseInLocation("02-Jan-2006 15:04:05", o.OrderDateTime, loc)
+
+		transactionType := "BUY"
+		if o.Side == -1 {
+			transactionType = "SELL"
+		}
+
+		result = append(result, Order{
+			OrderID:         o.ID,
+			Symbol:          symbol,
+			Exchange:        exchange,
+			TransactionType: transactionType,
+			OrderType:       fyersToOrderType(o.Type),
+			Product:         fyersToProduct(o.ProductType),
+			Quantity:        o.Qty,
+			Price:           o.LimitPrice,
+			TriggerPrice:    o.StopPrice,
+			Status:          fyersOrderStatus(o.Status),
+			FilledQuantity:  o.FilledQty,
+			PendingQuantity: o.RemainingQuantity,
+			AveragePrice:    o.TradedPrice,
+			PlacedAt:        placedAt,
+			UpdatedAt:       placedAt,
+		})
+	}
+
+	f.logger.Infof("📝 Orders today: %d", len(result))
+
+	return result, nil
+}
+
+// ============================================================================
+// MARKET DATA
+// ============================================================================
+
+// fyersQuote is a single entry from the quotes endpoint
+type fyersQuote struct {
+	N string `json:"n"`
+	S string `json:"s"`
+	V struct {
+		LP        float64 `json:"lp"`
+		Open      float64 `json:"open_price"`
+		High      float64 `json:"high_price"`
+		Low       float64 `json:"low_price"`
+		PrevClose float64 `json:"prev_close_price"`
+		Ch        float64 `json:"ch"`
+		Chp       float64 `json:"chp"`
+		Volume    int64   `json:"volume"`
+		TT        int64   `json:"tt"`
+	} `json:"v"`
+}
+
+func (f *FyersBroker) quotes(symbols []string) ([]fyersQuote, map[string]string, error) {
+	keys := make(map[string]string, len(symbols))
+	list := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		fs := FyersSymbol(symbol)
+		keys[fs] = symbol
+		list = append(list, fs)
+	}
+
+	var data struct {
+		fyersStatus
+		D []fyersQuote `json:"d"`
+	}
+	endpoint := fyersDataURL + "/quotes?symbols=" + url.QueryEscape(strings.Join(list, ","))
+	if err := f.request(http.MethodGet, endpoint, nil, &data); err != nil {
+		return nil, nil, err
+	}
+
+	return data.D, keys, nil
+}
+
+// GetQuote returns real-time quotes for "EXCHANGE:SYMBOL" instruments
+func (f *FyersBroker) GetQuote(symbols []string) (map[string]Quote, error) {
+	quotes, keys, err := f.quotes(symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]Quote)
+	for _, q := range quotes {
+		symbol, ok := keys[q.N]
+		if !ok || q.S != "ok" {
+			continue
+		}
+		result[symbol] = Quote{
+			Symbol:        symbol,
+			LastPrice:     q.V.LP,
+			Open:          q.V.Open,
+			High:          q.V.High,
+			Low:           q.V.Low,
+			Close:         q.V.PrevClose,
+			Change:        q.V.Ch,
+			ChangePercent: q.V.Chp,
+			Volume:        q.V.Volume,
+			Timestamp:     time.Unix(q.V.TT, 0),
+		}
+	}
+
+	return result, nil
+}
+
+// GetLTP returns last traded prices for "EXCHANGE:SYMBOL" instruments
+func (f *FyersBroker) GetLTP(symbols []string) (map[string]float64, error) {
+	quotes, keys, err := f.quotes(symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]float64)
+	for _, q := range quotes {
+		if symbol, ok := keys[q.N]; ok && q.S == "ok" {
+			result[symbol] = q.V.LP
+		}
+	}
+
+	return result, nil
+}
+
+// SymbolTokens returns the Fyers token ("10100000002885") of each Fyers
+// ticker ("NSE:RELIANCE-EQ"). Tickers Fyers doesn't know are left out.
+func (f *FyersBroker) SymbolTokens(symbols []string) (map[string]string, error) {
+	var data struct {
+		fyersStatus
+		ValidSymbol   map[string]string `json:"validSymbol"`
+		InvalidSymbol []string          `json:"invalidSymbol"`
+	}
+	err := f.request(http.MethodPost, fyersDataURL+"/symbol-token", map[string][]string{
+		"symbols": symbols,
+	}, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data.InvalidSymbol) > 0 {
+		f.logger.Warnf("⚠️  Fyers has no token for %s", strings.Join(data.InvalidSymbol, ", "))
+	}
+
+	return data.ValidSymbol, nil
+}
+
+// DataSocketKey returns the key the market data socket authenticates
+// with, which Fyers carries as hsm_key in the access token's claims
+func (f *FyersBroker) DataSocketKey() (string, error) {
+	f.mu.RLock()
+	token := f.accessToken
+	f.mu.RUnlock()
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", ErrSessionExpired
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode fyers access token: %w", err)
+	}
+
+	var claims struct {
+		HSMKey string `json:"hsm_key"`
+		Exp    int64  `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", fmt.Errorf("failed to decode fyers access token: %w", err)
+	}
+	if claims.HSMKey == "" || (claims.Exp > 0 && time.Now().Unix() >= claims.Exp) {
+		return "", ErrSessionExpired
+	}
+
+	return claims.HSMKey, nil
+}
+
+// fyersResolutions maps timeframes to Fyers resolutions
+var fyersResolutions = map[timeframe.Timeframe]string{
+	timeframe.Minute1:  "1",
+	timeframe.Minute3:  "3",
+	timeframe.Minute5:  "5",
+	timeframe.Minute10: "10",
+	timeframe.Minute15: "15",
+	timeframe.Minute30: "30",
+	timeframe.Hour1:    "60",
+	timeframe.Day:      "D",
+}
+
+// GetHistoricalData returns historical OHLCV data.
+// instrument is "EXCHANGE:SYMBOL"; interval is a Kite name (5minute) or bar timeframe (5m)
+func (f *FyersBroker) GetHistoricalData(instrument string, from, to time.Time, interval string) ([]Candle, error) {
+	tf, err := timeframe.Parse(interval)
+	if err != nil {
+		return nil, err
+	}
+	resolution := fyersResolutions[tf]
+
+	params := url.Values{}
+	params.Set("symbol", FyersSymbol(instrument))
+	params.Set("resolution", resolution)
+	params.Set("date_format", "0")
+	params.Set("range_from", strconv.FormatInt(from.Unix(), 10))
+	params.Set("range_to", strconv.FormatInt(to.Unix(), 10))
+	params.Set("cont_flag", "1")
+
+	var data struct {
+		fyersStatus
+		Candles [][]float64 `json:"candles"`
+	}
+	if err := f.request(http.MethodGet, fyersDataURL+"/history?"+params.Encode(), nil, &data); err != nil {
+		return nil, err
+	}
+
+	candles := make([]Candle, 0, len(data.Candles))
+	for _, row := range data.Candles {
+		if len(row) < 6 {
+			continue
+		}
+		candles = append(candles, Candle{
+			Date:   time.Unix(int64(row[0]), 0),
+			Open:   row[1],
+			High:   row[2],
+			Low:    row[3],
+			Close:  row[4],
+			Volume: int64(row[5]),
+		})
+	}
+
+	return candles, nil
+}
+
+// GetInstruments returns tradable instruments from the public symbol master
+func (f *FyersBroker) GetInstruments(exchange string) ([]Instrument, error) {
+	exchanges := []string{exchange}
+	if exchange == "" {
+		exchanges = []string{"NSE", "BSE", "MCX"}
+	}
+
+	result := make([]Instrument, 0)
+	for _, ex := range exchanges {
+		for _, segment := range fyersSegments[ex] {
+			instruments, err := f.downloadSymbolMaster(ex, segment)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, instruments...)
+		}
+	}
+
+	f.logger.Infof("🏢 Loaded %d instruments from %s", len(result), exchange)
+
+	return result, nil
+}
+
+// downloadSymbolMaster parses a Fyers symbol master CSV. Columns:
+// 0 Fytoken, 1 Symbol Details, 2 Instrument type, 3 Lot size, 4 Tick size,
+// 5 ISIN, 6 Session, 7 Last update, 8 Expiry (epoch), 9 Symbol ticker,
+// 10 Exchange, 11 Segment, 12 Scrip code, 13 Underlying symbol, 14 Underlying scrip code, 15 Strike
+func (f *FyersBroker) downloadSymbolMaster(exchange, segment string) ([]Instrument, error) {
+	resp, err := f.client.Get(fmt.Sprintf(fyersSymbolMaster, segment))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download symbol master: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("symbol master %s returned HTTP %d", segment, resp.StatusCode)
+	}
+
+	reader := csv.NewReader(resp.Body)
+	reader.FieldsPerRecord = -1
+
+	result := make([]Instrument, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse symbol master: %w", err)
+		}
+		if len(record) < 16 {
+			continue
+		}
+
+		token, err := strconv.ParseInt(record[12], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		var expiry *time.Time
+		if secs, err := strconv.ParseInt(record[8], 10, 64); err == nil && secs > 0 {
+			t := time.Unix(secs, 0)
+			expiry = &t
+		}
+
+		lotSize, _ := strconv.Atoi(record[3])
+		tickSize, _ := strconv.ParseFloat(record[4], 64)
+		strike, _ := strconv.ParseFloat(record[15], 64)
+		if strike < 0 {
+			strike = 0
+		}
+
+		_, tradingSymbol := splitFyersSymbol(record[9])
+
+		result = append(result, Instrument{
+			InstrumentToken: token,
+			ExchangeToken:   token,
+			TradingSymbol:   tradingSymbol,
+			Name:            record[1],
+			Exchange:        exchange,
+			InstrumentType:  record[2],
+			Segment:         segment,
+			Expiry:          expiry,
+			Strike:          strike,
+			TickSize:        tickSize,
+			LotSize:         lotSize,
+		})
+	}
+
+	return result, nil
+}
+
+// ============================================================================
+// TRADING
+// ============================================================================
+
+// PlaceOrder places a new order
+func (f *FyersBroker) PlaceOrder(order *OrderRequest) (string, error) {
+	if order.Quantity <= 0 {
+		return "", ErrInvalidQuantity
+	}
+	if order.HasExits() {
+		return "", ErrExitsNotSupported
+	}
+
+	orderType, err := orderTypeToFyers(order.OrderType)
+	if err != nil {
+		return "", err
+	}
+
+	side := 1
+	if order.TransactionType == "SELL" {
+		side = -1
+	}
+
+	validity := order.Validity
+	if validity == "" {
+		validity = "DAY"
+	}
+
+	params := map[string]interface{}{
+		"symbol":       FyersSymbol(order.Exchange + ":" + order.Symbol),
+		"qty":          order.Quantity,
+		"type":         orderType,
+		"side":         side,
+		"productType":  productToFyers(order.Product),
+		"limitPrice":   order.Price,
+		"stopPrice":    order.TriggerPrice,
+		"validity":     validity,
+		"disclosedQty": 0,
+		"offlineOrder": false,
+	}
+	if order.Tag != "" {
+		params["orderTag"] = order.Tag
+	}
+
+	var data struct {
+		fyersStatus
+		ID string `json:"id"`
+	}
+	if err := f.request(http.MethodPost, fyersBaseURL+"/orders/sync", params, &data); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrOrderRejected, err)
+	}
+
+	f.logger.Infof("📤 Order placed: %s - %s %d %s @ %s",
+		data.ID, order.TransactionType, order.Quantity, order.Symbol, order.OrderType)
+
+	return data.ID, nil
+}
+
+// ModifyOrder modifies an existing order
+func (f *FyersBroker) ModifyOrder(orderID string, modify *OrderModify) (string, error) {
+	params := map[string]interface{}{"id": orderID}
+
+	if modify.Quantity != nil {
+		params["qty"] = *modify.Quantity
+	}
+	if modify.Price != nil {
+		params["limitPrice"] = *modify.Price
+	}
+	if modify.TriggerPrice != nil {
+		params["stopPrice"] = *modify.TriggerPrice
+	}
+	if modify.OrderType != nil {
+		orderType, err := orderTypeToFyers(*modify.OrderType)
+		if err != nil {
+			return "", err
+		}
+		params["type"] = orderType
+	}
+
+	var data struct {
+		fyersStatus
+		ID string `json:"id"`
+	}
+	if err := f.request(http.MethodPatch, fyersBaseURL+"/orders/sync", params, &data); err != nil {
+		return "", err
+	}
+	if data.ID == "" {
+		data.ID = orderID
+	}
+
+	f.logger.Infof("✏️  Order modified: %s", data.ID)
+
+	return data.ID, nil
+}
+
+// CancelOrder cancels an order
+func (f *FyersBroker) CancelOrder(orderID string) (string, error) {
+	var data struct {
+		fyersStatus
+		ID string `json:"id"`
+	}
+	if err := f.request(http.MethodDelete, fyersBaseURL+"/orders/sync", map[string]string{"id": orderID}, &data); err != nil {
+		return "", err
+	}
+	if data.ID == "" {
+		data.ID = orderID
+	}
+
+	f.logger.Infof("❌ Order cancelled: %s", data.ID)
+
+	return data.ID, nil
+}
+
+// ============================================================================
+// UTILITY
+// ============================================================================
+
+// IsMarketOpen checks if market is open
+func (f *FyersBroker) IsMarketOpen() bool {
+	return isIndianMarketOpen()
+}
+
+// GetMarketStatus returns current market status
+func (f *FyersBroker) GetMarketStatus() string {
+	return indianMarketStatus()
+}
+
+// GetBrokerName returns the broker name
+func (f *FyersBroker) GetBrokerName() string {
+	return "fyers"
+}
+
+// FyersSymbol converts "NSE:RELIANCE" to the Fyers ticker "NSE:RELIANCE-EQ".
+// Symbols that already carry a series suffix or are derivatives are unchanged.
+func FyersSymbol(symbol string) string {
+	exchange, name := "NSE", symbol
+	if parts := strings.SplitN(symbol, ":", 2); len(parts) == 2 {
+		exchange, name = strings.ToUpper(parts[0]), parts[1]
+	}
+
+	if (exchange == "NSE" || exchange == "BSE") && !strings.Contains(name, "-") &&
+		!strings.HasSuffix(name, "FUT") && !strings.HasSuffix(name, "CE") && !strings.HasSuffix(name, "PE") {
+		name += "-EQ"
+	}
+
+	return exchange + ":" + name
+}
+
+// splitFyersSymbol turns "NSE:RELIANCE-EQ" into ("NSE", "RELIANCE")
+func splitFyersSymbol(symbol string) (string, string) {
+	exchange, name := "", symbol
+	if parts := strings.SplitN(symbol, ":", 2); len(parts) == 2 {
+		exchange, name = parts[0], parts[1]
+	}
+	name = strings.TrimSuffix(name, "-EQ")
+	name = strings.TrimSuffix(name, "-BE")
+	return exchange, name
+}
+
+// orderTypeToFyers maps MARKET/LIMIT/SL/SL-M to Fyers numeric order types
+func orderTypeToFyers(orderType string) (int, error) {
+	switch orderType {
+	case "LIMIT":
+		return 1, nil
+	case "MARKET":
+		return 2, nil
+	case "SL-M":
+		return 3, nil
+	case "SL":
+		return 4, nil
+	default:
+		return 0, ErrInvalidOrderType
+	}
+}
+
+func fyersToOrderType(orderType int) string {
+	switch orderType {
+	case 1:
+		return "LIMIT"
+	case 2:
+		return "MARKET"
+	case 3:
+		return "SL-M"
+	case 4:
+		return "SL"
+	default:
+		return strconv.Itoa(orderType)
+	}
+}
+
+func fyersOrderStatus(status int) string {
+	switch status {
+	case 1:
+		return "CANCELLED"
+	case 2:
+		return "COMPLETE"
+	case 4:
+		return "TRANSIT"
+	case 5:
+		return "REJECTED"
+	case 6:
+		return "OPEN"
+	default:
+		return strconv.Itoa(status)
+	}
+}
+
+// productToFyers maps MIS/CNC/NRML to Fyers product types
+func productToFyers(product string) string {
+	switch product {
+	case "MIS":
+		return "INTRADAY"
+	case "NRML":
+		return "MARGIN"
+	default:
+		return product
+	}
+}
+
+func fyersToProduct(product string) string {
+	switch product {
+	case "INTRADAY":
+		return "MIS"
+	case "MARGIN":
+		return "NRML"
+	default:
+		return product
+	}
+}
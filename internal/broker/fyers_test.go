@@ -0,0 +1,48 @@
+package broker
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFyersDataSocketKey(t *testing.T) {
+	token := func(claims string) string {
+		return "e30." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".sig"
+	}
+	future := time.Now().Add(time.Hour).Unix()
+	past := time.Now().Add(-time.Hour).Unix()
+
+	tests := []struct {
+		name    string
+		token   string
+		want    string
+		wantErr error
+	}{
+		{"key", token(`{"hsm_key":"abc123","exp":` + strconv.FormatInt(future, 10) + `}`), "abc123", nil},
+		{"no expiry", token(`{"hsm_key":"abc123"}`), "abc123", nil},
+		{"expired", token(`{"hsm_key":"abc123","exp":` + strconv.FormatInt(past, 10) + `}`), "", ErrSessionExpired},
+		{"no key", token(`{"exp":` + strconv.FormatInt(future, 10) + `}`), "", ErrSessionExpired},
+		{"no token", "", "", ErrSessionExpired},
+		{"not a jwt", "abc", "", ErrSessionExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFyersBroker(&BrokerConfig{APIKey: "XB12345-100", AccessToken: tt.token})
+			if err != nil {
+				t.Fatalf("NewFyersBroker: %v", err)
+			}
+
+			got, err := f.DataSocketKey()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DataSocketKey error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DataSocketKey = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package broker
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	kiteconnect "github.com/zerodha/gokiteconnect/v4"
+)
+
+func TestGTTRequestValidate(t *testing.T) {
+	leg := func(trigger float64) GTTLeg {
+		return GTTLeg{TriggerPrice: trigger, LimitPrice: trigger, Quantity: 10}
+	}
+	tests := []struct {
+		name    string
+		gtt     GTTRequest
+		wantErr error
+	}{
+		{"single", GTTRequest{Symbol: "infy", TransactionType: "buy", Legs: []GTTLeg{leg(1400)}}, nil},
+		{"oco", GTTRequest{Symbol: "INFY", TransactionType: "SELL", LastPrice: 1500, Legs: []GTTLeg{leg(1400), leg(1600)}}, nil},
+		{"no symbol", GTTRequest{TransactionType: "BUY", Legs: []GTTLeg{leg(1400)}}, ErrInvalidSymbol},
+		{"bad side", GTTRequest{Symbol: "INFY", TransactionType: "HOLD", Legs: []GTTLeg{leg(1400)}}, ErrInvalidOrderType},
+		{"no legs", GTTRequest{Symbol: "INFY", TransactionType: "BUY"}, ErrInvalidOrderType},
+		{"three legs", GTTRequest{Symbol: "INFY", TransactionType: "BUY", Legs: []GTTLeg{leg(1), leg(2), leg(3)}}, ErrInvalidOrderType},
+		{"no limit price", GTTRequest{Symbol: "INFY", TransactionType: "BUY", Legs: []GTTLeg{{TriggerPrice: 1400, Quantity: 1}}}, ErrInvalidPrice},
+		{"no quantity", GTTRequest{Symbol: "INFY", TransactionType: "BUY", Legs: []GTTLeg{{TriggerPrice: 1400, LimitPrice: 1400}}}, ErrInvalidQuantity},
+		{"oco upper first", GTTRequest{Symbol: "INFY", TransactionType: "SELL", Legs: []GTTLeg{leg(1600), leg(1400)}}, ErrInvalidPrice},
+		{"oco same side", GTTRequest{Symbol: "INFY", TransactionType: "SELL", LastPrice: 1300, Legs: []GTTLeg{leg(1400), leg(1600)}}, ErrInvalidPrice},
+		{"single at last price", GTTRequest{Symbol: "INFY", TransactionType: "BUY", LastPrice: 1400, Legs: []GTTLeg{leg(1400)}}, ErrInvalidPrice},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.gtt.Validate()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Validate = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Validate = %v", err)
+			}
+			if tt.gtt.Symbol != "INFY" || tt.gtt.Exchange != "NSE" || (tt.gtt.TransactionType != "BUY" && tt.gtt.TransactionType != "SELL") {
+				t.Errorf("request not normalized: %+v", tt.gtt)
+			}
+		})
+	}
+}
+
+func TestKiteGTT(t *testing.T) {
+	g := kiteconnect.GTT{
+		ID:     42,
+		Type:   kiteconnect.GTTTypeOCO,
+		Status: "active",
+		Condition: kiteconnect.GTTCondition{
+			Exchange:      "NSE",
+			Tradingsymbol: "INFY",
+			LastPrice:     1500,
+			TriggerValues: []float64{1400, 1600},
+		},
+		Orders: []kiteconnect.Order{
+			{TransactionType: "SELL", Product: "CNC", Quantity: 10, Price: 1395},
+			{TransactionType: "SELL", Product: "CNC", Quantity: 10, Price: 1600},
+		},
+	}
+
+	got := kiteGTT(g)
+	want := []GTTLeg{
+		{TriggerPrice: 1400, LimitPrice: 1395, Quantity: 10},
+		{TriggerPrice: 1600, LimitPrice: 1600, Quantity: 10},
+	}
+	if got.ID != 42 || got.Type != GTTOCO || got.Symbol != "INFY" || got.TransactionType != "SELL" || got.Product != "CNC" {
+		t.Errorf("kiteGTT = %+v", got)
+	}
+	if !reflect.DeepEqual(got.Legs, want) {
+		t.Errorf("legs = %+v, want %+v", got.Legs, want)
+	}
+}
+
+// gttBroker places GTTs, failing each call with the next of errs
+type gttBroker struct {
+	flakyBroker
+	placed []*GTTRequest
+}
+
+func (g *gttBroker) PlaceGTT(gtt *GTTRequest) (int, error) {
+	if err := g.next(); err != nil {
+		return 0, err
+	}
+	g.placed = append(g.placed, gtt)
+	return len(g.placed), nil
+}
+
+func (g *gttBroker) ModifyGTT(triggerID int, gtt *GTTRequest) error { return g.next() }
+func (g *gttBroker) DeleteGTT(triggerID int) error                  { return g.next() }
+func (g *gttBroker) GetGTTs() ([]GTT, error)                        { return nil, g.next() }
+func (g *gttBroker) GetGTT(triggerID int) (*GTT, error)             { return &GTT{ID: triggerID}, g.next() }
+
+func TestGTTs(t *testing.T) {
+	config := ResilienceConfig{FailureThreshold: 2, OpenTimeout: time.Minute}
+	tests := []struct {
+		name    string
+		broker  Broker
+		wantErr error
+	}{
+		{"bare", &gttBroker{}, nil},
+		{"decorated", WithResilience(WithMetrics(&gttBroker{}), config), nil},
+		{"no gtts", WithResilience(WithMetrics(&stubBroker{}), config), ErrGTTNotSupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gtts, err := GTTs(tt.broker)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GTTs = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if _, ok := gtts.(Broker); !ok || gtts.(Broker) != tt.broker {
+				t.Errorf("GTTs returned %T, want the broker itself", gtts)
+			}
+		})
+	}
+}
+
+func TestGTTsThroughCircuitBreaker(t *testing.T) {
+	inner := &gttBroker{flakyBroker: flakyBroker{errs: []error{ErrBrokerUnavailable, ErrBrokerUnavailable}}}
+	b := WithResilience(WithMetrics(inner), ResilienceConfig{FailureThreshold: 2, OpenTimeout: time.Minute})
+	gtts, err := GTTs(b)
+	if err != nil {
+		t.Fatalf("GTTs = %v", err)
+	}
+
+	gtt := &GTTRequest{Symbol: "INFY", TransactionType: "BUY", Legs: []GTTLeg{{TriggerPrice: 1400, LimitPrice: 1400, Quantity: 1}}}
+	for i := 0; i < 2; i++ {
+		if _, err := gtts.PlaceGTT(gtt); !errors.Is(err, ErrBrokerUnavailable) {
+			t.Fatalf("PlaceGTT %d = %v, want ErrBrokerUnavailable", i, err)
+		}
+	}
+	if _, err := gtts.PlaceGTT(gtt); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("PlaceGTT while open = %v, want ErrCircuitOpen", err)
+	}
+	if inner.calls != 2 || len(inner.placed) != 0 {
+		t.Errorf("calls = %d, placed = %d, want 2 and none while open", inner.calls, len(inner.placed))
+	}
+}
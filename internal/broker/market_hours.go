@@ -0,0 +1,148 @@
+package broker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Indian equity market hours shared by all NSE/BSE brokers
+
+// indianMarketHolidays are the NSE/BSE trading holidays falling on weekdays,
+// from the exchanges' holiday circulars. Extend them with MARKET_HOLIDAYS.
+var indianMarketHolidays = map[string]bool{
+	// 2024
+	"2024-01-22": true, "2024-01-26": true, "2024-03-08": true, "2024-03-25": true,
+	"2024-03-29": true, "2024-04-11": true, "2024-04-17": true, "2024-05-01": true,
+	"2024-05-20": true, "2024-06-17": true, "2024-07-17": true, "2024-08-15": true,
+	"2024-10-02": true, "2024-11-01": true, "2024-11-15": true, "2024-11-20": true,
+	"2024-12-25": true,
+	// 2025
+	"2025-02-26": true, "2025-03-14": true, "2025-03-31": true, "2025-04-10": true,
+	"2025-04-14": true, "2025-04-18": true, "2025-05-01": true, "2025-08-15": true,
+	"2025-08-27": true, "2025-10-02": true, "2025-10-21": true, "2025-10-22": true,
+	"2025-11-05": true, "2025-12-25": true,
+	// 2026
+	"2026-01-26": true, "2026-03-03": true, "2026-03-26": true, "2026-03-31": true,
+	"2026-04-03": true, "2026-04-14": true, "2026-05-01": true, "2026-05-28": true,
+	"2026-06-26": true, "2026-09-14": true, "2026-10-02": true, "2026-10-20": true,
+	"2026-11-10": true, "2026-11-24": true, "2026-12-25": true,
+}
+
+var holidaysMu sync.RWMutex
+
+// AddIndianMarketHolidays adds YYYY-MM-DD trading holidays to the calendar
+func AddIndianMarketHolidays(dates ...string) error {
+	holidaysMu.Lock()
+	defer holidaysMu.Unlock()
+
+	for _, date := range dates {
+		date = strings.TrimSpace(date)
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return fmt.Errorf("invalid holiday date %q: %w", date, err)
+		}
+		indianMarketHolidays[date] = true
+	}
+	return nil
+}
+
+// IsIndianMarketHoliday reports whether the IST calendar day of t is a
+// trading holiday. Weekends are not listed; see IsIndianTradingDay.
+func IsIndianMarketHoliday(t time.Time) bool {
+	holidaysMu.RLock()
+	defer holidaysMu.RUnlock()
+
+	return indianMarketHolidays[t.In(IST()).Format("2006-01-02")]
+}
+
+// IsIndianTradingDay reports whether the IST calendar day of t is a weekday
+// that isn't a trading holiday
+func IsIndianTradingDay(t time.Time) bool {
+	weekday := t.In(IST()).Weekday()
+	return weekday != time.Saturday && weekday != time.Sunday && !IsIndianMarketHoliday(t)
+}
+
+// IndianMarketHolidays returns the trading holidays between the IST
+// calendar days of from and to, inclusive, as sorted YYYY-MM-DD dates
+func IndianMarketHolidays(from, to time.Time) []string {
+	first := from.In(IST()).Format("2006-01-02")
+	last := to.In(IST()).Format("2006-01-02")
+
+	holidaysMu.RLock()
+	defer holidaysMu.RUnlock()
+
+	dates := []string{}
+	for date := range indianMarketHolidays {
+		if date >= first && date <= last {
+			dates = append(dates, date)
+		}
+	}
+	sort.Strings(dates)
+	return dates
+}
+
+var ist = loadIST()
+
+// IST returns India Standard Time: Asia/Kolkata, or a fixed +05:30 zone on
+// hosts without tzdata, where time.LoadLocation fails. Use it instead of
+// loading the zone.
+func IST() *time.Location {
+	return ist
+}
+
+func loadIST() *time.Location {
+	if loc, err := time.LoadLocation("Asia/Kolkata"); err == nil {
+		return loc
+	}
+	return time.FixedZone("IST", 5*3600+1800)
+}
+
+// isIndianMarketOpen checks if NSE/BSE cash market is open (9:15 AM - 3:30 PM
+// IST on trading days)
+func isIndianMarketOpen() bool {
+	loc := IST()
+	now := time.Now().In(loc)
+
+	if !IsIndianTradingDay(now) {
+		return false
+	}
+
+	// Market hours: 9:15 AM - 3:30 PM IST
+	marketOpen := time.Date(now.Year(), now.Month(), now.Day(), 9, 15, 0, 0, loc)
+	marketClose := time.Date(now.Year(), now.Month(), now.Day(), 15, 30, 0, 0, loc)
+
+	return now.After(marketOpen) && now.Before(marketClose)
+}
+
+// IsIndianMarketOpen reports whether the NSE/BSE cash market is open now
+func IsIndianMarketOpen() bool {
+	return isIndianMarketOpen()
+}
+
+// indianMarketStatus returns OPEN, WEEKEND, HOLIDAY, PRE_MARKET or CLOSED
+func indianMarketStatus() string {
+	if isIndianMarketOpen() {
+		return "OPEN"
+	}
+
+	now := time.Now().In(IST())
+
+	if now.Weekday() == time.Saturday || now.Weekday() == time.Sunday {
+		return "WEEKEND"
+	}
+
+	if IsIndianMarketHoliday(now) {
+		return "HOLIDAY"
+	}
+
+	if now.Hour() < 9 {
+		return "PRE_MARKET"
+	}
+
+	return "CLOSED"
+}
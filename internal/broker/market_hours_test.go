@@ -0,0 +1,110 @@
+package broker
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIsIndianTradingDay(t *testing.T) {
+	ist := IST()
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{"regular weekday", time.Date(2025, 1, 2, 10, 0, 0, 0, ist), true},
+		{"saturday", time.Date(2025, 1, 4, 10, 0, 0, 0, ist), false},
+		{"sunday", time.Date(2025, 1, 5, 10, 0, 0, 0, ist), false},
+		{"republic day 2024", time.Date(2024, 1, 26, 10, 0, 0, 0, ist), false},
+		{"diwali 2025", time.Date(2025, 10, 21, 10, 0, 0, 0, ist), false},
+		{"christmas 2026", time.Date(2026, 12, 25, 10, 0, 0, 0, ist), false},
+		// 20:00 UTC on the 25th is already the 26th in IST
+		{"holiday by IST date", time.Date(2024, 1, 25, 20, 0, 0, 0, time.UTC), false},
+		{"day before holiday in IST", time.Date(2024, 1, 25, 10, 0, 0, 0, time.UTC), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsIndianTradingDay(tt.t); got != tt.want {
+				t.Errorf("IsIndianTradingDay(%v) = %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndianMarketHolidays(t *testing.T) {
+	ist := IST()
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     []string
+	}{
+		{
+			name: "march 2025",
+			from: time.Date(2025, 3, 1, 0, 0, 0, 0, ist),
+			to:   time.Date(2025, 3, 31, 23, 59, 0, 0, ist),
+			want: []string{"2025-03-14", "2025-03-31"},
+		},
+		{
+			name: "none in range",
+			from: time.Date(2025, 6, 1, 0, 0, 0, 0, ist),
+			to:   time.Date(2025, 6, 30, 0, 0, 0, 0, ist),
+			want: []string{},
+		},
+		{
+			name: "single day range",
+			from: time.Date(2025, 5, 1, 9, 15, 0, 0, ist),
+			to:   time.Date(2025, 5, 1, 15, 30, 0, 0, ist),
+			want: []string{"2025-05-01"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := IndianMarketHolidays(tt.from, tt.to)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("IndianMarketHolidays = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddIndianMarketHolidays(t *testing.T) {
+	tests := []struct {
+		name    string
+		dates   []string
+		wantErr bool
+	}{
+		{"empty env value", []string{""}, false},
+		{"valid dates with spaces", []string{"2030-01-01", " 2030-01-02 "}, false},
+		{"invalid date", []string{"2030-13-01"}, true},
+		{"wrong format", []string{"01/01/2030"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := AddIndianMarketHolidays(tt.dates...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AddIndianMarketHolidays(%q) error = %v, wantErr %v", tt.dates, err, tt.wantErr)
+			}
+		})
+	}
+
+	if !IsIndianMarketHoliday(time.Date(2030, 1, 2, 12, 0, 0, 0, IST())) {
+		t.Error("added holiday 2030-01-02 not found")
+	}
+}
+
+func TestIST(t *testing.T) {
+	for _, at := range []time.Time{
+		time.Date(2024, 1, 15, 4, 0, 0, 0, time.UTC),
+		time.Date(2024, 7, 15, 4, 0, 0, 0, time.UTC),
+	} {
+		if _, offset := at.In(IST()).Zone(); offset != 5*3600+1800 {
+			t.Errorf("IST offset on %s = %ds, want +05:30", at.Format("2006-01-02"), offset)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package broker
+
+import (
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/metrics"
+)
+
+// WithMetrics returns a broker that records the latency and outcome of its
+// API calls in Prometheus. Check for optional interfaces such as
+// OrderUpdateHandler on b itself, before wrapping it.
+func WithMetrics(b Broker) Broker {
+	return &instrumentedBroker{Broker: b}
+}
+
+// instrumentedBroker decorates a broker with call metrics
+type instrumentedBroker struct {
+	Broker
+}
+
+// Unwrap returns the instrumented broker
+func (b *instrumentedBroker) Unwrap() Broker {
+	return b.Broker
+}
+
+// observe records a call started at start
+func (b *instrumentedBroker) observe(operation string, start time.Time, err error) {
+	metrics.RecordBrokerRequest(b.Broker.GetBrokerName(), operation, time.Since(start).Seconds(), err)
+}
+
+func (b *instrumentedBroker) GenerateSession(requestToken string) (*Session, error) {
+	start := time.Now()
+	session, err := b.Broker.GenerateSession(requestToken)
+	b.observe("generate_session", start, err)
+	return session, err
+}
+
+func (b *instrumentedBroker) GetProfile() (*Profile, error) {
+	start := time.Now()
+	profile, err := b.Broker.GetProfile()
+	b.observe("get_profile", start, err)
+	return profile, err
+}
+
+func (b *instrumentedBroker) GetMargins() (*Margins, error) {
+	start := time.Now()
+	margins, err := b.Broker.GetMargins()
+	b.observe("get_margins", start, err)
+	return margins, err
+}
+
+func (b *instrumentedBroker) GetPositions() (*Positions, error) {
+	start := time.Now()
+	positions, err := b.Broker.GetPositions()
+	b.observe("get_positions", start, err)
+	return positions, err
+}
+
+func (b *instrumentedBroker) GetHoldings() ([]Holding, error) {
+	start := time.Now()
+	holdings, err := b.Broker.GetHoldings()
+	b.observe("get_holdings", start, err)
+	return holdings, err
+}
+
+func (b *instrumentedBroker) GetOrders() ([]Order, error) {
+	start := time.Now()
+	orders, err := b.Broker.GetOrders()
+	b.observe("get_orders", start, err)
+	return orders, err
+}
+
+func (b *instrumentedBroker) GetQuote(symbols []string) (map[string]Quote, error) {
+	start := time.Now()
+	quotes, err := b.Broker.GetQuote(symbols)
+	b.observe("get_quote", start, err)
+	return quotes, err
+}
+
+func (b *instrumentedBroker) GetLTP(symbols []string) (map[string]float64, error) {
+	start := time.Now()
+	prices, err := b.Broker.GetLTP(symbols)
+	b.observe("get_ltp", start, err)
+	return prices, err
+}
+
+func (b *instrumentedBroker) GetHistoricalData(instrument string, from, to time.Time, interval string) ([]Candle, error) {
+	start := time.Now()
+	candles, err := b.Broker.GetHistoricalData(instrument, from, to, interval)
+	b.observe("get_historical_data", start, err)
+	return candles, err
+}
+
+func (b *instrumentedBroker) GetInstruments(exchange string) ([]Instrument, error) {
+	start := time.Now()
+	instruments, err := b.Broker.GetInstruments(exchange)
+	b.observe("get_instruments", start, err)
+	return instruments, err
+}
+
+func (b *instrumentedBroker) PlaceOrder(order *OrderRequest) (string, error) {
+	start := time.Now()
+	orderID, err := b.Broker.PlaceOrder(order)
+	b.observe("place_order", start, err)
+	return orderID, err
+}
+
+func (b *instrumentedBroker) ModifyOrder(orderID string, order *OrderModify) (string, error) {
+	start := time.Now()
+	id, err := b.Broker.ModifyOrder(orderID, order)
+	b.observe("modify_order", start, err)
+	return id, err
+}
+
+func (b *instrumentedBroker) CancelOrder(orderID string) (string, error) {
+	start := time.Now()
+	id, err := b.Broker.CancelOrder(orderID)
+	b.observe("cancel_order", start, err)
+	return id, err
+}
+
+func (b *instrumentedBroker) PlaceGTT(gtt *GTTRequest) (int, error) {
+	gtts, err := GTTs(b.Broker)
+	if err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	triggerID, err := gtts.PlaceGTT(gtt)
+	b.observe("place_gtt", start, err)
+	return triggerID, err
+}
+
+func (b *instrumentedBroker) ModifyGTT(triggerID int, gtt *GTTRequest) error {
+	gtts, err := GTTs(b.Broker)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	err = gtts.ModifyGTT(triggerID, gtt)
+	b.observe("modify_gtt", start, err)
+	return err
+}
+
+func (b *instrumentedBroker) DeleteGTT(triggerID int) error {
+	gtts, err := GTTs(b.Broker)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	err = gtts.DeleteGTT(triggerID)
+	b.observe("delete_gtt", start, err)
+	return err
+}
+
+func (b *instrumentedBroker) GetGTTs() ([]GTT, error) {
+	gtts, err := GTTs(b.Broker)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	list, err := gtts.GetGTTs()
+	b.observe("get_gtts", start, err)
+	return list, err
+}
+
+func (b *instrumentedBroker) GetGTT(triggerID int) (*GTT, error) {
+	gtts, err := GTTs(b.Broker)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	gtt, err := gtts.GetGTT(triggerID)
+	b.observe("get_gtt", start, err)
+	return gtt, err
+}
@@ -0,0 +1,75 @@
+package broker
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/trading-chitti/market-bridge/internal/metrics"
+)
+
+// stubBroker answers every call with err
+type stubBroker struct {
+	Broker
+	err error
+}
+
+func (s *stubBroker) GetBrokerName() string { return "stub" }
+
+func (s *stubBroker) GetLTP(symbols []string) (map[string]float64, error) {
+	return map[string]float64{}, s.err
+}
+
+func (s *stubBroker) PlaceOrder(order *OrderRequest) (string, error) {
+	return "1", s.err
+}
+
+func TestWithMetrics(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		call      func(Broker)
+		operation string
+		outcome   string
+	}{
+		{
+			name:      "ltp ok",
+			call:      func(b Broker) { b.GetLTP([]string{"NSE:INFY"}) },
+			operation: "get_ltp",
+			outcome:   "ok",
+		},
+		{
+			name:      "order failed",
+			err:       ErrOrderRejected,
+			call:      func(b Broker) { b.PlaceOrder(&OrderRequest{}) },
+			operation: "place_order",
+			outcome:   "error",
+		},
+		{
+			name:      "order ok",
+			call:      func(b Broker) { b.PlaceOrder(&OrderRequest{}) },
+			operation: "place_order",
+			outcome:   "ok",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := metrics.BrokerRequestsTotal.WithLabelValues("stub", tt.operation, tt.outcome)
+			before := testutil.ToFloat64(counter)
+
+			tt.call(WithMetrics(&stubBroker{err: tt.err}))
+
+			if got := testutil.ToFloat64(counter) - before; got != 1 {
+				t.Errorf("%s/%s counted %v times, want 1", tt.operation, tt.outcome, got)
+			}
+		})
+	}
+}
+
+func TestWithMetricsUnwrap(t *testing.T) {
+	inner := &stubBroker{err: errors.New("down")}
+	if got := Unwrap(WithMetrics(inner)); got != inner {
+		t.Errorf("Unwrap returned %T, want the wrapped broker", got)
+	}
+}
@@ -0,0 +1,1020 @@
+package broker
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Order statuses, matching Kite's
+const (
+	OrderStatusOpen           = "OPEN"
+	OrderStatusTriggerPending = "TRIGGER PENDING"
+	OrderStatusComplete       = "COMPLETE"
+	OrderStatusCancelled      = "CANCELLED"
+	OrderStatusRejected       = "REJECTED"
+)
+
+// paperMarginRates is the fraction of order value blocked per product.
+// MIS gets 5x intraday leverage; delivery and carry-forward are unleveraged.
+var paperMarginRates = map[string]float64{
+	"MIS":  0.20,
+	"CNC":  1.00,
+	"NRML": 1.00,
+}
+
+// paperPriceTTL is how long a price pushed through OnTick is preferred over
+// polling the price sources
+const paperPriceTTL = 10 * time.Second
+
+// PaperConfig configures the paper trading broker. It is set by the caller
+// on BrokerConfig.Paper and never stored.
+type PaperConfig struct {
+	AccountID      string        // Ledger account (default "default")
+	InitialCapital float64       // Starting funds (default ₹10,00,000)
+	SlippagePct    float64       // Adverse slippage applied to market fills
+	PollInterval   time.Duration // How often pending orders are matched (default 1s)
+	MarketData     Broker        // Live quotes, history and instruments (optional)
+	Ledger         PaperLedger   // Persists orders, positions and funds (optional)
+}
+
+// PaperOrder is a simulated order
+type PaperOrder struct {
+	Order
+	Validity      string
+	Tag           string
+	StatusMessage string
+	BlockedMargin float64 // Margin held while the order is pending
+
+	// Exit legs placed when an entry fills. The stop loss rests as an SL-M
+	// order and the target as a LIMIT order; whichever fills first cancels
+	// the other.
+	StopLoss      float64
+	Target        float64
+	ParentOrderID string // Entry order of an exit leg
+}
+
+// PaperPosition is a simulated net position
+type PaperPosition struct {
+	Exchange     string
+	Symbol       string
+	Product      string
+	Quantity     int
+	AveragePrice float64
+	LastPrice    float64
+	RealizedPnL  float64
+	UpdatedAt    time.Time
+}
+
+// PaperAccount is the persisted state of a paper account
+type PaperAccount struct {
+	AccountID      string
+	InitialCapital float64
+	RealizedPnL    float64
+	Orders         []PaperOrder
+	Positions      []PaperPosition
+}
+
+// PaperLedger persists paper trading state and supplies prices recorded by
+// the collectors. Implemented by the database package.
+type PaperLedger interface {
+	// LoadPaperAccount returns the account, creating it with initialCapital
+	// if it doesn't exist. Only open and today's orders are returned.
+	LoadPaperAccount(accountID string, initialCapital float64) (*PaperAccount, error)
+	SavePaperAccount(account *PaperAccount) error
+	SavePaperOrder(accountID string, order *PaperOrder) error
+	SavePaperPosition(accountID string, position *PaperPosition) error
+
+	// LatestPrice returns the most recent tick or bar close for a symbol
+	LatestPrice(exchange, symbol string) (float64, error)
+}
+
+// cachedPrice is a price pushed through OnTick
+type cachedPrice struct {
+	price float64
+	at    time.Time
+}
+
+// PaperBroker simulates order execution against live prices. Market orders
+// fill at the last traded price; limit and stop orders rest until the price
+// crosses them. Positions, margins and P&L are tracked virtually and, when a
+// ledger is configured, persisted so they survive restarts.
+type PaperBroker struct {
+	config PaperConfig
+	logger *logrus.Logger
+
+	mu        sync.Mutex
+	account   PaperAccount
+	orders    map[string]*PaperOrder
+	positions map[string]*PaperPosition
+	prices    map[string]cachedPrice
+
+	onUpdate func(OrderUpdate)
+
+	done     chan bool
+	stopOnce sync.Once
+}
+
+// NewPaperBroker creates a paper broker and starts matching pending orders
+func NewPaperBroker(config *BrokerConfig) (*PaperBroker, error) {
+	paper := PaperConfig{}
+	if config.Paper != nil {
+		paper = *config.Paper
+	}
+	if paper.AccountID == "" {
+		paper.AccountID = config.AccountName
+	}
+	if paper.AccountID == "" {
+		paper.AccountID = "default"
+	}
+	if paper.InitialCapital <= 0 {
+		paper.InitialCapital = 1000000
+	}
+	if paper.PollInterval <= 0 {
+		paper.PollInterval = time.Second
+	}
+	if paper.SlippagePct < 0 {
+		return nil, fmt.Errorf("paper slippage cannot be negative")
+	}
+
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.TextFormatter{
+		FullTimestamp: true,
+	})
+
+	b := &PaperBroker{
+		config: paper,
+		logger: logger,
+		account: PaperAccount{
+			AccountID:      paper.AccountID,
+			InitialCapital: paper.InitialCapital,
+		},
+		orders:    make(map[string]*PaperOrder),
+		positions: make(map[string]*PaperPosition),
+		prices:    make(map[string]cachedPrice),
+		done:      make(chan bool),
+	}
+
+	if paper.Ledger != nil {
+		account, err := paper.Ledger.LoadPaperAccount(paper.AccountID, paper.InitialCapital)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load paper account: %w", err)
+		}
+		b.account.InitialCapital = account.InitialCapital
+		b.account.RealizedPnL = account.RealizedPnL
+		for i := range account.Orders {
+			order := account.Orders[i]
+			b.orders[order.OrderID] = &order
+		}
+		for i := range account.Positions {
+			pos := account.Positions[i]
+			b.positions[positionKey(pos.Exchange, pos.Symbol, pos.Product)] = &pos
+		}
+	}
+
+	go b.matchLoop()
+
+	b.logger.Infof("✅ Paper broker initialized (account: %s, capital: ₹%.2f)",
+		b.account.AccountID, b.account.InitialCapital)
+
+	return b, nil
+}
+
+// Stop stops matching pending orders
+func (b *PaperBroker) Stop() {
+	b.stopOnce.Do(func() {
+		close(b.done)
+	})
+}
+
+// SetOrderUpdateHandler registers a callback for every order status change,
+// like the order updates Kite pushes over its WebSocket. The callback runs
+// with the broker locked and must not call back into it.
+func (b *PaperBroker) SetOrderUpdateHandler(handler func(OrderUpdate)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onUpdate = handler
+}
+
+func positionKey(exchange, symbol, product string) string {
+	return exchange + ":" + symbol + ":" + product
+}
+
+// ============================================================================
+// AUTHENTICATION
+// ============================================================================
+
+// GetLoginURL returns the market data broker's login URL, if any
+func (b *PaperBroker) GetLoginURL() string {
+	if b.config.MarketData != nil {
+		return b.config.MarketData.GetLoginURL()
+	}
+	return ""
+}
+
+// GenerateSession creates a session on the market data broker, or a local
+// paper session when there is none
+func (b *PaperBroker) GenerateSession(requestToken string) (*Session, error) {
+	if b.config.MarketData != nil {
+		return b.config.MarketData.GenerateSession(requestToken)
+	}
+	return &Session{
+		UserID:    "PAPER-" + b.account.AccountID,
+		ExpiresAt: time.Now().Add(24 * time.Hour),
+	}, nil
+}
+
+// SetAccessToken forwards the token to the market data broker
+func (b *PaperBroker) SetAccessToken(token string) {
+	if b.config.MarketData != nil {
+		b.config.MarketData.SetAccessToken(token)
+	}
+}
+
+// ============================================================================
+// ACCOUNT INFO
+// ============================================================================
+
+// GetProfile returns the paper account profile
+func (b *PaperBroker) GetProfile() (*Profile, error) {
+	return &Profile{
+		UserID:    "PAPER-" + b.account.AccountID,
+		UserName:  "Paper Trading (" + b.account.AccountID + ")",
+		Broker:    "paper",
+		Products:  []string{"MIS", "CNC", "NRML"},
+		Exchanges: []string{"NSE", "BSE"},
+	}, nil
+}
+
+// GetMargins returns virtual funds. Net is capital plus realized and
+// unrealized P&L; Used is margin held by open positions and pending orders.
+func (b *PaperBroker) GetMargins() (*Margins, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	used := b.usedMargin()
+	net := b.account.InitialCapital + b.account.RealizedPnL + b.unrealizedPnL()
+
+	margins := &Margins{}
+	margins.Equity.Used = round2(used)
+	margins.Equity.Net = round2(net)
+	margins.Equity.Available = round2(net - used)
+	return margins, nil
+}
+
+// GetPositions returns virtual positions, including closed ones with
+// realized P&L. Paper positions are never carried overnight into holdings.
+func (b *PaperBroker) GetPositions() (*Positions, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	positions := make([]Position, 0, len(b.positions))
+	for _, p := range b.positions {
+		positions = append(positions, Position{
+			Symbol:       p.Symbol,
+			Exchange:     p.Exchange,
+			Product:      p.Product,
+			Quantity:     p.Quantity,
+			AveragePrice: round2(p.AveragePrice),
+			LastPrice:    p.LastPrice,
+			PNL:          round2(p.RealizedPnL + unrealized(p)),
+		})
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		return positions[i].Symbol < positions[j].Symbol
+	})
+
+	return &Positions{Net: positions, Day: positions}, nil
+}
+
+// GetHoldings returns no holdings; paper trades stay as positions
+func (b *PaperBroker) GetHoldings() ([]Holding, error) {
+	return []Holding{}, nil
+}
+
+// GetOrders returns today's and still-open paper orders, oldest first
+func (b *PaperBroker) GetOrders() ([]Order, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	orders := make([]Order, 0, len(b.orders))
+	for _, o := range b.orders {
+		orders = append(orders, o.Order)
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].PlacedAt.Before(orders[j].PlacedAt)
+	})
+
+	return orders, nil
+}
+
+// ============================================================================
+// MARKET DATA
+// ============================================================================
+
+// GetQuote returns quotes from the market data broker
+func (b *PaperBroker) GetQuote(symbols []string) (map[string]Quote, error) {
+	if b.config.MarketData == nil {
+		return nil, fmt.Errorf("%w: paper broker has no market data source for quotes", ErrBrokerNotSupported)
+	}
+	return b.config.MarketData.GetQuote(symbols)
+}
+
+// GetLTP returns last traded prices for "EXCHANGE:SYMBOL" keys, from ticks
+// pushed through OnTick, the market data broker or the ledger, in that order
+func (b *PaperBroker) GetLTP(symbols []string) (map[string]float64, error) {
+	return b.fetchPrices(symbols), nil
+}
+
+// GetHistoricalData returns candles from the market data broker
+func (b *PaperBroker) GetHistoricalData(instrument string, from, to time.Time, interval string) ([]Candle, error) {
+	if b.config.MarketData == nil {
+		return nil, fmt.Errorf("%w: paper broker has no market data source for history", ErrBrokerNotSupported)
+	}
+	return b.config.MarketData.GetHistoricalData(instrument, from, to, interval)
+}
+
+// GetInstruments returns instruments from the market data broker
+func (b *PaperBroker) GetInstruments(exchange string) ([]Instrument, error) {
+	if b.config.MarketData == nil {
+		return nil, fmt.Errorf("%w: paper broker has no market data source for instruments", ErrBrokerNotSupported)
+	}
+	return b.config.MarketData.GetInstruments(exchange)
+}
+
+// OnTick records a live price and fills any orders it crosses. Collectors
+// can call this to match orders on every tick instead of every poll.
+func (b *PaperBroker) OnTick(exchange, symbol string, price float64) {
+	if price <= 0 {
+		return
+	}
+	key := exchange + ":" + symbol
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.prices[key] = cachedPrice{price: price, at: time.Now()}
+	b.applyPrices(map[string]float64{key: price})
+}
+
+// fetchPrices resolves prices without holding b.mu during network calls
+func (b *PaperBroker) fetchPrices(keys []string) map[string]float64 {
+	prices := make(map[string]float64, len(keys))
+	missing := []string{}
+
+	b.mu.Lock()
+	for _, key := range keys {
+		if cached, ok := b.prices[key]; ok && time.Since(cached.at) < paperPriceTTL {
+			prices[key] = cached.price
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	b.mu.Unlock()
+
+	if len(missing) > 0 && b.config.MarketData != nil {
+		ltp, err := b.config.MarketData.GetLTP(missing)
+		if err != nil {
+			b.logger.Warnf("⚠️  Paper broker LTP fetch failed: %v", err)
+		}
+		remaining := missing[:0]
+		for _, key := range missing {
+			if price, ok := ltp[key]; ok && price > 0 {
+				prices[key] = price
+			} else {
+				remaining = append(remaining, key)
+			}
+		}
+		missing = remaining
+	}
+
+	if len(missing) > 0 && b.config.Ledger != nil {
+		for _, key := range missing {
+			exchange, symbol := splitKey(key)
+			price, err := b.config.Ledger.LatestPrice(exchange, symbol)
+			if err == nil && price > 0 {
+				prices[key] = price
+			}
+		}
+	}
+
+	return prices
+}
+
+// splitKey splits "NSE:RELIANCE", defaulting to NSE
+func splitKey(key string) (string, string) {
+	if i := strings.Index(key, ":"); i >= 0 {
+		return key[:i], key[i+1:]
+	}
+	return "NSE", key
+}
+
+// ============================================================================
+// TRADING
+// ============================================================================
+
+// PlaceOrder validates and records an order, filling it immediately when the
+// current price allows
+func (b *PaperBroker) PlaceOrder(req *OrderRequest) (string, error) {
+	order := &PaperOrder{
+		Order: Order{
+			OrderID:         uuid.New().String(),
+			Symbol:          strings.ToUpper(req.Symbol),
+			Exchange:        strings.ToUpper(req.Exchange),
+			TransactionType: strings.ToUpper(req.TransactionType),
+			OrderType:       strings.ToUpper(req.OrderType),
+			Product:         strings.ToUpper(req.Product),
+			Quantity:        req.Quantity,
+			Price:           req.Price,
+			TriggerPrice:    req.TriggerPrice,
+			PendingQuantity: req.Quantity,
+			PlacedAt:        time.Now(),
+			UpdatedAt:       time.Now(),
+		},
+		Validity: req.Validity,
+		Tag:      req.Tag,
+		StopLoss: req.StopLoss,
+		Target:   req.Target,
+	}
+	if order.Exchange == "" {
+		order.Exchange = "NSE"
+	}
+	if order.Product == "" {
+		order.Product = "MIS"
+	}
+
+	if err := validatePaperOrder(&order.Order); err != nil {
+		return "", err
+	}
+
+	key := order.Exchange + ":" + order.Symbol
+	ltp, ok := b.fetchPrices([]string{key})[key]
+	if !ok {
+		return "", fmt.Errorf("%w: no price available for %s", ErrOrderRejected, key)
+	}
+
+	// Block margin at the price the order is expected to fill at
+	refPrice := ltp
+	if order.OrderType == "LIMIT" || order.OrderType == "SL" {
+		refPrice = order.Price
+	}
+	if err := req.ValidateExits(refPrice); err != nil {
+		return "", err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	required := b.marginRequired(&order.Order, refPrice)
+	if available := b.availableMargin(); required > available {
+		order.Status = OrderStatusRejected
+		order.StatusMessage = fmt.Sprintf("insufficient funds: required ₹%.2f, available ₹%.2f", required, available)
+		b.orders[order.OrderID] = order
+		b.saveOrder(order)
+		return "", fmt.Errorf("%w: %s", ErrInsufficientFunds, order.StatusMessage)
+	}
+	order.BlockedMargin = required
+
+	order.Status = OrderStatusOpen
+	if order.OrderType == "SL" || order.OrderType == "SL-M" {
+		order.Status = OrderStatusTriggerPending
+	}
+	b.orders[order.OrderID] = order
+
+	b.logger.Infof("📤 Paper order placed: %s - %s %d %s @ %s",
+		order.OrderID, order.TransactionType, order.Quantity, order.Symbol, order.OrderType)
+
+	if !b.match(order, ltp) {
+		b.saveOrder(order)
+	}
+
+	return order.OrderID, nil
+}
+
+// ModifyOrder changes a pending order
+func (b *PaperBroker) ModifyOrder(orderID string, modify *OrderModify) (string, error) {
+	b.mu.Lock()
+	order, ok := b.orders[orderID]
+	if !ok {
+		b.mu.Unlock()
+		return "", fmt.Errorf("%w: order %s not found", ErrOrderRejected, orderID)
+	}
+	if !isPending(order.Status) {
+		b.mu.Unlock()
+		return "", fmt.Errorf("%w: order %s is %s", ErrOrderRejected, orderID, order.Status)
+	}
+
+	updated := order.Order
+	if modify.Quantity != nil {
+		updated.Quantity = *modify.Quantity
+		updated.PendingQuantity = *modify.Quantity
+	}
+	if modify.Price != nil {
+		updated.Price = *modify.Price
+	}
+	if modify.TriggerPrice != nil {
+		updated.TriggerPrice = *modify.TriggerPrice
+	}
+	if modify.OrderType != nil {
+		updated.OrderType = strings.ToUpper(*modify.OrderType)
+	}
+	b.mu.Unlock()
+
+	if err := validatePaperOrder(&updated); err != nil {
+		return "", err
+	}
+
+	key := updated.Exchange + ":" + updated.Symbol
+	ltp, ok := b.fetchPrices([]string{key})[key]
+	if !ok {
+		return "", fmt.Errorf("%w: no price available for %s", ErrOrderRejected, key)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// The order may have filled while prices were fetched
+	if !isPending(order.Status) {
+		return "", fmt.Errorf("%w: order %s is %s", ErrOrderRejected, orderID, order.Status)
+	}
+
+	refPrice := ltp
+	if updated.OrderType == "LIMIT" || updated.OrderType == "SL" {
+		refPrice = updated.Price
+	}
+	required := b.marginRequired(&updated, refPrice)
+	if available := b.availableMargin() + order.BlockedMargin; required > available {
+		return "", fmt.Errorf("%w: required ₹%.2f, available ₹%.2f", ErrInsufficientFunds, required, available)
+	}
+
+	order.Order = updated
+	order.BlockedMargin = required
+	order.UpdatedAt = time.Now()
+	if order.OrderType == "SL" || order.OrderType == "SL-M" {
+		order.Status = OrderStatusTriggerPending
+	} else {
+		order.Status = OrderStatusOpen
+	}
+
+	b.logger.Infof("✏️  Paper order modified: %s", orderID)
+
+	if !b.match(order, ltp) {
+		b.saveOrder(order)
+	}
+
+	return orderID, nil
+}
+
+// CancelOrder cancels a pending order
+func (b *PaperBroker) CancelOrder(orderID string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	order, ok := b.orders[orderID]
+	if !ok {
+		return "", fmt.Errorf("%w: order %s not found", ErrOrderRejected, orderID)
+	}
+	if !isPending(order.Status) {
+		return "", fmt.Errorf("%w: order %s is %s", ErrOrderRejected, orderID, order.Status)
+	}
+
+	order.Status = OrderStatusCancelled
+	order.BlockedMargin = 0
+	order.UpdatedAt = time.Now()
+	b.saveOrder(order)
+
+	b.logger.Infof("❌ Paper order cancelled: %s", orderID)
+
+	return orderID, nil
+}
+
+// ============================================================================
+// UTILITY
+// ============================================================================
+
+// IsMarketOpen checks if the NSE/BSE cash market is open
+func (b *PaperBroker) IsMarketOpen() bool {
+	return isIndianMarketOpen()
+}
+
+// GetMarketStatus returns the market status
+func (b *PaperBroker) GetMarketStatus() string {
+	return indianMarketStatus()
+}
+
+// GetBrokerName returns "paper"
+func (b *PaperBroker) GetBrokerName() string {
+	return "paper"
+}
+
+// ============================================================================
+// MATCHING
+// ============================================================================
+
+func validatePaperOrder(order *Order) error {
+	if order.Symbol == "" {
+		return fmt.Errorf("%w: symbol is required", ErrInvalidSymbol)
+	}
+	if order.Quantity <= 0 {
+		return ErrInvalidQuantity
+	}
+	if order.TransactionType != "BUY" && order.TransactionType != "SELL" {
+		return fmt.Errorf("%w: transaction type must be BUY or SELL", ErrOrderRejected)
+	}
+	if _, ok := paperMarginRates[order.Product]; !ok {
+		return fmt.Errorf("%w: unsupported product %s", ErrOrderRejected, order.Product)
+	}
+
+	switch order.OrderType {
+	case "MARKET":
+	case "LIMIT":
+		if order.Price <= 0 {
+			return ErrInvalidPrice
+		}
+	case "SL":
+		if order.Price <= 0 || order.TriggerPrice <= 0 {
+			return ErrInvalidPrice
+		}
+	case "SL-M":
+		if order.TriggerPrice <= 0 {
+			return ErrInvalidPrice
+		}
+	default:
+		return ErrInvalidOrderType
+	}
+
+	return nil
+}
+
+func isPending(status string) bool {
+	return status == OrderStatusOpen || status == OrderStatusTriggerPending
+}
+
+// matchLoop periodically matches pending orders and marks positions to market
+func (b *PaperBroker) matchLoop() {
+	ticker := time.NewTicker(b.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.sweep()
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// sweep fetches prices for symbols with pending orders or open positions
+func (b *PaperBroker) sweep() {
+	b.mu.Lock()
+	seen := make(map[string]bool)
+	keys := []string{}
+	for _, o := range b.orders {
+		key := o.Exchange + ":" + o.Symbol
+		if isPending(o.Status) && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	for _, p := range b.positions {
+		key := p.Exchange + ":" + p.Symbol
+		if p.Quantity != 0 && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	b.mu.Unlock()
+
+	if len(keys) == 0 {
+		return
+	}
+
+	prices := b.fetchPrices(keys)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.applyPrices(prices)
+}
+
+// applyPrices marks positions and matches pending orders; callers hold b.mu
+func (b *PaperBroker) applyPrices(prices map[string]float64) {
+	for _, p := range b.positions {
+		if price, ok := prices[p.Exchange+":"+p.Symbol]; ok {
+			p.LastPrice = price
+		}
+	}
+
+	// Match in placement order so earlier orders fill first
+	pending := []*PaperOrder{}
+	for _, o := range b.orders {
+		if isPending(o.Status) {
+			pending = append(pending, o)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].PlacedAt.Before(pending[j].PlacedAt)
+	})
+
+	for _, o := range pending {
+		// An earlier fill may have cancelled this order's sibling exit leg
+		if !isPending(o.Status) {
+			continue
+		}
+		if price, ok := prices[o.Exchange+":"+o.Symbol]; ok {
+			b.match(o, price)
+		}
+	}
+}
+
+// match triggers and fills an order against ltp, returning true if the order
+// filled. Filled and newly triggered orders are saved; callers hold b.mu.
+func (b *PaperBroker) match(order *PaperOrder, ltp float64) bool {
+	buy := order.TransactionType == "BUY"
+
+	if order.Status == OrderStatusTriggerPending {
+		triggered := (buy && ltp >= order.TriggerPrice) || (!buy && ltp <= order.TriggerPrice)
+		if !triggered {
+			return false
+		}
+		order.Status = OrderStatusOpen
+		order.UpdatedAt = time.Now()
+
+		// A triggered SL becomes a resting limit order
+		if order.OrderType == "SL" && ((buy && ltp > order.Price) || (!buy && ltp < order.Price)) {
+			b.saveOrder(order)
+			return false
+		}
+	}
+
+	var fillPrice float64
+	switch order.OrderType {
+	case "MARKET", "SL-M":
+		fillPrice = ltp
+		if b.config.SlippagePct > 0 {
+			if buy {
+				fillPrice *= 1 + b.config.SlippagePct/100
+			} else {
+				fillPrice *= 1 - b.config.SlippagePct/100
+			}
+		}
+	case "LIMIT", "SL":
+		// Fill at the limit or better
+		if (buy && ltp > order.Price) || (!buy && ltp < order.Price) {
+			return false
+		}
+		fillPrice = ltp
+	default:
+		return false
+	}
+
+	b.fill(order, round2(fillPrice))
+	return true
+}
+
+// fill completes an order and updates the position; callers hold b.mu
+func (b *PaperBroker) fill(order *PaperOrder, price float64) {
+	now := time.Now()
+	order.Status = OrderStatusComplete
+	order.FilledQuantity = order.Quantity
+	order.PendingQuantity = 0
+	order.AveragePrice = price
+	order.BlockedMargin = 0
+	order.UpdatedAt = now
+
+	key := positionKey(order.Exchange, order.Symbol, order.Product)
+	pos, ok := b.positions[key]
+	if !ok {
+		pos = &PaperPosition{
+			Exchange: order.Exchange,
+			Symbol:   order.Symbol,
+			Product:  order.Product,
+		}
+		b.positions[key] = pos
+	}
+
+	signed := order.Quantity
+	if order.TransactionType == "SELL" {
+		signed = -signed
+	}
+
+	switch {
+	case pos.Quantity == 0 || (pos.Quantity > 0) == (signed > 0):
+		// Opening or adding
+		total := abs(pos.Quantity) + abs(signed)
+		pos.AveragePrice = (pos.AveragePrice*float64(abs(pos.Quantity)) + price*float64(abs(signed))) / float64(total)
+		pos.Quantity += signed
+	default:
+		// Reducing, closing or reversing
+		closing := min(abs(pos.Quantity), abs(signed))
+		direction := 1.0
+		if pos.Quantity < 0 {
+			direction = -1.0
+		}
+		pnl := float64(closing) * (price - pos.AveragePrice) * direction
+		pos.RealizedPnL += pnl
+		b.account.RealizedPnL += pnl
+
+		pos.Quantity += signed
+		switch {
+		case pos.Quantity == 0:
+			pos.AveragePrice = 0
+		case (pos.Quantity > 0) == (signed > 0):
+			// Reversed through flat: the remainder opens at the fill price
+			pos.AveragePrice = price
+		}
+	}
+	pos.LastPrice = price
+	pos.UpdatedAt = now
+
+	b.saveOrder(order)
+	b.savePosition(pos)
+	b.saveAccount()
+
+	b.logger.Infof("✅ Paper fill: %s %d %s @ %.2f (position %d)",
+		order.TransactionType, order.Quantity, order.Symbol, price, pos.Quantity)
+
+	if order.ParentOrderID != "" {
+		b.cancelSiblingExits(order)
+	} else if order.StopLoss > 0 || order.Target > 0 {
+		b.placeExits(order)
+	}
+}
+
+// placeExits places the stop-loss and target legs of a filled entry;
+// callers hold b.mu
+func (b *PaperBroker) placeExits(entry *PaperOrder) {
+	exitSide := "SELL"
+	if entry.TransactionType == "SELL" {
+		exitSide = "BUY"
+	}
+
+	newExit := func(orderType string) *PaperOrder {
+		now := time.Now()
+		return &PaperOrder{
+			Order: Order{
+				OrderID:         uuid.New().String(),
+				Symbol:          entry.Symbol,
+				Exchange:        entry.Exchange,
+				TransactionType: exitSide,
+				OrderType:       orderType,
+				Product:         entry.Product,
+				Quantity:        entry.Quantity,
+				PendingQuantity: entry.Quantity,
+				PlacedAt:        now,
+				UpdatedAt:       now,
+			},
+			Validity:      entry.Validity,
+			Tag:           entry.Tag,
+			ParentOrderID: entry.OrderID,
+		}
+	}
+
+	if entry.StopLoss > 0 {
+		stop := newExit("SL-M")
+		stop.TriggerPrice = entry.StopLoss
+		stop.Status = OrderStatusTriggerPending
+		b.orders[stop.OrderID] = stop
+		b.saveOrder(stop)
+	}
+	if entry.Target > 0 {
+		target := newExit("LIMIT")
+		target.Price = entry.Target
+		target.Status = OrderStatusOpen
+		b.orders[target.OrderID] = target
+		b.saveOrder(target)
+	}
+
+	b.logger.Infof("🎯 Paper exits placed for %s: SL %.2f, target %.2f",
+		entry.OrderID, entry.StopLoss, entry.Target)
+}
+
+// cancelSiblingExits cancels the other exit legs of a filled exit's entry;
+// callers hold b.mu
+func (b *PaperBroker) cancelSiblingExits(filled *PaperOrder) {
+	for _, o := range b.orders {
+		if o == filled || o.ParentOrderID != filled.ParentOrderID || !isPending(o.Status) {
+			continue
+		}
+		o.Status = OrderStatusCancelled
+		o.StatusMessage = "cancelled: other exit leg " + filled.OrderID + " filled"
+		o.BlockedMargin = 0
+		o.UpdatedAt = time.Now()
+		b.saveOrder(o)
+	}
+}
+
+// marginRequired is the margin for the part of an order that increases
+// exposure; orders that reduce a position need none. Callers hold b.mu.
+func (b *PaperBroker) marginRequired(order *Order, price float64) float64 {
+	current := 0
+	if pos, ok := b.positions[positionKey(order.Exchange, order.Symbol, order.Product)]; ok {
+		current = pos.Quantity
+	}
+
+	signed := order.Quantity
+	if order.TransactionType == "SELL" {
+		signed = -signed
+	}
+
+	increase := abs(current+signed) - abs(current)
+	if increase <= 0 {
+		return 0
+	}
+	return float64(increase) * price * paperMarginRates[order.Product]
+}
+
+// usedMargin sums margin on open positions and pending orders; callers hold b.mu
+func (b *PaperBroker) usedMargin() float64 {
+	used := 0.0
+	for _, p := range b.positions {
+		used += float64(abs(p.Quantity)) * p.AveragePrice * paperMarginRates[p.Product]
+	}
+	for _, o := range b.orders {
+		if isPending(o.Status) {
+			used += o.BlockedMargin
+		}
+	}
+	return used
+}
+
+// availableMargin is net funds less used margin; callers hold b.mu
+func (b *PaperBroker) availableMargin() float64 {
+	net := b.account.InitialCapital + b.account.RealizedPnL + b.unrealizedPnL()
+	return net - b.usedMargin()
+}
+
+// unrealizedPnL marks all positions to their last price; callers hold b.mu
+func (b *PaperBroker) unrealizedPnL() float64 {
+	total := 0.0
+	for _, p := range b.positions {
+		total += unrealized(p)
+	}
+	return total
+}
+
+func unrealized(p *PaperPosition) float64 {
+	if p.Quantity == 0 || p.LastPrice == 0 {
+		return 0
+	}
+	return float64(p.Quantity) * (p.LastPrice - p.AveragePrice)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// ============================================================================
+// PERSISTENCE
+// ============================================================================
+
+// Ledger writes are best effort: a failed write is logged and the in-memory
+// state stays authoritative until the next successful write.
+
+func (b *PaperBroker) saveOrder(order *PaperOrder) {
+	if b.onUpdate != nil {
+		b.onUpdate(OrderUpdate{
+			Order:         order.Order,
+			Tag:           order.Tag,
+			StatusMessage: order.StatusMessage,
+		})
+	}
+
+	if b.config.Ledger == nil {
+		return
+	}
+	if err := b.config.Ledger.SavePaperOrder(b.account.AccountID, order); err != nil {
+		b.logger.Errorf("❌ Failed to save paper order %s: %v", order.OrderID, err)
+	}
+}
+
+func (b *PaperBroker) savePosition(pos *PaperPosition) {
+	if b.config.Ledger == nil {
+		return
+	}
+	if err := b.config.Ledger.SavePaperPosition(b.account.AccountID, pos); err != nil {
+		b.logger.Errorf("❌ Failed to save paper position %s: %v", pos.Symbol, err)
+	}
+}
+
+func (b *PaperBroker) saveAccount() {
+	if b.config.Ledger == nil {
+		return
+	}
+	if err := b.config.Ledger.SavePaperAccount(&b.account); err != nil {
+		b.logger.Errorf("❌ Failed to save paper account: %v", err)
+	}
+}
@@ -0,0 +1,399 @@
+package broker
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/metrics"
+	kiteconnect "github.com/zerodha/gokiteconnect/v4"
+)
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"    // Calls go through
+	CircuitOpen     = "open"      // Calls fail fast with ErrCircuitOpen
+	CircuitHalfOpen = "half_open" // One probe call goes through
+)
+
+// ResilienceConfig sets how broker calls are retried and when the broker is
+// considered down
+type ResilienceConfig struct {
+	MaxRetries       int           // Retries of a read failing transiently, 0 for none
+	RetryBackoff     time.Duration // Wait before the first retry, doubled for each next one
+	MaxRetryBackoff  time.Duration // Longest wait between retries
+	FailureThreshold int           // Consecutive transient failures opening the circuit
+	OpenTimeout      time.Duration // How long the circuit stays open before a probe
+}
+
+// DefaultResilienceConfig retries reads twice from 200ms and opens the
+// circuit for 30s after 5 consecutive failures
+func DefaultResilienceConfig() ResilienceConfig {
+	return ResilienceConfig{
+		MaxRetries:       2,
+		RetryBackoff:     200 * time.Millisecond,
+		MaxRetryBackoff:  2 * time.Second,
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+	}
+}
+
+// IsTransient reports whether err is the broker being unreachable, timing
+// out, rate limiting or failing internally, which a later call may not
+// hit. Rejections, bad input and expired sessions are not.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrBrokerUnavailable) {
+		return true
+	}
+
+	var kiteErr kiteconnect.Error
+	if errors.As(err, &kiteErr) {
+		return kiteErr.ErrorType == kiteconnect.NetworkError || kiteErr.Code >= 500 || kiteErr.Code == 429
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// CircuitBreaker fails broker calls fast once the broker looks down. After
+// FailureThreshold consecutive transient failures it opens; after
+// OpenTimeout it lets one probe through (half open), closing again if the
+// probe succeeds and reopening if it fails. It is safe for concurrent use.
+type CircuitBreaker struct {
+	name      string
+	threshold int
+	timeout   time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	state     string
+	failures  int // Consecutive transient failures
+	openedAt  time.Time
+	probing   bool // A half-open probe is in flight
+	lastError string
+}
+
+// NewCircuitBreaker creates a closed breaker for the named broker
+func NewCircuitBreaker(name string, config ResilienceConfig) *CircuitBreaker {
+	cb := &CircuitBreaker{
+		name:      name,
+		threshold: config.FailureThreshold,
+		timeout:   config.OpenTimeout,
+		now:       time.Now,
+		state:     CircuitClosed,
+	}
+	metrics.SetBrokerCircuitState(name, CircuitClosed)
+	return cb
+}
+
+// Allow reports whether a call may go through, ErrCircuitOpen if not. A
+// call allowed must be followed by Record.
+func (cb *CircuitBreaker) Allow() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == CircuitOpen && cb.now().Sub(cb.openedAt) >= cb.timeout {
+		cb.setState(CircuitHalfOpen)
+	}
+
+	switch cb.state {
+	case CircuitOpen:
+		return ErrCircuitOpen
+	case CircuitHalfOpen:
+		if cb.probing {
+			return ErrCircuitOpen
+		}
+		cb.probing = true
+	}
+	return nil
+}
+
+// Record records the outcome of an allowed call. Only transient errors
+// count as failures; the broker answered any other.
+func (cb *CircuitBreaker) Record(err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.probing = false
+	if !IsTransient(err) {
+		cb.failures = 0
+		if cb.state != CircuitClosed {
+			cb.setState(CircuitClosed)
+		}
+		return
+	}
+
+	cb.failures++
+	cb.lastError = err.Error()
+	if cb.state == CircuitHalfOpen || (cb.threshold > 0 && cb.failures >= cb.threshold) {
+		cb.openedAt = cb.now()
+		cb.setState(CircuitOpen)
+	}
+}
+
+// setState moves to state, publishing it. Callers hold mu.
+func (cb *CircuitBreaker) setState(state string) {
+	cb.state = state
+	metrics.SetBrokerCircuitState(cb.name, state)
+}
+
+// CircuitStatus is a snapshot of a circuit breaker
+type CircuitStatus struct {
+	Broker    string     `json:"broker"`
+	State     string     `json:"state"`
+	Failures  int        `json:"consecutive_failures"`
+	OpenedAt  *time.Time `json:"opened_at,omitempty"`
+	RetryAt   *time.Time `json:"retry_at,omitempty"` // When an open circuit lets a probe through
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// Status returns the breaker's current state
+func (cb *CircuitBreaker) Status() CircuitStatus {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	status := CircuitStatus{
+		Broker:    cb.name,
+		State:     cb.state,
+		Failures:  cb.failures,
+		LastError: cb.lastError,
+	}
+	if cb.state != CircuitClosed {
+		openedAt := cb.openedAt
+		retryAt := openedAt.Add(cb.timeout)
+		status.OpenedAt = &openedAt
+		status.RetryAt = &retryAt
+	}
+	return status
+}
+
+// WithResilience returns a broker whose calls go through a circuit breaker,
+// with reads retried on transient failures. Orders are never retried: a
+// timed out order may still have been placed. Wrap the broker from
+// WithMetrics, so each attempt is measured.
+func WithResilience(b Broker, config ResilienceConfig) *ResilientBroker {
+	return &ResilientBroker{
+		Broker:  b,
+		config:  config,
+		breaker: NewCircuitBreaker(b.GetBrokerName(), config),
+		sleep:   time.Sleep,
+	}
+}
+
+// ResilientBroker decorates a broker with retries and a circuit breaker
+type ResilientBroker struct {
+	Broker
+	config  ResilienceConfig
+	breaker *CircuitBreaker
+	sleep   func(time.Duration)
+}
+
+// Unwrap returns the decorated broker
+func (b *ResilientBroker) Unwrap() Broker {
+	return b.Broker
+}
+
+// Breaker returns the broker's circuit breaker
+func (b *ResilientBroker) Breaker() *CircuitBreaker {
+	return b.breaker
+}
+
+// call runs fn through the breaker once
+func (b *ResilientBroker) call(fn func() error) error {
+	if err := b.breaker.Allow(); err != nil {
+		return err
+	}
+	err := fn()
+	b.breaker.Record(err)
+	return err
+}
+
+// retry runs fn through the breaker, retrying transient failures with
+// exponential backoff and jitter. It stops when the circuit opens.
+func (b *ResilientBroker) retry(operation string, fn func() error) error {
+	backoff := b.config.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := b.call(fn)
+		if err == nil || !IsTransient(err) || attempt >= b.config.MaxRetries {
+			return err
+		}
+
+		metrics.RecordBrokerRetry(b.GetBrokerName(), operation)
+		b.sleep(backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1)))
+		if backoff *= 2; b.config.MaxRetryBackoff > 0 && backoff > b.config.MaxRetryBackoff {
+			backoff = b.config.MaxRetryBackoff
+		}
+	}
+}
+
+func (b *ResilientBroker) GenerateSession(requestToken string) (session *Session, err error) {
+	err = b.call(func() error {
+		session, err = b.Broker.GenerateSession(requestToken)
+		return err
+	})
+	return session, err
+}
+
+func (b *ResilientBroker) GetProfile() (profile *Profile, err error) {
+	err = b.retry("get_profile", func() error {
+		profile, err = b.Broker.GetProfile()
+		return err
+	})
+	return profile, err
+}
+
+func (b *ResilientBroker) GetMargins() (margins *Margins, err error) {
+	err = b.retry("get_margins", func() error {
+		margins, err = b.Broker.GetMargins()
+		return err
+	})
+	return margins, err
+}
+
+func (b *ResilientBroker) GetPositions() (positions *Positions, err error) {
+	err = b.retry("get_positions", func() error {
+		positions, err = b.Broker.GetPositions()
+		return err
+	})
+	return positions, err
+}
+
+func (b *ResilientBroker) GetHoldings() (holdings []Holding, err error) {
+	err = b.retry("get_holdings", func() error {
+		holdings, err = b.Broker.GetHoldings()
+		return err
+	})
+	return holdings, err
+}
+
+func (b *ResilientBroker) GetOrders() (orders []Order, err error) {
+	err = b.retry("get_orders", func() error {
+		orders, err = b.Broker.GetOrders()
+		return err
+	})
+	return orders, err
+}
+
+func (b *ResilientBroker) GetQuote(symbols []string) (quotes map[string]Quote, err error) {
+	err = b.retry("get_quote", func() error {
+		quotes, err = b.Broker.GetQuote(symbols)
+		return err
+	})
+	return quotes, err
+}
+
+func (b *ResilientBroker) GetLTP(symbols []string) (prices map[string]float64, err error) {
+	err = b.retry("get_ltp", func() error {
+		prices, err = b.Broker.GetLTP(symbols)
+		return err
+	})
+	return prices, err
+}
+
+func (b *ResilientBroker) GetHistoricalData(instrument string, from, to time.Time, interval string) (candles []Candle, err error) {
+	err = b.retry("get_historical_data", func() error {
+		candles, err = b.Broker.GetHistoricalData(instrument, from, to, interval)
+		return err
+	})
+	return candles, err
+}
+
+func (b *ResilientBroker) GetInstruments(exchange string) (instruments []Instrument, err error) {
+	err = b.retry("get_instruments", func() error {
+		instruments, err = b.Broker.GetInstruments(exchange)
+		return err
+	})
+	return instruments, err
+}
+
+func (b *ResilientBroker) PlaceOrder(order *OrderRequest) (orderID string, err error) {
+	err = b.call(func() error {
+		orderID, err = b.Broker.PlaceOrder(order)
+		return err
+	})
+	return orderID, err
+}
+
+func (b *ResilientBroker) ModifyOrder(orderID string, order *OrderModify) (id string, err error) {
+	err = b.call(func() error {
+		id, err = b.Broker.ModifyOrder(orderID, order)
+		return err
+	})
+	return id, err
+}
+
+func (b *ResilientBroker) CancelOrder(orderID string) (id string, err error) {
+	err = b.call(func() error {
+		id, err = b.Broker.CancelOrder(orderID)
+		return err
+	})
+	return id, err
+}
+
+func (b *ResilientBroker) PlaceGTT(gtt *GTTRequest) (triggerID int, err error) {
+	gtts, err := GTTs(b.Broker)
+	if err != nil {
+		return 0, err
+	}
+	err = b.call(func() error {
+		triggerID, err = gtts.PlaceGTT(gtt)
+		return err
+	})
+	return triggerID, err
+}
+
+func (b *ResilientBroker) ModifyGTT(triggerID int, gtt *GTTRequest) error {
+	gtts, err := GTTs(b.Broker)
+	if err != nil {
+		return err
+	}
+	return b.call(func() error {
+		return gtts.ModifyGTT(triggerID, gtt)
+	})
+}
+
+func (b *ResilientBroker) DeleteGTT(triggerID int) error {
+	gtts, err := GTTs(b.Broker)
+	if err != nil {
+		return err
+	}
+	return b.call(func() error {
+		return gtts.DeleteGTT(triggerID)
+	})
+}
+
+func (b *ResilientBroker) GetGTTs() (list []GTT, err error) {
+	gtts, err := GTTs(b.Broker)
+	if err != nil {
+		return nil, err
+	}
+	err = b.retry("get_gtts", func() error {
+		list, err = gtts.GetGTTs()
+		return err
+	})
+	return list, err
+}
+
+func (b *ResilientBroker) GetGTT(triggerID int) (gtt *GTT, err error) {
+	gtts, err := GTTs(b.Broker)
+	if err != nil {
+		return nil, err
+	}
+	err = b.retry("get_gtt", func() error {
+		gtt, err = gtts.GetGTT(triggerID)
+		return err
+	})
+	return gtt, err
+}
@@ -0,0 +1,201 @@
+package broker
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	kiteconnect "github.com/zerodha/gokiteconnect/v4"
+)
+
+// flakyBroker fails each call with the next of errs, then succeeds
+type flakyBroker struct {
+	Broker
+	errs  []error
+	calls int
+}
+
+func (f *flakyBroker) GetBrokerName() string { return "flaky" }
+
+func (f *flakyBroker) next() error {
+	f.calls++
+	if len(f.errs) == 0 {
+		return nil
+	}
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	return err
+}
+
+func (f *flakyBroker) GetLTP(symbols []string) (map[string]float64, error) {
+	if err := f.next(); err != nil {
+		return nil, err
+	}
+	return map[string]float64{"NSE:INFY": 1500}, nil
+}
+
+func (f *flakyBroker) PlaceOrder(order *OrderRequest) (string, error) {
+	if err := f.next(); err != nil {
+		return "", err
+	}
+	return "1", nil
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"unavailable", fmt.Errorf("%w: upstox returned HTTP 503", ErrBrokerUnavailable), true},
+		{"kite network", kiteconnect.NewError(kiteconnect.NetworkError, "Request failed.", nil), true},
+		{"kite rate limited", kiteconnect.Error{Code: 429, ErrorType: kiteconnect.GeneralError}, true},
+		{"kite order rejected", kiteconnect.NewError(kiteconnect.OrderError, "Insufficient margin", nil), false},
+		{"kite token", kiteconnect.NewError(kiteconnect.TokenError, "Invalid token", nil), false},
+		{"timeout", fmt.Errorf("angelone request failed: %w", &net.OpError{Op: "dial", Err: errors.New("i/o timeout")}), true},
+		{"session expired", ErrSessionExpired, false},
+		{"rejected", fmt.Errorf("%w: no price", ErrOrderRejected), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTransient(tt.err); got != tt.want {
+				t.Errorf("IsTransient(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResilientBrokerRetries(t *testing.T) {
+	down := fmt.Errorf("%w: HTTP 502", ErrBrokerUnavailable)
+
+	tests := []struct {
+		name    string
+		errs    []error
+		call    func(Broker) error
+		calls   int
+		wantErr error
+	}{
+		{
+			name:  "read recovers",
+			errs:  []error{down, down},
+			call:  func(b Broker) error { _, err := b.GetLTP(nil); return err },
+			calls: 3,
+		},
+		{
+			name:    "read gives up",
+			errs:    []error{down, down, down, down},
+			call:    func(b Broker) error { _, err := b.GetLTP(nil); return err },
+			calls:   3,
+			wantErr: ErrBrokerUnavailable,
+		},
+		{
+			name:    "rejection not retried",
+			errs:    []error{ErrSessionExpired},
+			call:    func(b Broker) error { _, err := b.GetLTP(nil); return err },
+			calls:   1,
+			wantErr: ErrSessionExpired,
+		},
+		{
+			name:    "order not retried",
+			errs:    []error{down},
+			call:    func(b Broker) error { _, err := b.PlaceOrder(&OrderRequest{}); return err },
+			calls:   1,
+			wantErr: ErrBrokerUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &flakyBroker{errs: tt.errs}
+			b := WithResilience(inner, ResilienceConfig{MaxRetries: 2, RetryBackoff: time.Millisecond, FailureThreshold: 10, OpenTimeout: time.Minute})
+			b.sleep = func(time.Duration) {}
+
+			err := tt.call(b)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if inner.calls != tt.calls {
+				t.Errorf("calls = %d, want %d", inner.calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	down := fmt.Errorf("%w: HTTP 500", ErrBrokerUnavailable)
+	now := time.Date(2024, 1, 30, 10, 0, 0, 0, time.UTC)
+
+	cb := NewCircuitBreaker("test", ResilienceConfig{FailureThreshold: 3, OpenTimeout: 30 * time.Second})
+	cb.now = func() time.Time { return now }
+
+	fail := func(err error) {
+		t.Helper()
+		if allowErr := cb.Allow(); allowErr != nil {
+			t.Fatalf("Allow = %v, want allowed", allowErr)
+		}
+		cb.Record(err)
+	}
+
+	// Rejections don't count, and a success resets the count
+	fail(down)
+	fail(ErrOrderRejected)
+	fail(down)
+	fail(nil)
+	fail(down)
+	fail(down)
+	if got := cb.Status().State; got != CircuitClosed {
+		t.Fatalf("state after 2 consecutive failures = %s, want closed", got)
+	}
+
+	fail(down)
+	if got := cb.Status().State; got != CircuitOpen {
+		t.Fatalf("state after 3 consecutive failures = %s, want open", got)
+	}
+	if err := cb.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Allow while open = %v, want ErrCircuitOpen", err)
+	}
+
+	// One probe after the timeout; a failed probe reopens
+	now = now.Add(30 * time.Second)
+	fail(down)
+	if got := cb.Status().State; got != CircuitOpen {
+		t.Fatalf("state after failed probe = %s, want open", got)
+	}
+
+	now = now.Add(30 * time.Second)
+	if err := cb.Allow(); err != nil {
+		t.Fatalf("probe Allow = %v, want allowed", err)
+	}
+	if got := cb.Status().State; got != CircuitHalfOpen {
+		t.Fatalf("state during probe = %s, want half_open", got)
+	}
+	if err := cb.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("second Allow during probe = %v, want ErrCircuitOpen", err)
+	}
+	cb.Record(nil)
+	if got := cb.Status(); got.State != CircuitClosed || got.Failures != 0 {
+		t.Fatalf("status after successful probe = %+v, want closed", got)
+	}
+}
+
+func TestResilientBrokerFailsFast(t *testing.T) {
+	inner := &flakyBroker{errs: []error{ErrBrokerUnavailable, ErrBrokerUnavailable}}
+	b := WithResilience(inner, ResilienceConfig{FailureThreshold: 2, OpenTimeout: time.Minute})
+
+	for i := 0; i < 2; i++ {
+		b.GetLTP(nil)
+	}
+	if _, err := b.GetLTP(nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("err = %v, want ErrCircuitOpen", err)
+	}
+	if inner.calls != 2 {
+		t.Errorf("calls = %d, want 2, none while open", inner.calls)
+	}
+	if got := Unwrap(b); got != inner {
+		t.Errorf("Unwrap returned %T, want the wrapped broker", got)
+	}
+}
@@ -0,0 +1,915 @@
+package broker
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/trading-chitti/market-bridge/internal/timeframe"
+)
+
+const (
+	upstoxBaseURL       = "https://api.upstox.com"
+	upstoxOrderURL      = "https://api-hft.upstox.com"
+	upstoxInstrumentURL = "https://assets.upstox.com/market-quote/instruments/exchange/complete.json.gz"
+)
+
+// UpstoxBroker implements the Broker interface for Upstox API v2
+type UpstoxBroker struct {
+	config *BrokerConfig
+	client *http.Client
+	logger *logrus.Logger
+
+	mu          sync.RWMutex
+	accessToken string
+
+	// Instrument master cache: "NSE:RELIANCE" -> "NSE_EQ|INE002A01018"
+	instrumentMu   sync.RWMutex
+	instrumentKeys map[string]string
+}
+
+// NewUpstoxBroker creates a new Upstox broker instance
+func NewUpstoxBroker(config *BrokerConfig) (*UpstoxBroker, error) {
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("%w: upstox api key is required", ErrInvalidCredentials)
+	}
+
+	if config.RedirectURL == "" {
+		config.RedirectURL = os.Getenv("UPSTOX_REDIRECT_URI")
+	}
+
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.TextFormatter{
+		FullTimestamp: true,
+	})
+
+	broker := &UpstoxBroker{
+		config:      config,
+		client:      &http.Client{Timeout: 30 * time.Second},
+		logger:      logger,
+		accessToken: config.AccessToken,
+	}
+
+	broker.logger.Info("✅ Upstox broker initialized")
+
+	return broker, nil
+}
+
+// ============================================================================
+// HTTP HELPERS
+// ============================================================================
+
+// upstoxResponse is the common Upstox response envelope
+type upstoxResponse struct {
+	Status string          `json:"status"`
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		ErrorCode string `json:"errorCode"`
+		Message   string `json:"message"`
+	} `json:"errors"`
+}
+
+// request performs an Upstox API call and decodes the data field into out
+func (u *UpstoxBroker) request(method, endpoint string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	u.mu.RLock()
+	token := u.accessToken
+	u.mu.RUnlock()
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return u.do(req, out)
+}
+
+func (u *UpstoxBroker) do(req *http.Request, out interface{}) error {
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("upstox request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read upstox response: %w", err)
+	}
+
+	if unavailableStatus(resp.StatusCode) {
+		return fmt.Errorf("%w: upstox returned HTTP %d: %s", ErrBrokerUnavailable, resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return ErrSessionExpired
+	}
+
+	var envelope upstoxResponse
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return fmt.Errorf("upstox returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+
+	if envelope.Status != "success" {
+		if len(envelope.Errors) > 0 {
+			e := envelope.Errors[0]
+			if e.ErrorCode == "UDAPI100050" {
+				return ErrSessionExpired
+			}
+			return fmt.Errorf("upstox error %s: %s", e.ErrorCode, e.Message)
+		}
+		return fmt.Errorf("upstox returned HTTP %d", resp.StatusCode)
+	}
+
+	if out == nil || len(envelope.Data) == 0 || string(envelope.Data) == "null" {
+		return nil
+	}
+
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		return fmt.Errorf("failed to decode upstox response: %w", err)
+	}
+
+	return nil
+}
+
+// ============================================================================
+// AUTHENTICATION
+// ============================================================================
+
+// GetLoginURL returns the Upstox OAuth authorization URL
+func (u *UpstoxBroker) GetLoginURL() string {
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", u.config.APIKey)
+	params.Set("redirect_uri", u.config.RedirectURL)
+	return upstoxBaseURL + "/v2/login/authorization/dialog?" + params.Encode()
+}
+
+// GenerateSession exchanges the OAuth authorization code for an access token
+func (u *UpstoxBroker) GenerateSession(requestToken string) (*Session, error) {
+	form := url.Values{}
+	form.Set("code", requestToken)
+	form.Set("client_id", u.config.APIKey)
+	form.Set("client_secret", u.config.APISecret)
+	form.Set("redirect_uri", u.config.RedirectURL)
+	form.Set("grant_type", "authorization_code")
+
+	req, err := http.NewRequest(http.MethodPost, upstoxBaseURL+"/v2/login/authorization/token",
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate session: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// The token endpoint returns the payload without the usual envelope
+	var data struct {
+		UserID      string `json:"user_id"`
+		AccessToken string `json:"access_token"`
+		Errors      []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode session response: %w", err)
+	}
+
+	if data.AccessToken == "" {
+		if len(data.Errors) > 0 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCredentials, data.Errors[0].Message)
+		}
+		return nil, ErrInvalidCredentials
+	}
+
+	u.SetAccessToken(data.AccessToken)
+
+	u.logger.Infof("✅ Session generated for user: %s", data.UserID)
+
+	// Upstox tokens expire at 3:30 AM IST the next day
+	loc := IST()
+	now := time.Now().In(loc)
+	expiresAt := time.Date(now.Year(), now.Month(), now.Day(), 3, 30, 0, 0, loc)
+	if !expiresAt.After(now) {
+		expiresAt = expiresAt.AddDate(0, 0, 1)
+	}
+
+	return &Session{
+		UserID:      data.UserID,
+		AccessToken: data.AccessToken,
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+// SetAccessToken sets the access token
+func (u *UpstoxBroker) SetAccessToken(token string) {
+	u.mu.Lock()
+	u.accessToken = token
+	u.mu.Unlock()
+	u.config.AccessToken = token
+}
+
+// ============================================================================
+// ACCOUNT INFO
+// ============================================================================
+
+// GetProfile returns user profile
+func (u *UpstoxBroker) GetProfile() (*Profile, error) {
+	var data struct {
+		UserID    string   `json:"user_id"`
+		UserName  string   `json:"user_name"`
+		Email     string   `json:"email"`
+		Exchanges []string `json:"exchanges"`
+		Products  []string `json:"products"`
+	}
+	if err := u.request(http.MethodGet, upstoxBaseURL+"/v2/user/profile", nil, &data); err != nil {
+		return nil, err
+	}
+
+	return &Profile{
+		UserID:    data.UserID,
+		UserName:  data.UserName,
+		Email:     data.Email,
+		Broker:    "upstox",
+		Products:  data.Products,
+		Exchanges: data.Exchanges,
+	}, nil
+}
+
+// GetMargins returns account margins
+func (u *UpstoxBroker) GetMargins() (*Margins, error) {
+	type segment struct {
+		UsedMargin      float64 `json:"used_margin"`
+		AvailableMargin float64 `json:"available_margin"`
+	}
+	var data struct {
+		Equity    segment `json:"equity"`
+		Commodity segment `json:"commodity"`
+	}
+	if err := u.request(http.MethodGet, upstoxBaseURL+"/v2/user/get-funds-and-margin", nil, &data); err != nil {
+		return nil, err
+	}
+
+	result := &Margins{}
+	result.Equity.Available = data.Equity.AvailableMargin
+	result.Equity.Used = data.Equity.UsedMargin
+	result.Equity.Net = data.Equity.AvailableMargin - data.Equity.UsedMargin
+	result.Commodity.Available = data.Commodity.AvailableMargin
+	result.Commodity.Used = data.Commodity.UsedMargin
+	result.Commodity.Net = data.Commodity.AvailableMargin - data.Commodity.UsedMargin
+
+	u.logger.Infof("💰 Equity Available: ₹%.2f", result.Equity.Available)
+
+	return result, nil
+}
+
+// GetPositions returns current positions
+func (u *UpstoxBroker) GetPositions() (*Positions, error) {
+	var data []struct {
+		TradingSymbol     string  `json:"trading_symbol"`
+		Exchange          string  `json:"exchange"`
+		Product           string  `json:"product"`
+		Quantity          int     `json:"quantity"`
+		AveragePrice      float64 `json:"average_price"`
+		LastPrice         float64 `json:"last_price"`
+		PNL               float64 `json:"pnl"`
+		OvernightQuantity int     `json:"overnight_quantity"`
+	}
+	if err := u.request(http.MethodGet, upstoxBaseURL+"/v2/portfolio/short-term-positions", nil, &data); err != nil {
+		return nil, err
+	}
+
+	result := &Positions{
+		Net: make([]Position, 0, len(data)),
+		Day: make([]Position, 0, len(data)),
+	}
+
+	for _, p := range data {
+		position := Position{
+			Symbol:       p.TradingSymbol,
+			Exchange:     p.Exchange,
+			Product:      upstoxToProduct(p.Product),
+			Quantity:     p.Quantity,
+			AveragePrice: p.AveragePrice,
+			LastPrice:    p.LastPrice,
+			PNL:          p.PNL,
+			Overnight:    p.OvernightQuantity != 0,
+		}
+		result.Net = append(result.Net, position)
+		if p.OvernightQuantity == 0 {
+			result.Day = append(result.Day, position)
+		}
+	}
+
+	u.logger.Infof("📊 Positions: %d net, %d day", len(result.Net), len(result.Day))
+
+	return result, nil
+}
+
+// GetHoldings returns holdings
+func (u *UpstoxBroker) GetHoldings() ([]Holding, error) {
+	var data []struct {
+		TradingSymbol string  `json:"trading_symbol"`
+		Exchange      string  `json:"exchange"`
+		Quantity      int     `json:"quantity"`
+		AveragePrice  float64 `json:"average_price"`
+		LastPrice     float64 `json:"last_price"`
+		PNL           float64 `json:"pnl"`
+	}
+	if err := u.request(http.MethodGet, upstoxBaseURL+"/v2/portfolio/long-term-holdings", nil, &data); err != nil {
+		return nil, err
+	}
+
+	result := make([]Holding, 0, len(data))
+	for _, h := range data {
+		pnlPercent := 0.0
+		if h.AveragePrice != 0 {
+			pnlPercent = (h.LastPrice - h.AveragePrice) / h.AveragePrice * 100
+		}
+		result = append(result, Holding{
+			Symbol:       h.TradingSymbol,
+			Exchange:     h.Exchange,
+			Quantity:     h.Quantity,
+			AveragePrice: h.AveragePrice,
+			LastPrice:    h.LastPrice,
+			PNL:          h.PNL,
+			PNLPercent:   pnlPercent,
+		})
+	}
+
+	u.logger.Infof("💼 Holdings: %d stocks", len(result))
+
+	return result, nil
+}
+
+// upstoxOrder is an order book entry
+type upstoxOrder struct {
+	OrderID           string  `json:"order_id"`
+	TradingSymbol     string  `json:"trading_symbol"`
+	Exchange          string  `json:"exchange"`
+	TransactionType   string  `json:"transaction_type"`
+	OrderType         string  `json:"order_type"`
+	Product           string  `json:"product"`
+	Validity          string  `json:"validity"`
+	Quantity          int     `json:"quantity"`
+	DisclosedQuantity int     `json:"disclosed_quantity"`
+	Price             float64 `json:"price"`
+	TriggerPrice      float64 `json:"trigger_price"`
+	Status            string  `json:"status"`
+	FilledQuantity    int     `json:"filled_quantity"`
+	PendingQuantity   int     `json:"pending_quantity"`
+	AveragePrice      float64 `json:"average_price"`
+	OrderTimestamp    string  `json:"order_timestamp"`
+	ExchangeTimestamp string  `json:"exchange_timestamp"`
+}
+
+// GetOrders returns orders for the day
+func (u *UpstoxBroker) GetOrders() ([]Order, error) {
+	var orders []upstoxOrder
+	if err := u.request(http.MethodGet, upstoxBaseURL+"/v2/order/retrieve-all", nil, &orders); err != nil {
+		return nil, err
+	}
+
+	result := make([]Order, 0, len(orders))
+	for _, o := range orders {
+		placedAt := parseUpstoxTime(o.OrderTimestamp)
+		updatedAt := parseUpstoxTime(o.ExchangeTimestamp)
+		if updatedAt.IsZero() {
+			updatedAt = placedAt
+		}
+
+		result = append(result, Order{
+			OrderID:         o.OrderID,
+			Symbol:          o.TradingSymbol,
+			Exchange:        o.Exchange,
+			TransactionType: o.TransactionType,
+			OrderType:       o.OrderType,
+			Product:         upstoxToProduct(o.Product),
+			Quantity:        o.Quantity,
+			Price:           o.Price,
+			TriggerPrice:    o.TriggerPrice,
+			Status:          strings.ToUpper(o.Status),
+			FilledQuantity:  o.FilledQuantity,
+			PendingQuantity: o.PendingQuantity,
+			AveragePrice:    o.AveragePrice,
+			PlacedAt:        placedAt,
+			UpdatedAt:       updatedAt,
+		})
+	}
+
+	u.logger.Infof("📝 Orders today: %d", len(result))
+
+	return result, nil
+}
+
+// ============================================================================
+// MARKET DATA
+// ============================================================================
+
+// GetQuote returns real-time quotes for "EXCHANGE:SYMBOL" instruments
+func (u *UpstoxBroker) GetQuote(symbols []string) (map[string]Quote, error) {
+	keys, err := u.instrumentKeysFor(symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	var data map[string]struct {
+		InstrumentToken string  `json:"instrument_token"`
+		LastPrice       float64 `json:"last_price"`
+		NetChange       float64 `json:"net_change"`
+		Volume          int64   `json:"volume"`
+		TotalBuyQty     float64 `json:"total_buy_quantity"`
+		TotalSellQty    float64 `json:"total_sell_quantity"`
+		OI              float64 `json:"oi"`
+		Timestamp       string  `json:"timestamp"`
+		OHLC            struct {
+			Open  float64 `json:"open"`
+			High  float64 `json:"high"`
+			Low   float64 `json:"low"`
+			Close float64 `json:"close"`
+		} `json:"ohlc"`
+	}
+	endpoint := upstoxBaseURL + "/v2/market-quote/quotes?instrument_key=" + url.QueryEscape(joinKeys(keys))
+	if err := u.request(http.MethodGet, endpoint, nil, &data); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]Quote)
+	for _, q := range data {
+		symbol, ok := keys[q.InstrumentToken]
+		if !ok {
+			continue
+		}
+
+		// Upstox reports the previous close as ohlc.close
+		prevClose := q.LastPrice - q.NetChange
+		changePercent := 0.0
+		if prevClose != 0 {
+			changePercent = q.NetChange / prevClose * 100
+		}
+
+		timestamp, _ := time.Parse(time.RFC3339, q.Timestamp)
+
+		result[symbol] = Quote{
+			Symbol:        symbol,
+			LastPrice:     q.LastPrice,
+			Open:          q.OHLC.Open,
+			High:          q.OHLC.High,
+			Low:           q.OHLC.Low,
+			Close:         q.OHLC.Close,
+			Change:        q.NetChange,
+			ChangePercent: changePercent,
+			Volume:        q.Volume,
+			BuyQuantity:   int64(q.TotalBuyQty),
+			SellQuantity:  int64(q.TotalSellQty),
+			OI:            int64(q.OI),
+			Timestamp:     timestamp,
+		}
+	}
+
+	return result, nil
+}
+
+// GetLTP returns last traded prices for "EXCHANGE:SYMBOL" instruments
+func (u *UpstoxBroker) GetLTP(symbols []string) (map[string]float64, error) {
+	keys, err := u.instrumentKeysFor(symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	var data map[string]struct {
+		InstrumentToken string  `json:"instrument_token"`
+		LastPrice       float64 `json:"last_price"`
+	}
+	endpoint := upstoxBaseURL + "/v2/market-quote/ltp?instrument_key=" + url.QueryEscape(joinKeys(keys))
+	if err := u.request(http.MethodGet, endpoint, nil, &data); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]float64)
+	for _, q := range data {
+		if symbol, ok := keys[q.InstrumentToken]; ok {
+			result[symbol] = q.LastPrice
+		}
+	}
+
+	return result, nil
+}
+
+// upstoxIntervals maps timeframes to Upstox v3 unit/interval pairs
+var upstoxIntervals = map[timeframe.Timeframe][2]string{
+	timeframe.Minute1:  {"minutes", "1"},
+	timeframe.Minute3:  {"minutes", "3"},
+	timeframe.Minute5:  {"minutes", "5"},
+	timeframe.Minute10: {"minutes", "10"},
+	timeframe.Minute15: {"minutes", "15"},
+	timeframe.Minute30: {"minutes", "30"},
+	timeframe.Hour1:    {"hours", "1"},
+	timeframe.Day:      {"days", "1"},
+}
+
+// GetHistoricalData returns historical OHLCV data.
+// instrument is "EXCHANGE:SYMBOL" or an Upstox instrument key ("NSE_EQ|INE002A01018");
+// interval is a Kite name (5minute) or bar timeframe (5m)
+func (u *UpstoxBroker) GetHistoricalData(instrument string, from, to time.Time, interval string) ([]Candle, error) {
+	tf, err := timeframe.Parse(interval)
+	if err != nil {
+		return nil, err
+	}
+	unit := upstoxIntervals[tf]
+
+	key, err := u.resolveInstrumentKey(instrument)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := IST()
+	endpoint := fmt.Sprintf("%s/v3/historical-candle/%s/%s/%s/%s/%s",
+		upstoxBaseURL, url.PathEscape(key), unit[0], unit[1],
+		to.In(loc).Format("2006-01-02"), from.In(loc).Format("2006-01-02"))
+
+	var data struct {
+		Candles [][]interface{} `json:"candles"`
+	}
+	if err := u.request(http.MethodGet, endpoint, nil, &data); err != nil {
+		return nil, err
+	}
+
+	// Upstox returns newest first
+	candles := make([]Candle, 0, len(data.Candles))
+	for i := len(data.Candles) - 1; i >= 0; i-- {
+		row := data.Candles[i]
+		if len(row) < 6 {
+			continue
+		}
+		ts, _ := row[0].(string)
+		date, err := time.Parse(time.RFC3339, ts)
+		if err != nil {
+			continue
+		}
+		candles = append(candles, Candle{
+			Date:   date,
+			Open:   toFloat(row[1]),
+			High:   toFloat(row[2]),
+			Low:    toFloat(row[3]),
+			Close:  toFloat(row[4]),
+			Volume: int64(toFloat(row[5])),
+		})
+	}
+
+	return candles, nil
+}
+
+// upstoxInstrument is an entry in the Upstox instrument master
+type upstoxInstrument struct {
+	InstrumentKey  string  `json:"instrument_key"`
+	ExchangeToken  string  `json:"exchange_token"`
+	TradingSymbol  string  `json:"trading_symbol"`
+	Name           string  `json:"name"`
+	Exchange       string  `json:"exchange"`
+	Segment        string  `json:"segment"`
+	InstrumentType string  `json:"instrument_type"`
+	Expiry         int64   `json:"expiry"` // epoch millis
+	StrikePrice    float64 `json:"strike_price"`
+	TickSize       float64 `json:"tick_size"` // paise
+	LotSize        int     `json:"lot_size"`
+}
+
+func (u *UpstoxBroker) downloadInstrumentMaster() ([]upstoxInstrument, error) {
+	resp, err := u.client.Get(upstoxInstrumentURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download instrument master: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("instrument master download returned HTTP %d", resp.StatusCode)
+	}
+
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open instrument master: %w", err)
+	}
+	defer gz.Close()
+
+	var instruments []upstoxInstrument
+	if err := json.NewDecoder(gz).Decode(&instruments); err != nil {
+		return nil, fmt.Errorf("failed to decode instrument master: %w", err)
+	}
+
+	keys := make(map[string]string, len(instruments))
+	for _, inst := range instruments {
+		// Prefer cash segment when a symbol is listed in several segments
+		k := inst.Exchange + ":" + inst.TradingSymbol
+		if _, exists := keys[k]; !exists || strings.HasSuffix(inst.Segment, "_EQ") {
+			keys[k] = inst.InstrumentKey
+		}
+	}
+	u.instrumentMu.Lock()
+	u.instrumentKeys = keys
+	u.instrumentMu.Unlock()
+
+	return instruments, nil
+}
+
+// GetInstruments returns all tradable instruments from the instrument master
+func (u *UpstoxBroker) GetInstruments(exchange string) ([]Instrument, error) {
+	instruments, err := u.downloadInstrumentMaster()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Instrument, 0)
+	for _, inst := range instruments {
+		if exchange != "" && inst.Exchange != exchange {
+			continue
+		}
+
+		token, err := strconv.ParseInt(inst.ExchangeToken, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		var expiry *time.Time
+		if inst.Expiry > 0 {
+			t := time.UnixMilli(inst.Expiry)
+			expiry = &t
+		}
+
+		result = append(result, Instrument{
+			InstrumentToken: token,
+			ExchangeToken:   token,
+			TradingSymbol:   inst.TradingSymbol,
+			Name:            inst.Name,
+			Exchange:        inst.Exchange,
+			InstrumentType:  inst.InstrumentType,
+			Segment:         inst.Segment,
+			Expiry:          expiry,
+			Strike:          inst.StrikePrice,
+			TickSize:        inst.TickSize / 100,
+			LotSize:         inst.LotSize,
+		})
+	}
+
+	u.logger.Infof("🏢 Loaded %d instruments from %s", len(result), exchange)
+
+	return result, nil
+}
+
+// resolveInstrumentKey turns "EXCHANGE:SYMBOL" into an Upstox instrument key,
+// loading the instrument master on first use
+func (u *UpstoxBroker) resolveInstrumentKey(instrument string) (string, error) {
+	if strings.Contains(instrument, "|") {
+		return instrument, nil
+	}
+
+	exchange, symbol := "NSE", instrument
+	if parts := strings.SplitN(instrument, ":", 2); len(parts) == 2 {
+		exchange, symbol = strings.ToUpper(parts[0]), parts[1]
+	}
+
+	u.instrumentMu.RLock()
+	loaded := u.instrumentKeys != nil
+	u.instrumentMu.RUnlock()
+	if !loaded {
+		if _, err := u.downloadInstrumentMaster(); err != nil {
+			return "", err
+		}
+	}
+
+	u.instrumentMu.RLock()
+	defer u.instrumentMu.RUnlock()
+
+	if key, ok := u.instrumentKeys[exchange+":"+symbol]; ok {
+		return key, nil
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrInvalidSymbol, instrument)
+}
+
+// instrumentKeysFor resolves symbols and returns instrument key -> caller symbol
+func (u *UpstoxBroker) instrumentKeysFor(symbols []string) (map[string]string, error) {
+	keys := make(map[string]string, len(symbols))
+	for _, symbol := range symbols {
+		key, err := u.resolveInstrumentKey(symbol)
+		if err != nil {
+			return nil, err
+		}
+		keys[key] = symbol
+	}
+	return keys, nil
+}
+
+func joinKeys(keys map[string]string) string {
+	list := make([]string, 0, len(keys))
+	for k := range keys {
+		list = append(list, k)
+	}
+	return strings.Join(list, ",")
+}
+
+// ============================================================================
+// TRADING
+// ============================================================================
+
+// PlaceOrder places a new order
+func (u *UpstoxBroker) PlaceOrder(order *OrderRequest) (string, error) {
+	if order.Quantity <= 0 {
+		return "", ErrInvalidQuantity
+	}
+	if order.HasExits() {
+		return "", ErrExitsNotSupported
+	}
+
+	switch order.OrderType {
+	case "MARKET", "LIMIT", "SL", "SL-M":
+	default:
+		return "", ErrInvalidOrderType
+	}
+
+	key, err := u.resolveInstrumentKey(order.Exchange + ":" + order.Symbol)
+	if err != nil {
+		return "", err
+	}
+
+	validity := order.Validity
+	if validity == "" {
+		validity = "DAY"
+	}
+
+	params := map[string]interface{}{
+		"instrument_token":   key,
+		"quantity":           order.Quantity,
+		"product":            productToUpstox(order.Product),
+		"validity":           validity,
+		"price":              order.Price,
+		"tag":                order.Tag,
+		"order_type":         order.OrderType,
+		"transaction_type":   order.TransactionType,
+		"disclosed_quantity": 0,
+		"trigger_price":      order.TriggerPrice,
+		"is_amo":             false,
+	}
+
+	var data struct {
+		OrderID string `json:"order_id"`
+	}
+	if err := u.request(http.MethodPost, upstoxOrderURL+"/v2/order/place", params, &data); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrOrderRejected, err)
+	}
+
+	u.logger.Infof("📤 Order placed: %s - %s %d %s @ %s",
+		data.OrderID, order.TransactionType, order.Quantity, order.Symbol, order.OrderType)
+
+	return data.OrderID, nil
+}
+
+// ModifyOrder modifies an existing order. Upstox requires the full set of
+// modifiable fields, so unchanged values are taken from the current order.
+func (u *UpstoxBroker) ModifyOrder(orderID string, modify *OrderModify) (string, error) {
+	var existing upstoxOrder
+	endpoint := upstoxBaseURL + "/v2/order/details?order_id=" + url.QueryEscape(orderID)
+	if err := u.request(http.MethodGet, endpoint, nil, &existing); err != nil {
+		return "", err
+	}
+
+	params := map[string]interface{}{
+		"order_id":           orderID,
+		"quantity":           existing.Quantity,
+		"validity":           existing.Validity,
+		"price":              existing.Price,
+		"order_type":         existing.OrderType,
+		"disclosed_quantity": existing.DisclosedQuantity,
+		"trigger_price":      existing.TriggerPrice,
+	}
+
+	if modify.Quantity != nil {
+		params["quantity"] = *modify.Quantity
+	}
+	if modify.Price != nil {
+		params["price"] = *modify.Price
+	}
+	if modify.TriggerPrice != nil {
+		params["trigger_price"] = *modify.TriggerPrice
+	}
+	if modify.OrderType != nil {
+		params["order_type"] = *modify.OrderType
+	}
+
+	var data struct {
+		OrderID string `json:"order_id"`
+	}
+	if err := u.request(http.MethodPut, upstoxOrderURL+"/v2/order/modify", params, &data); err != nil {
+		return "", err
+	}
+	if data.OrderID == "" {
+		data.OrderID = orderID
+	}
+
+	u.logger.Infof("✏️  Order modified: %s", data.OrderID)
+
+	return data.OrderID, nil
+}
+
+// CancelOrder cancels an order
+func (u *UpstoxBroker) CancelOrder(orderID string) (string, error) {
+	var data struct {
+		OrderID string `json:"order_id"`
+	}
+	endpoint := upstoxOrderURL + "/v2/order/cancel?order_id=" + url.QueryEscape(orderID)
+	if err := u.request(http.MethodDelete, endpoint, nil, &data); err != nil {
+		return "", err
+	}
+	if data.OrderID == "" {
+		data.OrderID = orderID
+	}
+
+	u.logger.Infof("❌ Order cancelled: %s", data.OrderID)
+
+	return data.OrderID, nil
+}
+
+// ============================================================================
+// UTILITY
+// ============================================================================
+
+// IsMarketOpen checks if market is open
+func (u *UpstoxBroker) IsMarketOpen() bool {
+	return isIndianMarketOpen()
+}
+
+// GetMarketStatus returns current market status
+func (u *UpstoxBroker) GetMarketStatus() string {
+	return indianMarketStatus()
+}
+
+// GetBrokerName returns the broker name
+func (u *UpstoxBroker) GetBrokerName() string {
+	return "upstox"
+}
+
+// productToUpstox maps MIS/CNC/NRML to Upstox product codes
+func productToUpstox(product string) string {
+	switch product {
+	case "MIS":
+		return "I"
+	case "CNC", "NRML":
+		return "D"
+	default:
+		return product
+	}
+}
+
+func upstoxToProduct(product string) string {
+	switch product {
+	case "I":
+		return "MIS"
+	case "D":
+		return "CNC"
+	default:
+		return product
+	}
+}
+
+// parseUpstoxTime parses Upstox order timestamps ("2006-01-02 15:04:05" IST)
+func parseUpstoxTime(value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	loc := IST()
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", value, loc)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
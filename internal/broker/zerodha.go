@@ -2,10 +2,13 @@ package broker
 
 import (
 	"fmt"
+	"strconv"
+	"sync"
 	"time"
 	
 	kiteconnect "github.com/zerodha/gokiteconnect/v4"
 	"github.com/sirupsen/logrus"
+	"github.com/trading-chitti/market-bridge/internal/timeframe"
 )
 
 // ZerodhaBroker implements the Broker interface for Zerodha Kite Connect
@@ -13,6 +16,18 @@ type ZerodhaBroker struct {
 	config *BrokerConfig
 	kite   *kiteconnect.Client
 	logger *logrus.Logger
+
+	// Exit legs wait for their entry to fill, see HandleOrderUpdate
+	exitsMu      sync.Mutex
+	pendingExits map[string]*pendingExit // Entry order ID -> exits
+	exitSiblings map[string]string       // MIS exit order ID -> the other leg
+}
+
+// pendingExit is an entry order whose stop-loss and target are placed as
+// it fills
+type pendingExit struct {
+	order  OrderRequest
+	placed int // Filled quantity exits were placed for
 }
 
 // NewZerodhaBroker creates a new Zerodha broker instance
@@ -29,9 +44,11 @@ func NewZerodhaBroker(config *BrokerConfig) (*ZerodhaBroker, error) {
 	})
 	
 	broker := &ZerodhaBroker{
-		config: config,
-		kite:   kite,
-		logger: logger,
+		config:       config,
+		kite:         kite,
+		logger:       logger,
+		pendingExits: make(map[string]*pendingExit),
+		exitSiblings: make(map[string]string),
 	}
 	
 	broker.logger.Info("✅ Zerodha broker initialized")
@@ -59,9 +76,38 @@ func (z *ZerodhaBroker) GenerateSession(requestToken string) (*Session, error) {
 	z.logger.Infof("✅ Session generated for user: %s", data.UserID)
 	
 	return &Session{
-		UserID:      data.UserID,
-		AccessToken: data.AccessToken,
-		ExpiresAt:   time.Now().Add(24 * time.Hour), // Expires daily
+		UserID:       data.UserID,
+		AccessToken:  data.AccessToken,
+		RefreshToken: data.RefreshToken,
+		ExpiresAt:    time.Now().Add(24 * time.Hour), // Expires daily
+	}, nil
+}
+
+// RefreshSession renews the access token from the config's refresh token.
+// Kite only issues refresh tokens to apps approved for them; other apps
+// need the daily login.
+func (z *ZerodhaBroker) RefreshSession() (*Session, error) {
+	if z.config.RefreshToken == "" {
+		return nil, fmt.Errorf("%w: no refresh token", ErrInvalidCredentials)
+	}
+
+	tokens, err := z.kite.RenewAccessToken(z.config.RefreshToken, z.config.APISecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to renew access token: %w", err)
+	}
+
+	z.SetAccessToken(tokens.AccessToken)
+	if tokens.RefreshToken != "" {
+		z.config.RefreshToken = tokens.RefreshToken
+	}
+
+	z.logger.Infof("✅ Session renewed for user: %s", tokens.UserID)
+
+	return &Session{
+		UserID:       tokens.UserID,
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: z.config.RefreshToken,
+		ExpiresAt:    time.Now().Add(24 * time.Hour), // Expires daily
 	}, nil
 }
 
@@ -234,6 +280,7 @@ func (z *ZerodhaBroker) GetQuote(symbols []string) (map[string]Quote, error) {
 			Volume:        int64(q.Volume),
 			BuyQuantity:   int64(q.BuyQuantity),
 			SellQuantity:  int64(q.SellQuantity),
+			OI:            int64(q.OI),
 			Timestamp:     q.Timestamp.Time,
 		}
 	}
@@ -257,10 +304,37 @@ func (z *ZerodhaBroker) GetLTP(symbols []string) (map[string]float64, error) {
 }
 
 // GetHistoricalData returns historical OHLCV data
+// instrument must be the numeric instrument token (e.g. "738561");
+// interval is a Kite name (5minute) or bar timeframe (5m)
 func (z *ZerodhaBroker) GetHistoricalData(instrument string, from, to time.Time, interval string) ([]Candle, error) {
-	// instrument should be instrument_token as string
-	// For now, return error - need to implement instrument token lookup
-	return nil, fmt.Errorf("not implemented - need instrument token")
+	token, err := strconv.Atoi(instrument)
+	if err != nil {
+		return nil, fmt.Errorf("%w: expected instrument token, got %q", ErrInvalidSymbol, instrument)
+	}
+
+	tf, err := timeframe.Parse(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := z.kite.GetHistoricalData(token, tf.Kite(), from, to, false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	candles := make([]Candle, 0, len(data))
+	for _, d := range data {
+		candles = append(candles, Candle{
+			Date:   d.Date.Time,
+			Open:   d.Open,
+			High:   d.High,
+			Low:    d.Low,
+			Close:  d.Close,
+			Volume: int64(d.Volume),
+		})
+	}
+
+	return candles, nil
 }
 
 // GetInstruments returns all tradable instruments
@@ -300,8 +374,32 @@ func (z *ZerodhaBroker) GetInstruments(exchange string) ([]Instrument, error) {
 	return result, nil
 }
 
-// PlaceOrder places a new order
+// PlaceOrder places a new order. Stop-loss and target legs are placed once
+// the entry fills, for the filled quantity (see HandleOrderUpdate): as a GTT
+// for CNC, as SL-M and LIMIT orders for MIS.
 func (z *ZerodhaBroker) PlaceOrder(order *OrderRequest) (string, error) {
+	if order.HasExits() {
+		if order.Product != "CNC" && order.Product != "MIS" {
+			return "", fmt.Errorf("%w: Zerodha exits need product CNC or MIS", ErrExitsNotSupported)
+		}
+
+		entry := order.Price
+		if order.OrderType != "LIMIT" && order.OrderType != "SL" {
+			key := order.Exchange + ":" + order.Symbol
+			prices, err := z.GetLTP([]string{key})
+			if err != nil {
+				return "", err
+			}
+			entry = prices[key]
+			if entry <= 0 {
+				return "", fmt.Errorf("%w: no price available for %s", ErrOrderRejected, key)
+			}
+		}
+		if err := order.ValidateExits(entry); err != nil {
+			return "", err
+		}
+	}
+
 	params := kiteconnect.OrderParams{
 		Exchange:        order.Exchange,
 		Tradingsymbol:   order.Symbol,
@@ -322,10 +420,187 @@ func (z *ZerodhaBroker) PlaceOrder(order *OrderRequest) (string, error) {
 	
 	z.logger.Infof("📤 Order placed: %s - %s %d %s @ %s", 
 		response.OrderID, order.TransactionType, order.Quantity, order.Symbol, order.OrderType)
+
+	if order.HasExits() {
+		z.exitsMu.Lock()
+		z.pendingExits[response.OrderID] = &pendingExit{order: *order}
+		z.exitsMu.Unlock()
+		z.logger.Infof("🎯 Exits for order %s wait for its fills (SL %.2f, target %.2f)",
+			response.OrderID, order.StopLoss, order.Target)
+	}
 	
 	return response.OrderID, nil
 }
 
+// HandleOrderUpdate places the exits of an entry as it fills, for the
+// quantity filled since the last update, and forgets the entry once it is
+// complete, rejected or cancelled. When one of the two MIS exit legs
+// completes, the other is cancelled.
+func (z *ZerodhaBroker) HandleOrderUpdate(update OrderUpdate) {
+	z.exitsMu.Lock()
+
+	if sibling, ok := z.exitSiblings[update.OrderID]; ok {
+		switch update.Status {
+		case OrderStatusComplete, OrderStatusRejected, OrderStatusCancelled:
+			delete(z.exitSiblings, update.OrderID)
+			delete(z.exitSiblings, sibling)
+		default:
+			z.exitsMu.Unlock()
+			return
+		}
+		z.exitsMu.Unlock()
+
+		// A rejected or cancelled leg leaves the other one standing
+		if update.Status == OrderStatusComplete {
+			if _, err := z.CancelOrder(sibling); err != nil {
+				z.logger.Errorf("❌ Failed to cancel exit %s after exit %s filled: %v", sibling, update.OrderID, err)
+			}
+		}
+		return
+	}
+
+	pending, ok := z.pendingExits[update.OrderID]
+	if !ok {
+		z.exitsMu.Unlock()
+		return
+	}
+	filled := update.FilledQuantity - pending.placed
+	if filled > 0 {
+		pending.placed = update.FilledQuantity
+	}
+	switch update.Status {
+	case OrderStatusComplete, OrderStatusRejected, OrderStatusCancelled:
+		delete(z.pendingExits, update.OrderID)
+	}
+	order := pending.order
+	z.exitsMu.Unlock()
+
+	if filled <= 0 {
+		return
+	}
+	order.Quantity = filled
+
+	if order.Product == "CNC" {
+		triggerID, err := z.placeExitGTT(&order, update.AveragePrice)
+		if err != nil {
+			z.logger.Errorf("❌ Order %s filled %d but its exit GTT failed: %v", update.OrderID, filled, err)
+			return
+		}
+		z.logger.Infof("🎯 Exit GTT %d placed for %d filled of order %s", triggerID, filled, update.OrderID)
+		return
+	}
+
+	if err := z.placeExitOrders(&order); err != nil {
+		z.logger.Errorf("❌ Order %s filled %d but its exits failed: %v", update.OrderID, filled, err)
+		return
+	}
+	z.logger.Infof("🎯 Exit orders placed for %d filled of order %s", filled, update.OrderID)
+}
+
+// exitSide returns the transaction type that closes an order
+func exitSide(order *OrderRequest) string {
+	if order.TransactionType == kiteconnect.TransactionTypeBuy {
+		return kiteconnect.TransactionTypeSell
+	}
+	return kiteconnect.TransactionTypeBuy
+}
+
+// placeExitOrders places the stop-loss of an intraday order as an SL-M
+// order and its target as a LIMIT order. With both set, they cancel each
+// other through HandleOrderUpdate.
+func (z *ZerodhaBroker) placeExitOrders(order *OrderRequest) error {
+	base := kiteconnect.OrderParams{
+		Exchange:        order.Exchange,
+		Tradingsymbol:   order.Symbol,
+		TransactionType: exitSide(order),
+		Product:         order.Product,
+		Quantity:        order.Quantity,
+		Validity:        kiteconnect.ValidityDay,
+		Tag:             order.Tag,
+	}
+
+	var stopID, targetID string
+	if order.StopLoss > 0 {
+		params := base
+		params.OrderType = kiteconnect.OrderTypeSLM
+		params.TriggerPrice = order.StopLoss
+		response, err := z.kite.PlaceOrder(kiteconnect.VarietyRegular, params)
+		if err != nil {
+			return fmt.Errorf("stop-loss: %w", err)
+		}
+		stopID = response.OrderID
+	}
+	if order.Target > 0 {
+		params := base
+		params.OrderType = kiteconnect.OrderTypeLimit
+		params.Price = order.Target
+		response, err := z.kite.PlaceOrder(kiteconnect.VarietyRegular, params)
+		if err != nil {
+			return fmt.Errorf("target (stop-loss %s placed): %w", stopID, err)
+		}
+		targetID = response.OrderID
+	}
+
+	if stopID != "" && targetID != "" {
+		z.exitsMu.Lock()
+		z.exitSiblings[stopID] = targetID
+		z.exitSiblings[targetID] = stopID
+		z.exitsMu.Unlock()
+	}
+	return nil
+}
+
+// gttStopLimitBuffer puts the stop-loss leg's limit price past its trigger so
+// the exit still fills when the price moves quickly through the stop
+const gttStopLimitBuffer = 0.005
+
+// placeExitGTT places the stop-loss and target of an order as a GTT: OCO
+// when both are set, single-leg otherwise. Returns the GTT trigger ID.
+func (z *ZerodhaBroker) placeExitGTT(order *OrderRequest, ltp float64) (int, error) {
+	buy := order.TransactionType == kiteconnect.TransactionTypeBuy
+	stopLimit := order.StopLoss * (1 - gttStopLimitBuffer)
+	if !buy {
+		stopLimit = order.StopLoss * (1 + gttStopLimitBuffer)
+	}
+
+	quantity := float64(order.Quantity)
+	stop := kiteconnect.TriggerParams{
+		TriggerValue: order.StopLoss,
+		LimitPrice:   RoundTick(stopLimit),
+		Quantity:     quantity,
+	}
+	target := kiteconnect.TriggerParams{
+		TriggerValue: order.Target,
+		LimitPrice:   order.Target,
+		Quantity:     quantity,
+	}
+
+	var trigger kiteconnect.Trigger
+	switch {
+	case order.StopLoss > 0 && order.Target > 0 && buy:
+		trigger = &kiteconnect.GTTOneCancelsOtherTrigger{Upper: target, Lower: stop}
+	case order.StopLoss > 0 && order.Target > 0:
+		trigger = &kiteconnect.GTTOneCancelsOtherTrigger{Upper: stop, Lower: target}
+	case order.StopLoss > 0:
+		trigger = &kiteconnect.GTTSingleLegTrigger{TriggerParams: stop}
+	default:
+		trigger = &kiteconnect.GTTSingleLegTrigger{TriggerParams: target}
+	}
+
+	response, err := z.kite.PlaceGTT(kiteconnect.GTTParams{
+		Tradingsymbol:   order.Symbol,
+		Exchange:        order.Exchange,
+		LastPrice:       ltp,
+		TransactionType: exitSide(order),
+		Trigger:         trigger,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return response.TriggerID, nil
+}
+
 // ModifyOrder modifies an existing order
 func (z *ZerodhaBroker) ModifyOrder(orderID string, modify *OrderModify) (string, error) {
 	params := kiteconnect.OrderParams{}
@@ -365,41 +640,214 @@ func (z *ZerodhaBroker) CancelOrder(orderID string) (string, error) {
 	return response.OrderID, nil
 }
 
-// IsMarketOpen checks if market is open
-func (z *ZerodhaBroker) IsMarketOpen() bool {
-	loc, _ := time.LoadLocation("Asia/Kolkata")
-	now := time.Now().In(loc)
-	
-	// Weekend check
-	if now.Weekday() == time.Saturday || now.Weekday() == time.Sunday {
-		return false
+// GetOrderMargins computes the margin the orders would each block with
+// Kite's order margin calculator
+func (z *ZerodhaBroker) GetOrderMargins(orders []OrderRequest) ([]OrderMargin, error) {
+	margins, err := z.kite.GetOrderMargins(kiteconnect.GetMarginParams{OrderParams: kiteMarginParams(orders)})
+	if err != nil {
+		return nil, err
 	}
-	
-	// Market hours: 9:15 AM - 3:30 PM IST
-	marketOpen := time.Date(now.Year(), now.Month(), now.Day(), 9, 15, 0, 0, loc)
-	marketClose := time.Date(now.Year(), now.Month(), now.Day(), 15, 30, 0, 0, loc)
-	
-	return now.After(marketOpen) && now.Before(marketClose)
+	if len(margins) != len(orders) {
+		return nil, fmt.Errorf("kite returned %d order margins for %d orders", len(margins), len(orders))
+	}
+
+	result := make([]OrderMargin, len(margins))
+	for i, m := range margins {
+		result[i] = kiteOrderMargin(m, orders[i].TransactionType)
+	}
+	return result, nil
 }
 
-// GetMarketStatus returns current market status
-func (z *ZerodhaBroker) GetMarketStatus() string {
-	if z.IsMarketOpen() {
-		return "OPEN"
+// GetBasketMargin computes the margin the orders would block together with
+// Kite's basket margin calculator, counting the hedge of open positions
+func (z *ZerodhaBroker) GetBasketMargin(orders []OrderRequest) (*BasketMargin, error) {
+	basket, err := z.kite.GetBasketMargins(kiteconnect.GetBasketParams{
+		OrderParams:       kiteMarginParams(orders),
+		ConsiderPositions: true,
+	})
+	if err != nil {
+		return nil, err
 	}
-	
-	loc, _ := time.LoadLocation("Asia/Kolkata")
-	now := time.Now().In(loc)
-	
-	if now.Weekday() == time.Saturday || now.Weekday() == time.Sunday {
-		return "WEEKEND"
+	if len(basket.Orders) != len(orders) {
+		return nil, fmt.Errorf("kite returned %d order margins for %d orders", len(basket.Orders), len(orders))
 	}
-	
-	if now.Hour() < 9 {
-		return "PRE_MARKET"
+
+	result := &BasketMargin{
+		Initial: kiteOrderMargin(basket.Initial, ""),
+		Final:   kiteOrderMargin(basket.Final, ""),
+		Orders:  make([]OrderMargin, len(basket.Orders)),
 	}
-	
-	return "CLOSED"
+	for i, m := range basket.Orders {
+		result.Orders[i] = kiteOrderMargin(m, orders[i].TransactionType)
+	}
+	return result, nil
+}
+
+// kiteMarginParams converts orders to Kite margin calculator parameters
+func kiteMarginParams(orders []OrderRequest) []kiteconnect.OrderMarginParam {
+	params := make([]kiteconnect.OrderMarginParam, len(orders))
+	for i, order := range orders {
+		params[i] = kiteconnect.OrderMarginParam{
+			Exchange:        order.Exchange,
+			Tradingsymbol:   order.Symbol,
+			TransactionType: order.TransactionType,
+			Variety:         kiteconnect.VarietyRegular,
+			Product:         order.Product,
+			OrderType:       order.OrderType,
+			Quantity:        float64(order.Quantity),
+			Price:           order.Price,
+			TriggerPrice:    order.TriggerPrice,
+		}
+	}
+	return params
+}
+
+// kiteOrderMargin converts a Kite margin calculator result
+func kiteOrderMargin(m kiteconnect.OrderMargins, transactionType string) OrderMargin {
+	return OrderMargin{
+		Exchange:        m.Exchange,
+		Symbol:          m.TradingSymbol,
+		TransactionType: transactionType,
+		SPAN:            m.SPAN,
+		Exposure:        m.Exposure,
+		OptionPremium:   m.OptionPremium,
+		VAR:             m.VAR,
+		Total:           m.Total,
+	}
+}
+
+var _ GTTManager = (*ZerodhaBroker)(nil)
+
+// PlaceGTT places a GTT with Kite, returning its trigger ID. Kite places
+// the legs as CNC limit orders.
+func (z *ZerodhaBroker) PlaceGTT(gtt *GTTRequest) (int, error) {
+	params, err := z.kiteGTTParams(gtt)
+	if err != nil {
+		return 0, err
+	}
+	response, err := z.kite.PlaceGTT(params)
+	if err != nil {
+		return 0, err
+	}
+	return response.TriggerID, nil
+}
+
+// ModifyGTT replaces the triggers and orders of an active GTT
+func (z *ZerodhaBroker) ModifyGTT(triggerID int, gtt *GTTRequest) error {
+	params, err := z.kiteGTTParams(gtt)
+	if err != nil {
+		return err
+	}
+	_, err = z.kite.ModifyGTT(triggerID, params)
+	return err
+}
+
+// DeleteGTT deletes a GTT
+func (z *ZerodhaBroker) DeleteGTT(triggerID int) error {
+	_, err := z.kite.DeleteGTT(triggerID)
+	return err
+}
+
+// GetGTTs returns the account's GTTs, active and recently finished
+func (z *ZerodhaBroker) GetGTTs() ([]GTT, error) {
+	gtts, err := z.kite.GetGTTs()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]GTT, len(gtts))
+	for i, g := range gtts {
+		result[i] = kiteGTT(g)
+	}
+	return result, nil
+}
+
+// GetGTT returns a GTT
+func (z *ZerodhaBroker) GetGTT(triggerID int) (*GTT, error) {
+	g, err := z.kite.GetGTT(triggerID)
+	if err != nil {
+		return nil, err
+	}
+	result := kiteGTT(g)
+	return &result, nil
+}
+
+// kiteGTTParams validates a GTT request and converts it to Kite's GTT
+// parameters, reading the last price when it isn't set
+func (z *ZerodhaBroker) kiteGTTParams(gtt *GTTRequest) (kiteconnect.GTTParams, error) {
+	if err := gtt.Validate(); err != nil {
+		return kiteconnect.GTTParams{}, err
+	}
+
+	lastPrice := gtt.LastPrice
+	if lastPrice == 0 {
+		key := gtt.Exchange + ":" + gtt.Symbol
+		prices, err := z.GetLTP([]string{key})
+		if err != nil {
+			return kiteconnect.GTTParams{}, err
+		}
+		if lastPrice = prices[key]; lastPrice == 0 {
+			return kiteconnect.GTTParams{}, fmt.Errorf("%w: no last price for %s", ErrInvalidSymbol, key)
+		}
+	}
+
+	legs := make([]kiteconnect.TriggerParams, len(gtt.Legs))
+	for i, leg := range gtt.Legs {
+		legs[i] = kiteconnect.TriggerParams{
+			TriggerValue: leg.TriggerPrice,
+			LimitPrice:   leg.LimitPrice,
+			Quantity:     float64(leg.Quantity),
+		}
+	}
+	var trigger kiteconnect.Trigger = &kiteconnect.GTTSingleLegTrigger{TriggerParams: legs[0]}
+	if len(legs) == 2 {
+		trigger = &kiteconnect.GTTOneCancelsOtherTrigger{Lower: legs[0], Upper: legs[1]}
+	}
+
+	return kiteconnect.GTTParams{
+		Tradingsymbol:   gtt.Symbol,
+		Exchange:        gtt.Exchange,
+		LastPrice:       lastPrice,
+		TransactionType: gtt.TransactionType,
+		Trigger:         trigger,
+	}, nil
+}
+
+// kiteGTT converts a Kite GTT, pairing its trigger values with its orders
+func kiteGTT(g kiteconnect.GTT) GTT {
+	result := GTT{
+		ID:              g.ID,
+		Type:            string(g.Type),
+		Status:          g.Status,
+		Symbol:          g.Condition.Tradingsymbol,
+		Exchange:        g.Condition.Exchange,
+		LastPrice:       g.Condition.LastPrice,
+		Legs:            make([]GTTLeg, 0, len(g.Orders)),
+		RejectionReason: g.Meta.RejectionReason,
+		CreatedAt:       g.CreatedAt.Time,
+		UpdatedAt:       g.UpdatedAt.Time,
+		ExpiresAt:       g.ExpiresAt.Time,
+	}
+	for i, o := range g.Orders {
+		leg := GTTLeg{LimitPrice: o.Price, Quantity: int(o.Quantity)}
+		if i < len(g.Condition.TriggerValues) {
+			leg.TriggerPrice = g.Condition.TriggerValues[i]
+		}
+		result.Legs = append(result.Legs, leg)
+		result.TransactionType = o.TransactionType
+		result.Product = o.Product
+	}
+	return result
+}
+
+// IsMarketOpen checks if market is open
+func (z *ZerodhaBroker) IsMarketOpen() bool {
+	return isIndianMarketOpen()
+}
+
+// GetMarketStatus returns current market status
+func (z *ZerodhaBroker) GetMarketStatus() string {
+	return indianMarketStatus()
 }
 
 // GetBrokerName returns the broker name
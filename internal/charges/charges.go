@@ -0,0 +1,174 @@
+// Package charges estimates the brokerage, statutory taxes and exchange
+// fees of Indian equity and F&O trades
+package charges
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ErrUnsupportedSegment is returned for exchanges and products without
+// known charge rates, such as commodities and currency derivatives
+var ErrUnsupportedSegment = errors.New("unsupported segment")
+
+// Segment is the kind of trade charges are levied on
+type Segment string
+
+// Segments with known charge rates
+const (
+	EquityDelivery Segment = "equity_delivery" // CNC on NSE/BSE
+	EquityIntraday Segment = "equity_intraday" // MIS on NSE/BSE
+	Futures        Segment = "futures"         // NFO/BFO futures
+	Options        Segment = "options"         // NFO/BFO options
+)
+
+// GSTRate is levied on brokerage, exchange transaction charges and SEBI fees
+const GSTRate = 0.18
+
+// SEBIFeeRate is SEBI's turnover fee of ₹10 per crore
+const SEBIFeeRate = 0.000001
+
+// Rates are the charges of a segment, as fractions of turnover (premium
+// turnover for options) unless noted
+type Rates struct {
+	BrokerageRate float64            // Brokerage per executed order, capped at BrokerageCap
+	BrokerageCap  float64            // Most brokerage per executed order, ₹; 0 for no cap
+	BrokerageFlat float64            // Flat brokerage per executed order, ₹, used when BrokerageRate is 0
+	STTBuy        float64            // Securities transaction tax on buys
+	STTSell       float64            // Securities transaction tax on sells
+	StampDutyBuy  float64            // Stamp duty, levied on buys only
+	Transaction   map[string]float64 // Exchange transaction charges by exchange
+}
+
+// DefaultRates are Zerodha's brokerage with the statutory charges in force
+// since October 2024
+var DefaultRates = map[Segment]Rates{
+	EquityDelivery: {
+		STTBuy:       0.001,
+		STTSell:      0.001,
+		StampDutyBuy: 0.00015,
+		Transaction:  map[string]float64{"NSE": 0.0000297, "BSE": 0.0000375},
+	},
+	EquityIntraday: {
+		BrokerageRate: 0.0003,
+		BrokerageCap:  20,
+		STTSell:       0.00025,
+		StampDutyBuy:  0.00003,
+		Transaction:   map[string]float64{"NSE": 0.0000297, "BSE": 0.0000375},
+	},
+	Futures: {
+		BrokerageRate: 0.0003,
+		BrokerageCap:  20,
+		STTSell:       0.0002,
+		StampDutyBuy:  0.00002,
+		Transaction:   map[string]float64{"NFO": 0.0000173, "BFO": 0},
+	},
+	Options: {
+		BrokerageFlat: 20,
+		STTSell:       0.001,
+		StampDutyBuy:  0.00003,
+		Transaction:   map[string]float64{"NFO": 0.0003503, "BFO": 0.000325},
+	},
+}
+
+// SegmentOf returns the segment of an order by its exchange, product and
+// instrument type (EQ, FUT, CE or PE as in the instruments table). An empty
+// instrument type is told from the trading symbol's suffix.
+func SegmentOf(exchange, product, instrumentType, symbol string) (Segment, error) {
+	exchange = strings.ToUpper(exchange)
+	switch exchange {
+	case "NSE", "BSE":
+		switch strings.ToUpper(product) {
+		case "CNC", "MTF":
+			return EquityDelivery, nil
+		case "MIS":
+			return EquityIntraday, nil
+		}
+		return "", fmt.Errorf("%w: product %q on %s", ErrUnsupportedSegment, product, exchange)
+	case "NFO", "BFO":
+		instrumentType = strings.ToUpper(instrumentType)
+		if instrumentType == "" {
+			symbol = strings.ToUpper(symbol)
+			switch {
+			case strings.HasSuffix(symbol, "FUT"):
+				instrumentType = "FUT"
+			case strings.HasSuffix(symbol, "CE"), strings.HasSuffix(symbol, "PE"):
+				instrumentType = symbol[len(symbol)-2:]
+			}
+		}
+		switch instrumentType {
+		case "FUT":
+			return Futures, nil
+		case "CE", "PE":
+			return Options, nil
+		}
+		return "", fmt.Errorf("%w: instrument type %q on %s", ErrUnsupportedSegment, instrumentType, exchange)
+	}
+	return "", fmt.Errorf("%w: exchange %q", ErrUnsupportedSegment, exchange)
+}
+
+// Breakdown is the estimated cost of one executed order, ₹
+type Breakdown struct {
+	Segment         Segment `json:"segment"`
+	Turnover        float64 `json:"turnover"`
+	Brokerage       float64 `json:"brokerage"`
+	STT             float64 `json:"stt"`
+	ExchangeCharges float64 `json:"exchange_charges"`
+	SEBIFees        float64 `json:"sebi_fees"`
+	StampDuty       float64 `json:"stamp_duty"`
+	GST             float64 `json:"gst"`
+	Total           float64 `json:"total"`
+}
+
+// Estimate returns the charges of buying or selling quantity at price on
+// exchange, executed as a single order
+func Estimate(segment Segment, exchange string, buy bool, quantity int, price float64) (Breakdown, error) {
+	rates, ok := DefaultRates[segment]
+	if !ok {
+		return Breakdown{}, fmt.Errorf("%w: %q", ErrUnsupportedSegment, segment)
+	}
+	exchange = strings.ToUpper(exchange)
+	transaction, ok := rates.Transaction[exchange]
+	if !ok {
+		return Breakdown{}, fmt.Errorf("%w: %s on %s", ErrUnsupportedSegment, segment, exchange)
+	}
+
+	turnover := float64(quantity) * price
+	b := Breakdown{Segment: segment, Turnover: round(turnover)}
+
+	switch {
+	case rates.BrokerageRate > 0:
+		b.Brokerage = turnover * rates.BrokerageRate
+		if rates.BrokerageCap > 0 && b.Brokerage > rates.BrokerageCap {
+			b.Brokerage = rates.BrokerageCap
+		}
+	case turnover > 0:
+		b.Brokerage = rates.BrokerageFlat
+	}
+
+	if buy {
+		b.STT = turnover * rates.STTBuy
+		b.StampDuty = turnover * rates.StampDutyBuy
+	} else {
+		b.STT = turnover * rates.STTSell
+	}
+	b.ExchangeCharges = turnover * transaction
+	b.SEBIFees = turnover * SEBIFeeRate
+	b.GST = (b.Brokerage + b.ExchangeCharges + b.SEBIFees) * GSTRate
+
+	b.Brokerage = round(b.Brokerage)
+	b.STT = math.Round(b.STT) // STT is rounded to the rupee
+	b.ExchangeCharges = round(b.ExchangeCharges)
+	b.SEBIFees = round(b.SEBIFees)
+	b.StampDuty = math.Round(b.StampDuty) // Stamp duty too
+	b.GST = round(b.GST)
+	b.Total = round(b.Brokerage + b.STT + b.ExchangeCharges + b.SEBIFees + b.StampDuty + b.GST)
+	return b, nil
+}
+
+// round rounds to paise
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,99 @@
+package charges
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSegmentOf(t *testing.T) {
+	tests := []struct {
+		exchange, product, instrumentType, symbol string
+		want                                      Segment
+		wantErr                                   bool
+	}{
+		{"NSE", "CNC", "EQ", "RELIANCE", EquityDelivery, false},
+		{"bse", "mis", "", "RELIANCE", EquityIntraday, false},
+		{"NSE", "NRML", "", "RELIANCE", "", true},
+		{"NFO", "NRML", "FUT", "NIFTY24DECFUT", Futures, false},
+		{"NFO", "MIS", "", "NIFTY24DECFUT", Futures, false},
+		{"NFO", "NRML", "PE", "NIFTY24DEC24000PE", Options, false},
+		{"BFO", "NRML", "", "SENSEX24DEC80000CE", Options, false},
+		{"NFO", "NRML", "", "NIFTY", "", true},
+		{"MCX", "NRML", "FUT", "CRUDEOIL24DECFUT", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.exchange+":"+tt.symbol+"/"+tt.product, func(t *testing.T) {
+			got, err := SegmentOf(tt.exchange, tt.product, tt.instrumentType, tt.symbol)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnsupportedSegment) {
+					t.Errorf("SegmentOf error = %v, want ErrUnsupportedSegment", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("SegmentOf = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		name     string
+		segment  Segment
+		exchange string
+		buy      bool
+		quantity int
+		price    float64
+		want     Breakdown
+	}{
+		{
+			name:    "delivery buy",
+			segment: EquityDelivery, exchange: "NSE", buy: true, quantity: 100, price: 1000,
+			want: Breakdown{Turnover: 100000, STT: 100, ExchangeCharges: 2.97, SEBIFees: 0.1, StampDuty: 15, GST: 0.55, Total: 118.62},
+		},
+		{
+			name:    "intraday sell at the brokerage cap",
+			segment: EquityIntraday, exchange: "NSE", quantity: 100, price: 1000,
+			want: Breakdown{Turnover: 100000, Brokerage: 20, STT: 25, ExchangeCharges: 2.97, SEBIFees: 0.1, GST: 4.15, Total: 52.22},
+		},
+		{
+			name:    "small intraday buy",
+			segment: EquityIntraday, exchange: "NSE", buy: true, quantity: 10, price: 100,
+			want: Breakdown{Turnover: 1000, Brokerage: 0.3, ExchangeCharges: 0.03, GST: 0.06, Total: 0.39},
+		},
+		{
+			name:    "futures buy",
+			segment: Futures, exchange: "NFO", buy: true, quantity: 75, price: 24000,
+			want: Breakdown{Turnover: 1800000, Brokerage: 20, ExchangeCharges: 31.14, SEBIFees: 1.8, StampDuty: 36, GST: 9.53, Total: 98.47},
+		},
+		{
+			name:    "options sell",
+			segment: Options, exchange: "NFO", quantity: 75, price: 100,
+			want: Breakdown{Turnover: 7500, Brokerage: 20, STT: 8, ExchangeCharges: 2.63, SEBIFees: 0.01, GST: 4.07, Total: 34.71},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Estimate(tt.segment, tt.exchange, tt.buy, tt.quantity, tt.price)
+			if err != nil {
+				t.Fatalf("Estimate: %v", err)
+			}
+			tt.want.Segment = tt.segment
+			if got != tt.want {
+				t.Errorf("Estimate = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimateUnsupported(t *testing.T) {
+	if _, err := Estimate(Futures, "MCX", true, 1, 100); !errors.Is(err, ErrUnsupportedSegment) {
+		t.Errorf("Estimate on MCX = %v, want ErrUnsupportedSegment", err)
+	}
+	if _, err := Estimate("commodity", "NSE", true, 1, 100); !errors.Is(err, ErrUnsupportedSegment) {
+		t.Errorf("Estimate of an unknown segment = %v, want ErrUnsupportedSegment", err)
+	}
+}
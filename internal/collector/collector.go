@@ -2,49 +2,81 @@ package collector
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	kiteconnect "github.com/zerodha/gokiteconnect/v4"
-	kiteticker "github.com/zerodha/gokiteconnect/v4/ticker"
-	"github.com/zerodha/gokiteconnect/v4/models"
 	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/metrics"
+	"github.com/trading-chitti/market-bridge/internal/quotes"
+	"github.com/trading-chitti/market-bridge/internal/timeframe"
+	"github.com/trading-chitti/market-bridge/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // DataCollector manages real-time market data collection
 type DataCollector struct {
 	db             *database.Database
-	ticker         *kiteticker.Ticker
-	apiKey         string
-	accessToken    string
+	source         TickSource
 
 	// Subscribed instruments
 	subscribedTokens []uint32
 	tokenToSymbol    map[uint32]string
+	mode             string // Set on (re)connect: ltp, quote or full
 	mu               sync.RWMutex
 
 	// Candle aggregation
 	candleBuilders   map[uint32]*CandleBuilder
 	builderMu        sync.RWMutex
 
+	// Batches tick writes
+	ticks            *tickWriter
+
+	// Looks up registered instruments for their exchange
+	lookupInstrument func(token uint32) (*database.Instrument, error)
+
+	// Ticks being stored and aggregated, drained by Stop
+	pending          sync.WaitGroup
+
 	// Control
+	name             string // Label on the collector's Prometheus metrics
 	ctx              context.Context
 	cancel           context.CancelFunc
 	running          bool
+	startedAt        time.Time
+	failure          error // Set when the tick source gives up reconnecting
+
+	// Metrics, updated from the tick goroutines without holding mu
+	ticksReceived    atomic.Int64
+	barsCreated      atomic.Int64
+	errors           atomic.Int64
+
+	// Per-symbol counters and latencies; last ticks feed the health watchdog
+	symbols          *symbolTracker
+
+	// Called with errors reported by the tick source
+	errorHandler     func(error)
 
-	// Metrics
-	ticksReceived    int64
-	barsCreated      int64
-	errors           int64
+	// Receives stored ticks and completed bars
+	publisher        Publisher
+	qualityGate      QualityGate
+	quoteStore       *quotes.Store
 }
 
+// candleUpdateInterval throttles the forming candle published on ticks
+const candleUpdateInterval = 500 * time.Millisecond
+
 // CandleBuilder aggregates ticks into OHLCV candles
 type CandleBuilder struct {
 	InstrumentToken int64
 	Symbol          string
 	Exchange        string
 	Timeframe       string
+	Source          string // Tick source name, recorded on the bars
 
 	// Current candle data
 	CurrentOpen      float64
@@ -52,24 +84,114 @@ type CandleBuilder struct {
 	CurrentLow       float64
 	CurrentClose     float64
 	CurrentVolume    int64
+	CurrentOI        int64 // Open interest at the latest tick, 0 for equities
 	CurrentTimestamp time.Time
 
-	mu sync.Mutex
+	lastUpdate time.Time // Last forming candle published
+	mu         sync.Mutex
+}
+
+// bar returns the current candle; callers must hold b.mu
+func (b *CandleBuilder) bar() *database.IntradayBar {
+	return &database.IntradayBar{
+		Exchange:        b.Exchange,
+		Symbol:          b.Symbol,
+		InstrumentToken: b.InstrumentToken,
+		BarTimestamp:    b.CurrentTimestamp,
+		Timeframe:       b.Timeframe,
+		Open:            b.CurrentOpen,
+		High:            b.CurrentHigh,
+		Low:             b.CurrentLow,
+		Close:           b.CurrentClose,
+		Volume:          b.CurrentVolume,
+		OI:              b.oi(),
+		Source:          b.Source,
+	}
+}
+
+// start begins a candle at minute with a trade; callers must hold b.mu
+func (b *CandleBuilder) start(minute time.Time, price float64, quantity, oi int64) {
+	b.CurrentTimestamp = minute
+	b.CurrentOpen = price
+	b.CurrentHigh = price
+	b.CurrentLow = price
+	b.CurrentClose = price
+	b.CurrentVolume = quantity
+	b.CurrentOI = oi
+}
+
+// update adds a trade to the forming candle; callers must hold b.mu
+func (b *CandleBuilder) update(price float64, quantity, oi int64) {
+	if price > b.CurrentHigh {
+		b.CurrentHigh = price
+	}
+	if price < b.CurrentLow {
+		b.CurrentLow = price
+	}
+	b.CurrentClose = price
+	b.CurrentVolume += quantity
+	if oi > 0 {
+		b.CurrentOI = oi
+	}
 }
 
-// NewDataCollector creates a new data collector
+// oi returns the candle's closing open interest, nil when the instrument
+// reports none; callers must hold b.mu
+func (b *CandleBuilder) oi() *int64 {
+	if b.CurrentOI <= 0 {
+		return nil
+	}
+	oi := b.CurrentOI
+	return &oi
+}
+
+// NewDataCollector creates a new data collector fed by the Zerodha Kite ticker
 func NewDataCollector(db *database.Database, apiKey, accessToken string) *DataCollector {
+	return NewDataCollectorWithSource(db, NewZerodhaTickSource(apiKey, accessToken))
+}
+
+// NewDataCollectorWithSource creates a data collector fed by any tick source
+func NewDataCollectorWithSource(db *database.Database, source TickSource) *DataCollector {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &DataCollector{
+	dc := &DataCollector{
 		db:               db,
-		apiKey:           apiKey,
-		accessToken:      accessToken,
+		source:           source,
 		tokenToSymbol:    make(map[uint32]string),
+		mode:             ModeFull,
 		candleBuilders:   make(map[uint32]*CandleBuilder),
+		lookupInstrument: db.GetInstrumentByToken,
+		symbols:          newSymbolTracker(),
 		ctx:              ctx,
 		cancel:           cancel,
 	}
+	dc.ticks = newTickWriter(db.BulkInsertTickData, dc.ticksWritten)
+	return dc
+}
+
+// SetTickWriterConfig sets how tick writes are batched. A new flush
+// interval applies from the next Start.
+func (dc *DataCollector) SetTickWriterConfig(cfg TickWriterConfig) {
+	dc.ticks.setConfig(cfg)
+}
+
+// SetName names the collector in its Prometheus metrics. Must be called
+// before Start.
+func (dc *DataCollector) SetName(name string) {
+	dc.name = name
+}
+
+// SetCredentials passes renewed broker credentials to the tick source,
+// reporting whether it uses them. Restart a running collector to apply them.
+func (dc *DataCollector) SetCredentials(apiKey, accessToken string) bool {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	setter, ok := dc.source.(CredentialSetter)
+	if ok {
+		setter.SetCredentials(apiKey, accessToken)
+	}
+	return ok
 }
 
 // Start begins data collection
@@ -80,54 +202,52 @@ func (dc *DataCollector) Start() error {
 		return nil
 	}
 	dc.running = true
-	dc.mu.Unlock()
+	dc.startedAt = time.Now()
+	dc.failure = nil
 
-	// Initialize Kite Ticker
-	dc.ticker = kiteticker.New(dc.apiKey, dc.accessToken)
+	// A stopped collector's context is cancelled, so restarts need a new one
+	dc.ctx, dc.cancel = context.WithCancel(context.Background())
+	ctx := dc.ctx
+	dc.mu.Unlock()
 
 	// Set up callbacks
-	dc.ticker.OnConnect(dc.onConnect)
-	dc.ticker.OnTick(dc.onTick)
-	dc.ticker.OnReconnect(dc.onReconnect)
-	dc.ticker.OnNoReconnect(dc.onNoReconnect)
-	dc.ticker.OnError(dc.onError)
-	dc.ticker.OnClose(dc.onClose)
-	dc.ticker.OnOrderUpdate(dc.onOrderUpdate)
-
-	// Enable auto-reconnect
-	dc.ticker.SetAutoReconnect(true)
-	dc.ticker.SetReconnectMaxRetries(10)
-	dc.ticker.SetReconnectMaxDelay(60 * time.Second)
-
-	// Start periodic candle flushing
-	go dc.flushCandlesPeriodically()
-
-	// Serve (blocking call)
-	go func() {
-		dc.ticker.Serve()
-	}()
+	dc.source.OnConnect(dc.onConnect)
+	dc.source.OnTick(dc.FeedTick)
+	dc.source.OnError(dc.onError)
+
+	// Start periodic candle and tick flushing
+	go dc.flushCandlesPeriodically(ctx)
+	go dc.ticks.run(ctx)
+
+	if err := dc.source.Connect(); err != nil {
+		dc.mu.Lock()
+		dc.running = false
+		dc.mu.Unlock()
+		return err
+	}
 
 	log.Println("✅ Data collector started")
 	return nil
 }
 
-// Stop stops data collection
+// Stop stops data collection. Ticks already received are stored and the
+// forming candles flushed before it returns.
 func (dc *DataCollector) Stop() {
 	dc.mu.Lock()
-	defer dc.mu.Unlock()
-
 	if !dc.running {
+		dc.mu.Unlock()
 		return
 	}
-
 	dc.running = false
 	dc.cancel()
+	dc.source.Close()
+	dc.mu.Unlock()
 
-	if dc.ticker != nil {
-		dc.ticker.Stop()
-	}
+	// Storing ticks takes dc.mu, so wait without holding it
+	dc.pending.Wait()
 
-	// Flush remaining candles
+	// Flush remaining ticks and candles
+	dc.ticks.flush()
 	dc.flushAllCandles()
 
 	log.Println("🛑 Data collector stopped")
@@ -140,9 +260,9 @@ func (dc *DataCollector) Subscribe(tokens []uint32) error {
 
 	dc.subscribedTokens = append(dc.subscribedTokens, tokens...)
 
-	if dc.ticker != nil && dc.running {
+	if dc.running {
 		// Subscribe to full mode (OHLC + depth + LTP)
-		return dc.ticker.Subscribe(tokens)
+		return dc.source.Subscribe(tokens)
 	}
 
 	return nil
@@ -153,45 +273,102 @@ func (dc *DataCollector) Unsubscribe(tokens []uint32) error {
 	dc.mu.Lock()
 	defer dc.mu.Unlock()
 
-	if dc.ticker != nil && dc.running {
-		return dc.ticker.Unsubscribe(tokens)
+	removed := make(map[uint32]bool, len(tokens))
+	for _, token := range tokens {
+		removed[token] = true
+	}
+
+	remaining := dc.subscribedTokens[:0]
+	for _, token := range dc.subscribedTokens {
+		if !removed[token] {
+			remaining = append(remaining, token)
+		}
+	}
+	dc.subscribedTokens = remaining
+
+	if dc.running {
+		return dc.source.Unsubscribe(tokens)
 	}
 
 	return nil
 }
 
+// GetSubscribedSymbols returns the symbols of all subscribed instruments
+func (dc *DataCollector) GetSubscribedSymbols() []string {
+	dc.mu.RLock()
+	defer dc.mu.RUnlock()
+
+	symbols := make([]string, 0, len(dc.subscribedTokens))
+	for _, token := range dc.subscribedTokens {
+		if symbol, ok := dc.tokenToSymbol[token]; ok {
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	return symbols
+}
+
 // SetMode sets subscription mode for instruments
 func (dc *DataCollector) SetMode(mode string, tokens []uint32) error {
-	if dc.ticker == nil || !dc.running {
+	dc.mu.RLock()
+	running := dc.running
+	dc.mu.RUnlock()
+	if !running {
 		return nil
 	}
 
+	return dc.source.SetMode(mode, tokens)
+}
+
+// SetSubscriptionMode sets the mode subscribed instruments get on every
+// (re)connect, and applies it now if running
+func (dc *DataCollector) SetSubscriptionMode(mode string) error {
 	switch mode {
-	case "ltp":
-		return dc.ticker.SetMode(kiteticker.ModeLTP, tokens)
-	case "quote":
-		return dc.ticker.SetMode(kiteticker.ModeQuote, tokens)
-	case "full":
-		return dc.ticker.SetMode(kiteticker.ModeFull, tokens)
+	case ModeLTP, ModeQuote, ModeFull:
 	default:
-		return dc.ticker.SetMode(kiteticker.ModeLTP, tokens)
+		return fmt.Errorf("invalid mode %q (use ltp, quote or full)", mode)
+	}
+
+	dc.mu.Lock()
+	dc.mode = mode
+	tokens := append([]uint32{}, dc.subscribedTokens...)
+	running := dc.running
+	dc.mu.Unlock()
+
+	if running && len(tokens) > 0 {
+		return dc.source.SetMode(mode, tokens)
 	}
+	return nil
 }
 
-// RegisterSymbol maps a token to a symbol
+// RegisterSymbol maps a token to a symbol. Its ticks and bars are stored
+// under the exchange the instrument table lists the token on, or exchange
+// if it isn't there (e.g. indices).
 func (dc *DataCollector) RegisterSymbol(token uint32, exchange, symbol string) {
+	instrument, err := dc.lookupInstrument(token)
+	if err != nil {
+		log.Printf("⚠️  Failed to look up instrument %d (%s), using %s: %v", token, symbol, exchange, err)
+	} else if instrument != nil && instrument.Exchange != "" {
+		exchange = instrument.Exchange
+	}
+
 	dc.mu.Lock()
 	defer dc.mu.Unlock()
 
 	dc.tokenToSymbol[token] = symbol
 
+	if registrar, ok := dc.source.(SymbolRegistrar); ok {
+		registrar.RegisterSymbol(token, exchange, symbol)
+	}
+
 	// Initialize candle builders for different timeframes
 	dc.builderMu.Lock()
 	dc.candleBuilders[token] = &CandleBuilder{
 		InstrumentToken: int64(token),
 		Symbol:          symbol,
 		Exchange:        exchange,
-		Timeframe:       "1m",
+		Timeframe:       timeframe.Minute1.String(),
+		Source:          dc.source.Name(),
 	}
 	dc.builderMu.Unlock()
 }
@@ -201,20 +378,19 @@ func (dc *DataCollector) RegisterSymbol(token uint32, exchange, symbol string) {
 // ============================================================================
 
 func (dc *DataCollector) onConnect() {
-	log.Println("✅ Connected to Kite Ticker")
-
 	// Resubscribe to instruments
 	dc.mu.RLock()
 	tokens := dc.subscribedTokens
+	mode := dc.mode
 	dc.mu.RUnlock()
 
 	if len(tokens) > 0 {
-		if err := dc.ticker.Subscribe(tokens); err != nil {
+		if err := dc.source.Subscribe(tokens); err != nil {
 			log.Printf("❌ Failed to subscribe: %v", err)
 		}
 
-		// Set to full mode for complete data
-		if err := dc.ticker.SetMode(kiteticker.ModeFull, tokens); err != nil {
+		// Full mode by default for complete data
+		if err := dc.source.SetMode(mode, tokens); err != nil {
 			log.Printf("❌ Failed to set mode: %v", err)
 		}
 
@@ -222,86 +398,180 @@ func (dc *DataCollector) onConnect() {
 	}
 }
 
-func (dc *DataCollector) onTick(tick models.Tick) {
-	dc.ticksReceived++
+// FeedTick pushes a broker-neutral tick into the collector. It is the
+// OnTick callback for the collector's TickSource.
+func (dc *DataCollector) FeedTick(tick Tick) {
+	dc.ticksReceived.Add(1)
+	dc.pending.Add(2)
 
-	// Store tick data
-	go dc.storeTick(tick)
+	// Store and publish tick data
+	go func() {
+		defer dc.pending.Done()
+		dc.storeTick(tick)
+	}()
 
 	// Update candle builders
-	go dc.updateCandles(tick)
+	go func() {
+		defer dc.pending.Done()
+		dc.updateCandles(tick)
+	}()
 }
 
-func (dc *DataCollector) onReconnect(attempt int, delay time.Duration) {
-	log.Printf("🔄 Reconnecting (attempt %d, delay %v)", attempt, delay)
+func (dc *DataCollector) onError(err error) {
+	dc.errors.Add(1)
+	metrics.RecordCollectorError(dc.name, "source")
+
+	if errors.Is(err, ErrReconnectFailed) {
+		dc.mu.Lock()
+		dc.failure = err
+		dc.mu.Unlock()
+	}
+
+	if dc.errorHandler != nil {
+		dc.errorHandler(err)
+	}
 }
 
-func (dc *DataCollector) onNoReconnect(attempt int) {
-	log.Printf("❌ Reconnection failed after %d attempts", attempt)
-	dc.errors++
+// SetErrorHandler sets a callback for tick source errors. Must be called
+// before Start.
+func (dc *DataCollector) SetErrorHandler(fn func(error)) {
+	dc.errorHandler = fn
 }
 
-func (dc *DataCollector) onError(err error) {
-	log.Printf("❌ Ticker error: %v", err)
-	dc.errors++
+// SetPublisher sets where stored ticks and completed bars are published
+func (dc *DataCollector) SetPublisher(p Publisher) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.publisher = p
+}
+
+func (dc *DataCollector) getPublisher() Publisher {
+	dc.mu.RLock()
+	defer dc.mu.RUnlock()
+	return dc.publisher
+}
+
+// SetQualityGate sets the checks ticks and bars must pass to be stored
+func (dc *DataCollector) SetQualityGate(gate QualityGate) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.qualityGate = gate
 }
 
-func (dc *DataCollector) onClose(code int, reason string) {
-	log.Printf("🔌 Connection closed: code=%d, reason=%s", code, reason)
+func (dc *DataCollector) getQualityGate() QualityGate {
+	dc.mu.RLock()
+	defer dc.mu.RUnlock()
+	return dc.qualityGate
 }
 
-func (dc *DataCollector) onOrderUpdate(order kiteconnect.Order) {
-	log.Printf("📋 Order update: %s - %s", order.OrderID, order.Status)
-	// TODO: Store order updates in database
+// SetQuoteStore sets the store kept up to date with the latest ticks
+func (dc *DataCollector) SetQuoteStore(store *quotes.Store) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.quoteStore = store
+}
+
+func (dc *DataCollector) getQuoteStore() *quotes.Store {
+	dc.mu.RLock()
+	defer dc.mu.RUnlock()
+	return dc.quoteStore
 }
 
 // ============================================================================
 // DATA STORAGE
 // ============================================================================
 
-func (dc *DataCollector) storeTick(tickData interface{}) {
-	// Type assert to kiteticker.Tick
-	tick, ok := tickData.(map[string]interface{})
-	if !ok {
-		return
-	}
-	// Extract instrument token
-	instrumentToken, ok := tick["instrument_token"].(uint32)
-	if !ok {
-		return
-	}
-
+// storeTick queues a tick for writing and publishes it
+func (dc *DataCollector) storeTick(tick Tick) {
 	dc.mu.RLock()
-	symbol, exists := dc.tokenToSymbol[instrumentToken]
+	symbol, exists := dc.tokenToSymbol[tick.InstrumentToken]
 	dc.mu.RUnlock()
 
 	if !exists {
 		return
 	}
 
-	// Extract price and quantity
-	lastPrice, _ := tick["last_price"].(float64)
-	lastQuantity, _ := tick["last_quantity"].(uint32)
-	timestamp, _ := tick["timestamp"].(time.Time)
+	receivedAt := time.Now()
+	dc.symbols.recordTick(symbol, receivedAt)
+	metrics.RecordTick(dc.name, symbol, receivedAt)
+
+	// Resolved from the instrument table when the symbol was registered
+	exchange := "NSE"
+	dc.builderMu.RLock()
+	if builder, ok := dc.candleBuilders[tick.InstrumentToken]; ok {
+		exchange = builder.Exchange
+	}
+	dc.builderMu.RUnlock()
+
+	timestamp := tick.Timestamp
+	if timestamp.IsZero() {
+		timestamp = receivedAt
+	}
 
 	dbTickData := &database.TickData{
-		Exchange:        "NSE", // TODO: Get from instrument lookup
+		Exchange:        exchange,
 		Symbol:          symbol,
-		InstrumentToken: int64(instrumentToken),
+		InstrumentToken: int64(tick.InstrumentToken),
 		TickTimestamp:   timestamp,
-		Price:           lastPrice,
-		Quantity:        int64(lastQuantity),
+		Price:           tick.LastPrice,
+		Quantity:        tick.LastQuantity,
 		TradeType:       "unknown",
-		Source:          "zerodha",
+		Bid:             positiveFloat(tick.Bid),
+		Ask:             positiveFloat(tick.Ask),
+		OI:              positiveInt(tick.OI),
+		Source:          dc.source.Name(),
 	}
 
-	if err := dc.db.InsertTickData(dbTickData); err != nil {
-		log.Printf("❌ Failed to store tick: %v", err)
-		dc.errors++
+	if gate := dc.getQualityGate(); gate != nil && !gate.AcceptTick(dbTickData) {
+		return
+	}
+
+	if store := dc.getQuoteStore(); store != nil {
+		store.Update(dbTickData, tick.Volume)
+	}
+
+	dc.ticks.add(*dbTickData)
+
+	if publisher := dc.getPublisher(); publisher != nil {
+		publisher.BroadcastTick(symbol, dbTickData)
 	}
 }
 
-func (dc *DataCollector) updateCandles(tick models.Tick) {
+// ticksWritten counts a batch of ticks written, or failed to be
+func (dc *DataCollector) ticksWritten(ticks []database.TickData, err error) {
+	if err != nil {
+		log.Printf("❌ Failed to store %d ticks: %v", len(ticks), err)
+		dc.errors.Add(int64(len(ticks)))
+		metrics.RecordCollectorError(dc.name, "store_tick")
+		return
+	}
+
+	// From the exchange timestamp, so feed and batching delays count too
+	now := time.Now()
+	for _, tick := range ticks {
+		latency := now.Sub(tick.TickTimestamp)
+		dc.symbols.recordLatency(tick.Symbol, latency)
+		metrics.RecordTickLatency(dc.name, latency)
+	}
+}
+
+// positiveFloat returns a pointer to v, or nil if v isn't set
+func positiveFloat(v float64) *float64 {
+	if v <= 0 {
+		return nil
+	}
+	return &v
+}
+
+// positiveInt returns a pointer to v, or nil if v isn't set
+func positiveInt(v int64) *int64 {
+	if v <= 0 {
+		return nil
+	}
+	return &v
+}
+
+func (dc *DataCollector) updateCandles(tick Tick) {
 	dc.builderMu.RLock()
 	builder, exists := dc.candleBuilders[tick.InstrumentToken]
 	dc.builderMu.RUnlock()
@@ -317,81 +587,107 @@ func (dc *DataCollector) updateCandles(tick models.Tick) {
 	currentMinute := now.Truncate(time.Minute)
 
 	// Check if we need to start a new candle
-	if builder.CurrentTimestamp.IsZero() || !builder.CurrentTimestamp.Equal(currentMinute) {
-		// Flush old candle if exists
-		if !builder.CurrentTimestamp.IsZero() {
-			dc.flushCandle(builder)
-		}
+	newCandle := builder.CurrentTimestamp.IsZero() || !builder.CurrentTimestamp.Equal(currentMinute)
+	if newCandle {
+		// Complete the previous minute's candle
+		dc.completeCandle(dc.db, builder)
 
 		// Start new candle
-		builder.CurrentTimestamp = currentMinute
-		builder.CurrentOpen = tick.LastPrice
-		builder.CurrentHigh = tick.LastPrice
-		builder.CurrentLow = tick.LastPrice
-		builder.CurrentClose = tick.LastPrice
-		builder.CurrentVolume = int64(tick.LastTradedQuantity)
+		builder.start(currentMinute, tick.LastPrice, tick.LastQuantity, tick.OI)
 	} else {
-		// Update existing candle
-		if tick.LastPrice > builder.CurrentHigh {
-			builder.CurrentHigh = tick.LastPrice
-		}
-		if tick.LastPrice < builder.CurrentLow {
-			builder.CurrentLow = tick.LastPrice
+		builder.update(tick.LastPrice, tick.LastQuantity, tick.OI)
+	}
+
+	// Publish the forming candle, at most every candleUpdateInterval; the
+	// completed bar follows when the minute rolls over
+	if newCandle || now.Sub(builder.lastUpdate) >= candleUpdateInterval {
+		if publisher := dc.getPublisher(); publisher != nil {
+			publisher.BroadcastCandleUpdate(builder.Symbol, builder.bar())
 		}
-		builder.CurrentClose = tick.LastPrice
-		builder.CurrentVolume += int64(tick.LastTradedQuantity)
+		builder.lastUpdate = now
 	}
 }
 
-func (dc *DataCollector) flushCandle(builder *CandleBuilder) {
+// completeCandle stores in db, counts and publishes a builder's candle as a
+// completed bar, then clears it so the next tick starts a new one; callers
+// must hold builder.mu
+func (dc *DataCollector) completeCandle(db *database.Database, builder *CandleBuilder) {
 	if builder.CurrentTimestamp.IsZero() {
 		return
 	}
 
-	bar := &database.IntradayBar{
-		Exchange:        builder.Exchange,
-		Symbol:          builder.Symbol,
-		InstrumentToken: builder.InstrumentToken,
-		BarTimestamp:    builder.CurrentTimestamp,
-		Timeframe:       builder.Timeframe,
-		Open:            builder.CurrentOpen,
-		High:            builder.CurrentHigh,
-		Low:             builder.CurrentLow,
-		Close:           builder.CurrentClose,
-		Volume:          builder.CurrentVolume,
-		Source:          "zerodha_websocket",
+	bar := builder.bar()
+	builder.CurrentTimestamp = time.Time{}
+
+	if gate := dc.getQualityGate(); gate != nil && !gate.AcceptBar(bar) {
+		return
 	}
 
-	if err := dc.db.InsertIntradayBar(bar); err != nil {
+	if err := db.InsertIntradayBar(bar); err != nil {
 		log.Printf("❌ Failed to store bar: %v", err)
-		dc.errors++
+		dc.errors.Add(1)
+		metrics.RecordCollectorError(dc.name, "store_bar")
 	} else {
-		dc.barsCreated++
+		dc.barsCreated.Add(1)
+		dc.symbols.recordBar(bar.Symbol)
+		metrics.RecordBar(dc.name, bar.Timeframe)
+		metrics.RecordSymbolBar(dc.name, bar.Symbol)
+	}
+
+	// Stream it even if storing failed, live clients still want it
+	if publisher := dc.getPublisher(); publisher != nil {
+		publisher.BroadcastBar(bar.Symbol, bar)
 	}
 }
 
-func (dc *DataCollector) flushAllCandles() {
+// completeCandlesBefore completes the candles of minutes that started
+// before cutoff. A zero cutoff completes every candle, forming ones
+// included. Flushes that complete any candle are traced.
+func (dc *DataCollector) completeCandlesBefore(cutoff time.Time) {
 	dc.builderMu.RLock()
 	defer dc.builderMu.RUnlock()
 
+	var span trace.Span
+	db := dc.db
+	completed := 0
 	for _, builder := range dc.candleBuilders {
 		builder.mu.Lock()
-		dc.flushCandle(builder)
+		if !builder.CurrentTimestamp.IsZero() && (cutoff.IsZero() || builder.CurrentTimestamp.Before(cutoff)) {
+			if span == nil {
+				var ctx context.Context
+				ctx, span = tracing.Start(context.Background(), "collector.flush",
+					attribute.String("collector.name", dc.name))
+				db = dc.db.WithContext(ctx)
+			}
+			dc.completeCandle(db, builder)
+			completed++
+		}
 		builder.mu.Unlock()
 	}
 
-	log.Printf("💾 Flushed all candles")
+	if span != nil {
+		span.SetAttributes(attribute.Int("collector.bars", completed))
+		span.End()
+	}
 }
 
-func (dc *DataCollector) flushCandlesPeriodically() {
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
+func (dc *DataCollector) flushAllCandles() {
+	dc.completeCandlesBefore(time.Time{})
+	log.Printf("💾 Flushed all candles")
+}
 
+// flushCandlesPeriodically completes, just after every minute boundary, the
+// candles of symbols that haven't ticked since their minute ended. Candles
+// of symbols that keep ticking are completed by their next tick.
+func (dc *DataCollector) flushCandlesPeriodically(ctx context.Context) {
 	for {
+		now := time.Now()
+		wait := now.Truncate(time.Minute).Add(time.Minute).Sub(now)
+
 		select {
-		case <-ticker.C:
-			dc.flushAllCandles()
-		case <-dc.ctx.Done():
+		case <-time.After(wait):
+			dc.completeCandlesBefore(time.Now().Truncate(time.Minute))
+		case <-ctx.Done():
 			return
 		}
 	}
@@ -409,10 +705,49 @@ func (dc *DataCollector) GetMetrics() map[string]interface{} {
 	return map[string]interface{}{
 		"running":           dc.running,
 		"subscribed_tokens": len(dc.subscribedTokens),
-		"ticks_received":    dc.ticksReceived,
-		"bars_created":      dc.barsCreated,
-		"errors":            dc.errors,
+		"ticks_received":    dc.ticksReceived.Load(),
+		"bars_created":      dc.barsCreated.Load(),
+		"errors":            dc.errors.Load(),
+		"tick_latency":      dc.symbols.latencyStats(),
+	}
+}
+
+// SymbolMetrics returns the tick and bar counts, last tick and tick
+// latencies of each symbol, ordered by symbol
+func (dc *DataCollector) SymbolMetrics() []SymbolMetrics {
+	return dc.symbols.symbolMetrics()
+}
+
+// StartedAt returns when the collector was last started
+func (dc *DataCollector) StartedAt() time.Time {
+	dc.mu.RLock()
+	defer dc.mu.RUnlock()
+	return dc.startedAt
+}
+
+// Failure returns the error that stopped data from flowing, e.g. the tick
+// source giving up reconnecting, or nil
+func (dc *DataCollector) Failure() error {
+	dc.mu.RLock()
+	defer dc.mu.RUnlock()
+	return dc.failure
+}
+
+// LastTicks returns when each symbol last ticked
+func (dc *DataCollector) LastTicks() map[string]time.Time {
+	return dc.symbols.lastTicks()
+}
+
+// Connected reports whether the tick source is connected. Sources that
+// don't report their connection count as connected while the collector runs.
+func (dc *DataCollector) Connected() bool {
+	if !dc.IsRunning() {
+		return false
+	}
+	if reporter, ok := dc.source.(ConnectionReporter); ok {
+		return reporter.Connected()
 	}
+	return true
 }
 
 // IsRunning returns whether collector is active
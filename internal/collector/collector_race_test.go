@@ -0,0 +1,150 @@
+package collector
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// idleTickSource is a tick source that never connects to anything; tests
+// push ticks with FeedTick
+type idleTickSource struct{}
+
+func (idleTickSource) Name() string                               { return "test" }
+func (idleTickSource) Connect() error                             { return nil }
+func (idleTickSource) Close()                                     {}
+func (idleTickSource) Subscribe(tokens []uint32) error            { return nil }
+func (idleTickSource) Unsubscribe(tokens []uint32) error          { return nil }
+func (idleTickSource) SetMode(mode string, tokens []uint32) error { return nil }
+func (idleTickSource) OnTick(fn func(Tick))                       {}
+func (idleTickSource) OnConnect(fn func())                        {}
+func (idleTickSource) OnError(fn func(error))                     {}
+
+// noInstruments is an instrument lookup finding nothing
+func noInstruments(token uint32) (*database.Instrument, error) {
+	return nil, nil
+}
+
+// rejectingGate counts and rejects everything, keeping ticks and bars away
+// from the database
+type rejectingGate struct {
+	ticks, bars atomic.Int64
+}
+
+func (g *rejectingGate) AcceptBar(bar *database.IntradayBar) bool {
+	g.bars.Add(1)
+	return false
+}
+
+func (g *rejectingGate) AcceptTick(tick *database.TickData) bool {
+	g.ticks.Add(1)
+	return false
+}
+
+// TestDataCollectorConcurrentTicks feeds ticks and errors from many
+// goroutines while reading metrics, as a live feed does. Run it with
+// -race to catch unsynchronized collector state.
+func TestDataCollectorConcurrentTicks(t *testing.T) {
+	tests := []struct {
+		name       string
+		goroutines int
+		ticks      int // Per goroutine
+		errors     int // Per goroutine
+	}{
+		{name: "single feeder", goroutines: 1, ticks: 200, errors: 5},
+		{name: "many feeders", goroutines: 8, ticks: 250, errors: 10},
+	}
+
+	symbols := map[uint32]string{256265: "NIFTY", 738561: "RELIANCE", 2953217: "TCS"}
+	tokens := []uint32{256265, 738561, 2953217}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The gate turns everything away before it reaches the
+			// unconnected database
+			dc := NewDataCollectorWithSource(&database.Database{}, idleTickSource{})
+			dc.lookupInstrument = noInstruments
+			gate := &rejectingGate{}
+			dc.SetQualityGate(gate)
+			for token, symbol := range symbols {
+				dc.RegisterSymbol(token, "NSE", symbol)
+			}
+			if err := dc.Subscribe(tokens); err != nil {
+				t.Fatalf("Subscribe() error = %v", err)
+			}
+			if err := dc.Start(); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+
+			done := make(chan struct{})
+			var readers sync.WaitGroup
+			readers.Add(1)
+			go func() {
+				defer readers.Done()
+				for {
+					select {
+					case <-done:
+						return
+					default:
+					}
+					dc.GetMetrics()
+					dc.SymbolMetrics()
+					dc.LastTicks()
+					dc.GetSubscribedSymbols()
+					dc.SetMode(ModeQuote, tokens)
+				}
+			}()
+
+			var feeders sync.WaitGroup
+			for g := 0; g < tt.goroutines; g++ {
+				feeders.Add(1)
+				go func(g int) {
+					defer feeders.Done()
+					for i := 0; i < tt.ticks; i++ {
+						dc.FeedTick(Tick{
+							InstrumentToken: tokens[(g+i)%len(tokens)],
+							LastPrice:       100 + float64(i%10),
+							LastQuantity:    10,
+							Timestamp:       time.Now(),
+						})
+						if i < tt.errors {
+							dc.onError(errors.New("read timeout"))
+						}
+					}
+				}(g)
+			}
+			feeders.Wait()
+			dc.Stop()
+			close(done)
+			readers.Wait()
+
+			metrics := dc.GetMetrics()
+			wantTicks := int64(tt.goroutines * tt.ticks)
+			if got := metrics["ticks_received"]; got != wantTicks {
+				t.Errorf("ticks_received = %v, want %d", got, wantTicks)
+			}
+			if got := metrics["errors"]; got != int64(tt.goroutines*tt.errors) {
+				t.Errorf("errors = %v, want %d", got, tt.goroutines*tt.errors)
+			}
+			if got := gate.ticks.Load(); got != wantTicks {
+				t.Errorf("ticks checked by the gate = %d, want %d", got, wantTicks)
+			}
+
+			var symbolTicks int64
+			for _, m := range dc.SymbolMetrics() {
+				symbolTicks += m.Ticks
+			}
+			if symbolTicks != wantTicks {
+				t.Errorf("per-symbol ticks sum to %d, want %d", symbolTicks, wantTicks)
+			}
+			// One per symbol on stop, more if a minute ended meanwhile
+			if got := gate.bars.Load(); got < int64(len(tokens)) {
+				t.Errorf("bars completed = %d, want at least %d", got, len(tokens))
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package collector
+
+import "testing"
+
+func TestCandleBuilderOI(t *testing.T) {
+	tests := []struct {
+		name string
+		oi   int64
+		want *int64
+	}{
+		{name: "equity", oi: 0},
+		{name: "option", oi: 125000, want: func() *int64 { v := int64(125000); return &v }()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := &CandleBuilder{Symbol: "NIFTY24MAR22000CE", CurrentOI: tt.oi}
+			got := builder.bar().OI
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("bar OI = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,320 @@
+package collector
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// The Fyers market data socket speaks HSM, a binary protocol of big-endian
+// fields. Requests and responses start with [length uint16][type byte]
+// [field count byte], then each field is [id byte][length uint16][value].
+// Data feed frames carry one entry per instrument instead of fields.
+
+const fyersSocketURL = "wss://socket.fyers.in/hsm/v1-5/prod"
+
+// fyersChannel is the channel every subscription is made on
+const fyersChannel = 11
+
+// HSM request and response types
+const (
+	hsmAuth        byte = 1
+	hsmAck         byte = 3
+	hsmSubscribe   byte = 4
+	hsmUnsubscribe byte = 5
+	hsmDataFeed    byte = 6
+	hsmResume      byte = 8
+	hsmMode        byte = 12
+)
+
+// Kinds of data feed entries: a snapshot names the topic and carries every
+// value, updates and lite entries refer to it by topic ID
+const (
+	hsmSnapshot byte = 'S'
+	hsmUpdate   byte = 'U'
+	hsmLite     byte = 'L'
+)
+
+// hsmUnchanged marks the values an update leaves as they were
+const hsmUnchanged = math.MinInt32
+
+// Positions of the values used here in symbol ("sf|") topics, which carry
+// ltp, vol_traded_today, last_traded_time, exch_feed_time, bid_size,
+// ask_size, bid_price, ask_price, last_traded_qty, tot_buy_qty,
+// tot_sell_qty, avg_trade_price, OI, low, high, year high, year low, lower
+// and upper circuit, open and previous close
+const (
+	hsmLTP      = 0
+	hsmVolume   = 1
+	hsmFeedTime = 3
+	hsmBid      = 6
+	hsmAsk      = 7
+	hsmOI       = 12
+)
+
+// hsmIndexFeedTime is the position of exch_feed_time in index ("if|")
+// topics, which carry ltp, previous close, exch_feed_time, high, low and
+// open
+const hsmIndexFeedTime = 2
+
+// hsmSegments maps the first four digits of a Fyers token to its HSM
+// exchange segment
+var hsmSegments = map[string]string{
+	"1010": "nse_cm",
+	"1011": "nse_fo",
+	"1012": "cde_fo",
+	"1020": "nse_com",
+	"1120": "mcx_fo",
+	"1210": "bse_cm",
+	"1211": "bse_fo",
+	"1212": "bcs_fo",
+}
+
+// hsmIndexNames maps Fyers index tickers to the names HSM knows them by;
+// others go by their ticker
+var hsmIndexNames = map[string]string{
+	"NIFTY50":    "Nifty 50",
+	"NIFTYBANK":  "Nifty Bank",
+	"FINNIFTY":   "Nifty Fin Service",
+	"MIDCPNIFTY": "NIFTY MID SELECT",
+	"INDIAVIX":   "India VIX",
+	"NIFTYNXT50": "Nifty Next 50",
+	"NIFTYIT":    "Nifty IT",
+	"SENSEX":     "SENSEX",
+	"BANKEX":     "BANKEX",
+}
+
+var errHSMShort = errors.New("fyers data socket: short frame")
+
+// hsmTopic returns the HSM topic of a Fyers ticker ("NSE:RELIANCE-EQ") with
+// token ("10100000002885"): "sf|nse_cm|2885", or "if|nse_cm|Nifty 50" for
+// an index
+func hsmTopic(ticker, token string) (string, error) {
+	if len(token) <= 10 {
+		return "", fmt.Errorf("fyers data socket: bad token %q for %s", token, ticker)
+	}
+	segment, ok := hsmSegments[token[:4]]
+	if !ok {
+		return "", fmt.Errorf("fyers data socket: unknown segment %s of %s", token[:4], ticker)
+	}
+
+	name := ticker
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		name = name[i+1:]
+	}
+	if index, ok := strings.CutSuffix(name, "-INDEX"); ok {
+		if hsmName, ok := hsmIndexNames[index]; ok {
+			index = hsmName
+		}
+		return "if|" + segment + "|" + index, nil
+	}
+
+	return "sf|" + segment + "|" + token[10:], nil
+}
+
+// hsmRequest encodes a request of the given type, numbering its fields
+// from 1
+func hsmRequest(kind byte, fields ...[]byte) []byte {
+	size := 2
+	for _, field := range fields {
+		size += 3 + len(field)
+	}
+
+	buf := make([]byte, 0, 2+size)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(size))
+	buf = append(buf, kind, byte(len(fields)))
+	for i, field := range fields {
+		buf = append(buf, byte(i+1))
+		buf = binary.BigEndian.AppendUint16(buf, uint16(len(field)))
+		buf = append(buf, field...)
+	}
+	return buf
+}
+
+// hsmAuthRequest authenticates with the key from the access token
+func hsmAuthRequest(key, source string) []byte {
+	return hsmRequest(hsmAuth, []byte(key), []byte("P"), []byte{1}, []byte(source))
+}
+
+// hsmTopicsRequest subscribes (hsmSubscribe) or unsubscribes
+// (hsmUnsubscribe) topics on the channel
+func hsmTopicsRequest(kind byte, topics []string, channel byte) []byte {
+	list := binary.BigEndian.AppendUint16(nil, uint16(len(topics)))
+	for _, topic := range topics {
+		list = append(list, byte(len(topic)))
+		list = append(list, topic...)
+	}
+	return hsmRequest(kind, list, []byte{channel})
+}
+
+// hsmResumeRequest starts the channel's data feed
+func hsmResumeRequest(channel byte) []byte {
+	return hsmRequest(hsmResume, binary.BigEndian.AppendUint64(nil, 1<<channel))
+}
+
+// hsmModeRequest switches the channel between full entries and lite ones,
+// which carry the last price only
+func hsmModeRequest(channel byte, full bool) []byte {
+	mode := byte('L')
+	if full {
+		mode = 'F'
+	}
+	return hsmRequest(hsmMode, binary.BigEndian.AppendUint64(nil, 1<<channel), []byte{mode})
+}
+
+// hsmAckRequest acknowledges data feed frames up to msgNum
+func hsmAckRequest(msgNum uint32) []byte {
+	return hsmRequest(hsmAck, binary.BigEndian.AppendUint32(nil, msgNum))
+}
+
+// hsmFields decodes the fields of a response
+func hsmFields(frame []byte) ([][]byte, error) {
+	if len(frame) < 4 {
+		return nil, errHSMShort
+	}
+
+	count := int(frame[3])
+	fields := make([][]byte, 0, count)
+	offset := 4
+	for i := 0; i < count; i++ {
+		if len(frame) < offset+3 {
+			return nil, errHSMShort
+		}
+		size := int(binary.BigEndian.Uint16(frame[offset+1:]))
+		offset += 3
+		if len(frame) < offset+size {
+			return nil, errHSMShort
+		}
+		fields = append(fields, frame[offset:offset+size])
+		offset += size
+	}
+	return fields, nil
+}
+
+// hsmAuthResponse checks an auth response, returning how many data feed
+// frames may go unacknowledged; 0 means none need acknowledging
+func hsmAuthResponse(frame []byte) (uint32, error) {
+	fields, err := hsmFields(frame)
+	if err != nil {
+		return 0, err
+	}
+	if len(fields) == 0 || string(fields[0]) != "K" {
+		status := ""
+		if len(fields) > 0 {
+			status = string(fields[0])
+		}
+		return 0, fmt.Errorf("fyers data socket: authentication failed (%q)", status)
+	}
+
+	var ackCount uint32
+	if len(fields) > 1 && len(fields[1]) == 4 {
+		ackCount = binary.BigEndian.Uint32(fields[1])
+	}
+	return ackCount, nil
+}
+
+// hsmEntry is one instrument's entry in a data feed frame
+type hsmEntry struct {
+	kind    byte
+	topicID uint16
+	values  []int32 // hsmUnchanged where an update leaves the value
+
+	// Snapshots only
+	topic      string
+	multiplier uint16
+	precision  byte
+}
+
+// hsmFeed decodes a data feed frame
+func hsmFeed(frame []byte) (msgNum uint32, entries []hsmEntry, err error) {
+	if len(frame) < 9 {
+		return 0, nil, errHSMShort
+	}
+	msgNum = binary.BigEndian.Uint32(frame[3:])
+	count := int(binary.BigEndian.Uint16(frame[7:]))
+
+	r := hsmReader{buf: frame, offset: 9}
+	entries = make([]hsmEntry, 0, count)
+	for i := 0; i < count && r.err == nil; i++ {
+		entry := hsmEntry{kind: r.u8(), topicID: r.u16()}
+		switch entry.kind {
+		case hsmSnapshot:
+			entry.topic = r.str()
+			entry.values = r.int32s(int(r.u8()))
+			r.skip(2)
+			entry.multiplier = r.u16()
+			entry.precision = r.u8()
+			// Exchange, exchange token and symbol
+			for j := 0; j < 3; j++ {
+				r.str()
+			}
+		case hsmUpdate:
+			entry.values = r.int32s(int(r.u8()))
+		case hsmLite:
+			entry.values = r.int32s(1)
+		default:
+			return msgNum, entries, fmt.Errorf("fyers data socket: unknown entry kind %d", entry.kind)
+		}
+		if r.err == nil {
+			entries = append(entries, entry)
+		}
+	}
+	return msgNum, entries, r.err
+}
+
+// hsmReader reads big-endian values off a frame, recording the first
+// overrun in err
+type hsmReader struct {
+	buf    []byte
+	offset int
+	err    error
+}
+
+func (r *hsmReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.buf) < r.offset+n {
+		r.err = errHSMShort
+		return nil
+	}
+	b := r.buf[r.offset : r.offset+n]
+	r.offset += n
+	return b
+}
+
+func (r *hsmReader) skip(n int) {
+	r.next(n)
+}
+
+func (r *hsmReader) u8() byte {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *hsmReader) u16() uint16 {
+	if b := r.next(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *hsmReader) str() string {
+	return string(r.next(int(r.u8())))
+}
+
+func (r *hsmReader) int32s(n int) []int32 {
+	values := make([]int32, 0, n)
+	for i := 0; i < n; i++ {
+		b := r.next(4)
+		if b == nil {
+			return nil
+		}
+		values = append(values, int32(binary.BigEndian.Uint32(b)))
+	}
+	return values
+}
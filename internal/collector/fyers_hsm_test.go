@@ -0,0 +1,282 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// hsmFrame builds a data feed frame of the given entries
+func hsmFrame(msgNum uint32, entries ...[]byte) []byte {
+	frame := []byte{0, 0, hsmDataFeed}
+	frame = binary.BigEndian.AppendUint32(frame, msgNum)
+	frame = binary.BigEndian.AppendUint16(frame, uint16(len(entries)))
+	for _, entry := range entries {
+		frame = append(frame, entry...)
+	}
+	binary.BigEndian.PutUint16(frame, uint16(len(frame)-2))
+	return frame
+}
+
+func hsmValues(values []int32) []byte {
+	var b []byte
+	for _, value := range values {
+		b = binary.BigEndian.AppendUint32(b, uint32(value))
+	}
+	return b
+}
+
+// hsmSnapshotEntry builds a snapshot entry with precision 2
+func hsmSnapshotEntry(topicID uint16, topic string, values ...int32) []byte {
+	entry := []byte{hsmSnapshot}
+	entry = binary.BigEndian.AppendUint16(entry, topicID)
+	entry = append(entry, byte(len(topic)))
+	entry = append(entry, topic...)
+	entry = append(entry, byte(len(values)))
+	entry = append(entry, hsmValues(values)...)
+	entry = append(entry, 0, 0, 0, 1, 2)
+	for _, s := range []string{"NSE", "2885", "RELIANCE-EQ"} {
+		entry = append(entry, byte(len(s)))
+		entry = append(entry, s...)
+	}
+	return entry
+}
+
+func hsmUpdateEntry(topicID uint16, values ...int32) []byte {
+	entry := []byte{hsmUpdate}
+	entry = binary.BigEndian.AppendUint16(entry, topicID)
+	entry = append(entry, byte(len(values)))
+	return append(entry, hsmValues(values)...)
+}
+
+func hsmLiteEntry(topicID uint16, ltp int32) []byte {
+	entry := []byte{hsmLite}
+	entry = binary.BigEndian.AppendUint16(entry, topicID)
+	return append(entry, hsmValues([]int32{ltp})...)
+}
+
+// hsmSymbolValues returns symbol topic values with the given last price,
+// day volume, feed time, bid, ask and OI
+func hsmSymbolValues(ltp, volume, feedTime, bid, ask, oi int32) []int32 {
+	values := make([]int32, 21)
+	values[hsmLTP] = ltp
+	values[hsmVolume] = volume
+	values[hsmFeedTime] = feedTime
+	values[hsmBid] = bid
+	values[hsmAsk] = ask
+	values[hsmOI] = oi
+	return values
+}
+
+func TestHSMTopic(t *testing.T) {
+	tests := []struct {
+		ticker  string
+		token   string
+		want    string
+		wantErr bool
+	}{
+		{"NSE:RELIANCE-EQ", "10100000002885", "sf|nse_cm|2885", false},
+		{"NSE:NIFTY24DECFUT", "101124122635007", "sf|nse_fo|35007", false},
+		{"BSE:SBIN-A", "1210000000500112", "sf|bse_cm|500112", false},
+		{"NSE:NIFTY50-INDEX", "101000000026000", "if|nse_cm|Nifty 50", false},
+		{"BSE:SENSEX-INDEX", "121000000001", "if|bse_cm|SENSEX", false},
+		{"NSE:NEWINDEX-INDEX", "101000000026999", "if|nse_cm|NEWINDEX", false},
+		{"NSE:X-EQ", "9999000000001", "", true},
+		{"NSE:X-EQ", "1010", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ticker, func(t *testing.T) {
+			got, err := hsmTopic(tt.ticker, tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("hsmTopic error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("hsmTopic = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHSMRequests(t *testing.T) {
+	tests := []struct {
+		name    string
+		request []byte
+		want    []byte
+	}{
+		{
+			name:    "auth",
+			request: hsmAuthRequest("key", "src"),
+			want: []byte{
+				0, 22, hsmAuth, 4,
+				1, 0, 3, 'k', 'e', 'y',
+				2, 0, 1, 'P',
+				3, 0, 1, 1,
+				4, 0, 3, 's', 'r', 'c',
+			},
+		},
+		{
+			name:    "subscribe",
+			request: hsmTopicsRequest(hsmSubscribe, []string{"sf|nse_cm|22"}, fyersChannel),
+			want: append(append([]byte{
+				0, 24, hsmSubscribe, 2,
+				1, 0, 15, 0, 1, 12,
+			}, "sf|nse_cm|22"...), 2, 0, 1, fyersChannel),
+		},
+		{
+			name:    "full mode",
+			request: hsmModeRequest(fyersChannel, true),
+			want:    []byte{0, 17, hsmMode, 2, 1, 0, 8, 0, 0, 0, 0, 0, 0, 0x08, 0, 2, 0, 1, 'F'},
+		},
+		{
+			name:    "lite mode",
+			request: hsmModeRequest(fyersChannel, false),
+			want:    []byte{0, 17, hsmMode, 2, 1, 0, 8, 0, 0, 0, 0, 0, 0, 0x08, 0, 2, 0, 1, 'L'},
+		},
+		{
+			name:    "resume",
+			request: hsmResumeRequest(fyersChannel),
+			want:    []byte{0, 13, hsmResume, 1, 1, 0, 8, 0, 0, 0, 0, 0, 0, 0x08, 0},
+		},
+		{
+			name:    "ack",
+			request: hsmAckRequest(258),
+			want:    []byte{0, 9, hsmAck, 1, 1, 0, 4, 0, 0, 1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !bytes.Equal(tt.request, tt.want) {
+				t.Errorf("request = %v, want %v", tt.request, tt.want)
+			}
+		})
+	}
+}
+
+func TestHSMAuthResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		frame    []byte
+		ackCount uint32
+		wantErr  bool
+	}{
+		{"ok", []byte{0, 15, hsmAuth, 2, 1, 0, 1, 'K', 2, 0, 4, 0, 0, 0, 5}, 5, false},
+		{"ok without acks", []byte{0, 6, hsmAuth, 1, 1, 0, 1, 'K'}, 0, false},
+		{"rejected", []byte{0, 9, hsmAuth, 1, 1, 0, 4, 'F', 'A', 'I', 'L'}, 0, true},
+		{"short", []byte{0, 6, hsmAuth, 1, 1, 0, 5, 'K'}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ackCount, err := hsmAuthResponse(tt.frame)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("hsmAuthResponse error = %v, want error %v", err, tt.wantErr)
+			}
+			if ackCount != tt.ackCount {
+				t.Errorf("ack count = %d, want %d", ackCount, tt.ackCount)
+			}
+		})
+	}
+}
+
+func TestHSMFeed(t *testing.T) {
+	snapshot := hsmSymbolValues(245050, 1000, 1700000000, 245000, 245100, 0)
+	frame := hsmFrame(7,
+		hsmSnapshotEntry(3, "sf|nse_cm|2885", snapshot...),
+		hsmUpdateEntry(3, 245100, 1010, hsmUnchanged),
+		hsmLiteEntry(3, 245150),
+	)
+
+	msgNum, entries, err := hsmFeed(frame)
+	if err != nil {
+		t.Fatalf("hsmFeed: %v", err)
+	}
+	if msgNum != 7 {
+		t.Errorf("msgNum = %d, want 7", msgNum)
+	}
+
+	want := []hsmEntry{
+		{kind: hsmSnapshot, topicID: 3, values: snapshot, topic: "sf|nse_cm|2885", multiplier: 1, precision: 2},
+		{kind: hsmUpdate, topicID: 3, values: []int32{245100, 1010, hsmUnchanged}},
+		{kind: hsmLite, topicID: 3, values: []int32{245150}},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("entries = %+v, want %+v", entries, want)
+	}
+
+	if _, _, err := hsmFeed(frame[:len(frame)-2]); err == nil {
+		t.Error("truncated frame decoded without error")
+	}
+}
+
+func TestFyersTickSourceHandle(t *testing.T) {
+	feedTime := time.Date(2024, 1, 30, 4, 0, 0, 0, time.UTC)
+	ts := int32(feedTime.Unix())
+
+	tests := []struct {
+		name   string
+		frames [][]byte
+		want   []Tick
+	}{
+		{
+			name: "snapshot then updates",
+			frames: [][]byte{
+				hsmFrame(1, hsmSnapshotEntry(3, "sf|nse_cm|2885", hsmSymbolValues(245050, 1000, ts, 245000, 245100, 0)...)),
+				hsmFrame(2, hsmUpdateEntry(3, 245100, 1025, hsmUnchanged, ts+1)),
+				hsmFrame(3, hsmLiteEntry(3, 245150)),
+			},
+			want: []Tick{
+				{InstrumentToken: 738561, LastPrice: 2450.5, Volume: 1000, Bid: 2450, Ask: 2451, Timestamp: feedTime},
+				{InstrumentToken: 738561, LastPrice: 2451, LastQuantity: 25, Volume: 1025, Bid: 2450, Ask: 2451, Timestamp: feedTime.Add(time.Second)},
+				{InstrumentToken: 738561, LastPrice: 2451.5, Volume: 1025, Bid: 2450, Ask: 2451, Timestamp: feedTime.Add(time.Second)},
+			},
+		},
+		{
+			name: "index",
+			frames: [][]byte{
+				hsmFrame(1, hsmSnapshotEntry(4, "if|nse_cm|Nifty 50", 2150025, 2140000, ts, 2160000, 2130000, 2145000)),
+			},
+			want: []Tick{
+				{InstrumentToken: 256265, LastPrice: 21500.25, Timestamp: feedTime},
+			},
+		},
+		{
+			name: "unsubscribed and unknown topics",
+			frames: [][]byte{
+				hsmFrame(1, hsmSnapshotEntry(5, "sf|nse_cm|1594", hsmSymbolValues(150000, 10, ts, 0, 0, 0)...)),
+				hsmFrame(2, hsmUpdateEntry(9, 150100)),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := NewFyersTickSource(nil)
+			fs.topics = map[uint32]string{738561: "sf|nse_cm|2885", 256265: "if|nse_cm|Nifty 50", 408065: "sf|nse_cm|1594"}
+			fs.live = map[uint32]bool{738561: true, 256265: true}
+			fs.feeds = make(map[uint16]*fyersFeed)
+
+			var got []Tick
+			fs.OnTick(func(tick Tick) { got = append(got, tick) })
+			for _, frame := range tt.frames {
+				fs.handle(frame)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d ticks, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range tt.want {
+				if !got[i].Timestamp.Equal(tt.want[i].Timestamp) {
+					t.Errorf("tick %d timestamp = %v, want %v", i, got[i].Timestamp, tt.want[i].Timestamp)
+				}
+				got[i].Timestamp = tt.want[i].Timestamp
+				if got[i] != tt.want[i] {
+					t.Errorf("tick %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,577 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// fyersTokenBatch is the max symbols per Fyers symbol token call
+const fyersTokenBatch = 50
+
+const (
+	fyersPingInterval     = 10 * time.Second
+	fyersReconnectRetries = 10
+	fyersReconnectMaxWait = 60 * time.Second
+)
+
+// FyersTickSource streams ticks from the Fyers market data socket for
+// Fyers accounts. Instruments are subscribed by HSM topic, resolved from
+// their Fyers ticker through the symbol token API.
+type FyersTickSource struct {
+	broker *broker.FyersBroker
+	url    string
+
+	// token -> Fyers ticker ("NSE:RELIANCE-EQ")
+	symbols map[uint32]string
+	// token -> HSM topic ("sf|nse_cm|2885"), once resolved
+	topics     map[uint32]string
+	subscribed map[uint32]bool
+	full       bool
+
+	// Per connection: the tokens subscribed on it, its feeds by topic ID,
+	// and the data feed frames received since the last acknowledgement
+	conn     *websocket.Conn
+	live     map[uint32]bool
+	feeds    map[uint16]*fyersFeed
+	ackCount uint32
+	unacked  uint32
+	mu       sync.Mutex
+
+	writeMu   sync.Mutex
+	connected atomic.Bool
+
+	onTick    func(Tick)
+	onConnect func()
+	onError   func(error)
+
+	stop    chan struct{}
+	running bool
+}
+
+// fyersFeed is the last known state of a subscribed topic
+type fyersFeed struct {
+	token  uint32
+	index  bool
+	values []int32
+	scale  float64 // Divides prices
+
+	volume     int64
+	seenVolume bool
+}
+
+// NewFyersTickSource creates a tick source on the broker's session
+func NewFyersTickSource(brk *broker.FyersBroker) *FyersTickSource {
+	return &FyersTickSource{
+		broker:     brk,
+		url:        fyersSocketURL,
+		symbols:    make(map[uint32]string),
+		topics:     make(map[uint32]string),
+		subscribed: make(map[uint32]bool),
+		full:       true,
+	}
+}
+
+// RegisterSymbol maps a collector token to an exchange symbol
+func (fs *FyersTickSource) RegisterSymbol(token uint32, exchange, symbol string) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fs.symbols[token] = broker.FyersSymbol(exchange + ":" + symbol)
+}
+
+// OnTick sets the tick callback
+func (fs *FyersTickSource) OnTick(fn func(Tick)) {
+	fs.onTick = fn
+}
+
+// OnConnect sets the callback fired after every (re)connect
+func (fs *FyersTickSource) OnConnect(fn func()) {
+	fs.onConnect = fn
+}
+
+// OnError sets the error callback
+func (fs *FyersTickSource) OnError(fn func(error)) {
+	fs.onError = fn
+}
+
+// SetMode switches between full ticks and last price only (ltp). Fyers
+// sets the mode for the whole connection, so tokens is ignored.
+func (fs *FyersTickSource) SetMode(mode string, tokens []uint32) error {
+	fs.mu.Lock()
+	fs.full = mode != ModeLTP
+	full := fs.full
+	fs.mu.Unlock()
+
+	if !fs.connected.Load() {
+		return nil
+	}
+	return fs.send(hsmModeRequest(fyersChannel, full))
+}
+
+// Subscribe subscribes to instrument tokens; they are resubscribed on
+// every reconnect
+func (fs *FyersTickSource) Subscribe(tokens []uint32) error {
+	fs.mu.Lock()
+	for _, token := range tokens {
+		if _, ok := fs.symbols[token]; !ok {
+			log.Printf("⚠️  Fyers source: token %d has no registered symbol", token)
+			continue
+		}
+		fs.subscribed[token] = true
+	}
+	fs.mu.Unlock()
+
+	if !fs.connected.Load() {
+		return nil
+	}
+	return fs.subscribe(tokens)
+}
+
+// Unsubscribe unsubscribes from instrument tokens
+func (fs *FyersTickSource) Unsubscribe(tokens []uint32) error {
+	fs.mu.Lock()
+	topics := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		delete(fs.subscribed, token)
+		if fs.live[token] {
+			delete(fs.live, token)
+			topics = append(topics, fs.topics[token])
+		}
+	}
+	fs.mu.Unlock()
+
+	if len(topics) == 0 || !fs.connected.Load() {
+		return nil
+	}
+	return fs.send(hsmTopicsRequest(hsmUnsubscribe, topics, fyersChannel))
+}
+
+// Connect starts streaming in the background, reconnecting when the
+// socket drops
+func (fs *FyersTickSource) Connect() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.running {
+		return nil
+	}
+	fs.running = true
+	fs.stop = make(chan struct{})
+
+	go fs.serve(fs.stop)
+	return nil
+}
+
+// Name returns "fyers"
+func (fs *FyersTickSource) Name() string {
+	return "fyers"
+}
+
+// Close stops streaming
+func (fs *FyersTickSource) Close() {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if !fs.running {
+		return
+	}
+	fs.running = false
+	close(fs.stop)
+	if fs.conn != nil {
+		fs.conn.Close()
+	}
+	fs.connected.Store(false)
+
+	log.Println("🛑 Fyers tick source stopped")
+}
+
+// Connected reports whether the data socket is up
+func (fs *FyersTickSource) Connected() bool {
+	return fs.connected.Load()
+}
+
+// serve runs sessions until stopped, backing off between reconnects. It
+// gives up after fyersReconnectRetries failed attempts in a row, or at
+// once when the session has expired.
+func (fs *FyersTickSource) serve(stop chan struct{}) {
+	delay := time.Second
+	for attempt := 1; ; attempt++ {
+		authenticated, err := fs.session(stop)
+
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		if authenticated {
+			attempt, delay = 1, time.Second
+		}
+		log.Printf("🔌 Fyers data socket closed: %v", err)
+
+		if errors.Is(err, broker.ErrSessionExpired) {
+			fs.reportError(err)
+			return
+		}
+		if attempt > fyersReconnectRetries {
+			log.Printf("❌ Fyers reconnection failed after %d attempts", fyersReconnectRetries)
+			fs.reportError(ErrReconnectFailed)
+			return
+		}
+
+		log.Printf("🔄 Reconnecting to Fyers (attempt %d, delay %v)", attempt, delay)
+		select {
+		case <-time.After(delay):
+		case <-stop:
+			return
+		}
+		delay = min(2*delay, fyersReconnectMaxWait)
+	}
+}
+
+// session connects, authenticates and reads the data feed until the
+// socket fails or the source is closed
+func (fs *FyersTickSource) session(stop chan struct{}) (authenticated bool, err error) {
+	key, err := fs.broker.DataSocketKey()
+	if err != nil {
+		return false, err
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(fs.url, nil)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", broker.ErrBrokerUnavailable, err)
+	}
+	defer conn.Close()
+
+	fs.mu.Lock()
+	select {
+	case <-stop:
+		fs.mu.Unlock()
+		return false, nil
+	default:
+	}
+	fs.conn = conn
+	fs.live = make(map[uint32]bool)
+	fs.feeds = make(map[uint16]*fyersFeed)
+	fs.unacked = 0
+	fs.mu.Unlock()
+	defer fs.connected.Store(false)
+
+	if err := fs.send(hsmAuthRequest(key, "market-bridge")); err != nil {
+		return false, err
+	}
+	if err := fs.authenticate(conn); err != nil {
+		return false, err
+	}
+
+	fs.mu.Lock()
+	full := fs.full
+	tokens := make([]uint32, 0, len(fs.subscribed))
+	for token := range fs.subscribed {
+		tokens = append(tokens, token)
+	}
+	fs.mu.Unlock()
+
+	if err := fs.send(hsmModeRequest(fyersChannel, full)); err != nil {
+		return true, err
+	}
+	fs.connected.Store(true)
+	if err := fs.subscribe(tokens); err != nil {
+		fs.reportError(err)
+	}
+
+	log.Println("✅ Connected to Fyers data socket")
+	if fs.onConnect != nil {
+		fs.onConnect()
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go fs.ping(done)
+
+	for {
+		kind, frame, err := conn.ReadMessage()
+		if err != nil {
+			return true, err
+		}
+		// Text frames answer pings
+		if kind == websocket.BinaryMessage {
+			fs.handle(frame)
+		}
+	}
+}
+
+// authenticate reads the auth response
+func (fs *FyersTickSource) authenticate(conn *websocket.Conn) error {
+	conn.SetReadDeadline(time.Now().Add(fyersPingInterval))
+	defer conn.SetReadDeadline(time.Time{})
+
+	for {
+		kind, frame, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if kind != websocket.BinaryMessage || len(frame) < 3 || frame[2] != hsmAuth {
+			continue
+		}
+
+		ackCount, err := hsmAuthResponse(frame)
+		if err != nil {
+			return fmt.Errorf("%w: %v", broker.ErrSessionExpired, err)
+		}
+		fs.mu.Lock()
+		fs.ackCount = ackCount
+		fs.mu.Unlock()
+		return nil
+	}
+}
+
+// ping keeps the socket alive until done
+func (fs *FyersTickSource) ping(done chan struct{}) {
+	ticker := time.NewTicker(fyersPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := fs.sendText("ping"); err != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// subscribe resolves the tokens' topics and subscribes those not yet
+// subscribed on this connection, then resumes the channel
+func (fs *FyersTickSource) subscribe(tokens []uint32) error {
+	if err := fs.resolve(tokens); err != nil {
+		return err
+	}
+
+	fs.mu.Lock()
+	topics := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		topic, ok := fs.topics[token]
+		if !ok || !fs.subscribed[token] || fs.live[token] {
+			continue
+		}
+		fs.live[token] = true
+		topics = append(topics, topic)
+	}
+	fs.mu.Unlock()
+
+	if len(topics) == 0 {
+		return nil
+	}
+	if err := fs.send(hsmTopicsRequest(hsmSubscribe, topics, fyersChannel)); err != nil {
+		return err
+	}
+	return fs.send(hsmResumeRequest(fyersChannel))
+}
+
+// resolve looks up the topics of tokens that have none yet
+func (fs *FyersTickSource) resolve(tokens []uint32) error {
+	fs.mu.Lock()
+	tokenByTicker := make(map[string]uint32)
+	tickers := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		ticker, ok := fs.symbols[token]
+		if _, resolved := fs.topics[token]; !ok || resolved {
+			continue
+		}
+		tokenByTicker[ticker] = token
+		tickers = append(tickers, ticker)
+	}
+	fs.mu.Unlock()
+
+	for start := 0; start < len(tickers); start += fyersTokenBatch {
+		end := min(start+fyersTokenBatch, len(tickers))
+
+		fyTokens, err := fs.broker.SymbolTokens(tickers[start:end])
+		if err != nil {
+			return fmt.Errorf("failed to resolve fyers symbols: %w", err)
+		}
+
+		fs.mu.Lock()
+		for ticker, fyToken := range fyTokens {
+			token, ok := tokenByTicker[ticker]
+			if !ok {
+				continue
+			}
+			topic, err := hsmTopic(ticker, fyToken)
+			if err != nil {
+				log.Printf("⚠️  Fyers source: %v", err)
+				continue
+			}
+			fs.topics[token] = topic
+		}
+		fs.mu.Unlock()
+	}
+	return nil
+}
+
+// handle processes a binary frame from the socket
+func (fs *FyersTickSource) handle(frame []byte) {
+	if len(frame) < 3 || frame[2] != hsmDataFeed {
+		return
+	}
+
+	msgNum, entries, err := hsmFeed(frame)
+	if err != nil {
+		log.Printf("⚠️  Fyers source: %v", err)
+	}
+
+	fs.mu.Lock()
+	ticks := make([]Tick, 0, len(entries))
+	for _, entry := range entries {
+		if tick, ok := fs.apply(entry); ok {
+			ticks = append(ticks, tick)
+		}
+	}
+	ack := false
+	if fs.ackCount > 0 {
+		fs.unacked++
+		if fs.unacked >= fs.ackCount {
+			fs.unacked = 0
+			ack = true
+		}
+	}
+	fs.mu.Unlock()
+
+	if ack {
+		if err := fs.send(hsmAckRequest(msgNum)); err != nil {
+			log.Printf("⚠️  Fyers source: failed to acknowledge: %v", err)
+		}
+	}
+
+	if fs.onTick == nil {
+		return
+	}
+	for _, tick := range ticks {
+		fs.onTick(tick)
+	}
+}
+
+// apply merges an entry into its topic's feed and returns the resulting
+// tick. Must be called with fs.mu held.
+func (fs *FyersTickSource) apply(entry hsmEntry) (Tick, bool) {
+	feed, ok := fs.feeds[entry.topicID]
+	if entry.kind == hsmSnapshot {
+		token, known := fs.tokenOf(entry.topic)
+		if !known {
+			return Tick{}, false
+		}
+		scale := math.Pow10(int(entry.precision))
+		if entry.multiplier > 0 {
+			scale *= float64(entry.multiplier)
+		}
+		feed = &fyersFeed{
+			token:  token,
+			index:  len(entry.topic) > 3 && entry.topic[:3] == "if|",
+			values: entry.values,
+			scale:  scale,
+		}
+		fs.feeds[entry.topicID] = feed
+	} else if !ok {
+		return Tick{}, false
+	} else {
+		for i, value := range entry.values {
+			if i < len(feed.values) && value != hsmUnchanged {
+				feed.values[i] = value
+			}
+		}
+	}
+
+	return feed.tick(), true
+}
+
+// tokenOf returns the collector token subscribed to an HSM topic. Must be
+// called with fs.mu held.
+func (fs *FyersTickSource) tokenOf(topic string) (uint32, bool) {
+	for token, t := range fs.topics {
+		if t == topic && fs.live[token] {
+			return token, true
+		}
+	}
+	return 0, false
+}
+
+// tick builds a tick from the feed's values, taking the traded quantity
+// from the change in day volume; the first only sets the baseline
+func (feed *fyersFeed) tick() Tick {
+	value := func(i int) int32 {
+		if i >= len(feed.values) || feed.values[i] == hsmUnchanged {
+			return 0
+		}
+		return feed.values[i]
+	}
+	price := func(i int) float64 {
+		return float64(value(i)) / feed.scale
+	}
+
+	tick := Tick{
+		InstrumentToken: feed.token,
+		LastPrice:       price(hsmLTP),
+	}
+	feedTime := value(hsmIndexFeedTime)
+	if !feed.index {
+		volume := int64(value(hsmVolume))
+		if feed.seenVolume && volume > feed.volume {
+			tick.LastQuantity = volume - feed.volume
+		}
+		feed.volume, feed.seenVolume = volume, true
+
+		tick.Volume = volume
+		tick.OI = int64(value(hsmOI))
+		tick.Bid = price(hsmBid)
+		tick.Ask = price(hsmAsk)
+		feedTime = value(hsmFeedTime)
+	}
+
+	tick.Timestamp = time.Now()
+	if feedTime > 0 {
+		tick.Timestamp = time.Unix(int64(feedTime), 0)
+	}
+	return tick
+}
+
+// send writes a binary request to the socket
+func (fs *FyersTickSource) send(request []byte) error {
+	return fs.write(websocket.BinaryMessage, request)
+}
+
+// sendText writes a text message to the socket
+func (fs *FyersTickSource) sendText(text string) error {
+	return fs.write(websocket.TextMessage, []byte(text))
+}
+
+func (fs *FyersTickSource) write(kind int, data []byte) error {
+	fs.mu.Lock()
+	conn := fs.conn
+	fs.mu.Unlock()
+	if conn == nil {
+		return errors.New("fyers data socket is not connected")
+	}
+
+	fs.writeMu.Lock()
+	defer fs.writeMu.Unlock()
+	return conn.WriteMessage(kind, data)
+}
+
+func (fs *FyersTickSource) reportError(err error) {
+	log.Printf("❌ Fyers source error: %v", err)
+
+	if fs.onError != nil {
+		fs.onError(err)
+	}
+}
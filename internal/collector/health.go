@@ -0,0 +1,362 @@
+package collector
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// Collector health statuses
+const (
+	HealthHealthy  = "HEALTHY"  // Every symbol ticks
+	HealthDegraded = "DEGRADED" // Some symbols stopped ticking
+	HealthStalled  = "STALLED"  // No data at all, or the tick source gave up
+	HealthStopped  = "STOPPED"  // Not running
+	HealthIdle     = "IDLE"     // Running outside market hours, or nothing subscribed
+)
+
+// HealthConfig configures the collector health watchdog
+type HealthConfig struct {
+	Interval        time.Duration // How often collectors are checked
+	StaleAfter      time.Duration // A symbol without ticks this long is stale
+	StallAfter      time.Duration // A collector without any tick this long is stalled
+	AutoRestart     bool          // Restart stalled collectors, and stopped ones set to auto-start
+	RestartCooldown time.Duration // Minimum time between restarts of a collector
+	MarketOpen      func() bool   // Data is only expected while this returns true
+}
+
+// DefaultHealthConfig returns the watchdog defaults for NSE/BSE market hours
+func DefaultHealthConfig() HealthConfig {
+	return HealthConfig{
+		Interval:        30 * time.Second,
+		StaleAfter:      2 * time.Minute,
+		StallAfter:      5 * time.Minute,
+		RestartCooldown: 10 * time.Minute,
+		MarketOpen:      broker.IsIndianMarketOpen,
+	}
+}
+
+// SymbolHealth is when a symbol last ticked
+type SymbolHealth struct {
+	Symbol     string     `json:"symbol"`
+	LastTickAt *time.Time `json:"last_tick_at,omitempty"`
+	Stale      bool       `json:"stale"`
+}
+
+// CollectorHealth is the result of a collector health check
+type CollectorHealth struct {
+	Name          string         `json:"name"`
+	Type          string         `json:"type"`
+	Status        string         `json:"status"`
+	Reason        string         `json:"reason,omitempty"`
+	Running       bool           `json:"running"`
+	Connected     bool           `json:"connected"`
+	MarketOpen    bool           `json:"market_open"`
+	StartedAt     *time.Time     `json:"started_at,omitempty"`
+	LastTickAt    *time.Time     `json:"last_tick_at,omitempty"`
+	Symbols       []SymbolHealth `json:"symbols"`
+	StaleSymbols  int            `json:"stale_symbols"`
+	AutoStart     bool           `json:"auto_start"`
+	Restarts      int            `json:"restarts"`
+	LastRestartAt *time.Time     `json:"last_restart_at,omitempty"`
+	CheckedAt     time.Time      `json:"checked_at"`
+}
+
+// monitored is implemented by both collector kinds
+type monitored interface {
+	IsRunning() bool
+	Connected() bool
+	StartedAt() time.Time
+	Failure() error
+	LastTicks() map[string]time.Time
+	GetSubscribedSymbols() []string
+}
+
+// CheckHealth checks a collector's data flow now
+func (ucm *UnifiedCollectorManager) CheckHealth(name string) (*CollectorHealth, error) {
+	ucm.mu.RLock()
+	var collector monitored
+	collectorType := ""
+	if c, exists := ucm.realCollectors[name]; exists {
+		collector, collectorType = c, "real"
+	} else if c, exists := ucm.mockCollectors[name]; exists {
+		collector, collectorType = c, "mock"
+	}
+	ucm.mu.RUnlock()
+
+	if collector == nil {
+		return nil, fmt.Errorf("collector '%s' not found", name)
+	}
+
+	ucm.watchMu.Lock()
+	autoStart := ucm.autoStart[name]
+	ucm.watchMu.Unlock()
+
+	ucm.healthMu.Lock()
+	cfg := ucm.healthCfg
+	openedAt := ucm.marketOpenedAt
+	health := &CollectorHealth{
+		Name:      name,
+		Type:      collectorType,
+		AutoStart: autoStart,
+		Restarts:  ucm.restarts[name],
+		CheckedAt: time.Now(),
+	}
+	if at, ok := ucm.lastRestart[name]; ok {
+		health.LastRestartAt = &at
+	}
+	ucm.healthMu.Unlock()
+
+	health.MarketOpen = cfg.MarketOpen == nil || cfg.MarketOpen()
+	evaluateHealth(health, collector, openedAt, cfg)
+	return health, nil
+}
+
+// HealthReport checks every collector now, ordered by name
+func (ucm *UnifiedCollectorManager) HealthReport() []*CollectorHealth {
+	names := ucm.collectorNames()
+	sort.Strings(names)
+
+	report := make([]*CollectorHealth, 0, len(names))
+	for _, name := range names {
+		health, err := ucm.CheckHealth(name)
+		if err != nil {
+			continue // Deleted meanwhile
+		}
+		report = append(report, health)
+	}
+	return report
+}
+
+func (ucm *UnifiedCollectorManager) collectorNames() []string {
+	ucm.mu.RLock()
+	defer ucm.mu.RUnlock()
+	names := make([]string, 0, len(ucm.realCollectors)+len(ucm.mockCollectors))
+	for name := range ucm.realCollectors {
+		names = append(names, name)
+	}
+	for name := range ucm.mockCollectors {
+		names = append(names, name)
+	}
+	return names
+}
+
+// evaluateHealth fills in a collector's status. Staleness is measured from
+// the last tick, the collector start or the market open, whichever is
+// latest, so a collector isn't stale the moment the market opens.
+func evaluateHealth(health *CollectorHealth, collector monitored, openedAt time.Time, cfg HealthConfig) {
+	now := health.CheckedAt
+	health.Running = collector.IsRunning()
+	health.Connected = collector.Connected()
+
+	since := collector.StartedAt()
+	if !since.IsZero() {
+		startedAt := since
+		health.StartedAt = &startedAt
+	}
+	if openedAt.After(since) {
+		since = openedAt
+	}
+
+	lastTicks := collector.LastTicks()
+	symbols := collector.GetSubscribedSymbols()
+	sort.Strings(symbols)
+
+	var lastAny time.Time
+	health.Symbols = make([]SymbolHealth, 0, len(symbols))
+	for _, symbol := range symbols {
+		symbolHealth := SymbolHealth{Symbol: symbol}
+		last, ticked := lastTicks[symbol]
+		if ticked {
+			symbolHealth.LastTickAt = &last
+			if last.After(lastAny) {
+				lastAny = last
+			}
+		}
+
+		reference := since
+		if last.After(reference) {
+			reference = last
+		}
+		if health.Running && health.MarketOpen && now.Sub(reference) > cfg.StaleAfter {
+			symbolHealth.Stale = true
+			health.StaleSymbols++
+		}
+		health.Symbols = append(health.Symbols, symbolHealth)
+	}
+	if !lastAny.IsZero() {
+		health.LastTickAt = &lastAny
+	}
+
+	switch {
+	case !health.Running:
+		health.Status = HealthStopped
+	case !health.MarketOpen:
+		health.Status = HealthIdle
+		health.Reason = "market closed"
+	case len(symbols) == 0:
+		health.Status = HealthIdle
+		health.Reason = "no symbols subscribed"
+	case collector.Failure() != nil:
+		health.Status = HealthStalled
+		health.Reason = collector.Failure().Error()
+	default:
+		reference := since
+		if lastAny.After(reference) {
+			reference = lastAny
+		}
+		quiet := now.Sub(reference)
+
+		if quiet > cfg.StallAfter {
+			health.Status = HealthStalled
+			health.Reason = fmt.Sprintf("no ticks for %s", quiet.Truncate(time.Second))
+		} else if health.StaleSymbols > 0 {
+			health.Status = HealthDegraded
+			health.Reason = fmt.Sprintf("%d of %d symbols without ticks for %s",
+				health.StaleSymbols, len(symbols), cfg.StaleAfter)
+		} else {
+			health.Status = HealthHealthy
+		}
+	}
+}
+
+// StartHealthWatchdog checks every collector each cfg.Interval until
+// StopAll. Collectors turning STALLED are logged and reported to the error
+// handler; with cfg.AutoRestart they are restarted, as are stopped
+// collectors set to auto-start.
+func (ucm *UnifiedCollectorManager) StartHealthWatchdog(cfg HealthConfig) {
+	defaults := DefaultHealthConfig()
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaults.Interval
+	}
+	if cfg.StaleAfter <= 0 {
+		cfg.StaleAfter = defaults.StaleAfter
+	}
+	if cfg.StallAfter <= 0 {
+		cfg.StallAfter = defaults.StallAfter
+	}
+	if cfg.RestartCooldown <= 0 {
+		cfg.RestartCooldown = defaults.RestartCooldown
+	}
+	if cfg.MarketOpen == nil {
+		cfg.MarketOpen = defaults.MarketOpen
+	}
+
+	ucm.healthMu.Lock()
+	defer ucm.healthMu.Unlock()
+	if ucm.stopHealth != nil {
+		return
+	}
+	ucm.healthCfg = cfg
+	stop := make(chan struct{})
+	ucm.stopHealth = stop
+
+	go func() {
+		ticker := time.NewTicker(cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				ucm.checkAllHealth()
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	log.Printf("🩺 Collector health watchdog started (every %s, stale after %s, stalled after %s, auto-restart: %v)",
+		cfg.Interval, cfg.StaleAfter, cfg.StallAfter, cfg.AutoRestart)
+}
+
+func (ucm *UnifiedCollectorManager) stopHealthWatchdog() {
+	ucm.healthMu.Lock()
+	defer ucm.healthMu.Unlock()
+	if ucm.stopHealth != nil {
+		close(ucm.stopHealth)
+		ucm.stopHealth = nil
+	}
+}
+
+// checkAllHealth runs one watchdog pass
+func (ucm *UnifiedCollectorManager) checkAllHealth() {
+	ucm.healthMu.Lock()
+	cfg := ucm.healthCfg
+	marketOpen := cfg.MarketOpen()
+	if marketOpen && !ucm.marketWasOpen {
+		ucm.marketOpenedAt = time.Now()
+	}
+	ucm.marketWasOpen = marketOpen
+	ucm.healthMu.Unlock()
+
+	names := ucm.collectorNames()
+	ucm.mu.RLock()
+	onError := ucm.errorHandler
+	ucm.mu.RUnlock()
+
+	for _, name := range names {
+		health, err := ucm.CheckHealth(name)
+		if err != nil {
+			continue // Deleted meanwhile
+		}
+
+		ucm.healthMu.Lock()
+		previous := ucm.lastStatus[name]
+		ucm.lastStatus[name] = health.Status
+		ucm.healthMu.Unlock()
+
+		if health.Status != previous && previous != "" {
+			log.Printf("🩺 Collector %s: %s -> %s %s", name, previous, health.Status, health.Reason)
+			if health.Status == HealthStalled && onError != nil {
+				onError(name, fmt.Errorf("collector stalled: %s", health.Reason))
+			}
+		}
+
+		if cfg.AutoRestart && needsRestart(health) {
+			ucm.autoRestart(name, health, cfg.RestartCooldown)
+		}
+	}
+}
+
+// needsRestart reports whether the watchdog should restart a collector
+func needsRestart(health *CollectorHealth) bool {
+	if !health.MarketOpen {
+		return false
+	}
+	return health.Status == HealthStalled || (health.Status == HealthStopped && health.AutoStart)
+}
+
+// autoRestart restarts a collector unless it was restarted within cooldown.
+// It doesn't change whether the collector starts on boot.
+func (ucm *UnifiedCollectorManager) autoRestart(name string, health *CollectorHealth, cooldown time.Duration) {
+	if health.LastRestartAt != nil && time.Since(*health.LastRestartAt) < cooldown {
+		return
+	}
+
+	ucm.healthMu.Lock()
+	ucm.restarts[name]++
+	ucm.lastRestart[name] = time.Now()
+	ucm.healthMu.Unlock()
+
+	log.Printf("🔁 Restarting collector %s (%s)", name, health.Status)
+	if health.Running {
+		if err := ucm.stopCollector(name); err != nil {
+			log.Printf("⚠️  Failed to stop collector %s: %v", name, err)
+			return
+		}
+	}
+	if err := ucm.startCollector(name); err != nil {
+		log.Printf("❌ Failed to restart collector %s: %v", name, err)
+	}
+}
+
+// forgetHealth drops a deleted collector's watchdog state
+func (ucm *UnifiedCollectorManager) forgetHealth(name string) {
+	ucm.healthMu.Lock()
+	defer ucm.healthMu.Unlock()
+	delete(ucm.restarts, name)
+	delete(ucm.lastRestart, name)
+	delete(ucm.lastStatus, name)
+}
@@ -0,0 +1,108 @@
+package collector
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeCollector struct {
+	running   bool
+	connected bool
+	startedAt time.Time
+	failure   error
+	lastTicks map[string]time.Time
+	symbols   []string
+}
+
+func (f *fakeCollector) IsRunning() bool                 { return f.running }
+func (f *fakeCollector) Connected() bool                 { return f.connected }
+func (f *fakeCollector) StartedAt() time.Time            { return f.startedAt }
+func (f *fakeCollector) Failure() error                  { return f.failure }
+func (f *fakeCollector) LastTicks() map[string]time.Time { return f.lastTicks }
+func (f *fakeCollector) GetSubscribedSymbols() []string  { return append([]string(nil), f.symbols...) }
+
+func TestEvaluateHealth(t *testing.T) {
+	now := time.Date(2026, 3, 2, 11, 0, 0, 0, time.UTC)
+	cfg := HealthConfig{StaleAfter: 2 * time.Minute, StallAfter: 5 * time.Minute}
+	started := now.Add(-time.Hour)
+
+	tests := []struct {
+		name       string
+		collector  *fakeCollector
+		marketOpen bool
+		status     string
+		stale      int
+		connected  bool
+	}{
+		{
+			name:       "stopped",
+			collector:  &fakeCollector{symbols: []string{"INFY"}},
+			marketOpen: true,
+			status:     HealthStopped,
+		},
+		{
+			name:       "market closed",
+			collector:  &fakeCollector{running: true, connected: true, startedAt: started, symbols: []string{"INFY"}},
+			marketOpen: false,
+			status:     HealthIdle,
+			connected:  true,
+		},
+		{
+			name:       "nothing subscribed",
+			collector:  &fakeCollector{running: true, connected: true, startedAt: started},
+			marketOpen: true,
+			status:     HealthIdle,
+			connected:  true,
+		},
+		{
+			name: "every symbol ticking",
+			collector: &fakeCollector{running: true, connected: true, startedAt: started, symbols: []string{"INFY", "TCS"},
+				lastTicks: map[string]time.Time{"INFY": now.Add(-time.Second), "TCS": now.Add(-time.Second)}},
+			marketOpen: true,
+			status:     HealthHealthy,
+			connected:  true,
+		},
+		{
+			name: "one symbol stale",
+			collector: &fakeCollector{running: true, connected: true, startedAt: started, symbols: []string{"INFY", "TCS"},
+				lastTicks: map[string]time.Time{"INFY": now.Add(-time.Second), "TCS": now.Add(-3 * time.Minute)}},
+			marketOpen: true,
+			status:     HealthDegraded,
+			stale:      1,
+			connected:  true,
+		},
+		{
+			name: "no ticks at all",
+			collector: &fakeCollector{running: true, startedAt: started, symbols: []string{"INFY"},
+				lastTicks: map[string]time.Time{"INFY": now.Add(-10 * time.Minute)}},
+			marketOpen: true,
+			status:     HealthStalled,
+			stale:      1,
+		},
+		{
+			name: "source gave up",
+			collector: &fakeCollector{running: true, startedAt: started, symbols: []string{"INFY"},
+				failure: errors.New("reconnect failed"), lastTicks: map[string]time.Time{"INFY": now.Add(-time.Second)}},
+			marketOpen: true,
+			status:     HealthStalled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health := &CollectorHealth{Name: "test", CheckedAt: now, MarketOpen: tt.marketOpen}
+			evaluateHealth(health, tt.collector, time.Time{}, cfg)
+
+			if health.Status != tt.status {
+				t.Errorf("status = %s, want %s (%s)", health.Status, tt.status, health.Reason)
+			}
+			if health.StaleSymbols != tt.stale {
+				t.Errorf("stale symbols = %d, want %d", health.StaleSymbols, tt.stale)
+			}
+			if health.Connected != tt.connected {
+				t.Errorf("connected = %v, want %v", health.Connected, tt.connected)
+			}
+		})
+	}
+}
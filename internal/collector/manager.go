@@ -34,6 +34,7 @@ func (cm *CollectorManager) CreateCollector(name, apiKey, accessToken string) (*
 	}
 
 	collector := NewDataCollector(cm.db, apiKey, accessToken)
+	collector.SetName(name)
 	cm.collectors[name] = collector
 
 	log.Printf("✅ Created collector: %s", name)
@@ -121,19 +122,14 @@ func (cm *CollectorManager) SubscribeSymbols(collectorName string, symbols []str
 	// Get instrument tokens from database
 	tokens := []uint32{}
 	for _, symbol := range symbols {
-		// Try NSE first
-		token, err := cm.db.GetInstrumentToken("NSE", symbol)
-		if err != nil || token == 0 {
-			// Try BSE
-			token, err = cm.db.GetInstrumentToken("BSE", symbol)
-			if err != nil || token == 0 {
-				log.Printf("⚠️  Symbol not found: %s", symbol)
-				continue
-			}
+		token, exchange, stored, ok := resolveInstrument(cm.db, symbol)
+		if !ok {
+			log.Printf("⚠️  Symbol not found: %s", symbol)
+			continue
 		}
 
 		tokens = append(tokens, token)
-		collector.RegisterSymbol(token, "NSE", symbol)
+		collector.RegisterSymbol(token, exchange, stored)
 	}
 
 	if len(tokens) == 0 {
@@ -152,14 +148,9 @@ func (cm *CollectorManager) UnsubscribeSymbols(collectorName string, symbols []s
 
 	tokens := []uint32{}
 	for _, symbol := range symbols {
-		token, err := cm.db.GetInstrumentToken("NSE", symbol)
-		if err != nil || token == 0 {
-			token, err = cm.db.GetInstrumentToken("BSE", symbol)
-			if err != nil || token == 0 {
-				continue
-			}
+		if token, _, _, ok := resolveInstrument(cm.db, symbol); ok {
+			tokens = append(tokens, token)
 		}
-		tokens = append(tokens, token)
 	}
 
 	return collector.Unsubscribe(tokens)
@@ -4,12 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"math/rand"
 	"sync"
 	"time"
 
 	"github.com/trading-chitti/market-bridge/internal/database"
 	"github.com/trading-chitti/market-bridge/internal/metrics"
+	"github.com/trading-chitti/market-bridge/internal/timeframe"
 )
 
 // MockDataCollector generates fake market data for testing
@@ -31,10 +31,17 @@ type MockDataCollector struct {
 	errors         int64
 	startedAt      time.Time
 	lastTickAt     time.Time
+	symbolStats    *symbolTracker // Last ticks feed the health watchdog
 
-	// Price tracking for realistic movements
-	basePrices     map[string]float64
+	// Price path of each symbol, generated from config; both guarded by
+	// pricesMu
+	prices         map[string]*mockPrice
+	config         MockConfig
 	pricesMu       sync.RWMutex
+
+	// Receives generated ticks and bars
+	publisher      Publisher
+	qualityGate    QualityGate
 }
 
 // NewMockDataCollector creates a new mock data collector
@@ -42,16 +49,26 @@ func NewMockDataCollector(db *database.Database, name string, symbols []string)
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &MockDataCollector{
-		db:         db,
-		name:       name,
-		symbols:    symbols,
-		mode:       "full",
-		ctx:        ctx,
-		cancel:     cancel,
-		basePrices: make(map[string]float64),
+		db:          db,
+		name:        name,
+		symbols:     symbols,
+		mode:        "full",
+		ctx:         ctx,
+		cancel:      cancel,
+		prices:      make(map[string]*mockPrice),
+		config:      DefaultMockConfig(),
+		symbolStats: newSymbolTracker(),
 	}
 }
 
+// SetConfig sets how prices and volumes are generated. Paths already
+// started keep their seed and script position.
+func (mc *MockDataCollector) SetConfig(cfg MockConfig) {
+	mc.pricesMu.Lock()
+	defer mc.pricesMu.Unlock()
+	mc.config = cfg
+}
+
 // Start begins mock data generation
 func (mc *MockDataCollector) Start() error {
 	mc.mu.Lock()
@@ -61,16 +78,20 @@ func (mc *MockDataCollector) Start() error {
 	}
 	mc.running = true
 	mc.startedAt = time.Now()
+
+	// A stopped collector's context is cancelled, so restarts need a new one
+	mc.ctx, mc.cancel = context.WithCancel(context.Background())
+	ctx := mc.ctx
 	mc.mu.Unlock()
 
 	// Initialize base prices for symbols
 	mc.initializeBasePrices()
 
 	// Start tick generation
-	go mc.generateTicks()
+	go mc.generateTicks(ctx)
 
 	// Start bar aggregation (every minute)
-	go mc.aggregateBars()
+	go mc.aggregateBars(ctx)
 
 	log.Printf("✅ Mock collector '%s' started with %d symbols", mc.name, len(mc.symbols))
 	return nil
@@ -98,6 +119,12 @@ func (mc *MockDataCollector) IsRunning() bool {
 	return mc.running
 }
 
+// Connected reports whether the collector is running; the mock has no
+// connection to lose
+func (mc *MockDataCollector) Connected() bool {
+	return mc.IsRunning()
+}
+
 // GetMetrics returns collector metrics
 func (mc *MockDataCollector) GetMetrics() map[string]interface{} {
 	mc.mu.RLock()
@@ -108,6 +135,16 @@ func (mc *MockDataCollector) GetMetrics() map[string]interface{} {
 		uptime = int64(time.Since(mc.startedAt).Seconds())
 	}
 
+	mc.pricesMu.RLock()
+	regimes := make(map[string]string, len(mc.prices))
+	for symbol, p := range mc.prices {
+		if p.regime.Name != "" {
+			regimes[symbol] = p.regime.Name
+		}
+	}
+	marketHoursOnly := mc.config.MarketHoursOnly
+	mc.pricesMu.RUnlock()
+
 	return map[string]interface{}{
 		"running":           mc.running,
 		"symbols":           mc.symbols,
@@ -118,9 +155,56 @@ func (mc *MockDataCollector) GetMetrics() map[string]interface{} {
 		"uptime_seconds":    uptime,
 		"started_at":        mc.startedAt,
 		"last_tick_at":      mc.lastTickAt,
+		"market_hours_only": marketHoursOnly,
+		"regimes":           regimes,
+		"tick_latency":      mc.symbolStats.latencyStats(),
 	}
 }
 
+// SymbolMetrics returns the tick and bar counts, last tick and tick
+// latencies of each symbol, ordered by symbol
+func (mc *MockDataCollector) SymbolMetrics() []SymbolMetrics {
+	return mc.symbolStats.symbolMetrics()
+}
+
+// StartedAt returns when the collector was last started
+func (mc *MockDataCollector) StartedAt() time.Time {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	return mc.startedAt
+}
+
+// Failure always returns nil: generated data can't stop flowing on its own
+func (mc *MockDataCollector) Failure() error {
+	return nil
+}
+
+// LastTicks returns when each symbol last ticked
+func (mc *MockDataCollector) LastTicks() map[string]time.Time {
+	return mc.symbolStats.lastTicks()
+}
+
+// GetSubscribedSymbols returns the symbols data is generated for
+func (mc *MockDataCollector) GetSubscribedSymbols() []string {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	return append([]string{}, mc.symbols...)
+}
+
+// SetPublisher sets where generated ticks and bars are published
+func (mc *MockDataCollector) SetPublisher(p Publisher) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.publisher = p
+}
+
+// SetQualityGate sets the checks generated ticks and bars must pass to be stored
+func (mc *MockDataCollector) SetQualityGate(gate QualityGate) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.qualityGate = gate
+}
+
 // AddSymbols adds symbols to collection
 func (mc *MockDataCollector) AddSymbols(symbols []string) {
 	mc.mu.Lock()
@@ -194,27 +278,22 @@ func (mc *MockDataCollector) initializeBasePrices() {
 	}
 
 	for _, symbol := range mc.symbols {
-		if _, exists := mc.basePrices[symbol]; !exists {
+		if _, exists := mc.prices[symbol]; !exists {
 			// Use known price if available, otherwise generate random
-			if price, ok := knownPrices[symbol]; ok {
-				mc.basePrices[symbol] = price
-			} else {
-				// Random price between 100 and 5000
-				mc.basePrices[symbol] = 100 + rand.Float64()*4900
-			}
+			mc.prices[symbol] = newMockPrice(symbol, knownPrices[symbol], mc.config)
 		}
 	}
 }
 
 // generateTicks generates fake tick data
-func (mc *MockDataCollector) generateTicks() {
-	// Generate a tick every 1-3 seconds for each symbol
-	ticker := time.NewTicker(1 * time.Second)
+func (mc *MockDataCollector) generateTicks(ctx context.Context) {
+	// Generate a tick every second for each symbol
+	ticker := time.NewTicker(mockTickInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
-		case <-mc.ctx.Done():
+		case <-ctx.Done():
 			return
 		case <-ticker.C:
 			mc.mu.RLock()
@@ -225,6 +304,9 @@ func (mc *MockDataCollector) generateTicks() {
 			if mode == "bars_only" {
 				continue // Skip tick generation in bars_only mode
 			}
+			if !mc.generating() {
+				continue
+			}
 
 			// Generate tick for each symbol
 			for _, symbol := range symbols {
@@ -233,26 +315,41 @@ func (mc *MockDataCollector) generateTicks() {
 					mc.mu.Lock()
 					mc.errors++
 					mc.mu.Unlock()
+					metrics.RecordCollectorError(mc.name, "store_tick")
 				}
 			}
 		}
 	}
 }
 
-// generateTickForSymbol generates a single tick for a symbol
-func (mc *MockDataCollector) generateTickForSymbol(symbol string) error {
+// generating reports whether ticks are generated now: always, or only in
+// market hours if so configured
+func (mc *MockDataCollector) generating() bool {
 	mc.pricesMu.RLock()
-	basePrice := mc.basePrices[symbol]
+	cfg := mc.config
 	mc.pricesMu.RUnlock()
 
-	// Generate realistic price movement (±0.5%)
-	priceChange := (rand.Float64() - 0.5) * basePrice * 0.01
-	currentPrice := basePrice + priceChange
+	return !cfg.MarketHoursOnly || cfg.MarketOpen == nil || cfg.MarketOpen()
+}
 
-	// Update base price slowly to simulate trends
+// generateTickForSymbol generates a single tick for a symbol
+func (mc *MockDataCollector) generateTickForSymbol(symbol string) error {
+	now := time.Now()
+
+	// Play the symbol's script, or move its price in its current regime
+	// and session trend, trading more near the open and close
 	mc.pricesMu.Lock()
-	mc.basePrices[symbol] = mc.basePrices[symbol]*0.99 + currentPrice*0.01
+	p, ok := mc.prices[symbol]
+	if !ok {
+		mc.pricesMu.Unlock()
+		return nil // Removed meanwhile
+	}
+	priceChange, quantity, ok := p.next(now, mc.config, mc.config.Script[symbol])
+	currentPrice := p.price
 	mc.pricesMu.Unlock()
+	if !ok {
+		return nil // Script played out
+	}
 
 	// Determine trade type based on price movement
 	tradeType := "buy"
@@ -260,19 +357,23 @@ func (mc *MockDataCollector) generateTickForSymbol(symbol string) error {
 		tradeType = "sell"
 	}
 
-	// Generate random quantity
-	quantity := int64(rand.Intn(900) + 100) // 100-1000
-
 	tick := &database.TickData{
 		Exchange:      "NSE",
 		Symbol:        symbol,
-		TickTimestamp: time.Now(),
+		TickTimestamp: now,
 		Price:         currentPrice,
 		Quantity:      quantity,
 		TradeType:     tradeType,
 		Source:        fmt.Sprintf("mock_%s", mc.name),
 	}
 
+	mc.mu.RLock()
+	gate := mc.qualityGate
+	mc.mu.RUnlock()
+	if gate != nil && !gate.AcceptTick(tick) {
+		return nil
+	}
+
 	if err := mc.db.InsertTickData(tick); err != nil {
 		return err
 	}
@@ -280,16 +381,26 @@ func (mc *MockDataCollector) generateTickForSymbol(symbol string) error {
 	mc.mu.Lock()
 	mc.ticksGenerated++
 	mc.lastTickAt = time.Now()
+	publisher := mc.publisher
 	mc.mu.Unlock()
 
+	latency := mc.lastTickAt.Sub(now)
+	mc.symbolStats.recordTick(symbol, mc.lastTickAt)
+	mc.symbolStats.recordLatency(symbol, latency)
+
+	if publisher != nil {
+		publisher.BroadcastTick(symbol, tick)
+	}
+
 	// Record metrics
-	metrics.RecordTick(mc.name, symbol)
+	metrics.RecordTick(mc.name, symbol, now)
+	metrics.RecordTickLatency(mc.name, latency)
 
 	return nil
 }
 
 // aggregateBars aggregates ticks into 1-minute bars
-func (mc *MockDataCollector) aggregateBars() {
+func (mc *MockDataCollector) aggregateBars(ctx context.Context) {
 	// Wait for the next minute boundary to start
 	now := time.Now()
 	nextMinute := now.Truncate(time.Minute).Add(time.Minute)
@@ -303,7 +414,7 @@ func (mc *MockDataCollector) aggregateBars() {
 
 	for {
 		select {
-		case <-mc.ctx.Done():
+		case <-ctx.Done():
 			return
 		case <-ticker.C:
 			mc.mu.RLock()
@@ -317,6 +428,7 @@ func (mc *MockDataCollector) aggregateBars() {
 					mc.mu.Lock()
 					mc.errors++
 					mc.mu.Unlock()
+					metrics.RecordCollectorError(mc.name, "store_bar")
 				}
 			}
 		}
@@ -329,7 +441,7 @@ func (mc *MockDataCollector) aggregateBarForSymbol(symbol string) error {
 	oneMinuteAgo := now.Add(-1 * time.Minute)
 
 	// Get ticks from the last minute
-	ticks, err := mc.db.GetTickData(symbol, oneMinuteAgo, now, 10000)
+	ticks, err := mc.db.GetTickData("NSE", symbol, oneMinuteAgo, now, 10000)
 	if err != nil {
 		return fmt.Errorf("failed to get ticks: %w", err)
 	}
@@ -362,7 +474,7 @@ func (mc *MockDataCollector) aggregateBarForSymbol(symbol string) error {
 		Exchange:     "NSE",
 		Symbol:       symbol,
 		BarTimestamp: oneMinuteAgo,
-		Timeframe:    "1m",
+		Timeframe:    timeframe.Minute1.String(),
 		Open:         open,
 		High:         high,
 		Low:          low,
@@ -372,16 +484,30 @@ func (mc *MockDataCollector) aggregateBarForSymbol(symbol string) error {
 		Source:       fmt.Sprintf("mock_%s", mc.name),
 	}
 
+	mc.mu.RLock()
+	gate := mc.qualityGate
+	mc.mu.RUnlock()
+	if gate != nil && !gate.AcceptBar(bar) {
+		return nil
+	}
+
 	if err := mc.db.InsertIntradayBar(bar); err != nil {
 		return fmt.Errorf("failed to insert bar: %w", err)
 	}
 
 	mc.mu.Lock()
 	mc.barsGenerated++
+	publisher := mc.publisher
 	mc.mu.Unlock()
 
+	if publisher != nil {
+		publisher.BroadcastBar(symbol, bar)
+	}
+
 	// Record metrics
-	metrics.RecordBar(mc.name, "1m")
+	mc.symbolStats.recordBar(symbol)
+	metrics.RecordBar(mc.name, timeframe.Minute1.String())
+	metrics.RecordSymbolBar(mc.name, symbol)
 
 	log.Printf("📊 Generated 1m bar for %s: O=%.2f H=%.2f L=%.2f C=%.2f V=%d",
 		symbol, open, high, low, close, volume)
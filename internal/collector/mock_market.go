@@ -0,0 +1,264 @@
+package collector
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// MockConfig shapes the prices and volumes mock collectors generate
+type MockConfig struct {
+	MarketHoursOnly bool          // Only generate while MarketOpen returns true
+	MarketOpen      func() bool   // NSE/BSE hours by default
+	Volatility      float64       // Standard deviation of a tick's return in the normal regime, e.g. 0.0001 for 0.01%
+	RegimeLength    time.Duration // Mean time a volatility regime lasts
+	GapProbability  float64       // Chance a symbol's session opens with a gap, 0 to 1
+	MaxGapPct       float64       // Largest gap open, % of the previous close
+	MaxDriftPct     float64       // Largest trend of a symbol over a session, %
+
+	// Seed makes every symbol's generated path the same on every run; 0
+	// seeds from the clock
+	Seed int64
+
+	// Script replaces the generated path of its symbols with fixed ticks,
+	// played one per second. A symbol stops ticking after its last one.
+	Script map[string][]MockScriptTick
+}
+
+// MockScriptTick is one scripted mock tick
+type MockScriptTick struct {
+	Price    float64
+	Quantity int64
+}
+
+// LoadMockScript reads a mock price script: CSV rows of symbol, price and
+// optionally quantity (default 100), played in file order for each symbol.
+// A header row is skipped.
+func LoadMockScript(r io.Reader) (map[string][]MockScriptTick, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	script := make(map[string][]MockScriptTick)
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 2 || len(record) > 3 {
+			return nil, fmt.Errorf("line %d: want symbol,price[,quantity]", line)
+		}
+
+		price, err := strconv.ParseFloat(record[1], 64)
+		if err != nil {
+			if line == 1 {
+				continue // Header
+			}
+			return nil, fmt.Errorf("line %d: invalid price %q", line, record[1])
+		}
+		if price <= 0 {
+			return nil, fmt.Errorf("line %d: price must be positive", line)
+		}
+
+		tick := MockScriptTick{Price: price, Quantity: 100}
+		if len(record) == 3 && record[2] != "" {
+			tick.Quantity, err = strconv.ParseInt(record[2], 10, 64)
+			if err != nil || tick.Quantity <= 0 {
+				return nil, fmt.Errorf("line %d: invalid quantity %q", line, record[2])
+			}
+		}
+
+		symbol := strings.ToUpper(strings.TrimSpace(record[0]))
+		script[symbol] = append(script[symbol], tick)
+	}
+
+	if len(script) == 0 {
+		return nil, fmt.Errorf("script has no ticks")
+	}
+	return script, nil
+}
+
+// DefaultMockConfig returns mock settings giving daily moves of about 1.5%
+// in the normal regime, generating around the clock
+func DefaultMockConfig() MockConfig {
+	return MockConfig{
+		MarketOpen:     broker.IsIndianMarketOpen,
+		Volatility:     0.0001,
+		RegimeLength:   30 * time.Minute,
+		GapProbability: 0.3,
+		MaxGapPct:      2,
+		MaxDriftPct:    1,
+	}
+}
+
+// Validate checks the settings are in range
+func (cfg MockConfig) Validate() error {
+	if cfg.Volatility <= 0 || cfg.Volatility > 0.01 {
+		return fmt.Errorf("volatility must be above 0 and at most 0.01")
+	}
+	if cfg.RegimeLength <= 0 {
+		return fmt.Errorf("regime length must be positive")
+	}
+	if cfg.GapProbability < 0 || cfg.GapProbability > 1 {
+		return fmt.Errorf("gap probability must be between 0 and 1")
+	}
+	if cfg.MaxGapPct < 0 || cfg.MaxGapPct > 20 {
+		return fmt.Errorf("max gap must be between 0 and 20%%")
+	}
+	if cfg.MaxDriftPct < 0 || cfg.MaxDriftPct > 20 {
+		return fmt.Errorf("max drift must be between 0 and 20%%")
+	}
+	return nil
+}
+
+// mockRegime scales tick volatility for a while
+type mockRegime struct {
+	Name       string
+	Multiplier float64
+	Weight     float64 // Chance of being picked
+}
+
+var mockRegimes = []mockRegime{
+	{Name: "calm", Multiplier: 0.5, Weight: 0.3},
+	{Name: "normal", Multiplier: 1, Weight: 0.5},
+	{Name: "volatile", Multiplier: 2.5, Weight: 0.2},
+}
+
+const (
+	// mockTickInterval is the time between a symbol's mock ticks
+	mockTickInterval = time.Second
+
+	// mockSessionTicks is the number of ticks in a 09:15-15:30 session,
+	// over which a session's drift accumulates
+	mockSessionTicks = int(375 * time.Minute / mockTickInterval)
+
+	// minGapPct is the smallest gap open, % of the previous close
+	minGapPct = 0.25
+)
+
+// mockPrice is the generated price path of a symbol
+type mockPrice struct {
+	rng        *rand.Rand
+	price      float64
+	drift      float64 // Return added to every tick, the session's trend
+	regime     mockRegime
+	regimeLeft int    // Ticks until the regime is replaced
+	session    string // IST date of the last tick
+	scripted   int    // Script ticks played
+}
+
+// newMockPrice starts a symbol's path at price, or at a random price
+// between 100 and 5000 if 0. Its random numbers come from cfg's seed and
+// the symbol, so a seeded path doesn't depend on the other symbols.
+func newMockPrice(symbol string, price float64, cfg MockConfig) *mockPrice {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	hash := fnv.New64a()
+	hash.Write([]byte(symbol))
+
+	p := &mockPrice{rng: rand.New(rand.NewSource(seed ^ int64(hash.Sum64())))}
+	switch {
+	case len(cfg.Script[symbol]) > 0:
+		p.price = cfg.Script[symbol][0].Price
+	case price > 0:
+		p.price = price
+	default:
+		p.price = 100 + p.rng.Float64()*4900
+	}
+	return p
+}
+
+// next moves the price to its next tick at now, from the symbol's script if
+// it has one, and returns the change and the quantity traded. ok is false
+// once the script is played out.
+func (p *mockPrice) next(now time.Time, cfg MockConfig, script []MockScriptTick) (change float64, quantity int64, ok bool) {
+	if len(script) > 0 {
+		if p.scripted >= len(script) {
+			return 0, 0, false
+		}
+		tick := script[p.scripted]
+		p.scripted++
+		change = tick.Price - p.price
+		p.price = tick.Price
+		return change, tick.Quantity, true
+	}
+
+	change = p.step(now, cfg)
+	quantity = int64(float64(p.rng.Intn(900)+100) * sessionVolumeFactor(now))
+	return change, quantity, true
+}
+
+// step moves the price to its next tick at now. A new session draws a new
+// trend and may open with a gap, and an expired regime is replaced. It
+// returns the change from the previous tick.
+func (p *mockPrice) step(now time.Time, cfg MockConfig) float64 {
+	rng := p.rng
+	previous := p.price
+
+	session := now.In(broker.IST()).Format("2006-01-02")
+	if session != p.session {
+		if p.session != "" && cfg.MaxGapPct > 0 && rng.Float64() < cfg.GapProbability {
+			gap := minGapPct + rng.Float64()*math.Max(cfg.MaxGapPct-minGapPct, 0)
+			if rng.Intn(2) == 0 {
+				gap = -gap
+			}
+			p.price *= 1 + gap/100
+		}
+		p.drift = (rng.Float64()*2 - 1) * cfg.MaxDriftPct / 100 / float64(mockSessionTicks)
+		p.session = session
+	}
+
+	// Regimes last a number of ticks rather than a time, so a seeded path
+	// is the same however the ticks are timed
+	if p.regimeLeft <= 0 {
+		p.regime = pickRegime(rng)
+		p.regimeLeft = int(rng.ExpFloat64()*float64(cfg.RegimeLength/mockTickInterval)) + 1
+	}
+	p.regimeLeft--
+
+	p.price *= 1 + p.drift + rng.NormFloat64()*cfg.Volatility*p.regime.Multiplier
+	p.price = math.Max(p.price, 0.05)
+	return p.price - previous
+}
+
+// pickRegime draws a regime by weight
+func pickRegime(rng *rand.Rand) mockRegime {
+	r := rng.Float64()
+	for _, regime := range mockRegimes {
+		if r < regime.Weight {
+			return regime
+		}
+		r -= regime.Weight
+	}
+	return mockRegimes[len(mockRegimes)-1]
+}
+
+// sessionVolumeFactor scales tick volume over the 09:15-15:30 IST session
+// in a U shape: 3x at the open and close, 0.5x at midday, and 1x outside
+// the session
+func sessionVolumeFactor(now time.Time) float64 {
+	loc := broker.IST()
+	ist := now.In(loc)
+	open := time.Date(ist.Year(), ist.Month(), ist.Day(), 9, 15, 0, 0, loc)
+	elapsed := ist.Sub(open)
+	if elapsed < 0 || elapsed > 375*time.Minute {
+		return 1
+	}
+
+	x := elapsed.Minutes()/375*2 - 1 // -1 at the open, 1 at the close
+	return 0.5 + 2.5*x*x
+}
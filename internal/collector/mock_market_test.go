@@ -0,0 +1,259 @@
+package collector
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+func TestMockConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(cfg *MockConfig)
+		wantErr bool
+	}{
+		{name: "defaults", modify: func(cfg *MockConfig) {}},
+		{name: "no volatility", modify: func(cfg *MockConfig) { cfg.Volatility = 0 }, wantErr: true},
+		{name: "volatility too high", modify: func(cfg *MockConfig) { cfg.Volatility = 0.05 }, wantErr: true},
+		{name: "no regime length", modify: func(cfg *MockConfig) { cfg.RegimeLength = 0 }, wantErr: true},
+		{name: "gap probability above 1", modify: func(cfg *MockConfig) { cfg.GapProbability = 1.5 }, wantErr: true},
+		{name: "negative gap", modify: func(cfg *MockConfig) { cfg.MaxGapPct = -1 }, wantErr: true},
+		{name: "drift too large", modify: func(cfg *MockConfig) { cfg.MaxDriftPct = 25 }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultMockConfig()
+			tt.modify(&cfg)
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSessionVolumeFactor(t *testing.T) {
+	ist := broker.IST()
+	tests := []struct {
+		name string
+		at   time.Time
+		want float64
+	}{
+		{name: "open", at: time.Date(2024, 3, 1, 9, 15, 0, 0, ist), want: 3},
+		{name: "midday", at: time.Date(2024, 3, 1, 12, 22, 30, 0, ist), want: 0.5},
+		{name: "close", at: time.Date(2024, 3, 1, 15, 30, 0, 0, ist), want: 3},
+		{name: "pre-market", at: time.Date(2024, 3, 1, 8, 0, 0, 0, ist), want: 1},
+		{name: "evening", at: time.Date(2024, 3, 1, 20, 0, 0, 0, ist), want: 1},
+		{name: "open in UTC", at: time.Date(2024, 3, 1, 3, 45, 0, 0, time.UTC), want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sessionVolumeFactor(tt.at); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("sessionVolumeFactor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMockPriceStep(t *testing.T) {
+	ist := broker.IST()
+	day1 := time.Date(2024, 3, 1, 15, 29, 0, 0, ist)
+	day2 := time.Date(2024, 3, 4, 9, 15, 0, 0, ist)
+
+	tests := []struct {
+		name       string
+		cfg        func(cfg *MockConfig)
+		times      []time.Time
+		wantGap    bool // The last step moved more than the smallest gap
+		wantMaxPct float64
+	}{
+		{
+			name:       "first session never gaps",
+			cfg:        func(cfg *MockConfig) { cfg.GapProbability = 1 },
+			times:      []time.Time{day2},
+			wantMaxPct: 0.1,
+		},
+		{
+			name:       "new session gaps",
+			cfg:        func(cfg *MockConfig) { cfg.GapProbability = 1 },
+			times:      []time.Time{day1, day2},
+			wantGap:    true,
+			wantMaxPct: 2.1,
+		},
+		{
+			name:       "gaps disabled",
+			cfg:        func(cfg *MockConfig) { cfg.GapProbability = 0 },
+			times:      []time.Time{day1, day2},
+			wantMaxPct: 0.1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultMockConfig()
+			tt.cfg(&cfg)
+			for seed := int64(1); seed <= 50; seed++ {
+				p := &mockPrice{rng: rand.New(rand.NewSource(seed)), price: 1000}
+				var change float64
+				for _, at := range tt.times {
+					change = p.step(at, cfg)
+				}
+
+				movePct := math.Abs(change) / (p.price - change) * 100
+				if tt.wantGap && movePct < minGapPct-0.1 {
+					t.Fatalf("seed %d: moved %.3f%%, want a gap of at least %.2f%%", seed, movePct, minGapPct)
+				}
+				if movePct > tt.wantMaxPct {
+					t.Fatalf("seed %d: moved %.3f%%, want at most %.2f%%", seed, movePct, tt.wantMaxPct)
+				}
+				if p.regime.Name == "" {
+					t.Fatalf("seed %d: no regime drawn", seed)
+				}
+			}
+		})
+	}
+}
+
+func TestPickRegime(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		counts[pickRegime(rng).Name]++
+	}
+
+	for _, regime := range mockRegimes {
+		share := float64(counts[regime.Name]) / 10000
+		if math.Abs(share-regime.Weight) > 0.03 {
+			t.Errorf("%s picked %.3f of the time, want about %.2f", regime.Name, share, regime.Weight)
+		}
+	}
+}
+
+func TestMockGenerating(t *testing.T) {
+	tests := []struct {
+		name            string
+		marketHoursOnly bool
+		open            bool
+		want            bool
+	}{
+		{name: "around the clock", marketHoursOnly: false, open: false, want: true},
+		{name: "market open", marketHoursOnly: true, open: true, want: true},
+		{name: "market closed", marketHoursOnly: true, open: false, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc := NewMockDataCollector(nil, "test", []string{"RELIANCE"})
+			cfg := DefaultMockConfig()
+			cfg.MarketHoursOnly = tt.marketHoursOnly
+			cfg.MarketOpen = func() bool { return tt.open }
+			mc.SetConfig(cfg)
+
+			if got := mc.generating(); got != tt.want {
+				t.Errorf("generating() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMockScript(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		want    map[string][]MockScriptTick
+		wantErr bool
+	}{
+		{
+			name: "with header",
+			csv:  "symbol,price,quantity\nRELIANCE,2500,50\ntcs,3500\nRELIANCE,2501.5,\n",
+			want: map[string][]MockScriptTick{
+				"RELIANCE": {{Price: 2500, Quantity: 50}, {Price: 2501.5, Quantity: 100}},
+				"TCS":      {{Price: 3500, Quantity: 100}},
+			},
+		},
+		{
+			name: "without header",
+			csv:  "INFY, 1450, 10\n",
+			want: map[string][]MockScriptTick{"INFY": {{Price: 1450, Quantity: 10}}},
+		},
+		{name: "empty", csv: "symbol,price\n", wantErr: true},
+		{name: "bad price", csv: "INFY,1450\nINFY,abc\n", wantErr: true},
+		{name: "negative price", csv: "INFY,-1\n", wantErr: true},
+		{name: "bad quantity", csv: "INFY,1450,0\n", wantErr: true},
+		{name: "too many columns", csv: "INFY,1450,10,x\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadMockScript(strings.NewReader(tt.csv))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadMockScript() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadMockScript() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMockPriceSeeded(t *testing.T) {
+	cfg := DefaultMockConfig()
+	cfg.Seed = 42
+	at := time.Date(2024, 3, 1, 10, 0, 0, 0, broker.IST())
+
+	path := func(symbol string, price float64) []float64 {
+		p := newMockPrice(symbol, price, cfg)
+		prices := []float64{p.price}
+		for i := 0; i < 100; i++ {
+			p.next(at.Add(time.Duration(i)*time.Second), cfg, nil)
+			prices = append(prices, p.price)
+		}
+		return prices
+	}
+
+	if a, b := path("RELIANCE", 2500), path("RELIANCE", 2500); !reflect.DeepEqual(a, b) {
+		t.Error("the same seed and symbol generated different paths")
+	}
+	if a, b := path("NEWSTOCK", 0), path("NEWSTOCK", 0); !reflect.DeepEqual(a, b) {
+		t.Error("the same seed generated different starting prices")
+	}
+	if a, b := path("RELIANCE", 2500), path("TCS", 2500); reflect.DeepEqual(a, b) {
+		t.Error("different symbols generated the same path")
+	}
+
+	seeded := path("RELIANCE", 2500)
+	cfg.Seed = 43
+	if reflect.DeepEqual(seeded, path("RELIANCE", 2500)) {
+		t.Error("a different seed generated the same path")
+	}
+}
+
+func TestMockPriceScripted(t *testing.T) {
+	cfg := DefaultMockConfig()
+	script := []MockScriptTick{{Price: 100, Quantity: 5}, {Price: 101, Quantity: 7}, {Price: 99.5, Quantity: 1}}
+	cfg.Script = map[string][]MockScriptTick{"TEST": script}
+
+	p := newMockPrice("TEST", 2500, cfg)
+	if p.price != 100 {
+		t.Fatalf("scripted path starts at %v, want the first script price 100", p.price)
+	}
+
+	wantChanges := []float64{0, 1, -1.5}
+	for i, want := range script {
+		change, quantity, ok := p.next(time.Now(), cfg, script)
+		if !ok || p.price != want.Price || quantity != want.Quantity || change != wantChanges[i] {
+			t.Errorf("tick %d = %v x %d (change %v, ok %v), want %v x %d (change %v)",
+				i, p.price, quantity, change, ok, want.Price, want.Quantity, wantChanges[i])
+		}
+	}
+
+	if _, _, ok := p.next(time.Now(), cfg, script); ok {
+		t.Error("played past the end of the script")
+	}
+}
@@ -0,0 +1,242 @@
+package collector
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// SetCollectorMode sets the subscription mode (ltp, quote or full) of a
+// real collector
+func (ucm *UnifiedCollectorManager) SetCollectorMode(name, mode string) error {
+	ucm.watchMu.Lock()
+	defer ucm.watchMu.Unlock()
+
+	ucm.mu.RLock()
+	collector, exists := ucm.realCollectors[name]
+	_, isMock := ucm.mockCollectors[name]
+	ucm.mu.RUnlock()
+
+	if !exists {
+		if isMock {
+			return fmt.Errorf("mode applies to real collectors only")
+		}
+		return fmt.Errorf("collector '%s' not found", name)
+	}
+
+	if err := collector.SetSubscriptionMode(mode); err != nil {
+		return err
+	}
+	ucm.modes[name] = mode
+	ucm.persist(name)
+	return nil
+}
+
+// RestoreCollectors recreates the stored collectors with their
+// subscriptions and followed watchlists, and starts those that were
+// running. Real collectors use the given broker credentials and are skipped
+// without them. Call it on boot, before serving requests.
+func (ucm *UnifiedCollectorManager) RestoreCollectors(apiKey, accessToken string) error {
+	records, err := ucm.db.GetCollectorConfigs()
+	if err != nil {
+		return err
+	}
+
+	// The stored definitions are already up to date
+	ucm.watchMu.Lock()
+	ucm.restoring = true
+	ucm.watchMu.Unlock()
+	defer func() {
+		ucm.watchMu.Lock()
+		ucm.restoring = false
+		ucm.watchMu.Unlock()
+	}()
+
+	restored, started := 0, 0
+	for _, record := range records {
+		ok, running := ucm.restoreCollector(record, apiKey, accessToken)
+		if ok {
+			restored++
+		}
+		if running {
+			started++
+		}
+	}
+
+	log.Printf("🚀 Restored %d/%d collectors (%d started)", restored, len(records), started)
+	return nil
+}
+
+// restoreCollector recreates one stored collector, reporting whether it was
+// restored and started
+func (ucm *UnifiedCollectorManager) restoreCollector(record database.CollectorConfigRecord, apiKey, accessToken string) (restored, started bool) {
+	switch record.Type {
+	case "real":
+		if apiKey == "" || accessToken == "" {
+			log.Printf("⏭️  Skipping collector %s: no broker credentials", record.Name)
+			return false, false
+		}
+		if err := ucm.CreateRealCollector(record.Name, apiKey, accessToken); err != nil {
+			log.Printf("❌ Failed to restore collector %s: %v", record.Name, err)
+			return false, false
+		}
+		if record.Mode != "" && record.Mode != ModeFull {
+			if err := ucm.SetCollectorMode(record.Name, record.Mode); err != nil {
+				log.Printf("⚠️  Failed to set mode for %s: %v", record.Name, err)
+			}
+		}
+		if len(record.Symbols) > 0 {
+			if err := ucm.SubscribeSymbols(record.Name, record.Symbols); err != nil {
+				log.Printf("⚠️  Failed to subscribe %s to its symbols: %v", record.Name, err)
+
+				// Keep them stored, e.g. until instruments are loaded
+				ucm.watchMu.Lock()
+				ucm.directSymbols[record.Name] = make(map[string]bool, len(record.Symbols))
+				for _, symbol := range record.Symbols {
+					ucm.directSymbols[record.Name][symbol] = true
+				}
+				ucm.watchMu.Unlock()
+			}
+		}
+	case "mock":
+		if err := ucm.CreateMockCollector(record.Name, record.Symbols); err != nil {
+			log.Printf("❌ Failed to restore collector %s: %v", record.Name, err)
+			return false, false
+		}
+	default:
+		log.Printf("⚠️  Skipping collector %s: unknown type %q", record.Name, record.Type)
+		return false, false
+	}
+
+	for _, name := range record.Watchlists {
+		if _, err := ucm.SubscribeWatchlist(record.Name, name); err != nil {
+			log.Printf("⚠️  Failed to follow watchlist %s for %s: %v", name, record.Name, err)
+		}
+	}
+
+	// Keep the flag if starting fails, to retry on the next boot
+	ucm.watchMu.Lock()
+	ucm.autoStart[record.Name] = record.AutoStart
+	ucm.watchMu.Unlock()
+
+	if record.AutoStart {
+		if err := ucm.StartCollector(record.Name); err != nil {
+			log.Printf("❌ Failed to start collector %s: %v", record.Name, err)
+			return true, false
+		}
+		return true, true
+	}
+	return true, false
+}
+
+// UpdateAccessToken switches the real collectors to a renewed Kite access
+// token, restarting those running, and restores stored real collectors
+// skipped on boot for lack of one. It returns the names of the collectors
+// restarted or restored.
+func (ucm *UnifiedCollectorManager) UpdateAccessToken(apiKey, accessToken string) []string {
+	var updated []string
+
+	ucm.mu.RLock()
+	running := make(map[string]bool)
+	for name, collector := range ucm.realCollectors {
+		if collector.SetCredentials(apiKey, accessToken) {
+			running[name] = collector.IsRunning()
+		}
+	}
+	ucm.mu.RUnlock()
+
+	for name, wasRunning := range running {
+		if wasRunning {
+			if err := ucm.stopCollector(name); err != nil {
+				log.Printf("⚠️  Failed to stop collector %s: %v", name, err)
+				continue
+			}
+			if err := ucm.startCollector(name); err != nil {
+				log.Printf("❌ Failed to restart collector %s: %v", name, err)
+				continue
+			}
+		}
+		updated = append(updated, name)
+	}
+
+	records, err := ucm.db.GetCollectorConfigs()
+	if err != nil {
+		log.Printf("⚠️  Failed to load stored collectors: %v", err)
+		return updated
+	}
+
+	ucm.watchMu.Lock()
+	ucm.restoring = true
+	ucm.watchMu.Unlock()
+	defer func() {
+		ucm.watchMu.Lock()
+		ucm.restoring = false
+		ucm.watchMu.Unlock()
+	}()
+
+	for _, record := range records {
+		if record.Type != "real" {
+			continue
+		}
+		ucm.mu.RLock()
+		_, exists := ucm.realCollectors[record.Name]
+		ucm.mu.RUnlock()
+		if exists {
+			continue
+		}
+		if restored, _ := ucm.restoreCollector(record, apiKey, accessToken); restored {
+			updated = append(updated, record.Name)
+		}
+	}
+
+	sort.Strings(updated)
+	return updated
+}
+
+// setAutoStart records whether a collector starts on boot
+func (ucm *UnifiedCollectorManager) setAutoStart(name string, autoStart bool) {
+	ucm.watchMu.Lock()
+	defer ucm.watchMu.Unlock()
+	ucm.autoStart[name] = autoStart
+	ucm.persist(name)
+}
+
+// persist stores a collector's definition. Failures are logged: the
+// collector keeps working, it just won't be restored as it is now.
+// Callers hold watchMu.
+func (ucm *UnifiedCollectorManager) persist(name string) {
+	if ucm.restoring {
+		return
+	}
+
+	collectorType, err := ucm.GetCollectorType(name)
+	if err != nil {
+		return
+	}
+
+	record := &database.CollectorConfigRecord{
+		Name:       name,
+		Type:       collectorType,
+		Mode:       ucm.modes[name],
+		Symbols:    []string{},
+		Watchlists: []string{},
+		AutoStart:  ucm.autoStart[name],
+	}
+	if record.Mode == "" {
+		record.Mode = ModeFull
+	}
+	for symbol := range ucm.directSymbols[name] {
+		record.Symbols = append(record.Symbols, symbol)
+	}
+	for watchlistName := range ucm.watchlists[name] {
+		record.Watchlists = append(record.Watchlists, watchlistName)
+	}
+	sort.Strings(record.Symbols)
+	sort.Strings(record.Watchlists)
+
+	if err := ucm.db.SaveCollectorConfig(record); err != nil {
+		log.Printf("⚠️  Failed to store collector %s: %v", name, err)
+	}
+}
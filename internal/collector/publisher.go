@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/quotes"
+)
+
+// Publisher receives the ticks, forming candles and completed bars
+// collectors produce, e.g. the streaming hub behind /stream/ws. Calls must
+// not block.
+type Publisher interface {
+	BroadcastTick(symbol string, tick *database.TickData)
+	BroadcastCandleUpdate(symbol string, bar *database.IntradayBar)
+	BroadcastBar(symbol string, bar *database.IntradayBar)
+}
+
+// QualityGate decides whether collected bars and ticks are stored, e.g. a
+// quality.Monitor. Rejected records are neither stored nor published.
+type QualityGate interface {
+	AcceptBar(bar *database.IntradayBar) bool
+	AcceptTick(tick *database.TickData) bool
+}
+
+// SetPublisher publishes the ticks and bars of all collectors, current and
+// created afterwards, to p
+func (ucm *UnifiedCollectorManager) SetPublisher(p Publisher) {
+	ucm.mu.Lock()
+	defer ucm.mu.Unlock()
+
+	ucm.publisher = p
+	for _, collector := range ucm.realCollectors {
+		collector.SetPublisher(p)
+	}
+	for _, collector := range ucm.mockCollectors {
+		collector.SetPublisher(p)
+	}
+}
+
+// SetQualityGate checks the bars and ticks of all collectors, current and
+// created afterwards, with gate before they are stored
+func (ucm *UnifiedCollectorManager) SetQualityGate(gate QualityGate) {
+	ucm.mu.Lock()
+	defer ucm.mu.Unlock()
+
+	ucm.qualityGate = gate
+	for _, collector := range ucm.realCollectors {
+		collector.SetQualityGate(gate)
+	}
+	for _, collector := range ucm.mockCollectors {
+		collector.SetQualityGate(gate)
+	}
+}
+
+// SetQuoteStore keeps store up to date with the ticks of all real
+// collectors, current and created afterwards. Mock ticks are left out so
+// they never stand in for market prices.
+func (ucm *UnifiedCollectorManager) SetQuoteStore(store *quotes.Store) {
+	ucm.mu.Lock()
+	defer ucm.mu.Unlock()
+
+	ucm.quoteStore = store
+	for _, collector := range ucm.realCollectors {
+		collector.SetQuoteStore(store)
+	}
+}
@@ -0,0 +1,453 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/timeframe"
+)
+
+// Data a replay plays back
+const (
+	ReplayTicks = "ticks" // Stored ticks, rebuilt into 1m candles
+	ReplayBars  = "bars"  // Stored 1m bars
+)
+
+// Replay statuses
+const (
+	ReplayRunning   = "RUNNING"
+	ReplayCompleted = "COMPLETED"
+	ReplayStopped   = "STOPPED"
+)
+
+// Replay speeds, as multiples of the recorded pace
+const (
+	MinReplaySpeed = 1.0
+	MaxReplaySpeed = 100.0
+)
+
+const (
+	// maxReplays is the most replays running at once
+	maxReplays = 5
+
+	// replayLimit caps the ticks or bars loaded per symbol
+	replayLimit = 500000
+
+	// maxReplayGap caps the recorded time waited between two events, so
+	// gaps in the data don't stall a replay
+	maxReplayGap = 5 * time.Minute
+)
+
+// ReplaySource is the source recorded on replayed ticks and bars
+const ReplaySource = "replay"
+
+// ReplayConfig selects the stored session a replay plays back
+type ReplayConfig struct {
+	Exchange string // Exchange the symbols are stored under, default NSE
+	Symbols  []string
+	From     time.Time
+	To       time.Time
+	Speed    float64 // MinReplaySpeed to MaxReplaySpeed
+	Data     string  // ReplayTicks or ReplayBars
+}
+
+// Validate checks the config, defaulting Exchange to NSE and Data to
+// ReplayTicks
+func (cfg *ReplayConfig) Validate() error {
+	cfg.Exchange = strings.ToUpper(strings.TrimSpace(cfg.Exchange))
+	if cfg.Exchange == "" {
+		cfg.Exchange = "NSE"
+	}
+	if len(cfg.Symbols) == 0 {
+		return fmt.Errorf("at least one symbol is required")
+	}
+	if !cfg.To.After(cfg.From) {
+		return fmt.Errorf("replay must end after it starts")
+	}
+	if cfg.Speed < MinReplaySpeed || cfg.Speed > MaxReplaySpeed {
+		return fmt.Errorf("speed must be between %gx and %gx", MinReplaySpeed, MaxReplaySpeed)
+	}
+	switch cfg.Data {
+	case "":
+		cfg.Data = ReplayTicks
+	case ReplayTicks, ReplayBars:
+	default:
+		return fmt.Errorf("data must be '%s' or '%s'", ReplayTicks, ReplayBars)
+	}
+	return nil
+}
+
+// ReplayStatus reports a replay's progress
+type ReplayStatus struct {
+	ID         string     `json:"id"`
+	Exchange   string     `json:"exchange"`
+	Symbols    []string   `json:"symbols"`
+	From       time.Time  `json:"from"`
+	To         time.Time  `json:"to"`
+	Speed      float64    `json:"speed"`
+	Data       string     `json:"data"`
+	Status     string     `json:"status"`
+	Events     int        `json:"events"`
+	Played     int        `json:"played"`
+	MarketTime *time.Time `json:"market_time,omitempty"` // Timestamp of the last event played
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// replayEvent is a stored tick or bar to play back
+type replayEvent struct {
+	at   time.Time
+	tick *database.TickData
+	bar  *database.IntradayBar
+}
+
+// Replay plays stored ticks or bars of a past session back through a
+// Publisher, paced by their timestamps, so streaming clients see them as if
+// live. Replayed data is published as source ReplaySource and never stored.
+type Replay struct {
+	id        string
+	config    ReplayConfig
+	events    []replayEvent
+	publisher Publisher
+
+	// Waits d, or returns false once ctx is done; replaced in tests
+	sleep func(ctx context.Context, d time.Duration) bool
+
+	// 1m candles rebuilt from replayed ticks
+	builders map[string]*CandleBuilder
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu         sync.RWMutex
+	status     string
+	played     int
+	startedAt  time.Time
+	finishedAt time.Time
+}
+
+// newReplay creates a replay of events, which must be in time order
+func newReplay(id string, cfg ReplayConfig, events []replayEvent, publisher Publisher) *Replay {
+	return &Replay{
+		id:        id,
+		config:    cfg,
+		events:    events,
+		publisher: publisher,
+		sleep:     sleepContext,
+		builders:  make(map[string]*CandleBuilder),
+		done:      make(chan struct{}),
+	}
+}
+
+// loadReplayEvents reads the stored ticks or bars of cfg's symbols, merged
+// in time order
+func loadReplayEvents(db *database.Database, cfg ReplayConfig) ([]replayEvent, error) {
+	var events []replayEvent
+	for _, symbol := range cfg.Symbols {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+
+		if cfg.Data == ReplayBars {
+			bars, err := db.GetIntradayBars(cfg.Exchange, symbol, timeframe.Minute1.String(), cfg.From, cfg.To, replayLimit)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load %s bars: %w", symbol, err)
+			}
+			for i := range bars {
+				events = append(events, replayEvent{at: bars[i].BarTimestamp, bar: &bars[i]})
+			}
+			continue
+		}
+
+		ticks, err := db.GetTickData(cfg.Exchange, symbol, cfg.From, cfg.To, replayLimit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s ticks: %w", symbol, err)
+		}
+		for i := range ticks {
+			events = append(events, replayEvent{at: ticks[i].TickTimestamp, tick: &ticks[i]})
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].at.Before(events[j].at) })
+	return events, nil
+}
+
+// start plays the replay in the background
+func (r *Replay) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	r.mu.Lock()
+	r.status = ReplayRunning
+	r.startedAt = time.Now()
+	r.mu.Unlock()
+
+	go func() {
+		defer close(r.done)
+		status := ReplayCompleted
+		if !r.run(ctx) {
+			status = ReplayStopped
+		}
+
+		r.mu.Lock()
+		r.status = status
+		r.finishedAt = time.Now()
+		r.mu.Unlock()
+		log.Printf("⏹️  Replay %s %s after %d of %d events", r.id, strings.ToLower(status), r.Played(), len(r.events))
+	}()
+}
+
+// stop ends the replay and waits for it to finish
+func (r *Replay) stop() {
+	if r.cancel != nil {
+		r.cancel()
+		<-r.done
+	}
+}
+
+// run publishes the events, waiting between them their recorded gap (at
+// most maxReplayGap) divided by the speed. Candles forming when the replay
+// ends are completed. It reports whether every event was played.
+func (r *Replay) run(ctx context.Context) bool {
+	defer r.completeCandles()
+
+	for i, event := range r.events {
+		if i > 0 {
+			gap := min(event.at.Sub(r.events[i-1].at), maxReplayGap)
+			if gap > 0 && !r.sleep(ctx, time.Duration(float64(gap)/r.config.Speed)) {
+				return false
+			}
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+
+		if event.tick != nil {
+			r.playTick(*event.tick)
+		} else {
+			r.playBar(*event.bar)
+		}
+
+		r.mu.Lock()
+		r.played++
+		r.mu.Unlock()
+	}
+	return true
+}
+
+// playTick publishes a tick and folds it into its symbol's candle by its
+// recorded minute, publishing the candle as a bar once the minute rolls over
+func (r *Replay) playTick(tick database.TickData) {
+	tick.Source = ReplaySource
+	if r.publisher != nil {
+		r.publisher.BroadcastTick(tick.Symbol, &tick)
+	}
+
+	builder, ok := r.builders[tick.Symbol]
+	if !ok {
+		builder = &CandleBuilder{
+			InstrumentToken: tick.InstrumentToken,
+			Symbol:          tick.Symbol,
+			Exchange:        tick.Exchange,
+			Timeframe:       timeframe.Minute1.String(),
+			Source:          ReplaySource,
+		}
+		r.builders[tick.Symbol] = builder
+	}
+
+	builder.mu.Lock()
+	defer builder.mu.Unlock()
+
+	minute := tick.TickTimestamp.Truncate(time.Minute)
+	newCandle := !builder.CurrentTimestamp.Equal(minute)
+	if newCandle {
+		r.completeCandle(builder)
+		builder.start(minute, tick.Price, tick.Quantity, 0)
+	} else {
+		builder.update(tick.Price, tick.Quantity, 0)
+	}
+
+	now := time.Now()
+	if r.publisher != nil && (newCandle || now.Sub(builder.lastUpdate) >= candleUpdateInterval) {
+		r.publisher.BroadcastCandleUpdate(builder.Symbol, builder.bar())
+		builder.lastUpdate = now
+	}
+}
+
+// playBar publishes a stored bar
+func (r *Replay) playBar(bar database.IntradayBar) {
+	bar.Source = ReplaySource
+	if r.publisher != nil {
+		r.publisher.BroadcastBar(bar.Symbol, &bar)
+	}
+}
+
+// completeCandle publishes a builder's candle as a bar; callers must hold
+// builder.mu
+func (r *Replay) completeCandle(builder *CandleBuilder) {
+	if builder.CurrentTimestamp.IsZero() {
+		return
+	}
+	bar := builder.bar()
+	builder.CurrentTimestamp = time.Time{}
+	if r.publisher != nil {
+		r.publisher.BroadcastBar(bar.Symbol, bar)
+	}
+}
+
+// completeCandles publishes the candles still forming
+func (r *Replay) completeCandles() {
+	for _, builder := range r.builders {
+		builder.mu.Lock()
+		r.completeCandle(builder)
+		builder.mu.Unlock()
+	}
+}
+
+// Played returns how many events have been played
+func (r *Replay) Played() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.played
+}
+
+// Status returns the replay's progress
+func (r *Replay) Status() ReplayStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	status := ReplayStatus{
+		ID:        r.id,
+		Exchange:  r.config.Exchange,
+		Symbols:   r.config.Symbols,
+		From:      r.config.From,
+		To:        r.config.To,
+		Speed:     r.config.Speed,
+		Data:      r.config.Data,
+		Status:    r.status,
+		Events:    len(r.events),
+		Played:    r.played,
+		StartedAt: r.startedAt,
+	}
+	if r.played > 0 {
+		at := r.events[r.played-1].at
+		status.MarketTime = &at
+	}
+	if !r.finishedAt.IsZero() {
+		finishedAt := r.finishedAt
+		status.FinishedAt = &finishedAt
+	}
+	return status
+}
+
+// sleepContext waits d, returning false if ctx is done first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// ============================================================================
+// MANAGER
+// ============================================================================
+
+// StartReplay loads the stored session cfg selects and starts playing it
+// through the manager's publisher, returning its status
+func (ucm *UnifiedCollectorManager) StartReplay(cfg ReplayConfig) (ReplayStatus, error) {
+	if err := cfg.Validate(); err != nil {
+		return ReplayStatus{}, err
+	}
+
+	events, err := loadReplayEvents(ucm.db, cfg)
+	if err != nil {
+		return ReplayStatus{}, err
+	}
+	if len(events) == 0 {
+		return ReplayStatus{}, fmt.Errorf("no stored %s for %s between %s and %s",
+			cfg.Data, strings.Join(cfg.Symbols, ", "), cfg.From.Format(time.RFC3339), cfg.To.Format(time.RFC3339))
+	}
+
+	ucm.mu.RLock()
+	publisher := ucm.publisher
+	ucm.mu.RUnlock()
+
+	ucm.replayMu.Lock()
+	defer ucm.replayMu.Unlock()
+
+	running := 0
+	for _, replay := range ucm.replays {
+		if replay.Status().Status == ReplayRunning {
+			running++
+		}
+	}
+	if running >= maxReplays {
+		return ReplayStatus{}, fmt.Errorf("%d replays are already running", running)
+	}
+
+	ucm.replaySeq++
+	replay := newReplay(fmt.Sprintf("replay-%d", ucm.replaySeq), cfg, events, publisher)
+	ucm.replays[replay.id] = replay
+	replay.start()
+
+	log.Printf("▶️  Replay %s started: %d %s of %s at %gx", replay.id, len(events), cfg.Data, strings.Join(cfg.Symbols, ", "), cfg.Speed)
+	return replay.Status(), nil
+}
+
+// ListReplays returns the status of every replay, oldest first
+func (ucm *UnifiedCollectorManager) ListReplays() []ReplayStatus {
+	ucm.replayMu.Lock()
+	defer ucm.replayMu.Unlock()
+
+	statuses := make([]ReplayStatus, 0, len(ucm.replays))
+	for _, replay := range ucm.replays {
+		statuses = append(statuses, replay.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].StartedAt.Before(statuses[j].StartedAt) })
+	return statuses
+}
+
+// GetReplay returns the status of a replay
+func (ucm *UnifiedCollectorManager) GetReplay(id string) (ReplayStatus, error) {
+	ucm.replayMu.Lock()
+	defer ucm.replayMu.Unlock()
+
+	replay, ok := ucm.replays[id]
+	if !ok {
+		return ReplayStatus{}, fmt.Errorf("replay '%s' not found", id)
+	}
+	return replay.Status(), nil
+}
+
+// DeleteReplay stops a replay if it's running and forgets it
+func (ucm *UnifiedCollectorManager) DeleteReplay(id string) error {
+	ucm.replayMu.Lock()
+	replay, ok := ucm.replays[id]
+	delete(ucm.replays, id)
+	ucm.replayMu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("replay '%s' not found", id)
+	}
+	replay.stop()
+	return nil
+}
+
+// stopReplays stops every running replay
+func (ucm *UnifiedCollectorManager) stopReplays() {
+	ucm.replayMu.Lock()
+	defer ucm.replayMu.Unlock()
+
+	for _, replay := range ucm.replays {
+		replay.stop()
+	}
+}
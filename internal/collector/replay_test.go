@@ -0,0 +1,187 @@
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// recordingPublisher records what a replay publishes
+type recordingPublisher struct {
+	ticks []*database.TickData
+	bars  []*database.IntradayBar
+}
+
+func (p *recordingPublisher) BroadcastTick(symbol string, tick *database.TickData) {
+	p.ticks = append(p.ticks, tick)
+}
+
+func (p *recordingPublisher) BroadcastCandleUpdate(symbol string, bar *database.IntradayBar) {}
+
+func (p *recordingPublisher) BroadcastBar(symbol string, bar *database.IntradayBar) {
+	p.bars = append(p.bars, bar)
+}
+
+func TestReplayConfigValidate(t *testing.T) {
+	from := time.Date(2024, 3, 1, 9, 15, 0, 0, time.UTC)
+	valid := ReplayConfig{Symbols: []string{"RELIANCE"}, From: from, To: from.Add(time.Hour), Speed: 10}
+
+	tests := []struct {
+		name    string
+		modify  func(cfg *ReplayConfig)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(cfg *ReplayConfig) {}},
+		{name: "bars", modify: func(cfg *ReplayConfig) { cfg.Data = ReplayBars }},
+		{name: "no symbols", modify: func(cfg *ReplayConfig) { cfg.Symbols = nil }, wantErr: true},
+		{name: "ends before it starts", modify: func(cfg *ReplayConfig) { cfg.To = from }, wantErr: true},
+		{name: "too slow", modify: func(cfg *ReplayConfig) { cfg.Speed = 0.5 }, wantErr: true},
+		{name: "too fast", modify: func(cfg *ReplayConfig) { cfg.Speed = 101 }, wantErr: true},
+		{name: "unknown data", modify: func(cfg *ReplayConfig) { cfg.Data = "quotes" }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			tt.modify(&cfg)
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && cfg.Data == "" {
+				t.Error("Validate() left Data empty")
+			}
+		})
+	}
+}
+
+func TestReplayRun(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 15, 0, 0, time.UTC)
+	tick := func(offset time.Duration, symbol string, price float64, quantity int64) replayEvent {
+		return replayEvent{at: start.Add(offset), tick: &database.TickData{
+			Exchange: "NSE", Symbol: symbol, TickTimestamp: start.Add(offset), Price: price, Quantity: quantity, Source: "zerodha",
+		}}
+	}
+	bar := func(offset time.Duration, close float64) replayEvent {
+		return replayEvent{at: start.Add(offset), bar: &database.IntradayBar{
+			Symbol: "TCS", Timeframe: "1m", BarTimestamp: start.Add(offset), Close: close, Source: "zerodha",
+		}}
+	}
+
+	tests := []struct {
+		name      string
+		events    []replayEvent
+		speed     float64
+		wantTicks int
+		wantBars  []database.IntradayBar // Symbol, timestamp and OHLCV
+		wantSleep []time.Duration
+	}{
+		{
+			name: "ticks rebuilt into candles",
+			events: []replayEvent{
+				tick(0, "RELIANCE", 2500, 10),
+				tick(20*time.Second, "RELIANCE", 2510, 5),
+				tick(40*time.Second, "RELIANCE", 2495, 5),
+				tick(70*time.Second, "RELIANCE", 2505, 20),
+			},
+			speed:     10,
+			wantTicks: 4,
+			wantBars: []database.IntradayBar{
+				{Symbol: "RELIANCE", BarTimestamp: start, Open: 2500, High: 2510, Low: 2495, Close: 2495, Volume: 20},
+				{Symbol: "RELIANCE", BarTimestamp: start.Add(time.Minute), Open: 2505, High: 2505, Low: 2505, Close: 2505, Volume: 20},
+			},
+			wantSleep: []time.Duration{2 * time.Second, 2 * time.Second, 3 * time.Second},
+		},
+		{
+			name:      "bars at the recorded pace",
+			events:    []replayEvent{bar(0, 3500), bar(time.Minute, 3510)},
+			speed:     1,
+			wantBars:  []database.IntradayBar{{Symbol: "TCS", BarTimestamp: start, Close: 3500}, {Symbol: "TCS", BarTimestamp: start.Add(time.Minute), Close: 3510}},
+			wantSleep: []time.Duration{time.Minute},
+		},
+		{
+			name:      "gaps capped",
+			events:    []replayEvent{bar(0, 3500), bar(time.Hour, 3510)},
+			speed:     100,
+			wantBars:  []database.IntradayBar{{Symbol: "TCS", BarTimestamp: start, Close: 3500}, {Symbol: "TCS", BarTimestamp: start.Add(time.Hour), Close: 3510}},
+			wantSleep: []time.Duration{maxReplayGap / 100},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &recordingPublisher{}
+			replay := newReplay("replay-1", ReplayConfig{Speed: tt.speed}, tt.events, publisher)
+			var slept []time.Duration
+			replay.sleep = func(ctx context.Context, d time.Duration) bool {
+				slept = append(slept, d)
+				return true
+			}
+
+			if !replay.run(context.Background()) {
+				t.Fatal("run() reported the replay stopped")
+			}
+			if replay.Played() != len(tt.events) {
+				t.Errorf("played %d events, want %d", replay.Played(), len(tt.events))
+			}
+
+			if len(publisher.ticks) != tt.wantTicks {
+				t.Errorf("published %d ticks, want %d", len(publisher.ticks), tt.wantTicks)
+			}
+			for _, tick := range publisher.ticks {
+				if tick.Source != ReplaySource {
+					t.Errorf("tick source = %q, want %q", tick.Source, ReplaySource)
+				}
+			}
+
+			if len(publisher.bars) != len(tt.wantBars) {
+				t.Fatalf("published %d bars, want %d", len(publisher.bars), len(tt.wantBars))
+			}
+			for i, want := range tt.wantBars {
+				got := publisher.bars[i]
+				if got.Symbol != want.Symbol || !got.BarTimestamp.Equal(want.BarTimestamp) || got.Open != want.Open ||
+					got.High != want.High || got.Low != want.Low || got.Close != want.Close || got.Volume != want.Volume {
+					t.Errorf("bar %d = %+v, want %+v", i, *got, want)
+				}
+				if got.Source != ReplaySource {
+					t.Errorf("bar %d source = %q, want %q", i, got.Source, ReplaySource)
+				}
+			}
+
+			if len(slept) != len(tt.wantSleep) {
+				t.Fatalf("slept %v, want %v", slept, tt.wantSleep)
+			}
+			for i := range slept {
+				if slept[i] != tt.wantSleep[i] {
+					t.Errorf("sleep %d = %v, want %v", i, slept[i], tt.wantSleep[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReplayStop(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 15, 0, 0, time.UTC)
+	events := []replayEvent{
+		{at: start, bar: &database.IntradayBar{Symbol: "TCS", BarTimestamp: start}},
+		{at: start.Add(time.Minute), bar: &database.IntradayBar{Symbol: "TCS", BarTimestamp: start.Add(time.Minute)}},
+	}
+
+	replay := newReplay("replay-1", ReplayConfig{Speed: 1}, events, &recordingPublisher{})
+	replay.start()
+	// The first event plays at once, the second a minute later
+	for deadline := time.Now().Add(5 * time.Second); replay.Played() == 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	replay.stop()
+
+	status := replay.Status()
+	if status.Status != ReplayStopped || status.Played != 1 || status.FinishedAt == nil {
+		t.Errorf("status after stop = %+v, want STOPPED after 1 event", status)
+	}
+	if status.MarketTime == nil || !status.MarketTime.Equal(start) {
+		t.Errorf("market time = %v, want %v", status.MarketTime, start)
+	}
+}
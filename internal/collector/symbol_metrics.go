@@ -0,0 +1,172 @@
+package collector
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// latencyWindow is the number of recent tick latencies a collector
+	// computes its percentiles over
+	latencyWindow = 4096
+
+	// symbolLatencyWindow is the same per symbol
+	symbolLatencyWindow = 256
+)
+
+// LatencyStats summarizes recent tick-to-database latencies
+type LatencyStats struct {
+	Samples int     `json:"samples"`
+	P50Ms   float64 `json:"p50_ms"`
+	P90Ms   float64 `json:"p90_ms"`
+	P99Ms   float64 `json:"p99_ms"`
+	MaxMs   float64 `json:"max_ms"`
+}
+
+// SymbolMetrics are a collector's counters for one symbol
+type SymbolMetrics struct {
+	Symbol     string       `json:"symbol"`
+	Ticks      int64        `json:"ticks"`
+	Bars       int64        `json:"bars"`
+	LastTickAt *time.Time   `json:"last_tick_at,omitempty"`
+	Latency    LatencyStats `json:"tick_latency"`
+}
+
+// latencies keeps the most recent latency samples in a ring
+type latencies struct {
+	samples []time.Duration
+	next    int
+}
+
+func (l *latencies) add(d time.Duration, size int) {
+	if len(l.samples) < size {
+		l.samples = append(l.samples, d)
+		return
+	}
+	l.samples[l.next] = d
+	l.next = (l.next + 1) % size
+}
+
+// stats computes nearest-rank percentiles of the samples
+func (l *latencies) stats() LatencyStats {
+	stats := LatencyStats{Samples: len(l.samples)}
+	if len(l.samples) == 0 {
+		return stats
+	}
+
+	sorted := append([]time.Duration{}, l.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	percentile := func(p float64) float64 {
+		rank := int(p*float64(len(sorted))+0.999999) - 1
+		rank = max(0, min(rank, len(sorted)-1))
+		return float64(sorted[rank]) / float64(time.Millisecond)
+	}
+
+	stats.P50Ms = percentile(0.50)
+	stats.P90Ms = percentile(0.90)
+	stats.P99Ms = percentile(0.99)
+	stats.MaxMs = float64(sorted[len(sorted)-1]) / float64(time.Millisecond)
+	return stats
+}
+
+// symbolCounters are the counters of one symbol
+type symbolCounters struct {
+	ticks    int64
+	bars     int64
+	lastTick time.Time
+	latency  latencies
+}
+
+// symbolTracker counts each symbol's ticks and bars and keeps their recent
+// tick-to-database latencies. It is safe for concurrent use.
+type symbolTracker struct {
+	mu      sync.Mutex
+	symbols map[string]*symbolCounters
+	latency latencies // Every symbol's
+}
+
+func newSymbolTracker() *symbolTracker {
+	return &symbolTracker{symbols: make(map[string]*symbolCounters)}
+}
+
+// counters returns a symbol's counters; callers must hold t.mu
+func (t *symbolTracker) counters(symbol string) *symbolCounters {
+	c, ok := t.symbols[symbol]
+	if !ok {
+		c = &symbolCounters{}
+		t.symbols[symbol] = c
+	}
+	return c
+}
+
+// recordTick counts a tick of symbol received at
+func (t *symbolTracker) recordTick(symbol string, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c := t.counters(symbol)
+	c.ticks++
+	c.lastTick = at
+}
+
+// recordBar counts a stored bar of symbol
+func (t *symbolTracker) recordBar(symbol string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.counters(symbol).bars++
+}
+
+// recordLatency records the time a tick of symbol took to be stored
+func (t *symbolTracker) recordLatency(symbol string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.counters(symbol).latency.add(d, symbolLatencyWindow)
+	t.latency.add(d, latencyWindow)
+}
+
+// lastTicks returns when each symbol last ticked
+func (t *symbolTracker) lastTicks() map[string]time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ticks := make(map[string]time.Time, len(t.symbols))
+	for symbol, c := range t.symbols {
+		if !c.lastTick.IsZero() {
+			ticks[symbol] = c.lastTick
+		}
+	}
+	return ticks
+}
+
+// symbolMetrics returns every symbol's counters, ordered by symbol
+func (t *symbolTracker) symbolMetrics() []SymbolMetrics {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	metrics := make([]SymbolMetrics, 0, len(t.symbols))
+	for symbol, c := range t.symbols {
+		m := SymbolMetrics{
+			Symbol:  symbol,
+			Ticks:   c.ticks,
+			Bars:    c.bars,
+			Latency: c.latency.stats(),
+		}
+		if !c.lastTick.IsZero() {
+			lastTick := c.lastTick
+			m.LastTickAt = &lastTick
+		}
+		metrics = append(metrics, m)
+	}
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].Symbol < metrics[j].Symbol })
+	return metrics
+}
+
+// latencyStats returns the latency percentiles of every symbol's recent
+// ticks together
+func (t *symbolTracker) latencyStats() LatencyStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.latency.stats()
+}
@@ -0,0 +1,111 @@
+package collector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyStats(t *testing.T) {
+	ms := func(n ...int) []time.Duration {
+		durations := make([]time.Duration, len(n))
+		for i, v := range n {
+			durations[i] = time.Duration(v) * time.Millisecond
+		}
+		return durations
+	}
+	oneToHundred := make([]int, 100)
+	for i := range oneToHundred {
+		oneToHundred[i] = 100 - i
+	}
+
+	tests := []struct {
+		name    string
+		samples []time.Duration
+		size    int
+		want    LatencyStats
+	}{
+		{
+			name: "empty",
+			size: 10,
+			want: LatencyStats{},
+		},
+		{
+			name:    "one sample",
+			samples: ms(40),
+			size:    10,
+			want:    LatencyStats{Samples: 1, P50Ms: 40, P90Ms: 40, P99Ms: 40, MaxMs: 40},
+		},
+		{
+			name:    "nearest rank",
+			samples: ms(oneToHundred...),
+			size:    100,
+			want:    LatencyStats{Samples: 100, P50Ms: 50, P90Ms: 90, P99Ms: 99, MaxMs: 100},
+		},
+		{
+			name:    "window keeps the latest",
+			samples: ms(1000, 1000, 10, 20, 30, 40),
+			size:    4,
+			want:    LatencyStats{Samples: 4, P50Ms: 20, P90Ms: 40, P99Ms: 40, MaxMs: 40},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var l latencies
+			for _, d := range tt.samples {
+				l.add(d, tt.size)
+			}
+			if got := l.stats(); got != tt.want {
+				t.Errorf("stats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSymbolTracker(t *testing.T) {
+	at := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	tracker := newSymbolTracker()
+
+	tracker.recordTick("TCS", at)
+	tracker.recordTick("RELIANCE", at)
+	tracker.recordTick("RELIANCE", at.Add(time.Second))
+	tracker.recordLatency("RELIANCE", 10*time.Millisecond)
+	tracker.recordLatency("TCS", 30*time.Millisecond)
+	tracker.recordBar("RELIANCE")
+	tracker.recordBar("INFY") // Bar without ticks
+
+	got := tracker.symbolMetrics()
+	want := []struct {
+		symbol    string
+		ticks     int64
+		bars      int64
+		lastTick  time.Time
+		latencies int
+	}{
+		{symbol: "INFY", bars: 1},
+		{symbol: "RELIANCE", ticks: 2, bars: 1, lastTick: at.Add(time.Second), latencies: 1},
+		{symbol: "TCS", ticks: 1, lastTick: at, latencies: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("symbolMetrics() returned %d symbols, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		m := got[i]
+		if m.Symbol != w.symbol || m.Ticks != w.ticks || m.Bars != w.bars || m.Latency.Samples != w.latencies {
+			t.Errorf("symbolMetrics()[%d] = %+v, want %+v", i, m, w)
+		}
+		switch {
+		case w.lastTick.IsZero() && m.LastTickAt != nil:
+			t.Errorf("%s LastTickAt = %v, want none", w.symbol, *m.LastTickAt)
+		case !w.lastTick.IsZero() && (m.LastTickAt == nil || !m.LastTickAt.Equal(w.lastTick)):
+			t.Errorf("%s LastTickAt = %v, want %v", w.symbol, m.LastTickAt, w.lastTick)
+		}
+	}
+
+	if ticks := tracker.lastTicks(); len(ticks) != 2 {
+		t.Errorf("lastTicks() = %v, want RELIANCE and TCS only", ticks)
+	}
+	if stats := tracker.latencyStats(); stats.Samples != 2 || stats.MaxMs != 30 {
+		t.Errorf("latencyStats() = %+v, want 2 samples up to 30ms", stats)
+	}
+}
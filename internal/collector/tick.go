@@ -0,0 +1,67 @@
+package collector
+
+import (
+	"errors"
+	"time"
+)
+
+// Subscription modes understood by TickSource.SetMode
+const (
+	ModeLTP   = "ltp"
+	ModeQuote = "quote"
+	ModeFull  = "full"
+)
+
+// ErrReconnectFailed is reported through OnError when a source gives up reconnecting
+var ErrReconnectFailed = errors.New("tick source reconnection failed")
+
+// Tick is a broker-neutral market tick consumed by the candle builders
+type Tick struct {
+	InstrumentToken uint32
+	LastPrice       float64
+	LastQuantity    int64   // Quantity traded since the previous tick
+	Volume          int64   // Cumulative day volume
+	OI              int64   // Open interest, for futures and options
+	Bid             float64 // Best bid, full mode only; 0 if unknown
+	Ask             float64 // Best ask, full mode only; 0 if unknown
+	Timestamp       time.Time
+}
+
+// TickSource streams market ticks into a DataCollector. Implementations:
+// ZerodhaTickSource (Kite WebSocket), FyersTickSource (Fyers data socket).
+type TickSource interface {
+	// Name identifies the source on the ticks and bars it produces ("zerodha")
+	Name() string
+
+	// Connect starts streaming in the background
+	Connect() error
+	// Close stops streaming
+	Close()
+
+	Subscribe(tokens []uint32) error
+	Unsubscribe(tokens []uint32) error
+	SetMode(mode string, tokens []uint32) error
+
+	// Callbacks must be set before Connect
+	OnTick(fn func(Tick))
+	OnConnect(fn func())
+	OnError(fn func(error))
+}
+
+// CredentialSetter is implemented by sources that authenticate with a
+// broker access token. New credentials apply from the next Connect.
+type CredentialSetter interface {
+	SetCredentials(apiKey, accessToken string)
+}
+
+// ConnectionReporter is implemented by sources that hold a streaming
+// connection and know whether it is up
+type ConnectionReporter interface {
+	Connected() bool
+}
+
+// SymbolRegistrar is implemented by sources that subscribe by symbol rather
+// than instrument token and need the token -> symbol mapping
+type SymbolRegistrar interface {
+	RegisterSymbol(token uint32, exchange, symbol string)
+}
@@ -0,0 +1,112 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// TickWriterConfig configures how a real collector batches its tick writes
+type TickWriterConfig struct {
+	BatchSize     int           // Ticks written together; a full batch is written at once
+	FlushInterval time.Duration // Longest a tick waits in a partial batch
+}
+
+// DefaultTickWriterConfig returns batches of up to 500 ticks, written at
+// least every second
+func DefaultTickWriterConfig() TickWriterConfig {
+	return TickWriterConfig{
+		BatchSize:     500,
+		FlushInterval: time.Second,
+	}
+}
+
+// Validate checks the settings are in range
+func (cfg TickWriterConfig) Validate() error {
+	if cfg.BatchSize < 1 || cfg.BatchSize > 10000 {
+		return fmt.Errorf("batch size must be between 1 and 10000")
+	}
+	if cfg.FlushInterval <= 0 {
+		return fmt.Errorf("flush interval must be positive")
+	}
+	return nil
+}
+
+// tickWriter buffers ticks and writes them in batches, so a busy feed costs
+// a COPY per batch rather than an INSERT per tick. It is safe for
+// concurrent use.
+type tickWriter struct {
+	insert  func(ticks []database.TickData) error
+	written func(ticks []database.TickData, err error) // Called after every write
+
+	mu      sync.Mutex
+	config  TickWriterConfig
+	pending []database.TickData
+}
+
+func newTickWriter(insert func([]database.TickData) error, written func([]database.TickData, error)) *tickWriter {
+	return &tickWriter{
+		insert:  insert,
+		written: written,
+		config:  DefaultTickWriterConfig(),
+	}
+}
+
+// setConfig applies from the next tick added
+func (w *tickWriter) setConfig(cfg TickWriterConfig) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.config = cfg
+}
+
+// add queues a tick, writing the batch if it's full
+func (w *tickWriter) add(tick database.TickData) {
+	w.mu.Lock()
+	w.pending = append(w.pending, tick)
+	full := len(w.pending) >= w.config.BatchSize
+	w.mu.Unlock()
+
+	if full {
+		w.flush()
+	}
+}
+
+// flush writes the queued ticks
+func (w *tickWriter) flush() {
+	w.mu.Lock()
+	ticks := w.pending
+	w.pending = nil
+	w.mu.Unlock()
+
+	if len(ticks) == 0 {
+		return
+	}
+
+	err := w.insert(ticks)
+	if w.written != nil {
+		w.written(ticks, err)
+	}
+}
+
+// run writes partial batches every flush interval until ctx is done. The
+// ticks still queued then are left for a final flush.
+func (w *tickWriter) run(ctx context.Context) {
+	w.mu.Lock()
+	interval := w.config.FlushInterval
+	w.mu.Unlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.flush()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package collector
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// recordingInserter records the batches a tick writer inserts
+type recordingInserter struct {
+	mu      sync.Mutex
+	batches [][]database.TickData
+	err     error
+}
+
+func (r *recordingInserter) insert(ticks []database.TickData) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, ticks)
+	return r.err
+}
+
+func (r *recordingInserter) sizes() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sizes := make([]int, len(r.batches))
+	for i, batch := range r.batches {
+		sizes[i] = len(batch)
+	}
+	return sizes
+}
+
+func TestTickWriterConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  TickWriterConfig
+		wantErr bool
+	}{
+		{name: "default", config: DefaultTickWriterConfig()},
+		{name: "unbatched", config: TickWriterConfig{BatchSize: 1, FlushInterval: time.Second}},
+		{name: "zero batch", config: TickWriterConfig{BatchSize: 0, FlushInterval: time.Second}, wantErr: true},
+		{name: "huge batch", config: TickWriterConfig{BatchSize: 20000, FlushInterval: time.Second}, wantErr: true},
+		{name: "zero interval", config: TickWriterConfig{BatchSize: 100}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTickWriterBatches(t *testing.T) {
+	tests := []struct {
+		name        string
+		batchSize   int
+		ticks       int
+		wantBatches []int // Sizes, the last from the final flush
+	}{
+		{name: "partial batch", batchSize: 10, ticks: 3, wantBatches: []int{3}},
+		{name: "full batches", batchSize: 2, ticks: 4, wantBatches: []int{2, 2}},
+		{name: "full then partial", batchSize: 3, ticks: 7, wantBatches: []int{3, 3, 1}},
+		{name: "unbatched", batchSize: 1, ticks: 2, wantBatches: []int{1, 1}},
+		{name: "nothing", batchSize: 5, ticks: 0, wantBatches: []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inserter := &recordingInserter{}
+			written := 0
+			w := newTickWriter(inserter.insert, func(ticks []database.TickData, err error) {
+				written += len(ticks)
+			})
+			w.setConfig(TickWriterConfig{BatchSize: tt.batchSize, FlushInterval: time.Hour})
+
+			for i := 0; i < tt.ticks; i++ {
+				w.add(database.TickData{Symbol: "RELIANCE", Price: float64(100 + i)})
+			}
+			w.flush()
+
+			got := inserter.sizes()
+			if len(got) != len(tt.wantBatches) {
+				t.Fatalf("batches = %v, want %v", got, tt.wantBatches)
+			}
+			for i := range got {
+				if got[i] != tt.wantBatches[i] {
+					t.Errorf("batches = %v, want %v", got, tt.wantBatches)
+					break
+				}
+			}
+			if written != tt.ticks {
+				t.Errorf("written callback saw %d ticks, want %d", written, tt.ticks)
+			}
+		})
+	}
+}
+
+func TestTickWriterReportsFailures(t *testing.T) {
+	inserter := &recordingInserter{err: errors.New("connection refused")}
+	var failed int
+	w := newTickWriter(inserter.insert, func(ticks []database.TickData, err error) {
+		if err != nil {
+			failed += len(ticks)
+		}
+	})
+
+	w.add(database.TickData{Symbol: "TCS"})
+	w.add(database.TickData{Symbol: "INFY"})
+	w.flush()
+
+	if failed != 2 {
+		t.Errorf("failed ticks = %d, want 2", failed)
+	}
+}
+
+func TestStoreTick(t *testing.T) {
+	instruments := map[uint32]*database.Instrument{
+		500325:  {Exchange: "BSE"},
+		9604354: {Exchange: "NFO"},
+	}
+
+	tests := []struct {
+		name         string
+		token        uint32
+		registeredOn string
+		tick         Tick
+		wantExchange string
+		wantBid      float64 // 0 for none
+		wantAsk      float64
+		wantOI       int64
+	}{
+		{
+			name:         "full mode equity",
+			token:        738561,
+			registeredOn: "NSE",
+			tick:         Tick{LastPrice: 2500, LastQuantity: 10, Bid: 2499.95, Ask: 2500.05},
+			wantExchange: "NSE",
+			wantBid:      2499.95,
+			wantAsk:      2500.05,
+		},
+		{
+			name:         "exchange from the instrument table",
+			token:        500325,
+			registeredOn: "NSE",
+			tick:         Tick{LastPrice: 2500, LastQuantity: 5},
+			wantExchange: "BSE",
+		},
+		{
+			name:         "option with open interest",
+			token:        9604354,
+			registeredOn: "NSE",
+			tick:         Tick{LastPrice: 120.5, LastQuantity: 50, OI: 125000, Bid: 120.4, Ask: 120.6},
+			wantExchange: "NFO",
+			wantBid:      120.4,
+			wantAsk:      120.6,
+			wantOI:       125000,
+		},
+		{
+			name:         "index missing from the instrument table",
+			token:        256265,
+			registeredOn: "NSE",
+			tick:         Tick{LastPrice: 22000},
+			wantExchange: "NSE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dc := NewDataCollectorWithSource(&database.Database{}, idleTickSource{})
+			dc.lookupInstrument = func(token uint32) (*database.Instrument, error) {
+				return instruments[token], nil
+			}
+			inserter := &recordingInserter{}
+			dc.ticks = newTickWriter(inserter.insert, dc.ticksWritten)
+			publisher := &recordingPublisher{}
+			dc.SetPublisher(publisher)
+
+			dc.RegisterSymbol(tt.token, tt.registeredOn, "TEST")
+			tt.tick.InstrumentToken = tt.token
+			tt.tick.Timestamp = time.Now()
+			dc.storeTick(tt.tick)
+			dc.ticks.flush()
+
+			if len(inserter.batches) != 1 || len(inserter.batches[0]) != 1 {
+				t.Fatalf("inserted batches = %v, want one tick", inserter.sizes())
+			}
+			got := inserter.batches[0][0]
+			if got.Exchange != tt.wantExchange || got.Price != tt.tick.LastPrice || got.Quantity != tt.tick.LastQuantity || got.Source != "test" {
+				t.Errorf("stored %s %v x %d from %s, want %s %v x %d from test",
+					got.Exchange, got.Price, got.Quantity, got.Source, tt.wantExchange, tt.tick.LastPrice, tt.tick.LastQuantity)
+			}
+			if !floatPtrEquals(got.Bid, tt.wantBid) || !floatPtrEquals(got.Ask, tt.wantAsk) {
+				t.Errorf("bid/ask = %v/%v, want %v/%v", got.Bid, got.Ask, tt.wantBid, tt.wantAsk)
+			}
+			if (got.OI == nil) != (tt.wantOI == 0) || (got.OI != nil && *got.OI != tt.wantOI) {
+				t.Errorf("oi = %v, want %d", got.OI, tt.wantOI)
+			}
+
+			if len(publisher.ticks) != 1 {
+				t.Errorf("published %d ticks, want 1", len(publisher.ticks))
+			}
+			if stats := dc.symbols.latencyStats(); stats.Samples != 1 {
+				t.Errorf("latency samples = %d, want 1", stats.Samples)
+			}
+		})
+	}
+}
+
+// floatPtrEquals reports whether p points to want, or is nil for 0
+func floatPtrEquals(p *float64, want float64) bool {
+	if p == nil {
+		return want == 0
+	}
+	return *p == want
+}
@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/trading-chitti/market-bridge/internal/database"
 	"github.com/trading-chitti/market-bridge/internal/metrics"
+	"github.com/trading-chitti/market-bridge/internal/quotes"
 )
 
 // CollectorInterface defines the interface that all collectors must implement
@@ -23,6 +25,43 @@ type UnifiedCollectorManager struct {
 	realCollectors  map[string]*DataCollector
 	mockCollectors  map[string]*MockDataCollector
 	mu              sync.RWMutex
+
+	// Called with errors reported by real collectors
+	errorHandler    func(name string, err error)
+
+	// Receives every collector's ticks and bars
+	publisher       Publisher
+	qualityGate     QualityGate
+	quoteStore      *quotes.Store
+	mockConfig      MockConfig
+	tickWriter      TickWriterConfig
+
+	// Watchlists each collector follows, with the symbols they resolved to
+	// on the last refresh, the symbols subscribed directly, and the mode
+	// and auto-start flag stored with the collector. Lock watchMu before
+	// mu, never the other way round.
+	watchMu         sync.Mutex
+	watchlists      map[string]map[string][]string
+	directSymbols   map[string]map[string]bool
+	modes           map[string]string
+	autoStart       map[string]bool
+	restoring       bool
+	stopRefresh     chan struct{}
+
+	// Health watchdog state
+	healthMu        sync.Mutex
+	healthCfg       HealthConfig
+	marketOpenedAt  time.Time
+	marketWasOpen   bool
+	lastStatus      map[string]string
+	restarts        map[string]int
+	lastRestart     map[string]time.Time
+	stopHealth      chan struct{}
+
+	// Replays of stored sessions, by ID
+	replayMu        sync.Mutex
+	replays         map[string]*Replay
+	replaySeq       int
 }
 
 // NewUnifiedCollectorManager creates a new unified collector manager
@@ -31,11 +70,67 @@ func NewUnifiedCollectorManager(db *database.Database) *UnifiedCollectorManager
 		db:             db,
 		realCollectors: make(map[string]*DataCollector),
 		mockCollectors: make(map[string]*MockDataCollector),
+		watchlists:     make(map[string]map[string][]string),
+		directSymbols:  make(map[string]map[string]bool),
+		modes:          make(map[string]string),
+		autoStart:      make(map[string]bool),
+		healthCfg:      DefaultHealthConfig(),
+		mockConfig:     DefaultMockConfig(),
+		tickWriter:     DefaultTickWriterConfig(),
+		lastStatus:     make(map[string]string),
+		restarts:       make(map[string]int),
+		lastRestart:    make(map[string]time.Time),
+		replays:        make(map[string]*Replay),
+	}
+}
+
+// SetErrorHandler sets a callback for errors reported by real collectors
+// created afterwards, e.g. a lost ticker connection
+func (ucm *UnifiedCollectorManager) SetErrorHandler(fn func(name string, err error)) {
+	ucm.mu.Lock()
+	defer ucm.mu.Unlock()
+	ucm.errorHandler = fn
+}
+
+// SetMockConfig sets how mock collectors, current and created afterwards,
+// generate prices and volumes
+func (ucm *UnifiedCollectorManager) SetMockConfig(cfg MockConfig) {
+	ucm.mu.Lock()
+	defer ucm.mu.Unlock()
+
+	ucm.mockConfig = cfg
+	for _, collector := range ucm.mockCollectors {
+		collector.SetConfig(cfg)
+	}
+}
+
+// SetTickWriterConfig sets how real collectors, current and created
+// afterwards, batch their tick writes. Running collectors pick up a new
+// flush interval when next started.
+func (ucm *UnifiedCollectorManager) SetTickWriterConfig(cfg TickWriterConfig) {
+	ucm.mu.Lock()
+	defer ucm.mu.Unlock()
+
+	ucm.tickWriter = cfg
+	for _, collector := range ucm.realCollectors {
+		collector.SetTickWriterConfig(cfg)
 	}
 }
 
 // CreateRealCollector creates a new real data collector (Zerodha WebSocket)
 func (ucm *UnifiedCollectorManager) CreateRealCollector(name, apiKey, accessToken string) error {
+	if err := ucm.createRealCollector(name, apiKey, accessToken); err != nil {
+		return err
+	}
+
+	ucm.watchMu.Lock()
+	defer ucm.watchMu.Unlock()
+	ucm.modes[name] = ModeFull
+	ucm.persist(name)
+	return nil
+}
+
+func (ucm *UnifiedCollectorManager) createRealCollector(name, apiKey, accessToken string) error {
 	ucm.mu.Lock()
 	defer ucm.mu.Unlock()
 
@@ -47,14 +142,46 @@ func (ucm *UnifiedCollectorManager) CreateRealCollector(name, apiKey, accessToke
 	}
 
 	collector := NewDataCollector(ucm.db, apiKey, accessToken)
+	collector.SetName(name)
+	collector.SetTickWriterConfig(ucm.tickWriter)
+	if handler := ucm.errorHandler; handler != nil {
+		collector.SetErrorHandler(func(err error) {
+			handler(name, err)
+		})
+	}
+	if ucm.publisher != nil {
+		collector.SetPublisher(ucm.publisher)
+	}
+	if ucm.qualityGate != nil {
+		collector.SetQualityGate(ucm.qualityGate)
+	}
+	if ucm.quoteStore != nil {
+		collector.SetQuoteStore(ucm.quoteStore)
+	}
 	ucm.realCollectors[name] = collector
 
 	log.Printf("✅ Created real collector: %s", name)
 	return nil
 }
 
-// CreateMockCollector creates a new mock data collector
+// CreateMockCollector creates a new mock data collector. Its symbols count
+// as subscribed directly.
 func (ucm *UnifiedCollectorManager) CreateMockCollector(name string, symbols []string) error {
+	if err := ucm.createMockCollector(name, symbols); err != nil {
+		return err
+	}
+
+	ucm.watchMu.Lock()
+	defer ucm.watchMu.Unlock()
+	ucm.directSymbols[name] = make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		ucm.directSymbols[name][symbol] = true
+	}
+	ucm.persist(name)
+	return nil
+}
+
+func (ucm *UnifiedCollectorManager) createMockCollector(name string, symbols []string) error {
 	ucm.mu.Lock()
 	defer ucm.mu.Unlock()
 
@@ -66,14 +193,30 @@ func (ucm *UnifiedCollectorManager) CreateMockCollector(name string, symbols []s
 	}
 
 	collector := NewMockDataCollector(ucm.db, name, symbols)
+	collector.SetConfig(ucm.mockConfig)
+	if ucm.publisher != nil {
+		collector.SetPublisher(ucm.publisher)
+	}
+	if ucm.qualityGate != nil {
+		collector.SetQualityGate(ucm.qualityGate)
+	}
 	ucm.mockCollectors[name] = collector
 
 	log.Printf("✅ Created mock collector: %s with %d symbols", name, len(symbols))
 	return nil
 }
 
-// StartCollector starts a collector (real or mock)
+// StartCollector starts a collector (real or mock) and marks it to start
+// again on boot
 func (ucm *UnifiedCollectorManager) StartCollector(name string) error {
+	if err := ucm.startCollector(name); err != nil {
+		return err
+	}
+	ucm.setAutoStart(name, true)
+	return nil
+}
+
+func (ucm *UnifiedCollectorManager) startCollector(name string) error {
 	ucm.mu.RLock()
 	var err error
 
@@ -101,8 +244,16 @@ func (ucm *UnifiedCollectorManager) StartCollector(name string) error {
 	return fmt.Errorf("collector '%s' not found", name)
 }
 
-// StopCollector stops a collector
+// StopCollector stops a collector so it no longer starts on boot
 func (ucm *UnifiedCollectorManager) StopCollector(name string) error {
+	if err := ucm.stopCollector(name); err != nil {
+		return err
+	}
+	ucm.setAutoStart(name, false)
+	return nil
+}
+
+func (ucm *UnifiedCollectorManager) stopCollector(name string) error {
 	ucm.mu.RLock()
 
 	// Check real collectors
@@ -125,8 +276,14 @@ func (ucm *UnifiedCollectorManager) StopCollector(name string) error {
 	return fmt.Errorf("collector '%s' not found", name)
 }
 
-// StopAll stops all collectors
+// StopAll stops all collectors and replays, the watchlist refresh and the
+// health watchdog. Collectors keep their auto-start flag, so running
+// collectors start again on boot.
 func (ucm *UnifiedCollectorManager) StopAll() {
+	ucm.stopHealthWatchdog()
+	ucm.stopWatchlistRefresh()
+	ucm.stopReplays()
+
 	ucm.mu.RLock()
 	defer ucm.mu.RUnlock()
 
@@ -169,7 +326,8 @@ func (ucm *UnifiedCollectorManager) ListCollectors() []map[string]interface{} {
 	return collectors
 }
 
-// GetCollectorMetrics returns metrics for a specific collector
+// GetCollectorMetrics returns metrics for a specific collector, including
+// its per-symbol metrics
 func (ucm *UnifiedCollectorManager) GetCollectorMetrics(name string) (map[string]interface{}, error) {
 	ucm.mu.RLock()
 	defer ucm.mu.RUnlock()
@@ -179,6 +337,7 @@ func (ucm *UnifiedCollectorManager) GetCollectorMetrics(name string) (map[string
 		metrics := collector.GetMetrics()
 		metrics["type"] = "real"
 		metrics["name"] = name
+		metrics["symbol_metrics"] = collector.SymbolMetrics()
 		return metrics, nil
 	}
 
@@ -187,6 +346,7 @@ func (ucm *UnifiedCollectorManager) GetCollectorMetrics(name string) (map[string
 		metrics := collector.GetMetrics()
 		metrics["type"] = "mock"
 		metrics["name"] = name
+		metrics["symbol_metrics"] = collector.SymbolMetrics()
 		return metrics, nil
 	}
 
@@ -215,8 +375,39 @@ func (ucm *UnifiedCollectorManager) GetAllMetrics() map[string]interface{} {
 	return metrics
 }
 
-// SubscribeSymbols subscribes to symbols (real collectors only)
+// SubscribeSymbols subscribes to symbols. They stay subscribed when a
+// followed watchlist drops them.
 func (ucm *UnifiedCollectorManager) SubscribeSymbols(collectorName string, symbols []string) error {
+	ucm.watchMu.Lock()
+	defer ucm.watchMu.Unlock()
+
+	// Symbols of followed watchlists are already subscribed
+	followed := ucm.followedSymbols(collectorName)
+	pending := []string{}
+	for _, symbol := range symbols {
+		if !followed[symbol] {
+			pending = append(pending, symbol)
+		}
+	}
+	if len(pending) > 0 {
+		if err := ucm.subscribe(collectorName, pending); err != nil {
+			return err
+		}
+	}
+
+	if ucm.directSymbols[collectorName] == nil {
+		ucm.directSymbols[collectorName] = make(map[string]bool)
+	}
+	for _, symbol := range symbols {
+		ucm.directSymbols[collectorName][symbol] = true
+	}
+	ucm.persist(collectorName)
+	return nil
+}
+
+// subscribe subscribes a real collector to symbols' instrument tokens, or
+// adds them to a mock collector
+func (ucm *UnifiedCollectorManager) subscribe(collectorName string, symbols []string) error {
 	ucm.mu.RLock()
 	defer ucm.mu.RUnlock()
 
@@ -225,19 +416,14 @@ func (ucm *UnifiedCollectorManager) SubscribeSymbols(collectorName string, symbo
 		// Get instrument tokens from database
 		tokens := []uint32{}
 		for _, symbol := range symbols {
-			// Try NSE first
-			token, err := ucm.db.GetInstrumentToken("NSE", symbol)
-			if err != nil || token == 0 {
-				// Try BSE
-				token, err = ucm.db.GetInstrumentToken("BSE", symbol)
-				if err != nil || token == 0 {
-					log.Printf("⚠️  Symbol not found: %s", symbol)
-					continue
-				}
+			token, exchange, stored, ok := resolveInstrument(ucm.db, symbol)
+			if !ok {
+				log.Printf("⚠️  Symbol not found: %s", symbol)
+				continue
 			}
 
 			tokens = append(tokens, token)
-			collector.RegisterSymbol(token, "NSE", symbol)
+			collector.RegisterSymbol(token, exchange, stored)
 		}
 
 		if len(tokens) == 0 {
@@ -256,8 +442,37 @@ func (ucm *UnifiedCollectorManager) SubscribeSymbols(collectorName string, symbo
 	return fmt.Errorf("collector '%s' not found", collectorName)
 }
 
-// UnsubscribeSymbols unsubscribes from symbols
+// UnsubscribeSymbols unsubscribes from symbols. Symbols of followed
+// watchlists stay subscribed until the watchlist drops them.
 func (ucm *UnifiedCollectorManager) UnsubscribeSymbols(collectorName string, symbols []string) error {
+	ucm.watchMu.Lock()
+	defer ucm.watchMu.Unlock()
+
+	followed := ucm.followedSymbols(collectorName)
+	pending := []string{}
+	for _, symbol := range symbols {
+		delete(ucm.directSymbols[collectorName], symbol)
+		if !followed[symbol] {
+			pending = append(pending, symbol)
+		}
+	}
+	if len(pending) == 0 {
+		_, err := ucm.GetCollectorType(collectorName)
+		if err == nil {
+			ucm.persist(collectorName)
+		}
+		return err
+	}
+	if err := ucm.unsubscribe(collectorName, pending); err != nil {
+		return err
+	}
+	ucm.persist(collectorName)
+	return nil
+}
+
+// unsubscribe unsubscribes a real collector from symbols' instrument
+// tokens, or removes them from a mock collector
+func (ucm *UnifiedCollectorManager) unsubscribe(collectorName string, symbols []string) error {
 	ucm.mu.RLock()
 	defer ucm.mu.RUnlock()
 
@@ -265,14 +480,9 @@ func (ucm *UnifiedCollectorManager) UnsubscribeSymbols(collectorName string, sym
 	if collector, exists := ucm.realCollectors[collectorName]; exists {
 		tokens := []uint32{}
 		for _, symbol := range symbols {
-			token, err := ucm.db.GetInstrumentToken("NSE", symbol)
-			if err != nil || token == 0 {
-				token, err = ucm.db.GetInstrumentToken("BSE", symbol)
-				if err != nil || token == 0 {
-					continue
-				}
+			if token, _, _, ok := resolveInstrument(ucm.db, symbol); ok {
+				tokens = append(tokens, token)
 			}
-			tokens = append(tokens, token)
 		}
 
 		return collector.Unsubscribe(tokens)
@@ -287,8 +497,48 @@ func (ucm *UnifiedCollectorManager) UnsubscribeSymbols(collectorName string, sym
 	return fmt.Errorf("collector '%s' not found", collectorName)
 }
 
-// DeleteCollector removes a collector
+// GetSubscribedSymbols returns the unique symbols subscribed on real collectors
+func (ucm *UnifiedCollectorManager) GetSubscribedSymbols() []string {
+	ucm.mu.RLock()
+	defer ucm.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	symbols := []string{}
+
+	for _, collector := range ucm.realCollectors {
+		for _, symbol := range collector.GetSubscribedSymbols() {
+			if !seen[symbol] {
+				seen[symbol] = true
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+
+	return symbols
+}
+
+// DeleteCollector removes a collector along with the watchlists it follows
+// and its stored definition
 func (ucm *UnifiedCollectorManager) DeleteCollector(name string) error {
+	if err := ucm.deleteCollector(name); err != nil {
+		return err
+	}
+	ucm.forgetHealth(name)
+
+	ucm.watchMu.Lock()
+	defer ucm.watchMu.Unlock()
+	delete(ucm.watchlists, name)
+	delete(ucm.directSymbols, name)
+	delete(ucm.modes, name)
+	delete(ucm.autoStart, name)
+
+	if err := ucm.db.DeleteCollectorConfig(name); err != nil {
+		log.Printf("⚠️  Failed to delete stored collector %s: %v", name, err)
+	}
+	return nil
+}
+
+func (ucm *UnifiedCollectorManager) deleteCollector(name string) error {
 	ucm.mu.Lock()
 	defer ucm.mu.Unlock()
 
@@ -354,3 +604,22 @@ func (ucm *UnifiedCollectorManager) updateActiveCollectorsMetric() {
 
 	metrics.SetActiveCollectors(activeCount)
 }
+
+// resolveInstrument returns the instrument token a symbol is subscribed by,
+// and the exchange and symbol its ticks and bars are stored under. Indices
+// (NIFTY, BANKNIFTY, VIX) are stored on database.IndexExchange under their
+// tradingsymbol; anything else is looked up on NSE, then BSE.
+func resolveInstrument(db *database.Database, symbol string) (uint32, string, string, bool) {
+	if index, ok := database.LookupIndex(symbol); ok {
+		return index.InstrumentToken, database.IndexExchange, index.Symbol, true
+	}
+
+	token, err := db.GetInstrumentToken("NSE", symbol)
+	if err != nil || token == 0 {
+		token, err = db.GetInstrumentToken("BSE", symbol)
+		if err != nil || token == 0 {
+			return 0, "", "", false
+		}
+	}
+	return token, "NSE", symbol, true
+}
@@ -0,0 +1,222 @@
+package collector
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/watchlist"
+)
+
+// DefaultWatchlistRefreshInterval is how often followed watchlists are
+// re-resolved
+const DefaultWatchlistRefreshInterval = 5 * time.Minute
+
+// resolveWatchlistSymbols returns a watchlist's current symbols
+func resolveWatchlistSymbols(name string) ([]string, error) {
+	wl, err := watchlist.Resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	if wl == nil {
+		return nil, fmt.Errorf("watchlist not found: %s", name)
+	}
+	return removeDuplicates(wl.Symbols), nil
+}
+
+// SubscribeWatchlist subscribes a collector to a watchlist's symbols and
+// keeps following it: each refresh subscribes symbols that joined the
+// watchlist and unsubscribes those that left. Returns the symbols it
+// resolved to.
+func (ucm *UnifiedCollectorManager) SubscribeWatchlist(collectorName, name string) ([]string, error) {
+	if _, err := ucm.GetCollectorType(collectorName); err != nil {
+		return nil, err
+	}
+
+	symbols, err := resolveWatchlistSymbols(name)
+	if err != nil {
+		return nil, err
+	}
+
+	ucm.watchMu.Lock()
+	defer ucm.watchMu.Unlock()
+
+	if _, ok := ucm.watchlists[collectorName][name]; ok {
+		return nil, fmt.Errorf("collector '%s' already follows watchlist %s", collectorName, name)
+	}
+
+	subscribed := ucm.followedSymbols(collectorName)
+	for symbol := range ucm.directSymbols[collectorName] {
+		subscribed[symbol] = true
+	}
+	pending := []string{}
+	for _, symbol := range symbols {
+		if !subscribed[symbol] {
+			pending = append(pending, symbol)
+		}
+	}
+	if len(pending) > 0 {
+		if err := ucm.subscribe(collectorName, pending); err != nil {
+			return nil, err
+		}
+	}
+
+	if ucm.watchlists[collectorName] == nil {
+		ucm.watchlists[collectorName] = make(map[string][]string)
+	}
+	ucm.watchlists[collectorName][name] = symbols
+	ucm.persist(collectorName)
+
+	log.Printf("📋 Collector %s follows watchlist %s (%d symbols)", collectorName, name, len(symbols))
+	return symbols, nil
+}
+
+// UnsubscribeWatchlist stops following a watchlist, unsubscribing its
+// symbols unless another followed watchlist or a direct subscription still
+// needs them
+func (ucm *UnifiedCollectorManager) UnsubscribeWatchlist(collectorName, name string) error {
+	ucm.watchMu.Lock()
+	defer ucm.watchMu.Unlock()
+
+	symbols, ok := ucm.watchlists[collectorName][name]
+	if !ok {
+		return fmt.Errorf("collector '%s' doesn't follow watchlist %s", collectorName, name)
+	}
+	delete(ucm.watchlists[collectorName], name)
+
+	needed := ucm.followedSymbols(collectorName)
+	pending := []string{}
+	for _, symbol := range symbols {
+		if !needed[symbol] && !ucm.directSymbols[collectorName][symbol] {
+			pending = append(pending, symbol)
+		}
+	}
+	if len(pending) > 0 {
+		if err := ucm.unsubscribe(collectorName, pending); err != nil {
+			return err
+		}
+	}
+	ucm.persist(collectorName)
+
+	log.Printf("📋 Collector %s stopped following watchlist %s", collectorName, name)
+	return nil
+}
+
+// GetFollowedWatchlists returns the watchlists a collector follows with the
+// symbols they resolved to on the last refresh
+func (ucm *UnifiedCollectorManager) GetFollowedWatchlists(collectorName string) map[string][]string {
+	ucm.watchMu.Lock()
+	defer ucm.watchMu.Unlock()
+
+	followed := make(map[string][]string, len(ucm.watchlists[collectorName]))
+	for name, symbols := range ucm.watchlists[collectorName] {
+		followed[name] = append([]string{}, symbols...)
+	}
+	return followed
+}
+
+// RefreshWatchlists re-resolves every followed watchlist and updates the
+// collectors' subscriptions with the symbols that joined or left. A
+// watchlist that fails to resolve keeps its previous symbols.
+func (ucm *UnifiedCollectorManager) RefreshWatchlists() {
+	ucm.watchMu.Lock()
+	defer ucm.watchMu.Unlock()
+
+	for collectorName, lists := range ucm.watchlists {
+		before := ucm.followedSymbols(collectorName)
+
+		for name := range lists {
+			symbols, err := resolveWatchlistSymbols(name)
+			if err != nil {
+				log.Printf("⚠️  Failed to refresh watchlist %s for collector %s: %v", name, collectorName, err)
+				continue
+			}
+			lists[name] = symbols
+		}
+
+		after := ucm.followedSymbols(collectorName)
+		direct := ucm.directSymbols[collectorName]
+
+		var added, removed []string
+		for symbol := range after {
+			if !before[symbol] && !direct[symbol] {
+				added = append(added, symbol)
+			}
+		}
+		for symbol := range before {
+			if !after[symbol] && !direct[symbol] {
+				removed = append(removed, symbol)
+			}
+		}
+		if len(added) == 0 && len(removed) == 0 {
+			continue
+		}
+		sort.Strings(added)
+		sort.Strings(removed)
+
+		if len(added) > 0 {
+			if err := ucm.subscribe(collectorName, added); err != nil {
+				log.Printf("⚠️  Failed to subscribe %s to %v: %v", collectorName, added, err)
+			}
+		}
+		if len(removed) > 0 {
+			if err := ucm.unsubscribe(collectorName, removed); err != nil {
+				log.Printf("⚠️  Failed to unsubscribe %s from %v: %v", collectorName, removed, err)
+			}
+		}
+		log.Printf("🔄 Collector %s watchlists refreshed: +%d -%d symbols", collectorName, len(added), len(removed))
+	}
+}
+
+// StartWatchlistRefresh re-resolves followed watchlists every interval
+// until StopAll
+func (ucm *UnifiedCollectorManager) StartWatchlistRefresh(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultWatchlistRefreshInterval
+	}
+
+	ucm.watchMu.Lock()
+	defer ucm.watchMu.Unlock()
+	if ucm.stopRefresh != nil {
+		return
+	}
+	stop := make(chan struct{})
+	ucm.stopRefresh = stop
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				ucm.RefreshWatchlists()
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	log.Printf("🔄 Collector watchlist refresh started (every %s)", interval)
+}
+
+func (ucm *UnifiedCollectorManager) stopWatchlistRefresh() {
+	ucm.watchMu.Lock()
+	defer ucm.watchMu.Unlock()
+	if ucm.stopRefresh != nil {
+		close(ucm.stopRefresh)
+		ucm.stopRefresh = nil
+	}
+}
+
+// followedSymbols returns the union of a collector's followed watchlists.
+// Callers hold watchMu.
+func (ucm *UnifiedCollectorManager) followedSymbols(collectorName string) map[string]bool {
+	symbols := make(map[string]bool)
+	for _, list := range ucm.watchlists[collectorName] {
+		for _, symbol := range list {
+			symbols[symbol] = true
+		}
+	}
+	return symbols
+}
@@ -0,0 +1,191 @@
+package collector
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+
+	kiteconnect "github.com/zerodha/gokiteconnect/v4"
+	"github.com/zerodha/gokiteconnect/v4/models"
+	kiteticker "github.com/zerodha/gokiteconnect/v4/ticker"
+)
+
+// ZerodhaTickSource streams ticks from the Kite Connect WebSocket
+type ZerodhaTickSource struct {
+	apiKey      string
+	accessToken string
+	ticker      *kiteticker.Ticker
+	connected   atomic.Bool
+
+	onTick    func(Tick)
+	onConnect func()
+	onError   func(error)
+}
+
+// NewZerodhaTickSource creates a Kite ticker based tick source
+func NewZerodhaTickSource(apiKey, accessToken string) *ZerodhaTickSource {
+	return &ZerodhaTickSource{
+		apiKey:      apiKey,
+		accessToken: accessToken,
+	}
+}
+
+// SetCredentials sets the API key and access token used from the next
+// Connect, e.g. after the daily login
+func (zs *ZerodhaTickSource) SetCredentials(apiKey, accessToken string) {
+	zs.apiKey = apiKey
+	zs.accessToken = accessToken
+}
+
+// OnTick sets the tick callback
+func (zs *ZerodhaTickSource) OnTick(fn func(Tick)) {
+	zs.onTick = fn
+}
+
+// OnConnect sets the callback fired after every (re)connect
+func (zs *ZerodhaTickSource) OnConnect(fn func()) {
+	zs.onConnect = fn
+}
+
+// OnError sets the error callback
+func (zs *ZerodhaTickSource) OnError(fn func(error)) {
+	zs.onError = fn
+}
+
+// Connect creates the Kite ticker and starts serving in the background
+func (zs *ZerodhaTickSource) Connect() error {
+	zs.ticker = kiteticker.New(zs.apiKey, zs.accessToken)
+
+	// Set up callbacks
+	zs.ticker.OnConnect(zs.handleConnect)
+	zs.ticker.OnTick(zs.handleTick)
+	zs.ticker.OnReconnect(zs.handleReconnect)
+	zs.ticker.OnNoReconnect(zs.handleNoReconnect)
+	zs.ticker.OnError(zs.handleError)
+	zs.ticker.OnClose(zs.handleClose)
+	zs.ticker.OnOrderUpdate(zs.handleOrderUpdate)
+
+	// Enable auto-reconnect
+	zs.ticker.SetAutoReconnect(true)
+	zs.ticker.SetReconnectMaxRetries(10)
+	zs.ticker.SetReconnectMaxDelay(60 * time.Second)
+
+	// Serve (blocking call)
+	go func() {
+		zs.ticker.Serve()
+	}()
+
+	return nil
+}
+
+// Name returns "zerodha"
+func (zs *ZerodhaTickSource) Name() string {
+	return "zerodha"
+}
+
+// Close stops the Kite ticker
+func (zs *ZerodhaTickSource) Close() {
+	if zs.ticker != nil {
+		zs.ticker.Stop()
+	}
+	zs.connected.Store(false)
+}
+
+// Connected reports whether the ticker WebSocket is up
+func (zs *ZerodhaTickSource) Connected() bool {
+	return zs.connected.Load()
+}
+
+// Subscribe subscribes to instrument tokens
+func (zs *ZerodhaTickSource) Subscribe(tokens []uint32) error {
+	if zs.ticker == nil {
+		return nil
+	}
+	return zs.ticker.Subscribe(tokens)
+}
+
+// Unsubscribe unsubscribes from instrument tokens
+func (zs *ZerodhaTickSource) Unsubscribe(tokens []uint32) error {
+	if zs.ticker == nil {
+		return nil
+	}
+	return zs.ticker.Unsubscribe(tokens)
+}
+
+// SetMode sets subscription mode (ltp, quote, full) for instruments
+func (zs *ZerodhaTickSource) SetMode(mode string, tokens []uint32) error {
+	if zs.ticker == nil {
+		return nil
+	}
+
+	switch mode {
+	case ModeQuote:
+		return zs.ticker.SetMode(kiteticker.ModeQuote, tokens)
+	case ModeFull:
+		return zs.ticker.SetMode(kiteticker.ModeFull, tokens)
+	default:
+		return zs.ticker.SetMode(kiteticker.ModeLTP, tokens)
+	}
+}
+
+// ============================================================================
+// KITE CALLBACKS
+// ============================================================================
+
+func (zs *ZerodhaTickSource) handleConnect() {
+	log.Println("✅ Connected to Kite Ticker")
+	zs.connected.Store(true)
+
+	if zs.onConnect != nil {
+		zs.onConnect()
+	}
+}
+
+func (zs *ZerodhaTickSource) handleTick(tick models.Tick) {
+	if zs.onTick == nil {
+		return
+	}
+
+	zs.onTick(Tick{
+		InstrumentToken: tick.InstrumentToken,
+		LastPrice:       tick.LastPrice,
+		LastQuantity:    int64(tick.LastTradedQuantity),
+		Volume:          int64(tick.VolumeTraded),
+		OI:              int64(tick.OI),
+		Bid:             tick.Depth.Buy[0].Price,
+		Ask:             tick.Depth.Sell[0].Price,
+		Timestamp:       tick.Timestamp.Time,
+	})
+}
+
+func (zs *ZerodhaTickSource) handleReconnect(attempt int, delay time.Duration) {
+	log.Printf("🔄 Reconnecting (attempt %d, delay %v)", attempt, delay)
+	zs.connected.Store(false)
+}
+
+func (zs *ZerodhaTickSource) handleNoReconnect(attempt int) {
+	log.Printf("❌ Reconnection failed after %d attempts", attempt)
+	zs.connected.Store(false)
+
+	if zs.onError != nil {
+		zs.onError(ErrReconnectFailed)
+	}
+}
+
+func (zs *ZerodhaTickSource) handleError(err error) {
+	log.Printf("❌ Ticker error: %v", err)
+
+	if zs.onError != nil {
+		zs.onError(err)
+	}
+}
+
+func (zs *ZerodhaTickSource) handleClose(code int, reason string) {
+	log.Printf("🔌 Connection closed: code=%d, reason=%s", code, reason)
+	zs.connected.Store(false)
+}
+
+func (zs *ZerodhaTickSource) handleOrderUpdate(order kiteconnect.Order) {
+	log.Printf("📋 Order update: %s - %s", order.OrderID, order.Status)
+	// TODO: Store order updates in database
+}
@@ -0,0 +1,363 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Alert kinds
+const (
+	AlertKindPrice     = "price"
+	AlertKindIndicator = "indicator"
+	AlertKindPattern   = "pattern"
+)
+
+// Alert states. An alert is armed until its condition holds, stays triggered
+// until the user acknowledges it, and can be re-armed from either state.
+const (
+	AlertArmed        = "armed"
+	AlertTriggered    = "triggered"
+	AlertAcknowledged = "acknowledged"
+)
+
+// ErrAlertState is returned when an alert can't move to the requested state
+// from the state it is in
+var ErrAlertState = errors.New("invalid alert state transition")
+
+// Alert is a stored alert condition. UserID is nil for alerts created in
+// single-user mode.
+type Alert struct {
+	AlertID        string          `json:"alert_id" db:"alert_id"`
+	UserID         *string         `json:"user_id,omitempty" db:"user_id"`
+	Name           string          `json:"name" db:"name"`
+	Kind           string          `json:"kind" db:"kind"`
+	Exchange       string          `json:"exchange" db:"exchange"`
+	Symbol         string          `json:"symbol" db:"symbol"`
+	Interval       string          `json:"interval" db:"interval"`
+	Condition      json.RawMessage `json:"condition" db:"condition"`
+	State          string          `json:"state" db:"state"`
+	LastValue      *float64        `json:"last_value,omitempty" db:"last_value"`
+	LastBar        *time.Time      `json:"last_bar,omitempty" db:"last_bar"`
+	TriggeredAt    *time.Time      `json:"triggered_at,omitempty" db:"triggered_at"`
+	AcknowledgedAt *time.Time      `json:"acknowledged_at,omitempty" db:"acknowledged_at"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// AlertEvent is one state transition in an alert's history
+type AlertEvent struct {
+	EventID   int64     `json:"event_id" db:"event_id"`
+	AlertID   string    `json:"alert_id" db:"alert_id"`
+	Name      string    `json:"name" db:"name"`
+	Symbol    string    `json:"symbol" db:"symbol"`
+	State     string    `json:"state" db:"state"`
+	Value     *float64  `json:"value,omitempty" db:"value"`
+	Message   string    `json:"message" db:"message"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+const alertColumns = `
+	SELECT alert_id, user_id, name, kind, exchange, symbol, interval, condition,
+	       state, last_value, last_bar, triggered_at, acknowledged_at, created_at, updated_at
+	FROM alerts.rules`
+
+// CreateAlert stores a new armed alert and fills in its ID and timestamps
+func (db *Database) CreateAlert(userID string, alert *Alert) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to create alert: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO alerts.rules (user_id, name, kind, exchange, symbol, interval, condition)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING alert_id, user_id, state, created_at, updated_at
+	`,
+		nullableUserID(userID),
+		alert.Name,
+		alert.Kind,
+		alert.Exchange,
+		alert.Symbol,
+		alert.Interval,
+		[]byte(alert.Condition),
+	).Scan(&alert.AlertID, &alert.UserID, &alert.State, &alert.CreatedAt, &alert.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create alert: %w", err)
+	}
+
+	if err := insertAlertEvent(tx, alert.AlertID, AlertArmed, nil, "created"); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateAlert replaces an alert's condition and re-arms it. Returns
+// sql.ErrNoRows if the user doesn't own the alert.
+func (db *Database) UpdateAlert(userID string, alert *Alert) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to update alert: %w", err)
+	}
+	defer tx.Rollback()
+
+	updated, err := scanAlert(tx.QueryRow(`
+		UPDATE alerts.rules
+		SET name = $3, kind = $4, exchange = $5, symbol = $6, interval = $7, condition = $8,
+		    state = 'armed', last_value = NULL, last_bar = NULL,
+		    triggered_at = NULL, acknowledged_at = NULL, updated_at = NOW()
+		WHERE alert_id = $1 AND user_id IS NOT DISTINCT FROM $2
+		RETURNING alert_id, user_id, name, kind, exchange, symbol, interval, condition,
+		          state, last_value, last_bar, triggered_at, acknowledged_at, created_at, updated_at
+	`,
+		alert.AlertID,
+		nullableUserID(userID),
+		alert.Name,
+		alert.Kind,
+		alert.Exchange,
+		alert.Symbol,
+		alert.Interval,
+		[]byte(alert.Condition),
+	))
+	if err == sql.ErrNoRows {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update alert: %w", err)
+	}
+
+	if err := insertAlertEvent(tx, alert.AlertID, AlertArmed, nil, "updated"); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to update alert: %w", err)
+	}
+
+	*alert = *updated
+	return nil
+}
+
+// DeleteAlert deletes an alert and its history. Returns sql.ErrNoRows if the
+// user doesn't own the alert.
+func (db *Database) DeleteAlert(userID, alertID string) error {
+	result, err := db.conn.Exec(`
+		DELETE FROM alerts.rules
+		WHERE alert_id = $1 AND user_id IS NOT DISTINCT FROM $2
+	`, alertID, nullableUserID(userID))
+	if err != nil {
+		return fmt.Errorf("failed to delete alert: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete alert: %w", err)
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetAlert returns one of a user's alerts, or nil if not found
+func (db *Database) GetAlert(userID, alertID string) (*Alert, error) {
+	query := alertColumns + `
+		WHERE alert_id = $1 AND user_id IS NOT DISTINCT FROM $2
+	`
+
+	alert, err := scanAlert(db.conn.QueryRow(query, alertID, nullableUserID(userID)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert: %w", err)
+	}
+
+	return alert, nil
+}
+
+// GetAlerts lists a user's alerts, newest first, optionally filtered by state
+func (db *Database) GetAlerts(userID, state string) ([]Alert, error) {
+	query := alertColumns + `
+		WHERE user_id IS NOT DISTINCT FROM $1 AND ($2 = '' OR state = $2)
+		ORDER BY created_at DESC
+	`
+
+	return db.queryAlerts(query, nullableUserID(userID), state)
+}
+
+// GetArmedAlerts lists the armed alerts of every user, for evaluation
+func (db *Database) GetArmedAlerts() ([]Alert, error) {
+	query := alertColumns + `
+		WHERE state = 'armed'
+		ORDER BY exchange, symbol, interval
+	`
+
+	return db.queryAlerts(query)
+}
+
+func (db *Database) queryAlerts(query string, args ...interface{}) ([]Alert, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alerts: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := []Alert{}
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert: %w", err)
+		}
+		alerts = append(alerts, *alert)
+	}
+
+	return alerts, rows.Err()
+}
+
+// SetAlertState moves an alert to state if it is currently in one of from,
+// recording the transition in its history. value, when set, becomes the
+// alert's last value. Returns ErrAlertState if the alert is in another state
+// and sql.ErrNoRows if it doesn't exist.
+func (db *Database) SetAlertState(alertID string, from []string, state string, value *float64, message string) (*Alert, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to set alert state: %w", err)
+	}
+	defer tx.Rollback()
+
+	alert, err := scanAlert(tx.QueryRow(`
+		UPDATE alerts.rules
+		SET state = $3,
+		    last_value = COALESCE($4, last_value),
+		    triggered_at = CASE $3 WHEN 'triggered' THEN NOW() WHEN 'armed' THEN NULL ELSE triggered_at END,
+		    acknowledged_at = CASE $3 WHEN 'acknowledged' THEN NOW() ELSE NULL END,
+		    updated_at = NOW()
+		WHERE alert_id = $1 AND state = ANY($2)
+		RETURNING alert_id, user_id, name, kind, exchange, symbol, interval, condition,
+		          state, last_value, last_bar, triggered_at, acknowledged_at, created_at, updated_at
+	`, alertID, pq.Array(from), state, value))
+	if err == sql.ErrNoRows {
+		var exists bool
+		if err := db.conn.QueryRow(`SELECT EXISTS (SELECT 1 FROM alerts.rules WHERE alert_id = $1)`, alertID).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("failed to set alert state: %w", err)
+		}
+		if exists {
+			return nil, ErrAlertState
+		}
+		return nil, sql.ErrNoRows
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to set alert state: %w", err)
+	}
+
+	if err := insertAlertEvent(tx, alertID, state, value, message); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to set alert state: %w", err)
+	}
+
+	return alert, nil
+}
+
+// UpdateAlertProgress stores the last value and bar seen by the evaluator
+// without changing the alert's state
+func (db *Database) UpdateAlertProgress(alertID string, value *float64, bar *time.Time) error {
+	_, err := db.conn.Exec(`
+		UPDATE alerts.rules
+		SET last_value = COALESCE($2, last_value), last_bar = COALESCE($3, last_bar)
+		WHERE alert_id = $1
+	`, alertID, value, bar)
+	if err != nil {
+		return fmt.Errorf("failed to update alert progress: %w", err)
+	}
+	return nil
+}
+
+// GetAlertEvents returns a user's most recent alert transitions, optionally
+// for a single alert
+func (db *Database) GetAlertEvents(userID, alertID string, limit int) ([]AlertEvent, error) {
+	query := `
+		SELECT e.event_id, e.alert_id, r.name, r.symbol, e.state, e.value,
+		       COALESCE(e.message, ''), e.created_at
+		FROM alerts.events e
+		JOIN alerts.rules r ON r.alert_id = e.alert_id
+		WHERE r.user_id IS NOT DISTINCT FROM $1 AND ($2 = '' OR e.alert_id::text = $2)
+		ORDER BY e.created_at DESC, e.event_id DESC
+		LIMIT $3
+	`
+
+	rows, err := db.conn.Query(query, nullableUserID(userID), alertID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []AlertEvent{}
+	for rows.Next() {
+		var e AlertEvent
+		err := rows.Scan(
+			&e.EventID,
+			&e.AlertID,
+			&e.Name,
+			&e.Symbol,
+			&e.State,
+			&e.Value,
+			&e.Message,
+			&e.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert event: %w", err)
+		}
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+func insertAlertEvent(tx *sql.Tx, alertID, state string, value *float64, message string) error {
+	_, err := tx.Exec(`
+		INSERT INTO alerts.events (alert_id, state, value, message)
+		VALUES ($1, $2, $3, $4)
+	`, alertID, state, value, nullableString(message))
+	if err != nil {
+		return fmt.Errorf("failed to record alert event: %w", err)
+	}
+	return nil
+}
+
+func scanAlert(row rowScanner) (*Alert, error) {
+	var alert Alert
+	var condition []byte
+
+	err := row.Scan(
+		&alert.AlertID,
+		&alert.UserID,
+		&alert.Name,
+		&alert.Kind,
+		&alert.Exchange,
+		&alert.Symbol,
+		&alert.Interval,
+		&condition,
+		&alert.State,
+		&alert.LastValue,
+		&alert.LastBar,
+		&alert.TriggeredAt,
+		&alert.AcknowledgedAt,
+		&alert.CreatedAt,
+		&alert.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	alert.Condition = json.RawMessage(condition)
+	return &alert, nil
+}
@@ -0,0 +1,197 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/trading-chitti/market-bridge/internal/analyzer"
+)
+
+// AnalysisRecord is a stored 52-day analysis: the headline trend, volatility
+// and indicator values plus the full analyzer output
+type AnalysisRecord struct {
+	AnalysisID     int64           `json:"analysis_id" db:"analysis_id"`
+	Symbol         string          `json:"symbol" db:"symbol"`
+	AnalysisDate   time.Time       `json:"analysis_date" db:"analysis_date"`
+	PeriodDays     int             `json:"period_days" db:"period_days"`
+	TrendDirection string          `json:"trend_direction" db:"trend_direction"`
+	TrendSlope     float64         `json:"trend_slope" db:"trend_slope"`
+	TrendRSquared  float64         `json:"trend_r_squared" db:"trend_r_squared"`
+	Volatility     float64         `json:"volatility" db:"volatility"`
+	ATR            float64         `json:"atr" db:"atr"`
+	RSI            float64         `json:"rsi" db:"rsi"`
+	MACD           float64         `json:"macd" db:"macd"`
+	SMA20          float64         `json:"sma_20" db:"sma_20"`
+	SMA50          float64         `json:"sma_50" db:"sma_50"`
+	SignalsCount   int             `json:"signals_count" db:"signals_count"`
+	Analysis       json.RawMessage `json:"analysis,omitempty" db:"analysis_json"`
+}
+
+// AnalysisFilter selects stored analyses. Zero values match everything.
+type AnalysisFilter struct {
+	Symbols []string
+	From    time.Time // Inclusive
+	To      time.Time // Exclusive
+	Limit   int
+
+	// Full includes the complete analyzer output in each record
+	Full bool
+}
+
+// SaveAnalysis stores an analysis and returns its ID
+func (db *Database) SaveAnalysis(analysis *analyzer.Analysis) (int64, error) {
+	payload, err := json.Marshal(analysis)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode analysis: %w", err)
+	}
+
+	query := `
+		INSERT INTO trades.analysis (
+			symbol, analysis_date, period_days,
+			trend_direction, trend_slope, trend_r_squared,
+			volatility, atr, rsi, macd, sma_20, sma_50,
+			signals_count, analysis_json
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING analysis_id
+	`
+
+	var id int64
+	err = db.conn.QueryRow(query,
+		analysis.Symbol,
+		time.Now(),
+		analysis.PeriodDays,
+		analysis.Trend.Direction,
+		analysis.Trend.Slope,
+		analysis.Trend.RSquared,
+		analysis.Volatility.Annualized,
+		analysis.Volatility.ATR,
+		analysis.Indicators.RSI,
+		analysis.Indicators.MACD,
+		analysis.Indicators.SMA20,
+		analysis.Indicators.SMA50,
+		len(analysis.Signals),
+		payload,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save analysis: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetAnalysisHistory returns a symbol's stored analyses, oldest first. With
+// a limit, the most recent ones are kept.
+func (db *Database) GetAnalysisHistory(symbol string, filter AnalysisFilter) ([]AnalysisRecord, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 100
+	}
+
+	query := `SELECT * FROM (` + analysisColumns(filter.Full) + `
+		WHERE symbol = $1
+		  AND ($2::timestamptz IS NULL OR analysis_date >= $2)
+		  AND ($3::timestamptz IS NULL OR analysis_date < $3)
+		ORDER BY analysis_date DESC, analysis_id DESC
+		LIMIT $4
+	) recent ORDER BY analysis_date, analysis_id`
+
+	rows, err := db.conn.Query(query,
+		symbol,
+		nullableTime(filter.From),
+		nullableTime(filter.To),
+		filter.Limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get analysis history: %w", err)
+	}
+	defer rows.Close()
+
+	return scanAnalyses(rows)
+}
+
+// GetLatestAnalyses returns the most recent analysis of each symbol within
+// the filter's window, newest first
+func (db *Database) GetLatestAnalyses(filter AnalysisFilter) ([]AnalysisRecord, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 500
+	}
+	if filter.Symbols == nil {
+		filter.Symbols = []string{}
+	}
+
+	query := `SELECT * FROM (
+		SELECT DISTINCT ON (symbol) * FROM (` + analysisColumns(filter.Full) + `
+			WHERE (cardinality($1::text[]) = 0 OR symbol = ANY($1))
+			  AND ($2::timestamptz IS NULL OR analysis_date >= $2)
+			  AND ($3::timestamptz IS NULL OR analysis_date < $3)
+		) a
+		ORDER BY symbol, analysis_date DESC, analysis_id DESC
+	) latest ORDER BY analysis_date DESC, symbol
+	LIMIT $4`
+
+	rows, err := db.conn.Query(query,
+		pq.Array(filter.Symbols),
+		nullableTime(filter.From),
+		nullableTime(filter.To),
+		filter.Limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest analyses: %w", err)
+	}
+	defer rows.Close()
+
+	return scanAnalyses(rows)
+}
+
+// analysisColumns selects the analysis columns, with the full JSON only
+// when requested since it dwarfs the rest of the row
+func analysisColumns(full bool) string {
+	analysisJSON := "NULL::jsonb"
+	if full {
+		analysisJSON = "analysis_json"
+	}
+
+	return `
+	SELECT analysis_id, symbol, analysis_date, period_days,
+	       COALESCE(trend_direction, ''), COALESCE(trend_slope, 0), COALESCE(trend_r_squared, 0),
+	       COALESCE(volatility, 0), COALESCE(atr, 0), COALESCE(rsi, 0), COALESCE(macd, 0),
+	       COALESCE(sma_20, 0), COALESCE(sma_50, 0), COALESCE(signals_count, 0),
+	       ` + analysisJSON + ` AS analysis_json
+	FROM trades.analysis`
+}
+
+func scanAnalyses(rows *sql.Rows) ([]AnalysisRecord, error) {
+	records := []AnalysisRecord{}
+	for rows.Next() {
+		var r AnalysisRecord
+		var payload []byte
+		err := rows.Scan(
+			&r.AnalysisID,
+			&r.Symbol,
+			&r.AnalysisDate,
+			&r.PeriodDays,
+			&r.TrendDirection,
+			&r.TrendSlope,
+			&r.TrendRSquared,
+			&r.Volatility,
+			&r.ATR,
+			&r.RSI,
+			&r.MACD,
+			&r.SMA20,
+			&r.SMA50,
+			&r.SignalsCount,
+			&payload,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan analysis: %w", err)
+		}
+		if payload != nil {
+			r.Analysis = payload
+		}
+		records = append(records, r)
+	}
+
+	return records, rows.Err()
+}
@@ -0,0 +1,187 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Backfill run statuses
+const (
+	BackfillRunning   = "running"
+	BackfillCompleted = "completed"
+	BackfillFailed    = "failed"
+	BackfillCancelled = "cancelled"
+)
+
+// BackfillRun is the report of one backfill run
+type BackfillRun struct {
+	RunID        int64                  `json:"run_id" db:"run_id"`
+	Trigger      string                 `json:"trigger" db:"trigger"`
+	Timeframe    string                 `json:"timeframe" db:"timeframe"`
+	FromDate     time.Time              `json:"from_date" db:"from_date"`
+	ToDate       time.Time              `json:"to_date" db:"to_date"`
+	Status       string                 `json:"status" db:"status"`
+	TotalSymbols int                    `json:"total_symbols" db:"total_symbols"`
+	Successful   int                    `json:"successful" db:"successful"`
+	Failed       int                    `json:"failed" db:"failed"`
+	TotalBars    int                    `json:"total_bars" db:"total_bars"`
+	Results      []BackfillSymbolResult `json:"results" db:"results"`
+	Error        *string                `json:"error,omitempty" db:"error"`
+	StartedAt    time.Time              `json:"started_at" db:"started_at"`
+	FinishedAt   *time.Time             `json:"finished_at,omitempty" db:"finished_at"`
+}
+
+// BackfillSymbolResult is the per-symbol outcome stored with a run
+type BackfillSymbolResult struct {
+	Symbol       string `json:"symbol"`
+	BarsInserted int    `json:"bars_inserted"`
+	GapsFound    int    `json:"gaps_found,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// CreateBackfillRun records the start of a run and fills in RunID and StartedAt
+func (db *Database) CreateBackfillRun(run *BackfillRun) error {
+	query := `
+		INSERT INTO md.backfill_runs (trigger, timeframe, from_date, to_date, status, total_symbols)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING run_id, started_at
+	`
+
+	if run.Status == "" {
+		run.Status = BackfillRunning
+	}
+
+	err := db.conn.QueryRow(query,
+		run.Trigger,
+		run.Timeframe,
+		run.FromDate,
+		run.ToDate,
+		run.Status,
+		run.TotalSymbols,
+	).Scan(&run.RunID, &run.StartedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create backfill run: %w", err)
+	}
+
+	return nil
+}
+
+// FinishBackfillRun stores the final counts, results and status of a run
+func (db *Database) FinishBackfillRun(run *BackfillRun) error {
+	results, err := json.Marshal(run.Results)
+	if err != nil {
+		return fmt.Errorf("failed to encode backfill results: %w", err)
+	}
+
+	query := `
+		UPDATE md.backfill_runs
+		SET status = $2, successful = $3, failed = $4, total_bars = $5,
+			results = $6, error = $7, finished_at = NOW()
+		WHERE run_id = $1
+		RETURNING finished_at
+	`
+
+	var finishedAt time.Time
+	err = db.conn.QueryRow(query,
+		run.RunID,
+		run.Status,
+		run.Successful,
+		run.Failed,
+		run.TotalBars,
+		string(results),
+		run.Error,
+	).Scan(&finishedAt)
+	if err != nil {
+		return fmt.Errorf("failed to finish backfill run: %w", err)
+	}
+
+	run.FinishedAt = &finishedAt
+	return nil
+}
+
+// GetBackfillRuns returns the most recent runs, newest first
+func (db *Database) GetBackfillRuns(limit int) ([]BackfillRun, error) {
+	query := `
+		SELECT run_id, trigger, timeframe, from_date, to_date, status,
+			total_symbols, successful, failed, total_bars, results, error,
+			started_at, finished_at
+		FROM md.backfill_runs
+		ORDER BY started_at DESC
+		LIMIT $1
+	`
+
+	rows, err := db.conn.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query backfill runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []BackfillRun{}
+	for rows.Next() {
+		run, err := scanBackfillRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+
+	return runs, rows.Err()
+}
+
+// GetBackfillRun returns a single run, or nil if it doesn't exist
+func (db *Database) GetBackfillRun(runID int64) (*BackfillRun, error) {
+	query := `
+		SELECT run_id, trigger, timeframe, from_date, to_date, status,
+			total_symbols, successful, failed, total_bars, results, error,
+			started_at, finished_at
+		FROM md.backfill_runs
+		WHERE run_id = $1
+	`
+
+	run, err := scanBackfillRun(db.conn.QueryRow(query, runID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return run, err
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanBackfillRun(row rowScanner) (*BackfillRun, error) {
+	var run BackfillRun
+	var results []byte
+
+	err := row.Scan(
+		&run.RunID,
+		&run.Trigger,
+		&run.Timeframe,
+		&run.FromDate,
+		&run.ToDate,
+		&run.Status,
+		&run.TotalSymbols,
+		&run.Successful,
+		&run.Failed,
+		&run.TotalBars,
+		&results,
+		&run.Error,
+		&run.StartedAt,
+		&run.FinishedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan backfill run: %w", err)
+	}
+
+	if err := json.Unmarshal(results, &run.Results); err != nil {
+		return nil, fmt.Errorf("failed to decode backfill results: %w", err)
+	}
+
+	return &run, nil
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/analyzer"
+)
+
+// BreadthRecord is a watchlist's stored breadth for one session
+type BreadthRecord struct {
+	Watchlist string `json:"watchlist" db:"watchlist"`
+
+	// Date is the session (trade_date)
+	analyzer.Breadth
+
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SaveBreadth stores a watchlist's breadth for its session, replacing any
+// earlier computation of the same session
+func (db *Database) SaveBreadth(watchlist string, breadth analyzer.Breadth) error {
+	query := `
+		INSERT INTO analytics.breadth_daily (
+			watchlist, trade_date, symbols, advancers, decliners, unchanged,
+			advance_decline_ratio, above_sma_20, above_sma_50,
+			pct_above_sma_20, pct_above_sma_50, new_52w_highs, new_52w_lows, average_rsi
+		) VALUES ($1, ($2::timestamptz AT TIME ZONE 'Asia/Kolkata')::date, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (watchlist, trade_date) DO UPDATE SET
+			symbols = EXCLUDED.symbols,
+			advancers = EXCLUDED.advancers,
+			decliners = EXCLUDED.decliners,
+			unchanged = EXCLUDED.unchanged,
+			advance_decline_ratio = EXCLUDED.advance_decline_ratio,
+			above_sma_20 = EXCLUDED.above_sma_20,
+			above_sma_50 = EXCLUDED.above_sma_50,
+			pct_above_sma_20 = EXCLUDED.pct_above_sma_20,
+			pct_above_sma_50 = EXCLUDED.pct_above_sma_50,
+			new_52w_highs = EXCLUDED.new_52w_highs,
+			new_52w_lows = EXCLUDED.new_52w_lows,
+			average_rsi = EXCLUDED.average_rsi,
+			updated_at = NOW()
+	`
+
+	_, err := db.conn.Exec(query,
+		watchlist,
+		breadth.Date,
+		breadth.Symbols,
+		breadth.Advancers,
+		breadth.Decliners,
+		breadth.Unchanged,
+		breadth.AdvanceDecline,
+		breadth.AboveSMA20,
+		breadth.AboveSMA50,
+		breadth.PercentAboveSMA20,
+		breadth.PercentAboveSMA50,
+		breadth.NewHighs,
+		breadth.NewLows,
+		breadth.AverageRSI,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save breadth: %w", err)
+	}
+	return nil
+}
+
+// GetBreadthHistory returns a watchlist's stored breadth for sessions on or
+// after from, oldest first
+func (db *Database) GetBreadthHistory(watchlist string, from time.Time) ([]BreadthRecord, error) {
+	query := `
+		SELECT watchlist, trade_date, symbols, advancers, decliners, unchanged,
+		       advance_decline_ratio, above_sma_20, above_sma_50,
+		       pct_above_sma_20, pct_above_sma_50, new_52w_highs, new_52w_lows,
+		       average_rsi, updated_at
+		FROM analytics.breadth_daily
+		WHERE watchlist = $1 AND trade_date >= $2::date
+		ORDER BY trade_date
+	`
+
+	rows, err := db.conn.Query(query, watchlist, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get breadth history: %w", err)
+	}
+	defer rows.Close()
+
+	records := []BreadthRecord{}
+	for rows.Next() {
+		var r BreadthRecord
+		err := rows.Scan(
+			&r.Watchlist,
+			&r.Date,
+			&r.Symbols,
+			&r.Advancers,
+			&r.Decliners,
+			&r.Unchanged,
+			&r.AdvanceDecline,
+			&r.AboveSMA20,
+			&r.AboveSMA50,
+			&r.PercentAboveSMA20,
+			&r.PercentAboveSMA50,
+			&r.NewHighs,
+			&r.NewLows,
+			&r.AverageRSI,
+			&r.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan breadth: %w", err)
+		}
+		records = append(records, r)
+	}
+
+	return records, rows.Err()
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// CollectorConfigRecord is a stored collector definition. Credentials are
+// not stored: real collectors are restored with the active broker's.
+type CollectorConfigRecord struct {
+	Name       string    `json:"name" db:"name"`
+	Type       string    `json:"type" db:"type"`
+	Mode       string    `json:"mode" db:"mode"`
+	Symbols    []string  `json:"symbols" db:"symbols"`
+	Watchlists []string  `json:"watchlists" db:"watchlists"`
+	AutoStart  bool      `json:"auto_start" db:"auto_start"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SaveCollectorConfig creates or replaces a collector definition
+func (db *Database) SaveCollectorConfig(record *CollectorConfigRecord) error {
+	query := `
+		INSERT INTO collectors.configs (name, type, mode, symbols, watchlists, auto_start)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (name) DO UPDATE SET
+			type = EXCLUDED.type,
+			mode = EXCLUDED.mode,
+			symbols = EXCLUDED.symbols,
+			watchlists = EXCLUDED.watchlists,
+			auto_start = EXCLUDED.auto_start,
+			updated_at = NOW()
+		RETURNING created_at, updated_at
+	`
+
+	err := db.conn.QueryRow(query,
+		record.Name,
+		record.Type,
+		record.Mode,
+		pq.Array(record.Symbols),
+		pq.Array(record.Watchlists),
+		record.AutoStart,
+	).Scan(&record.CreatedAt, &record.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save collector config: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteCollectorConfig deletes a collector definition, if stored
+func (db *Database) DeleteCollectorConfig(name string) error {
+	if _, err := db.conn.Exec(`DELETE FROM collectors.configs WHERE name = $1`, name); err != nil {
+		return fmt.Errorf("failed to delete collector config: %w", err)
+	}
+	return nil
+}
+
+// GetCollectorConfigs lists the stored collector definitions in creation order
+func (db *Database) GetCollectorConfigs() ([]CollectorConfigRecord, error) {
+	rows, err := db.conn.Query(`
+		SELECT name, type, mode, symbols, watchlists, auto_start, created_at, updated_at
+		FROM collectors.configs
+		ORDER BY created_at, name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collector configs: %w", err)
+	}
+	defer rows.Close()
+
+	records := []CollectorConfigRecord{}
+	for rows.Next() {
+		var record CollectorConfigRecord
+		if err := rows.Scan(
+			&record.Name,
+			&record.Type,
+			&record.Mode,
+			pq.Array(&record.Symbols),
+			pq.Array(&record.Watchlists),
+			&record.AutoStart,
+			&record.CreatedAt,
+			&record.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan collector config: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
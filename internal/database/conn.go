@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/trading-chitti/market-bridge/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// conn runs queries with the context of the Database it belongs to. Within
+// a traced request or job each query is a span of its own.
+type conn struct {
+	*sql.DB
+	ctx context.Context
+}
+
+func (c conn) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// span starts the span of a statement
+func (c conn) span(query string) (context.Context, func(error)) {
+	ctx, span := tracing.StartChild(c.context(), "db."+statementName(query),
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.statement", query),
+	)
+	return ctx, func(err error) { tracing.End(span, err) }
+}
+
+func (c conn) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, end := c.span(query)
+	rows, err := c.DB.QueryContext(ctx, query, args...)
+	end(err)
+	return rows, err
+}
+
+func (c conn) QueryRow(query string, args ...interface{}) *sql.Row {
+	ctx, end := c.span(query)
+	row := c.DB.QueryRowContext(ctx, query, args...)
+	end(row.Err())
+	return row
+}
+
+func (c conn) Exec(query string, args ...interface{}) (sql.Result, error) {
+	ctx, end := c.span(query)
+	result, err := c.DB.ExecContext(ctx, query, args...)
+	end(err)
+	return result, err
+}
+
+// Begin starts a transaction that is cancelled with the Database's context
+func (c conn) Begin() (*sql.Tx, error) {
+	return c.DB.BeginTx(c.context(), nil)
+}
+
+// statementName is a statement's first keyword, e.g. select
+func statementName(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "query"
+	}
+	return strings.ToLower(fields[0])
+}
+
+// WithContext returns a Database whose queries are cancelled with ctx and
+// traced as children of its span
+func (db *Database) WithContext(ctx context.Context) *Database {
+	scoped := *db
+	scoped.conn = conn{DB: db.conn.DB, ctx: ctx}
+	return &scoped
+}
@@ -0,0 +1,21 @@
+package database
+
+import "testing"
+
+func TestStatementName(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT 1", "select"},
+		{"\n\t\tINSERT INTO md.intraday_bars (symbol) VALUES ($1)", "insert"},
+		{"with recent AS (SELECT 1) SELECT * FROM recent", "with"},
+		{"   ", "query"},
+	}
+
+	for _, tt := range tests {
+		if got := statementName(tt.query); got != tt.want {
+			t.Errorf("statementName(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
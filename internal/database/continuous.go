@@ -0,0 +1,247 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ContinuousSuffix marks a continuous futures symbol: NIFTY-I is the front
+// month NIFTY future, rolled onto the next contract at each expiry
+const ContinuousSuffix = "-I"
+
+// Rollover price adjustments of a continuous series
+const (
+	AdjustDifference = "difference" // Shift earlier contracts by the roll gap
+	AdjustRatio      = "ratio"      // Scale earlier contracts by the roll ratio
+	AdjustNone       = "none"       // Raw prices, with jumps at each roll
+)
+
+// futuresExchanges are the exchanges futures contracts are listed on
+var futuresExchanges = map[string]bool{"NFO": true, "BFO": true, "MCX": true, "CDS": true}
+
+// rollOverlap is how far before its window a contract's candles are fetched,
+// enough to find a bar it shares with the contract it replaces
+const rollOverlap = 7 * 24 * time.Hour
+
+// ContinuousOptions control how a continuous series is stitched
+type ContinuousOptions struct {
+	RollDays int    // Roll this many days before expiry; 0 rolls after the expiry day
+	Adjust   string // AdjustDifference (default), AdjustRatio or AdjustNone
+}
+
+// ContractRoll is one switch of a continuous series to the next contract
+type ContractRoll struct {
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	Date   time.Time `json:"date"`   // The new contract's first candle
+	Gap    float64   `json:"gap"`    // To's close minus From's at From's last candle
+	Factor float64   `json:"factor"` // To's close over From's at that candle
+}
+
+// ContractCandles are a futures contract's candles, the input of
+// StitchContinuous
+type ContractCandles struct {
+	Contract Instrument
+	Candles  []HistoricalCandle
+}
+
+// ContinuousUnderlying returns the underlying of a continuous futures symbol
+// (NIFTY for NFO:NIFTY-I), ok false for any other symbol
+func ContinuousUnderlying(exchange, symbol string) (string, bool) {
+	symbol = strings.ToUpper(symbol)
+	if !futuresExchanges[strings.ToUpper(exchange)] || !strings.HasSuffix(symbol, ContinuousSuffix) {
+		return "", false
+	}
+	underlying := strings.TrimSuffix(symbol, ContinuousSuffix)
+	return underlying, underlying != ""
+}
+
+// ValidateContinuousOptions checks options and fills in the default
+// adjustment
+func ValidateContinuousOptions(opts *ContinuousOptions) error {
+	switch opts.Adjust {
+	case "":
+		opts.Adjust = AdjustDifference
+	case AdjustDifference, AdjustRatio, AdjustNone:
+	default:
+		return fmt.Errorf("invalid adjust %q, use %s, %s or %s", opts.Adjust, AdjustDifference, AdjustRatio, AdjustNone)
+	}
+	if opts.RollDays < 0 || opts.RollDays > 30 {
+		return fmt.Errorf("roll_days must be between 0 and 30")
+	}
+	return nil
+}
+
+// rollCutoff returns when a contract stops being the front month: the start
+// of the day after its expiry, brought forward by rollDays
+func rollCutoff(expiry time.Time, rollDays int) time.Time {
+	day := time.Date(expiry.Year(), expiry.Month(), expiry.Day(), 0, 0, 0, 0, istZone)
+	return day.AddDate(0, 0, 1-rollDays)
+}
+
+// StitchContinuous joins contracts, ordered by expiry, into one series that
+// follows each contract until its roll cutoff. Earlier contracts are
+// adjusted by the price gap at each roll, measured at the outgoing
+// contract's last candle, so the series has no jumps the market didn't make.
+func StitchContinuous(contracts []ContractCandles, opts ContinuousOptions) ([]HistoricalCandle, []ContractRoll) {
+	type segment struct {
+		contract ContractCandles
+		candles  []HistoricalCandle
+	}
+
+	var segments []segment
+	var start time.Time
+	for _, cc := range contracts {
+		if cc.Contract.Expiry == nil {
+			continue
+		}
+		cutoff := rollCutoff(*cc.Contract.Expiry, opts.RollDays)
+
+		var candles []HistoricalCandle
+		for _, candle := range cc.Candles {
+			if !candle.CandleTimestamp.Before(start) && candle.CandleTimestamp.Before(cutoff) {
+				candles = append(candles, candle)
+			}
+		}
+		if len(candles) > 0 {
+			segments = append(segments, segment{contract: cc, candles: candles})
+		}
+		start = cutoff
+	}
+
+	rolls := make([]ContractRoll, 0, max(len(segments)-1, 0))
+	for i := 1; i < len(segments); i++ {
+		prev, next := segments[i-1], segments[i]
+		last := prev.candles[len(prev.candles)-1]
+		roll := ContractRoll{
+			From:   prev.contract.Contract.Tradingsymbol,
+			To:     next.contract.Contract.Tradingsymbol,
+			Date:   next.candles[0].CandleTimestamp,
+			Factor: 1,
+		}
+		if price, ok := closeAt(next.contract.Candles, last.CandleTimestamp); ok && last.Close > 0 {
+			roll.Gap = price - last.Close
+			roll.Factor = price / last.Close
+		}
+		rolls = append(rolls, roll)
+	}
+
+	// Adjust from the latest contract back, so its prices stay as traded
+	offset, factor := 0.0, 1.0
+	var series []HistoricalCandle
+	for i := len(segments) - 1; i >= 0; i-- {
+		if i < len(segments)-1 {
+			offset += rolls[i].Gap
+			factor *= rolls[i].Factor
+		}
+		adjusted := make([]HistoricalCandle, len(segments[i].candles))
+		for j, candle := range segments[i].candles {
+			switch opts.Adjust {
+			case AdjustRatio:
+				candle.Open, candle.High, candle.Low, candle.Close =
+					candle.Open*factor, candle.High*factor, candle.Low*factor, candle.Close*factor
+			case AdjustNone:
+			default:
+				candle.Open, candle.High, candle.Low, candle.Close =
+					candle.Open+offset, candle.High+offset, candle.Low+offset, candle.Close+offset
+			}
+			adjusted[j] = candle
+		}
+		series = append(adjusted, series...)
+	}
+	return series, rolls
+}
+
+// closeAt returns the close of the latest candle at or before t
+func closeAt(candles []HistoricalCandle, t time.Time) (float64, bool) {
+	i := sort.Search(len(candles), func(i int) bool {
+		return candles[i].CandleTimestamp.After(t)
+	})
+	if i == 0 {
+		return 0, false
+	}
+	return candles[i-1].Close, true
+}
+
+// GetFutureContracts returns an underlying's futures contracts on exchange
+// expiring on or after from, nearest first
+func (db *Database) GetFutureContracts(exchange, underlying string, from time.Time) ([]Instrument, error) {
+	query := `
+		SELECT instrument_token, exchange_token, tradingsymbol, name, exchange,
+		       segment, instrument_type, COALESCE(isin, ''), expiry, strike, tick_size, lot_size,
+		       COALESCE(last_price, 0), last_updated
+		FROM trades.instruments
+		WHERE exchange = $1
+		  AND name = $2
+		  AND instrument_type = 'FUT'
+		  AND expiry >= $3::date
+		ORDER BY expiry
+	`
+
+	rows, err := db.conn.Query(query, strings.ToUpper(exchange), underlying, from.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanInstruments(rows)
+}
+
+// GetContinuousData builds an underlying's continuous front month futures
+// series from the contracts in the instrument master. Expired contracts are
+// only there if they were synced while live, and only stitched while the
+// broker still serves their candles.
+func (s *HistoricalDataService) GetContinuousData(
+	exchange, underlying, interval string,
+	fromDate, toDate time.Time,
+	opts ContinuousOptions,
+) ([]HistoricalCandle, []ContractRoll, error) {
+	if err := ValidateContinuousOptions(&opts); err != nil {
+		return nil, nil, err
+	}
+
+	contracts, err := s.db.GetFutureContracts(exchange, underlying, fromDate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var series []ContractCandles
+	start := fromDate
+	for _, contract := range contracts {
+		if !start.Before(toDate) {
+			break
+		}
+		cutoff := rollCutoff(*contract.Expiry, opts.RollDays)
+		if !cutoff.After(fromDate) {
+			continue
+		}
+
+		candles, err := s.GetHistoricalData(exchange, contract.Tradingsymbol, interval, start.Add(-rollOverlap), minTime(cutoff, toDate))
+		if err != nil {
+			log.Printf("⚠️  Skipping %s in %s%s: %v", contract.Tradingsymbol, underlying, ContinuousSuffix, err)
+		} else {
+			series = append(series, ContractCandles{Contract: contract, Candles: candles})
+		}
+		start = cutoff
+	}
+
+	candles, rolls := StitchContinuous(series, opts)
+	inRange := candles[:0]
+	for _, candle := range candles {
+		if !candle.CandleTimestamp.Before(fromDate) && !candle.CandleTimestamp.After(toDate) {
+			inRange = append(inRange, candle)
+		}
+	}
+	return inRange, rolls, nil
+}
+
+// minTime returns the earlier of two times
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestContinuousUnderlying(t *testing.T) {
+	tests := []struct {
+		exchange string
+		symbol   string
+		want     string
+		wantOK   bool
+	}{
+		{"NFO", "NIFTY-I", "NIFTY", true},
+		{"nfo", "banknifty-i", "BANKNIFTY", true},
+		{"MCX", "CRUDEOIL-I", "CRUDEOIL", true},
+		{"NFO", "NIFTY24MARFUT", "", false},
+		{"NSE", "NIFTY-I", "", false},
+		{"NFO", "-I", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ContinuousUnderlying(tt.exchange, tt.symbol)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ContinuousUnderlying(%q, %q) = %q, %v, want %q, %v", tt.exchange, tt.symbol, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// futureCandles returns daily candles of a contract from the first day on,
+// with closes and OHL derived from them
+func futureCandles(token uint32, first time.Time, closes ...float64) []HistoricalCandle {
+	candles := make([]HistoricalCandle, len(closes))
+	for i, close := range closes {
+		candles[i] = HistoricalCandle{
+			InstrumentToken: token,
+			Interval:        "day",
+			CandleTimestamp: first.AddDate(0, 0, i),
+			Open:            close - 1,
+			High:            close + 2,
+			Low:             close - 2,
+			Close:           close,
+		}
+	}
+	return candles
+}
+
+func TestStitchContinuous(t *testing.T) {
+	day := func(month time.Month, d int) time.Time { return time.Date(2024, month, d, 0, 0, 0, 0, istZone) }
+	future := func(symbol string, token uint32, expiry time.Time, candles []HistoricalCandle) ContractCandles {
+		return ContractCandles{
+			Contract: Instrument{InstrumentToken: token, Tradingsymbol: symbol, Expiry: &expiry},
+			Candles:  candles,
+		}
+	}
+
+	// March expires on the 28th, 10 below April; April expires on the 25th,
+	// 20 below May
+	contracts := []ContractCandles{
+		future("NIFTY24MARFUT", 1, day(3, 28), futureCandles(1, day(3, 25), 100, 101, 102, 103)),
+		future("NIFTY24APRFUT", 2, day(4, 25), futureCandles(2, day(3, 25), 110, 111, 112, 113, 114, 115)),
+		future("NIFTY24MAYFUT", 3, day(5, 30), futureCandles(3, day(3, 29), 134, 135)),
+	}
+
+	tests := []struct {
+		name       string
+		opts       ContinuousOptions
+		wantTokens []uint32
+		wantCloses []float64
+		wantRolls  []ContractRoll
+	}{
+		{
+			name:       "difference",
+			opts:       ContinuousOptions{Adjust: AdjustDifference},
+			wantTokens: []uint32{1, 1, 1, 1, 2, 2},
+			wantCloses: []float64{110, 111, 112, 113, 114, 115},
+			wantRolls:  []ContractRoll{{From: "NIFTY24MARFUT", To: "NIFTY24APRFUT", Date: day(3, 29), Gap: 10, Factor: 113.0 / 103}},
+		},
+		{
+			name:       "ratio",
+			opts:       ContinuousOptions{Adjust: AdjustRatio},
+			wantTokens: []uint32{1, 1, 1, 1, 2, 2},
+			wantCloses: []float64{100 * 113.0 / 103, 101 * 113.0 / 103, 102 * 113.0 / 103, 113, 114, 115},
+			wantRolls:  []ContractRoll{{From: "NIFTY24MARFUT", To: "NIFTY24APRFUT", Date: day(3, 29), Gap: 10, Factor: 113.0 / 103}},
+		},
+		{
+			name:       "unadjusted",
+			opts:       ContinuousOptions{Adjust: AdjustNone},
+			wantTokens: []uint32{1, 1, 1, 1, 2, 2},
+			wantCloses: []float64{100, 101, 102, 103, 114, 115},
+			wantRolls:  []ContractRoll{{From: "NIFTY24MARFUT", To: "NIFTY24APRFUT", Date: day(3, 29), Gap: 10, Factor: 113.0 / 103}},
+		},
+		{
+			name:       "rolled two days early",
+			opts:       ContinuousOptions{Adjust: AdjustDifference, RollDays: 2},
+			wantTokens: []uint32{1, 1, 2, 2, 2, 2},
+			wantCloses: []float64{110, 111, 112, 113, 114, 115},
+			wantRolls:  []ContractRoll{{From: "NIFTY24MARFUT", To: "NIFTY24APRFUT", Date: day(3, 27), Gap: 10, Factor: 111.0 / 101}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candles, rolls := StitchContinuous(contracts, tt.opts)
+
+			if len(candles) != len(tt.wantCloses) {
+				t.Fatalf("got %d candles, want %d", len(candles), len(tt.wantCloses))
+			}
+			for i, candle := range candles {
+				if candle.InstrumentToken != tt.wantTokens[i] {
+					t.Errorf("candle %d token = %d, want %d", i, candle.InstrumentToken, tt.wantTokens[i])
+				}
+				if math.Abs(candle.Close-tt.wantCloses[i]) > 1e-9 {
+					t.Errorf("candle %d close = %v, want %v", i, candle.Close, tt.wantCloses[i])
+				}
+				if i > 0 && !candle.CandleTimestamp.After(candles[i-1].CandleTimestamp) {
+					t.Errorf("candle %d at %v is not after the one before", i, candle.CandleTimestamp)
+				}
+			}
+
+			if len(rolls) != len(tt.wantRolls) {
+				t.Fatalf("rolls = %+v, want %+v", rolls, tt.wantRolls)
+			}
+			for i, roll := range rolls {
+				want := tt.wantRolls[i]
+				if roll.From != want.From || roll.To != want.To || !roll.Date.Equal(want.Date) ||
+					math.Abs(roll.Gap-want.Gap) > 1e-9 || math.Abs(roll.Factor-want.Factor) > 1e-9 {
+					t.Errorf("roll %d = %+v, want %+v", i, roll, want)
+				}
+			}
+		})
+	}
+
+	if contracts[0].Candles[0].Close != 100 {
+		t.Error("StitchContinuous modified its input candles")
+	}
+}
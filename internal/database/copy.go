@@ -0,0 +1,149 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// copyThreshold is the batch size from which bulk inserts switch to COPY.
+// Below it the temp table setup costs more than it saves.
+const copyThreshold = 100
+
+// copyIntradayBars loads bars with COPY into a temp table, then upserts them
+// into md.intraday_bars in a single statement (COPY itself cannot upsert)
+func (db *Database) copyIntradayBars(bars []IntradayBar) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// ord is each bar's position in the batch, to pick the last of duplicates
+	_, err = tx.Exec(`
+		CREATE TEMP TABLE tmp_intraday_bars ON COMMIT DROP AS
+		SELECT exchange, symbol, instrument_token, bar_timestamp, timeframe,
+		       open, high, low, close, volume, trades_count, vwap, oi, source,
+		       0::bigint AS ord
+		FROM md.intraday_bars WITH NO DATA
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create temp table: %w", err)
+	}
+
+	stmt, err := tx.Prepare(pq.CopyIn("tmp_intraday_bars",
+		"exchange", "symbol", "instrument_token", "bar_timestamp", "timeframe",
+		"open", "high", "low", "close", "volume", "trades_count", "vwap", "oi", "source", "ord"))
+	if err != nil {
+		return fmt.Errorf("failed to start COPY: %w", err)
+	}
+
+	for i, bar := range bars {
+		_, err := stmt.Exec(
+			bar.Exchange,
+			bar.Symbol,
+			bar.InstrumentToken,
+			bar.BarTimestamp,
+			bar.Timeframe,
+			bar.Open,
+			bar.High,
+			bar.Low,
+			bar.Close,
+			bar.Volume,
+			bar.TradesCount,
+			bar.VWAP,
+			bar.OI,
+			bar.Source,
+			i,
+		)
+		if err != nil {
+			stmt.Close()
+			return fmt.Errorf("failed to COPY bar: %w", err)
+		}
+	}
+
+	// Flush buffered rows
+	if _, err := stmt.Exec(); err != nil {
+		stmt.Close()
+		return fmt.Errorf("failed to flush COPY: %w", err)
+	}
+	if err := stmt.Close(); err != nil {
+		return err
+	}
+
+	// DISTINCT ON guards against duplicate keys within one batch, which
+	// ON CONFLICT DO UPDATE rejects. The last one wins, as with row inserts.
+	_, err = tx.Exec(`
+		INSERT INTO md.intraday_bars (
+			exchange, symbol, instrument_token, bar_timestamp, timeframe,
+			open, high, low, close, volume, trades_count, vwap, oi, source
+		)
+		SELECT DISTINCT ON (exchange, symbol, bar_timestamp, timeframe)
+			exchange, symbol, instrument_token, bar_timestamp, timeframe,
+			open, high, low, close, volume, trades_count, vwap, oi, source
+		FROM tmp_intraday_bars
+		ORDER BY exchange, symbol, bar_timestamp, timeframe, ord DESC
+		ON CONFLICT (exchange, symbol, bar_timestamp, timeframe)
+		DO UPDATE SET
+			open = EXCLUDED.open,
+			high = EXCLUDED.high,
+			low = EXCLUDED.low,
+			close = EXCLUDED.close,
+			volume = EXCLUDED.volume,
+			trades_count = EXCLUDED.trades_count,
+			vwap = EXCLUDED.vwap,
+			oi = EXCLUDED.oi
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to upsert from temp table: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// copyTickData loads ticks straight into md.tick_data with COPY (ticks are append-only)
+func (db *Database) copyTickData(ticks []TickData) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(pq.CopyInSchema("md", "tick_data",
+		"exchange", "symbol", "instrument_token", "tick_timestamp",
+		"price", "quantity", "trade_type", "bid", "ask", "oi", "source"))
+	if err != nil {
+		return fmt.Errorf("failed to start COPY: %w", err)
+	}
+
+	for _, tick := range ticks {
+		_, err := stmt.Exec(
+			tick.Exchange,
+			tick.Symbol,
+			tick.InstrumentToken,
+			tick.TickTimestamp,
+			tick.Price,
+			tick.Quantity,
+			tick.TradeType,
+			tick.Bid,
+			tick.Ask,
+			tick.OI,
+			tick.Source,
+		)
+		if err != nil {
+			stmt.Close()
+			return fmt.Errorf("failed to COPY tick: %w", err)
+		}
+	}
+
+	// Flush buffered rows
+	if _, err := stmt.Exec(); err != nil {
+		stmt.Close()
+		return fmt.Errorf("failed to flush COPY: %w", err)
+	}
+	if err := stmt.Close(); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
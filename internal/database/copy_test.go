@@ -0,0 +1,270 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// testDatabase migrates and connects to TRADING_CHITTI_TEST_PG_DSN, skipping
+// without it. Tests write rows under the TEST exchange and delete them
+// afterwards.
+func testDatabase(tb testing.TB) *Database {
+	tb.Helper()
+
+	dsn := os.Getenv("TRADING_CHITTI_TEST_PG_DSN")
+	if dsn == "" {
+		tb.Skip("TRADING_CHITTI_TEST_PG_DSN not set")
+	}
+	if _, err := Migrate(dsn); err != nil {
+		tb.Fatalf("failed to migrate: %v", err)
+	}
+	db, err := NewDatabase(dsn)
+	if err != nil {
+		tb.Fatalf("failed to connect: %v", err)
+	}
+
+	cleanup := func() {
+		db.conn.Exec(`DELETE FROM md.intraday_bars WHERE exchange = 'TEST'`)
+		db.conn.Exec(`DELETE FROM md.tick_data WHERE exchange = 'TEST'`)
+		db.conn.Exec(`DELETE FROM md.symbols WHERE exchange = 'TEST'`)
+	}
+	cleanup()
+	tb.Cleanup(func() {
+		cleanup()
+		db.Close()
+	})
+	return db
+}
+
+// addTestSymbols registers TEST symbols, which bars and ticks reference
+func addTestSymbols(tb testing.TB, db *Database, symbols ...string) {
+	tb.Helper()
+
+	for _, symbol := range symbols {
+		_, err := db.conn.Exec(`
+			INSERT INTO md.symbols (exchange, symbol) VALUES ('TEST', $1)
+			ON CONFLICT DO NOTHING
+		`, symbol)
+		if err != nil {
+			tb.Fatalf("failed to add symbol %s: %v", symbol, err)
+		}
+	}
+}
+
+// testBars returns n 1m bars of TEST:symbol starting at 09:15 on 2024-01-01
+func testBars(symbol string, n int) []IntradayBar {
+	start := time.Date(2024, 1, 1, 3, 45, 0, 0, time.UTC)
+	bars := make([]IntradayBar, n)
+	for i := range bars {
+		price := 100 + float64(i%50)
+		bars[i] = IntradayBar{
+			Exchange:     "TEST",
+			Symbol:       symbol,
+			BarTimestamp: start.Add(time.Duration(i) * time.Minute),
+			Timeframe:    "1m",
+			Open:         price,
+			High:         price + 1,
+			Low:          price - 1,
+			Close:        price + 0.5,
+			Volume:       int64(1000 + i),
+			Source:       "test",
+		}
+	}
+	return bars
+}
+
+func TestCopyIntradayBarsKeepsLastDuplicate(t *testing.T) {
+	db := testDatabase(t)
+
+	tests := []struct {
+		name      string
+		bars      []IntradayBar
+		wantClose float64
+	}{
+		{
+			name: "single",
+			bars: []IntradayBar{
+				{Exchange: "TEST", Symbol: "DUP1", Timeframe: "1m", Close: 10},
+			},
+			wantClose: 10,
+		},
+		{
+			name: "last of three wins",
+			bars: []IntradayBar{
+				{Exchange: "TEST", Symbol: "DUP2", Timeframe: "1m", Close: 10},
+				{Exchange: "TEST", Symbol: "DUP2", Timeframe: "1m", Close: 20},
+				{Exchange: "TEST", Symbol: "DUP2", Timeframe: "1m", Close: 30},
+			},
+			wantClose: 30,
+		},
+		{
+			name: "interleaved with another key",
+			bars: []IntradayBar{
+				{Exchange: "TEST", Symbol: "DUP3", Timeframe: "1m", Close: 10},
+				{Exchange: "TEST", Symbol: "OTHER", Timeframe: "1m", Close: 99},
+				{Exchange: "TEST", Symbol: "DUP3", Timeframe: "1m", Close: 5},
+			},
+			wantClose: 5,
+		},
+	}
+
+	addTestSymbols(t, db, "DUP1", "DUP2", "DUP3", "OTHER")
+
+	ts := time.Date(2024, 1, 1, 3, 45, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := range tt.bars {
+				tt.bars[i].BarTimestamp = ts
+			}
+			if err := db.copyIntradayBars(tt.bars); err != nil {
+				t.Fatalf("copyIntradayBars: %v", err)
+			}
+
+			symbol := tt.bars[0].Symbol
+			var got float64
+			err := db.conn.QueryRow(`
+				SELECT close FROM md.intraday_bars
+				WHERE exchange = 'TEST' AND symbol = $1 AND bar_timestamp = $2 AND timeframe = '1m'
+			`, symbol, ts).Scan(&got)
+			if err != nil {
+				t.Fatalf("failed to read back %s: %v", symbol, err)
+			}
+			if got != tt.wantClose {
+				t.Errorf("close = %v, want %v", got, tt.wantClose)
+			}
+		})
+	}
+}
+
+func TestCopyIntradayBarsMatchesRowInserts(t *testing.T) {
+	db := testDatabase(t)
+
+	addTestSymbols(t, db, "COPYROWS")
+	bars := testBars("COPYROWS", 500)
+	if err := db.copyIntradayBars(bars); err != nil {
+		t.Fatalf("copyIntradayBars: %v", err)
+	}
+
+	var count int
+	var volume int64
+	err := db.conn.QueryRow(`
+		SELECT COUNT(*), SUM(volume) FROM md.intraday_bars
+		WHERE exchange = 'TEST' AND symbol = 'COPYROWS'
+	`).Scan(&count, &volume)
+	if err != nil {
+		t.Fatalf("failed to count bars: %v", err)
+	}
+
+	var wantVolume int64
+	for _, bar := range bars {
+		wantVolume += bar.Volume
+	}
+	if count != len(bars) || volume != wantVolume {
+		t.Errorf("got %d bars with volume %d, want %d with %d", count, volume, len(bars), wantVolume)
+	}
+}
+
+func TestTickDataQuoteFields(t *testing.T) {
+	db := testDatabase(t)
+
+	bid, ask, oi := 99.95, 100.05, int64(125000)
+	tests := []struct {
+		name   string
+		symbol string
+		insert func([]TickData) error
+	}{
+		{name: "copy", symbol: "QUOTECOPY", insert: db.copyTickData},
+		{name: "rows", symbol: "QUOTEROWS", insert: db.insertTickDataRows},
+	}
+
+	start := time.Date(2024, 1, 1, 3, 45, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addTestSymbols(t, db, tt.symbol)
+			ticks := []TickData{
+				{Exchange: "TEST", Symbol: tt.symbol, TickTimestamp: start, Price: 100, Quantity: 1, TradeType: "unknown", Bid: &bid, Ask: &ask, OI: &oi, Source: "test"},
+				{Exchange: "TEST", Symbol: tt.symbol, TickTimestamp: start.Add(time.Second), Price: 100, Quantity: 1, TradeType: "unknown", Source: "test"},
+			}
+			if err := tt.insert(ticks); err != nil {
+				t.Fatalf("insert: %v", err)
+			}
+
+			got, err := db.GetTickData("TEST", tt.symbol, start, start.Add(time.Minute), 10)
+			if err != nil {
+				t.Fatalf("GetTickData: %v", err)
+			}
+			if len(got) != 2 {
+				t.Fatalf("got %d ticks, want 2", len(got))
+			}
+			if got[0].Bid == nil || *got[0].Bid != bid || got[0].Ask == nil || *got[0].Ask != ask || got[0].OI == nil || *got[0].OI != oi {
+				t.Errorf("quoted tick bid/ask/oi = %v/%v/%v, want %v/%v/%v", got[0].Bid, got[0].Ask, got[0].OI, bid, ask, oi)
+			}
+			if got[1].Bid != nil || got[1].Ask != nil || got[1].OI != nil {
+				t.Errorf("unquoted tick bid/ask/oi = %v/%v/%v, want NULLs", got[1].Bid, got[1].Ask, got[1].OI)
+			}
+		})
+	}
+}
+
+func BenchmarkInsertIntradayBars(b *testing.B) {
+	db := testDatabase(b)
+
+	for _, n := range []int{100, 1000, 10000} {
+		symbol := fmt.Sprintf("BENCH%d", n)
+		addTestSymbols(b, db, symbol)
+		bars := testBars(symbol, n)
+
+		b.Run(fmt.Sprintf("copy/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := db.copyIntradayBars(bars); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("rows/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := db.insertIntradayBarsRows(bars); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkInsertTickData(b *testing.B) {
+	db := testDatabase(b)
+	addTestSymbols(b, db, "BENCH")
+
+	for _, n := range []int{100, 1000, 10000} {
+		start := time.Now()
+		ticks := make([]TickData, n)
+		for i := range ticks {
+			ticks[i] = TickData{
+				Exchange:      "TEST",
+				Symbol:        "BENCH",
+				TickTimestamp: start.Add(time.Duration(i) * time.Millisecond),
+				Price:         100 + float64(i%50),
+				Quantity:      int64(1 + i%10),
+				TradeType:     "unknown",
+				Source:        "test",
+			}
+		}
+
+		b.Run(fmt.Sprintf("copy/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := db.copyTickData(ticks); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("rows/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := db.insertTickDataRows(ticks); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Corporate action types
+const (
+	ActionSplit    = "split"
+	ActionBonus    = "bonus"
+	ActionDividend = "dividend"
+)
+
+// CorporateAction is a split, bonus or dividend of a symbol. Splits and
+// bonuses carry a ratio, RatioFrom:RatioTo; dividends an amount per share.
+type CorporateAction struct {
+	ActionID    int64     `json:"action_id" db:"action_id"`
+	Exchange    string    `json:"exchange" db:"exchange"`
+	Symbol      string    `json:"symbol" db:"symbol"`
+	ExDate      time.Time `json:"ex_date" db:"ex_date"`
+	Type        string    `json:"type" db:"action_type"`
+	RatioFrom   int       `json:"ratio_from,omitempty" db:"ratio_from"` // Split: old shares; bonus: bonus shares
+	RatioTo     int       `json:"ratio_to,omitempty" db:"ratio_to"`     // Split: new shares; bonus: shares held
+	Amount      float64   `json:"amount,omitempty" db:"amount"`         // Dividend per share
+	Description string    `json:"description,omitempty" db:"description"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// ParseCorporateAction builds an action from its text fields: a split of
+// ratio 1:5 turns one share into five, a bonus of 1:2 gives one share for
+// every two held. Dividends need an amount and no ratio.
+func ParseCorporateAction(exchange, symbol, exDate, actionType, ratio string, amount float64) (CorporateAction, error) {
+	action := CorporateAction{
+		Exchange: strings.ToUpper(strings.TrimSpace(exchange)),
+		Symbol:   strings.ToUpper(strings.TrimSpace(symbol)),
+		Type:     strings.ToLower(strings.TrimSpace(actionType)),
+		Amount:   amount,
+	}
+	if action.Exchange == "" || action.Symbol == "" {
+		return CorporateAction{}, fmt.Errorf("exchange and symbol are required")
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(exDate), istZone)
+	if err != nil {
+		return CorporateAction{}, fmt.Errorf("invalid ex_date %q (use YYYY-MM-DD)", exDate)
+	}
+	action.ExDate = date
+
+	switch action.Type {
+	case ActionSplit, ActionBonus:
+		action.RatioFrom, action.RatioTo, err = parseRatio(ratio)
+		if err != nil {
+			return CorporateAction{}, err
+		}
+		if action.Type == ActionSplit && action.RatioFrom == action.RatioTo {
+			return CorporateAction{}, fmt.Errorf("split ratio %s changes nothing", ratio)
+		}
+	case ActionDividend:
+		if amount <= 0 {
+			return CorporateAction{}, fmt.Errorf("dividend amount must be greater than 0")
+		}
+	default:
+		return CorporateAction{}, fmt.Errorf("invalid type %q, use %s, %s or %s", actionType, ActionSplit, ActionBonus, ActionDividend)
+	}
+	return action, nil
+}
+
+// parseRatio parses a ratio of positive whole numbers like 1:5
+func parseRatio(ratio string) (int, int, error) {
+	parts := strings.Split(strings.TrimSpace(ratio), ":")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid ratio %q (use e.g. 1:5)", ratio)
+	}
+	from, errFrom := strconv.Atoi(strings.TrimSpace(parts[0]))
+	to, errTo := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if errFrom != nil || errTo != nil || from <= 0 || to <= 0 {
+		return 0, 0, fmt.Errorf("invalid ratio %q (use e.g. 1:5)", ratio)
+	}
+	return from, to, nil
+}
+
+// PriceFactor is what prices before the ex-date are multiplied by to
+// compare with prices after it: 0.2 for a 1:5 split, 2/3 for a 1:2 bonus.
+// Dividends don't adjust prices.
+func (a CorporateAction) PriceFactor() float64 {
+	switch a.Type {
+	case ActionSplit:
+		if a.RatioTo > 0 {
+			return float64(a.RatioFrom) / float64(a.RatioTo)
+		}
+	case ActionBonus:
+		if a.RatioFrom+a.RatioTo > 0 {
+			return float64(a.RatioTo) / float64(a.RatioFrom+a.RatioTo)
+		}
+	}
+	return 1
+}
+
+// AdjustmentFactor returns the combined price factor of the actions whose
+// ex-date starts, in IST, after t
+func AdjustmentFactor(actions []CorporateAction, t time.Time) float64 {
+	factor := 1.0
+	for _, action := range actions {
+		exDate := time.Date(action.ExDate.Year(), action.ExDate.Month(), action.ExDate.Day(), 0, 0, 0, 0, istZone)
+		if t.Before(exDate) {
+			factor *= action.PriceFactor()
+		}
+	}
+	return factor
+}
+
+// AdjustCandles returns copies of candles with prices before each split or
+// bonus scaled by its factor and volumes by its inverse, so they compare
+// with the prices traded since
+func AdjustCandles(candles []HistoricalCandle, actions []CorporateAction) []HistoricalCandle {
+	adjusted := make([]HistoricalCandle, len(candles))
+	for i, candle := range candles {
+		if factor := AdjustmentFactor(actions, candle.CandleTimestamp); factor != 1 {
+			candle.Open, candle.High, candle.Low, candle.Close =
+				candle.Open*factor, candle.High*factor, candle.Low*factor, candle.Close*factor
+			candle.Volume = int64(float64(candle.Volume)/factor + 0.5)
+		}
+		adjusted[i] = candle
+	}
+	return adjusted
+}
+
+// AdjustBars is AdjustCandles for intraday bars, VWAP included
+func AdjustBars(bars []IntradayBar, actions []CorporateAction) []IntradayBar {
+	adjusted := make([]IntradayBar, len(bars))
+	for i, bar := range bars {
+		if factor := AdjustmentFactor(actions, bar.BarTimestamp); factor != 1 {
+			bar.Open, bar.High, bar.Low, bar.Close =
+				bar.Open*factor, bar.High*factor, bar.Low*factor, bar.Close*factor
+			bar.Volume = int64(float64(bar.Volume)/factor + 0.5)
+			if bar.VWAP != nil {
+				vwap := *bar.VWAP * factor
+				bar.VWAP = &vwap
+			}
+		}
+		adjusted[i] = bar
+	}
+	return adjusted
+}
+
+// SaveCorporateActions stores actions, replacing the ratio, amount and
+// description of ones already stored for the same symbol, ex-date and type
+func (db *Database) SaveCorporateActions(actions []CorporateAction) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO md.corporate_actions (
+			exchange, symbol, ex_date, action_type, ratio_from, ratio_to, amount, description
+		) VALUES ($1, $2, $3, $4, NULLIF($5::INTEGER, 0), NULLIF($6::INTEGER, 0), NULLIF($7::DOUBLE PRECISION, 0), NULLIF($8, ''))
+		ON CONFLICT (exchange, symbol, ex_date, action_type) DO UPDATE SET
+			ratio_from = EXCLUDED.ratio_from,
+			ratio_to = EXCLUDED.ratio_to,
+			amount = EXCLUDED.amount,
+			description = EXCLUDED.description
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, action := range actions {
+		_, err := stmt.Exec(
+			action.Exchange,
+			action.Symbol,
+			action.ExDate.Format("2006-01-02"),
+			action.Type,
+			action.RatioFrom,
+			action.RatioTo,
+			action.Amount,
+			action.Description,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save %s %s of %s: %w", action.Type, action.ExDate.Format("2006-01-02"), action.Symbol, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetCorporateActions returns a symbol's actions on exchange, oldest first
+func (db *Database) GetCorporateActions(exchange, symbol string) ([]CorporateAction, error) {
+	query := `
+		SELECT action_id, exchange, symbol, ex_date, action_type,
+		       COALESCE(ratio_from, 0), COALESCE(ratio_to, 0), COALESCE(amount, 0),
+		       COALESCE(description, ''), created_at
+		FROM md.corporate_actions
+		WHERE exchange = $1 AND symbol = $2
+		ORDER BY ex_date, action_type
+	`
+
+	rows, err := db.conn.Query(query, strings.ToUpper(exchange), strings.ToUpper(symbol))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	actions := []CorporateAction{}
+	for rows.Next() {
+		var action CorporateAction
+		err := rows.Scan(
+			&action.ActionID,
+			&action.Exchange,
+			&action.Symbol,
+			&action.ExDate,
+			&action.Type,
+			&action.RatioFrom,
+			&action.RatioTo,
+			&action.Amount,
+			&action.Description,
+			&action.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, action)
+	}
+
+	return actions, rows.Err()
+}
+
+// DeleteCorporateAction removes an action of symbol, returning false when
+// the symbol has none with the ID
+func (db *Database) DeleteCorporateAction(symbol string, actionID int64) (bool, error) {
+	result, err := db.conn.Exec(`DELETE FROM md.corporate_actions WHERE action_id = $1 AND symbol = $2`, actionID, symbol)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
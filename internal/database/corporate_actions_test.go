@@ -0,0 +1,105 @@
+package database
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseCorporateAction(t *testing.T) {
+	tests := []struct {
+		name       string
+		actionType string
+		exDate     string
+		ratio      string
+		amount     float64
+		wantFactor float64
+		wantErr    bool
+	}{
+		{"split", "split", "2024-06-10", "1:5", 0, 0.2, false},
+		{"reverse split", "SPLIT", "2024-06-10", "10:1", 0, 10, false},
+		{"bonus", "bonus", "2024-06-10", "1:2", 0, 2.0 / 3, false},
+		{"dividend", "dividend", "2024-06-10", "", 12.5, 1, false},
+		{"split without a change", "split", "2024-06-10", "2:2", 0, 0, true},
+		{"bad ratio", "split", "2024-06-10", "1-5", 0, 0, true},
+		{"zero ratio", "bonus", "2024-06-10", "0:1", 0, 0, true},
+		{"dividend without amount", "dividend", "2024-06-10", "", 0, 0, true},
+		{"bad date", "split", "10/06/2024", "1:5", 0, 0, true},
+		{"unknown type", "merger", "2024-06-10", "1:1", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, err := ParseCorporateAction("nse", " reliance ", tt.exDate, tt.actionType, tt.ratio, tt.amount)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if action.Exchange != "NSE" || action.Symbol != "RELIANCE" {
+				t.Errorf("got %s:%s, want NSE:RELIANCE", action.Exchange, action.Symbol)
+			}
+			if got := action.PriceFactor(); math.Abs(got-tt.wantFactor) > 1e-12 {
+				t.Errorf("PriceFactor() = %v, want %v", got, tt.wantFactor)
+			}
+		})
+	}
+}
+
+func TestAdjustCandles(t *testing.T) {
+	day := func(d, hour int) time.Time { return time.Date(2024, 6, d, hour, 0, 0, 0, istZone) }
+	mustParse := func(exDate, actionType, ratio string) CorporateAction {
+		action, err := ParseCorporateAction("NSE", "ABC", exDate, actionType, ratio, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return action
+	}
+
+	// A 1:2 split on the 10th, then a 1:1 bonus on the 20th
+	actions := []CorporateAction{
+		mustParse("2024-06-10", ActionSplit, "1:2"),
+		mustParse("2024-06-15", ActionDividend, ""),
+		mustParse("2024-06-20", ActionBonus, "1:1"),
+	}
+
+	tests := []struct {
+		name       string
+		at         time.Time
+		wantFactor float64
+	}{
+		{"before both", day(7, 15), 0.25},
+		{"last session before the split", day(9, 15), 0.25},
+		{"split ex-date", day(10, 9), 0.5},
+		{"before the bonus", day(19, 15), 0.5},
+		{"bonus ex-date", day(20, 9), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candles := []HistoricalCandle{{CandleTimestamp: tt.at, Open: 400, High: 440, Low: 380, Close: 420, Volume: 1000}}
+			vwap := 410.0
+			bars := []IntradayBar{{BarTimestamp: tt.at, Open: 400, High: 440, Low: 380, Close: 420, Volume: 1000, VWAP: &vwap}}
+
+			candle := AdjustCandles(candles, actions)[0]
+			bar := AdjustBars(bars, actions)[0]
+
+			if candle.Close != 420*tt.wantFactor || candle.Low != 380*tt.wantFactor {
+				t.Errorf("candle close %v low %v, want %v and %v", candle.Close, candle.Low, 420*tt.wantFactor, 380*tt.wantFactor)
+			}
+			if want := int64(1000 / tt.wantFactor); candle.Volume != want || bar.Volume != want {
+				t.Errorf("volume = %d candle, %d bar, want %d", candle.Volume, bar.Volume, want)
+			}
+			if bar.High != 440*tt.wantFactor || *bar.VWAP != 410*tt.wantFactor {
+				t.Errorf("bar high %v VWAP %v, want %v and %v", bar.High, *bar.VWAP, 440*tt.wantFactor, 410*tt.wantFactor)
+			}
+		})
+	}
+
+	candles := []HistoricalCandle{{CandleTimestamp: day(7, 15), Close: 420}}
+	AdjustCandles(candles, actions)
+	if candles[0].Close != 420 {
+		t.Error("AdjustCandles modified its input candles")
+	}
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cursor is a keyset pagination position, the timestamp and id of the last
+// bar or tick a page returned. The next page starts after it, so rows
+// inserted meanwhile neither shift nor repeat pages. Aggregated and
+// resampled bars have id 0; their timestamps are unique.
+type Cursor struct {
+	Time time.Time
+	ID   int64
+}
+
+// String encodes the cursor as an opaque URL-safe token
+func (c Cursor) String() string {
+	raw := strconv.FormatInt(c.Time.UnixNano(), 10) + "." + strconv.FormatInt(c.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseCursor decodes a token from Cursor.String
+func ParseCursor(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	nanos, id, ok := strings.Cut(string(raw), ".")
+	if !ok {
+		return Cursor{}, ErrInvalidCursor
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	i, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || i < 0 {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return Cursor{Time: time.Unix(0, n).UTC(), ID: i}, nil
+}
+
+// BarCursor returns the cursor positioned at bar
+func BarCursor(bar IntradayBar) Cursor {
+	return Cursor{Time: bar.BarTimestamp, ID: bar.BarID}
+}
+
+// TickCursor returns the cursor positioned at tick
+func TickCursor(tick TickData) Cursor {
+	return Cursor{Time: tick.TickTimestamp, ID: tick.TickID}
+}
@@ -0,0 +1,44 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCursor(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor Cursor
+	}{
+		{name: "tick", cursor: Cursor{Time: time.Date(2024, 1, 30, 3, 45, 0, 123456789, time.UTC), ID: 98765}},
+		{name: "aggregated bar", cursor: Cursor{Time: time.Date(2024, 1, 30, 4, 0, 0, 0, time.UTC)}},
+		{name: "IST timestamp", cursor: Cursor{Time: time.Date(2024, 1, 30, 9, 15, 0, 0, time.FixedZone("IST", 5*3600+1800)), ID: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCursor(tt.cursor.String())
+			if err != nil {
+				t.Fatalf("ParseCursor(%q): %v", tt.cursor, err)
+			}
+			if !got.Time.Equal(tt.cursor.Time) || got.ID != tt.cursor.ID {
+				t.Errorf("round trip = %+v, want %+v", got, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestParseCursorInvalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"not base64!",
+		"MTcwNjU4NTkwMDAwMDAwMDAwMA",     // No id
+		"YWJjLjE",                        // abc.1
+		"MTcwNjU4NTkwMDAwMDAwMDAwMC4tMQ", // Negative id
+	} {
+		if c, err := ParseCursor(s); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("ParseCursor(%q) = %+v, %v, want ErrInvalidCursor", s, c, err)
+		}
+	}
+}
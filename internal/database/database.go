@@ -1,7 +1,9 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"time"
 
 	_ "github.com/lib/pq"
@@ -13,21 +15,30 @@ type BrokerConfig = broker.BrokerConfig
 
 // Database handles PostgreSQL operations
 type Database struct {
-	conn *sql.DB
+	conn conn
+
+	// Continuous aggregates bars are read from, by timeframe. Set once at
+	// startup by DetectContinuousAggregates.
+	aggregates map[string]string
 }
 
 // NewDatabase creates a new database connection
 func NewDatabase(dsn string) (*Database, error) {
-	conn, err := sql.Open("postgres", dsn)
+	sqlDB, err := sql.Open("postgres", dsn)
 	if err != nil {
 		return nil, err
 	}
 	
-	if err := conn.Ping(); err != nil {
+	if err := sqlDB.Ping(); err != nil {
 		return nil, err
 	}
 	
-	return &Database{conn: conn}, nil
+	return &Database{conn: conn{DB: sqlDB}}, nil
+}
+
+// Ping checks the database is reachable
+func (db *Database) Ping(ctx context.Context) error {
+	return db.conn.PingContext(ctx)
 }
 
 // Close closes the database connection
@@ -39,7 +50,8 @@ func (db *Database) Close() error {
 func (db *Database) GetActiveBrokerConfig() (*broker.BrokerConfig, error) {
 	query := `
 		SELECT id, broker_name, display_name, enabled, api_key, api_secret, 
-		       access_token, user_id, max_positions, max_risk_per_trade, 
+		       access_token, user_id, max_positions, max_risk_per_trade,
+		       COALESCE(max_daily_loss, 0), COALESCE(max_symbol_exposure, 0),
 		       created_at, updated_at
 		FROM brokers.config
 		WHERE enabled = true
@@ -59,6 +71,8 @@ func (db *Database) GetActiveBrokerConfig() (*broker.BrokerConfig, error) {
 		&config.UserID,
 		&config.MaxPositions,
 		&config.MaxRiskPerTrade,
+		&config.MaxDailyLoss,
+		&config.MaxSymbolExposure,
 		&config.CreatedAt,
 		&config.UpdatedAt,
 	)
@@ -79,6 +93,7 @@ func (db *Database) GetAllBrokerConfigs() ([]broker.BrokerConfig, error) {
 	query := `
 		SELECT id, broker_name, display_name, enabled, api_key, api_secret,
 		       access_token, user_id, max_positions, max_risk_per_trade,
+		       COALESCE(max_daily_loss, 0), COALESCE(max_symbol_exposure, 0),
 		       created_at, updated_at
 		FROM brokers.config
 		ORDER BY created_at DESC
@@ -104,6 +119,8 @@ func (db *Database) GetAllBrokerConfigs() ([]broker.BrokerConfig, error) {
 			&config.UserID,
 			&config.MaxPositions,
 			&config.MaxRiskPerTrade,
+			&config.MaxDailyLoss,
+			&config.MaxSymbolExposure,
 			&config.CreatedAt,
 			&config.UpdatedAt,
 		)
@@ -121,8 +138,9 @@ func (db *Database) SaveBrokerConfig(config *broker.BrokerConfig) (int, error) {
 	query := `
 		INSERT INTO brokers.config (
 			broker_name, display_name, enabled, api_key, api_secret,
-			access_token, user_id, max_positions, max_risk_per_trade
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			access_token, user_id, max_positions, max_risk_per_trade,
+			max_daily_loss, max_symbol_exposure
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		RETURNING id
 	`
 	
@@ -138,29 +156,13 @@ func (db *Database) SaveBrokerConfig(config *broker.BrokerConfig) (int, error) {
 		config.UserID,
 		config.MaxPositions,
 		config.MaxRiskPerTrade,
+		config.MaxDailyLoss,
+		config.MaxSymbolExposure,
 	).Scan(&id)
 	
 	return id, err
 }
 
-// SaveAnalysis saves analysis results
-func (db *Database) SaveAnalysis(symbol string, analysis interface{}) error {
-	query := `
-		INSERT INTO trades.analysis (
-			symbol, analysis_date, period_days, analysis_json
-		) VALUES ($1, $2, $3, $4)
-	`
-	
-	_, err := db.conn.Exec(query, symbol, time.Now(), 52, analysis)
-	return err
-}
-
-// SaveTrade saves a trade execution
-func (db *Database) SaveTrade(trade interface{}) error {
-	// TODO: Implement trade saving
-	return nil
-}
-
 // ============================================================================
 // INSTRUMENT MANAGEMENT
 // ============================================================================
@@ -198,7 +200,7 @@ func (db *Database) UpsertInstrument(inst Instrument) error {
 			name = EXCLUDED.name,
 			segment = EXCLUDED.segment,
 			instrument_type = EXCLUDED.instrument_type,
-			isin = EXCLUDED.isin,
+			isin = COALESCE(NULLIF(EXCLUDED.isin, ''), trades.instruments.isin),
 			expiry = EXCLUDED.expiry,
 			strike = EXCLUDED.strike,
 			tick_size = EXCLUDED.tick_size,
@@ -280,50 +282,28 @@ func (db *Database) GetInstrumentByToken(token uint32) (*Instrument, error) {
 	return inst, err
 }
 
-// SearchInstruments searches instruments by symbol pattern
-func (db *Database) SearchInstruments(pattern string, limit int) ([]Instrument, error) {
+// GetInstrument returns the instrument listed as symbol on exchange, nil if
+// there is none
+func (db *Database) GetInstrument(exchange, symbol string) (*Instrument, error) {
 	query := `
 		SELECT instrument_token, exchange_token, tradingsymbol, name, exchange,
-		       segment, instrument_type, isin, expiry, strike, tick_size, lot_size,
-		       last_price, last_updated
+		       segment, instrument_type, COALESCE(isin, ''), expiry, strike, tick_size, lot_size,
+		       COALESCE(last_price, 0), last_updated
 		FROM trades.instruments
-		WHERE tradingsymbol ILIKE $1 OR name ILIKE $1
-		ORDER BY tradingsymbol
-		LIMIT $2
+		WHERE exchange = $1 AND tradingsymbol = $2
 	`
 
-	rows, err := db.conn.Query(query, "%"+pattern+"%", limit)
+	rows, err := db.conn.Query(query, exchange, symbol)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	instruments := []Instrument{}
-	for rows.Next() {
-		inst := Instrument{}
-		err := rows.Scan(
-			&inst.InstrumentToken,
-			&inst.ExchangeToken,
-			&inst.Tradingsymbol,
-			&inst.Name,
-			&inst.Exchange,
-			&inst.Segment,
-			&inst.InstrumentType,
-			&inst.ISIN,
-			&inst.Expiry,
-			&inst.Strike,
-			&inst.TickSize,
-			&inst.LotSize,
-			&inst.LastPrice,
-			&inst.LastUpdated,
-		)
-		if err != nil {
-			return nil, err
-		}
-		instruments = append(instruments, inst)
+	instruments, err := scanInstruments(rows)
+	if err != nil || len(instruments) == 0 {
+		return nil, err
 	}
-
-	return instruments, nil
+	return &instruments[0], nil
 }
 
 // ============================================================================
@@ -445,11 +425,77 @@ func (db *Database) GetHistoricalFromCache(
 	return candles, nil
 }
 
+// UpdateBrokerRiskLimits stores the risk limits of a broker configuration
+func (db *Database) UpdateBrokerRiskLimits(brokerID int, maxPositions int, maxRiskPerTrade, maxDailyLoss, maxSymbolExposure float64) error {
+	query := `
+		UPDATE brokers.config
+		SET max_positions = $1,
+		    max_risk_per_trade = $2,
+		    max_daily_loss = $3,
+		    max_symbol_exposure = $4,
+		    updated_at = NOW()
+		WHERE id = $5
+	`
+
+	result, err := db.conn.Exec(query, maxPositions, maxRiskPerTrade, maxDailyLoss, maxSymbolExposure, brokerID)
+	if err != nil {
+		return fmt.Errorf("failed to update risk limits: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("broker config %d not found", brokerID)
+	}
+	return nil
+}
+
 // ============================================================================
 // TOKEN MANAGEMENT
 // ============================================================================
 
-// UpdateBrokerTokens updates access and refresh tokens for a broker
+// Broker token statuses stored in brokers.config.token_status
+const (
+	TokenStatusActive        = "active"
+	TokenStatusExpired       = "expired"        // Needs the user's login
+	TokenStatusRefreshFailed = "refresh_failed" // The refresh token was rejected
+)
+
+// brokerTokenColumns are the brokers.config columns scanned by
+// scanBrokerTokenConfig
+const brokerTokenColumns = `
+		id, broker_name, display_name, enabled, api_key, api_secret,
+		COALESCE(access_token, ''), COALESCE(refresh_token, ''), COALESCE(user_id::text, ''),
+		max_positions, max_risk_per_trade,
+		COALESCE(max_daily_loss, 0), COALESCE(max_symbol_exposure, 0),
+		token_expires_at, last_token_refresh,
+		COALESCE(token_status, 'active'), COALESCE(token_error, ''),
+		created_at, updated_at`
+
+func scanBrokerTokenConfig(row interface{ Scan(...interface{}) error }) (broker.BrokerConfig, error) {
+	config := broker.BrokerConfig{}
+	err := row.Scan(
+		&config.ID,
+		&config.BrokerName,
+		&config.DisplayName,
+		&config.Enabled,
+		&config.APIKey,
+		&config.APISecret,
+		&config.AccessToken,
+		&config.RefreshToken,
+		&config.UserID,
+		&config.MaxPositions,
+		&config.MaxRiskPerTrade,
+		&config.MaxDailyLoss,
+		&config.MaxSymbolExposure,
+		&config.TokenExpiresAt,
+		&config.LastTokenRefresh,
+		&config.TokenStatus,
+		&config.TokenError,
+		&config.CreatedAt,
+		&config.UpdatedAt,
+	)
+	return config, err
+}
+
+// UpdateBrokerTokens stores a broker's renewed tokens and marks them active
 func (db *Database) UpdateBrokerTokens(brokerID int, accessToken, refreshToken string, expiresAt time.Time) error {
 	query := `
 		UPDATE brokers.config
@@ -457,6 +503,8 @@ func (db *Database) UpdateBrokerTokens(brokerID int, accessToken, refreshToken s
 		    refresh_token = $2,
 		    token_expires_at = $3,
 		    last_token_refresh = NOW(),
+		    token_status = 'active',
+		    token_error = NULL,
 		    updated_at = NOW()
 		WHERE id = $4
 	`
@@ -465,12 +513,45 @@ func (db *Database) UpdateBrokerTokens(brokerID int, accessToken, refreshToken s
 	return err
 }
 
-// GetExpiringSoonBrokerConfigs returns brokers whose tokens expire within threshold
-func (db *Database) GetExpiringSoonBrokerConfigs(threshold time.Duration) ([]broker.BrokerConfig, error) {
+// SetBrokerTokenStatus records the outcome of a failed or impossible token
+// refresh
+func (db *Database) SetBrokerTokenStatus(brokerID int, status, tokenError string) error {
 	query := `
-		SELECT id, broker_name, display_name, enabled, api_key, api_secret,
-		       access_token, user_id, max_positions, max_risk_per_trade,
-		       created_at, updated_at
+		UPDATE brokers.config
+		SET token_status = $1,
+		    token_error = NULLIF($2, ''),
+		    updated_at = NOW()
+		WHERE id = $3
+	`
+
+	if _, err := db.conn.Exec(query, status, tokenError, brokerID); err != nil {
+		return fmt.Errorf("failed to update token status: %w", err)
+	}
+	return nil
+}
+
+// GetBrokerConfig returns a broker configuration with its tokens, nil if
+// there is none with the ID
+func (db *Database) GetBrokerConfig(brokerID int) (*broker.BrokerConfig, error) {
+	query := `SELECT` + brokerTokenColumns + `
+		FROM brokers.config
+		WHERE id = $1
+	`
+
+	config, err := scanBrokerTokenConfig(db.conn.QueryRow(query, brokerID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get broker config: %w", err)
+	}
+	return &config, nil
+}
+
+// GetExpiringSoonBrokerConfigs returns brokers whose tokens expire within
+// threshold, or have expired
+func (db *Database) GetExpiringSoonBrokerConfigs(threshold time.Duration) ([]broker.BrokerConfig, error) {
+	query := `SELECT` + brokerTokenColumns + `
 		FROM brokers.config
 		WHERE enabled = true
 		  AND token_expires_at IS NOT NULL
@@ -487,26 +568,12 @@ func (db *Database) GetExpiringSoonBrokerConfigs(threshold time.Duration) ([]bro
 
 	configs := []broker.BrokerConfig{}
 	for rows.Next() {
-		config := broker.BrokerConfig{}
-		err := rows.Scan(
-			&config.ID,
-			&config.BrokerName,
-			&config.DisplayName,
-			&config.Enabled,
-			&config.APIKey,
-			&config.APISecret,
-			&config.AccessToken,
-			&config.UserID,
-			&config.MaxPositions,
-			&config.MaxRiskPerTrade,
-			&config.CreatedAt,
-			&config.UpdatedAt,
-		)
+		config, err := scanBrokerTokenConfig(rows)
 		if err != nil {
 			return nil, err
 		}
 		configs = append(configs, config)
 	}
 
-	return configs, nil
+	return configs, rows.Err()
 }
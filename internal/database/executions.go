@@ -0,0 +1,225 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Execution journal events
+const (
+	ExecutionPlace  = "PLACE"
+	ExecutionModify = "MODIFY"
+	ExecutionCancel = "CANCEL"
+	ExecutionUpdate = "UPDATE"
+)
+
+// Execution is one entry of the order audit trail: an order placed, modified
+// or cancelled through the API, or a status update pushed by the broker
+type Execution struct {
+	ExecutionID    int64     `json:"execution_id" db:"execution_id"`
+	BrokerName     string    `json:"broker_name" db:"broker_name"`
+	UserID         string    `json:"user_id,omitempty" db:"user_id"`
+	Event          string    `json:"event" db:"event"`
+	OrderID        string    `json:"order_id" db:"order_id"`
+	OrderStatus    string    `json:"order_status" db:"order_status"`
+	Symbol         string    `json:"symbol" db:"symbol"`
+	Exchange       string    `json:"exchange" db:"exchange"`
+	Action         string    `json:"action" db:"action"`
+	Quantity       int       `json:"quantity" db:"quantity"`
+	FilledQuantity int       `json:"filled_quantity" db:"filled_quantity"`
+	Price          float64   `json:"price" db:"entry_price"`
+	TriggerPrice   float64   `json:"trigger_price" db:"trigger_price"`
+	AveragePrice   float64   `json:"average_price" db:"average_price"`
+	StopLoss       float64   `json:"stop_loss,omitempty" db:"stop_loss"`
+	TakeProfit     float64   `json:"take_profit,omitempty" db:"take_profit"`
+	OrderType      string    `json:"order_type" db:"order_type"`
+	Product        string    `json:"product" db:"product"`
+	Strategy       string    `json:"strategy,omitempty" db:"strategy"`
+	DryRun         bool      `json:"dry_run" db:"dry_run"`
+	Error          string    `json:"error,omitempty" db:"error"`
+	Notes          string    `json:"notes,omitempty" db:"notes"`
+	ExecutedAt     time.Time `json:"executed_at" db:"executed_at"`
+}
+
+// ExecutionFilter narrows a trade journal query. Zero values match everything.
+type ExecutionFilter struct {
+	UserID   string
+	Symbol   string
+	Strategy string
+	Event    string
+	From     time.Time
+	To       time.Time
+	Limit    int
+}
+
+// SaveTrade appends an execution to the trade journal and fills in its ID
+// and timestamp
+func (db *Database) SaveTrade(execution *Execution) error {
+	query := `
+		INSERT INTO trades.executions (
+			broker_name, user_id, event, order_id, order_status, symbol, exchange,
+			action, quantity, filled_quantity, entry_price, trigger_price, average_price,
+			stop_loss, take_profit, order_type, product, strategy, dry_run, error, notes
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18,
+			$19, $20, $21)
+		RETURNING execution_id, executed_at
+	`
+
+	err := db.conn.QueryRow(query,
+		execution.BrokerName,
+		nullableUserID(execution.UserID),
+		execution.Event,
+		execution.OrderID,
+		execution.OrderStatus,
+		execution.Symbol,
+		execution.Exchange,
+		execution.Action,
+		execution.Quantity,
+		execution.FilledQuantity,
+		execution.Price,
+		execution.TriggerPrice,
+		execution.AveragePrice,
+		nullableFloat(execution.StopLoss),
+		nullableFloat(execution.TakeProfit),
+		execution.OrderType,
+		execution.Product,
+		nullableString(execution.Strategy),
+		execution.DryRun,
+		nullableString(execution.Error),
+		nullableString(execution.Notes),
+	).Scan(&execution.ExecutionID, &execution.ExecutedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save trade execution: %w", err)
+	}
+
+	return nil
+}
+
+// GetLatestExecution returns the most recent journal entry for an order
+func (db *Database) GetLatestExecution(orderID string) (*Execution, error) {
+	query := executionColumns + `
+		WHERE order_id = $1
+		ORDER BY executed_at DESC, execution_id DESC
+		LIMIT 1
+	`
+
+	execution, err := scanExecution(db.conn.QueryRow(query, orderID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get execution: %w", err)
+	}
+
+	return execution, nil
+}
+
+// GetExecutions returns journal entries matching the filter, oldest first
+func (db *Database) GetExecutions(filter ExecutionFilter) ([]Execution, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 500
+	}
+
+	query := `SELECT * FROM (` + executionColumns + `
+		WHERE ($1 = '' OR user_id = $1)
+		  AND ($2 = '' OR symbol = $2)
+		  AND ($3 = '' OR strategy = $3)
+		  AND ($4 = '' OR event = $4)
+		  AND ($5::timestamptz IS NULL OR executed_at >= $5)
+		  AND ($6::timestamptz IS NULL OR executed_at < $6)
+		ORDER BY executed_at DESC, execution_id DESC
+		LIMIT $7
+	) recent ORDER BY executed_at, execution_id`
+
+	rows, err := db.conn.Query(query,
+		filter.UserID,
+		filter.Symbol,
+		filter.Strategy,
+		filter.Event,
+		nullableTime(filter.From),
+		nullableTime(filter.To),
+		filter.Limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get executions: %w", err)
+	}
+	defer rows.Close()
+
+	executions := []Execution{}
+	for rows.Next() {
+		execution, err := scanExecution(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan execution: %w", err)
+		}
+		executions = append(executions, *execution)
+	}
+
+	return executions, rows.Err()
+}
+
+const executionColumns = `
+	SELECT execution_id, COALESCE(broker_name, ''), COALESCE(user_id, ''), event,
+	       COALESCE(order_id, ''), COALESCE(order_status, ''), symbol, exchange, action,
+	       quantity, filled_quantity, COALESCE(entry_price, 0), COALESCE(trigger_price, 0),
+	       COALESCE(average_price, 0), COALESCE(stop_loss, 0), COALESCE(take_profit, 0),
+	       order_type, product, COALESCE(strategy, ''),
+	       COALESCE(dry_run, FALSE), COALESCE(error, ''), COALESCE(notes, ''), executed_at
+	FROM trades.executions`
+
+func scanExecution(row rowScanner) (*Execution, error) {
+	var e Execution
+	err := row.Scan(
+		&e.ExecutionID,
+		&e.BrokerName,
+		&e.UserID,
+		&e.Event,
+		&e.OrderID,
+		&e.OrderStatus,
+		&e.Symbol,
+		&e.Exchange,
+		&e.Action,
+		&e.Quantity,
+		&e.FilledQuantity,
+		&e.Price,
+		&e.TriggerPrice,
+		&e.AveragePrice,
+		&e.StopLoss,
+		&e.TakeProfit,
+		&e.OrderType,
+		&e.Product,
+		&e.Strategy,
+		&e.DryRun,
+		&e.Error,
+		&e.Notes,
+		&e.ExecutedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// nullableString stores "" as NULL
+func nullableString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// nullableFloat stores 0 as NULL
+func nullableFloat(v float64) interface{} {
+	if v == 0 {
+		return nil
+	}
+	return v
+}
+
+// nullableTime stores the zero time as NULL
+func nullableTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
@@ -2,35 +2,120 @@ package database
 
 import (
 	"log"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/metrics"
+	"github.com/trading-chitti/market-bridge/internal/timeframe"
 )
 
 // HistoricalDataService provides cached historical data access
 type HistoricalDataService struct {
 	db     *Database
 	broker broker.Broker
+	policy CachePolicy
+
+	pruneMu   sync.Mutex
+	lastPrune time.Time
 }
 
-// NewHistoricalDataService creates a new historical data service
+// NewHistoricalDataService creates a new historical data service caching
+// under DefaultCachePolicy
 func NewHistoricalDataService(db *Database, brk broker.Broker) *HistoricalDataService {
 	return &HistoricalDataService{
 		db:     db,
 		broker: brk,
+		policy: DefaultCachePolicy(),
 	}
 }
 
-// GetHistoricalData fetches historical data with caching
+// SetCachePolicy replaces the cache policy
+func (s *HistoricalDataService) SetCachePolicy(policy CachePolicy) {
+	s.policy = policy
+}
+
+// CachePolicy returns the cache policy
+func (s *HistoricalDataService) CachePolicy() CachePolicy {
+	return s.policy
+}
+
+// GetHistoricalData fetches historical data with caching. Continuous
+// futures symbols (NFO:NIFTY-I) are stitched from their contracts, and
+// renamed symbols from the candles of their earlier symbols. The interval
+// may be a Kite name (5minute) or bar timeframe (5m); it is cached under
+// the Kite name.
 func (s *HistoricalDataService) GetHistoricalData(
 	exchange, symbol, interval string,
 	fromDate, toDate time.Time,
 ) ([]HistoricalCandle, error) {
-	// Get instrument token
+	interval, err := timeframe.KiteInterval(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	if underlying, ok := ContinuousUnderlying(exchange, symbol); ok {
+		candles, _, err := s.GetContinuousData(exchange, underlying, interval, fromDate, toDate, ContinuousOptions{})
+		return candles, err
+	}
+
+	// Get instrument token; indices are known without an instrument sync
+	index, ok := ResolveIndex(exchange, symbol)
+	if ok {
+		return s.getSymbolData(exchange, symbol, index.InstrumentToken, interval, fromDate, toDate)
+	}
+
+	changes, err := s.db.GetSymbolHistory(exchange, symbol)
+	if err != nil {
+		log.Printf("⚠️  Failed to read symbol changes of %s:%s: %v", exchange, symbol, err)
+	}
+	if segments := SymbolSegments(symbol, changes, fromDate, toDate); len(segments) > 1 {
+		return s.getRenamedData(exchange, interval, segments)
+	}
+
 	token, err := s.db.GetInstrumentToken(exchange, symbol)
 	if err != nil {
 		return nil, err
 	}
+	return s.getSymbolData(exchange, symbol, token, interval, fromDate, toDate)
+}
+
+// getRenamedData joins the candles of a renamed instrument's segments, each
+// fetched under the symbol it traded as
+func (s *HistoricalDataService) getRenamedData(exchange, interval string, segments []SymbolSegment) ([]HistoricalCandle, error) {
+	var candles []HistoricalCandle
+	for _, segment := range segments {
+		token, err := s.db.GetInstrumentToken(exchange, segment.Symbol)
+		if err != nil {
+			return nil, err
+		}
+		if token == 0 {
+			log.Printf("⚠️  Instrument token not found for %s:%s, skipping its history before the rename", exchange, segment.Symbol)
+			continue
+		}
+
+		segmentCandles, err := s.getSymbolData(exchange, segment.Symbol, token, interval, segment.From, segment.To)
+		if err != nil {
+			return nil, err
+		}
+		for _, candle := range segmentCandles {
+			if !candle.CandleTimestamp.Before(segment.From) && !candle.CandleTimestamp.After(segment.To) {
+				candles = append(candles, candle)
+			}
+		}
+	}
+	return candles, nil
+}
+
+// getSymbolData fetches a symbol's candles by instrument token, from the
+// cache when it covers the range
+func (s *HistoricalDataService) getSymbolData(
+	exchange, symbol string,
+	token uint32,
+	interval string,
+	fromDate, toDate time.Time,
+) ([]HistoricalCandle, error) {
 
 	if token == 0 {
 		log.Printf("⚠️  Instrument token not found for %s:%s", exchange, symbol)
@@ -43,17 +128,55 @@ func (s *HistoricalDataService) GetHistoricalData(
 		log.Printf("❌ Cache read error: %v", err)
 		// Continue to fetch from broker
 	}
+	if err := s.db.TouchHistoricalCache(token, interval); err != nil {
+		log.Printf("⚠️  Failed to record cache read of %s: %v", symbol, err)
+	}
 
-	// If we have complete data in cache, return it
+	// If we have complete data in cache, return it, re-fetching from the
+	// first stale candle's day on
 	if s.isCacheComplete(cached, fromDate, toDate, interval) {
-		log.Printf("✅ Returning %d candles from cache for %s", len(cached), symbol)
-		return cached, nil
+		stale := s.policy.firstStale(cached, time.Now())
+		if stale == len(cached) {
+			metrics.RecordHistoricalCache(interval, CacheHit)
+			log.Printf("✅ Returning %d candles from cache for %s", len(cached), symbol)
+			return cached, nil
+		}
+
+		refetchFrom, _ := ISTDay(cached[stale].CandleTimestamp)
+		if refetchFrom.Before(fromDate) {
+			refetchFrom = fromDate
+		}
+		fresh, err := s.fetchAndCache(symbol, token, interval, refetchFrom, toDate)
+		if err != nil {
+			return nil, err
+		}
+		metrics.RecordHistoricalCache(interval, CacheStale)
+
+		candles := make([]HistoricalCandle, 0, len(cached)+len(fresh))
+		for _, candle := range cached {
+			if candle.CandleTimestamp.Before(refetchFrom) {
+				candles = append(candles, candle)
+			}
+		}
+		return append(candles, fresh...), nil
 	}
 
+	metrics.RecordHistoricalCache(interval, CacheMiss)
+	return s.fetchAndCache(symbol, token, interval, fromDate, toDate)
+}
+
+// fetchAndCache fetches an instrument's candles from the broker and caches
+// them
+func (s *HistoricalDataService) fetchAndCache(
+	symbol string,
+	token uint32,
+	interval string,
+	fromDate, toDate time.Time,
+) ([]HistoricalCandle, error) {
 	// Fetch from broker
 	log.Printf("🔄 Fetching historical data from broker for %s (%s)", symbol, interval)
 
-	brokerCandles, err := s.broker.GetHistoricalData(symbol, fromDate, toDate, interval)
+	brokerCandles, err := s.broker.GetHistoricalData(strconv.FormatUint(uint64(token), 10), fromDate, toDate, interval)
 	if err != nil {
 		return nil, err
 	}
@@ -81,11 +204,76 @@ func (s *HistoricalDataService) GetHistoricalData(
 		// Continue anyway, return the data
 	} else {
 		log.Printf("💾 Cached %d candles for %s", len(dbCandles), symbol)
+		s.maybePrune()
 	}
 
 	return dbCandles, nil
 }
 
+// maybePrune prunes the cache to the policy's row cap, at most once per
+// prune interval
+func (s *HistoricalDataService) maybePrune() {
+	if s.policy.MaxRows <= 0 {
+		return
+	}
+
+	s.pruneMu.Lock()
+	defer s.pruneMu.Unlock()
+	if time.Since(s.lastPrune) < s.policy.PruneInterval {
+		return
+	}
+	s.lastPrune = time.Now()
+
+	if _, err := s.PruneCache(); err != nil {
+		log.Printf("⚠️  Failed to prune historical cache: %v", err)
+	}
+}
+
+// PruneCache deletes the least recently read series beyond the policy's
+// row cap, returning the candles deleted. It does nothing without a cap.
+func (s *HistoricalDataService) PruneCache() (int64, error) {
+	if s.policy.MaxRows <= 0 {
+		return 0, nil
+	}
+
+	rows, pruned, err := s.db.PruneHistoricalCache(s.policy.MaxRows)
+	if err != nil {
+		return pruned, err
+	}
+	metrics.RecordHistoricalCachePrune(rows, pruned)
+	if pruned > 0 {
+		log.Printf("🧹 Pruned %d cached candles over the %d row cap", pruned, s.policy.MaxRows)
+	}
+	return pruned, nil
+}
+
+// InvalidateCache deletes a symbol's cached candles of interval (every
+// interval if empty) from fromDate to toDate (open-ended if zero), so the
+// next read fetches them from the broker. It returns the candles deleted.
+func (s *HistoricalDataService) InvalidateCache(exchange, symbol, interval string, fromDate, toDate time.Time) (int64, error) {
+	if interval != "" {
+		var err error
+		if interval, err = timeframe.KiteInterval(interval); err != nil {
+			return 0, err
+		}
+	}
+
+	var token uint32
+	if index, ok := ResolveIndex(exchange, symbol); ok {
+		token = index.InstrumentToken
+	} else {
+		var err error
+		if token, err = s.db.GetInstrumentToken(exchange, symbol); err != nil {
+			return 0, err
+		}
+	}
+	if token == 0 {
+		return 0, ErrInstrumentNotFound
+	}
+
+	return s.db.InvalidateHistoricalCache(token, interval, fromDate, toDate)
+}
+
 // isCacheComplete checks if cached data is complete for the requested range
 func (s *HistoricalDataService) isCacheComplete(
 	cached []HistoricalCandle,
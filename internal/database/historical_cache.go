@@ -0,0 +1,210 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/timeframe"
+)
+
+// ErrInstrumentNotFound is returned for symbols without an instrument token
+var ErrInstrumentNotFound = errors.New("instrument not found")
+
+// sessionClose is when the NSE/BSE session closes after IST midnight
+const sessionClose = 15*time.Hour + 30*time.Minute
+
+// Historical cache read results, the result label of the cache metrics
+const (
+	CacheHit   = "hit"   // Served from the cache
+	CacheStale = "stale" // Cached, with stale recent candles re-fetched
+	CacheMiss  = "miss"  // Fetched from the broker
+)
+
+// CachePolicy decides when cached historical candles are re-fetched and
+// how large the cache may grow
+type CachePolicy struct {
+	TTL           time.Duration // How long any candle is served before it may be stale
+	RefetchDays   int           // Candles of the last RefetchDays IST days are re-fetched once older than TTL
+	MaxRows       int64         // Cached candles kept, least recently read series pruned first; 0 for no cap
+	PruneInterval time.Duration // Least time between prunes
+}
+
+// DefaultCachePolicy re-fetches today's and yesterday's candles after 5
+// minutes, and never prunes
+func DefaultCachePolicy() CachePolicy {
+	return CachePolicy{
+		TTL:           5 * time.Minute,
+		RefetchDays:   2,
+		PruneInterval: 10 * time.Minute,
+	}
+}
+
+// Stale reports whether a cached candle must be re-fetched at now: once
+// older than the TTL, a candle cached before it ended (a partial candle of
+// a session then trading) or of the last RefetchDays days, which brokers
+// still correct
+func (p CachePolicy) Stale(candle HistoricalCandle, now time.Time) bool {
+	if now.Sub(candle.CachedAt) <= p.TTL {
+		return false
+	}
+	if candle.CachedAt.Before(candleEnd(candle)) {
+		return true
+	}
+	today, _ := ISTDay(now)
+	return !candle.CandleTimestamp.Before(today.AddDate(0, 0, 1-p.RefetchDays))
+}
+
+// candleEnd returns when a candle's interval ends, at the latest its
+// session's close
+func candleEnd(candle HistoricalCandle) time.Time {
+	day, _ := ISTDay(candle.CandleTimestamp)
+	end := day.Add(sessionClose)
+	if tf, err := timeframe.Parse(candle.Interval); err == nil && tf != timeframe.Day {
+		if candleEnd := candle.CandleTimestamp.Add(tf.Duration()); candleEnd.Before(end) {
+			return candleEnd
+		}
+	}
+	return end
+}
+
+// firstStale returns the index of the first stale candle, len(candles) if
+// none is
+func (p CachePolicy) firstStale(candles []HistoricalCandle, now time.Time) int {
+	for i, candle := range candles {
+		if p.Stale(candle, now) {
+			return i
+		}
+	}
+	return len(candles)
+}
+
+// HistoricalCacheStats describes the historical cache
+type HistoricalCacheStats struct {
+	Rows           int64      `json:"rows"`
+	Series         int64      `json:"series"` // Instrument and interval pairs
+	OldestCachedAt *time.Time `json:"oldest_cached_at,omitempty"`
+	NewestCachedAt *time.Time `json:"newest_cached_at,omitempty"`
+}
+
+// GetHistoricalCacheStats counts the cached candles and series
+func (db *Database) GetHistoricalCacheStats() (*HistoricalCacheStats, error) {
+	query := `
+		SELECT COUNT(*),
+		       COUNT(DISTINCT (instrument_token, interval)),
+		       MIN(cached_at),
+		       MAX(cached_at)
+		FROM trades.historical_cache
+	`
+
+	stats := &HistoricalCacheStats{}
+	err := db.conn.QueryRow(query).Scan(&stats.Rows, &stats.Series, &stats.OldestCachedAt, &stats.NewestCachedAt)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// TouchHistoricalCache records a read of an instrument's cached candles
+func (db *Database) TouchHistoricalCache(instrumentToken uint32, interval string) error {
+	query := `
+		INSERT INTO trades.historical_cache_access (instrument_token, interval, last_accessed_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (instrument_token, interval)
+		DO UPDATE SET last_accessed_at = NOW()
+	`
+
+	_, err := db.conn.Exec(query, instrumentToken, interval)
+	return err
+}
+
+// InvalidateHistoricalCache deletes an instrument's cached candles from
+// fromDate to toDate, returning how many. An empty interval matches every
+// interval, zero dates leave the range open.
+func (db *Database) InvalidateHistoricalCache(instrumentToken uint32, interval string, fromDate, toDate time.Time) (int64, error) {
+	query := `
+		DELETE FROM trades.historical_cache
+		WHERE instrument_token = $1
+		  AND ($2 = '' OR interval = $2)
+		  AND ($3::timestamptz IS NULL OR candle_timestamp >= $3)
+		  AND ($4::timestamptz IS NULL OR candle_timestamp <= $4)
+	`
+
+	result, err := db.conn.Exec(query, instrumentToken, interval, nullableTime(fromDate), nullableTime(toDate))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ClearHistoricalCache deletes every cached candle of interval (all if
+// empty), returning how many
+func (db *Database) ClearHistoricalCache(interval string) (int64, error) {
+	query := `
+		DELETE FROM trades.historical_cache
+		WHERE $1 = '' OR interval = $1
+	`
+
+	result, err := db.conn.Exec(query, interval)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// PruneHistoricalCache deletes whole series, least recently read first,
+// until at most maxRows cached candles remain. It returns the rows cached
+// before pruning and the rows deleted.
+func (db *Database) PruneHistoricalCache(maxRows int64) (int64, int64, error) {
+	var rows int64
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM trades.historical_cache`).Scan(&rows); err != nil {
+		return 0, 0, err
+	}
+	if rows <= maxRows {
+		return rows, 0, nil
+	}
+
+	// Keep series, most recently read first, while their running total of
+	// rows fits; series never read count as the oldest
+	query := `
+		WITH sizes AS (
+			SELECT instrument_token, interval, COUNT(*) AS rows
+			FROM trades.historical_cache
+			GROUP BY instrument_token, interval
+		), ranked AS (
+			SELECT sizes.instrument_token, sizes.interval,
+			       SUM(sizes.rows) OVER (
+			           ORDER BY COALESCE(access.last_accessed_at, '-infinity') DESC,
+			                    sizes.instrument_token, sizes.interval
+			       ) AS running_rows
+			FROM sizes
+			LEFT JOIN trades.historical_cache_access access
+			  ON access.instrument_token = sizes.instrument_token
+			 AND access.interval = sizes.interval
+		)
+		DELETE FROM trades.historical_cache cache
+		USING ranked
+		WHERE cache.instrument_token = ranked.instrument_token
+		  AND cache.interval = ranked.interval
+		  AND ranked.running_rows > $1
+	`
+
+	result, err := db.conn.Exec(query, maxRows)
+	if err != nil {
+		return rows, 0, err
+	}
+	pruned, err := result.RowsAffected()
+	if err != nil {
+		return rows, 0, err
+	}
+
+	// Forget the reads of series no longer cached
+	_, err = db.conn.Exec(`
+		DELETE FROM trades.historical_cache_access access
+		WHERE NOT EXISTS (
+			SELECT 1 FROM trades.historical_cache cache
+			WHERE cache.instrument_token = access.instrument_token
+			  AND cache.interval = access.interval
+		)
+	`)
+	return rows, pruned, err
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCachePolicyStale(t *testing.T) {
+	ist := time.FixedZone("IST", 5*3600+1800)
+	now := time.Date(2024, 1, 31, 11, 0, 0, 0, ist) // Wednesday, mid-session
+	policy := CachePolicy{TTL: 5 * time.Minute, RefetchDays: 2}
+
+	tests := []struct {
+		name   string
+		candle HistoricalCandle
+		want   bool
+	}{
+		{
+			name:   "old day candle",
+			candle: HistoricalCandle{Interval: "day", CandleTimestamp: time.Date(2024, 1, 10, 0, 0, 0, 0, ist), CachedAt: time.Date(2024, 1, 12, 9, 0, 0, 0, ist)},
+		},
+		{
+			name:   "partial day candle of an old session",
+			candle: HistoricalCandle{Interval: "day", CandleTimestamp: time.Date(2024, 1, 10, 0, 0, 0, 0, ist), CachedAt: time.Date(2024, 1, 10, 12, 0, 0, 0, ist)},
+			want:   true,
+		},
+		{
+			name:   "yesterday cached after the close",
+			candle: HistoricalCandle{Interval: "day", CandleTimestamp: time.Date(2024, 1, 30, 0, 0, 0, 0, ist), CachedAt: time.Date(2024, 1, 30, 18, 0, 0, 0, ist)},
+			want:   true,
+		},
+		{
+			name:   "two days ago",
+			candle: HistoricalCandle{Interval: "day", CandleTimestamp: time.Date(2024, 1, 29, 0, 0, 0, 0, ist), CachedAt: time.Date(2024, 1, 29, 18, 0, 0, 0, ist)},
+		},
+		{
+			name:   "today within the TTL",
+			candle: HistoricalCandle{Interval: "day", CandleTimestamp: time.Date(2024, 1, 31, 0, 0, 0, 0, ist), CachedAt: now.Add(-4 * time.Minute)},
+		},
+		{
+			name:   "today past the TTL",
+			candle: HistoricalCandle{Interval: "day", CandleTimestamp: time.Date(2024, 1, 31, 0, 0, 0, 0, ist), CachedAt: now.Add(-6 * time.Minute)},
+			want:   true,
+		},
+		{
+			name:   "finished 5minute candle of an old session",
+			candle: HistoricalCandle{Interval: "5minute", CandleTimestamp: time.Date(2024, 1, 10, 9, 15, 0, 0, ist), CachedAt: time.Date(2024, 1, 10, 9, 21, 0, 0, ist)},
+		},
+		{
+			name:   "partial 5minute candle of an old session",
+			candle: HistoricalCandle{Interval: "5minute", CandleTimestamp: time.Date(2024, 1, 10, 9, 15, 0, 0, ist), CachedAt: time.Date(2024, 1, 10, 9, 17, 0, 0, ist)},
+			want:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Stale(tt.candle, now); got != tt.want {
+				t.Errorf("Stale = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCachePolicyNoRefetchDays(t *testing.T) {
+	ist := time.FixedZone("IST", 5*3600+1800)
+	now := time.Date(2024, 1, 31, 18, 0, 0, 0, ist)
+	policy := CachePolicy{TTL: time.Minute}
+
+	candles := []HistoricalCandle{
+		{Interval: "day", CandleTimestamp: time.Date(2024, 1, 30, 0, 0, 0, 0, ist), CachedAt: time.Date(2024, 1, 30, 16, 0, 0, 0, ist)},
+		{Interval: "day", CandleTimestamp: time.Date(2024, 1, 31, 0, 0, 0, 0, ist), CachedAt: time.Date(2024, 1, 31, 16, 0, 0, 0, ist)},
+	}
+	if i := policy.firstStale(candles, now); i != len(candles) {
+		t.Errorf("firstStale = %d, want none stale", i)
+	}
+
+	candles[1].CachedAt = time.Date(2024, 1, 31, 14, 0, 0, 0, ist)
+	if i := policy.firstStale(candles, now); i != 1 {
+		t.Errorf("firstStale = %d, want the partial candle 1", i)
+	}
+}
@@ -0,0 +1,56 @@
+package database
+
+import "strings"
+
+// IndexExchange tags the ticks and bars collectors store for index
+// instruments, so they never mix with an equity of the same name
+const IndexExchange = "INDEX"
+
+// Index is an index instrument collectors and historical data know without
+// an instrument sync
+type Index struct {
+	Symbol          string   `json:"symbol"`   // Tradingsymbol, also the stored symbol
+	Exchange        string   `json:"exchange"` // Exchange the broker lists it on
+	InstrumentToken uint32   `json:"instrument_token"`
+	Aliases         []string `json:"aliases"`
+	Watchlist       string   `json:"watchlist,omitempty"` // Watchlist of its constituents
+}
+
+// Indices are the supported indices, with their Kite instrument tokens
+var Indices = []Index{
+	{Symbol: "NIFTY 50", Exchange: "NSE", InstrumentToken: 256265, Aliases: []string{"NIFTY", "NIFTY50"}, Watchlist: "NIFTY50"},
+	{Symbol: "NIFTY BANK", Exchange: "NSE", InstrumentToken: 260105, Aliases: []string{"BANKNIFTY"}, Watchlist: "BANKNIFTY"},
+	{Symbol: "INDIA VIX", Exchange: "NSE", InstrumentToken: 264969, Aliases: []string{"VIX", "INDIAVIX"}},
+}
+
+// LookupIndex returns the index named by its symbol or an alias, in any
+// case
+func LookupIndex(name string) (Index, bool) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	for _, index := range Indices {
+		if name == index.Symbol {
+			return index, true
+		}
+		for _, alias := range index.Aliases {
+			if name == alias {
+				return index, true
+			}
+		}
+	}
+	return Index{}, false
+}
+
+// ResolveIndex returns the index a symbol on exchange names, ok false for
+// anything else. Aliases only count on IndexExchange, where no equity can
+// share them.
+func ResolveIndex(exchange, symbol string) (Index, bool) {
+	index, ok := LookupIndex(symbol)
+	if !ok {
+		return Index{}, false
+	}
+	exchange = strings.ToUpper(exchange)
+	if exchange == IndexExchange || (exchange == index.Exchange && strings.ToUpper(symbol) == index.Symbol) {
+		return index, true
+	}
+	return Index{}, false
+}
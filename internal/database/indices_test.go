@@ -0,0 +1,28 @@
+package database
+
+import "testing"
+
+func TestResolveIndex(t *testing.T) {
+	tests := []struct {
+		exchange string
+		symbol   string
+		want     string // Resolved index, empty for none
+	}{
+		{"NSE", "NIFTY 50", "NIFTY 50"},
+		{"nse", "nifty bank", "NIFTY BANK"},
+		{"INDEX", "NIFTY", "NIFTY 50"},
+		{"INDEX", "banknifty", "NIFTY BANK"},
+		{"INDEX", "VIX", "INDIA VIX"},
+		{"NSE", "NIFTY", ""}, // Aliases only on INDEX
+		{"BSE", "NIFTY 50", ""},
+		{"NSE", "RELIANCE", ""},
+		{"INDEX", "RELIANCE", ""},
+	}
+
+	for _, tt := range tests {
+		index, ok := ResolveIndex(tt.exchange, tt.symbol)
+		if ok != (tt.want != "") || index.Symbol != tt.want {
+			t.Errorf("ResolveIndex(%q, %q) = %q, %v, want %q", tt.exchange, tt.symbol, index.Symbol, ok, tt.want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package database
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned for a search or pagination cursor this
+// package didn't issue
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// InstrumentFilter narrows an instrument search. Zero fields don't filter;
+// Query matches the symbol or name anywhere, case-insensitively.
+type InstrumentFilter struct {
+	Query          string
+	Exchange       string
+	Segment        string
+	InstrumentType string
+	ExpiryFrom     *time.Time // Inclusive, by date
+	ExpiryTo       *time.Time // Inclusive, by date
+	StrikeMin      *float64
+	StrikeMax      *float64
+	Cursor         string // NextCursor of the previous page
+	Limit          int
+}
+
+// InstrumentPage is a page of search results, ordered by symbol then
+// exchange. NextCursor is empty on the last page.
+type InstrumentPage struct {
+	Instruments []Instrument
+	NextCursor  string
+}
+
+// EncodeInstrumentCursor returns the cursor of the page after inst
+func EncodeInstrumentCursor(inst Instrument) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(inst.Tradingsymbol + "\n" + inst.Exchange))
+}
+
+// DecodeInstrumentCursor returns the symbol and exchange a cursor continues
+// after
+func DecodeInstrumentCursor(cursor string) (string, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", "", ErrInvalidCursor
+	}
+	symbol, exchange, ok := strings.Cut(string(raw), "\n")
+	if !ok || symbol == "" {
+		return "", "", ErrInvalidCursor
+	}
+	return symbol, exchange, nil
+}
+
+// instrumentSearchQuery builds the query and arguments of a search page,
+// fetching one row past the limit to tell whether another page follows
+func instrumentSearchQuery(filter InstrumentFilter) (string, []interface{}, error) {
+	var conditions []string
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.Query != "" {
+		pattern := arg("%" + escapeLike(filter.Query) + "%")
+		conditions = append(conditions, fmt.Sprintf("(tradingsymbol ILIKE %s OR name ILIKE %s)", pattern, pattern))
+	}
+	if filter.Exchange != "" {
+		conditions = append(conditions, "exchange = "+arg(strings.ToUpper(filter.Exchange)))
+	}
+	if filter.Segment != "" {
+		conditions = append(conditions, "segment = "+arg(strings.ToUpper(filter.Segment)))
+	}
+	if filter.InstrumentType != "" {
+		conditions = append(conditions, "instrument_type = "+arg(strings.ToUpper(filter.InstrumentType)))
+	}
+	if filter.ExpiryFrom != nil {
+		conditions = append(conditions, "expiry >= "+arg(filter.ExpiryFrom.Format("2006-01-02"))+"::date")
+	}
+	if filter.ExpiryTo != nil {
+		conditions = append(conditions, "expiry <= "+arg(filter.ExpiryTo.Format("2006-01-02"))+"::date")
+	}
+	if filter.StrikeMin != nil {
+		conditions = append(conditions, "strike >= "+arg(*filter.StrikeMin))
+	}
+	if filter.StrikeMax != nil {
+		conditions = append(conditions, "strike <= "+arg(*filter.StrikeMax))
+	}
+	if filter.Cursor != "" {
+		symbol, exchange, err := DecodeInstrumentCursor(filter.Cursor)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, fmt.Sprintf("(tradingsymbol, exchange) > (%s, %s)", arg(symbol), arg(exchange)))
+	}
+
+	query := `
+		SELECT instrument_token, exchange_token, tradingsymbol, name, exchange,
+		       segment, instrument_type, COALESCE(isin, ''), expiry, strike, tick_size, lot_size,
+		       COALESCE(last_price, 0), last_updated
+		FROM trades.instruments`
+	if len(conditions) > 0 {
+		query += "\n\t\tWHERE " + strings.Join(conditions, "\n\t\t  AND ")
+	}
+	query += "\n\t\tORDER BY tradingsymbol, exchange\n\t\tLIMIT " + arg(filter.Limit+1)
+
+	return query, args, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// SearchInstruments returns a page of the instruments matching filter
+func (db *Database) SearchInstruments(filter InstrumentFilter) (*InstrumentPage, error) {
+	query, args, err := instrumentSearchQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	instruments, err := scanInstruments(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &InstrumentPage{Instruments: instruments}
+	if len(instruments) > filter.Limit {
+		page.Instruments = instruments[:filter.Limit]
+		page.NextCursor = EncodeInstrumentCursor(page.Instruments[filter.Limit-1])
+	}
+	return page, nil
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInstrumentCursor(t *testing.T) {
+	tests := []struct {
+		symbol   string
+		exchange string
+	}{
+		{"NIFTY24MAR22000CE", "NFO"},
+		{"NIFTY 50", "INDEX"},
+		{"M&M", "NSE"},
+	}
+
+	for _, tt := range tests {
+		cursor := EncodeInstrumentCursor(Instrument{Tradingsymbol: tt.symbol, Exchange: tt.exchange})
+		symbol, exchange, err := DecodeInstrumentCursor(cursor)
+		if err != nil || symbol != tt.symbol || exchange != tt.exchange {
+			t.Errorf("cursor %q decoded to %q, %q, %v, want %q, %q", cursor, symbol, exchange, err, tt.symbol, tt.exchange)
+		}
+	}
+
+	for _, cursor := range []string{"not base64!", "", "Tk9ORVdMSU5F"} {
+		if _, _, err := DecodeInstrumentCursor(cursor); err != ErrInvalidCursor {
+			t.Errorf("DecodeInstrumentCursor(%q) error = %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+}
+
+func TestInstrumentSearchQuery(t *testing.T) {
+	expiry := time.Date(2024, 3, 28, 0, 0, 0, 0, istZone)
+	strikeMin, strikeMax := 21000.0, 23000.0
+	cursor := EncodeInstrumentCursor(Instrument{Tradingsymbol: "NIFTY24MAR21500CE", Exchange: "NFO"})
+
+	tests := []struct {
+		name      string
+		filter    InstrumentFilter
+		wantWhere []string
+		wantArgs  []interface{}
+	}{
+		{
+			name:      "query only",
+			filter:    InstrumentFilter{Query: "50_50%", Limit: 20},
+			wantWhere: []string{"(tradingsymbol ILIKE $1 OR name ILIKE $1)"},
+			wantArgs:  []interface{}{`%50\_50\%%`, 21},
+		},
+		{
+			name: "option filters after a cursor",
+			filter: InstrumentFilter{
+				Query: "nifty", Exchange: "nfo", InstrumentType: "ce",
+				ExpiryFrom: &expiry, ExpiryTo: &expiry,
+				StrikeMin: &strikeMin, StrikeMax: &strikeMax,
+				Cursor: cursor, Limit: 50,
+			},
+			wantWhere: []string{
+				"(tradingsymbol ILIKE $1 OR name ILIKE $1)",
+				"exchange = $2",
+				"instrument_type = $3",
+				"expiry >= $4::date",
+				"expiry <= $5::date",
+				"strike >= $6",
+				"strike <= $7",
+				"(tradingsymbol, exchange) > ($8, $9)",
+			},
+			wantArgs: []interface{}{"%nifty%", "NFO", "CE", "2024-03-28", "2024-03-28", 21000.0, 23000.0, "NIFTY24MAR21500CE", "NFO", 51},
+		},
+		{
+			name:      "segment only",
+			filter:    InstrumentFilter{Segment: "nfo-opt", Limit: 10},
+			wantWhere: []string{"segment = $1"},
+			wantArgs:  []interface{}{"NFO-OPT", 11},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := instrumentSearchQuery(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, condition := range tt.wantWhere {
+				if !strings.Contains(query, condition) {
+					t.Errorf("query lacks %q:\n%s", condition, query)
+				}
+			}
+			if !strings.Contains(query, "ORDER BY tradingsymbol, exchange") {
+				t.Errorf("query isn't ordered for keyset pagination:\n%s", query)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %#v, want %#v", args, tt.wantArgs)
+			}
+		})
+	}
+
+	if _, _, err := instrumentSearchQuery(InstrumentFilter{Cursor: "!!", Limit: 20}); err != ErrInvalidCursor {
+		t.Errorf("bad cursor error = %v, want ErrInvalidCursor", err)
+	}
+}
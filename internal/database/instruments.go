@@ -10,13 +10,27 @@ import (
 
 // SyncInstrumentsFromBroker fetches all instruments from broker and syncs to database
 func (db *Database) SyncInstrumentsFromBroker(brk broker.Broker) error {
-	log.Println("🔄 Starting instrument sync...")
+	return SyncInstruments(db, brk, "")
+}
+
+// SyncInstrumentsByExchange syncs instruments for specific exchange
+func (db *Database) SyncInstrumentsByExchange(brk broker.Broker, exchange string) error {
+	return SyncInstruments(db, brk, exchange)
+}
+
+// SyncInstruments fetches the broker's instruments, all of them or those of
+// one exchange, into store
+func SyncInstruments(store Store, brk broker.Broker, exchange string) error {
+	if exchange == "" {
+		log.Println("🔄 Starting instrument sync...")
+	} else {
+		log.Printf("🔄 Starting instrument sync for exchange: %s", exchange)
+	}
 
 	// Type assertion to get underlying Kite client
-	zerodhaBroker, ok := brk.(*broker.ZerodhaBroker)
+	zerodhaBroker, ok := broker.Unwrap(brk).(*broker.ZerodhaBroker)
 	if !ok {
-		log.Println("⚠️  Instrument sync currently only supports Zerodha")
-		return nil
+		return syncGenericInstruments(store, brk, exchange)
 	}
 
 	// Fetch instruments from Zerodha
@@ -39,15 +53,19 @@ func (db *Database) SyncInstrumentsFromBroker(brk broker.Broker) error {
 
 		batch := instruments[i:end]
 		for _, inst := range batch {
+			if exchange != "" && inst.Exchange != exchange {
+				continue
+			}
+
 			dbInst := convertToDBInstrument(inst)
-			if err := db.UpsertInstrument(dbInst); err != nil {
+			if err := store.UpsertInstrument(dbInst); err != nil {
 				log.Printf("❌ Error syncing %s: %v", inst.Tradingsymbol, err)
 				continue
 			}
 			synced++
 		}
 
-		log.Printf("📊 Synced %d/%d instruments", synced, len(instruments))
+		log.Printf("📊 Synced %d instruments (%d/%d fetched)", synced, end, len(instruments))
 	}
 
 	log.Printf("✅ Instrument sync completed: %d instruments synced", synced)
@@ -83,35 +101,40 @@ func convertToDBInstrument(kiteInst kiteconnect.Instrument) Instrument {
 	return inst
 }
 
-// SyncInstrumentsByExchange syncs instruments for specific exchange
-func (db *Database) SyncInstrumentsByExchange(brk broker.Broker, exchange string) error {
-	log.Printf("🔄 Starting instrument sync for exchange: %s", exchange)
-
-	zerodhaBroker, ok := brk.(*broker.ZerodhaBroker)
-	if !ok {
-		return nil
-	}
-
-	instruments, err := zerodhaBroker.GetClient().GetInstruments()
+// syncGenericInstruments syncs instruments through the Broker interface for
+// brokers without a Kite client (Angel One, Upstox, ...)
+func syncGenericInstruments(store Store, brk broker.Broker, exchange string) error {
+	instruments, err := brk.GetInstruments(exchange)
 	if err != nil {
 		return err
 	}
 
+	log.Printf("📥 Fetched %d instruments from %s", len(instruments), brk.GetBrokerName())
+
 	synced := 0
 	for _, inst := range instruments {
-		if inst.Exchange != exchange {
-			continue
+		dbInst := Instrument{
+			InstrumentToken: uint32(inst.InstrumentToken),
+			ExchangeToken:   uint32(inst.ExchangeToken),
+			Tradingsymbol:   inst.TradingSymbol,
+			Name:            inst.Name,
+			Exchange:        inst.Exchange,
+			Segment:         inst.Segment,
+			InstrumentType:  inst.InstrumentType,
+			Expiry:          inst.Expiry,
+			Strike:          inst.Strike,
+			TickSize:        inst.TickSize,
+			LotSize:         inst.LotSize,
+			LastUpdated:     time.Now(),
 		}
-
-		dbInst := convertToDBInstrument(inst)
-		if err := db.UpsertInstrument(dbInst); err != nil {
-			log.Printf("❌ Error syncing %s: %v", inst.Tradingsymbol, err)
+		if err := store.UpsertInstrument(dbInst); err != nil {
+			log.Printf("❌ Error syncing %s: %v", inst.TradingSymbol, err)
 			continue
 		}
 		synced++
 	}
 
-	log.Printf("✅ Synced %d instruments for exchange %s", synced, exchange)
+	log.Printf("✅ Instrument sync completed: %d instruments synced", synced)
 	return nil
 }
 
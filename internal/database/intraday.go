@@ -3,9 +3,12 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"log"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/metrics"
 )
 
 // IntradayBar represents a single OHLCV bar
@@ -38,6 +41,9 @@ type TickData struct {
 	Price           float64   `json:"price" db:"price"`
 	Quantity        int64     `json:"quantity" db:"quantity"`
 	TradeType       string    `json:"trade_type" db:"trade_type"`
+	Bid             *float64  `json:"bid,omitempty" db:"bid"`
+	Ask             *float64  `json:"ask,omitempty" db:"ask"`
+	OI              *int64    `json:"oi,omitempty" db:"oi"`
 	Source          string    `json:"source" db:"source"`
 	CreatedAt       time.Time `json:"created_at" db:"created_at"`
 }
@@ -64,6 +70,7 @@ type OrderBookSnapshot struct {
 
 // InsertIntradayBar inserts a single intraday bar
 func (db *Database) InsertIntradayBar(bar *IntradayBar) error {
+	start := time.Now()
 	query := `
 		INSERT INTO md.intraday_bars (
 			exchange, symbol, instrument_token, bar_timestamp, timeframe,
@@ -100,15 +107,43 @@ func (db *Database) InsertIntradayBar(bar *IntradayBar) error {
 		bar.Source,
 	).Scan(&bar.BarID)
 
+	observeWrite("insert", "intraday_bars", start, err)
+	if err == nil {
+		metrics.RecordBarsWritten(bar.Timeframe, 1)
+	}
 	return err
 }
 
-// BulkInsertIntradayBars efficiently inserts multiple bars
+// BulkInsertIntradayBars efficiently inserts multiple bars. Large batches are
+// loaded with COPY; small batches and COPY failures use row-by-row upserts.
 func (db *Database) BulkInsertIntradayBars(bars []IntradayBar) error {
 	if len(bars) == 0 {
 		return nil
 	}
 
+	start := time.Now()
+	err := db.bulkInsertIntradayBars(bars)
+	observeWrite("bulk_insert", "intraday_bars", start, err)
+	if err == nil {
+		recordBarsWritten(bars)
+	}
+	return err
+}
+
+func (db *Database) bulkInsertIntradayBars(bars []IntradayBar) error {
+	if len(bars) >= copyThreshold {
+		err := db.copyIntradayBars(bars)
+		if err == nil {
+			return nil
+		}
+		log.Printf("⚠️  COPY insert of %d bars failed, falling back to row inserts: %v", len(bars), err)
+	}
+
+	return db.insertIntradayBarsRows(bars)
+}
+
+// insertIntradayBarsRows upserts bars one statement per row inside a transaction
+func (db *Database) insertIntradayBarsRows(bars []IntradayBar) error {
 	tx, err := db.conn.Begin()
 	if err != nil {
 		return err
@@ -161,22 +196,94 @@ func (db *Database) BulkInsertIntradayBars(bars []IntradayBar) error {
 	return tx.Commit()
 }
 
-// GetIntradayBars retrieves intraday bars for a symbol
-func (db *Database) GetIntradayBars(symbol, timeframe string, fromTime, toTime time.Time, limit int) ([]IntradayBar, error) {
-	query := `
+// GetIntradayBars retrieves intraday bars for a symbol on an exchange
+func (db *Database) GetIntradayBars(exchange, symbol, timeframe string, fromTime, toTime time.Time, limit int) ([]IntradayBar, error) {
+	return db.getIntradayBars(exchange, symbol, timeframe, fromTime, toTime, nil, limit)
+}
+
+// GetIntradayBarsAfter retrieves the page of a symbol's bars on an exchange
+// following the cursor, up to toTime
+func (db *Database) GetIntradayBarsAfter(exchange, symbol, timeframe string, after Cursor, toTime time.Time, limit int) ([]IntradayBar, error) {
+	return db.getIntradayBars(exchange, symbol, timeframe, after.Time, toTime, &after, limit)
+}
+
+// getIntradayBars reads bars from fromTime, or after the cursor when there
+// is one, ordered by timestamp and id
+func (db *Database) getIntradayBars(exchange, symbol, timeframe string, fromTime, toTime time.Time, after *Cursor, limit int) ([]IntradayBar, error) {
+	start := "bar_timestamp >= $4"
+	args := []interface{}{exchange, symbol, timeframe, fromTime, toTime, limit}
+	if after != nil {
+		start = "(bar_timestamp, bar_id) > ($4, $7)"
+		args = append(args, after.ID)
+	}
+
+	query := fmt.Sprintf(`
 		SELECT
 			bar_id, exchange, symbol, instrument_token, bar_timestamp, timeframe,
 			open, high, low, close, volume, trades_count, vwap, oi, source, created_at
-		FROM md.intraday_bars
-		WHERE symbol = $1
-		  AND timeframe = $2
-		  AND bar_timestamp >= $3
-		  AND bar_timestamp <= $4
+		FROM %s
+		WHERE exchange = $1
+		  AND symbol = $2
+		  AND timeframe = $3
+		  AND %s
+		  AND bar_timestamp <= $5
+		ORDER BY bar_timestamp ASC, bar_id ASC
+		LIMIT $6
+	`, db.barSource(timeframe), start)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bars := []IntradayBar{}
+	for rows.Next() {
+		var bar IntradayBar
+		err := rows.Scan(
+			&bar.BarID,
+			&bar.Exchange,
+			&bar.Symbol,
+			&bar.InstrumentToken,
+			&bar.BarTimestamp,
+			&bar.Timeframe,
+			&bar.Open,
+			&bar.High,
+			&bar.Low,
+			&bar.Close,
+			&bar.Volume,
+			&bar.TradesCount,
+			&bar.VWAP,
+			&bar.OI,
+			&bar.Source,
+			&bar.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		bars = append(bars, bar)
+	}
+
+	return bars, nil
+}
+
+// GetRecentIntradayBars retrieves the most recent bars for a symbol on an
+// exchange, oldest first
+func (db *Database) GetRecentIntradayBars(exchange, symbol, timeframe string, limit int) ([]IntradayBar, error) {
+	query := fmt.Sprintf(`
+		SELECT * FROM (
+			SELECT
+				bar_id, exchange, symbol, instrument_token, bar_timestamp, timeframe,
+				open, high, low, close, volume, trades_count, vwap, oi, source, created_at
+			FROM %s
+			WHERE exchange = $1 AND symbol = $2 AND timeframe = $3
+			ORDER BY bar_timestamp DESC
+			LIMIT $4
+		) recent
 		ORDER BY bar_timestamp ASC
-		LIMIT $5
-	`
+	`, db.barSource(timeframe))
 
-	rows, err := db.conn.Query(query, symbol, timeframe, fromTime, toTime, limit)
+	rows, err := db.conn.Query(query, exchange, symbol, timeframe, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -212,20 +319,20 @@ func (db *Database) GetIntradayBars(symbol, timeframe string, fromTime, toTime t
 	return bars, nil
 }
 
-// GetLatestIntradayBar retrieves the most recent bar for a symbol
-func (db *Database) GetLatestIntradayBar(symbol, timeframe string) (*IntradayBar, error) {
-	query := `
+// GetLatestIntradayBar retrieves the most recent bar for a symbol on an exchange
+func (db *Database) GetLatestIntradayBar(exchange, symbol, timeframe string) (*IntradayBar, error) {
+	query := fmt.Sprintf(`
 		SELECT
 			bar_id, exchange, symbol, instrument_token, bar_timestamp, timeframe,
 			open, high, low, close, volume, trades_count, vwap, oi, source, created_at
-		FROM md.intraday_bars
-		WHERE symbol = $1 AND timeframe = $2
+		FROM %s
+		WHERE exchange = $1 AND symbol = $2 AND timeframe = $3
 		ORDER BY bar_timestamp DESC
 		LIMIT 1
-	`
+	`, db.barSource(timeframe))
 
 	var bar IntradayBar
-	err := db.conn.QueryRow(query, symbol, timeframe).Scan(
+	err := db.conn.QueryRow(query, exchange, symbol, timeframe).Scan(
 		&bar.BarID,
 		&bar.Exchange,
 		&bar.Symbol,
@@ -255,10 +362,18 @@ func (db *Database) GetLatestIntradayBar(symbol, timeframe string) (*IntradayBar
 }
 
 // GetTodayBars retrieves all bars for current trading day
-func (db *Database) GetTodayBars(symbol, timeframe string) ([]IntradayBar, error) {
-	today := time.Now().Truncate(24 * time.Hour)
-	tomorrow := today.Add(24 * time.Hour)
-	return db.GetIntradayBars(symbol, timeframe, today, tomorrow, 1000)
+func (db *Database) GetTodayBars(exchange, symbol, timeframe string) ([]IntradayBar, error) {
+	today, tomorrow := ISTDay(time.Now())
+	return db.GetIntradayBars(exchange, symbol, timeframe, today, tomorrow.Add(-time.Nanosecond), 1000)
+}
+
+// ISTDay returns the IST midnight starting the day of t and the one ending
+// it. Trading days are IST days whatever the server's time zone, so a
+// session never straddles two UTC days' queries.
+func ISTDay(t time.Time) (time.Time, time.Time) {
+	t = t.In(istZone)
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, istZone)
+	return start, start.AddDate(0, 0, 1)
 }
 
 // ============================================================================
@@ -267,11 +382,12 @@ func (db *Database) GetTodayBars(symbol, timeframe string) ([]IntradayBar, error
 
 // InsertTickData inserts a single tick
 func (db *Database) InsertTickData(tick *TickData) error {
+	start := time.Now()
 	query := `
 		INSERT INTO md.tick_data (
 			exchange, symbol, instrument_token, tick_timestamp,
-			price, quantity, trade_type, source
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			price, quantity, trade_type, bid, ask, oi, source
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		RETURNING tick_id
 	`
 
@@ -284,18 +400,48 @@ func (db *Database) InsertTickData(tick *TickData) error {
 		tick.Price,
 		tick.Quantity,
 		tick.TradeType,
+		tick.Bid,
+		tick.Ask,
+		tick.OI,
 		tick.Source,
 	).Scan(&tick.TickID)
 
+	observeWrite("insert", "tick_data", start, err)
+	if err == nil {
+		metrics.RecordTicksWritten(1)
+	}
 	return err
 }
 
-// BulkInsertTickData efficiently inserts multiple ticks
+// BulkInsertTickData efficiently inserts multiple ticks, using COPY for large batches
 func (db *Database) BulkInsertTickData(ticks []TickData) error {
 	if len(ticks) == 0 {
 		return nil
 	}
 
+	start := time.Now()
+	err := db.bulkInsertTickData(ticks)
+	observeWrite("bulk_insert", "tick_data", start, err)
+	if err == nil {
+		metrics.RecordTicksWritten(len(ticks))
+	}
+	return err
+}
+
+func (db *Database) bulkInsertTickData(ticks []TickData) error {
+	if len(ticks) >= copyThreshold {
+		err := db.copyTickData(ticks)
+		if err == nil {
+			return nil
+		}
+		log.Printf("⚠️  COPY insert of %d ticks failed, falling back to row inserts: %v", len(ticks), err)
+	}
+
+	return db.insertTickDataRows(ticks)
+}
+
+// insertTickDataRows inserts ticks one statement per row inside a transaction
+func (db *Database) insertTickDataRows(ticks []TickData) error {
 	tx, err := db.conn.Begin()
 	if err != nil {
 		return err
@@ -305,8 +451,8 @@ func (db *Database) BulkInsertTickData(ticks []TickData) error {
 	stmt, err := tx.Prepare(`
 		INSERT INTO md.tick_data (
 			exchange, symbol, instrument_token, tick_timestamp,
-			price, quantity, trade_type, source
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			price, quantity, trade_type, bid, ask, oi, source
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`)
 	if err != nil {
 		return err
@@ -322,6 +468,9 @@ func (db *Database) BulkInsertTickData(ticks []TickData) error {
 			tick.Price,
 			tick.Quantity,
 			tick.TradeType,
+			tick.Bid,
+			tick.Ask,
+			tick.OI,
 			tick.Source,
 		)
 		if err != nil {
@@ -332,21 +481,42 @@ func (db *Database) BulkInsertTickData(ticks []TickData) error {
 	return tx.Commit()
 }
 
-// GetTickData retrieves tick data for a symbol
-func (db *Database) GetTickData(symbol string, fromTime, toTime time.Time, limit int) ([]TickData, error) {
+// GetTickData retrieves tick data for a symbol on an exchange
+func (db *Database) GetTickData(exchange, symbol string, fromTime, toTime time.Time, limit int) ([]TickData, error) {
+	return db.getTickData(exchange, symbol, fromTime, toTime, nil, limit)
+}
+
+// GetTickDataAfter retrieves the page of a symbol's ticks on an exchange
+// following the cursor, up to toTime
+func (db *Database) GetTickDataAfter(exchange, symbol string, after Cursor, toTime time.Time, limit int) ([]TickData, error) {
+	return db.getTickData(exchange, symbol, after.Time, toTime, &after, limit)
+}
+
+// getTickData reads ticks from fromTime, or after the cursor when there is
+// one, ordered by timestamp and id. Ticks of the same millisecond are
+// common, so the id breaks ties.
+func (db *Database) getTickData(exchange, symbol string, fromTime, toTime time.Time, after *Cursor, limit int) ([]TickData, error) {
+	start := "tick_timestamp >= $3"
+	args := []interface{}{exchange, symbol, fromTime, toTime, limit}
+	if after != nil {
+		start = "(tick_timestamp, tick_id) > ($3, $6)"
+		args = append(args, after.ID)
+	}
+
 	query := `
 		SELECT
 			tick_id, exchange, symbol, instrument_token, tick_timestamp,
-			price, quantity, trade_type, source, created_at
+			price, quantity, trade_type, bid, ask, oi, source, created_at
 		FROM md.tick_data
-		WHERE symbol = $1
-		  AND tick_timestamp >= $2
-		  AND tick_timestamp <= $3
-		ORDER BY tick_timestamp ASC
-		LIMIT $4
+		WHERE exchange = $1
+		  AND symbol = $2
+		  AND ` + start + `
+		  AND tick_timestamp <= $4
+		ORDER BY tick_timestamp ASC, tick_id ASC
+		LIMIT $5
 	`
 
-	rows, err := db.conn.Query(query, symbol, fromTime, toTime, limit)
+	rows, err := db.conn.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -364,6 +534,9 @@ func (db *Database) GetTickData(symbol string, fromTime, toTime time.Time, limit
 			&tick.Price,
 			&tick.Quantity,
 			&tick.TradeType,
+			&tick.Bid,
+			&tick.Ask,
+			&tick.OI,
 			&tick.Source,
 			&tick.CreatedAt,
 		)
@@ -407,20 +580,21 @@ func (db *Database) InsertOrderBookSnapshot(snapshot *OrderBookSnapshot) error {
 	return err
 }
 
-// GetLatestOrderBook retrieves the most recent order book snapshot
-func (db *Database) GetLatestOrderBook(symbol string) (*OrderBookSnapshot, error) {
+// GetLatestOrderBook retrieves the most recent order book snapshot of a
+// symbol on an exchange
+func (db *Database) GetLatestOrderBook(exchange, symbol string) (*OrderBookSnapshot, error) {
 	query := `
 		SELECT
 			snapshot_id, exchange, symbol, instrument_token, snapshot_timestamp,
 			bids, asks, bid_quantity, ask_quantity, spread, source, created_at
 		FROM md.order_book
-		WHERE symbol = $1
+		WHERE exchange = $1 AND symbol = $2
 		ORDER BY snapshot_timestamp DESC
 		LIMIT 1
 	`
 
 	var snapshot OrderBookSnapshot
-	err := db.conn.QueryRow(query, symbol).Scan(
+	err := db.conn.QueryRow(query, exchange, symbol).Scan(
 		&snapshot.SnapshotID,
 		&snapshot.Exchange,
 		&snapshot.Symbol,
@@ -449,62 +623,152 @@ func (db *Database) GetLatestOrderBook(symbol string) (*OrderBookSnapshot, error
 // AGGREGATION & ANALYTICS
 // ============================================================================
 
-// CalculateTodayVWAP calculates VWAP for current trading day
-func (db *Database) CalculateTodayVWAP(symbol, timeframe string) (float64, error) {
+// CalculateTodayVWAP calculates VWAP for current trading day from the
+// typical price of each bar, 0 without volume
+func (db *Database) CalculateTodayVWAP(exchange, symbol, timeframe string) (float64, error) {
 	query := `
-		SELECT md.calculate_today_vwap($1, $2)
+		SELECT COALESCE(
+			SUM((high + low + close) / 3 * volume) / NULLIF(SUM(volume), 0),
+			0
+		)
+		FROM md.intraday_bars
+		WHERE exchange = $1
+		  AND symbol = $2
+		  AND timeframe = $3
+		  AND bar_timestamp >= $4
 	`
 
+	today, _ := ISTDay(time.Now())
 	var vwap float64
-	err := db.conn.QueryRow(query, symbol, timeframe).Scan(&vwap)
+	err := db.conn.QueryRow(query, exchange, symbol, timeframe, today).Scan(&vwap)
 	return vwap, err
 }
 
-// GetIntradayStats retrieves statistics for current trading day
-func (db *Database) GetIntradayStats(symbol, timeframe string) (map[string]interface{}, error) {
+// IntradayStats summarizes a symbol's trading day so far
+type IntradayStats struct {
+	DayOpen      float64   `json:"day_open"`
+	DayHigh      float64   `json:"day_high"`
+	DayLow       float64   `json:"day_low"`
+	CurrentPrice float64   `json:"current_price"`
+	TotalVolume  int64     `json:"total_volume"`
+	BarsCount    int       `json:"bars_count"`
+	DayChange    float64   `json:"day_change"`
+	DayChangePct float64   `json:"day_change_pct"` // From the open
+	AsOf         time.Time `json:"as_of"`          // Start of the latest bar, or time of the latest tick
+}
+
+// NewIntradayStats returns the stats of a day, with the change from the
+// open (0% if the open is unknown)
+func NewIntradayStats(open, high, low, last float64, volume int64, bars int, asOf time.Time) IntradayStats {
+	stats := IntradayStats{
+		DayOpen:      open,
+		DayHigh:      high,
+		DayLow:       low,
+		CurrentPrice: last,
+		TotalVolume:  volume,
+		BarsCount:    bars,
+		DayChange:    last - open,
+		AsOf:         asOf,
+	}
+	if open != 0 {
+		stats.DayChangePct = (last - open) / open * 100
+	}
+	return stats
+}
+
+// GetIntradayStats retrieves statistics for the current IST trading day
+// from a symbol's bars, nil before its first bar of the day
+func (db *Database) GetIntradayStats(exchange, symbol, timeframe string) (*IntradayStats, error) {
 	query := `
 		SELECT
 			MIN(low) AS day_low,
 			MAX(high) AS day_high,
-			first(open, bar_timestamp) AS day_open,
-			last(close, bar_timestamp) AS current_price,
-			SUM(volume) AS total_volume,
-			COUNT(*) AS bars_count
+			(array_agg(open ORDER BY bar_timestamp))[1] AS day_open,
+			(array_agg(close ORDER BY bar_timestamp DESC))[1] AS current_price,
+			COALESCE(SUM(volume), 0) AS total_volume,
+			COUNT(*) AS bars_count,
+			MAX(bar_timestamp) AS as_of
 		FROM md.intraday_bars
-		WHERE symbol = $1
-		  AND timeframe = $2
-		  AND bar_timestamp >= date_trunc('day', NOW())
+		WHERE exchange = $1
+		  AND symbol = $2
+		  AND timeframe = $3
+		  AND bar_timestamp >= $4
+		  AND bar_timestamp < $5
 	`
 
-	var dayLow, dayHigh, dayOpen, currentPrice float64
+	// The aggregates are NULL without bars
+	var dayLow, dayHigh, dayOpen, currentPrice sql.NullFloat64
 	var totalVolume int64
 	var barsCount int
+	var asOf sql.NullTime
 
-	err := db.conn.QueryRow(query, symbol, timeframe).Scan(
+	today, tomorrow := ISTDay(time.Now())
+	err := db.conn.QueryRow(query, exchange, symbol, timeframe, today, tomorrow).Scan(
 		&dayLow,
 		&dayHigh,
 		&dayOpen,
 		&currentPrice,
 		&totalVolume,
 		&barsCount,
+		&asOf,
 	)
-
 	if err != nil {
 		return nil, err
 	}
+	if barsCount == 0 {
+		return nil, nil
+	}
+
+	stats := NewIntradayStats(dayOpen.Float64, dayHigh.Float64, dayLow.Float64, currentPrice.Float64, totalVolume, barsCount, asOf.Time)
+	return &stats, nil
+}
+
+// GetPriorSessionOHLC returns the high, low and close of the last trading
+// day before today (in IST) from a symbol's bars, or nil if there is none
+func (db *Database) GetPriorSessionOHLC(exchange, symbol, timeframe string) (*broker.Candle, error) {
+	query := `
+		WITH prior AS (
+			SELECT ((MAX(bar_timestamp) AT TIME ZONE 'Asia/Kolkata')::date::timestamp AT TIME ZONE 'Asia/Kolkata') AS session
+			FROM md.intraday_bars
+			WHERE exchange = $1
+			  AND symbol = $2
+			  AND timeframe = $3
+			  AND bar_timestamp < $4
+		)
+		SELECT
+			prior.session,
+			(array_agg(open ORDER BY bar_timestamp))[1],
+			MAX(high),
+			MIN(low),
+			(array_agg(close ORDER BY bar_timestamp DESC))[1],
+			SUM(volume)
+		FROM md.intraday_bars, prior
+		WHERE exchange = $1
+		  AND symbol = $2
+		  AND timeframe = $3
+		  AND bar_timestamp >= prior.session
+		  AND bar_timestamp < $4
+		GROUP BY prior.session
+	`
 
-	stats := map[string]interface{}{
-		"day_low":       dayLow,
-		"day_high":      dayHigh,
-		"day_open":      dayOpen,
-		"current_price": currentPrice,
-		"total_volume":  totalVolume,
-		"bars_count":    barsCount,
-		"day_change":    currentPrice - dayOpen,
-		"day_change_pct": ((currentPrice - dayOpen) / dayOpen) * 100,
+	today, _ := ISTDay(time.Now())
+	var session broker.Candle
+	err := db.conn.QueryRow(query, exchange, symbol, timeframe, today).Scan(
+		&session.Date,
+		&session.Open,
+		&session.High,
+		&session.Low,
+		&session.Close,
+		&session.Volume,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return stats, nil
+	return &session, nil
 }
 
 // ConvertBrokerCandlesToIntradayBars converts broker candles to intraday bars
@@ -535,34 +799,78 @@ func ConvertBrokerCandlesToIntradayBars(
 	return bars
 }
 
-// GetDataGaps identifies missing data gaps in intraday bars
-func (db *Database) GetDataGaps(symbol, timeframe string, startTime, endTime time.Time) ([]map[string]interface{}, error) {
-	query := `
-		WITH expected_bars AS (
-			SELECT generate_series(
-				date_trunc('minute', $3),
-				$4,
-				CASE $2
-					WHEN '1m' THEN INTERVAL '1 minute'
-					WHEN '5m' THEN INTERVAL '5 minutes'
-					WHEN '15m' THEN INTERVAL '15 minutes'
-					WHEN '1h' THEN INTERVAL '1 hour'
-					ELSE INTERVAL '1 day'
-				END
-			) AS expected_time
-		)
+// expectedBarsSQL generates the bar timestamps expected between $3 and $4
+// for timeframe $2: every bar start of the 09:15-15:30 IST session on
+// trading days, or IST midnight of each trading day for daily bars. Trading
+// days are weekdays other than the holidays in $5 (see sessionHolidays).
+// Queries using it pass the exchange as $6. This is the one place market hours are applied to gap and completeness
+// checks; expectedBarTimes mirrors it for SQLite stores.
+const expectedBarsSQL = `
+	WITH params AS (
+		SELECT CASE $2
+			WHEN '1m' THEN INTERVAL '1 minute'
+			WHEN '5m' THEN INTERVAL '5 minutes'
+			WHEN '15m' THEN INTERVAL '15 minutes'
+			WHEN '1h' THEN INTERVAL '1 hour'
+		END AS step
+	),
+	days AS (
+		SELECT d::date AS day
+		FROM generate_series(
+			($3::timestamptz AT TIME ZONE 'Asia/Kolkata')::date,
+			($4::timestamptz AT TIME ZONE 'Asia/Kolkata')::date,
+			INTERVAL '1 day'
+		) AS d
+		WHERE EXTRACT(ISODOW FROM d) < 6
+		  AND d::date <> ALL($5::date[])
+	),
+	expected_bars AS (
+		SELECT generate_series(
+			(days.day + TIME '09:15') AT TIME ZONE 'Asia/Kolkata',
+			(days.day + TIME '15:29:59') AT TIME ZONE 'Asia/Kolkata',
+			params.step
+		) AS expected_time
+		FROM days, params
+		WHERE params.step IS NOT NULL
+		UNION ALL
+		SELECT days.day::timestamp AT TIME ZONE 'Asia/Kolkata'
+		FROM days, params
+		WHERE params.step IS NULL
+	)
+`
+
+// sessionHolidays returns the trading holidays between start and end as the
+// $5 parameter of expectedBarsSQL
+func sessionHolidays(start, end time.Time) interface{} {
+	return pq.Array(broker.IndianMarketHolidays(start, end))
+}
+
+// DayCompleteness is the share of a trading day's expected bars present
+type DayCompleteness struct {
+	Date         string  `json:"date"` // IST trading day, YYYY-MM-DD
+	ExpectedBars int     `json:"expected_bars"`
+	PresentBars  int     `json:"present_bars"`
+	Completeness float64 `json:"completeness"` // Percent
+}
+
+// GetDataGaps identifies missing bars in market hours (09:15-15:30 IST,
+// weekdays)
+func (db *Database) GetDataGaps(exchange, symbol, timeframe string, startTime, endTime time.Time) ([]map[string]interface{}, error) {
+	query := expectedBarsSQL + `
 		SELECT expected_time
 		FROM expected_bars
-		WHERE NOT EXISTS (
+		WHERE expected_time BETWEEN $3 AND $4
+		  AND NOT EXISTS (
 			SELECT 1 FROM md.intraday_bars
-			WHERE symbol = $1
+			WHERE exchange = $6
+			  AND symbol = $1
 			  AND timeframe = $2
 			  AND bar_timestamp = expected_bars.expected_time
-		)
+		  )
 		ORDER BY expected_time
 	`
 
-	rows, err := db.conn.Query(query, symbol, timeframe, startTime, endTime)
+	rows, err := db.conn.Query(query, symbol, timeframe, startTime, endTime, sessionHolidays(startTime, endTime), exchange)
 	if err != nil {
 		return nil, err
 	}
@@ -576,40 +884,77 @@ func (db *Database) GetDataGaps(symbol, timeframe string, startTime, endTime tim
 		}
 		gaps = append(gaps, map[string]interface{}{
 			"missing_timestamp": gapTime,
+			"exchange":          exchange,
 			"symbol":            symbol,
 			"timeframe":         timeframe,
 		})
 	}
 
-	return gaps, nil
+	return gaps, rows.Err()
 }
 
-// GetDataCompleteness calculates data completeness percentage
-func (db *Database) GetDataCompleteness(symbol, timeframe string, startTime, endTime time.Time) (float64, error) {
-	gaps, err := db.GetDataGaps(symbol, timeframe, startTime, endTime)
+// GetDataCompletenessByDay returns, per trading day, how many of the bars
+// expected in market hours are present
+func (db *Database) GetDataCompletenessByDay(exchange, symbol, timeframe string, startTime, endTime time.Time) ([]DayCompleteness, error) {
+	query := expectedBarsSQL + `
+		SELECT
+			to_char((expected_time AT TIME ZONE 'Asia/Kolkata')::date, 'YYYY-MM-DD') AS day,
+			COUNT(*) AS expected,
+			COUNT(*) FILTER (WHERE EXISTS (
+				SELECT 1 FROM md.intraday_bars
+				WHERE exchange = $6
+				  AND symbol = $1
+				  AND timeframe = $2
+				  AND bar_timestamp = expected_bars.expected_time
+			)) AS present
+		FROM expected_bars
+		WHERE expected_time BETWEEN $3 AND $4
+		GROUP BY day
+		ORDER BY day
+	`
+
+	rows, err := db.conn.Query(query, symbol, timeframe, startTime, endTime, sessionHolidays(startTime, endTime), exchange)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get gaps: %w", err)
+		return nil, fmt.Errorf("failed to get completeness by day: %w", err)
 	}
+	defer rows.Close()
 
-	// Calculate expected number of bars
-	var expectedBars int64
-	switch timeframe {
-	case "1m":
-		expectedBars = int64(endTime.Sub(startTime).Minutes())
-	case "5m":
-		expectedBars = int64(endTime.Sub(startTime).Minutes() / 5)
-	case "15m":
-		expectedBars = int64(endTime.Sub(startTime).Minutes() / 15)
-	case "1h":
-		expectedBars = int64(endTime.Sub(startTime).Hours())
-	default:
-		expectedBars = int64(endTime.Sub(startTime).Hours() / 24)
+	days := []DayCompleteness{}
+	for rows.Next() {
+		var day DayCompleteness
+		if err := rows.Scan(&day.Date, &day.ExpectedBars, &day.PresentBars); err != nil {
+			return nil, fmt.Errorf("failed to scan completeness: %w", err)
+		}
+		if day.ExpectedBars > 0 {
+			day.Completeness = float64(day.PresentBars) / float64(day.ExpectedBars) * 100
+		}
+		days = append(days, day)
 	}
 
-	if expectedBars == 0 {
-		return 0, nil
+	return days, rows.Err()
+}
+
+// GetDataCompleteness calculates the percentage of bars expected in market
+// hours that are present
+func (db *Database) GetDataCompleteness(exchange, symbol, timeframe string, startTime, endTime time.Time) (float64, error) {
+	days, err := db.GetDataCompletenessByDay(exchange, symbol, timeframe, startTime, endTime)
+	if err != nil {
+		return 0, err
 	}
 
-	completeness := float64(expectedBars-int64(len(gaps))) / float64(expectedBars) * 100
-	return completeness, nil
+	return TotalCompleteness(days), nil
+}
+
+// TotalCompleteness is the completeness over all days, 0 without expected bars
+func TotalCompleteness(days []DayCompleteness) float64 {
+	var expected, present int
+	for _, day := range days {
+		expected += day.ExpectedBars
+		present += day.PresentBars
+	}
+
+	if expected == 0 {
+		return 0
+	}
+	return float64(present) / float64(expected) * 100
 }
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestISTDay(t *testing.T) {
+	ist := time.FixedZone("IST", 5*3600+1800)
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{name: "session", t: time.Date(2024, 1, 30, 4, 0, 0, 0, time.UTC), want: time.Date(2024, 1, 30, 0, 0, 0, 0, ist)},
+		{name: "after UTC midnight before IST", t: time.Date(2024, 1, 29, 20, 0, 0, 0, time.UTC), want: time.Date(2024, 1, 30, 0, 0, 0, 0, ist)},
+		{name: "before UTC midnight", t: time.Date(2024, 1, 29, 18, 29, 59, 0, time.UTC), want: time.Date(2024, 1, 29, 0, 0, 0, 0, ist)},
+		{name: "IST midnight", t: time.Date(2024, 1, 30, 0, 0, 0, 0, ist), want: time.Date(2024, 1, 30, 0, 0, 0, 0, ist)},
+		{name: "month end", t: time.Date(2024, 1, 31, 23, 0, 0, 0, ist), want: time.Date(2024, 1, 31, 0, 0, 0, 0, ist)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := ISTDay(tt.t)
+			if !start.Equal(tt.want) {
+				t.Errorf("start = %v, want %v", start, tt.want)
+			}
+			if want := tt.want.Add(24 * time.Hour); !end.Equal(want) {
+				t.Errorf("end = %v, want %v", end, want)
+			}
+		})
+	}
+}
+
+func TestNewIntradayStats(t *testing.T) {
+	tests := []struct {
+		name       string
+		open, last float64
+		change     float64
+		changePct  float64
+	}{
+		{name: "up", open: 100, last: 102.5, change: 2.5, changePct: 2.5},
+		{name: "down", open: 200, last: 190, change: -10, changePct: -5},
+		{name: "unknown open", open: 0, last: 50, change: 50, changePct: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := NewIntradayStats(tt.open, tt.last, tt.last, tt.last, 10, 1, time.Time{})
+			if stats.DayChange != tt.change || stats.DayChangePct != tt.changePct {
+				t.Errorf("change = %v (%v%%), want %v (%v%%)", stats.DayChange, stats.DayChangePct, tt.change, tt.changePct)
+			}
+		})
+	}
+}
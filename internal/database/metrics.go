@@ -0,0 +1,38 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/trading-chitti/market-bridge/internal/metrics"
+)
+
+// observeWrite records a write's latency, and its failure by Postgres error
+// class
+func observeWrite(operation, table string, start time.Time, err error) {
+	metrics.RecordDatabaseQuery(operation, table, time.Since(start).Seconds())
+	if err != nil {
+		metrics.RecordDatabaseError(operation, errorClass(err))
+	}
+}
+
+// errorClass names the class of a Postgres error, e.g. integrity_constraint_violation
+func errorClass(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code.Class().Name() != "" {
+		return pqErr.Code.Class().Name()
+	}
+	return "other"
+}
+
+// recordBarsWritten counts written bars by timeframe
+func recordBarsWritten(bars []IntradayBar) {
+	counts := make(map[string]int)
+	for _, bar := range bars {
+		counts[bar.Timeframe]++
+	}
+	for timeframe, count := range counts {
+		metrics.RecordBarsWritten(timeframe, count)
+	}
+}
@@ -0,0 +1,30 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"unique violation", &pq.Error{Code: "23505"}, "integrity_constraint_violation"},
+		{"wrapped", fmt.Errorf("failed to insert: %w", &pq.Error{Code: "08006"}), "connection_exception"},
+		{"unknown class", &pq.Error{Code: "ZZ000"}, "other"},
+		{"not postgres", errors.New("boom"), "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorClass(tt.err); got != tt.want {
+				t.Errorf("errorClass() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
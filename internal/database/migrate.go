@@ -0,0 +1,119 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// Versioned schema migrations, applied in order. Every migration is
+// idempotent so databases set up by hand with psql can adopt the runner
+// from version 0.
+//
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// migrationLogger prints the runner's progress through the standard logger
+type migrationLogger struct{}
+
+func (migrationLogger) Printf(format string, v ...interface{}) {
+	log.Printf("📦 "+format, v...)
+}
+
+func (migrationLogger) Verbose() bool {
+	return false
+}
+
+// newMigrator opens its own connection, since closing the runner closes the
+// database it was given
+func newMigrator(dsn string) (*migrate.Migrate, error) {
+	source, err := iofs.New(migrations, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	conn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	driver, err := postgres.WithInstance(conn, &postgres.Config{})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", source, "postgres", driver)
+	if err != nil {
+		driver.Close()
+		return nil, fmt.Errorf("failed to create migrator: %w", err)
+	}
+	m.Log = migrationLogger{}
+	return m, nil
+}
+
+// Migrate applies the migrations that haven't run yet and returns the
+// resulting version
+func Migrate(dsn string) (uint, error) {
+	m, err := newMigrator(dsn)
+	if err != nil {
+		return 0, err
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return 0, fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	return migrationVersion(m)
+}
+
+// MigrationVersion returns the last applied migration, and whether it
+// failed part way and needs fixing by hand before the next run
+func MigrationVersion(dsn string) (uint, bool, error) {
+	m, err := newMigrator(dsn)
+	if err != nil {
+		return 0, false, err
+	}
+	defer m.Close()
+
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read migration version: %w", err)
+	}
+	return version, dirty, nil
+}
+
+// ForceMigrationVersion records version as applied without running it,
+// clearing the dirty flag a failed migration leaves
+func ForceMigrationVersion(dsn string, version int) error {
+	m, err := newMigrator(dsn)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Force(version); err != nil {
+		return fmt.Errorf("failed to force migration version: %w", err)
+	}
+	return nil
+}
+
+func migrationVersion(m *migrate.Migrate) (uint, error) {
+	version, _, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migration version: %w", err)
+	}
+	return version, nil
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestMigrationFiles(t *testing.T) {
+	entries, err := fs.ReadDir(migrations, "migrations")
+	if err != nil {
+		t.Fatalf("failed to read migrations: %v", err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+
+	// ReadDir sorts by name, so versions must count up from 1 with no gaps
+	for i, entry := range entries {
+		name := entry.Name()
+		version, rest, ok := strings.Cut(name, "_")
+		if !ok || !strings.HasSuffix(rest, ".up.sql") {
+			t.Errorf("%s: want NNNN_name.up.sql", name)
+			continue
+		}
+		if n, err := strconv.Atoi(version); err != nil || n != i+1 || len(version) != 4 {
+			t.Errorf("%s: version %s, want %04d", name, version, i+1)
+		}
+	}
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	dsn := os.Getenv("TRADING_CHITTI_TEST_PG_DSN")
+	if dsn == "" {
+		t.Skip("TRADING_CHITTI_TEST_PG_DSN not set")
+	}
+
+	first, err := Migrate(dsn)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	second, err := Migrate(dsn)
+	if err != nil {
+		t.Fatalf("second Migrate: %v", err)
+	}
+	if first != second {
+		t.Errorf("version moved from %d to %d with nothing pending", first, second)
+	}
+
+	version, dirty, err := MigrationVersion(dsn)
+	if err != nil {
+		t.Fatalf("MigrationVersion: %v", err)
+	}
+	if version != first || dirty {
+		t.Errorf("MigrationVersion = %d, %v; want %d, false", version, dirty, first)
+	}
+}
@@ -0,0 +1,22 @@
+-- ============================================================================
+-- Trading Chitti - Base schemas
+-- ============================================================================
+--
+-- Schemas the later migrations create tables in, and the md.symbols master
+-- that bars and ticks reference. Databases that already have md.symbols
+-- keep theirs.
+--
+-- ============================================================================
+
+CREATE SCHEMA IF NOT EXISTS md;
+CREATE SCHEMA IF NOT EXISTS auth;
+CREATE SCHEMA IF NOT EXISTS brokers;
+CREATE SCHEMA IF NOT EXISTS trades;
+
+CREATE TABLE IF NOT EXISTS md.symbols (
+    exchange TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    name TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (exchange, symbol)
+);
@@ -24,9 +24,11 @@ CREATE TABLE IF NOT EXISTS brokers.config (
     token_expires_at TIMESTAMPTZ,
     last_token_refresh TIMESTAMPTZ,
 
-    -- Trading Configuration
+    -- Risk Limits (0 disables a limit)
     max_positions INTEGER DEFAULT 5,
-    max_risk_per_trade NUMERIC(5,2) DEFAULT 2.0,
+    max_risk_per_trade NUMERIC(5,2) DEFAULT 2.0, -- % of capital at risk per trade
+    max_daily_loss NUMERIC(15,2) DEFAULT 0, -- Loss for the day after which new orders are rejected
+    max_symbol_exposure NUMERIC(5,2) DEFAULT 0, -- % of capital in a single symbol
 
     -- Timestamps
     created_at TIMESTAMPTZ DEFAULT NOW(),
@@ -35,8 +37,19 @@ CREATE TABLE IF NOT EXISTS brokers.config (
     UNIQUE(broker_name, user_id)
 );
 
-CREATE INDEX idx_brokers_enabled ON brokers.config(enabled);
-CREATE INDEX idx_brokers_token_expiry ON brokers.config(token_expires_at) WHERE enabled = TRUE;
+-- Upgrade tables created before the daily loss and exposure limits
+ALTER TABLE brokers.config
+    ADD COLUMN IF NOT EXISTS max_daily_loss NUMERIC(15,2) DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS max_symbol_exposure NUMERIC(5,2) DEFAULT 0;
+
+-- Token refresh outcome: active, expired (needs the user's login) or
+-- refresh_failed, with the last refresh error
+ALTER TABLE brokers.config
+    ADD COLUMN IF NOT EXISTS token_status TEXT NOT NULL DEFAULT 'active',
+    ADD COLUMN IF NOT EXISTS token_error TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_brokers_enabled ON brokers.config(enabled);
+CREATE INDEX IF NOT EXISTS idx_brokers_token_expiry ON brokers.config(token_expires_at) WHERE enabled = TRUE;
 
 -- ============================================================================
 -- ANALYSIS RESULTS (52-day analyzer output)
@@ -71,53 +84,70 @@ CREATE TABLE IF NOT EXISTS trades.analysis (
     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
 
-CREATE INDEX idx_analysis_symbol ON trades.analysis(symbol, analysis_date DESC);
-CREATE INDEX idx_analysis_date ON trades.analysis(analysis_date DESC);
+CREATE INDEX IF NOT EXISTS idx_analysis_symbol ON trades.analysis(symbol, analysis_date DESC);
+CREATE INDEX IF NOT EXISTS idx_analysis_date ON trades.analysis(analysis_date DESC);
 
 -- ============================================================================
--- TRADE EXECUTIONS
+-- TRADE EXECUTIONS (order audit trail: one row per order event)
 -- ============================================================================
 CREATE TABLE IF NOT EXISTS trades.executions (
     execution_id SERIAL PRIMARY KEY,
     broker_id INTEGER REFERENCES brokers.config(id),
-    
+    broker_name TEXT,
+    user_id TEXT,
+
+    -- Event
+    event TEXT NOT NULL, -- PLACE, MODIFY, CANCEL, UPDATE
+    order_id TEXT NOT NULL, -- Empty when the broker rejected the order outright
+    order_status TEXT, -- Broker status: OPEN, TRIGGER PENDING, COMPLETE, CANCELLED, REJECTED
+
     symbol TEXT NOT NULL,
     exchange TEXT NOT NULL,
-    order_id TEXT NOT NULL,
-    
+
     -- Order Details
     action TEXT NOT NULL CHECK (action IN ('BUY', 'SELL')),
     quantity INTEGER NOT NULL,
-    entry_price NUMERIC(12,2) NOT NULL,
+    filled_quantity INTEGER NOT NULL DEFAULT 0,
+    entry_price NUMERIC(12,2), -- Order price (0 for market orders)
+    trigger_price NUMERIC(12,2),
+    average_price NUMERIC(12,2), -- Fill price
     order_type TEXT NOT NULL,
     product TEXT NOT NULL, -- MIS, CNC, NRML
-    
+
     -- Risk Management
     stop_loss NUMERIC(12,2),
     take_profit NUMERIC(12,2),
-    
+
     -- Signal Info
     confidence NUMERIC(3,2),
-    strategy TEXT NOT NULL,
-    
+    strategy TEXT, -- Order tag
+
     -- Execution
     executed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    
-    -- Exit Tracking
-    exit_price NUMERIC(12,2),
-    exit_date TIMESTAMPTZ,
-    pnl NUMERIC(15,2),
-    pnl_pct NUMERIC(8,4),
-    status TEXT DEFAULT 'OPEN' CHECK (status IN ('OPEN', 'CLOSED', 'CANCELLED')),
-    
+
     -- Metadata
-    dry_run BOOLEAN DEFAULT TRUE,
+    dry_run BOOLEAN DEFAULT FALSE,
+    error TEXT,
     notes TEXT
 );
 
-CREATE INDEX idx_executions_symbol ON trades.executions(symbol, executed_at DESC);
-CREATE INDEX idx_executions_status ON trades.executions(status, executed_at DESC);
-CREATE INDEX idx_executions_broker ON trades.executions(broker_id, executed_at DESC);
+-- Upgrade tables created before the audit trail
+ALTER TABLE trades.executions
+    ADD COLUMN IF NOT EXISTS broker_name TEXT,
+    ADD COLUMN IF NOT EXISTS user_id TEXT,
+    ADD COLUMN IF NOT EXISTS event TEXT NOT NULL DEFAULT 'PLACE',
+    ADD COLUMN IF NOT EXISTS order_status TEXT,
+    ADD COLUMN IF NOT EXISTS filled_quantity INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS trigger_price NUMERIC(12,2),
+    ADD COLUMN IF NOT EXISTS average_price NUMERIC(12,2),
+    ADD COLUMN IF NOT EXISTS error TEXT,
+    ALTER COLUMN entry_price DROP NOT NULL,
+    ALTER COLUMN strategy DROP NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_executions_symbol ON trades.executions(symbol, executed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_executions_order ON trades.executions(order_id, executed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_executions_strategy ON trades.executions(strategy, executed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_executions_broker ON trades.executions(broker_id, executed_at DESC);
 
 -- ============================================================================
 -- TRADING SIGNALS (all generated signals)
@@ -144,9 +174,9 @@ CREATE TABLE IF NOT EXISTS trades.signals (
     expires_at TIMESTAMPTZ
 );
 
-CREATE INDEX idx_signals_symbol ON trades.signals(symbol, generated_at DESC);
-CREATE INDEX idx_signals_confidence ON trades.signals(confidence DESC);
-CREATE INDEX idx_signals_executed ON trades.signals(executed);
+CREATE INDEX IF NOT EXISTS idx_signals_symbol ON trades.signals(symbol, generated_at DESC);
+CREATE INDEX IF NOT EXISTS idx_signals_confidence ON trades.signals(confidence DESC);
+CREATE INDEX IF NOT EXISTS idx_signals_executed ON trades.signals(executed);
 
 -- ============================================================================
 -- PERFORMANCE TRACKING
@@ -188,7 +218,7 @@ CREATE TABLE IF NOT EXISTS trades.market_data_cache (
     PRIMARY KEY (symbol, exchange)
 );
 
-CREATE INDEX idx_market_cache_timestamp ON trades.market_data_cache(timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_market_cache_timestamp ON trades.market_data_cache(timestamp DESC);
 
 -- ============================================================================
 -- WEBSOCKET SUBSCRIPTIONS
@@ -233,10 +263,10 @@ CREATE TABLE IF NOT EXISTS trades.instruments (
     UNIQUE(exchange, tradingsymbol)
 );
 
-CREATE INDEX idx_instruments_tradingsymbol ON trades.instruments(tradingsymbol);
-CREATE INDEX idx_instruments_exchange ON trades.instruments(exchange, tradingsymbol);
-CREATE INDEX idx_instruments_segment ON trades.instruments(segment);
-CREATE INDEX idx_instruments_expiry ON trades.instruments(expiry) WHERE expiry IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_instruments_tradingsymbol ON trades.instruments(tradingsymbol);
+CREATE INDEX IF NOT EXISTS idx_instruments_exchange ON trades.instruments(exchange, tradingsymbol);
+CREATE INDEX IF NOT EXISTS idx_instruments_segment ON trades.instruments(segment);
+CREATE INDEX IF NOT EXISTS idx_instruments_expiry ON trades.instruments(expiry) WHERE expiry IS NOT NULL;
 
 -- ============================================================================
 -- HISTORICAL DATA CACHE
@@ -262,8 +292,8 @@ CREATE TABLE IF NOT EXISTS trades.historical_cache (
     PRIMARY KEY (instrument_token, interval, candle_timestamp)
 );
 
-CREATE INDEX idx_historical_token_interval ON trades.historical_cache(instrument_token, interval, candle_timestamp DESC);
-CREATE INDEX idx_historical_timestamp ON trades.historical_cache(candle_timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_historical_token_interval ON trades.historical_cache(instrument_token, interval, candle_timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_historical_timestamp ON trades.historical_cache(candle_timestamp DESC);
 
 -- ============================================================================
 -- GRANTS
@@ -14,8 +14,8 @@ CREATE TABLE IF NOT EXISTS auth.users (
     email_verified BOOLEAN NOT NULL DEFAULT FALSE
 );
 
-CREATE INDEX idx_users_email ON auth.users(email);
-CREATE INDEX idx_users_active ON auth.users(is_active);
+CREATE INDEX IF NOT EXISTS idx_users_email ON auth.users(email);
+CREATE INDEX IF NOT EXISTS idx_users_active ON auth.users(is_active);
 
 -- Sessions table for JWT token management
 CREATE TABLE IF NOT EXISTS auth.sessions (
@@ -31,9 +31,9 @@ CREATE TABLE IF NOT EXISTS auth.sessions (
     is_revoked BOOLEAN NOT NULL DEFAULT FALSE
 );
 
-CREATE INDEX idx_sessions_user ON auth.sessions(user_id);
-CREATE INDEX idx_sessions_token ON auth.sessions(token_hash);
-CREATE INDEX idx_sessions_expires ON auth.sessions(expires_at);
+CREATE INDEX IF NOT EXISTS idx_sessions_user ON auth.sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_token ON auth.sessions(token_hash);
+CREATE INDEX IF NOT EXISTS idx_sessions_expires ON auth.sessions(expires_at);
 
 -- Update brokers.config to support multi-user accounts
 ALTER TABLE brokers.config
@@ -61,8 +61,8 @@ CREATE TABLE IF NOT EXISTS auth.api_keys (
     is_active BOOLEAN NOT NULL DEFAULT TRUE
 );
 
-CREATE INDEX idx_api_keys_user ON auth.api_keys(user_id);
-CREATE INDEX idx_api_keys_hash ON auth.api_keys(key_hash);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user ON auth.api_keys(user_id);
+CREATE INDEX IF NOT EXISTS idx_api_keys_hash ON auth.api_keys(key_hash);
 
 -- Audit log for security tracking
 CREATE TABLE IF NOT EXISTS auth.audit_log (
@@ -77,8 +77,8 @@ CREATE TABLE IF NOT EXISTS auth.audit_log (
     created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
 
-CREATE INDEX idx_audit_log_user ON auth.audit_log(user_id, created_at DESC);
-CREATE INDEX idx_audit_log_action ON auth.audit_log(action, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_user ON auth.audit_log(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_action ON auth.audit_log(action, created_at DESC);
 
 -- Function to update updated_at timestamp
 CREATE OR REPLACE FUNCTION update_updated_at_column()
@@ -90,6 +90,7 @@ END;
 $$ language 'plpgsql';
 
 -- Trigger to auto-update updated_at
+DROP TRIGGER IF EXISTS update_users_updated_at ON auth.users;
 CREATE TRIGGER update_users_updated_at
     BEFORE UPDATE ON auth.users
     FOR EACH ROW
@@ -99,3 +100,41 @@ COMMENT ON TABLE auth.users IS 'User accounts for multi-tenant Market Bridge';
 COMMENT ON TABLE auth.sessions IS 'Active JWT sessions with refresh tokens';
 COMMENT ON TABLE auth.api_keys IS 'API keys for programmatic access';
 COMMENT ON TABLE auth.audit_log IS 'Security audit trail';
+
+-- One-time tokens sent by email (address verification, password reset).
+-- Only hashes are stored; issuing a token replaces the user's unused ones.
+CREATE TABLE IF NOT EXISTS auth.user_tokens (
+    token_hash TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES auth.users(user_id) ON DELETE CASCADE,
+    purpose TEXT NOT NULL,                    -- verify_email, reset_password
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_tokens_user ON auth.user_tokens(user_id, purpose);
+
+COMMENT ON TABLE auth.user_tokens IS 'One-time email verification and password reset tokens';
+
+-- TOTP two-factor authentication. totp_secret is set at enrollment and
+-- only enforced once a code has confirmed it (totp_enabled). totp_last_step
+-- is the time step of the last accepted code, so no code works twice.
+ALTER TABLE auth.users
+    ADD COLUMN IF NOT EXISTS totp_secret TEXT,
+    ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS totp_for_orders BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN auth.users.totp_for_orders IS 'Require a TOTP code with every order placed';
+
+-- Consecutive invalid TOTP codes, and until when checks are locked after
+-- too many of them
+ALTER TABLE auth.users
+    ADD COLUMN IF NOT EXISTS totp_failed_attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS totp_locked_until TIMESTAMPTZ;
+
+-- Administrators may change service-wide settings (risk limits, square-off,
+-- retention) and act on the operator's broker. Granted with SQL only:
+--   UPDATE auth.users SET is_admin = TRUE WHERE email = '...';
+ALTER TABLE auth.users
+    ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT FALSE;
@@ -1,23 +1,19 @@
 -- ============================================================================
--- Trading Chitti - Intraday Market Data Schema (TimescaleDB)
+-- Trading Chitti - Intraday Market Data Schema
 -- ============================================================================
 --
--- This schema creates tables for real-time and intraday market data storage
--- using TimescaleDB hypertables for time-series optimization.
+-- This schema creates tables for real-time and intraday market data storage.
+-- It runs on plain PostgreSQL; apply schema_timescale.sql afterwards for
+-- hypertables, compression, retention and continuous aggregates.
 --
 -- Features:
 -- - Intraday OHLCV bars (1m, 5m, 15m, 1h timeframes)
 -- - Tick-level data (raw trades)
 -- - Order book snapshots
--- - Automatic compression (90% space reduction)
--- - Data retention policies
 -- - Optimized indexes for fast queries
 --
 -- ============================================================================
 
--- Enable TimescaleDB extension (if not already enabled)
-CREATE EXTENSION IF NOT EXISTS timescaledb;
-
 -- ==================================================================================================
 -- TABLE: md.intraday_bars - Stores aggregated OHLCV bars at multiple timeframes
 -- ================================================================================================
@@ -43,15 +39,6 @@ CREATE TABLE IF NOT EXISTS md.intraday_bars (
     FOREIGN KEY (exchange, symbol) REFERENCES md.symbols(exchange, symbol) ON DELETE CASCADE
 );
 
--- Convert to TimescaleDB hypertable
-SELECT create_hypertable('md.intraday_bars', 'bar_timestamp', chunk_time_interval => INTERVAL '1 day', if_not_exists => TRUE);
-
--- Add compression policy
-SELECT add_compression_policy('md.intraday_bars', compress_after => INTERVAL '7 days', if_not_exists => TRUE);
-
--- Retention policy
-SELECT add_retention_policy('md.intraday_bars', drop_after => INTERVAL '365 days', if_not_exists => TRUE);
-
 -- Indexes
 CREATE INDEX IF NOT EXISTS idx_intraday_bars_symbol_time ON md.intraday_bars (symbol, bar_timestamp DESC) WHERE exchange = 'NSE';
 CREATE INDEX IF NOT EXISTS idx_intraday_bars_timeframe ON md.intraday_bars (timeframe, bar_timestamp DESC);
@@ -75,11 +62,6 @@ CREATE TABLE IF NOT EXISTS md.tick_data (
     FOREIGN KEY (exchange, symbol) REFERENCES md.symbols(exchange, symbol) ON DELETE CASCADE
 );
 
--- Convert to hypertable
-SELECT create_hypertable('md.tick_data', 'tick_timestamp', chunk_time_interval => INTERVAL '1 day', if_not_exists => TRUE);
-SELECT add_compression_policy('md.tick_data', compress_after => INTERVAL '3 days', if_not_exists => TRUE);
-SELECT add_retention_policy('md.tick_data', drop_after => INTERVAL '30 days', if_not_exists => TRUE);
-
 -- Indexes
 CREATE INDEX IF NOT EXISTS idx_tick_data_symbol_time ON md.tick_data (symbol, tick_timestamp DESC) WHERE exchange = 'NSE';
 
@@ -99,14 +81,32 @@ CREATE TABLE IF NOT EXISTS md.order_book (
     FOREIGN KEY (exchange, symbol) REFERENCES md.symbols(exchange, symbol) ON DELETE CASCADE
 );
 
--- Convert to hypertable
-SELECT create_hypertable('md.order_book', 'snapshot_timestamp', chunk_time_interval => INTERVAL '1 day', if_not_exists => TRUE);
-SELECT add_compression_policy('md.order_book', compress_after => INTERVAL '7 days', if_not_exists => TRUE);
-SELECT add_retention_policy('md.order_book', drop_after => INTERVAL '90 days', if_not_exists => TRUE);
-
 -- Indexes
 CREATE INDEX IF NOT EXISTS idx_order_book_symbol_time ON md.order_book (symbol, snapshot_timestamp DESC);
 
+-- ==============================================================================================
+-- TABLE: md.backfill_runs - Reports of historical backfill runs
+-- ==============================================================================================
+
+CREATE TABLE IF NOT EXISTS md.backfill_runs (
+    run_id BIGSERIAL PRIMARY KEY,
+    trigger TEXT NOT NULL DEFAULT 'scheduled',  -- scheduled, api
+    timeframe TEXT NOT NULL,
+    from_date TIMESTAMPTZ NOT NULL,
+    to_date TIMESTAMPTZ NOT NULL,
+    status TEXT NOT NULL DEFAULT 'running',     -- running, completed, failed, cancelled
+    total_symbols INTEGER NOT NULL DEFAULT 0,
+    successful INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    total_bars INTEGER NOT NULL DEFAULT 0,
+    results JSONB NOT NULL DEFAULT '[]',        -- Per-symbol results
+    error TEXT,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_backfill_runs_started ON md.backfill_runs (started_at DESC);
+
 -- ==============================================================================================
 -- VIEWS
 -- ==============================================================================================
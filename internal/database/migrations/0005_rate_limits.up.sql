@@ -0,0 +1,18 @@
+-- ============================================================================
+-- Trading Chitti - API Rate Limits
+-- ============================================================================
+--
+-- Token buckets shared by all instances when RATE_LIMIT_STORE=postgres.
+-- One row per client and route class; rows idle for an hour are deleted.
+-- Unlogged since losing the buckets on a crash only resets the limits.
+--
+-- ============================================================================
+
+CREATE UNLOGGED TABLE IF NOT EXISTS auth.rate_limit_buckets (
+    bucket_key TEXT PRIMARY KEY,              -- <route class>:<client>
+    tokens DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL,                 -- Whether the last request got a token
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_updated ON auth.rate_limit_buckets (updated_at);
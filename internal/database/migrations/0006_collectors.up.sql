@@ -0,0 +1,19 @@
+-- Collector Schema
+-- Collector definitions restored when the server starts
+
+CREATE SCHEMA IF NOT EXISTS collectors;
+
+-- ==============================================================================================
+-- TABLE: collectors.configs - Collectors with their subscriptions
+-- ==============================================================================================
+
+CREATE TABLE IF NOT EXISTS collectors.configs (
+    name TEXT PRIMARY KEY,
+    type TEXT NOT NULL,                         -- real, mock
+    mode TEXT NOT NULL DEFAULT 'full',          -- ltp, quote, full (real collectors)
+    symbols TEXT[] NOT NULL DEFAULT '{}',       -- Subscribed directly
+    watchlists TEXT[] NOT NULL DEFAULT '{}',    -- Followed watchlists
+    auto_start BOOLEAN NOT NULL DEFAULT false,  -- Start on boot; true while the collector runs
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,23 @@
+-- Data Quality Schema
+-- Bars and ticks the collectors rejected or tagged as suspect
+
+-- ==============================================================================================
+-- TABLE: md.data_quality_issues - One row per issue found in an incoming record
+-- ==============================================================================================
+
+CREATE TABLE IF NOT EXISTS md.data_quality_issues (
+    issue_id BIGSERIAL PRIMARY KEY,
+    exchange TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    record_type TEXT NOT NULL,                  -- bar, tick
+    timeframe TEXT,                             -- Bars only
+    record_timestamp TIMESTAMPTZ NOT NULL,      -- bar_timestamp or tick_timestamp of the record
+    code TEXT NOT NULL,                         -- non_positive_price, ohlc_inconsistent, price_spike, ...
+    severity TEXT NOT NULL,                     -- reject (not stored), suspect (stored)
+    message TEXT NOT NULL,
+    source TEXT,
+    detected_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_data_quality_issues_symbol
+    ON md.data_quality_issues (symbol, record_timestamp DESC);
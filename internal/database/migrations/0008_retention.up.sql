@@ -0,0 +1,23 @@
+-- ============================================================================
+-- Trading Chitti - Data Retention Runs
+-- ============================================================================
+--
+-- Reports of the retention service, which rolls old ticks and 1m bars up
+-- into coarser bars and deletes them.
+--
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS md.retention_runs (
+    run_id BIGSERIAL PRIMARY KEY,
+    trigger TEXT NOT NULL DEFAULT 'scheduled',  -- scheduled, api
+    dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+    status TEXT NOT NULL DEFAULT 'running',     -- running, completed, failed, cancelled
+    steps JSONB NOT NULL DEFAULT '[]',          -- Rows rolled up or deleted per step
+    rows_rolled_up BIGINT NOT NULL DEFAULT 0,
+    rows_deleted BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_retention_runs_started ON md.retention_runs (started_at DESC);
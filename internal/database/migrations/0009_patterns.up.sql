@@ -0,0 +1,29 @@
+-- Patterns Schema
+-- Candlestick and chart patterns found by pattern scans
+
+CREATE SCHEMA IF NOT EXISTS patterns;
+
+-- ==============================================================================================
+-- TABLE: patterns.detections - One row per pattern, stored the first time a scan finds it
+-- ==============================================================================================
+
+CREATE TABLE IF NOT EXISTS patterns.detections (
+    detection_id BIGSERIAL PRIMARY KEY,
+    exchange TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    timeframe TEXT NOT NULL,                    -- Candle interval scanned (day, 15minute, ...)
+    pattern_type TEXT NOT NULL,                 -- e.g. Hammer, Double Bottom
+    category TEXT NOT NULL,                     -- candlestick, chart
+    signal TEXT NOT NULL,                       -- bullish, bearish, neutral
+    confidence NUMERIC(5, 4) NOT NULL,
+    start_date TIMESTAMPTZ NOT NULL,
+    end_date TIMESTAMPTZ NOT NULL,              -- Bar that completed the pattern
+    description TEXT,
+    key_levels DOUBLE PRECISION[] NOT NULL DEFAULT '{}',
+    detected_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT uq_pattern_detection UNIQUE (exchange, symbol, timeframe, pattern_type, end_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pattern_detections_end ON patterns.detections (end_date DESC);
+CREATE INDEX IF NOT EXISTS idx_pattern_detections_symbol ON patterns.detections (symbol, end_date DESC);
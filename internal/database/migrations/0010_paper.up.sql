@@ -0,0 +1,74 @@
+-- Paper Trading Schema
+-- Virtual funds, orders and positions for the "paper" broker
+
+CREATE SCHEMA IF NOT EXISTS paper;
+
+-- ==============================================================================================
+-- TABLE: paper.accounts - Virtual funds per paper account
+-- ==============================================================================================
+
+CREATE TABLE IF NOT EXISTS paper.accounts (
+    account_id TEXT PRIMARY KEY,
+    initial_capital DOUBLE PRECISION NOT NULL,
+    realized_pnl DOUBLE PRECISION NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- ==============================================================================================
+-- TABLE: paper.orders - Simulated orders
+-- ==============================================================================================
+
+CREATE TABLE IF NOT EXISTS paper.orders (
+    order_id TEXT PRIMARY KEY,
+    account_id TEXT NOT NULL REFERENCES paper.accounts(account_id) ON DELETE CASCADE,
+    exchange TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    transaction_type TEXT NOT NULL,             -- BUY, SELL
+    order_type TEXT NOT NULL,                   -- MARKET, LIMIT, SL, SL-M
+    product TEXT NOT NULL,                      -- MIS, CNC, NRML
+    quantity INTEGER NOT NULL,
+    price DOUBLE PRECISION NOT NULL DEFAULT 0,
+    trigger_price DOUBLE PRECISION NOT NULL DEFAULT 0,
+    status TEXT NOT NULL,                       -- OPEN, TRIGGER PENDING, COMPLETE, CANCELLED, REJECTED
+    filled_quantity INTEGER NOT NULL DEFAULT 0,
+    pending_quantity INTEGER NOT NULL DEFAULT 0,
+    average_price DOUBLE PRECISION NOT NULL DEFAULT 0,
+    validity TEXT,
+    tag TEXT,
+    status_message TEXT,
+    blocked_margin DOUBLE PRECISION NOT NULL DEFAULT 0,
+    stop_loss DOUBLE PRECISION NOT NULL DEFAULT 0,  -- Exit legs placed when the entry fills
+    target DOUBLE PRECISION NOT NULL DEFAULT 0,
+    parent_order_id TEXT,                       -- Entry order of an exit leg
+    placed_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+-- Upgrade tables created before stop-loss and target legs
+ALTER TABLE paper.orders
+    ADD COLUMN IF NOT EXISTS stop_loss DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS target DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS parent_order_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_paper_orders_account ON paper.orders (account_id, placed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_paper_orders_pending ON paper.orders (account_id)
+    WHERE status IN ('OPEN', 'TRIGGER PENDING');
+
+-- ==============================================================================================
+-- TABLE: paper.positions - Net positions with realized P&L
+-- ==============================================================================================
+
+CREATE TABLE IF NOT EXISTS paper.positions (
+    account_id TEXT NOT NULL REFERENCES paper.accounts(account_id) ON DELETE CASCADE,
+    exchange TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    product TEXT NOT NULL,
+    quantity INTEGER NOT NULL DEFAULT 0,
+    average_price DOUBLE PRECISION NOT NULL DEFAULT 0,
+    last_price DOUBLE PRECISION NOT NULL DEFAULT 0,
+    realized_pnl DOUBLE PRECISION NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (account_id, exchange, symbol, product)
+);
@@ -0,0 +1,43 @@
+-- User-Defined Strategy Schema
+-- Rule-based strategy definitions and the signals they generate
+
+CREATE SCHEMA IF NOT EXISTS strategies;
+
+-- ==============================================================================================
+-- TABLE: strategies.definitions - Strategy definitions (JSON rules) per user
+-- ==============================================================================================
+
+CREATE TABLE IF NOT EXISTS strategies.definitions (
+    strategy_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES auth.users(user_id) ON DELETE CASCADE,  -- NULL in single-user mode
+    name TEXT NOT NULL,
+    description TEXT,
+    definition JSONB NOT NULL,                  -- Entry/exit rules, sizing, stops
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_strategy_definitions_user_name
+    ON strategies.definitions (COALESCE(user_id, '00000000-0000-0000-0000-000000000000'::UUID), name);
+
+-- ==============================================================================================
+-- TABLE: strategies.signals - Signals emitted by scans and live runs
+-- ==============================================================================================
+
+CREATE TABLE IF NOT EXISTS strategies.signals (
+    signal_id BIGSERIAL PRIMARY KEY,
+    strategy_id UUID NOT NULL REFERENCES strategies.definitions(strategy_id) ON DELETE CASCADE,
+    mode TEXT NOT NULL,                         -- scan, live
+    exchange TEXT NOT NULL DEFAULT 'NSE',
+    symbol TEXT NOT NULL,
+    action TEXT NOT NULL,                       -- BUY, SELL, EXIT
+    price NUMERIC(12, 2) NOT NULL,
+    bar_timestamp TIMESTAMPTZ NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    UNIQUE (strategy_id, mode, symbol, bar_timestamp)
+);
+
+CREATE INDEX IF NOT EXISTS idx_strategy_signals_strategy ON strategies.signals (strategy_id, created_at DESC);
@@ -0,0 +1,37 @@
+-- User Watchlist Schema
+-- User-defined symbol lists; the built-in index, sector and movers lists
+-- live in code and seed these
+
+CREATE SCHEMA IF NOT EXISTS watchlists;
+
+-- ==============================================================================================
+-- TABLE: watchlists.lists - Watchlists per user
+-- ==============================================================================================
+
+CREATE TABLE IF NOT EXISTS watchlists.lists (
+    watchlist_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES auth.users(user_id) ON DELETE CASCADE,  -- NULL in single-user mode
+    name TEXT NOT NULL,
+    description TEXT,
+    exchange TEXT NOT NULL DEFAULT 'NSE',
+    symbols TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_watchlists_user_name
+    ON watchlists.lists (COALESCE(user_id, '00000000-0000-0000-0000-000000000000'::UUID), name);
+
+-- ==============================================================================================
+-- TABLE: watchlists.shares - Read-only access granted to other users (multi-user mode)
+-- ==============================================================================================
+
+CREATE TABLE IF NOT EXISTS watchlists.shares (
+    watchlist_id UUID NOT NULL REFERENCES watchlists.lists(watchlist_id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES auth.users(user_id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (watchlist_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_watchlist_shares_user ON watchlists.shares (user_id);
@@ -0,0 +1,47 @@
+-- Alerts Schema
+-- Price, indicator and pattern alerts evaluated against collector data
+
+CREATE SCHEMA IF NOT EXISTS alerts;
+
+-- ==============================================================================================
+-- TABLE: alerts.rules - Alert conditions and their current state per user
+-- ==============================================================================================
+
+CREATE TABLE IF NOT EXISTS alerts.rules (
+    alert_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES auth.users(user_id) ON DELETE CASCADE,  -- NULL in single-user mode
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL,                         -- price, indicator, pattern
+    exchange TEXT NOT NULL DEFAULT 'NSE',
+    symbol TEXT NOT NULL,
+    interval TEXT NOT NULL DEFAULT '15minute',  -- Bar interval for indicator and pattern alerts
+    condition JSONB NOT NULL,                   -- Kind-specific condition
+    state TEXT NOT NULL DEFAULT 'armed',        -- armed, triggered, acknowledged
+    last_value NUMERIC(14, 4),                  -- Last evaluated price or indicator value
+    last_bar TIMESTAMPTZ,                       -- Last bar evaluated, so a bar fires at most once
+    triggered_at TIMESTAMPTZ,
+    acknowledged_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chk_alert_kind CHECK (kind IN ('price', 'indicator', 'pattern')),
+    CONSTRAINT chk_alert_state CHECK (state IN ('armed', 'triggered', 'acknowledged'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_rules_user ON alerts.rules (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_alert_rules_armed ON alerts.rules (exchange, symbol) WHERE state = 'armed';
+
+-- ==============================================================================================
+-- TABLE: alerts.events - State transitions (history) of each alert
+-- ==============================================================================================
+
+CREATE TABLE IF NOT EXISTS alerts.events (
+    event_id BIGSERIAL PRIMARY KEY,
+    alert_id UUID NOT NULL REFERENCES alerts.rules(alert_id) ON DELETE CASCADE,
+    state TEXT NOT NULL,                        -- State entered: armed, triggered, acknowledged
+    value NUMERIC(14, 4),                       -- Price or indicator value when triggered
+    message TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_events_alert ON alerts.events (alert_id, created_at DESC);
@@ -0,0 +1,26 @@
+-- Notification Schema
+-- Per-user channels (Telegram, Slack, email, webhooks) that events are pushed to
+
+CREATE SCHEMA IF NOT EXISTS notify;
+
+-- ==============================================================================================
+-- TABLE: notify.channels - Notification channels per user
+-- ==============================================================================================
+
+CREATE TABLE IF NOT EXISTS notify.channels (
+    channel_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES auth.users(user_id) ON DELETE CASCADE,  -- NULL in single-user mode
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL,                         -- telegram, slack, email, webhook
+    config JSONB NOT NULL,                      -- Kind-specific settings, including credentials
+    events TEXT[] NOT NULL DEFAULT '{}',        -- Events delivered; empty means all
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    last_sent_at TIMESTAMPTZ,
+    last_error TEXT,                            -- Error from the last delivery, NULL on success
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chk_channel_kind CHECK (kind IN ('telegram', 'slack', 'email', 'webhook'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_notify_channels_user ON notify.channels (user_id) WHERE enabled;
@@ -0,0 +1,28 @@
+-- Breadth Schema
+-- Daily market breadth of watchlists (advance/decline, SMA participation, new highs/lows)
+
+CREATE SCHEMA IF NOT EXISTS analytics;
+
+-- ==============================================================================================
+-- TABLE: analytics.breadth_daily - One row per watchlist and session, updated on each computation
+-- ==============================================================================================
+
+CREATE TABLE IF NOT EXISTS analytics.breadth_daily (
+    watchlist TEXT NOT NULL,
+    trade_date DATE NOT NULL,                   -- IST session the breadth describes
+    symbols INTEGER NOT NULL,                   -- Constituents with enough data
+    advancers INTEGER NOT NULL,
+    decliners INTEGER NOT NULL,
+    unchanged INTEGER NOT NULL,
+    advance_decline_ratio DOUBLE PRECISION NOT NULL,
+    above_sma_20 INTEGER NOT NULL,
+    above_sma_50 INTEGER NOT NULL,
+    pct_above_sma_20 DOUBLE PRECISION NOT NULL,
+    pct_above_sma_50 DOUBLE PRECISION NOT NULL,
+    new_52w_highs INTEGER NOT NULL,
+    new_52w_lows INTEGER NOT NULL,
+    average_rsi DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (watchlist, trade_date)
+);
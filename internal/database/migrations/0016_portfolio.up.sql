@@ -0,0 +1,23 @@
+-- Portfolio Schema
+-- Daily portfolio valuation of the broker account, for performance history
+
+CREATE SCHEMA IF NOT EXISTS analytics;
+
+-- ==============================================================================================
+-- TABLE: analytics.portfolio_daily - One row per account and session, updated on each snapshot
+-- ==============================================================================================
+
+CREATE TABLE IF NOT EXISTS analytics.portfolio_daily (
+    account TEXT NOT NULL,                      -- Broker name
+    trade_date DATE NOT NULL,                   -- IST session the snapshot describes
+    net_value DOUBLE PRECISION NOT NULL,        -- Holdings and positions at the close, shorts negative
+    gross_value DOUBLE PRECISION NOT NULL,
+    holdings_value DOUBLE PRECISION NOT NULL,
+    positions_value DOUBLE PRECISION NOT NULL,
+    total_pnl DOUBLE PRECISION NOT NULL,
+    day_change DOUBLE PRECISION NOT NULL,
+    day_change_pct DOUBLE PRECISION NOT NULL,   -- Of the value at the previous close; chained into returns
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (account, trade_date)
+);
@@ -0,0 +1,10 @@
+-- Options Schema
+-- Option chain lookups: an underlying's expiries and the contracts of one
+
+-- ==============================================================================================
+-- INDEX: option contracts by underlying (Kite's instrument name) and expiry
+-- ==============================================================================================
+
+CREATE INDEX IF NOT EXISTS idx_instruments_options
+    ON trades.instruments(name, expiry, strike)
+    WHERE instrument_type IN ('CE', 'PE');
@@ -0,0 +1,12 @@
+-- Indices Schema
+-- Index spot data: collectors store NIFTY 50, NIFTY BANK and INDIA VIX ticks and bars on the INDEX exchange
+
+-- ==============================================================================================
+-- DATA: md.symbols rows the index bars and ticks reference
+-- ==============================================================================================
+
+INSERT INTO md.symbols (exchange, symbol, name) VALUES
+    ('INDEX', 'NIFTY 50', 'Nifty 50'),
+    ('INDEX', 'NIFTY BANK', 'Nifty Bank'),
+    ('INDEX', 'INDIA VIX', 'India VIX')
+ON CONFLICT (exchange, symbol) DO NOTHING;
@@ -0,0 +1,22 @@
+-- Corporate Actions Schema
+-- Splits, bonuses and dividends, for split/bonus-adjusted candles and bars
+
+-- ==============================================================================================
+-- TABLE: md.corporate_actions - One row per symbol, ex-date and action type
+-- ==============================================================================================
+
+CREATE TABLE IF NOT EXISTS md.corporate_actions (
+    action_id BIGSERIAL PRIMARY KEY,
+    exchange TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    ex_date DATE NOT NULL,
+    action_type TEXT NOT NULL CHECK (action_type IN ('split', 'bonus', 'dividend')),
+    ratio_from INTEGER,                         -- split: old shares, bonus: bonus shares
+    ratio_to INTEGER,                           -- split: new shares, bonus: shares held
+    amount DOUBLE PRECISION,                    -- dividend per share
+    description TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (exchange, symbol, ex_date, action_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_corporate_actions_symbol ON md.corporate_actions (symbol, ex_date);
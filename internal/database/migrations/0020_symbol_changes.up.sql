@@ -0,0 +1,26 @@
+-- Symbol Changes Schema
+-- ISIN-based instrument identity: renames of a tradingsymbol with their effective dates
+
+-- ==============================================================================================
+-- TABLE: md.symbol_changes - One row per rename of a symbol on an exchange
+-- ==============================================================================================
+
+CREATE TABLE IF NOT EXISTS md.symbol_changes (
+    change_id BIGSERIAL PRIMARY KEY,
+    exchange TEXT NOT NULL,
+    old_symbol TEXT NOT NULL,
+    new_symbol TEXT NOT NULL,
+    isin TEXT,
+    effective_date DATE NOT NULL,               -- First session traded as new_symbol
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (exchange, old_symbol, effective_date),
+    CHECK (old_symbol <> new_symbol)
+);
+
+CREATE INDEX IF NOT EXISTS idx_symbol_changes_new_symbol ON md.symbol_changes (exchange, new_symbol);
+
+-- ==============================================================================================
+-- INDEX: instruments by ISIN, for lookups across renames
+-- ==============================================================================================
+
+CREATE INDEX IF NOT EXISTS idx_instruments_isin ON trades.instruments (isin) WHERE isin <> '';
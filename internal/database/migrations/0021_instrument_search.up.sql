@@ -0,0 +1,24 @@
+-- Instrument Search Schema
+-- Substring search on symbols and names, filtered by exchange, segment, type, expiry and strike
+
+-- ==============================================================================================
+-- EXTENSION: pg_trgm, so ILIKE '%...%' can use an index
+-- ==============================================================================================
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- ==============================================================================================
+-- INDEXES: trigram indexes for q, and keyset pagination by symbol then exchange
+-- ==============================================================================================
+
+CREATE INDEX IF NOT EXISTS idx_instruments_tradingsymbol_trgm
+    ON trades.instruments USING GIN (tradingsymbol gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS idx_instruments_name_trgm
+    ON trades.instruments USING GIN (name gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS idx_instruments_search_order
+    ON trades.instruments (tradingsymbol, exchange);
+
+CREATE INDEX IF NOT EXISTS idx_instruments_type_expiry
+    ON trades.instruments (exchange, instrument_type, expiry, strike);
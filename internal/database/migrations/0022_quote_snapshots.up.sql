@@ -0,0 +1,25 @@
+-- Quote Snapshots Schema
+-- Periodic full quotes of the collected symbols: OHLC, volume and order book pressure
+
+-- ==============================================================================================
+-- TABLE: md.quote_snapshots - One row per symbol and snapshot
+-- ==============================================================================================
+
+CREATE TABLE IF NOT EXISTS md.quote_snapshots (
+    exchange TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    snapshot_time TIMESTAMPTZ NOT NULL,
+    last_price DOUBLE PRECISION NOT NULL,
+    open DOUBLE PRECISION,
+    high DOUBLE PRECISION,
+    low DOUBLE PRECISION,
+    close DOUBLE PRECISION,                     -- Previous session's close
+    change_pct DOUBLE PRECISION,
+    volume BIGINT,
+    buy_quantity BIGINT,                        -- Total pending buy quantity
+    sell_quantity BIGINT,                       -- Total pending sell quantity
+    oi BIGINT,
+    PRIMARY KEY (exchange, symbol, snapshot_time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_quote_snapshots_time ON md.quote_snapshots (snapshot_time DESC);
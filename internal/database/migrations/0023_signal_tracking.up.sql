@@ -0,0 +1,17 @@
+-- Signal Tracking Schema
+-- Outcomes of stored trading signals against the prices that followed them
+
+-- ==============================================================================================
+-- TABLE: trades.signals - Exchange, outcome and exit of each signal
+-- ==============================================================================================
+
+ALTER TABLE trades.signals ADD COLUMN IF NOT EXISTS exchange TEXT NOT NULL DEFAULT 'NSE';
+ALTER TABLE trades.signals ADD COLUMN IF NOT EXISTS outcome TEXT NOT NULL DEFAULT 'OPEN'
+    CHECK (outcome IN ('OPEN', 'TARGET', 'STOP', 'EXPIRED'));
+ALTER TABLE trades.signals ADD COLUMN IF NOT EXISTS exit_price NUMERIC(12,2);
+ALTER TABLE trades.signals ADD COLUMN IF NOT EXISTS exited_at TIMESTAMPTZ;
+ALTER TABLE trades.signals ADD COLUMN IF NOT EXISTS r_multiple NUMERIC(10,3);   -- Profit in units of the entry-to-stop risk
+ALTER TABLE trades.signals ADD COLUMN IF NOT EXISTS evaluated_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_signals_open ON trades.signals (generated_at) WHERE outcome = 'OPEN';
+CREATE INDEX IF NOT EXISTS idx_signals_strategy ON trades.signals (strategy, generated_at DESC);
@@ -0,0 +1,10 @@
+-- Tick Quotes Schema
+-- Best bid and ask and open interest on collected ticks
+
+-- ==============================================================================================
+-- TABLE: md.tick_data - Quote fields, NULL where the feed doesn't send them
+-- ==============================================================================================
+
+ALTER TABLE md.tick_data ADD COLUMN IF NOT EXISTS bid DOUBLE PRECISION;   -- Best bid, full mode ticks only
+ALTER TABLE md.tick_data ADD COLUMN IF NOT EXISTS ask DOUBLE PRECISION;   -- Best ask, full mode ticks only
+ALTER TABLE md.tick_data ADD COLUMN IF NOT EXISTS oi BIGINT;              -- Open interest, futures and options
@@ -0,0 +1,18 @@
+-- Historical Cache Access Schema
+-- When each cached candle series was last read, for least recently used pruning
+
+-- ==============================================================================================
+-- TABLE: trades.historical_cache_access - Last read of each instrument and interval
+-- ==============================================================================================
+
+CREATE TABLE IF NOT EXISTS trades.historical_cache_access (
+    instrument_token BIGINT NOT NULL,
+    interval TEXT NOT NULL,
+    last_accessed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (instrument_token, interval)
+);
+
+CREATE INDEX IF NOT EXISTS idx_historical_cache_access_last ON trades.historical_cache_access (last_accessed_at);
+
+GRANT SELECT, INSERT, UPDATE, DELETE ON trades.historical_cache_access TO PUBLIC;
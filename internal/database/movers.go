@@ -0,0 +1,78 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/trading-chitti/market-bridge/internal/watchlist"
+)
+
+// SessionMoves returns the latest session's move of every symbol with
+// collector bars on an exchange: the last price of the session against the
+// prior session's close (the session open for symbols without one) and the
+// session volume. The session is the day of the exchange's latest 1m bar,
+// so the moves of the last trading day remain available after the close.
+func (db *Database) SessionMoves(exchange string) ([]watchlist.Mover, error) {
+	query := `
+		WITH session AS (
+			SELECT date_trunc('day', MAX(bar_timestamp)) AS start
+			FROM md.intraday_bars
+			WHERE exchange = $1 AND timeframe = '1m'
+		),
+		today AS (
+			SELECT
+				symbol,
+				first(open, bar_timestamp) AS open,
+				last(close, bar_timestamp) AS close,
+				SUM(volume) AS volume,
+				MAX(bar_timestamp) AS updated_at
+			FROM md.intraday_bars, session
+			WHERE exchange = $1
+			  AND timeframe = '1m'
+			  AND bar_timestamp >= session.start
+			GROUP BY symbol
+		)
+		SELECT
+			t.symbol, t.open, t.close, t.volume, t.updated_at,
+			(
+				SELECT b.close
+				FROM md.intraday_bars b, session
+				WHERE b.exchange = $1
+				  AND b.symbol = t.symbol
+				  AND b.timeframe = '1m'
+				  AND b.bar_timestamp < session.start
+				ORDER BY b.bar_timestamp DESC
+				LIMIT 1
+			) AS prev_close
+		FROM today t
+		ORDER BY t.symbol
+	`
+
+	rows, err := db.conn.Query(query, exchange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session moves: %w", err)
+	}
+	defer rows.Close()
+
+	moves := []watchlist.Mover{}
+	for rows.Next() {
+		var m watchlist.Mover
+		var open float64
+		var prevClose sql.NullFloat64
+		if err := rows.Scan(&m.Symbol, &open, &m.LastPrice, &m.Volume, &m.UpdatedAt, &prevClose); err != nil {
+			return nil, fmt.Errorf("failed to scan session move: %w", err)
+		}
+
+		m.PrevClose = open
+		if prevClose.Valid {
+			m.PrevClose = prevClose.Float64
+		}
+		m.Change = m.LastPrice - m.PrevClose
+		if m.PrevClose != 0 {
+			m.ChangePct = m.Change / m.PrevClose * 100
+		}
+		moves = append(moves, m)
+	}
+
+	return moves, rows.Err()
+}
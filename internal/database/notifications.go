@@ -0,0 +1,207 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// NotificationChannel is a stored notification channel. UserID is nil for
+// channels created in single-user mode, which also receive system events.
+type NotificationChannel struct {
+	ChannelID  string          `json:"channel_id" db:"channel_id"`
+	UserID     *string         `json:"user_id,omitempty" db:"user_id"`
+	Name       string          `json:"name" db:"name"`
+	Kind       string          `json:"kind" db:"kind"`
+	Config     json.RawMessage `json:"config" db:"config"`
+	Events     []string        `json:"events" db:"events"`
+	Enabled    bool            `json:"enabled" db:"enabled"`
+	LastSentAt *time.Time      `json:"last_sent_at,omitempty" db:"last_sent_at"`
+	LastError  *string         `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+const notificationChannelColumns = `
+	SELECT channel_id, user_id, name, kind, config, events, enabled,
+	       last_sent_at, last_error, created_at, updated_at
+	FROM notify.channels`
+
+// CreateNotificationChannel stores a new channel and fills in its ID and
+// timestamps
+func (db *Database) CreateNotificationChannel(userID string, channel *NotificationChannel) error {
+	query := `
+		INSERT INTO notify.channels (user_id, name, kind, config, events, enabled)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING channel_id, user_id, created_at, updated_at
+	`
+
+	err := db.conn.QueryRow(query,
+		nullableUserID(userID),
+		channel.Name,
+		channel.Kind,
+		[]byte(channel.Config),
+		pq.Array(channel.Events),
+		channel.Enabled,
+	).Scan(&channel.ChannelID, &channel.UserID, &channel.CreatedAt, &channel.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create notification channel: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateNotificationChannel replaces a channel's settings. Returns
+// sql.ErrNoRows if the user doesn't own the channel.
+func (db *Database) UpdateNotificationChannel(userID string, channel *NotificationChannel) error {
+	query := `
+		UPDATE notify.channels
+		SET name = $3, kind = $4, config = $5, events = $6, enabled = $7,
+		    last_error = NULL, updated_at = NOW()
+		WHERE channel_id = $1 AND user_id IS NOT DISTINCT FROM $2
+		RETURNING user_id, last_sent_at, created_at, updated_at
+	`
+
+	err := db.conn.QueryRow(query,
+		channel.ChannelID,
+		nullableUserID(userID),
+		channel.Name,
+		channel.Kind,
+		[]byte(channel.Config),
+		pq.Array(channel.Events),
+		channel.Enabled,
+	).Scan(&channel.UserID, &channel.LastSentAt, &channel.CreatedAt, &channel.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update notification channel: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteNotificationChannel deletes a channel. Returns sql.ErrNoRows if the
+// user doesn't own the channel.
+func (db *Database) DeleteNotificationChannel(userID, channelID string) error {
+	result, err := db.conn.Exec(`
+		DELETE FROM notify.channels
+		WHERE channel_id = $1 AND user_id IS NOT DISTINCT FROM $2
+	`, channelID, nullableUserID(userID))
+	if err != nil {
+		return fmt.Errorf("failed to delete notification channel: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete notification channel: %w", err)
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetNotificationChannel returns one of a user's channels, or nil if not found
+func (db *Database) GetNotificationChannel(userID, channelID string) (*NotificationChannel, error) {
+	query := notificationChannelColumns + `
+		WHERE channel_id = $1 AND user_id IS NOT DISTINCT FROM $2
+	`
+
+	channel, err := scanNotificationChannel(db.conn.QueryRow(query, channelID, nullableUserID(userID)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification channel: %w", err)
+	}
+
+	return channel, nil
+}
+
+// GetNotificationChannels lists a user's channels by name
+func (db *Database) GetNotificationChannels(userID string) ([]NotificationChannel, error) {
+	query := notificationChannelColumns + `
+		WHERE user_id IS NOT DISTINCT FROM $1
+		ORDER BY name
+	`
+
+	return db.queryNotificationChannels(query, nullableUserID(userID))
+}
+
+// GetChannelsForEvent lists a user's enabled channels that subscribe to event
+func (db *Database) GetChannelsForEvent(userID, event string) ([]NotificationChannel, error) {
+	query := notificationChannelColumns + `
+		WHERE user_id IS NOT DISTINCT FROM $1
+		  AND enabled
+		  AND (cardinality(events) = 0 OR $2 = ANY(events))
+	`
+
+	return db.queryNotificationChannels(query, nullableUserID(userID), event)
+}
+
+func (db *Database) queryNotificationChannels(query string, args ...interface{}) ([]NotificationChannel, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification channels: %w", err)
+	}
+	defer rows.Close()
+
+	channels := []NotificationChannel{}
+	for rows.Next() {
+		channel, err := scanNotificationChannel(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification channel: %w", err)
+		}
+		channels = append(channels, *channel)
+	}
+
+	return channels, rows.Err()
+}
+
+// RecordNotificationResult stores the outcome of a delivery; an empty
+// errMsg marks success
+func (db *Database) RecordNotificationResult(channelID, errMsg string) error {
+	_, err := db.conn.Exec(`
+		UPDATE notify.channels
+		SET last_sent_at = CASE WHEN $2::text IS NULL THEN NOW() ELSE last_sent_at END,
+		    last_error = $2
+		WHERE channel_id = $1
+	`, channelID, nullableString(errMsg))
+	if err != nil {
+		return fmt.Errorf("failed to record notification result: %w", err)
+	}
+	return nil
+}
+
+func scanNotificationChannel(row rowScanner) (*NotificationChannel, error) {
+	var channel NotificationChannel
+	var config []byte
+
+	err := row.Scan(
+		&channel.ChannelID,
+		&channel.UserID,
+		&channel.Name,
+		&channel.Kind,
+		&config,
+		pq.Array(&channel.Events),
+		&channel.Enabled,
+		&channel.LastSentAt,
+		&channel.LastError,
+		&channel.CreatedAt,
+		&channel.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	channel.Config = json.RawMessage(config)
+	if channel.Events == nil {
+		channel.Events = []string{}
+	}
+	return &channel, nil
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+// GetOptionExpiries returns the expiries of an underlying's option
+// contracts on NFO or BFO on or after from, nearest first
+func (db *Database) GetOptionExpiries(underlying string, from time.Time) ([]time.Time, error) {
+	query := `
+		SELECT DISTINCT expiry
+		FROM trades.instruments
+		WHERE name = $1
+		  AND instrument_type IN ('CE', 'PE')
+		  AND exchange IN ('NFO', 'BFO')
+		  AND expiry >= $2::date
+		ORDER BY expiry
+	`
+
+	rows, err := db.conn.Query(query, underlying, from.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	expiries := []time.Time{}
+	for rows.Next() {
+		var expiry time.Time
+		if err := rows.Scan(&expiry); err != nil {
+			return nil, err
+		}
+		expiries = append(expiries, expiry)
+	}
+
+	return expiries, rows.Err()
+}
+
+// GetOptionContracts returns an underlying's call and put contracts of one
+// expiry, by strike
+func (db *Database) GetOptionContracts(underlying string, expiry time.Time) ([]Instrument, error) {
+	query := `
+		SELECT instrument_token, exchange_token, tradingsymbol, name, exchange,
+		       segment, instrument_type, COALESCE(isin, ''), expiry, strike, tick_size, lot_size,
+		       COALESCE(last_price, 0), last_updated
+		FROM trades.instruments
+		WHERE name = $1
+		  AND instrument_type IN ('CE', 'PE')
+		  AND exchange IN ('NFO', 'BFO')
+		  AND expiry = $2::date
+		ORDER BY strike, instrument_type
+	`
+
+	rows, err := db.conn.Query(query, underlying, expiry.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanInstruments(rows)
+}
+
+// scanInstruments reads instrument rows selected in the column order of
+// GetOptionContracts
+func scanInstruments(rows *sql.Rows) ([]Instrument, error) {
+	instruments := []Instrument{}
+	for rows.Next() {
+		inst := Instrument{}
+		err := rows.Scan(
+			&inst.InstrumentToken,
+			&inst.ExchangeToken,
+			&inst.Tradingsymbol,
+			&inst.Name,
+			&inst.Exchange,
+			&inst.Segment,
+			&inst.InstrumentType,
+			&inst.ISIN,
+			&inst.Expiry,
+			&inst.Strike,
+			&inst.TickSize,
+			&inst.LotSize,
+			&inst.LastPrice,
+			&inst.LastUpdated,
+		)
+		if err != nil {
+			return nil, err
+		}
+		instruments = append(instruments, inst)
+	}
+
+	return instruments, rows.Err()
+}
@@ -0,0 +1,251 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// Database persists paper trading state for the paper broker
+var _ broker.PaperLedger = (*Database)(nil)
+
+// LoadPaperAccount returns a paper account with its open and today's orders
+// and positions, creating the account if it doesn't exist
+func (db *Database) LoadPaperAccount(accountID string, initialCapital float64) (*broker.PaperAccount, error) {
+	_, err := db.conn.Exec(`
+		INSERT INTO paper.accounts (account_id, initial_capital)
+		VALUES ($1, $2)
+		ON CONFLICT (account_id) DO NOTHING
+	`, accountID, initialCapital)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create paper account: %w", err)
+	}
+
+	account := &broker.PaperAccount{AccountID: accountID}
+	err = db.conn.QueryRow(`
+		SELECT initial_capital, realized_pnl FROM paper.accounts WHERE account_id = $1
+	`, accountID).Scan(&account.InitialCapital, &account.RealizedPnL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paper account: %w", err)
+	}
+
+	// Pending orders from earlier days stay live until cancelled
+	rows, err := db.conn.Query(`
+		SELECT order_id, exchange, symbol, transaction_type, order_type, product,
+		       quantity, price, trigger_price, status, filled_quantity, pending_quantity,
+		       average_price, COALESCE(validity, ''), COALESCE(tag, ''),
+		       COALESCE(status_message, ''), blocked_margin, stop_loss, target,
+		       COALESCE(parent_order_id, ''), placed_at, updated_at
+		FROM paper.orders
+		WHERE account_id = $1
+		  AND (status IN ('OPEN', 'TRIGGER PENDING')
+		       OR placed_at >= (NOW() AT TIME ZONE 'Asia/Kolkata')::date AT TIME ZONE 'Asia/Kolkata')
+		ORDER BY placed_at
+	`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paper orders: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var o broker.PaperOrder
+		err := rows.Scan(
+			&o.OrderID,
+			&o.Exchange,
+			&o.Symbol,
+			&o.TransactionType,
+			&o.OrderType,
+			&o.Product,
+			&o.Quantity,
+			&o.Price,
+			&o.TriggerPrice,
+			&o.Status,
+			&o.FilledQuantity,
+			&o.PendingQuantity,
+			&o.AveragePrice,
+			&o.Validity,
+			&o.Tag,
+			&o.StatusMessage,
+			&o.BlockedMargin,
+			&o.StopLoss,
+			&o.Target,
+			&o.ParentOrderID,
+			&o.PlacedAt,
+			&o.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan paper order: %w", err)
+		}
+		account.Orders = append(account.Orders, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read paper orders: %w", err)
+	}
+
+	posRows, err := db.conn.Query(`
+		SELECT exchange, symbol, product, quantity, average_price, last_price,
+		       realized_pnl, updated_at
+		FROM paper.positions
+		WHERE account_id = $1
+		  AND (quantity <> 0
+		       OR updated_at >= (NOW() AT TIME ZONE 'Asia/Kolkata')::date AT TIME ZONE 'Asia/Kolkata')
+	`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paper positions: %w", err)
+	}
+	defer posRows.Close()
+
+	for posRows.Next() {
+		var p broker.PaperPosition
+		err := posRows.Scan(
+			&p.Exchange,
+			&p.Symbol,
+			&p.Product,
+			&p.Quantity,
+			&p.AveragePrice,
+			&p.LastPrice,
+			&p.RealizedPnL,
+			&p.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan paper position: %w", err)
+		}
+		account.Positions = append(account.Positions, p)
+	}
+
+	return account, posRows.Err()
+}
+
+// SavePaperAccount stores a paper account's realized P&L
+func (db *Database) SavePaperAccount(account *broker.PaperAccount) error {
+	_, err := db.conn.Exec(`
+		UPDATE paper.accounts
+		SET realized_pnl = $2, updated_at = NOW()
+		WHERE account_id = $1
+	`, account.AccountID, account.RealizedPnL)
+	if err != nil {
+		return fmt.Errorf("failed to save paper account: %w", err)
+	}
+	return nil
+}
+
+// SavePaperOrder inserts or updates a paper order
+func (db *Database) SavePaperOrder(accountID string, o *broker.PaperOrder) error {
+	query := `
+		INSERT INTO paper.orders (
+			order_id, account_id, exchange, symbol, transaction_type, order_type, product,
+			quantity, price, trigger_price, status, filled_quantity, pending_quantity,
+			average_price, validity, tag, status_message, blocked_margin, stop_loss, target,
+			parent_order_id, placed_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18,
+			$19, $20, $21, $22, $23)
+		ON CONFLICT (order_id) DO UPDATE SET
+			order_type = EXCLUDED.order_type,
+			quantity = EXCLUDED.quantity,
+			price = EXCLUDED.price,
+			trigger_price = EXCLUDED.trigger_price,
+			status = EXCLUDED.status,
+			filled_quantity = EXCLUDED.filled_quantity,
+			pending_quantity = EXCLUDED.pending_quantity,
+			average_price = EXCLUDED.average_price,
+			status_message = EXCLUDED.status_message,
+			blocked_margin = EXCLUDED.blocked_margin,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := db.conn.Exec(query,
+		o.OrderID,
+		accountID,
+		o.Exchange,
+		o.Symbol,
+		o.TransactionType,
+		o.OrderType,
+		o.Product,
+		o.Quantity,
+		o.Price,
+		o.TriggerPrice,
+		o.Status,
+		o.FilledQuantity,
+		o.PendingQuantity,
+		o.AveragePrice,
+		o.Validity,
+		o.Tag,
+		o.StatusMessage,
+		o.BlockedMargin,
+		o.StopLoss,
+		o.Target,
+		nullableString(o.ParentOrderID),
+		o.PlacedAt,
+		o.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save paper order: %w", err)
+	}
+	return nil
+}
+
+// SavePaperPosition inserts or updates a paper position
+func (db *Database) SavePaperPosition(accountID string, p *broker.PaperPosition) error {
+	query := `
+		INSERT INTO paper.positions (
+			account_id, exchange, symbol, product, quantity, average_price,
+			last_price, realized_pnl, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (account_id, exchange, symbol, product) DO UPDATE SET
+			quantity = EXCLUDED.quantity,
+			average_price = EXCLUDED.average_price,
+			last_price = EXCLUDED.last_price,
+			realized_pnl = EXCLUDED.realized_pnl,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := db.conn.Exec(query,
+		accountID,
+		p.Exchange,
+		p.Symbol,
+		p.Product,
+		p.Quantity,
+		p.AveragePrice,
+		p.LastPrice,
+		p.RealizedPnL,
+		p.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save paper position: %w", err)
+	}
+	return nil
+}
+
+// LatestPrice returns the last collected tick for a symbol, falling back to
+// the most recent bar close
+func (db *Database) LatestPrice(exchange, symbol string) (float64, error) {
+	var price float64
+	err := db.conn.QueryRow(`
+		SELECT price FROM md.tick_data
+		WHERE exchange = $1 AND symbol = $2
+		ORDER BY tick_timestamp DESC
+		LIMIT 1
+	`, exchange, symbol).Scan(&price)
+	if err == nil {
+		return price, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to get latest tick: %w", err)
+	}
+
+	err = db.conn.QueryRow(`
+		SELECT close FROM md.intraday_bars
+		WHERE exchange = $1 AND symbol = $2
+		ORDER BY bar_timestamp DESC
+		LIMIT 1
+	`, exchange, symbol).Scan(&price)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("no price data for %s:%s", exchange, symbol)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest bar: %w", err)
+	}
+
+	return price, nil
+}
@@ -0,0 +1,177 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/trading-chitti/market-bridge/internal/analyzer"
+)
+
+// PatternDetection is a stored pattern found by a scan
+type PatternDetection struct {
+	DetectionID int64     `json:"detection_id" db:"detection_id"`
+	Exchange    string    `json:"exchange" db:"exchange"`
+	Symbol      string    `json:"symbol" db:"symbol"`
+	Timeframe   string    `json:"timeframe" db:"timeframe"`
+	Type        string    `json:"type" db:"pattern_type"`
+	Category    string    `json:"category" db:"category"`
+	Signal      string    `json:"signal" db:"signal"`
+	Confidence  float64   `json:"confidence" db:"confidence"`
+	StartDate   time.Time `json:"start_date" db:"start_date"`
+	EndDate     time.Time `json:"end_date" db:"end_date"`
+	Description string    `json:"description" db:"description"`
+	KeyLevels   []float64 `json:"key_levels" db:"key_levels"`
+	DetectedAt  time.Time `json:"detected_at" db:"detected_at"`
+}
+
+// PatternFilter selects stored patterns. Zero values match everything.
+type PatternFilter struct {
+	Exchange      string
+	Symbol        string
+	Timeframe     string
+	Type          string
+	Signal        string
+	Category      string
+	MinConfidence float64
+	Since         time.Time // Patterns completed at or after this time
+	Limit         int
+}
+
+// SavePatterns stores the patterns a scan found for a symbol and returns
+// the ones not seen before. A pattern is identified by its type and the bar
+// that completed it, so rescanning overlapping history doesn't duplicate it.
+func (db *Database) SavePatterns(exchange, symbol, timeframe string, patterns []analyzer.Pattern) ([]PatternDetection, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		INSERT INTO patterns.detections (
+			exchange, symbol, timeframe, pattern_type, category, signal,
+			confidence, start_date, end_date, description, key_levels
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (exchange, symbol, timeframe, pattern_type, end_date) DO NOTHING
+		RETURNING detection_id, detected_at
+	`
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare pattern insert: %w", err)
+	}
+	defer stmt.Close()
+
+	exchange = strings.ToUpper(exchange)
+	symbol = strings.ToUpper(symbol)
+
+	added := []PatternDetection{}
+	for _, p := range patterns {
+		d := PatternDetection{
+			Exchange:    exchange,
+			Symbol:      symbol,
+			Timeframe:   timeframe,
+			Type:        p.Type,
+			Category:    p.Category,
+			Signal:      p.Signal,
+			Confidence:  p.Confidence,
+			StartDate:   p.StartDate,
+			EndDate:     p.EndDate,
+			Description: p.Description,
+			KeyLevels:   p.KeyLevels,
+		}
+		if d.KeyLevels == nil {
+			d.KeyLevels = []float64{}
+		}
+
+		err := stmt.QueryRow(
+			d.Exchange, d.Symbol, d.Timeframe, d.Type, d.Category, d.Signal,
+			d.Confidence, d.StartDate, d.EndDate, d.Description, pq.Array(d.KeyLevels),
+		).Scan(&d.DetectionID, &d.DetectedAt)
+		if err == sql.ErrNoRows {
+			continue // Already stored
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to save pattern: %w", err)
+		}
+		added = append(added, d)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit patterns: %w", err)
+	}
+	return added, nil
+}
+
+// GetRecentPatterns returns stored patterns matching the filter, most
+// recently completed first
+func (db *Database) GetRecentPatterns(filter PatternFilter) ([]PatternDetection, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 100
+	}
+
+	query := `
+		SELECT detection_id, exchange, symbol, timeframe, pattern_type, category, signal,
+		       confidence, start_date, end_date, COALESCE(description, ''), key_levels, detected_at
+		FROM patterns.detections
+		WHERE ($1 = '' OR exchange = $1)
+		  AND ($2 = '' OR symbol = $2)
+		  AND ($3 = '' OR timeframe = $3)
+		  AND ($4 = '' OR LOWER(pattern_type) = LOWER($4))
+		  AND ($5 = '' OR signal = $5)
+		  AND ($6 = '' OR category = $6)
+		  AND confidence >= $7
+		  AND ($8::timestamptz IS NULL OR end_date >= $8)
+		ORDER BY end_date DESC, confidence DESC
+		LIMIT $9
+	`
+
+	rows, err := db.conn.Query(query,
+		strings.ToUpper(filter.Exchange),
+		strings.ToUpper(filter.Symbol),
+		filter.Timeframe,
+		filter.Type,
+		filter.Signal,
+		filter.Category,
+		filter.MinConfidence,
+		nullableTime(filter.Since),
+		filter.Limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get patterns: %w", err)
+	}
+	defer rows.Close()
+
+	detections := []PatternDetection{}
+	for rows.Next() {
+		var d PatternDetection
+		err := rows.Scan(
+			&d.DetectionID,
+			&d.Exchange,
+			&d.Symbol,
+			&d.Timeframe,
+			&d.Type,
+			&d.Category,
+			&d.Signal,
+			&d.Confidence,
+			&d.StartDate,
+			&d.EndDate,
+			&d.Description,
+			pq.Array(&d.KeyLevels),
+			&d.DetectedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pattern: %w", err)
+		}
+		detections = append(detections, d)
+	}
+
+	return detections, rows.Err()
+}
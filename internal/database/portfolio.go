@@ -0,0 +1,98 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// PortfolioDay is an account's stored portfolio valuation for one session
+type PortfolioDay struct {
+	Account        string    `json:"account" db:"account"`
+	Date           time.Time `json:"date" db:"trade_date"`
+	NetValue       float64   `json:"net_value" db:"net_value"`
+	GrossValue     float64   `json:"gross_value" db:"gross_value"`
+	HoldingsValue  float64   `json:"holdings_value" db:"holdings_value"`
+	PositionsValue float64   `json:"positions_value" db:"positions_value"`
+	TotalPNL       float64   `json:"total_pnl" db:"total_pnl"`
+	DayChange      float64   `json:"day_change" db:"day_change"`
+	DayChangePct   float64   `json:"day_change_pct" db:"day_change_pct"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SavePortfolioDay stores an account's valuation for the IST session of
+// day.Date, replacing any earlier snapshot of the same session
+func (db *Database) SavePortfolioDay(day *PortfolioDay) error {
+	query := `
+		INSERT INTO analytics.portfolio_daily (
+			account, trade_date, net_value, gross_value, holdings_value,
+			positions_value, total_pnl, day_change, day_change_pct
+		) VALUES ($1, ($2::timestamptz AT TIME ZONE 'Asia/Kolkata')::date, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (account, trade_date) DO UPDATE SET
+			net_value = EXCLUDED.net_value,
+			gross_value = EXCLUDED.gross_value,
+			holdings_value = EXCLUDED.holdings_value,
+			positions_value = EXCLUDED.positions_value,
+			total_pnl = EXCLUDED.total_pnl,
+			day_change = EXCLUDED.day_change,
+			day_change_pct = EXCLUDED.day_change_pct,
+			updated_at = NOW()
+		RETURNING trade_date, updated_at
+	`
+
+	err := db.conn.QueryRow(query,
+		day.Account,
+		day.Date,
+		day.NetValue,
+		day.GrossValue,
+		day.HoldingsValue,
+		day.PositionsValue,
+		day.TotalPNL,
+		day.DayChange,
+		day.DayChangePct,
+	).Scan(&day.Date, &day.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save portfolio day: %w", err)
+	}
+	return nil
+}
+
+// GetPortfolioHistory returns an account's stored valuations for sessions
+// on or after from, oldest first
+func (db *Database) GetPortfolioHistory(account string, from time.Time) ([]PortfolioDay, error) {
+	query := `
+		SELECT account, trade_date, net_value, gross_value, holdings_value,
+		       positions_value, total_pnl, day_change, day_change_pct, updated_at
+		FROM analytics.portfolio_daily
+		WHERE account = $1 AND trade_date >= $2::date
+		ORDER BY trade_date
+	`
+
+	rows, err := db.conn.Query(query, account, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get portfolio history: %w", err)
+	}
+	defer rows.Close()
+
+	days := []PortfolioDay{}
+	for rows.Next() {
+		var d PortfolioDay
+		err := rows.Scan(
+			&d.Account,
+			&d.Date,
+			&d.NetValue,
+			&d.GrossValue,
+			&d.HoldingsValue,
+			&d.PositionsValue,
+			&d.TotalPNL,
+			&d.DayChange,
+			&d.DayChangePct,
+			&d.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan portfolio day: %w", err)
+		}
+		days = append(days, d)
+	}
+
+	return days, rows.Err()
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// QualityIssue is a recorded problem with an incoming bar or tick
+type QualityIssue struct {
+	IssueID         int64     `json:"issue_id" db:"issue_id"`
+	Exchange        string    `json:"exchange" db:"exchange"`
+	Symbol          string    `json:"symbol" db:"symbol"`
+	RecordType      string    `json:"record_type" db:"record_type"` // bar, tick
+	Timeframe       string    `json:"timeframe,omitempty" db:"timeframe"`
+	RecordTimestamp time.Time `json:"record_timestamp" db:"record_timestamp"`
+	Code            string    `json:"code" db:"code"`
+	Severity        string    `json:"severity" db:"severity"` // reject, suspect
+	Message         string    `json:"message" db:"message"`
+	Source          string    `json:"source,omitempty" db:"source"`
+	DetectedAt      time.Time `json:"detected_at" db:"detected_at"`
+}
+
+// InsertQualityIssues records issues found in incoming records
+func (db *Database) InsertQualityIssues(issues []QualityIssue) error {
+	if len(issues) == 0 {
+		return nil
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to record quality issues: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO md.data_quality_issues (
+			exchange, symbol, record_type, timeframe, record_timestamp,
+			code, severity, message, source
+		) VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, NULLIF($9, ''))
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to record quality issues: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, issue := range issues {
+		if _, err := stmt.Exec(
+			issue.Exchange,
+			issue.Symbol,
+			issue.RecordType,
+			issue.Timeframe,
+			issue.RecordTimestamp,
+			issue.Code,
+			issue.Severity,
+			issue.Message,
+			issue.Source,
+		); err != nil {
+			return fmt.Errorf("failed to record quality issue: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetQualityIssues returns a symbol's recorded issues for records between
+// from and to, newest first
+func (db *Database) GetQualityIssues(symbol string, from, to time.Time, limit int) ([]QualityIssue, error) {
+	rows, err := db.conn.Query(`
+		SELECT issue_id, exchange, symbol, record_type, COALESCE(timeframe, ''), record_timestamp,
+		       code, severity, message, COALESCE(source, ''), detected_at
+		FROM md.data_quality_issues
+		WHERE symbol = $1 AND record_timestamp BETWEEN $2 AND $3
+		ORDER BY record_timestamp DESC, issue_id DESC
+		LIMIT $4
+	`, symbol, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quality issues: %w", err)
+	}
+	defer rows.Close()
+
+	issues := []QualityIssue{}
+	for rows.Next() {
+		var issue QualityIssue
+		if err := rows.Scan(
+			&issue.IssueID,
+			&issue.Exchange,
+			&issue.Symbol,
+			&issue.RecordType,
+			&issue.Timeframe,
+			&issue.RecordTimestamp,
+			&issue.Code,
+			&issue.Severity,
+			&issue.Message,
+			&issue.Source,
+			&issue.DetectedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan quality issue: %w", err)
+		}
+		issues = append(issues, issue)
+	}
+
+	return issues, rows.Err()
+}
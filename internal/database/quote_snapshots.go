@@ -0,0 +1,162 @@
+package database
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// QuoteSnapshot is a symbol's full quote at one moment
+type QuoteSnapshot struct {
+	Exchange     string    `json:"exchange" db:"exchange"`
+	Symbol       string    `json:"symbol" db:"symbol"`
+	SnapshotTime time.Time `json:"snapshot_time" db:"snapshot_time"`
+	LastPrice    float64   `json:"last_price" db:"last_price"`
+	Open         float64   `json:"open" db:"open"`
+	High         float64   `json:"high" db:"high"`
+	Low          float64   `json:"low" db:"low"`
+	Close        float64   `json:"close" db:"close"` // Previous session's close
+	ChangePct    float64   `json:"change_pct" db:"change_pct"`
+	Volume       int64     `json:"volume" db:"volume"`
+	BuyQuantity  int64     `json:"buy_quantity" db:"buy_quantity"`
+	SellQuantity int64     `json:"sell_quantity" db:"sell_quantity"`
+	OI           int64     `json:"oi,omitempty" db:"oi"`
+}
+
+// NewQuoteSnapshot records a broker quote of exchange:symbol taken at at.
+// The change is 0 for quotes without a previous close.
+func NewQuoteSnapshot(exchange, symbol string, quote broker.Quote, at time.Time) QuoteSnapshot {
+	changePct := quote.ChangePercent
+	if math.IsNaN(changePct) || math.IsInf(changePct, 0) {
+		changePct = 0
+	}
+	return QuoteSnapshot{
+		Exchange:     strings.ToUpper(exchange),
+		Symbol:       strings.ToUpper(symbol),
+		SnapshotTime: at,
+		LastPrice:    quote.LastPrice,
+		Open:         quote.Open,
+		High:         quote.High,
+		Low:          quote.Low,
+		Close:        quote.Close,
+		ChangePct:    changePct,
+		Volume:       quote.Volume,
+		BuyQuantity:  quote.BuyQuantity,
+		SellQuantity: quote.SellQuantity,
+		OI:           quote.OI,
+	}
+}
+
+// Imbalance is the order book pressure of a snapshot, from -1 (only
+// sellers) to 1 (only buyers), 0 without pending orders
+func (s QuoteSnapshot) Imbalance() float64 {
+	total := s.BuyQuantity + s.SellQuantity
+	if total == 0 {
+		return 0
+	}
+	return float64(s.BuyQuantity-s.SellQuantity) / float64(total)
+}
+
+// SaveQuoteSnapshots stores snapshots, ignoring ones already stored for the
+// same symbol and time
+func (db *Database) SaveQuoteSnapshots(snapshots []QuoteSnapshot) error {
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO md.quote_snapshots (
+			exchange, symbol, snapshot_time, last_price, open, high, low, close,
+			change_pct, volume, buy_quantity, sell_quantity, oi
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (exchange, symbol, snapshot_time) DO NOTHING
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, s := range snapshots {
+		_, err := stmt.Exec(
+			s.Exchange,
+			s.Symbol,
+			s.SnapshotTime,
+			s.LastPrice,
+			s.Open,
+			s.High,
+			s.Low,
+			s.Close,
+			s.ChangePct,
+			s.Volume,
+			s.BuyQuantity,
+			s.SellQuantity,
+			s.OI,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save snapshot of %s: %w", s.Symbol, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetQuoteSnapshots returns a symbol's snapshots between from and to,
+// oldest first, the latest limit of them
+func (db *Database) GetQuoteSnapshots(exchange, symbol string, from, to time.Time, limit int) ([]QuoteSnapshot, error) {
+	query := `
+		SELECT exchange, symbol, snapshot_time, last_price,
+		       COALESCE(open, 0), COALESCE(high, 0), COALESCE(low, 0), COALESCE(close, 0),
+		       COALESCE(change_pct, 0), COALESCE(volume, 0),
+		       COALESCE(buy_quantity, 0), COALESCE(sell_quantity, 0), COALESCE(oi, 0)
+		FROM (
+			SELECT *
+			FROM md.quote_snapshots
+			WHERE exchange = $1 AND symbol = $2
+			  AND snapshot_time >= $3 AND snapshot_time <= $4
+			ORDER BY snapshot_time DESC
+			LIMIT $5
+		) latest
+		ORDER BY snapshot_time
+	`
+
+	rows, err := db.conn.Query(query, strings.ToUpper(exchange), strings.ToUpper(symbol), from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := []QuoteSnapshot{}
+	for rows.Next() {
+		var s QuoteSnapshot
+		err := rows.Scan(
+			&s.Exchange,
+			&s.Symbol,
+			&s.SnapshotTime,
+			&s.LastPrice,
+			&s.Open,
+			&s.High,
+			&s.Low,
+			&s.Close,
+			&s.ChangePct,
+			&s.Volume,
+			&s.BuyQuantity,
+			&s.SellQuantity,
+			&s.OI,
+		)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, s)
+	}
+
+	return snapshots, rows.Err()
+}
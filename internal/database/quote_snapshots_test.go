@@ -0,0 +1,55 @@
+package database
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+func TestNewQuoteSnapshot(t *testing.T) {
+	at := time.Date(2024, 1, 30, 10, 0, 0, 0, istZone)
+	quote := broker.Quote{
+		LastPrice:     1520.5,
+		Open:          1500,
+		High:          1525,
+		Low:           1495,
+		Close:         1490,
+		ChangePercent: math.NaN(),
+		Volume:        125000,
+		BuyQuantity:   3000,
+		SellQuantity:  1000,
+	}
+
+	snapshot := NewQuoteSnapshot("nse", "infy", quote, at)
+	if snapshot.Exchange != "NSE" || snapshot.Symbol != "INFY" || !snapshot.SnapshotTime.Equal(at) {
+		t.Errorf("snapshot is of %s:%s at %v, want NSE:INFY at %v", snapshot.Exchange, snapshot.Symbol, snapshot.SnapshotTime, at)
+	}
+	if snapshot.ChangePct != 0 {
+		t.Errorf("ChangePct = %v, want 0 for a NaN change", snapshot.ChangePct)
+	}
+	if snapshot.LastPrice != 1520.5 || snapshot.Close != 1490 || snapshot.Volume != 125000 {
+		t.Errorf("snapshot = %+v, doesn't match the quote", snapshot)
+	}
+}
+
+func TestQuoteSnapshotImbalance(t *testing.T) {
+	tests := []struct {
+		buy, sell int64
+		want      float64
+	}{
+		{3000, 1000, 0.5},
+		{1000, 3000, -0.5},
+		{500, 0, 1},
+		{0, 500, -1},
+		{0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		snapshot := QuoteSnapshot{BuyQuantity: tt.buy, SellQuantity: tt.sell}
+		if got := snapshot.Imbalance(); got != tt.want {
+			t.Errorf("Imbalance() of %d/%d = %v, want %v", tt.buy, tt.sell, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// rateLimitRefill is a bucket's token count refilled up to now: its stored
+// tokens plus rate ($2) per second since the last request, capped at the
+// burst ($3)
+const rateLimitRefill = `LEAST($3::float8, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at)::float8 * $2::float8)`
+
+// TakeRateLimitToken takes a token from the shared bucket of key, which
+// refills at rate tokens per second up to burst. It returns whether a token
+// was available and how many are left. The bucket is updated atomically, so
+// any number of instances can share it.
+func (db *Database) TakeRateLimitToken(key string, rate float64, burst int) (allowed bool, tokens float64, err error) {
+	query := `
+		INSERT INTO auth.rate_limit_buckets AS b (bucket_key, tokens, allowed, updated_at)
+		VALUES ($1, $3::float8 - 1, TRUE, NOW())
+		ON CONFLICT (bucket_key) DO UPDATE SET
+			tokens = CASE WHEN ` + rateLimitRefill + ` >= 1
+				THEN ` + rateLimitRefill + ` - 1
+				ELSE ` + rateLimitRefill + ` END,
+			allowed = ` + rateLimitRefill + ` >= 1,
+			updated_at = NOW()
+		RETURNING allowed, tokens
+	`
+
+	err = db.conn.QueryRow(query, key, rate, burst).Scan(&allowed, &tokens)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+	return allowed, tokens, nil
+}
+
+// DeleteIdleRateLimitBuckets deletes buckets not used for idle, which have
+// refilled completely by then. It returns the number deleted.
+func (db *Database) DeleteIdleRateLimitBuckets(idle time.Duration) (int64, error) {
+	result, err := db.conn.Exec(`
+		DELETE FROM auth.rate_limit_buckets
+		WHERE updated_at < NOW() - $1 * INTERVAL '1 second'
+	`, idle.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete idle rate limit buckets: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxResampleWidth is the widest bar 1m bars can be resampled into, a full
+// 09:15-15:30 session
+const MaxResampleWidth = 375 * time.Minute
+
+// ParseResampleTimeframe parses a timeframe 1m bars can be resampled into, a
+// number of minutes ("3m", "10m") or hours ("2h")
+func ParseResampleTimeframe(timeframe string) (time.Duration, error) {
+	if len(timeframe) < 2 {
+		return 0, fmt.Errorf("invalid timeframe %q", timeframe)
+	}
+
+	var unit time.Duration
+	switch strings.ToLower(timeframe[len(timeframe)-1:]) {
+	case "m":
+		unit = time.Minute
+	case "h":
+		unit = time.Hour
+	default:
+		return 0, fmt.Errorf("invalid timeframe %q, use minutes (e.g. 3m) or hours (e.g. 2h)", timeframe)
+	}
+
+	n, err := strconv.Atoi(timeframe[:len(timeframe)-1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid timeframe %q", timeframe)
+	}
+
+	width := time.Duration(n) * unit
+	if width > MaxResampleWidth {
+		return 0, fmt.Errorf("timeframe %q is longer than a trading session", timeframe)
+	}
+	return width, nil
+}
+
+// GetResampledBars aggregates a symbol's 1m bars on an exchange between
+// fromTime and toTime into timeframe bars at query time. Bars start at 09:15 IST each session,
+// so the session's last bar may be shorter.
+func (db *Database) GetResampledBars(exchange, symbol, timeframe string, fromTime, toTime time.Time, limit int) ([]IntradayBar, error) {
+	width, err := ParseResampleTimeframe(timeframe)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT
+			0, exchange, symbol, COALESCE(MAX(instrument_token), 0), bucket, $3::TEXT,
+			(array_agg(open ORDER BY bar_timestamp))[1],
+			MAX(high),
+			MIN(low),
+			(array_agg(close ORDER BY bar_timestamp DESC))[1],
+			SUM(volume)::BIGINT,
+			SUM(trades_count)::INTEGER,
+			SUM(COALESCE(vwap, close) * volume) / NULLIF(SUM(volume), 0),
+			(array_agg(oi ORDER BY bar_timestamp DESC))[1],
+			'resampled',
+			MAX(created_at)
+		FROM (
+			SELECT *,
+				date_bin($2::interval, bar_timestamp,
+					((bar_timestamp AT TIME ZONE 'Asia/Kolkata')::date + TIME '09:15') AT TIME ZONE 'Asia/Kolkata'
+				) AS bucket
+			FROM md.intraday_bars
+			WHERE exchange = $7
+			  AND symbol = $1
+			  AND timeframe = '1m'
+			  AND bar_timestamp >= $4
+			  AND bar_timestamp <= $5
+		) minute_bars
+		GROUP BY exchange, symbol, bucket
+		ORDER BY bucket ASC
+		LIMIT $6
+	`
+
+	interval := fmt.Sprintf("%d minutes", int(width.Minutes()))
+	rows, err := db.conn.Query(query, symbol, interval, timeframe, fromTime, toTime, limit, exchange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resample bars: %w", err)
+	}
+	defer rows.Close()
+
+	bars := []IntradayBar{}
+	for rows.Next() {
+		var bar IntradayBar
+		err := rows.Scan(
+			&bar.BarID,
+			&bar.Exchange,
+			&bar.Symbol,
+			&bar.InstrumentToken,
+			&bar.BarTimestamp,
+			&bar.Timeframe,
+			&bar.Open,
+			&bar.High,
+			&bar.Low,
+			&bar.Close,
+			&bar.Volume,
+			&bar.TradesCount,
+			&bar.VWAP,
+			&bar.OI,
+			&bar.Source,
+			&bar.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan resampled bar: %w", err)
+		}
+		bars = append(bars, bar)
+	}
+
+	return bars, rows.Err()
+}
+
+// GetResampledBarsAfter resamples the page of bars following the cursor, a
+// resampled bar's timestamp. Buckets are whole timeframes apart within a
+// session, so the next one starts a timeframe after the cursor or in a
+// later session.
+func (db *Database) GetResampledBarsAfter(exchange, symbol, timeframe string, after Cursor, toTime time.Time, limit int) ([]IntradayBar, error) {
+	width, err := ParseResampleTimeframe(timeframe)
+	if err != nil {
+		return nil, err
+	}
+	return db.GetResampledBars(exchange, symbol, timeframe, after.Time.Add(width), toTime, limit)
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseResampleTimeframe(t *testing.T) {
+	tests := []struct {
+		timeframe string
+		want      time.Duration
+		wantErr   bool
+	}{
+		{"3m", 3 * time.Minute, false},
+		{"10m", 10 * time.Minute, false},
+		{"2h", 2 * time.Hour, false},
+		{"2H", 2 * time.Hour, false},
+		{"375m", MaxResampleWidth, false},
+		{"376m", 0, true},
+		{"7h", 0, true},
+		{"0m", 0, true},
+		{"-5m", 0, true},
+		{"m", 0, true},
+		{"5", 0, true},
+		{"5d", 0, true},
+		{"1.5h", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.timeframe, func(t *testing.T) {
+			got, err := ParseResampleTimeframe(tt.timeframe)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseResampleTimeframe(%q) error = %v, wantErr %v", tt.timeframe, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseResampleTimeframe(%q) = %v, want %v", tt.timeframe, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetResampledBars(t *testing.T) {
+	db := testDatabase(t)
+	addTestSymbols(t, db, "RESAMPLE")
+
+	// 09:15-09:21 IST: seven 1m bars with close = open + 0.5
+	if err := db.insertIntradayBarsRows(testBars("RESAMPLE", 7)); err != nil {
+		t.Fatalf("failed to insert bars: %v", err)
+	}
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	tests := []struct {
+		timeframe  string
+		wantStarts []string // IST bar starts
+		wantFirst  IntradayBar
+	}{
+		{
+			timeframe:  "3m",
+			wantStarts: []string{"09:15", "09:18", "09:21"},
+			wantFirst:  IntradayBar{Open: 100, High: 103, Low: 99, Close: 102.5, Volume: 3003},
+		},
+		{
+			timeframe:  "5m",
+			wantStarts: []string{"09:15", "09:20"},
+			wantFirst:  IntradayBar{Open: 100, High: 105, Low: 99, Close: 104.5, Volume: 5010},
+		},
+		{
+			timeframe:  "1h",
+			wantStarts: []string{"09:15"},
+			wantFirst:  IntradayBar{Open: 100, High: 107, Low: 99, Close: 106.5, Volume: 7021},
+		},
+	}
+
+	ist := time.FixedZone("IST", 5*3600+1800)
+	for _, tt := range tests {
+		t.Run(tt.timeframe, func(t *testing.T) {
+			bars, err := db.GetResampledBars("TEST", "RESAMPLE", tt.timeframe, from, to, 100)
+			if err != nil {
+				t.Fatalf("GetResampledBars: %v", err)
+			}
+			if len(bars) != len(tt.wantStarts) {
+				t.Fatalf("got %d bars, want %d", len(bars), len(tt.wantStarts))
+			}
+			for i, bar := range bars {
+				if got := bar.BarTimestamp.In(ist).Format("15:04"); got != tt.wantStarts[i] {
+					t.Errorf("bar %d starts at %s, want %s", i, got, tt.wantStarts[i])
+				}
+			}
+
+			first := bars[0]
+			want := tt.wantFirst
+			if first.Open != want.Open || first.High != want.High || first.Low != want.Low ||
+				first.Close != want.Close || first.Volume != want.Volume {
+				t.Errorf("first bar OHLCV = %v %v %v %v %d, want %v %v %v %v %d",
+					first.Open, first.High, first.Low, first.Close, first.Volume,
+					want.Open, want.High, want.Low, want.Close, want.Volume)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Retention run statuses
+const (
+	RetentionRunning   = "running"
+	RetentionCompleted = "completed"
+	RetentionFailed    = "failed"
+	RetentionCancelled = "cancelled"
+)
+
+// RetentionRun is the report of one retention run. In a dry run the counts
+// are the rows that would have been rolled up or deleted.
+type RetentionRun struct {
+	RunID        int64           `json:"run_id" db:"run_id"`
+	Trigger      string          `json:"trigger" db:"trigger"`
+	DryRun       bool            `json:"dry_run" db:"dry_run"`
+	Status       string          `json:"status" db:"status"`
+	Steps        []RetentionStep `json:"steps" db:"steps"`
+	RowsRolledUp int64           `json:"rows_rolled_up" db:"rows_rolled_up"`
+	RowsDeleted  int64           `json:"rows_deleted" db:"rows_deleted"`
+	Error        *string         `json:"error,omitempty" db:"error"`
+	StartedAt    time.Time       `json:"started_at" db:"started_at"`
+	FinishedAt   *time.Time      `json:"finished_at,omitempty" db:"finished_at"`
+}
+
+// RetentionStep is one rollup or delete of a run
+type RetentionStep struct {
+	Action        string    `json:"action"` // rollup, delete
+	Table         string    `json:"table"`
+	FromTimeframe string    `json:"from_timeframe,omitempty"` // Rollup source, empty for ticks
+	Timeframe     string    `json:"timeframe,omitempty"`
+	Before        time.Time `json:"before"`
+	Rows          int64     `json:"rows"`
+	Error         string    `json:"error,omitempty"`
+}
+
+// rollupBuckets maps the timeframes bars are rolled up into to their bucket
+// start. Hourly bars start at :15 like the broker's, daily bars at midnight,
+// both in IST.
+var rollupBuckets = map[string]string{
+	"1h": `(date_trunc('hour', (bar_timestamp AT TIME ZONE 'Asia/Kolkata') - INTERVAL '15 minutes')
+		+ INTERVAL '15 minutes') AT TIME ZONE 'Asia/Kolkata'`,
+	"1d": `date_trunc('day', bar_timestamp AT TIME ZONE 'Asia/Kolkata') AT TIME ZONE 'Asia/Kolkata'`,
+}
+
+// IsRollupTimeframe reports whether bars can be rolled up into timeframe
+func IsRollupTimeframe(timeframe string) bool {
+	_, ok := rollupBuckets[timeframe]
+	return ok
+}
+
+// RollupTicks aggregates ticks older than before into 1m bars where no 1m
+// bar exists yet, returning the bars inserted (or, in a dry run, that would
+// be)
+func (db *Database) RollupTicks(before time.Time, dryRun bool) (int64, error) {
+	aggregate := `
+		SELECT exchange, symbol,
+			MAX(instrument_token) AS instrument_token,
+			date_trunc('minute', tick_timestamp) AS bucket,
+			(array_agg(price ORDER BY tick_timestamp, tick_id))[1] AS open,
+			MAX(price) AS high,
+			MIN(price) AS low,
+			(array_agg(price ORDER BY tick_timestamp DESC, tick_id DESC))[1] AS close,
+			SUM(quantity) AS volume,
+			COUNT(*) AS trades_count,
+			SUM(price * quantity) / NULLIF(SUM(quantity), 0) AS vwap,
+			0 AS oi
+		FROM md.tick_data
+		WHERE tick_timestamp < $1
+		GROUP BY exchange, symbol, date_trunc('minute', tick_timestamp)
+	`
+
+	rows, err := db.rollup(aggregate, "1m", dryRun, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to roll up ticks: %w", err)
+	}
+	return rows, nil
+}
+
+// RollupBars aggregates fromTimeframe bars older than before into timeframe
+// bars where none exist yet, returning the bars inserted (or, in a dry run,
+// that would be). before should be an IST midnight so no bucket is split.
+func (db *Database) RollupBars(fromTimeframe, timeframe string, before time.Time, dryRun bool) (int64, error) {
+	bucket, ok := rollupBuckets[timeframe]
+	if !ok {
+		return 0, fmt.Errorf("cannot roll bars up into timeframe %s", timeframe)
+	}
+
+	aggregate := fmt.Sprintf(`
+		SELECT exchange, symbol,
+			MAX(instrument_token) AS instrument_token,
+			%[1]s AS bucket,
+			(array_agg(open ORDER BY bar_timestamp))[1] AS open,
+			MAX(high) AS high,
+			MIN(low) AS low,
+			(array_agg(close ORDER BY bar_timestamp DESC))[1] AS close,
+			SUM(volume) AS volume,
+			SUM(COALESCE(trades_count, 0)) AS trades_count,
+			SUM(COALESCE(vwap, close) * volume) / NULLIF(SUM(volume), 0) AS vwap,
+			(array_agg(oi ORDER BY bar_timestamp DESC))[1] AS oi
+		FROM md.intraday_bars
+		WHERE timeframe = $2 AND bar_timestamp < $1
+		GROUP BY exchange, symbol, %[1]s
+	`, bucket)
+
+	rows, err := db.rollup(aggregate, timeframe, dryRun, before, fromTimeframe)
+	if err != nil {
+		return 0, fmt.Errorf("failed to roll up %s bars into %s: %w", fromTimeframe, timeframe, err)
+	}
+	return rows, nil
+}
+
+// rollup inserts the bars selected by aggregate as timeframe bars, keeping
+// bars that already exist
+func (db *Database) rollup(aggregate, timeframe string, dryRun bool, args ...interface{}) (int64, error) {
+	timeframeArg := fmt.Sprintf("$%d", len(args)+1)
+	args = append(args, timeframe)
+
+	if dryRun {
+		query := fmt.Sprintf(`
+			WITH agg AS (%s)
+			SELECT COUNT(*)
+			FROM agg a
+			WHERE NOT EXISTS (
+				SELECT 1 FROM md.intraday_bars b
+				WHERE b.exchange = a.exchange AND b.symbol = a.symbol
+				  AND b.timeframe = %s AND b.bar_timestamp = a.bucket
+			)
+		`, aggregate, timeframeArg)
+
+		var count int64
+		err := db.conn.QueryRow(query, args...).Scan(&count)
+		return count, err
+	}
+
+	query := fmt.Sprintf(`
+		WITH agg AS (%s)
+		INSERT INTO md.intraday_bars (
+			exchange, symbol, instrument_token, bar_timestamp, timeframe,
+			open, high, low, close, volume, trades_count, vwap, oi, source
+		)
+		SELECT exchange, symbol, instrument_token, bucket, %s,
+			open, high, low, close, volume, trades_count, vwap, oi, 'retention_rollup'
+		FROM agg
+		ON CONFLICT (exchange, symbol, bar_timestamp, timeframe) DO NOTHING
+	`, aggregate, timeframeArg)
+
+	result, err := db.conn.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DeleteTicksBefore deletes ticks older than before, returning the rows
+// deleted (or, in a dry run, that would be)
+func (db *Database) DeleteTicksBefore(before time.Time, dryRun bool) (int64, error) {
+	if dryRun {
+		var count int64
+		err := db.conn.QueryRow(`SELECT COUNT(*) FROM md.tick_data WHERE tick_timestamp < $1`, before).Scan(&count)
+		if err != nil {
+			return 0, fmt.Errorf("failed to count old ticks: %w", err)
+		}
+		return count, nil
+	}
+
+	result, err := db.conn.Exec(`DELETE FROM md.tick_data WHERE tick_timestamp < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old ticks: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// DeleteBarsBefore deletes timeframe bars older than before, returning the
+// rows deleted (or, in a dry run, that would be)
+func (db *Database) DeleteBarsBefore(timeframe string, before time.Time, dryRun bool) (int64, error) {
+	if dryRun {
+		var count int64
+		err := db.conn.QueryRow(`
+			SELECT COUNT(*) FROM md.intraday_bars WHERE timeframe = $1 AND bar_timestamp < $2
+		`, timeframe, before).Scan(&count)
+		if err != nil {
+			return 0, fmt.Errorf("failed to count old %s bars: %w", timeframe, err)
+		}
+		return count, nil
+	}
+
+	result, err := db.conn.Exec(`
+		DELETE FROM md.intraday_bars WHERE timeframe = $1 AND bar_timestamp < $2
+	`, timeframe, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old %s bars: %w", timeframe, err)
+	}
+	return result.RowsAffected()
+}
+
+// CreateRetentionRun records the start of a run and fills in RunID and StartedAt
+func (db *Database) CreateRetentionRun(run *RetentionRun) error {
+	query := `
+		INSERT INTO md.retention_runs (trigger, dry_run, status)
+		VALUES ($1, $2, $3)
+		RETURNING run_id, started_at
+	`
+
+	if run.Status == "" {
+		run.Status = RetentionRunning
+	}
+
+	err := db.conn.QueryRow(query, run.Trigger, run.DryRun, run.Status).Scan(&run.RunID, &run.StartedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create retention run: %w", err)
+	}
+
+	return nil
+}
+
+// FinishRetentionRun stores the steps, counts and status of a run
+func (db *Database) FinishRetentionRun(run *RetentionRun) error {
+	steps, err := json.Marshal(run.Steps)
+	if err != nil {
+		return fmt.Errorf("failed to encode retention steps: %w", err)
+	}
+
+	query := `
+		UPDATE md.retention_runs
+		SET status = $2, steps = $3, rows_rolled_up = $4, rows_deleted = $5,
+			error = $6, finished_at = NOW()
+		WHERE run_id = $1
+		RETURNING finished_at
+	`
+
+	var finishedAt time.Time
+	err = db.conn.QueryRow(query,
+		run.RunID,
+		run.Status,
+		string(steps),
+		run.RowsRolledUp,
+		run.RowsDeleted,
+		run.Error,
+	).Scan(&finishedAt)
+	if err != nil {
+		return fmt.Errorf("failed to finish retention run: %w", err)
+	}
+
+	run.FinishedAt = &finishedAt
+	return nil
+}
+
+// GetRetentionRuns returns the most recent runs, newest first
+func (db *Database) GetRetentionRuns(limit int) ([]RetentionRun, error) {
+	query := `
+		SELECT run_id, trigger, dry_run, status, steps, rows_rolled_up,
+			rows_deleted, error, started_at, finished_at
+		FROM md.retention_runs
+		ORDER BY started_at DESC
+		LIMIT $1
+	`
+
+	rows, err := db.conn.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query retention runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []RetentionRun{}
+	for rows.Next() {
+		var run RetentionRun
+		var steps []byte
+
+		err := rows.Scan(
+			&run.RunID,
+			&run.Trigger,
+			&run.DryRun,
+			&run.Status,
+			&steps,
+			&run.RowsRolledUp,
+			&run.RowsDeleted,
+			&run.Error,
+			&run.StartedAt,
+			&run.FinishedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan retention run: %w", err)
+		}
+		if err := json.Unmarshal(steps, &run.Steps); err != nil {
+			return nil, fmt.Errorf("failed to decode retention steps: %w", err)
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
@@ -0,0 +1,118 @@
+-- ============================================================================
+-- Trading Chitti - TimescaleDB Integration (optional)
+-- ============================================================================
+--
+-- Apply after migrations/0004_intraday.up.sql on a server with TimescaleDB 2.13+.
+--
+-- Features:
+-- - Hypertables for md.intraday_bars and md.tick_data (existing rows are migrated)
+-- - Automatic compression. Retention is left to the retention job
+--   (RETENTION_ENABLED), which rolls data up before deleting it
+-- - Continuous aggregates of 1m bars into 5m, 15m and 1h bars, which
+--   GetIntradayBars reads transparently when they exist
+--
+-- md.order_book stays a plain table: its primary key doesn't include the
+-- time column, which hypertables require.
+--
+-- ============================================================================
+
+CREATE EXTENSION IF NOT EXISTS timescaledb;
+
+-- ==============================================================================================
+-- HYPERTABLES
+-- ==============================================================================================
+
+SELECT create_hypertable('md.intraday_bars', 'bar_timestamp', chunk_time_interval => INTERVAL '1 day', if_not_exists => TRUE, migrate_data => TRUE);
+SELECT add_compression_policy('md.intraday_bars', compress_after => INTERVAL '7 days', if_not_exists => TRUE);
+-- Drop the retention policy earlier versions of this file added
+SELECT remove_retention_policy('md.intraday_bars', if_exists => TRUE);
+
+SELECT create_hypertable('md.tick_data', 'tick_timestamp', chunk_time_interval => INTERVAL '1 day', if_not_exists => TRUE, migrate_data => TRUE);
+SELECT add_compression_policy('md.tick_data', compress_after => INTERVAL '3 days', if_not_exists => TRUE);
+SELECT remove_retention_policy('md.tick_data', if_exists => TRUE);
+
+-- ==============================================================================================
+-- CONTINUOUS AGGREGATES - 5m, 15m and 1h bars from 1m bars
+-- ==============================================================================================
+--
+-- Real-time aggregation (materialized_only = false) includes the 1m bars
+-- written since the last refresh, so the current bar is always up to date.
+-- Buckets start at 09:15 IST (03:45 UTC): 5m and 15m buckets line up on
+-- their own, 1h buckets are offset by 45 minutes.
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS md.intraday_bars_5m
+WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
+SELECT
+    exchange,
+    symbol,
+    time_bucket(INTERVAL '5 minutes', bar_timestamp) AS bar_timestamp,
+    MAX(instrument_token) AS instrument_token,
+    first(open, bar_timestamp) AS open,
+    MAX(high) AS high,
+    MIN(low) AS low,
+    last(close, bar_timestamp) AS close,
+    SUM(volume) AS volume,
+    SUM(trades_count) AS trades_count,
+    SUM(COALESCE(vwap, close) * volume) / NULLIF(SUM(volume), 0) AS vwap,
+    last(oi, bar_timestamp) AS oi
+FROM md.intraday_bars
+WHERE timeframe = '1m'
+GROUP BY exchange, symbol, time_bucket(INTERVAL '5 minutes', bar_timestamp)
+WITH NO DATA;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS md.intraday_bars_15m
+WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
+SELECT
+    exchange,
+    symbol,
+    time_bucket(INTERVAL '15 minutes', bar_timestamp) AS bar_timestamp,
+    MAX(instrument_token) AS instrument_token,
+    first(open, bar_timestamp) AS open,
+    MAX(high) AS high,
+    MIN(low) AS low,
+    last(close, bar_timestamp) AS close,
+    SUM(volume) AS volume,
+    SUM(trades_count) AS trades_count,
+    SUM(COALESCE(vwap, close) * volume) / NULLIF(SUM(volume), 0) AS vwap,
+    last(oi, bar_timestamp) AS oi
+FROM md.intraday_bars
+WHERE timeframe = '1m'
+GROUP BY exchange, symbol, time_bucket(INTERVAL '15 minutes', bar_timestamp)
+WITH NO DATA;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS md.intraday_bars_1h
+WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
+SELECT
+    exchange,
+    symbol,
+    time_bucket(INTERVAL '1 hour', bar_timestamp, "offset" => INTERVAL '45 minutes') AS bar_timestamp,
+    MAX(instrument_token) AS instrument_token,
+    first(open, bar_timestamp) AS open,
+    MAX(high) AS high,
+    MIN(low) AS low,
+    last(close, bar_timestamp) AS close,
+    SUM(volume) AS volume,
+    SUM(trades_count) AS trades_count,
+    SUM(COALESCE(vwap, close) * volume) / NULLIF(SUM(volume), 0) AS vwap,
+    last(oi, bar_timestamp) AS oi
+FROM md.intraday_bars
+WHERE timeframe = '1m'
+GROUP BY exchange, symbol, time_bucket(INTERVAL '1 hour', bar_timestamp, "offset" => INTERVAL '45 minutes')
+WITH NO DATA;
+
+-- Refresh the last few days regularly; older buckets only change on backfill,
+-- refresh those with CALL refresh_continuous_aggregate(...)
+SELECT add_continuous_aggregate_policy('md.intraday_bars_5m',
+    start_offset => INTERVAL '3 days', end_offset => INTERVAL '5 minutes',
+    schedule_interval => INTERVAL '5 minutes', if_not_exists => TRUE);
+SELECT add_continuous_aggregate_policy('md.intraday_bars_15m',
+    start_offset => INTERVAL '3 days', end_offset => INTERVAL '15 minutes',
+    schedule_interval => INTERVAL '15 minutes', if_not_exists => TRUE);
+SELECT add_continuous_aggregate_policy('md.intraday_bars_1h',
+    start_offset => INTERVAL '3 days', end_offset => INTERVAL '1 hour',
+    schedule_interval => INTERVAL '1 hour', if_not_exists => TRUE);
+
+-- Materialize the existing history once
+CALL refresh_continuous_aggregate('md.intraday_bars_5m', NULL, NULL);
+CALL refresh_continuous_aggregate('md.intraday_bars_15m', NULL, NULL);
+CALL refresh_continuous_aggregate('md.intraday_bars_1h', NULL, NULL);
@@ -0,0 +1,305 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/analyzer"
+)
+
+// DefaultSignalExpiry is how long a stored signal is tracked before it
+// expires at the last price
+const DefaultSignalExpiry = 14 * 24 * time.Hour
+
+// SignalRecord is a stored signal and, once closed, its outcome
+type SignalRecord struct {
+	SignalID    int64      `json:"signal_id" db:"signal_id"`
+	AnalysisID  *int64     `json:"analysis_id,omitempty" db:"analysis_id"`
+	Exchange    string     `json:"exchange" db:"exchange"`
+	Symbol      string     `json:"symbol" db:"symbol"`
+	Type        string     `json:"type" db:"signal_type"`
+	Strategy    string     `json:"strategy" db:"strategy"`
+	Confidence  float64    `json:"confidence" db:"confidence"`
+	EntryPrice  float64    `json:"entry_price" db:"entry_price"`
+	StopLoss    float64    `json:"stop_loss" db:"stop_loss"`
+	TakeProfit  float64    `json:"take_profit" db:"take_profit"`
+	Reason      string     `json:"reason" db:"reason"`
+	GeneratedAt time.Time  `json:"generated_at" db:"generated_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	Outcome     string     `json:"outcome" db:"outcome"`
+	ExitPrice   *float64   `json:"exit_price,omitempty" db:"exit_price"`
+	ExitedAt    *time.Time `json:"exited_at,omitempty" db:"exited_at"`
+	RMultiple   *float64   `json:"r_multiple,omitempty" db:"r_multiple"`
+}
+
+// Signal returns the record as an analyzer signal
+func (r SignalRecord) Signal() analyzer.Signal {
+	return analyzer.Signal{
+		Type:       r.Type,
+		Strategy:   r.Strategy,
+		Confidence: r.Confidence,
+		EntryPrice: r.EntryPrice,
+		StopLoss:   r.StopLoss,
+		TakeProfit: r.TakeProfit,
+		Reason:     r.Reason,
+	}
+}
+
+// SignalFilter selects stored signals. Zero values match everything.
+type SignalFilter struct {
+	Symbol   string
+	Strategy string
+	Outcome  string
+	From     time.Time // Generated at or after
+	To       time.Time // Generated before
+	Limit    int       // 0 for all
+}
+
+// SignalPerformance summarizes the closed signals of a strategy. A win is a
+// closed signal with a positive R multiple, including expired ones.
+type SignalPerformance struct {
+	Strategy      string  `json:"strategy"`
+	Signals       int     `json:"signals"`
+	Open          int     `json:"open"`
+	Closed        int     `json:"closed"`
+	Targets       int     `json:"targets"`
+	Stops         int     `json:"stops"`
+	Expired       int     `json:"expired"`
+	HitRate       float64 `json:"hit_rate"`       // Wins / closed
+	AvgR          float64 `json:"avg_r"`          // Mean R multiple, the expectancy in R
+	AvgWinR       float64 `json:"avg_win_r"`      // Mean R of wins
+	AvgLossR      float64 `json:"avg_loss_r"`     // Mean R of the rest
+	ExpectancyPct float64 `json:"expectancy_pct"` // Mean return per signal, % of entry
+}
+
+// SummarizeSignals computes the performance of each strategy's signals,
+// ordered by strategy, plus the overall performance as strategy "ALL"
+func SummarizeSignals(records []SignalRecord) []SignalPerformance {
+	type totals struct {
+		perf              SignalPerformance
+		sumR, winR, lossR float64
+		wins, losses      int
+		sumReturn         float64
+	}
+	byStrategy := make(map[string]*totals)
+	all := &totals{perf: SignalPerformance{Strategy: "ALL"}}
+
+	for _, r := range records {
+		t, ok := byStrategy[r.Strategy]
+		if !ok {
+			t = &totals{perf: SignalPerformance{Strategy: r.Strategy}}
+			byStrategy[r.Strategy] = t
+		}
+
+		for _, t := range []*totals{t, all} {
+			t.perf.Signals++
+			switch r.Outcome {
+			case analyzer.OutcomeTarget:
+				t.perf.Targets++
+			case analyzer.OutcomeStop:
+				t.perf.Stops++
+			case analyzer.OutcomeExpired:
+				t.perf.Expired++
+			default:
+				t.perf.Open++
+				continue
+			}
+			t.perf.Closed++
+
+			rMultiple := 0.0
+			if r.RMultiple != nil {
+				rMultiple = *r.RMultiple
+			}
+			t.sumR += rMultiple
+			if rMultiple > 0 {
+				t.wins++
+				t.winR += rMultiple
+			} else {
+				t.losses++
+				t.lossR += rMultiple
+			}
+
+			if r.ExitPrice != nil && r.EntryPrice > 0 {
+				direction := 1.0
+				if r.Type == "SELL" {
+					direction = -1
+				}
+				t.sumReturn += direction * (*r.ExitPrice - r.EntryPrice) / r.EntryPrice * 100
+			}
+		}
+	}
+
+	finish := func(t *totals) SignalPerformance {
+		p := t.perf
+		if p.Closed > 0 {
+			p.HitRate = float64(t.wins) / float64(p.Closed)
+			p.AvgR = t.sumR / float64(p.Closed)
+			p.ExpectancyPct = t.sumReturn / float64(p.Closed)
+		}
+		if t.wins > 0 {
+			p.AvgWinR = t.winR / float64(t.wins)
+		}
+		if t.losses > 0 {
+			p.AvgLossR = t.lossR / float64(t.losses)
+		}
+		return p
+	}
+
+	performance := make([]SignalPerformance, 0, len(byStrategy)+1)
+	for _, t := range byStrategy {
+		performance = append(performance, finish(t))
+	}
+	sort.Slice(performance, func(i, j int) bool { return performance[i].Strategy < performance[j].Strategy })
+	return append(performance, finish(all))
+}
+
+// SaveSignals stores an analysis's signals, generated at generatedAt and
+// tracked until expiresAt. analysisID 0 stores them without an analysis.
+func (db *Database) SaveSignals(analysisID int64, exchange, symbol string, signals []analyzer.Signal, generatedAt, expiresAt time.Time) error {
+	if len(signals) == 0 {
+		return nil
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO trades.signals (
+			analysis_id, exchange, symbol, signal_type, strategy, confidence,
+			entry_price, stop_loss, take_profit, reason, generated_at, expires_at
+		) VALUES (NULLIF($1::INTEGER, 0), $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, s := range signals {
+		_, err := stmt.Exec(
+			analysisID,
+			strings.ToUpper(exchange),
+			strings.ToUpper(symbol),
+			s.Type,
+			s.Strategy,
+			s.Confidence,
+			s.EntryPrice,
+			s.StopLoss,
+			s.TakeProfit,
+			s.Reason,
+			generatedAt,
+			expiresAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save %s signal: %w", s.Strategy, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetSignals returns the stored signals matching filter, newest first
+func (db *Database) GetSignals(filter SignalFilter) ([]SignalRecord, error) {
+	query := signalColumns + `
+		WHERE ($1 = '' OR symbol = $1)
+		  AND ($2 = '' OR strategy = $2)
+		  AND ($3 = '' OR outcome = $3)
+		  AND ($4::timestamptz IS NULL OR generated_at >= $4)
+		  AND ($5::timestamptz IS NULL OR generated_at < $5)
+		ORDER BY generated_at DESC, signal_id DESC`
+	args := []interface{}{
+		strings.ToUpper(filter.Symbol),
+		filter.Strategy,
+		strings.ToUpper(filter.Outcome),
+		nullableTime(filter.From),
+		nullableTime(filter.To),
+	}
+	if filter.Limit > 0 {
+		query += "\n\t\tLIMIT $6"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signals: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSignals(rows)
+}
+
+// GetOpenSignals returns up to limit signals without an outcome, oldest
+// first
+func (db *Database) GetOpenSignals(limit int) ([]SignalRecord, error) {
+	rows, err := db.conn.Query(signalColumns+`
+		WHERE outcome = 'OPEN'
+		ORDER BY generated_at, signal_id
+		LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open signals: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSignals(rows)
+}
+
+// CloseSignal records the outcome of a signal
+func (db *Database) CloseSignal(signalID int64, outcome analyzer.SignalOutcome) error {
+	var exitedAt interface{}
+	if !outcome.ExitAt.IsZero() {
+		exitedAt = outcome.ExitAt
+	}
+
+	_, err := db.conn.Exec(`
+		UPDATE trades.signals
+		SET outcome = $2, exit_price = $3, exited_at = $4, r_multiple = $5, evaluated_at = NOW()
+		WHERE signal_id = $1
+	`, signalID, outcome.Outcome, outcome.ExitPrice, exitedAt, outcome.RMultiple)
+	if err != nil {
+		return fmt.Errorf("failed to close signal %d: %w", signalID, err)
+	}
+	return nil
+}
+
+const signalColumns = `
+		SELECT signal_id, analysis_id, exchange, symbol, signal_type, strategy, confidence,
+		       entry_price, COALESCE(stop_loss, 0), COALESCE(take_profit, 0), COALESCE(reason, ''),
+		       generated_at, expires_at, outcome, exit_price, exited_at, r_multiple
+		FROM trades.signals`
+
+func scanSignals(rows *sql.Rows) ([]SignalRecord, error) {
+	records := []SignalRecord{}
+	for rows.Next() {
+		var r SignalRecord
+		err := rows.Scan(
+			&r.SignalID,
+			&r.AnalysisID,
+			&r.Exchange,
+			&r.Symbol,
+			&r.Type,
+			&r.Strategy,
+			&r.Confidence,
+			&r.EntryPrice,
+			&r.StopLoss,
+			&r.TakeProfit,
+			&r.Reason,
+			&r.GeneratedAt,
+			&r.ExpiresAt,
+			&r.Outcome,
+			&r.ExitPrice,
+			&r.ExitedAt,
+			&r.RMultiple,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan signal: %w", err)
+		}
+		records = append(records, r)
+	}
+
+	return records, rows.Err()
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/trading-chitti/market-bridge/internal/analyzer"
+)
+
+func TestSummarizeSignals(t *testing.T) {
+	closed := func(strategy, signalType, outcome string, entry, exit, r float64) SignalRecord {
+		return SignalRecord{Strategy: strategy, Type: signalType, Outcome: outcome, EntryPrice: entry, ExitPrice: &exit, RMultiple: &r}
+	}
+
+	records := []SignalRecord{
+		closed("RSI_OVERSOLD", "BUY", analyzer.OutcomeTarget, 100, 105, 2),
+		closed("RSI_OVERSOLD", "BUY", analyzer.OutcomeStop, 100, 97, -1),
+		closed("RSI_OVERSOLD", "BUY", analyzer.OutcomeExpired, 100, 101, 0.5),
+		{Strategy: "RSI_OVERSOLD", Type: "BUY", Outcome: analyzer.OutcomeOpen, EntryPrice: 100},
+		closed("BOLLINGER_REVERSAL", "SELL", analyzer.OutcomeTarget, 200, 190, 1.5),
+	}
+
+	got := SummarizeSignals(records)
+	want := []SignalPerformance{
+		{
+			Strategy: "BOLLINGER_REVERSAL", Signals: 1, Closed: 1, Targets: 1,
+			HitRate: 1, AvgR: 1.5, AvgWinR: 1.5, ExpectancyPct: 5,
+		},
+		{
+			Strategy: "RSI_OVERSOLD", Signals: 4, Open: 1, Closed: 3, Targets: 1, Stops: 1, Expired: 1,
+			HitRate: 2.0 / 3, AvgR: 0.5, AvgWinR: 1.25, AvgLossR: -1, ExpectancyPct: 1,
+		},
+		{
+			Strategy: "ALL", Signals: 5, Open: 1, Closed: 4, Targets: 2, Stops: 1, Expired: 1,
+			HitRate: 0.75, AvgR: 0.75, AvgWinR: 4.0 / 3, AvgLossR: -1, ExpectancyPct: 2,
+		},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("SummarizeSignals() = %+v, want %+v", got, want)
+	}
+	near := func(a, b float64) bool { return a-b < 1e-9 && b-a < 1e-9 }
+	for i := range got {
+		g, w := got[i], want[i]
+		if g.Strategy != w.Strategy || g.Signals != w.Signals || g.Open != w.Open || g.Closed != w.Closed ||
+			g.Targets != w.Targets || g.Stops != w.Stops || g.Expired != w.Expired ||
+			!near(g.HitRate, w.HitRate) || !near(g.AvgR, w.AvgR) || !near(g.AvgWinR, w.AvgWinR) ||
+			!near(g.AvgLossR, w.AvgLossR) || !near(g.ExpectancyPct, w.ExpectancyPct) {
+			t.Errorf("performance %d = %+v, want %+v", i, g, w)
+		}
+	}
+
+	if got := SummarizeSignals(nil); len(got) != 1 || got[0].Strategy != "ALL" || got[0].Signals != 0 {
+		t.Errorf("SummarizeSignals(nil) = %+v, want only an empty ALL", got)
+	}
+}
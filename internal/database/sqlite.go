@@ -0,0 +1,427 @@
+package database
+
+import (
+	"database/sql"
+	_ "embed"
+	"fmt"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	_ "modernc.org/sqlite"
+)
+
+//go:embed sqlite_schema.sql
+var sqliteSchema string
+
+// istZone is India Standard Time, which has no daylight saving
+var istZone = broker.IST()
+
+// barSteps are the intraday bar intervals; other timeframes are daily
+var barSteps = map[string]time.Duration{
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"1h":  time.Hour,
+}
+
+// SQLiteStore keeps bars, ticks and instruments in a local SQLite file for
+// development and the CLIs. Timestamps are stored as Unix milliseconds.
+type SQLiteStore struct {
+	conn *sql.DB
+}
+
+// NewSQLiteStore opens or creates the SQLite file at path
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	dsn := "file:" + path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	conn, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	// SQLite allows one writer; a single connection queues writes from
+	// concurrent backfill workers instead of failing them as busy
+	conn.SetMaxOpenConns(1)
+
+	if _, err := conn.Exec(sqliteSchema); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create schema: %w", err)
+	}
+
+	return &SQLiteStore{conn: conn}, nil
+}
+
+// Close closes the database file
+func (s *SQLiteStore) Close() error {
+	return s.conn.Close()
+}
+
+// BulkInsertIntradayBars upserts bars in one transaction
+func (s *SQLiteStore) BulkInsertIntradayBars(bars []IntradayBar) error {
+	if len(bars) == 0 {
+		return nil
+	}
+
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO intraday_bars (
+			exchange, symbol, instrument_token, bar_timestamp, timeframe,
+			open, high, low, close, volume, trades_count, vwap, oi, source, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (exchange, symbol, bar_timestamp, timeframe)
+		DO UPDATE SET
+			open = excluded.open,
+			high = excluded.high,
+			low = excluded.low,
+			close = excluded.close,
+			volume = excluded.volume,
+			trades_count = excluded.trades_count,
+			vwap = excluded.vwap,
+			oi = excluded.oi
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	now := time.Now().UnixMilli()
+	for _, bar := range bars {
+		_, err := stmt.Exec(
+			bar.Exchange,
+			bar.Symbol,
+			bar.InstrumentToken,
+			bar.BarTimestamp.UnixMilli(),
+			bar.Timeframe,
+			bar.Open,
+			bar.High,
+			bar.Low,
+			bar.Close,
+			bar.Volume,
+			bar.TradesCount,
+			bar.VWAP,
+			bar.OI,
+			bar.Source,
+			now,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetIntradayBars retrieves intraday bars for a symbol on an exchange,
+// oldest first
+func (s *SQLiteStore) GetIntradayBars(exchange, symbol, timeframe string, fromTime, toTime time.Time, limit int) ([]IntradayBar, error) {
+	rows, err := s.conn.Query(`
+		SELECT
+			bar_id, exchange, symbol, instrument_token, bar_timestamp, timeframe,
+			open, high, low, close, volume, trades_count, vwap, oi, source, created_at
+		FROM intraday_bars
+		WHERE exchange = ?
+		  AND symbol = ?
+		  AND timeframe = ?
+		  AND bar_timestamp >= ?
+		  AND bar_timestamp <= ?
+		ORDER BY bar_timestamp ASC
+		LIMIT ?
+	`, exchange, symbol, timeframe, fromTime.UnixMilli(), toTime.UnixMilli(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bars := []IntradayBar{}
+	for rows.Next() {
+		bar, err := scanSQLiteBar(rows)
+		if err != nil {
+			return nil, err
+		}
+		bars = append(bars, *bar)
+	}
+
+	return bars, rows.Err()
+}
+
+// GetLatestIntradayBar retrieves the most recent bar for a symbol on an exchange
+func (s *SQLiteStore) GetLatestIntradayBar(exchange, symbol, timeframe string) (*IntradayBar, error) {
+	row := s.conn.QueryRow(`
+		SELECT
+			bar_id, exchange, symbol, instrument_token, bar_timestamp, timeframe,
+			open, high, low, close, volume, trades_count, vwap, oi, source, created_at
+		FROM intraday_bars
+		WHERE exchange = ? AND symbol = ? AND timeframe = ?
+		ORDER BY bar_timestamp DESC
+		LIMIT 1
+	`, exchange, symbol, timeframe)
+
+	bar, err := scanSQLiteBar(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return bar, err
+}
+
+func scanSQLiteBar(row interface{ Scan(...interface{}) error }) (*IntradayBar, error) {
+	var bar IntradayBar
+	var token sql.NullInt64
+	var barTimestamp, createdAt int64
+	err := row.Scan(
+		&bar.BarID,
+		&bar.Exchange,
+		&bar.Symbol,
+		&token,
+		&barTimestamp,
+		&bar.Timeframe,
+		&bar.Open,
+		&bar.High,
+		&bar.Low,
+		&bar.Close,
+		&bar.Volume,
+		&bar.TradesCount,
+		&bar.VWAP,
+		&bar.OI,
+		&bar.Source,
+		&createdAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	bar.InstrumentToken = token.Int64
+	bar.BarTimestamp = time.UnixMilli(barTimestamp)
+	bar.CreatedAt = time.UnixMilli(createdAt)
+	return &bar, nil
+}
+
+// GetDataGaps identifies missing bars in market hours, generating the
+// expected bars in Go with the same rules as expectedBarsSQL
+func (s *SQLiteStore) GetDataGaps(exchange, symbol, timeframe string, startTime, endTime time.Time) ([]map[string]interface{}, error) {
+	rows, err := s.conn.Query(`
+		SELECT bar_timestamp FROM intraday_bars
+		WHERE exchange = ? AND symbol = ? AND timeframe = ? AND bar_timestamp BETWEEN ? AND ?
+	`, exchange, symbol, timeframe, startTime.UnixMilli(), endTime.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	present := make(map[int64]bool)
+	for rows.Next() {
+		var ts int64
+		if err := rows.Scan(&ts); err != nil {
+			return nil, err
+		}
+		present[ts] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	gaps := []map[string]interface{}{}
+	for _, expected := range expectedBarTimes(timeframe, startTime, endTime) {
+		if present[expected.UnixMilli()] {
+			continue
+		}
+		gaps = append(gaps, map[string]interface{}{
+			"missing_timestamp": expected,
+			"exchange":          exchange,
+			"symbol":            symbol,
+			"timeframe":         timeframe,
+		})
+	}
+
+	return gaps, nil
+}
+
+// expectedBarTimes returns the bar starts between start and end that
+// expectedBarsSQL generates: each step of the 09:15-15:30 IST session on
+// trading days, or IST midnight of each trading day for daily bars
+func expectedBarTimes(timeframe string, start, end time.Time) []time.Time {
+	step, intraday := barSteps[timeframe]
+
+	var times []time.Time
+	first := start.In(istZone)
+	day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, istZone)
+	for ; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if !broker.IsIndianTradingDay(day) {
+			continue
+		}
+
+		if !intraday {
+			if !day.Before(start) {
+				times = append(times, day)
+			}
+			continue
+		}
+
+		open := day.Add(9*time.Hour + 15*time.Minute)
+		sessionEnd := day.Add(15*time.Hour + 30*time.Minute)
+		for t := open; t.Before(sessionEnd); t = t.Add(step) {
+			if !t.Before(start) && !t.After(end) {
+				times = append(times, t)
+			}
+		}
+	}
+
+	return times
+}
+
+// BulkInsertTickData inserts ticks in one transaction
+func (s *SQLiteStore) BulkInsertTickData(ticks []TickData) error {
+	if len(ticks) == 0 {
+		return nil
+	}
+
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO tick_data (
+			exchange, symbol, instrument_token, tick_timestamp,
+			price, quantity, trade_type, source, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	now := time.Now().UnixMilli()
+	for _, tick := range ticks {
+		_, err := stmt.Exec(
+			tick.Exchange,
+			tick.Symbol,
+			tick.InstrumentToken,
+			tick.TickTimestamp.UnixMilli(),
+			tick.Price,
+			tick.Quantity,
+			tick.TradeType,
+			tick.Source,
+			now,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetTickData retrieves tick data for a symbol on an exchange, oldest first
+func (s *SQLiteStore) GetTickData(exchange, symbol string, fromTime, toTime time.Time, limit int) ([]TickData, error) {
+	rows, err := s.conn.Query(`
+		SELECT
+			tick_id, exchange, symbol, instrument_token, tick_timestamp,
+			price, quantity, trade_type, source, created_at
+		FROM tick_data
+		WHERE exchange = ?
+		  AND symbol = ?
+		  AND tick_timestamp >= ?
+		  AND tick_timestamp <= ?
+		ORDER BY tick_timestamp ASC
+		LIMIT ?
+	`, exchange, symbol, fromTime.UnixMilli(), toTime.UnixMilli(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ticks := []TickData{}
+	for rows.Next() {
+		var tick TickData
+		var token sql.NullInt64
+		var tickTimestamp, createdAt int64
+		err := rows.Scan(
+			&tick.TickID,
+			&tick.Exchange,
+			&tick.Symbol,
+			&token,
+			&tickTimestamp,
+			&tick.Price,
+			&tick.Quantity,
+			&tick.TradeType,
+			&tick.Source,
+			&createdAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		tick.InstrumentToken = token.Int64
+		tick.TickTimestamp = time.UnixMilli(tickTimestamp)
+		tick.CreatedAt = time.UnixMilli(createdAt)
+		ticks = append(ticks, tick)
+	}
+
+	return ticks, rows.Err()
+}
+
+// UpsertInstrument inserts or updates an instrument
+func (s *SQLiteStore) UpsertInstrument(inst Instrument) error {
+	var expiry interface{}
+	if inst.Expiry != nil {
+		expiry = inst.Expiry.Format("2006-01-02")
+	}
+
+	_, err := s.conn.Exec(`
+		INSERT INTO instruments (
+			instrument_token, exchange_token, tradingsymbol, name, exchange,
+			segment, instrument_type, isin, expiry, strike, tick_size, lot_size,
+			last_price, last_updated
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (exchange, tradingsymbol)
+		DO UPDATE SET
+			instrument_token = excluded.instrument_token,
+			exchange_token = excluded.exchange_token,
+			name = excluded.name,
+			segment = excluded.segment,
+			instrument_type = excluded.instrument_type,
+			isin = COALESCE(NULLIF(excluded.isin, ''), instruments.isin),
+			expiry = excluded.expiry,
+			strike = excluded.strike,
+			tick_size = excluded.tick_size,
+			lot_size = excluded.lot_size,
+			last_price = excluded.last_price,
+			last_updated = excluded.last_updated
+	`,
+		inst.InstrumentToken,
+		inst.ExchangeToken,
+		inst.Tradingsymbol,
+		inst.Name,
+		inst.Exchange,
+		inst.Segment,
+		inst.InstrumentType,
+		inst.ISIN,
+		expiry,
+		inst.Strike,
+		inst.TickSize,
+		inst.LotSize,
+		inst.LastPrice,
+		inst.LastUpdated.UnixMilli(),
+	)
+
+	return err
+}
+
+// GetInstrumentToken returns instrument token for a given exchange and
+// symbol, or 0 when it isn't known
+func (s *SQLiteStore) GetInstrumentToken(exchange, symbol string) (uint32, error) {
+	var token uint32
+	err := s.conn.QueryRow(`
+		SELECT instrument_token FROM instruments
+		WHERE exchange = ? AND tradingsymbol = ?
+	`, exchange, symbol).Scan(&token)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+
+	return token, err
+}
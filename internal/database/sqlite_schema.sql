@@ -0,0 +1,64 @@
+-- ============================================================================
+-- Trading Chitti - SQLite schema for local development
+-- ============================================================================
+--
+-- The bar, tick and instrument tables of md.intraday_bars, md.tick_data and
+-- trades.instruments, without the PostgreSQL schemas. Timestamps are Unix
+-- milliseconds. Applied by NewSQLiteStore every time a file is opened.
+--
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS intraday_bars (
+    bar_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    exchange TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    instrument_token INTEGER,
+    bar_timestamp INTEGER NOT NULL,
+    timeframe TEXT NOT NULL CHECK (timeframe IN ('1m', '5m', '15m', '1h', '1d')),
+    open REAL NOT NULL,
+    high REAL NOT NULL,
+    low REAL NOT NULL,
+    close REAL NOT NULL,
+    volume INTEGER NOT NULL DEFAULT 0,
+    trades_count INTEGER DEFAULT 0,
+    vwap REAL,
+    oi INTEGER DEFAULT 0,
+    source TEXT NOT NULL DEFAULT 'collector',
+    created_at INTEGER NOT NULL,
+    UNIQUE (exchange, symbol, bar_timestamp, timeframe)
+);
+
+CREATE INDEX IF NOT EXISTS idx_intraday_bars_symbol_time ON intraday_bars (symbol, timeframe, bar_timestamp);
+
+CREATE TABLE IF NOT EXISTS tick_data (
+    tick_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    exchange TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    instrument_token INTEGER,
+    tick_timestamp INTEGER NOT NULL,
+    price REAL NOT NULL,
+    quantity INTEGER NOT NULL,
+    trade_type TEXT CHECK (trade_type IN ('buy', 'sell', 'unknown')),
+    source TEXT NOT NULL DEFAULT 'collector',
+    created_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_tick_data_symbol_time ON tick_data (symbol, tick_timestamp);
+
+CREATE TABLE IF NOT EXISTS instruments (
+    instrument_token INTEGER PRIMARY KEY,
+    exchange_token INTEGER,
+    tradingsymbol TEXT NOT NULL,
+    name TEXT,
+    exchange TEXT NOT NULL,
+    segment TEXT,
+    instrument_type TEXT,
+    isin TEXT,
+    expiry TEXT, -- YYYY-MM-DD
+    strike REAL,
+    tick_size REAL,
+    lot_size INTEGER,
+    last_price REAL,
+    last_updated INTEGER,
+    UNIQUE (exchange, tradingsymbol)
+);
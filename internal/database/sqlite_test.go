@@ -0,0 +1,285 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testSQLiteStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestSQLiteStoreBars(t *testing.T) {
+	store := testSQLiteStore(t)
+
+	bars := testBars("RELIANCE", 5)
+	if err := store.BulkInsertIntradayBars(bars); err != nil {
+		t.Fatalf("BulkInsertIntradayBars: %v", err)
+	}
+
+	// Re-inserting a bar updates it in place
+	updated := bars[2]
+	updated.Close = 999
+	if err := store.BulkInsertIntradayBars([]IntradayBar{updated}); err != nil {
+		t.Fatalf("BulkInsertIntradayBars update: %v", err)
+	}
+
+	got, err := store.GetIntradayBars("TEST", "RELIANCE", "1m", bars[0].BarTimestamp, bars[4].BarTimestamp, 100)
+	if err != nil {
+		t.Fatalf("GetIntradayBars: %v", err)
+	}
+	if len(got) != 5 {
+		t.Fatalf("got %d bars, want 5", len(got))
+	}
+	for i, bar := range got {
+		if !bar.BarTimestamp.Equal(bars[i].BarTimestamp) {
+			t.Errorf("bar %d at %v, want %v", i, bar.BarTimestamp, bars[i].BarTimestamp)
+		}
+	}
+	if got[2].Close != 999 {
+		t.Errorf("updated close = %v, want 999", got[2].Close)
+	}
+	if got[0].Open != bars[0].Open || got[0].Volume != bars[0].Volume || got[0].Source != bars[0].Source {
+		t.Errorf("bar = %+v, want %+v", got[0], bars[0])
+	}
+
+	latest, err := store.GetLatestIntradayBar("TEST", "RELIANCE", "1m")
+	if err != nil {
+		t.Fatalf("GetLatestIntradayBar: %v", err)
+	}
+	if latest == nil || !latest.BarTimestamp.Equal(bars[4].BarTimestamp) {
+		t.Errorf("latest bar = %+v, want %v", latest, bars[4].BarTimestamp)
+	}
+
+	missing, err := store.GetLatestIntradayBar("TEST", "TCS", "1m")
+	if err != nil || missing != nil {
+		t.Errorf("GetLatestIntradayBar without bars = %+v, %v; want nil, nil", missing, err)
+	}
+}
+
+func TestSQLiteStoreTicks(t *testing.T) {
+	store := testSQLiteStore(t)
+
+	start := time.Date(2024, 1, 1, 3, 45, 0, 0, time.UTC)
+	ticks := []TickData{
+		{Exchange: "NSE", Symbol: "INFY", TickTimestamp: start.Add(1500 * time.Millisecond), Price: 1500.5, Quantity: 10, TradeType: "buy", Source: "test"},
+		{Exchange: "NSE", Symbol: "INFY", TickTimestamp: start, Price: 1500, Quantity: 5, TradeType: "sell", Source: "test"},
+		{Exchange: "NSE", Symbol: "TCS", TickTimestamp: start, Price: 4000, Quantity: 1, TradeType: "unknown", Source: "test"},
+	}
+	if err := store.BulkInsertTickData(ticks); err != nil {
+		t.Fatalf("BulkInsertTickData: %v", err)
+	}
+
+	got, err := store.GetTickData("NSE", "INFY", start, start.Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("GetTickData: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d ticks, want 2", len(got))
+	}
+	if got[0].Price != 1500 || !got[1].TickTimestamp.Equal(ticks[0].TickTimestamp) {
+		t.Errorf("ticks = %+v, want oldest first with millisecond timestamps", got)
+	}
+}
+
+func TestSQLiteStoreSeparatesExchanges(t *testing.T) {
+	store := testSQLiteStore(t)
+
+	// RELIANCE trades on both exchanges; BSE has one bar fewer
+	var bars []IntradayBar
+	var ticks []TickData
+	for exchange, n := range map[string]int{"NSE": 3, "BSE": 2} {
+		for _, bar := range testBars("RELIANCE", n) {
+			bar.Exchange = exchange
+			if exchange == "BSE" {
+				bar.Close += 0.25
+			}
+			bars = append(bars, bar)
+			ticks = append(ticks, TickData{
+				Exchange: exchange, Symbol: "RELIANCE", TickTimestamp: bar.BarTimestamp,
+				Price: bar.Close, Quantity: 1, TradeType: "unknown", Source: "test",
+			})
+		}
+	}
+	if err := store.BulkInsertIntradayBars(bars); err != nil {
+		t.Fatalf("BulkInsertIntradayBars: %v", err)
+	}
+	if err := store.BulkInsertTickData(ticks); err != nil {
+		t.Fatalf("BulkInsertTickData: %v", err)
+	}
+
+	start := bars[0].BarTimestamp
+	end := start.Add(2 * time.Minute)
+	tests := []struct {
+		exchange  string
+		wantBars  int
+		wantClose float64 // Of the latest bar
+		wantGaps  int
+	}{
+		{exchange: "NSE", wantBars: 3, wantClose: 102.5},
+		{exchange: "BSE", wantBars: 2, wantClose: 101.75, wantGaps: 1},
+		{exchange: "NFO"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.exchange, func(t *testing.T) {
+			got, err := store.GetIntradayBars(tt.exchange, "RELIANCE", "1m", start, end, 100)
+			if err != nil {
+				t.Fatalf("GetIntradayBars: %v", err)
+			}
+			if len(got) != tt.wantBars {
+				t.Errorf("got %d bars, want %d", len(got), tt.wantBars)
+			}
+			for _, bar := range got {
+				if bar.Exchange != tt.exchange {
+					t.Errorf("bar from %s, want %s", bar.Exchange, tt.exchange)
+				}
+			}
+
+			latest, err := store.GetLatestIntradayBar(tt.exchange, "RELIANCE", "1m")
+			if err != nil {
+				t.Fatalf("GetLatestIntradayBar: %v", err)
+			}
+			switch {
+			case tt.wantBars == 0 && latest != nil:
+				t.Errorf("latest bar = %+v, want none", latest)
+			case tt.wantBars > 0 && (latest == nil || latest.Close != tt.wantClose):
+				t.Errorf("latest bar = %+v, want close %v", latest, tt.wantClose)
+			}
+
+			gotTicks, err := store.GetTickData(tt.exchange, "RELIANCE", start, end, 100)
+			if err != nil {
+				t.Fatalf("GetTickData: %v", err)
+			}
+			if len(gotTicks) != tt.wantBars {
+				t.Errorf("got %d ticks, want %d", len(gotTicks), tt.wantBars)
+			}
+
+			if tt.wantBars == 0 {
+				return
+			}
+			gaps, err := store.GetDataGaps(tt.exchange, "RELIANCE", "1m", start, end)
+			if err != nil {
+				t.Fatalf("GetDataGaps: %v", err)
+			}
+			if len(gaps) != tt.wantGaps {
+				t.Errorf("got %d gaps, want %d: %v", len(gaps), tt.wantGaps, gaps)
+			}
+		})
+	}
+}
+
+func TestSQLiteStoreInstruments(t *testing.T) {
+	store := testSQLiteStore(t)
+
+	expiry := time.Date(2024, 1, 25, 0, 0, 0, 0, time.UTC)
+	instruments := []Instrument{
+		{InstrumentToken: 738561, Tradingsymbol: "RELIANCE", Exchange: "NSE", LastUpdated: time.Now()},
+		{InstrumentToken: 128083204, Tradingsymbol: "RELIANCE", Exchange: "BSE", LastUpdated: time.Now()},
+		{InstrumentToken: 12345, Tradingsymbol: "NIFTY24JANFUT", Exchange: "NFO", Expiry: &expiry, LotSize: 50, LastUpdated: time.Now()},
+		// A new token for a listed symbol replaces the old one
+		{InstrumentToken: 738562, Tradingsymbol: "RELIANCE", Exchange: "NSE", LastUpdated: time.Now()},
+	}
+	for _, inst := range instruments {
+		if err := store.UpsertInstrument(inst); err != nil {
+			t.Fatalf("UpsertInstrument %s: %v", inst.Tradingsymbol, err)
+		}
+	}
+
+	tests := []struct {
+		exchange, symbol string
+		want             uint32
+	}{
+		{"NSE", "RELIANCE", 738562},
+		{"BSE", "RELIANCE", 128083204},
+		{"NFO", "NIFTY24JANFUT", 12345},
+		{"NSE", "UNKNOWN", 0},
+	}
+	for _, tt := range tests {
+		token, err := store.GetInstrumentToken(tt.exchange, tt.symbol)
+		if err != nil {
+			t.Fatalf("GetInstrumentToken %s:%s: %v", tt.exchange, tt.symbol, err)
+		}
+		if token != tt.want {
+			t.Errorf("GetInstrumentToken %s:%s = %d, want %d", tt.exchange, tt.symbol, token, tt.want)
+		}
+	}
+}
+
+func TestExpectedBarTimes(t *testing.T) {
+	ist := time.FixedZone("IST", 5*3600+1800)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 1, day, hour, minute, 0, 0, ist)
+	}
+
+	tests := []struct {
+		name       string
+		timeframe  string
+		start, end time.Time
+		wantCount  int
+		wantFirst  time.Time
+		wantLast   time.Time
+	}{
+		// Monday 2024-01-01 is a trading day: 375 one-minute bars
+		{"1m session", "1m", at(1, 0, 0), at(1, 23, 59), 375, at(1, 9, 15), at(1, 15, 29)},
+		{"5m session", "5m", at(1, 0, 0), at(1, 23, 59), 75, at(1, 9, 15), at(1, 15, 25)},
+		{"1h session", "1h", at(1, 0, 0), at(1, 23, 59), 7, at(1, 9, 15), at(1, 15, 15)},
+		{"clipped to range", "15m", at(1, 10, 0), at(1, 11, 0), 5, at(1, 10, 0), at(1, 11, 0)},
+		// 2024-01-06 and 07 are a weekend
+		{"weekend", "1m", at(6, 0, 0), at(7, 23, 59), 0, time.Time{}, time.Time{}},
+		// Republic Day, 2024-01-26, is a holiday
+		{"holiday", "1h", at(26, 0, 0), at(26, 23, 59), 0, time.Time{}, time.Time{}},
+		// Mon 01-01 to Sun 01-07: five trading days
+		{"daily bars", "1d", at(1, 0, 0), at(7, 0, 0), 5, at(1, 0, 0), at(5, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			times := expectedBarTimes(tt.timeframe, tt.start, tt.end)
+			if len(times) != tt.wantCount {
+				t.Fatalf("got %d bars, want %d", len(times), tt.wantCount)
+			}
+			if tt.wantCount == 0 {
+				return
+			}
+			if !times[0].Equal(tt.wantFirst) || !times[len(times)-1].Equal(tt.wantLast) {
+				t.Errorf("bars %v to %v, want %v to %v", times[0], times[len(times)-1], tt.wantFirst, tt.wantLast)
+			}
+		})
+	}
+}
+
+func TestSQLiteStoreDataGaps(t *testing.T) {
+	store := testSQLiteStore(t)
+
+	// testBars starts at the 09:15 IST open; leave out the third bar
+	bars := testBars("SBIN", 5)
+	bars = append(bars[:2], bars[3:]...)
+	if err := store.BulkInsertIntradayBars(bars); err != nil {
+		t.Fatalf("BulkInsertIntradayBars: %v", err)
+	}
+
+	start := bars[0].BarTimestamp
+	gaps, err := store.GetDataGaps("TEST", "SBIN", "1m", start, start.Add(5*time.Minute))
+	if err != nil {
+		t.Fatalf("GetDataGaps: %v", err)
+	}
+
+	want := []time.Time{start.Add(2 * time.Minute), start.Add(5 * time.Minute)}
+	if len(gaps) != len(want) {
+		t.Fatalf("got %d gaps, want %d: %v", len(gaps), len(want), gaps)
+	}
+	for i, gap := range gaps {
+		if ts, _ := gap["missing_timestamp"].(time.Time); !ts.Equal(want[i]) {
+			t.Errorf("gap %d at %v, want %v", i, ts, want[i])
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"time"
+)
+
+// Store is the bar, tick and instrument storage the backfill and import
+// CLIs need. *Database implements it on PostgreSQL and *SQLiteStore on a
+// local file, so the CLIs run without a PostgreSQL instance.
+type Store interface {
+	BulkInsertIntradayBars(bars []IntradayBar) error
+	GetIntradayBars(exchange, symbol, timeframe string, fromTime, toTime time.Time, limit int) ([]IntradayBar, error)
+	GetLatestIntradayBar(exchange, symbol, timeframe string) (*IntradayBar, error)
+	GetDataGaps(exchange, symbol, timeframe string, startTime, endTime time.Time) ([]map[string]interface{}, error)
+
+	BulkInsertTickData(ticks []TickData) error
+	GetTickData(exchange, symbol string, fromTime, toTime time.Time, limit int) ([]TickData, error)
+
+	UpsertInstrument(inst Instrument) error
+	GetInstrumentToken(exchange, symbol string) (uint32, error)
+
+	Close() error
+}
+
+var (
+	_ Store = (*Database)(nil)
+	_ Store = (*SQLiteStore)(nil)
+)
+
+// DefaultSQLitePath is where the CLIs keep their SQLite store without a
+// PostgreSQL DSN
+const DefaultSQLitePath = "./market_bridge.db"
+
+// OpenStore opens PostgreSQL at dsn, or the SQLite file at sqlitePath when
+// dsn is empty
+func OpenStore(dsn, sqlitePath string) (Store, error) {
+	if dsn == "" {
+		return NewSQLiteStore(sqlitePath)
+	}
+
+	db, err := NewDatabase(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
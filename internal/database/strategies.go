@@ -0,0 +1,276 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrStrategyExists is returned when a user already has a strategy with the same name
+var ErrStrategyExists = errors.New("strategy with this name already exists")
+
+// StrategyRecord is a stored strategy definition. UserID is nil for
+// strategies created in single-user mode.
+type StrategyRecord struct {
+	StrategyID  string          `json:"strategy_id" db:"strategy_id"`
+	UserID      *string         `json:"user_id,omitempty" db:"user_id"`
+	Name        string          `json:"name" db:"name"`
+	Description string          `json:"description" db:"description"`
+	Definition  json.RawMessage `json:"definition" db:"definition"`
+	IsActive    bool            `json:"is_active" db:"is_active"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// StrategySignal is a signal emitted by a strategy scan or live run
+type StrategySignal struct {
+	SignalID     int64     `json:"signal_id" db:"signal_id"`
+	StrategyID   string    `json:"strategy_id" db:"strategy_id"`
+	Mode         string    `json:"mode" db:"mode"`
+	Exchange     string    `json:"exchange" db:"exchange"`
+	Symbol       string    `json:"symbol" db:"symbol"`
+	Action       string    `json:"action" db:"action"`
+	Price        float64   `json:"price" db:"price"`
+	BarTimestamp time.Time `json:"bar_timestamp" db:"bar_timestamp"`
+	Reason       string    `json:"reason" db:"reason"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// nullableUserID maps the single-user "" owner to NULL
+func nullableUserID(userID string) interface{} {
+	if userID == "" {
+		return nil
+	}
+	return userID
+}
+
+// CreateStrategy stores a new strategy and fills in its ID and timestamps
+func (db *Database) CreateStrategy(userID string, record *StrategyRecord) error {
+	query := `
+		INSERT INTO strategies.definitions (user_id, name, description, definition, is_active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING strategy_id, user_id, created_at, updated_at
+	`
+
+	err := db.conn.QueryRow(query,
+		nullableUserID(userID),
+		record.Name,
+		record.Description,
+		[]byte(record.Definition),
+		record.IsActive,
+	).Scan(&record.StrategyID, &record.UserID, &record.CreatedAt, &record.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrStrategyExists
+		}
+		return fmt.Errorf("failed to create strategy: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateStrategy replaces a strategy's name, description, definition and
+// active flag. Returns sql.ErrNoRows if the user doesn't own the strategy.
+func (db *Database) UpdateStrategy(userID string, record *StrategyRecord) error {
+	query := `
+		UPDATE strategies.definitions
+		SET name = $3, description = $4, definition = $5, is_active = $6, updated_at = NOW()
+		WHERE strategy_id = $1 AND user_id IS NOT DISTINCT FROM $2
+		RETURNING user_id, created_at, updated_at
+	`
+
+	err := db.conn.QueryRow(query,
+		record.StrategyID,
+		nullableUserID(userID),
+		record.Name,
+		record.Description,
+		[]byte(record.Definition),
+		record.IsActive,
+	).Scan(&record.UserID, &record.CreatedAt, &record.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return err
+	}
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrStrategyExists
+		}
+		return fmt.Errorf("failed to update strategy: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteStrategy deletes a strategy and its signals. Returns sql.ErrNoRows if
+// the user doesn't own the strategy.
+func (db *Database) DeleteStrategy(userID, strategyID string) error {
+	result, err := db.conn.Exec(`
+		DELETE FROM strategies.definitions
+		WHERE strategy_id = $1 AND user_id IS NOT DISTINCT FROM $2
+	`, strategyID, nullableUserID(userID))
+	if err != nil {
+		return fmt.Errorf("failed to delete strategy: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete strategy: %w", err)
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetStrategy returns one of a user's strategies, or nil if not found
+func (db *Database) GetStrategy(userID, strategyID string) (*StrategyRecord, error) {
+	query := `
+		SELECT strategy_id, user_id, name, COALESCE(description, ''), definition,
+		       is_active, created_at, updated_at
+		FROM strategies.definitions
+		WHERE strategy_id = $1 AND user_id IS NOT DISTINCT FROM $2
+	`
+
+	record, err := scanStrategy(db.conn.QueryRow(query, strategyID, nullableUserID(userID)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get strategy: %w", err)
+	}
+
+	return record, nil
+}
+
+// GetStrategies lists a user's strategies by name
+func (db *Database) GetStrategies(userID string) ([]StrategyRecord, error) {
+	query := `
+		SELECT strategy_id, user_id, name, COALESCE(description, ''), definition,
+		       is_active, created_at, updated_at
+		FROM strategies.definitions
+		WHERE user_id IS NOT DISTINCT FROM $1
+		ORDER BY name
+	`
+
+	rows, err := db.conn.Query(query, nullableUserID(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get strategies: %w", err)
+	}
+	defer rows.Close()
+
+	records := []StrategyRecord{}
+	for rows.Next() {
+		record, err := scanStrategy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan strategy: %w", err)
+		}
+		records = append(records, *record)
+	}
+
+	return records, rows.Err()
+}
+
+func scanStrategy(row rowScanner) (*StrategyRecord, error) {
+	var record StrategyRecord
+	var definition []byte
+
+	err := row.Scan(
+		&record.StrategyID,
+		&record.UserID,
+		&record.Name,
+		&record.Description,
+		&definition,
+		&record.IsActive,
+		&record.CreatedAt,
+		&record.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	record.Definition = json.RawMessage(definition)
+	return &record, nil
+}
+
+// InsertStrategySignal stores a signal. A repeated signal for the same bar is
+// ignored; the returned bool reports whether the signal was new.
+func (db *Database) InsertStrategySignal(signal *StrategySignal) (bool, error) {
+	query := `
+		INSERT INTO strategies.signals
+			(strategy_id, mode, exchange, symbol, action, price, bar_timestamp, reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (strategy_id, mode, symbol, bar_timestamp) DO NOTHING
+		RETURNING signal_id, created_at
+	`
+
+	err := db.conn.QueryRow(query,
+		signal.StrategyID,
+		signal.Mode,
+		signal.Exchange,
+		signal.Symbol,
+		signal.Action,
+		signal.Price,
+		signal.BarTimestamp,
+		signal.Reason,
+	).Scan(&signal.SignalID, &signal.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to insert strategy signal: %w", err)
+	}
+
+	return true, nil
+}
+
+// GetStrategySignals returns a strategy's most recent signals, optionally
+// filtered by mode
+func (db *Database) GetStrategySignals(strategyID, mode string, limit int) ([]StrategySignal, error) {
+	query := `
+		SELECT signal_id, strategy_id, mode, exchange, symbol, action, price,
+		       bar_timestamp, COALESCE(reason, ''), created_at
+		FROM strategies.signals
+		WHERE strategy_id = $1 AND ($2 = '' OR mode = $2)
+		ORDER BY bar_timestamp DESC, signal_id DESC
+		LIMIT $3
+	`
+
+	rows, err := db.conn.Query(query, strategyID, mode, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get strategy signals: %w", err)
+	}
+	defer rows.Close()
+
+	signals := []StrategySignal{}
+	for rows.Next() {
+		var s StrategySignal
+		err := rows.Scan(
+			&s.SignalID,
+			&s.StrategyID,
+			&s.Mode,
+			&s.Exchange,
+			&s.Symbol,
+			&s.Action,
+			&s.Price,
+			&s.BarTimestamp,
+			&s.Reason,
+			&s.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan strategy signal: %w", err)
+		}
+		signals = append(signals, s)
+	}
+
+	return signals, rows.Err()
+}
+
+// isUniqueViolation reports whether err is a Postgres unique constraint error
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,261 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SymbolChange is a rename of a tradingsymbol, effective from the first
+// session traded under the new one
+type SymbolChange struct {
+	ChangeID      int64     `json:"change_id" db:"change_id"`
+	Exchange      string    `json:"exchange" db:"exchange"`
+	OldSymbol     string    `json:"old_symbol" db:"old_symbol"`
+	NewSymbol     string    `json:"new_symbol" db:"new_symbol"`
+	ISIN          string    `json:"isin,omitempty" db:"isin"`
+	EffectiveDate time.Time `json:"effective_date" db:"effective_date"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// SymbolSegment is a stretch of a renamed instrument's history traded under
+// one symbol
+type SymbolSegment struct {
+	Symbol string    `json:"symbol"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+}
+
+// ValidateISIN checks an ISIN's format and check digit: two letters of
+// country, nine alphanumerics and a Luhn digit over their digit expansion
+func ValidateISIN(isin string) error {
+	if len(isin) != 12 {
+		return fmt.Errorf("invalid ISIN %q: must be 12 characters", isin)
+	}
+	if !isUpperLetter(isin[0]) || !isUpperLetter(isin[1]) {
+		return fmt.Errorf("invalid ISIN %q: must start with a country code", isin)
+	}
+
+	// Letters count as two digits, A=10 to Z=35
+	var digits []int
+	for i := 0; i < 11; i++ {
+		switch ch := isin[i]; {
+		case ch >= '0' && ch <= '9':
+			digits = append(digits, int(ch-'0'))
+		case isUpperLetter(ch):
+			value := int(ch-'A') + 10
+			digits = append(digits, value/10, value%10)
+		default:
+			return fmt.Errorf("invalid ISIN %q: must be alphanumeric", isin)
+		}
+	}
+	if isin[11] < '0' || isin[11] > '9' {
+		return fmt.Errorf("invalid ISIN %q: must end in a check digit", isin)
+	}
+
+	// Luhn, doubling every other digit from the rightmost
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := digits[i]
+		if (len(digits)-1-i)%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	if check := (10 - sum%10) % 10; int(isin[11]-'0') != check {
+		return fmt.Errorf("invalid ISIN %q: check digit should be %d", isin, check)
+	}
+	return nil
+}
+
+func isUpperLetter(ch byte) bool {
+	return ch >= 'A' && ch <= 'Z'
+}
+
+// SymbolSegments splits from..to by the symbol traded in each stretch,
+// given the renames leading to symbol. Segments end a second before the
+// IST start of the next one's effective date.
+func SymbolSegments(symbol string, changes []SymbolChange, from, to time.Time) []SymbolSegment {
+	changes = append([]SymbolChange(nil), changes...)
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].EffectiveDate.Before(changes[j].EffectiveDate)
+	})
+
+	var segments []SymbolSegment
+	start := from
+	for _, change := range changes {
+		effective := time.Date(change.EffectiveDate.Year(), change.EffectiveDate.Month(), change.EffectiveDate.Day(), 0, 0, 0, 0, istZone)
+		if !effective.After(start) {
+			continue
+		}
+		if effective.After(to) {
+			segments = append(segments, SymbolSegment{Symbol: change.OldSymbol, From: start, To: to})
+			return segments
+		}
+		segments = append(segments, SymbolSegment{Symbol: change.OldSymbol, From: start, To: effective.Add(-time.Second)})
+		start = effective
+	}
+	if !start.After(to) {
+		segments = append(segments, SymbolSegment{Symbol: symbol, From: start, To: to})
+	}
+	return segments
+}
+
+// SaveSymbolChanges stores renames, ignoring ones already stored for the
+// same old symbol and date
+func (db *Database) SaveSymbolChanges(changes []SymbolChange) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, change := range changes {
+		if err := insertSymbolChange(tx, change); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// insertSymbolChange stores a rename unless it is already stored
+func insertSymbolChange(tx *sql.Tx, change SymbolChange) error {
+	_, err := tx.Exec(`
+		INSERT INTO md.symbol_changes (exchange, old_symbol, new_symbol, isin, effective_date)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+		ON CONFLICT (exchange, old_symbol, effective_date) DO NOTHING
+	`,
+		strings.ToUpper(change.Exchange),
+		strings.ToUpper(change.OldSymbol),
+		strings.ToUpper(change.NewSymbol),
+		change.ISIN,
+		change.EffectiveDate.Format("2006-01-02"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save %s to %s: %w", change.OldSymbol, change.NewSymbol, err)
+	}
+	return nil
+}
+
+// AssignISIN sets the ISIN of an instrument on exchange. When another
+// symbol on the exchange had the ISIN, the instrument was renamed from it,
+// and the rename is recorded as effective on the given date and returned.
+func (db *Database) AssignISIN(exchange, symbol, isin string, effective time.Time) (*SymbolChange, bool, error) {
+	exchange, symbol = strings.ToUpper(exchange), strings.ToUpper(symbol)
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE trades.instruments SET isin = $3
+		WHERE exchange = $1 AND tradingsymbol = $2
+	`, exchange, symbol, isin)
+	if err != nil {
+		return nil, false, err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return nil, false, err
+	}
+
+	var previous string
+	err = tx.QueryRow(`
+		SELECT tradingsymbol
+		FROM trades.instruments
+		WHERE exchange = $1 AND isin = $2 AND tradingsymbol <> $3
+		  AND NOT EXISTS (
+			SELECT 1 FROM md.symbol_changes
+			WHERE exchange = $1 AND old_symbol = tradingsymbol
+		  )
+		ORDER BY last_updated DESC
+		LIMIT 1
+	`, exchange, isin, symbol).Scan(&previous)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, false, err
+	}
+
+	var change *SymbolChange
+	if previous != "" {
+		change = &SymbolChange{Exchange: exchange, OldSymbol: previous, NewSymbol: symbol, ISIN: isin, EffectiveDate: effective}
+		if err := insertSymbolChange(tx, *change); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return change, true, tx.Commit()
+}
+
+// GetSymbolHistory returns the renames that led to symbol on exchange,
+// following each one's old symbol back, oldest first
+func (db *Database) GetSymbolHistory(exchange, symbol string) ([]SymbolChange, error) {
+	query := `
+		WITH RECURSIVE chain AS (
+			SELECT change_id, exchange, old_symbol, new_symbol, isin, effective_date, created_at, 1 AS depth
+			FROM md.symbol_changes
+			WHERE exchange = $1 AND new_symbol = $2
+			UNION ALL
+			SELECT c.change_id, c.exchange, c.old_symbol, c.new_symbol, c.isin, c.effective_date, c.created_at, chain.depth + 1
+			FROM md.symbol_changes c
+			JOIN chain ON c.exchange = chain.exchange AND c.new_symbol = chain.old_symbol
+			WHERE c.effective_date < chain.effective_date AND chain.depth < 20
+		)
+		SELECT change_id, exchange, old_symbol, new_symbol, COALESCE(isin, ''), effective_date, created_at
+		FROM chain
+		ORDER BY effective_date
+	`
+
+	rows, err := db.conn.Query(query, strings.ToUpper(exchange), strings.ToUpper(symbol))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []SymbolChange{}
+	for rows.Next() {
+		var change SymbolChange
+		err := rows.Scan(
+			&change.ChangeID,
+			&change.Exchange,
+			&change.OldSymbol,
+			&change.NewSymbol,
+			&change.ISIN,
+			&change.EffectiveDate,
+			&change.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+// GetInstrumentsByISIN returns the instruments listed under an ISIN on any
+// exchange, including renamed symbols still in the instrument master
+func (db *Database) GetInstrumentsByISIN(isin string) ([]Instrument, error) {
+	query := `
+		SELECT instrument_token, exchange_token, tradingsymbol, name, exchange,
+		       segment, instrument_type, COALESCE(isin, ''), expiry, strike, tick_size, lot_size,
+		       COALESCE(last_price, 0), last_updated
+		FROM trades.instruments
+		WHERE isin = $1
+		ORDER BY exchange, last_updated DESC
+	`
+
+	rows, err := db.conn.Query(query, isin)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanInstruments(rows)
+}
@@ -0,0 +1,107 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateISIN(t *testing.T) {
+	tests := []struct {
+		isin    string
+		wantErr bool
+	}{
+		{"INE002A01018", false}, // Reliance
+		{"INE009A01021", false}, // Infosys
+		{"US0378331005", false}, // Apple
+		{"INE002A01019", true},  // Wrong check digit
+		{"INE002A0101", true},   // Too short
+		{"1NE002A01018", true},  // No country code
+		{"INE002A0101X", true},  // No check digit
+		{"INE002a01018", true},  // Lower case
+	}
+
+	for _, tt := range tests {
+		if err := ValidateISIN(tt.isin); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateISIN(%q) = %v, want error %v", tt.isin, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSymbolSegments(t *testing.T) {
+	day := func(month time.Month, d int) time.Time { return time.Date(2024, month, d, 0, 0, 0, 0, istZone) }
+	change := func(old, new string, effective time.Time) SymbolChange {
+		return SymbolChange{Exchange: "NSE", OldSymbol: old, NewSymbol: new, EffectiveDate: effective}
+	}
+
+	// OLDCO became MIDCO on March 1st and NEWCO on June 1st
+	changes := []SymbolChange{
+		change("MIDCO", "NEWCO", day(6, 1)),
+		change("OLDCO", "MIDCO", day(3, 1)),
+	}
+
+	tests := []struct {
+		name     string
+		changes  []SymbolChange
+		from, to time.Time
+		want     []SymbolSegment
+	}{
+		{
+			name:    "no renames",
+			changes: nil,
+			from:    day(1, 1), to: day(12, 31),
+			want: []SymbolSegment{{"NEWCO", day(1, 1), day(12, 31)}},
+		},
+		{
+			name:    "across both renames",
+			changes: changes,
+			from:    day(1, 1), to: day(12, 31),
+			want: []SymbolSegment{
+				{"OLDCO", day(1, 1), day(3, 1).Add(-time.Second)},
+				{"MIDCO", day(3, 1), day(6, 1).Add(-time.Second)},
+				{"NEWCO", day(6, 1), day(12, 31)},
+			},
+		},
+		{
+			name:    "after the first rename",
+			changes: changes,
+			from:    day(4, 1), to: day(12, 31),
+			want: []SymbolSegment{
+				{"MIDCO", day(4, 1), day(6, 1).Add(-time.Second)},
+				{"NEWCO", day(6, 1), day(12, 31)},
+			},
+		},
+		{
+			name:    "before the last rename",
+			changes: changes,
+			from:    day(1, 1), to: day(4, 30),
+			want: []SymbolSegment{
+				{"OLDCO", day(1, 1), day(3, 1).Add(-time.Second)},
+				{"MIDCO", day(3, 1), day(4, 30)},
+			},
+		},
+		{
+			name:    "starting on the effective date",
+			changes: changes,
+			from:    day(6, 1), to: day(6, 30),
+			want: []SymbolSegment{{"NEWCO", day(6, 1), day(6, 30)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SymbolSegments("NEWCO", tt.changes, tt.from, tt.to)
+			if len(got) != len(tt.want) {
+				t.Fatalf("segments = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i].Symbol != tt.want[i].Symbol || !got[i].From.Equal(tt.want[i].From) || !got[i].To.Equal(tt.want[i].To) {
+					t.Errorf("segment %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	if changes[0].OldSymbol != "MIDCO" {
+		t.Error("SymbolSegments reordered its input")
+	}
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+)
+
+// continuousAggregates maps timeframes to the continuous aggregates of 1m
+// bars created by schema_timescale.sql
+var continuousAggregates = map[string]string{
+	"5m":  "md.intraday_bars_5m",
+	"15m": "md.intraday_bars_15m",
+	"1h":  "md.intraday_bars_1h",
+}
+
+// DetectContinuousAggregates looks up which continuous aggregates exist and
+// makes bar queries of their timeframes read from them. Returns the
+// timeframes found; none without TimescaleDB. Call it before serving
+// queries.
+func (db *Database) DetectContinuousAggregates() ([]string, error) {
+	aggregates := make(map[string]string)
+	for timeframe, view := range continuousAggregates {
+		var exists bool
+		if err := db.conn.QueryRow(`SELECT to_regclass($1) IS NOT NULL`, view).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("failed to look up continuous aggregate %s: %w", view, err)
+		}
+		if exists {
+			aggregates[timeframe] = view
+		}
+	}
+	db.aggregates = aggregates
+
+	timeframes := make([]string, 0, len(aggregates))
+	for timeframe := range aggregates {
+		timeframes = append(timeframes, timeframe)
+	}
+	sort.Strings(timeframes)
+	return timeframes, nil
+}
+
+// barSource returns the relation timeframe bars are read from. With a
+// continuous aggregate for the timeframe, stored bars (e.g. backfilled from
+// the broker) are merged with the aggregated ones, stored bars winning.
+func (db *Database) barSource(timeframe string) string {
+	view, ok := db.aggregates[timeframe]
+	if !ok {
+		return "md.intraday_bars"
+	}
+
+	return fmt.Sprintf(`(
+		SELECT DISTINCT ON (exchange, symbol, bar_timestamp)
+			bar_id, exchange, symbol, instrument_token, bar_timestamp, timeframe,
+			open, high, low, close, volume, trades_count, vwap, oi, source, created_at
+		FROM (
+			SELECT
+				bar_id, exchange, symbol, instrument_token, bar_timestamp, timeframe,
+				open, high, low, close, volume, trades_count, vwap, oi, source, created_at,
+				0 AS preference
+			FROM md.intraday_bars
+			WHERE timeframe = '%[1]s'
+			UNION ALL
+			SELECT
+				0, exchange, symbol, COALESCE(instrument_token, 0), bar_timestamp, '%[1]s',
+				open, high, low, close, volume::BIGINT, trades_count::INTEGER, vwap, oi, 'continuous_aggregate', bar_timestamp,
+				1 AS preference
+			FROM %[2]s
+		) merged
+		ORDER BY exchange, symbol, bar_timestamp, preference
+	) bars`, timeframe, view)
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -15,7 +16,7 @@ func (db *Database) CreateUser(email, passwordHash, fullName string) (*auth.User
 		INSERT INTO auth.users (email, password_hash, full_name)
 		VALUES ($1, $2, $3)
 		RETURNING user_id, email, password_hash, full_name, created_at, updated_at,
-		          last_login_at, is_active, email_verified
+		          last_login_at, is_active, email_verified, totp_enabled
 	`
 
 	var user auth.User
@@ -29,6 +30,7 @@ func (db *Database) CreateUser(email, passwordHash, fullName string) (*auth.User
 		&user.LastLoginAt,
 		&user.IsActive,
 		&user.EmailVerified,
+		&user.TOTPEnabled,
 	)
 
 	if err != nil {
@@ -42,7 +44,7 @@ func (db *Database) CreateUser(email, passwordHash, fullName string) (*auth.User
 func (db *Database) GetUserByEmail(email string) (*auth.User, error) {
 	query := `
 		SELECT user_id, email, password_hash, full_name, created_at, updated_at,
-		       last_login_at, is_active, email_verified
+		       last_login_at, is_active, email_verified, totp_enabled
 		FROM auth.users
 		WHERE email = $1
 	`
@@ -58,6 +60,7 @@ func (db *Database) GetUserByEmail(email string) (*auth.User, error) {
 		&user.LastLoginAt,
 		&user.IsActive,
 		&user.EmailVerified,
+		&user.TOTPEnabled,
 	)
 
 	if err == sql.ErrNoRows {
@@ -74,7 +77,7 @@ func (db *Database) GetUserByEmail(email string) (*auth.User, error) {
 func (db *Database) GetUserByID(userID string) (*auth.User, error) {
 	query := `
 		SELECT user_id, email, password_hash, full_name, created_at, updated_at,
-		       last_login_at, is_active, email_verified
+		       last_login_at, is_active, email_verified, totp_enabled
 		FROM auth.users
 		WHERE user_id = $1
 	`
@@ -90,6 +93,7 @@ func (db *Database) GetUserByID(userID string) (*auth.User, error) {
 		&user.LastLoginAt,
 		&user.IsActive,
 		&user.EmailVerified,
+		&user.TOTPEnabled,
 	)
 
 	if err == sql.ErrNoRows {
@@ -199,6 +203,95 @@ func (db *Database) RevokeAllUserSessions(userID string) error {
 	return err
 }
 
+// IsSessionActive reports whether a session exists and is neither revoked
+// nor expired
+func (db *Database) IsSessionActive(sessionID string) (bool, error) {
+	var active bool
+	err := db.conn.QueryRow(`
+		SELECT NOT is_revoked AND expires_at > NOW()
+		FROM auth.sessions
+		WHERE session_id = $1
+	`, sessionID).Scan(&active)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check session: %w", err)
+	}
+	return active, nil
+}
+
+// IsUserAdmin reports whether a user is an active administrator
+func (db *Database) IsUserAdmin(userID string) (bool, error) {
+	var admin bool
+	err := db.conn.QueryRow(`
+		SELECT is_admin AND is_active
+		FROM auth.users
+		WHERE user_id = $1
+	`, userID).Scan(&admin)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check administrator: %w", err)
+	}
+	return admin, nil
+}
+
+// GetUserSessions returns a user's active sessions, most recently used first
+func (db *Database) GetUserSessions(userID string) ([]auth.Session, error) {
+	rows, err := db.conn.Query(`
+		SELECT session_id, user_id, expires_at, created_at, last_used_at,
+		       COALESCE(ip_address, ''), COALESCE(user_agent, ''), is_revoked
+		FROM auth.sessions
+		WHERE user_id = $1 AND NOT is_revoked AND expires_at > NOW()
+		ORDER BY last_used_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []auth.Session{}
+	for rows.Next() {
+		var session auth.Session
+		if err := rows.Scan(
+			&session.SessionID,
+			&session.UserID,
+			&session.ExpiresAt,
+			&session.CreatedAt,
+			&session.LastUsedAt,
+			&session.IPAddress,
+			&session.UserAgent,
+			&session.IsRevoked,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// RevokeUserSession revokes one of a user's active sessions. Returns
+// sql.ErrNoRows if the user has no such active session.
+func (db *Database) RevokeUserSession(userID, sessionID string) error {
+	result, err := db.conn.Exec(`
+		UPDATE auth.sessions SET is_revoked = TRUE
+		WHERE session_id = $1 AND user_id = $2 AND NOT is_revoked AND expires_at > NOW()
+	`, sessionID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
 // CleanupExpiredSessions removes expired sessions
 func (db *Database) CleanupExpiredSessions(ctx context.Context) error {
 	query := `DELETE FROM auth.sessions WHERE expires_at < NOW() OR is_revoked = TRUE`
@@ -206,6 +299,246 @@ func (db *Database) CleanupExpiredSessions(ctx context.Context) error {
 	return err
 }
 
+// CreateUserToken stores the hash of a one-time token for purpose,
+// replacing the user's unused tokens of the same purpose
+func (db *Database) CreateUserToken(userID, purpose, tokenHash string, expiresAt time.Time) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to create user token: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		DELETE FROM auth.user_tokens
+		WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL
+	`, userID, purpose)
+	if err != nil {
+		return fmt.Errorf("failed to replace user tokens: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO auth.user_tokens (token_hash, user_id, purpose, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`, tokenHash, userID, purpose, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create user token: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// useUserToken marks a valid one-time token as used and returns its user.
+// Returns auth.ErrInvalidToken if it is unknown, used or expired.
+func useUserToken(tx *sql.Tx, purpose, tokenHash string) (string, error) {
+	var userID string
+	err := tx.QueryRow(`
+		UPDATE auth.user_tokens
+		SET used_at = NOW()
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id
+	`, tokenHash, purpose).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return "", auth.ErrInvalidToken
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to use token: %w", err)
+	}
+	return userID, nil
+}
+
+// VerifyUserEmail marks the email of a verification token's user as
+// verified and returns the user ID. Returns auth.ErrInvalidToken if the
+// token is unknown, used or expired.
+func (db *Database) VerifyUserEmail(tokenHash string) (string, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return "", fmt.Errorf("failed to verify email: %w", err)
+	}
+	defer tx.Rollback()
+
+	userID, err := useUserToken(tx, auth.TokenPurposeVerifyEmail, tokenHash)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(`UPDATE auth.users SET email_verified = TRUE WHERE user_id = $1`, userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to verify email: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to verify email: %w", err)
+	}
+	return userID, nil
+}
+
+// ResetUserPassword sets a new password for a reset token's user and
+// revokes all their sessions. It returns the user ID, or
+// auth.ErrInvalidToken if the token is unknown, used or expired.
+func (db *Database) ResetUserPassword(tokenHash, passwordHash string) (string, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return "", fmt.Errorf("failed to reset password: %w", err)
+	}
+	defer tx.Rollback()
+
+	userID, err := useUserToken(tx, auth.TokenPurposeResetPassword, tokenHash)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(`UPDATE auth.users SET password_hash = $2 WHERE user_id = $1`, userID, passwordHash)
+	if err != nil {
+		return "", fmt.Errorf("failed to reset password: %w", err)
+	}
+	_, err = tx.Exec(`UPDATE auth.sessions SET is_revoked = TRUE WHERE user_id = $1`, userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to reset password: %w", err)
+	}
+	return userID, nil
+}
+
+// UserTOTP is a user's two-factor authentication state
+type UserTOTP struct {
+	Secret      string    // Empty until the user enrolls
+	Enabled     bool      // Set once a code has confirmed the secret
+	LastStep    int64     // Time step of the last accepted code
+	ForOrders   bool      // Require a code with every order
+	LockedUntil time.Time // Codes are refused until then after too many invalid ones
+}
+
+// GetUserTOTP returns a user's two-factor authentication state
+func (db *Database) GetUserTOTP(userID string) (*UserTOTP, error) {
+	var totp UserTOTP
+	var secret sql.NullString
+	var lockedUntil sql.NullTime
+	err := db.conn.QueryRow(`
+		SELECT totp_secret, totp_enabled, totp_last_step, totp_for_orders, totp_locked_until
+		FROM auth.users
+		WHERE user_id = $1
+	`, userID).Scan(&secret, &totp.Enabled, &totp.LastStep, &totp.ForOrders, &lockedUntil)
+	if err == sql.ErrNoRows {
+		return nil, auth.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two-factor state: %w", err)
+	}
+	totp.Secret = secret.String
+	totp.LockedUntil = lockedUntil.Time
+	return &totp, nil
+}
+
+// SetUserTOTPSecret stores a new, not yet enabled TOTP secret. It does
+// nothing once two-factor authentication is enabled.
+func (db *Database) SetUserTOTPSecret(userID, secret string) error {
+	_, err := db.conn.Exec(`
+		UPDATE auth.users SET totp_secret = $2
+		WHERE user_id = $1 AND NOT totp_enabled
+	`, userID, secret)
+	if err != nil {
+		return fmt.Errorf("failed to set TOTP secret: %w", err)
+	}
+	return nil
+}
+
+// UseUserTOTPStep records the time step of an accepted code and clears the
+// count of invalid ones. It returns false if that step or a later one was
+// already used, so concurrent requests can't both use the same code.
+func (db *Database) UseUserTOTPStep(userID string, step int64) (bool, error) {
+	result, err := db.conn.Exec(`
+		UPDATE auth.users SET totp_last_step = $2, totp_failed_attempts = 0
+		WHERE user_id = $1 AND totp_last_step < $2
+	`, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to use TOTP code: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to use TOTP code: %w", err)
+	}
+	return n > 0, nil
+}
+
+// RecordTOTPFailure counts an invalid code. The maxFailures-th one in a row
+// locks the user's codes for lockout and starts the count again. Returns
+// when codes are locked until, zero if they aren't.
+func (db *Database) RecordTOTPFailure(userID string, maxFailures int, lockout time.Duration) (time.Time, error) {
+	var lockedUntil sql.NullTime
+	err := db.conn.QueryRow(`
+		UPDATE auth.users
+		SET totp_failed_attempts = CASE
+		        WHEN totp_failed_attempts + 1 >= $2 THEN 0
+		        ELSE totp_failed_attempts + 1
+		    END,
+		    totp_locked_until = CASE
+		        WHEN totp_failed_attempts + 1 >= $2 THEN NOW() + make_interval(secs => $3)
+		        ELSE totp_locked_until
+		    END
+		WHERE user_id = $1
+		RETURNING CASE WHEN totp_locked_until > NOW() THEN totp_locked_until END
+	`, userID, maxFailures, lockout.Seconds()).Scan(&lockedUntil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to record invalid TOTP code: %w", err)
+	}
+	return lockedUntil.Time, nil
+}
+
+// SetUserTOTPEnabled turns two-factor authentication on or off. Turning it
+// off also drops the secret and the order requirement.
+func (db *Database) SetUserTOTPEnabled(userID string, enabled bool) error {
+	query := `UPDATE auth.users SET totp_enabled = TRUE WHERE user_id = $1 AND totp_secret IS NOT NULL`
+	if !enabled {
+		query = `
+			UPDATE auth.users
+			SET totp_enabled = FALSE, totp_secret = NULL, totp_for_orders = FALSE
+			WHERE user_id = $1
+		`
+	}
+	if _, err := db.conn.Exec(query, userID); err != nil {
+		return fmt.Errorf("failed to update two-factor authentication: %w", err)
+	}
+	return nil
+}
+
+// SetUserTOTPForOrders sets whether orders need a TOTP code. Only takes
+// effect while two-factor authentication is enabled.
+func (db *Database) SetUserTOTPForOrders(userID string, require bool) error {
+	_, err := db.conn.Exec(`
+		UPDATE auth.users SET totp_for_orders = $2
+		WHERE user_id = $1 AND totp_enabled
+	`, userID, require)
+	if err != nil {
+		return fmt.Errorf("failed to update order two-factor setting: %w", err)
+	}
+	return nil
+}
+
+// AuditLogEntry is a security audit trail entry
+type AuditLogEntry struct {
+	LogID        int64                  `json:"log_id"`
+	UserID       string                 `json:"user_id,omitempty"`
+	Action       string                 `json:"action"`
+	ResourceType string                 `json:"resource_type,omitempty"`
+	ResourceID   string                 `json:"resource_id,omitempty"`
+	IPAddress    string                 `json:"ip_address,omitempty"`
+	UserAgent    string                 `json:"user_agent,omitempty"`
+	Details      map[string]interface{} `json:"details,omitempty"`
+	CreatedAt    time.Time              `json:"created_at"`
+}
+
+// AuditLogFilter narrows an audit log query. Zero values match everything.
+type AuditLogFilter struct {
+	UserID       string
+	Action       string
+	ResourceType string
+	From         time.Time
+	To           time.Time
+	Limit        int
+	Offset       int
+}
+
 // CreateAuditLog creates an audit log entry
 func (db *Database) CreateAuditLog(userID, action, resourceType, resourceID, ipAddress, userAgent string, details map[string]interface{}) error {
 	query := `
@@ -213,92 +546,119 @@ func (db *Database) CreateAuditLog(userID, action, resourceType, resourceID, ipA
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
 
-	var detailsJSON sql.NullString
+	var detailsJSON []byte
 	if details != nil {
-		// Convert details to JSON (simplified - in production use json.Marshal)
-		detailsJSON.Valid = true
+		var err error
+		detailsJSON, err = json.Marshal(details)
+		if err != nil {
+			return fmt.Errorf("failed to encode audit log details: %w", err)
+		}
 	}
 
-	_, err := db.conn.Exec(query, userID, action, resourceType, resourceID, ipAddress, userAgent, detailsJSON)
-	return err
+	_, err := db.conn.Exec(query, nullableUserID(userID), action, resourceType, resourceID, ipAddress, userAgent, detailsJSON)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log entry: %w", err)
+	}
+	return nil
 }
 
-// GetUserBrokerConfigs retrieves all broker configurations for a user
-func (db *Database) GetUserBrokerConfigs(userID string) ([]*BrokerConfig, error) {
+// auditLogWhere applies an AuditLogFilter ($1-$5)
+const auditLogWhere = `
+		WHERE ($1 = '' OR user_id = $1::uuid)
+		  AND ($2 = '' OR action = $2)
+		  AND ($3 = '' OR resource_type = $3)
+		  AND ($4::timestamptz IS NULL OR created_at >= $4)
+		  AND ($5::timestamptz IS NULL OR created_at < $5)
+`
+
+// GetAuditLog returns audit log entries matching the filter, newest first,
+// and the total number matching for paging
+func (db *Database) GetAuditLog(filter AuditLogFilter) ([]AuditLogEntry, int, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 100
+	}
+
 	query := `
-		SELECT config_id, user_id, broker_name, api_key, api_secret, access_token,
-		       refresh_token, token_expires_at, last_token_refresh, is_active,
-		       account_name, is_default, created_at, updated_at
-		FROM brokers.config
-		WHERE user_id = $1
-		ORDER BY is_default DESC, created_at DESC
+		SELECT log_id, COALESCE(user_id::text, ''), action, COALESCE(resource_type, ''),
+		       COALESCE(resource_id, ''), COALESCE(ip_address, ''), COALESCE(user_agent, ''),
+		       details, created_at, COUNT(*) OVER ()
+		FROM auth.audit_log` + auditLogWhere + `
+		ORDER BY created_at DESC, log_id DESC
+		LIMIT $6 OFFSET $7
 	`
 
-	rows, err := db.conn.Query(query, userID)
+	rows, err := db.conn.Query(query,
+		filter.UserID,
+		filter.Action,
+		filter.ResourceType,
+		nullableTime(filter.From),
+		nullableTime(filter.To),
+		filter.Limit,
+		filter.Offset,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query broker configs: %w", err)
+		return nil, 0, fmt.Errorf("failed to get audit log: %w", err)
 	}
 	defer rows.Close()
 
-	var configs []*BrokerConfig
+	entries := []AuditLogEntry{}
+	total := 0
 	for rows.Next() {
-		var config BrokerConfig
-		var userIDNullable sql.NullString
-		var accountNameNullable sql.NullString
-		var isDefaultNullable sql.NullBool
-
-		err := rows.Scan(
-			&config.ConfigID,
-			&userIDNullable,
-			&config.BrokerName,
-			&config.APIKey,
-			&config.APISecret,
-			&config.AccessToken,
-			&config.RefreshToken,
-			&config.TokenExpiresAt,
-			&config.LastTokenRefresh,
-			&config.IsActive,
-			&accountNameNullable,
-			&isDefaultNullable,
-			&config.CreatedAt,
-			&config.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan broker config: %w", err)
+		var entry AuditLogEntry
+		var details []byte
+		if err := rows.Scan(
+			&entry.LogID,
+			&entry.UserID,
+			&entry.Action,
+			&entry.ResourceType,
+			&entry.ResourceID,
+			&entry.IPAddress,
+			&entry.UserAgent,
+			&details,
+			&entry.CreatedAt,
+			&total,
+		); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan audit log entry: %w", err)
 		}
-
-		if userIDNullable.Valid {
-			config.UserID = userIDNullable.String
-		}
-		if accountNameNullable.Valid {
-			config.AccountName = accountNameNullable.String
-		}
-		if isDefaultNullable.Valid {
-			config.IsDefault = isDefaultNullable.Bool
+		if len(details) > 0 {
+			if err := json.Unmarshal(details, &entry.Details); err != nil {
+				return nil, 0, fmt.Errorf("failed to decode audit log details: %w", err)
+			}
 		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read audit log: %w", err)
+	}
 
-		configs = append(configs, &config)
+	// Past the last page COUNT(*) OVER () has no row to ride on
+	if len(entries) == 0 && filter.Offset > 0 {
+		err := db.conn.QueryRow(`SELECT COUNT(*) FROM auth.audit_log`+auditLogWhere, filter.UserID, filter.Action, filter.ResourceType,
+			nullableTime(filter.From), nullableTime(filter.To)).Scan(&total)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to count audit log: %w", err)
+		}
 	}
 
-	return configs, nil
+	return entries, total, nil
 }
 
-// CreateUserBrokerConfig creates a new broker configuration for a user
-func (db *Database) CreateUserBrokerConfig(userID, brokerName, apiKey, apiSecret, accountName string, isDefault bool) (*BrokerConfig, error) {
-	query := `
-		INSERT INTO brokers.config (user_id, broker_name, api_key, api_secret, account_name, is_default, is_active)
-		VALUES ($1, $2, $3, $4, $5, $6, TRUE)
-		RETURNING config_id, user_id, broker_name, api_key, api_secret, access_token,
-		          refresh_token, token_expires_at, last_token_refresh, is_active,
-		          account_name, is_default, created_at, updated_at
-	`
+// userBrokerConfigColumns are the columns scanned by scanUserBrokerConfig
+const userBrokerConfigColumns = `
+	config_id, user_id, broker_name, api_key, api_secret, access_token,
+	refresh_token, token_expires_at, last_token_refresh, is_active,
+	account_name, is_default, COALESCE(max_positions, 0),
+	COALESCE(max_risk_per_trade, 0), COALESCE(max_daily_loss, 0),
+	COALESCE(max_symbol_exposure, 0), created_at, updated_at
+`
 
+func scanUserBrokerConfig(row rowScanner) (*BrokerConfig, error) {
 	var config BrokerConfig
 	var userIDNullable sql.NullString
 	var accountNameNullable sql.NullString
 	var isDefaultNullable sql.NullBool
 
-	err := db.conn.QueryRow(query, userID, brokerName, apiKey, apiSecret, accountName, isDefault).Scan(
+	err := row.Scan(
 		&config.ConfigID,
 		&userIDNullable,
 		&config.BrokerName,
@@ -311,12 +671,15 @@ func (db *Database) CreateUserBrokerConfig(userID, brokerName, apiKey, apiSecret
 		&config.IsActive,
 		&accountNameNullable,
 		&isDefaultNullable,
+		&config.MaxPositions,
+		&config.MaxRiskPerTrade,
+		&config.MaxDailyLoss,
+		&config.MaxSymbolExposure,
 		&config.CreatedAt,
 		&config.UpdatedAt,
 	)
-
 	if err != nil {
-		return nil, fmt.Errorf("failed to create broker config: %w", err)
+		return nil, err
 	}
 
 	if userIDNullable.Valid {
@@ -331,3 +694,219 @@ func (db *Database) CreateUserBrokerConfig(userID, brokerName, apiKey, apiSecret
 
 	return &config, nil
 }
+
+// GetUserBrokerConfigs retrieves all broker configurations for a user
+func (db *Database) GetUserBrokerConfigs(userID string) ([]*BrokerConfig, error) {
+	query := `SELECT ` + userBrokerConfigColumns + `
+		FROM brokers.config
+		WHERE user_id = $1
+		ORDER BY is_default DESC, created_at DESC
+	`
+
+	rows, err := db.conn.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query broker configs: %w", err)
+	}
+	defer rows.Close()
+
+	var configs []*BrokerConfig
+	for rows.Next() {
+		config, err := scanUserBrokerConfig(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan broker config: %w", err)
+		}
+		configs = append(configs, config)
+	}
+
+	return configs, nil
+}
+
+// GetUserBrokerConfig returns one of a user's broker configurations, or nil
+// if the user has none with the ID
+func (db *Database) GetUserBrokerConfig(userID string, configID int) (*BrokerConfig, error) {
+	query := `SELECT ` + userBrokerConfigColumns + `
+		FROM brokers.config
+		WHERE config_id = $1 AND user_id = $2
+	`
+
+	config, err := scanUserBrokerConfig(db.conn.QueryRow(query, configID, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get broker config: %w", err)
+	}
+	return config, nil
+}
+
+// CreateUserBrokerConfig creates a new broker configuration for a user. A
+// new default replaces the user's previous default.
+func (db *Database) CreateUserBrokerConfig(userID, brokerName, apiKey, apiSecret, accountName string, isDefault bool) (*BrokerConfig, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create broker config: %w", err)
+	}
+	defer tx.Rollback()
+
+	if isDefault {
+		if err := clearUserDefaultBroker(tx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	query := `
+		INSERT INTO brokers.config (user_id, broker_name, api_key, api_secret, account_name, is_default, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, TRUE)
+		RETURNING ` + userBrokerConfigColumns
+
+	config, err := scanUserBrokerConfig(tx.QueryRow(query, userID, brokerName, apiKey, apiSecret, accountName, isDefault))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create broker config: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to create broker config: %w", err)
+	}
+
+	return config, nil
+}
+
+// BrokerConfigUpdate is a change to a user's broker configuration. Empty
+// strings and nil flags leave the field as it is.
+type BrokerConfigUpdate struct {
+	AccountName string
+	AccessToken string
+	IsActive    *bool
+	IsDefault   *bool
+
+	// Risk limits checked on the account's orders, 0 disables a limit
+	MaxPositions      *int
+	MaxRiskPerTrade   *float64
+	MaxDailyLoss      *float64
+	MaxSymbolExposure *float64
+}
+
+// UpdateUserBrokerConfig applies an update to one of a user's broker
+// configurations. Making it the default clears the user's previous default;
+// a new access token resets the token status. Returns sql.ErrNoRows if the
+// user doesn't own the config.
+func (db *Database) UpdateUserBrokerConfig(userID string, configID int, update BrokerConfigUpdate) (*BrokerConfig, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to update broker config: %w", err)
+	}
+	defer tx.Rollback()
+
+	if update.IsDefault != nil && *update.IsDefault {
+		if err := clearUserDefaultBroker(tx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	query := `
+		UPDATE brokers.config
+		SET account_name = COALESCE(NULLIF($3, ''), account_name),
+		    access_token = COALESCE(NULLIF($4, ''), access_token),
+		    token_status = CASE WHEN $4 = '' THEN token_status ELSE 'active' END,
+		    token_error = CASE WHEN $4 = '' THEN token_error ELSE NULL END,
+		    is_active = COALESCE($5, is_active),
+		    is_default = COALESCE($6, is_default),
+		    max_positions = COALESCE($7, max_positions),
+		    max_risk_per_trade = COALESCE($8, max_risk_per_trade),
+		    max_daily_loss = COALESCE($9, max_daily_loss),
+		    max_symbol_exposure = COALESCE($10, max_symbol_exposure),
+		    updated_at = NOW()
+		WHERE config_id = $1 AND user_id = $2
+		RETURNING ` + userBrokerConfigColumns
+
+	config, err := scanUserBrokerConfig(tx.QueryRow(query,
+		configID,
+		userID,
+		update.AccountName,
+		update.AccessToken,
+		update.IsActive,
+		update.IsDefault,
+		update.MaxPositions,
+		update.MaxRiskPerTrade,
+		update.MaxDailyLoss,
+		update.MaxSymbolExposure,
+	))
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update broker config: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to update broker config: %w", err)
+	}
+
+	return config, nil
+}
+
+// SetUserDefaultBrokerConfig makes one of a user's broker configurations
+// their only default. Returns sql.ErrNoRows if the user doesn't own it.
+func (db *Database) SetUserDefaultBrokerConfig(userID string, configID int) (*BrokerConfig, error) {
+	isDefault := true
+	return db.UpdateUserBrokerConfig(userID, configID, BrokerConfigUpdate{IsDefault: &isDefault})
+}
+
+// DeleteUserBrokerConfig deletes one of a user's broker configurations.
+// Returns sql.ErrNoRows if the user doesn't own it.
+func (db *Database) DeleteUserBrokerConfig(userID string, configID int) error {
+	result, err := db.conn.Exec(`
+		DELETE FROM brokers.config
+		WHERE config_id = $1 AND user_id = $2
+	`, configID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete broker config: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete broker config: %w", err)
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// clearUserDefaultBroker unsets a user's default broker configuration, so
+// that another can take its place
+func clearUserDefaultBroker(tx *sql.Tx, userID string) error {
+	_, err := tx.Exec(`
+		UPDATE brokers.config
+		SET is_default = FALSE, updated_at = NOW()
+		WHERE user_id = $1 AND is_default = TRUE
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to clear default broker config: %w", err)
+	}
+	return nil
+}
+
+// UpdateUserBrokerTokens stores the tokens of a user's broker config after
+// a login
+func (db *Database) UpdateUserBrokerTokens(userID string, configID int, accessToken, refreshToken string, expiresAt time.Time) error {
+	query := `
+		UPDATE brokers.config
+		SET access_token = $1,
+		    refresh_token = $2,
+		    token_expires_at = $3,
+		    last_token_refresh = NOW(),
+		    token_status = 'active',
+		    token_error = NULL,
+		    updated_at = NOW()
+		WHERE config_id = $4 AND user_id = $5
+	`
+
+	result, err := db.conn.Exec(query, accessToken, refreshToken, expiresAt, configID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update broker tokens: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("broker config %d not found", configID)
+	}
+	return nil
+}
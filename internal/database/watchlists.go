@@ -0,0 +1,356 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/trading-chitti/market-bridge/internal/watchlist"
+)
+
+// ErrWatchlistExists is returned when a user already has a watchlist with the same name
+var ErrWatchlistExists = errors.New("watchlist with this name already exists")
+
+// WatchlistRecord is a stored user watchlist. UserID is nil for watchlists
+// created in single-user mode.
+type WatchlistRecord struct {
+	WatchlistID string    `json:"watchlist_id" db:"watchlist_id"`
+	UserID      *string   `json:"user_id,omitempty" db:"user_id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	Exchange    string    `json:"exchange" db:"exchange"`
+	Symbols     []string  `json:"symbols" db:"symbols"`
+	SharedWith  []string  `json:"shared_with,omitempty"` // Emails of the users it is shared with
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// watchlistColumns selects a watchlist row for scanWatchlist
+const watchlistColumns = `
+	l.watchlist_id, l.user_id, l.name, COALESCE(l.description, ''), l.exchange, l.symbols,
+	ARRAY(
+		SELECT u.email
+		FROM watchlists.shares s
+		JOIN auth.users u ON u.user_id = s.user_id
+		WHERE s.watchlist_id = l.watchlist_id
+		ORDER BY u.email
+	),
+	l.created_at, l.updated_at
+`
+
+// CreateWatchlist stores a new watchlist and fills in its ID and timestamps
+func (db *Database) CreateWatchlist(userID string, record *WatchlistRecord) error {
+	query := `
+		INSERT INTO watchlists.lists (user_id, name, description, exchange, symbols)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING watchlist_id, user_id, created_at, updated_at
+	`
+
+	err := db.conn.QueryRow(query,
+		nullableUserID(userID),
+		record.Name,
+		record.Description,
+		record.Exchange,
+		pq.Array(record.Symbols),
+	).Scan(&record.WatchlistID, &record.UserID, &record.CreatedAt, &record.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrWatchlistExists
+		}
+		return fmt.Errorf("failed to create watchlist: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateWatchlist replaces a watchlist's name, description, exchange and
+// symbols. Returns sql.ErrNoRows if the user doesn't own the watchlist.
+func (db *Database) UpdateWatchlist(userID string, record *WatchlistRecord) error {
+	query := `
+		UPDATE watchlists.lists
+		SET name = $3, description = $4, exchange = $5, symbols = $6, updated_at = NOW()
+		WHERE watchlist_id = $1 AND user_id IS NOT DISTINCT FROM $2
+		RETURNING user_id, created_at, updated_at
+	`
+
+	err := db.conn.QueryRow(query,
+		record.WatchlistID,
+		nullableUserID(userID),
+		record.Name,
+		record.Description,
+		record.Exchange,
+		pq.Array(record.Symbols),
+	).Scan(&record.UserID, &record.CreatedAt, &record.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return err
+	}
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrWatchlistExists
+		}
+		return fmt.Errorf("failed to update watchlist: %w", err)
+	}
+
+	return nil
+}
+
+// AddWatchlistSymbols appends symbols a watchlist doesn't have yet and
+// returns the updated watchlist. Returns sql.ErrNoRows if the user doesn't
+// own the watchlist.
+func (db *Database) AddWatchlistSymbols(userID, watchlistID string, symbols []string) (*WatchlistRecord, error) {
+	query := `
+		UPDATE watchlists.lists l
+		SET symbols = l.symbols || ARRAY(
+				SELECT s FROM unnest($3::TEXT[]) WITH ORDINALITY AS t(s, n)
+				WHERE s <> ALL(l.symbols)
+				ORDER BY n
+			),
+			updated_at = NOW()
+		WHERE l.watchlist_id = $1 AND l.user_id IS NOT DISTINCT FROM $2
+		RETURNING ` + watchlistColumns
+
+	record, err := scanWatchlist(db.conn.QueryRow(query, watchlistID, nullableUserID(userID), pq.Array(symbols)))
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to add watchlist symbols: %w", err)
+	}
+
+	return record, nil
+}
+
+// RemoveWatchlistSymbols removes symbols from a watchlist and returns the
+// updated watchlist. Returns sql.ErrNoRows if the user doesn't own the
+// watchlist.
+func (db *Database) RemoveWatchlistSymbols(userID, watchlistID string, symbols []string) (*WatchlistRecord, error) {
+	query := `
+		UPDATE watchlists.lists l
+		SET symbols = ARRAY(
+				SELECT s FROM unnest(l.symbols) WITH ORDINALITY AS t(s, n)
+				WHERE s <> ALL($3::TEXT[])
+				ORDER BY n
+			),
+			updated_at = NOW()
+		WHERE l.watchlist_id = $1 AND l.user_id IS NOT DISTINCT FROM $2
+		RETURNING ` + watchlistColumns
+
+	record, err := scanWatchlist(db.conn.QueryRow(query, watchlistID, nullableUserID(userID), pq.Array(symbols)))
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove watchlist symbols: %w", err)
+	}
+
+	return record, nil
+}
+
+// DeleteWatchlist deletes a watchlist and its shares. Returns sql.ErrNoRows
+// if the user doesn't own the watchlist.
+func (db *Database) DeleteWatchlist(userID, watchlistID string) error {
+	result, err := db.conn.Exec(`
+		DELETE FROM watchlists.lists
+		WHERE watchlist_id = $1 AND user_id IS NOT DISTINCT FROM $2
+	`, watchlistID, nullableUserID(userID))
+	if err != nil {
+		return fmt.Errorf("failed to delete watchlist: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete watchlist: %w", err)
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetWatchlistRecord returns a watchlist the user owns or that is shared
+// with them, or nil if not found
+func (db *Database) GetWatchlistRecord(userID, watchlistID string) (*WatchlistRecord, error) {
+	query := `
+		SELECT ` + watchlistColumns + `
+		FROM watchlists.lists l
+		WHERE l.watchlist_id = $1
+		  AND (
+			l.user_id IS NOT DISTINCT FROM $2
+			OR EXISTS (
+				SELECT 1 FROM watchlists.shares s
+				WHERE s.watchlist_id = l.watchlist_id AND s.user_id = $2
+			)
+		  )
+	`
+
+	record, err := scanWatchlist(db.conn.QueryRow(query, watchlistID, nullableUserID(userID)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watchlist: %w", err)
+	}
+
+	return record, nil
+}
+
+// GetWatchlistRecords lists a user's own watchlists by name
+func (db *Database) GetWatchlistRecords(userID string) ([]WatchlistRecord, error) {
+	query := `
+		SELECT ` + watchlistColumns + `
+		FROM watchlists.lists l
+		WHERE l.user_id IS NOT DISTINCT FROM $1
+		ORDER BY l.name
+	`
+	return db.queryWatchlists(query, nullableUserID(userID))
+}
+
+// GetSharedWatchlistRecords lists the watchlists other users shared with a
+// user, by name
+func (db *Database) GetSharedWatchlistRecords(userID string) ([]WatchlistRecord, error) {
+	query := `
+		SELECT ` + watchlistColumns + `
+		FROM watchlists.lists l
+		JOIN watchlists.shares s ON s.watchlist_id = l.watchlist_id
+		WHERE s.user_id = $1
+		ORDER BY l.name
+	`
+	return db.queryWatchlists(query, userID)
+}
+
+func (db *Database) queryWatchlists(query string, args ...interface{}) ([]WatchlistRecord, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watchlists: %w", err)
+	}
+	defer rows.Close()
+
+	records := []WatchlistRecord{}
+	for rows.Next() {
+		record, err := scanWatchlist(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan watchlist: %w", err)
+		}
+		records = append(records, *record)
+	}
+
+	return records, rows.Err()
+}
+
+// ShareWatchlist gives another user read access to a watchlist. Returns
+// sql.ErrNoRows if the owner doesn't own the watchlist.
+func (db *Database) ShareWatchlist(ownerID, watchlistID, userID string) error {
+	result, err := db.conn.Exec(`
+		INSERT INTO watchlists.shares (watchlist_id, user_id)
+		SELECT watchlist_id, $3
+		FROM watchlists.lists
+		WHERE watchlist_id = $1 AND user_id = $2
+		ON CONFLICT (watchlist_id, user_id) DO NOTHING
+	`, watchlistID, ownerID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to share watchlist: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to share watchlist: %w", err)
+	}
+	if rows == 0 {
+		// Already shared, or not the owner's
+		owned, err := db.ownsWatchlist(ownerID, watchlistID)
+		if err != nil {
+			return err
+		}
+		if !owned {
+			return sql.ErrNoRows
+		}
+	}
+
+	return nil
+}
+
+// UnshareWatchlist revokes a user's access to a watchlist. Returns
+// sql.ErrNoRows if the owner doesn't own the watchlist.
+func (db *Database) UnshareWatchlist(ownerID, watchlistID, userID string) error {
+	owned, err := db.ownsWatchlist(ownerID, watchlistID)
+	if err != nil {
+		return err
+	}
+	if !owned {
+		return sql.ErrNoRows
+	}
+
+	if _, err := db.conn.Exec(`
+		DELETE FROM watchlists.shares
+		WHERE watchlist_id = $1 AND user_id = $2
+	`, watchlistID, userID); err != nil {
+		return fmt.Errorf("failed to unshare watchlist: %w", err)
+	}
+
+	return nil
+}
+
+func (db *Database) ownsWatchlist(ownerID, watchlistID string) (bool, error) {
+	var owned bool
+	err := db.conn.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM watchlists.lists
+			WHERE watchlist_id = $1 AND user_id = $2
+		)
+	`, watchlistID, ownerID).Scan(&owned)
+	if err != nil {
+		return false, fmt.Errorf("failed to check watchlist owner: %w", err)
+	}
+	return owned, nil
+}
+
+func scanWatchlist(row rowScanner) (*WatchlistRecord, error) {
+	var record WatchlistRecord
+
+	err := row.Scan(
+		&record.WatchlistID,
+		&record.UserID,
+		&record.Name,
+		&record.Description,
+		&record.Exchange,
+		pq.Array(&record.Symbols),
+		pq.Array(&record.SharedWith),
+		&record.CreatedAt,
+		&record.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if record.Symbols == nil {
+		record.Symbols = []string{}
+	}
+	return &record, nil
+}
+
+// LookupWatchlist returns a stored watchlist by ID, or by name among the
+// watchlists created in single-user mode, or nil if there is none
+func (db *Database) LookupWatchlist(ref string) (*watchlist.Watchlist, error) {
+	query := `
+		SELECT l.name, COALESCE(l.description, ''), l.exchange, l.symbols
+		FROM watchlists.lists l
+		WHERE l.watchlist_id::TEXT = $1
+		   OR (l.user_id IS NULL AND l.name = UPPER($1))
+		ORDER BY (l.watchlist_id::TEXT = $1) DESC
+		LIMIT 1
+	`
+
+	wl := watchlist.Watchlist{Category: "custom"}
+	err := db.conn.QueryRow(query, ref).Scan(&wl.Name, &wl.Description, &wl.Exchange, pq.Array(&wl.Symbols))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up watchlist: %w", err)
+	}
+
+	return &wl, nil
+}
@@ -0,0 +1,468 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/timeframe"
+)
+
+// batchSize is the number of bars written per BulkInsertIntradayBars call
+const batchSize = 5000
+
+// maxReportedErrors caps the row errors kept in a Result
+const maxReportedErrors = 50
+
+// columnAliases maps normalized header names to canonical columns
+var columnAliases = map[string]string{
+	"date":          "date",
+	"trade_date":    "date",
+	"time":          "time",
+	"datetime":      "timestamp",
+	"timestamp":     "timestamp",
+	"date_time":     "timestamp",
+	"bar_timestamp": "timestamp",
+	"symbol":        "symbol",
+	"ticker":        "symbol",
+	"tradingsymbol": "symbol",
+	"scrip":         "symbol",
+	"exchange":      "exchange",
+	"open":          "open",
+	"o":             "open",
+	"high":          "high",
+	"h":             "high",
+	"low":           "low",
+	"l":             "low",
+	"close":         "close",
+	"c":             "close",
+	"ltp":           "close",
+	"volume":        "volume",
+	"vol":           "volume",
+	"v":             "volume",
+	"oi":            "oi",
+	"open_interest": "oi",
+}
+
+// timestampLayouts are tried in order for timestamp and date+time columns
+var timestampLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"02-01-2006 15:04:05",
+	"02-01-2006 15:04",
+	"02/01/2006 15:04:05",
+	"02/01/2006 15:04",
+	"2006/01/02 15:04:05",
+	"20060102 15:04:05",
+	"20060102 15:04",
+	"2006-01-02",
+	"02-01-2006",
+	"02/01/2006",
+	"20060102",
+	"02-Jan-2006",
+}
+
+// Options controls how a file is mapped onto md.intraday_bars
+type Options struct {
+	Timeframe string            // Required: 1m, 5m, 15m, 1h, 1d (or day, 5minute, ...)
+	Exchange  string            // Default exchange when the file has no exchange column (NSE)
+	Symbol    string            // Symbol for files without a symbol column
+	SymbolMap map[string]string // Vendor symbol -> exchange tradingsymbol
+	Location  *time.Location    // Zone for timestamps without an offset (IST)
+	Source    string            // Value stored in md.intraday_bars.source (import)
+}
+
+// Result summarizes an import
+type Result struct {
+	Rows     int      `json:"rows"`
+	Inserted int      `json:"inserted"`
+	Skipped  int      `json:"skipped"`
+	Symbols  []string `json:"symbols"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// Importer loads vendor OHLCV files into md.intraday_bars
+type Importer struct {
+	db     database.Store
+	opts   Options
+	tokens map[string]uint32 // exchange:symbol -> instrument token (0 = unknown)
+}
+
+// New creates an importer, applying defaults for unset options
+func New(db database.Store, opts Options) (*Importer, error) {
+	tf, err := timeframe.ParseStored(opts.Timeframe)
+	if err != nil {
+		return nil, err
+	}
+	opts.Timeframe = tf.String()
+
+	if opts.Exchange == "" {
+		opts.Exchange = "NSE"
+	}
+	opts.Exchange = strings.ToUpper(opts.Exchange)
+
+	if opts.Location == nil {
+		opts.Location = broker.IST()
+	}
+
+	if opts.Source == "" {
+		opts.Source = "import"
+	}
+
+	return &Importer{
+		db:     db,
+		opts:   opts,
+		tokens: make(map[string]uint32),
+	}, nil
+}
+
+// recordReader yields the rows of a file as strings, returning io.EOF after
+// the last one
+type recordReader interface {
+	Read() ([]string, error)
+}
+
+// ImportFile imports a file, choosing the format from its extension. Parquet
+// needs random access: files and uploads are read in place, other readers
+// are buffered in memory.
+func (im *Importer) ImportFile(name string, r io.Reader) (*Result, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".parquet", ".pq":
+		if file, ok := r.(interface {
+			io.ReaderAt
+			io.Seeker
+		}); ok {
+			size, err := file.Seek(0, io.SeekEnd)
+			if err != nil {
+				return nil, fmt.Errorf("failed to size Parquet file: %w", err)
+			}
+			return im.ImportParquet(file, size)
+		}
+
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Parquet file: %w", err)
+		}
+		return im.ImportParquet(bytes.NewReader(data), int64(len(data)))
+	default:
+		return im.ImportCSV(r)
+	}
+}
+
+// ImportCSV imports a CSV file with a header row. Existing bars are updated
+// through the ON CONFLICT upsert, so re-importing a file is idempotent.
+func (im *Importer) ImportCSV(r io.Reader) (*Result, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	// Data starts on line 2, after the header
+	return im.importRecords(header, reader, "line", 2)
+}
+
+// importRecords maps header onto bars and inserts the records in batches.
+// Errors name records as "<unit> <n>", counting from first.
+func (im *Importer) importRecords(header []string, reader recordReader, unit string, first int) (*Result, error) {
+	columns := mapColumns(header)
+	if err := im.validateColumns(columns); err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	symbols := make(map[string]bool)
+	batch := make([]database.IntradayBar, 0, batchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := im.db.BulkInsertIntradayBars(batch); err != nil {
+			return fmt.Errorf("failed to insert bars: %w", err)
+		}
+		result.Inserted += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	n := first - 1
+	for {
+		record, err := reader.Read()
+		n++
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return result, fmt.Errorf("failed to read %s %d: %w", unit, n, err)
+		}
+
+		result.Rows++
+
+		bar, err := im.parseRow(columns, record)
+		if err != nil {
+			result.Skipped++
+			result.addError(fmt.Sprintf("%s %d: %v", unit, n, err))
+			continue
+		}
+
+		symbols[bar.Symbol] = true
+		batch = append(batch, *bar)
+
+		if len(batch) >= batchSize {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return result, err
+	}
+
+	for symbol := range symbols {
+		result.Symbols = append(result.Symbols, symbol)
+	}
+
+	log.Printf("✅ Imported %d bars (%d rows, %d skipped, %d symbols)",
+		result.Inserted, result.Rows, result.Skipped, len(result.Symbols))
+
+	return result, nil
+}
+
+func (r *Result) addError(msg string) {
+	if len(r.Errors) < maxReportedErrors {
+		r.Errors = append(r.Errors, msg)
+	}
+}
+
+// mapColumns returns canonical column name -> index
+func mapColumns(header []string) map[string]int {
+	columns := make(map[string]int)
+	for i, name := range header {
+		key := strings.ToLower(strings.TrimSpace(name))
+		key = strings.TrimPrefix(key, "\ufeff") // UTF-8 BOM
+		key = strings.NewReplacer(" ", "_", "<", "", ">", "").Replace(key)
+
+		if canonical, ok := columnAliases[key]; ok {
+			if _, exists := columns[canonical]; !exists {
+				columns[canonical] = i
+			}
+		}
+	}
+	return columns
+}
+
+func (im *Importer) validateColumns(columns map[string]int) error {
+	for _, required := range []string{"open", "high", "low", "close"} {
+		if _, ok := columns[required]; !ok {
+			return fmt.Errorf("missing required column: %s", required)
+		}
+	}
+
+	_, hasTimestamp := columns["timestamp"]
+	_, hasDate := columns["date"]
+	if !hasTimestamp && !hasDate {
+		return fmt.Errorf("missing timestamp column (timestamp, datetime or date)")
+	}
+
+	if _, ok := columns["symbol"]; !ok && im.opts.Symbol == "" {
+		return fmt.Errorf("file has no symbol column, a symbol must be given")
+	}
+
+	return nil
+}
+
+// parseRow converts one record into a bar
+func (im *Importer) parseRow(columns map[string]int, record []string) (*database.IntradayBar, error) {
+	field := func(name string) string {
+		idx, ok := columns[name]
+		if !ok || idx >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[idx])
+	}
+
+	symbol := im.opts.Symbol
+	if s := field("symbol"); s != "" {
+		symbol = s
+	}
+	symbol = im.mapSymbol(symbol)
+
+	exchange := im.opts.Exchange
+	if e := field("exchange"); e != "" {
+		exchange = strings.ToUpper(e)
+	}
+
+	// Vendor symbols often carry the exchange ("NSE:RELIANCE")
+	if idx := strings.Index(symbol, ":"); idx > 0 {
+		exchange = symbol[:idx]
+		symbol = symbol[idx+1:]
+	}
+
+	timestamp, err := im.parseTimestamp(field("timestamp"), field("date"), field("time"))
+	if err != nil {
+		return nil, err
+	}
+
+	open, err := parseFloat(field("open"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid open: %w", err)
+	}
+	high, err := parseFloat(field("high"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid high: %w", err)
+	}
+	low, err := parseFloat(field("low"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid low: %w", err)
+	}
+	closePrice, err := parseFloat(field("close"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid close: %w", err)
+	}
+
+	if high < low || open <= 0 || closePrice <= 0 {
+		return nil, fmt.Errorf("inconsistent OHLC values")
+	}
+
+	var volume int64
+	if v := field("volume"); v != "" {
+		f, err := parseFloat(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid volume: %w", err)
+		}
+		volume = int64(f)
+	}
+
+	token, err := im.instrumentToken(exchange, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	bar := &database.IntradayBar{
+		Exchange:        exchange,
+		Symbol:          symbol,
+		InstrumentToken: int64(token),
+		BarTimestamp:    timestamp,
+		Timeframe:       im.opts.Timeframe,
+		Open:            open,
+		High:            high,
+		Low:             low,
+		Close:           closePrice,
+		Volume:          volume,
+		Source:          im.opts.Source,
+	}
+
+	if v := field("oi"); v != "" {
+		if f, err := parseFloat(v); err == nil {
+			oi := int64(f)
+			bar.OI = &oi
+		}
+	}
+
+	return bar, nil
+}
+
+// mapSymbol applies the vendor symbol map and common suffix clean-up
+func (im *Importer) mapSymbol(symbol string) string {
+	if mapped, ok := im.opts.SymbolMap[symbol]; ok {
+		return mapped
+	}
+
+	symbol = strings.ToUpper(symbol)
+	if mapped, ok := im.opts.SymbolMap[symbol]; ok {
+		return mapped
+	}
+
+	// Yahoo style suffixes
+	if strings.HasSuffix(symbol, ".NS") {
+		return "NSE:" + strings.TrimSuffix(symbol, ".NS")
+	}
+	if strings.HasSuffix(symbol, ".BO") {
+		return "BSE:" + strings.TrimSuffix(symbol, ".BO")
+	}
+
+	// NSE series suffix
+	return strings.TrimSuffix(symbol, "-EQ")
+}
+
+// parseTimestamp parses either a combined timestamp or separate date and time
+// columns. Values without an offset are interpreted in the configured zone.
+func (im *Importer) parseTimestamp(timestamp, date, clock string) (time.Time, error) {
+	value := timestamp
+	if value == "" {
+		value = date
+		if clock != "" {
+			value = date + " " + clock
+		}
+	}
+
+	if value == "" {
+		return time.Time{}, fmt.Errorf("missing timestamp")
+	}
+
+	// Unix epoch in seconds or milliseconds
+	if epoch, err := strconv.ParseInt(value, 10, 64); err == nil && len(value) >= 10 {
+		if epoch > 1e12 {
+			return time.UnixMilli(epoch).In(im.opts.Location), nil
+		}
+		return time.Unix(epoch, 0).In(im.opts.Location), nil
+	}
+
+	for _, layout := range timestampLayouts {
+		var t time.Time
+		var err error
+		if layout == time.RFC3339 {
+			t, err = time.Parse(layout, value)
+		} else {
+			t, err = time.ParseInLocation(layout, value, im.opts.Location)
+		}
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized timestamp: %q", value)
+}
+
+// instrumentToken looks up and caches the token for a symbol. Unknown symbols
+// are rejected since md.intraday_bars references md.symbols.
+func (im *Importer) instrumentToken(exchange, symbol string) (uint32, error) {
+	key := exchange + ":" + symbol
+	if token, ok := im.tokens[key]; ok {
+		if token == 0 {
+			return 0, fmt.Errorf("unknown symbol %s", key)
+		}
+		return token, nil
+	}
+
+	token, err := im.db.GetInstrumentToken(exchange, symbol)
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up %s: %w", key, err)
+	}
+
+	im.tokens[key] = token
+	if token == 0 {
+		return 0, fmt.Errorf("unknown symbol %s", key)
+	}
+	return token, nil
+}
+
+func parseFloat(s string) (float64, error) {
+	return strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+}
@@ -0,0 +1,162 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/format"
+)
+
+// parquetReadAhead is the number of rows decoded per ReadRows call
+const parquetReadAhead = 1024
+
+// ImportParquet imports a Parquet file with a flat schema. Column names are
+// matched like CSV headers; values are converted to the strings parseRow
+// reads, so timestamp, date and decimal columns are understood.
+func (im *Importer) ImportParquet(r io.ReaderAt, size int64) (*Result, error) {
+	file, err := parquet.OpenFile(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Parquet file: %w", err)
+	}
+
+	fields := file.Schema().Fields()
+	header := make([]string, len(fields))
+	for i, field := range fields {
+		if !field.Leaf() || field.Repeated() {
+			return nil, fmt.Errorf("unsupported Parquet column %q: only flat schemas can be imported", field.Name())
+		}
+		header[i] = field.Name()
+	}
+
+	return im.importRecords(header, &parquetRecords{
+		fields:    fields,
+		rowGroups: file.RowGroups(),
+	}, "row", 1)
+}
+
+// parquetRecords reads the rows of each row group in turn as strings
+type parquetRecords struct {
+	fields    []parquet.Field
+	rowGroups []parquet.RowGroup
+	rows      parquet.Rows
+	buffer    []parquet.Row
+	pending   []parquet.Row
+}
+
+// Read returns the next row, or io.EOF after the last row group
+func (pr *parquetRecords) Read() ([]string, error) {
+	for len(pr.pending) == 0 {
+		if err := pr.fill(); err != nil {
+			return nil, err
+		}
+	}
+
+	row := pr.pending[0]
+	pr.pending = pr.pending[1:]
+
+	record := make([]string, len(pr.fields))
+	for _, value := range row {
+		column := value.Column()
+		if column < 0 || column >= len(record) {
+			continue
+		}
+		record[column] = parquetString(pr.fields[column], value)
+	}
+	return record, nil
+}
+
+// fill decodes the next rows into pending, moving on to the next row group
+// when the current one is exhausted
+func (pr *parquetRecords) fill() error {
+	if pr.rows == nil {
+		if len(pr.rowGroups) == 0 {
+			return io.EOF
+		}
+		pr.rows = pr.rowGroups[0].Rows()
+		pr.rowGroups = pr.rowGroups[1:]
+	}
+	if pr.buffer == nil {
+		pr.buffer = make([]parquet.Row, parquetReadAhead)
+	}
+
+	n, err := pr.rows.ReadRows(pr.buffer)
+	pr.pending = pr.buffer[:n]
+	if err == io.EOF {
+		pr.rows.Close()
+		pr.rows = nil
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read Parquet rows: %w", err)
+	}
+	return nil
+}
+
+// parquetString formats a value the way it would appear in a CSV export.
+// Timestamps adjusted to UTC keep their offset; local ones are written
+// without it, so they are read in the configured zone.
+func parquetString(field parquet.Field, value parquet.Value) string {
+	if value.IsNull() {
+		return ""
+	}
+
+	logical := field.Type().LogicalType()
+	switch value.Kind() {
+	case parquet.Boolean:
+		return strconv.FormatBool(value.Boolean())
+	case parquet.Int32:
+		if logical != nil && logical.Date != nil {
+			return time.Unix(int64(value.Int32())*86400, 0).UTC().Format("2006-01-02")
+		}
+		if logical != nil && logical.Decimal != nil {
+			return formatDecimal(int64(value.Int32()), logical.Decimal.Scale)
+		}
+		return strconv.FormatInt(int64(value.Int32()), 10)
+	case parquet.Int64:
+		if logical != nil && logical.Timestamp != nil {
+			return formatTimestamp(timestampValue(value.Int64(), logical.Timestamp.Unit), logical.Timestamp.IsAdjustedToUTC)
+		}
+		if logical != nil && logical.Decimal != nil {
+			return formatDecimal(value.Int64(), logical.Decimal.Scale)
+		}
+		return strconv.FormatInt(value.Int64(), 10)
+	case parquet.Int96:
+		// Legacy Impala/Spark timestamps: nanoseconds of the day and a Julian day
+		i96 := value.Int96()
+		nanos := int64(i96[1])<<32 | int64(i96[0])
+		days := int64(i96[2]) - 2440588 // Julian day of the Unix epoch
+		return formatTimestamp(time.Unix(days*86400, nanos), true)
+	case parquet.Float:
+		return strconv.FormatFloat(float64(value.Float()), 'f', -1, 32)
+	case parquet.Double:
+		return strconv.FormatFloat(value.Double(), 'f', -1, 64)
+	default:
+		return string(value.ByteArray())
+	}
+}
+
+func timestampValue(v int64, unit format.TimeUnit) time.Time {
+	switch {
+	case unit.Millis != nil:
+		return time.UnixMilli(v)
+	case unit.Micros != nil:
+		return time.UnixMicro(v)
+	default:
+		return time.Unix(0, v)
+	}
+}
+
+func formatTimestamp(t time.Time, utc bool) string {
+	if utc {
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+func formatDecimal(unscaled int64, scale int32) string {
+	return strconv.FormatFloat(float64(unscaled)/math.Pow10(int(scale)), 'f', -1, 64)
+}
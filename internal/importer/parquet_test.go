@@ -0,0 +1,122 @@
+package importer
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+type parquetBar struct {
+	Symbol    string    `parquet:"symbol"`
+	Timestamp time.Time `parquet:"timestamp,timestamp(millisecond)"`
+	Date      int32     `parquet:"date,date"` // Days since the Unix epoch
+	Open      float64   `parquet:"open"`
+	Close     float32   `parquet:"close"`
+	Volume    int64     `parquet:"volume"`
+	OI        *int32    `parquet:"oi,optional"`
+}
+
+func TestParquetRecords(t *testing.T) {
+	oi := int32(1500)
+	ts := time.Date(2024, 1, 30, 3, 45, 0, 0, time.UTC)
+	day := int32(ts.Unix() / 86400)
+	rows := []parquetBar{
+		{Symbol: "RELIANCE", Timestamp: ts, Date: day, Open: 2501.5, Close: 2502.25, Volume: 1200, OI: &oi},
+		{Symbol: "TCS", Timestamp: ts.Add(time.Minute), Date: day, Open: 3800, Close: 3801.5, Volume: 0},
+	}
+
+	var buf bytes.Buffer
+	if err := parquet.Write(&buf, rows); err != nil {
+		t.Fatalf("failed to write Parquet: %v", err)
+	}
+
+	file, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("failed to open Parquet: %v", err)
+	}
+	reader := &parquetRecords{fields: file.Schema().Fields(), rowGroups: file.RowGroups()}
+
+	var names []string
+	for _, field := range reader.fields {
+		names = append(names, field.Name())
+	}
+	columns := mapColumns(names)
+
+	tests := []struct {
+		column string
+		want   []string
+	}{
+		{"symbol", []string{"RELIANCE", "TCS"}},
+		{"timestamp", []string{"2024-01-30T03:45:00Z", "2024-01-30T03:46:00Z"}},
+		{"date", []string{"2024-01-30", "2024-01-30"}},
+		{"open", []string{"2501.5", "3800"}},
+		{"close", []string{"2502.25", "3801.5"}},
+		{"volume", []string{"1200", "0"}},
+		{"oi", []string{"1500", ""}},
+	}
+
+	var records [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != len(rows) {
+		t.Fatalf("read %d records, want %d", len(records), len(rows))
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.column, func(t *testing.T) {
+			idx, ok := columns[tt.column]
+			if !ok {
+				t.Fatalf("column %s not mapped from %v", tt.column, names)
+			}
+			var got []string
+			for _, record := range records {
+				got = append(got, record[idx])
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTimestampFromParquetStrings(t *testing.T) {
+	ist := broker.IST()
+	im := &Importer{opts: Options{Location: ist}}
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Time
+	}{
+		{"utc timestamp", "2024-01-30T03:45:00Z", time.Date(2024, 1, 30, 3, 45, 0, 0, time.UTC)},
+		{"utc with fraction", "2024-01-30T03:45:00.5Z", time.Date(2024, 1, 30, 3, 45, 0, 5e8, time.UTC)},
+		{"local timestamp", "2024-01-30 09:15:00", time.Date(2024, 1, 30, 9, 15, 0, 0, ist)},
+		{"date", "2024-01-30", time.Date(2024, 1, 30, 0, 0, 0, 0, ist)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := im.parseTimestamp(tt.value, "", "")
+			if err != nil {
+				t.Fatalf("parseTimestamp(%q): %v", tt.value, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseTimestamp(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package journal
+
+import (
+	"log"
+	"strings"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// Journal records every order placed, modified or cancelled through a
+// broker, and the status updates the broker pushes back, in
+// trades.executions
+type Journal struct {
+	db         *database.Database
+	brokerName string
+	userID     string
+}
+
+// New creates a journal for one broker account. userID is empty in
+// single-user mode.
+func New(db *database.Database, brokerName, userID string) *Journal {
+	return &Journal{
+		db:         db,
+		brokerName: brokerName,
+		userID:     userID,
+	}
+}
+
+// Wrap returns a broker that journals its order calls before returning
+// their result
+func (j *Journal) Wrap(brk broker.Broker) broker.Broker {
+	return &journaledBroker{Broker: brk, journal: j}
+}
+
+// RecordOrderUpdate journals an order status change pushed by the broker
+func (j *Journal) RecordOrderUpdate(update broker.OrderUpdate) {
+	j.record(&database.Execution{
+		Event:          database.ExecutionUpdate,
+		OrderID:        update.OrderID,
+		OrderStatus:    update.Status,
+		Symbol:         update.Symbol,
+		Exchange:       update.Exchange,
+		Action:         update.TransactionType,
+		Quantity:       update.Quantity,
+		FilledQuantity: update.FilledQuantity,
+		Price:          update.Price,
+		TriggerPrice:   update.TriggerPrice,
+		AveragePrice:   update.AveragePrice,
+		OrderType:      update.OrderType,
+		Product:        update.Product,
+		Strategy:       update.Tag,
+		Error:          update.StatusMessage,
+	})
+}
+
+// record stores an execution; failures are logged so journaling never
+// blocks trading
+func (j *Journal) record(execution *database.Execution) {
+	execution.BrokerName = j.brokerName
+	execution.UserID = j.userID
+	execution.DryRun = j.brokerName == "paper"
+
+	if err := j.db.SaveTrade(execution); err != nil {
+		log.Printf("⚠️  Failed to journal %s for order %s: %v", execution.Event, execution.OrderID, err)
+	}
+}
+
+// journaledBroker decorates a broker with the trade journal
+type journaledBroker struct {
+	broker.Broker
+	journal *Journal
+}
+
+// Unwrap returns the journaled broker
+func (b *journaledBroker) Unwrap() broker.Broker {
+	return b.Broker
+}
+
+func (b *journaledBroker) PlaceOrder(order *broker.OrderRequest) (string, error) {
+	orderID, err := b.Broker.PlaceOrder(order)
+
+	execution := &database.Execution{
+		Event:        database.ExecutionPlace,
+		OrderID:      orderID,
+		OrderStatus:  broker.OrderStatusOpen,
+		Symbol:       strings.ToUpper(order.Symbol),
+		Exchange:     strings.ToUpper(order.Exchange),
+		Action:       strings.ToUpper(order.TransactionType),
+		Quantity:     order.Quantity,
+		Price:        order.Price,
+		TriggerPrice: order.TriggerPrice,
+		StopLoss:     order.StopLoss,
+		TakeProfit:   order.Target,
+		OrderType:    strings.ToUpper(order.OrderType),
+		Product:      strings.ToUpper(order.Product),
+		Strategy:     order.Tag,
+	}
+	if execution.Exchange == "" {
+		execution.Exchange = "NSE"
+	}
+	if err != nil {
+		execution.OrderStatus = broker.OrderStatusRejected
+		execution.Error = err.Error()
+	}
+	b.journal.record(execution)
+
+	return orderID, err
+}
+
+func (b *journaledBroker) ModifyOrder(orderID string, order *broker.OrderModify) (string, error) {
+	newOrderID, err := b.Broker.ModifyOrder(orderID, order)
+
+	execution := b.lastKnown(orderID)
+	execution.Event = database.ExecutionModify
+	if newOrderID != "" {
+		execution.OrderID = newOrderID
+	}
+	if order.Quantity != nil {
+		execution.Quantity = *order.Quantity
+	}
+	if order.Price != nil {
+		execution.Price = *order.Price
+	}
+	if order.TriggerPrice != nil {
+		execution.TriggerPrice = *order.TriggerPrice
+	}
+	if order.OrderType != nil {
+		execution.OrderType = strings.ToUpper(*order.OrderType)
+	}
+	if err != nil {
+		execution.Error = err.Error()
+	}
+	b.journal.record(execution)
+
+	return newOrderID, err
+}
+
+func (b *journaledBroker) CancelOrder(orderID string) (string, error) {
+	cancelledID, err := b.Broker.CancelOrder(orderID)
+
+	execution := b.lastKnown(orderID)
+	execution.Event = database.ExecutionCancel
+	if err != nil {
+		execution.Error = err.Error()
+	} else {
+		execution.OrderStatus = broker.OrderStatusCancelled
+	}
+	b.journal.record(execution)
+
+	return cancelledID, err
+}
+
+// lastKnown returns the order's details for a modify or cancel entry, from
+// the journal or, for orders placed elsewhere, the broker's order book
+func (b *journaledBroker) lastKnown(orderID string) *database.Execution {
+	execution, err := b.journal.db.GetLatestExecution(orderID)
+	if err != nil {
+		log.Printf("⚠️  Failed to look up journaled order %s: %v", orderID, err)
+	}
+	if execution != nil {
+		execution.Error = ""
+		execution.Notes = ""
+		return execution
+	}
+
+	execution = &database.Execution{OrderID: orderID}
+	orders, err := b.Broker.GetOrders()
+	if err != nil {
+		return execution
+	}
+	for _, o := range orders {
+		if o.OrderID != orderID {
+			continue
+		}
+		execution.OrderStatus = o.Status
+		execution.Symbol = o.Symbol
+		execution.Exchange = o.Exchange
+		execution.Action = o.TransactionType
+		execution.Quantity = o.Quantity
+		execution.FilledQuantity = o.FilledQuantity
+		execution.Price = o.Price
+		execution.TriggerPrice = o.TriggerPrice
+		execution.AveragePrice = o.AveragePrice
+		execution.OrderType = o.OrderType
+		execution.Product = o.Product
+		break
+	}
+	return execution
+}
+
+// GTT calls are forwarded unjournaled: the broker places a GTT's order
+// itself when it triggers, and its status updates are journaled then.
+
+func (b *journaledBroker) PlaceGTT(gtt *broker.GTTRequest) (int, error) {
+	gtts, err := broker.GTTs(b.Broker)
+	if err != nil {
+		return 0, err
+	}
+	return gtts.PlaceGTT(gtt)
+}
+
+func (b *journaledBroker) ModifyGTT(triggerID int, gtt *broker.GTTRequest) error {
+	gtts, err := broker.GTTs(b.Broker)
+	if err != nil {
+		return err
+	}
+	return gtts.ModifyGTT(triggerID, gtt)
+}
+
+func (b *journaledBroker) DeleteGTT(triggerID int) error {
+	gtts, err := broker.GTTs(b.Broker)
+	if err != nil {
+		return err
+	}
+	return gtts.DeleteGTT(triggerID)
+}
+
+func (b *journaledBroker) GetGTTs() ([]broker.GTT, error) {
+	gtts, err := broker.GTTs(b.Broker)
+	if err != nil {
+		return nil, err
+	}
+	return gtts.GetGTTs()
+}
+
+func (b *journaledBroker) GetGTT(triggerID int) (*broker.GTT, error) {
+	gtts, err := broker.GTTs(b.Broker)
+	if err != nil {
+		return nil, err
+	}
+	return gtts.GetGTT(triggerID)
+}
@@ -0,0 +1,126 @@
+package journal
+
+import (
+	"math"
+	"sort"
+
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// Summary is the realized P&L of one symbol traded by one strategy tag
+type Summary struct {
+	Symbol       string  `json:"symbol"`
+	Strategy     string  `json:"strategy,omitempty"`
+	Orders       int     `json:"orders"`
+	BuyQuantity  int     `json:"buy_quantity"`
+	SellQuantity int     `json:"sell_quantity"`
+	BuyValue     float64 `json:"buy_value"`
+	SellValue    float64 `json:"sell_value"`
+	OpenQuantity int     `json:"open_quantity"`
+	AveragePrice float64 `json:"average_price"`
+	RealizedPnL  float64 `json:"realized_pnl"`
+}
+
+// Summarize computes realized P&L per symbol and strategy from the fills in
+// a set of journal entries. Each order counts once, at its most filled
+// state; P&L is booked against the average cost of the open quantity.
+func Summarize(executions []database.Execution) []Summary {
+	fills := make(map[string]database.Execution)
+	for _, e := range executions {
+		if e.OrderID == "" || e.FilledQuantity <= 0 || e.AveragePrice <= 0 {
+			continue
+		}
+		if prev, ok := fills[e.OrderID]; !ok || e.FilledQuantity >= prev.FilledQuantity {
+			// Modify and cancel entries don't know the fill's strategy
+			// tag if the order was placed elsewhere, so keep the first one
+			if ok && e.Strategy == "" {
+				e.Strategy = prev.Strategy
+			}
+			fills[e.OrderID] = e
+		}
+	}
+
+	ordered := make([]database.Execution, 0, len(fills))
+	for _, e := range fills {
+		ordered = append(ordered, e)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i].ExecutedAt.Before(ordered[j].ExecutedAt)
+	})
+
+	byKey := make(map[string]*Summary)
+	var keys []string
+	for _, e := range ordered {
+		key := e.Strategy + "|" + e.Symbol
+		s, ok := byKey[key]
+		if !ok {
+			s = &Summary{Symbol: e.Symbol, Strategy: e.Strategy}
+			byKey[key] = s
+			keys = append(keys, key)
+		}
+		s.apply(e)
+	}
+
+	summaries := make([]Summary, 0, len(keys))
+	for _, key := range keys {
+		s := byKey[key]
+		s.BuyValue = round2(s.BuyValue)
+		s.SellValue = round2(s.SellValue)
+		s.AveragePrice = round2(s.AveragePrice)
+		s.RealizedPnL = round2(s.RealizedPnL)
+		summaries = append(summaries, *s)
+	}
+	return summaries
+}
+
+// apply nets one fill into the running position
+func (s *Summary) apply(e database.Execution) {
+	qty := e.FilledQuantity
+	price := e.AveragePrice
+	signed := qty
+	if e.Action == "SELL" {
+		signed = -qty
+		s.SellQuantity += qty
+		s.SellValue += float64(qty) * price
+	} else {
+		s.BuyQuantity += qty
+		s.BuyValue += float64(qty) * price
+	}
+	s.Orders++
+
+	switch {
+	case s.OpenQuantity == 0 || (s.OpenQuantity > 0) == (signed > 0):
+		// Opening or adding to a position
+		total := abs(s.OpenQuantity) + qty
+		s.AveragePrice = (s.AveragePrice*float64(abs(s.OpenQuantity)) + price*float64(qty)) / float64(total)
+		s.OpenQuantity += signed
+	default:
+		// Reducing, closing or flipping a position
+		closed := qty
+		if abs(s.OpenQuantity) < closed {
+			closed = abs(s.OpenQuantity)
+		}
+		if s.OpenQuantity > 0 {
+			s.RealizedPnL += float64(closed) * (price - s.AveragePrice)
+		} else {
+			s.RealizedPnL += float64(closed) * (s.AveragePrice - price)
+		}
+		s.OpenQuantity += signed
+		if s.OpenQuantity == 0 {
+			s.AveragePrice = 0
+		} else if closed < qty {
+			s.AveragePrice = price
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -1,6 +1,8 @@
 package metrics
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -28,11 +30,36 @@ var (
 	CollectorTicksReceived = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "marketbridge_collector_ticks_total",
-			Help: "Total ticks received by collectors",
+			Help: "Total ticks received by collectors, by symbol (\"other\" past the symbol label limit)",
+		},
+		[]string{"collector_name", "symbol"},
+	)
+
+	CollectorSymbolBars = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "marketbridge_collector_symbol_bars_total",
+			Help: "Total bars generated by collectors, by symbol (\"other\" past the symbol label limit)",
+		},
+		[]string{"collector_name", "symbol"},
+	)
+
+	CollectorLastTick = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "marketbridge_collector_last_tick_timestamp_seconds",
+			Help: "Unix time of the last tick of each symbol (\"other\" past the symbol label limit)",
 		},
 		[]string{"collector_name", "symbol"},
 	)
 
+	CollectorTickLatency = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "marketbridge_collector_tick_latency_seconds",
+			Help:    "Time from a tick's exchange timestamp to it being written to the database",
+			Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		},
+		[]string{"collector_name"},
+	)
+
 	CollectorBarsGenerated = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "marketbridge_collector_bars_total",
@@ -60,18 +87,42 @@ var (
 	WebSocketConnections = promauto.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "marketbridge_websocket_connections",
-			Help: "Number of active WebSocket connections",
+			Help: "Number of active WebSocket and SSE streaming connections",
 		},
 	)
 
 	WebSocketMessagesTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "marketbridge_websocket_messages_total",
-			Help: "Total WebSocket messages sent",
+			Help: "Total streaming messages sent to /stream clients, by message type",
 		},
 		[]string{"message_type"},
 	)
 
+	StreamRejected = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "marketbridge_stream_rejected_total",
+			Help: "Total streaming connections, subscriptions and client messages rejected by limits or authentication",
+		},
+		[]string{"endpoint", "reason"},
+	)
+
+	StreamSlowClients = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "marketbridge_stream_slow_clients_total",
+			Help: "Total streaming clients disconnected for not keeping up",
+		},
+		[]string{"endpoint"},
+	)
+
+	StreamMessagesDropped = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "marketbridge_stream_messages_dropped_total",
+			Help: "Total streaming messages dropped because a hub or client buffer was full",
+		},
+		[]string{"endpoint", "message_type"},
+	)
+
 	// Database Metrics
 	DatabaseQueriesTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -98,6 +149,78 @@ var (
 		[]string{"operation", "error_type"},
 	)
 
+	BarsWritten = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "marketbridge_bars_written_total",
+			Help: "Total intraday bars written to the database",
+		},
+		[]string{"timeframe"},
+	)
+
+	TicksWritten = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "marketbridge_ticks_written_total",
+			Help: "Total ticks written to the database",
+		},
+	)
+
+	// Broker Metrics
+	BrokerRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "marketbridge_broker_requests_total",
+			Help: "Total broker API calls, by outcome (ok, error)",
+		},
+		[]string{"broker", "operation", "outcome"},
+	)
+
+	BrokerRequestDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "marketbridge_broker_request_duration_seconds",
+			Help:    "Broker API call duration in seconds",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"broker", "operation"},
+	)
+
+	// Historical Cache Metrics
+	HistoricalCacheRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "marketbridge_historical_cache_requests_total",
+			Help: "Total historical candle reads, by result (hit, stale, miss)",
+		},
+		[]string{"interval", "result"},
+	)
+
+	HistoricalCacheRows = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "marketbridge_historical_cache_rows",
+			Help: "Candles in the historical cache at the last prune",
+		},
+	)
+
+	HistoricalCachePruned = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "marketbridge_historical_cache_pruned_rows_total",
+			Help: "Total candles pruned from the historical cache over its row cap",
+		},
+	)
+
+	BrokerRetries = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "marketbridge_broker_retries_total",
+			Help: "Total broker API calls retried after a transient failure",
+		},
+		[]string{"broker", "operation"},
+	)
+
+	BrokerCircuitState = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "marketbridge_broker_circuit_state",
+			Help: "Broker circuit breaker state: 1 for the current state (closed, open, half_open), 0 for the others",
+		},
+		[]string{"broker", "state"},
+	)
+
 	// Data Quality Metrics
 	DataCompletenessPercent = promauto.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -114,6 +237,39 @@ var (
 		},
 		[]string{"symbol", "timeframe"},
 	)
+
+	DataQualityRejected = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "marketbridge_data_quality_rejected_total",
+			Help: "Total incoming bars and ticks rejected by quality checks",
+		},
+		[]string{"record_type", "code"},
+	)
+
+	DataQualitySuspect = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "marketbridge_data_quality_suspect_total",
+			Help: "Total incoming bars and ticks stored but tagged as suspect",
+		},
+		[]string{"record_type", "code"},
+	)
+
+	// Rate Limit Metrics
+	RateLimitRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "marketbridge_rate_limit_requests_total",
+			Help: "Total requests checked by the rate limiter, by route class and outcome (allowed, limited, error)",
+		},
+		[]string{"route_class", "outcome"},
+	)
+
+	RateLimitBudget = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "marketbridge_rate_limit_budget",
+			Help: "Configured rate limit per route class: requests per second (rate) and burst size (burst)",
+		},
+		[]string{"route_class", "setting"},
+	)
 )
 
 // RecordHTTPRequest records an HTTP request
@@ -122,9 +278,22 @@ func RecordHTTPRequest(method, endpoint, status string, duration float64) {
 	HttpRequestDuration.WithLabelValues(method, endpoint).Observe(duration)
 }
 
-// RecordTick records a tick received by a collector
-func RecordTick(collectorName, symbol string) {
-	CollectorTicksReceived.WithLabelValues(collectorName, symbol).Inc()
+// RecordTick records a tick received by a collector at the given time
+func RecordTick(collectorName, symbol string, at time.Time) {
+	label := symbolLabel(collectorName, symbol)
+	CollectorTicksReceived.WithLabelValues(collectorName, label).Inc()
+	CollectorLastTick.WithLabelValues(collectorName, label).Set(float64(at.UnixNano()) / 1e9)
+}
+
+// RecordSymbolBar records a bar of a symbol generated by a collector
+func RecordSymbolBar(collectorName, symbol string) {
+	CollectorSymbolBars.WithLabelValues(collectorName, symbolLabel(collectorName, symbol)).Inc()
+}
+
+// RecordTickLatency records the time from a tick's exchange timestamp to
+// it being stored
+func RecordTickLatency(collectorName string, latency time.Duration) {
+	CollectorTickLatency.WithLabelValues(collectorName).Observe(latency.Seconds())
 }
 
 // RecordBar records a bar generated by a collector
@@ -157,6 +326,24 @@ func RecordWebSocketMessage(messageType string) {
 	WebSocketMessagesTotal.WithLabelValues(messageType).Inc()
 }
 
+// RecordStreamRejection records a streaming connection, subscription or
+// client message rejected for reason
+func RecordStreamRejection(endpoint, reason string) {
+	StreamRejected.WithLabelValues(endpoint, reason).Inc()
+}
+
+// RecordSlowStreamClient records a streaming client disconnected because
+// its send buffer filled up
+func RecordSlowStreamClient(endpoint string) {
+	StreamSlowClients.WithLabelValues(endpoint).Inc()
+}
+
+// RecordDroppedStreamMessage records a streaming message that was not
+// delivered because a buffer was full
+func RecordDroppedStreamMessage(endpoint, messageType string) {
+	StreamMessagesDropped.WithLabelValues(endpoint, messageType).Inc()
+}
+
 // RecordDatabaseQuery records a database query
 func RecordDatabaseQuery(operation, table string, duration float64) {
 	DatabaseQueriesTotal.WithLabelValues(operation, table).Inc()
@@ -168,6 +355,55 @@ func RecordDatabaseError(operation, errorType string) {
 	DatabaseErrors.WithLabelValues(operation, errorType).Inc()
 }
 
+// RecordBarsWritten records bars written to the database
+func RecordBarsWritten(timeframe string, count int) {
+	BarsWritten.WithLabelValues(timeframe).Add(float64(count))
+}
+
+// RecordTicksWritten records ticks written to the database
+func RecordTicksWritten(count int) {
+	TicksWritten.Add(float64(count))
+}
+
+// RecordBrokerRequest records a broker API call and whether it failed
+func RecordBrokerRequest(brokerName, operation string, duration float64, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+	BrokerRequestsTotal.WithLabelValues(brokerName, operation, outcome).Inc()
+	BrokerRequestDuration.WithLabelValues(brokerName, operation).Observe(duration)
+}
+
+// RecordHistoricalCache records a historical candle read served from the
+// cache (hit), partly re-fetched (stale) or fetched from the broker (miss)
+func RecordHistoricalCache(interval, result string) {
+	HistoricalCacheRequests.WithLabelValues(interval, result).Inc()
+}
+
+// RecordHistoricalCachePrune records the cache size after a prune and the
+// candles it removed
+func RecordHistoricalCachePrune(rows, pruned int64) {
+	HistoricalCacheRows.Set(float64(rows - pruned))
+	HistoricalCachePruned.Add(float64(pruned))
+}
+
+// RecordBrokerRetry records a broker call retried after a transient failure
+func RecordBrokerRetry(brokerName, operation string) {
+	BrokerRetries.WithLabelValues(brokerName, operation).Inc()
+}
+
+// SetBrokerCircuitState publishes a broker's circuit breaker state
+func SetBrokerCircuitState(brokerName, state string) {
+	for _, s := range []string{"closed", "open", "half_open"} {
+		value := 0.0
+		if s == state {
+			value = 1
+		}
+		BrokerCircuitState.WithLabelValues(brokerName, s).Set(value)
+	}
+}
+
 // SetDataCompleteness sets data completeness percentage for a symbol
 func SetDataCompleteness(symbol string, percent float64) {
 	DataCompletenessPercent.WithLabelValues(symbol).Set(percent)
@@ -177,3 +413,24 @@ func SetDataCompleteness(symbol string, percent float64) {
 func RecordDataGap(symbol, timeframe string) {
 	DataGapsDetected.WithLabelValues(symbol, timeframe).Inc()
 }
+
+// RecordQualityRejection records a bar or tick rejected by a quality check
+func RecordQualityRejection(recordType, code string) {
+	DataQualityRejected.WithLabelValues(recordType, code).Inc()
+}
+
+// RecordQualitySuspect records a bar or tick tagged as suspect
+func RecordQualitySuspect(recordType, code string) {
+	DataQualitySuspect.WithLabelValues(recordType, code).Inc()
+}
+
+// RecordRateLimit records a request checked by the rate limiter
+func RecordRateLimit(routeClass, outcome string) {
+	RateLimitRequests.WithLabelValues(routeClass, outcome).Inc()
+}
+
+// SetRateLimitBudget publishes the configured budget of a route class
+func SetRateLimitBudget(routeClass string, rate float64, burst int) {
+	RateLimitBudget.WithLabelValues(routeClass, "rate").Set(rate)
+	RateLimitBudget.WithLabelValues(routeClass, "burst").Set(float64(burst))
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import "sync"
+
+// DefaultMaxSymbolLabels is the default number of symbols per collector
+// given their own label on per-symbol metrics
+const DefaultMaxSymbolLabels = 200
+
+// OtherSymbol labels the symbols of a collector past the label limit
+const OtherSymbol = "other"
+
+var symbolLabels = struct {
+	mu       sync.Mutex
+	max      int
+	assigned map[string]map[string]bool // Symbols labelled, by collector
+}{
+	max:      DefaultMaxSymbolLabels,
+	assigned: make(map[string]map[string]bool),
+}
+
+// SetMaxSymbolLabels sets how many symbols of each collector get their own
+// label on per-symbol metrics; later symbols share OtherSymbol. 0 labels
+// every symbol OtherSymbol. Symbols already labelled keep their label.
+func SetMaxSymbolLabels(max int) {
+	symbolLabels.mu.Lock()
+	defer symbolLabels.mu.Unlock()
+	symbolLabels.max = max
+}
+
+// symbolLabel returns the label of a collector's symbol: the symbol itself
+// for the first symbols seen, up to the limit, else OtherSymbol
+func symbolLabel(collectorName, symbol string) string {
+	symbolLabels.mu.Lock()
+	defer symbolLabels.mu.Unlock()
+
+	assigned := symbolLabels.assigned[collectorName]
+	if assigned == nil {
+		assigned = make(map[string]bool)
+		symbolLabels.assigned[collectorName] = assigned
+	}
+	if assigned[symbol] {
+		return symbol
+	}
+	if len(assigned) >= symbolLabels.max {
+		return OtherSymbol
+	}
+	assigned[symbol] = true
+	return symbol
+}
@@ -0,0 +1,31 @@
+package metrics
+
+import "testing"
+
+func TestSymbolLabel(t *testing.T) {
+	defer SetMaxSymbolLabels(DefaultMaxSymbolLabels)
+	SetMaxSymbolLabels(2)
+
+	tests := []struct {
+		collector string
+		symbol    string
+		want      string
+	}{
+		{collector: "label-test", symbol: "RELIANCE", want: "RELIANCE"},
+		{collector: "label-test", symbol: "TCS", want: "TCS"},
+		{collector: "label-test", symbol: "INFY", want: OtherSymbol},
+		{collector: "label-test", symbol: "RELIANCE", want: "RELIANCE"},
+		{collector: "label-test-2", symbol: "INFY", want: "INFY"},
+	}
+
+	for _, tt := range tests {
+		if got := symbolLabel(tt.collector, tt.symbol); got != tt.want {
+			t.Errorf("symbolLabel(%q, %q) = %q, want %q", tt.collector, tt.symbol, got, tt.want)
+		}
+	}
+
+	SetMaxSymbolLabels(0)
+	if got := symbolLabel("label-test-3", "SBIN"); got != OtherSymbol {
+		t.Errorf("symbolLabel() with no labels allowed = %q, want %q", got, OtherSymbol)
+	}
+}
@@ -0,0 +1,231 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+)
+
+var httpClient = &http.Client{Timeout: sendTimeout}
+
+// postJSON posts a JSON body and treats any non-2xx response as an error
+func postJSON(ctx context.Context, url string, body interface{}, headers map[string]string) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// ============================================================================
+// TELEGRAM
+// ============================================================================
+
+// TelegramConfig sends through a Telegram bot to a chat
+//
+//	{"bot_token": "123456:ABC...", "chat_id": "987654321"}
+type TelegramConfig struct {
+	BotToken string `json:"bot_token"`
+	ChatID   string `json:"chat_id"`
+}
+
+type telegramChannel struct {
+	config TelegramConfig
+}
+
+func newTelegramChannel(raw json.RawMessage) (*telegramChannel, error) {
+	var config TelegramConfig
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, fmt.Errorf("invalid telegram config: %w", err)
+	}
+	if config.BotToken == "" || config.ChatID == "" {
+		return nil, fmt.Errorf("telegram config needs bot_token and chat_id")
+	}
+	return &telegramChannel{config: config}, nil
+}
+
+func (t *telegramChannel) Send(ctx context.Context, n Notification) error {
+	url := "https://api.telegram.org/bot" + t.config.BotToken + "/sendMessage"
+	return postJSON(ctx, url, map[string]string{
+		"chat_id": t.config.ChatID,
+		"text":    n.Text(),
+	}, nil)
+}
+
+// ============================================================================
+// SLACK
+// ============================================================================
+
+// SlackConfig posts to a Slack incoming webhook
+//
+//	{"webhook_url": "https://hooks.slack.com/services/..."}
+type SlackConfig struct {
+	WebhookURL string `json:"webhook_url"`
+}
+
+type slackChannel struct {
+	config SlackConfig
+}
+
+func newSlackChannel(raw json.RawMessage) (*slackChannel, error) {
+	var config SlackConfig
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, fmt.Errorf("invalid slack config: %w", err)
+	}
+	if !strings.HasPrefix(config.WebhookURL, "https://") {
+		return nil, fmt.Errorf("slack config needs an https webhook_url")
+	}
+	return &slackChannel{config: config}, nil
+}
+
+func (s *slackChannel) Send(ctx context.Context, n Notification) error {
+	text := "*" + n.Title + "*"
+	if n.Message != "" {
+		text += "\n" + n.Message
+	}
+	return postJSON(ctx, s.config.WebhookURL, map[string]string{"text": text}, nil)
+}
+
+// ============================================================================
+// EMAIL
+// ============================================================================
+
+// EmailConfig sends through an SMTP server. STARTTLS is used when the server
+// offers it.
+//
+//	{"host": "smtp.gmail.com", "port": 587, "username": "...", "password": "...",
+//	 "from": "alerts@example.com", "to": ["me@example.com"]}
+type EmailConfig struct {
+	Host     string   `json:"host"`
+	Port     int      `json:"port,omitempty"` // Default 587
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+}
+
+type emailChannel struct {
+	config EmailConfig
+}
+
+func newEmailChannel(raw json.RawMessage) (*emailChannel, error) {
+	var config EmailConfig
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, fmt.Errorf("invalid email config: %w", err)
+	}
+	if config.Host == "" || config.From == "" || len(config.To) == 0 {
+		return nil, fmt.Errorf("email config needs host, from and to")
+	}
+	if config.Port == 0 {
+		config.Port = 587
+	}
+	return &emailChannel{config: config}, nil
+}
+
+func (e *emailChannel) Send(ctx context.Context, n Notification) error {
+	var msg strings.Builder
+	msg.WriteString("From: " + e.config.From + "\r\n")
+	msg.WriteString("To: " + strings.Join(e.config.To, ", ") + "\r\n")
+	msg.WriteString("Subject: " + n.Title + "\r\n")
+	msg.WriteString("Date: " + n.Time.Format("Mon, 02 Jan 2006 15:04:05 -0700") + "\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(n.Message + "\r\n")
+
+	var auth smtp.Auth
+	if e.config.Username != "" {
+		auth = smtp.PlainAuth("", e.config.Username, e.config.Password, e.config.Host)
+	}
+
+	// smtp.SendMail has no context; run it aside so the timeout still applies
+	addr := net.JoinHostPort(e.config.Host, strconv.Itoa(e.config.Port))
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, e.config.From, e.config.To, []byte(msg.String()))
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("smtp: %w", ctx.Err())
+	}
+}
+
+// ============================================================================
+// WEBHOOK
+// ============================================================================
+
+// WebhookConfig posts the notification as JSON to any URL. With a secret,
+// the body is signed in X-Signature as "sha256=<hex HMAC>".
+//
+//	{"url": "https://example.com/hooks/trading", "secret": "...", "headers": {"X-Token": "..."}}
+type WebhookConfig struct {
+	URL     string            `json:"url"`
+	Secret  string            `json:"secret,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+type webhookChannel struct {
+	config WebhookConfig
+}
+
+func newWebhookChannel(raw json.RawMessage) (*webhookChannel, error) {
+	var config WebhookConfig
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, fmt.Errorf("invalid webhook config: %w", err)
+	}
+	if !strings.HasPrefix(config.URL, "http://") && !strings.HasPrefix(config.URL, "https://") {
+		return nil, fmt.Errorf("webhook config needs an http(s) url")
+	}
+	return &webhookChannel{config: config}, nil
+}
+
+func (w *webhookChannel) Send(ctx context.Context, n Notification) error {
+	headers := make(map[string]string, len(w.config.Headers)+1)
+	for k, v := range w.config.Headers {
+		headers[k] = v
+	}
+
+	if w.config.Secret != "" {
+		payload, err := json.Marshal(n)
+		if err != nil {
+			return fmt.Errorf("failed to encode payload: %w", err)
+		}
+		mac := hmac.New(sha256.New, []byte(w.config.Secret))
+		mac.Write(payload)
+		headers["X-Signature"] = "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		return postJSON(ctx, w.config.URL, json.RawMessage(payload), headers)
+	}
+
+	return postJSON(ctx, w.config.URL, n, headers)
+}
@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// AlertTriggered notifies an alert's owner that it fired
+func (n *Notifier) AlertTriggered(alert database.Alert, message string) {
+	userID := ""
+	if alert.UserID != nil {
+		userID = *alert.UserID
+	}
+
+	data := map[string]interface{}{
+		"alert_id": alert.AlertID,
+		"kind":     alert.Kind,
+		"exchange": alert.Exchange,
+		"symbol":   alert.Symbol,
+	}
+	if alert.LastValue != nil {
+		data["value"] = *alert.LastValue
+	}
+
+	n.Notify(userID, Notification{
+		Event:   EventAlertTriggered,
+		Title:   "🔔 Alert: " + alert.Name,
+		Message: message,
+		Data:    data,
+	})
+}
+
+// PatternDetected notifies system channels of a pattern found by the
+// background pattern scanner
+func (n *Notifier) PatternDetected(detection database.PatternDetection) {
+	n.Notify("", Notification{
+		Event: EventPatternDetected,
+		Title: fmt.Sprintf("🔍 %s on %s:%s (%s)", detection.Type, detection.Exchange, detection.Symbol, detection.Timeframe),
+		Message: fmt.Sprintf("%s, %s, confidence %.0f%%, completed %s",
+			detection.Description, detection.Signal, detection.Confidence*100,
+			detection.EndDate.Format("2006-01-02 15:04")),
+		Data: map[string]interface{}{
+			"detection_id": detection.DetectionID,
+			"exchange":     detection.Exchange,
+			"symbol":       detection.Symbol,
+			"timeframe":    detection.Timeframe,
+			"type":         detection.Type,
+			"signal":       detection.Signal,
+			"confidence":   detection.Confidence,
+			"key_levels":   detection.KeyLevels,
+		},
+	})
+}
+
+// OrderUpdate notifies the user of filled and rejected orders; other
+// status changes are ignored. Use it as, or from, a broker order update
+// handler.
+func (n *Notifier) OrderUpdate(userID string, update broker.OrderUpdate) {
+	var event, title, message string
+	switch update.Status {
+	case broker.OrderStatusComplete:
+		event = EventOrderFilled
+		title = fmt.Sprintf("✅ Filled: %s %d %s", update.TransactionType, update.FilledQuantity, update.Symbol)
+		message = fmt.Sprintf("Order %s filled at %.2f (%s %s)", update.OrderID, update.AveragePrice, update.OrderType, update.Product)
+	case broker.OrderStatusRejected:
+		event = EventOrderRejected
+		title = fmt.Sprintf("❌ Rejected: %s %d %s", update.TransactionType, update.Quantity, update.Symbol)
+		message = fmt.Sprintf("Order %s rejected: %s", update.OrderID, update.StatusMessage)
+	default:
+		return
+	}
+
+	n.Notify(userID, Notification{
+		Event:   event,
+		Title:   title,
+		Message: message,
+		Data: map[string]interface{}{
+			"order_id":         update.OrderID,
+			"exchange":         update.Exchange,
+			"symbol":           update.Symbol,
+			"transaction_type": update.TransactionType,
+			"quantity":         update.Quantity,
+			"filled_quantity":  update.FilledQuantity,
+			"average_price":    update.AveragePrice,
+			"tag":              update.Tag,
+		},
+		Key: update.OrderID, // Brokers can push the final status more than once
+	})
+}
+
+// CollectorFailure notifies system channels that a collector reported an
+// error. Repeats from the same collector are sent at most once per cooldown.
+func (n *Notifier) CollectorFailure(name string, err error) {
+	n.Notify("", Notification{
+		Event:   EventCollectorFailure,
+		Title:   "⚠️ Collector failure: " + name,
+		Message: err.Error(),
+		Data: map[string]interface{}{
+			"collector": name,
+		},
+		Key: name,
+	})
+}
+
+// TokenExpiring warns that a broker access token expires soon, has expired
+// or failed to refresh, so the user has to log in again. The warning goes
+// to system channels and, in multi-user mode, to the account's owner.
+func (n *Notifier) TokenExpiring(config broker.BrokerConfig) {
+	message := "The access token needs to be renewed"
+	if config.TokenExpiresAt != nil {
+		if until := time.Until(*config.TokenExpiresAt); until > 0 {
+			message = fmt.Sprintf("The access token expires at %s (in %s)",
+				config.TokenExpiresAt.Format(time.RFC3339), until.Round(time.Minute))
+		} else {
+			message = fmt.Sprintf("The access token expired at %s",
+				config.TokenExpiresAt.Format(time.RFC3339))
+		}
+	}
+	if config.TokenError != "" {
+		message += ". Refreshing it failed: " + config.TokenError
+	}
+	message += ". Log in again to renew it."
+
+	notification := Notification{
+		Event:   EventTokenExpiry,
+		Title:   "🔑 Broker token expiring: " + config.BrokerName,
+		Message: message,
+		Data: map[string]interface{}{
+			"broker_name":  config.BrokerName,
+			"config_id":    config.ID,
+			"token_status": config.TokenStatus,
+		},
+		Key: fmt.Sprintf("%s/%d", config.BrokerName, config.ID),
+	}
+
+	n.Notify("", notification)
+	if _, err := uuid.Parse(config.UserID); err == nil {
+		n.Notify(config.UserID, notification)
+	}
+}
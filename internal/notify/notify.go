@@ -0,0 +1,317 @@
+// Package notify pushes events (triggered alerts, detected patterns, order
+// fills, collector failures, token expiry) to per-user channels: Telegram bots, Slack
+// webhooks, SMTP email and generic HTTP webhooks.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// Events that can be delivered
+const (
+	EventAlertTriggered   = "alert_triggered"
+	EventPatternDetected  = "pattern_detected"
+	EventOrderFilled      = "order_filled"
+	EventOrderRejected    = "order_rejected"
+	EventCollectorFailure = "collector_failure"
+	EventTokenExpiry      = "token_expiry"
+)
+
+// Events lists every event a channel can subscribe to
+var Events = []string{
+	EventAlertTriggered,
+	EventPatternDetected,
+	EventOrderFilled,
+	EventOrderRejected,
+	EventCollectorFailure,
+	EventTokenExpiry,
+}
+
+// Channel kinds
+const (
+	KindTelegram = "telegram"
+	KindSlack    = "slack"
+	KindEmail    = "email"
+	KindWebhook  = "webhook"
+)
+
+const (
+	// DefaultCooldown suppresses repeats of a keyed notification
+	DefaultCooldown = time.Hour
+
+	// sendTimeout bounds a single delivery
+	sendTimeout = 10 * time.Second
+
+	// redacted replaces credentials in API responses
+	redacted = "********"
+)
+
+// secretFields are the config fields of each kind that hold credentials
+var secretFields = map[string][]string{
+	KindTelegram: {"bot_token"},
+	KindSlack:    {"webhook_url"},
+	KindEmail:    {"password"},
+	KindWebhook:  {"secret"},
+}
+
+// Notification is one event pushed to a user's channels
+type Notification struct {
+	Event   string                 `json:"event"`
+	Title   string                 `json:"title"`
+	Message string                 `json:"message"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+	Time    time.Time              `json:"time"`
+
+	// Key identifies repeats of the same condition (e.g. a collector that
+	// keeps failing); keyed notifications are sent at most once per cooldown
+	Key string `json:"-"`
+}
+
+// Text renders the notification as plain text
+func (n Notification) Text() string {
+	if n.Message == "" {
+		return n.Title
+	}
+	return n.Title + "\n" + n.Message
+}
+
+// Channel delivers notifications to one destination
+type Channel interface {
+	Send(ctx context.Context, n Notification) error
+}
+
+// NewChannel builds a channel from its kind and JSON config
+func NewChannel(kind string, config json.RawMessage) (Channel, error) {
+	switch kind {
+	case KindTelegram:
+		return newTelegramChannel(config)
+	case KindSlack:
+		return newSlackChannel(config)
+	case KindEmail:
+		return newEmailChannel(config)
+	case KindWebhook:
+		return newWebhookChannel(config)
+	}
+	return nil, fmt.Errorf("invalid kind %q (use telegram, slack, email or webhook)", kind)
+}
+
+// Validate checks a channel record and normalizes its fields
+func Validate(channel *database.NotificationChannel) error {
+	channel.Name = strings.TrimSpace(channel.Name)
+	channel.Kind = strings.ToLower(channel.Kind)
+	if channel.Name == "" {
+		channel.Name = channel.Kind
+	}
+	if _, err := NewChannel(channel.Kind, channel.Config); err != nil {
+		return err
+	}
+
+	if channel.Events == nil {
+		channel.Events = []string{}
+	}
+	for _, event := range channel.Events {
+		if !validEvent(event) {
+			return fmt.Errorf("invalid event %q (use %s)", event, strings.Join(Events, ", "))
+		}
+	}
+	return nil
+}
+
+func validEvent(event string) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns a channel config with credentials masked
+func Redact(kind string, config json.RawMessage) json.RawMessage {
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(config, &fields); err != nil {
+		return json.RawMessage("{}")
+	}
+	for _, key := range secretFields[kind] {
+		if v, ok := fields[key].(string); ok && v != "" {
+			fields[key] = redacted
+		}
+	}
+
+	masked, err := json.Marshal(fields)
+	if err != nil {
+		return json.RawMessage("{}")
+	}
+	return masked
+}
+
+// KeepSecrets copies credentials from the stored config into an updated one
+// where the update leaves them empty or masked, so clients can edit a channel
+// without resending its credentials
+func KeepSecrets(kind string, stored, updated json.RawMessage) (json.RawMessage, error) {
+	old := map[string]interface{}{}
+	if err := json.Unmarshal(stored, &old); err != nil {
+		return nil, fmt.Errorf("invalid stored config: %w", err)
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(updated, &fields); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	for _, key := range secretFields[kind] {
+		if v, _ := fields[key].(string); v == "" || v == redacted {
+			if prev, ok := old[key]; ok {
+				fields[key] = prev
+			}
+		}
+	}
+
+	return json.Marshal(fields)
+}
+
+// ============================================================================
+// NOTIFIER
+// ============================================================================
+
+// Notifier delivers notifications to the channels a user has configured.
+// Deliveries run in the background so callers never wait on a channel. A
+// nil *Notifier drops everything, so callers can hold one unconditionally.
+type Notifier struct {
+	db       *database.Database
+	cooldown time.Duration
+
+	mu   sync.Mutex
+	sent map[string]time.Time // user|event|key -> last delivery
+}
+
+// New creates a notifier backed by the channels stored in db
+func New(db *database.Database) *Notifier {
+	return &Notifier{
+		db:       db,
+		cooldown: DefaultCooldown,
+		sent:     make(map[string]time.Time),
+	}
+}
+
+// Notify sends a notification to the user's channels subscribed to its
+// event. userID is empty for single-user mode and system events.
+func (n *Notifier) Notify(userID string, notification Notification) {
+	if n == nil {
+		return
+	}
+	if notification.Time.IsZero() {
+		notification.Time = time.Now()
+	}
+	if !n.allow(userID, notification) {
+		return
+	}
+
+	go n.deliver(userID, notification)
+}
+
+// allow applies the cooldown to keyed notifications
+func (n *Notifier) allow(userID string, notification Notification) bool {
+	if notification.Key == "" {
+		return true
+	}
+
+	key := userID + "|" + notification.Event + "|" + notification.Key
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if last, ok := n.sent[key]; ok && notification.Time.Sub(last) < n.cooldown {
+		return false
+	}
+	n.sent[key] = notification.Time
+
+	// Forget entries past the cooldown so the map stays small
+	for k, t := range n.sent {
+		if notification.Time.Sub(t) >= n.cooldown {
+			delete(n.sent, k)
+		}
+	}
+	return true
+}
+
+func (n *Notifier) deliver(userID string, notification Notification) {
+	channels, err := n.db.GetChannelsForEvent(userID, notification.Event)
+	if err != nil {
+		log.Printf("⚠️  Failed to load notification channels: %v", err)
+		return
+	}
+
+	for _, record := range channels {
+		if err := n.Send(record, notification); err != nil {
+			log.Printf("⚠️  Failed to send %s notification via %s (%s): %v",
+				notification.Event, record.Name, record.Kind, err)
+		}
+	}
+}
+
+// Send delivers a notification through one channel and records the outcome
+func (n *Notifier) Send(record database.NotificationChannel, notification Notification) error {
+	if notification.Time.IsZero() {
+		notification.Time = time.Now()
+	}
+
+	channel, err := NewChannel(record.Kind, record.Config)
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		err = channel.Send(ctx, notification)
+		cancel()
+	}
+
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+	}
+	if recordErr := n.db.RecordNotificationResult(record.ChannelID, errMsg); recordErr != nil {
+		log.Printf("⚠️  %v", recordErr)
+	}
+	return err
+}
+
+// ============================================================================
+// MAILER
+// ============================================================================
+
+// Mailer sends account emails (address verification, password reset) to
+// any address through the server's own SMTP account, unlike the email
+// channels users configure for themselves
+type Mailer struct {
+	config EmailConfig
+}
+
+// NewMailer creates a mailer from the server's SMTP settings. config.To is
+// ignored.
+func NewMailer(config EmailConfig) (*Mailer, error) {
+	if config.Host == "" || config.From == "" {
+		return nil, fmt.Errorf("mailer needs host and from")
+	}
+	if config.Port == 0 {
+		config.Port = 587
+	}
+	return &Mailer{config: config}, nil
+}
+
+// Send emails a notification to one address, the title as subject
+func (m *Mailer) Send(to string, notification Notification) error {
+	if notification.Time.IsZero() {
+		notification.Time = time.Now()
+	}
+
+	config := m.config
+	config.To = []string{to}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	return (&emailChannel{config: config}).Send(ctx, notification)
+}
@@ -0,0 +1,235 @@
+// Package options builds option chains from the NFO/BFO contracts in the
+// instrument master and the broker's quotes
+package options
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/analyzer"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// DefaultRiskFreeRate prices options for implied volatility and Greeks
+// unless RISK_FREE_RATE is set, roughly the 91-day T-bill yield
+const DefaultRiskFreeRate = 0.065
+
+// quoteBatch is the most instruments fetched in one quote request (Kite's
+// limit)
+const quoteBatch = 500
+
+var ist = broker.IST()
+
+// indexSpots maps index underlyings to the symbol their spot trades under
+var indexSpots = map[string]string{
+	"NIFTY":      "NSE:NIFTY 50",
+	"BANKNIFTY":  "NSE:NIFTY BANK",
+	"FINNIFTY":   "NSE:NIFTY FIN SERVICE",
+	"MIDCPNIFTY": "NSE:NIFTY MID SELECT",
+	"NIFTYNXT50": "NSE:NIFTY NEXT 50",
+	"SENSEX":     "BSE:SENSEX",
+	"BANKEX":     "BSE:BANKEX",
+}
+
+// Contract is one call or put of a chain with its market data
+type Contract struct {
+	Tradingsymbol   string           `json:"tradingsymbol"`
+	Exchange        string           `json:"exchange"`
+	InstrumentToken uint32           `json:"instrument_token"`
+	LotSize         int              `json:"lot_size"`
+	LastPrice       float64          `json:"last_price"`
+	Change          float64          `json:"change"`
+	Volume          int64            `json:"volume"`
+	OI              int64            `json:"oi"`
+	IV              *float64         `json:"iv,omitempty"`     // Annualized, in percent; unset when the price has none
+	Greeks          *analyzer.Greeks `json:"greeks,omitempty"` // At IV; unset with it
+}
+
+// Strike is a row of a chain, the call and put sharing a strike price
+type Strike struct {
+	Strike float64   `json:"strike"`
+	Call   *Contract `json:"call,omitempty"`
+	Put    *Contract `json:"put,omitempty"`
+}
+
+// Chain is an underlying's options of one expiry
+type Chain struct {
+	Underlying   string    `json:"underlying"`
+	SpotSymbol   string    `json:"spot_symbol"`
+	Spot         float64   `json:"spot"`
+	Expiry       time.Time `json:"expiry"`
+	DaysToExpiry float64   `json:"days_to_expiry"`
+	ATMStrike    float64   `json:"atm_strike"`
+	CallOI       int64     `json:"call_oi"`
+	PutOI        int64     `json:"put_oi"`
+	PCR          float64   `json:"pcr"` // Put/call open interest ratio
+	RiskFreeRate float64   `json:"risk_free_rate"`
+	Strikes      []Strike  `json:"strikes"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// SpotSymbol returns the EXCHANGE:SYMBOL quote key of an underlying's spot
+// price: the index for index options, the NSE equity otherwise
+func SpotSymbol(underlying string) string {
+	if symbol, ok := indexSpots[underlying]; ok {
+		return symbol
+	}
+	return "NSE:" + underlying
+}
+
+// ExpiresAt returns when contracts of an expiry date stop trading, 15:30 IST
+func ExpiresAt(expiry time.Time) time.Time {
+	return time.Date(expiry.Year(), expiry.Month(), expiry.Day(), 15, 30, 0, 0, ist)
+}
+
+// QuoteKey returns the EXCHANGE:SYMBOL key a contract is quoted under
+func QuoteKey(inst database.Instrument) string {
+	return inst.Exchange + ":" + inst.Tradingsymbol
+}
+
+// NearStrikes keeps the contracts of the n strikes either side of the one
+// nearest spot. n <= 0 or an unknown spot keeps every contract.
+func NearStrikes(contracts []database.Instrument, spot float64, n int) []database.Instrument {
+	strikes := uniqueStrikes(contracts)
+	if n <= 0 || spot <= 0 || len(strikes) == 0 {
+		return contracts
+	}
+
+	atm := nearestIndex(strikes, spot)
+	low, high := strikes[max(atm-n, 0)], strikes[min(atm+n, len(strikes)-1)]
+
+	near := make([]database.Instrument, 0, (2*n+1)*2)
+	for _, inst := range contracts {
+		if inst.Strike >= low && inst.Strike <= high {
+			near = append(near, inst)
+		}
+	}
+	return near
+}
+
+// FetchQuotes fetches the quotes of contracts in batches the broker accepts
+func FetchQuotes(brk broker.Broker, contracts []database.Instrument) (map[string]broker.Quote, error) {
+	quotes := make(map[string]broker.Quote, len(contracts))
+	for start := 0; start < len(contracts); start += quoteBatch {
+		end := min(start+quoteBatch, len(contracts))
+		keys := make([]string, 0, end-start)
+		for _, inst := range contracts[start:end] {
+			keys = append(keys, QuoteKey(inst))
+		}
+
+		batch, err := brk.GetQuote(keys)
+		if err != nil {
+			return nil, err
+		}
+		for key, quote := range batch {
+			quotes[key] = quote
+		}
+	}
+	return quotes, nil
+}
+
+// BuildChain lays contracts out by strike with their quotes, and computes
+// each one's implied volatility from its last price and spot, and its Greeks
+// at that volatility. Contracts without a quote are listed with zero prices.
+func BuildChain(underlying string, expiry time.Time, spot float64, contracts []database.Instrument,
+	quotes map[string]broker.Quote, now time.Time, rate float64) *Chain {
+	years := ExpiresAt(expiry).Sub(now).Hours() / 24 / 365
+	chain := &Chain{
+		Underlying:   underlying,
+		SpotSymbol:   SpotSymbol(underlying),
+		Spot:         spot,
+		Expiry:       expiry,
+		DaysToExpiry: math.Round(math.Max(years*365, 0)*100) / 100,
+		RiskFreeRate: rate,
+		Strikes:      []Strike{},
+		Timestamp:    now,
+	}
+
+	rows := make(map[float64]*Strike)
+	for _, inst := range contracts {
+		quote := quotes[QuoteKey(inst)]
+		contract := &Contract{
+			Tradingsymbol:   inst.Tradingsymbol,
+			Exchange:        inst.Exchange,
+			InstrumentToken: inst.InstrumentToken,
+			LotSize:         inst.LotSize,
+			LastPrice:       quote.LastPrice,
+			Change:          quote.Change,
+			Volume:          quote.Volume,
+			OI:              quote.OI,
+		}
+		if iv, err := analyzer.ImpliedVolatility(inst.InstrumentType, quote.LastPrice, spot, inst.Strike, years, rate); err == nil {
+			pct := math.Round(iv*10000) / 100
+			greeks := roundGreeks(analyzer.BlackScholesGreeks(inst.InstrumentType, spot, inst.Strike, years, rate, iv))
+			contract.IV = &pct
+			contract.Greeks = &greeks
+		}
+
+		row, ok := rows[inst.Strike]
+		if !ok {
+			row = &Strike{Strike: inst.Strike}
+			rows[inst.Strike] = row
+		}
+		switch inst.InstrumentType {
+		case analyzer.OptionCall:
+			row.Call = contract
+			chain.CallOI += contract.OI
+		case analyzer.OptionPut:
+			row.Put = contract
+			chain.PutOI += contract.OI
+		}
+	}
+
+	strikes := uniqueStrikes(contracts)
+	for _, strike := range strikes {
+		chain.Strikes = append(chain.Strikes, *rows[strike])
+	}
+	if spot > 0 && len(strikes) > 0 {
+		chain.ATMStrike = strikes[nearestIndex(strikes, spot)]
+	}
+	if chain.CallOI > 0 {
+		chain.PCR = math.Round(float64(chain.PutOI)/float64(chain.CallOI)*100) / 100
+	}
+	return chain
+}
+
+// roundGreeks rounds Greeks for display, keeping gamma's smaller digits
+func roundGreeks(greeks analyzer.Greeks) analyzer.Greeks {
+	round := func(value float64, places float64) float64 {
+		scale := math.Pow(10, places)
+		return math.Round(value*scale) / scale
+	}
+	return analyzer.Greeks{
+		Delta: round(greeks.Delta, 4),
+		Gamma: round(greeks.Gamma, 6),
+		Theta: round(greeks.Theta, 2),
+		Vega:  round(greeks.Vega, 2),
+	}
+}
+
+// uniqueStrikes returns the contracts' strikes, ascending
+func uniqueStrikes(contracts []database.Instrument) []float64 {
+	seen := make(map[float64]bool)
+	strikes := []float64{}
+	for _, inst := range contracts {
+		if !seen[inst.Strike] {
+			seen[inst.Strike] = true
+			strikes = append(strikes, inst.Strike)
+		}
+	}
+	sort.Float64s(strikes)
+	return strikes
+}
+
+// nearestIndex returns the index of the strike nearest price
+func nearestIndex(strikes []float64, price float64) int {
+	nearest := 0
+	for i, strike := range strikes {
+		if math.Abs(strike-price) < math.Abs(strikes[nearest]-price) {
+			nearest = i
+		}
+	}
+	return nearest
+}
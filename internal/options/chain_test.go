@@ -0,0 +1,165 @@
+package options
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/analyzer"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+var testExpiry = time.Date(2024, 3, 28, 0, 0, 0, 0, time.UTC)
+
+func contract(strike float64, optionType string) database.Instrument {
+	return database.Instrument{
+		Tradingsymbol:  fmt.Sprintf("NIFTY24MAR%.0f%s", strike, optionType),
+		Name:           "NIFTY",
+		Exchange:       "NFO",
+		InstrumentType: optionType,
+		Strike:         strike,
+		LotSize:        50,
+	}
+}
+
+func testContracts(strikes ...float64) []database.Instrument {
+	var contracts []database.Instrument
+	for _, strike := range strikes {
+		contracts = append(contracts, contract(strike, analyzer.OptionCall), contract(strike, analyzer.OptionPut))
+	}
+	return contracts
+}
+
+func TestNearStrikes(t *testing.T) {
+	contracts := testContracts(21800, 21900, 22000, 22100, 22200)
+
+	tests := []struct {
+		name string
+		spot float64
+		n    int
+		want []float64
+	}{
+		{"all strikes", 22040, 0, []float64{21800, 21900, 22000, 22100, 22200}},
+		{"unknown spot", 0, 1, []float64{21800, 21900, 22000, 22100, 22200}},
+		{"one either side", 22040, 1, []float64{21900, 22000, 22100}},
+		{"clipped at the lowest strike", 21700, 1, []float64{21800, 21900}},
+		{"more than listed", 22000, 10, []float64{21800, 21900, 22000, 22100, 22200}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := uniqueStrikes(NearStrikes(contracts, tt.spot, tt.n))
+			if len(got) != len(tt.want) {
+				t.Fatalf("strikes = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("strikes = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildChain(t *testing.T) {
+	const spot, vol = 22040.0, 0.15
+	now := ExpiresAt(testExpiry).Add(-30 * 24 * time.Hour)
+	years := 30.0 / 365
+
+	contracts := testContracts(22000, 21900, 22100)
+	quotes := map[string]broker.Quote{}
+	for i, inst := range contracts {
+		if inst.Strike == 22100 && inst.InstrumentType == analyzer.OptionPut {
+			continue // No quote
+		}
+		price := analyzer.BlackScholesPrice(inst.InstrumentType, spot, inst.Strike, years, DefaultRiskFreeRate, vol)
+		quotes[QuoteKey(inst)] = broker.Quote{LastPrice: price, OI: int64(1000 * (i + 1))}
+	}
+
+	chain := BuildChain("NIFTY", testExpiry, spot, contracts, quotes, now, DefaultRiskFreeRate)
+
+	if chain.SpotSymbol != "NSE:NIFTY 50" {
+		t.Errorf("spot symbol = %q, want NSE:NIFTY 50", chain.SpotSymbol)
+	}
+	if chain.DaysToExpiry != 30 {
+		t.Errorf("days to expiry = %v, want 30", chain.DaysToExpiry)
+	}
+	if chain.ATMStrike != 22000 {
+		t.Errorf("ATM strike = %v, want 22000", chain.ATMStrike)
+	}
+	if chain.CallOI != 1000+3000+5000 || chain.PutOI != 2000+4000 {
+		t.Errorf("OI = %d calls, %d puts, want 9000 and 6000", chain.CallOI, chain.PutOI)
+	}
+	if chain.PCR != 0.67 {
+		t.Errorf("PCR = %v, want 0.67", chain.PCR)
+	}
+	if chain.RiskFreeRate != DefaultRiskFreeRate {
+		t.Errorf("risk-free rate = %v, want %v", chain.RiskFreeRate, DefaultRiskFreeRate)
+	}
+
+	if len(chain.Strikes) != 3 {
+		t.Fatalf("got %d strikes, want 3", len(chain.Strikes))
+	}
+	rows := map[float64]Strike{}
+	for i, row := range chain.Strikes {
+		if want := []float64{21900, 22000, 22100}[i]; row.Strike != want {
+			t.Errorf("strike %d = %v, want %v", i, row.Strike, want)
+		}
+		rows[row.Strike] = row
+	}
+
+	tests := []struct {
+		name      string
+		contract  *Contract
+		wantIV    bool
+		wantDelta float64 // Sign of delta
+	}{
+		{"21900 call", rows[21900].Call, true, 1},
+		{"21900 put", rows[21900].Put, true, -1},
+		{"22000 call", rows[22000].Call, true, 1},
+		{"22100 put without a quote", rows[22100].Put, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.contract == nil {
+				t.Fatal("contract missing")
+			}
+			if (tt.contract.IV != nil) != tt.wantIV {
+				t.Fatalf("IV = %v, want set=%v", tt.contract.IV, tt.wantIV)
+			}
+			if (tt.contract.Greeks != nil) != tt.wantIV {
+				t.Fatalf("greeks = %+v, want set=%v", tt.contract.Greeks, tt.wantIV)
+			}
+			if !tt.wantIV {
+				return
+			}
+			if math.Abs(*tt.contract.IV-vol*100) > 0.01 {
+				t.Errorf("IV = %v%%, want %v%%", *tt.contract.IV, vol*100)
+			}
+			greeks := tt.contract.Greeks
+			if greeks.Delta*tt.wantDelta <= 0 || greeks.Gamma <= 0 || greeks.Vega <= 0 || greeks.Theta >= 0 {
+				t.Errorf("greeks = %+v, want delta sign %v, positive gamma and vega, negative theta", *greeks, tt.wantDelta)
+			}
+		})
+	}
+}
+
+func TestSpotSymbol(t *testing.T) {
+	tests := []struct {
+		underlying string
+		want       string
+	}{
+		{"NIFTY", "NSE:NIFTY 50"},
+		{"BANKNIFTY", "NSE:NIFTY BANK"},
+		{"SENSEX", "BSE:SENSEX"},
+		{"RELIANCE", "NSE:RELIANCE"},
+	}
+
+	for _, tt := range tests {
+		if got := SpotSymbol(tt.underlying); got != tt.want {
+			t.Errorf("SpotSymbol(%q) = %q, want %q", tt.underlying, got, tt.want)
+		}
+	}
+}
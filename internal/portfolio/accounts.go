@@ -0,0 +1,320 @@
+package portfolio
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// Account is one broker account in a multi-account view
+type Account struct {
+	ConfigID    int    `json:"config_id"`
+	BrokerName  string `json:"broker_name"`
+	AccountName string `json:"account_name"`
+	IsDefault   bool   `json:"is_default"`
+	Error       string `json:"error,omitempty"` // Why the account is left out of the totals
+}
+
+// AccountFetch selects what FetchAccounts asks each broker for
+type AccountFetch struct {
+	Positions bool
+	Holdings  bool
+	Margins   bool
+}
+
+// AccountData is what was fetched from one account. Only the requested
+// parts are set, none if Error is.
+type AccountData struct {
+	Account
+	Positions *broker.Positions
+	Holdings  []broker.Holding
+	Margins   *broker.Margins
+}
+
+// FetchAccounts asks each active broker account for the requested data,
+// all accounts at once. An account that can't be reached keeps the error
+// and the others are still returned. Inactive accounts are skipped.
+func FetchAccounts(configs []*broker.BrokerConfig, fetch AccountFetch) []AccountData {
+	data := make([]AccountData, 0, len(configs))
+	for _, config := range configs {
+		if !config.IsActive {
+			continue
+		}
+		data = append(data, AccountData{Account: Account{
+			ConfigID:    config.ConfigID,
+			BrokerName:  config.BrokerName,
+			AccountName: config.AccountName,
+			IsDefault:   config.IsDefault,
+		}})
+	}
+
+	var wg sync.WaitGroup
+	i := 0
+	for _, config := range configs {
+		if !config.IsActive {
+			continue
+		}
+		wg.Add(1)
+		go func(account *AccountData, config *broker.BrokerConfig) {
+			defer wg.Done()
+			account.fetch(config, fetch)
+		}(&data[i], config)
+		i++
+	}
+	wg.Wait()
+
+	return data
+}
+
+// fetch fills in the account's data from its broker, or its error
+func (a *AccountData) fetch(config *broker.BrokerConfig, fetch AccountFetch) {
+	if config.AccessToken == "" {
+		a.Error = "not logged in (no access token)"
+		return
+	}
+
+	brk, err := broker.NewBroker(config)
+	if err != nil {
+		a.Error = "failed to create broker: " + err.Error()
+		return
+	}
+
+	if fetch.Positions {
+		if a.Positions, err = brk.GetPositions(); err != nil {
+			a.Error = "failed to get positions: " + err.Error()
+			return
+		}
+	}
+	if fetch.Holdings {
+		if a.Holdings, err = brk.GetHoldings(); err != nil {
+			a.Error = "failed to get holdings: " + err.Error()
+			return
+		}
+	}
+	if fetch.Margins {
+		if a.Margins, err = brk.GetMargins(); err != nil {
+			a.Error = "failed to get margins: " + err.Error()
+			return
+		}
+	}
+}
+
+// AccountShare is one account's part of a merged holding or position
+type AccountShare struct {
+	ConfigID     int     `json:"config_id"`
+	AccountName  string  `json:"account_name"`
+	Quantity     int     `json:"quantity"`
+	AveragePrice float64 `json:"average_price"`
+	PNL          float64 `json:"pnl"`
+}
+
+// MergedLine is a holding or position summed across accounts. The average
+// price is quantity-weighted, and 0 when the accounts hold opposite sides.
+type MergedLine struct {
+	Symbol       string         `json:"symbol"`
+	Exchange     string         `json:"exchange"`
+	Product      string         `json:"product,omitempty"`
+	Quantity     int            `json:"quantity"`
+	AveragePrice float64        `json:"average_price"`
+	LastPrice    float64        `json:"last_price"`
+	Value        float64        `json:"value"` // Quantity x LastPrice
+	PNL          float64        `json:"pnl"`
+	Accounts     []AccountShare `json:"accounts"`
+}
+
+// lineMerger sums lines of the same instrument in first-seen order
+type lineMerger struct {
+	lines map[string]*MergedLine
+	order []string
+	cost  map[string]float64 // Sum of AveragePrice x Quantity
+	mixed map[string]bool    // Long in one account, short in another
+}
+
+func newLineMerger() *lineMerger {
+	return &lineMerger{
+		lines: make(map[string]*MergedLine),
+		cost:  make(map[string]float64),
+		mixed: make(map[string]bool),
+	}
+}
+
+func (m *lineMerger) add(account Account, symbol, exchange, product string, quantity int, averagePrice, lastPrice, pnl float64) {
+	key := exchange + ":" + symbol + ":" + product
+	line, ok := m.lines[key]
+	if !ok {
+		line = &MergedLine{Symbol: symbol, Exchange: exchange, Product: product}
+		m.lines[key] = line
+		m.order = append(m.order, key)
+	}
+
+	if quantity != 0 && line.Quantity != 0 && (quantity > 0) != (line.Quantity > 0) {
+		m.mixed[key] = true
+	}
+	line.Quantity += quantity
+	line.PNL += pnl
+	if lastPrice > 0 {
+		line.LastPrice = lastPrice
+	}
+	m.cost[key] += averagePrice * float64(quantity)
+	line.Accounts = append(line.Accounts, AccountShare{
+		ConfigID:     account.ConfigID,
+		AccountName:  account.AccountName,
+		Quantity:     quantity,
+		AveragePrice: averagePrice,
+		PNL:          pnl,
+	})
+}
+
+func (m *lineMerger) merged() []MergedLine {
+	lines := make([]MergedLine, 0, len(m.order))
+	for _, key := range m.order {
+		line := m.lines[key]
+		if line.Quantity != 0 && !m.mixed[key] {
+			line.AveragePrice = m.cost[key] / float64(line.Quantity)
+		}
+		line.Value = line.LastPrice * float64(line.Quantity)
+		lines = append(lines, *line)
+	}
+	return lines
+}
+
+// MergePositions sums the accounts' net positions by instrument and product
+func MergePositions(data []AccountData) []MergedLine {
+	merger := newLineMerger()
+	for _, account := range data {
+		if account.Positions == nil {
+			continue
+		}
+		for _, p := range account.Positions.Net {
+			merger.add(account.Account, p.Symbol, p.Exchange, p.Product, p.Quantity, p.AveragePrice, p.LastPrice, p.PNL)
+		}
+	}
+	return merger.merged()
+}
+
+// MergeHoldings sums the accounts' holdings by instrument
+func MergeHoldings(data []AccountData) []MergedLine {
+	merger := newLineMerger()
+	for _, account := range data {
+		for _, h := range account.Holdings {
+			merger.add(account.Account, h.Symbol, h.Exchange, "", h.Quantity, h.AveragePrice, h.LastPrice, h.PNL)
+		}
+	}
+	return merger.merged()
+}
+
+// MarginTotals are an account's margins, or their sum across accounts
+type MarginTotals struct {
+	EquityAvailable    float64 `json:"equity_available"`
+	EquityUsed         float64 `json:"equity_used"`
+	EquityNet          float64 `json:"equity_net"`
+	CommodityAvailable float64 `json:"commodity_available"`
+	CommodityUsed      float64 `json:"commodity_used"`
+	CommodityNet       float64 `json:"commodity_net"`
+}
+
+func (t *MarginTotals) add(margins *broker.Margins) {
+	t.EquityAvailable += margins.Equity.Available
+	t.EquityUsed += margins.Equity.Used
+	t.EquityNet += margins.Equity.Net
+	t.CommodityAvailable += margins.Commodity.Available
+	t.CommodityUsed += margins.Commodity.Used
+	t.CommodityNet += margins.Commodity.Net
+}
+
+// AccountMargins is one account's margins
+type AccountMargins struct {
+	Account
+	Margins *MarginTotals `json:"margins,omitempty"`
+}
+
+// MergeMargins returns each account's margins and their sum
+func MergeMargins(data []AccountData) ([]AccountMargins, MarginTotals) {
+	accounts := make([]AccountMargins, 0, len(data))
+	var totals MarginTotals
+	for _, account := range data {
+		margins := AccountMargins{Account: account.Account}
+		if account.Margins != nil {
+			margins.Margins = &MarginTotals{}
+			margins.Margins.add(account.Margins)
+			totals.add(account.Margins)
+		}
+		accounts = append(accounts, margins)
+	}
+	return accounts, totals
+}
+
+// AccountSummary totals one account's holdings, positions and margins
+type AccountSummary struct {
+	Account
+	HoldingsValue float64       `json:"holdings_value"`
+	HoldingsPNL   float64       `json:"holdings_pnl"`
+	PositionsPNL  float64       `json:"positions_pnl"`
+	OpenPositions int           `json:"open_positions"`
+	Margins       *MarginTotals `json:"margins,omitempty"`
+}
+
+// AccountTotals sums the account summaries of the reachable accounts
+type AccountTotals struct {
+	Accounts      int          `json:"accounts"`
+	HoldingsValue float64      `json:"holdings_value"`
+	HoldingsPNL   float64      `json:"holdings_pnl"`
+	PositionsPNL  float64      `json:"positions_pnl"`
+	OpenPositions int          `json:"open_positions"`
+	Margins       MarginTotals `json:"margins"`
+}
+
+// SummarizeAccounts totals each account and all of them, the largest
+// accounts by holdings first
+func SummarizeAccounts(data []AccountData) ([]AccountSummary, AccountTotals) {
+	summaries := make([]AccountSummary, 0, len(data))
+	var totals AccountTotals
+
+	for _, account := range data {
+		summary := AccountSummary{Account: account.Account}
+		if account.Error != "" {
+			summaries = append(summaries, summary)
+			continue
+		}
+
+		for _, h := range account.Holdings {
+			summary.HoldingsValue += h.LastPrice * float64(h.Quantity)
+			summary.HoldingsPNL += h.PNL
+		}
+		if account.Positions != nil {
+			for _, p := range account.Positions.Net {
+				summary.PositionsPNL += p.PNL
+				if p.Quantity != 0 {
+					summary.OpenPositions++
+				}
+			}
+		}
+		if account.Margins != nil {
+			summary.Margins = &MarginTotals{}
+			summary.Margins.add(account.Margins)
+			totals.Margins.add(account.Margins)
+		}
+
+		totals.Accounts++
+		totals.HoldingsValue += summary.HoldingsValue
+		totals.HoldingsPNL += summary.HoldingsPNL
+		totals.PositionsPNL += summary.PositionsPNL
+		totals.OpenPositions += summary.OpenPositions
+		summaries = append(summaries, summary)
+	}
+
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return summaries[i].HoldingsValue > summaries[j].HoldingsValue
+	})
+	return summaries, totals
+}
+
+// Accounts returns the accounts of the data, with their errors
+func Accounts(data []AccountData) []Account {
+	accounts := make([]Account, len(data))
+	for i, account := range data {
+		accounts[i] = account.Account
+	}
+	return accounts
+}
@@ -0,0 +1,208 @@
+package portfolio
+
+import (
+	"math"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/analyzer"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// minCAGRDays is the shortest history CAGR is annualized from
+const minCAGRDays = 30
+
+// performancePeriods are the lookbacks period returns are reported for
+var performancePeriods = []struct {
+	name  string
+	start func(last time.Time) time.Time
+}{
+	{"1W", func(t time.Time) time.Time { return t.AddDate(0, 0, -7) }},
+	{"1M", func(t time.Time) time.Time { return t.AddDate(0, -1, 0) }},
+	{"3M", func(t time.Time) time.Time { return t.AddDate(0, -3, 0) }},
+	{"6M", func(t time.Time) time.Time { return t.AddDate(0, -6, 0) }},
+	{"1Y", func(t time.Time) time.Time { return t.AddDate(-1, 0, 0) }},
+	{"YTD", func(t time.Time) time.Time { return time.Date(t.Year()-1, 12, 31, 0, 0, 0, 0, t.Location()) }},
+}
+
+// PeriodReturn is the return over one lookback, in percent
+type PeriodReturn struct {
+	Period          string   `json:"period"`
+	Return          float64  `json:"return_pct"`
+	BenchmarkReturn *float64 `json:"benchmark_return_pct,omitempty"`
+	ExcessReturn    *float64 `json:"excess_return_pct,omitempty"`
+}
+
+// SeriesPoint is one session of the performance series. Value is the
+// portfolio's return index (100 at the first session).
+type SeriesPoint struct {
+	Date      time.Time `json:"date"`
+	Value     float64   `json:"value"`
+	NetValue  float64   `json:"net_value"`
+	Benchmark *float64  `json:"benchmark,omitempty"` // Benchmark index, 100 at the first session
+}
+
+// BenchmarkPerformance is the benchmark's performance over the same sessions
+type BenchmarkPerformance struct {
+	Symbol      string               `json:"symbol"`
+	TotalReturn float64              `json:"total_return_pct"`
+	CAGR        *float64             `json:"cagr_pct,omitempty"`
+	RiskMetrics analyzer.RiskMetrics `json:"risk_metrics"`
+}
+
+// Performance is the portfolio's performance over its stored history.
+// Returns chain each session's change against the previous close, so
+// deposits, withdrawals and new buys don't count as performance.
+type Performance struct {
+	Account     string                `json:"account"`
+	From        time.Time             `json:"from"`
+	To          time.Time             `json:"to"`
+	Sessions    int                   `json:"sessions"`
+	TotalReturn float64               `json:"total_return_pct"`
+	CAGR        *float64              `json:"cagr_pct,omitempty"` // Once the history spans minCAGRDays
+	RiskMetrics analyzer.RiskMetrics  `json:"risk_metrics"`
+	Periods     []PeriodReturn        `json:"periods"`
+	Benchmark   *BenchmarkPerformance `json:"benchmark,omitempty"`
+	Series      []SeriesPoint         `json:"series"`
+}
+
+// series is a dated value series, oldest first
+type series struct {
+	dates  []time.Time
+	values []float64
+}
+
+// on returns the last value on or before t
+func (s series) on(t time.Time) (float64, bool) {
+	value, ok := 0.0, false
+	for i, date := range s.dates {
+		if date.After(t) {
+			break
+		}
+		value, ok = s.values[i], true
+	}
+	return value, ok
+}
+
+// returnSince returns the percent change from the value on start to the
+// last value, false if the series starts after start
+func (s series) returnSince(start time.Time) (float64, bool) {
+	from, ok := s.on(start)
+	if !ok || from == 0 {
+		return 0, false
+	}
+	return (s.values[len(s.values)-1]/from - 1) * 100, true
+}
+
+// cagr annualizes the series' total return, false for short histories
+func (s series) cagr() (float64, bool) {
+	first, last := s.values[0], s.values[len(s.values)-1]
+	days := s.dates[len(s.dates)-1].Sub(s.dates[0]).Hours() / 24
+	if days < minCAGRDays || first <= 0 || last <= 0 {
+		return 0, false
+	}
+	return (math.Pow(last/first, 365.25/days) - 1) * 100, true
+}
+
+// sessionDate is the calendar date of an IST session, at UTC midnight like
+// the DATE columns
+func sessionDate(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// AnalyzePerformance computes period returns, CAGR and risk metrics of the
+// stored portfolio history (oldest first), compared with the benchmark's
+// daily candles when there are any. Nil without history.
+func AnalyzePerformance(days []database.PortfolioDay, benchmarkSymbol string, benchmark []broker.Candle) *Performance {
+	if len(days) == 0 {
+		return nil
+	}
+
+	portfolio := series{}
+	perf := &Performance{
+		Account:  days[0].Account,
+		From:     days[0].Date,
+		To:       days[len(days)-1].Date,
+		Sessions: len(days),
+		Series:   make([]SeriesPoint, 0, len(days)),
+	}
+
+	value := 100.0
+	for i, day := range days {
+		if i > 0 {
+			value *= 1 + day.DayChangePct/100
+		}
+		date := sessionDate(day.Date, time.UTC)
+		portfolio.dates = append(portfolio.dates, date)
+		portfolio.values = append(portfolio.values, value)
+		perf.Series = append(perf.Series, SeriesPoint{
+			Date:     date,
+			Value:    round2(value),
+			NetValue: day.NetValue,
+		})
+	}
+
+	perf.TotalReturn = round2(value - 100)
+	if cagr, ok := portfolio.cagr(); ok {
+		perf.CAGR = roundPtr(cagr)
+	}
+	perf.RiskMetrics = analyzer.CalculateRiskMetrics(portfolio.values)
+
+	// Benchmark closes over the same sessions, indexed like the portfolio
+	bench := series{}
+	for _, candle := range benchmark {
+		date := sessionDate(candle.Date, ist)
+		if date.Before(portfolio.dates[0]) || date.After(portfolio.dates[len(portfolio.dates)-1]) {
+			continue
+		}
+		bench.dates = append(bench.dates, date)
+		bench.values = append(bench.values, candle.Close)
+	}
+	if len(bench.values) > 0 {
+		for i := range perf.Series {
+			if close, ok := bench.on(perf.Series[i].Date); ok {
+				perf.Series[i].Benchmark = roundPtr(close / bench.values[0] * 100)
+			}
+		}
+
+		perf.Benchmark = &BenchmarkPerformance{
+			Symbol:      benchmarkSymbol,
+			TotalReturn: round2((bench.values[len(bench.values)-1]/bench.values[0] - 1) * 100),
+			RiskMetrics: analyzer.CalculateRiskMetrics(bench.values),
+		}
+		if cagr, ok := bench.cagr(); ok {
+			perf.Benchmark.CAGR = roundPtr(cagr)
+		}
+	}
+
+	last := portfolio.dates[len(portfolio.dates)-1]
+	perf.Periods = []PeriodReturn{}
+	for _, period := range performancePeriods {
+		start := period.start(last)
+		ret, ok := portfolio.returnSince(start)
+		if !ok {
+			continue // History too short
+		}
+
+		result := PeriodReturn{Period: period.name, Return: round2(ret)}
+		if len(bench.values) > 0 {
+			if benchRet, ok := bench.returnSince(start); ok {
+				result.BenchmarkReturn = roundPtr(benchRet)
+				result.ExcessReturn = roundPtr(ret - benchRet)
+			}
+		}
+		perf.Periods = append(perf.Periods, result)
+	}
+
+	return perf
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func roundPtr(v float64) *float64 {
+	r := round2(v)
+	return &r
+}
@@ -0,0 +1,303 @@
+// Package portfolio values the broker account's holdings and positions at
+// live prices: the collectors' latest quotes when fresh, the broker's last
+// price otherwise.
+package portfolio
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/quotes"
+	"github.com/trading-chitti/market-bridge/internal/watchlist"
+)
+
+// UnknownSector is the sector of symbols in no sector watchlist
+const UnknownSector = "OTHER"
+
+// Previous closes are refreshed each IST day
+var ist = broker.IST()
+
+// Line is one holding or position valued at the live price
+type Line struct {
+	Kind         string  `json:"kind"` // holding or position
+	Symbol       string  `json:"symbol"`
+	Exchange     string  `json:"exchange"`
+	Product      string  `json:"product,omitempty"`
+	Sector       string  `json:"sector"`
+	Quantity     int     `json:"quantity"`
+	AveragePrice float64 `json:"average_price"`
+	LastPrice    float64 `json:"last_price"`
+	PrevClose    float64 `json:"prev_close,omitempty"`
+	Value        float64 `json:"value"` // Quantity x LastPrice, negative for shorts
+	PNL          float64 `json:"pnl"`
+	DayChange    float64 `json:"day_change"`
+	Live         bool    `json:"live"` // LastPrice is a collector quote
+}
+
+// SectorExposure is the gross value held in a sector
+type SectorExposure struct {
+	Sector  string   `json:"sector"`
+	Value   float64  `json:"value"`
+	Pct     float64  `json:"pct"` // Of the gross value
+	Symbols []string `json:"symbols"`
+}
+
+// Snapshot is the portfolio valued at one point in time
+type Snapshot struct {
+	Holdings     []Line           `json:"holdings"`
+	Positions    []Line           `json:"positions"`
+	GrossValue   float64          `json:"gross_value"` // Sum of |Value|
+	NetValue     float64          `json:"net_value"`
+	TotalPNL     float64          `json:"total_pnl"`
+	DayChange    float64          `json:"day_change"`
+	DayChangePct float64          `json:"day_change_pct"` // Of the value at the previous close
+	Exposure     []SectorExposure `json:"exposure"`
+	Timestamp    time.Time        `json:"timestamp"`
+}
+
+// Service values a broker account's portfolio
+type Service struct {
+	brk     broker.Broker
+	quotes  *quotes.Store
+	sectors map[string]string // symbol -> sector
+
+	// Previous closes, fetched once a day
+	mu         sync.Mutex
+	closes     map[string]float64 // "EXCHANGE:SYMBOL" -> previous close
+	closesDate string
+}
+
+// NewService creates a portfolio service. store may be nil to value at the
+// broker's prices only.
+func NewService(brk broker.Broker, store *quotes.Store) *Service {
+	return &Service{
+		brk:     brk,
+		quotes:  store,
+		sectors: sectorMap(),
+		closes:  make(map[string]float64),
+	}
+}
+
+// sectorMap maps the symbols of the sector watchlists to their sector. A
+// symbol in several keeps the first.
+func sectorMap() map[string]string {
+	sectors := make(map[string]string)
+	for _, wl := range watchlist.GetWatchlistsByCategory("sector") {
+		for _, symbol := range wl.Symbols {
+			if _, ok := sectors[symbol]; !ok {
+				sectors[symbol] = wl.Name
+			}
+		}
+	}
+	return sectors
+}
+
+// Sector returns a symbol's sector, UnknownSector if it is in no sector
+// watchlist
+func (s *Service) Sector(symbol string) string {
+	if sector, ok := s.sectors[strings.ToUpper(symbol)]; ok {
+		return sector
+	}
+	return UnknownSector
+}
+
+// Snapshot values the current holdings and positions
+func (s *Service) Snapshot() (*Snapshot, error) {
+	holdings, err := s.brk.GetHoldings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get holdings: %w", err)
+	}
+	positions, err := s.brk.GetPositions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get positions: %w", err)
+	}
+
+	snapshot := &Snapshot{
+		Holdings:  make([]Line, 0, len(holdings)),
+		Positions: make([]Line, 0, len(positions.Net)),
+		Timestamp: time.Now(),
+	}
+
+	var instruments []string
+	for _, h := range holdings {
+		instruments = append(instruments, h.Exchange+":"+h.Symbol)
+	}
+	for _, p := range positions.Net {
+		instruments = append(instruments, p.Exchange+":"+p.Symbol)
+	}
+	closes := s.prevCloses(instruments)
+
+	for _, h := range holdings {
+		line := s.line("holding", h.Symbol, h.Exchange, "", h.Quantity, h.AveragePrice, h.LastPrice)
+		line.PNL = (line.LastPrice - h.AveragePrice) * float64(h.Quantity)
+		if prevClose, ok := closes[h.Exchange+":"+h.Symbol]; ok {
+			line.PrevClose = prevClose
+			line.DayChange = (line.LastPrice - prevClose) * float64(h.Quantity)
+		}
+		snapshot.Holdings = append(snapshot.Holdings, line)
+	}
+
+	for _, p := range positions.Net {
+		line := s.line("position", p.Symbol, p.Exchange, p.Product, p.Quantity, p.AveragePrice, p.LastPrice)
+		// The broker's P&L includes booked profit, so move it by the
+		// price change since the broker's last price
+		line.PNL = p.PNL + (line.LastPrice-p.LastPrice)*float64(p.Quantity)
+		line.DayChange = line.PNL
+		if prevClose, ok := closes[p.Exchange+":"+p.Symbol]; ok {
+			line.PrevClose = prevClose
+			if p.Overnight {
+				line.DayChange = (line.LastPrice - prevClose) * float64(p.Quantity)
+			}
+		}
+		snapshot.Positions = append(snapshot.Positions, line)
+	}
+
+	snapshot.total()
+	return snapshot, nil
+}
+
+// Account names the broker account in the portfolio history
+func (s *Service) Account() string {
+	return s.brk.GetBrokerName()
+}
+
+// RecordDay values the portfolio and stores it as the current session's
+// entry in the portfolio history
+func (s *Service) RecordDay(db *database.Database) (*database.PortfolioDay, error) {
+	snapshot, err := s.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	day := &database.PortfolioDay{
+		Account:      s.Account(),
+		Date:         snapshot.Timestamp,
+		NetValue:     snapshot.NetValue,
+		GrossValue:   snapshot.GrossValue,
+		TotalPNL:     snapshot.TotalPNL,
+		DayChange:    snapshot.DayChange,
+		DayChangePct: snapshot.DayChangePct,
+	}
+	for _, line := range snapshot.Holdings {
+		day.HoldingsValue += line.Value
+	}
+	for _, line := range snapshot.Positions {
+		day.PositionsValue += line.Value
+	}
+
+	if err := db.SavePortfolioDay(day); err != nil {
+		return nil, err
+	}
+	return day, nil
+}
+
+// line values a holding or position at the live price
+func (s *Service) line(kind, symbol, exchange, product string, quantity int, averagePrice, brokerPrice float64) Line {
+	line := Line{
+		Kind:         kind,
+		Symbol:       symbol,
+		Exchange:     exchange,
+		Product:      product,
+		Sector:       s.Sector(symbol),
+		Quantity:     quantity,
+		AveragePrice: averagePrice,
+		LastPrice:    brokerPrice,
+	}
+	if s.quotes != nil {
+		if quote, ok := s.quotes.Get(exchange, symbol); ok {
+			line.LastPrice = quote.LastPrice
+			line.Live = true
+		}
+	}
+	line.Value = line.LastPrice * float64(quantity)
+	return line
+}
+
+// total sums the lines into the snapshot's totals and sector exposure
+func (snapshot *Snapshot) total() {
+	sectors := make(map[string]*SectorExposure)
+	var prevValue float64
+
+	lines := append(append([]Line{}, snapshot.Holdings...), snapshot.Positions...)
+	for _, line := range lines {
+		gross := line.Value
+		if gross < 0 {
+			gross = -gross
+		}
+
+		snapshot.GrossValue += gross
+		snapshot.NetValue += line.Value
+		snapshot.TotalPNL += line.PNL
+		snapshot.DayChange += line.DayChange
+		prevValue += line.Value - line.DayChange
+
+		if line.Quantity == 0 {
+			continue
+		}
+		exposure, ok := sectors[line.Sector]
+		if !ok {
+			exposure = &SectorExposure{Sector: line.Sector}
+			sectors[line.Sector] = exposure
+		}
+		exposure.Value += gross
+		exposure.Symbols = append(exposure.Symbols, line.Symbol)
+	}
+
+	if prevValue != 0 {
+		snapshot.DayChangePct = snapshot.DayChange / prevValue * 100
+	}
+
+	snapshot.Exposure = make([]SectorExposure, 0, len(sectors))
+	for _, exposure := range sectors {
+		if snapshot.GrossValue > 0 {
+			exposure.Pct = exposure.Value / snapshot.GrossValue * 100
+		}
+		snapshot.Exposure = append(snapshot.Exposure, *exposure)
+	}
+	sort.Slice(snapshot.Exposure, func(i, j int) bool {
+		return snapshot.Exposure[i].Value > snapshot.Exposure[j].Value
+	})
+}
+
+// prevCloses returns the previous closes of the instruments, asking the
+// broker only for those not fetched yet today. Instruments it can't quote
+// are left out.
+func (s *Service) prevCloses(instruments []string) map[string]float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	today := time.Now().In(ist).Format("2006-01-02")
+	if s.closesDate != today {
+		s.closes = make(map[string]float64)
+		s.closesDate = today
+	}
+
+	var missing []string
+	for _, instrument := range instruments {
+		if _, ok := s.closes[instrument]; !ok {
+			missing = append(missing, instrument)
+		}
+	}
+	if len(missing) > 0 {
+		if quotes, err := s.brk.GetQuote(missing); err == nil {
+			// Zero marks instruments without a close so they aren't asked
+			// for again today
+			for _, instrument := range missing {
+				s.closes[instrument] = quotes[instrument].Close
+			}
+		}
+	}
+
+	closes := make(map[string]float64, len(instruments))
+	for _, instrument := range instruments {
+		if prevClose := s.closes[instrument]; prevClose > 0 {
+			closes[instrument] = prevClose
+		}
+	}
+	return closes
+}
@@ -0,0 +1,75 @@
+package quality
+
+import (
+	"log"
+
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/metrics"
+)
+
+// Monitor validates the records collectors are about to store, records
+// the issues found and counts them in Prometheus
+type Monitor struct {
+	db        *database.Database
+	validator *Validator
+}
+
+// NewMonitor creates a monitor; zero config fields take the defaults
+func NewMonitor(db *database.Database, cfg Config) *Monitor {
+	return &Monitor{
+		db:        db,
+		validator: NewValidator(cfg),
+	}
+}
+
+// AcceptBar checks a bar and reports whether it should be stored
+func (m *Monitor) AcceptBar(bar *database.IntradayBar) bool {
+	issues := m.validator.CheckBar(bar)
+	if len(issues) == 0 {
+		return true
+	}
+
+	m.record("bar", bar.Exchange, bar.Symbol, bar.Timeframe, bar.Source, issues)
+	return !Rejected(issues)
+}
+
+// AcceptTick checks a tick and reports whether it should be stored
+func (m *Monitor) AcceptTick(tick *database.TickData) bool {
+	issues := m.validator.CheckTick(tick)
+	if len(issues) == 0 {
+		return true
+	}
+
+	m.record("tick", tick.Exchange, tick.Symbol, "", tick.Source, issues)
+	return !Rejected(issues)
+}
+
+func (m *Monitor) record(recordType, exchange, symbol, timeframe, source string, issues []Issue) {
+	records := make([]database.QualityIssue, 0, len(issues))
+	for _, issue := range issues {
+		if issue.Severity == SeverityReject {
+			metrics.RecordQualityRejection(recordType, issue.Code)
+		} else {
+			metrics.RecordQualitySuspect(recordType, issue.Code)
+		}
+
+		records = append(records, database.QualityIssue{
+			Exchange:        exchange,
+			Symbol:          symbol,
+			RecordType:      recordType,
+			Timeframe:       timeframe,
+			RecordTimestamp: issue.Timestamp,
+			Code:            issue.Code,
+			Severity:        issue.Severity,
+			Message:         issue.Message,
+			Source:          source,
+		})
+	}
+
+	if Rejected(issues) {
+		log.Printf("🚫 Rejected %s %s %s: %s", recordType, symbol, issues[0].Timestamp.Format("15:04:05"), issues[0].Message)
+	}
+	if err := m.db.InsertQualityIssues(records); err != nil {
+		log.Printf("⚠️  Failed to record quality issues for %s: %v", symbol, err)
+	}
+}
@@ -0,0 +1,334 @@
+// Package quality validates the bars and ticks collectors write. Records
+// that can't be right (non-positive prices, inconsistent OHLC, duplicate
+// ticks) are rejected; records that look wrong but may be real (spikes far
+// beyond the recent ATR, out-of-order bars) are stored and tagged as
+// suspect.
+package quality
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// Issue codes
+const (
+	CodeNonPositivePrice   = "non_positive_price"
+	CodeNegativeVolume     = "negative_volume"
+	CodeOHLCInconsistent   = "ohlc_inconsistent"
+	CodePriceSpike         = "price_spike"
+	CodeDuplicateTimestamp = "duplicate_timestamp"
+	CodeOutOfOrder         = "out_of_order"
+)
+
+// Issue severities: rejected records are not stored, suspect ones are
+// stored and tagged
+const (
+	SeverityReject  = "reject"
+	SeveritySuspect = "suspect"
+)
+
+// Issue is a problem found in a bar or tick
+type Issue struct {
+	Code      string    `json:"code"`
+	Severity  string    `json:"severity"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"` // Bar or tick timestamp
+}
+
+// Config tunes the checks
+type Config struct {
+	ATRPeriod        int     // Bars in the ATR spikes are measured against
+	SpikeATRMultiple float64 // A bar's true range beyond this many ATRs is a spike
+	MaxTickMovePct   float64 // A tick moving more than this from the previous one is a spike
+}
+
+// DefaultConfig returns the default thresholds
+func DefaultConfig() Config {
+	return Config{
+		ATRPeriod:        14,
+		SpikeATRMultiple: 8,
+		MaxTickMovePct:   10,
+	}
+}
+
+// Validator checks records against each other per symbol and timeframe, so
+// it must see a series' records in order. It is safe for concurrent use.
+type Validator struct {
+	cfg Config
+
+	mu    sync.Mutex
+	bars  map[string]*barState
+	ticks map[string]*tickState
+}
+
+type barState struct {
+	lastTimestamp time.Time
+	prevClose     float64 // Close of the bar before lastTimestamp's
+	lastClose     float64
+	atr           float64
+	count         int
+}
+
+type tickState struct {
+	timestamp time.Time
+	price     float64
+	quantity  int64
+}
+
+// NewValidator creates a validator; zero config fields take the defaults
+func NewValidator(cfg Config) *Validator {
+	defaults := DefaultConfig()
+	if cfg.ATRPeriod <= 0 {
+		cfg.ATRPeriod = defaults.ATRPeriod
+	}
+	if cfg.SpikeATRMultiple <= 0 {
+		cfg.SpikeATRMultiple = defaults.SpikeATRMultiple
+	}
+	if cfg.MaxTickMovePct <= 0 {
+		cfg.MaxTickMovePct = defaults.MaxTickMovePct
+	}
+
+	return &Validator{
+		cfg:   cfg,
+		bars:  make(map[string]*barState),
+		ticks: make(map[string]*tickState),
+	}
+}
+
+// CheckBar validates a bar. A bar with the same timestamp as the previous
+// one is a revision of it (collectors re-flush the current minute) and is
+// checked against the bar before.
+func (v *Validator) CheckBar(bar *database.IntradayBar) []Issue {
+	issues := checkBarValues(bar)
+	if Rejected(issues) {
+		return issues
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key := bar.Exchange + ":" + bar.Symbol + ":" + bar.Timeframe
+	state, ok := v.bars[key]
+	if !ok {
+		state = &barState{}
+		v.bars[key] = state
+	}
+
+	switch {
+	case state.count > 0 && bar.BarTimestamp.Before(state.lastTimestamp):
+		return append(issues, Issue{
+			Code:      CodeOutOfOrder,
+			Severity:  SeveritySuspect,
+			Message:   fmt.Sprintf("bar older than the last one at %s", state.lastTimestamp.Format(time.RFC3339)),
+			Timestamp: bar.BarTimestamp,
+		})
+	case state.count > 0 && bar.BarTimestamp.Equal(state.lastTimestamp):
+		// Revision: measure against the bar before, keep the ATR as is
+		if issue := v.spike(bar, state.prevClose, state); issue != nil {
+			issues = append(issues, *issue)
+		}
+		state.lastClose = bar.Close
+		return issues
+	}
+
+	if issue := v.spike(bar, state.lastClose, state); issue != nil {
+		issues = append(issues, *issue)
+	}
+
+	// Spikes don't feed the ATR, or one bad bar would hide the next
+	if len(issues) == 0 {
+		tr := trueRange(bar, state.lastClose)
+		if state.count < v.cfg.ATRPeriod {
+			state.atr = (state.atr*float64(state.count) + tr) / float64(state.count+1)
+		} else {
+			state.atr = (state.atr*float64(v.cfg.ATRPeriod-1) + tr) / float64(v.cfg.ATRPeriod)
+		}
+		state.count++
+	}
+	state.prevClose = state.lastClose
+	state.lastClose = bar.Close
+	state.lastTimestamp = bar.BarTimestamp
+
+	return issues
+}
+
+// spike flags a bar whose true range is far beyond the ATR, once the ATR
+// covers a full period
+func (v *Validator) spike(bar *database.IntradayBar, prevClose float64, state *barState) *Issue {
+	if state.count < v.cfg.ATRPeriod || state.atr <= 0 {
+		return nil
+	}
+
+	tr := trueRange(bar, prevClose)
+	if tr <= v.cfg.SpikeATRMultiple*state.atr {
+		return nil
+	}
+	return &Issue{
+		Code:      CodePriceSpike,
+		Severity:  SeveritySuspect,
+		Message:   fmt.Sprintf("range %.2f is %.1fx the %d-bar ATR %.2f", tr, tr/state.atr, v.cfg.ATRPeriod, state.atr),
+		Timestamp: bar.BarTimestamp,
+	}
+}
+
+// CheckTick validates a tick against the symbol's previous one
+func (v *Validator) CheckTick(tick *database.TickData) []Issue {
+	var issues []Issue
+	if tick.Price <= 0 {
+		issues = append(issues, Issue{
+			Code:      CodeNonPositivePrice,
+			Severity:  SeverityReject,
+			Message:   fmt.Sprintf("price %.2f", tick.Price),
+			Timestamp: tick.TickTimestamp,
+		})
+	}
+	if tick.Quantity < 0 {
+		issues = append(issues, Issue{
+			Code:      CodeNegativeVolume,
+			Severity:  SeverityReject,
+			Message:   fmt.Sprintf("quantity %d", tick.Quantity),
+			Timestamp: tick.TickTimestamp,
+		})
+	}
+	if len(issues) > 0 {
+		return issues
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key := tick.Exchange + ":" + tick.Symbol
+	last, ok := v.ticks[key]
+	if !ok {
+		v.ticks[key] = &tickState{timestamp: tick.TickTimestamp, price: tick.Price, quantity: tick.Quantity}
+		return nil
+	}
+
+	if tick.TickTimestamp.Equal(last.timestamp) && tick.Price == last.price && tick.Quantity == last.quantity {
+		return []Issue{{
+			Code:      CodeDuplicateTimestamp,
+			Severity:  SeverityReject,
+			Message:   "same timestamp, price and quantity as the previous tick",
+			Timestamp: tick.TickTimestamp,
+		}}
+	}
+
+	if move := math.Abs(tick.Price-last.price) / last.price * 100; move > v.cfg.MaxTickMovePct {
+		issues = append(issues, Issue{
+			Code:      CodePriceSpike,
+			Severity:  SeveritySuspect,
+			Message:   fmt.Sprintf("moved %.1f%% from the previous tick %.2f", move, last.price),
+			Timestamp: tick.TickTimestamp,
+		})
+	}
+
+	last.timestamp, last.price, last.quantity = tick.TickTimestamp, tick.Price, tick.Quantity
+	return issues
+}
+
+// Rejected reports whether any issue rejects the record
+func Rejected(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityReject {
+			return true
+		}
+	}
+	return false
+}
+
+// checkBarValues checks a bar on its own
+func checkBarValues(bar *database.IntradayBar) []Issue {
+	var issues []Issue
+	reject := func(code, message string) {
+		issues = append(issues, Issue{Code: code, Severity: SeverityReject, Message: message, Timestamp: bar.BarTimestamp})
+	}
+
+	if bar.Open <= 0 || bar.High <= 0 || bar.Low <= 0 || bar.Close <= 0 {
+		reject(CodeNonPositivePrice, fmt.Sprintf("O=%.2f H=%.2f L=%.2f C=%.2f", bar.Open, bar.High, bar.Low, bar.Close))
+	}
+	if bar.Volume < 0 {
+		reject(CodeNegativeVolume, fmt.Sprintf("volume %d", bar.Volume))
+	}
+	if bar.High < bar.Low || bar.High < math.Max(bar.Open, bar.Close) || bar.Low > math.Min(bar.Open, bar.Close) {
+		reject(CodeOHLCInconsistent, fmt.Sprintf("O=%.2f H=%.2f L=%.2f C=%.2f", bar.Open, bar.High, bar.Low, bar.Close))
+	}
+
+	return issues
+}
+
+// trueRange is the bar's range including any gap from the previous close
+func trueRange(bar *database.IntradayBar, prevClose float64) float64 {
+	tr := bar.High - bar.Low
+	if prevClose > 0 {
+		tr = math.Max(tr, math.Max(math.Abs(bar.High-prevClose), math.Abs(bar.Low-prevClose)))
+	}
+	return tr
+}
+
+// Report summarizes the quality of a series of stored bars
+type Report struct {
+	BarsChecked  int            `json:"bars_checked"`
+	RejectedBars int            `json:"rejected_bars"` // Would have been rejected on the way in
+	SuspectBars  int            `json:"suspect_bars"`
+	Score        float64        `json:"score"` // Percent of bars without issues
+	IssueCounts  map[string]int `json:"issue_counts"`
+	Issues       []Issue        `json:"issues"`
+}
+
+// ScanBars checks stored bars of one symbol and timeframe as if they came
+// in in timestamp order, also flagging timestamps stored more than once
+// (e.g. from different sources or exchanges)
+func ScanBars(bars []database.IntradayBar, cfg Config) *Report {
+	sorted := append([]database.IntradayBar{}, bars...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].BarTimestamp.Before(sorted[j].BarTimestamp)
+	})
+
+	validator := NewValidator(cfg)
+	report := &Report{
+		BarsChecked: len(sorted),
+		IssueCounts: make(map[string]int),
+		Issues:      []Issue{},
+	}
+
+	for i := range sorted {
+		bar := sorted[i]
+		bar.Exchange = "" // Series are compared across exchanges for duplicates
+
+		var issues []Issue
+		if i > 0 && bar.BarTimestamp.Equal(sorted[i-1].BarTimestamp) {
+			issues = append(checkBarValues(&bar), Issue{
+				Code:      CodeDuplicateTimestamp,
+				Severity:  SeveritySuspect,
+				Message:   fmt.Sprintf("stored more than once (%s, %s)", sorted[i-1].Source, bar.Source),
+				Timestamp: bar.BarTimestamp,
+			})
+		} else {
+			issues = validator.CheckBar(&bar)
+		}
+
+		if len(issues) == 0 {
+			continue
+		}
+		if Rejected(issues) {
+			report.RejectedBars++
+		} else {
+			report.SuspectBars++
+		}
+		for _, issue := range issues {
+			report.IssueCounts[issue.Code]++
+		}
+		report.Issues = append(report.Issues, issues...)
+	}
+
+	if report.BarsChecked > 0 {
+		clean := report.BarsChecked - report.RejectedBars - report.SuspectBars
+		report.Score = float64(clean) / float64(report.BarsChecked) * 100
+	}
+	return report
+}
@@ -0,0 +1,225 @@
+// Package quotes keeps the latest quote of every symbol the collectors
+// receive ticks for, so the API can answer LTP and latest-bar requests from
+// memory during market hours.
+package quotes
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/timeframe"
+)
+
+// DefaultMaxAge is how long a quote is served without a new tick. Older
+// quotes (unsubscribed symbols, market closed) are left to the broker or
+// the database.
+const DefaultMaxAge = time.Minute
+
+// Quote is the latest tick of a symbol
+type Quote struct {
+	Exchange        string    `json:"exchange"`
+	Symbol          string    `json:"symbol"`
+	InstrumentToken int64     `json:"instrument_token"`
+	LastPrice       float64   `json:"last_price"`
+	LastQuantity    int64     `json:"last_quantity"`
+	Volume          int64     `json:"volume"`    // Cumulative day volume
+	Timestamp       time.Time `json:"timestamp"` // Exchange time of the tick
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// sessionOpen is when the NSE/BSE session opens after IST midnight.
+// DayStats only serves days the store saw from within openGrace of it.
+const (
+	sessionOpen = 9*time.Hour + 15*time.Minute
+	openGrace   = time.Minute
+)
+
+type entry struct {
+	quote Quote
+	bar   database.IntradayBar // 1m bar being built from the ticks
+	day   day                  // Stats of the IST day of the latest tick
+}
+
+// day accumulates a symbol's ticks of one IST day
+type day struct {
+	start                 time.Time // IST midnight
+	first                 time.Time // Exchange time of the first tick seen
+	open, high, low, last float64
+	minutes               int // Minutes with ticks
+}
+
+// Store holds the latest quote per exchange and symbol. It is safe for
+// concurrent use.
+type Store struct {
+	maxAge time.Duration
+
+	mu      sync.RWMutex
+	entries map[string]*entry
+}
+
+// NewStore creates a store serving quotes up to maxAge old (DefaultMaxAge
+// if zero)
+func NewStore(maxAge time.Duration) *Store {
+	if maxAge <= 0 {
+		maxAge = DefaultMaxAge
+	}
+	return &Store{
+		maxAge:  maxAge,
+		entries: make(map[string]*entry),
+	}
+}
+
+func key(exchange, symbol string) string {
+	return strings.ToUpper(exchange) + ":" + strings.ToUpper(symbol)
+}
+
+// lookupKey returns the key a symbol's quote is kept under. Collectors
+// store indices on database.IndexExchange, so NSE:NIFTY 50 finds them too.
+func lookupKey(exchange, symbol string) string {
+	if index, ok := database.ResolveIndex(exchange, symbol); ok {
+		return key(database.IndexExchange, index.Symbol)
+	}
+	return key(exchange, symbol)
+}
+
+// Update records a tick
+func (s *Store) Update(tick *database.TickData, volume int64) {
+	now := time.Now()
+	minute := tick.TickTimestamp.Truncate(time.Minute)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := key(tick.Exchange, tick.Symbol)
+	e, ok := s.entries[k]
+	if !ok {
+		e = &entry{}
+		s.entries[k] = e
+	}
+
+	e.quote = Quote{
+		Exchange:        tick.Exchange,
+		Symbol:          tick.Symbol,
+		InstrumentToken: tick.InstrumentToken,
+		LastPrice:       tick.Price,
+		LastQuantity:    tick.Quantity,
+		Volume:          volume,
+		Timestamp:       tick.TickTimestamp,
+		UpdatedAt:       now,
+	}
+
+	d := &e.day
+	if start, _ := database.ISTDay(tick.TickTimestamp); !d.start.Equal(start) {
+		*d = day{
+			start: start,
+			first: tick.TickTimestamp,
+			open:  tick.Price,
+			high:  tick.Price,
+			low:   tick.Price,
+		}
+	}
+	if tick.Price > d.high {
+		d.high = tick.Price
+	}
+	if tick.Price < d.low {
+		d.low = tick.Price
+	}
+	d.last = tick.Price
+
+	bar := &e.bar
+	if !bar.BarTimestamp.Equal(minute) {
+		d.minutes++
+		*bar = database.IntradayBar{
+			Exchange:        tick.Exchange,
+			Symbol:          tick.Symbol,
+			InstrumentToken: tick.InstrumentToken,
+			BarTimestamp:    minute,
+			Timeframe:       timeframe.Minute1.String(),
+			Open:            tick.Price,
+			High:            tick.Price,
+			Low:             tick.Price,
+			Source:          "memory",
+		}
+	}
+	if tick.Price > bar.High {
+		bar.High = tick.Price
+	}
+	if tick.Price < bar.Low {
+		bar.Low = tick.Price
+	}
+	bar.Close = tick.Price
+	bar.Volume += tick.Quantity
+	bar.CreatedAt = now
+}
+
+// Get returns a symbol's quote if it is fresh
+func (s *Store) Get(exchange, symbol string) (Quote, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	e, ok := s.entries[lookupKey(exchange, symbol)]
+	if !ok || time.Since(e.quote.UpdatedAt) > s.maxAge {
+		return Quote{}, false
+	}
+	return e.quote, true
+}
+
+// LatestBar returns the 1m bar being built from a symbol's ticks if its
+// quote is fresh
+func (s *Store) LatestBar(exchange, symbol string) (*database.IntradayBar, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	e, ok := s.entries[lookupKey(exchange, symbol)]
+	if !ok || time.Since(e.quote.UpdatedAt) > s.maxAge {
+		return nil, false
+	}
+	bar := e.bar
+	return &bar, true
+}
+
+// DayStats returns the stats of a symbol's trading day so far from its
+// ticks, if its quote is fresh and the store has seen the day from the
+// session open. A store started mid-session leaves the day to the database.
+// The volume is the exchange's day volume.
+func (s *Store) DayStats(exchange, symbol string) (*database.IntradayStats, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	e, ok := s.entries[lookupKey(exchange, symbol)]
+	if !ok || time.Since(e.quote.UpdatedAt) > s.maxAge {
+		return nil, false
+	}
+	d := e.day
+	if d.first.After(d.start.Add(sessionOpen + openGrace)) {
+		return nil, false
+	}
+
+	stats := database.NewIntradayStats(d.open, d.high, d.low, d.last, e.quote.Volume, d.minutes, e.quote.Timestamp)
+	return &stats, true
+}
+
+// LTP returns the last prices of the instruments ("NSE:RELIANCE", or
+// "RELIANCE" for NSE) with fresh quotes, keyed like the request, and the
+// instruments without
+func (s *Store) LTP(instruments []string) (map[string]float64, []string) {
+	prices := make(map[string]float64, len(instruments))
+	var missing []string
+
+	for _, instrument := range instruments {
+		exchange, symbol := "NSE", instrument
+		if i := strings.Index(instrument, ":"); i >= 0 {
+			exchange, symbol = instrument[:i], instrument[i+1:]
+		}
+
+		if quote, ok := s.Get(exchange, symbol); ok {
+			prices[instrument] = quote.LastPrice
+		} else {
+			missing = append(missing, instrument)
+		}
+	}
+
+	return prices, missing
+}
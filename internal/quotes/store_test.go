@@ -0,0 +1,128 @@
+package quotes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+func TestStoreDayStats(t *testing.T) {
+	ist := time.FixedZone("IST", 5*3600+1800)
+	today, _ := database.ISTDay(time.Now())
+	at := func(hour, min, sec int) time.Time {
+		return today.Add(time.Duration(hour)*time.Hour + time.Duration(min)*time.Minute + time.Duration(sec)*time.Second)
+	}
+	type tick struct {
+		at     time.Time
+		price  float64
+		volume int64
+	}
+
+	tests := []struct {
+		name  string
+		ticks []tick
+		want  *database.IntradayStats
+	}{
+		{
+			name: "from the open",
+			ticks: []tick{
+				{at: at(9, 15, 2), price: 100, volume: 10},
+				{at: at(9, 15, 30), price: 104, volume: 25},
+				{at: at(9, 16, 1), price: 98, volume: 40},
+				{at: at(9, 17, 0), price: 101, volume: 55},
+			},
+			want: &database.IntradayStats{
+				DayOpen: 100, DayHigh: 104, DayLow: 98, CurrentPrice: 101,
+				TotalVolume: 55, BarsCount: 3, DayChange: 1, DayChangePct: 1,
+				AsOf: at(9, 17, 0),
+			},
+		},
+		{
+			name: "pre-open ticks",
+			ticks: []tick{
+				{at: at(9, 8, 0), price: 100, volume: 5},
+				{at: at(9, 15, 0), price: 110, volume: 20},
+			},
+			want: &database.IntradayStats{
+				DayOpen: 100, DayHigh: 110, DayLow: 100, CurrentPrice: 110,
+				TotalVolume: 20, BarsCount: 2, DayChange: 10, DayChangePct: 10,
+				AsOf: at(9, 15, 0),
+			},
+		},
+		{
+			name: "started mid-session",
+			ticks: []tick{
+				{at: at(11, 0, 0), price: 100, volume: 5000},
+			},
+		},
+		{
+			name: "previous day",
+			ticks: []tick{
+				{at: at(9, 15, 0).AddDate(0, 0, -1), price: 90, volume: 100},
+				{at: at(12, 0, 0), price: 100, volume: 5},
+			},
+		},
+		{
+			name: "new day",
+			ticks: []tick{
+				{at: at(15, 29, 0).AddDate(0, 0, -1), price: 90, volume: 100},
+				{at: at(9, 15, 0), price: 100, volume: 5},
+			},
+			want: &database.IntradayStats{
+				DayOpen: 100, DayHigh: 100, DayLow: 100, CurrentPrice: 100,
+				TotalVolume: 5, BarsCount: 1,
+				AsOf: at(9, 15, 0),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewStore(0)
+			for _, tk := range tt.ticks {
+				store.Update(&database.TickData{
+					Exchange:      "NSE",
+					Symbol:        "RELIANCE",
+					TickTimestamp: tk.at.In(ist),
+					Price:         tk.price,
+					Quantity:      1,
+				}, tk.volume)
+			}
+
+			got, ok := store.DayStats("nse", "reliance")
+			if tt.want == nil {
+				if ok {
+					t.Fatalf("DayStats = %+v, want none", got)
+				}
+				return
+			}
+			if !ok {
+				t.Fatal("DayStats: no stats")
+			}
+			if !got.AsOf.Equal(tt.want.AsOf) {
+				t.Errorf("AsOf = %v, want %v", got.AsOf, tt.want.AsOf)
+			}
+			got.AsOf = tt.want.AsOf
+			if *got != *tt.want {
+				t.Errorf("DayStats = %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestStoreDayStatsStale(t *testing.T) {
+	store := NewStore(time.Nanosecond)
+	open, _ := database.ISTDay(time.Now())
+	store.Update(&database.TickData{
+		Exchange:      "NSE",
+		Symbol:        "RELIANCE",
+		TickTimestamp: open.Add(sessionOpen),
+		Price:         100,
+	}, 10)
+	time.Sleep(time.Millisecond)
+
+	if stats, ok := store.DayStats("NSE", "RELIANCE"); ok {
+		t.Errorf("DayStats = %+v from a stale quote", stats)
+	}
+}
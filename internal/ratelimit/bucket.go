@@ -0,0 +1,125 @@
+// Package ratelimit provides the token bucket behind the backfill request
+// pacing, the per-connection stream message limits and the in-memory API
+// rate limit store.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Bucket is a token bucket refilled at Rate tokens per second up to Burst.
+// It starts full. It is not safe for concurrent use; see Limiter.
+type Bucket struct {
+	Rate  float64
+	Burst int
+
+	tokens  float64
+	last    time.Time
+	started bool
+}
+
+// refill adds the tokens earned since the last call
+func (b *Bucket) refill(now time.Time) {
+	burst := float64(b.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+
+	if !b.started {
+		b.tokens = burst
+		b.last = now
+		b.started = true
+		return
+	}
+
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(burst, b.tokens+elapsed*b.Rate)
+	}
+	b.last = now
+}
+
+// Take takes a token if one is available at now and returns the tokens left
+func (b *Bucket) Take(now time.Time) (bool, float64) {
+	b.refill(now)
+	if b.tokens < 1 {
+		return false, b.tokens
+	}
+	b.tokens--
+	return true, b.tokens
+}
+
+// Delay returns how long after now the next token becomes available, zero
+// if one already is. A bucket without a rate never refills.
+func (b *Bucket) Delay(now time.Time) time.Duration {
+	b.refill(now)
+	if b.tokens >= 1 {
+		return 0
+	}
+	if b.Rate <= 0 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration((1 - b.tokens) / b.Rate * float64(time.Second))
+}
+
+// LastUsed returns when the bucket was last taken from or checked
+func (b *Bucket) LastUsed() time.Time {
+	return b.last
+}
+
+// Limiter is a Bucket safe for concurrent use. A nil Limiter allows
+// everything.
+type Limiter struct {
+	mu     sync.Mutex
+	bucket Bucket
+}
+
+// NewLimiter creates a limiter allowing rate events per second on average,
+// with bursts of up to burst
+func NewLimiter(rate float64, burst int) *Limiter {
+	return &Limiter{bucket: Bucket{Rate: rate, Burst: burst}}
+}
+
+// Allow takes a token if one is available
+func (l *Limiter) Allow() bool {
+	if l == nil {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	allowed, _ := l.bucket.Take(time.Now())
+	return allowed
+}
+
+// Wait blocks until a token is taken or ctx is cancelled
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		delay := l.bucket.Delay(now)
+		if delay == 0 {
+			l.bucket.Take(now)
+		}
+		l.mu.Unlock()
+
+		if delay == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
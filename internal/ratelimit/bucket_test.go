@@ -0,0 +1,134 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBucketTake(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 15, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		rate    float64
+		burst   int
+		offsets []time.Duration // When each Take happens, after start
+		want    []bool
+	}{
+		{
+			name:    "burst then empty",
+			rate:    1,
+			burst:   3,
+			offsets: []time.Duration{0, 0, 0, 0},
+			want:    []bool{true, true, true, false},
+		},
+		{
+			name:    "refills at rate",
+			rate:    2,
+			burst:   1,
+			offsets: []time.Duration{0, 0, 250 * time.Millisecond, 500 * time.Millisecond},
+			want:    []bool{true, false, false, true},
+		},
+		{
+			name:    "refill capped at burst",
+			rate:    10,
+			burst:   2,
+			offsets: []time.Duration{0, 0, time.Hour, time.Hour, time.Hour},
+			want:    []bool{true, true, true, true, false},
+		},
+		{
+			name:    "zero burst allows one",
+			rate:    1,
+			burst:   0,
+			offsets: []time.Duration{0, 0},
+			want:    []bool{true, false},
+		},
+		{
+			name:    "zero rate never refills",
+			rate:    0,
+			burst:   1,
+			offsets: []time.Duration{0, time.Hour},
+			want:    []bool{true, false},
+		},
+		{
+			name:    "clock going back doesn't refill",
+			rate:    1,
+			burst:   1,
+			offsets: []time.Duration{time.Minute, 0},
+			want:    []bool{true, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Bucket{Rate: tt.rate, Burst: tt.burst}
+			for i, offset := range tt.offsets {
+				got, _ := b.Take(start.Add(offset))
+				if got != tt.want[i] {
+					t.Errorf("take %d at +%v = %v, want %v", i, offset, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBucketDelay(t *testing.T) {
+	start := time.Now()
+
+	tests := []struct {
+		name  string
+		rate  float64
+		taken int
+		want  time.Duration
+	}{
+		{"token available", 4, 0, 0},
+		{"one token short", 4, 1, 250 * time.Millisecond},
+		{"half a second per token", 2, 1, 500 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Bucket{Rate: tt.rate, Burst: 1}
+			for i := 0; i < tt.taken; i++ {
+				b.Take(start)
+			}
+			if got := b.Delay(start); got != tt.want {
+				t.Errorf("Delay = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimiterWait(t *testing.T) {
+	l := NewLimiter(50, 1)
+
+	begin := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait: %v", err)
+		}
+	}
+	// The first token is free, the next two take 20ms each
+	if elapsed := time.Since(begin); elapsed < 35*time.Millisecond {
+		t.Errorf("3 waits at 50/s with burst 1 took %v, want at least 40ms", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	empty := NewLimiter(0.001, 1)
+	empty.Allow()
+	if err := empty.Wait(ctx); err != context.Canceled {
+		t.Errorf("Wait on cancelled context = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestNilLimiterAllows(t *testing.T) {
+	var l *Limiter
+	if !l.Allow() {
+		t.Error("nil limiter rejected")
+	}
+	if err := l.Wait(context.Background()); err != nil {
+		t.Errorf("nil limiter Wait = %v", err)
+	}
+}
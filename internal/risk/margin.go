@@ -0,0 +1,216 @@
+package risk
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/charges"
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// ErrNoMarginEstimate is returned when an order's margin can't be
+// approximated, e.g. short options of unknown strike
+var ErrNoMarginEstimate = errors.New("margin cannot be estimated")
+
+// Where the margin of a margin check comes from
+const (
+	MarginFromBroker   = "broker"      // The broker's margin calculator
+	MarginApproximated = "approximate" // ApproximateMargin
+)
+
+// Approximate margin rates, as fractions of order value, for brokers
+// without a margin calculator. Exchanges set SPAN per contract daily; these
+// are typical of index derivatives and large caps.
+const (
+	IntradayMarginRate  = 0.20 // VaR + ELM of MIS equity, 5x leverage
+	FuturesSPANRate     = 0.09 // SPAN of futures and short options, of contract value
+	FuturesExposureRate = 0.03 // Exposure margin on top of SPAN
+)
+
+// InstrumentLookup returns an instrument's details, nil if it isn't known.
+// The type and strike of derivatives decide their margin.
+type InstrumentLookup func(exchange, symbol string) (*database.Instrument, error)
+
+// ApproximateMargin returns the margin an order of segment is likely to
+// block: the full value of delivery buys, a fifth of intraday equity,
+// SPAN and exposure on the contract value of futures and on the strike
+// value of short options, and the premium of long options. Delivery sells
+// come out of holdings and block nothing.
+func ApproximateMargin(segment charges.Segment, buy bool, quantity int, price, strike float64) (broker.OrderMargin, error) {
+	value := float64(quantity) * price
+	var margin broker.OrderMargin
+
+	switch segment {
+	case charges.EquityDelivery:
+		if buy {
+			margin.VAR = value
+		}
+	case charges.EquityIntraday:
+		margin.VAR = value * IntradayMarginRate
+	case charges.Futures:
+		margin.SPAN = value * FuturesSPANRate
+		margin.Exposure = value * FuturesExposureRate
+	case charges.Options:
+		if buy {
+			margin.OptionPremium = value
+			break
+		}
+		if strike <= 0 {
+			return margin, fmt.Errorf("%w: the strike of short options is unknown, sync the instruments", ErrNoMarginEstimate)
+		}
+		contract := float64(quantity) * strike
+		margin.SPAN = contract * FuturesSPANRate
+		margin.Exposure = contract * FuturesExposureRate
+	default:
+		return margin, fmt.Errorf("%w: %q", charges.ErrUnsupportedSegment, segment)
+	}
+
+	margin.SPAN = roundRupees(margin.SPAN)
+	margin.Exposure = roundRupees(margin.Exposure)
+	margin.OptionPremium = roundRupees(margin.OptionPremium)
+	margin.VAR = roundRupees(margin.VAR)
+	margin.Total = roundRupees(margin.SPAN + margin.Exposure + margin.OptionPremium + margin.VAR)
+	return margin, nil
+}
+
+// OrderEstimate is the margin and charges of one order of a margin check
+type OrderEstimate struct {
+	Order   broker.OrderRequest `json:"order"`
+	Price   float64             `json:"price"` // Limit price, or the last price of market orders
+	Segment charges.Segment     `json:"segment,omitempty"`
+	Margin  broker.OrderMargin  `json:"margin"`
+	Charges *charges.Breakdown  `json:"charges,omitempty"` // Nil for segments without known rates
+}
+
+// MarginCheck compares the margin and charges of orders with the margin
+// available in the account
+type MarginCheck struct {
+	Orders         []OrderEstimate `json:"orders"`
+	Source         string          `json:"source"` // broker or approximate
+	RequiredMargin float64         `json:"required_margin"`
+	HedgeBenefit   float64         `json:"hedge_benefit"` // Margin orders placed together save, per the broker
+	Charges        float64         `json:"charges"`
+	Required       float64         `json:"required"` // Margin and charges
+	Available      float64         `json:"available"`
+	Sufficient     bool            `json:"sufficient"`
+	Shortfall      float64         `json:"shortfall"`
+}
+
+// CheckMargin works out the margin and charges of orders and whether the
+// equity margin available covers them. The broker's margin calculator is
+// used when it has one, ApproximateMargin otherwise. Several orders are
+// margined as a basket by the broker, with the benefit of hedging each
+// other and the open positions; approximate margins are summed.
+func (e *Engine) CheckMargin(brk broker.Broker, orders []broker.OrderRequest) (*MarginCheck, error) {
+	e.mu.RLock()
+	lookup := e.instruments
+	e.mu.RUnlock()
+
+	check := &MarginCheck{Orders: make([]OrderEstimate, len(orders))}
+	strikes := make([]float64, len(orders))
+	segmentErrs := make([]error, len(orders))
+
+	for i, order := range orders {
+		order.Exchange = strings.ToUpper(order.Exchange)
+		if order.Exchange == "" {
+			order.Exchange = "NSE"
+		}
+		order.Symbol = strings.ToUpper(order.Symbol)
+		order.TransactionType = strings.ToUpper(order.TransactionType)
+		order.Product = strings.ToUpper(order.Product)
+		order.OrderType = strings.ToUpper(order.OrderType)
+		key := order.Exchange + ":" + order.Symbol
+
+		price, err := orderPrice(brk, &order, key)
+		if err != nil {
+			return nil, err
+		}
+
+		instrumentType := ""
+		if lookup != nil {
+			inst, err := lookup(order.Exchange, order.Symbol)
+			if err != nil {
+				return nil, fmt.Errorf("failed to look up %s: %w", key, err)
+			}
+			if inst != nil {
+				instrumentType, strikes[i] = inst.InstrumentType, inst.Strike
+			}
+		}
+
+		estimate := OrderEstimate{Order: order, Price: price}
+		estimate.Segment, segmentErrs[i] = charges.SegmentOf(order.Exchange, order.Product, instrumentType, order.Symbol)
+		if segmentErrs[i] == nil {
+			breakdown, err := charges.Estimate(estimate.Segment, order.Exchange, order.TransactionType == "BUY", order.Quantity, price)
+			if err == nil {
+				estimate.Charges = &breakdown
+				check.Charges += breakdown.Total
+			}
+		}
+		check.Orders[i] = estimate
+	}
+
+	if calculator, ok := broker.Unwrap(brk).(broker.MarginCalculator); ok {
+		requests := make([]broker.OrderRequest, len(orders))
+		for i, estimate := range check.Orders {
+			requests[i] = estimate.Order
+		}
+		check.Source = MarginFromBroker
+		if len(requests) > 1 {
+			basket, err := calculator.GetBasketMargin(requests)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get basket margin: %w", err)
+			}
+			for i := range check.Orders {
+				check.Orders[i].Margin = basket.Orders[i]
+			}
+			check.RequiredMargin = basket.Final.Total
+			check.HedgeBenefit = roundRupees(basket.Initial.Total - basket.Final.Total)
+		} else {
+			margins, err := calculator.GetOrderMargins(requests)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get order margins: %w", err)
+			}
+			check.Orders[0].Margin = margins[0]
+			check.RequiredMargin = margins[0].Total
+		}
+	} else {
+		check.Source = MarginApproximated
+		for i, estimate := range check.Orders {
+			if segmentErrs[i] != nil {
+				return nil, segmentErrs[i]
+			}
+			margin, err := ApproximateMargin(estimate.Segment, estimate.Order.TransactionType == "BUY", estimate.Order.Quantity, estimate.Price, strikes[i])
+			if err != nil {
+				return nil, fmt.Errorf("%s:%s: %w", estimate.Order.Exchange, estimate.Order.Symbol, err)
+			}
+			margin.Exchange = estimate.Order.Exchange
+			margin.Symbol = estimate.Order.Symbol
+			margin.TransactionType = estimate.Order.TransactionType
+			check.Orders[i].Margin = margin
+			check.RequiredMargin += margin.Total
+		}
+	}
+
+	margins, err := brk.GetMargins()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get margins: %w", err)
+	}
+
+	check.RequiredMargin = roundRupees(check.RequiredMargin)
+	check.Charges = roundRupees(check.Charges)
+	check.Required = roundRupees(check.RequiredMargin + check.Charges)
+	check.Available = margins.Equity.Available
+	check.Sufficient = check.Available >= check.Required
+	if !check.Sufficient {
+		check.Shortfall = roundRupees(check.Required - check.Available)
+	}
+	return check, nil
+}
+
+// roundRupees rounds to paise
+func roundRupees(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,261 @@
+package risk
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/charges"
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// calculatingBroker is a fakeBroker with a margin calculator
+type calculatingBroker struct {
+	*fakeBroker
+	total float64
+	hedge float64 // Basket margin saved
+}
+
+func (b *calculatingBroker) GetOrderMargins(orders []broker.OrderRequest) ([]broker.OrderMargin, error) {
+	margins := make([]broker.OrderMargin, len(orders))
+	for i, order := range orders {
+		margins[i] = broker.OrderMargin{Exchange: order.Exchange, Symbol: order.Symbol, TransactionType: order.TransactionType, Total: b.total}
+	}
+	return margins, nil
+}
+
+func (b *calculatingBroker) GetBasketMargin(orders []broker.OrderRequest) (*broker.BasketMargin, error) {
+	margins, _ := b.GetOrderMargins(orders)
+	initial := b.total * float64(len(orders))
+	return &broker.BasketMargin{
+		Initial: broker.OrderMargin{Total: initial},
+		Final:   broker.OrderMargin{Total: initial - b.hedge},
+		Orders:  margins,
+	}, nil
+}
+
+func TestApproximateMargin(t *testing.T) {
+	tests := []struct {
+		name     string
+		segment  charges.Segment
+		buy      bool
+		quantity int
+		price    float64
+		strike   float64
+		want     broker.OrderMargin
+		wantErr  error
+	}{
+		{
+			name:    "delivery buy",
+			segment: charges.EquityDelivery, buy: true, quantity: 10, price: 100,
+			want: broker.OrderMargin{VAR: 1000, Total: 1000},
+		},
+		{
+			name:    "delivery sell",
+			segment: charges.EquityDelivery, quantity: 10, price: 100,
+		},
+		{
+			name:    "intraday",
+			segment: charges.EquityIntraday, quantity: 10, price: 100,
+			want: broker.OrderMargin{VAR: 200, Total: 200},
+		},
+		{
+			name:    "futures",
+			segment: charges.Futures, buy: true, quantity: 75, price: 24000,
+			want: broker.OrderMargin{SPAN: 162000, Exposure: 54000, Total: 216000},
+		},
+		{
+			name:    "long option",
+			segment: charges.Options, buy: true, quantity: 75, price: 100, strike: 24000,
+			want: broker.OrderMargin{OptionPremium: 7500, Total: 7500},
+		},
+		{
+			name:    "short option",
+			segment: charges.Options, quantity: 75, price: 100, strike: 24000,
+			want: broker.OrderMargin{SPAN: 162000, Exposure: 54000, Total: 216000},
+		},
+		{
+			name:    "short option without strike",
+			segment: charges.Options, quantity: 75, price: 100,
+			wantErr: ErrNoMarginEstimate,
+		},
+		{
+			name:    "unknown segment",
+			segment: "commodity", buy: true, quantity: 1, price: 100,
+			wantErr: charges.ErrUnsupportedSegment,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApproximateMargin(tt.segment, tt.buy, tt.quantity, tt.price, tt.strike)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("ApproximateMargin error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApproximateMargin: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ApproximateMargin = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEngineCheckMargin(t *testing.T) {
+	order := broker.OrderRequest{Symbol: "tcs", TransactionType: "buy", Product: "cnc", OrderType: "LIMIT", Price: 4000, Quantity: 10}
+
+	tests := []struct {
+		name           string
+		broker         broker.Broker
+		wantSource     string
+		wantMargin     float64
+		wantSufficient bool
+	}{
+		{
+			name:           "approximated",
+			broker:         &fakeBroker{available: 50000},
+			wantSource:     MarginApproximated,
+			wantMargin:     40000,
+			wantSufficient: true,
+		},
+		{
+			name:       "approximated over the available margin",
+			broker:     &fakeBroker{available: 40000},
+			wantSource: MarginApproximated,
+			wantMargin: 40000,
+		},
+		{
+			name:           "from the broker",
+			broker:         &calculatingBroker{fakeBroker: &fakeBroker{available: 50000}, total: 12345},
+			wantSource:     MarginFromBroker,
+			wantMargin:     12345,
+			wantSufficient: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check, err := NewEngine(Limits{}).CheckMargin(tt.broker, []broker.OrderRequest{order})
+			if err != nil {
+				t.Fatalf("CheckMargin: %v", err)
+			}
+			if check.Source != tt.wantSource {
+				t.Errorf("source = %q, want %q", check.Source, tt.wantSource)
+			}
+			if check.RequiredMargin != tt.wantMargin {
+				t.Errorf("required margin = %v, want %v", check.RequiredMargin, tt.wantMargin)
+			}
+			// Delivery buy of ₹40,000 on NSE
+			if check.Charges != 47.45 {
+				t.Errorf("charges = %v, want 47.45", check.Charges)
+			}
+			if check.Required != roundRupees(tt.wantMargin+47.45) {
+				t.Errorf("required = %v, want margin and charges", check.Required)
+			}
+			if check.Sufficient != tt.wantSufficient {
+				t.Errorf("sufficient = %v, want %v", check.Sufficient, tt.wantSufficient)
+			}
+			if !check.Sufficient && check.Shortfall != roundRupees(check.Required-check.Available) {
+				t.Errorf("shortfall = %v, want %v", check.Shortfall, check.Required-check.Available)
+			}
+			if got := check.Orders[0].Order; got.Exchange != "NSE" || got.Symbol != "TCS" || got.Product != "CNC" {
+				t.Errorf("order not normalized: %+v", got)
+			}
+		})
+	}
+}
+
+func TestEngineCheckMarginLooksUpStrikes(t *testing.T) {
+	brk := &fakeBroker{available: 300000, ltp: map[string]float64{"NFO:NIFTY24DEC24000PE": 100}}
+	engine := NewEngine(Limits{})
+	order := broker.OrderRequest{Exchange: "NFO", Symbol: "NIFTY24DEC24000PE", TransactionType: "SELL", Product: "NRML", OrderType: "MARKET", Quantity: 75}
+
+	if _, err := engine.CheckMargin(brk, []broker.OrderRequest{order}); !errors.Is(err, ErrNoMarginEstimate) {
+		t.Fatalf("CheckMargin without instruments = %v, want ErrNoMarginEstimate", err)
+	}
+
+	engine.SetInstrumentLookup(func(exchange, symbol string) (*database.Instrument, error) {
+		return &database.Instrument{Exchange: exchange, Tradingsymbol: symbol, InstrumentType: "PE", Strike: 24000}, nil
+	})
+	check, err := engine.CheckMargin(brk, []broker.OrderRequest{order})
+	if err != nil {
+		t.Fatalf("CheckMargin: %v", err)
+	}
+	if check.RequiredMargin != 216000 || check.Orders[0].Segment != charges.Options {
+		t.Errorf("margin = %v of %q, want 216000 of options", check.RequiredMargin, check.Orders[0].Segment)
+	}
+}
+
+func TestEngineCheckRequiresMargin(t *testing.T) {
+	brk := &fakeBroker{available: 10000}
+	engine := NewEngine(Limits{})
+	order := &broker.OrderRequest{Symbol: "TCS", TransactionType: "BUY", Product: "CNC", OrderType: "LIMIT", Price: 4000, Quantity: 10}
+
+	if err := engine.Check(brk, order); err != nil {
+		t.Fatalf("Check without the margin requirement = %v", err)
+	}
+
+	engine.SetRequireMargin(true)
+	if err := engine.Check(brk, order); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Check = %v, want ErrLimitExceeded", err)
+	}
+
+	order.Quantity = 2
+	if err := engine.Check(brk, order); err != nil {
+		t.Errorf("Check of a covered order = %v", err)
+	}
+}
+
+func TestEngineCheckMarginBasket(t *testing.T) {
+	orders := []broker.OrderRequest{
+		{Exchange: "NFO", Symbol: "NIFTY24DEC24500CE", TransactionType: "BUY", Product: "NRML", OrderType: "LIMIT", Price: 50, Quantity: 75},
+		{Exchange: "NFO", Symbol: "NIFTY24DEC24000CE", TransactionType: "SELL", Product: "NRML", OrderType: "LIMIT", Price: 200, Quantity: 75},
+	}
+
+	tests := []struct {
+		name       string
+		broker     broker.Broker
+		wantMargin float64
+		wantHedge  float64
+	}{
+		{
+			name:       "broker basket with hedge benefit",
+			broker:     &calculatingBroker{fakeBroker: &fakeBroker{available: 100000}, total: 100000, hedge: 150000},
+			wantMargin: 50000,
+			wantHedge:  150000,
+		},
+		{
+			// The long call's premium plus the short call's margin on its
+			// strike, without hedge benefit
+			name:       "approximated sum",
+			broker:     &fakeBroker{available: 100000},
+			wantMargin: 75*50 + 75*24000*(FuturesSPANRate+FuturesExposureRate),
+		},
+	}
+
+	engine := NewEngine(Limits{})
+	engine.SetInstrumentLookup(func(exchange, symbol string) (*database.Instrument, error) {
+		strike := map[string]float64{"NIFTY24DEC24500CE": 24500, "NIFTY24DEC24000CE": 24000}[symbol]
+		return &database.Instrument{InstrumentType: "CE", Strike: strike}, nil
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check, err := engine.CheckMargin(tt.broker, orders)
+			if err != nil {
+				t.Fatalf("CheckMargin: %v", err)
+			}
+			if check.RequiredMargin != roundRupees(tt.wantMargin) || check.HedgeBenefit != tt.wantHedge {
+				t.Errorf("margin = %v with hedge benefit %v, want %v with %v",
+					check.RequiredMargin, check.HedgeBenefit, tt.wantMargin, tt.wantHedge)
+			}
+			if len(check.Orders) != 2 || check.Orders[1].Margin.TransactionType != "SELL" {
+				t.Errorf("orders = %+v", check.Orders)
+			}
+		})
+	}
+}
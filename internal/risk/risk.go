@@ -0,0 +1,415 @@
+package risk
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// ErrLimitExceeded is returned when an order would breach a risk limit
+var ErrLimitExceeded = errors.New("risk limit exceeded")
+
+// DefaultStopLossPct is the stop distance assumed when sizing the risk of an
+// order that doesn't carry its own stop loss
+const DefaultStopLossPct = 2.0
+
+// Limits are the risk limits enforced on new orders. A zero value disables
+// that limit.
+type Limits struct {
+	MaxPositions      int     `json:"max_positions"`
+	MaxRiskPerTrade   float64 `json:"max_risk_per_trade"`  // % of capital at risk per trade
+	MaxDailyLoss      float64 `json:"max_daily_loss"`      // Loss for the day after which new orders are rejected
+	MaxSymbolExposure float64 `json:"max_symbol_exposure"` // % of capital in a single symbol
+}
+
+// LimitsFromConfig returns the risk limits of a broker configuration
+func LimitsFromConfig(config *broker.BrokerConfig) Limits {
+	return Limits{
+		MaxPositions:      config.MaxPositions,
+		MaxRiskPerTrade:   config.MaxRiskPerTrade,
+		MaxDailyLoss:      config.MaxDailyLoss,
+		MaxSymbolExposure: config.MaxSymbolExposure,
+	}
+}
+
+// Enabled reports whether any limit is set
+func (l Limits) Enabled() bool {
+	return l.MaxPositions > 0 || l.MaxRiskPerTrade > 0 || l.MaxDailyLoss > 0 || l.MaxSymbolExposure > 0
+}
+
+// Validate rejects negative limits and percentages above 100
+func (l Limits) Validate() error {
+	if l.MaxPositions < 0 || l.MaxRiskPerTrade < 0 || l.MaxDailyLoss < 0 || l.MaxSymbolExposure < 0 {
+		return errors.New("risk limits cannot be negative")
+	}
+	if l.MaxRiskPerTrade > 100 || l.MaxSymbolExposure > 100 {
+		return errors.New("percentage limits cannot exceed 100")
+	}
+	return nil
+}
+
+// Usage is the account state the limits are checked against
+type Usage struct {
+	Capital       float64            `json:"capital"`
+	OpenPositions int                `json:"open_positions"`
+	DayPnL        float64            `json:"day_pnl"`
+	Exposure      map[string]float64 `json:"exposure"` // EXCHANGE:SYMBOL -> position value
+}
+
+// Engine checks orders against risk limits before they reach the broker
+type Engine struct {
+	mu            sync.RWMutex
+	limits        Limits
+	requireMargin bool             // Reject orders the available margin doesn't cover
+	instruments   InstrumentLookup // Types and strikes of derivatives, for their margin
+}
+
+// NewEngine creates a risk engine with the given limits
+func NewEngine(limits Limits) *Engine {
+	return &Engine{limits: limits}
+}
+
+// Limits returns the current limits
+func (e *Engine) Limits() Limits {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.limits
+}
+
+// SetLimits replaces the limits for subsequent orders
+func (e *Engine) SetLimits(limits Limits) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.limits = limits
+}
+
+// RequireMargin reports whether orders must be covered by the available
+// margin
+func (e *Engine) RequireMargin() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.requireMargin
+}
+
+// SetRequireMargin sets whether orders the available margin doesn't cover,
+// charges included, are rejected
+func (e *Engine) SetRequireMargin(require bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.requireMargin = require
+}
+
+// SetInstrumentLookup sets where the types and strikes of derivatives are
+// looked up when margins are checked
+func (e *Engine) SetInstrumentLookup(lookup InstrumentLookup) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.instruments = lookup
+}
+
+// Wrap returns a broker that rejects orders breaching the limits
+func (e *Engine) Wrap(brk broker.Broker) broker.Broker {
+	return &guardedBroker{Broker: brk, engine: e}
+}
+
+// Usage reads the capital, open positions, day P&L and per-symbol exposure
+// of the account behind brk
+func (e *Engine) Usage(brk broker.Broker) (*Usage, error) {
+	margins, err := brk.GetMargins()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get margins: %w", err)
+	}
+	positions, err := brk.GetPositions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get positions: %w", err)
+	}
+
+	usage := &Usage{
+		Capital:  margins.Equity.Net,
+		Exposure: make(map[string]float64),
+	}
+	for _, p := range positions.Day {
+		usage.DayPnL += p.PNL
+	}
+	for _, p := range positions.Net {
+		if p.Quantity == 0 {
+			continue
+		}
+		key := p.Exchange + ":" + p.Symbol
+		if _, ok := usage.Exposure[key]; !ok {
+			usage.OpenPositions++
+		}
+		usage.Exposure[key] += math.Abs(float64(p.Quantity)) * p.LastPrice
+	}
+	usage.DayPnL = math.Round(usage.DayPnL*100) / 100
+
+	return usage, nil
+}
+
+// Check returns an error wrapping ErrLimitExceeded if the order would breach
+// a limit, or when margins are required, if the available margin doesn't
+// cover it. Orders that only reduce an open position are always allowed.
+func (e *Engine) Check(brk broker.Broker, order *broker.OrderRequest) error {
+	limits := e.Limits()
+	requireMargin := e.RequireMargin()
+	if !limits.Enabled() && !requireMargin {
+		return nil
+	}
+
+	positions, err := brk.GetPositions()
+	if err != nil {
+		return fmt.Errorf("risk check failed: %w", err)
+	}
+
+	exchange := strings.ToUpper(order.Exchange)
+	if exchange == "" {
+		exchange = "NSE"
+	}
+	symbol := strings.ToUpper(order.Symbol)
+	key := exchange + ":" + symbol
+
+	signed := order.Quantity
+	if strings.ToUpper(order.TransactionType) == "SELL" {
+		signed = -signed
+	}
+
+	held := 0
+	openSymbols := make(map[string]bool)
+	var dayPnL float64
+	for _, p := range positions.Net {
+		if p.Quantity == 0 {
+			continue
+		}
+		openSymbols[p.Exchange+":"+p.Symbol] = true
+		if p.Exchange == exchange && p.Symbol == symbol {
+			held += p.Quantity
+		}
+	}
+	for _, p := range positions.Day {
+		dayPnL += p.PNL
+	}
+
+	// Exits don't add risk
+	if held != 0 && (held > 0) != (signed > 0) && abs(signed) <= abs(held) {
+		return nil
+	}
+
+	if limits.MaxDailyLoss > 0 && dayPnL <= -limits.MaxDailyLoss {
+		return fmt.Errorf("%w: day loss ₹%.2f has reached the limit of ₹%.2f",
+			ErrLimitExceeded, -dayPnL, limits.MaxDailyLoss)
+	}
+
+	if limits.MaxPositions > 0 && !openSymbols[key] && len(openSymbols) >= limits.MaxPositions {
+		return fmt.Errorf("%w: %v (%d open, limit %d)",
+			ErrLimitExceeded, broker.ErrMaxPositionsReached, len(openSymbols), limits.MaxPositions)
+	}
+
+	if requireMargin {
+		check, err := e.CheckMargin(brk, []broker.OrderRequest{*order})
+		if err != nil {
+			return fmt.Errorf("risk check failed: %w", err)
+		}
+		if !check.Sufficient {
+			return fmt.Errorf("%w: order needs ₹%.2f of margin and charges, ₹%.2f is available",
+				ErrLimitExceeded, check.Required, check.Available)
+		}
+	}
+
+	if limits.MaxRiskPerTrade <= 0 && limits.MaxSymbolExposure <= 0 {
+		return nil
+	}
+
+	margins, err := brk.GetMargins()
+	if err != nil {
+		return fmt.Errorf("risk check failed: %w", err)
+	}
+	capital := margins.Equity.Net
+	if capital <= 0 {
+		return fmt.Errorf("%w: no capital available", ErrLimitExceeded)
+	}
+
+	price, err := orderPrice(brk, order, key)
+	if err != nil {
+		return fmt.Errorf("risk check failed: %w", err)
+	}
+	value := float64(order.Quantity) * price
+
+	if limits.MaxRiskPerTrade > 0 {
+		risk := value * DefaultStopLossPct / 100
+		if order.StopLoss > 0 {
+			risk = float64(order.Quantity) * math.Abs(price-order.StopLoss)
+		}
+		allowed := capital * limits.MaxRiskPerTrade / 100
+		if risk > allowed {
+			return fmt.Errorf("%w: trade risk ₹%.2f exceeds %.2f%% of capital (₹%.2f)",
+				ErrLimitExceeded, risk, limits.MaxRiskPerTrade, allowed)
+		}
+	}
+
+	if limits.MaxSymbolExposure > 0 {
+		exposure := math.Abs(float64(held+signed)) * price
+		allowed := capital * limits.MaxSymbolExposure / 100
+		if exposure > allowed {
+			return fmt.Errorf("%w: %s exposure ₹%.2f exceeds %.2f%% of capital (₹%.2f)",
+				ErrLimitExceeded, key, exposure, limits.MaxSymbolExposure, allowed)
+		}
+	}
+
+	return nil
+}
+
+// orderPrice is the order's limit price, or the last traded price for
+// market and SL-M orders
+func orderPrice(brk broker.Broker, order *broker.OrderRequest, key string) (float64, error) {
+	orderType := strings.ToUpper(order.OrderType)
+	if (orderType == "LIMIT" || orderType == "SL") && order.Price > 0 {
+		return order.Price, nil
+	}
+
+	ltp, err := brk.GetLTP([]string{key})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get price for %s: %w", key, err)
+	}
+	price, ok := ltp[key]
+	if !ok || price <= 0 {
+		return 0, fmt.Errorf("no price available for %s", key)
+	}
+	return price, nil
+}
+
+// guardedBroker decorates a broker with pre-trade risk checks
+type guardedBroker struct {
+	broker.Broker
+	engine *Engine
+}
+
+// Unwrap returns the guarded broker
+func (b *guardedBroker) Unwrap() broker.Broker {
+	return b.Broker
+}
+
+func (b *guardedBroker) PlaceOrder(order *broker.OrderRequest) (string, error) {
+	if err := b.engine.Check(b.Broker, order); err != nil {
+		return "", err
+	}
+	return b.Broker.PlaceOrder(order)
+}
+
+// ModifyOrder checks a modification raising the order's quantity or price
+// like an order for what is left to fill on the new terms
+func (b *guardedBroker) ModifyOrder(orderID string, modify *broker.OrderModify) (string, error) {
+	if (modify.Quantity != nil || modify.Price != nil) && (b.engine.Limits().Enabled() || b.engine.RequireMargin()) {
+		order, err := b.raised(orderID, modify)
+		if err != nil {
+			return "", err
+		}
+		if order != nil {
+			if err := b.engine.Check(b.Broker, order); err != nil {
+				return "", err
+			}
+		}
+	}
+	return b.Broker.ModifyOrder(orderID, modify)
+}
+
+// raised returns the order left to fill after a modification raising its
+// quantity or price, or nil when it raises neither. Orders missing from the
+// order book are left for the broker to reject.
+func (b *guardedBroker) raised(orderID string, modify *broker.OrderModify) (*broker.OrderRequest, error) {
+	orders, err := b.Broker.GetOrders()
+	if err != nil {
+		return nil, fmt.Errorf("risk check failed: %w", err)
+	}
+
+	for _, o := range orders {
+		if o.OrderID != orderID {
+			continue
+		}
+
+		order := &broker.OrderRequest{
+			Symbol:          o.Symbol,
+			Exchange:        o.Exchange,
+			TransactionType: o.TransactionType,
+			OrderType:       o.OrderType,
+			Product:         o.Product,
+			Quantity:        o.Quantity,
+			Price:           o.Price,
+			TriggerPrice:    o.TriggerPrice,
+		}
+		if modify.Quantity != nil {
+			order.Quantity = *modify.Quantity
+		}
+		if modify.Price != nil {
+			order.Price = *modify.Price
+		}
+		if order.Quantity <= o.Quantity && order.Price <= o.Price {
+			return nil, nil
+		}
+		if modify.TriggerPrice != nil {
+			order.TriggerPrice = *modify.TriggerPrice
+		}
+		if modify.OrderType != nil {
+			order.OrderType = *modify.OrderType
+		}
+		order.Quantity -= o.FilledQuantity
+		if order.Quantity <= 0 {
+			return nil, nil
+		}
+		return order, nil
+	}
+	return nil, nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// GTT calls are forwarded unchecked: the broker places a GTT's order
+// itself when it triggers, long after any check here would have run.
+
+func (b *guardedBroker) PlaceGTT(gtt *broker.GTTRequest) (int, error) {
+	gtts, err := broker.GTTs(b.Broker)
+	if err != nil {
+		return 0, err
+	}
+	return gtts.PlaceGTT(gtt)
+}
+
+func (b *guardedBroker) ModifyGTT(triggerID int, gtt *broker.GTTRequest) error {
+	gtts, err := broker.GTTs(b.Broker)
+	if err != nil {
+		return err
+	}
+	return gtts.ModifyGTT(triggerID, gtt)
+}
+
+func (b *guardedBroker) DeleteGTT(triggerID int) error {
+	gtts, err := broker.GTTs(b.Broker)
+	if err != nil {
+		return err
+	}
+	return gtts.DeleteGTT(triggerID)
+}
+
+func (b *guardedBroker) GetGTTs() ([]broker.GTT, error) {
+	gtts, err := broker.GTTs(b.Broker)
+	if err != nil {
+		return nil, err
+	}
+	return gtts.GetGTTs()
+}
+
+func (b *guardedBroker) GetGTT(triggerID int) (*broker.GTT, error) {
+	gtts, err := broker.GTTs(b.Broker)
+	if err != nil {
+		return nil, err
+	}
+	return gtts.GetGTT(triggerID)
+}
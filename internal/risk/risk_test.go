@@ -0,0 +1,277 @@
+package risk
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// fakeBroker serves fixed positions, capital and prices. Other Broker
+// methods panic through the nil embedded interface.
+type fakeBroker struct {
+	broker.Broker
+	positions broker.Positions
+	capital   float64
+	available float64
+	ltp       map[string]float64
+	orders    []broker.Order
+	placed    int
+	modified  int
+}
+
+func (f *fakeBroker) GetPositions() (*broker.Positions, error) {
+	return &f.positions, nil
+}
+
+func (f *fakeBroker) GetMargins() (*broker.Margins, error) {
+	margins := &broker.Margins{}
+	margins.Equity.Net = f.capital
+	margins.Equity.Available = f.available
+	return margins, nil
+}
+
+func (f *fakeBroker) GetLTP(symbols []string) (map[string]float64, error) {
+	return f.ltp, nil
+}
+
+func (f *fakeBroker) PlaceOrder(order *broker.OrderRequest) (string, error) {
+	f.placed++
+	return "1", nil
+}
+
+func (f *fakeBroker) GetOrders() ([]broker.Order, error) {
+	return f.orders, nil
+}
+
+func (f *fakeBroker) ModifyOrder(orderID string, modify *broker.OrderModify) (string, error) {
+	f.modified++
+	return orderID, nil
+}
+
+func position(symbol string, quantity int, price, pnl float64) broker.Position {
+	return broker.Position{Exchange: "NSE", Symbol: symbol, Quantity: quantity, LastPrice: price, PNL: pnl}
+}
+
+func TestEngineCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		limits    Limits
+		positions broker.Positions
+		order     broker.OrderRequest
+		wantErr   bool
+	}{
+		{
+			name:   "no limits",
+			limits: Limits{},
+			order:  broker.OrderRequest{Symbol: "TCS", TransactionType: "BUY", Quantity: 1000, OrderType: "MARKET"},
+		},
+		{
+			name:      "max positions reached for a new symbol",
+			limits:    Limits{MaxPositions: 2},
+			positions: broker.Positions{Net: []broker.Position{position("INFY", 10, 1500, 0), position("SBIN", 10, 600, 0)}},
+			order:     broker.OrderRequest{Symbol: "TCS", TransactionType: "BUY", Quantity: 1, OrderType: "MARKET"},
+			wantErr:   true,
+		},
+		{
+			name:      "max positions allows adding to an open symbol",
+			limits:    Limits{MaxPositions: 2},
+			positions: broker.Positions{Net: []broker.Position{position("INFY", 10, 1500, 0), position("SBIN", 10, 600, 0)}},
+			order:     broker.OrderRequest{Symbol: "infy", TransactionType: "BUY", Quantity: 1, OrderType: "MARKET"},
+		},
+		{
+			name:      "closed positions don't count",
+			limits:    Limits{MaxPositions: 1},
+			positions: broker.Positions{Net: []broker.Position{position("INFY", 0, 1500, 0)}},
+			order:     broker.OrderRequest{Symbol: "TCS", TransactionType: "BUY", Quantity: 1, OrderType: "MARKET"},
+		},
+		{
+			name:      "daily loss reached",
+			limits:    Limits{MaxDailyLoss: 5000},
+			positions: broker.Positions{Day: []broker.Position{position("INFY", 0, 1500, -3000), position("SBIN", 0, 600, -2000)}},
+			order:     broker.OrderRequest{Symbol: "TCS", TransactionType: "BUY", Quantity: 1, OrderType: "MARKET"},
+			wantErr:   true,
+		},
+		{
+			name:      "daily loss under the limit",
+			limits:    Limits{MaxDailyLoss: 5000},
+			positions: broker.Positions{Day: []broker.Position{position("INFY", 0, 1500, -4999)}},
+			order:     broker.OrderRequest{Symbol: "TCS", TransactionType: "BUY", Quantity: 1, OrderType: "MARKET"},
+		},
+		{
+			name:      "exit allowed after daily loss",
+			limits:    Limits{MaxDailyLoss: 5000, MaxPositions: 1},
+			positions: broker.Positions{Net: []broker.Position{position("INFY", 10, 1500, 0)}, Day: []broker.Position{position("INFY", 10, 1500, -9000)}},
+			order:     broker.OrderRequest{Symbol: "INFY", TransactionType: "SELL", Quantity: 10, OrderType: "MARKET"},
+		},
+		{
+			name:      "reversal is not an exit",
+			limits:    Limits{MaxDailyLoss: 5000},
+			positions: broker.Positions{Net: []broker.Position{position("INFY", 10, 1500, 0)}, Day: []broker.Position{position("INFY", 10, 1500, -9000)}},
+			order:     broker.OrderRequest{Symbol: "INFY", TransactionType: "SELL", Quantity: 20, OrderType: "MARKET"},
+			wantErr:   true,
+		},
+		{
+			// 100 x 1000 at the default 2% stop risks 2000, 1% of 100000 is 1000
+			name:    "risk per trade with default stop",
+			limits:  Limits{MaxRiskPerTrade: 1},
+			order:   broker.OrderRequest{Symbol: "TCS", TransactionType: "BUY", Quantity: 100, OrderType: "LIMIT", Price: 1000},
+			wantErr: true,
+		},
+		{
+			// The stop 5 below risks 500
+			name:   "risk per trade with order stop",
+			limits: Limits{MaxRiskPerTrade: 1},
+			order:  broker.OrderRequest{Symbol: "TCS", TransactionType: "BUY", Quantity: 100, OrderType: "LIMIT", Price: 1000, StopLoss: 995},
+		},
+		{
+			// Market orders are valued at the LTP of 4000: 10 x 4000 x 2% = 800
+			name:   "risk per trade priced at LTP",
+			limits: Limits{MaxRiskPerTrade: 1},
+			order:  broker.OrderRequest{Symbol: "TCS", TransactionType: "BUY", Quantity: 10, OrderType: "MARKET"},
+		},
+		{
+			// 10 held + 10 bought at 4000 = 80000, over 50% of 100000
+			name:      "symbol exposure includes the held quantity",
+			limits:    Limits{MaxSymbolExposure: 50},
+			positions: broker.Positions{Net: []broker.Position{position("TCS", 10, 4000, 0)}},
+			order:     broker.OrderRequest{Symbol: "TCS", TransactionType: "BUY", Quantity: 10, OrderType: "MARKET"},
+			wantErr:   true,
+		},
+		{
+			name:   "symbol exposure within the limit",
+			limits: Limits{MaxSymbolExposure: 50},
+			order:  broker.OrderRequest{Symbol: "TCS", TransactionType: "BUY", Quantity: 10, OrderType: "MARKET"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			brk := &fakeBroker{
+				positions: tt.positions,
+				capital:   100000,
+				ltp:       map[string]float64{"NSE:TCS": 4000, "NSE:INFY": 1500},
+			}
+
+			err := NewEngine(tt.limits).Check(brk, &tt.order)
+			if tt.wantErr {
+				if !errors.Is(err, ErrLimitExceeded) {
+					t.Errorf("Check = %v, want ErrLimitExceeded", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Check = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestEngineCheckWithoutCapital(t *testing.T) {
+	brk := &fakeBroker{ltp: map[string]float64{"NSE:TCS": 4000}}
+	order := &broker.OrderRequest{Symbol: "TCS", TransactionType: "BUY", Quantity: 1, OrderType: "MARKET"}
+
+	err := NewEngine(Limits{MaxRiskPerTrade: 1}).Check(brk, order)
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Check = %v, want ErrLimitExceeded without capital", err)
+	}
+}
+
+func TestEngineWrap(t *testing.T) {
+	brk := &fakeBroker{capital: 100000}
+	engine := NewEngine(Limits{MaxPositions: 1})
+	brk.positions.Net = []broker.Position{position("INFY", 10, 1500, 0)}
+	guarded := engine.Wrap(brk)
+
+	order := &broker.OrderRequest{Symbol: "TCS", TransactionType: "BUY", Quantity: 1, OrderType: "MARKET"}
+	if _, err := guarded.PlaceOrder(order); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("PlaceOrder = %v, want ErrLimitExceeded", err)
+	}
+	if brk.placed != 0 {
+		t.Errorf("rejected order reached the broker")
+	}
+
+	engine.SetLimits(Limits{MaxPositions: 2})
+	if _, err := guarded.PlaceOrder(order); err != nil {
+		t.Fatalf("PlaceOrder after raising the limit = %v", err)
+	}
+	if brk.placed != 1 {
+		t.Errorf("placed %d orders, want 1", brk.placed)
+	}
+}
+
+func TestGuardedModifyOrder(t *testing.T) {
+	quantity := func(n int) *int { return &n }
+	price := func(p float64) *float64 { return &p }
+
+	// An open buy of 100 TCS at 4000, 40 filled; risk per trade allows
+	// 100000 * 2% / 2% stop loss = ₹100000 of TCS, i.e. 25 at 4000
+	tests := []struct {
+		name    string
+		limits  Limits
+		modify  broker.OrderModify
+		wantErr bool
+	}{
+		{"quantity raised", Limits{MaxRiskPerTrade: 2}, broker.OrderModify{Quantity: quantity(120)}, true},
+		{"price raised", Limits{MaxRiskPerTrade: 2}, broker.OrderModify{Price: price(4010)}, true},
+		{"quantity lowered", Limits{MaxRiskPerTrade: 2}, broker.OrderModify{Quantity: quantity(80)}, false},
+		{"price lowered", Limits{MaxRiskPerTrade: 2}, broker.OrderModify{Price: price(3990)}, false},
+		{"trigger only", Limits{MaxRiskPerTrade: 2}, broker.OrderModify{TriggerPrice: price(3900)}, false},
+		{"raised within limits", Limits{MaxRiskPerTrade: 20}, broker.OrderModify{Quantity: quantity(110), Price: price(4010)}, false},
+		{"raised to what's filled", Limits{MaxRiskPerTrade: 2}, broker.OrderModify{Quantity: quantity(40), Price: price(4010)}, false},
+		{"no limits", Limits{}, broker.OrderModify{Quantity: quantity(1000)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			brk := &fakeBroker{
+				capital: 100000,
+				orders: []broker.Order{
+					{OrderID: "2", Exchange: "NSE", Symbol: "INFY", TransactionType: "BUY", OrderType: "LIMIT", Quantity: 1, Price: 1500},
+					{OrderID: "7", Exchange: "NSE", Symbol: "TCS", TransactionType: "BUY", OrderType: "LIMIT", Quantity: 100, Price: 4000, FilledQuantity: 40},
+				},
+			}
+			guarded := NewEngine(tt.limits).Wrap(brk)
+
+			_, err := guarded.ModifyOrder("7", &tt.modify)
+			if tt.wantErr {
+				if !errors.Is(err, ErrLimitExceeded) {
+					t.Fatalf("ModifyOrder = %v, want ErrLimitExceeded", err)
+				}
+				if brk.modified != 0 {
+					t.Errorf("rejected modification reached the broker")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ModifyOrder = %v", err)
+			}
+			if brk.modified != 1 {
+				t.Errorf("modified %d orders, want 1", brk.modified)
+			}
+		})
+	}
+}
+
+func TestLimitsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  Limits
+		wantErr bool
+	}{
+		{"zero", Limits{}, false},
+		{"all set", Limits{MaxPositions: 5, MaxRiskPerTrade: 2, MaxDailyLoss: 10000, MaxSymbolExposure: 20}, false},
+		{"negative positions", Limits{MaxPositions: -1}, true},
+		{"negative loss", Limits{MaxDailyLoss: -1}, true},
+		{"risk over 100%", Limits{MaxRiskPerTrade: 101}, true},
+		{"exposure over 100%", Limits{MaxSymbolExposure: 150}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.limits.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/backfill"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/timeframe"
+	"github.com/trading-chitti/market-bridge/internal/watchlist"
+)
+
+// DefaultBackfillCron runs at 16:00 IST on weekdays, after the 15:30 close
+const DefaultBackfillCron = "0 16 * * 1-5"
+
+// SymbolProvider returns the symbols currently being collected
+type SymbolProvider interface {
+	GetSubscribedSymbols() []string
+}
+
+// BackfillSchedulerConfig configures the scheduled backfill
+type BackfillSchedulerConfig struct {
+	Cron       string   // 5-field cron expression, evaluated in IST
+	Timeframe  string   // Kite interval (default "minute")
+	Watchlists []string // Watchlists backfilled in addition to collector symbols
+}
+
+// BackfillScheduler backfills the day's bars after market close
+type BackfillScheduler struct {
+	broker    broker.Broker
+	db        *database.Database
+	collector SymbolProvider
+	config    BackfillSchedulerConfig
+	schedule  *CronSchedule
+	location  *time.Location
+
+	cancel context.CancelFunc
+	done   chan bool
+}
+
+// NewBackfillScheduler creates a new backfill scheduler
+func NewBackfillScheduler(brk broker.Broker, db *database.Database, collector SymbolProvider, config BackfillSchedulerConfig) (*BackfillScheduler, error) {
+	if config.Cron == "" {
+		config.Cron = DefaultBackfillCron
+	}
+	if config.Timeframe == "" {
+		config.Timeframe = "minute"
+	}
+	stored, err := timeframe.ParseStored(config.Timeframe)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported backfill timeframe: %w", err)
+	}
+	config.Timeframe = stored.Kite()
+
+	schedule, err := ParseCron(config.Cron)
+	if err != nil {
+		return nil, err
+	}
+
+	ist := broker.IST()
+
+	return &BackfillScheduler{
+		broker:    brk,
+		db:        db,
+		collector: collector,
+		config:    config,
+		schedule:  schedule,
+		location:  ist,
+		done:      make(chan bool),
+	}, nil
+}
+
+// Start begins waiting for scheduled runs
+func (s *BackfillScheduler) Start() {
+	log.Printf("🔄 Starting backfill scheduler (cron: %q IST, timeframe: %s)", s.config.Cron, s.config.Timeframe)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	go func() {
+		for {
+			next := s.schedule.Next(time.Now().In(s.location))
+			if next.IsZero() {
+				log.Println("⚠️  Backfill cron never fires, scheduler idle")
+				<-s.done
+				return
+			}
+			log.Printf("📋 Next scheduled backfill at %s", next.Format("2006-01-02 15:04 MST"))
+
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-timer.C:
+				s.RunOnce(ctx)
+			case <-s.done:
+				timer.Stop()
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the scheduler, cancelling a run in progress
+func (s *BackfillScheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.done <- true
+	log.Println("⏹️  Backfill scheduler stopped")
+}
+
+// RunOnce backfills today's bars for all collected and watchlist symbols and
+// records the run report
+func (s *BackfillScheduler) RunOnce(ctx context.Context) *database.BackfillRun {
+	now := time.Now().In(s.location)
+	fromDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.location)
+	toDate := fromDate.Add(24*time.Hour - time.Second)
+
+	symbols := s.symbols()
+
+	run := &database.BackfillRun{
+		Trigger:      "scheduled",
+		Timeframe:    s.config.Timeframe,
+		FromDate:     fromDate,
+		ToDate:       toDate,
+		TotalSymbols: len(symbols),
+	}
+	if err := s.db.CreateBackfillRun(run); err != nil {
+		log.Printf("❌ Failed to record backfill run: %v", err)
+		return nil
+	}
+
+	log.Printf("🚀 Scheduled backfill #%d: %d symbols for %s", run.RunID, len(symbols), fromDate.Format("2006-01-02"))
+
+	if len(symbols) == 0 {
+		run.Status = database.BackfillCompleted
+		s.finish(run)
+		return run
+	}
+
+	backfiller, err := backfill.New(s.broker, s.db, backfill.Options{
+		Timeframe: s.config.Timeframe,
+		Mode:      backfill.ModeFull,
+		Source:    "scheduled_backfill",
+	})
+	if err != nil {
+		msg := err.Error()
+		run.Status = database.BackfillFailed
+		run.Error = &msg
+		s.finish(run)
+		return run
+	}
+
+	stats := backfiller.Run(ctx, symbols, fromDate, toDate)
+
+	run.Successful = stats.Successful
+	run.Failed = stats.Failed
+	run.TotalBars = stats.TotalBars
+	for _, result := range stats.Results {
+		run.Results = append(run.Results, result.RunResult())
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		run.Status = database.BackfillCancelled
+	case stats.Failed > 0 && stats.Successful == 0:
+		run.Status = database.BackfillFailed
+	default:
+		run.Status = database.BackfillCompleted
+	}
+
+	s.finish(run)
+
+	log.Printf("📊 Scheduled backfill #%d %s: %d/%d symbols, %d bars in %v",
+		run.RunID, run.Status, stats.Successful, stats.TotalSymbols, stats.TotalBars, stats.Duration)
+
+	return run
+}
+
+func (s *BackfillScheduler) finish(run *database.BackfillRun) {
+	if err := s.db.FinishBackfillRun(run); err != nil {
+		log.Printf("❌ Failed to save backfill run #%d: %v", run.RunID, err)
+	}
+}
+
+// symbols returns the sorted union of collector and watchlist symbols
+func (s *BackfillScheduler) symbols() []string {
+	seen := make(map[string]bool)
+
+	if s.collector != nil {
+		for _, symbol := range s.collector.GetSubscribedSymbols() {
+			seen[symbol] = true
+		}
+	}
+
+	for _, name := range s.config.Watchlists {
+		wl := watchlist.GetWatchlist(name)
+		if wl == nil {
+			log.Printf("⚠️  Backfill watchlist not found: %s", name)
+			continue
+		}
+		for _, symbol := range wl.Symbols {
+			seen[symbol] = true
+		}
+	}
+
+	symbols := make([]string, 0, len(seen))
+	for symbol := range seen {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	return symbols
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"too few fields", "0 16 * *"},
+		{"too many fields", "0 16 * * 1-5 2024"},
+		{"minute out of range", "60 16 * * *"},
+		{"hour out of range", "0 24 * * *"},
+		{"day zero", "0 0 0 * *"},
+		{"month out of range", "0 0 1 13 *"},
+		{"weekday out of range", "0 0 * * 8"},
+		{"inverted range", "0 0 * * 5-1"},
+		{"zero step", "*/0 * * * *"},
+		{"bad step", "*/x * * * *"},
+		{"not a number", "a * * * *"},
+		{"bad range", "0 9-x * * *"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseCron(tt.expr); err == nil {
+				t.Errorf("ParseCron(%q) succeeded, want an error", tt.expr)
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	ist := broker.IST()
+	at := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, ist)
+	}
+
+	// 2024-01-05 is a Friday
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"every minute", "* * * * *", at(2024, 1, 5, 10, 0), at(2024, 1, 5, 10, 1)},
+		{"strictly after", "0 16 * * *", at(2024, 1, 5, 16, 0), at(2024, 1, 6, 16, 0)},
+		{"later today", "0 16 * * *", at(2024, 1, 5, 9, 30), at(2024, 1, 5, 16, 0)},
+		{"weekdays skip weekend", "0 16 * * 1-5", at(2024, 1, 5, 17, 0), at(2024, 1, 8, 16, 0)},
+		{"step minutes", "*/15 9-15 * * *", at(2024, 1, 5, 9, 16), at(2024, 1, 5, 9, 30)},
+		{"range with step", "0 9-15/2 * * *", at(2024, 1, 5, 11, 30), at(2024, 1, 5, 13, 0)},
+		{"list", "0 10,14 * * *", at(2024, 1, 5, 10, 0), at(2024, 1, 5, 14, 0)},
+		{"seven is sunday", "0 2 * * 7", at(2024, 1, 5, 0, 0), at(2024, 1, 7, 2, 0)},
+		{"month rollover", "0 0 1 * *", at(2024, 1, 5, 0, 0), at(2024, 2, 1, 0, 0)},
+		{"year rollover", "30 1 1 1 *", at(2024, 1, 5, 0, 0), at(2025, 1, 1, 1, 30)},
+		{"leap day", "0 0 29 2 *", at(2024, 3, 1, 0, 0), at(2028, 2, 29, 0, 0)},
+		// Both day fields restricted: either matches (the 15th, or a Monday)
+		{"day or weekday", "0 0 15 * 1", at(2024, 1, 9, 0, 0), at(2024, 1, 15, 0, 0)},
+		{"weekday before day", "0 0 20 * 1", at(2024, 1, 9, 0, 0), at(2024, 1, 15, 0, 0)},
+		{"seconds truncated", "* * * * *", at(2024, 1, 5, 10, 0).Add(59 * time.Second), at(2024, 1, 5, 10, 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+			if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronNextNeverMatches(t *testing.T) {
+	schedule, err := ParseCron("0 0 31 2 *")
+	if err != nil {
+		t.Fatalf("ParseCron: %v", err)
+	}
+	if got := schedule.Next(time.Now()); !got.IsZero() {
+		t.Errorf("Next = %v, want the zero time for February 31st", got)
+	}
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/analyzer"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/timeframe"
+	"github.com/trading-chitti/market-bridge/internal/watchlist"
+)
+
+// DefaultPatternScanInterval is the time between pattern scans
+const DefaultPatternScanInterval = 5 * time.Minute
+
+// PatternScannerConfig configures the background pattern scanner
+type PatternScannerConfig struct {
+	Interval      time.Duration // Time between scans (default 5m)
+	Watchlists    []string      // Watchlists whose symbols are scanned (default NIFTY50)
+	Timeframes    []string      // Kite intervals scanned (default 5minute and 15minute)
+	MinConfidence float64       // Patterns below this are ignored (default 0.7)
+	Bars          int           // Bars scanned per symbol and timeframe (default 100)
+}
+
+// PatternScanStatus describes the scanner's configuration and last pass
+type PatternScanStatus struct {
+	Interval      string     `json:"interval"`
+	Watchlists    []string   `json:"watchlists"`
+	Timeframes    []string   `json:"timeframes"`
+	Symbols       int        `json:"symbols"`
+	MinConfidence float64    `json:"min_confidence"`
+	LastRunAt     *time.Time `json:"last_run_at,omitempty"`
+	LastDuration  string     `json:"last_duration,omitempty"`
+	LastDetected  int        `json:"last_detected"`
+	Errors        int        `json:"errors"`
+}
+
+// PatternScannerService periodically scans the collector's intraday bars
+// of watchlist symbols for new patterns, stores them and hands each new
+// detection to a handler (streaming, notifications)
+type PatternScannerService struct {
+	db      *database.Database
+	config  PatternScannerConfig
+	symbols []scanSymbol
+	scanner *analyzer.PatternScanner
+
+	mu       sync.Mutex
+	onDetect func(detection database.PatternDetection)
+	lastBar  map[string]time.Time // symbol|timeframe -> last bar scanned
+	status   PatternScanStatus
+
+	done     chan bool
+	stopOnce sync.Once
+}
+
+type scanSymbol struct {
+	exchange string
+	symbol   string
+}
+
+// NewPatternScannerService creates a pattern scanner
+func NewPatternScannerService(db *database.Database, config PatternScannerConfig) (*PatternScannerService, error) {
+	if config.Interval <= 0 {
+		config.Interval = DefaultPatternScanInterval
+	}
+	if len(config.Watchlists) == 0 {
+		config.Watchlists = []string{"NIFTY50"}
+	}
+	if len(config.Timeframes) == 0 {
+		config.Timeframes = []string{"5minute", "15minute"}
+	}
+	if config.MinConfidence <= 0 {
+		config.MinConfidence = 0.7
+	}
+	if config.Bars <= 0 {
+		config.Bars = 100
+	}
+
+	for i, tf := range config.Timeframes {
+		stored, err := timeframe.ParseStored(tf)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported pattern scan timeframe: %w", err)
+		}
+		config.Timeframes[i] = stored.Kite()
+	}
+
+	seen := make(map[string]bool)
+	var symbols []scanSymbol
+	for _, name := range config.Watchlists {
+		wl := watchlist.GetWatchlist(name)
+		if wl == nil {
+			return nil, fmt.Errorf("unknown watchlist: %s", name)
+		}
+		exchange := wl.Exchange
+		if exchange == "" {
+			exchange = "NSE"
+		}
+		for _, symbol := range wl.Symbols {
+			if !seen[symbol] {
+				seen[symbol] = true
+				symbols = append(symbols, scanSymbol{exchange: exchange, symbol: symbol})
+			}
+		}
+	}
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i].symbol < symbols[j].symbol })
+
+	scanner := analyzer.NewPatternScanner()
+	scanner.MinConfidence = config.MinConfidence
+
+	return &PatternScannerService{
+		db:      db,
+		config:  config,
+		symbols: symbols,
+		scanner: scanner,
+		lastBar: make(map[string]time.Time),
+		status: PatternScanStatus{
+			Interval:      config.Interval.String(),
+			Watchlists:    config.Watchlists,
+			Timeframes:    config.Timeframes,
+			Symbols:       len(symbols),
+			MinConfidence: config.MinConfidence,
+		},
+		done: make(chan bool),
+	}, nil
+}
+
+// SetDetectionHandler sets a callback invoked for each newly stored pattern
+func (s *PatternScannerService) SetDetectionHandler(fn func(detection database.PatternDetection)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onDetect = fn
+}
+
+// Start begins scanning in the background
+func (s *PatternScannerService) Start() {
+	log.Printf("🔍 Pattern scanner started: %d symbols on %v every %s",
+		len(s.symbols), s.config.Timeframes, s.config.Interval)
+
+	go func() {
+		ticker := time.NewTicker(s.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.RunOnce()
+			case <-s.done:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the scanner
+func (s *PatternScannerService) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+		log.Println("⏹️  Pattern scanner stopped")
+	})
+}
+
+// Status returns the scanner's configuration and last pass
+func (s *PatternScannerService) Status() PatternScanStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// RunOnce scans every symbol and timeframe once and returns the patterns
+// stored for the first time. Only patterns completed since the previous
+// pass are considered, so history already on screen isn't re-announced
+// after a restart.
+func (s *PatternScannerService) RunOnce() []database.PatternDetection {
+	start := time.Now()
+	detected := []database.PatternDetection{}
+	failed := 0
+
+	for _, sym := range s.symbols {
+		for _, interval := range s.config.Timeframes {
+			added, err := s.scan(sym, interval)
+			if err != nil {
+				failed++
+				continue
+			}
+			detected = append(detected, added...)
+		}
+	}
+
+	s.mu.Lock()
+	s.status.LastRunAt = &start
+	s.status.LastDuration = time.Since(start).Round(time.Millisecond).String()
+	s.status.LastDetected = len(detected)
+	s.status.Errors = failed
+	onDetect := s.onDetect
+	s.mu.Unlock()
+
+	if len(detected) > 0 {
+		log.Printf("🔍 Pattern scan found %d new patterns in %s", len(detected), time.Since(start).Round(time.Millisecond))
+	}
+	if onDetect != nil {
+		for _, d := range detected {
+			onDetect(d)
+		}
+	}
+	return detected
+}
+
+// scan looks for patterns completed after the last bar seen for a symbol
+// and timeframe and stores them
+func (s *PatternScannerService) scan(sym scanSymbol, interval string) ([]database.PatternDetection, error) {
+	tf, _ := timeframe.Parse(interval)
+	bars, err := s.db.GetRecentIntradayBars(sym.exchange, sym.symbol, tf.String(), s.config.Bars)
+	if err != nil {
+		return nil, err
+	}
+	if len(bars) < 2 {
+		return nil, nil
+	}
+
+	candles := make([]broker.Candle, len(bars))
+	for i, b := range bars {
+		candles[i] = broker.Candle{
+			Date:   b.BarTimestamp,
+			Open:   b.Open,
+			High:   b.High,
+			Low:    b.Low,
+			Close:  b.Close,
+			Volume: b.Volume,
+		}
+	}
+	latest := candles[len(candles)-1].Date
+
+	key := sym.symbol + "|" + interval
+	s.mu.Lock()
+	since, seen := s.lastBar[key]
+	s.mu.Unlock()
+	if !seen {
+		// First pass: only the latest bar counts as new
+		since = candles[len(candles)-2].Date
+	}
+	if !latest.After(since) {
+		return nil, nil
+	}
+
+	var fresh []analyzer.Pattern
+	for _, p := range s.scanner.ScanAllPatterns(candles) {
+		if p.EndDate.After(since) {
+			fresh = append(fresh, p)
+		}
+	}
+
+	added, err := s.db.SavePatterns(sym.exchange, sym.symbol, interval, fresh)
+	if err != nil {
+		log.Printf("❌ Failed to store patterns for %s (%s): %v", sym.symbol, interval, err)
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.lastBar[key] = latest
+	s.mu.Unlock()
+	return added, nil
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/portfolio"
+)
+
+// DefaultPortfolioSnapshotCron runs at 15:45 IST on weekdays, after the
+// close when the collectors' last quotes are the closing prices
+const DefaultPortfolioSnapshotCron = "45 15 * * 1-5"
+
+// PortfolioSnapshotService records the portfolio's value once a session
+// into the portfolio history behind /portfolio/performance
+type PortfolioSnapshotService struct {
+	db       *database.Database
+	valuer   *portfolio.Service
+	cron     string
+	schedule *CronSchedule
+	location *time.Location
+
+	done chan bool
+}
+
+// NewPortfolioSnapshotService creates a new portfolio snapshot service. cron
+// is a 5-field expression evaluated in IST, empty for the default.
+func NewPortfolioSnapshotService(db *database.Database, valuer *portfolio.Service, cron string) (*PortfolioSnapshotService, error) {
+	if cron == "" {
+		cron = DefaultPortfolioSnapshotCron
+	}
+
+	schedule, err := ParseCron(cron)
+	if err != nil {
+		return nil, err
+	}
+
+	ist := broker.IST()
+
+	return &PortfolioSnapshotService{
+		db:       db,
+		valuer:   valuer,
+		cron:     cron,
+		schedule: schedule,
+		location: ist,
+		done:     make(chan bool),
+	}, nil
+}
+
+// Start begins waiting for scheduled snapshots
+func (s *PortfolioSnapshotService) Start() {
+	log.Printf("🔄 Starting portfolio snapshots (cron: %q IST)", s.cron)
+
+	go func() {
+		for {
+			next := s.schedule.Next(time.Now().In(s.location))
+			if next.IsZero() {
+				log.Println("⚠️  Portfolio snapshot cron never fires, service idle")
+				<-s.done
+				return
+			}
+
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-timer.C:
+				s.RunOnce()
+			case <-s.done:
+				timer.Stop()
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the service
+func (s *PortfolioSnapshotService) Stop() {
+	s.done <- true
+	log.Println("⏹️  Portfolio snapshots stopped")
+}
+
+// RunOnce records today's portfolio value, replacing an earlier snapshot
+// of the same session
+func (s *PortfolioSnapshotService) RunOnce() (*database.PortfolioDay, error) {
+	day, err := s.valuer.RecordDay(s.db)
+	if err != nil {
+		log.Printf("❌ Failed to record portfolio snapshot: %v", err)
+		return nil, err
+	}
+
+	log.Printf("✅ Recorded portfolio value %.2f (day change %.2f%%)", day.NetValue, day.DayChangePct)
+	return day, nil
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/watchlist"
+)
+
+// DefaultQuoteSnapshotInterval is the time between quote snapshots
+const DefaultQuoteSnapshotInterval = 5 * time.Minute
+
+// quoteBatchSize is the most instruments asked for in one quote request,
+// Kite's limit
+const quoteBatchSize = 500
+
+// QuoteSnapshotConfig configures the quote snapshots
+type QuoteSnapshotConfig struct {
+	Interval   time.Duration // Time between snapshots while the market is open (default 5m)
+	Watchlists []string      // Watchlists snapshotted in addition to collector symbols
+}
+
+// QuoteSnapshotService stores the broker's full quote of every collected
+// symbol at an interval during market hours
+type QuoteSnapshotService struct {
+	broker    broker.Broker
+	db        *database.Database
+	collector SymbolProvider
+	config    QuoteSnapshotConfig
+
+	done     chan bool
+	stopOnce sync.Once
+}
+
+// snapshotTarget is where a quoted instrument's snapshots are stored
+type snapshotTarget struct {
+	exchange string
+	symbol   string
+}
+
+// NewQuoteSnapshotService creates a new quote snapshot service
+func NewQuoteSnapshotService(brk broker.Broker, db *database.Database, collector SymbolProvider, config QuoteSnapshotConfig) *QuoteSnapshotService {
+	if config.Interval <= 0 {
+		config.Interval = DefaultQuoteSnapshotInterval
+	}
+	return &QuoteSnapshotService{
+		broker:    brk,
+		db:        db,
+		collector: collector,
+		config:    config,
+		done:      make(chan bool),
+	}
+}
+
+// Start begins taking snapshots in the background
+func (s *QuoteSnapshotService) Start() {
+	log.Printf("📸 Quote snapshots started: every %s while the market is open", s.config.Interval)
+
+	go func() {
+		ticker := time.NewTicker(s.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if s.broker.IsMarketOpen() {
+					s.RunOnce()
+				}
+			case <-s.done:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the service
+func (s *QuoteSnapshotService) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+		log.Println("⏹️  Quote snapshots stopped")
+	})
+}
+
+// RunOnce quotes every collected and watchlist symbol and stores the
+// snapshots, returning how many were stored
+func (s *QuoteSnapshotService) RunOnce() int {
+	targets := snapshotTargets(s.symbols())
+	if len(targets) == 0 {
+		return 0
+	}
+
+	instruments := make([]string, 0, len(targets))
+	for instrument := range targets {
+		instruments = append(instruments, instrument)
+	}
+	sort.Strings(instruments)
+
+	at := time.Now().Truncate(time.Second)
+	stored := 0
+	for start := 0; start < len(instruments); start += quoteBatchSize {
+		batch := instruments[start:min(start+quoteBatchSize, len(instruments))]
+
+		quotes, err := s.broker.GetQuote(batch)
+		if err != nil {
+			log.Printf("❌ Failed to fetch quotes for snapshots: %v", err)
+			continue
+		}
+
+		snapshots := make([]database.QuoteSnapshot, 0, len(quotes))
+		for _, instrument := range batch {
+			quote, ok := quotes[instrument]
+			if !ok || quote.LastPrice == 0 {
+				continue
+			}
+			target := targets[instrument]
+			snapshots = append(snapshots, database.NewQuoteSnapshot(target.exchange, target.symbol, quote, at))
+		}
+
+		if err := s.db.SaveQuoteSnapshots(snapshots); err != nil {
+			log.Printf("❌ Failed to save quote snapshots: %v", err)
+			continue
+		}
+		stored += len(snapshots)
+	}
+
+	log.Printf("📸 Stored %d quote snapshots", stored)
+	return stored
+}
+
+// symbols returns the union of collector and watchlist symbols
+func (s *QuoteSnapshotService) symbols() []string {
+	var symbols []string
+	if s.collector != nil {
+		symbols = append(symbols, s.collector.GetSubscribedSymbols()...)
+	}
+	for _, name := range s.config.Watchlists {
+		if wl := watchlist.GetWatchlist(name); wl != nil {
+			symbols = append(symbols, wl.Symbols...)
+		} else {
+			log.Printf("⚠️  Unknown quote snapshot watchlist: %s", name)
+		}
+	}
+	return symbols
+}
+
+// snapshotTargets maps the broker instrument of each symbol ("NSE:INFY") to
+// where its snapshots are stored. Indices are quoted on their broker
+// exchange and stored on database.IndexExchange, like their bars.
+func snapshotTargets(symbols []string) map[string]snapshotTarget {
+	targets := make(map[string]snapshotTarget, len(symbols))
+	for _, symbol := range symbols {
+		if index, ok := database.ResolveIndex(database.IndexExchange, symbol); ok {
+			targets[index.Exchange+":"+index.Symbol] = snapshotTarget{exchange: database.IndexExchange, symbol: index.Symbol}
+			continue
+		}
+		targets["NSE:"+symbol] = snapshotTarget{exchange: "NSE", symbol: symbol}
+	}
+	return targets
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSnapshotTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		symbols []string
+		want    map[string]snapshotTarget
+	}{
+		{
+			name:    "equities",
+			symbols: []string{"INFY", "TCS"},
+			want: map[string]snapshotTarget{
+				"NSE:INFY": {exchange: "NSE", symbol: "INFY"},
+				"NSE:TCS":  {exchange: "NSE", symbol: "TCS"},
+			},
+		},
+		{
+			name:    "indices by symbol and alias",
+			symbols: []string{"NIFTY 50", "BANKNIFTY", "VIX"},
+			want: map[string]snapshotTarget{
+				"NSE:NIFTY 50":   {exchange: "INDEX", symbol: "NIFTY 50"},
+				"NSE:NIFTY BANK": {exchange: "INDEX", symbol: "NIFTY BANK"},
+				"NSE:INDIA VIX":  {exchange: "INDEX", symbol: "INDIA VIX"},
+			},
+		},
+		{
+			name:    "duplicates from collectors and watchlists",
+			symbols: []string{"INFY", "NIFTY", "INFY", "NIFTY50"},
+			want: map[string]snapshotTarget{
+				"NSE:INFY":     {exchange: "NSE", symbol: "INFY"},
+				"NSE:NIFTY 50": {exchange: "INDEX", symbol: "NIFTY 50"},
+			},
+		},
+		{
+			name:    "none",
+			symbols: nil,
+			want:    map[string]snapshotTarget{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := snapshotTargets(tt.symbols); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("snapshotTargets(%v) = %v, want %v", tt.symbols, got, tt.want)
+			}
+		})
+	}
+}
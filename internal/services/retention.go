@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/timeframe"
+)
+
+// DefaultRetentionCron runs at 02:00 IST every night, well clear of market
+// hours and the after-close backfill
+const DefaultRetentionCron = "0 2 * * *"
+
+// Retention run triggers
+const (
+	RetentionScheduled = "scheduled"
+	RetentionManual    = "api"
+)
+
+// RetentionConfig configures how long market data is kept
+type RetentionConfig struct {
+	Cron             string   // 5-field cron expression, evaluated in IST
+	TickDays         int      // Days raw ticks are kept (default 7)
+	MinuteBarMonths  int      // Months 1m bars are kept (default 6)
+	RollupTimeframes []string // Timeframes 1m bars are rolled up into before deletion (default 1h, 1d)
+	DryRun           bool     // Count what would be rolled up and deleted, change nothing
+}
+
+// RetentionService keeps md.tick_data and 1m bars from growing unbounded.
+// Old ticks are rolled up into 1m bars where the collector didn't write
+// them, and old 1m bars into coarser bars, before they are deleted.
+type RetentionService struct {
+	db       *database.Database
+	config   RetentionConfig
+	schedule *CronSchedule
+	location *time.Location
+
+	// Serializes runs, scheduled or manual
+	runMu sync.Mutex
+
+	cancel context.CancelFunc
+	done   chan bool
+}
+
+// NewRetentionService creates a new retention service
+func NewRetentionService(db *database.Database, config RetentionConfig) (*RetentionService, error) {
+	if config.Cron == "" {
+		config.Cron = DefaultRetentionCron
+	}
+	if config.TickDays <= 0 {
+		config.TickDays = 7
+	}
+	if config.MinuteBarMonths <= 0 {
+		config.MinuteBarMonths = 6
+	}
+	if config.RollupTimeframes == nil {
+		config.RollupTimeframes = []string{"1h", "1d"}
+	}
+	for i, name := range config.RollupTimeframes {
+		tf, err := timeframe.ParseStored(name)
+		if err != nil || !database.IsRollupTimeframe(tf.String()) {
+			return nil, fmt.Errorf("unsupported retention rollup timeframe: %s", name)
+		}
+		config.RollupTimeframes[i] = tf.String()
+	}
+
+	schedule, err := ParseCron(config.Cron)
+	if err != nil {
+		return nil, err
+	}
+
+	ist := broker.IST()
+
+	return &RetentionService{
+		db:       db,
+		config:   config,
+		schedule: schedule,
+		location: ist,
+		done:     make(chan bool),
+	}, nil
+}
+
+// Config returns the service configuration
+func (s *RetentionService) Config() RetentionConfig {
+	return s.config
+}
+
+// Start begins waiting for scheduled runs
+func (s *RetentionService) Start() {
+	log.Printf("🔄 Starting data retention (cron: %q IST, ticks: %d days, 1m bars: %d months, rollups: %v, dry run: %v)",
+		s.config.Cron, s.config.TickDays, s.config.MinuteBarMonths, s.config.RollupTimeframes, s.config.DryRun)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	go func() {
+		for {
+			next := s.schedule.Next(time.Now().In(s.location))
+			if next.IsZero() {
+				log.Println("⚠️  Retention cron never fires, service idle")
+				<-s.done
+				return
+			}
+			log.Printf("📋 Next data retention run at %s", next.Format("2006-01-02 15:04 MST"))
+
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-timer.C:
+				s.RunOnce(ctx, RetentionScheduled, s.config.DryRun)
+			case <-s.done:
+				timer.Stop()
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the service, cancelling a run in progress between steps
+func (s *RetentionService) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.done <- true
+	log.Println("⏹️  Data retention stopped")
+}
+
+// RunOnce applies the retention policy and records the run report. Each
+// tier is only deleted once its rollups succeeded.
+func (s *RetentionService) RunOnce(ctx context.Context, trigger string, dryRun bool) *database.RetentionRun {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	run := &database.RetentionRun{
+		Trigger: trigger,
+		DryRun:  dryRun,
+		Steps:   []database.RetentionStep{},
+	}
+	if err := s.db.CreateRetentionRun(run); err != nil {
+		log.Printf("❌ Failed to record retention run: %v", err)
+		return nil
+	}
+
+	// Cut off at IST midnight so no day or hour is split between kept and
+	// rolled up data
+	now := time.Now().In(s.location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.location)
+	tickCutoff := today.AddDate(0, 0, -s.config.TickDays)
+	barCutoff := today.AddDate(0, -s.config.MinuteBarMonths, 0)
+
+	log.Printf("🧹 Retention run #%d (dry run: %v): ticks before %s, 1m bars before %s",
+		run.RunID, dryRun, tickCutoff.Format("2006-01-02"), barCutoff.Format("2006-01-02"))
+
+	failed := false
+	step := func(action, table, fromTimeframe, timeframe string, before time.Time, fn func() (int64, error)) bool {
+		if ctx.Err() != nil {
+			return false
+		}
+
+		rows, err := fn()
+		result := database.RetentionStep{
+			Action:        action,
+			Table:         table,
+			FromTimeframe: fromTimeframe,
+			Timeframe:     timeframe,
+			Before:        before,
+			Rows:          rows,
+		}
+		if err != nil {
+			log.Printf("❌ Retention %s of %s failed: %v", action, table, err)
+			result.Error = err.Error()
+			failed = true
+		} else if action == "rollup" {
+			run.RowsRolledUp += rows
+		} else {
+			run.RowsDeleted += rows
+		}
+		run.Steps = append(run.Steps, result)
+		return err == nil
+	}
+
+	// Ticks: fill missing 1m bars, then delete
+	if step("rollup", "tick_data", "", "1m", tickCutoff, func() (int64, error) {
+		return s.db.RollupTicks(tickCutoff, dryRun)
+	}) {
+		step("delete", "tick_data", "", "", tickCutoff, func() (int64, error) {
+			return s.db.DeleteTicksBefore(tickCutoff, dryRun)
+		})
+	}
+
+	// 1m bars: roll up into every coarser timeframe, then delete
+	rolledUp := true
+	for _, timeframe := range s.config.RollupTimeframes {
+		timeframe := timeframe
+		if !step("rollup", "intraday_bars", "1m", timeframe, barCutoff, func() (int64, error) {
+			return s.db.RollupBars("1m", timeframe, barCutoff, dryRun)
+		}) {
+			rolledUp = false
+		}
+	}
+	if rolledUp {
+		step("delete", "intraday_bars", "", "1m", barCutoff, func() (int64, error) {
+			return s.db.DeleteBarsBefore("1m", barCutoff, dryRun)
+		})
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		run.Status = database.RetentionCancelled
+	case failed:
+		run.Status = database.RetentionFailed
+		msg := "one or more retention steps failed"
+		run.Error = &msg
+	default:
+		run.Status = database.RetentionCompleted
+	}
+
+	if err := s.db.FinishRetentionRun(run); err != nil {
+		log.Printf("❌ Failed to save retention run #%d: %v", run.RunID, err)
+	}
+
+	log.Printf("📊 Retention run #%d %s: %d rows rolled up, %d rows deleted (dry run: %v)",
+		run.RunID, run.Status, run.RowsRolledUp, run.RowsDeleted, dryRun)
+
+	return run
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/analyzer"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+)
+
+// DefaultSignalTrackInterval is the time between signal evaluations
+const DefaultSignalTrackInterval = 15 * time.Minute
+
+// signalTrackBatch is the most open signals evaluated per pass
+const signalTrackBatch = 1000
+
+// SignalTrackerService closes stored signals once the prices after them
+// reach their stop loss or take profit, or they expire
+type SignalTrackerService struct {
+	db       *database.Database
+	history  *database.HistoricalDataService
+	interval time.Duration
+
+	done     chan bool
+	stopOnce sync.Once
+}
+
+// NewSignalTrackerService creates a signal tracker. Signals are evaluated
+// against the collector's 1m bars, or the broker's minute candles for
+// symbols that aren't collected.
+func NewSignalTrackerService(db *database.Database, history *database.HistoricalDataService, interval time.Duration) *SignalTrackerService {
+	if interval <= 0 {
+		interval = DefaultSignalTrackInterval
+	}
+	return &SignalTrackerService{
+		db:       db,
+		history:  history,
+		interval: interval,
+		done:     make(chan bool),
+	}
+}
+
+// Start begins tracking in the background
+func (s *SignalTrackerService) Start() {
+	log.Printf("🎯 Signal tracker started: every %s", s.interval)
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.RunOnce()
+			case <-s.done:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the tracker
+func (s *SignalTrackerService) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+		log.Println("⏹️  Signal tracker stopped")
+	})
+}
+
+// RunOnce evaluates the open signals and returns how many were closed
+func (s *SignalTrackerService) RunOnce() int {
+	signals, err := s.db.GetOpenSignals(signalTrackBatch)
+	if err != nil {
+		log.Printf("❌ Failed to load open signals: %v", err)
+		return 0
+	}
+
+	now := time.Now()
+	closed := 0
+	for _, record := range signals {
+		until := now
+		expired := false
+		if record.ExpiresAt != nil && !record.ExpiresAt.After(now) {
+			until = *record.ExpiresAt
+			expired = true
+		}
+
+		candles, err := s.candlesSince(record, until)
+		if err != nil {
+			log.Printf("⚠️  Failed to fetch prices for signal %d (%s): %v", record.SignalID, record.Symbol, err)
+			continue
+		}
+
+		outcome := analyzer.EvaluateSignal(record.Signal(), candles, expired)
+		if outcome.Outcome == analyzer.OutcomeOpen {
+			continue
+		}
+		if err := s.db.CloseSignal(record.SignalID, outcome); err != nil {
+			log.Printf("❌ %v", err)
+			continue
+		}
+		closed++
+	}
+
+	if closed > 0 {
+		log.Printf("🎯 Closed %d of %d open signals", closed, len(signals))
+	}
+	return closed
+}
+
+// candlesSince returns the 1m candles of a signal's symbol from its
+// generation to until, from the collector or else the broker
+func (s *SignalTrackerService) candlesSince(record database.SignalRecord, until time.Time) ([]broker.Candle, error) {
+	from := record.GeneratedAt
+
+	bars, err := s.db.GetIntradayBars(record.Exchange, record.Symbol, "1m", from, until, 50000)
+	if err != nil {
+		return nil, err
+	}
+	if len(bars) > 0 {
+		candles := make([]broker.Candle, len(bars))
+		for i, b := range bars {
+			candles[i] = broker.Candle{Date: b.BarTimestamp, Open: b.Open, High: b.High, Low: b.Low, Close: b.Close, Volume: b.Volume}
+		}
+		return candles, nil
+	}
+
+	if s.history == nil {
+		return nil, nil
+	}
+	history, err := s.history.GetHistoricalData(record.Exchange, record.Symbol, "minute", from, until)
+	if err != nil {
+		return nil, err
+	}
+	candles := make([]broker.Candle, 0, len(history))
+	for _, hc := range history {
+		// Minute candles are fetched by day; skip the ones before the signal
+		if hc.CandleTimestamp.Before(from) {
+			continue
+		}
+		candles = append(candles, broker.Candle{
+			Date:   hc.CandleTimestamp,
+			Open:   hc.Open,
+			High:   hc.High,
+			Low:    hc.Low,
+			Close:  hc.Close,
+			Volume: hc.Volume,
+		})
+	}
+	return candles, nil
+}
@@ -0,0 +1,418 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// DefaultSquareOffCron closes intraday positions at 15:15 IST on weekdays,
+// ahead of the broker's own 15:20 auto square-off
+const DefaultSquareOffCron = "15 15 * * 1-5"
+
+// Square-off reasons
+const (
+	SquareOffScheduled = "scheduled"
+	SquareOffManual    = "manual"
+	SquareOffStopLoss  = "stop_loss"
+	SquareOffTrailing  = "trailing_stop"
+)
+
+// SquareOffConfig configures the auto square-off service
+type SquareOffConfig struct {
+	Cron            string        // When MIS positions are closed, evaluated in IST
+	CheckInterval   time.Duration // How often stops are checked while the market is open (default 5s)
+	StopLossPct     float64       // Default stop, % from the average price (0 disables)
+	TrailingStopPct float64       // Default trailing stop, % from the best price seen (0 disables)
+	DryRun          bool          // Report what would be closed without placing orders
+}
+
+// PositionStop is a stop watched for one position. StopLoss is an absolute
+// price; TrailingPct trails the best price seen since the stop was set.
+type PositionStop struct {
+	Exchange    string  `json:"exchange"`
+	Symbol      string  `json:"symbol"`
+	Product     string  `json:"product"`
+	StopLoss    float64 `json:"stop_loss"`
+	TrailingPct float64 `json:"trailing_pct"`
+	BestPrice   float64 `json:"best_price"`
+	Default     bool    `json:"default"`   // Derived from the config rather than set explicitly
+	Triggered   bool    `json:"triggered"` // Hit and acted on; cleared when the position closes
+}
+
+// SquareOffAction is one position closed, or that would be closed in dry-run
+type SquareOffAction struct {
+	Exchange string  `json:"exchange"`
+	Symbol   string  `json:"symbol"`
+	Product  string  `json:"product"`
+	Side     string  `json:"side"`
+	Quantity int     `json:"quantity"`
+	Price    float64 `json:"price"`
+	Reason   string  `json:"reason"`
+	OrderID  string  `json:"order_id,omitempty"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// SquareOffReport is the outcome of one square-off pass
+type SquareOffReport struct {
+	Trigger string            `json:"trigger"`
+	DryRun  bool              `json:"dry_run"`
+	RanAt   time.Time         `json:"ran_at"`
+	Actions []SquareOffAction `json:"actions"`
+}
+
+// SquareOffService closes intraday positions before market close and exits
+// positions whose stop loss or trailing stop is hit
+type SquareOffService struct {
+	broker   broker.Broker
+	config   SquareOffConfig
+	schedule *CronSchedule
+	location *time.Location
+
+	mu         sync.Mutex
+	stops      map[string]*PositionStop
+	lastReport *SquareOffReport
+
+	cancel context.CancelFunc
+	done   chan bool
+}
+
+// NewSquareOffService creates a new square-off service
+func NewSquareOffService(brk broker.Broker, config SquareOffConfig) (*SquareOffService, error) {
+	if config.Cron == "" {
+		config.Cron = DefaultSquareOffCron
+	}
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = 5 * time.Second
+	}
+	if config.StopLossPct < 0 || config.TrailingStopPct < 0 {
+		return nil, fmt.Errorf("square-off stop percentages cannot be negative")
+	}
+
+	schedule, err := ParseCron(config.Cron)
+	if err != nil {
+		return nil, err
+	}
+
+	ist := broker.IST()
+
+	return &SquareOffService{
+		broker:   brk,
+		config:   config,
+		schedule: schedule,
+		location: ist,
+		stops:    make(map[string]*PositionStop),
+		done:     make(chan bool),
+	}, nil
+}
+
+// Config returns the service configuration
+func (s *SquareOffService) Config() SquareOffConfig {
+	return s.config
+}
+
+// Start begins the scheduled square-off and the stop checks
+func (s *SquareOffService) Start() {
+	log.Printf("🔄 Starting auto square-off (cron: %q IST, stop: %.2f%%, trailing: %.2f%%, dry run: %v)",
+		s.config.Cron, s.config.StopLossPct, s.config.TrailingStopPct, s.config.DryRun)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	go func() {
+		ticker := time.NewTicker(s.config.CheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if s.broker.IsMarketOpen() {
+					s.CheckStops()
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		for {
+			next := s.schedule.Next(time.Now().In(s.location))
+			if next.IsZero() {
+				log.Println("⚠️  Square-off cron never fires, only stops are checked")
+				<-s.done
+				return
+			}
+			log.Printf("📋 Next intraday square-off at %s", next.Format("2006-01-02 15:04 MST"))
+
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-timer.C:
+				s.SquareOffIntraday(SquareOffScheduled, s.config.DryRun)
+			case <-s.done:
+				timer.Stop()
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the service
+func (s *SquareOffService) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.done <- true
+	log.Println("⏹️  Auto square-off stopped")
+}
+
+// LastReport returns the most recent square-off pass that acted on positions
+func (s *SquareOffService) LastReport() *SquareOffReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastReport
+}
+
+// SetStop watches a position with an explicit stop loss and/or trailing stop,
+// replacing any default stop
+func (s *SquareOffService) SetStop(stop PositionStop) error {
+	if stop.Symbol == "" {
+		return fmt.Errorf("symbol is required")
+	}
+	if stop.StopLoss < 0 || stop.TrailingPct < 0 || stop.TrailingPct >= 100 {
+		return fmt.Errorf("invalid stop: stop_loss must be >= 0 and trailing_pct between 0 and 100")
+	}
+	if stop.StopLoss == 0 && stop.TrailingPct == 0 {
+		return fmt.Errorf("stop_loss or trailing_pct is required")
+	}
+	if stop.Exchange == "" {
+		stop.Exchange = "NSE"
+	}
+	if stop.Product == "" {
+		stop.Product = "MIS"
+	}
+	stop.BestPrice = 0
+	stop.Default = false
+	stop.Triggered = false
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stops[stopKey(stop.Exchange, stop.Symbol, stop.Product)] = &stop
+	return nil
+}
+
+// RemoveStop stops watching a position, returning false if it wasn't watched
+func (s *SquareOffService) RemoveStop(exchange, symbol, product string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := stopKey(exchange, symbol, product)
+	if _, ok := s.stops[key]; !ok {
+		return false
+	}
+	delete(s.stops, key)
+	return true
+}
+
+// Stops returns the watched stops
+func (s *SquareOffService) Stops() []PositionStop {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stops := make([]PositionStop, 0, len(s.stops))
+	for _, stop := range s.stops {
+		stops = append(stops, *stop)
+	}
+	sort.Slice(stops, func(i, j int) bool {
+		return stops[i].Symbol < stops[j].Symbol
+	})
+	return stops
+}
+
+// SquareOffIntraday closes every open MIS position
+func (s *SquareOffService) SquareOffIntraday(trigger string, dryRun bool) *SquareOffReport {
+	report := &SquareOffReport{Trigger: trigger, DryRun: dryRun, RanAt: time.Now()}
+
+	positions, err := s.broker.GetPositions()
+	if err != nil {
+		log.Printf("❌ Square-off failed to get positions: %v", err)
+		report.Actions = append(report.Actions, SquareOffAction{Reason: trigger, Error: err.Error()})
+		s.record(report)
+		return report
+	}
+
+	for _, pos := range positions.Net {
+		if pos.Quantity == 0 || pos.Product != "MIS" {
+			continue
+		}
+		report.Actions = append(report.Actions, s.close(pos, trigger, dryRun))
+	}
+
+	log.Printf("🔔 Intraday square-off (%s): %d MIS position(s)%s", trigger, len(report.Actions), dryRunSuffix(dryRun))
+	s.record(report)
+	return report
+}
+
+// CheckStops exits positions whose stop loss or trailing stop is hit
+func (s *SquareOffService) CheckStops() *SquareOffReport {
+	positions, err := s.broker.GetPositions()
+	if err != nil {
+		log.Printf("❌ Stop check failed to get positions: %v", err)
+		return nil
+	}
+
+	report := &SquareOffReport{Trigger: "stop_check", DryRun: s.config.DryRun, RanAt: time.Now()}
+	open := make(map[string]bool)
+
+	for _, pos := range positions.Net {
+		if pos.Quantity == 0 || pos.LastPrice <= 0 {
+			continue
+		}
+		key := stopKey(pos.Exchange, pos.Symbol, pos.Product)
+		open[key] = true
+
+		if reason, hit := s.updateStop(key, pos); hit {
+			action := s.close(pos, reason, s.config.DryRun)
+			report.Actions = append(report.Actions, action)
+
+			// Failed exits are retried on the next check
+			if action.Error == "" {
+				s.mu.Lock()
+				if stop, ok := s.stops[key]; ok {
+					stop.Triggered = true
+				}
+				s.mu.Unlock()
+			}
+		}
+	}
+
+	// Forget default and spent stops of positions that have been closed
+	s.mu.Lock()
+	for key, stop := range s.stops {
+		if (stop.Default || stop.Triggered) && !open[key] {
+			delete(s.stops, key)
+		}
+	}
+	s.mu.Unlock()
+
+	if len(report.Actions) == 0 {
+		return report
+	}
+	s.record(report)
+	return report
+}
+
+// updateStop trails the stop of a position and reports whether it was hit
+func (s *SquareOffService) updateStop(key string, pos broker.Position) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stop, ok := s.stops[key]
+	if !ok {
+		if s.config.StopLossPct == 0 && s.config.TrailingStopPct == 0 {
+			return "", false
+		}
+		stop = &PositionStop{
+			Exchange:    pos.Exchange,
+			Symbol:      pos.Symbol,
+			Product:     pos.Product,
+			TrailingPct: s.config.TrailingStopPct,
+			Default:     true,
+		}
+		if s.config.StopLossPct > 0 {
+			if pos.Quantity > 0 {
+				stop.StopLoss = pos.AveragePrice * (1 - s.config.StopLossPct/100)
+			} else {
+				stop.StopLoss = pos.AveragePrice * (1 + s.config.StopLossPct/100)
+			}
+		}
+		s.stops[key] = stop
+	}
+
+	if stop.Triggered {
+		return "", false
+	}
+
+	long := pos.Quantity > 0
+	if stop.BestPrice == 0 || (long && pos.LastPrice > stop.BestPrice) || (!long && pos.LastPrice < stop.BestPrice) {
+		stop.BestPrice = pos.LastPrice
+	}
+
+	if stop.StopLoss > 0 && ((long && pos.LastPrice <= stop.StopLoss) || (!long && pos.LastPrice >= stop.StopLoss)) {
+		return SquareOffStopLoss, true
+	}
+	if stop.TrailingPct > 0 {
+		if long && pos.LastPrice <= stop.BestPrice*(1-stop.TrailingPct/100) {
+			return SquareOffTrailing, true
+		}
+		if !long && pos.LastPrice >= stop.BestPrice*(1+stop.TrailingPct/100) {
+			return SquareOffTrailing, true
+		}
+	}
+	return "", false
+}
+
+// close places a market order that flattens a position
+func (s *SquareOffService) close(pos broker.Position, reason string, dryRun bool) SquareOffAction {
+	side := "SELL"
+	quantity := pos.Quantity
+	if quantity < 0 {
+		side = "BUY"
+		quantity = -quantity
+	}
+
+	action := SquareOffAction{
+		Exchange: pos.Exchange,
+		Symbol:   pos.Symbol,
+		Product:  pos.Product,
+		Side:     side,
+		Quantity: quantity,
+		Price:    pos.LastPrice,
+		Reason:   reason,
+	}
+
+	if dryRun {
+		log.Printf("🧪 [dry run] Would %s %d %s (%s) @ %.2f", side, quantity, pos.Symbol, reason, pos.LastPrice)
+		return action
+	}
+
+	orderID, err := s.broker.PlaceOrder(&broker.OrderRequest{
+		Symbol:          pos.Symbol,
+		Exchange:        pos.Exchange,
+		TransactionType: side,
+		OrderType:       "MARKET",
+		Product:         pos.Product,
+		Quantity:        quantity,
+	})
+	if err != nil {
+		log.Printf("❌ Failed to square off %s: %v", pos.Symbol, err)
+		action.Error = err.Error()
+		return action
+	}
+
+	log.Printf("🔔 Squared off %s: %s %d (%s), order %s", pos.Symbol, side, quantity, reason, orderID)
+	action.OrderID = orderID
+	return action
+}
+
+func (s *SquareOffService) record(report *SquareOffReport) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastReport = report
+}
+
+func stopKey(exchange, symbol, product string) string {
+	return exchange + ":" + symbol + ":" + product
+}
+
+func dryRunSuffix(dryRun bool) string {
+	if dryRun {
+		return " [dry run]"
+	}
+	return ""
+}
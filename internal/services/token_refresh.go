@@ -1,6 +1,8 @@
 package services
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"time"
 
@@ -8,11 +10,44 @@ import (
 	"github.com/trading-chitti/market-bridge/internal/database"
 )
 
-// TokenRefreshService handles automatic token refresh for brokers
+// tokenExpiryThreshold is how long before expiry tokens are refreshed
+const tokenExpiryThreshold = 6 * time.Hour
+
+// Token refresh strategies
+const (
+	RefreshStrategyToken = "refresh_token" // Renewed from the refresh token
+	RefreshStrategyLogin = "login"         // The user has to log in again
+)
+
+// ErrLoginRequired is returned when a broker token can only be renewed by
+// the user logging in again
+var ErrLoginRequired = errors.New("broker login required")
+
+// TokenStatus is the state of a broker's access token
+type TokenStatus struct {
+	ConfigID       int        `json:"config_id"`
+	BrokerName     string     `json:"broker_name"`
+	Status         string     `json:"status"` // valid, expiring, expired, refresh_failed or missing
+	Strategy       string     `json:"strategy"`
+	HasAccessToken bool       `json:"has_access_token"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	LastRefresh    *time.Time `json:"last_refresh,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	LoginURL       string     `json:"login_url,omitempty"` // When the user has to log in
+	UserID         string     `json:"-"`                   // Owner in multi-user mode
+}
+
+// TokenRefreshService renews broker access tokens before they expire. Brokers
+// that issue refresh tokens (Zerodha for approved apps, Angel One) are
+// renewed from them; the others are marked expired and their users told to
+// log in again.
 type TokenRefreshService struct {
 	db     *database.Database
 	ticker *time.Ticker
 	done   chan bool
+
+	// Called for each broker whose token needs the user's login
+	onExpiring func(config broker.BrokerConfig)
 }
 
 // NewTokenRefreshService creates a new token refresh service
@@ -23,6 +58,13 @@ func NewTokenRefreshService(db *database.Database) *TokenRefreshService {
 	}
 }
 
+// SetExpiryHandler sets a callback for brokers whose token is about to
+// expire, has expired or failed to refresh, e.g. to warn the user. Must be
+// called before Start.
+func (s *TokenRefreshService) SetExpiryHandler(fn func(config broker.BrokerConfig)) {
+	s.onExpiring = fn
+}
+
 // Start begins the token refresh loop
 func (s *TokenRefreshService) Start(checkInterval time.Duration) {
 	log.Printf("🔄 Starting token refresh service (check interval: %v)", checkInterval)
@@ -56,9 +98,7 @@ func (s *TokenRefreshService) Stop() {
 
 // refreshExpiredTokens checks for expiring tokens and refreshes them
 func (s *TokenRefreshService) refreshExpiredTokens() {
-	// Get brokers whose tokens expire within 6 hours
-	threshold := 6 * time.Hour
-	configs, err := s.db.GetExpiringSoonBrokerConfigs(threshold)
+	configs, err := s.db.GetExpiringSoonBrokerConfigs(tokenExpiryThreshold)
 	if err != nil {
 		log.Printf("❌ Error fetching expiring configs: %v", err)
 		return
@@ -72,53 +112,140 @@ func (s *TokenRefreshService) refreshExpiredTokens() {
 	log.Printf("🔄 Found %d broker(s) with expiring tokens", len(configs))
 
 	for _, config := range configs {
-		if err := s.refreshBrokerToken(&config); err != nil {
-			log.Printf("❌ Failed to refresh token for %s (ID: %d): %v",
-				config.BrokerName, config.ID, err)
-		} else {
+		err := s.refreshBrokerToken(&config)
+		switch {
+		case err == nil:
 			log.Printf("✅ Successfully refreshed token for %s (ID: %d)",
 				config.BrokerName, config.ID)
+		case errors.Is(err, ErrLoginRequired):
+			log.Printf("ℹ️  Token for %s (ID: %d) needs a new login", config.BrokerName, config.ID)
+		default:
+			log.Printf("❌ Failed to refresh token for %s (ID: %d): %v",
+				config.BrokerName, config.ID, err)
 		}
 	}
 }
 
-// refreshBrokerToken refreshes access token for a specific broker
+// refreshBrokerToken renews a broker's access token from its refresh token.
+// Brokers without one get ErrLoginRequired, and are marked expired once
+// the token has expired. The user is told whenever they need to log in.
 func (s *TokenRefreshService) refreshBrokerToken(config *broker.BrokerConfig) error {
+	brk, err := broker.NewBroker(config)
+	if err != nil {
+		return err
+	}
+
+	refresher, ok := brk.(broker.TokenRefresher)
+	if !ok || config.RefreshToken == "" {
+		expired := config.TokenExpiresAt != nil && time.Now().After(*config.TokenExpiresAt)
+		if expired && config.TokenStatus == database.TokenStatusExpired {
+			return ErrLoginRequired // Already marked and the user told
+		}
+		if expired {
+			config.TokenStatus = database.TokenStatusExpired
+			if err := s.db.SetBrokerTokenStatus(config.ID, config.TokenStatus, ""); err != nil {
+				return err
+			}
+		}
+		s.notify(*config)
+		return ErrLoginRequired
+	}
+
 	log.Printf("🔑 Refreshing token for %s broker (ID: %d)", config.BrokerName, config.ID)
 
-	// Create broker instance
-	brk, err := broker.NewBroker(config)
+	session, err := refresher.RefreshSession()
 	if err != nil {
+		config.TokenStatus = database.TokenStatusRefreshFailed
+		config.TokenError = err.Error()
+		if dbErr := s.db.SetBrokerTokenStatus(config.ID, config.TokenStatus, config.TokenError); dbErr != nil {
+			log.Printf("⚠️  Failed to record token refresh failure: %v", dbErr)
+		}
+		s.notify(*config)
 		return err
 	}
 
-	// For Zerodha, we need to use refresh token to get new access token
-	zerodhaBroker, ok := brk.(*broker.ZerodhaBroker)
-	if !ok {
-		log.Printf("⚠️  Token refresh not implemented for %s", config.BrokerName)
-		return nil
+	refreshToken := session.RefreshToken
+	if refreshToken == "" {
+		refreshToken = config.RefreshToken
+	}
+	return s.db.UpdateBrokerTokens(config.ID, session.AccessToken, refreshToken, session.ExpiresAt)
+}
+
+func (s *TokenRefreshService) notify(config broker.BrokerConfig) {
+	if s.onExpiring != nil {
+		s.onExpiring(config)
+	}
+}
+
+// Status reports a broker's token state and how it gets renewed, nil if
+// there is no broker config with the ID
+func (s *TokenRefreshService) Status(configID int) (*TokenStatus, error) {
+	config, err := s.db.GetBrokerConfig(configID)
+	if err != nil || config == nil {
+		return nil, err
+	}
+	return s.status(config), nil
+}
+
+func (s *TokenRefreshService) status(config *broker.BrokerConfig) *TokenStatus {
+	status := &TokenStatus{
+		ConfigID:       config.ID,
+		BrokerName:     config.BrokerName,
+		Strategy:       RefreshStrategyLogin,
+		HasAccessToken: config.AccessToken != "",
+		ExpiresAt:      config.TokenExpiresAt,
+		LastRefresh:    config.LastTokenRefresh,
+		LastError:      config.TokenError,
+		UserID:         config.UserID,
 	}
 
-	// Note: Zerodha Kite Connect v4 supports refresh tokens
-	// You would need to implement the refresh logic here
-	// For now, this is a placeholder
+	brk, err := broker.NewBroker(config)
+	if err == nil {
+		if _, ok := brk.(broker.TokenRefresher); ok && config.RefreshToken != "" {
+			status.Strategy = RefreshStrategyToken
+		}
+	}
 
-	_ = zerodhaBroker // Use the variable to avoid unused error
+	now := time.Now()
+	switch {
+	case config.AccessToken == "":
+		status.Status = "missing"
+	case config.TokenStatus == database.TokenStatusExpired,
+		config.TokenExpiresAt != nil && now.After(*config.TokenExpiresAt):
+		status.Status = "expired"
+	case config.TokenStatus == database.TokenStatusRefreshFailed:
+		status.Status = "refresh_failed"
+	case config.TokenExpiresAt != nil && config.TokenExpiresAt.Sub(now) < tokenExpiryThreshold:
+		status.Status = "expiring"
+	default:
+		status.Status = "valid"
+	}
 
-	// TODO: Implement actual token refresh
-	// newTokens, err := zerodhaBroker.GetClient().RenewAccessToken(refreshToken, apiSecret)
-	// if err != nil {
-	//     return err
-	// }
+	if err == nil && status.Status != "valid" && status.Strategy == RefreshStrategyLogin {
+		status.LoginURL = brk.GetLoginURL()
+	}
+	return status
+}
 
-	// Update database with new tokens
-	// expiresAt := time.Now().Add(24 * time.Hour) // Zerodha tokens expire in 24 hours
-	// err = s.db.UpdateBrokerTokens(config.ID, newTokens.AccessToken, newTokens.RefreshToken, expiresAt)
+// Refresh renews a broker's token now. It returns the resulting status,
+// nil if there is no broker config with the ID, and ErrLoginRequired when
+// only the user can renew it.
+func (s *TokenRefreshService) Refresh(configID int) (*TokenStatus, error) {
+	config, err := s.db.GetBrokerConfig(configID)
+	if err != nil || config == nil {
+		return nil, err
+	}
 
-	log.Printf("ℹ️  Token refresh for %s requires manual intervention (Zerodha requires daily login)",
-		config.BrokerName)
+	refreshErr := s.refreshBrokerToken(config)
+	if refreshErr != nil && !errors.Is(refreshErr, ErrLoginRequired) {
+		refreshErr = fmt.Errorf("failed to refresh token: %w", refreshErr)
+	}
 
-	return nil
+	// Report what was stored
+	if updated, err := s.db.GetBrokerConfig(configID); err == nil && updated != nil {
+		config = updated
+	}
+	return s.status(config), refreshErr
 }
 
 // RefreshAllTokensNow forces immediate refresh of all enabled brokers
@@ -133,7 +260,7 @@ func (s *TokenRefreshService) RefreshAllTokensNow() error {
 			continue
 		}
 
-		if err := s.refreshBrokerToken(&config); err != nil {
+		if _, err := s.Refresh(config.ID); err != nil && !errors.Is(err, ErrLoginRequired) {
 			log.Printf("❌ Failed to refresh %s: %v", config.BrokerName, err)
 		}
 	}
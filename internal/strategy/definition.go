@@ -0,0 +1,326 @@
+package strategy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/trading-chitti/market-bridge/internal/timeframe"
+)
+
+// Position sides
+const (
+	SideLong  = "LONG"
+	SideShort = "SHORT"
+)
+
+// Position sizing methods
+const (
+	SizingPercentEquity = "percent_equity"
+	SizingFixedQuantity = "fixed_quantity"
+	SizingFixedAmount   = "fixed_amount"
+	SizingRiskPercent   = "risk_percent"
+)
+
+// Comparison operators
+const (
+	OpGreater      = ">"
+	OpGreaterEqual = ">="
+	OpLess         = "<"
+	OpLessEqual    = "<="
+	OpCrossesAbove = "crosses_above"
+	OpCrossesBelow = "crosses_below"
+)
+
+// Definition is a user-defined rule-based strategy. Rules are evaluated at
+// the close of each bar: Entry opens a position on Side, Exit closes it.
+//
+//	{
+//	  "name": "rsi_dip",
+//	  "interval": "day",
+//	  "side": "LONG",
+//	  "entry": {"all": [
+//	    {"left": {"indicator": "rsi", "period": 14}, "op": "crosses_above", "right": {"value": 30}},
+//	    {"left": {"indicator": "close"}, "op": ">", "right": {"indicator": "sma", "period": 200}}
+//	  ]},
+//	  "exit": {"any": [
+//	    {"left": {"indicator": "rsi", "period": 14}, "op": ">", "right": {"value": 70}}
+//	  ]},
+//	  "stop_loss_pct": 3,
+//	  "take_profit_pct": 8,
+//	  "sizing": {"method": "risk_percent", "value": 1}
+//	}
+type Definition struct {
+	Name          string  `json:"name"`
+	Description   string  `json:"description,omitempty"`
+	Interval      string  `json:"interval"` // day, 60minute, 15minute, 5minute, minute (or 1d, 1h, 15m, 5m, 1m)
+	Side          string  `json:"side"`     // LONG or SHORT
+	Entry         RuleSet `json:"entry"`
+	Exit          RuleSet `json:"exit"`
+	StopLossPct   float64 `json:"stop_loss_pct,omitempty"`
+	TakeProfitPct float64 `json:"take_profit_pct,omitempty"`
+	Sizing        Sizing  `json:"sizing"`
+}
+
+// RuleSet matches when every All condition holds and, if Any is non-empty,
+// at least one Any condition holds. An empty rule set never matches.
+type RuleSet struct {
+	All []Condition `json:"all,omitempty"`
+	Any []Condition `json:"any,omitempty"`
+}
+
+// Condition compares two operands on the current bar
+type Condition struct {
+	Left  Operand `json:"left"`
+	Op    string  `json:"op"`
+	Right Operand `json:"right"`
+}
+
+// Operand is either a constant Value or an indicator series. Offset reads
+// the indicator that many bars back; Scale multiplies it, e.g. 2 times the
+// average volume.
+type Operand struct {
+	Indicator string   `json:"indicator,omitempty"`
+	Period    int      `json:"period,omitempty"`
+	Param     float64  `json:"param,omitempty"` // Band width for bb_*, multiplier for supertrend
+	Offset    int      `json:"offset,omitempty"`
+	Scale     float64  `json:"scale,omitempty"`
+	Value     *float64 `json:"value,omitempty"`
+}
+
+// Sizing decides the quantity for each entry
+type Sizing struct {
+	Method string  `json:"method"`
+	Value  float64 `json:"value"`
+}
+
+// indicatorSpec describes a supported indicator
+type indicatorSpec struct {
+	defaultPeriod int
+	defaultParam  float64
+}
+
+// indicators lists the supported operand indicators
+var indicators = map[string]indicatorSpec{
+	"open":           {},
+	"high":           {},
+	"low":            {},
+	"close":          {},
+	"volume":         {},
+	"vwap":           {},
+	"sma":            {defaultPeriod: 20},
+	"ema":            {defaultPeriod: 20},
+	"rsi":            {defaultPeriod: 14},
+	"atr":            {defaultPeriod: 14},
+	"adx":            {defaultPeriod: 14},
+	"roc":            {defaultPeriod: 10},
+	"highest":        {defaultPeriod: 20},
+	"lowest":         {defaultPeriod: 20},
+	"volume_sma":     {defaultPeriod: 20},
+	"macd":           {},
+	"macd_signal":    {},
+	"macd_histogram": {},
+	"bb_upper":       {defaultPeriod: 20, defaultParam: 2},
+	"bb_middle":      {defaultPeriod: 20, defaultParam: 2},
+	"bb_lower":       {defaultPeriod: 20, defaultParam: 2},
+	"supertrend":     {defaultPeriod: 10, defaultParam: 3},
+}
+
+// Parse decodes and validates a JSON strategy definition
+func Parse(data []byte) (*Definition, error) {
+	var def Definition
+	if err := json.Unmarshal(data, &def); err != nil {
+		return nil, fmt.Errorf("invalid strategy definition: %w", err)
+	}
+	if err := def.Validate(); err != nil {
+		return nil, err
+	}
+	return &def, nil
+}
+
+// Validate checks the definition and fills in defaults
+func (d *Definition) Validate() error {
+	d.Name = strings.TrimSpace(d.Name)
+	if d.Name == "" {
+		return fmt.Errorf("strategy name is required")
+	}
+
+	if d.Interval == "" {
+		d.Interval = "day"
+	}
+	interval, err := NormalizeInterval(d.Interval)
+	if err != nil {
+		return err
+	}
+	d.Interval = interval
+
+	d.Side = strings.ToUpper(d.Side)
+	if d.Side == "" {
+		d.Side = SideLong
+	}
+	if d.Side != SideLong && d.Side != SideShort {
+		return fmt.Errorf("invalid side %q (use LONG or SHORT)", d.Side)
+	}
+
+	if len(d.Entry.All) == 0 && len(d.Entry.Any) == 0 {
+		return fmt.Errorf("entry rules are required")
+	}
+	if err := d.Entry.validate("entry"); err != nil {
+		return err
+	}
+	if err := d.Exit.validate("exit"); err != nil {
+		return err
+	}
+
+	if d.StopLossPct < 0 || d.TakeProfitPct < 0 {
+		return fmt.Errorf("stop_loss_pct and take_profit_pct cannot be negative")
+	}
+
+	switch d.Sizing.Method {
+	case "":
+		d.Sizing = Sizing{Method: SizingPercentEquity, Value: 100}
+	case SizingPercentEquity:
+		if d.Sizing.Value <= 0 || d.Sizing.Value > 100 {
+			return fmt.Errorf("percent_equity sizing needs a value between 0 and 100")
+		}
+	case SizingFixedQuantity, SizingFixedAmount:
+		if d.Sizing.Value <= 0 {
+			return fmt.Errorf("%s sizing needs a positive value", d.Sizing.Method)
+		}
+	case SizingRiskPercent:
+		if d.Sizing.Value <= 0 || d.Sizing.Value > 100 {
+			return fmt.Errorf("risk_percent sizing needs a value between 0 and 100")
+		}
+		if d.StopLossPct <= 0 {
+			return fmt.Errorf("risk_percent sizing needs stop_loss_pct")
+		}
+	default:
+		return fmt.Errorf("invalid sizing method %q", d.Sizing.Method)
+	}
+
+	return nil
+}
+
+func (r *RuleSet) validate(name string) error {
+	for i := range r.All {
+		if err := r.All[i].validate(); err != nil {
+			return fmt.Errorf("%s.all[%d]: %w", name, i, err)
+		}
+	}
+	for i := range r.Any {
+		if err := r.Any[i].validate(); err != nil {
+			return fmt.Errorf("%s.any[%d]: %w", name, i, err)
+		}
+	}
+	return nil
+}
+
+// NormalizeInterval returns the Kite name of a candle interval rules can be
+// evaluated on, accepting the bar names too (5m for 5minute)
+func NormalizeInterval(interval string) (string, error) {
+	tf, err := timeframe.ParseStored(interval)
+	if err != nil {
+		return "", fmt.Errorf("invalid interval: %w", err)
+	}
+	return tf.Kite(), nil
+}
+
+// Validate checks a standalone condition and fills in indicator defaults
+func (c *Condition) Validate() error {
+	return c.validate()
+}
+
+// Warmup is the number of bars a standalone condition needs before it can
+// hold, including the extra bar for crossovers
+func (c *Condition) Warmup() int {
+	bars := c.Left.warmup()
+	if w := c.Right.warmup(); w > bars {
+		bars = w
+	}
+	return bars + 1
+}
+
+func (c *Condition) validate() error {
+	switch c.Op {
+	case OpGreater, OpGreaterEqual, OpLess, OpLessEqual, OpCrossesAbove, OpCrossesBelow:
+	default:
+		return fmt.Errorf("invalid op %q", c.Op)
+	}
+	if c.Left.Value != nil && c.Right.Value != nil {
+		return fmt.Errorf("condition compares two constants")
+	}
+	if err := c.Left.validate(); err != nil {
+		return fmt.Errorf("left: %w", err)
+	}
+	if err := c.Right.validate(); err != nil {
+		return fmt.Errorf("right: %w", err)
+	}
+	return nil
+}
+
+func (o *Operand) validate() error {
+	if o.Value != nil {
+		if o.Indicator != "" {
+			return fmt.Errorf("operand has both value and indicator")
+		}
+		if o.Scale != 0 {
+			return fmt.Errorf("scale only applies to indicators")
+		}
+		return nil
+	}
+
+	o.Indicator = strings.ToLower(o.Indicator)
+	spec, ok := indicators[o.Indicator]
+	if !ok {
+		return fmt.Errorf("unknown indicator %q", o.Indicator)
+	}
+	if o.Period == 0 {
+		o.Period = spec.defaultPeriod
+	}
+	if o.Param == 0 {
+		o.Param = spec.defaultParam
+	}
+	if o.Period < 0 || o.Offset < 0 || o.Scale < 0 {
+		return fmt.Errorf("period, offset and scale cannot be negative")
+	}
+	if spec.defaultPeriod > 0 && o.Period < 2 && o.Indicator != "roc" {
+		return fmt.Errorf("%s needs a period of at least 2", o.Indicator)
+	}
+	return nil
+}
+
+// warmup is the number of bars an operand needs before it is defined
+func (o *Operand) warmup() int {
+	if o.Value != nil {
+		return 0
+	}
+
+	bars := o.Period
+	switch o.Indicator {
+	case "macd", "macd_signal", "macd_histogram":
+		bars = 26 + 9
+	case "adx":
+		bars = o.Period * 2
+	case "rsi", "roc":
+		bars = o.Period + 1
+	}
+	return bars + o.Offset
+}
+
+// warmup is the number of bars the strategy needs before rules can fire.
+// One extra bar is needed for crossovers.
+func (d *Definition) warmup() int {
+	bars := 0
+	for _, rules := range []RuleSet{d.Entry, d.Exit} {
+		for _, group := range [][]Condition{rules.All, rules.Any} {
+			for i := range group {
+				for _, o := range []*Operand{&group[i].Left, &group[i].Right} {
+					if w := o.warmup(); w > bars {
+						bars = w
+					}
+				}
+			}
+		}
+	}
+	return bars + 1
+}
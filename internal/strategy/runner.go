@@ -0,0 +1,402 @@
+package strategy
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/backtest"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"github.com/trading-chitti/market-bridge/internal/database"
+	"github.com/trading-chitti/market-bridge/internal/timeframe"
+)
+
+// Signal modes stored with strategies.signals
+const (
+	ModeScan = "scan"
+	ModeLive = "live"
+)
+
+var (
+	// ErrAlreadyRunning is returned when starting a strategy that is live
+	ErrAlreadyRunning = errors.New("strategy is already running live")
+	// ErrNotRunning is returned when stopping a strategy that isn't live
+	ErrNotRunning = errors.New("strategy is not running live")
+)
+
+// barsPerDay is the number of bars in one NSE session (09:15-15:30)
+var barsPerDay = map[string]int{
+	"minute":   375,
+	"5minute":  75,
+	"15minute": 25,
+	"60minute": 7,
+	"day":      1,
+}
+
+// ScanResult is the outcome of scanning a list of symbols
+type ScanResult struct {
+	Strategy string                    `json:"strategy"`
+	Scanned  int                       `json:"scanned"`
+	Signals  []database.StrategySignal `json:"signals"`
+	Errors   map[string]string         `json:"errors,omitempty"`
+}
+
+// LiveStatus describes a strategy running in live-signal mode
+type LiveStatus struct {
+	StrategyID string            `json:"strategy_id"`
+	UserID     string            `json:"user_id,omitempty"`
+	Name       string            `json:"name"`
+	Exchange   string            `json:"exchange"`
+	Symbols    []string          `json:"symbols"`
+	Interval   string            `json:"interval"`
+	PollEvery  string            `json:"poll_every"`
+	StartedAt  time.Time         `json:"started_at"`
+	LastRunAt  *time.Time        `json:"last_run_at,omitempty"`
+	Signals    int               `json:"signals"`
+	Errors     map[string]string `json:"errors,omitempty"`
+}
+
+// liveRun is one strategy polling the collector's bars
+type liveRun struct {
+	strategy *Strategy
+	status   LiveStatus
+	done     chan bool
+}
+
+// Runner loads candles and runs strategies in backtest, scan and live modes
+type Runner struct {
+	db         *database.Database
+	historical *database.HistoricalDataService
+
+	mu   sync.Mutex
+	live map[string]*liveRun
+	wg   sync.WaitGroup
+}
+
+// NewRunner creates a strategy runner
+func NewRunner(brk broker.Broker, db *database.Database) *Runner {
+	return &Runner{
+		db:         db,
+		historical: database.NewHistoricalDataService(db, brk),
+		live:       make(map[string]*liveRun),
+	}
+}
+
+// ============================================================================
+// BACKTEST
+// ============================================================================
+
+// Backtest runs the strategy over cached historical candles. The
+// definition's stops, side and sizing override those in config.
+func (r *Runner) Backtest(s *Strategy, exchange, symbol string, from, to time.Time, config backtest.Config) (*backtest.Result, error) {
+	candles, err := r.historicalCandles(exchange, symbol, s.def.Interval, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(candles) == 0 {
+		return nil, fmt.Errorf("no historical data for %s:%s", exchange, symbol)
+	}
+
+	config.Symbol = symbol
+	return backtest.Run(s, candles, s.BacktestConfig(config))
+}
+
+// ============================================================================
+// SCAN
+// ============================================================================
+
+// Scan evaluates the latest bar of each symbol and returns the symbols whose
+// entry or exit rules fire. Signals are stored when strategyID is set.
+func (r *Runner) Scan(strategyID string, s *Strategy, exchange string, symbols []string) *ScanResult {
+	result := &ScanResult{
+		Strategy: s.Name(),
+		Signals:  []database.StrategySignal{},
+		Errors:   make(map[string]string),
+	}
+
+	for _, symbol := range symbols {
+		candles, err := r.recentCandles(exchange, symbol, s.def.Interval, s.Warmup()+1, true)
+		if err != nil {
+			result.Errors[symbol] = err.Error()
+			continue
+		}
+		result.Scanned++
+
+		signal, ok := r.evaluate(s, exchange, symbol, candles)
+		if !ok {
+			continue
+		}
+		signal.StrategyID = strategyID
+		signal.Mode = ModeScan
+
+		if strategyID != "" {
+			if _, err := r.db.InsertStrategySignal(&signal); err != nil {
+				log.Printf("⚠️  Failed to store scan signal for %s: %v", symbol, err)
+			}
+		}
+		result.Signals = append(result.Signals, signal)
+	}
+
+	return result
+}
+
+// evaluate runs the strategy on the last candle and converts a non-hold
+// decision into a signal
+func (r *Runner) evaluate(s *Strategy, exchange, symbol string, candles []broker.Candle) (database.StrategySignal, bool) {
+	decision := s.Evaluate(candles)
+	if decision.Action == backtest.ActionHold {
+		return database.StrategySignal{}, false
+	}
+
+	last := candles[len(candles)-1]
+	return database.StrategySignal{
+		Exchange:     exchange,
+		Symbol:       symbol,
+		Action:       string(decision.Action),
+		Price:        last.Close,
+		BarTimestamp: last.Date,
+		Reason:       decision.Reason,
+	}, true
+}
+
+// ============================================================================
+// LIVE SIGNALS
+// ============================================================================
+
+// StartLive evaluates the strategy on the collector's latest bars every
+// pollEvery and stores new signals. Each bar produces at most one signal per
+// symbol.
+func (r *Runner) StartLive(strategyID, userID string, s *Strategy, exchange string, symbols []string, pollEvery time.Duration) (LiveStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.live[strategyID]; ok {
+		return LiveStatus{}, ErrAlreadyRunning
+	}
+
+	run := &liveRun{
+		strategy: s,
+		status: LiveStatus{
+			StrategyID: strategyID,
+			UserID:     userID,
+			Name:       s.Name(),
+			Exchange:   exchange,
+			Symbols:    symbols,
+			Interval:   s.def.Interval,
+			PollEvery:  pollEvery.String(),
+			StartedAt:  time.Now(),
+			Errors:     make(map[string]string),
+		},
+		done: make(chan bool),
+	}
+	r.live[strategyID] = run
+
+	r.wg.Add(1)
+	go r.runLive(run, pollEvery)
+
+	log.Printf("🚀 Strategy %s running live on %d symbols (%s bars)", s.Name(), len(symbols), s.def.Interval)
+	return run.status, nil
+}
+
+// StopLive stops a live strategy
+func (r *Runner) StopLive(strategyID string) error {
+	r.mu.Lock()
+	run, ok := r.live[strategyID]
+	if ok {
+		delete(r.live, strategyID)
+	}
+	r.mu.Unlock()
+
+	if !ok {
+		return ErrNotRunning
+	}
+
+	close(run.done)
+	log.Printf("🛑 Strategy %s stopped", run.status.Name)
+	return nil
+}
+
+// GetLiveStatus returns the status of a live strategy
+func (r *Runner) GetLiveStatus(strategyID string) (LiveStatus, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	run, ok := r.live[strategyID]
+	if !ok {
+		return LiveStatus{}, false
+	}
+	return run.snapshot(), true
+}
+
+// ListLive returns the live strategies owned by userID, sorted by name
+func (r *Runner) ListLive(userID string) []LiveStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	statuses := []LiveStatus{}
+	for _, run := range r.live {
+		if run.status.UserID == userID {
+			statuses = append(statuses, run.snapshot())
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// Stop stops all live strategies and waits for them to exit
+func (r *Runner) Stop() {
+	r.mu.Lock()
+	for id, run := range r.live {
+		close(run.done)
+		delete(r.live, id)
+	}
+	r.mu.Unlock()
+
+	r.wg.Wait()
+}
+
+// snapshot copies the status; callers must hold r.mu
+func (run *liveRun) snapshot() LiveStatus {
+	status := run.status
+	status.Errors = make(map[string]string, len(run.status.Errors))
+	for k, v := range run.status.Errors {
+		status.Errors[k] = v
+	}
+	return status
+}
+
+func (r *Runner) runLive(run *liveRun, pollEvery time.Duration) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(pollEvery)
+	defer ticker.Stop()
+
+	r.pollLive(run)
+	for {
+		select {
+		case <-ticker.C:
+			r.pollLive(run)
+		case <-run.done:
+			return
+		}
+	}
+}
+
+// pollLive evaluates every symbol once. Strategies aren't safe for
+// concurrent use, but each live run owns its own.
+func (r *Runner) pollLive(run *liveRun) {
+	s := run.strategy
+	exchange := run.status.Exchange
+	errs := make(map[string]string)
+	newSignals := 0
+
+	for _, symbol := range run.status.Symbols {
+		candles, err := r.recentCandles(exchange, symbol, s.def.Interval, s.Warmup()+1, false)
+		if err != nil {
+			errs[symbol] = err.Error()
+			continue
+		}
+
+		signal, ok := r.evaluate(s, exchange, symbol, candles)
+		if !ok {
+			continue
+		}
+		signal.StrategyID = run.status.StrategyID
+		signal.Mode = ModeLive
+
+		inserted, err := r.db.InsertStrategySignal(&signal)
+		if err != nil {
+			errs[symbol] = err.Error()
+			continue
+		}
+		if inserted {
+			newSignals++
+			log.Printf("📊 [%s] %s %s @ %.2f: %s", s.Name(), signal.Action, symbol, signal.Price, signal.Reason)
+		}
+	}
+
+	now := time.Now()
+	r.mu.Lock()
+	run.status.LastRunAt = &now
+	run.status.Signals += newSignals
+	run.status.Errors = errs
+	r.mu.Unlock()
+}
+
+// ============================================================================
+// CANDLES
+// ============================================================================
+
+// recentCandles returns at least bars of the most recent candles, read from
+// the collector's md.intraday_bars. When allowHistorical is set, missing
+// history is fetched through the historical data cache instead.
+func (r *Runner) recentCandles(exchange, symbol, interval string, bars int, allowHistorical bool) ([]broker.Candle, error) {
+	tf, err := timeframe.ParseStored(interval)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported interval: %w", err)
+	}
+
+	stored, err := r.db.GetRecentIntradayBars(exchange, symbol, tf.String(), bars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bars: %w", err)
+	}
+	if len(stored) >= bars {
+		candles := make([]broker.Candle, len(stored))
+		for i, bar := range stored {
+			candles[i] = broker.Candle{
+				Date:   bar.BarTimestamp,
+				Open:   bar.Open,
+				High:   bar.High,
+				Low:    bar.Low,
+				Close:  bar.Close,
+				Volume: bar.Volume,
+			}
+		}
+		return candles, nil
+	}
+
+	if !allowHistorical {
+		return nil, fmt.Errorf("waiting for data: %d of %d %s bars", len(stored), bars, tf)
+	}
+
+	// Widen the window by weekends and holidays
+	tradingDays := (bars + barsPerDay[interval] - 1) / barsPerDay[interval]
+	calendarDays := tradingDays*7/5 + 10
+	to := time.Now()
+	from := to.AddDate(0, 0, -calendarDays)
+
+	candles, err := r.historicalCandles(exchange, symbol, interval, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(candles) < bars {
+		return nil, fmt.Errorf("insufficient data: need %d %s bars, got %d", bars, interval, len(candles))
+	}
+	return candles[len(candles)-bars:], nil
+}
+
+// historicalCandles loads candles through the historical data cache
+func (r *Runner) historicalCandles(exchange, symbol, interval string, from, to time.Time) ([]broker.Candle, error) {
+	cached, err := r.historical.GetHistoricalData(exchange, symbol, interval, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch historical data: %w", err)
+	}
+
+	candles := make([]broker.Candle, len(cached))
+	for i, hc := range cached {
+		candles[i] = broker.Candle{
+			Date:   hc.CandleTimestamp,
+			Open:   hc.Open,
+			High:   hc.High,
+			Low:    hc.Low,
+			Close:  hc.Close,
+			Volume: hc.Volume,
+		}
+	}
+	return candles, nil
+}
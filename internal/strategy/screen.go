@@ -0,0 +1,397 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/analyzer"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// screenPatternBars is the history a screen with pattern filters needs;
+// chart patterns need a few dozen bars of context
+const screenPatternBars = 100
+
+// Screen is a parsed screener filter: conditions joined by AND, evaluated
+// on the latest bar.
+//
+//	RSI < 30 AND price > SMA50 AND volume > 2x avg AND pattern = "Bullish Engulfing"
+//
+// Operands are indicator names with an optional period suffix (rsi, rsi7,
+// sma50, ema20, atr, adx, macd, ...), the bar fields open, high, low, close
+// (or price) and volume, avg (average volume, 20 bars, or avg50), or
+// numbers. An indicator on the right can be scaled, e.g. 2x avg or 0.95x
+// sma200. Operators are <, <=, >, >=, crosses_above and crosses_below.
+// "pattern" and "signal" (bullish, bearish, neutral) filter on patterns
+// completed on the latest bar with = or !=.
+type Screen struct {
+	Expression string          `json:"expression"`
+	Conditions []Condition     `json:"conditions"`
+	Patterns   []PatternFilter `json:"patterns,omitempty"`
+}
+
+// PatternFilter matches patterns completed on the latest bar by name or
+// signal. Negate requires that no such pattern completed.
+type PatternFilter struct {
+	Field  string `json:"field"` // pattern or signal
+	Value  string `json:"value"`
+	Negate bool   `json:"negate,omitempty"`
+}
+
+// ScreenMatch is a symbol that passed a screen, with the values of every
+// indicator the screen referenced on the latest bar
+type ScreenMatch struct {
+	Symbol   string             `json:"symbol"`
+	Date     time.Time          `json:"date"`
+	Close    float64            `json:"close"`
+	Metrics  map[string]float64 `json:"metrics"`
+	Patterns []string           `json:"patterns,omitempty"` // Patterns completed on the latest bar
+}
+
+// operandAliases maps screener names onto rule-language indicators
+var operandAliases = map[string]string{
+	"price":      "close",
+	"avg":        "volume_sma",
+	"avg_volume": "volume_sma",
+	"avgvol":     "volume_sma",
+}
+
+// ParseScreen parses and validates a screener filter expression
+func ParseScreen(expr string) (*Screen, error) {
+	tokens, err := screenTokens(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("filter is empty")
+	}
+
+	screen := &Screen{Expression: strings.TrimSpace(expr)}
+
+	var clause []screenToken
+	for i := 0; i <= len(tokens); i++ {
+		if i < len(tokens) && !tokens[i].isWord("and") {
+			if tokens[i].isWord("or") {
+				return nil, fmt.Errorf("only AND is supported between conditions")
+			}
+			clause = append(clause, tokens[i])
+			continue
+		}
+		if err := screen.addClause(clause); err != nil {
+			return nil, fmt.Errorf("condition %d: %w", len(screen.Conditions)+len(screen.Patterns)+1, err)
+		}
+		clause = nil
+	}
+
+	return screen, nil
+}
+
+// addClause parses one "left op right" clause
+func (s *Screen) addClause(clause []screenToken) error {
+	if len(clause) < 3 {
+		return fmt.Errorf("expected <metric> <operator> <value>")
+	}
+	left, op, right := clause[0], clause[1], clause[2:]
+	if left.kind != tokenWord {
+		return fmt.Errorf("expected a metric, got %q", left.text)
+	}
+	if op.kind != tokenOp {
+		return fmt.Errorf("expected an operator after %s, got %q", left.text, op.text)
+	}
+
+	field := strings.ToLower(left.text)
+	if field == "pattern" || field == "signal" {
+		if op.text != "=" && op.text != "==" && op.text != "!=" {
+			return fmt.Errorf("%s filters use = or !=", field)
+		}
+		words := make([]string, len(right))
+		for i, t := range right {
+			words[i] = t.text
+		}
+		value := strings.Join(words, " ")
+		if field == "signal" {
+			value = strings.ToLower(value)
+			if value != "bullish" && value != "bearish" && value != "neutral" {
+				return fmt.Errorf("signal must be bullish, bearish or neutral")
+			}
+		}
+		s.Patterns = append(s.Patterns, PatternFilter{Field: field, Value: value, Negate: op.text == "!="})
+		return nil
+	}
+
+	cond := Condition{Op: strings.ToLower(op.text)}
+	if cond.Op == "=" || cond.Op == "==" {
+		return fmt.Errorf("use <, <=, >, >= or a crossover to compare %s", left.text)
+	}
+
+	var err error
+	if cond.Left, err = parseOperand(left.text); err != nil {
+		return err
+	}
+	if cond.Right, err = parseRightOperand(right); err != nil {
+		return err
+	}
+	if cond.Left.Value != nil {
+		return fmt.Errorf("the left side must be a metric, got %q", left.text)
+	}
+	if err := cond.validate(); err != nil {
+		return err
+	}
+
+	s.Conditions = append(s.Conditions, cond)
+	return nil
+}
+
+// parseRightOperand parses a number, a metric or a scaled metric: 2x avg,
+// 2 x avg or 2 * avg
+func parseRightOperand(tokens []screenToken) (Operand, error) {
+	switch len(tokens) {
+	case 1:
+		text := strings.ToLower(tokens[0].text)
+		if strings.HasSuffix(text, "x") {
+			if _, err := strconv.ParseFloat(strings.TrimSuffix(text, "x"), 64); err == nil {
+				return Operand{}, fmt.Errorf("%q needs a metric to scale, e.g. 2x avg", tokens[0].text)
+			}
+		}
+		return parseOperand(tokens[0].text)
+
+	case 2, 3:
+		scaleText := strings.ToLower(tokens[0].text)
+		if len(tokens) == 3 {
+			if sep := strings.ToLower(tokens[1].text); sep != "x" && sep != "*" {
+				break
+			}
+		} else {
+			if !strings.HasSuffix(scaleText, "x") {
+				break
+			}
+			scaleText = strings.TrimSuffix(scaleText, "x")
+		}
+		scale, err := strconv.ParseFloat(scaleText, 64)
+		if err != nil || scale <= 0 {
+			break
+		}
+		o, err := parseOperand(tokens[len(tokens)-1].text)
+		if err != nil {
+			return Operand{}, err
+		}
+		if o.Value != nil {
+			return Operand{}, fmt.Errorf("scale a metric, not a number")
+		}
+		o.Scale = scale
+		return o, nil
+	}
+
+	texts := make([]string, len(tokens))
+	for i, t := range tokens {
+		texts[i] = t.text
+	}
+	return Operand{}, fmt.Errorf("unexpected %q", strings.Join(texts, " "))
+}
+
+// parseOperand parses a number or a metric name with an optional period
+// suffix, e.g. 30, sma50, sma_50 or rsi
+func parseOperand(text string) (Operand, error) {
+	if v, err := strconv.ParseFloat(text, 64); err == nil {
+		return Operand{Value: &v}, nil
+	}
+
+	name := strings.ToLower(text)
+	if alias, ok := operandAliases[name]; ok {
+		return Operand{Indicator: alias}, nil
+	}
+	if _, ok := indicators[name]; ok {
+		return Operand{Indicator: name}, nil
+	}
+
+	base := strings.TrimRight(name, "0123456789")
+	period, err := strconv.Atoi(name[len(base):])
+	if err != nil {
+		return Operand{}, fmt.Errorf("unknown metric %q", text)
+	}
+	base = strings.TrimSuffix(base, "_")
+	if alias, ok := operandAliases[base]; ok {
+		base = alias
+	}
+	if spec, ok := indicators[base]; !ok || spec.defaultPeriod == 0 {
+		return Operand{}, fmt.Errorf("unknown metric %q", text)
+	}
+	if period > 500 {
+		return Operand{}, fmt.Errorf("%s period cannot exceed 500", base)
+	}
+	return Operand{Indicator: base, Period: period}, nil
+}
+
+// Warmup is the number of bars the screen needs on each symbol
+func (s *Screen) Warmup() int {
+	bars := 2
+	for i := range s.Conditions {
+		if w := s.Conditions[i].Warmup(); w > bars {
+			bars = w
+		}
+	}
+	if len(s.Patterns) > 0 && bars < screenPatternBars {
+		bars = screenPatternBars
+	}
+	return bars
+}
+
+// Evaluate applies the screen to a symbol's candles (oldest first) on the
+// latest bar. The scanner is only used when the screen filters on patterns.
+func (s *Screen) Evaluate(symbol string, candles []broker.Candle, scanner *analyzer.PatternScanner) (*ScreenMatch, bool) {
+	if len(candles) < 2 {
+		return nil, false
+	}
+
+	st := &Strategy{}
+	st.Prepare(candles)
+	last := len(candles) - 1
+
+	for _, cond := range s.Conditions {
+		if !st.holds(cond, last) {
+			return nil, false
+		}
+	}
+
+	var completed []analyzer.Pattern
+	if len(s.Patterns) > 0 {
+		for _, p := range scanner.ScanAllPatterns(candles) {
+			if p.EndIndex == last {
+				completed = append(completed, p)
+			}
+		}
+	}
+	for _, f := range s.Patterns {
+		if f.matches(completed) == f.Negate {
+			return nil, false
+		}
+	}
+
+	match := &ScreenMatch{
+		Symbol:  symbol,
+		Date:    candles[last].Date,
+		Close:   candles[last].Close,
+		Metrics: make(map[string]float64),
+	}
+	for _, cond := range s.Conditions {
+		for _, o := range []Operand{cond.Left, cond.Right} {
+			if o.Value != nil {
+				continue
+			}
+			o.Scale = 0
+			if v := st.value(o, last); !math.IsNaN(v) {
+				match.Metrics[o.String()] = v
+			}
+		}
+	}
+	for _, p := range completed {
+		match.Patterns = append(match.Patterns, p.Type)
+	}
+
+	return match, true
+}
+
+// matches reports whether any of the patterns satisfies the filter
+func (f PatternFilter) matches(patterns []analyzer.Pattern) bool {
+	for _, p := range patterns {
+		if f.Field == "signal" && p.Signal == f.Value {
+			return true
+		}
+		if f.Field == "pattern" && strings.EqualFold(p.Type, f.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// String renders the screen's conditions in canonical form
+func (s *Screen) String() string {
+	parts := make([]string, 0, len(s.Conditions)+len(s.Patterns))
+	for _, cond := range s.Conditions {
+		parts = append(parts, cond.String())
+	}
+	for _, f := range s.Patterns {
+		op := "="
+		if f.Negate {
+			op = "!="
+		}
+		parts = append(parts, fmt.Sprintf("%s %s %q", f.Field, op, f.Value))
+	}
+	return strings.Join(parts, " AND ")
+}
+
+// Screen expression tokens
+const (
+	tokenWord = iota
+	tokenString
+	tokenOp
+)
+
+type screenToken struct {
+	kind int
+	text string
+}
+
+func (t screenToken) isWord(word string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, word)
+}
+
+// screenTokens splits an expression into words, quoted strings and
+// comparison operators
+func screenTokens(expr string) ([]screenToken, error) {
+	var tokens []screenToken
+	for i := 0; i < len(expr); {
+		ch := expr[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+
+		case ch == '"' || ch == '\'':
+			end := strings.IndexByte(expr[i+1:], ch)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at position %d", i+1)
+			}
+			tokens = append(tokens, screenToken{kind: tokenString, text: expr[i+1 : i+1+end]})
+			i += end + 2
+
+		case strings.IndexByte("<>=!", ch) >= 0:
+			op := string(ch)
+			if i+1 < len(expr) && expr[i+1] == '=' {
+				op += "="
+			}
+			if op == "!" {
+				return nil, fmt.Errorf("unexpected ! at position %d", i+1)
+			}
+			tokens = append(tokens, screenToken{kind: tokenOp, text: op})
+			i += len(op)
+
+		case ch == '*':
+			tokens = append(tokens, screenToken{kind: tokenWord, text: "*"})
+			i++
+
+		case isScreenWordChar(ch) || ch == '-' && i+1 < len(expr) && expr[i+1] >= '0' && expr[i+1] <= '9':
+			start := i
+			i++
+			for i < len(expr) && isScreenWordChar(expr[i]) {
+				i++
+			}
+			word := expr[start:i]
+			if w := strings.ToLower(word); w == OpCrossesAbove || w == OpCrossesBelow {
+				tokens = append(tokens, screenToken{kind: tokenOp, text: w})
+				continue
+			}
+			tokens = append(tokens, screenToken{kind: tokenWord, text: word})
+
+		default:
+			return nil, fmt.Errorf("unexpected %q at position %d", ch, i+1)
+		}
+	}
+	return tokens, nil
+}
+
+func isScreenWordChar(ch byte) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || ch == '_' || ch == '.'
+}
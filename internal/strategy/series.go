@@ -0,0 +1,270 @@
+package strategy
+
+import (
+	"math"
+
+	"github.com/trading-chitti/market-bridge/internal/analyzer"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+// Indicator series are aligned with the input candles. Bars before an
+// indicator is defined hold NaN, so comparisons against them never match.
+
+func nanSeries(n int) []float64 {
+	s := make([]float64, n)
+	for i := range s {
+		s[i] = math.NaN()
+	}
+	return s
+}
+
+// computeSeries evaluates an indicator operand over all candles
+func computeSeries(o Operand, candles []broker.Candle) []float64 {
+	n := len(candles)
+	closes := make([]float64, n)
+	for i, c := range candles {
+		closes[i] = c.Close
+	}
+
+	switch o.Indicator {
+	case "open", "high", "low", "close", "volume":
+		s := make([]float64, n)
+		for i, c := range candles {
+			switch o.Indicator {
+			case "open":
+				s[i] = c.Open
+			case "high":
+				s[i] = c.High
+			case "low":
+				s[i] = c.Low
+			case "close":
+				s[i] = c.Close
+			case "volume":
+				s[i] = float64(c.Volume)
+			}
+		}
+		return s
+
+	case "sma":
+		return smaSeries(closes, o.Period)
+
+	case "ema":
+		return emaSeries(closes, o.Period)
+
+	case "rsi":
+		return rsiSeries(closes, o.Period)
+
+	case "roc":
+		s := nanSeries(n)
+		for i := o.Period; i < n; i++ {
+			if closes[i-o.Period] != 0 {
+				s[i] = (closes[i] - closes[i-o.Period]) / closes[i-o.Period] * 100
+			}
+		}
+		return s
+
+	case "highest", "lowest":
+		s := nanSeries(n)
+		for i := o.Period - 1; i < n; i++ {
+			v := candles[i].High
+			if o.Indicator == "lowest" {
+				v = candles[i].Low
+			}
+			for _, c := range candles[i-o.Period+1 : i+1] {
+				if o.Indicator == "highest" {
+					v = math.Max(v, c.High)
+				} else {
+					v = math.Min(v, c.Low)
+				}
+			}
+			s[i] = v
+		}
+		return s
+
+	case "volume_sma":
+		volumes := make([]float64, n)
+		for i, c := range candles {
+			volumes[i] = float64(c.Volume)
+		}
+		return smaSeries(volumes, o.Period)
+
+	case "vwap":
+		return sessionVWAP(candles)
+
+	case "atr":
+		return definedFrom(analyzer.CalculateATR(candles, o.Period), o.Period-1, n)
+
+	case "adx":
+		return definedFrom(analyzer.CalculateADX(candles, o.Period), o.Period*2, n)
+
+	case "macd", "macd_signal", "macd_histogram":
+		fast, slow := emaSeries(closes, 12), emaSeries(closes, 26)
+		macd := make([]float64, n)
+		for i := range macd {
+			macd[i] = fast[i] - slow[i]
+		}
+		switch o.Indicator {
+		case "macd":
+			return macd
+		case "macd_signal":
+			return emaSeries(macd, 9)
+		default:
+			signal := emaSeries(macd, 9)
+			for i := range macd {
+				macd[i] -= signal[i]
+			}
+			return macd
+		}
+
+	case "bb_upper", "bb_middle", "bb_lower":
+		middle := smaSeries(closes, o.Period)
+		if o.Indicator == "bb_middle" {
+			return middle
+		}
+		s := nanSeries(n)
+		for i := o.Period - 1; i < n; i++ {
+			variance := 0.0
+			for _, v := range closes[i-o.Period+1 : i+1] {
+				variance += (v - middle[i]) * (v - middle[i])
+			}
+			band := o.Param * math.Sqrt(variance/float64(o.Period))
+			if o.Indicator == "bb_upper" {
+				s[i] = middle[i] + band
+			} else {
+				s[i] = middle[i] - band
+			}
+		}
+		return s
+
+	case "supertrend":
+		result := analyzer.CalculateSuperTrend(candles, o.Period, o.Param)
+		return definedFrom(result.SuperTrend, o.Period, n)
+	}
+
+	return nanSeries(n)
+}
+
+// definedFrom copies an analyzer series, marking bars before start as NaN
+func definedFrom(values []float64, start, n int) []float64 {
+	s := nanSeries(n)
+	for i := start; i < len(values) && i < n; i++ {
+		s[i] = values[i]
+	}
+	return s
+}
+
+// smaSeries returns the simple moving average, skipping NaN inputs
+func smaSeries(values []float64, period int) []float64 {
+	s := nanSeries(len(values))
+	total, count := 0.0, 0
+	for i, v := range values {
+		if math.IsNaN(v) {
+			total, count = 0, 0
+			continue
+		}
+		total += v
+		count++
+		if count > period {
+			total -= values[i-period]
+			count = period
+		}
+		if count == period {
+			s[i] = total / float64(period)
+		}
+	}
+	return s
+}
+
+// emaSeries returns the exponential moving average seeded with an SMA
+func emaSeries(values []float64, period int) []float64 {
+	s := nanSeries(len(values))
+	k := 2.0 / float64(period+1)
+
+	start := 0
+	for start < len(values) && math.IsNaN(values[start]) {
+		start++
+	}
+	if len(values)-start < period {
+		return s
+	}
+
+	seed := 0.0
+	for _, v := range values[start : start+period] {
+		seed += v
+	}
+	prev := seed / float64(period)
+	s[start+period-1] = prev
+
+	for i := start + period; i < len(values); i++ {
+		prev = values[i]*k + prev*(1-k)
+		s[i] = prev
+	}
+	return s
+}
+
+// rsiSeries returns Wilder's RSI
+func rsiSeries(closes []float64, period int) []float64 {
+	s := nanSeries(len(closes))
+	if len(closes) <= period {
+		return s
+	}
+
+	avgGain, avgLoss := 0.0, 0.0
+	for i := 1; i <= period; i++ {
+		change := closes[i] - closes[i-1]
+		if change > 0 {
+			avgGain += change
+		} else {
+			avgLoss -= change
+		}
+	}
+	avgGain /= float64(period)
+	avgLoss /= float64(period)
+	s[period] = rsiValue(avgGain, avgLoss)
+
+	for i := period + 1; i < len(closes); i++ {
+		gain, loss := 0.0, 0.0
+		change := closes[i] - closes[i-1]
+		if change > 0 {
+			gain = change
+		} else {
+			loss = -change
+		}
+		avgGain = (avgGain*float64(period-1) + gain) / float64(period)
+		avgLoss = (avgLoss*float64(period-1) + loss) / float64(period)
+		s[i] = rsiValue(avgGain, avgLoss)
+	}
+	return s
+}
+
+func rsiValue(avgGain, avgLoss float64) float64 {
+	if avgLoss == 0 {
+		return 100
+	}
+	return 100 - 100/(1+avgGain/avgLoss)
+}
+
+// sessionVWAP returns VWAP reset at the start of each trading day (IST)
+func sessionVWAP(candles []broker.Candle) []float64 {
+	s := nanSeries(len(candles))
+	tpv, volume := 0.0, 0.0
+	var session string
+
+	for i, c := range candles {
+		day := c.Date.In(ist).Format("2006-01-02")
+		if day != session {
+			session = day
+			tpv, volume = 0, 0
+		}
+
+		typical := (c.High + c.Low + c.Close) / 3
+		tpv += typical * float64(c.Volume)
+		volume += float64(c.Volume)
+		if volume > 0 {
+			s[i] = tpv / volume
+		} else {
+			s[i] = typical
+		}
+	}
+	return s
+}
@@ -0,0 +1,234 @@
+// Package strategy runs user-defined, rule-based strategies. Definitions are
+// stored as JSON per user and can be evaluated over history (backtest), over
+// a list of symbols on the latest bar (scan), or continuously against the
+// bars written by the collectors (live signals).
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/trading-chitti/market-bridge/internal/backtest"
+	"github.com/trading-chitti/market-bridge/internal/broker"
+)
+
+var ist = broker.IST()
+
+// Strategy evaluates a Definition. It implements backtest.IndexedStrategy,
+// computing each indicator once per candle set.
+type Strategy struct {
+	def     *Definition
+	warmup  int
+	candles []broker.Candle
+	series  map[Operand][]float64
+}
+
+// New compiles a validated definition
+func New(def *Definition) *Strategy {
+	return &Strategy{
+		def:    def,
+		warmup: def.warmup(),
+	}
+}
+
+// Definition returns the strategy's definition
+func (s *Strategy) Definition() *Definition { return s.def }
+
+// Name returns the strategy name
+func (s *Strategy) Name() string { return s.def.Name }
+
+// Warmup returns the number of bars needed before rules can fire
+func (s *Strategy) Warmup() int { return s.warmup }
+
+// Prepare sets the candles to evaluate; indicator series are computed on
+// first use and cached until the next Prepare
+func (s *Strategy) Prepare(candles []broker.Candle) {
+	s.candles = candles
+	s.series = make(map[Operand][]float64)
+}
+
+// Evaluate prepares candles and evaluates the last bar
+func (s *Strategy) Evaluate(candles []broker.Candle) backtest.Decision {
+	s.Prepare(candles)
+	return s.EvaluateAt(len(candles) - 1)
+}
+
+// EvaluateAt evaluates the rules at the close of bar i. Entry rules take
+// precedence; when they don't match, matching exit rules close the position.
+func (s *Strategy) EvaluateAt(i int) backtest.Decision {
+	if i < 1 || i >= len(s.candles) {
+		return backtest.Decision{Action: backtest.ActionHold}
+	}
+
+	if ok, reason := s.match(s.def.Entry, i); ok {
+		action := backtest.ActionBuy
+		if s.def.Side == SideShort {
+			action = backtest.ActionSell
+		}
+		return backtest.Decision{
+			Action:        action,
+			StopLossPct:   s.def.StopLossPct,
+			TakeProfitPct: s.def.TakeProfitPct,
+			Reason:        "entry: " + reason,
+		}
+	}
+
+	if ok, reason := s.match(s.def.Exit, i); ok {
+		return backtest.Decision{
+			Action: backtest.ActionExit,
+			Reason: "exit: " + reason,
+		}
+	}
+
+	return backtest.Decision{Action: backtest.ActionHold}
+}
+
+// BacktestConfig maps the definition's side, stops and sizing onto a
+// backtest configuration
+func (s *Strategy) BacktestConfig(config backtest.Config) backtest.Config {
+	config.StopLossPct = s.def.StopLossPct
+	config.TakeProfitPct = s.def.TakeProfitPct
+	config.AllowShort = s.def.Side == SideShort
+
+	switch s.def.Sizing.Method {
+	case SizingPercentEquity:
+		config.PositionSizePct = s.def.Sizing.Value
+	case SizingFixedQuantity:
+		config.FixedQuantity = int64(s.def.Sizing.Value)
+	case SizingFixedAmount:
+		config.FixedAmount = s.def.Sizing.Value
+	case SizingRiskPercent:
+		config.RiskPct = s.def.Sizing.Value
+	}
+
+	return config
+}
+
+// match reports whether a rule set holds at bar i and describes the
+// conditions that held
+func (s *Strategy) match(rules RuleSet, i int) (bool, string) {
+	if len(rules.All) == 0 && len(rules.Any) == 0 {
+		return false, ""
+	}
+
+	reasons := []string{}
+	for _, cond := range rules.All {
+		if !s.holds(cond, i) {
+			return false, ""
+		}
+		reasons = append(reasons, cond.String())
+	}
+
+	if len(rules.Any) > 0 {
+		matched := false
+		for _, cond := range rules.Any {
+			if s.holds(cond, i) {
+				reasons = append(reasons, cond.String())
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, ""
+		}
+	}
+
+	return true, strings.Join(reasons, " AND ")
+}
+
+// holds evaluates one condition at bar i
+func (s *Strategy) holds(cond Condition, i int) bool {
+	left, right := s.value(cond.Left, i), s.value(cond.Right, i)
+	if math.IsNaN(left) || math.IsNaN(right) {
+		return false
+	}
+
+	switch cond.Op {
+	case OpGreater:
+		return left > right
+	case OpGreaterEqual:
+		return left >= right
+	case OpLess:
+		return left < right
+	case OpLessEqual:
+		return left <= right
+	case OpCrossesAbove, OpCrossesBelow:
+		prevLeft, prevRight := s.value(cond.Left, i-1), s.value(cond.Right, i-1)
+		if math.IsNaN(prevLeft) || math.IsNaN(prevRight) {
+			return false
+		}
+		if cond.Op == OpCrossesAbove {
+			return prevLeft <= prevRight && left > right
+		}
+		return prevLeft >= prevRight && left < right
+	}
+
+	return false
+}
+
+// Holds evaluates a single validated condition on the last candle and
+// returns the left operand's value there, e.g. the RSI reading
+func Holds(cond Condition, candles []broker.Candle) (bool, float64) {
+	if len(candles) < 2 {
+		return false, math.NaN()
+	}
+
+	s := &Strategy{}
+	s.Prepare(candles)
+	i := len(candles) - 1
+	return s.holds(cond, i), s.value(cond.Left, i)
+}
+
+// value returns an operand's value at bar i, or NaN when undefined
+func (s *Strategy) value(o Operand, i int) float64 {
+	if o.Value != nil {
+		return *o.Value
+	}
+
+	i -= o.Offset
+	if i < 0 {
+		return math.NaN()
+	}
+
+	// Offset and scale don't change the series, so share it across them
+	key := o
+	key.Offset = 0
+	key.Scale = 0
+	series, ok := s.series[key]
+	if !ok {
+		series = computeSeries(key, s.candles)
+		s.series[key] = series
+	}
+	if o.Scale != 0 {
+		return series[i] * o.Scale
+	}
+	return series[i]
+}
+
+// String renders the condition for signal reasons
+func (c Condition) String() string {
+	return fmt.Sprintf("%s %s %s", c.Left, c.Op, c.Right)
+}
+
+// String renders the operand, e.g. "rsi(14)" or "30"
+func (o Operand) String() string {
+	if o.Value != nil {
+		return fmt.Sprintf("%g", *o.Value)
+	}
+
+	name := o.Indicator
+	switch {
+	case o.Period > 0 && o.Param > 0:
+		name = fmt.Sprintf("%s(%d,%g)", o.Indicator, o.Period, o.Param)
+	case o.Period > 0:
+		name = fmt.Sprintf("%s(%d)", o.Indicator, o.Period)
+	}
+	if o.Offset > 0 {
+		name = fmt.Sprintf("%s[%d]", name, o.Offset)
+	}
+	if o.Scale != 0 {
+		name = fmt.Sprintf("%gx %s", o.Scale, name)
+	}
+	return name
+}
@@ -0,0 +1,128 @@
+// Package timeframe names bar intervals one way across the codebase. The
+// canonical names are the md.intraday_bars ones (1m, 5m, 15m, 1h, 1d).
+// Parse also accepts the Kite interval names the broker historical APIs and
+// the backfill CLI use (minute, 5minute, 60minute, day), which Kite converts
+// back to.
+package timeframe
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Timeframe is a canonical bar interval name
+type Timeframe string
+
+// Timeframes the brokers serve history in
+const (
+	Minute1  Timeframe = "1m"
+	Minute3  Timeframe = "3m"
+	Minute5  Timeframe = "5m"
+	Minute10 Timeframe = "10m"
+	Minute15 Timeframe = "15m"
+	Minute30 Timeframe = "30m"
+	Hour1    Timeframe = "1h"
+	Day      Timeframe = "1d"
+)
+
+// info describes a timeframe
+type info struct {
+	duration time.Duration
+	kite     string // Kite historical interval
+	stored   bool   // md.intraday_bars keeps bars of it
+}
+
+var timeframes = map[Timeframe]info{
+	Minute1:  {duration: time.Minute, kite: "minute", stored: true},
+	Minute3:  {duration: 3 * time.Minute, kite: "3minute"},
+	Minute5:  {duration: 5 * time.Minute, kite: "5minute", stored: true},
+	Minute10: {duration: 10 * time.Minute, kite: "10minute"},
+	Minute15: {duration: 15 * time.Minute, kite: "15minute", stored: true},
+	Minute30: {duration: 30 * time.Minute, kite: "30minute"},
+	Hour1:    {duration: time.Hour, kite: "60minute", stored: true},
+	Day:      {duration: 24 * time.Hour, kite: "day", stored: true},
+}
+
+// aliases maps the other accepted names to timeframes
+var aliases = map[string]Timeframe{
+	"1minute": Minute1,
+	"60m":     Hour1,
+	"1hour":   Hour1,
+	"hour":    Hour1,
+	"1day":    Day,
+	"d":       Day,
+	"daily":   Day,
+}
+
+func init() {
+	for tf, i := range timeframes {
+		aliases[i.kite] = tf
+	}
+}
+
+// Parse returns the timeframe named by s, a canonical name (5m), Kite
+// interval (5minute) or alias (day), ignoring case
+func Parse(s string) (Timeframe, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if _, ok := timeframes[Timeframe(name)]; ok {
+		return Timeframe(name), nil
+	}
+	if tf, ok := aliases[name]; ok {
+		return tf, nil
+	}
+	return "", fmt.Errorf("unsupported timeframe %q, use 1m, 3m, 5m, 10m, 15m, 30m, 1h or 1d", s)
+}
+
+// ParseStored is Parse limited to the timeframes md.intraday_bars keeps
+func ParseStored(s string) (Timeframe, error) {
+	tf, err := Parse(s)
+	if err != nil || !tf.Stored() {
+		return "", fmt.Errorf("unsupported timeframe %q, use one of %s", s, StoredNames())
+	}
+	return tf, nil
+}
+
+// Stored returns the timeframes md.intraday_bars keeps, shortest first
+func Stored() []Timeframe {
+	return []Timeframe{Minute1, Minute5, Minute15, Hour1, Day}
+}
+
+// StoredNames lists the stored timeframes for error messages, mentioning
+// that day is accepted for 1d
+func StoredNames() string {
+	names := make([]string, 0, len(Stored()))
+	for _, tf := range Stored() {
+		names = append(names, string(tf))
+	}
+	return strings.Join(names, ", ") + " (or day)"
+}
+
+// String returns the canonical name
+func (tf Timeframe) String() string {
+	return string(tf)
+}
+
+// Duration returns the length of a bar, 24h for 1d
+func (tf Timeframe) Duration() time.Duration {
+	return timeframes[tf].duration
+}
+
+// Kite returns the Kite historical interval name, e.g. 5minute
+func (tf Timeframe) Kite() string {
+	return timeframes[tf].kite
+}
+
+// Stored reports whether md.intraday_bars keeps bars of tf
+func (tf Timeframe) Stored() bool {
+	return timeframes[tf].stored
+}
+
+// KiteInterval normalizes any accepted name to a Kite historical interval
+func KiteInterval(s string) (string, error) {
+	tf, err := Parse(s)
+	if err != nil {
+		return "", err
+	}
+	return tf.Kite(), nil
+}
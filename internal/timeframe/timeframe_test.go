@@ -0,0 +1,97 @@
+package timeframe
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		in         string
+		want       Timeframe
+		wantErr    bool
+		wantStored bool
+	}{
+		{name: "canonical", in: "5m", want: Minute5, wantStored: true},
+		{name: "kite minute", in: "minute", want: Minute1, wantStored: true},
+		{name: "kite hour", in: "60minute", want: Hour1, wantStored: true},
+		{name: "kite day", in: "day", want: Day, wantStored: true},
+		{name: "canonical day", in: "1d", want: Day, wantStored: true},
+		{name: "mixed case and spaces", in: " 15Minute ", want: Minute15, wantStored: true},
+		{name: "alias", in: "60m", want: Hour1, wantStored: true},
+		{name: "not stored", in: "3minute", want: Minute3},
+		{name: "not stored canonical", in: "30m", want: Minute30},
+		{name: "unknown", in: "2h", wantErr: true},
+		{name: "empty", in: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+
+			stored, err := ParseStored(tt.in)
+			if (err == nil) != tt.wantStored {
+				t.Fatalf("ParseStored(%q) error = %v, want stored %v", tt.in, err, tt.wantStored)
+			}
+			if tt.wantStored && stored != tt.want {
+				t.Errorf("ParseStored(%q) = %q, want %q", tt.in, stored, tt.want)
+			}
+		})
+	}
+}
+
+func TestConversions(t *testing.T) {
+	tests := []struct {
+		tf           Timeframe
+		wantKite     string
+		wantDuration time.Duration
+	}{
+		{tf: Minute1, wantKite: "minute", wantDuration: time.Minute},
+		{tf: Minute3, wantKite: "3minute", wantDuration: 3 * time.Minute},
+		{tf: Minute5, wantKite: "5minute", wantDuration: 5 * time.Minute},
+		{tf: Minute10, wantKite: "10minute", wantDuration: 10 * time.Minute},
+		{tf: Minute15, wantKite: "15minute", wantDuration: 15 * time.Minute},
+		{tf: Minute30, wantKite: "30minute", wantDuration: 30 * time.Minute},
+		{tf: Hour1, wantKite: "60minute", wantDuration: time.Hour},
+		{tf: Day, wantKite: "day", wantDuration: 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tf.String(), func(t *testing.T) {
+			if got := tt.tf.Kite(); got != tt.wantKite {
+				t.Errorf("Kite() = %q, want %q", got, tt.wantKite)
+			}
+			if got := tt.tf.Duration(); got != tt.wantDuration {
+				t.Errorf("Duration() = %v, want %v", got, tt.wantDuration)
+			}
+
+			// Both names round-trip
+			for _, name := range []string{tt.tf.String(), tt.tf.Kite()} {
+				if got, err := Parse(name); err != nil || got != tt.tf {
+					t.Errorf("Parse(%q) = %q, %v, want %q", name, got, err, tt.tf)
+				}
+			}
+			if got, err := KiteInterval(tt.tf.String()); err != nil || got != tt.wantKite {
+				t.Errorf("KiteInterval(%q) = %q, %v, want %q", tt.tf, got, err, tt.wantKite)
+			}
+		})
+	}
+}
+
+func TestStored(t *testing.T) {
+	for _, tf := range Stored() {
+		if !tf.Stored() {
+			t.Errorf("%s is listed as stored but Stored() is false", tf)
+		}
+	}
+	if got, want := StoredNames(), "1m, 5m, 15m, 1h, 1d (or day)"; got != want {
+		t.Errorf("StoredNames() = %q, want %q", got, want)
+	}
+}
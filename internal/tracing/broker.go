@@ -0,0 +1,188 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Broker returns a broker whose API calls are traced as children of the
+// span in ctx. Wrap per request or job; calls made outside a trace aren't
+// traced.
+func Broker(ctx context.Context, b broker.Broker) broker.Broker {
+	return &tracedBroker{Broker: b, ctx: ctx}
+}
+
+// tracedBroker decorates a broker with spans
+type tracedBroker struct {
+	broker.Broker
+	ctx context.Context
+}
+
+// Unwrap returns the traced broker
+func (b *tracedBroker) Unwrap() broker.Broker {
+	return b.Broker
+}
+
+// start starts the span of a broker call
+func (b *tracedBroker) start(operation string, attrs ...attribute.KeyValue) func(error) {
+	attrs = append(attrs,
+		attribute.String("broker.name", b.Broker.GetBrokerName()),
+		attribute.String("broker.operation", operation),
+	)
+	_, span := StartChild(b.ctx, "broker."+operation, attrs...)
+	return func(err error) { End(span, err) }
+}
+
+func (b *tracedBroker) GenerateSession(requestToken string) (*broker.Session, error) {
+	end := b.start("generate_session")
+	session, err := b.Broker.GenerateSession(requestToken)
+	end(err)
+	return session, err
+}
+
+func (b *tracedBroker) GetProfile() (*broker.Profile, error) {
+	end := b.start("get_profile")
+	profile, err := b.Broker.GetProfile()
+	end(err)
+	return profile, err
+}
+
+func (b *tracedBroker) GetMargins() (*broker.Margins, error) {
+	end := b.start("get_margins")
+	margins, err := b.Broker.GetMargins()
+	end(err)
+	return margins, err
+}
+
+func (b *tracedBroker) GetPositions() (*broker.Positions, error) {
+	end := b.start("get_positions")
+	positions, err := b.Broker.GetPositions()
+	end(err)
+	return positions, err
+}
+
+func (b *tracedBroker) GetHoldings() ([]broker.Holding, error) {
+	end := b.start("get_holdings")
+	holdings, err := b.Broker.GetHoldings()
+	end(err)
+	return holdings, err
+}
+
+func (b *tracedBroker) GetOrders() ([]broker.Order, error) {
+	end := b.start("get_orders")
+	orders, err := b.Broker.GetOrders()
+	end(err)
+	return orders, err
+}
+
+func (b *tracedBroker) GetQuote(symbols []string) (map[string]broker.Quote, error) {
+	end := b.start("get_quote", attribute.Int("broker.symbols", len(symbols)))
+	quotes, err := b.Broker.GetQuote(symbols)
+	end(err)
+	return quotes, err
+}
+
+func (b *tracedBroker) GetLTP(symbols []string) (map[string]float64, error) {
+	end := b.start("get_ltp", attribute.Int("broker.symbols", len(symbols)))
+	prices, err := b.Broker.GetLTP(symbols)
+	end(err)
+	return prices, err
+}
+
+func (b *tracedBroker) GetHistoricalData(instrument string, from, to time.Time, interval string) ([]broker.Candle, error) {
+	end := b.start("get_historical_data",
+		attribute.String("broker.instrument", instrument),
+		attribute.String("broker.interval", interval),
+		attribute.String("broker.from", from.Format(time.RFC3339)),
+		attribute.String("broker.to", to.Format(time.RFC3339)),
+	)
+	candles, err := b.Broker.GetHistoricalData(instrument, from, to, interval)
+	end(err)
+	return candles, err
+}
+
+func (b *tracedBroker) GetInstruments(exchange string) ([]broker.Instrument, error) {
+	end := b.start("get_instruments", attribute.String("broker.exchange", exchange))
+	instruments, err := b.Broker.GetInstruments(exchange)
+	end(err)
+	return instruments, err
+}
+
+func (b *tracedBroker) PlaceOrder(order *broker.OrderRequest) (string, error) {
+	end := b.start("place_order", attribute.String("broker.symbol", order.Exchange+":"+order.Symbol))
+	orderID, err := b.Broker.PlaceOrder(order)
+	end(err)
+	return orderID, err
+}
+
+func (b *tracedBroker) ModifyOrder(orderID string, order *broker.OrderModify) (string, error) {
+	end := b.start("modify_order", attribute.String("broker.order_id", orderID))
+	id, err := b.Broker.ModifyOrder(orderID, order)
+	end(err)
+	return id, err
+}
+
+func (b *tracedBroker) CancelOrder(orderID string) (string, error) {
+	end := b.start("cancel_order", attribute.String("broker.order_id", orderID))
+	id, err := b.Broker.CancelOrder(orderID)
+	end(err)
+	return id, err
+}
+
+func (b *tracedBroker) PlaceGTT(gtt *broker.GTTRequest) (int, error) {
+	gtts, err := broker.GTTs(b.Broker)
+	if err != nil {
+		return 0, err
+	}
+	end := b.start("place_gtt", attribute.String("broker.symbol", gtt.Symbol))
+	triggerID, err := gtts.PlaceGTT(gtt)
+	end(err)
+	return triggerID, err
+}
+
+func (b *tracedBroker) ModifyGTT(triggerID int, gtt *broker.GTTRequest) error {
+	gtts, err := broker.GTTs(b.Broker)
+	if err != nil {
+		return err
+	}
+	end := b.start("modify_gtt", attribute.Int("broker.trigger_id", triggerID))
+	err = gtts.ModifyGTT(triggerID, gtt)
+	end(err)
+	return err
+}
+
+func (b *tracedBroker) DeleteGTT(triggerID int) error {
+	gtts, err := broker.GTTs(b.Broker)
+	if err != nil {
+		return err
+	}
+	end := b.start("delete_gtt", attribute.Int("broker.trigger_id", triggerID))
+	err = gtts.DeleteGTT(triggerID)
+	end(err)
+	return err
+}
+
+func (b *tracedBroker) GetGTTs() ([]broker.GTT, error) {
+	gtts, err := broker.GTTs(b.Broker)
+	if err != nil {
+		return nil, err
+	}
+	end := b.start("get_gtts")
+	list, err := gtts.GetGTTs()
+	end(err)
+	return list, err
+}
+
+func (b *tracedBroker) GetGTT(triggerID int) (*broker.GTT, error) {
+	gtts, err := broker.GTTs(b.Broker)
+	if err != nil {
+		return nil, err
+	}
+	end := b.start("get_gtt", attribute.Int("broker.trigger_id", triggerID))
+	gtt, err := gtts.GetGTT(triggerID)
+	end(err)
+	return gtt, err
+}
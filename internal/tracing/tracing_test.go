@@ -0,0 +1,94 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/trading-chitti/market-bridge/internal/broker"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a tracer provider keeping spans in memory
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+// stubBroker answers GetLTP with err
+type stubBroker struct {
+	broker.Broker
+	err error
+}
+
+func (s *stubBroker) GetBrokerName() string { return "stub" }
+
+func (s *stubBroker) GetLTP(symbols []string) (map[string]float64, error) {
+	return map[string]float64{}, s.err
+}
+
+func TestStartChild(t *testing.T) {
+	recorder := recordSpans(t)
+
+	_, orphan := StartChild(context.Background(), "orphan")
+	orphan.End()
+
+	ctx, parent := Start(context.Background(), "parent")
+	_, child := StartChild(ctx, "child")
+	child.End()
+	parent.End()
+
+	ended := recorder.Ended()
+	if len(ended) != 2 {
+		t.Fatalf("got %d spans, want parent and child", len(ended))
+	}
+	if ended[0].Name() != "child" || ended[0].Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("first span = %s, want child of parent", ended[0].Name())
+	}
+}
+
+func TestBroker(t *testing.T) {
+	tests := []struct {
+		name   string
+		traced bool
+		err    error
+		spans  int
+		status codes.Code
+	}{
+		{name: "ok", traced: true, spans: 1, status: codes.Unset},
+		{name: "error", traced: true, err: broker.ErrSessionExpired, spans: 1, status: codes.Error},
+		{name: "outside a trace", spans: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := recordSpans(t)
+
+			ctx := context.Background()
+			if tt.traced {
+				ctx, _ = Start(ctx, "request")
+			}
+			Broker(ctx, &stubBroker{err: tt.err}).GetLTP([]string{"NSE:INFY"})
+
+			ended := recorder.Ended()
+			if len(ended) != tt.spans {
+				t.Fatalf("got %d spans, want %d", len(ended), tt.spans)
+			}
+			if tt.spans == 0 {
+				return
+			}
+			if ended[0].Name() != "broker.get_ltp" {
+				t.Errorf("span = %s, want broker.get_ltp", ended[0].Name())
+			}
+			if ended[0].Status().Code != tt.status {
+				t.Errorf("status = %v, want %v", ended[0].Status().Code, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package watchlist
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Mover rankings
+const (
+	MoversGainers = "gainers"
+	MoversLosers  = "losers"
+	MoversActive  = "active"
+)
+
+const (
+	// DefaultMoversTTL is how long an exchange's session moves are reused
+	DefaultMoversTTL = 5 * time.Minute
+
+	// DefaultMoversLimit is the size of the movers watchlists
+	DefaultMoversLimit = 20
+)
+
+// Mover is a symbol's move over its latest session
+type Mover struct {
+	Symbol    string    `json:"symbol"`
+	LastPrice float64   `json:"last_price"`
+	PrevClose float64   `json:"prev_close"` // Prior session close, or the session open without one
+	Change    float64   `json:"change"`
+	ChangePct float64   `json:"change_pct"`
+	Volume    int64     `json:"volume"`
+	UpdatedAt time.Time `json:"updated_at"` // Time of the latest bar
+}
+
+// MoverSource loads the latest session move of every symbol with stored
+// data on an exchange
+type MoverSource interface {
+	SessionMoves(exchange string) ([]Mover, error)
+}
+
+type moversEntry struct {
+	moves    []Mover
+	loadedAt time.Time
+}
+
+var movers = struct {
+	sync.Mutex
+	source MoverSource
+	ttl    time.Duration
+	cache  map[string]moversEntry
+}{}
+
+// SetMoverSource computes the movers watchlists (TOP_GAINERS, TOP_LOSERS,
+// MOST_ACTIVE) from source, reusing each exchange's moves for ttl. Without
+// a source they are empty.
+func SetMoverSource(source MoverSource, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultMoversTTL
+	}
+
+	movers.Lock()
+	defer movers.Unlock()
+	movers.source = source
+	movers.ttl = ttl
+	movers.cache = make(map[string]moversEntry)
+}
+
+// GetMovers returns up to limit symbols of an exchange ranked by kind:
+// gainers and losers by change against the prior close (only symbols that
+// moved that way), active by session volume. It also returns when the
+// moves were loaded.
+func GetMovers(kind, exchange string, limit int) ([]Mover, time.Time, error) {
+	if kind != MoversGainers && kind != MoversLosers && kind != MoversActive {
+		return nil, time.Time{}, fmt.Errorf("invalid movers type %q (use gainers, losers or active)", kind)
+	}
+
+	moves, loadedAt, err := sessionMoves(exchange)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	ranked := make([]Mover, 0, len(moves))
+	for _, m := range moves {
+		switch {
+		case kind == MoversGainers && m.ChangePct > 0,
+			kind == MoversLosers && m.ChangePct < 0,
+			kind == MoversActive && m.Volume > 0:
+			ranked = append(ranked, m)
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		switch kind {
+		case MoversGainers:
+			return ranked[i].ChangePct > ranked[j].ChangePct
+		case MoversLosers:
+			return ranked[i].ChangePct < ranked[j].ChangePct
+		default:
+			return ranked[i].Volume > ranked[j].Volume
+		}
+	})
+
+	if limit > 0 && len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked, loadedAt, nil
+}
+
+// sessionMoves returns an exchange's cached moves, reloading them once
+// they are older than the TTL
+func sessionMoves(exchange string) ([]Mover, time.Time, error) {
+	movers.Lock()
+	defer movers.Unlock()
+
+	if movers.source == nil {
+		return nil, time.Time{}, fmt.Errorf("movers are not available without stored market data")
+	}
+
+	if entry, ok := movers.cache[exchange]; ok && time.Since(entry.loadedAt) < movers.ttl {
+		return entry.moves, entry.loadedAt, nil
+	}
+
+	moves, err := movers.source.SessionMoves(exchange)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to load session moves: %w", err)
+	}
+
+	entry := moversEntry{moves: moves, loadedAt: time.Now()}
+	movers.cache[exchange] = entry
+	return entry.moves, entry.loadedAt, nil
+}
+
+// moverSymbols returns the symbols of a movers watchlist, or none when
+// moves can't be loaded
+func moverSymbols(kind, exchange string) []string {
+	ranked, _, err := GetMovers(kind, exchange, DefaultMoversLimit)
+	if err != nil {
+		return []string{}
+	}
+
+	symbols := make([]string, len(ranked))
+	for i, m := range ranked {
+		symbols[i] = m.Symbol
+	}
+	return symbols
+}
@@ -0,0 +1,43 @@
+package watchlist
+
+import (
+	"strings"
+	"sync"
+)
+
+// Store looks up user-defined watchlists
+type Store interface {
+	// LookupWatchlist returns a stored watchlist by ID, or by name among
+	// the watchlists created in single-user mode; nil if there is none
+	LookupWatchlist(ref string) (*Watchlist, error)
+}
+
+var stored = struct {
+	sync.RWMutex
+	store Store
+}{}
+
+// SetStore makes Resolve fall back to user-defined watchlists
+func SetStore(store Store) {
+	stored.Lock()
+	defer stored.Unlock()
+	stored.store = store
+}
+
+// Resolve returns a built-in watchlist by name, or a user-defined one from
+// the store, or nil if neither exists. Movers and stored watchlists are
+// re-read on every call, so callers that resolve periodically follow their
+// changes.
+func Resolve(ref string) (*Watchlist, error) {
+	if wl := GetWatchlist(strings.ToUpper(ref)); wl != nil {
+		return wl, nil
+	}
+
+	stored.RLock()
+	store := stored.store
+	stored.RUnlock()
+	if store == nil {
+		return nil, nil
+	}
+	return store.LookupWatchlist(ref)
+}
@@ -132,46 +132,37 @@ func NiftyMidcap50() Watchlist {
 // MARKET MOVERS
 // ============================================================================
 
-// TopGainers returns top gainers watchlist
+// TopGainers returns the top gaining stocks of the latest session
 func TopGainers() Watchlist {
 	return Watchlist{
 		Name:        "TOP_GAINERS",
-		Description: "Top gaining stocks (updated daily)",
+		Description: "Top gaining stocks of the latest session",
 		Category:    "movers",
 		Exchange:    "NSE",
-		Symbols: []string{
-			// This would be dynamically updated based on market data
-			"RELIANCE", "TCS", "INFY", "HDFC", "ICICIBANK",
-			"SBIN", "BHARTIARTL", "ITC", "KOTAKBANK", "LT",
-		},
+		Symbols:     moverSymbols(MoversGainers, "NSE"),
 	}
 }
 
-// TopLosers returns top losers watchlist
+// TopLosers returns the top losing stocks of the latest session
 func TopLosers() Watchlist {
 	return Watchlist{
 		Name:        "TOP_LOSERS",
-		Description: "Top losing stocks (updated daily)",
+		Description: "Top losing stocks of the latest session",
 		Category:    "movers",
 		Exchange:    "NSE",
-		Symbols: []string{
-			// This would be dynamically updated based on market data
-			"TATASTEEL", "HINDALCO", "VEDL", "COALINDIA", "JSWSTEEL",
-		},
+		Symbols:     moverSymbols(MoversLosers, "NSE"),
 	}
 }
 
-// MostActive returns most active stocks by volume
+// MostActive returns the most actively traded stocks of the latest session
+// by volume
 func MostActive() Watchlist {
 	return Watchlist{
 		Name:        "MOST_ACTIVE",
-		Description: "Most actively traded stocks by volume",
+		Description: "Most actively traded stocks of the latest session by volume",
 		Category:    "movers",
 		Exchange:    "NSE",
-		Symbols: []string{
-			"RELIANCE", "SBIN", "TATASTEEL", "ICICIBANK", "HDFCBANK",
-			"INFY", "TCS", "AXISBANK", "KOTAKBANK", "ITC",
-		},
+		Symbols:     moverSymbols(MoversActive, "NSE"),
 	}
 }
 
@@ -92,7 +92,7 @@ if psql "$TRADING_CHITTI_PG_DSN" -c "SELECT 1" &>/dev/null; then
         echo -e "${GREEN}✅ Intraday tables exist${NC}"
     else
         echo -e "${YELLOW}⚠️  Intraday tables not found${NC}"
-        echo "   Run: psql $TRADING_CHITTI_PG_DSN -f internal/database/schema_intraday.sql"
+        echo "   Run: go run ./cmd/migrate up"
     fi
 else
     echo -e "${RED}❌ Database connection failed${NC}"